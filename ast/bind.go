@@ -0,0 +1,57 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ast
+
+var (
+	_ StmtNode = &CreateBindingStmt{}
+	_ StmtNode = &DropBindingStmt{}
+)
+
+// CreateBindingStmt creates a binding which replaces the hints of OriginStmt by the hints of HintedStmt
+// when a statement like OriginStmt is planned.
+// The statements are not visited by Accept, their texts are stored and they are parsed again.
+type CreateBindingStmt struct {
+	stmtNode
+
+	OriginStmt StmtNode
+	HintedStmt StmtNode
+}
+
+// Accept implements Node Accept interface.
+func (n *CreateBindingStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*CreateBindingStmt)
+	return v.Leave(n)
+}
+
+// DropBindingStmt drops the binding of OriginStmt.
+// The statement is not visited by Accept.
+type DropBindingStmt struct {
+	stmtNode
+
+	OriginStmt StmtNode
+}
+
+// Accept implements Node Accept interface.
+func (n *DropBindingStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*DropBindingStmt)
+	return v.Leave(n)
+}
@@ -26,8 +26,10 @@ var (
 	_ DDLNode = &DropDatabaseStmt{}
 	_ DDLNode = &DropIndexStmt{}
 	_ DDLNode = &DropTableStmt{}
+	_ DDLNode = &LockTablesStmt{}
 	_ DDLNode = &RenameTableStmt{}
 	_ DDLNode = &TruncateTableStmt{}
+	_ DDLNode = &UnlockTablesStmt{}
 
 	_ Node = &AlterTableSpec{}
 	_ Node = &ColumnDef{}
@@ -105,6 +107,10 @@ type IndexColName struct {
 
 	Column *ColumnName
 	Length int
+	// Expr is the key of an expression index like `(lower(name))`, Column is nil if it's set.
+	Expr ExprNode `json:"-"`
+	// Desc indicates the key is in descending order.
+	Desc bool
 }
 
 // Accept implements Node Accept interface.
@@ -114,6 +120,14 @@ func (n *IndexColName) Accept(v Visitor) (Node, bool) {
 		return v.Leave(newNode)
 	}
 	n = newNode.(*IndexColName)
+	if n.Expr != nil {
+		node, ok := n.Expr.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.Expr = node.(ExprNode)
+		return v.Leave(n)
+	}
 	node, ok := n.Column.Accept(v)
 	if !ok {
 		return n, false
@@ -252,6 +266,8 @@ type ColumnOption struct {
 	Expr ExprNode
 	// Stored is only for ColumnOptionGenerated, default is false.
 	Stored bool
+	// PrimaryKeyTp is only for ColumnOptionPrimaryKey.
+	PrimaryKeyTp PrimaryKeyType
 }
 
 // Accept implements Node Accept interface.
@@ -271,11 +287,34 @@ func (n *ColumnOption) Accept(v Visitor) (Node, bool) {
 	return v.Leave(n)
 }
 
+// IndexVisibility is the visibility of an index, the optimizer ignores the invisible indices.
+type IndexVisibility int
+
+// IndexVisibility types.
+const (
+	IndexVisibilityDefault IndexVisibility = iota
+	IndexVisibilityVisible
+	IndexVisibilityInvisible
+)
+
+// PrimaryKeyType is the type of a primary key, the rows are clustered by a clustered primary key,
+// which is the handle of the rows.
+type PrimaryKeyType int
+
+// PrimaryKeyType types.
+const (
+	PrimaryKeyTypeDefault PrimaryKeyType = iota
+	PrimaryKeyTypeClustered
+	PrimaryKeyTypeNonClustered
+)
+
 // IndexOption is the index options.
 //    KEY_BLOCK_SIZE [=] value
 //  | index_type
 //  | WITH PARSER parser_name
 //  | COMMENT 'string'
+//  | {VISIBLE | INVISIBLE}
+//  | {CLUSTERED | NONCLUSTERED}
 // See http://dev.mysql.com/doc/refman/5.7/en/create-table.html
 type IndexOption struct {
 	node
@@ -283,6 +322,8 @@ type IndexOption struct {
 	KeyBlockSize uint64
 	Tp           model.IndexType
 	Comment      string
+	Visibility   IndexVisibility
+	PrimaryKeyTp PrimaryKeyType
 }
 
 // Accept implements Node Accept interface.
@@ -462,6 +503,53 @@ func (n *DropTableStmt) Accept(v Visitor) (Node, bool) {
 	return v.Leave(n)
 }
 
+// TableLock is a table and the type of its lock in LOCK TABLES.
+type TableLock struct {
+	Table *TableName
+	Type  model.TableLockType
+}
+
+// LockTablesStmt is a statement to lock tables.
+// See https://dev.mysql.com/doc/refman/5.7/en/lock-tables.html
+type LockTablesStmt struct {
+	ddlNode
+
+	TableLocks []TableLock
+}
+
+// Accept implements Node Accept interface.
+func (n *LockTablesStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*LockTablesStmt)
+	for i := range n.TableLocks {
+		node, ok := n.TableLocks[i].Table.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.TableLocks[i].Table = node.(*TableName)
+	}
+	return v.Leave(n)
+}
+
+// UnlockTablesStmt is a statement to release the table locks of the session.
+// See https://dev.mysql.com/doc/refman/5.7/en/lock-tables.html
+type UnlockTablesStmt struct {
+	ddlNode
+}
+
+// Accept implements Node Accept interface.
+func (n *UnlockTablesStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*UnlockTablesStmt)
+	return v.Leave(n)
+}
+
 // RenameTableStmt is a statement to rename a table.
 // See http://dev.mysql.com/doc/refman/5.7/en/rename-table.html
 type RenameTableStmt struct {
@@ -618,6 +706,7 @@ const (
 	TableOptionDelayKeyWrite
 	TableOptionRowFormat
 	TableOptionStatsPersistent
+	TableOptionAutoIDCache
 )
 
 // RowFormat types
@@ -690,6 +779,8 @@ const (
 	AlterTableRenameTable
 	AlterTableAlterColumn
 	AlterTableLock
+	AlterTableRenameIndex
+	AlterTableIndexVisibility
 
 // TODO: Add more actions
 )
@@ -719,6 +810,11 @@ type AlterTableSpec struct {
 	OldColumnName *ColumnName
 	Position      *ColumnPosition
 	LockType      LockType
+	// FromKey and ToKey are the old and new names of RENAME INDEX.
+	FromKey model.CIStr
+	ToKey   model.CIStr
+	// Visibility is the new visibility of the index named Name.
+	Visibility IndexVisibility
 }
 
 // Accept implements Node Accept interface.
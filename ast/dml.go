@@ -116,6 +116,8 @@ type TableName struct {
 	TableInfo *model.TableInfo
 
 	IndexHints []*IndexHint
+	// TableSample is the TABLESAMPLE clause, only a part of the rows are read if it's set.
+	TableSample *TableSample
 }
 
 // IndexHintType is the type for index hint use, ignore or force.
@@ -146,6 +148,35 @@ type IndexHint struct {
 	HintScope  IndexHintScope
 }
 
+// TableSampleMethod is the method to sample the rows of a table.
+type TableSampleMethod int
+
+// TableSampleMethod values.
+const (
+	// SampleRegions reads the first row of every region of the table.
+	SampleRegions TableSampleMethod = iota + 1
+	// SampleBernoulli reads every row of the table with the probability of the percentage.
+	SampleBernoulli
+)
+
+// String implements fmt.Stringer interface.
+func (m TableSampleMethod) String() string {
+	switch m {
+	case SampleRegions:
+		return "REGIONS"
+	case SampleBernoulli:
+		return "BERNOULLI"
+	}
+	return ""
+}
+
+// TableSample represents the TABLESAMPLE clause, e.g. TABLESAMPLE REGIONS() or TABLESAMPLE BERNOULLI(10).
+type TableSample struct {
+	Method TableSampleMethod
+	// Percent is the percentage of the rows BERNOULLI reads.
+	Percent float64
+}
+
 // Accept implements Node Accept interface.
 func (n *TableName) Accept(v Visitor) (Node, bool) {
 	newNode, skipChildren := v.Enter(n)
@@ -391,6 +422,11 @@ func (n *ByItem) Accept(v Visitor) (Node, bool) {
 type GroupByClause struct {
 	node
 	Items []*ByItem
+	// Rollup is true for "GROUP BY ... WITH ROLLUP", the items are grouped by every prefix of them.
+	Rollup bool
+	// GroupingSets are the positions of the items in each set of "GROUP BY GROUPING SETS (...)",
+	// the items are the expressions of all the sets.
+	GroupingSets [][]int
 }
 
 // Accept implements Node Accept interface.
@@ -962,6 +998,9 @@ const (
 	ShowStatsMeta
 	ShowStatsHistograms
 	ShowStatsBuckets
+	ShowBindings
+	ShowErrors
+	ShowSessionStates
 )
 
 // ShowStmt is a statement to provide information about databases, tables, columns and so on.
@@ -982,6 +1021,11 @@ type ShowStmt struct {
 	GlobalScope bool
 	Pattern     *PatternLikeExpr
 	Where       ExprNode
+
+	// CountWarningsOrErrors is used by show warnings and errors, only the count is returned if it's true.
+	CountWarningsOrErrors bool
+	// Limit is used by show warnings and errors.
+	Limit *Limit
 }
 
 // Accept implements Node Accept interface.
@@ -1012,6 +1056,13 @@ func (n *ShowStmt) Accept(v Visitor) (Node, bool) {
 		}
 		n.Pattern = node.(*PatternLikeExpr)
 	}
+	if n.Limit != nil {
+		node, ok := n.Limit.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.Limit = node.(*Limit)
+	}
 
 	switch n.Tp {
 	case ShowTriggers, ShowProcedureStatus, ShowProcessList, ShowEvents:
@@ -0,0 +1,91 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ast
+
+var (
+	_ StmtNode = &CreateEventStmt{}
+	_ StmtNode = &DropEventStmt{}
+)
+
+// EventSchedule is the schedule of an event, it's AT At for a one time event, or
+// EVERY Every Unit [STARTS Starts] [ENDS Ends] for a recurring event.
+type EventSchedule struct {
+	At     ExprNode
+	Every  ExprNode
+	Unit   string
+	Starts ExprNode
+	Ends   ExprNode
+}
+
+func (s *EventSchedule) accept(v Visitor) bool {
+	for _, expr := range []*ExprNode{&s.At, &s.Every, &s.Starts, &s.Ends} {
+		if *expr == nil {
+			continue
+		}
+		node, ok := (*expr).Accept(v)
+		if !ok {
+			return false
+		}
+		*expr = node.(ExprNode)
+	}
+	return true
+}
+
+// CreateEventStmt creates an event.
+// The body is not visited by Accept, it's parsed again from the stored text when the event runs.
+// See https://dev.mysql.com/doc/refman/5.7/en/create-event.html
+type CreateEventStmt struct {
+	stmtNode
+
+	IfNotExists bool
+	Name        *TableName
+	Schedule    *EventSchedule
+	// Preserve indicates the event is kept after it expires.
+	Preserve bool
+	Disabled bool
+	Comment  string
+	Body     StmtNode
+}
+
+// Accept implements Node Accept interface.
+func (n *CreateEventStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*CreateEventStmt)
+	if !n.Schedule.accept(v) {
+		return n, false
+	}
+	return v.Leave(n)
+}
+
+// DropEventStmt drops an event.
+// See https://dev.mysql.com/doc/refman/5.7/en/drop-event.html
+type DropEventStmt struct {
+	stmtNode
+
+	IfExists bool
+	Name     *TableName
+}
+
+// Accept implements Node Accept interface.
+func (n *DropEventStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*DropEventStmt)
+	return v.Leave(n)
+}
@@ -280,6 +280,13 @@ const (
 	UncompressedLength       = "uncompressed_length"
 	ValidatePasswordStrength = "validate_password_strength"
 
+	// data masking functions
+	MaskEmail  = "mask_email"
+	MaskInner  = "mask_inner"
+	MaskOuter  = "mask_outer"
+	MaskRandom = "mask_random"
+	MaskSSN    = "mask_ssn"
+
 	// json functions
 	JSONType     = "json_type"
 	JSONExtract  = "json_extract"
@@ -401,6 +408,31 @@ const (
 	AggFuncMin = "min"
 	// AggFuncGroupConcat is the name of group_concat function.
 	AggFuncGroupConcat = "group_concat"
+	// AggFuncBitOr is the name of bit_or function.
+	AggFuncBitOr = "bit_or"
+	// AggFuncBitXor is the name of bit_xor function.
+	AggFuncBitXor = "bit_xor"
+	// AggFuncBitAnd is the name of bit_and function.
+	AggFuncBitAnd = "bit_and"
+	// AggFuncVarPop is the name of var_pop function, variance is its synonym.
+	AggFuncVarPop = "var_pop"
+	// AggFuncVarSamp is the name of var_samp function.
+	AggFuncVarSamp = "var_samp"
+	// AggFuncStddevPop is the name of stddev_pop function, std and stddev are its synonyms.
+	AggFuncStddevPop = "stddev_pop"
+	// AggFuncStddevSamp is the name of stddev_samp function.
+	AggFuncStddevSamp = "stddev_samp"
+	// AggFuncJSONArrayAgg is the name of json_arrayagg function.
+	AggFuncJSONArrayAgg = "json_arrayagg"
+	// AggFuncJSONObjectAgg is the name of json_objectagg function.
+	AggFuncJSONObjectAgg = "json_objectagg"
+	// AggFuncApproxCountDistinct is the name of approx_count_distinct function.
+	AggFuncApproxCountDistinct = "approx_count_distinct"
+	// AggFuncApproxPercentile is the name of approx_percentile function.
+	AggFuncApproxPercentile = "approx_percentile"
+	// AggFuncGrouping is the name of grouping function, it tells whether the group-by expressions are NULL because
+	// of ROLLUP or GROUPING SETS.
+	AggFuncGrouping = "grouping"
 )
 
 // AggregateFuncExpr represents aggregate function expression.
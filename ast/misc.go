@@ -19,6 +19,7 @@ import (
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/util/types"
 )
 
 var (
@@ -27,15 +28,20 @@ var (
 	_ StmtNode = &BeginStmt{}
 	_ StmtNode = &BinlogStmt{}
 	_ StmtNode = &CommitStmt{}
+	_ StmtNode = &CreateFunctionStmt{}
 	_ StmtNode = &CreateUserStmt{}
 	_ StmtNode = &DeallocateStmt{}
 	_ StmtNode = &DoStmt{}
+	_ StmtNode = &DropFunctionStmt{}
 	_ StmtNode = &ExecuteStmt{}
 	_ StmtNode = &ExplainStmt{}
+	_ StmtNode = &ExplainForStmt{}
+	_ StmtNode = &TraceStmt{}
 	_ StmtNode = &GrantStmt{}
 	_ StmtNode = &PrepareStmt{}
 	_ StmtNode = &RollbackStmt{}
 	_ StmtNode = &SetPwdStmt{}
+	_ StmtNode = &SetSessionStatesStmt{}
 	_ StmtNode = &SetStmt{}
 	_ StmtNode = &UseStmt{}
 	_ StmtNode = &FlushStmt{}
@@ -99,6 +105,50 @@ func (n *ExplainStmt) Accept(v Visitor) (Node, bool) {
 	return v.Leave(n)
 }
 
+// ExplainForStmt is a statement to explain the plan of the statement being executed in a connection,
+// or of the last one if the connection is idle.
+// See https://dev.mysql.com/doc/refman/5.7/en/explain-for-connection.html
+type ExplainForStmt struct {
+	stmtNode
+
+	ConnectionID uint64
+}
+
+// Accept implements Node Accept interface.
+func (n *ExplainForStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*ExplainForStmt)
+	return v.Leave(n)
+}
+
+// TraceStmt is a statement to execute a SQL statement and return the spans of the execution,
+// the spans form a tree from the parse, plan and execute of the statement down to the coprocessor requests.
+// If Plan is true, the statement isn't executed, how the optimizer chooses its plan is returned instead.
+type TraceStmt struct {
+	stmtNode
+
+	Stmt StmtNode
+	Plan bool
+}
+
+// Accept implements Node Accept interface.
+func (n *TraceStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*TraceStmt)
+	node, ok := n.Stmt.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.Stmt = node.(StmtNode)
+	return v.Leave(n)
+}
+
 // PrepareStmt is a statement to prepares a SQL statement which contains placeholders,
 // and it is executed with ExecuteStmt and released with DeallocateStmt.
 // See https://dev.mysql.com/doc/refman/5.7/en/prepare.html
@@ -299,6 +349,7 @@ const (
 	FlushNone FlushStmtType = iota
 	FlushTables
 	FlushPrivileges
+	FlushUserResources
 )
 
 // FlushStmt is a statement to flush tables/privileges/optimizer costs and so on.
@@ -337,7 +388,8 @@ type KillStmt struct {
 	// new a connection; kill xxx;
 	// kill command may send to the wrong TiDB, because the exists of LVS proxy, and kill the wrong session.
 	// So, "KILL TIDB" grammar is introduced, and it REQUIRES DIRECT client -> TiDB TOPOLOGY.
-	// TODO: The standard KILL grammar will be supported once we have global connectionID.
+	// The standard KILL grammar is supported when the connection IDs are global, that's when the tidb-server
+	// is registered in etcd, the connection on another tidb-server is killed by the status API of that server.
 	TiDBExtension bool
 }
 
@@ -415,6 +467,23 @@ func (n *SetPwdStmt) Accept(v Visitor) (Node, bool) {
 	return v.Leave(n)
 }
 
+// SetSessionStatesStmt is a statement to import the session states exported by SHOW SESSION_STATES.
+type SetSessionStatesStmt struct {
+	stmtNode
+
+	States string
+}
+
+// Accept implements Node Accept interface.
+func (n *SetSessionStatesStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*SetSessionStatesStmt)
+	return v.Leave(n)
+}
+
 // UserSpec is used for parsing create user statement.
 type UserSpec struct {
 	User    string
@@ -435,13 +504,33 @@ func (u *UserSpec) SecurityString() string {
 	return u.User
 }
 
+// ResourceOptionType is the type of a resource limit of the user account.
+type ResourceOptionType int
+
+// Resource option types.
+const (
+	MaxUserConnections ResourceOptionType = iota + 1
+	MaxQueriesPerHour
+	MaxUpdatesPerHour
+	MaxConnectionsPerHour
+)
+
+// ResourceOption is a resource limit of the user account, like "MAX_USER_CONNECTIONS 10".
+type ResourceOption struct {
+	Type  ResourceOptionType
+	Count int64
+}
+
 // CreateUserStmt creates user account.
 // See https://dev.mysql.com/doc/refman/5.7/en/create-user.html
 type CreateUserStmt struct {
 	stmtNode
 
-	IfNotExists bool
-	Specs       []*UserSpec
+	IfNotExists     bool
+	Specs           []*UserSpec
+	ResourceOptions []*ResourceOption
+	// ResourceGroup is the resource group of the users, it's empty if it's not specified.
+	ResourceGroup string
 }
 
 // Accept implements Node Accept interface.
@@ -459,9 +548,12 @@ func (n *CreateUserStmt) Accept(v Visitor) (Node, bool) {
 type AlterUserStmt struct {
 	stmtNode
 
-	IfExists    bool
-	CurrentAuth *AuthOption
-	Specs       []*UserSpec
+	IfExists        bool
+	CurrentAuth     *AuthOption
+	Specs           []*UserSpec
+	ResourceOptions []*ResourceOption
+	// ResourceGroup is the resource group of the users, it's empty if it's not specified.
+	ResourceGroup string
 }
 
 // Accept implements Node Accept interface.
@@ -493,6 +585,53 @@ func (n *DropUserStmt) Accept(v Visitor) (Node, bool) {
 	return v.Leave(n)
 }
 
+// FuncParam is a parameter of the user-defined function.
+type FuncParam struct {
+	Name string
+	Tp   *types.FieldType
+}
+
+// CreateFunctionStmt creates a user-defined function written in a scripting language.
+type CreateFunctionStmt struct {
+	stmtNode
+
+	IfNotExists   bool
+	Name          model.CIStr
+	Params        []*FuncParam
+	ReturnType    *types.FieldType
+	Language      string
+	Deterministic bool
+	Body          string
+}
+
+// Accept implements Node Accept interface.
+func (n *CreateFunctionStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*CreateFunctionStmt)
+	return v.Leave(n)
+}
+
+// DropFunctionStmt drops a user-defined function.
+type DropFunctionStmt struct {
+	stmtNode
+
+	IfExists bool
+	Name     model.CIStr
+}
+
+// Accept implements Node Accept interface.
+func (n *DropFunctionStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*DropFunctionStmt)
+	return v.Leave(n)
+}
+
 // DoStmt is the struct for DO statement.
 type DoStmt struct {
 	stmtNode
@@ -524,14 +663,43 @@ type AdminStmtType int
 const (
 	AdminShowDDL = iota + 1
 	AdminCheckTable
+	AdminEnableFailpoint
+	AdminDisableFailpoint
+	AdminShowFailpoints
+	AdminCheckIndex
+	AdminRepairIndex
+	AdminChecksumTable
+	AdminDumpDatabase
+	AdminPluginEnable
+	AdminPluginDisable
+	AdminCalibrate
+	AdminCleanupTableLock
 )
 
+// HandleRange represents a range of row handles, from Begin to End, End is not included.
+type HandleRange struct {
+	Begin int64
+	End   int64
+}
+
 // AdminStmt is the struct for Admin statement.
 type AdminStmt struct {
 	stmtNode
 
 	Tp     AdminStmtType
 	Tables []*TableName
+	// Index and HandleRanges are for the index statements, the whole index is checked
+	// or repaired if HandleRanges is empty.
+	Index        string
+	HandleRanges []HandleRange
+	// Failpoint and FailpointTerms are for the failpoint statements.
+	Failpoint      string
+	FailpointTerms string
+	// DBName and DumpPath are for the dump statement.
+	DBName   string
+	DumpPath string
+	// Plugins are the names of the plugins to be enabled or disabled.
+	Plugins []string
 }
 
 // Accept implements Node Accpet interface.
@@ -691,7 +859,8 @@ func (i Ident) String() string {
 
 // SelectStmtOpts wrap around select hints and switches
 type SelectStmtOpts struct {
-	Distinct      bool
+	Distinct bool
+	// SQLCache is false if the statement has SQL_NO_CACHE, its result is not put into the result cache.
 	SQLCache      bool
 	CalcFoundRows bool
 	Priority      mysql.PriorityEnum
@@ -58,6 +58,7 @@ func (ts *testMiscSuite) TestMiscVisitorCover(c *C) {
 		(&PrepareStmt{SQLVar: &VariableExpr{Value: &ValueExpr{}}}),
 		(&RollbackStmt{}),
 		(&SetPwdStmt{}),
+		(&SetSessionStatesStmt{}),
 		(&SetStmt{Variables: []*VariableAssignment{
 			{
 				Value: &ValueExpr{},
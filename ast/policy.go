@@ -0,0 +1,112 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ast
+
+import (
+	"github.com/pingcap/tidb/model"
+)
+
+var (
+	_ StmtNode = &CreatePolicyStmt{}
+	_ StmtNode = &DropPolicyStmt{}
+	_ StmtNode = &CreateMaskingPolicyStmt{}
+	_ StmtNode = &DropMaskingPolicyStmt{}
+)
+
+// CreatePolicyStmt creates a row-level security policy, the rows of the table are filtered by the predicate
+// whenever the table is read.
+// The table and the predicate are not visited by Accept, the text of the predicate is stored and it's parsed
+// again when the table is planned.
+type CreatePolicyStmt struct {
+	stmtNode
+
+	IfNotExists bool
+	Name        model.CIStr
+	Table       *TableName
+	Predicate   ExprNode
+}
+
+// Accept implements Node Accept interface.
+func (n *CreatePolicyStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*CreatePolicyStmt)
+	return v.Leave(n)
+}
+
+// DropPolicyStmt drops a row-level security policy of the table.
+// The table is not visited by Accept.
+type DropPolicyStmt struct {
+	stmtNode
+
+	IfExists bool
+	Name     model.CIStr
+	Table    *TableName
+}
+
+// Accept implements Node Accept interface.
+func (n *DropPolicyStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*DropPolicyStmt)
+	return v.Leave(n)
+}
+
+// CreateMaskingPolicyStmt creates a masking policy, the column of the table is replaced by the mask expression
+// when it's read by the users without the SUPER privilege.
+// The table and the mask expression are not visited by Accept, the text of the expression is stored and it's
+// parsed again when the table is planned.
+type CreateMaskingPolicyStmt struct {
+	stmtNode
+
+	IfNotExists bool
+	Name        model.CIStr
+	Table       *TableName
+	Column      model.CIStr
+	MaskExpr    ExprNode
+}
+
+// Accept implements Node Accept interface.
+func (n *CreateMaskingPolicyStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*CreateMaskingPolicyStmt)
+	return v.Leave(n)
+}
+
+// DropMaskingPolicyStmt drops a masking policy of the table.
+// The table is not visited by Accept.
+type DropMaskingPolicyStmt struct {
+	stmtNode
+
+	IfExists bool
+	Name     model.CIStr
+	Table    *TableName
+}
+
+// Accept implements Node Accept interface.
+func (n *DropMaskingPolicyStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*DropMaskingPolicyStmt)
+	return v.Leave(n)
+}
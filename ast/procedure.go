@@ -0,0 +1,300 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ast
+
+import (
+	"github.com/pingcap/tidb/util/types"
+)
+
+var (
+	_ StmtNode = &CallStmt{}
+	_ StmtNode = &CreateProcedureStmt{}
+	_ StmtNode = &DropProcedureStmt{}
+	_ StmtNode = &ProcBlockStmt{}
+	_ StmtNode = &ProcDeclareStmt{}
+	_ StmtNode = &ProcIfStmt{}
+	_ StmtNode = &ProcIterateStmt{}
+	_ StmtNode = &ProcLeaveStmt{}
+	_ StmtNode = &ProcLoopStmt{}
+	_ StmtNode = &ProcWhileStmt{}
+)
+
+// ProcParamMode is the mode of a stored procedure parameter.
+type ProcParamMode int
+
+// Stored procedure parameter modes.
+const (
+	ProcParamIn ProcParamMode = iota
+	ProcParamOut
+	ProcParamInOut
+)
+
+// String implements fmt.Stringer interface.
+func (m ProcParamMode) String() string {
+	switch m {
+	case ProcParamOut:
+		return "OUT"
+	case ProcParamInOut:
+		return "INOUT"
+	}
+	return "IN"
+}
+
+// ProcParam is a parameter of the stored procedure.
+type ProcParam struct {
+	Mode ProcParamMode
+	Name string
+	Tp   *types.FieldType
+}
+
+// CreateProcedureStmt creates a stored procedure.
+// The body is not visited by Accept, it's parsed again from the stored text when the procedure is called.
+// See https://dev.mysql.com/doc/refman/5.7/en/create-procedure.html
+type CreateProcedureStmt struct {
+	stmtNode
+
+	IfNotExists bool
+	Name        *TableName
+	Params      []*ProcParam
+	Body        *ProcBlockStmt
+}
+
+// Accept implements Node Accept interface.
+func (n *CreateProcedureStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*CreateProcedureStmt)
+	return v.Leave(n)
+}
+
+// DropProcedureStmt drops a stored procedure.
+// See https://dev.mysql.com/doc/refman/5.7/en/drop-procedure.html
+type DropProcedureStmt struct {
+	stmtNode
+
+	IfExists bool
+	Name     *TableName
+}
+
+// Accept implements Node Accept interface.
+func (n *DropProcedureStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*DropProcedureStmt)
+	return v.Leave(n)
+}
+
+// CallStmt calls a stored procedure.
+// See https://dev.mysql.com/doc/refman/5.7/en/call.html
+type CallStmt struct {
+	stmtNode
+
+	Name *TableName
+	Args []ExprNode
+}
+
+// Accept implements Node Accept interface.
+func (n *CallStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*CallStmt)
+	for i, val := range n.Args {
+		node, ok := val.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.Args[i] = node.(ExprNode)
+	}
+	return v.Leave(n)
+}
+
+func acceptStmts(v Visitor, stmts []StmtNode) bool {
+	for i, stmt := range stmts {
+		node, ok := stmt.Accept(v)
+		if !ok {
+			return false
+		}
+		stmts[i] = node.(StmtNode)
+	}
+	return true
+}
+
+// ProcBlockStmt is a BEGIN ... END compound statement in a stored procedure.
+type ProcBlockStmt struct {
+	stmtNode
+
+	Label string
+	Stmts []StmtNode
+}
+
+// Accept implements Node Accept interface.
+func (n *ProcBlockStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*ProcBlockStmt)
+	if !acceptStmts(v, n.Stmts) {
+		return n, false
+	}
+	return v.Leave(n)
+}
+
+// ProcDeclareStmt declares the local variables in a stored procedure.
+// See https://dev.mysql.com/doc/refman/5.7/en/declare-local-variable.html
+type ProcDeclareStmt struct {
+	stmtNode
+
+	Names []string
+	Tp    *types.FieldType
+	// Default is nil if the variables are initialized to NULL.
+	Default ExprNode
+}
+
+// Accept implements Node Accept interface.
+func (n *ProcDeclareStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*ProcDeclareStmt)
+	if n.Default != nil {
+		node, ok := n.Default.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.Default = node.(ExprNode)
+	}
+	return v.Leave(n)
+}
+
+// ProcIfStmt is an IF statement in a stored procedure, ELSEIF is an ProcIfStmt in Else.
+// See https://dev.mysql.com/doc/refman/5.7/en/if.html
+type ProcIfStmt struct {
+	stmtNode
+
+	Cond ExprNode
+	Then []StmtNode
+	Else []StmtNode
+}
+
+// Accept implements Node Accept interface.
+func (n *ProcIfStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*ProcIfStmt)
+	node, ok := n.Cond.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.Cond = node.(ExprNode)
+	if !acceptStmts(v, n.Then) || !acceptStmts(v, n.Else) {
+		return n, false
+	}
+	return v.Leave(n)
+}
+
+// ProcWhileStmt is a WHILE statement in a stored procedure.
+// See https://dev.mysql.com/doc/refman/5.7/en/while.html
+type ProcWhileStmt struct {
+	stmtNode
+
+	Label string
+	Cond  ExprNode
+	Body  []StmtNode
+}
+
+// Accept implements Node Accept interface.
+func (n *ProcWhileStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*ProcWhileStmt)
+	node, ok := n.Cond.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.Cond = node.(ExprNode)
+	if !acceptStmts(v, n.Body) {
+		return n, false
+	}
+	return v.Leave(n)
+}
+
+// ProcLoopStmt is a LOOP statement in a stored procedure, it runs until a LEAVE statement.
+// See https://dev.mysql.com/doc/refman/5.7/en/loop.html
+type ProcLoopStmt struct {
+	stmtNode
+
+	Label string
+	Body  []StmtNode
+}
+
+// Accept implements Node Accept interface.
+func (n *ProcLoopStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*ProcLoopStmt)
+	if !acceptStmts(v, n.Body) {
+		return n, false
+	}
+	return v.Leave(n)
+}
+
+// ProcLeaveStmt exits the labeled block or loop.
+// See https://dev.mysql.com/doc/refman/5.7/en/leave.html
+type ProcLeaveStmt struct {
+	stmtNode
+
+	Label string
+}
+
+// Accept implements Node Accept interface.
+func (n *ProcLeaveStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*ProcLeaveStmt)
+	return v.Leave(n)
+}
+
+// ProcIterateStmt starts the next iteration of the labeled loop.
+// See https://dev.mysql.com/doc/refman/5.7/en/iterate.html
+type ProcIterateStmt struct {
+	stmtNode
+
+	Label string
+}
+
+// Accept implements Node Accept interface.
+func (n *ProcIterateStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*ProcIterateStmt)
+	return v.Leave(n)
+}
@@ -0,0 +1,248 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bindinfo manages the SQL bindings which fix the plans of the statements.
+//
+// A binding is created by CREATE BINDING and stored in the mysql.bind_info table, it maps the normalized
+// form of a statement to a statement with hints. Every server caches the bindings in a Handle which is
+// reloaded when a binding is created or dropped. When a statement is planned, its hints are replaced by
+// the hints of the bound statement, so its plan is fixed without changing the SQL of the application.
+package bindinfo
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/juju/errors"
+	"github.com/ngaut/log"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/parser"
+	"github.com/pingcap/tidb/util/sqlexec"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// StatusUsing is the status of the bindings which are used by the planner.
+const StatusUsing = "using"
+
+// Binding is a SQL binding.
+type Binding struct {
+	// OriginalSQL is the normalized form of the bound statement.
+	OriginalSQL string
+	// BindSQL is the statement with the hints.
+	BindSQL string
+	// DefaultDB is the current database when the binding is created, the binding is used only in it.
+	DefaultDB  string
+	Status     string
+	CreateTime types.Time
+	UpdateTime types.Time
+	// Charset and Collation are used to parse BindSQL.
+	Charset   string
+	Collation string
+
+	hinted ast.StmtNode
+}
+
+// NormalizeSQL returns the normalized form of the statement which is the key of its binding.
+func NormalizeSQL(sql string) string {
+	sql = strings.TrimRight(strings.TrimSpace(sql), "; \t\n")
+	return parser.Normalize(sql)
+}
+
+// hintsCollector collects the nodes which hold the hints in the order they're visited.
+type hintsCollector struct {
+	selects []*ast.SelectStmt
+	tables  []*ast.TableName
+}
+
+func (c *hintsCollector) Enter(in ast.Node) (ast.Node, bool) {
+	switch x := in.(type) {
+	case *ast.SelectStmt:
+		c.selects = append(c.selects, x)
+	case *ast.TableName:
+		c.tables = append(c.tables, x)
+	}
+	return in, false
+}
+
+func (c *hintsCollector) Leave(in ast.Node) (ast.Node, bool) {
+	return in, true
+}
+
+func collectHints(stmt ast.StmtNode) *hintsCollector {
+	c := &hintsCollector{}
+	stmt.Accept(c)
+	return c
+}
+
+// matchHints checks the two statements have the same SELECTs and reference the same tables in the same order,
+// so the hints of one statement can be applied to the other one.
+func matchHints(origin, hinted *hintsCollector) bool {
+	if len(origin.selects) != len(hinted.selects) || len(origin.tables) != len(hinted.tables) {
+		return false
+	}
+	for i, tbl := range origin.tables {
+		if tbl.Schema.L != hinted.tables[i].Schema.L || tbl.Name.L != hinted.tables[i].Name.L {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchHinted checks the hinted statement is the original statement with different hints.
+func MatchHinted(origin, hinted ast.StmtNode) bool {
+	return matchHints(collectHints(origin), collectHints(hinted))
+}
+
+// applyHints replaces the hints of the statement by the hints of the hinted statement, the statements
+// should be matched by MatchHinted. The hints are shared, so they mustn't be changed.
+func applyHints(stmt, hinted ast.StmtNode) bool {
+	origin, bound := collectHints(stmt), collectHints(hinted)
+	if !matchHints(origin, bound) {
+		return false
+	}
+	for i, sel := range origin.selects {
+		sel.TableHints = append([]*ast.TableOptimizerHint(nil), bound.selects[i].TableHints...)
+	}
+	for i, tbl := range origin.tables {
+		tbl.IndexHints = append([]*ast.IndexHint(nil), bound.tables[i].IndexHints...)
+	}
+	return true
+}
+
+// Handle caches the bindings.
+type Handle struct {
+	// bindings is a map[string]*Binding keyed by the default database and the original SQL, it's
+	// replaced by Update.
+	bindings atomic.Value
+}
+
+// NewHandle creates a Handle without bindings.
+func NewHandle() *Handle {
+	h := &Handle{}
+	h.bindings.Store(make(map[string]*Binding))
+	return h
+}
+
+func bindingKey(db, normalizedSQL string) string {
+	return strings.ToLower(db) + ":" + normalizedSQL
+}
+
+// Get returns the binding of the normalized statement in the database, or nil if it doesn't exist.
+func (h *Handle) Get(db, normalizedSQL string) *Binding {
+	return h.bindings.Load().(map[string]*Binding)[bindingKey(db, normalizedSQL)]
+}
+
+// All returns all the bindings ordered by the original SQL and the default database.
+func (h *Handle) All() []*Binding {
+	bindings := h.bindings.Load().(map[string]*Binding)
+	all := make([]*Binding, 0, len(bindings))
+	for _, b := range bindings {
+		all = append(all, b)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].OriginalSQL != all[j].OriginalSQL {
+			return all[i].OriginalSQL < all[j].OriginalSQL
+		}
+		return all[i].DefaultDB < all[j].DefaultDB
+	})
+	return all
+}
+
+var loadSQL = fmt.Sprintf(`select original_sql, bind_sql, default_db, status, create_time, update_time, charset, collation
+	from %s.%s where status = "%s"`, mysql.SystemDB, mysql.BindInfoTable, StatusUsing)
+
+// Update loads all the bindings from the mysql.bind_info table. A binding which fails to parse is
+// skipped, so a bad binding can't break the others.
+func (h *Handle) Update(ctx context.Context) error {
+	rss, err := ctx.(sqlexec.SQLExecutor).Execute(loadSQL)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	rs := rss[0]
+	defer rs.Close()
+	bindings := make(map[string]*Binding)
+	for {
+		row, err := rs.Next()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if row == nil {
+			break
+		}
+		b := &Binding{
+			OriginalSQL: row.Data[0].GetString(),
+			BindSQL:     row.Data[1].GetString(),
+			DefaultDB:   row.Data[2].GetString(),
+			Status:      row.Data[3].GetString(),
+			CreateTime:  row.Data[4].GetMysqlTime(),
+			UpdateTime:  row.Data[5].GetMysqlTime(),
+			Charset:     row.Data[6].GetString(),
+			Collation:   row.Data[7].GetString(),
+		}
+		b.hinted, err = parser.New().ParseOneStmt(b.BindSQL, b.Charset, b.Collation)
+		if err != nil {
+			log.Errorf("[bindinfo] load binding for %s fail: %v", b.OriginalSQL, err)
+			continue
+		}
+		bindings[bindingKey(b.DefaultDB, b.OriginalSQL)] = b
+	}
+	h.bindings.Store(bindings)
+	return nil
+}
+
+// BindHints replaces the hints of the SELECT or UNION statement, or the explained one, by the hints of its
+// binding in the current database. It returns false if the statement isn't bound.
+func BindHints(ctx context.Context, stmt ast.StmtNode) bool {
+	h := GetHandle(ctx)
+	if h == nil || ctx.GetSessionVars().InRestrictedSQL {
+		return false
+	}
+	if explain, ok := stmt.(*ast.ExplainStmt); ok {
+		stmt = explain.Stmt
+	}
+	switch stmt.(type) {
+	case *ast.SelectStmt, *ast.UnionStmt:
+	default:
+		return false
+	}
+	b := h.Get(ctx.GetSessionVars().CurrentDB, NormalizeSQL(stmt.Text()))
+	if b == nil {
+		return false
+	}
+	return applyHints(stmt, b.hinted)
+}
+
+type keyType int
+
+func (k keyType) String() string {
+	return "bindinfo-key"
+}
+
+const key keyType = 0
+
+// BindHandle binds the Handle to context.
+func BindHandle(ctx context.Context, h *Handle) {
+	ctx.SetValue(key, h)
+}
+
+// GetHandle gets the Handle from context, it returns nil if no Handle is bound.
+func GetHandle(ctx context.Context) *Handle {
+	if v, ok := ctx.Value(key).(*Handle); ok {
+		return v
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bindinfo
+
+import (
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/parser"
+	"github.com/pingcap/tidb/util/testleak"
+)
+
+func TestT(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testBindInfoSuite{})
+
+type testBindInfoSuite struct{}
+
+func (s *testBindInfoSuite) TestNormalizeSQL(c *C) {
+	defer testleak.AfterTest(c)()
+	c.Assert(NormalizeSQL(" SELECT * FROM t WHERE a = 1;\n"), Equals, "select * from t where a = ?")
+	c.Assert(NormalizeSQL("select * from t where a = 'x'"), Equals, NormalizeSQL("select *  from T where A = 2"))
+}
+
+func (s *testBindInfoSuite) TestApplyHints(c *C) {
+	defer testleak.AfterTest(c)()
+	tests := []struct {
+		origin  string
+		hinted  string
+		matched bool
+	}{
+		{"select * from t where a = 1", "select * from t use index(a) where a = 2", true},
+		{"select * from t, t1 where t.a = t1.a", "select /*+ TIDB_INLJ(t1) */ * from t, t1 where t.a = t1.a", true},
+		{"select a from t where a in (select a from t1)", "select a from t ignore index(a) where a in (select a from t1 use index(a))", true},
+		{"select * from t union select * from t1", "select * from t union select * from t1 force index(a)", true},
+		{"select * from t", "select * from t1 use index(a)", false},
+		{"select * from t, t1", "select * from t1, t", false},
+		{"select * from t", "select * from test.t", false},
+		{"select a from t where a in (select a from t1)", "select a from t, t1", false},
+	}
+	p := parser.New()
+	for _, tt := range tests {
+		origin, err := p.ParseOneStmt(tt.origin, "", "")
+		c.Assert(err, IsNil)
+		hinted, err := p.ParseOneStmt(tt.hinted, "", "")
+		c.Assert(err, IsNil)
+		c.Assert(MatchHinted(origin, hinted), Equals, tt.matched, Commentf("%s", tt.hinted))
+		c.Assert(applyHints(origin, hinted), Equals, tt.matched)
+		if tt.matched {
+			originHints, hintedHints := collectHints(origin), collectHints(hinted)
+			for i, sel := range originHints.selects {
+				c.Assert(sel.TableHints, DeepEquals, hintedHints.selects[i].TableHints)
+			}
+			for i, tbl := range originHints.tables {
+				c.Assert(tbl.IndexHints, DeepEquals, hintedHints.tables[i].IndexHints)
+			}
+		}
+	}
+
+	stmt, err := p.ParseOneStmt("select * from t use index(a)", "", "")
+	c.Assert(err, IsNil)
+	h := NewHandle()
+	c.Assert(h.Get("test", "select * from t"), IsNil)
+	c.Assert(h.All(), HasLen, 0)
+	b := &Binding{OriginalSQL: "select * from t", DefaultDB: "test", hinted: stmt}
+	h.bindings.Store(map[string]*Binding{bindingKey(b.DefaultDB, b.OriginalSQL): b})
+	c.Assert(h.Get("TEST", "select * from t"), Equals, b)
+	c.Assert(h.Get("test1", "select * from t"), IsNil)
+}
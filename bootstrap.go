@@ -18,8 +18,10 @@
 package tidb
 
 import (
+	"crypto/rand"
 	"encoding/hex"
 	"fmt"
+	"math/big"
 	"runtime/debug"
 	"strconv"
 	"strings"
@@ -65,6 +67,11 @@ const (
 		Create_user_priv		ENUM('N','Y') NOT NULL DEFAULT 'N',
 		Event_priv			ENUM('N','Y') NOT NULL DEFAULT 'N',
 		Trigger_priv			ENUM('N','Y') NOT NULL DEFAULT 'N',
+		max_questions			INT(11) UNSIGNED NOT NULL DEFAULT 0,
+		max_updates			INT(11) UNSIGNED NOT NULL DEFAULT 0,
+		max_connections			INT(11) UNSIGNED NOT NULL DEFAULT 0,
+		max_user_connections		INT(11) UNSIGNED NOT NULL DEFAULT 0,
+		Resource_group			VARCHAR(64) NOT NULL DEFAULT '',
 		PRIMARY KEY (Host, User));`
 	// CreateDBPrivTable is the SQL statement creates DB scope privilege table in system db.
 	CreateDBPrivTable = `CREATE TABLE if not exists mysql.db (
@@ -185,8 +192,138 @@ const (
 		UNIQUE KEY (element_id),
 		KEY (job_id, element_id)
 	);`
+
+	// CreateFuncTable stores the user-defined functions.
+	CreateFuncTable = `CREATE TABLE IF NOT EXISTS mysql.func (
+		name VARCHAR(64) NOT NULL,
+		language VARCHAR(16) NOT NULL,
+		params TEXT NOT NULL COMMENT "the parameters like 'a INT,b STRING'",
+		returns VARCHAR(16) NOT NULL,
+		deterministic TINYINT(1) NOT NULL DEFAULT 0,
+		body LONGTEXT NOT NULL,
+		PRIMARY KEY (name)
+	);`
+
+	// CreateProcTable stores the stored procedures.
+	CreateProcTable = `CREATE TABLE IF NOT EXISTS mysql.proc (
+		db VARCHAR(64) NOT NULL,
+		name VARCHAR(64) NOT NULL,
+		body LONGTEXT NOT NULL COMMENT "the CREATE PROCEDURE statement",
+		PRIMARY KEY (db, name)
+	);`
+
+	// CreateEventTable stores the events and the times they run.
+	CreateEventTable = `CREATE TABLE IF NOT EXISTS mysql.event (
+		db VARCHAR(64) NOT NULL,
+		name VARCHAR(64) NOT NULL,
+		definer VARCHAR(93) NOT NULL,
+		body LONGTEXT NOT NULL COMMENT "the CREATE EVENT statement",
+		definition LONGTEXT NOT NULL COMMENT "the text of the event body",
+		execute_at DATETIME COMMENT "the time of the one time event",
+		interval_value BIGINT,
+		interval_field VARCHAR(16),
+		starts DATETIME,
+		ends DATETIME,
+		status VARCHAR(16) NOT NULL,
+		preserve TINYINT(1) NOT NULL DEFAULT 0,
+		comment VARCHAR(64) NOT NULL DEFAULT "",
+		created DATETIME NOT NULL,
+		last_executed DATETIME,
+		next_run DATETIME COMMENT "NULL if the event doesn't run again",
+		PRIMARY KEY (db, name)
+	);`
+
+	// CreateBindInfoTable stores the SQL bindings.
+	CreateBindInfoTable = `CREATE TABLE IF NOT EXISTS mysql.bind_info (
+		original_sql LONGTEXT NOT NULL COMMENT "the normalized statement",
+		bind_sql LONGTEXT NOT NULL COMMENT "the statement with the hints",
+		default_db VARCHAR(64) NOT NULL,
+		status VARCHAR(16) NOT NULL,
+		create_time DATETIME NOT NULL,
+		update_time DATETIME NOT NULL,
+		charset VARCHAR(32) NOT NULL,
+		collation VARCHAR(32) NOT NULL
+	);`
+
+	// CreateRowPolicyTable stores the row-level security policies.
+	CreateRowPolicyTable = `CREATE TABLE IF NOT EXISTS mysql.row_policy (
+		db VARCHAR(64) NOT NULL,
+		table_name VARCHAR(64) NOT NULL,
+		name VARCHAR(64) NOT NULL,
+		predicate LONGTEXT NOT NULL COMMENT "the rows are visible if the predicate is true",
+		create_time DATETIME NOT NULL,
+		PRIMARY KEY (db, table_name, name)
+	);`
+
+	// CreateColumnMaskTable stores the masking policies of the columns.
+	CreateColumnMaskTable = `CREATE TABLE IF NOT EXISTS mysql.column_mask (
+		db VARCHAR(64) NOT NULL,
+		table_name VARCHAR(64) NOT NULL,
+		column_name VARCHAR(64) NOT NULL,
+		name VARCHAR(64) NOT NULL,
+		mask_expr LONGTEXT NOT NULL COMMENT "the expression which replaces the column",
+		create_time DATETIME NOT NULL,
+		PRIMARY KEY (db, table_name, column_name)
+	);`
+)
+
+// InitializeMode is the mode the first server initializes a new store in.
+type InitializeMode string
+
+// The initialize modes.
+const (
+	// InitializeInsecure creates the root user of any host without a password.
+	InitializeInsecure InitializeMode = "insecure"
+	// InitializeSecure creates the root user of the BootstrapRootHost with the BootstrapRootPassword,
+	// a random password is generated and written to the log if BootstrapRootPassword is empty.
+	InitializeSecure InitializeMode = "secure"
+)
+
+// The options of initializing a new store, they're set by the flags of tidb-server.
+// They are ignored if the store is already bootstrapped.
+var (
+	BootstrapMode         = InitializeInsecure
+	BootstrapRootPassword string
+	BootstrapRootHost     = "127.0.0.1"
 )
 
+// generatedPasswordLen is the length of the root password generated in the secure mode.
+const generatedPasswordLen = 16
+
+const passwordChars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789!#%*+-.:=?@^_~"
+
+func generatePassword() (string, error) {
+	b := make([]byte, generatedPasswordLen)
+	for i := range b {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(passwordChars))))
+		if err != nil {
+			return "", errors.Trace(err)
+		}
+		b[i] = passwordChars[n.Int64()]
+	}
+	return string(b), nil
+}
+
+// rootUser returns the host and the encoded password of the root user created by the bootstrap.
+func rootUser() (host, password string, err error) {
+	if BootstrapMode != InitializeSecure {
+		return "%", "", nil
+	}
+	host = BootstrapRootHost
+	if host == "" || host == "%" || strings.ContainsAny(host, "\"'`\\") {
+		return "", "", errors.Errorf("invalid root host %q of the secure initialization", host)
+	}
+	password = BootstrapRootPassword
+	if password == "" {
+		password, err = generatePassword()
+		if err != nil {
+			return "", "", errors.Trace(err)
+		}
+		log.Warnf("[bootstrap] a temporary password is generated for root@%s: %s", host, password)
+	}
+	return host, util.EncodePassword(password), nil
+}
+
 // bootstrap initiates system DB for a store.
 func bootstrap(s Session) {
 	b, err := checkBootstrapped(s)
@@ -211,6 +348,10 @@ const (
 	// The variable name in mysql.TiDB table.
 	// It is used for getting the version of the TiDB server which bootstrapped the store.
 	tidbServerVersionVar = "tidb_server_version" //
+	// The variable names in mysql.TiDB table which record how the store is bootstrapped.
+	bootstrapModeVar   = "bootstrap_mode"
+	bootstrapTimeVar   = "bootstrap_time"
+	bootstrapServerVar = "bootstrap_server_version"
 	// Const for TiDB server version 2.
 	version2  = 2
 	version3  = 3
@@ -226,6 +367,14 @@ const (
 	version13 = 13
 	version14 = 14
 	version15 = 15
+	version16 = 16
+	version17 = 17
+	version18 = 18
+	version19 = 19
+	version20 = 20
+	version21 = 21
+	version22 = 22
+	version23 = 23
 )
 
 func checkBootstrapped(s Session) (bool, error) {
@@ -340,6 +489,38 @@ func upgrade(s Session) {
 		upgradeToVer15(s)
 	}
 
+	if ver < version16 {
+		upgradeToVer16(s)
+	}
+
+	if ver < version17 {
+		upgradeToVer17(s)
+	}
+
+	if ver < version18 {
+		upgradeToVer18(s)
+	}
+
+	if ver < version19 {
+		upgradeToVer19(s)
+	}
+
+	if ver < version20 {
+		upgradeToVer20(s)
+	}
+
+	if ver < version21 {
+		upgradeToVer21(s)
+	}
+
+	if ver < version22 {
+		upgradeToVer22(s)
+	}
+
+	if ver < version23 {
+		upgradeToVer23(s)
+	}
+
 	updateBootstrapVer(s)
 	_, err = s.Execute("COMMIT")
 
@@ -559,6 +740,45 @@ func upgradeToVer15(s Session) {
 	}
 }
 
+func upgradeToVer16(s Session) {
+	mustExecute(s, CreateFuncTable)
+}
+
+func upgradeToVer17(s Session) {
+	mustExecute(s, CreateProcTable)
+}
+
+func upgradeToVer18(s Session) {
+	mustExecute(s, CreateEventTable)
+}
+
+func upgradeToVer19(s Session) {
+	mustExecute(s, CreateBindInfoTable)
+}
+
+func upgradeToVer20(s Session) {
+	doReentrantDDL(s, "ALTER TABLE mysql.user ADD COLUMN `max_user_connections` int(11) unsigned NOT NULL DEFAULT 0 AFTER `Trigger_priv`", infoschema.ErrColumnExists)
+	// max_connections is enforced now, 0 means no limit. The old default 151 was never enforced, reset it so
+	// the upgraded servers don't start to reject the connections.
+	mustExecute(s, fmt.Sprintf(`UPDATE %s.%s SET VARIABLE_VALUE = "0" WHERE VARIABLE_NAME = "%s" AND VARIABLE_VALUE = "151"`,
+		mysql.SystemDB, mysql.GlobalVariablesTable, variable.MaxConnections))
+}
+
+func upgradeToVer21(s Session) {
+	doReentrantDDL(s, "ALTER TABLE mysql.user ADD COLUMN `max_questions` int(11) unsigned NOT NULL DEFAULT 0 AFTER `Trigger_priv`", infoschema.ErrColumnExists)
+	doReentrantDDL(s, "ALTER TABLE mysql.user ADD COLUMN `max_updates` int(11) unsigned NOT NULL DEFAULT 0 AFTER `max_questions`", infoschema.ErrColumnExists)
+	doReentrantDDL(s, "ALTER TABLE mysql.user ADD COLUMN `max_connections` int(11) unsigned NOT NULL DEFAULT 0 AFTER `max_updates`", infoschema.ErrColumnExists)
+	doReentrantDDL(s, "ALTER TABLE mysql.user ADD COLUMN `Resource_group` varchar(64) NOT NULL DEFAULT '' AFTER `max_user_connections`", infoschema.ErrColumnExists)
+}
+
+func upgradeToVer22(s Session) {
+	mustExecute(s, CreateRowPolicyTable)
+}
+
+func upgradeToVer23(s Session) {
+	mustExecute(s, CreateColumnMaskTable)
+}
+
 // updateBootstrapVer updates bootstrap version variable in mysql.TiDB table.
 func updateBootstrapVer(s Session) {
 	// Update bootstrap version.
@@ -605,6 +825,18 @@ func doDDLWorks(s Session) {
 	mustExecute(s, CreateStatsBucketsTable)
 	// Create gc_delete_range table.
 	mustExecute(s, CreateGCDeleteRangeTable)
+	// Create func table.
+	mustExecute(s, CreateFuncTable)
+	// Create proc table.
+	mustExecute(s, CreateProcTable)
+	// Create event table.
+	mustExecute(s, CreateEventTable)
+	// Create bind_info table.
+	mustExecute(s, CreateBindInfoTable)
+	// Create row_policy table.
+	mustExecute(s, CreateRowPolicyTable)
+	// Create column_mask table.
+	mustExecute(s, CreateColumnMaskTable)
 }
 
 // doDMLWorks executes DML statements in bootstrap stage.
@@ -612,15 +844,20 @@ func doDDLWorks(s Session) {
 func doDMLWorks(s Session) {
 	mustExecute(s, "BEGIN")
 
-	// Insert a default user with empty password.
-	mustExecute(s, `INSERT INTO mysql.user VALUES
-		("%", "root", "", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y")`)
+	// Insert a default user, it has an empty password unless the store is initialized in the secure mode.
+	host, password, err := rootUser()
+	if err != nil {
+		log.Fatal(errors.ErrorStack(err))
+	}
+	mustExecute(s, fmt.Sprintf(`INSERT INTO mysql.user VALUES
+		("%s", "root", "%s", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", 0, 0, 0, 0, "")`,
+		host, password))
 
 	// Init global system variables table.
 	values := make([]string, 0, len(variable.SysVars))
 	for k, v := range variable.SysVars {
-		// Session only variable should not be inserted.
-		if v.Scope != variable.ScopeSession {
+		// Session only and instance only variables should not be inserted.
+		if v.Scope != variable.ScopeSession && v.Scope != variable.ScopeInstance {
 			value := fmt.Sprintf(`("%s", "%s")`, strings.ToLower(k), v.Value)
 			values = append(values, value)
 		}
@@ -638,7 +875,14 @@ func doDMLWorks(s Session) {
 		mysql.SystemDB, mysql.TiDBTable, tidbServerVersionVar, currentBootstrapVersion)
 	mustExecute(s, sql)
 
-	_, err := s.Execute("COMMIT")
+	// Record how and when the store is initialized.
+	sql = fmt.Sprintf(`INSERT INTO %s.%s VALUES("%s", "%s", "The mode the store is initialized in."),
+		("%s", "%s", "The time the store is bootstrapped."), ("%s", "%s", "The version of the server which bootstraps the store.")`,
+		mysql.SystemDB, mysql.TiDBTable, bootstrapModeVar, BootstrapMode,
+		bootstrapTimeVar, time.Now().Format(types.TimeFormat), bootstrapServerVar, mysql.ServerVersion)
+	mustExecute(s, sql)
+
+	_, err = s.Execute("COMMIT")
 	if err != nil {
 		time.Sleep(1 * time.Second)
 		// Check if TiDB is already bootstrapped.
@@ -49,7 +49,7 @@ func (s *testBootstrapSuite) TestBootstrap(c *C) {
 	row, err := r.Next()
 	c.Assert(err, IsNil)
 	c.Assert(row, NotNil)
-	match(c, row.Data, []byte("%"), []byte("root"), []byte(""), "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y")
+	match(c, row.Data, []byte("%"), []byte("root"), []byte(""), "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", 0, 0, 0, 0, []byte(""))
 
 	c.Assert(se.Auth("root@anyhost", []byte(""), []byte("")), IsTrue)
 	mustExecSQL(c, se, "USE test;")
@@ -99,7 +99,7 @@ func (s *testBootstrapSuite) TestBootstrap(c *C) {
 func globalVarsCount() int64 {
 	var count int64
 	for _, v := range variable.SysVars {
-		if v.Scope != variable.ScopeSession {
+		if v.Scope != variable.ScopeSession && v.Scope != variable.ScopeInstance {
 			count++
 		}
 	}
@@ -233,3 +233,33 @@ func (s *testBootstrapSuite) TestOldPasswordUpgrade(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(newpwd, Equals, "*0D3CED9BEC10A777AEC23CCC353A8C08A633045E")
 }
+
+func (s *testBootstrapSuite) TestBootstrapSecure(c *C) {
+	defer testleak.AfterTest(c)()
+	defer func(mode InitializeMode, pwd string) {
+		BootstrapMode, BootstrapRootPassword = mode, pwd
+	}(BootstrapMode, BootstrapRootPassword)
+	BootstrapMode, BootstrapRootPassword = InitializeSecure, "123"
+
+	store := newStoreWithBootstrap(c, "test_bootstrap_secure")
+	defer store.Close()
+	se := newSession(c, store, s.dbName)
+	r := mustExecSQL(c, se, `select host, password from mysql.user where user = "root"`)
+	row, err := r.Next()
+	c.Assert(err, IsNil)
+	c.Assert(row, NotNil)
+	match(c, row.Data, []byte("127.0.0.1"), []byte(util.EncodePassword("123")))
+	c.Assert(se.Auth("root@anyhost", []byte(""), []byte("")), IsFalse)
+
+	r = mustExecSQL(c, se, `select variable_value from mysql.tidb where variable_name = "bootstrap_mode"`)
+	row, err = r.Next()
+	c.Assert(err, IsNil)
+	c.Assert(row, NotNil)
+	match(c, row.Data, []byte(InitializeSecure))
+
+	// The host of the root user can't be any host in the secure mode.
+	BootstrapRootHost = "%"
+	defer func() { BootstrapRootHost = "127.0.0.1" }()
+	_, _, err = rootUser()
+	c.Assert(err, NotNil)
+}
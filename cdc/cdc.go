@@ -0,0 +1,347 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cdc publishes the row changes of the committed transactions, it's built on the binlog.
+//
+// A Sink is used as the binlog client of the sessions, it keeps the prewrite binlog of a transaction
+// until the commit binlog arrives, then decodes the mutations into the row change events with the old
+// and the new values, and sends them to a Producer in the order of the transaction. The events of a
+// table share the same key, so a producer like Kafka which partitions the messages by key keeps the
+// events of a table in order.
+package cdc
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/ngaut/log"
+	"github.com/pingcap/tidb/infoschema"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/tablecodec"
+	"github.com/pingcap/tidb/util/codec"
+	"github.com/pingcap/tidb/util/types"
+	"github.com/pingcap/tipb/go-binlog"
+	goctx "golang.org/x/net/context"
+	grpc "google.golang.org/grpc"
+)
+
+// EventType is the type of a row change.
+type EventType string
+
+// The row change types.
+const (
+	EventInsert EventType = "insert"
+	EventUpdate EventType = "update"
+	EventDelete EventType = "delete"
+)
+
+// Event is a row change of a committed transaction.
+type Event struct {
+	Type   EventType `json:"type"`
+	Schema string    `json:"schema"`
+	Table  string    `json:"table"`
+	// CommitTS is the commit ts of the transaction.
+	CommitTS int64 `json:"commit_ts"`
+	// SchemaVersion is the schema version the transaction is executed with.
+	SchemaVersion int64 `json:"schema_version"`
+	// Old is the row before an update or a delete, New is the row after an insert or an update.
+	// They are keyed by the column names, the timestamps are in UTC.
+	Old map[string]interface{} `json:"old,omitempty"`
+	New map[string]interface{} `json:"new,omitempty"`
+}
+
+// Producer sends the messages to a topic, the messages with the same key must be kept in order.
+type Producer interface {
+	// Send sends a message, it's called concurrently by the transactions.
+	Send(topic string, key, value []byte) error
+	// Close flushes the sent messages and closes the producer.
+	Close() error
+}
+
+// Sink is a binlog.PumpClient that publishes the row changes to a Producer, the binlogs are written
+// to the next client too if it's set, e.g. the client of Pump.
+type Sink struct {
+	next     binlog.PumpClient
+	producer Producer
+	topic    string
+	// is returns the latest information schema, the columns of the changed tables are found in it.
+	is func() infoschema.InfoSchema
+
+	mu sync.Mutex
+	// prewrites are the prewrite values of the transactions not finished yet, keyed by the start ts.
+	prewrites map[int64][]byte
+}
+
+// NewSink creates a Sink that publishes the row change events to topic of producer.
+func NewSink(next binlog.PumpClient, producer Producer, topic string, is func() infoschema.InfoSchema) *Sink {
+	return &Sink{
+		next:      next,
+		producer:  producer,
+		topic:     topic,
+		is:        is,
+		prewrites: make(map[int64][]byte),
+	}
+}
+
+// WriteBinlog implements binlog.PumpClient interface.
+// The events of a transaction are published before its commit binlog is written to the next client,
+// an error is returned if they fail to be published, so the commit binlog is retried by the caller.
+func (s *Sink) WriteBinlog(ctx goctx.Context, in *binlog.WriteBinlogReq, opts ...grpc.CallOption) (*binlog.WriteBinlogResp, error) {
+	bin := &binlog.Binlog{}
+	if err := bin.Unmarshal(in.Payload); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if err := s.handleBinlog(bin); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if s.next == nil {
+		return &binlog.WriteBinlogResp{}, nil
+	}
+	return s.next.WriteBinlog(ctx, in, opts...)
+}
+
+// PullBinlogs implements binlog.PumpClient interface.
+func (s *Sink) PullBinlogs(ctx goctx.Context, in *binlog.PullBinlogReq, opts ...grpc.CallOption) (binlog.Pump_PullBinlogsClient, error) {
+	if s.next == nil {
+		return nil, errors.New("pulling binlogs from a CDC sink is not supported")
+	}
+	return s.next.PullBinlogs(ctx, in, opts...)
+}
+
+func (s *Sink) handleBinlog(bin *binlog.Binlog) error {
+	// The binlogs of DDL jobs have no row changes.
+	if bin.DdlJobId != 0 {
+		return nil
+	}
+	switch bin.Tp {
+	case binlog.BinlogType_Prewrite:
+		s.mu.Lock()
+		s.prewrites[bin.StartTs] = bin.PrewriteValue
+		s.mu.Unlock()
+	case binlog.BinlogType_Commit:
+		s.mu.Lock()
+		value, ok := s.prewrites[bin.StartTs]
+		s.mu.Unlock()
+		if !ok {
+			// The events are published already, it's a retry.
+			return nil
+		}
+		if err := s.publish(value, bin.CommitTs); err != nil {
+			return errors.Trace(err)
+		}
+		s.mu.Lock()
+		delete(s.prewrites, bin.StartTs)
+		s.mu.Unlock()
+	case binlog.BinlogType_Rollback:
+		s.mu.Lock()
+		delete(s.prewrites, bin.StartTs)
+		s.mu.Unlock()
+	}
+	return nil
+}
+
+func (s *Sink) publish(value []byte, commitTS int64) error {
+	events, err := DecodeEvents(s.is(), value, commitTS)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	for _, event := range events {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		key := fmt.Sprintf("%s.%s", event.Schema, event.Table)
+		if err = s.producer.Send(s.topic, []byte(key), data); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// DecodeEvents decodes the mutations in the prewrite value of a binlog into the row change events.
+// The changes of the tables not found in is, e.g. the dropped tables, are skipped.
+func DecodeEvents(is infoschema.InfoSchema, prewriteValue []byte, commitTS int64) ([]*Event, error) {
+	pv := &binlog.PrewriteValue{}
+	if err := pv.Unmarshal(prewriteValue); err != nil {
+		return nil, errors.Trace(err)
+	}
+	var events []*Event
+	for _, mutation := range pv.Mutations {
+		schema, tblInfo, ok := findTable(is, mutation.TableId)
+		if !ok {
+			log.Warnf("[cdc] skip the changes of table %d, it's not found", mutation.TableId)
+			continue
+		}
+		var inserted, updated, deleted int
+		for _, tp := range mutation.Sequence {
+			event := &Event{
+				Schema:        schema.Name.O,
+				Table:         tblInfo.Name.O,
+				CommitTS:      commitTS,
+				SchemaVersion: pv.SchemaVersion,
+			}
+			var err error
+			switch tp {
+			case binlog.MutationType_Insert:
+				if inserted >= len(mutation.InsertedRows) {
+					return nil, errors.Errorf("table %d has not enough inserted rows", mutation.TableId)
+				}
+				event.Type = EventInsert
+				event.New, err = decodeInsertedRow(tblInfo, mutation.InsertedRows[inserted])
+				inserted++
+			case binlog.MutationType_Update:
+				if updated >= len(mutation.UpdatedRows) {
+					return nil, errors.Errorf("table %d has not enough updated rows", mutation.TableId)
+				}
+				event.Type = EventUpdate
+				event.Old, event.New, err = decodeUpdatedRow(tblInfo, mutation.UpdatedRows[updated])
+				updated++
+			case binlog.MutationType_DeleteRow:
+				if deleted >= len(mutation.DeletedRows) {
+					return nil, errors.Errorf("table %d has not enough deleted rows", mutation.TableId)
+				}
+				event.Type = EventDelete
+				event.Old, err = decodeRow(tblInfo, mutation.DeletedRows[deleted], false)
+				deleted++
+			default:
+				// The obsolete mutations which delete the rows by the IDs or the primary keys aren't written.
+				return nil, errors.Errorf("unsupported mutation type %s", tp)
+			}
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func findTable(is infoschema.InfoSchema, tableID int64) (*model.DBInfo, *model.TableInfo, bool) {
+	for _, schema := range is.AllSchemas() {
+		for _, tblInfo := range schema.Tables {
+			if tblInfo.ID == tableID {
+				return schema, tblInfo, true
+			}
+		}
+	}
+	return nil, nil, false
+}
+
+// decodeInsertedRow decodes an inserted row, which is the encoded handle followed by the encoded row.
+// The row skips the columns of the handle and the columns with null values.
+func decodeInsertedRow(tblInfo *model.TableInfo, data []byte) (map[string]interface{}, error) {
+	data, handle, err := codec.DecodeOne(data)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	row, err := decodeRow(tblInfo, data, true)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if tblInfo.PKIsHandle {
+		for _, col := range tblInfo.Columns {
+			if !mysql.HasPriKeyFlag(col.Flag) {
+				continue
+			}
+			if mysql.HasUnsignedFlag(col.Flag) {
+				row[col.Name.O] = uint64(handle.GetInt64())
+			} else {
+				row[col.Name.O] = handle.GetInt64()
+			}
+		}
+	}
+	return row, nil
+}
+
+// decodeUpdatedRow decodes an updated row, which is the encoded old row followed by the encoded new
+// row, both of them have the same columns.
+func decodeUpdatedRow(tblInfo *model.TableInfo, data []byte) (oldRow, newRow map[string]interface{}, err error) {
+	var cnt int
+	for b := data; len(b) > 0; cnt++ {
+		if _, b, err = codec.CutOne(b); err != nil {
+			return nil, nil, errors.Trace(err)
+		}
+	}
+	if cnt%2 != 0 {
+		return nil, nil, errors.Errorf("table %d has an invalid updated row", tblInfo.ID)
+	}
+	b := data
+	for i := 0; i < cnt/2; i++ {
+		if _, b, err = codec.CutOne(b); err != nil {
+			return nil, nil, errors.Trace(err)
+		}
+	}
+	oldRow, err = decodeRow(tblInfo, data[:len(data)-len(b)], false)
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	newRow, err = decodeRow(tblInfo, b, false)
+	return oldRow, newRow, errors.Trace(err)
+}
+
+// decodeRow decodes the encoded row into the values keyed by the column names, the columns not in the
+// row are set to null if fillNull is true. The virtual generated columns and the hidden columns are
+// never in the result.
+func decodeRow(tblInfo *model.TableInfo, data []byte, fillNull bool) (map[string]interface{}, error) {
+	fts := make(map[int64]*types.FieldType, len(tblInfo.Columns))
+	for _, col := range tblInfo.Columns {
+		fts[col.ID] = &col.FieldType
+	}
+	// The timestamps are encoded in UTC.
+	datums, err := tablecodec.DecodeRow(data, fts, time.UTC)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	row := make(map[string]interface{}, len(tblInfo.Columns))
+	for _, col := range tblInfo.Columns {
+		if col.Hidden || (len(col.GeneratedExprString) != 0 && !col.GeneratedStored) {
+			continue
+		}
+		d, ok := datums[col.ID]
+		if !ok {
+			if fillNull {
+				row[col.Name.O] = nil
+			}
+			continue
+		}
+		row[col.Name.O], err = datumValue(d)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	return row, nil
+}
+
+// datumValue returns the JSON value of a datum, the numbers are kept as numbers and the others are
+// converted to strings.
+func datumValue(d types.Datum) (interface{}, error) {
+	switch d.Kind() {
+	case types.KindNull:
+		return nil, nil
+	case types.KindInt64:
+		return d.GetInt64(), nil
+	case types.KindUint64:
+		return d.GetUint64(), nil
+	case types.KindFloat32:
+		return d.GetFloat32(), nil
+	case types.KindFloat64:
+		return d.GetFloat64(), nil
+	default:
+		s, err := d.ToString()
+		return s, errors.Trace(err)
+	}
+}
@@ -0,0 +1,250 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdc_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb"
+	"github.com/pingcap/tidb/cdc"
+	"github.com/pingcap/tidb/domain"
+	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/store/tikv"
+	"github.com/pingcap/tidb/util/testkit"
+	"github.com/pingcap/tipb/go-binlog"
+	goctx "golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+func TestT(t *testing.T) {
+	CustomVerboseFlag = true
+	TestingT(t)
+}
+
+var _ = Suite(&testCDCSuite{})
+
+type testCDCSuite struct {
+	store kv.Storage
+	dom   *domain.Domain
+}
+
+func (s *testCDCSuite) SetUpSuite(c *C) {
+	store, err := tikv.NewMockTikvStore()
+	c.Assert(err, IsNil)
+	s.store = store
+	tidb.SetSchemaLease(0)
+	s.dom, err = tidb.BootstrapSession(store)
+	c.Assert(err, IsNil)
+}
+
+func (s *testCDCSuite) TearDownSuite(c *C) {
+	s.dom.Close()
+	s.store.Close()
+}
+
+type message struct {
+	topic string
+	key   string
+	event *cdc.Event
+}
+
+type mockProducer struct {
+	mu       sync.Mutex
+	messages []message
+	err      error
+}
+
+func (p *mockProducer) Send(topic string, key, value []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return p.err
+	}
+	event := &cdc.Event{}
+	if err := json.Unmarshal(value, event); err != nil {
+		return err
+	}
+	p.messages = append(p.messages, message{topic: topic, key: string(key), event: event})
+	return nil
+}
+
+func (p *mockProducer) Close() error {
+	return nil
+}
+
+// waitMessages waits for the messages published by the commit binlogs, which are written asynchronously.
+func (p *mockProducer) waitMessages(c *C, cnt int) []message {
+	for i := 0; i < 100; i++ {
+		p.mu.Lock()
+		messages := p.messages
+		p.mu.Unlock()
+		if len(messages) >= cnt {
+			c.Assert(messages, HasLen, cnt)
+			p.mu.Lock()
+			p.messages = nil
+			p.mu.Unlock()
+			return messages
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	c.Fatalf("timeout waiting for %d messages", cnt)
+	return nil
+}
+
+type mockPumpClient struct {
+	mu  sync.Mutex
+	tps []binlog.BinlogType
+}
+
+func (p *mockPumpClient) WriteBinlog(ctx goctx.Context, in *binlog.WriteBinlogReq, opts ...grpc.CallOption) (*binlog.WriteBinlogResp, error) {
+	bin := &binlog.Binlog{}
+	if err := bin.Unmarshal(in.Payload); err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	p.tps = append(p.tps, bin.Tp)
+	p.mu.Unlock()
+	return &binlog.WriteBinlogResp{}, nil
+}
+
+func (p *mockPumpClient) PullBinlogs(ctx goctx.Context, in *binlog.PullBinlogReq, opts ...grpc.CallOption) (binlog.Pump_PullBinlogsClient, error) {
+	return nil, nil
+}
+
+func (s *testCDCSuite) TestSink(c *C) {
+	producer := &mockProducer{}
+	pump := &mockPumpClient{}
+	sink := cdc.NewSink(pump, producer, "tidb_cdc", s.dom.InfoSchema)
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("create table cdc_t (id int unsigned primary key, name varchar(10), price decimal(5,2), ts timestamp null, v int as (id + 1))")
+	tk.MustExec("create table cdc_t2 (a int)")
+	tk.Se.GetSessionVars().BinlogClient = sink
+
+	tk.MustExec("insert cdc_t (id, name, price) values (1, 'abc', 1.5), (2, null, 2.5)")
+	messages := producer.waitMessages(c, 2)
+	c.Assert(messages[0].topic, Equals, "tidb_cdc")
+	c.Assert(messages[0].key, Equals, "test.cdc_t")
+	event := messages[0].event
+	c.Assert(event.Type, Equals, cdc.EventInsert)
+	c.Assert(event.Schema, Equals, "test")
+	c.Assert(event.Table, Equals, "cdc_t")
+	c.Assert(event.CommitTS, Greater, int64(0))
+	c.Assert(event.SchemaVersion, Equals, s.dom.InfoSchema().SchemaMetaVersion())
+	c.Assert(event.Old, IsNil)
+	c.Assert(event.New, DeepEquals, map[string]interface{}{"id": float64(1), "name": "abc", "price": "1.50", "ts": nil})
+	c.Assert(messages[1].event.New, DeepEquals, map[string]interface{}{"id": float64(2), "name": nil, "price": "2.50", "ts": nil})
+
+	// The changes of a transaction are published in order, with the old and the new rows.
+	tk.MustExec("begin")
+	tk.MustExec("update cdc_t set name = 'x', ts = '2017-01-02 03:04:05' where id = 2")
+	tk.MustExec("delete from cdc_t where id = 1")
+	tk.MustExec("insert cdc_t2 values (3)")
+	tk.MustExec("commit")
+	messages = producer.waitMessages(c, 3)
+	event = messages[0].event
+	c.Assert(event.Type, Equals, cdc.EventUpdate)
+	c.Assert(event.Old, DeepEquals, map[string]interface{}{"id": float64(2), "name": nil, "price": "2.50", "ts": nil})
+	c.Assert(event.New, DeepEquals, map[string]interface{}{"id": float64(2), "name": "x", "price": "2.50", "ts": "2017-01-02 03:04:05"})
+	event = messages[1].event
+	c.Assert(event.Type, Equals, cdc.EventDelete)
+	c.Assert(event.Old, DeepEquals, map[string]interface{}{"id": float64(1), "name": "abc", "price": "1.50", "ts": nil})
+	c.Assert(event.New, IsNil)
+	c.Assert(messages[2].key, Equals, "test.cdc_t2")
+	c.Assert(messages[2].event.New, DeepEquals, map[string]interface{}{"a": float64(3)})
+	c.Assert(messages[2].event.CommitTS, Equals, messages[0].event.CommitTS)
+
+	// The binlogs are written to the next client after the events are published.
+	pump.mu.Lock()
+	c.Assert(len(pump.tps), Greater, 0)
+	c.Assert(pump.tps[len(pump.tps)-1], Equals, binlog.BinlogType_Commit)
+	pump.mu.Unlock()
+
+	// The commit binlog is retried if the events fail to be published.
+	producer.mu.Lock()
+	producer.err = kv.ErrNotExist
+	producer.mu.Unlock()
+	tk.MustExec("insert cdc_t2 values (4)")
+	time.Sleep(50 * time.Millisecond)
+	producer.mu.Lock()
+	producer.err = nil
+	producer.mu.Unlock()
+	messages = producer.waitMessages(c, 1)
+	c.Assert(messages[0].event.New, DeepEquals, map[string]interface{}{"a": float64(4)})
+
+	// The rolled back transactions are not published.
+	tk.MustExec("begin")
+	tk.MustExec("insert cdc_t2 values (5)")
+	tk.MustExec("rollback")
+	tk.MustExec("insert cdc_t2 values (6)")
+	messages = producer.waitMessages(c, 1)
+	c.Assert(messages[0].event.New, DeepEquals, map[string]interface{}{"a": float64(6)})
+
+	tk.Se.GetSessionVars().BinlogClient = nil
+	tk.MustExec("drop table cdc_t, cdc_t2")
+}
+
+func (s *testCDCSuite) TestFileProducer(c *C) {
+	dir, err := ioutil.TempDir("", "cdc")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "cdc.log")
+
+	producer, err := cdc.NewFileProducer(path)
+	c.Assert(err, IsNil)
+	sink := cdc.NewSink(nil, producer, "tidb_cdc", s.dom.InfoSchema)
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("create table cdc_file (a int primary key, b varchar(10))")
+	tk.Se.GetSessionVars().BinlogClient = sink
+	tk.MustExec("insert cdc_file values (1, 'a')")
+	tk.MustExec("update cdc_file set b = 'b'")
+
+	var events []*cdc.Event
+	for i := 0; i < 100 && len(events) < 2; i++ {
+		time.Sleep(10 * time.Millisecond)
+		file, err := os.Open(path)
+		c.Assert(err, IsNil)
+		events = events[:0]
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			msg := &cdc.FileMessage{}
+			c.Assert(json.Unmarshal(scanner.Bytes(), msg), IsNil)
+			c.Assert(msg.Topic, Equals, "tidb_cdc")
+			c.Assert(msg.Key, Equals, "test.cdc_file")
+			event := &cdc.Event{}
+			c.Assert(json.Unmarshal(msg.Value, event), IsNil)
+			events = append(events, event)
+		}
+		file.Close()
+	}
+	c.Assert(events, HasLen, 2)
+	c.Assert(events[0].Type, Equals, cdc.EventInsert)
+	c.Assert(events[0].New, DeepEquals, map[string]interface{}{"a": float64(1), "b": "a"})
+	c.Assert(events[1].Type, Equals, cdc.EventUpdate)
+	c.Assert(events[1].Old, DeepEquals, map[string]interface{}{"a": float64(1), "b": "a"})
+	c.Assert(events[1].New, DeepEquals, map[string]interface{}{"a": float64(1), "b": "b"})
+	c.Assert(producer.Close(), IsNil)
+
+	tk.Se.GetSessionVars().BinlogClient = nil
+	tk.MustExec("drop table cdc_file")
+}
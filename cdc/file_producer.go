@@ -0,0 +1,67 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdc
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/juju/errors"
+)
+
+// FileProducer is a Producer that appends the messages to a local file, a message is a JSON object
+// of its topic, key and value in a line, the value is the JSON of the event.
+type FileProducer struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// FileMessage is a message in the file written by FileProducer.
+type FileMessage struct {
+	Topic string          `json:"topic"`
+	Key   string          `json:"key"`
+	Value json.RawMessage `json:"value"`
+}
+
+// NewFileProducer creates a producer that writes the messages to the file at path, the new messages
+// are appended if the file exists.
+func NewFileProducer(path string) (*FileProducer, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &FileProducer{file: file}, nil
+}
+
+// Send implements Producer interface.
+func (p *FileProducer) Send(topic string, key, value []byte) error {
+	line, err := json.Marshal(&FileMessage{Topic: topic, Key: string(key), Value: value})
+	if err != nil {
+		return errors.Trace(err)
+	}
+	line = append(line, '\n')
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, err = p.file.Write(line)
+	return errors.Trace(err)
+}
+
+// Close implements Producer interface.
+func (p *FileProducer) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return errors.Trace(p.file.Close())
+}
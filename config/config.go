@@ -17,17 +17,38 @@ import "sync"
 
 // Config contains configuration options.
 type Config struct {
-	Addr           string `json:"addr" toml:"addr"`
-	LogLevel       string `json:"log_level" toml:"log_level"`
-	SkipAuth       bool   `json:"skip_auth" toml:"skip_auth"`
-	StatusAddr     string `json:"status_addr" toml:"status_addr"`
-	Socket         string `json:"socket" toml:"socket"`
-	ReportStatus   bool   `json:"report_status" toml:"report_status"`
-	StorePath      string `json:"store_path" toml:"store_path"`
-	Store          string `json:"store" toml:"store"`
-	SlowThreshold  int    `json:"slow_threshold" toml:"slow_threshold"`
-	QueryLogMaxlen int    `json:"query_log_max_len" toml:"query_log_max_len"`
-	TCPKeepAlive   bool   `json:"tcp_keep_alive" toml:"tcp_keep_alive"`
+	Addr             string `json:"addr" toml:"addr"`
+	AdvertiseAddr    string `json:"advertise_addr" toml:"advertise_addr"`
+	LogLevel         string `json:"log_level" toml:"log_level"`
+	SkipAuth         bool   `json:"skip_auth" toml:"skip_auth"`
+	StatusAddr       string `json:"status_addr" toml:"status_addr"`
+	MetricsPath      string `json:"metrics_path" toml:"metrics_path"`
+	EnablePprof      bool   `json:"enable_pprof" toml:"enable_pprof"`
+	Socket           string `json:"socket" toml:"socket"`
+	ReportStatus     bool   `json:"report_status" toml:"report_status"`
+	StorePath        string `json:"store_path" toml:"store_path"`
+	Store            string `json:"store" toml:"store"`
+	SlowThreshold    int    `json:"slow_threshold" toml:"slow_threshold"`
+	QueryLogMaxlen   int    `json:"query_log_max_len" toml:"query_log_max_len"`
+	TCPKeepAlive     bool   `json:"tcp_keep_alive" toml:"tcp_keep_alive"`
+	DrainTimeout     int    `json:"drain_timeout" toml:"drain_timeout"`
+	EnableTableLock  bool   `json:"enable_table_lock" toml:"enable_table_lock"`
+	MetadataLockWait int    `json:"metadata_lock_wait" toml:"metadata_lock_wait"`
+	ConnectionWait   int    `json:"connection_wait" toml:"connection_wait"`
+	// ProxyProtocolNetworks are the comma separated networks of the proxies which send the PROXY protocol header.
+	ProxyProtocolNetworks string `json:"proxy_protocol_networks" toml:"proxy_protocol_networks"`
+	// ResourceGroups are the resource groups with their max concurrent statements, like "etl=4,report=8".
+	ResourceGroups string `json:"resource_groups" toml:"resource_groups"`
+	// ResultCacheSize is the max bytes of the query results cached on the server, 0 disables the result cache.
+	ResultCacheSize int64 `json:"result_cache_size" toml:"result_cache_size"`
+	// ResultCacheTTL is the max seconds a cached query result is used for.
+	ResultCacheTTL int `json:"result_cache_ttl" toml:"result_cache_ttl"`
+	// DumpDir is the directory the files of ADMIN DUMP DATABASE are written under, empty disables the dump.
+	DumpDir string `json:"dump_dir" toml:"dump_dir"`
+	// CDCFile is the file the row change events are appended to, empty disables the CDC sink.
+	CDCFile string `json:"cdc_file" toml:"cdc_file"`
+	// CDCTopic is the topic of the row change events.
+	CDCTopic string `json:"cdc_topic" toml:"cdc_topic"`
 }
 
 var cfg *Config
@@ -41,6 +62,7 @@ func GetGlobalConfig() *Config {
 		cfg = &Config{
 			SlowThreshold:  300,
 			QueryLogMaxlen: 2048,
+			ResultCacheTTL: 60,
 		}
 	})
 	return cfg
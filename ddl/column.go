@@ -298,6 +298,7 @@ func (d *ddl) addTableColumn(t table.Table, columnInfo *model.ColumnInfo, reorgI
 
 		d.setReorgRowCount(count)
 		batchHandleDataHistogram.WithLabelValues(batchAddCol).Observe(sub)
+		reorgRowsCounter.WithLabelValues(batchAddCol).Add(float64(len(handles)))
 		log.Infof("[ddl] added column for %v rows, take time %v", count, sub)
 	}
 }
@@ -911,6 +911,11 @@ func (s *testColumnSuite) TestModifyColumn(c *C) {
 		{"varchar(10)", "varchar(8)", errUnsupportedModifyColumn.GenByArgs("length 8 is less than origin 10")},
 		{"varchar(10)", "varchar(11)", nil},
 		{"varchar(10) character set utf8 collate utf8_bin", "varchar(10) character set utf8", nil},
+		{"enum('a', 'b')", "enum('a', 'b', 'c')", nil},
+		{"enum('a', 'b')", "enum('a')", errUnsupportedModifyColumn.GenByArgs("the number of enum column's elements is less than the original: 2")},
+		{"enum('a', 'b')", "enum('b', 'a', 'c')", errUnsupportedModifyColumn.GenByArgs("cannot modify enum column value a to b")},
+		{"set('a', 'b')", "set('a', 'b', 'c')", nil},
+		{"set('a', 'b')", "enum('a', 'b', 'c')", errUnsupportedModifyColumn.GenByArgs("type 247 not match origin 248")},
 	}
 	for _, tt := range tests {
 		ftA := s.colDefStrToFieldType(c, tt.origin)
@@ -58,6 +58,10 @@ var (
 	errWaitReorgTimeout      = terror.ClassDDL.New(codeWaitReorgTimeout, "wait for reorganization timeout")
 	errInvalidStoreVer       = terror.ClassDDL.New(codeInvalidStoreVer, "invalid storage current version")
 
+	// ErrClusterDDLVersion returns for a DDL job which needs a higher DDL version than some servers in the cluster.
+	ErrClusterDDLVersion = terror.ClassDDL.New(codeClusterDDLVersion,
+		"DDL job '%s' needs DDL version %d, but some servers in the cluster are at DDL version %d, upgrade them first")
+
 	// We don't support dropping column with index covered now.
 	errCantDropColWithIndex    = terror.ClassDDL.New(codeCantDropColWithIndex, "can't drop column with index")
 	errUnsupportedAddColumn    = terror.ClassDDL.New(codeUnsupportedAddColumn, "unsupported add column")
@@ -107,6 +111,12 @@ var (
 	// ErrUnsupportedModifyPrimaryKey returns an error when add or drop the primary key.
 	// It's exported for testing.
 	ErrUnsupportedModifyPrimaryKey = terror.ClassDDL.New(codeUnsupportedModifyPrimaryKey, "unsupported %s primary key")
+	// ErrUnsupportedClusteredPrimaryKey returns for a clustered primary key which isn't on a single integer column.
+	ErrUnsupportedClusteredPrimaryKey = terror.ClassDDL.New(codeClusteredPrimaryKey,
+		"unsupported clustered primary key, only a single integer column can be the clustered primary key")
+	// ErrUnsupportedClusteredSecondaryKey returns for CLUSTERED or NONCLUSTERED on an index which isn't the primary key.
+	ErrUnsupportedClusteredSecondaryKey = terror.ClassDDL.New(codeClusteredSecondaryKey,
+		"CLUSTERED and NONCLUSTERED keyword is only supported for primary key")
 
 	// ErrColumnBadNull returns for a bad null value.
 	ErrColumnBadNull = terror.ClassDDL.New(codeBadNull, "column cann't be null")
@@ -114,6 +124,17 @@ var (
 	ErrCantRemoveAllFields = terror.ClassDDL.New(codeCantRemoveAllFields, "can't delete all columns with ALTER TABLE")
 	// ErrCantDropFieldOrKey returns for dropping a non-existent field or key.
 	ErrCantDropFieldOrKey = terror.ClassDDL.New(codeCantDropFieldOrKey, "can't drop field; check that column/key exists")
+	// ErrPKIndexCantBeInvisible returns for making the primary key invisible.
+	ErrPKIndexCantBeInvisible = terror.ClassDDL.New(codePKIndexCantBeInvisible, mysql.MySQLErrName[mysql.ErrPKIndexCantBeInvisible])
+	// ErrFunctionalIndexOnField returns for an expression index key which is a column.
+	ErrFunctionalIndexOnField = terror.ClassDDL.New(codeFunctionalIndexOnField, mysql.MySQLErrName[mysql.ErrFunctionalIndexOnField])
+	// ErrFunctionalIndexPrimaryKey returns for a primary key with an expression key.
+	ErrFunctionalIndexPrimaryKey = terror.ClassDDL.New(codeFunctionalIndexPrimaryKey, mysql.MySQLErrName[mysql.ErrFunctionalIndexPrimaryKey])
+	// ErrFunctionalIndexFunctionIsNotAllowed returns for an expression index key with a function which isn't allowed.
+	ErrFunctionalIndexFunctionIsNotAllowed = terror.ClassDDL.New(codeFunctionalIndexNotAllowed,
+		mysql.MySQLErrName[mysql.ErrFunctionalIndexFunctionIsNotAllowed])
+	// ErrKeyDoesNotExist returns for renaming a non-existent key.
+	ErrKeyDoesNotExist = terror.ClassDDL.New(codeKeyDoesNotExist, mysql.MySQLErrName[mysql.ErrKeyDoesNotExits])
 	// ErrInvalidOnUpdate returns for invalid ON UPDATE clause.
 	ErrInvalidOnUpdate = terror.ClassDDL.New(codeInvalidOnUpdate, "invalid ON UPDATE clause for the column")
 	// ErrTooLongIdent returns for too long name of database/table/column.
@@ -143,6 +164,15 @@ type DDL interface {
 	AlterTable(ctx context.Context, tableIdent ast.Ident, spec []*ast.AlterTableSpec) error
 	TruncateTable(ctx context.Context, tableIdent ast.Ident) error
 	RenameTable(ctx context.Context, oldTableIdent, newTableIdent ast.Ident) error
+	// RenameTables renames the tables in order in a single job, so the tables can swap their names.
+	RenameTables(ctx context.Context, oldTableIdents, newTableIdents []ast.Ident) error
+	// LockTables locks the tables for the session.
+	LockTables(ctx context.Context, locks []variable.TableLock) error
+	// UnlockTables releases the table locks held by the session.
+	UnlockTables(ctx context.Context, locks []variable.TableLock) error
+	// CleanupTableLock removes the locks of the tables held by all the sessions, e.g. the sessions of a
+	// crashed server.
+	CleanupTableLock(ctx context.Context, locks []variable.TableLock) error
 	// SetLease will reset the lease time for online DDL change,
 	// it's a very dangerous function and you must guarantee that all servers have the same lease time.
 	SetLease(ctx goctx.Context, lease time.Duration)
@@ -200,9 +230,10 @@ type ddl struct {
 	ownerManager OwnerManager
 	schemaSyncer SchemaSyncer
 	// lease is schema seconds.
-	lease        time.Duration
-	uuid         string
-	ddlJobCh     chan struct{}
+	lease time.Duration
+	uuid  string
+	// ddlJobChs notifies the workers of every type that there are jobs in their queues.
+	ddlJobChs    map[workerType]chan struct{}
 	ddlJobDoneCh chan struct{}
 	ddlEventCh   chan<- *Event
 
@@ -270,10 +301,10 @@ func newDDL(ctx goctx.Context, etcdCli *clientv3.Client, store kv.Storage,
 	if etcdCli == nil {
 		// The etcdCli is nil if the store is localstore which is only used for testing.
 		// So we use mockOwnerManager and mockSchemaSyncer.
-		manager = NewMockOwnerManager(id, cancelFunc)
+		manager = NewMockOwnerManager(id, DDLOwnerKey, cancelFunc)
 		syncer = NewMockSchemaSyncer()
 	} else {
-		manager = NewOwnerManager(etcdCli, id, cancelFunc)
+		manager = NewOwnerManager(etcdCli, id, DDLOwnerKey, cancelFunc)
 		syncer = NewSchemaSyncer(etcdCli, id)
 	}
 	d := &ddl{
@@ -282,13 +313,16 @@ func newDDL(ctx goctx.Context, etcdCli *clientv3.Client, store kv.Storage,
 		store:        store,
 		uuid:         id,
 		lease:        lease,
-		ddlJobCh:     make(chan struct{}, 1),
+		ddlJobChs:    make(map[workerType]chan struct{}),
 		ddlJobDoneCh: make(chan struct{}, 1),
 		ownerManager: manager,
 		schemaSyncer: syncer,
 		workerVars:   variable.NewSessionVars(),
 	}
 	d.workerVars.BinlogClient = binloginfo.GetPumpClient()
+	for _, tp := range workerTypes {
+		d.ddlJobChs[tp] = make(chan struct{}, 1)
+	}
 
 	if ctxPool != nil {
 		supportDelRange := store.SupportDeleteRange()
@@ -318,12 +352,14 @@ func (d *ddl) start(ctx goctx.Context) {
 	d.quitCh = make(chan struct{})
 	d.ownerManager.CampaignOwners(ctx)
 
-	d.wait.Add(1)
-	go d.onDDLWorker()
+	for _, tp := range workerTypes {
+		d.wait.Add(1)
+		go d.onDDLWorker(tp)
 
-	// For every start, we will send a fake job to let worker
-	// check owner firstly and try to find whether a job exists and run.
-	asyncNotify(d.ddlJobCh)
+		// For every start, we will send a fake job to let worker
+		// check owner firstly and try to find whether a job exists and run.
+		asyncNotify(d.ddlJobChs[tp])
+	}
 
 	d.delRangeManager.start()
 }
@@ -413,13 +449,13 @@ func (d *ddl) doDDLJob(ctx context.Context, job *model.Job) error {
 	}
 
 	// Get a global job ID and put the DDL job in the queue.
-	err := d.addDDLJob(ctx, job)
+	tp, err := d.addDDLJob(ctx, job)
 	if err != nil {
 		return errors.Trace(err)
 	}
 
 	// Notice worker that we push a new job and wait the job done.
-	asyncNotify(d.ddlJobCh)
+	asyncNotify(d.ddlJobChs[tp])
 	log.Infof("[ddl] start DDL job %s, Query:\n%s", job, job.Query)
 
 	var historyJob *model.Job
@@ -503,6 +539,7 @@ const (
 	codeUnknownTypeLength                    = 9
 	codeUnknownFractionLength                = 10
 	codeInvalidJobVersion                    = 11
+	codeClusterDDLVersion                    = 12
 
 	codeInvalidDBState         = 100
 	codeInvalidTableState      = 101
@@ -516,6 +553,8 @@ const (
 	codeUnsupportedDropPKHandle     = 204
 	codeUnsupportedCharset          = 205
 	codeUnsupportedModifyPrimaryKey = 206
+	codeClusteredPrimaryKey         = 207
+	codeClusteredSecondaryKey       = 208
 
 	codeFileNotFound                 = 1017
 	codeErrorOnRename                = 1025
@@ -535,12 +574,17 @@ const (
 	codeInvalidUseOfNull             = 1138
 	codeWrongColumnName              = 1166
 	codeWrongKeyColumn               = 1167
+	codeKeyDoesNotExist              = 1176
 	codeBlobKeyWithoutLength         = 1170
 	codeInvalidOnUpdate              = 1294
 	codeUnsupportedOnGeneratedColumn = 3106
 	codeGeneratedColumnNonPrior      = 3107
 	codeDependentByGeneratedColumn   = 3108
 	codeJSONUsedAsKey                = 3152
+	codePKIndexCantBeInvisible       = 3522
+	codeFunctionalIndexOnField       = 3756
+	codeFunctionalIndexPrimaryKey    = 3757
+	codeFunctionalIndexNotAllowed    = 3758
 	codeWrongNameForIndex            = terror.ErrCode(mysql.ErrWrongNameForIndex)
 )
 
@@ -567,9 +611,14 @@ func init() {
 		codeGeneratedColumnNonPrior:      mysql.ErrGeneratedColumnNonPrior,
 		codeDependentByGeneratedColumn:   mysql.ErrDependentByGeneratedColumn,
 		codeJSONUsedAsKey:                mysql.ErrJSONUsedAsKey,
+		codePKIndexCantBeInvisible:       mysql.ErrPKIndexCantBeInvisible,
+		codeFunctionalIndexOnField:       mysql.ErrFunctionalIndexOnField,
+		codeFunctionalIndexPrimaryKey:    mysql.ErrFunctionalIndexPrimaryKey,
+		codeFunctionalIndexNotAllowed:    mysql.ErrFunctionalIndexFunctionIsNotAllowed,
 		codeBlobCantHaveDefault:          mysql.ErrBlobCantHaveDefault,
 		codeWrongColumnName:              mysql.ErrWrongColumnName,
 		codeWrongKeyColumn:               mysql.ErrWrongKeyColumn,
+		codeKeyDoesNotExist:              mysql.ErrKeyDoesNotExits,
 		codeWrongNameForIndex:            mysql.ErrWrongNameForIndex,
 	}
 	terror.ErrClassToMySQLCodes[terror.ClassDDL] = ddlMySQLErrCodes
@@ -24,6 +24,7 @@ import (
 	"time"
 
 	"github.com/juju/errors"
+	"github.com/ngaut/log"
 	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/expression"
@@ -31,6 +32,7 @@ import (
 	"github.com/pingcap/tidb/meta/autoid"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/table"
 	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util/charset"
@@ -118,6 +120,9 @@ func setColumnFlagWithConstraint(colMap map[string]*table.Column, v *ast.Constra
 	switch v.Tp {
 	case ast.ConstraintPrimaryKey:
 		for _, key := range v.Keys {
+			if key.Column == nil {
+				continue
+			}
 			c, ok := colMap[key.Column.Name.L]
 			if !ok {
 				continue
@@ -128,6 +133,9 @@ func setColumnFlagWithConstraint(colMap map[string]*table.Column, v *ast.Constra
 		}
 	case ast.ConstraintUniq, ast.ConstraintUniqIndex, ast.ConstraintUniqKey:
 		for i, key := range v.Keys {
+			if key.Column == nil {
+				continue
+			}
 			c, ok := colMap[key.Column.Name.L]
 			if !ok {
 				continue
@@ -146,6 +154,9 @@ func setColumnFlagWithConstraint(colMap map[string]*table.Column, v *ast.Constra
 		}
 	case ast.ConstraintKey, ast.ConstraintIndex:
 		for i, key := range v.Keys {
+			if key.Column == nil {
+				continue
+			}
 			c, ok := colMap[key.Column.Name.L]
 			if !ok {
 				continue
@@ -280,6 +291,9 @@ func columnDefToCol(ctx context.Context, offset int, colDef *ast.ColumnDef) (*ta
 				col.Flag |= mysql.AutoIncrementFlag
 			case ast.ColumnOptionPrimaryKey:
 				constraint := &ast.Constraint{Tp: ast.ConstraintPrimaryKey, Keys: keys}
+				if v.PrimaryKeyTp != ast.PrimaryKeyTypeDefault {
+					constraint.Option = &ast.IndexOption{PrimaryKeyTp: v.PrimaryKeyTp}
+				}
 				constraints = append(constraints, constraint)
 				col.Flag |= mysql.PriKeyFlag
 			case ast.ColumnOptionUniqKey:
@@ -495,7 +509,10 @@ func checkDuplicateConstraint(namesMap map[string]bool, name string, foreign boo
 
 func setEmptyConstraintName(namesMap map[string]bool, constr *ast.Constraint, foreign bool) {
 	if constr.Name == "" && len(constr.Keys) > 0 {
-		colName := constr.Keys[0].Column.Name.L
+		colName := anonymousExpressionIndex
+		if constr.Keys[0].Column != nil {
+			colName = constr.Keys[0].Column.Name.L
+		}
 		constrName := colName
 		i := 2
 		if strings.EqualFold(constrName, mysql.PrimaryKeyName) {
@@ -547,7 +564,7 @@ func checkConstraintNames(constraints []*ast.Constraint) error {
 	return nil
 }
 
-func (d *ddl) buildTableInfo(tableName model.CIStr, cols []*table.Column, constraints []*ast.Constraint) (tbInfo *model.TableInfo, err error) {
+func (d *ddl) buildTableInfo(ctx context.Context, tableName model.CIStr, cols []*table.Column, constraints []*ast.Constraint) (tbInfo *model.TableInfo, err error) {
 	tbInfo = &model.TableInfo{
 		Name: tableName,
 	}
@@ -589,7 +606,13 @@ func (d *ddl) buildTableInfo(tableName model.CIStr, cols []*table.Column, constr
 			continue
 		}
 		if constr.Tp == ast.ConstraintPrimaryKey {
+			if constr.Option != nil && constr.Option.Visibility == ast.IndexVisibilityInvisible {
+				return nil, ErrPKIndexCantBeInvisible
+			}
 			for _, key := range constr.Keys {
+				if key.Expr != nil {
+					return nil, ErrFunctionalIndexPrimaryKey
+				}
 				col := table.FindCol(cols, key.Column.Name.O)
 				if col == nil {
 					return nil, errKeyColumnDoesNotExits.Gen("key column %s doesn't exist in table", key.Column.Name)
@@ -599,7 +622,12 @@ func (d *ddl) buildTableInfo(tableName model.CIStr, cols []*table.Column, constr
 					return nil, errUnsupportedOnGeneratedColumn.GenByArgs("Defining a virtual generated column as primary key")
 				}
 			}
-			if len(constr.Keys) == 1 {
+			pkTp := ast.PrimaryKeyTypeDefault
+			if constr.Option != nil {
+				pkTp = constr.Option.PrimaryKeyTp
+			}
+			// The handle is ascending, so a descending primary key is a unique index.
+			if len(constr.Keys) == 1 && !constr.Keys[0].Desc && pkTp != ast.PrimaryKeyTypeNonClustered {
 				key := constr.Keys[0]
 				col := table.FindCol(cols, key.Column.Name.O)
 				if col == nil {
@@ -613,9 +641,23 @@ func (d *ddl) buildTableInfo(tableName model.CIStr, cols []*table.Column, constr
 					continue
 				}
 			}
+			// The handle of the rows is an integer, so only a single integer column can cluster the rows.
+			// Clustering by the string or composite keys needs the handles to be encoded keys, but the
+			// row keys, the index values, the coprocessor responses, the binlog and the statistics
+			// all carry int64 handles, so it's refused instead of being emulated by a unique index.
+			if pkTp == ast.PrimaryKeyTypeClustered {
+				return nil, ErrUnsupportedClusteredPrimaryKey
+			}
+		} else if constr.Option != nil && constr.Option.PrimaryKeyTp != ast.PrimaryKeyTypeDefault {
+			return nil, ErrUnsupportedClusteredSecondaryKey
 		}
+		keys, hiddenCols, err := buildHiddenColumns(ctx, tbInfo, model.NewCIStr(constr.Name), constr.Keys)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		addHiddenColumns(tbInfo, hiddenCols)
 		// build index info.
-		idxInfo, err := buildIndexInfo(tbInfo, model.NewCIStr(constr.Name), constr.Keys, model.StatePublic)
+		idxInfo, err := buildIndexInfo(tbInfo, model.NewCIStr(constr.Name), keys, model.StatePublic)
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
@@ -631,6 +673,7 @@ func (d *ddl) buildTableInfo(tableName model.CIStr, cols []*table.Column, constr
 		// set index type.
 		if constr.Option != nil {
 			idxInfo.Comment = constr.Option.Comment
+			idxInfo.Invisible = constr.Option.Visibility == ast.IndexVisibilityInvisible
 			if constr.Option.Tp == model.IndexTypeInvalid {
 				// Use btree as default index type.
 				idxInfo.Tp = model.IndexTypeBtree
@@ -719,7 +762,7 @@ func (d *ddl) CreateTable(ctx context.Context, ident ast.Ident, colDefs []*ast.C
 		return errors.Trace(err)
 	}
 
-	tbInfo, err := d.buildTableInfo(ident.Name, cols, newConstraints)
+	tbInfo, err := d.buildTableInfo(ctx, ident.Name, cols, newConstraints)
 	if err != nil {
 		return errors.Trace(err)
 	}
@@ -751,7 +794,7 @@ func (d *ddl) handleAutoIncID(tbInfo *model.TableInfo, schemaID int64) error {
 	if tbInfo.OldSchemaID != 0 {
 		schemaID = tbInfo.OldSchemaID
 	}
-	alloc := autoid.NewAllocator(d.store, schemaID)
+	alloc := autoid.NewAllocatorWithCache(d.store, schemaID, tbInfo.AutoIDCache)
 
 	tbInfo.State = model.StatePublic
 	tb, err := table.TableFromMeta(alloc, tbInfo)
@@ -773,6 +816,8 @@ func handleTableOptions(options []*ast.TableOption, tbInfo *model.TableInfo) {
 		switch op.Tp {
 		case ast.TableOptionAutoIncrement:
 			tbInfo.AutoIncID = int64(op.UintValue)
+		case ast.TableOptionAutoIDCache:
+			tbInfo.AutoIDCache = int64(op.UintValue)
 		case ast.TableOptionComment:
 			tbInfo.Comment = op.StrValue
 		case ast.TableOptionCharset:
@@ -793,9 +838,11 @@ func (d *ddl) AlterTable(ctx context.Context, ident ast.Ident, specs []*ast.Alte
 		validSpecs = append(validSpecs, spec)
 	}
 
-	if len(validSpecs) != 1 {
+	if len(validSpecs) > 1 {
+		return errors.Trace(d.multiSchemaChange(ctx, ident, validSpecs))
+	}
+	if len(validSpecs) == 0 {
 		// TODO: Hanlde len(validSpecs) == 0.
-		// Now we only allow one schema changing at the same time.
 		return errRunMultiSchemaChanges
 	}
 
@@ -832,6 +879,10 @@ func (d *ddl) AlterTable(ctx context.Context, ident ast.Ident, specs []*ast.Alte
 		case ast.AlterTableRenameTable:
 			newIdent := ast.Ident{Schema: spec.NewTable.Schema, Name: spec.NewTable.Name}
 			err = d.RenameTable(ctx, ident, newIdent)
+		case ast.AlterTableRenameIndex:
+			err = d.RenameIndex(ctx, ident, spec.FromKey, spec.ToKey)
+		case ast.AlterTableIndexVisibility:
+			err = d.AlterIndexVisibility(ctx, ident, model.NewCIStr(spec.Name), spec.Visibility == ast.IndexVisibilityInvisible)
 		case ast.AlterTableDropPrimaryKey:
 			err = ErrUnsupportedModifyPrimaryKey.GenByArgs("drop")
 		default:
@@ -846,6 +897,89 @@ func (d *ddl) AlterTable(ctx context.Context, ident ast.Ident, specs []*ast.Alte
 	return nil
 }
 
+// multiSchemaChange runs the schema changes of an ALTER TABLE statement in a single job. Only adding
+// and dropping columns and indices are supported. The dropped columns and indices can't be restored,
+// so they are dropped after the others are added, and the added ones are dropped if the job fails.
+func (d *ddl) multiSchemaChange(ctx context.Context, ident ast.Ident, specs []*ast.AlterTableSpec) error {
+	var addJobs, dropJobs []*model.SubJob
+	var schemaID, tableID int64
+	added := make(map[string]struct{})
+	dropped := make(map[string]struct{})
+	for _, spec := range specs {
+		var (
+			job *model.Job
+			err error
+		)
+		switch spec.Tp {
+		case ast.AlterTableAddColumn:
+			job, err = d.buildAddColumnJob(ctx, ident, spec)
+		case ast.AlterTableDropColumn:
+			job, err = d.buildDropColumnJob(ident, spec.OldColumnName.Name)
+		case ast.AlterTableDropIndex:
+			job, err = d.buildDropIndexJob(ident, model.NewCIStr(spec.Name))
+		case ast.AlterTableAddConstraint:
+			constr := spec.Constraint
+			switch constr.Tp {
+			case ast.ConstraintKey, ast.ConstraintIndex:
+				job, err = d.buildCreateIndexJob(ctx, ident, false, model.NewCIStr(constr.Name), constr.Keys, constr.Option)
+			case ast.ConstraintUniq, ast.ConstraintUniqIndex, ast.ConstraintUniqKey:
+				job, err = d.buildCreateIndexJob(ctx, ident, true, model.NewCIStr(constr.Name), constr.Keys, constr.Option)
+			default:
+				return errRunMultiSchemaChanges
+			}
+		default:
+			return errRunMultiSchemaChanges
+		}
+		if err != nil {
+			return errors.Trace(err)
+		}
+
+		schemaID, tableID = job.SchemaID, job.TableID
+		sub := &model.SubJob{Type: job.Type, Args: job.Args}
+		switch job.Type {
+		case model.ActionDropColumn, model.ActionDropIndex:
+			kind := "column"
+			if job.Type == model.ActionDropIndex {
+				kind = "index"
+			}
+			// The column or index doesn't exist after it's dropped by a previous spec.
+			name := job.Args[0].(model.CIStr)
+			key := kind + " " + name.L
+			if _, ok := dropped[key]; ok {
+				return ErrCantDropFieldOrKey.Gen("%s %s doesn't exist", kind, name)
+			}
+			dropped[key] = struct{}{}
+			dropJobs = append(dropJobs, sub)
+		case model.ActionAddColumn:
+			// The column exists after it's added by a previous spec.
+			name := job.Args[0].(*table.Column).Name
+			if _, ok := added["column "+name.L]; ok {
+				return infoschema.ErrColumnExists.GenByArgs(name)
+			}
+			added["column "+name.L] = struct{}{}
+			addJobs = append(addJobs, sub)
+		case model.ActionAddIndex:
+			name := job.Args[1].(model.CIStr)
+			if _, ok := added["index "+name.L]; ok {
+				return errDupKeyName.Gen("index already exist %s", name)
+			}
+			added["index "+name.L] = struct{}{}
+			addJobs = append(addJobs, sub)
+		}
+	}
+
+	job := &model.Job{
+		SchemaID:        schemaID,
+		TableID:         tableID,
+		Type:            model.ActionMultiSchemaChange,
+		BinlogInfo:      &model.HistoryInfo{},
+		MultiSchemaInfo: &model.MultiSchemaInfo{SubJobs: append(addJobs, dropJobs...)},
+	}
+	err := d.doDDLJob(ctx, job)
+	err = d.callHookOnChanged(err)
+	return errors.Trace(err)
+}
+
 func checkColumnConstraint(constraints []*ast.ColumnOption) error {
 	for _, constraint := range constraints {
 		switch constraint.Tp {
@@ -859,27 +993,37 @@ func checkColumnConstraint(constraints []*ast.ColumnOption) error {
 
 // AddColumn will add a new column to the table.
 func (d *ddl) AddColumn(ctx context.Context, ti ast.Ident, spec *ast.AlterTableSpec) error {
+	job, err := d.buildAddColumnJob(ctx, ti, spec)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	err = d.doDDLJob(ctx, job)
+	err = d.callHookOnChanged(err)
+	return errors.Trace(err)
+}
+
+func (d *ddl) buildAddColumnJob(ctx context.Context, ti ast.Ident, spec *ast.AlterTableSpec) (*model.Job, error) {
 	// Check whether the added column constraints are supported.
 	err := checkColumnConstraint(spec.NewColumn.Options)
 	if err != nil {
-		return errors.Trace(err)
+		return nil, errors.Trace(err)
 	}
 
 	is := d.infoHandle.Get()
 	schema, ok := is.SchemaByName(ti.Schema)
 	if !ok {
-		return errors.Trace(infoschema.ErrDatabaseNotExists)
+		return nil, errors.Trace(infoschema.ErrDatabaseNotExists)
 	}
 	t, err := is.TableByName(ti.Schema, ti.Name)
 	if err != nil {
-		return errors.Trace(infoschema.ErrTableNotExists.GenByArgs(ti.Schema, ti.Name))
+		return nil, errors.Trace(infoschema.ErrTableNotExists.GenByArgs(ti.Schema, ti.Name))
 	}
 
 	// Check whether added column has existed.
 	colName := spec.NewColumn.Name.Name.O
 	col := table.FindCol(t.Cols(), colName)
 	if col != nil {
-		return infoschema.ErrColumnExists.GenByArgs(colName)
+		return nil, infoschema.ErrColumnExists.GenByArgs(colName)
 	}
 
 	// If new column is a generated column, do validation.
@@ -894,13 +1038,13 @@ func (d *ddl) AddColumn(ctx context.Context, ti ast.Ident, spec *ast.AlterTableS
 			}
 			_, dependColNames := findDependedColumnNames(spec.NewColumn)
 			if err = columnNamesCover(referableColNames, dependColNames); err != nil {
-				return errors.Trace(err)
+				return nil, errors.Trace(err)
 			}
 		}
 	}
 
 	if len(colName) > mysql.MaxColumnNameLength {
-		return ErrTooLongIdent.Gen("too long column %s", colName)
+		return nil, ErrTooLongIdent.Gen("too long column %s", colName)
 	}
 
 	// Ingore table constraints now, maybe return error later.
@@ -908,14 +1052,14 @@ func (d *ddl) AddColumn(ctx context.Context, ti ast.Ident, spec *ast.AlterTableS
 	// column's offset later.
 	col, _, err = buildColumnAndConstraint(ctx, len(t.Cols()), spec.NewColumn)
 	if err != nil {
-		return errors.Trace(err)
+		return nil, errors.Trace(err)
 	}
 	col.OriginDefaultValue = col.DefaultValue
 	if col.OriginDefaultValue == nil && mysql.HasNotNullFlag(col.Flag) {
 		zeroVal := table.GetZeroValue(col.ToInfo())
 		col.OriginDefaultValue, err = zeroVal.ToString()
 		if err != nil {
-			return errors.Trace(err)
+			return nil, errors.Trace(err)
 		}
 	}
 
@@ -931,37 +1075,44 @@ func (d *ddl) AddColumn(ctx context.Context, ti ast.Ident, spec *ast.AlterTableS
 		BinlogInfo: &model.HistoryInfo{},
 		Args:       []interface{}{col, spec.Position, 0},
 	}
+	return job, nil
+}
 
+// DropColumn will drop a column from the table, now we don't support drop the column with index covered.
+func (d *ddl) DropColumn(ctx context.Context, ti ast.Ident, colName model.CIStr) error {
+	job, err := d.buildDropColumnJob(ti, colName)
+	if err != nil {
+		return errors.Trace(err)
+	}
 	err = d.doDDLJob(ctx, job)
 	err = d.callHookOnChanged(err)
 	return errors.Trace(err)
 }
 
-// DropColumn will drop a column from the table, now we don't support drop the column with index covered.
-func (d *ddl) DropColumn(ctx context.Context, ti ast.Ident, colName model.CIStr) error {
+func (d *ddl) buildDropColumnJob(ti ast.Ident, colName model.CIStr) (*model.Job, error) {
 	is := d.infoHandle.Get()
 	schema, ok := is.SchemaByName(ti.Schema)
 	if !ok {
-		return errors.Trace(infoschema.ErrDatabaseNotExists)
+		return nil, errors.Trace(infoschema.ErrDatabaseNotExists)
 	}
 	t, err := is.TableByName(ti.Schema, ti.Name)
 	if err != nil {
-		return errors.Trace(infoschema.ErrTableNotExists.GenByArgs(ti.Schema, ti.Name))
+		return nil, errors.Trace(infoschema.ErrTableNotExists.GenByArgs(ti.Schema, ti.Name))
 	}
 
 	// Check whether dropped column has existed.
 	col := table.FindCol(t.Cols(), colName.L)
 	if col == nil {
-		return ErrCantDropFieldOrKey.Gen("column %s doesn't exist", colName)
+		return nil, ErrCantDropFieldOrKey.Gen("column %s doesn't exist", colName)
 	}
 
 	tblInfo := t.Meta()
 	if err = isDroppableColumn(tblInfo, colName); err != nil {
-		return errors.Trace(err)
+		return nil, errors.Trace(err)
 	}
 	// We don't support dropping column with PK handle covered now.
 	if col.IsPKHandleColumn(tblInfo) {
-		return errUnsupportedPKHandle
+		return nil, errUnsupportedPKHandle
 	}
 
 	job := &model.Job{
@@ -971,10 +1122,7 @@ func (d *ddl) DropColumn(ctx context.Context, ti ast.Ident, colName model.CIStr)
 		BinlogInfo: &model.HistoryInfo{},
 		Args:       []interface{}{colName},
 	}
-
-	err = d.doDDLJob(ctx, job)
-	err = d.callHookOnChanged(err)
-	return errors.Trace(err)
+	return job, nil
 }
 
 // modifiable checks if the 'origin' type can be modified to 'to' type with out the need to
@@ -1017,8 +1165,23 @@ func modifiable(origin *types.FieldType, to *types.FieldType) error {
 		case mysql.TypeTiny, mysql.TypeShort, mysql.TypeInt24, mysql.TypeLong, mysql.TypeLonglong:
 			return nil
 		}
-	case mysql.TypeEnum:
-		return errUnsupportedModifyColumn.GenByArgs("modify enum column is not supported")
+	case mysql.TypeEnum, mysql.TypeSet:
+		if origin.Tp != to.Tp {
+			break
+		}
+		// Stored values are indexes into the element list, so only appending
+		// new elements keeps existing data valid.
+		if len(to.Elems) < len(origin.Elems) {
+			msg := fmt.Sprintf("the number of %s column's elements is less than the original: %d", types.TypeStr(origin.Tp), len(origin.Elems))
+			return errUnsupportedModifyColumn.GenByArgs(msg)
+		}
+		for i, elem := range origin.Elems {
+			if to.Elems[i] != elem {
+				msg := fmt.Sprintf("cannot modify %s column value %s to %s", types.TypeStr(origin.Tp), elem, to.Elems[i])
+				return errUnsupportedModifyColumn.GenByArgs(msg)
+			}
+		}
+		return nil
 	default:
 		if origin.Tp == to.Tp {
 			return nil
@@ -1360,6 +1523,152 @@ func (d *ddl) RenameTable(ctx context.Context, oldIdent, newIdent ast.Ident) err
 	return errors.Trace(err)
 }
 
+// RenameTables renames the tables in order, the renames are checked against the names after the
+// previous renames, so `RENAME TABLE a TO tmp, b TO a, tmp TO b` swaps the tables.
+func (d *ddl) RenameTables(ctx context.Context, oldIdents, newIdents []ast.Ident) error {
+	is := d.GetInformationSchema()
+	// names maps the names changed by the previous renames to the table IDs, 0 means the name is free.
+	names := make(map[string]int64)
+	tableIDOf := func(ident ast.Ident) (int64, bool) {
+		key := ident.Schema.L + "." + ident.Name.L
+		if id, ok := names[key]; ok {
+			return id, id != 0
+		}
+		tbl, err := is.TableByName(ident.Schema, ident.Name)
+		if err != nil {
+			return 0, false
+		}
+		return tbl.Meta().ID, true
+	}
+
+	var oldSchemaIDs, newSchemaIDs, tableIDs []int64
+	var newNames []model.CIStr
+	// renamed maps a table ID to its position in the job arguments, a table renamed twice is
+	// renamed only once from its original name to its final name.
+	renamed := make(map[int64]int)
+	for i, oldIdent := range oldIdents {
+		newIdent := newIdents[i]
+		oldSchema, ok := is.SchemaByName(oldIdent.Schema)
+		if !ok {
+			return errFileNotFound.GenByArgs(oldIdent.Schema, oldIdent.Name)
+		}
+		tableID, ok := tableIDOf(oldIdent)
+		if !ok {
+			return errFileNotFound.GenByArgs(oldIdent.Schema, oldIdent.Name)
+		}
+		newSchema, ok := is.SchemaByName(newIdent.Schema)
+		if !ok {
+			return errErrorOnRename.GenByArgs(oldIdent.Schema, oldIdent.Name, newIdent.Schema, newIdent.Name)
+		}
+		if _, ok = tableIDOf(newIdent); ok {
+			return infoschema.ErrTableExists.GenByArgs(newIdent)
+		}
+		names[oldIdent.Schema.L+"."+oldIdent.Name.L] = 0
+		names[newIdent.Schema.L+"."+newIdent.Name.L] = tableID
+
+		if idx, ok := renamed[tableID]; ok {
+			newSchemaIDs[idx] = newSchema.ID
+			newNames[idx] = newIdent.Name
+			continue
+		}
+		renamed[tableID] = len(tableIDs)
+		oldSchemaIDs = append(oldSchemaIDs, oldSchema.ID)
+		newSchemaIDs = append(newSchemaIDs, newSchema.ID)
+		newNames = append(newNames, newIdent.Name)
+		tableIDs = append(tableIDs, tableID)
+	}
+
+	job := &model.Job{
+		SchemaID:   newSchemaIDs[len(newSchemaIDs)-1],
+		TableID:    tableIDs[len(tableIDs)-1],
+		Type:       model.ActionRenameTables,
+		BinlogInfo: &model.HistoryInfo{},
+		Args:       []interface{}{oldSchemaIDs, newSchemaIDs, newNames, tableIDs},
+	}
+
+	err := d.doDDLJob(ctx, job)
+	err = d.callHookOnChanged(err)
+	return errors.Trace(err)
+}
+
+// LockTables locks the tables for the session one by one, the locks taken by the statement are
+// released if a table fails to be locked.
+func (d *ddl) LockTables(ctx context.Context, locks []variable.TableLock) error {
+	session := model.SessionInfo{ServerID: d.uuid, SessionID: ctx.GetSessionVars().ConnectionID}
+	is := d.GetInformationSchema()
+	for i, l := range locks {
+		tp := model.TableLockRead
+		if l.Write {
+			tp = model.TableLockWrite
+		}
+		var err error
+		if tbl, ok := is.TableByID(l.TableID); ok {
+			// Check the lock before running the job to fail fast, the job checks it again.
+			err = checkTableLockConflict(tbl.Meta(), tp, session)
+		}
+		if err == nil {
+			job := &model.Job{
+				SchemaID:   l.SchemaID,
+				TableID:    l.TableID,
+				Type:       model.ActionLockTable,
+				BinlogInfo: &model.HistoryInfo{},
+				Args:       []interface{}{tp, session},
+			}
+			err = d.doDDLJob(ctx, job)
+			err = d.callHookOnChanged(err)
+		}
+		if err != nil {
+			if err1 := d.UnlockTables(ctx, locks[:i]); err1 != nil {
+				log.Errorf("[ddl] unlock tables error %v", errors.ErrorStack(err1))
+			}
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// UnlockTables releases the table locks of the session, the tables which have been dropped are skipped.
+func (d *ddl) UnlockTables(ctx context.Context, locks []variable.TableLock) error {
+	session := model.SessionInfo{ServerID: d.uuid, SessionID: ctx.GetSessionVars().ConnectionID}
+	is := d.GetInformationSchema()
+	for _, l := range locks {
+		if _, ok := is.TableByID(l.TableID); !ok {
+			continue
+		}
+		job := &model.Job{
+			SchemaID:   l.SchemaID,
+			TableID:    l.TableID,
+			Type:       model.ActionUnlockTable,
+			BinlogInfo: &model.HistoryInfo{},
+			Args:       []interface{}{session},
+		}
+		err := d.doDDLJob(ctx, job)
+		err = d.callHookOnChanged(err)
+		if err != nil && !infoschema.ErrTableNotExists.Equal(err) && !infoschema.ErrDatabaseNotExists.Equal(err) {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// CleanupTableLock removes the locks of the tables no matter which sessions hold them.
+func (d *ddl) CleanupTableLock(ctx context.Context, locks []variable.TableLock) error {
+	for _, l := range locks {
+		job := &model.Job{
+			SchemaID:   l.SchemaID,
+			TableID:    l.TableID,
+			Type:       model.ActionCleanupTableLock,
+			BinlogInfo: &model.HistoryInfo{},
+		}
+		err := d.doDDLJob(ctx, job)
+		err = d.callHookOnChanged(err)
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
 func getAnonymousIndex(t table.Table, colName model.CIStr) model.CIStr {
 	id := 2
 	l := len(t.Indices())
@@ -1376,23 +1685,46 @@ func getAnonymousIndex(t table.Table, colName model.CIStr) model.CIStr {
 
 func (d *ddl) CreateIndex(ctx context.Context, ti ast.Ident, unique bool, indexName model.CIStr,
 	idxColNames []*ast.IndexColName, indexOption *ast.IndexOption) error {
+	job, err := d.buildCreateIndexJob(ctx, ti, unique, indexName, idxColNames, indexOption)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	err = d.doDDLJob(ctx, job)
+	err = d.callHookOnChanged(err)
+	return errors.Trace(err)
+}
+
+func (d *ddl) buildCreateIndexJob(ctx context.Context, ti ast.Ident, unique bool, indexName model.CIStr,
+	idxColNames []*ast.IndexColName, indexOption *ast.IndexOption) (*model.Job, error) {
 	is := d.infoHandle.Get()
 	schema, ok := is.SchemaByName(ti.Schema)
 	if !ok {
-		return infoschema.ErrDatabaseNotExists.GenByArgs(ti.Schema)
+		return nil, infoschema.ErrDatabaseNotExists.GenByArgs(ti.Schema)
 	}
 	t, err := is.TableByName(ti.Schema, ti.Name)
 	if err != nil {
-		return errors.Trace(infoschema.ErrTableNotExists.GenByArgs(ti.Schema, ti.Name))
+		return nil, errors.Trace(infoschema.ErrTableNotExists.GenByArgs(ti.Schema, ti.Name))
 	}
 
 	// Deal with anonymous index.
 	if len(indexName.L) == 0 {
-		indexName = getAnonymousIndex(t, idxColNames[0].Column.Name)
+		colName := model.NewCIStr(anonymousExpressionIndex)
+		if idxColNames[0].Column != nil {
+			colName = idxColNames[0].Column.Name
+		}
+		indexName = getAnonymousIndex(t, colName)
 	}
 
 	if indexInfo := findIndexByName(indexName.L, t.Meta().Indices); indexInfo != nil {
-		return errDupKeyName.Gen("index already exist %s", indexName)
+		return nil, errDupKeyName.Gen("index already exist %s", indexName)
+	}
+	if indexOption != nil && indexOption.PrimaryKeyTp != ast.PrimaryKeyTypeDefault {
+		return nil, ErrUnsupportedClusteredSecondaryKey
+	}
+	// The expression keys are replaced by the keys on the hidden columns, which are added with the index.
+	idxColNames, hiddenCols, err := buildHiddenColumns(ctx, t.Meta(), indexName, idxColNames)
+	if err != nil {
+		return nil, errors.Trace(err)
 	}
 
 	job := &model.Job{
@@ -1400,12 +1732,9 @@ func (d *ddl) CreateIndex(ctx context.Context, ti ast.Ident, unique bool, indexN
 		TableID:    t.Meta().ID,
 		Type:       model.ActionAddIndex,
 		BinlogInfo: &model.HistoryInfo{},
-		Args:       []interface{}{unique, indexName, idxColNames, indexOption},
+		Args:       []interface{}{unique, indexName, idxColNames, indexOption, hiddenCols},
 	}
-
-	err = d.doDDLJob(ctx, job)
-	err = d.callHookOnChanged(err)
-	return errors.Trace(err)
+	return job, nil
 }
 
 func buildFKInfo(fkName model.CIStr, keys []*ast.IndexColName, refer *ast.ReferenceDef) (*model.FKInfo, error) {
@@ -1487,6 +1816,17 @@ func (d *ddl) DropForeignKey(ctx context.Context, ti ast.Ident, fkName model.CIS
 }
 
 func (d *ddl) DropIndex(ctx context.Context, ti ast.Ident, indexName model.CIStr) error {
+	job, err := d.buildDropIndexJob(ti, indexName)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	err = d.doDDLJob(ctx, job)
+	err = d.callHookOnChanged(err)
+	return errors.Trace(err)
+}
+
+// RenameIndex renames the index without rebuilding it, only the table info changes.
+func (d *ddl) RenameIndex(ctx context.Context, ti ast.Ident, from, to model.CIStr) error {
 	is := d.infoHandle.Get()
 	schema, ok := is.SchemaByName(ti.Schema)
 	if !ok {
@@ -1496,24 +1836,74 @@ func (d *ddl) DropIndex(ctx context.Context, ti ast.Ident, indexName model.CIStr
 	if err != nil {
 		return errors.Trace(infoschema.ErrTableNotExists.GenByArgs(ti.Schema, ti.Name))
 	}
-
-	if indexInfo := findIndexByName(indexName.L, t.Meta().Indices); indexInfo == nil {
-		return ErrCantDropFieldOrKey.Gen("index %s doesn't exist", indexName)
+	if err = checkRenameIndex(t.Meta(), from, to); err != nil {
+		return errors.Trace(err)
 	}
 
 	job := &model.Job{
 		SchemaID:   schema.ID,
 		TableID:    t.Meta().ID,
-		Type:       model.ActionDropIndex,
+		Type:       model.ActionRenameIndex,
 		BinlogInfo: &model.HistoryInfo{},
-		Args:       []interface{}{indexName},
+		Args:       []interface{}{from, to},
+	}
+	err = d.doDDLJob(ctx, job)
+	err = d.callHookOnChanged(err)
+	return errors.Trace(err)
+}
+
+// AlterIndexVisibility makes the index visible or invisible to the optimizer, the index data isn't changed.
+func (d *ddl) AlterIndexVisibility(ctx context.Context, ti ast.Ident, name model.CIStr, invisible bool) error {
+	is := d.infoHandle.Get()
+	schema, ok := is.SchemaByName(ti.Schema)
+	if !ok {
+		return errors.Trace(infoschema.ErrDatabaseNotExists)
+	}
+	t, err := is.TableByName(ti.Schema, ti.Name)
+	if err != nil {
+		return errors.Trace(infoschema.ErrTableNotExists.GenByArgs(ti.Schema, ti.Name))
+	}
+	if _, err = checkAlterIndexVisibility(t.Meta(), name, invisible); err != nil {
+		return errors.Trace(err)
 	}
 
+	job := &model.Job{
+		SchemaID:   schema.ID,
+		TableID:    t.Meta().ID,
+		Type:       model.ActionAlterIndexVisibility,
+		BinlogInfo: &model.HistoryInfo{},
+		Args:       []interface{}{name, invisible},
+	}
 	err = d.doDDLJob(ctx, job)
 	err = d.callHookOnChanged(err)
 	return errors.Trace(err)
 }
 
+func (d *ddl) buildDropIndexJob(ti ast.Ident, indexName model.CIStr) (*model.Job, error) {
+	is := d.infoHandle.Get()
+	schema, ok := is.SchemaByName(ti.Schema)
+	if !ok {
+		return nil, errors.Trace(infoschema.ErrDatabaseNotExists)
+	}
+	t, err := is.TableByName(ti.Schema, ti.Name)
+	if err != nil {
+		return nil, errors.Trace(infoschema.ErrTableNotExists.GenByArgs(ti.Schema, ti.Name))
+	}
+
+	if indexInfo := findIndexByName(indexName.L, t.Meta().Indices); indexInfo == nil {
+		return nil, ErrCantDropFieldOrKey.Gen("index %s doesn't exist", indexName)
+	}
+
+	job := &model.Job{
+		SchemaID:   schema.ID,
+		TableID:    t.Meta().ID,
+		Type:       model.ActionDropIndex,
+		BinlogInfo: &model.HistoryInfo{},
+		Args:       []interface{}{indexName},
+	}
+	return job, nil
+}
+
 // findCol finds column in cols by name.
 func findCol(cols []*model.ColumnInfo, name string) *model.ColumnInfo {
 	name = strings.ToLower(name)
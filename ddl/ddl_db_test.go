@@ -1245,12 +1245,28 @@ func (s *testDBSuite) TestRenameMultiTables(c *C) {
 	s.tk.MustExec("use test")
 	s.tk.MustExec("create table t1(id int)")
 	s.tk.MustExec("create table t2(id int)")
-	// Currently it will fail only.
-	sql := fmt.Sprintf("rename table t1 to t3, t2 to t4")
-	_, err = s.tk.Exec(sql)
+	s.tk.MustExec("insert t1 values (1)")
+	s.tk.MustExec("insert t2 values (2)")
+	s.tk.MustExec("create database test1")
+
+	// Swap the tables.
+	s.tk.MustExec("rename table t1 to tmp, t2 to t1, tmp to t2")
+	s.tk.MustQuery("select * from t1").Check(testkit.Rows("2"))
+	s.tk.MustQuery("select * from t2").Check(testkit.Rows("1"))
+	// Rename the tables across databases.
+	s.tk.MustExec("rename table t1 to test1.t3, t2 to test1.t1, test1.t3 to t2")
+	s.tk.MustQuery("select * from test1.t1").Check(testkit.Rows("1"))
+	s.tk.MustQuery("select * from t2").Check(testkit.Rows("2"))
+	s.tk.MustExec("insert test1.t1 values (3)")
+	s.tk.MustQuery("select * from test1.t1").Check(testkit.Rows("1", "3"))
+
+	// None of the tables is renamed if a rename fails.
+	_, err = s.tk.Exec("rename table t2 to t4, t4 to test1.t1")
+	c.Assert(infoschema.ErrTableExists.Equal(err), IsTrue)
+	_, err = s.tk.Exec("rename table t2 to t4, t5 to t6")
 	c.Assert(err, NotNil)
-	originErr := errors.Cause(err)
-	c.Assert(originErr.Error(), Equals, "can't run multi schema change")
+	s.tk.MustQuery("select * from t2").Check(testkit.Rows("2"))
+	s.tk.MustQuery("show tables").Check(testkit.Rows("t2"))
 }
 
 func (s *testDBSuite) TestAddNotNullColumn(c *C) {
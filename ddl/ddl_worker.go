@@ -18,21 +18,68 @@ import (
 
 	"github.com/juju/errors"
 	"github.com/ngaut/log"
+	"github.com/pingcap/tidb/config"
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/meta"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/sessionctx/binloginfo"
 	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/failpoint"
 	goctx "golang.org/x/net/context"
 )
 
+// errInjectedWorkerCrash is returned by the failpoint that crashes the worker between the job steps.
+var errInjectedWorkerCrash = errors.New("injected ddl worker crash")
+
 // RunWorker indicates if this TiDB server starts DDL worker and can run DDL job.
 var RunWorker = true
 
+// workerType is the type of the DDL workers, every type of worker handles the jobs in its own job queue.
+// So a job which reorganizes the data of a big table doesn't block the jobs on the other tables.
+type workerType byte
+
+const (
+	// generalWorker handles the jobs which only change the schema.
+	generalWorker workerType = 0
+	// reorgWorker handles the jobs which reorganize the data, like adding an index, they may take a long time.
+	reorgWorker workerType = 1
+)
+
+var workerTypes = []workerType{generalWorker, reorgWorker}
+
+func (w workerType) String() string {
+	if w == reorgWorker {
+		return "reorg"
+	}
+	return "general"
+}
+
+func (w workerType) jobListKey() meta.JobListKeyType {
+	if w == reorgWorker {
+		return meta.ReorgJobListKey
+	}
+	return meta.DefaultJobListKey
+}
+
+// other returns the type of the worker handling the other job queue.
+func (w workerType) other() workerType {
+	if w == reorgWorker {
+		return generalWorker
+	}
+	return reorgWorker
+}
+
+func jobWorkerType(job *model.Job) workerType {
+	if job.IsReorgJob() {
+		return reorgWorker
+	}
+	return generalWorker
+}
+
 // onDDLWorker is for async online schema changing, it will try to become the owner firstly,
-// then wait or pull the job queue to handle a schema change job.
-func (d *ddl) onDDLWorker() {
+// then wait or pull the job queue of the worker type to handle a schema change job.
+func (d *ddl) onDDLWorker(tp workerType) {
 	defer d.wait.Done()
 	if !RunWorker {
 		return
@@ -49,15 +96,15 @@ func (d *ddl) onDDLWorker() {
 	for {
 		select {
 		case <-ticker.C:
-			log.Debugf("[ddl] wait %s to check DDL status again", checkTime)
-		case <-d.ddlJobCh:
+			log.Debugf("[ddl] %s worker wait %s to check DDL status again", tp, checkTime)
+		case <-d.ddlJobChs[tp]:
 		case <-d.quitCh:
 			return
 		}
 
-		err := d.handleDDLJobQueue()
+		err := d.handleDDLJobQueue(tp)
 		if err != nil {
-			log.Errorf("[ddl] handle ddl job err %v", errors.ErrorStack(err))
+			log.Errorf("[ddl] %s worker handle ddl job err %v", tp, errors.ErrorStack(err))
 		}
 	}
 }
@@ -75,12 +122,26 @@ func (d *ddl) isOwner() bool {
 	return isOwner
 }
 
-// addDDLJob gets a global job ID and puts the DDL job in the DDL queue.
-func (d *ddl) addDDLJob(ctx context.Context, job *model.Job) error {
+// addDDLJob gets a global job ID and puts the DDL job in the DDL queue of its worker type, it returns the worker
+// type. The job is refused if some servers in the cluster are at a lower DDL version than the job needs, and a
+// reorganization job is put in the general job queue if some servers don't have the reorganization job queue.
+func (d *ddl) addDDLJob(ctx context.Context, job *model.Job) (workerType, error) {
+	minVer, err := d.schemaSyncer.MinDDLVersion(goctx.Background())
+	if err != nil {
+		return generalWorker, errors.Trace(err)
+	}
+	if ver := job.RequiredDDLVersion(); ver > minVer {
+		return generalWorker, ErrClusterDDLVersion.GenByArgs(job.Type, ver, minVer)
+	}
+	tp := jobWorkerType(job)
+	if minVer < model.DDLVersion2 {
+		tp = generalWorker
+	}
+
 	job.Version = currentVersion
 	job.Query, _ = ctx.Value(context.QueryString).(string)
-	return kv.RunInNewTxn(d.store, true, func(txn kv.Transaction) error {
-		t := meta.NewMeta(txn)
+	err = kv.RunInNewTxn(d.store, true, func(txn kv.Transaction) error {
+		t := meta.NewMeta(txn, tp.jobListKey())
 		var err error
 		job.ID, err = t.GenGlobalID()
 		if err != nil {
@@ -89,6 +150,7 @@ func (d *ddl) addDDLJob(ctx context.Context, job *model.Job) error {
 		err = t.EnQueueDDLJob(job)
 		return errors.Trace(err)
 	})
+	return tp, errors.Trace(err)
 }
 
 // getFirstDDLJob gets the first DDL job form DDL queue.
@@ -130,6 +192,15 @@ func (d *ddl) finishDDLJob(t *meta.Meta, job *model.Job) (err error) {
 		if err != nil {
 			return errors.Trace(err)
 		}
+	case model.ActionMultiSchemaChange:
+		// Delete the data of the dropped indices.
+		for _, sub := range job.MultiSchemaInfo.SubJobs {
+			if sub.Type == model.ActionDropIndex && (sub.State == model.JobDone || sub.State == model.JobRollbackDone) {
+				if err = d.delRangeManager.addDelRangeJob(sub.ToProxyJob(job)); err != nil {
+					return errors.Trace(err)
+				}
+			}
+		}
 	}
 
 	_, err = t.DeQueueDDLJob()
@@ -156,7 +227,53 @@ func (d *ddl) getHistoryDDLJob(id int64) (*model.Job, error) {
 	return job, errors.Trace(err)
 }
 
-func (d *ddl) handleDDLJobQueue() error {
+// getDependencyJob returns the earlier job in the other job queue which changes the same schema or table as the job.
+// The job must wait until it's done, so the jobs on a table are still run in the order they are added.
+// The jobs in a queue are in the order of their IDs, so the first jobs of the queues never wait for each other.
+func getDependencyJob(txn kv.Transaction, tp workerType, job *model.Job) (*model.Job, error) {
+	jobs, err := meta.NewMeta(txn, tp.other().jobListKey()).GetAllDDLJobs()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	for _, other := range jobs {
+		if other.ID > job.ID {
+			continue
+		}
+		isDependent, err := job.IsDependentOn(other)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if isDependent {
+			return other, nil
+		}
+	}
+	return nil, nil
+}
+
+// moveReorgJob moves the first job of the general job queue to the reorganization job queue, the job is added by
+// the servers which only have the general job queue.
+func moveReorgJob(txn kv.Transaction, job *model.Job) error {
+	_, err := meta.NewMeta(txn).DeQueueDDLJob()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	err = meta.NewMeta(txn, meta.ReorgJobListKey).EnQueueDDLJob(job)
+	return errors.Trace(err)
+}
+
+// clusterHasReorgQueue returns whether all the servers in the cluster have the reorganization job queue. The
+// reorganization jobs are run in the general job queue until all the servers are upgraded, because a server
+// without the reorganization job queue can't run the jobs in it after it becomes the owner.
+func (d *ddl) clusterHasReorgQueue() bool {
+	minVer, err := d.schemaSyncer.MinDDLVersion(goctx.Background())
+	if err != nil {
+		log.Warnf("[ddl] get the min DDL version of the cluster failed %v", err)
+		return false
+	}
+	return minVer >= model.DDLVersion2
+}
+
+func (d *ddl) handleDDLJobQueue(tp workerType) error {
 	once := true
 	for {
 		if d.isClosed() {
@@ -164,8 +281,13 @@ func (d *ddl) handleDDLJobQueue() error {
 		}
 
 		waitTime := 2 * d.lease
+		if waitTime > 0 {
+			// The servers hold back their schema versions for the transactions using the old schema.
+			waitTime += time.Duration(config.GetGlobalConfig().MetadataLockWait) * time.Second
+		}
 		var job *model.Job
 		var schemaVer int64
+		var moved bool
 		err := kv.RunInNewTxn(d.store, false, func(txn kv.Transaction) error {
 			// We are not owner, return and retry checking later.
 			if !d.isOwner() {
@@ -173,12 +295,33 @@ func (d *ddl) handleDDLJobQueue() error {
 			}
 
 			var err error
-			t := meta.NewMeta(txn)
+			t := meta.NewMeta(txn, tp.jobListKey())
+			queueLen, err := t.DDLJobQueueLen()
+			if err != nil {
+				return errors.Trace(err)
+			}
+			jobQueueGauge.WithLabelValues(tp.String()).Set(float64(queueLen))
 			// We become the owner. Get the first job and run it.
 			job, err = d.getFirstDDLJob(t)
 			if job == nil || err != nil {
 				return errors.Trace(err)
 			}
+			if tp == generalWorker && job.IsReorgJob() && d.clusterHasReorgQueue() {
+				log.Infof("[ddl] move DDL job %s to the reorg job queue", job)
+				err = moveReorgJob(txn, job)
+				moved = err == nil
+				return errors.Trace(err)
+			}
+			dependency, err := getDependencyJob(txn, tp, job)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			if dependency != nil {
+				// Wait until the dependency job is done, the worker is notified then.
+				log.Infof("[ddl] DDL job %d waits for the dependency job %d", job.ID, dependency.ID)
+				job = nil
+				return nil
+			}
 
 			if job.IsRunning() || job.IsDone() {
 				// If we enter a new state, crash when waiting 2 * lease time, and restart quickly,
@@ -204,9 +347,10 @@ func (d *ddl) handleDDLJobQueue() error {
 				return errors.Trace(err)
 			}
 
-			d.hookMu.Lock()
+			// The workers of the job queues call the hook concurrently.
+			d.hookMu.RLock()
 			d.hook.OnJobRunBefore(job)
-			d.hookMu.Unlock()
+			d.hookMu.RUnlock()
 
 			// If running job meets error, we will save this error in job Error
 			// and retry later if the job is not cancelled.
@@ -215,6 +359,10 @@ func (d *ddl) handleDDLJobQueue() error {
 				err = d.finishDDLJob(t, job)
 				return errors.Trace(err)
 			}
+			// The job step is done but not saved, the worker runs it again after it restarts.
+			if _, ok := failpoint.Eval("ddl/crashBetweenJobSteps"); ok {
+				return errors.Trace(errInjectedWorkerCrash)
+			}
 			err = d.updateDDLJob(t, job, txn.StartTS())
 			return errors.Trace(err)
 		})
@@ -224,20 +372,29 @@ func (d *ddl) handleDDLJobQueue() error {
 			// No job now, return and retry getting later.
 			return nil
 		}
+		if moved {
+			asyncNotify(d.ddlJobChs[reorgWorker])
+			continue
+		}
 
-		d.hookMu.Lock()
+		d.hookMu.RLock()
 		d.hook.OnJobUpdated(job)
-		d.hookMu.Unlock()
+		d.hookMu.RUnlock()
 
 		// Here means the job enters another state (delete only, write only, public, etc...) or is cancelled.
 		// If the job is done or still running, we will wait 2 * lease time to guarantee other servers to update
-		// the newest schema.
-		if job.State == model.JobRunning || job.State == model.JobDone {
+		// the newest schema. The rollback of a multi-schema change job drops the added columns step by step too.
+		if job.State == model.JobRunning || job.State == model.JobDone ||
+			(job.State == model.JobRollback && job.Type == model.ActionMultiSchemaChange) {
 			d.waitSchemaChanged(waitTime, schemaVer)
 		}
 		if job.IsSynced() {
 			asyncNotify(d.ddlJobDoneCh)
 		}
+		if job.IsSynced() || job.IsCancelled() {
+			// The jobs in the other queue may wait for this job.
+			asyncNotify(d.ddlJobChs[tp.other()])
+		}
 	}
 }
 
@@ -289,6 +446,20 @@ func (d *ddl) runDDLJob(t *meta.Meta, job *model.Job) (ver int64) {
 		ver, err = d.onRenameTable(t, job)
 	case model.ActionSetDefaultValue:
 		ver, err = d.onSetDefaultValue(t, job)
+	case model.ActionLockTable:
+		ver, err = d.onLockTable(t, job)
+	case model.ActionUnlockTable:
+		ver, err = d.onUnlockTable(t, job)
+	case model.ActionCleanupTableLock:
+		ver, err = d.onCleanupTableLock(t, job)
+	case model.ActionMultiSchemaChange:
+		ver, err = d.onMultiSchemaChange(t, job)
+	case model.ActionRenameIndex:
+		ver, err = d.onRenameIndex(t, job)
+	case model.ActionRenameTables:
+		ver, err = d.onRenameTables(t, job)
+	case model.ActionAlterIndexVisibility:
+		ver, err = d.onAlterIndexVisibility(t, job)
 	default:
 		// Invalid job, cancel it.
 		job.State = model.JobCancelled
@@ -384,6 +555,21 @@ func updateSchemaVersion(t *meta.Meta, job *model.Job) (int64, error) {
 			return 0, errors.Trace(err)
 		}
 		diff.TableID = job.TableID
+	} else if job.Type == model.ActionRenameTables {
+		var oldSchemaIDs, newSchemaIDs, tableIDs []int64
+		var newNames []model.CIStr
+		err = job.DecodeArgs(&oldSchemaIDs, &newSchemaIDs, &newNames, &tableIDs)
+		if err != nil {
+			return 0, errors.Trace(err)
+		}
+		for i, tableID := range tableIDs {
+			diff.AffectedOpts = append(diff.AffectedOpts, &model.AffectedOption{
+				SchemaID:    newSchemaIDs[i],
+				TableID:     tableID,
+				OldSchemaID: oldSchemaIDs[i],
+			})
+		}
+		diff.TableID = job.TableID
 	} else {
 		diff.TableID = job.TableID
 	}
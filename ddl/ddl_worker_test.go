@@ -14,6 +14,8 @@
 package ddl
 
 import (
+	"sync"
+	"sync/atomic"
 	"time"
 
 	. "github.com/pingcap/check"
@@ -237,3 +239,129 @@ func doDDLJobErr(c *C, schemaID, tableID int64, tp model.ActionType, args []inte
 
 	return job
 }
+
+func (s *testDDLSuite) TestParallelDDL(c *C) {
+	defer testleak.AfterTest(c)()
+	store := testCreateStore(c, "test_parallel_ddl")
+	defer store.Close()
+
+	d := testNewDDL(goctx.Background(), nil, store, nil, nil, testLease)
+	defer d.Stop()
+	ctx := testNewContext(d)
+	dbInfo := testSchemaInfo(c, d, "test_parallel_ddl")
+	testCreateSchema(c, ctx, d, dbInfo)
+	tblInfo1 := testTableInfo(c, d, "t1", 3)
+	testCreateTable(c, ctx, d, dbInfo, tblInfo1)
+	tblInfo2 := testTableInfo(c, d, "t2", 3)
+	testCreateTable(c, ctx, d, dbInfo, tblInfo2)
+
+	// Block the add index job on t1 until it's released.
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var once sync.Once
+	tc := &TestDDLCallback{}
+	tc.onJobRunBefore = func(job *model.Job) {
+		if job.Type == model.ActionAddIndex && job.TableID == tblInfo1.ID {
+			once.Do(func() {
+				close(started)
+				<-release
+			})
+		}
+	}
+	d.SetHook(tc)
+
+	doJob := func(job *model.Job) chan error {
+		done := make(chan error, 1)
+		go func() {
+			done <- d.doDDLJob(testNewContext(d), job)
+		}()
+		return done
+	}
+	addIndexDone := doJob(&model.Job{
+		SchemaID:   dbInfo.ID,
+		TableID:    tblInfo1.ID,
+		Type:       model.ActionAddIndex,
+		BinlogInfo: &model.HistoryInfo{},
+		Args: []interface{}{false, model.NewCIStr("c1_index"),
+			[]*ast.IndexColName{{
+				Column: &ast.ColumnName{Name: model.NewCIStr("c1")},
+				Length: types.UnspecifiedLength}}},
+	})
+	<-started
+
+	// The job on the other table isn't blocked by the add index job.
+	testDropTable(c, ctx, d, dbInfo, tblInfo2)
+
+	// The job on the same table runs after the add index job.
+	dropColumnDone := doJob(&model.Job{
+		SchemaID:   dbInfo.ID,
+		TableID:    tblInfo1.ID,
+		Type:       model.ActionDropColumn,
+		BinlogInfo: &model.HistoryInfo{},
+		Args:       []interface{}{model.NewCIStr("c3")},
+	})
+	select {
+	case err := <-dropColumnDone:
+		c.Fatalf("the drop column job is done before the add index job, err %v", err)
+	case <-time.After(20 * testLease):
+	}
+
+	close(release)
+	c.Assert(<-addIndexDone, IsNil)
+	c.Assert(<-dropColumnDone, IsNil)
+}
+
+func (s *testDDLSuite) TestClusterDDLVersion(c *C) {
+	defer testleak.AfterTest(c)()
+	store := testCreateStore(c, "test_cluster_ddl_version")
+	defer store.Close()
+
+	d := testNewDDL(goctx.Background(), nil, store, nil, nil, testLease)
+	defer d.Stop()
+	ctx := testNewContext(d)
+	dbInfo := testSchemaInfo(c, d, "test_cluster_ddl_version")
+	testCreateSchema(c, ctx, d, dbInfo)
+	tblInfo := testTableInfo(c, d, "t", 3)
+	testCreateTable(c, ctx, d, dbInfo, tblInfo)
+
+	// Some servers in the cluster aren't upgraded.
+	syncer := d.SchemaSyncer().(*mockSchemaSyncer)
+	atomic.StoreInt64(&syncer.minDDLVersion, model.DDLVersion1)
+	m, err := d.Stats(nil)
+	c.Assert(err, IsNil)
+	c.Assert(m[ddlVersion], Equals, model.CurrentDDLVersion)
+	c.Assert(m[ddlClusterVersion], Equals, model.DDLVersion1)
+
+	// The job the old servers can't run is refused.
+	job := &model.Job{
+		SchemaID:   dbInfo.ID,
+		TableID:    tblInfo.ID,
+		Type:       model.ActionRenameIndex,
+		BinlogInfo: &model.HistoryInfo{},
+		Args:       []interface{}{model.NewCIStr("c1_index"), model.NewCIStr("c1_index_new")},
+	}
+	err = d.doDDLJob(ctx, job)
+	c.Assert(ErrClusterDDLVersion.Equal(err), IsTrue)
+
+	// The add index job runs in the general job queue, so the old servers can run it after they become the owner.
+	var reorgQueueLen, generalQueueLen int64
+	tc := &TestDDLCallback{}
+	tc.onJobRunBefore = func(job *model.Job) {
+		kv.RunInNewTxn(store, false, func(txn kv.Transaction) error {
+			reorgQueueLen, _ = meta.NewMeta(txn, meta.ReorgJobListKey).DDLJobQueueLen()
+			generalQueueLen, _ = meta.NewMeta(txn).DDLJobQueueLen()
+			return nil
+		})
+	}
+	d.SetHook(tc)
+	testCreateIndex(c, ctx, d, dbInfo, tblInfo, false, "c1_index", "c1")
+	c.Assert(reorgQueueLen, Equals, int64(0))
+	c.Assert(generalQueueLen, Equals, int64(1))
+
+	// The jobs are run after all the servers are upgraded.
+	atomic.StoreInt64(&syncer.minDDLVersion, 0)
+	c.Assert(d.doDDLJob(ctx, job), IsNil)
+	testCreateIndex(c, ctx, d, dbInfo, tblInfo, false, "c2_index", "c2")
+	c.Assert(reorgQueueLen, Equals, int64(1))
+	c.Assert(generalQueueLen, Equals, int64(0))
+}
@@ -0,0 +1,192 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ddl
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/mysql"
+)
+
+// anonymousExpressionIndex is the name of an anonymous index whose first key is an expression.
+const anonymousExpressionIndex = "functional_index"
+
+// expressionIndexFuncs are the functions which can be used in the expressions of the expression indices,
+// they are deterministic and don't depend on the session.
+var expressionIndexFuncs = map[string]struct{}{
+	// string functions
+	ast.Lower: {}, ast.Upper: {}, ast.Lcase: {}, ast.Ucase: {}, ast.Concat: {}, ast.ConcatWS: {},
+	ast.Substring: {}, ast.Substr: {}, ast.Left: {}, ast.Right: {}, ast.Trim: {}, ast.LTrim: {},
+	ast.RTrim: {}, ast.Length: {}, ast.CharLength: {}, ast.Reverse: {}, ast.Replace: {}, ast.Lpad: {},
+	ast.Rpad: {}, ast.Hex: {}, ast.MD5: {}, ast.SHA1: {},
+	// math functions
+	ast.Abs: {}, ast.Ceil: {}, ast.Ceiling: {}, ast.Floor: {}, ast.Round: {}, ast.Truncate: {}, ast.Mod: {},
+	// time functions
+	ast.Year: {}, ast.Month: {}, ast.Day: {}, ast.DayOfMonth: {}, ast.Hour: {}, ast.Minute: {},
+	ast.Date: {}, ast.ToDays: {}, ast.Weekday: {},
+	// control functions
+	ast.If: {}, ast.Ifnull: {}, ast.Coalesce: {},
+}
+
+// expressionIndexChecker checks the nodes of the expression of an expression index key.
+type expressionIndexChecker struct {
+	allowed bool
+}
+
+func (c *expressionIndexChecker) Enter(inNode ast.Node) (outNode ast.Node, skipChildren bool) {
+	switch x := inNode.(type) {
+	case *ast.ColumnNameExpr, *ast.ColumnName, *ast.ValueExpr, *ast.BinaryOperationExpr, *ast.UnaryOperationExpr,
+		*ast.ParenthesesExpr, *ast.FuncCastExpr, *ast.IsNullExpr, *ast.BetweenExpr, *ast.PatternInExpr,
+		*ast.PatternLikeExpr, *ast.CaseExpr, *ast.WhenClause:
+		return inNode, false
+	case *ast.FuncCallExpr:
+		if _, ok := expressionIndexFuncs[x.FnName.L]; ok {
+			return inNode, false
+		}
+	}
+	c.allowed = false
+	return inNode, true
+}
+
+func (c *expressionIndexChecker) Leave(inNode ast.Node) (node ast.Node, ok bool) {
+	return inNode, true
+}
+
+// checkExpressionIndexKey checks the expression of an expression index key isn't a column and only
+// uses the allowed functions.
+func checkExpressionIndexKey(indexName model.CIStr, expr ast.ExprNode) error {
+	for {
+		p, ok := expr.(*ast.ParenthesesExpr)
+		if !ok {
+			break
+		}
+		expr = p.Expr
+	}
+	if _, ok := expr.(*ast.ColumnNameExpr); ok {
+		return ErrFunctionalIndexOnField.GenByArgs()
+	}
+	c := &expressionIndexChecker{allowed: true}
+	expr.Accept(c)
+	if !c.allowed {
+		return ErrFunctionalIndexFunctionIsNotAllowed.GenByArgs(indexName.O)
+	}
+	return nil
+}
+
+// hiddenColumnName returns the name of the hidden column of the ith key of the index, it's unique in the table.
+func hiddenColumnName(tblInfo *model.TableInfo, hiddenCols []*model.ColumnInfo, indexName model.CIStr, i int) model.CIStr {
+	name := fmt.Sprintf("_V$_%s_%d", indexName.O, i)
+	for n := 0; ; n++ {
+		if n > 0 {
+			name = fmt.Sprintf("_V$_%s_%d_%d", indexName.O, i, n)
+		}
+		if findCol(tblInfo.Columns, name) == nil && findCol(hiddenCols, name) == nil {
+			return model.NewCIStr(name)
+		}
+	}
+}
+
+// buildHiddenColumns builds the hidden virtual generated columns of the expression keys of the index,
+// the expression keys are replaced by the keys on the hidden columns. The hidden columns are not added
+// to the table, their IDs and offsets are set when they are added.
+func buildHiddenColumns(ctx context.Context, tblInfo *model.TableInfo, indexName model.CIStr,
+	idxColNames []*ast.IndexColName) ([]*ast.IndexColName, []*model.ColumnInfo, error) {
+	var hiddenCols []*model.ColumnInfo
+	newColNames := make([]*ast.IndexColName, 0, len(idxColNames))
+	for i, ic := range idxColNames {
+		if ic.Expr == nil {
+			newColNames = append(newColNames, ic)
+			continue
+		}
+		if err := checkExpressionIndexKey(indexName, ic.Expr); err != nil {
+			return nil, nil, errors.Trace(err)
+		}
+		var dependences []string
+		for _, colName := range findColumnNamesInExpr(ic.Expr) {
+			if findCol(tblInfo.Columns, colName.Name.L) == nil {
+				return nil, nil, errKeyColumnDoesNotExits.Gen("column does not exist: %s", colName.Name)
+			}
+			dependences = append(dependences, colName.Name.L)
+		}
+		expr, err := expression.RewriteAstExprOnTable(ic.Expr, tblInfo, ctx)
+		if err != nil {
+			return nil, nil, errors.Trace(err)
+		}
+		col := &model.ColumnInfo{
+			Name:                hiddenColumnName(tblInfo, hiddenCols, indexName, i),
+			GeneratedExprString: strings.TrimSpace(ic.Expr.Text()),
+			Dependences:         make(map[string]struct{}, len(dependences)),
+			FieldType:           *expr.GetType(),
+			Hidden:              true,
+			State:               model.StatePublic,
+		}
+		col.Flag &= ^uint(mysql.NotNullFlag | mysql.PriKeyFlag | mysql.UniqueKeyFlag | mysql.MultipleKeyFlag)
+		for _, name := range dependences {
+			col.Dependences[name] = struct{}{}
+		}
+		hiddenCols = append(hiddenCols, col)
+		newColNames = append(newColNames, &ast.IndexColName{Column: &ast.ColumnName{Name: col.Name}, Length: ic.Length, Desc: ic.Desc})
+	}
+	return newColNames, hiddenCols, nil
+}
+
+// addHiddenColumns adds the hidden columns of an expression index to the end of the table.
+func addHiddenColumns(tblInfo *model.TableInfo, hiddenCols []*model.ColumnInfo) {
+	for _, col := range hiddenCols {
+		if findCol(tblInfo.Columns, col.Name.L) != nil {
+			continue
+		}
+		col.ID = allocateColumnID(tblInfo)
+		col.Offset = len(tblInfo.Columns)
+		tblInfo.Columns = append(tblInfo.Columns, col)
+	}
+}
+
+// dropHiddenColumns drops the hidden columns of the dropped index, the offsets of the other columns
+// are updated.
+func dropHiddenColumns(tblInfo *model.TableInfo, indexInfo *model.IndexInfo) {
+	dropped := make(map[string]struct{})
+	for _, ic := range indexInfo.Columns {
+		if col := findCol(tblInfo.Columns, ic.Name.L); col != nil && col.Hidden {
+			dropped[col.Name.L] = struct{}{}
+		}
+	}
+	if len(dropped) == 0 {
+		return
+	}
+	offsetChanged := make(map[int]int)
+	newColumns := make([]*model.ColumnInfo, 0, len(tblInfo.Columns))
+	for _, col := range tblInfo.Columns {
+		if _, ok := dropped[col.Name.L]; ok {
+			continue
+		}
+		offsetChanged[col.Offset] = len(newColumns)
+		col.Offset = len(newColumns)
+		newColumns = append(newColumns, col)
+	}
+	tblInfo.Columns = newColumns
+	for _, idx := range tblInfo.Indices {
+		for _, col := range idx.Columns {
+			if newOffset, ok := offsetChanged[col.Offset]; ok {
+				col.Offset = newOffset
+			}
+		}
+	}
+}
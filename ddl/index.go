@@ -16,12 +16,14 @@ package ddl
 import (
 	"math"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/juju/errors"
 	"github.com/ngaut/log"
 	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/meta"
 	"github.com/pingcap/tidb/model"
@@ -119,6 +121,7 @@ func buildIndexColumns(columns []*model.ColumnInfo, idxColNames []*ast.IndexColN
 			Name:   col.Name,
 			Offset: col.Offset,
 			Length: ic.Length,
+			Desc:   ic.Desc,
 		})
 	}
 
@@ -195,8 +198,9 @@ func (d *ddl) onCreateIndex(t *meta.Meta, job *model.Job) (ver int64, err error)
 		indexName   model.CIStr
 		idxColNames []*ast.IndexColName
 		indexOption *ast.IndexOption
+		hiddenCols  []*model.ColumnInfo
 	)
-	err = job.DecodeArgs(&unique, &indexName, &idxColNames, &indexOption)
+	err = job.DecodeArgs(&unique, &indexName, &idxColNames, &indexOption, &hiddenCols)
 	if err != nil {
 		job.State = model.JobCancelled
 		return ver, errors.Trace(err)
@@ -209,6 +213,8 @@ func (d *ddl) onCreateIndex(t *meta.Meta, job *model.Job) (ver int64, err error)
 	}
 
 	if indexInfo == nil {
+		// The hidden columns of an expression index are virtual, so they are public when they are added.
+		addHiddenColumns(tblInfo, hiddenCols)
 		indexInfo, err = buildIndexInfo(tblInfo, indexName, idxColNames, model.StateNone)
 		if err != nil {
 			job.State = model.JobCancelled
@@ -216,6 +222,7 @@ func (d *ddl) onCreateIndex(t *meta.Meta, job *model.Job) (ver int64, err error)
 		}
 		if indexOption != nil {
 			indexInfo.Comment = indexOption.Comment
+			indexInfo.Invisible = indexOption.Visibility == ast.IndexVisibilityInvisible
 			if indexOption.Tp == model.IndexTypeInvalid {
 				// Use btree as default index type.
 				indexInfo.Tp = model.IndexTypeBtree
@@ -366,6 +373,7 @@ func (d *ddl) onDropIndex(t *meta.Meta, job *model.Job) (ver int64, _ error) {
 		tblInfo.Indices = newIndices
 		// Set column index flag.
 		dropIndexColumnFlag(tblInfo, indexInfo)
+		dropHiddenColumns(tblInfo, indexInfo)
 
 		job.SchemaState = model.StateNone
 		ver, err = updateTableInfo(t, job, tblInfo, originalState)
@@ -388,6 +396,98 @@ func (d *ddl) onDropIndex(t *meta.Meta, job *model.Job) (ver int64, _ error) {
 	return ver, errors.Trace(err)
 }
 
+// checkRenameIndex checks the index exists and the new name isn't used, the primary key can't be renamed.
+func checkRenameIndex(tblInfo *model.TableInfo, from, to model.CIStr) error {
+	for _, name := range []model.CIStr{from, to} {
+		if name.L == strings.ToLower(mysql.PrimaryKeyName) {
+			return ErrWrongNameForIndex.GenByArgs(name.O)
+		}
+	}
+	if findIndexByName(from.L, tblInfo.Indices) == nil {
+		return ErrKeyDoesNotExist.GenByArgs(from.O, tblInfo.Name.O)
+	}
+	if from.L != to.L && findIndexByName(to.L, tblInfo.Indices) != nil {
+		return errDupKeyName.Gen("duplicate key name %s", to)
+	}
+	return nil
+}
+
+func (d *ddl) onRenameIndex(t *meta.Meta, job *model.Job) (ver int64, _ error) {
+	var from, to model.CIStr
+	if err := job.DecodeArgs(&from, &to); err != nil {
+		job.State = model.JobCancelled
+		return ver, errors.Trace(err)
+	}
+	tblInfo, err := getTableInfo(t, job, job.SchemaID)
+	if err != nil {
+		return ver, errors.Trace(err)
+	}
+	if err = checkRenameIndex(tblInfo, from, to); err != nil {
+		job.State = model.JobCancelled
+		return ver, errors.Trace(err)
+	}
+	findIndexByName(from.L, tblInfo.Indices).Name = to
+
+	ver, err = updateSchemaVersion(t, job)
+	if err != nil {
+		return ver, errors.Trace(err)
+	}
+	err = t.UpdateTable(job.SchemaID, tblInfo)
+	if err != nil {
+		job.State = model.JobCancelled
+		return ver, errors.Trace(err)
+	}
+	job.State = model.JobDone
+	job.SchemaState = model.StatePublic
+	job.BinlogInfo.AddTableInfo(ver, tblInfo)
+	return ver, nil
+}
+
+// checkAlterIndexVisibility returns the index whose visibility is changed, the primary key can't be invisible.
+func checkAlterIndexVisibility(tblInfo *model.TableInfo, name model.CIStr, invisible bool) (*model.IndexInfo, error) {
+	if invisible && name.L == strings.ToLower(mysql.PrimaryKeyName) {
+		return nil, ErrPKIndexCantBeInvisible
+	}
+	indexInfo := findIndexByName(name.L, tblInfo.Indices)
+	if indexInfo == nil || indexInfo.State != model.StatePublic {
+		return nil, ErrKeyDoesNotExist.GenByArgs(name.O, tblInfo.Name.O)
+	}
+	return indexInfo, nil
+}
+
+func (d *ddl) onAlterIndexVisibility(t *meta.Meta, job *model.Job) (ver int64, _ error) {
+	var name model.CIStr
+	var invisible bool
+	if err := job.DecodeArgs(&name, &invisible); err != nil {
+		job.State = model.JobCancelled
+		return ver, errors.Trace(err)
+	}
+	tblInfo, err := getTableInfo(t, job, job.SchemaID)
+	if err != nil {
+		return ver, errors.Trace(err)
+	}
+	indexInfo, err := checkAlterIndexVisibility(tblInfo, name, invisible)
+	if err != nil {
+		job.State = model.JobCancelled
+		return ver, errors.Trace(err)
+	}
+	indexInfo.Invisible = invisible
+
+	ver, err = updateSchemaVersion(t, job)
+	if err != nil {
+		return ver, errors.Trace(err)
+	}
+	err = t.UpdateTable(job.SchemaID, tblInfo)
+	if err != nil {
+		job.State = model.JobCancelled
+		return ver, errors.Trace(err)
+	}
+	job.State = model.JobDone
+	job.SchemaState = model.StatePublic
+	job.BinlogInfo.AddTableInfo(ver, tblInfo)
+	return ver, nil
+}
+
 func (d *ddl) fetchRowColVals(txn kv.Transaction, t table.Table, taskOpInfo *indexTaskOpInfo, handleInfo *handleInfo) (
 	[]*indexRecord, *taskResult) {
 	startTime := time.Now()
@@ -447,32 +547,58 @@ func (d *ddl) getIndexRecords(t table.Table, taskOpInfo *indexTaskOpInfo, rawRec
 			return errors.Trace(err)
 		}
 		idxVal := make([]types.Datum, len(idxInfo.Columns))
-		for j, v := range idxInfo.Columns {
-			col := cols[v.Offset]
-			if col.IsPKHandleColumn(t.Meta()) {
-				if mysql.HasUnsignedFlag(col.Flag) {
-					idxVal[j].SetUint64(uint64(idxRecord.handle))
-				} else {
-					idxVal[j].SetInt64(idxRecord.handle)
+		if taskOpInfo.hasHiddenCols {
+			// The hidden columns of the expression index are computed from the columns they depend on.
+			row := make([]types.Datum, len(cols))
+			for _, col := range cols {
+				if _, ok := taskOpInfo.colMap[col.ID]; !ok && !col.IsPKHandleColumn(t.Meta()) {
+					continue
+				}
+				row[col.Offset], err = getRowColumnVal(ctx, t, col, rowMap, idxRecord.handle, defaultVals)
+				if err != nil {
+					return errors.Trace(err)
 				}
-				continue
 			}
-			idxColumnVal := rowMap[col.ID]
-			if _, ok := rowMap[col.ID]; ok {
-				idxVal[j] = idxColumnVal
-				continue
+			if err = tables.FillHiddenColumns(ctx, t, row); err != nil {
+				return errors.Trace(err)
+			}
+			for j, v := range idxInfo.Columns {
+				idxVal[j] = row[v.Offset]
 			}
-			idxColumnVal, err = tables.GetColDefaultValue(ctx, col, defaultVals)
+			idxRecord.vals = idxVal
+			continue
+		}
+		for j, v := range idxInfo.Columns {
+			idxVal[j], err = getRowColumnVal(ctx, t, cols[v.Offset], rowMap, idxRecord.handle, defaultVals)
 			if err != nil {
 				return errors.Trace(err)
 			}
-			idxVal[j] = idxColumnVal
 		}
 		idxRecord.vals = idxVal
 	}
 	return nil
 }
 
+// getRowColumnVal gets the value of the column from the decoded row, the default value is used if the
+// column isn't in the row.
+func getRowColumnVal(ctx context.Context, t table.Table, col *table.Column, rowMap map[int64]types.Datum, handle int64,
+	defaultVals []types.Datum) (types.Datum, error) {
+	var val types.Datum
+	if col.IsPKHandleColumn(t.Meta()) {
+		if mysql.HasUnsignedFlag(col.Flag) {
+			val.SetUint64(uint64(handle))
+		} else {
+			val.SetInt64(handle)
+		}
+		return val, nil
+	}
+	if val, ok := rowMap[col.ID]; ok {
+		return val, nil
+	}
+	val, err := tables.GetColDefaultValue(ctx, col, defaultVals)
+	return val, errors.Trace(err)
+}
+
 const (
 	defaultBatchCnt      = 1024
 	defaultSmallBatchCnt = 128
@@ -502,10 +628,13 @@ type indexRecord struct {
 
 // indexTaskOpInfo records the information that is needed in the task.
 type indexTaskOpInfo struct {
-	tblIndex  table.Index
-	colMap    map[int64]*types.FieldType // It's the index columns map.
-	taskRetCh chan *taskResult           // Get the results of all tasks.
-	nextCh    chan int64                 // It notifies to start the next task.
+	tblIndex table.Index
+	colMap   map[int64]*types.FieldType // It's the index columns map.
+	// hasHiddenCols indicates the index is an expression index, the columns which the hidden columns
+	// depend on are in colMap.
+	hasHiddenCols bool
+	taskRetCh     chan *taskResult // Get the results of all tasks.
+	nextCh        chan int64       // It notifies to start the next task.
 }
 
 // addTableIndex adds index into table.
@@ -517,11 +646,14 @@ type indexTaskOpInfo struct {
 // Real concurrent processing needs to perform after the handle range has been acquired.
 // The operation flow of the each task of data is as follows:
 //  1. Open a goroutine. Traverse the snapshot to obtain the handle range, while accessing the corresponding row key and
+//
 // raw index value. Then notify to start the next task.
 //  2. Decode this task of raw index value to get the corresponding index value.
 //  3. Deal with these index records one by one. If the index record exists, skip to the next row.
+//
 // If the index doesn't exist, create the index and then continue to handle the next row.
 //  4. When the handle of a range is completed, return the corresponding task result.
+//
 // The above operations are completed in a transaction.
 // When concurrent tasks are processed, the task result returned by each task is sorted by the handle. Then traverse the
 // task results, get the total number of rows in the concurrent task and update the processed handle value. If
@@ -530,16 +662,27 @@ type indexTaskOpInfo struct {
 func (d *ddl) addTableIndex(t table.Table, indexInfo *model.IndexInfo, reorgInfo *reorgInfo, job *model.Job) error {
 	cols := t.Cols()
 	colMap := make(map[int64]*types.FieldType)
+	hasHiddenCols := false
 	for _, v := range indexInfo.Columns {
 		col := cols[v.Offset]
 		colMap[col.ID] = &col.FieldType
+		if !col.Hidden {
+			continue
+		}
+		hasHiddenCols = true
+		for name := range col.Dependences {
+			if depCol := table.FindCol(cols, name); depCol != nil {
+				colMap[depCol.ID] = &depCol.FieldType
+			}
+		}
 	}
 	taskCnt := defaultTaskCnt
 	taskOpInfo := &indexTaskOpInfo{
-		tblIndex:  tables.NewIndex(t.Meta(), indexInfo),
-		colMap:    colMap,
-		nextCh:    make(chan int64, 1),
-		taskRetCh: make(chan *taskResult, taskCnt),
+		tblIndex:      tables.NewIndex(t.Meta(), indexInfo),
+		colMap:        colMap,
+		hasHiddenCols: hasHiddenCols,
+		nextCh:        make(chan int64, 1),
+		taskRetCh:     make(chan *taskResult, taskCnt),
 	}
 
 	addedCount := job.GetRowCount()
@@ -585,6 +728,7 @@ func (d *ddl) addTableIndex(t table.Table, indexInfo *model.IndexInfo, reorgInfo
 		}
 		d.setReorgRowCount(addedCount)
 		batchHandleDataHistogram.WithLabelValues(batchAddIdx).Observe(sub)
+		reorgRowsCounter.WithLabelValues(batchAddIdx).Add(float64(taskAddedCount))
 		log.Infof("[ddl] total added index for %d rows, this task added index for %d rows, take time %v",
 			addedCount, taskAddedCount, sub)
 
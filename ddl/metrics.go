@@ -24,6 +24,14 @@ var (
 			Help:      "Gauge of jobs.",
 		}, []string{"action"})
 
+	jobQueueGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "tidb",
+			Subsystem: "ddl",
+			Name:      "job_queue_length",
+			Help:      "Length of the DDL job queues, it's only updated by the owner.",
+		}, []string{"type"})
+
 	// handle job result state.
 	handleJobSucc      = "handle_job_succ"
 	handleJobFailed    = "handle_job_failed"
@@ -48,10 +56,21 @@ var (
 			Help:      "Bucketed histogram of processing time (s) of batch handle data",
 			Buckets:   prometheus.ExponentialBuckets(0.001, 2, 20),
 		}, []string{"handle_data_type"})
+
+	// reorgRowsCounter counts the rows handled in the reorganization, its rate is the reorganization speed.
+	reorgRowsCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "tidb",
+			Subsystem: "ddl",
+			Name:      "reorg_rows_total",
+			Help:      "Counter of rows handled in the reorganization.",
+		}, []string{"handle_data_type"})
 )
 
 func init() {
 	prometheus.MustRegister(jobsGauge)
 	prometheus.MustRegister(handleJobHistogram)
 	prometheus.MustRegister(batchHandleDataHistogram)
+	prometheus.MustRegister(jobQueueGauge)
+	prometheus.MustRegister(reorgRowsCounter)
 }
@@ -32,13 +32,15 @@ var _ SchemaSyncer = &mockSchemaSyncer{}
 type mockOwnerManager struct {
 	ddlOwner int32
 	ddlID    string // id is the ID of DDL.
+	key      string // key is the owner path.
 	cancel   goctx.CancelFunc
 }
 
 // NewMockOwnerManager creates a new mock OwnerManager.
-func NewMockOwnerManager(id string, cancel goctx.CancelFunc) OwnerManager {
+func NewMockOwnerManager(id, key string, cancel goctx.CancelFunc) OwnerManager {
 	return &mockOwnerManager{
 		ddlID:  id,
+		key:    key,
 		cancel: cancel,
 	}
 }
@@ -69,7 +71,7 @@ func (m *mockOwnerManager) Cancel() {
 
 // GetOwnerID implements OwnerManager.GetOwnerID interface.
 func (m *mockOwnerManager) GetOwnerID(ctx goctx.Context, key string) (string, error) {
-	if key != DDLOwnerKey {
+	if key != m.key {
 		return "", errors.New("invalid owner key")
 	}
 	if m.IsOwner() {
@@ -89,6 +91,8 @@ const mockCheckVersInterval = 2 * time.Millisecond
 type mockSchemaSyncer struct {
 	selfSchemaVersion int64
 	globalVerCh       chan clientv3.WatchResponse
+	// minDDLVersion is the min DDL version of the mocked cluster, it's model.CurrentDDLVersion if it's 0.
+	minDDLVersion int64
 }
 
 // NewMockSchemaSyncer creates a new mock SchemaSyncer.
@@ -133,6 +137,14 @@ func (s *mockSchemaSyncer) OwnerUpdateGlobalVersion(ctx goctx.Context, version i
 	return nil
 }
 
+// MinDDLVersion implements SchemaSyncer.MinDDLVersion interface.
+func (s *mockSchemaSyncer) MinDDLVersion(ctx goctx.Context) (int64, error) {
+	if ver := atomic.LoadInt64(&s.minDDLVersion); ver != 0 {
+		return ver, nil
+	}
+	return model.CurrentDDLVersion, nil
+}
+
 // OwnerCheckAllVersions implements SchemaSyncer.OwnerCheckAllVersions interface.
 func (s *mockSchemaSyncer) OwnerCheckAllVersions(ctx goctx.Context, latestVer int64) error {
 	ticker := time.NewTicker(mockCheckVersInterval)
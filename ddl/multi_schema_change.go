@@ -0,0 +1,217 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ddl
+
+import (
+	"fmt"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/infoschema"
+	"github.com/pingcap/tidb/meta"
+	"github.com/pingcap/tidb/model"
+)
+
+// onMultiSchemaChange runs the sub-jobs one by one, every step of a sub-job is a step of the job.
+// If a sub-job fails, the job is converted to a rollback job which drops the added columns and
+// indices in the reverse order. The dropped columns and indices can't be restored, so all the drop
+// sub-jobs are checked before the first one runs, the job is rolled back if any of them would fail.
+func (d *ddl) onMultiSchemaChange(t *meta.Meta, job *model.Job) (ver int64, err error) {
+	if job.State == model.JobRollback {
+		return d.rollbackMultiSchemaChange(t, job)
+	}
+
+	subJobs := job.MultiSchemaInfo.SubJobs
+	for i, sub := range subJobs {
+		if sub.State == model.JobDone {
+			continue
+		}
+		if sub.State == model.JobNone && i > 0 {
+			// The reorganization handle is saved by the job ID, so the handle of the previous
+			// sub-job is removed.
+			if err = t.RemoveDDLReorgHandle(job); err != nil {
+				return ver, errors.Trace(err)
+			}
+		}
+
+		if sub.State == model.JobNone && isDropSubJob(sub) && (i == 0 || !isDropSubJob(subJobs[i-1])) {
+			tblInfo, err := t.GetTable(job.SchemaID, job.TableID)
+			if err != nil {
+				return ver, errors.Trace(err)
+			}
+			if err = checkDropSubJobs(tblInfo, job, subJobs[i:]); err != nil {
+				sub.State = model.JobCancelled
+				if i == 0 {
+					job.State = model.JobCancelled
+				} else {
+					job.State = model.JobRollback
+				}
+				return ver, errors.Trace(err)
+			}
+		}
+
+		ver, err = d.runSubJob(t, job, sub)
+		job.SchemaState = sub.SchemaState
+		switch sub.State {
+		case model.JobCancelled:
+			if i == 0 {
+				job.State = model.JobCancelled
+			} else {
+				job.State = model.JobRollback
+			}
+		case model.JobRollbackDone:
+			job.State = model.JobRollback
+		case model.JobDone:
+			if i == len(subJobs)-1 {
+				job.State = model.JobDone
+			}
+		}
+		return ver, errors.Trace(err)
+	}
+	// All the sub-jobs are done, it only happens if the job is empty.
+	job.State = model.JobDone
+	return ver, nil
+}
+
+// rollbackMultiSchemaChange drops the columns and indices added by the sub-jobs which are done, the
+// dropped columns and indices can't be restored, but they are dropped after all the others are added
+// and checked by checkDropSubJobs.
+func (d *ddl) rollbackMultiSchemaChange(t *meta.Meta, job *model.Job) (ver int64, err error) {
+	subJobs := job.MultiSchemaInfo.SubJobs
+	for i := len(subJobs) - 1; i >= 0; i-- {
+		sub := subJobs[i]
+		if sub.State == model.JobDone {
+			if err = convertSubJobToRollback(job, sub); err != nil {
+				return ver, errors.Trace(err)
+			}
+		}
+		if sub.State != model.JobRollback {
+			continue
+		}
+
+		ver, err = d.runSubJob(t, job, sub)
+		job.SchemaState = sub.SchemaState
+		if sub.State == model.JobDone {
+			sub.State = model.JobRollbackDone
+		}
+		return ver, errors.Trace(err)
+	}
+	job.State = model.JobRollbackDone
+	job.SchemaState = model.StateNone
+	return ver, nil
+}
+
+func isDropSubJob(sub *model.SubJob) bool {
+	return sub.Type == model.ActionDropColumn || sub.Type == model.ActionDropIndex
+}
+
+// checkDropSubJobs checks that the drop sub-jobs can be done in order on the table, tblInfo is loaded
+// from the meta by the caller, the sub-jobs are applied to it one by one.
+func checkDropSubJobs(tblInfo *model.TableInfo, job *model.Job, subJobs []*model.SubJob) error {
+	if tblInfo == nil {
+		return infoschema.ErrTableNotExists.GenByArgs(fmt.Sprintf("(Schema ID %d)", job.SchemaID),
+			fmt.Sprintf("(Table ID %d)", job.TableID))
+	}
+	for _, sub := range subJobs {
+		var name model.CIStr
+		if err := sub.ToProxyJob(job).DecodeArgs(&name); err != nil {
+			return errors.Trace(err)
+		}
+		switch sub.Type {
+		case model.ActionDropColumn:
+			if findCol(tblInfo.Columns, name.L) == nil {
+				return ErrCantDropFieldOrKey.Gen("column %s doesn't exist", name)
+			}
+			if err := isDroppableColumn(tblInfo, name); err != nil {
+				return errors.Trace(err)
+			}
+			newColumns := make([]*model.ColumnInfo, 0, len(tblInfo.Columns))
+			for _, col := range tblInfo.Columns {
+				if col.Name.L != name.L {
+					newColumns = append(newColumns, col)
+				}
+			}
+			tblInfo.Columns = newColumns
+		case model.ActionDropIndex:
+			indexInfo := findIndexByName(name.L, tblInfo.Indices)
+			if indexInfo == nil {
+				return ErrCantDropFieldOrKey.Gen("index %s doesn't exist", name)
+			}
+			newIndices := make([]*model.IndexInfo, 0, len(tblInfo.Indices))
+			for _, idx := range tblInfo.Indices {
+				if idx.Name.L != name.L {
+					newIndices = append(newIndices, idx)
+				}
+			}
+			tblInfo.Indices = newIndices
+			dropHiddenColumns(tblInfo, indexInfo)
+		}
+	}
+	return nil
+}
+
+// convertSubJobToRollback converts a sub-job which is done to drop the added column or index.
+func convertSubJobToRollback(job *model.Job, sub *model.SubJob) error {
+	proxy := sub.ToProxyJob(job)
+	var name model.CIStr
+	switch sub.Type {
+	case model.ActionAddColumn:
+		col := &model.ColumnInfo{}
+		if err := proxy.DecodeArgs(col); err != nil {
+			return errors.Trace(err)
+		}
+		name = col.Name
+		sub.Type = model.ActionDropColumn
+	case model.ActionAddIndex:
+		var unique bool
+		if err := proxy.DecodeArgs(&unique, &name); err != nil {
+			return errors.Trace(err)
+		}
+		sub.Type = model.ActionDropIndex
+	default:
+		// The column or index is dropped, it can't be restored.
+		return nil
+	}
+	if err := sub.UpdateArgs(name); err != nil {
+		return errors.Trace(err)
+	}
+	sub.State = model.JobRollback
+	sub.SchemaState = model.StatePublic
+	sub.SnapshotVer = 0
+	return nil
+}
+
+// runSubJob runs a step of the sub-job by the handler of its type.
+func (d *ddl) runSubJob(t *meta.Meta, job *model.Job, sub *model.SubJob) (ver int64, err error) {
+	proxy := sub.ToProxyJob(job)
+	if proxy.State == model.JobNone {
+		proxy.State = model.JobRunning
+	}
+	switch proxy.Type {
+	case model.ActionAddColumn:
+		ver, err = d.onAddColumn(t, proxy)
+	case model.ActionDropColumn:
+		ver, err = d.onDropColumn(t, proxy)
+	case model.ActionAddIndex:
+		ver, err = d.onCreateIndex(t, proxy)
+	case model.ActionDropIndex:
+		ver, err = d.onDropIndex(t, proxy)
+	default:
+		proxy.State = model.JobCancelled
+		err = errInvalidDDLJob.Gen("invalid sub-job %v", proxy)
+	}
+	if err1 := sub.FromProxyJob(job, proxy); err1 != nil {
+		return ver, errors.Trace(err1)
+	}
+	return ver, errors.Trace(err)
+}
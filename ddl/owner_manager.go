@@ -18,6 +18,7 @@ import (
 	"math"
 	"os"
 	"strconv"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -42,9 +43,9 @@ type OwnerManager interface {
 	SetOwner(isOwner bool)
 	// GetOwnerID gets the owner ID.
 	GetOwnerID(ctx goctx.Context, ownerKey string) (string, error)
-	// CampaignOwners campaigns the DDL owner and the background owner.
+	// CampaignOwners campaigns the owner of the key.
 	CampaignOwners(ctx goctx.Context) error
-	// Cancel cancels this etcd ownerManager campaign.
+	// Cancel cancels this etcd ownerManager campaign, it returns after the owner lease is revoked.
 	Cancel()
 }
 
@@ -59,15 +60,19 @@ const (
 type ownerManager struct {
 	ddlOwner int32
 	ddlID    string // id is the ID of DDL.
+	key      string // key is the owner path in etcd.
 	etcdCli  *clientv3.Client
 	cancel   goctx.CancelFunc
+	wg       sync.WaitGroup // wg waits for the campaign loop to exit.
 }
 
-// NewOwnerManager creates a new OwnerManager.
-func NewOwnerManager(etcdCli *clientv3.Client, id string, cancel goctx.CancelFunc) OwnerManager {
+// NewOwnerManager creates a new OwnerManager which campaigns the owner of the key, so other
+// cluster singletons can be elected like the DDL owner.
+func NewOwnerManager(etcdCli *clientv3.Client, id, key string, cancel goctx.CancelFunc) OwnerManager {
 	return &ownerManager{
 		etcdCli: etcdCli,
 		ddlID:   id,
+		key:     key,
 		cancel:  cancel,
 	}
 }
@@ -94,6 +99,7 @@ func (m *ownerManager) SetOwner(isOwner bool) {
 // Cancel implements OwnerManager.Cancel interface.
 func (m *ownerManager) Cancel() {
 	m.cancel()
+	m.wg.Wait()
 }
 
 // ManagerSessionTTL is the etcd session's TTL in seconds. It's exported for testing.
@@ -134,16 +140,18 @@ func newSession(ctx goctx.Context, flag string, etcdCli *clientv3.Client, retryC
 
 // CampaignOwners implements OwnerManager.CampaignOwners interface.
 func (m *ownerManager) CampaignOwners(ctx goctx.Context) error {
-	ddlSession, err := newSession(ctx, DDLOwnerKey, m.etcdCli, newSessionDefaultRetryCnt, ManagerSessionTTL)
+	ddlSession, err := newSession(ctx, m.key, m.etcdCli, newSessionDefaultRetryCnt, ManagerSessionTTL)
 	if err != nil {
 		return errors.Trace(err)
 	}
 	ddlCtx, _ := goctx.WithCancel(ctx)
-	go m.campaignLoop(ddlCtx, ddlSession, DDLOwnerKey)
+	m.wg.Add(1)
+	go m.campaignLoop(ddlCtx, ddlSession, m.key)
 	return nil
 }
 
 func (m *ownerManager) campaignLoop(ctx goctx.Context, etcdSession *concurrency.Session, key string) {
+	defer m.wg.Done()
 	idInfo := fmt.Sprintf("%s ownerManager %s", key, m.ddlID)
 	var err error
 	for {
@@ -230,7 +238,7 @@ func GetOwnerInfo(ctx goctx.Context, elec *concurrency.Election, key, id string)
 }
 
 func (m *ownerManager) setOwnerVal(key string, val bool) {
-	if key == DDLOwnerKey {
+	if key == m.key {
 		m.SetOwner(val)
 	}
 }
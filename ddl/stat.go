@@ -17,12 +17,16 @@ import (
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/inspectkv"
 	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/sessionctx/variable"
+	goctx "golang.org/x/net/context"
 )
 
 var (
 	serverID             = "server_id"
 	ddlSchemaVersion     = "ddl_schema_version"
+	ddlVersion           = "ddl_version"
+	ddlClusterVersion    = "ddl_cluster_version"
 	ddlOwnerID           = "ddl_owner_id"
 	ddlOwnerLastUpdateTS = "ddl_owner_last_update_ts"
 	ddlJobID             = "ddl_job_id"
@@ -64,24 +68,34 @@ func (d *ddl) Stats(vars *variable.SessionVars) (map[string]interface{}, error)
 	}
 
 	m[ddlSchemaVersion] = ddlInfo.SchemaVer
+	m[ddlVersion] = model.CurrentDDLVersion
+	// The cluster DDL version is the min DDL version of all the servers, the jobs needing a higher version are refused.
+	if minVer, err := d.schemaSyncer.MinDDLVersion(goctx.Background()); err == nil {
+		m[ddlClusterVersion] = minVer
+	}
 	// TODO: Get the owner information.
-	if ddlInfo.Job != nil {
-		m[ddlJobID] = ddlInfo.Job.ID
-		m[ddlJobAction] = ddlInfo.Job.Type.String()
-		m[ddlJobLastUpdateTS] = ddlInfo.Job.LastUpdateTS / 1e9
-		m[ddlJobState] = ddlInfo.Job.State.String()
-		m[ddlJobRows] = ddlInfo.Job.RowCount
-		if ddlInfo.Job.Error == nil {
+	// The job of the reorganization job queue may run for a long time, it is shown if it exists.
+	job := ddlInfo.ReorgJob
+	if job == nil {
+		job = ddlInfo.Job
+	}
+	if job != nil {
+		m[ddlJobID] = job.ID
+		m[ddlJobAction] = job.Type.String()
+		m[ddlJobLastUpdateTS] = job.LastUpdateTS / 1e9
+		m[ddlJobState] = job.State.String()
+		m[ddlJobRows] = job.RowCount
+		if job.Error == nil {
 			m[ddlJobError] = ""
 		} else {
-			m[ddlJobError] = ddlInfo.Job.Error.Error()
+			m[ddlJobError] = job.Error.Error()
 		}
-		m[ddlJobSchemaState] = ddlInfo.Job.SchemaState.String()
-		m[ddlJobSchemaID] = ddlInfo.Job.SchemaID
-		m[ddlJobTableID] = ddlInfo.Job.TableID
-		m[ddlJobSnapshotVer] = ddlInfo.Job.SnapshotVer
+		m[ddlJobSchemaState] = job.SchemaState.String()
+		m[ddlJobSchemaID] = job.SchemaID
+		m[ddlJobTableID] = job.TableID
+		m[ddlJobSnapshotVer] = job.SnapshotVer
 		m[ddlJobReorgHandle] = ddlInfo.ReorgHandle
-		m[ddlJobArgs] = ddlInfo.Job.Args
+		m[ddlJobArgs] = job.Args
 	}
 	return m, nil
 }
@@ -17,12 +17,14 @@ import (
 	"fmt"
 	"math"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/coreos/etcd/clientv3"
 	"github.com/coreos/etcd/clientv3/concurrency"
 	"github.com/juju/errors"
 	"github.com/ngaut/log"
+	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/terror"
 	goctx "golang.org/x/net/context"
 )
@@ -31,6 +33,9 @@ const (
 	// DDLAllSchemaVersions is the path on etcd that is used to store all servers current schema versions.
 	// It's exported for testing.
 	DDLAllSchemaVersions = "/tidb/ddl/all_schema_versions"
+	// DDLAllDDLVersions is the path on etcd that is used to store the DDL versions of all the servers, the servers
+	// which don't register the DDL versions are at model.DDLVersion1.
+	DDLAllDDLVersions = "/tidb/ddl/all_ddl_versions"
 	// DDLGlobalSchemaVersion is the path on etcd that is used to store the latest schema versions.
 	// It's exported for testing.
 	DDLGlobalSchemaVersion = "/tidb/ddl/global_schema_version"
@@ -71,6 +76,8 @@ type SchemaSyncer interface {
 	Done() <-chan struct{}
 	// Restart restarts the syncer when it's on longer being refreshed.
 	Restart(ctx goctx.Context) error
+	// MinDDLVersion returns the min DDL version of all the servers in the cluster.
+	MinDDLVersion(ctx goctx.Context) (int64, error)
 	// OwnerCheckAllVersions checks whether all followers' schema version are equal to
 	// the latest schema version. If the result is false, wait for a while and check again util the processing time reach 2 * lease.
 	// It returns until all servers' versions are equal to the latest version or the ctx is done.
@@ -79,6 +86,7 @@ type SchemaSyncer interface {
 
 type schemaVersionSyncer struct {
 	selfSchemaVerPath string
+	selfDDLVerPath    string
 	etcdCli           *clientv3.Client
 	session           *concurrency.Session
 	globalVerCh       clientv3.WatchChan
@@ -89,6 +97,7 @@ func NewSchemaSyncer(etcdCli *clientv3.Client, id string) SchemaSyncer {
 	return &schemaVersionSyncer{
 		etcdCli:           etcdCli,
 		selfSchemaVerPath: fmt.Sprintf("%s/%s", DDLAllSchemaVersions, id),
+		selfDDLVerPath:    fmt.Sprintf("%s/%s", DDLAllDDLVersions, id),
 	}
 }
 
@@ -129,6 +138,11 @@ func (s *schemaVersionSyncer) Init(ctx goctx.Context) error {
 		return errors.Trace(err)
 	}
 	s.globalVerCh = s.etcdCli.Watch(ctx, DDLGlobalSchemaVersion)
+	err = s.putKV(ctx, keyOpDefaultRetryCnt, s.selfDDLVerPath, strconv.FormatInt(model.CurrentDDLVersion, 10),
+		clientv3.WithLease(s.session.Lease()))
+	if err != nil {
+		return errors.Trace(err)
+	}
 	return s.putKV(ctx, keyOpDefaultRetryCnt, s.selfSchemaVerPath, InitialVersion,
 		clientv3.WithLease(s.session.Lease()))
 }
@@ -146,6 +160,11 @@ func (s *schemaVersionSyncer) Restart(ctx goctx.Context) error {
 		return errors.Trace(err)
 	}
 	s.session = session
+	err = s.putKV(ctx, putKeyRetryUnlimited, s.selfDDLVerPath, strconv.FormatInt(model.CurrentDDLVersion, 10),
+		clientv3.WithLease(s.session.Lease()))
+	if err != nil {
+		return errors.Trace(err)
+	}
 	return s.putKV(ctx, putKeyRetryUnlimited, s.selfSchemaVerPath, InitialVersion,
 		clientv3.WithLease(s.session.Lease()))
 }
@@ -170,20 +189,64 @@ func (s *schemaVersionSyncer) OwnerUpdateGlobalVersion(ctx goctx.Context, versio
 
 // RemoveSelfVersionPath implements SchemaSyncer.RemoveSelfVersionPath interface.
 func (s *schemaVersionSyncer) RemoveSelfVersionPath() error {
+	if err := s.removeKey(s.selfDDLVerPath); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(s.removeKey(s.selfSchemaVerPath))
+}
+
+func (s *schemaVersionSyncer) removeKey(key string) error {
 	ctx := goctx.Background()
 	var err error
 	for i := 0; i < keyOpDefaultRetryCnt; i++ {
 		childCtx, cancel := goctx.WithTimeout(ctx, keyOpDefaultTimeout)
-		_, err = s.etcdCli.Delete(childCtx, s.selfSchemaVerPath)
+		_, err = s.etcdCli.Delete(childCtx, key)
 		cancel()
 		if err == nil {
 			return nil
 		}
-		log.Warnf("remove schema version path %s failed %v no.%d", s.selfSchemaVerPath, err, i)
+		log.Warnf("remove path %s failed %v no.%d", key, err, i)
 	}
 	return errors.Trace(err)
 }
 
+// MinDDLVersion implements SchemaSyncer.MinDDLVersion interface.
+// Every server registers its schema version, a server whose DDL version isn't registered is at model.DDLVersion1.
+func (s *schemaVersionSyncer) MinDDLVersion(ctx goctx.Context) (int64, error) {
+	childCtx, cancel := goctx.WithTimeout(ctx, keyOpDefaultTimeout)
+	schemaResp, err := s.etcdCli.Get(childCtx, DDLAllSchemaVersions+"/", clientv3.WithPrefix())
+	cancel()
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	childCtx, cancel = goctx.WithTimeout(ctx, keyOpDefaultTimeout)
+	ddlResp, err := s.etcdCli.Get(childCtx, DDLAllDDLVersions+"/", clientv3.WithPrefix())
+	cancel()
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+
+	ddlVers := make(map[string]int64, len(ddlResp.Kvs))
+	for _, kv := range ddlResp.Kvs {
+		ver, err := strconv.ParseInt(string(kv.Value), 10, 64)
+		if err != nil {
+			return 0, errors.Errorf("invalid DDL version %q of %s", kv.Value, kv.Key)
+		}
+		ddlVers[strings.TrimPrefix(string(kv.Key), DDLAllDDLVersions+"/")] = ver
+	}
+	minVer := model.CurrentDDLVersion
+	for _, kv := range schemaResp.Kvs {
+		ver, ok := ddlVers[strings.TrimPrefix(string(kv.Key), DDLAllSchemaVersions+"/")]
+		if !ok {
+			ver = model.DDLVersion1
+		}
+		if ver < minVer {
+			minVer = ver
+		}
+	}
+	return minVer, nil
+}
+
 func isContextFinished(err error) bool {
 	if terror.ErrorEqual(err, goctx.Canceled) ||
 		terror.ErrorEqual(err, goctx.DeadlineExceeded) {
@@ -17,6 +17,7 @@ import (
 	"fmt"
 
 	"github.com/juju/errors"
+	"github.com/ngaut/log"
 	"github.com/pingcap/tidb/infoschema"
 	"github.com/pingcap/tidb/meta"
 	"github.com/pingcap/tidb/meta/autoid"
@@ -132,13 +133,16 @@ func (d *ddl) getTable(schemaID int64, tblInfo *model.TableInfo) (table.Table, e
 	if tblInfo.OldSchemaID != 0 {
 		schemaID = tblInfo.OldSchemaID
 	}
-	alloc := autoid.NewAllocator(d.store, schemaID)
+	alloc := autoid.NewAllocatorWithCache(d.store, schemaID, tblInfo.AutoIDCache)
 	tbl, err := table.TableFromMeta(alloc, tblInfo)
 	return tbl, errors.Trace(err)
 }
 
 func getTableInfo(t *meta.Meta, job *model.Job, schemaID int64) (*model.TableInfo, error) {
-	tableID := job.TableID
+	return getTableInfoByID(t, job, schemaID, job.TableID)
+}
+
+func getTableInfoByID(t *meta.Meta, job *model.Job, schemaID, tableID int64) (*model.TableInfo, error) {
 	tblInfo, err := t.GetTable(schemaID, tableID)
 	if err != nil {
 		if terror.ErrorEqual(err, meta.ErrDBNotExists) {
@@ -250,6 +254,196 @@ func (d *ddl) onRenameTable(t *meta.Meta, job *model.Job) (ver int64, _ error) {
 	return ver, nil
 }
 
+// onRenameTables renames the tables at once. All the tables are dropped before they are created
+// with the new names, so the tables can swap their names.
+func (d *ddl) onRenameTables(t *meta.Meta, job *model.Job) (ver int64, _ error) {
+	var oldSchemaIDs, newSchemaIDs, tableIDs []int64
+	var newNames []model.CIStr
+	if err := job.DecodeArgs(&oldSchemaIDs, &newSchemaIDs, &newNames, &tableIDs); err != nil {
+		// Invalid arguments, cancel this job.
+		job.State = model.JobCancelled
+		return ver, errors.Trace(err)
+	}
+
+	tblInfos := make([]*model.TableInfo, 0, len(tableIDs))
+	renamed := make(map[int64]struct{}, len(tableIDs))
+	for i, tableID := range tableIDs {
+		tblInfo, err := getTableInfoByID(t, job, oldSchemaIDs[i], tableID)
+		if err != nil {
+			return ver, errors.Trace(err)
+		}
+		tblInfos = append(tblInfos, tblInfo)
+		renamed[tableID] = struct{}{}
+	}
+	// Check the new names before any table is changed, only the tables which are not renamed
+	// can conflict with the new names.
+	for i, name := range newNames {
+		tables, err := t.ListTables(newSchemaIDs[i])
+		if err != nil {
+			if terror.ErrorEqual(err, meta.ErrDBNotExists) {
+				job.State = model.JobCancelled
+				return ver, infoschema.ErrDatabaseNotExists.GenByArgs("")
+			}
+			return ver, errors.Trace(err)
+		}
+		for _, tbl := range tables {
+			if _, ok := renamed[tbl.ID]; !ok && tbl.Name.L == name.L {
+				job.State = model.JobCancelled
+				return ver, infoschema.ErrTableExists.GenByArgs(tbl.Name)
+			}
+		}
+	}
+
+	for i, tblInfo := range tblInfos {
+		err := t.DropTable(oldSchemaIDs[i], tblInfo.ID, false)
+		if err != nil {
+			job.State = model.JobCancelled
+			return ver, errors.Trace(err)
+		}
+	}
+	for i, tblInfo := range tblInfos {
+		if newSchemaIDs[i] != oldSchemaIDs[i] && tblInfo.OldSchemaID == 0 {
+			tblInfo.OldSchemaID = oldSchemaIDs[i]
+		}
+		tblInfo.Name = newNames[i]
+		err := t.CreateTable(newSchemaIDs[i], tblInfo)
+		if err != nil {
+			job.State = model.JobCancelled
+			return ver, errors.Trace(err)
+		}
+	}
+
+	ver, err := updateSchemaVersion(t, job)
+	if err != nil {
+		return ver, errors.Trace(err)
+	}
+	job.State = model.JobDone
+	job.SchemaState = model.StatePublic
+	job.BinlogInfo.AddTableInfo(ver, tblInfos[len(tblInfos)-1])
+	return ver, nil
+}
+
+// onLockTable adds the session to the lock of the table. A READ lock is shared with the other
+// sessions holding READ locks, and a lock held only by the session itself can be changed.
+func (d *ddl) onLockTable(t *meta.Meta, job *model.Job) (ver int64, _ error) {
+	var tp model.TableLockType
+	var session model.SessionInfo
+	if err := job.DecodeArgs(&tp, &session); err != nil {
+		job.State = model.JobCancelled
+		return ver, errors.Trace(err)
+	}
+	tblInfo, err := getTableInfo(t, job, job.SchemaID)
+	if err != nil {
+		return ver, errors.Trace(err)
+	}
+
+	if err = checkTableLockConflict(tblInfo, tp, session); err != nil {
+		job.State = model.JobCancelled
+		return ver, errors.Trace(err)
+	}
+	var others []model.SessionInfo
+	if tblInfo.Lock != nil {
+		others = removeLockSession(tblInfo.Lock.Sessions, session)
+	}
+	tblInfo.Lock = &model.TableLockInfo{Tp: tp, Sessions: append(others, session)}
+
+	ver, err = updateSchemaVersion(t, job)
+	if err != nil {
+		return ver, errors.Trace(err)
+	}
+	err = t.UpdateTable(job.SchemaID, tblInfo)
+	if err != nil {
+		job.State = model.JobCancelled
+		return ver, errors.Trace(err)
+	}
+	job.State = model.JobDone
+	job.BinlogInfo.AddTableInfo(ver, tblInfo)
+	return ver, nil
+}
+
+// onUnlockTable removes the session from the lock of the table.
+func (d *ddl) onUnlockTable(t *meta.Meta, job *model.Job) (ver int64, _ error) {
+	var session model.SessionInfo
+	if err := job.DecodeArgs(&session); err != nil {
+		job.State = model.JobCancelled
+		return ver, errors.Trace(err)
+	}
+	tblInfo, err := getTableInfo(t, job, job.SchemaID)
+	if err != nil {
+		return ver, errors.Trace(err)
+	}
+
+	if tblInfo.Lock != nil {
+		tblInfo.Lock.Sessions = removeLockSession(tblInfo.Lock.Sessions, session)
+		if len(tblInfo.Lock.Sessions) == 0 {
+			tblInfo.Lock = nil
+		}
+	}
+
+	ver, err = updateSchemaVersion(t, job)
+	if err != nil {
+		return ver, errors.Trace(err)
+	}
+	err = t.UpdateTable(job.SchemaID, tblInfo)
+	if err != nil {
+		job.State = model.JobCancelled
+		return ver, errors.Trace(err)
+	}
+	job.State = model.JobDone
+	job.BinlogInfo.AddTableInfo(ver, tblInfo)
+	return ver, nil
+}
+
+// onCleanupTableLock removes the lock of the table held by all the sessions.
+func (d *ddl) onCleanupTableLock(t *meta.Meta, job *model.Job) (ver int64, _ error) {
+	tblInfo, err := getTableInfo(t, job, job.SchemaID)
+	if err != nil {
+		return ver, errors.Trace(err)
+	}
+
+	if tblInfo.Lock != nil {
+		log.Infof("[ddl] cleanup the %s lock of table %s held by %v", tblInfo.Lock.Tp, tblInfo.Name, tblInfo.Lock.Sessions)
+		tblInfo.Lock = nil
+	}
+
+	ver, err = updateSchemaVersion(t, job)
+	if err != nil {
+		return ver, errors.Trace(err)
+	}
+	err = t.UpdateTable(job.SchemaID, tblInfo)
+	if err != nil {
+		job.State = model.JobCancelled
+		return ver, errors.Trace(err)
+	}
+	job.State = model.JobDone
+	job.BinlogInfo.AddTableInfo(ver, tblInfo)
+	return ver, nil
+}
+
+// checkTableLockConflict checks whether the table is locked by the other sessions in conflict
+// with the lock of type tp.
+func checkTableLockConflict(tblInfo *model.TableInfo, tp model.TableLockType, session model.SessionInfo) error {
+	if tblInfo.Lock == nil {
+		return nil
+	}
+	others := removeLockSession(tblInfo.Lock.Sessions, session)
+	if len(others) > 0 && (tp == model.TableLockWrite || tblInfo.Lock.Tp == model.TableLockWrite) {
+		return infoschema.ErrTableLocked.GenByArgs(tblInfo.Name.O, tblInfo.Lock.Tp, others[0])
+	}
+	return nil
+}
+
+// removeLockSession returns the sessions except session.
+func removeLockSession(sessions []model.SessionInfo, session model.SessionInfo) []model.SessionInfo {
+	others := make([]model.SessionInfo, 0, len(sessions))
+	for _, s := range sessions {
+		if s != session {
+			others = append(others, s)
+		}
+	}
+	return others
+}
+
 func checkTableNotExists(t *meta.Meta, job *model.Job, schemaID int64, tableName string) error {
 	// Check this table's database.
 	tables, err := t.ListTables(schemaID)
@@ -0,0 +1,127 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package distsql
+
+import (
+	"hash/crc64"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/util/codec"
+	goctx "golang.org/x/net/context"
+)
+
+var crc64Table = crc64.MakeTable(crc64.ECMA)
+
+// ChecksumRequest is the request data of a checksum coprocessor request.
+type ChecksumRequest struct {
+	StartTs uint64
+}
+
+// Marshal encodes the request.
+func (r *ChecksumRequest) Marshal() []byte {
+	return codec.EncodeUint(nil, r.StartTs)
+}
+
+// Unmarshal decodes the request.
+func (r *ChecksumRequest) Unmarshal(data []byte) error {
+	_, startTS, err := codec.DecodeUint(data)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	r.StartTs = startTS
+	return nil
+}
+
+// ChecksumResponse is the checksum of the key-value pairs in some key ranges. The checksum is
+// the XOR of the CRC64 of every key-value pair, so the partial results of the regions can be
+// merged in any order.
+type ChecksumResponse struct {
+	Checksum   uint64
+	TotalKvs   uint64
+	TotalBytes uint64
+}
+
+// Update adds a key-value pair to the checksum.
+func (r *ChecksumResponse) Update(key, value []byte) {
+	h := crc64.New(crc64Table)
+	h.Write(key)
+	h.Write(value)
+	r.Checksum ^= h.Sum64()
+	r.TotalKvs++
+	r.TotalBytes += uint64(len(key) + len(value))
+}
+
+// Merge merges the checksum of other key ranges.
+func (r *ChecksumResponse) Merge(other *ChecksumResponse) {
+	r.Checksum ^= other.Checksum
+	r.TotalKvs += other.TotalKvs
+	r.TotalBytes += other.TotalBytes
+}
+
+// Marshal encodes the response.
+func (r *ChecksumResponse) Marshal() []byte {
+	data := codec.EncodeUint(nil, r.Checksum)
+	data = codec.EncodeUint(data, r.TotalKvs)
+	return codec.EncodeUint(data, r.TotalBytes)
+}
+
+// Unmarshal decodes the response.
+func (r *ChecksumResponse) Unmarshal(data []byte) error {
+	var err error
+	for _, v := range []*uint64{&r.Checksum, &r.TotalKvs, &r.TotalBytes} {
+		data, *v, err = codec.DecodeUint(data)
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// Checksum sends a checksum request, it returns the checksum of the key ranges at the snapshot
+// of startTS.
+func Checksum(client kv.Client, ctx goctx.Context, startTS uint64, keyRanges []kv.KeyRange, concurrency int, priority int) (*ChecksumResponse, error) {
+	kvReq := &kv.Request{
+		Tp:          kv.ReqTypeChecksum,
+		Data:        (&ChecksumRequest{StartTs: startTS}).Marshal(),
+		KeyRanges:   keyRanges,
+		Concurrency: concurrency,
+		Priority:    priority,
+	}
+	resp := client.Send(ctx, kvReq)
+	if resp == nil {
+		return nil, errors.New("client returns nil response")
+	}
+	defer resp.Close()
+
+	result := &ChecksumResponse{}
+	for {
+		data, err := resp.Next()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if data == nil {
+			return result, nil
+		}
+		// The response of a region without any data in the ranges is empty.
+		if len(data) == 0 {
+			continue
+		}
+		partial := &ChecksumResponse{}
+		if err = partial.Unmarshal(data); err != nil {
+			return nil, errors.Trace(err)
+		}
+		result.Merge(partial)
+	}
+}
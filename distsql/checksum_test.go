@@ -0,0 +1,49 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package distsql
+
+import (
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/util/testleak"
+)
+
+func (s *testDistsqlSuite) TestChecksum(c *C) {
+	defer testleak.AfterTest(c)()
+	req := &ChecksumRequest{StartTs: 100}
+	decodedReq := &ChecksumRequest{}
+	c.Assert(decodedReq.Unmarshal(req.Marshal()), IsNil)
+	c.Assert(decodedReq, DeepEquals, req)
+	c.Assert(decodedReq.Unmarshal(nil), NotNil)
+
+	kvs := [][2]string{{"a", "1"}, {"b", "22"}, {"c", "333"}}
+	all := &ChecksumResponse{}
+	for _, kv := range kvs {
+		all.Update([]byte(kv[0]), []byte(kv[1]))
+	}
+	c.Assert(all.TotalKvs, Equals, uint64(3))
+	c.Assert(all.TotalBytes, Equals, uint64(9))
+
+	// The partial results can be merged in any order.
+	first, second := &ChecksumResponse{}, &ChecksumResponse{}
+	first.Update([]byte(kvs[2][0]), []byte(kvs[2][1]))
+	second.Update([]byte(kvs[1][0]), []byte(kvs[1][1]))
+	second.Update([]byte(kvs[0][0]), []byte(kvs[0][1]))
+	merged := &ChecksumResponse{}
+	decoded := &ChecksumResponse{}
+	c.Assert(decoded.Unmarshal(first.Marshal()), IsNil)
+	merged.Merge(decoded)
+	merged.Merge(second)
+	c.Assert(merged, DeepEquals, all)
+	c.Assert(decoded.Unmarshal([]byte{1}), NotNil)
+}
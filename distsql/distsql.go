@@ -20,7 +20,10 @@ import (
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/tablecodec"
 	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/chunk"
+	"github.com/pingcap/tidb/util/codec"
 	"github.com/pingcap/tidb/util/types"
 	"github.com/pingcap/tipb/go-tipb"
 	goctx "golang.org/x/net/context"
@@ -41,16 +44,26 @@ type SelectResult interface {
 	Next() (PartialResult, error)
 	// Close closes the iterator.
 	Close() error
+	// SetFields sets the field types of the result columns, they are used by PartialResult.NextRow
+	// to decode the values in the time zone loc.
+	SetFields(fields []*types.FieldType, loc *time.Location)
 	// Fetch fetches partial results from client.
 	// The caller should call SetFields() before call Fetch().
 	Fetch(ctx goctx.Context)
+	// SkippedRanges returns the key ranges skipped for the unavailable regions if the request allows the
+	// partial result, it's called after Next returns nil.
+	SkippedRanges() []kv.KeyRange
 }
 
 // PartialResult is the result from a single region server.
 type PartialResult interface {
 	// Next returns the next rowData of the sub result.
 	// If no more row to return, rowData would be nil.
+	// It can not be used if the response is chunk encoded.
 	Next() (handle int64, rowData []byte, err error)
+	// NextRow decodes the first len(row) values of the next row of the sub result into row,
+	// and returns the handle of the row. If no more row to return, ok would be false.
+	NextRow(row []types.Datum) (handle int64, ok bool, err error)
 	// Close closes the partial result.
 	Close() error
 }
@@ -60,6 +73,8 @@ type selectResult struct {
 	label     string
 	aggregate bool
 	resp      kv.Response
+	fields    []*types.FieldType
+	loc       *time.Location
 
 	results chan resultWithErr
 	closed  chan struct{}
@@ -90,7 +105,7 @@ func (r *selectResult) fetch(ctx goctx.Context) {
 		if resultSubset == nil {
 			return
 		}
-		pr := &partialResult{}
+		pr := &partialResult{fields: r.fields, loc: r.loc}
 		pr.unmarshal(resultSubset)
 
 		select {
@@ -104,12 +119,26 @@ func (r *selectResult) fetch(ctx goctx.Context) {
 	}
 }
 
+// SkippedRanges implements the SelectResult SkippedRanges interface.
+func (r *selectResult) SkippedRanges() []kv.KeyRange {
+	if reporter, ok := r.resp.(kv.SkippedRangesReporter); ok {
+		return reporter.SkippedRanges()
+	}
+	return nil
+}
+
 // Next returns the next row.
 func (r *selectResult) Next() (PartialResult, error) {
 	re := <-r.results
 	return re.result, errors.Trace(re.err)
 }
 
+// SetFields implements the SelectResult SetFields interface.
+func (r *selectResult) SetFields(fields []*types.FieldType, loc *time.Location) {
+	r.fields = fields
+	r.loc = loc
+}
+
 // Close closes SelectResult.
 func (r *selectResult) Close() error {
 	// close this channel tell fetch goroutine to exit
@@ -123,6 +152,13 @@ type partialResult struct {
 	chunkIdx   int
 	cursor     int
 	dataOffset int64
+	fields     []*types.FieldType
+	loc        *time.Location
+
+	// chunkEncoded indicates if the rows of the response are chunk encoded.
+	chunkEncoded bool
+	// chk is the decoded chunk at chunkIdx-1 if the response is chunk encoded.
+	chk *chunk.Chunk
 }
 
 func (pr *partialResult) unmarshal(resultSubset []byte) error {
@@ -135,21 +171,32 @@ func (pr *partialResult) unmarshal(resultSubset []byte) error {
 	if pr.resp.Error != nil {
 		return errInvalidResp.Gen("[%d %s]", pr.resp.Error.GetCode(), pr.resp.Error.GetMsg())
 	}
+	pr.chunkEncoded = isChunkEncoded(pr.resp)
 
 	return nil
 }
 
+// isChunkEncoded checks whether the rows of resp are chunk encoded. Only the mock TiKV sends the chunk
+// encoded responses, for the DAG requests with xeval.FlagChunkEncode. Their chunks carry the encoded
+// columns without any row meta, while a chunk of the default encoded responses has a meta for each row.
+func isChunkEncoded(resp *tipb.SelectResponse) bool {
+	return len(resp.Chunks) > 0 && len(resp.Chunks[0].RowsMeta) == 0
+}
+
 var zeroLenData = make([]byte, 0)
 
 // Next returns the next row of the sub result.
 // If no more row to return, data would be nil.
 func (pr *partialResult) Next() (handle int64, data []byte, err error) {
-	chunk := pr.getChunk()
-	if chunk == nil {
+	if pr.chunkEncoded {
+		return 0, nil, errInvalidResp.Gen("chunk encoded response should be read by NextRow")
+	}
+	chk := pr.getChunk()
+	if chk == nil {
 		return 0, nil, nil
 	}
-	rowMeta := chunk.RowsMeta[pr.cursor]
-	data = chunk.RowsData[pr.dataOffset : pr.dataOffset+rowMeta.Length]
+	rowMeta := chk.RowsMeta[pr.cursor]
+	data = chk.RowsData[pr.dataOffset : pr.dataOffset+rowMeta.Length]
 	if data == nil {
 		// The caller checks if data is nil to determine finished.
 		data = zeroLenData
@@ -175,6 +222,64 @@ func (pr *partialResult) getChunk() *tipb.Chunk {
 	}
 }
 
+// NextRow implements the PartialResult NextRow interface.
+func (pr *partialResult) NextRow(row []types.Datum) (handle int64, ok bool, err error) {
+	if len(row) > len(pr.fields) {
+		return 0, false, errors.Errorf("can not decode %d values with %d fields", len(row), len(pr.fields))
+	}
+	if pr.chunkEncoded {
+		handle, ok, err = pr.nextChunkRow(row)
+		return handle, ok, errors.Trace(err)
+	}
+	handle, data, err := pr.Next()
+	if err != nil || data == nil {
+		return 0, false, errors.Trace(err)
+	}
+	err = codec.SetRawValues(data, row)
+	if err != nil {
+		return 0, false, errors.Trace(err)
+	}
+	for i := range row {
+		row[i], err = tablecodec.DecodeColumnValue(row[i].GetRaw(), pr.fields[i], pr.loc)
+		if err != nil {
+			return 0, false, errors.Trace(err)
+		}
+	}
+	return handle, true, nil
+}
+
+// nextChunkRow reads the next row from the chunk encoded response, the last column of the chunks is the row handle.
+func (pr *partialResult) nextChunkRow(row []types.Datum) (handle int64, ok bool, err error) {
+	for pr.chk == nil || pr.cursor >= pr.chk.NumRows() {
+		if pr.chunkIdx >= len(pr.resp.Chunks) {
+			return 0, false, nil
+		}
+		pr.chk, _, err = chunk.Decode(pr.resp.Chunks[pr.chunkIdx].RowsData)
+		if err != nil {
+			return 0, false, errors.Trace(err)
+		}
+		if pr.chk.NumCols() <= len(row) {
+			return 0, false, errInvalidResp.Gen("chunk has %d columns, but %d values are wanted", pr.chk.NumCols(), len(row))
+		}
+		pr.chunkIdx++
+		pr.cursor = 0
+	}
+	r := pr.chk.GetRow(pr.cursor)
+	pr.cursor++
+	for i := range row {
+		var d types.Datum
+		d, err = r.GetDatum(i)
+		if err != nil {
+			return 0, false, errors.Trace(err)
+		}
+		row[i], err = tablecodec.Unflatten(d, pr.fields[i], pr.loc)
+		if err != nil {
+			return 0, false, errors.Trace(err)
+		}
+	}
+	return r.GetInt64(pr.chk.NumCols() - 1), true, nil
+}
+
 // Close closes the sub result.
 func (pr *partialResult) Close() error {
 	return nil
@@ -229,7 +334,8 @@ func Select(client kv.Client, ctx goctx.Context, req *tipb.SelectRequest, keyRan
 // concurrency: The max concurrency for underlying coprocessor request.
 // keepOrder: If the result should returned in key order. For example if we need keep data in order by
 //            scan index, we should set keepOrder to true.
-func SelectDAG(client kv.Client, ctx goctx.Context, dag *tipb.DAGRequest, keyRanges []kv.KeyRange, concurrency int, keepOrder bool, desc bool, isolationLevel kv.IsoLevel, priority int) (SelectResult, error) {
+// allowPartialResult: If the ranges of the unavailable regions are skipped, they're got by SkippedRanges.
+func SelectDAG(client kv.Client, ctx goctx.Context, dag *tipb.DAGRequest, keyRanges []kv.KeyRange, concurrency int, keepOrder bool, desc bool, isolationLevel kv.IsoLevel, priority int, allowPartialResult bool) (SelectResult, error) {
 	var err error
 	defer func() {
 		// Add metrics.
@@ -241,13 +347,14 @@ func SelectDAG(client kv.Client, ctx goctx.Context, dag *tipb.DAGRequest, keyRan
 	}()
 
 	kvReq := &kv.Request{
-		Tp:             kv.ReqTypeDAG,
-		Concurrency:    concurrency,
-		KeepOrder:      keepOrder,
-		KeyRanges:      keyRanges,
-		Desc:           desc,
-		IsolationLevel: isolationLevel,
-		Priority:       priority,
+		Tp:                 kv.ReqTypeDAG,
+		Concurrency:        concurrency,
+		KeepOrder:          keepOrder,
+		KeyRanges:          keyRanges,
+		Desc:               desc,
+		IsolationLevel:     isolationLevel,
+		Priority:           priority,
+		AllowPartialResult: allowPartialResult,
 	}
 	kvReq.Data, err = dag.Marshal()
 	if err != nil {
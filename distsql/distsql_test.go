@@ -22,6 +22,8 @@ import (
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/util/chunk"
+	"github.com/pingcap/tidb/util/codec"
 	"github.com/pingcap/tidb/util/testleak"
 	"github.com/pingcap/tidb/util/types"
 	"github.com/pingcap/tipb/go-tipb"
@@ -160,6 +162,80 @@ func (s *testDistsqlSuite) TestGoroutineLeak(c *C) {
 	c.Error("distsql goroutine leak!")
 }
 
+func (s *testDistsqlSuite) TestPartialResultNextRow(c *C) {
+	defer testleak.AfterTest(c)()
+	fields := []*types.FieldType{
+		types.NewFieldType(mysql.TypeLonglong),
+		types.NewFieldType(mysql.TypeEnum),
+		types.NewFieldType(mysql.TypeDatetime),
+		types.NewFieldType(mysql.TypeVarchar),
+	}
+	fields[1].Elems = []string{"a", "b"}
+	tm, err := types.ParseDatetime("2017-10-01 12:30:00")
+	c.Assert(err, IsNil)
+	packed, err := tm.ToPackedUint()
+	c.Assert(err, IsNil)
+	// The values sent by the coprocessor are in flattened format.
+	rows := [][]types.Datum{
+		types.MakeDatums(1, uint64(2), packed, []byte("x")),
+		types.MakeDatums(nil, uint64(1), nil, nil),
+	}
+	expected := [][]types.Datum{
+		types.MakeDatums(1, types.Enum{Name: "b", Value: 2}, tm, []byte("x")),
+		types.MakeDatums(nil, types.Enum{Name: "a", Value: 1}, nil, nil),
+	}
+
+	defaultResp := &tipb.SelectResponse{}
+	chk := chunk.NewChunk(len(fields) + 1)
+	for i, row := range rows {
+		data, err1 := codec.EncodeValue(nil, row...)
+		c.Assert(err1, IsNil)
+		defaultResp.Chunks = append(defaultResp.Chunks, tipb.Chunk{
+			RowsData: data,
+			RowsMeta: []tipb.RowMeta{{Handle: int64(i + 10), Length: int64(len(data))}},
+		})
+		c.Assert(chk.AppendRow(append(row, types.NewIntDatum(int64(i+10)))), IsNil)
+	}
+	chunkResp := &tipb.SelectResponse{
+		Chunks: []tipb.Chunk{{}, {RowsData: chunk.Encode(chk)}},
+	}
+	chunkResp.Chunks[0].RowsData = chunk.Encode(chunk.NewChunk(len(fields) + 1))
+
+	for _, resp := range []*tipb.SelectResponse{defaultResp, chunkResp} {
+		data, err1 := resp.Marshal()
+		c.Assert(err1, IsNil)
+		pr := &partialResult{fields: fields, loc: time.UTC}
+		c.Assert(pr.unmarshal(data), IsNil)
+		for i := range expected {
+			row := make([]types.Datum, len(fields))
+			handle, ok, err1 := pr.NextRow(row)
+			c.Assert(err1, IsNil)
+			c.Assert(ok, IsTrue)
+			c.Assert(handle, Equals, int64(i+10))
+			for j := range row {
+				c.Assert(row[j].Kind(), Equals, expected[i][j].Kind())
+				cmp, err2 := row[j].CompareDatum(nil, expected[i][j])
+				c.Assert(err2, IsNil)
+				c.Assert(cmp, Equals, 0, Commentf("chunk encoded %v", pr.chunkEncoded))
+			}
+		}
+		_, ok, err1 := pr.NextRow(nil)
+		c.Assert(err1, IsNil)
+		c.Assert(ok, IsFalse)
+	}
+
+	// The chunk encoded response can only be read by NextRow.
+	pr := &partialResult{fields: fields}
+	data, err := chunkResp.Marshal()
+	c.Assert(err, IsNil)
+	c.Assert(pr.unmarshal(data), IsNil)
+	_, _, err = pr.Next()
+	c.Assert(err, NotNil)
+	// Values can not be decoded without fields.
+	_, _, err = pr.NextRow(make([]types.Datum, len(fields)+1))
+	c.Assert(err, NotNil)
+}
+
 type mockResponse struct {
 	count int
 }
@@ -49,6 +49,9 @@ const (
 	// This flag only matters if FlagIgnoreTruncate is not set, in strict sql mode, truncate error should
 	// be returned as error, in non-strict sql mode, truncate error should be saved as warning.
 	FlagTruncateAsWarning uint64 = 1 << 1
+	// FlagChunkEncode indicates if the rows of the DAG response are wanted in chunk encoded format.
+	// Only the mock TiKV understands it, so it is never sent to TiKV.
+	FlagChunkEncode uint64 = 1 << 2
 )
 
 // Evaluator evaluates tipb.Expr.
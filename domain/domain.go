@@ -18,20 +18,27 @@ import (
 	"time"
 
 	"github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/clientv3/concurrency"
 	"github.com/juju/errors"
 	"github.com/ngaut/log"
 	"github.com/ngaut/pools"
+	"github.com/pingcap/tidb/bindinfo"
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/ddl"
+	"github.com/pingcap/tidb/event"
 	"github.com/pingcap/tidb/infoschema"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/meta"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/perfschema"
+	"github.com/pingcap/tidb/plugin"
+	"github.com/pingcap/tidb/policy"
 	"github.com/pingcap/tidb/privilege/privileges"
 	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/statistics"
 	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/udf"
+	"github.com/pingcap/tidb/util/resultcache"
 	goctx "golang.org/x/net/context"
 )
 
@@ -41,6 +48,9 @@ type Domain struct {
 	store           kv.Storage
 	infoHandle      *infoschema.Handle
 	privHandle      *privileges.Handle
+	udfHandle       *udf.Handle
+	bindHandle      *bindinfo.Handle
+	policyHandle    *policy.Handle
 	statsHandle     *statistics.Handle
 	statsLease      time.Duration
 	ddl             ddl.DDL
@@ -49,6 +59,16 @@ type Domain struct {
 	sysSessionPool  *pools.ResourcePool
 	exit            chan struct{}
 	etcdClient      *clientv3.Client
+	infoMu          sync.Mutex // infoMu protects serverInfo, infoSession and serverIDHook.
+	serverInfo      *ServerInfo
+	serverIDHook    func(uint64)
+	mdl             *MetadataLock
+	infoSession     *concurrency.Session
+	slowQueries     slowQueries
+	globalVars      globalVars
+	resultCache     *resultcache.Cache
+	// schemaCacheVersion is the schema version in the schema cache file, it's protected by m.
+	schemaCacheVersion int64
 
 	MockReloadFailed MockFailure // It mocks reload failed.
 }
@@ -76,13 +96,27 @@ func (do *Domain) loadInfoSchema(handle *infoschema.Handle, usedSchemaVersion in
 			log.Info("[ddl] not update self schema version to etcd")
 			return
 		}
-		err = do.ddl.SchemaSyncer().UpdateSelfVersion(goctx.Background(), latestSchemaVersion)
+		err = do.updateSelfVersion(latestSchemaVersion)
 		if err != nil {
 			log.Infof("[ddl] update self version from %v to %v failed %v", usedSchemaVersion, latestSchemaVersion, err)
 		}
 	}()
 
 	startTime := time.Now()
+	if usedSchemaVersion == initialVersion && handle == do.infoHandle {
+		ok, _, err1 := do.tryLoadSchemaCache(m, latestSchemaVersion)
+		if err1 != nil {
+			// We can fall back to full load, don't need to return the error.
+			log.Errorf("[ddl] failed to load schema cache err %v", err1)
+		}
+		if ok {
+			log.Infof("[ddl] cache load InfoSchema from version %d to %d, in %v",
+				do.schemaCacheVersion, latestSchemaVersion, time.Since(startTime))
+			do.updateSchemaCache(false)
+			return latestSchemaVersion, nil, nil
+		}
+	}
+
 	ok, tblIDs, err := do.tryLoadSchemaDiffs(m, usedSchemaVersion, latestSchemaVersion)
 	if err != nil {
 		// We can fall back to full load, don't need to return the error.
@@ -91,41 +125,68 @@ func (do *Domain) loadInfoSchema(handle *infoschema.Handle, usedSchemaVersion in
 	if ok {
 		log.Infof("[ddl] diff load InfoSchema from version %d to %d, in %v",
 			usedSchemaVersion, latestSchemaVersion, time.Since(startTime))
+		if handle == do.infoHandle {
+			do.updateSchemaCache(false)
+		}
 		return latestSchemaVersion, tblIDs, nil
 	}
 
-	schemas, err := do.fetchAllSchemasWithTables(m)
+	lazy := handle.TableLoader() != nil
+	schemas, hashes, err := do.fetchAllSchemasWithTables(m, lazy)
 	if err != nil {
 		return 0, nil, errors.Trace(err)
 	}
 
-	newISBuilder, err := infoschema.NewBuilder(handle).InitWithDBInfos(schemas, latestSchemaVersion)
-	if err != nil {
-		return 0, nil, errors.Trace(err)
+	var newISBuilder *infoschema.Builder
+	if lazy {
+		newISBuilder = infoschema.NewBuilder(handle).InitWithTableStubs(schemas, hashes, startTS, latestSchemaVersion)
+	} else {
+		newISBuilder, err = infoschema.NewBuilder(handle).InitWithDBInfos(schemas, latestSchemaVersion)
+		if err != nil {
+			return 0, nil, errors.Trace(err)
+		}
 	}
 	log.Infof("[ddl] full load InfoSchema from version %d to %d, in %v",
 		usedSchemaVersion, latestSchemaVersion, time.Since(startTime))
 	newISBuilder.Build()
+	if handle == do.infoHandle {
+		do.updateSchemaCache(true)
+	}
 	return latestSchemaVersion, nil, nil
 }
 
-func (do *Domain) fetchAllSchemasWithTables(m *meta.Meta) ([]*model.DBInfo, error) {
+// fetchAllSchemasWithTables fetches all the databases with their tables. If lazy is true, only the stubs of the
+// tables are fetched by meta.ListTableStubs, and the hashes of the tables keyed by the table IDs are returned.
+func (do *Domain) fetchAllSchemasWithTables(m *meta.Meta, lazy bool) ([]*model.DBInfo, map[int64]uint64, error) {
 	allSchemas, err := m.ListDatabases()
 	if err != nil {
-		return nil, errors.Trace(err)
+		return nil, nil, errors.Trace(err)
+	}
+	var hashes *tableHashes
+	if lazy {
+		hashes = &tableHashes{hashes: make(map[int64]uint64)}
 	}
 	splittedSchemas := do.splitForConcurrentFetch(allSchemas)
 	doneCh := make(chan error, len(splittedSchemas))
 	for _, schemas := range splittedSchemas {
-		go do.fetchSchemasWithTables(schemas, m, doneCh)
+		go do.fetchSchemasWithTables(schemas, m, hashes, doneCh)
 	}
 	for range splittedSchemas {
 		err = <-doneCh
 		if err != nil {
-			return nil, errors.Trace(err)
+			return nil, nil, errors.Trace(err)
 		}
 	}
-	return allSchemas, nil
+	if hashes == nil {
+		return allSchemas, nil, nil
+	}
+	return allSchemas, hashes.hashes, nil
+}
+
+// tableHashes collects the hashes of the table stubs fetched concurrently.
+type tableHashes struct {
+	sync.Mutex
+	hashes map[int64]uint64
 }
 
 const fetchSchemaConcurrency = 8
@@ -144,24 +205,38 @@ func (do *Domain) splitForConcurrentFetch(schemas []*model.DBInfo) [][]*model.DB
 	return splitted
 }
 
-func (do *Domain) fetchSchemasWithTables(schemas []*model.DBInfo, m *meta.Meta, done chan error) {
+func (do *Domain) fetchSchemasWithTables(schemas []*model.DBInfo, m *meta.Meta, hashes *tableHashes, done chan error) {
 	for _, di := range schemas {
 		if di.State != model.StatePublic {
 			// schema is not public, can't be used outside.
 			continue
 		}
-		tables, err := m.ListTables(di.ID)
+		var (
+			tables      []*model.TableInfo
+			stubsHashes []uint64
+			err         error
+		)
+		if hashes != nil {
+			tables, stubsHashes, err = m.ListTableStubs(di.ID)
+		} else {
+			tables, err = m.ListTables(di.ID)
+		}
 		if err != nil {
 			done <- err
 			return
 		}
 		di.Tables = make([]*model.TableInfo, 0, len(tables))
-		for _, tbl := range tables {
+		for i, tbl := range tables {
 			if tbl.State != model.StatePublic {
 				// schema is not public, can't be used outside.
 				continue
 			}
 			di.Tables = append(di.Tables, tbl)
+			if hashes != nil {
+				hashes.Lock()
+				hashes.hashes[tbl.ID] = stubsHashes[i]
+				hashes.Unlock()
+			}
 		}
 	}
 	done <- nil
@@ -297,6 +372,13 @@ func (do *Domain) Reload() error {
 	loadSchemaCounter.WithLabelValues("succ").Inc()
 
 	do.SchemaValidator.Update(ver.Ver, schemaVersion, latestSchemaVersion, changedTableIDs)
+	if oldInfoSchema != nil && latestSchemaVersion != schemaVersion {
+		plugin.NotifySchemaChange(&plugin.SchemaEvent{
+			OldVersion:      schemaVersion,
+			NewVersion:      latestSchemaVersion,
+			ChangedTableIDs: changedTableIDs,
+		})
+	}
 
 	lease := do.DDL().GetLease()
 	sub := time.Since(startTime)
@@ -322,6 +404,9 @@ func (do *Domain) loadSchemaInLoop(lease time.Duration) {
 			if err != nil {
 				log.Errorf("[ddl] reload schema in loop err %v", errors.ErrorStack(err))
 			}
+			do.refreshSelfVersion()
+		case <-do.mdl.releaseCh:
+			do.refreshSelfVersion()
 		case <-syncer.GlobalVersionCh():
 			err := do.Reload()
 			if err != nil {
@@ -343,10 +428,44 @@ func (do *Domain) loadSchemaInLoop(lease time.Duration) {
 	}
 }
 
+// updateSelfVersion reports the self schema version to etcd, the version is held back by the
+// metadata lock while the transactions use an older version.
+func (do *Domain) updateSelfVersion(latestSchemaVersion int64) error {
+	ver := latestSchemaVersion
+	if maxWait := metadataLockMaxWait(); maxWait > 0 {
+		ver = do.mdl.selfVersion(latestSchemaVersion, time.Now(), maxWait)
+	}
+	if ver != latestSchemaVersion {
+		log.Infof("[ddl] self schema version %d is held back by the metadata lock, the latest version is %d",
+			ver, latestSchemaVersion)
+	}
+	err := do.ddl.SchemaSyncer().UpdateSelfVersion(goctx.Background(), ver)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	do.mdl.setReported(ver)
+	return nil
+}
+
+// refreshSelfVersion updates the self schema version held back by the metadata lock after the
+// transactions end or the lock expires.
+func (do *Domain) refreshSelfVersion() {
+	do.m.Lock()
+	defer do.m.Unlock()
+	is := do.infoHandle.Get()
+	if is == nil || !do.mdl.isHolding(is.SchemaMetaVersion()) {
+		return
+	}
+	if err := do.updateSelfVersion(is.SchemaMetaVersion()); err != nil {
+		log.Infof("[ddl] update self version to %v failed %v", is.SchemaMetaVersion(), err)
+	}
+}
+
 // Close closes the Domain and release its resource.
 func (do *Domain) Close() {
 	do.ddl.Stop()
 	close(do.exit)
+	do.closeInfoSession()
 	if do.etcdClient != nil {
 		do.etcdClient.Close()
 	}
@@ -396,6 +515,10 @@ type etcdBackend interface {
 	EtcdAddrs() []string
 }
 
+// TableCacheCapacity is the max number of the tables kept in memory, the other tables are loaded on demand when
+// they are used. It's 0 if all the tables are kept in memory.
+var TableCacheCapacity int
+
 // NewDomain creates a new domain. Should not create multiple domains for the same store.
 func NewDomain(store kv.Storage, ddlLease time.Duration, statsLease time.Duration, factory pools.Factory, sysFactory func(*Domain) (pools.Resource, error)) (d *Domain, err error) {
 	capacity := 200                // capacity of the sysSessionPool size
@@ -406,6 +529,8 @@ func NewDomain(store kv.Storage, ddlLease time.Duration, statsLease time.Duratio
 		exit:            make(chan struct{}),
 		sysSessionPool:  pools.NewResourcePool(factory, capacity, capacity, idleTimeout),
 		statsLease:      statsLease,
+		mdl:             newMetadataLock(),
+		resultCache:     resultcache.NewCache(),
 	}
 
 	if ebd, ok := store.(etcdBackend); ok {
@@ -426,6 +551,9 @@ func NewDomain(store kv.Storage, ddlLease time.Duration, statsLease time.Duratio
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
+	if TableCacheCapacity > 0 {
+		d.infoHandle.SetTableLoader(infoschema.NewTableLoader(d.store, TableCacheCapacity))
+	}
 	ctx := goctx.Background()
 	callback := &ddlCallback{do: d}
 
@@ -458,6 +586,16 @@ func NewDomain(store kv.Storage, ddlLease time.Duration, statsLease time.Duratio
 	return d, nil
 }
 
+// MetadataLock returns the metadata lock of the transactions.
+func (do *Domain) MetadataLock() *MetadataLock {
+	return do.mdl
+}
+
+// ResultCache returns the query result cache of the server.
+func (do *Domain) ResultCache() *resultcache.Cache {
+	return do.resultCache
+}
+
 // SysSessionPool returns the system session pool.
 func (do *Domain) SysSessionPool() *pools.ResourcePool {
 	return do.sysSessionPool
@@ -517,6 +655,209 @@ func (do *Domain) PrivilegeHandle() *privileges.Handle {
 	return do.privHandle
 }
 
+// LoadUDFLoop creates a goroutine loads the user-defined functions in a loop, it
+// should be called only once in BootstrapSession.
+func (do *Domain) LoadUDFLoop(ctx context.Context) error {
+	ctx.GetSessionVars().InRestrictedSQL = true
+	do.udfHandle = udf.NewHandle()
+	err := do.udfHandle.Update(ctx)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	var watchCh clientv3.WatchChan
+	duration := 5 * time.Minute
+	if do.etcdClient != nil {
+		watchCh = do.etcdClient.Watch(goctx.Background(), udfKey)
+		duration = 10 * time.Minute
+	}
+
+	go func() {
+		var count int
+		for {
+			ok := true
+			select {
+			case <-do.exit:
+				return
+			case _, ok = <-watchCh:
+			case <-time.After(duration):
+			}
+			if !ok {
+				log.Error("[domain] load udf loop watch channel closed.")
+				watchCh = do.etcdClient.Watch(goctx.Background(), udfKey)
+				count++
+				if count > 10 {
+					time.Sleep(time.Duration(count) * time.Second)
+				}
+				continue
+			}
+
+			count = 0
+			err := do.udfHandle.Update(ctx)
+			if err != nil {
+				log.Error("[domain] load udf fail:", errors.ErrorStack(err))
+			} else {
+				log.Info("[domain] reload udf success.")
+			}
+		}
+	}()
+	return nil
+}
+
+// LoadBindInfoLoop creates a goroutine loads the SQL bindings in a loop, it
+// should be called only once in BootstrapSession.
+func (do *Domain) LoadBindInfoLoop(ctx context.Context) error {
+	ctx.GetSessionVars().InRestrictedSQL = true
+	do.bindHandle = bindinfo.NewHandle()
+	err := do.bindHandle.Update(ctx)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	var watchCh clientv3.WatchChan
+	duration := 5 * time.Minute
+	if do.etcdClient != nil {
+		watchCh = do.etcdClient.Watch(goctx.Background(), bindInfoKey)
+		duration = 10 * time.Minute
+	}
+
+	go func() {
+		var count int
+		for {
+			ok := true
+			select {
+			case <-do.exit:
+				return
+			case _, ok = <-watchCh:
+			case <-time.After(duration):
+			}
+			if !ok {
+				log.Error("[domain] load bind info loop watch channel closed.")
+				watchCh = do.etcdClient.Watch(goctx.Background(), bindInfoKey)
+				count++
+				if count > 10 {
+					time.Sleep(time.Duration(count) * time.Second)
+				}
+				continue
+			}
+
+			count = 0
+			err := do.bindHandle.Update(ctx)
+			if err != nil {
+				log.Error("[domain] load bind info fail:", errors.ErrorStack(err))
+			} else {
+				log.Info("[domain] reload bind info success.")
+			}
+		}
+	}()
+	return nil
+}
+
+// LoadPolicyLoop creates a goroutine loads the row-level security policies in a loop, it
+// should be called only once in BootstrapSession.
+func (do *Domain) LoadPolicyLoop(ctx context.Context) error {
+	ctx.GetSessionVars().InRestrictedSQL = true
+	do.policyHandle = policy.NewHandle()
+	err := do.policyHandle.Update(ctx)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	var watchCh clientv3.WatchChan
+	duration := 5 * time.Minute
+	if do.etcdClient != nil {
+		watchCh = do.etcdClient.Watch(goctx.Background(), policyKey)
+		duration = 10 * time.Minute
+	}
+
+	go func() {
+		var count int
+		for {
+			ok := true
+			select {
+			case <-do.exit:
+				return
+			case _, ok = <-watchCh:
+			case <-time.After(duration):
+			}
+			if !ok {
+				log.Error("[domain] load policy loop watch channel closed.")
+				watchCh = do.etcdClient.Watch(goctx.Background(), policyKey)
+				count++
+				if count > 10 {
+					time.Sleep(time.Duration(count) * time.Second)
+				}
+				continue
+			}
+
+			count = 0
+			err := do.policyHandle.Update(ctx)
+			if err != nil {
+				log.Error("[domain] load policy fail:", errors.ErrorStack(err))
+			} else {
+				log.Info("[domain] reload policy success.")
+			}
+		}
+	}()
+	return nil
+}
+
+// EventSchedulerLoop campaigns the owner of the event scheduler like the DDL owner, and creates a
+// goroutine runs the due events by run in a loop while it's the owner. It should be called only
+// once in BootstrapSession.
+func (do *Domain) EventSchedulerLoop(ctx context.Context, run event.Runner) error {
+	ctx.GetSessionVars().InRestrictedSQL = true
+	id := do.ddl.OwnerManager().ID()
+	cancelCtx, cancel := goctx.WithCancel(goctx.Background())
+	var manager ddl.OwnerManager
+	if do.etcdClient == nil {
+		manager = ddl.NewMockOwnerManager(id, event.OwnerKey, cancel)
+	} else {
+		manager = ddl.NewOwnerManager(do.etcdClient, id, event.OwnerKey, cancel)
+	}
+	err := manager.CampaignOwners(cancelCtx)
+	if err != nil {
+		cancel()
+		return errors.Trace(err)
+	}
+
+	go func() {
+		ticker := time.NewTicker(event.CheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-do.exit:
+				manager.Cancel()
+				return
+			case now := <-ticker.C:
+				if !manager.IsOwner() {
+					continue
+				}
+				err := event.RunDue(ctx, now, run)
+				if err != nil {
+					log.Error("[domain] run events fail:", errors.ErrorStack(err))
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// UDFHandle returns the handle of the user-defined functions.
+func (do *Domain) UDFHandle() *udf.Handle {
+	return do.udfHandle
+}
+
+// BindHandle returns the handle of the SQL bindings.
+func (do *Domain) BindHandle() *bindinfo.Handle {
+	return do.bindHandle
+}
+
+// PolicyHandle returns the handle of the row-level security policies.
+func (do *Domain) PolicyHandle() *policy.Handle {
+	return do.policyHandle
+}
+
 // StatsHandle returns the statistic handle.
 func (do *Domain) StatsHandle() *statistics.Handle {
 	return do.statsHandle
@@ -592,10 +933,54 @@ func (do *Domain) NotifyUpdatePrivilege(ctx context.Context) {
 	}
 }
 
+const udfKey = "/tidb/udf"
+
+// NotifyUpdateUDF updates udf key in etcd, TiDB client that watches
+// the key will get notification.
+func (do *Domain) NotifyUpdateUDF(ctx context.Context) {
+	if do.etcdClient != nil {
+		kv := do.etcdClient.KV
+		_, err := kv.Put(goctx.Background(), udfKey, "")
+		if err != nil {
+			log.Warn("notify update udf failed:", err)
+		}
+	}
+}
+
+const bindInfoKey = "/tidb/bindinfo"
+
+// NotifyUpdateBindInfo updates bind info key in etcd, TiDB client that watches
+// the key will get notification.
+func (do *Domain) NotifyUpdateBindInfo(ctx context.Context) {
+	if do.etcdClient != nil {
+		kv := do.etcdClient.KV
+		_, err := kv.Put(goctx.Background(), bindInfoKey, "")
+		if err != nil {
+			log.Warn("notify update bind info failed:", err)
+		}
+	}
+}
+
+const policyKey = "/tidb/policy"
+
+// NotifyUpdatePolicy updates policy key in etcd, TiDB client that watches
+// the key will get notification.
+func (do *Domain) NotifyUpdatePolicy(ctx context.Context) {
+	if do.etcdClient != nil {
+		kv := do.etcdClient.KV
+		_, err := kv.Put(goctx.Background(), policyKey, "")
+		if err != nil {
+			log.Warn("notify update policy failed:", err)
+		}
+	}
+}
+
 // Domain error codes.
 const (
 	codeInfoSchemaExpired terror.ErrCode = 1
 	codeInfoSchemaChanged terror.ErrCode = 2
+	codeUsedTableChanged  terror.ErrCode = 3
+	codeUsedTableDropped  terror.ErrCode = 4
 )
 
 var (
@@ -603,4 +988,8 @@ var (
 	ErrInfoSchemaExpired = terror.ClassDomain.New(codeInfoSchemaExpired, "Information schema is out of date.")
 	// ErrInfoSchemaChanged returns the error that information schema is changed.
 	ErrInfoSchemaChanged = terror.ClassDomain.New(codeInfoSchemaChanged, "Information schema is changed.")
+	// ErrUsedTableChanged is the warning that a table used by the session is changed.
+	ErrUsedTableChanged = terror.ClassDomain.New(codeUsedTableChanged, "Table '%s.%s' used by this session is changed at schema version %d")
+	// ErrUsedTableDropped is the warning that a table used by the session is dropped.
+	ErrUsedTableDropped = terror.ClassDomain.New(codeUsedTableDropped, "Table '%s.%s' used by this session is dropped at schema version %d")
 )
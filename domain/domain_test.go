@@ -24,6 +24,7 @@ import (
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/store/localstore"
 	"github.com/pingcap/tidb/store/localstore/goleveldb"
+	"github.com/pingcap/tidb/util"
 	"github.com/pingcap/tidb/util/mock"
 	"github.com/pingcap/tidb/util/testleak"
 )
@@ -100,3 +101,40 @@ func (*testSuite) TestT(c *C) {
 	err = store.Close()
 	c.Assert(err, IsNil)
 }
+
+func (*testSuite) TestServerInfo(c *C) {
+	defer testleak.AfterTest(c)()
+	driver := localstore.Driver{Driver: goleveldb.MemoryDriver{}}
+	store, err := driver.Open("memory")
+	c.Assert(err, IsNil)
+	defer store.Close()
+	dom, err := NewDomain(store, 80*time.Millisecond, 0, mockFactory, sysMockFactory)
+	c.Assert(err, IsNil)
+	defer dom.Close()
+
+	// Without etcd, only the info of this server is available.
+	c.Assert(dom.ServerInfo(), IsNil)
+	infos, err := dom.ServerInfos()
+	c.Assert(err, IsNil)
+	c.Assert(infos, HasLen, 0)
+	err = dom.RegisterServerInfo(&ServerInfo{IP: "127.0.0.1", Port: 4000, StatusPort: 10080})
+	c.Assert(err, IsNil)
+	info := dom.ServerInfo()
+	c.Assert(info.ID, Equals, dom.DDL().OwnerManager().ID())
+	c.Assert(info.Addr(), Equals, "127.0.0.1:4000")
+	c.Assert(info.StatusAddr(), Equals, "127.0.0.1:10080")
+	infos, err = dom.ServerInfos()
+	c.Assert(err, IsNil)
+	c.Assert(infos, DeepEquals, []*ServerInfo{info})
+
+	// Only the recent slow queries are kept.
+	c.Assert(dom.SlowQueries(), HasLen, 0)
+	for i := 0; i < slowQueryCapacity+10; i++ {
+		dom.LogSlowQuery(util.SlowQueryInfo{ConnID: uint64(i)})
+	}
+	queries := dom.SlowQueries()
+	c.Assert(queries, HasLen, slowQueryCapacity)
+	for i, q := range queries {
+		c.Assert(q.ConnID, Equals, uint64(i+10))
+	}
+}
@@ -0,0 +1,209 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package domain
+
+import (
+	"sync"
+	"time"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/juju/errors"
+	"github.com/ngaut/log"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/util/sqlexec"
+	goctx "golang.org/x/net/context"
+)
+
+// globalVarsKey is updated in etcd when a global system variable is set, the servers watch it to reload
+// the global system variables.
+const globalVarsKey = "/tidb/global_vars"
+
+const loadGlobalVarsSQL = "select HIGH_PRIORITY variable_name, variable_value from mysql.global_variables"
+
+// globalVars caches the global system variables in mysql.global_variables.
+type globalVars struct {
+	sync.RWMutex
+	loaded bool
+	vars   map[string]string
+	// version is the etcd revision of the latest change of the global variables seen by this server,
+	// without etcd it's the count of the changes made on this server.
+	version int64
+}
+
+// LoadGlobalVarsLoop loads the global system variables and creates a goroutine reloads them in a loop,
+// it should be called only once in BootstrapSession.
+// The variables are reloaded when any server notifies with NotifyUpdateGlobalVar, the SetGlobal hooks of the
+// variables are called for the loaded values which are changed, the first load applies all the values.
+func (do *Domain) LoadGlobalVarsLoop(ctx context.Context) error {
+	ctx.GetSessionVars().InRestrictedSQL = true
+	// The revision is got before the first load, so no change after the load is missed by the watch.
+	var revision int64
+	if do.etcdClient != nil {
+		resp, err := do.etcdClient.Get(goctx.Background(), globalVarsKey)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		revision = resp.Header.Revision
+		for _, kv := range resp.Kvs {
+			do.updateGlobalVarsVersion(kv.ModRevision)
+		}
+	}
+	err := do.reloadGlobalVars(ctx)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	var watchCh clientv3.WatchChan
+	duration := 5 * time.Minute
+	if do.etcdClient != nil {
+		watchCh = do.etcdClient.Watch(goctx.Background(), globalVarsKey, clientv3.WithRev(revision+1))
+		duration = 10 * time.Minute
+	}
+
+	go func() {
+		var count int
+		for {
+			ok := true
+			var resp clientv3.WatchResponse
+			select {
+			case <-do.exit:
+				return
+			case resp, ok = <-watchCh:
+			case <-time.After(duration):
+			}
+			if !ok {
+				log.Error("[domain] load global variables loop watch channel closed.")
+				watchCh = do.etcdClient.Watch(goctx.Background(), globalVarsKey)
+				count++
+				if count > 10 {
+					time.Sleep(time.Duration(count) * time.Second)
+				}
+				continue
+			}
+
+			count = 0
+			err := do.reloadGlobalVars(ctx)
+			if err != nil {
+				log.Error("[domain] load global variables fail:", errors.ErrorStack(err))
+				continue
+			}
+			for _, event := range resp.Events {
+				do.updateGlobalVarsVersion(event.Kv.ModRevision)
+			}
+		}
+	}()
+	return nil
+}
+
+func (do *Domain) reloadGlobalVars(ctx context.Context) error {
+	rows, _, err := ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(ctx, loadGlobalVarsSQL)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	vars := make(map[string]string, len(rows))
+	for _, row := range rows {
+		vars[row.Data[0].GetString()] = row.Data[1].GetString()
+	}
+
+	do.globalVars.Lock()
+	old := do.globalVars.vars
+	do.globalVars.vars = vars
+	do.globalVars.loaded = true
+	do.globalVars.Unlock()
+
+	for name, value := range vars {
+		if oldValue, ok := old[name]; !ok || oldValue != value {
+			callSetGlobalHook(name, value)
+		}
+	}
+	return nil
+}
+
+func callSetGlobalHook(name, value string) {
+	sysVar := variable.SysVars[name]
+	if sysVar == nil || sysVar.SetGlobal == nil || sysVar.Scope == variable.ScopeInstance {
+		return
+	}
+	if err := sysVar.SetGlobal(value); err != nil {
+		log.Errorf("[domain] set global variable %s = %s fail: %v", name, value, err)
+	}
+}
+
+// GlobalVar returns the cached value of the global system variable, ok is false if the global variables
+// aren't loaded or the variable isn't in mysql.global_variables.
+func (do *Domain) GlobalVar(name string) (value string, ok bool) {
+	do.globalVars.RLock()
+	defer do.globalVars.RUnlock()
+	if !do.globalVars.loaded {
+		return "", false
+	}
+	value, ok = do.globalVars.vars[name]
+	return
+}
+
+// GlobalVarsLoaded returns whether the global system variables are loaded into the cache.
+func (do *Domain) GlobalVarsLoaded() bool {
+	do.globalVars.RLock()
+	defer do.globalVars.RUnlock()
+	return do.globalVars.loaded
+}
+
+// SetGlobalVar updates the cached value of the global system variable after it's written to
+// mysql.global_variables, and calls the SetGlobal hook of the variable.
+func (do *Domain) SetGlobalVar(name, value string) {
+	do.globalVars.Lock()
+	changed := true
+	if do.globalVars.loaded {
+		changed = do.globalVars.vars[name] != value
+		do.globalVars.vars[name] = value
+	}
+	if do.etcdClient == nil {
+		do.globalVars.version++
+	}
+	do.globalVars.Unlock()
+	if changed {
+		callSetGlobalHook(name, value)
+	}
+}
+
+// NotifyUpdateGlobalVar updates the global variables key in etcd, TiDB servers that watch
+// the key will reload the global variables.
+func (do *Domain) NotifyUpdateGlobalVar() {
+	if do.etcdClient != nil {
+		resp, err := do.etcdClient.KV.Put(goctx.Background(), globalVarsKey, "")
+		if err != nil {
+			log.Warn("notify update global variables failed:", err)
+			return
+		}
+		do.updateGlobalVarsVersion(resp.Header.Revision)
+	}
+}
+
+func (do *Domain) updateGlobalVarsVersion(version int64) {
+	do.globalVars.Lock()
+	if version > do.globalVars.version {
+		do.globalVars.version = version
+	}
+	do.globalVars.Unlock()
+}
+
+// GlobalVarsVersion returns the version of the global variables on this server, it's used for debugging
+// whether a change of the global variables has been propagated to the server.
+// The servers of a cluster have the same version after they have reloaded the change.
+func (do *Domain) GlobalVarsVersion() int64 {
+	do.globalVars.RLock()
+	defer do.globalVars.RUnlock()
+	return do.globalVars.version
+}
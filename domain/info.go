@@ -0,0 +1,310 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package domain
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/clientv3/concurrency"
+	"github.com/juju/errors"
+	"github.com/ngaut/log"
+	"github.com/pingcap/tidb/util"
+	goctx "golang.org/x/net/context"
+)
+
+const (
+	// ServerInfoPath is the etcd path of the server infos, a server registers its info at ServerInfoPath/<DDL ID>.
+	ServerInfoPath = "/tidb/server/info"
+	// ServerIDPath is the etcd path of the allocated server IDs, a server holds its ID at ServerIDPath/<server ID>.
+	ServerIDPath = "/tidb/server/id"
+	// MaxServerID is the max server ID, the server ID is in the higher bits of the connection ID.
+	MaxServerID = 1<<10 - 1
+	// serverInfoTTL is the TTL in seconds of the registered server info, it's kept alive until the domain is closed.
+	serverInfoTTL = 60
+	// etcdOpTimeout is the timeout of an etcd operation for the server infos.
+	etcdOpTimeout = 3 * time.Second
+	// slowQueryCapacity is the max number of the recent slow queries kept in memory.
+	slowQueryCapacity = 500
+)
+
+// ServerInfo is the information of a TiDB server, it's registered in etcd so the servers in a cluster can find each other.
+type ServerInfo struct {
+	ID         string `json:"ddl_id"`
+	IP         string `json:"ip"`
+	Port       uint   `json:"listening_port"`
+	StatusPort uint   `json:"status_port"`
+	Version    string `json:"version"`
+	// ServerID is a small integer unique in the cluster, it's allocated when the info is registered,
+	// it's 0 if there is no etcd.
+	ServerID uint64 `json:"server_id"`
+	// Token is a random secret generated when the info is registered. The other servers get it from etcd and
+	// send it with the requests which change the state of the server, e.g. killing a connection, so the status
+	// API can tell them from the requests of anyone else who can reach the status port.
+	Token string `json:"token"`
+}
+
+// Addr returns the address of the MySQL protocol listener of the server.
+func (info *ServerInfo) Addr() string {
+	return fmt.Sprintf("%s:%d", info.IP, info.Port)
+}
+
+// StatusAddr returns the address of the status server of the server.
+func (info *ServerInfo) StatusAddr() string {
+	return fmt.Sprintf("%s:%d", info.IP, info.StatusPort)
+}
+
+// RegisterServerInfo registers the info of the server, the ID of the info is set to the ID of the DDL and
+// a server ID is allocated for the info. The info and the server ID are kept in etcd until the domain is
+// closed, the info is only kept in memory if there is no etcd.
+func (do *Domain) RegisterServerInfo(info *ServerInfo) error {
+	info.ID = do.ddl.OwnerManager().ID()
+	if len(info.Token) == 0 {
+		token := make([]byte, 16)
+		if _, err := rand.Read(token); err != nil {
+			return errors.Trace(err)
+		}
+		info.Token = hex.EncodeToString(token)
+	}
+	do.infoMu.Lock()
+	do.serverInfo = info
+	do.infoMu.Unlock()
+	if do.etcdClient == nil {
+		return nil
+	}
+
+	session, err := do.putServerInfo(info)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	do.infoMu.Lock()
+	do.infoSession = session
+	do.infoMu.Unlock()
+	go do.keepServerInfo(session)
+	return nil
+}
+
+// SetServerIDHook sets the function called when the server ID is changed after the info is registered again.
+func (do *Domain) SetServerIDHook(hook func(serverID uint64)) {
+	do.infoMu.Lock()
+	do.serverIDHook = hook
+	do.infoMu.Unlock()
+}
+
+// putServerInfo allocates a server ID for the info and puts the info into etcd, both are held with the lease
+// of a new session.
+func (do *Domain) putServerInfo(info *ServerInfo) (*concurrency.Session, error) {
+	session, err := concurrency.NewSession(do.etcdClient, concurrency.WithTTL(serverInfoTTL))
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	info.ServerID, err = do.allocServerID(session, info.ID, info.ServerID)
+	if err != nil {
+		session.Close()
+		return nil, errors.Trace(err)
+	}
+	data, err := json.Marshal(info)
+	if err != nil {
+		session.Close()
+		return nil, errors.Trace(err)
+	}
+	ctx, cancel := goctx.WithTimeout(goctx.Background(), etcdOpTimeout)
+	_, err = do.etcdClient.Put(ctx, ServerInfoPath+"/"+info.ID, string(data), clientv3.WithLease(session.Lease()))
+	cancel()
+	if err != nil {
+		session.Close()
+		return nil, errors.Trace(err)
+	}
+	return session, nil
+}
+
+// keepServerInfo registers the info again if the lease of the session is lost, e.g. etcd can't be reached for
+// the TTL. The server ID may be allocated to another server after the lease is lost, so the connections of this
+// server would be killed on the wrong server. The same server ID is claimed again first, the hook is called if a
+// different one is allocated.
+func (do *Domain) keepServerInfo(session *concurrency.Session) {
+	for {
+		select {
+		case <-do.exit:
+			return
+		case <-session.Done():
+		}
+		do.infoMu.Lock()
+		if do.infoSession != session {
+			// The session is closed by closeInfoSession.
+			do.infoMu.Unlock()
+			return
+		}
+		info := *do.serverInfo
+		do.infoMu.Unlock()
+		log.Warnf("[domain] the lease of the server info is lost, register it again")
+
+		for {
+			newSession, err := do.putServerInfo(&info)
+			if err == nil {
+				do.infoMu.Lock()
+				if do.infoSession != session {
+					do.infoMu.Unlock()
+					newSession.Close()
+					return
+				}
+				oldID := do.serverInfo.ServerID
+				do.serverInfo = &info
+				do.infoSession = newSession
+				hook := do.serverIDHook
+				do.infoMu.Unlock()
+				if info.ServerID != oldID {
+					log.Warnf("[domain] the server ID is changed from %d to %d", oldID, info.ServerID)
+					if hook != nil {
+						hook(info.ServerID)
+					}
+				}
+				session = newSession
+				break
+			}
+			log.Errorf("[domain] register the server info failed: %v", errors.ErrorStack(err))
+			select {
+			case <-do.exit:
+				return
+			case <-time.After(time.Second):
+			}
+		}
+	}
+}
+
+// allocServerID allocates the smallest server ID which isn't held by another server, the ID is held
+// with the lease of the session. The preferred ID is claimed first if it's not 0.
+func (do *Domain) allocServerID(session *concurrency.Session, ddlID string, preferred uint64) (uint64, error) {
+	if preferred > 0 && preferred <= MaxServerID {
+		ok, err := do.claimServerID(session, ddlID, preferred)
+		if err != nil {
+			return 0, errors.Trace(err)
+		}
+		if ok {
+			return preferred, nil
+		}
+	}
+	for id := uint64(1); id <= MaxServerID; id++ {
+		ok, err := do.claimServerID(session, ddlID, id)
+		if err != nil {
+			return 0, errors.Trace(err)
+		}
+		if ok {
+			return id, nil
+		}
+	}
+	return 0, errors.Errorf("all the %d server IDs are allocated", MaxServerID)
+}
+
+// claimServerID holds the server ID with the lease of the session if it isn't held by another server.
+func (do *Domain) claimServerID(session *concurrency.Session, ddlID string, id uint64) (bool, error) {
+	key := fmt.Sprintf("%s/%d", ServerIDPath, id)
+	ctx, cancel := goctx.WithTimeout(goctx.Background(), etcdOpTimeout)
+	resp, err := do.etcdClient.Txn(ctx).
+		If(clientv3.Compare(clientv3.CreateRevision(key), "=", 0)).
+		Then(clientv3.OpPut(key, ddlID, clientv3.WithLease(session.Lease()))).
+		Commit()
+	cancel()
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	return resp.Succeeded, nil
+}
+
+// ServerInfo returns the registered info of this server, it returns nil if the info isn't registered.
+func (do *Domain) ServerInfo() *ServerInfo {
+	do.infoMu.Lock()
+	defer do.infoMu.Unlock()
+	return do.serverInfo
+}
+
+// ServerInfos returns the registered infos of all the servers in the cluster, in the order of the address.
+func (do *Domain) ServerInfos() ([]*ServerInfo, error) {
+	if do.etcdClient == nil {
+		if info := do.ServerInfo(); info != nil {
+			return []*ServerInfo{info}, nil
+		}
+		return nil, nil
+	}
+	ctx, cancel := goctx.WithTimeout(goctx.Background(), etcdOpTimeout)
+	resp, err := do.etcdClient.Get(ctx, ServerInfoPath+"/", clientv3.WithPrefix())
+	cancel()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	infos := make([]*ServerInfo, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		info := &ServerInfo{}
+		if err = json.Unmarshal(kv.Value, info); err != nil {
+			return nil, errors.Trace(err)
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Addr() < infos[j].Addr() })
+	return infos, nil
+}
+
+// closeInfoSession revokes the lease of the registered server info, so the info is removed from etcd.
+func (do *Domain) closeInfoSession() {
+	do.infoMu.Lock()
+	session := do.infoSession
+	do.infoSession = nil
+	do.infoMu.Unlock()
+	if session != nil {
+		session.Close()
+	}
+}
+
+// slowQueries keeps the recent slow queries in a ring.
+type slowQueries struct {
+	mu      sync.Mutex
+	queries []util.SlowQueryInfo
+	// next is the position of the next query when the ring is full.
+	next int
+}
+
+func (sq *slowQueries) append(q util.SlowQueryInfo) {
+	sq.mu.Lock()
+	if len(sq.queries) < slowQueryCapacity {
+		sq.queries = append(sq.queries, q)
+	} else {
+		sq.queries[sq.next] = q
+		sq.next = (sq.next + 1) % slowQueryCapacity
+	}
+	sq.mu.Unlock()
+}
+
+func (sq *slowQueries) list() []util.SlowQueryInfo {
+	sq.mu.Lock()
+	defer sq.mu.Unlock()
+	queries := make([]util.SlowQueryInfo, 0, len(sq.queries))
+	queries = append(queries, sq.queries[sq.next:]...)
+	return append(queries, sq.queries[:sq.next]...)
+}
+
+// LogSlowQuery keeps the slow query in memory, only the recent slow queries are kept.
+func (do *Domain) LogSlowQuery(q util.SlowQueryInfo) {
+	do.slowQueries.append(q)
+}
+
+// SlowQueries returns the recent slow queries kept in memory, in the order of the time they are logged.
+func (do *Domain) SlowQueries() []util.SlowQueryInfo {
+	return do.slowQueries.list()
+}
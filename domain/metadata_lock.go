@@ -0,0 +1,144 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package domain
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pingcap/tidb/config"
+)
+
+// metadataLockMaxWait returns the max time a transaction can block the DDL, the metadata lock is
+// disabled if it's 0.
+func metadataLockMaxWait() time.Duration {
+	return time.Duration(config.GetGlobalConfig().MetadataLockWait) * time.Second
+}
+
+// MetadataLock tracks the schema versions used by the active transactions of this server.
+// While a transaction uses an old schema version, the server reports the old version as its self
+// schema version, so the DDL owner waits for the transaction before it moves on to the next
+// schema state. A transaction blocks the DDL for at most the max wait after a newer schema
+// version is loaded, then it fails with "information schema is changed" if it commits.
+type MetadataLock struct {
+	mu     sync.Mutex
+	nextID uint64
+	txns   map[uint64]*mdlTxn
+	// reported is the last self schema version reported to the syncer.
+	reported int64
+	// releaseCh is notified when a transaction releases the lock, so the self version is updated.
+	releaseCh chan struct{}
+}
+
+type mdlTxn struct {
+	schemaVer int64
+	// staleSince is the time a newer schema version is loaded, it's zero if the version is the latest.
+	staleSince time.Time
+	expired    bool
+	// committing indicates the transaction passed the schema check, it doesn't expire when it commits.
+	committing bool
+}
+
+func newMetadataLock() *MetadataLock {
+	return &MetadataLock{
+		txns:      make(map[uint64]*mdlTxn),
+		releaseCh: make(chan struct{}, 1),
+	}
+}
+
+// Acquire records a transaction using the schema version, it returns the ID to release the lock.
+// It returns 0 if the metadata lock is disabled.
+func (l *MetadataLock) Acquire(schemaVer int64) uint64 {
+	if metadataLockMaxWait() == 0 {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.nextID++
+	l.txns[l.nextID] = &mdlTxn{schemaVer: schemaVer}
+	return l.nextID
+}
+
+// Release releases the lock of the transaction when the transaction ends.
+func (l *MetadataLock) Release(id uint64) {
+	if id == 0 {
+		return
+	}
+	l.mu.Lock()
+	txn, ok := l.txns[id]
+	delete(l.txns, id)
+	// Only the transactions using the reported version may hold the self version back.
+	holding := ok && txn.schemaVer == l.reported
+	l.mu.Unlock()
+	if holding {
+		select {
+		case l.releaseCh <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Pin checks whether the transaction still blocks the DDL when it commits, the DDL can't move the
+// schema more than one state further until the transaction ends. The lock doesn't expire after
+// it's pinned.
+func (l *MetadataLock) Pin(id uint64) bool {
+	if id == 0 {
+		return false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	txn, ok := l.txns[id]
+	if !ok || txn.expired {
+		return false
+	}
+	txn.committing = true
+	return true
+}
+
+// selfVersion returns the self schema version of the server when the latest version is loaded,
+// it's the oldest version used by the transactions which don't expire.
+func (l *MetadataLock) selfVersion(latest int64, now time.Time, maxWait time.Duration) int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	ver := latest
+	for _, txn := range l.txns {
+		if txn.expired || txn.schemaVer >= latest {
+			continue
+		}
+		if txn.staleSince.IsZero() {
+			txn.staleSince = now
+		}
+		if !txn.committing && now.Sub(txn.staleSince) >= maxWait {
+			txn.expired = true
+			continue
+		}
+		if txn.schemaVer < ver {
+			ver = txn.schemaVer
+		}
+	}
+	return ver
+}
+
+// isHolding indicates the reported self version is older than the latest version.
+func (l *MetadataLock) isHolding(latest int64) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.reported < latest
+}
+
+func (l *MetadataLock) setReported(ver int64) {
+	l.mu.Lock()
+	l.reported = ver
+	l.mu.Unlock()
+}
@@ -0,0 +1,63 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package domain
+
+import (
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/config"
+	"github.com/pingcap/tidb/util/testleak"
+)
+
+func (*testSuite) TestMetadataLock(c *C) {
+	defer testleak.AfterTest(c)()
+	cfg := config.GetGlobalConfig()
+	l := newMetadataLock()
+	// The metadata lock is disabled.
+	c.Assert(l.Acquire(1), Equals, uint64(0))
+	c.Assert(l.Pin(0), IsFalse)
+
+	defer func() { cfg.MetadataLockWait = 0 }()
+	cfg.MetadataLockWait = 1
+	maxWait := time.Second
+	now := time.Now()
+	id1 := l.Acquire(1)
+	id2 := l.Acquire(2)
+	c.Assert(l.selfVersion(2, now, maxWait), Equals, int64(1))
+	l.setReported(1)
+	c.Assert(l.isHolding(2), IsTrue)
+
+	// The self version isn't held back after the transaction ends.
+	l.Release(id1)
+	select {
+	case <-l.releaseCh:
+	default:
+		c.Fatal("the release isn't notified")
+	}
+	c.Assert(l.selfVersion(2, now, maxWait), Equals, int64(2))
+	l.setReported(2)
+	c.Assert(l.isHolding(2), IsFalse)
+
+	// The lock expires after the max wait, unless the transaction is committing.
+	id3 := l.Acquire(2)
+	c.Assert(l.selfVersion(3, now, maxWait), Equals, int64(2))
+	c.Assert(l.Pin(id3), IsTrue)
+	c.Assert(l.selfVersion(3, now.Add(maxWait), maxWait), Equals, int64(2))
+	c.Assert(l.Pin(id2), IsFalse)
+	l.Release(id3)
+	c.Assert(l.selfVersion(3, now.Add(maxWait), maxWait), Equals, int64(3))
+	l.Release(id2)
+	c.Assert(l.Pin(id2), IsFalse)
+}
@@ -0,0 +1,166 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/juju/errors"
+	"github.com/ngaut/log"
+	"github.com/pingcap/tidb/infoschema"
+	"github.com/pingcap/tidb/meta"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/perfschema"
+)
+
+// SchemaCachePath is the file the information schema is cached in, it's empty if the schema isn't cached.
+// When the server starts, the cached schema is loaded and only the schema diffs after its version are loaded
+// from the storage, which is much faster than loading all the tables when there are many of them.
+// The schema isn't cached if the tables are loaded on demand.
+var SchemaCachePath string
+
+// schemaCache is the content of the schema cache file.
+type schemaCache struct {
+	// StoreUUID is the UUID of the store the schema belongs to, the cache of the other stores isn't used.
+	StoreUUID string `json:"store_uuid"`
+	Version   int64  `json:"version"`
+	// Checksum is the hex SHA-256 of Schemas, a cache which is broken or partially written isn't used.
+	Checksum string          `json:"checksum"`
+	Schemas  json.RawMessage `json:"schemas"`
+}
+
+// saveSchemaCache writes the information schema to the cache file. The file is replaced by a rename, so the
+// servers never read a partially written cache.
+func saveSchemaCache(path, storeUUID string, is infoschema.InfoSchema) error {
+	var schemas []*model.DBInfo
+	for _, di := range is.AllSchemas() {
+		if isMemSchema(di.Name.L) {
+			continue
+		}
+		di = di.Clone()
+		di.Tables = di.Tables[:0]
+		for _, tbl := range is.SchemaTables(di.Name) {
+			di.Tables = append(di.Tables, tbl.Meta())
+		}
+		schemas = append(schemas, di)
+	}
+	data, err := json.Marshal(schemas)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	checksum := sha256.Sum256(data)
+	content, err := json.Marshal(&schemaCache{
+		StoreUUID: storeUUID,
+		Version:   is.SchemaMetaVersion(),
+		Checksum:  hex.EncodeToString(checksum[:]),
+		Schemas:   data,
+	})
+	if err != nil {
+		return errors.Trace(err)
+	}
+	tmpFile, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	_, err = tmpFile.Write(content)
+	if err1 := tmpFile.Close(); err == nil {
+		err = err1
+	}
+	if err == nil {
+		err = os.Rename(tmpFile.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmpFile.Name())
+		return errors.Trace(err)
+	}
+	return nil
+}
+
+// readSchemaCache reads the schema cache file of the store, it returns nil if the file doesn't exist or it
+// isn't the cache of the store.
+func readSchemaCache(path, storeUUID string) (*schemaCache, []*model.DBInfo, error) {
+	content, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	cache := &schemaCache{}
+	if err = json.Unmarshal(content, cache); err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	if cache.StoreUUID != storeUUID {
+		return nil, nil, nil
+	}
+	checksum := sha256.Sum256(cache.Schemas)
+	if hex.EncodeToString(checksum[:]) != cache.Checksum {
+		return nil, nil, errors.Errorf("checksum mismatch of schema cache %s", path)
+	}
+	var schemas []*model.DBInfo
+	if err = json.Unmarshal(cache.Schemas, &schemas); err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	return cache, schemas, nil
+}
+
+func isMemSchema(name string) bool {
+	return name == strings.ToLower(infoschema.Name) || name == strings.ToLower(perfschema.Name)
+}
+
+// tryLoadSchemaCache tries to load the information schema from the cache file and the schema diffs after it.
+// Return true if the schema is loaded successfully.
+// Return false if the cache can't be used, then we need to do full load.
+func (do *Domain) tryLoadSchemaCache(m *meta.Meta, newVersion int64) (bool, []int64, error) {
+	if SchemaCachePath == "" || do.infoHandle.TableLoader() != nil {
+		return false, nil, nil
+	}
+	cache, schemas, err := readSchemaCache(SchemaCachePath, do.store.UUID())
+	if err != nil || cache == nil {
+		return false, nil, errors.Trace(err)
+	}
+	if cache.Version > newVersion || isTooOldSchema(cache.Version, newVersion) {
+		return false, nil, nil
+	}
+	builder, err := infoschema.NewBuilder(do.infoHandle).InitWithDBInfos(schemas, cache.Version)
+	if err != nil {
+		return false, nil, errors.Trace(err)
+	}
+	builder.Build()
+	do.schemaCacheVersion = cache.Version
+	return do.tryLoadSchemaDiffs(m, cache.Version, newVersion)
+}
+
+// updateSchemaCache rewrites the cache file after a full load, or after the cached version is so old that it
+// may not be loaded by the schema diffs soon.
+func (do *Domain) updateSchemaCache(fullLoad bool) {
+	if SchemaCachePath == "" || do.infoHandle.TableLoader() != nil {
+		return
+	}
+	is := do.infoHandle.Get()
+	if !fullLoad && is.SchemaMetaVersion()-do.schemaCacheVersion < maxNumberOfDiffsToLoad/2 {
+		return
+	}
+	if err := saveSchemaCache(SchemaCachePath, do.store.UUID(), is); err != nil {
+		log.Warnf("[ddl] save schema cache to %s failed %v", SchemaCachePath, errors.ErrorStack(err))
+		return
+	}
+	do.schemaCacheVersion = is.SchemaMetaVersion()
+}
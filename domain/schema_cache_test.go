@@ -0,0 +1,97 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package domain
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/store/localstore"
+	"github.com/pingcap/tidb/store/localstore/goleveldb"
+	"github.com/pingcap/tidb/util/mock"
+	"github.com/pingcap/tidb/util/testleak"
+)
+
+func (*testSuite) TestSchemaCache(c *C) {
+	defer testleak.AfterTest(c)()
+	dir, err := ioutil.TempDir("", "schema_cache")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(dir)
+	SchemaCachePath = filepath.Join(dir, "schema.json")
+	defer func() { SchemaCachePath = "" }()
+
+	driver := localstore.Driver{Driver: goleveldb.MemoryDriver{}}
+	store, err := driver.Open("memory")
+	c.Assert(err, IsNil)
+	defer store.Close()
+	dom, err := NewDomain(store, 0, 0, mockFactory, sysMockFactory)
+	c.Assert(err, IsNil)
+	ctx := mock.NewContext()
+	ctx.Store = dom.Store()
+	cs := &ast.CharsetOpt{Chs: "utf8", Col: "utf8_bin"}
+	c.Assert(dom.DDL().CreateSchema(ctx, model.NewCIStr("aaa"), cs), IsNil)
+	dom.m.Lock()
+	dom.updateSchemaCache(true)
+	dom.m.Unlock()
+	cachedVersion := dom.InfoSchema().SchemaMetaVersion()
+	c.Assert(dom.schemaCacheVersion, Equals, cachedVersion)
+	// The change after the cache is loaded by the schema diff.
+	c.Assert(dom.DDL().CreateSchema(ctx, model.NewCIStr("bbb"), cs), IsNil)
+	latestVersion := dom.InfoSchema().SchemaMetaVersion()
+	dom.Close()
+
+	cache, schemas, err := readSchemaCache(SchemaCachePath, store.UUID())
+	c.Assert(err, IsNil)
+	c.Assert(cache.Version, Equals, cachedVersion)
+	names := make([]string, 0, len(schemas))
+	for _, di := range schemas {
+		names = append(names, di.Name.L)
+	}
+	c.Assert(names, DeepEquals, []string{"aaa"})
+	// The cache of the other stores isn't used.
+	cache, _, err = readSchemaCache(SchemaCachePath, "other")
+	c.Assert(err, IsNil)
+	c.Assert(cache, IsNil)
+
+	dom, err = NewDomain(store, 0, 0, mockFactory, sysMockFactory)
+	c.Assert(err, IsNil)
+	c.Assert(dom.schemaCacheVersion, Equals, cachedVersion)
+	is := dom.InfoSchema()
+	c.Assert(is.SchemaMetaVersion(), Equals, latestVersion)
+	_, ok := is.SchemaByName(model.NewCIStr("aaa"))
+	c.Assert(ok, IsTrue)
+	_, ok = is.SchemaByName(model.NewCIStr("bbb"))
+	c.Assert(ok, IsTrue)
+	dom.Close()
+
+	// A broken cache isn't used, the schema is fully loaded.
+	content, err := ioutil.ReadFile(SchemaCachePath)
+	c.Assert(err, IsNil)
+	c.Assert(ioutil.WriteFile(SchemaCachePath, content[:len(content)-10], 0644), IsNil)
+	_, _, err = readSchemaCache(SchemaCachePath, store.UUID())
+	c.Assert(err, NotNil)
+	dom, err = NewDomain(store, 10*time.Millisecond, 0, mockFactory, sysMockFactory)
+	c.Assert(err, IsNil)
+	defer dom.Close()
+	_, ok = dom.InfoSchema().SchemaByName(model.NewCIStr("bbb"))
+	c.Assert(ok, IsTrue)
+	cache, _, err = readSchemaCache(SchemaCachePath, store.UUID())
+	c.Assert(err, IsNil)
+	c.Assert(cache.Version, Equals, latestVersion)
+}
@@ -0,0 +1,51 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tidb
+
+import (
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/event"
+	"github.com/pingcap/tidb/kv"
+)
+
+// eventRunner returns the runner of the event scheduler, every event runs in a new session.
+func eventRunner(store kv.Storage) event.Runner {
+	return func(e *event.Event) error {
+		s, err := createSession(store)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		defer s.Close()
+		return errors.Trace(s.executeEvent(e))
+	}
+}
+
+// executeEvent runs the body of the event like a procedure without parameters, the database of
+// the event is the default database when it runs.
+func (s *session) executeEvent(e *event.Event) error {
+	charset, collation := s.sessionVars.GetCharsetInfo()
+	stmts, err := s.ParseSQL(e.Body, charset, collation)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	create := stmts[0].(*ast.CreateEventStmt)
+	c := newProcCall(s, nil, e.DB+"."+e.Name)
+	c.bindStmts([]ast.StmtNode{create.Body}, nil)
+	// The body isn't visited by CreateEventStmt, so the flags are set here.
+	ast.SetFlag(create.Body)
+	s.sessionVars.CurrentDB = e.DB
+	_, err = c.execStmt(create.Body)
+	return errors.Trace(err)
+}
@@ -0,0 +1,298 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package event manages the events created by CREATE EVENT.
+//
+// The events are stored in the mysql.event table with the time they run next. Only one server
+// in the cluster, the owner of the event scheduler which is elected like the DDL owner, checks
+// the table every CheckInterval and runs the events which are due. The times have a resolution
+// of one second and are in the local time zone of the servers.
+package event
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/ngaut/log"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/util/sqlexec"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// OwnerKey is the owner path of the event scheduler in etcd.
+const OwnerKey = "/tidb/event/owner"
+
+// CheckInterval is the interval the scheduler checks the due events, it's exported for testing.
+var CheckInterval = time.Second
+
+// The status of the events.
+const (
+	StatusEnabled  = "ENABLED"
+	StatusDisabled = "DISABLED"
+)
+
+// maxInterval is the max interval of the recurring events.
+const maxInterval = 100 * 365 * 24 * time.Hour
+
+const timeFormat = "2006-01-02 15:04:05"
+
+// interval is the length of an interval unit, the units of months and days are added by
+// calendar, so they are not always of the same duration.
+type interval struct {
+	months int64
+	days   int64
+	dur    time.Duration
+}
+
+var intervals = map[string]interval{
+	"SECOND":  {dur: time.Second},
+	"MINUTE":  {dur: time.Minute},
+	"HOUR":    {dur: time.Hour},
+	"DAY":     {days: 1},
+	"WEEK":    {days: 7},
+	"MONTH":   {months: 1},
+	"QUARTER": {months: 3},
+	"YEAR":    {months: 12},
+}
+
+// approximate returns the approximate duration of n units, a year is 365 days.
+func (i interval) approximate(n int64) time.Duration {
+	return time.Duration(n) * (time.Duration(i.months)*365*24*time.Hour/12 + time.Duration(i.days)*24*time.Hour + i.dur)
+}
+
+// ValidInterval checks the interval of a recurring event, the value must be positive and the
+// interval can't be longer than 100 years.
+func ValidInterval(value int64, unit string) bool {
+	i, ok := intervals[unit]
+	if !ok || value <= 0 {
+		return false
+	}
+	return value <= int64(maxInterval/i.approximate(1))
+}
+
+// Event is an event created by CREATE EVENT.
+type Event struct {
+	DB   string
+	Name string
+	// Definer is the user who created the event like "root@%".
+	Definer string
+	// Body is the CREATE EVENT statement, the body is parsed again from it when the event runs.
+	Body string
+	// Definition is the text of the event body.
+	Definition string
+	// ExecuteAt is the time of a one time event, it's zero for a recurring event.
+	ExecuteAt time.Time
+	// IntervalValue and IntervalField are the interval of a recurring event like 1 and "DAY".
+	IntervalValue int64
+	IntervalField string
+	Starts        time.Time
+	// Ends is zero if the recurring event doesn't end.
+	Ends         time.Time
+	Status       string
+	Preserve     bool
+	Comment      string
+	Created      time.Time
+	LastExecuted time.Time
+	// NextRun is zero if the event doesn't run again.
+	NextRun time.Time
+}
+
+// Recurring indicates the event runs every interval.
+func (e *Event) Recurring() bool {
+	return e.IntervalField != ""
+}
+
+// nthTime returns the time of the nth run of the recurring event, from 0.
+func (e *Event) nthTime(n int64) time.Time {
+	i := intervals[e.IntervalField]
+	v := n * e.IntervalValue
+	return e.Starts.AddDate(0, int(v*i.months), int(v*i.days)).Add(time.Duration(v) * i.dur)
+}
+
+// NextTime returns the first time of the schedule not before t, it returns the zero time if the
+// event doesn't run after t.
+func (e *Event) NextTime(t time.Time) time.Time {
+	if !e.Recurring() {
+		if e.ExecuteAt.Before(t) {
+			return time.Time{}
+		}
+		return e.ExecuteAt
+	}
+	var n int64
+	if e.Starts.Before(t) {
+		// Guess the number of the intervals by the duration, then correct it by the calendar.
+		n = int64(t.Sub(e.Starts) / intervals[e.IntervalField].approximate(e.IntervalValue))
+		for n > 0 && !e.nthTime(n-1).Before(t) {
+			n--
+		}
+		for e.nthTime(n).Before(t) {
+			n++
+		}
+	}
+	next := e.nthTime(n)
+	if !e.Ends.IsZero() && next.After(e.Ends) {
+		return time.Time{}
+	}
+	return next
+}
+
+// escape escapes str to be quoted by double quotes in a SQL.
+func escape(str string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(str)
+}
+
+func quote(str string) string {
+	return `"` + escape(str) + `"`
+}
+
+func quoteTime(t time.Time) string {
+	if t.IsZero() {
+		return "NULL"
+	}
+	return quote(t.Format(timeFormat))
+}
+
+func exec(ctx context.Context, sql string) ([]*ast.Row, error) {
+	rows, _, err := ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(ctx, sql)
+	return rows, errors.Trace(err)
+}
+
+// Insert stores the new event.
+func Insert(ctx context.Context, e *Event) error {
+	var value, field interface{} = "NULL", "NULL"
+	if e.Recurring() {
+		value, field = e.IntervalValue, quote(e.IntervalField)
+	}
+	preserve := 0
+	if e.Preserve {
+		preserve = 1
+	}
+	sql := fmt.Sprintf(`INSERT INTO %s.%s VALUES (%s, %s, %s, %s, %s, %s, %v, %v, %s, %s, %s, %d, %s, %s, %s, %s);`,
+		mysql.SystemDB, mysql.EventTable, quote(e.DB), quote(e.Name), quote(e.Definer), quote(e.Body), quote(e.Definition),
+		quoteTime(e.ExecuteAt), value, field, quoteTime(e.Starts), quoteTime(e.Ends), quote(e.Status), preserve,
+		quote(e.Comment), quoteTime(e.Created), quoteTime(e.LastExecuted), quoteTime(e.NextRun))
+	_, err := exec(ctx, sql)
+	return errors.Trace(err)
+}
+
+// Delete deletes the event.
+func Delete(ctx context.Context, db, name string) error {
+	sql := fmt.Sprintf(`DELETE FROM %s.%s WHERE db = %s AND name = %s;`, mysql.SystemDB, mysql.EventTable,
+		quote(db), quote(name))
+	_, err := exec(ctx, sql)
+	return errors.Trace(err)
+}
+
+// DeleteDB deletes all the events of the database.
+func DeleteDB(ctx context.Context, db string) error {
+	sql := fmt.Sprintf(`DELETE FROM %s.%s WHERE db = %s;`, mysql.SystemDB, mysql.EventTable, quote(db))
+	_, err := exec(ctx, sql)
+	return errors.Trace(err)
+}
+
+// updateRun stores the status and the run times of the event after it starts to run.
+func updateRun(ctx context.Context, e *Event) error {
+	sql := fmt.Sprintf(`UPDATE %s.%s SET status = %s, last_executed = %s, next_run = %s WHERE db = %s AND name = %s;`,
+		mysql.SystemDB, mysql.EventTable, quote(e.Status), quoteTime(e.LastExecuted), quoteTime(e.NextRun),
+		quote(e.DB), quote(e.Name))
+	_, err := exec(ctx, sql)
+	return errors.Trace(err)
+}
+
+const loadSQL = `SELECT db, name, definer, body, definition, execute_at, interval_value, interval_field, starts, ends,
+	status, preserve, comment, created, last_executed, next_run FROM %s.%s`
+
+// Load loads the events matching the condition like `db = "test"`, all the events are loaded if
+// where is empty. The events are ordered by the database and the name.
+func Load(ctx context.Context, where string) ([]*Event, error) {
+	sql := fmt.Sprintf(loadSQL, mysql.SystemDB, mysql.EventTable)
+	if where != "" {
+		sql += " WHERE " + where
+	}
+	rows, err := exec(ctx, sql+" ORDER BY db, name;")
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	events := make([]*Event, 0, len(rows))
+	for _, row := range rows {
+		d := row.Data
+		e := &Event{
+			DB:            d[0].GetString(),
+			Name:          d[1].GetString(),
+			Definer:       d[2].GetString(),
+			Body:          d[3].GetString(),
+			Definition:    d[4].GetString(),
+			IntervalValue: d[6].GetInt64(),
+			IntervalField: d[7].GetString(),
+			Status:        d[10].GetString(),
+			Preserve:      d[11].GetInt64() != 0,
+			Comment:       d[12].GetString(),
+		}
+		times := []*time.Time{&e.ExecuteAt, &e.Starts, &e.Ends, &e.Created, &e.LastExecuted, &e.NextRun}
+		for i, col := range []int{5, 8, 9, 13, 14, 15} {
+			if *times[i], err = goTime(d[col]); err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
+		events = append(events, e)
+	}
+	return events, nil
+}
+
+func goTime(d types.Datum) (time.Time, error) {
+	if d.IsNull() {
+		return time.Time{}, nil
+	}
+	t, err := d.GetMysqlTime().Time.GoTime(time.Local)
+	return t, errors.Trace(err)
+}
+
+// Runner runs the body of the event.
+type Runner func(e *Event) error
+
+// RunDue runs the enabled events whose next run time isn't after now. The run times are updated
+// before the events run, and every event runs in a goroutine, so a slow event doesn't delay the
+// others. An event which doesn't run again is dropped, or disabled if it's preserved.
+func RunDue(ctx context.Context, now time.Time, run Runner) error {
+	now = now.Truncate(time.Second)
+	events, err := Load(ctx, fmt.Sprintf("status = %s AND next_run <= %s", quote(StatusEnabled), quoteTime(now)))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	for _, e := range events {
+		e.LastExecuted = now
+		e.NextRun = e.NextTime(now.Add(time.Second))
+		if e.NextRun.IsZero() && !e.Preserve {
+			err = Delete(ctx, e.DB, e.Name)
+		} else {
+			if e.NextRun.IsZero() {
+				e.Status = StatusDisabled
+			}
+			err = updateRun(ctx, e)
+		}
+		if err != nil {
+			return errors.Trace(err)
+		}
+		go func(e *Event) {
+			log.Infof("[event] run event %s.%s", e.DB, e.Name)
+			if err := run(e); err != nil {
+				log.Errorf("[event] run event %s.%s fail: %v", e.DB, e.Name, errors.ErrorStack(err))
+			}
+		}(e)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package event
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/util/testleak"
+)
+
+func TestT(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testEventSuite{})
+
+type testEventSuite struct{}
+
+func parseTime(c *C, s string) time.Time {
+	if s == "" {
+		return time.Time{}
+	}
+	t, err := time.ParseInLocation(timeFormat, s, time.Local)
+	c.Assert(err, IsNil)
+	return t
+}
+
+func (s *testEventSuite) TestValidInterval(c *C) {
+	defer testleak.AfterTest(c)()
+	tests := []struct {
+		value int64
+		unit  string
+		valid bool
+	}{
+		{1, "SECOND", true},
+		{0, "SECOND", false},
+		{-1, "DAY", false},
+		{1, "MICROSECOND", false},
+		{1, "DAY_HOUR", false},
+		{100, "YEAR", true},
+		{101, "YEAR", false},
+		{400, "QUARTER", true},
+		{36500, "DAY", true},
+		{36501, "DAY", false},
+		{3153600000, "SECOND", true},
+		{3153600001, "SECOND", false},
+	}
+	for _, t := range tests {
+		c.Assert(ValidInterval(t.value, t.unit), Equals, t.valid, Commentf("%d %s", t.value, t.unit))
+	}
+}
+
+func (s *testEventSuite) TestNextTime(c *C) {
+	defer testleak.AfterTest(c)()
+	tests := []struct {
+		executeAt string
+		value     int64
+		unit      string
+		starts    string
+		ends      string
+		t         string
+		next      string
+	}{
+		{"2017-10-01 10:00:00", 0, "", "", "", "2017-09-01 00:00:00", "2017-10-01 10:00:00"},
+		{"2017-10-01 10:00:00", 0, "", "", "", "2017-10-01 10:00:00", "2017-10-01 10:00:00"},
+		{"2017-10-01 10:00:00", 0, "", "", "", "2017-10-01 10:00:01", ""},
+		{"", 10, "SECOND", "2017-10-01 10:00:00", "", "2017-09-01 00:00:00", "2017-10-01 10:00:00"},
+		{"", 10, "SECOND", "2017-10-01 10:00:00", "", "2017-10-01 10:00:00", "2017-10-01 10:00:00"},
+		{"", 10, "SECOND", "2017-10-01 10:00:00", "", "2017-10-01 10:00:01", "2017-10-01 10:00:10"},
+		{"", 10, "SECOND", "2017-10-01 10:00:00", "", "2017-10-02 10:00:05", "2017-10-02 10:00:10"},
+		{"", 2, "HOUR", "2017-10-01 10:00:00", "2017-10-01 15:00:00", "2017-10-01 13:00:00", "2017-10-01 14:00:00"},
+		{"", 2, "HOUR", "2017-10-01 10:00:00", "2017-10-01 15:00:00", "2017-10-01 14:00:01", ""},
+		{"", 1, "WEEK", "2017-10-01 10:00:00", "", "2017-10-20 00:00:00", "2017-10-22 10:00:00"},
+		{"", 1, "MONTH", "2017-01-31 00:00:00", "", "2017-02-01 00:00:00", "2017-03-03 00:00:00"},
+		{"", 1, "MONTH", "2017-01-15 00:00:00", "", "2017-12-15 00:00:01", "2018-01-15 00:00:00"},
+		{"", 1, "QUARTER", "2017-01-01 00:00:00", "", "2017-05-01 00:00:00", "2017-07-01 00:00:00"},
+		{"", 1, "YEAR", "2000-02-29 00:00:00", "", "2017-06-01 00:00:00", "2018-03-01 00:00:00"},
+	}
+	for _, t := range tests {
+		e := &Event{
+			ExecuteAt:     parseTime(c, t.executeAt),
+			IntervalValue: t.value,
+			IntervalField: t.unit,
+			Starts:        parseTime(c, t.starts),
+			Ends:          parseTime(c, t.ends),
+		}
+		c.Assert(e.NextTime(parseTime(c, t.t)), DeepEquals, parseTime(c, t.next), Commentf("%v", t))
+	}
+}
@@ -14,7 +14,6 @@
 package executor
 
 import (
-	"fmt"
 	"math"
 	"time"
 
@@ -23,15 +22,18 @@ import (
 	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/config"
 	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/infoschema"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/plan"
+	"github.com/pingcap/tidb/sessionctx"
+	"github.com/pingcap/tidb/util"
 )
 
 type processinfoSetter interface {
-	SetProcessInfo(string)
+	SetProcessInfo(string, plan.Plan)
 }
 
 // recordSet wraps an executor, implements ast.RecordSet interface
@@ -45,25 +47,36 @@ type recordSet struct {
 
 func (a *recordSet) Fields() ([]*ast.ResultField, error) {
 	if len(a.fields) == 0 {
-		for _, col := range a.executor.Schema().Columns {
-			rf := &ast.ResultField{
-				ColumnAsName: col.ColName,
-				TableAsName:  col.TblName,
-				DBName:       col.DBName,
-				Column: &model.ColumnInfo{
-					FieldType: *col.RetType,
-					Name:      col.ColName,
-				},
-			}
-			a.fields = append(a.fields, rf)
-		}
+		a.fields = schemaResultFields(a.executor.Schema())
 	}
 	return a.fields, nil
 }
 
+// schemaResultFields converts the columns of the schema to the result fields sent to the client.
+func schemaResultFields(schema *expression.Schema) []*ast.ResultField {
+	fields := make([]*ast.ResultField, 0, schema.Len())
+	for _, col := range schema.Columns {
+		rf := &ast.ResultField{
+			ColumnAsName: col.ColName,
+			TableAsName:  col.TblName,
+			DBName:       col.DBName,
+			Column: &model.ColumnInfo{
+				FieldType: *col.RetType,
+				Name:      col.ColName,
+			},
+		}
+		fields = append(fields, rf)
+	}
+	return fields
+}
+
 func (a *recordSet) Next() (*ast.Row, error) {
 	row, err := a.executor.Next()
 	if err != nil {
+		if a.stmt != nil {
+			// The error is kept for show warnings and errors like the errors which fail the statement early.
+			a.stmt.ctx.GetSessionVars().StmtCtx.AppendError(err)
+		}
 		return nil, errors.Trace(err)
 	}
 	if row == nil {
@@ -83,7 +96,7 @@ func (a *recordSet) Close() error {
 	err := a.executor.Close()
 	a.stmt.logSlowQuery()
 	if a.processinfo != nil {
-		a.processinfo.SetProcessInfo("")
+		a.processinfo.SetProcessInfo("", nil)
 	}
 	return errors.Trace(err)
 }
@@ -98,6 +111,8 @@ type statement struct {
 	startTime      time.Time
 	isPreparedStmt bool
 	expensive      bool
+	// label is the metrics label of the statement, the statement isn't observed if it's empty.
+	label string
 }
 
 func (a *statement) OriginText() string {
@@ -129,7 +144,7 @@ func (a *statement) Exec(ctx context.Context) (ast.RecordSet, error) {
 	if raw, ok := ctx.(processinfoSetter); ok {
 		pi = raw
 		// Update processinfo, ShowProcess() will use it.
-		pi.SetProcessInfo(a.OriginText())
+		pi.SetProcessInfo(a.OriginText(), a.plan)
 	}
 
 	// Fields or Schema are only used for statements that return result set.
@@ -157,7 +172,7 @@ func (a *statement) handleNoDelayExecutor(e Executor, ctx context.Context, pi pr
 
 	defer func() {
 		if pi != nil {
-			pi.SetProcessInfo("")
+			pi.SetProcessInfo("", nil)
 		}
 		e.Close()
 		a.logSlowQuery()
@@ -177,6 +192,18 @@ func (a *statement) handleNoDelayExecutor(e Executor, ctx context.Context, pi pr
 	}
 }
 
+// stmtPriorityToKV converts the statement priority to the kv request priority.
+// DELAYED statements are not latency sensitive, so they are sent with low priority.
+func stmtPriorityToKV(pri mysql.PriorityEnum) int {
+	switch pri {
+	case mysql.HighPriority:
+		return kv.PriorityHigh
+	case mysql.LowPriority, mysql.DelayedPriority:
+		return kv.PriorityLow
+	}
+	return kv.PriorityNormal
+}
+
 // buildExecutor build a executor from plan, prepared statement may need additional procedure.
 func (a *statement) buildExecutor(ctx context.Context) (Executor, error) {
 	priority := kv.PriorityNormal
@@ -197,7 +224,11 @@ func (a *statement) buildExecutor(ctx context.Context) (Executor, error) {
 		}
 
 		if stmtPri := ctx.GetSessionVars().StmtCtx.Priority; stmtPri != mysql.NoPriority {
-			priority = int(stmtPri)
+			priority = stmtPriorityToKV(stmtPri)
+			if txn := ctx.Txn(); txn != nil && !ctx.GetSessionVars().InTxn() {
+				// The transaction only serves this statement, so it takes the priority too.
+				txn.SetOption(kv.Priority, priority)
+			}
 		} else {
 			switch {
 			case isPointGet:
@@ -221,6 +252,7 @@ func (a *statement) buildExecutor(ctx context.Context) (Executor, error) {
 			return nil, errors.Trace(err)
 		}
 		a.text = executorExec.Stmt.Text()
+		a.label = executorExec.stmtLabel
 		a.isPreparedStmt = true
 		a.plan = executorExec.Plan
 		e = executorExec.StmtExec
@@ -231,15 +263,32 @@ func (a *statement) buildExecutor(ctx context.Context) (Executor, error) {
 func (a *statement) logSlowQuery() {
 	cfg := config.GetGlobalConfig()
 	costTime := time.Since(a.startTime)
-	sql := a.text
-	if len(sql) > cfg.QueryLogMaxlen {
-		sql = sql[:cfg.QueryLogMaxlen] + fmt.Sprintf("(len:%d)", len(sql))
+	if a.label != "" {
+		stmtDurationHistogram.WithLabelValues(a.label).Observe(costTime.Seconds())
+	}
+	isSlow := costTime >= time.Duration(cfg.SlowThreshold)*time.Millisecond
+	// Formatting the fields costs, only do it when the log is written.
+	if !isSlow && log.GetLogLevel() < log.LOG_LEVEL_DEBUG {
+		return
+	}
+	sessVars := a.ctx.GetSessionVars()
+	fields := StmtLogFields(sessVars, a.text, cfg.QueryLogMaxlen)
+	if !isSlow {
+		log.Debugf("[TIME_QUERY] cost=%v %s", costTime, fields)
+		return
 	}
-	connID := a.ctx.GetSessionVars().ConnectionID
-	if costTime < time.Duration(cfg.SlowThreshold)*time.Millisecond {
-		log.Debugf("[%d][TIME_QUERY] %v %s", connID, costTime, sql)
-	} else {
-		log.Warnf("[%d][TIME_QUERY] %v %s", connID, costTime, sql)
+	log.Warnf("[TIME_QUERY] cost=%v %s%s", costTime, fields, connectAttrsLogField(sessVars.ConnectAttrs))
+	if dom := sessionctx.GetDomain(a.ctx); dom != nil && !sessVars.InRestrictedSQL {
+		sql, digest := redactSQL(sessVars, a.text)
+		dom.LogSlowQuery(util.SlowQueryInfo{
+			SQL:      sql,
+			Digest:   digest,
+			Start:    a.startTime,
+			Duration: costTime,
+			ConnID:   sessVars.ConnectionID,
+			User:     sessVars.User,
+			DB:       sessVars.CurrentDB,
+		})
 	}
 }
 
@@ -276,10 +325,10 @@ func IsPointGetWithPKOrUniqueKeyByAutoCommit(ctx context.Context, p plan.Plan) b
 		indexScan := v.IndexPlans[0].(*plan.PhysicalIndexScan)
 		return indexScan.IsPointGetByUniqueKey(ctx.GetSessionVars().StmtCtx)
 	case *plan.PhysicalTableScan:
-		return len(v.Ranges) == 1 && v.Ranges[0].IsPoint()
+		return len(v.Ranges) == 1 && v.Ranges[0].IsPoint(ctx.GetSessionVars().StmtCtx)
 	case *plan.PhysicalTableReader:
 		tableScan := v.TablePlans[0].(*plan.PhysicalTableScan)
-		return len(tableScan.Ranges) == 1 && tableScan.Ranges[0].IsPoint()
+		return len(tableScan.Ranges) == 1 && tableScan.Ranges[0].IsPoint(ctx.GetSessionVars().StmtCtx)
 	default:
 		return false
 	}
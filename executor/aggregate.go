@@ -37,6 +37,9 @@ type HashAggExec struct {
 	groupMap      *mvmap.MVMap
 	groupIterator *mvmap.Iterator
 	GroupByItems  []expression.Expression
+	// groupKeyVals and groupKeyBuf are reused to compute the group key of every row.
+	groupKeyVals []types.Datum
+	groupKeyBuf  []byte
 }
 
 // Close implements the Executor Close interface.
@@ -83,9 +86,9 @@ func (e *HashAggExec) Next() (Row, error) {
 	if groupKey == nil {
 		return nil, nil
 	}
-	retRow := make([]types.Datum, 0, len(e.AggFuncs))
-	for _, af := range e.AggFuncs {
-		retRow = append(retRow, af.GetGroupResult(groupKey))
+	retRow := e.rowAlloc.alloc(len(e.AggFuncs))
+	for i, af := range e.AggFuncs {
+		retRow[i] = af.GetGroupResult(groupKey)
 	}
 	return retRow, nil
 }
@@ -101,19 +104,21 @@ func (e *HashAggExec) getGroupKey(row Row) ([]byte, error) {
 	if !e.hasGby {
 		return []byte{}, nil
 	}
-	vals := make([]types.Datum, 0, len(e.GroupByItems))
-	for _, item := range e.GroupByItems {
-		v, err := item.Eval(row)
+	if e.groupKeyVals == nil {
+		e.groupKeyVals = make([]types.Datum, len(e.GroupByItems))
+	}
+	var err error
+	for i, item := range e.GroupByItems {
+		e.groupKeyVals[i], err = item.Eval(row)
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
-		vals = append(vals, v)
 	}
-	bs, err := codec.EncodeValue([]byte{}, vals...)
+	e.groupKeyBuf, err = codec.EncodeValue(e.groupKeyBuf[:0], e.groupKeyVals...)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
-	return bs, nil
+	return e.groupKeyBuf, nil
 }
 
 // innerNext fetches a single row from src and update each aggregate function.
@@ -135,7 +140,9 @@ func (e *HashAggExec) innerNext() (ret bool, err error) {
 		e.groupMap.Put(groupKey, []byte{})
 	}
 	for _, af := range e.AggFuncs {
-		af.Update(srcRow, groupKey, e.sc)
+		if err = af.Update(srcRow, groupKey, e.sc); err != nil {
+			return false, errors.Trace(err)
+		}
 	}
 	return true, nil
 }
@@ -160,6 +167,7 @@ type StreamAggExec struct {
 func (e *StreamAggExec) Open() error {
 	e.executed = false
 	e.hasData = false
+	e.curGroupKey = e.curGroupKey[:0]
 	for _, agg := range e.AggFuncs {
 		agg.Reset()
 	}
@@ -14,13 +14,18 @@
 package executor_test
 
 import (
+	"fmt"
+	"strings"
 	"sync/atomic"
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/executor"
 	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/plan"
+	"github.com/pingcap/tidb/sessionctx"
+	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util/testkit"
 	"github.com/pingcap/tidb/util/testleak"
 )
@@ -286,7 +291,7 @@ func (s *testSuite) TestAggregation(c *C) {
 
 	result = tk.MustQuery("select count(*) from information_schema.columns")
 	// When adding new memory table in information_schema, please update this variable.
-	columnCountOfAllInformationSchemaTables := "738"
+	columnCountOfAllInformationSchemaTables := "844"
 	result.Check(testkit.Rows(columnCountOfAllInformationSchemaTables))
 
 	tk.MustExec("drop table if exists t1")
@@ -350,6 +355,21 @@ func (s *testSuite) TestSelectDistinct(c *C) {
 	r.Check(testkit.Rows("hello"))
 	tk.MustExec("commit")
 
+	// The rows are distinct if they have the primary key or a unique key of not null columns.
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t(a int primary key, b int not null, c int, unique index b(b), unique index c(c))")
+	tk.MustExec("insert into t values(1, 1, null), (2, 2, null), (3, 3, 1)")
+	for _, on := range []string{"1", "0"} {
+		tk.MustExec("set @@session.tidb_opt_agg_push_down = " + on)
+		tk.MustQuery("explain select distinct b from t").Check(testkit.Rows(
+			"TableScan_5   cop table:t, range:(-inf,+inf), keep order:false 8000",
+			"TableReader_6 Projection_4  root data:TableScan_5 8000",
+			"Projection_4  TableReader_6 root b 8000",
+		))
+		tk.MustQuery("select distinct a from t").Check(testkit.Rows("1", "2", "3"))
+		tk.MustQuery("select distinct b from t").Check(testkit.Rows("1", "2", "3"))
+		tk.MustQuery("select distinct c from t order by c").Check(testkit.Rows("<nil>", "1"))
+	}
 }
 
 func (s *testSuite) TestAggPushDown(c *C) {
@@ -366,4 +386,257 @@ func (s *testSuite) TestAggPushDown(c *C) {
 	tk.MustExec("insert into tt values(1, 2, 1)")
 	tk.MustQuery("select max(a.b), max(b.b) from t a join tt b on a.a = b.a group by a.c").Check(testkit.Rows("1 2"))
 	tk.MustQuery("select a, count(b) from (select * from t union all select * from tt) k group by a").Check(testkit.Rows("1 2", "2 1"))
+
+	// The fact table is aggregated before it's joined with the dimension tables.
+	tk.MustExec("drop table if exists f, d1, d2")
+	tk.MustExec("create table f(d1 int, d2 int, x int)")
+	tk.MustExec("create table d1(id int primary key, y int)")
+	tk.MustExec("create table d2(id int primary key, z int)")
+	tk.MustExec("insert into f values(1, 1, 1), (1, 1, 2), (1, 2, 3), (2, 2, 4), (3, 1, 5), (null, 1, 6)")
+	tk.MustExec("insert into d1 values(1, 10), (2, 10), (3, 20)")
+	tk.MustExec("insert into d2 values(1, 100), (2, 200)")
+	sqls := []string{
+		"select d1.y, d2.z, sum(f.x), count(f.x), max(f.x) from f, d1, d2 where f.d1 = d1.id and f.d2 = d2.id group by d1.y, d2.z order by d1.y, d2.z",
+		"select d2.z, count(*) from f join d1 on f.d1 = d1.id left join d2 on f.d2 = d2.id and d2.z > 100 group by d2.z order by d2.z",
+		"select k.y, sum(k.x) from (select d1.y, f.x + 1 as x from f, d1 where f.d1 = d1.id) k group by k.y order by k.y",
+	}
+	var expected [][][]interface{}
+	tk.MustExec("set @@session.tidb_opt_agg_push_down = 0")
+	for _, sql := range sqls {
+		expected = append(expected, tk.MustQuery(sql).Rows())
+	}
+	tk.MustExec("set @@session.tidb_opt_agg_push_down = 1")
+	for i, sql := range sqls {
+		tk.MustQuery(sql).Check(expected[i])
+	}
+	tk.MustQuery(sqls[0]).Check(testkit.Rows("10 100 3 2 2", "10 200 7 2 4", "20 100 5 1 5"))
+
+	// The limit of the distinct values is pushed down to the coprocessor.
+	tk.MustQuery("select count(*) from (select distinct x from f limit 4) k").Check(testkit.Rows("4"))
+	tk.MustQuery("select count(*) from (select distinct d2 from f limit 1, 5) k").Check(testkit.Rows("1"))
+	tk.MustQuery("select count(*) from (select distinct d1 from f where d1 > 1 limit 1, 5) k").Check(testkit.Rows("1"))
+}
+
+func (s *testSuite) TestStreamAgg(c *C) {
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t(a int primary key, b int, c int, index b(b))")
+	tk.MustExec("insert into t values(1, 2, 1), (2, null, 2), (3, 1, 1), (4, 2, 3), (5, null, 2), (6, 3, 3), (7, 2, 1)")
+
+	// The input of the aggregation is read from the index in the order of the group-by column.
+	tk.MustQuery("explain select b, count(*), max(a) from t group by b").Check(testkit.Rows(
+		"IndexScan_9   cop table:t, index:b, range:[<nil>,+inf], out of order:false 8000",
+		"IndexReader_10 StreamAgg_5  root index:IndexScan_9 8000",
+		"StreamAgg_5 Projection_3 IndexReader_10 root type:stream, group by:test.t.b, funcs:count(1), max(test.t.a), firstrow(test.t.b) 6400",
+		"Projection_3  StreamAgg_5 root test.t.b, aggregation_2_col_0, aggregation_2_col_1 6400",
+	))
+	tk.MustQuery("select b, count(*), max(a) from t group by b").Check(testkit.Rows("<nil> 2 5", "1 1 3", "2 3 7", "3 1 6"))
+	tk.MustQuery("select b, count(distinct c), sum(c) from t group by b order by b").Check(testkit.Rows("<nil> 1 4", "1 1 1", "2 2 5", "3 1 3"))
+	tk.MustQuery("select b, count(*) from t where b > 1 group by b").Check(testkit.Rows("2 3", "3 1"))
+	tk.MustQuery("select b, count(*) from t where b > 10 group by b").Check(testkit.Rows())
+	// The aggregation is opened again for every outer row.
+	tk.MustQuery("select a, (select count(*) from t t2 where t2.b = t1.b group by t2.b) from t t1 order by a").Check(testkit.Rows(
+		"1 3", "2 <nil>", "3 1", "4 3", "5 <nil>", "6 1", "7 3"))
+}
+
+func (s *testSuite) TestBitAggregation(c *C) {
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t, tt")
+	tk.MustExec("create table t(a int primary key, b bit(10), c int, d bigint unsigned)")
+	tk.MustExec("insert into t values(1, b'1010', 1, 18446744073709551615), (2, 3, 1, 1), (3, b'1', 2, 0), (4, null, 2, null)")
+
+	tk.MustQuery("select bit_and(b), bit_or(b), bit_xor(b) from t").Check(testkit.Rows("0 11 8"))
+	tk.MustQuery("select bit_and(d), bit_or(d), bit_xor(d) from t").Check(testkit.Rows("0 18446744073709551615 18446744073709551614"))
+	tk.MustQuery("select c, bit_and(b), bit_or(b), bit_xor(b) from t group by c order by c").Check(testkit.Rows("1 2 11 9", "2 1 1 1"))
+	// Aggregation grouped by the primary key is rewritten to a projection.
+	tk.MustQuery("select a, bit_and(b), bit_or(b), bit_xor(b) from t group by a order by a").Check(testkit.Rows(
+		"1 10 10 10", "2 3 3 3", "3 1 1 1", "4 18446744073709551615 0 0"))
+	// Empty input and input of all nulls.
+	tk.MustQuery("select bit_and(b), bit_or(b), bit_xor(b) from t where a > 10").Check(testkit.Rows("18446744073709551615 0 0"))
+	tk.MustQuery("select bit_and(b), bit_or(b), bit_xor(b) from t where b is null").Check(testkit.Rows("18446744073709551615 0 0"))
+	tk.MustQuery("select bit_and(-1), bit_or(1.5), bit_xor('3')").Check(testkit.Rows("18446744073709551615 2 3"))
+
+	// Bit aggregate functions can be pushed down to coprocessor.
+	tk.MustQuery("explain select bit_xor(b) from t").Check(testkit.Rows(
+		"TableScan_5 HashAgg_4  cop table:t, range:(-inf,+inf), keep order:false 8000",
+		"HashAgg_4  TableScan_5 cop type:complete, funcs:bit_xor(test.t.b) 1",
+		"TableReader_7 HashAgg_6  root data:HashAgg_4 1",
+		"HashAgg_6  TableReader_7 root type:final, funcs:bit_xor(col_0) 1",
+	))
+
+	tk.MustExec("create table tt(a int primary key, b int)")
+	tk.MustExec("insert into tt values(1, 1), (2, 1), (3, 2)")
+	tk.MustQuery("select bit_or(t.b), bit_and(t.b) from t join tt on t.a = tt.a group by tt.b order by tt.b").Check(testkit.Rows("11 2", "1 1"))
+}
+
+func (s *testSuite) TestVarianceAggregation(c *C) {
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t(a int primary key, b int, c int, d double, index idx_c(c))")
+	tk.MustExec("insert into t values(1, 1, 1, 1.5), (2, 2, 1, 2.5), (3, 3, 1, null), (4, null, 2, null), (5, 6, 2, -1)")
+
+	tk.MustQuery("select var_pop(b), variance(b), var_samp(b), std(b), stddev(b), stddev_pop(b), stddev_samp(b) from t").Check(testkit.Rows(
+		"3.5 3.5 4.666666666666667 1.8708286933869707 1.8708286933869707 1.8708286933869707 2.160246899469287"))
+	tk.MustQuery("select c, var_pop(b), var_samp(b), stddev_pop(b), stddev_samp(b) from t group by c order by c").Check(testkit.Rows(
+		"1 0.6666666666666666 1 0.816496580927726 1", "2 0 <nil> 0 <nil>"))
+	tk.MustQuery("select var_pop(d), var_samp(d) from t").Check(testkit.Rows("2.1666666666666665 3.25"))
+	// Aggregation grouped by the primary key is rewritten to a projection.
+	tk.MustQuery("select a, var_pop(b), var_samp(b), stddev_pop(b), stddev_samp(b) from t group by a order by a").Check(testkit.Rows(
+		"1 0 <nil> 0 <nil>", "2 0 <nil> 0 <nil>", "3 0 <nil> 0 <nil>", "4 <nil> <nil> <nil> <nil>", "5 0 <nil> 0 <nil>"))
+	// Empty input and input of all nulls.
+	tk.MustQuery("select var_pop(b), var_samp(b), stddev_pop(b), stddev_samp(b) from t where a > 10").Check(testkit.Rows("<nil> <nil> <nil> <nil>"))
+	tk.MustQuery("select var_pop(b), var_samp(b), stddev_pop(b), stddev_samp(b) from t where b is null").Check(testkit.Rows("<nil> <nil> <nil> <nil>"))
+	tk.MustQuery("select var_pop('3'), var_samp(1), stddev_pop(null)").Check(testkit.Rows("0 <nil> <nil>"))
+
+	// The coprocessor doesn't support the variance functions, they're calculated by TiDB.
+	tk.MustQuery("explain select var_pop(b) from t").Check(testkit.Rows(
+		"TableScan_5   cop table:t, range:(-inf,+inf), keep order:false 8000",
+		"TableReader_6 HashAgg_4  root data:TableScan_5 8000",
+		"HashAgg_4  TableReader_6 root type:complete, funcs:var_pop(test.t.b) 1",
+	))
+	tk.MustQuery("select c, var_pop(b), stddev_samp(b) from t use index(idx_c) group by c").Check(testkit.Rows("1 0.6666666666666666 1", "2 0 <nil>"))
+	tk.MustQuery("explain select c, stddev_samp(b) from t use index(idx_c) group by c").Check(testkit.Rows(
+		"IndexScan_6   cop table:t, index:c, range:[<nil>,+inf], out of order:false 8000",
+		"TableScan_7   cop table:t, keep order:false 8000",
+		"IndexLookUp_8 StreamAgg_5  root index:IndexScan_6, table:TableScan_7 8000",
+		"StreamAgg_5 Projection_3 IndexLookUp_8 root type:stream, group by:test.t.c, funcs:stddev_samp(test.t.b), firstrow(test.t.c) 6400",
+		"Projection_3  StreamAgg_5 root test.t.c, aggregation_2_col_0 6400",
+	))
+}
+
+func (s *testSuite) TestJSONAggregation(c *C) {
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t(a int primary key, b int, c int, d varchar(10))")
+	tk.MustExec("insert into t values(1, 1, 1, 'x'), (2, 2, 1, 'y'), (3, 3, 1, null), (4, null, 2, 'x'), (5, 6, 2, 'x')")
+
+	tk.MustQuery("select json_arrayagg(b) from t").Check(testkit.Rows("[1,2,3,null,6]"))
+	tk.MustQuery("select c, json_arrayagg(d), json_objectagg(a, d) from t group by c order by c").Check(testkit.Rows(
+		`1 ["x","y",null] {"1":"x","2":"y","3":null}`, `2 ["x","x"] {"4":"x","5":"x"}`))
+	// The value of a duplicate key is the last one.
+	tk.MustQuery("select json_objectagg(d, a) from t where d is not null").Check(testkit.Rows(`{"x":5,"y":2}`))
+	// The json object can't contain the null keys.
+	rs, err := tk.Exec("select json_objectagg(d, a) from t")
+	c.Assert(err, IsNil)
+	_, err = rs.Next()
+	c.Assert(err, NotNil)
+	// Aggregation grouped by the primary key is rewritten to a projection.
+	tk.MustQuery("select a, json_arrayagg(b), json_objectagg(d, b) from t where d is not null group by a order by a").Check(testkit.Rows(
+		`1 [1] {"x":1}`, `2 [2] {"y":2}`, `4 [null] {"x":null}`, `5 [6] {"x":6}`))
+	// Empty input.
+	tk.MustQuery("select json_arrayagg(b), json_objectagg(a, b) from t where a > 10").Check(testkit.Rows("<nil> <nil>"))
+}
+
+func (s *testSuite) TestApproxAggregation(c *C) {
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t(a int primary key, b int, c int)")
+	values := make([]string, 0, 100)
+	for i := 1; i <= 100; i++ {
+		values = append(values, fmt.Sprintf("(%d, %d, %d)", i, i%30, i%2))
+	}
+	tk.MustExec("insert into t values " + strings.Join(values, ","))
+	tk.MustExec("insert into t values(101, null, null)")
+	// The partial results of the regions are merged.
+	tbl, err := sessionctx.GetDomain(tk.Se).InfoSchema().TableByName(model.NewCIStr("test"), model.NewCIStr("t"))
+	c.Assert(err, IsNil)
+	s.cluster.SplitTable(s.mvccStore, tbl.Meta().ID, 4)
+
+	tk.MustQuery("select approx_count_distinct(b), approx_count_distinct(b, c), approx_count_distinct(c) from t").Check(testkit.Rows("30 30 2"))
+	tk.MustQuery("select approx_percentile(a, 0), approx_percentile(a, 50), approx_percentile(a, 100) from t where a <= 100").Check(testkit.Rows("1 50.5 100"))
+	tk.MustQuery("select c, approx_count_distinct(b), approx_percentile(b, 50) from t group by c order by c").Check(testkit.Rows(
+		"<nil> 0 <nil>", "0 15 12", "1 15 13"))
+	// Aggregation grouped by the primary key is rewritten to a projection.
+	tk.MustQuery("select a, approx_count_distinct(b), approx_percentile(b, 50) from t where a in (1, 101) group by a order by a").Check(testkit.Rows(
+		"1 1 1", "101 0 <nil>"))
+	// Empty input.
+	tk.MustQuery("select approx_count_distinct(b), approx_percentile(b, 50) from t where a > 200").Check(testkit.Rows("0 <nil>"))
+
+	// The partial aggregation is pushed down to coprocessor, the partial results are the sketches.
+	tk.MustQuery("explain select approx_count_distinct(b) from t").Check(testkit.Rows(
+		"TableScan_5 HashAgg_4  cop table:t, range:(-inf,+inf), keep order:false 8000",
+		"HashAgg_4  TableScan_5 cop type:complete, funcs:approx_count_distinct(test.t.b) 1",
+		"TableReader_7 HashAgg_6  root data:HashAgg_4 1",
+		"HashAgg_6  TableReader_7 root type:final, funcs:approx_count_distinct(col_0) 1",
+	))
+	tk.MustQuery("explain select approx_percentile(b, 90) from t").Check(testkit.Rows(
+		"TableScan_5 HashAgg_4  cop table:t, range:(-inf,+inf), keep order:false 8000",
+		"HashAgg_4  TableScan_5 cop type:complete, funcs:approx_percentile(test.t.b, 90) 1",
+		"TableReader_7 HashAgg_6  root data:HashAgg_4 1",
+		"HashAgg_6  TableReader_7 root type:final, funcs:approx_percentile(col_0, 90) 1",
+	))
+
+	_, err = tk.Exec("select approx_percentile(b, a) from t")
+	c.Assert(terror.ErrorEqual(err, plan.ErrBadPercentage), IsTrue, Commentf("err %v", err))
+	_, err = tk.Exec("select approx_percentile(b, 101) from t")
+	c.Assert(terror.ErrorEqual(err, plan.ErrBadPercentage), IsTrue, Commentf("err %v", err))
+}
+
+func (s *testSuite) TestGroupingSets(c *C) {
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t(a int, b int, c int)")
+	tk.MustExec("insert into t values(1, 1, 1), (1, 2, 2), (2, 1, 3), (2, 2, 4)")
+
+	// The rows are expanded for every grouping set, then grouped by the copies of the group-by columns and the grouping id.
+	tk.MustQuery("explain select a, b, sum(c) from t group by a, b with rollup").Check(testkit.Rows(
+		"TableScan_6   cop table:t, range:(-inf,+inf), keep order:false 8000",
+		"TableReader_7 Expand_2  root data:TableScan_6 8000",
+		"Expand_2 HashAgg_5 TableReader_7 root grouping sets:(test.t.a, test.t.b), (test.t.a), () 24000",
+		"HashAgg_5 Projection_4 Expand_2 root type:complete, group by:expand_2_gby_0, expand_2_gby_1, expand_2_gid, funcs:sum(test.t.c), firstrow(expand_2_gby_0), firstrow(expand_2_gby_1) 6401",
+		"Projection_4  HashAgg_5 root test.t.a, test.t.b, aggregation_3_col_0 6401",
+	))
+	tk.MustQuery("select a, b, sum(c) from t group by a, b with rollup order by a is null, a, b is null, b").Check(testkit.Rows(
+		"1 1 1", "1 2 2", "1 <nil> 3", "2 1 3", "2 2 4", "2 <nil> 7", "<nil> <nil> 10"))
+	tk.MustQuery("select a, b, sum(c), grouping(a, b) from t group by grouping sets ((a), (b), ()) order by grouping(a, b), a, b").Check(testkit.Rows(
+		"1 <nil> 3 1", "2 <nil> 7 1", "<nil> 1 4 2", "<nil> 2 6 2", "<nil> <nil> 10 3"))
+	tk.MustQuery("select a, sum(c) from t where b = 1 group by a with rollup having grouping(a) = 1").Check(testkit.Rows("<nil> 4"))
+	// The aggregate functions are evaluated on the original values of the group-by columns.
+	tk.MustQuery("select a, max(a), count(distinct a) from t group by a with rollup order by grouping(a), a").Check(testkit.Rows(
+		"1 1 1", "2 2 1", "<nil> 2 2"))
+	// The same columns in the grouping sets are merged.
+	tk.MustQuery("select a, b, count(*) from t group by grouping sets ((a, b), (a)) having b = 1 or b is null order by a, b").Check(testkit.Rows(
+		"1 <nil> 2", "1 1 1", "2 <nil> 2", "2 1 1"))
+
+	// GROUPING() tells the nulls of the grouping sets from the nulls of the values.
+	tk.MustExec("insert into t values(null, 1, 5)")
+	tk.MustQuery("select a, grouping(a), count(*) from t group by a with rollup order by grouping(a), a").Check(testkit.Rows(
+		"<nil> 0 1", "1 0 2", "2 0 2", "<nil> 1 5"))
+
+	_, err := tk.Exec("select a, grouping(c) from t group by a with rollup")
+	c.Assert(plan.ErrFieldNotInGrouping.Equal(err), IsTrue)
+	_, err = tk.Exec("select a, grouping(a) from t group by a")
+	c.Assert(plan.ErrInvalidGroupFuncUse.Equal(err), IsTrue)
+	_, err = tk.Exec("select a + 1, count(*) from t group by a + 1 with rollup")
+	c.Assert(plan.ErrUnsupportedType.Equal(err), IsTrue)
 }
@@ -194,7 +194,7 @@ func (e *AnalyzeExec) analyzeColumns(task *analyzeTask) statistics.AnalyzeResult
 }
 
 func (e *AnalyzeExec) analyzeIndex(task *analyzeTask) statistics.AnalyzeResult {
-	count, hg, err := statistics.BuildIndex(e.ctx, defaultBucketCount, task.indexInfo.ID, &recordSet{executor: task.src})
+	count, hg, err := statistics.BuildIndex(e.ctx, defaultBucketCount, task.indexInfo.ID, task.indexInfo.DescColumns(), &recordSet{executor: task.src})
 	return statistics.AnalyzeResult{TableID: task.tableInfo.ID, Hist: []*statistics.Histogram{hg}, Count: count, IsIndex: 1, Err: err}
 }
 
@@ -0,0 +1,132 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/bindinfo"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/plan"
+	"github.com/pingcap/tidb/sessionctx"
+	"github.com/pingcap/tidb/util/sqlexec"
+	"github.com/pingcap/tidb/util/types"
+)
+
+func (e *SimpleExec) executeCreateBinding(s *ast.CreateBindingStmt) error {
+	if !bindinfo.MatchHinted(s.OriginStmt, s.HintedStmt) {
+		return ErrBindingNotMatch
+	}
+	// The hinted statement is planned to check it's valid, e.g. the tables exist.
+	is := GetInfoSchema(e.ctx)
+	if err := plan.Preprocess(s.HintedStmt, is, e.ctx); err != nil {
+		return errors.Trace(err)
+	}
+	if _, err := plan.Optimize(e.ctx, s.HintedStmt, is); err != nil {
+		return errors.Trace(err)
+	}
+
+	db := e.ctx.GetSessionVars().CurrentDB
+	originalSQL := bindinfo.NormalizeSQL(s.OriginStmt.Text())
+	bindSQL := strings.TrimSpace(s.HintedStmt.Text())
+	exists, err := bindingExists(e.ctx, db, originalSQL)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	charset, collation := e.ctx.GetSessionVars().GetCharsetInfo()
+	var sql string
+	if exists {
+		sql = fmt.Sprintf(`UPDATE %s.%s SET bind_sql = "%s", status = "%s", update_time = NOW(), charset = "%s", collation = "%s"
+			WHERE original_sql = "%s" AND default_db = "%s";`, mysql.SystemDB, mysql.BindInfoTable,
+			escapeSQLString(bindSQL), bindinfo.StatusUsing, charset, collation, escapeSQLString(originalSQL), escapeSQLString(db))
+	} else {
+		sql = fmt.Sprintf(`INSERT INTO %s.%s VALUES ("%s", "%s", "%s", "%s", NOW(), NOW(), "%s", "%s");`,
+			mysql.SystemDB, mysql.BindInfoTable, escapeSQLString(originalSQL), escapeSQLString(bindSQL),
+			escapeSQLString(db), bindinfo.StatusUsing, charset, collation)
+	}
+	_, _, err = e.ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(e.ctx, sql)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(e.reloadBindings())
+}
+
+func (e *SimpleExec) executeDropBinding(s *ast.DropBindingStmt) error {
+	db := e.ctx.GetSessionVars().CurrentDB
+	originalSQL := bindinfo.NormalizeSQL(s.OriginStmt.Text())
+	exists, err := bindingExists(e.ctx, db, originalSQL)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if !exists {
+		return ErrBindingNotExists.GenByArgs(db)
+	}
+	sql := fmt.Sprintf(`DELETE FROM %s.%s WHERE original_sql = "%s" AND default_db = "%s";`, mysql.SystemDB,
+		mysql.BindInfoTable, escapeSQLString(originalSQL), escapeSQLString(db))
+	_, _, err = e.ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(e.ctx, sql)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(e.reloadBindings())
+}
+
+// reloadBindings reloads the bindings of this server at once, and notifies the other servers to
+// reload them.
+func (e *SimpleExec) reloadBindings() error {
+	dom := sessionctx.GetDomain(e.ctx)
+	sysSessionPool := dom.SysSessionPool()
+	ctx, err := sysSessionPool.Get()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer sysSessionPool.Put(ctx)
+	err = dom.BindHandle().Update(ctx.(context.Context))
+	dom.NotifyUpdateBindInfo(e.ctx)
+	return errors.Trace(err)
+}
+
+func bindingExists(ctx context.Context, db, originalSQL string) (bool, error) {
+	sql := fmt.Sprintf(`SELECT original_sql FROM %s.%s WHERE original_sql = "%s" AND default_db = "%s";`,
+		mysql.SystemDB, mysql.BindInfoTable, escapeSQLString(originalSQL), escapeSQLString(db))
+	rows, _, err := ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(ctx, sql)
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	return len(rows) > 0, nil
+}
+
+func (e *ShowExec) fetchShowBindings() error {
+	h := bindinfo.GetHandle(e.ctx)
+	if h == nil {
+		return nil
+	}
+	for _, b := range h.All() {
+		row := types.MakeDatums(
+			b.OriginalSQL,
+			b.BindSQL,
+			b.DefaultDB,
+			b.Status,
+			b.CreateTime,
+			b.UpdateTime,
+			b.Charset,
+			b.Collation,
+		)
+		e.rows = append(e.rows, row)
+	}
+	return nil
+}
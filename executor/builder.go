@@ -28,9 +28,11 @@ import (
 	"github.com/pingcap/tidb/inspectkv"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/perfschema"
 	"github.com/pingcap/tidb/plan"
 	"github.com/pingcap/tidb/sessionctx"
 	"github.com/pingcap/tidb/table"
+	"github.com/pingcap/tidb/util/ranger"
 	"github.com/pingcap/tidb/util/types"
 	"github.com/pingcap/tipb/go-tipb"
 	goctx "golang.org/x/net/context"
@@ -70,6 +72,8 @@ func (b *executorBuilder) build(p plan.Plan) Executor {
 		return b.buildExecute(v)
 	case *plan.Explain:
 		return b.buildExplain(v)
+	case *plan.Trace:
+		return b.buildTrace(v)
 	case *plan.Insert:
 		return b.buildInsert(v)
 	case *plan.LoadData:
@@ -80,8 +84,26 @@ func (b *executorBuilder) build(p plan.Plan) Executor {
 		return b.buildPrepare(v)
 	case *plan.SelectLock:
 		return b.buildSelectLock(v)
+	case *plan.ChecksumTable:
+		return b.buildChecksumTable(v)
+	case *plan.DumpDatabase:
+		return b.buildDumpDatabase(v)
+	case *plan.CheckIndex:
+		return b.buildCheckIndex(v)
+	case *plan.RepairIndex:
+		return b.buildRepairIndex(v)
 	case *plan.ShowDDL:
 		return b.buildShowDDL(v)
+	case *plan.SetFailpoint:
+		return b.buildSetFailpoint(v)
+	case *plan.ShowFailpoints:
+		return b.buildShowFailpoints(v)
+	case *plan.Calibrate:
+		return b.buildCalibrate(v)
+	case *plan.SetPlugins:
+		return b.buildSetPlugins(v)
+	case *plan.CleanupTableLock:
+		return b.buildCleanupTableLock(v)
 	case *plan.Show:
 		return b.buildShow(v)
 	case *plan.Simple:
@@ -126,6 +148,8 @@ func (b *executorBuilder) build(p plan.Plan) Executor {
 		return b.buildExists(v)
 	case *plan.MaxOneRow:
 		return b.buildMaxOneRow(v)
+	case *plan.Expand:
+		return b.buildExpand(v)
 	case *plan.Cache:
 		return b.buildCache(v)
 	case *plan.Analyze:
@@ -170,6 +194,80 @@ func (b *executorBuilder) buildShowDDL(v *plan.ShowDDL) Executor {
 	return e
 }
 
+func (b *executorBuilder) buildChecksumTable(v *plan.ChecksumTable) Executor {
+	return &ChecksumTableExec{
+		baseExecutor: newBaseExecutor(v.Schema(), b.ctx),
+		tables:       v.Tables,
+		is:           b.is,
+	}
+}
+
+func (b *executorBuilder) buildDumpDatabase(v *plan.DumpDatabase) Executor {
+	return &DumpDatabaseExec{
+		baseExecutor: newBaseExecutor(v.Schema(), b.ctx),
+		dbName:       model.NewCIStr(v.DBName),
+		path:         v.Path,
+		startTS:      b.getStartTS(),
+		is:           b.is,
+	}
+}
+
+func (b *executorBuilder) buildCheckIndex(v *plan.CheckIndex) Executor {
+	return &CheckIndexExec{
+		baseExecutor: newBaseExecutor(v.Schema(), b.ctx),
+		table:        v.Table,
+		indexName:    v.IndexName,
+		ranges:       v.HandleRanges,
+		is:           b.is,
+	}
+}
+
+func (b *executorBuilder) buildRepairIndex(v *plan.RepairIndex) Executor {
+	return &RepairIndexExec{
+		baseExecutor: newBaseExecutor(v.Schema(), b.ctx),
+		table:        v.Table,
+		indexName:    v.IndexName,
+		ranges:       v.HandleRanges,
+		is:           b.is,
+	}
+}
+
+func (b *executorBuilder) buildSetFailpoint(v *plan.SetFailpoint) Executor {
+	return &SetFailpointExec{
+		baseExecutor: newBaseExecutor(v.Schema(), b.ctx),
+		name:         v.Name,
+		terms:        v.Terms,
+		enable:       v.Enable,
+	}
+}
+
+func (b *executorBuilder) buildShowFailpoints(v *plan.ShowFailpoints) Executor {
+	return &ShowFailpointsExec{
+		baseExecutor: newBaseExecutor(v.Schema(), b.ctx),
+	}
+}
+
+func (b *executorBuilder) buildCalibrate(v *plan.Calibrate) Executor {
+	return &CalibrateExec{
+		baseExecutor: newBaseExecutor(v.Schema(), b.ctx),
+	}
+}
+
+func (b *executorBuilder) buildSetPlugins(v *plan.SetPlugins) Executor {
+	return &SetPluginsExec{
+		baseExecutor: newBaseExecutor(v.Schema(), b.ctx),
+		names:        v.Names,
+		enable:       v.Enable,
+	}
+}
+
+func (b *executorBuilder) buildCleanupTableLock(v *plan.CleanupTableLock) Executor {
+	return &CleanupTableLockExec{
+		baseExecutor: newBaseExecutor(v.Schema(), b.ctx),
+		tables:       v.Tables,
+	}
+}
+
 func (b *executorBuilder) buildCheckTable(v *plan.CheckTable) Executor {
 	return &CheckTableExec{
 		tables: v.Tables,
@@ -202,10 +300,19 @@ func (b *executorBuilder) buildSelectLock(v *plan.SelectLock) Executor {
 }
 
 func (b *executorBuilder) buildLimit(v *plan.Limit) Executor {
+	src := b.build(v.Children()[0])
+	// Every handle of the index lookup gets a row if the table side doesn't filter the rows,
+	// so the index lookup stops reading the index after it gets enough handles.
+	if reader, ok := v.Children()[0].(*plan.PhysicalIndexLookUpReader); ok && len(reader.TablePlans) == 1 && !v.CalcFoundRows {
+		if lookUp, ok := src.(*IndexLookUpExecutor); ok {
+			lookUp.handleLimit = v.Offset + v.Count
+		}
+	}
 	e := &LimitExec{
-		baseExecutor: newBaseExecutor(v.Schema(), b.ctx, b.build(v.Children()[0])),
-		Offset:       v.Offset,
-		Count:        v.Count,
+		baseExecutor:  newBaseExecutor(v.Schema(), b.ctx, src),
+		Offset:        v.Offset,
+		Count:         v.Count,
+		CalcFoundRows: v.CalcFoundRows,
 	}
 	return e
 }
@@ -241,6 +348,8 @@ func (b *executorBuilder) buildShow(v *plan.Show) Executor {
 		Full:         v.Full,
 		GlobalScope:  v.GlobalScope,
 		is:           b.is,
+
+		CountWarningsOrErrors: v.CountWarningsOrErrors,
 	}
 	if e.Tp == ast.ShowGrants && len(e.User) == 0 {
 		e.User = e.ctx.GetSessionVars().User
@@ -365,6 +474,16 @@ func (b *executorBuilder) buildExplain(v *plan.Explain) Executor {
 	return exec
 }
 
+func (b *executorBuilder) buildTrace(v *plan.Trace) Executor {
+	return &TraceExec{
+		baseExecutor: newBaseExecutor(v.Schema(), b.ctx),
+		stmtText:     v.StmtText,
+		tracePlan:    v.Plan,
+		is:           b.is,
+		priority:     b.priority,
+	}
+}
+
 func (b *executorBuilder) buildUnionScanExec(v *plan.PhysicalUnionScan) Executor {
 	src := b.build(v.Children()[0])
 	if b.err != nil {
@@ -390,6 +509,7 @@ func (b *executorBuilder) buildUnionScanExec(v *plan.PhysicalUnionScan) Executor
 			for i, col := range x.schema.Columns {
 				if col.ColName.L == ic.Name.L {
 					us.usedIndex = append(us.usedIndex, i)
+					us.usedIndexDesc = append(us.usedIndexDesc, ic.Desc)
 					break
 				}
 			}
@@ -404,6 +524,7 @@ func (b *executorBuilder) buildUnionScanExec(v *plan.PhysicalUnionScan) Executor
 			for i, col := range x.schema.Columns {
 				if col.ColName.L == ic.Name.L {
 					us.usedIndex = append(us.usedIndex, i)
+					us.usedIndexDesc = append(us.usedIndexDesc, ic.Desc)
 					break
 				}
 			}
@@ -418,6 +539,7 @@ func (b *executorBuilder) buildUnionScanExec(v *plan.PhysicalUnionScan) Executor
 			for i, col := range x.schema.Columns {
 				if col.ColName.L == ic.Name.L {
 					us.usedIndex = append(us.usedIndex, i)
+					us.usedIndexDesc = append(us.usedIndexDesc, ic.Desc)
 					break
 				}
 			}
@@ -536,6 +658,7 @@ func (b *executorBuilder) buildSemiJoin(v *plan.PhysicalHashSemiJoin) *HashSemiJ
 		smallHashKey: rightHashKey,
 		auxMode:      v.WithAux,
 		anti:         v.Anti,
+		hashKeyBuf:   make([]types.Datum, len(rightHashKey)),
 	}
 	return e
 }
@@ -569,10 +692,15 @@ func (b *executorBuilder) buildSelection(v *plan.Selection) Executor {
 }
 
 func (b *executorBuilder) buildProjection(v *plan.Projection) Executor {
-	return &ProjectionExec{
+	e := &ProjectionExec{
 		baseExecutor: newBaseExecutor(v.Schema(), b.ctx, b.build(v.Children()[0])),
 		exprs:        v.Exprs,
+		concurrency:  1,
 	}
+	if concurrency := b.ctx.GetSessionVars().ProjectionConcurrency; concurrency > 1 && canEvalConcurrently(v.Exprs) {
+		e.concurrency = concurrency
+	}
+	return e
 }
 
 func (b *executorBuilder) buildTableDual(v *plan.TableDual) Executor {
@@ -592,15 +720,29 @@ func (b *executorBuilder) getStartTS() uint64 {
 
 func (b *executorBuilder) buildMemTable(v *plan.PhysicalMemTable) Executor {
 	table, _ := b.is.TableByID(v.Table.ID)
+	if v.Sample != nil {
+		startTS := b.getStartTS()
+		if b.err != nil {
+			return nil
+		}
+		return &TableSampleExec{
+			baseExecutor: newBaseExecutor(v.Schema(), b.ctx),
+			t:            table,
+			columns:      v.Columns,
+			sample:       v.Sample,
+			startTS:      startTS,
+		}
+	}
 	ts := &TableScanExec{
-		t:            table,
-		asName:       v.TableAsName,
-		ctx:          b.ctx,
-		columns:      v.Columns,
-		schema:       v.Schema(),
-		seekHandle:   math.MinInt64,
-		ranges:       v.Ranges,
-		isInfoSchema: strings.EqualFold(v.DBName.L, infoschema.Name),
+		t:          table,
+		asName:     v.TableAsName,
+		ctx:        b.ctx,
+		columns:    v.Columns,
+		schema:     v.Schema(),
+		seekHandle: math.MinInt64,
+		ranges:     v.Ranges,
+		isInfoSchema: strings.EqualFold(v.DBName.L, infoschema.Name) ||
+			(strings.EqualFold(v.DBName.L, perfschema.Name) && perfschema.IsVirtualTable(v.Table.Name.L)),
 	}
 	return ts
 }
@@ -679,7 +821,7 @@ func (b *executorBuilder) buildIndexScan(v *plan.PhysicalIndexScan) Executor {
 	}
 	vars := b.ctx.GetSessionVars()
 	if v.OutOfOrder {
-		e.scanConcurrency = vars.DistSQLScanConcurrency
+		e.scanConcurrency = distSQLScanConcurrency(b.ctx, b.priority)
 	} else {
 		// The cost of index scan double-read is higher than single-read. Usually ordered index scan has a limit
 		// which may not have been pushed down, so we set concurrency lower to avoid fetching unnecessary data.
@@ -785,6 +927,23 @@ func (b *executorBuilder) buildApply(v *plan.PhysicalApply) Executor {
 		outerSchema: v.OuterSchema,
 		schema:      v.Schema(),
 	}
+	// The inner rows are cached by the correlated values, so the cache is skipped if the inner plan may return
+	// different rows for the same values, e.g. it calls rand().
+	inner := v.PhysicalJoin.Children()[1]
+	if x, ok := v.PhysicalJoin.(*plan.PhysicalHashJoin); ok {
+		inner = x.Children()[x.SmallTable]
+	}
+	innerPlan, ok := inner.(plan.PhysicalPlan)
+	if capacity := b.ctx.GetSessionVars().ApplyCacheCapacity; capacity > 0 && ok && plan.IsDeterministic(innerPlan) {
+		switch x := join.(type) {
+		case *HashSemiJoinExec:
+			apply.cache = newApplyCacheExec(x.smallExec, v.OuterSchema, capacity)
+			x.smallExec = apply.cache
+		case *NestedLoopJoinExec:
+			apply.cache = newApplyCacheExec(x.SmallExec, v.OuterSchema, capacity)
+			x.SmallExec = apply.cache
+		}
+	}
 	return apply
 }
 
@@ -800,6 +959,24 @@ func (b *executorBuilder) buildMaxOneRow(v *plan.MaxOneRow) Executor {
 	}
 }
 
+func (b *executorBuilder) buildExpand(v *plan.Expand) Executor {
+	e := &ExpandExec{
+		baseExecutor: newBaseExecutor(v.Schema(), b.ctx, b.build(v.Children()[0])),
+		groupByCols:  v.GroupByCols,
+		inSets:       make([][]bool, 0, len(v.GroupingSets)),
+		groupingIDs:  make([]int64, 0, len(v.GroupingSets)),
+	}
+	for _, set := range v.GroupingSets {
+		inSet := make([]bool, len(v.GroupByCols))
+		for _, idx := range set {
+			inSet[idx] = true
+		}
+		e.inSets = append(e.inSets, inSet)
+		e.groupingIDs = append(e.groupingIDs, v.GroupingID(set))
+	}
+	return e
+}
+
 func (b *executorBuilder) buildUnion(v *plan.Union) Executor {
 	srcs := make([]Executor, len(v.Children()))
 	for i, sel := range v.Children() {
@@ -808,6 +985,7 @@ func (b *executorBuilder) buildUnion(v *plan.Union) Executor {
 	}
 	e := &UnionExec{
 		baseExecutor: newBaseExecutor(v.Schema(), b.ctx, srcs...),
+		KeepOrder:    v.KeepOrder,
 	}
 	return e
 }
@@ -857,7 +1035,7 @@ func (b *executorBuilder) buildTableScanForAnalyze(tblInfo *model.TableInfo, pk
 		cols = append([]*model.ColumnInfo{pk}, cols...)
 	}
 	schema := expression.NewSchema(expression.ColumnInfos2Columns(tblInfo.Name, cols)...)
-	ranges := []types.IntColumnRange{{LowVal: math.MinInt64, HighVal: math.MaxInt64}}
+	ranges := ranger.FullIntRange()
 	if b.ctx.GetClient().IsRequestTypeSupported(kv.ReqTypeDAG, kv.ReqSubTypeBasic) {
 		e := &TableReaderExecutor{
 			table:     table,
@@ -867,7 +1045,7 @@ func (b *executorBuilder) buildTableScanForAnalyze(tblInfo *model.TableInfo, pk
 			dagPB: &tipb.DAGRequest{
 				StartTs:        b.getStartTS(),
 				TimeZoneOffset: timeZoneOffset(b.ctx),
-				Flags:          statementContextToFlags(b.ctx.GetSessionVars().StmtCtx),
+				Flags:          dagFlags(b.ctx),
 			},
 			schema:  schema,
 			columns: cols,
@@ -911,19 +1089,19 @@ func (b *executorBuilder) buildIndexScanForAnalyze(tblInfo *model.TableInfo, idx
 		cols[i] = tblInfo.Columns[col.Offset]
 	}
 	schema := expression.NewSchema(expression.ColumnInfos2Columns(tblInfo.Name, cols)...)
-	idxRange := &types.IndexRange{LowVal: []types.Datum{types.MinNotNullDatum()}, HighVal: []types.Datum{types.MaxValueDatum()}}
+	idxRange := &types.Range{LowVal: []types.Datum{types.MinNotNullDatum()}, HighVal: []types.Datum{types.MaxValueDatum()}}
 	scanConcurrency := b.ctx.GetSessionVars().IndexSerialScanConcurrency
 	if b.ctx.GetClient().IsRequestTypeSupported(kv.ReqTypeDAG, kv.ReqSubTypeBasic) {
 		e := &IndexReaderExecutor{
 			table:     table,
 			index:     idxInfo,
 			tableID:   tblInfo.ID,
-			ranges:    []*types.IndexRange{idxRange},
+			ranges:    []*types.Range{idxRange},
 			keepOrder: true,
 			dagPB: &tipb.DAGRequest{
 				StartTs:        b.getStartTS(),
 				TimeZoneOffset: timeZoneOffset(b.ctx),
-				Flags:          statementContextToFlags(b.ctx.GetSessionVars().StmtCtx),
+				Flags:          dagFlags(b.ctx),
 			},
 			schema:   schema,
 			columns:  cols,
@@ -952,7 +1130,7 @@ func (b *executorBuilder) buildIndexScanForAnalyze(tblInfo *model.TableInfo, idx
 		startTS:         startTS,
 		idxColsSchema:   schema,
 		schema:          schema,
-		ranges:          []*types.IndexRange{idxRange},
+		ranges:          []*types.Range{idxRange},
 		columns:         cols,
 		index:           idxInfo,
 		outOfOrder:      false,
@@ -991,8 +1169,7 @@ func (b *executorBuilder) constructDAGReq(plans []plan.PhysicalPlan) *tipb.DAGRe
 	dagReq := &tipb.DAGRequest{}
 	dagReq.StartTs = b.getStartTS()
 	dagReq.TimeZoneOffset = timeZoneOffset(b.ctx)
-	sc := b.ctx.GetSessionVars().StmtCtx
-	dagReq.Flags = statementContextToFlags(sc)
+	dagReq.Flags = dagFlags(b.ctx)
 	for _, p := range plans {
 		execPB, err := p.ToPB(b.ctx)
 		if err != nil {
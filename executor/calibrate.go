@@ -0,0 +1,236 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/tablecodec"
+	"github.com/pingcap/tidb/util/codec"
+	"github.com/pingcap/tidb/util/types"
+)
+
+const (
+	calibrateRows  = 10000
+	calibrateSeeks = 100
+	// calibrateTableID is the table ID of the calibration rows, they're only kept in the memory.
+	calibrateTableID = 1
+)
+
+// CalibrateExec represents an executor that measures the cost factors on the current hardware.
+// It is built from the "admin calibrate" statement. Every factor is measured by a micro-benchmark of
+// the work it stands for, then it's scaled by the CPU factor of the session, because only the ratios of
+// the factors matter. The rows are the variables of the factors, their values and the calibrated values,
+// the variables aren't changed, they can be set by the user.
+type CalibrateExec struct {
+	baseExecutor
+
+	rows []Row
+	done bool
+}
+
+// Next implements the Executor Next interface.
+func (e *CalibrateExec) Next() (Row, error) {
+	if !e.done {
+		e.done = true
+		if err := e.calibrate(); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	if len(e.rows) == 0 {
+		return nil, nil
+	}
+	row := e.rows[0]
+	e.rows = e.rows[1:]
+	return row, nil
+}
+
+// calibrateBench holds the rows the micro-benchmarks work on, the encoded rows are kept in a memory
+// buffer like they're kept in the storage.
+type calibrateBench struct {
+	rows   [][]types.Datum
+	colIDs []int64
+	cols   map[int64]*types.FieldType
+	buffer kv.MemBuffer
+}
+
+func newCalibrateBench() (*calibrateBench, error) {
+	b := &calibrateBench{
+		colIDs: []int64{1, 2, 3},
+		cols: map[int64]*types.FieldType{
+			1: types.NewFieldType(mysql.TypeLonglong),
+			2: types.NewFieldType(mysql.TypeVarchar),
+			3: types.NewFieldType(mysql.TypeDouble),
+		},
+		buffer: kv.NewMemDbBuffer(),
+	}
+	for i := 0; i < calibrateRows; i++ {
+		row := types.MakeDatums(int64(i), fmt.Sprintf("calibrate row %d", i), float64(i)/3)
+		value, err := tablecodec.EncodeRow(row, b.colIDs, time.UTC)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if err = b.buffer.Set(tablecodec.EncodeRowKeyWithHandle(calibrateTableID, int64(i)), value); err != nil {
+			return nil, errors.Trace(err)
+		}
+		b.rows = append(b.rows, row)
+	}
+	return b, nil
+}
+
+// perRow returns the nanoseconds f spends on each of the n rows.
+func perRow(n int, f func() error) (float64, error) {
+	start := time.Now()
+	if err := f(); err != nil {
+		return 0, errors.Trace(err)
+	}
+	ns := float64(time.Since(start).Nanoseconds())
+	if ns <= 0 {
+		ns = 1
+	}
+	return ns / float64(n), nil
+}
+
+// cpu filters the rows.
+func (b *calibrateBench) cpu(sc *variable.StatementContext) (float64, error) {
+	return perRow(len(b.rows), func() error {
+		con := types.NewIntDatum(calibrateRows / 2)
+		for _, row := range b.rows {
+			if _, err := row[0].CompareDatum(sc, con); err != nil {
+				return errors.Trace(err)
+			}
+		}
+		return nil
+	})
+}
+
+// network encodes and decodes the rows as they're sent from the storage.
+func (b *calibrateBench) network() (float64, error) {
+	return perRow(len(b.rows), func() error {
+		for _, row := range b.rows {
+			data, err := codec.EncodeValue(nil, row...)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			if _, err = codec.Decode(data, len(row)); err != nil {
+				return errors.Trace(err)
+			}
+		}
+		return nil
+	})
+}
+
+// scan iterates and decodes the rows in the order or the reverse order.
+func (b *calibrateBench) scan(desc bool) (float64, error) {
+	return perRow(len(b.rows), func() error {
+		var it kv.Iterator
+		var err error
+		if desc {
+			it, err = b.buffer.SeekReverse(nil)
+		} else {
+			it, err = b.buffer.Seek(nil)
+		}
+		if err != nil {
+			return errors.Trace(err)
+		}
+		defer it.Close()
+		for it.Valid() {
+			if _, err = tablecodec.DecodeRow(it.Value(), b.cols, time.UTC); err != nil {
+				return errors.Trace(err)
+			}
+			if err = it.Next(); err != nil {
+				return errors.Trace(err)
+			}
+		}
+		return nil
+	})
+}
+
+// memory builds a hash table of the rows.
+func (b *calibrateBench) memory() (float64, error) {
+	return perRow(len(b.rows), func() error {
+		table := make(map[int64][]types.Datum)
+		for _, row := range b.rows {
+			table[row[0].GetInt64()] = append([]types.Datum(nil), row...)
+		}
+		return nil
+	})
+}
+
+// seek sends requests to the storage, so the latency of the network is measured.
+func (e *CalibrateExec) seek() (float64, error) {
+	store := e.ctx.GetStore()
+	ver, err := store.CurrentVersion()
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	snapshot, err := store.GetSnapshot(ver)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	return perRow(calibrateSeeks, func() error {
+		for i := 0; i < calibrateSeeks; i++ {
+			// The row doesn't exist, the storage seeks it and returns at once.
+			_, err := snapshot.Get(tablecodec.EncodeRowKeyWithHandle(calibrateTableID, int64(i)))
+			if err != nil && !kv.IsErrNotFound(err) {
+				return errors.Trace(err)
+			}
+		}
+		return nil
+	})
+}
+
+func (e *CalibrateExec) calibrate() error {
+	b, err := newCalibrateBench()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	vars := e.ctx.GetSessionVars()
+	cpu, err := b.cpu(vars.StmtCtx)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	factors := vars.CostFactors
+	measures := []struct {
+		name    string
+		value   float64
+		measure func() (float64, error)
+	}{
+		{variable.TiDBOptCPUFactor, factors.CPU, func() (float64, error) { return cpu, nil }},
+		{variable.TiDBOptNetworkFactor, factors.Network, b.network},
+		{variable.TiDBOptScanFactor, factors.Scan, func() (float64, error) { return b.scan(false) }},
+		{variable.TiDBOptDescScanFactor, factors.DescScan, func() (float64, error) { return b.scan(true) }},
+		{variable.TiDBOptSeekFactor, factors.Seek, e.seek},
+		{variable.TiDBOptMemoryFactor, factors.Memory, b.memory},
+	}
+	for _, m := range measures {
+		ns, err := m.measure()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		calibrated := factors.CPU * ns / cpu
+		e.rows = append(e.rows, types.MakeDatums(
+			m.name,
+			strconv.FormatFloat(m.value, 'f', -1, 64),
+			strconv.FormatFloat(calibrated, 'f', 2, 64),
+		))
+	}
+	return nil
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/juju/errors"
 	"github.com/ngaut/log"
 	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/bindinfo"
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/infoschema"
 	"github.com/pingcap/tidb/plan"
@@ -31,6 +32,8 @@ type Compiler struct {
 // then wrappped to an adapter *statement as stmt.Statement.
 func (c *Compiler) Compile(ctx context.Context, node ast.StmtNode) (ast.Statement, error) {
 	is := GetInfoSchema(ctx)
+	// The hints of the binding are used like they're written in the statement.
+	bindinfo.BindHints(ctx, node)
 	if err := plan.Preprocess(node, is, ctx); err != nil {
 		return nil, errors.Trace(err)
 	}
@@ -44,11 +47,15 @@ func (c *Compiler) Compile(ctx context.Context, node ast.StmtNode) (ast.Statemen
 	}
 
 	// Don't take restricted SQL into account for metrics.
-	isExpensive := stmtCount(node, p, ctx.GetSessionVars().InRestrictedSQL)
+	label, isExpensive := stmtCount(node, p, ctx.GetSessionVars().InRestrictedSQL)
+	if isExpensive {
+		logExpensiveStmt(ctx, node.Text())
+	}
 	sa := &statement{
 		is:        is,
 		plan:      p,
 		text:      node.Text(),
+		label:     label,
 		expensive: isExpensive,
 	}
 	return sa, nil
@@ -18,7 +18,9 @@ import (
 
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/config"
 	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/event"
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/infoschema"
 	"github.com/pingcap/tidb/model"
@@ -69,6 +71,10 @@ func (e *DDLExec) Next() (Row, error) {
 		err = e.executeAlterTable(x)
 	case *ast.RenameTableStmt:
 		err = e.executeRenameTable(x)
+	case *ast.LockTablesStmt:
+		err = e.executeLockTables(x)
+	case *ast.UnlockTablesStmt:
+		err = e.executeUnlockTables()
 	}
 	if err != nil {
 		return nil, errors.Trace(err)
@@ -103,9 +109,15 @@ func (e *DDLExec) executeTruncateTable(s *ast.TruncateTableStmt) error {
 }
 
 func (e *DDLExec) executeRenameTable(s *ast.RenameTableStmt) error {
-	if len(s.TableToTables) != 1 {
-		// Now we only allow one schema changing at the same time.
-		return errors.Errorf("can't run multi schema change")
+	if len(s.TableToTables) > 1 {
+		oldIdents := make([]ast.Ident, 0, len(s.TableToTables))
+		newIdents := make([]ast.Ident, 0, len(s.TableToTables))
+		for _, tt := range s.TableToTables {
+			oldIdents = append(oldIdents, ast.Ident{Schema: tt.OldTable.Schema, Name: tt.OldTable.Name})
+			newIdents = append(newIdents, ast.Ident{Schema: tt.NewTable.Schema, Name: tt.NewTable.Name})
+		}
+		err := sessionctx.GetDomain(e.ctx).DDL().RenameTables(e.ctx, oldIdents, newIdents)
+		return errors.Trace(err)
 	}
 	oldIdent := ast.Ident{Schema: s.OldTable.Schema, Name: s.OldTable.Name}
 	newIdent := ast.Ident{Schema: s.NewTable.Schema, Name: s.NewTable.Name}
@@ -113,6 +125,49 @@ func (e *DDLExec) executeRenameTable(s *ast.RenameTableStmt) error {
 	return errors.Trace(err)
 }
 
+// executeLockTables releases the table locks of the session and locks the tables. It does nothing
+// if the table locks aren't enabled, and the system and memory tables are skipped.
+func (e *DDLExec) executeLockTables(s *ast.LockTablesStmt) error {
+	if !config.GetGlobalConfig().EnableTableLock {
+		return nil
+	}
+	locks := make([]variable.TableLock, 0, len(s.TableLocks))
+	locked := make(map[int64]struct{}, len(s.TableLocks))
+	for _, tl := range s.TableLocks {
+		if tl.Table.Schema.L == mysql.SystemDB || infoschema.IsMemoryDB(tl.Table.Schema.L) {
+			continue
+		}
+		if _, ok := locked[tl.Table.TableInfo.ID]; ok {
+			return ErrNonUniqTable.GenByArgs(tl.Table.Name.O)
+		}
+		locked[tl.Table.TableInfo.ID] = struct{}{}
+		locks = append(locks, variable.TableLock{
+			SchemaID: tl.Table.DBInfo.ID,
+			TableID:  tl.Table.TableInfo.ID,
+			Write:    tl.Type == model.TableLockWrite,
+		})
+	}
+	if err := e.executeUnlockTables(); err != nil {
+		return errors.Trace(err)
+	}
+	err := sessionctx.GetDomain(e.ctx).DDL().LockTables(e.ctx, locks)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	e.ctx.GetSessionVars().TableLocks = locks
+	return nil
+}
+
+func (e *DDLExec) executeUnlockTables() error {
+	vars := e.ctx.GetSessionVars()
+	err := sessionctx.GetDomain(e.ctx).DDL().UnlockTables(e.ctx, vars.TableLocks)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	vars.TableLocks = nil
+	return nil
+}
+
 func (e *DDLExec) executeCreateDatabase(s *ast.CreateDatabaseStmt) error {
 	var opt *ast.CharsetOpt
 	if len(s.Options) != 0 {
@@ -129,6 +184,7 @@ func (e *DDLExec) executeCreateDatabase(s *ast.CreateDatabaseStmt) error {
 	err := sessionctx.GetDomain(e.ctx).DDL().CreateSchema(e.ctx, model.NewCIStr(s.Name), opt)
 	if err != nil {
 		if terror.ErrorEqual(err, infoschema.ErrDatabaseExists) && s.IfNotExists {
+			e.ctx.GetSessionVars().StmtCtx.AppendNote(err)
 			err = nil
 		}
 	}
@@ -146,6 +202,7 @@ func (e *DDLExec) executeCreateTable(s *ast.CreateTableStmt) error {
 	}
 	if terror.ErrorEqual(err, infoschema.ErrTableExists) {
 		if s.IfNotExists {
+			e.ctx.GetSessionVars().StmtCtx.AppendNote(err)
 			return nil
 		}
 		return err
@@ -162,16 +219,24 @@ func (e *DDLExec) executeCreateIndex(s *ast.CreateIndexStmt) error {
 func (e *DDLExec) executeDropDatabase(s *ast.DropDatabaseStmt) error {
 	dbName := model.NewCIStr(s.Name)
 	err := sessionctx.GetDomain(e.ctx).DDL().DropSchema(e.ctx, dbName)
+	if err == nil {
+		// Like MySQL, the procedures and the events of the database are dropped with it.
+		err = dropProcedures(e.ctx, dbName.L)
+		if err == nil {
+			err = event.DeleteDB(e.ctx, dbName.L)
+		}
+	}
 	if terror.ErrorEqual(err, infoschema.ErrDatabaseNotExists) {
+		err = infoschema.ErrDatabaseDropExists.GenByArgs(s.Name)
 		if s.IfExists {
+			e.ctx.GetSessionVars().StmtCtx.AppendNote(err)
 			err = nil
-		} else {
-			err = infoschema.ErrDatabaseDropExists.GenByArgs(s.Name)
 		}
 	}
 	sessionVars := e.ctx.GetSessionVars()
 	if err == nil && strings.ToLower(sessionVars.CurrentDB) == dbName.L {
 		sessionVars.CurrentDB = ""
+		sessionVars.TrackSchema()
 		err = varsutil.SetSessionSystemVar(sessionVars, variable.CharsetDatabase, types.NewStringDatum("utf8"))
 		if err != nil {
 			return errors.Trace(err)
@@ -213,6 +278,10 @@ func (e *DDLExec) executeDropTable(s *ast.DropTableStmt) error {
 	if len(notExistTables) > 0 && !s.IfExists {
 		return infoschema.ErrTableDropExists.GenByArgs(strings.Join(notExistTables, ","))
 	}
+	// Like MySQL, each table which doesn't exist has a note.
+	for _, tbl := range notExistTables {
+		e.ctx.GetSessionVars().StmtCtx.AppendNote(infoschema.ErrTableDropExists.GenByArgs(tbl))
+	}
 	return nil
 }
 
@@ -15,12 +15,18 @@ package executor_test
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/config"
+	"github.com/pingcap/tidb/ddl"
+	"github.com/pingcap/tidb/executor"
+	"github.com/pingcap/tidb/infoschema"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/plan"
 	"github.com/pingcap/tidb/sessionctx"
+	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util/testkit"
 	"github.com/pingcap/tidb/util/testleak"
 	"github.com/pingcap/tidb/util/types"
@@ -185,6 +191,301 @@ func (s *testSuite) TestAddNotNullColumnNoDefault(c *C) {
 	tk.MustQuery("select * from nn").Check(testkit.Rows("1 0", "2 0", "3 0"))
 }
 
+func (s *testSuite) TestAlterTableMultiSchemaChange(c *C) {
+	defer testleak.AfterTest(c)()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("create table multi_change (a int, b int, index ib(b))")
+	tk.MustExec("insert multi_change values (1, 1), (2, 1)")
+	tk.MustExec("alter table multi_change add column c int default 5, add index ic(c), drop index ib")
+	tk.MustQuery("select a, b, c from multi_change use index(ic) where c = 5").Check(testkit.Rows("1 1 5", "2 1 5"))
+	tbl, err := sessionctx.GetDomain(tk.Se).InfoSchema().TableByName(model.NewCIStr("test"), model.NewCIStr("multi_change"))
+	c.Assert(err, IsNil)
+	c.Assert(tbl.Meta().Indices, HasLen, 1)
+	c.Assert(tbl.Meta().Indices[0].Name.L, Equals, "ic")
+
+	tk.MustExec("alter table multi_change drop column b, add column d varchar(10) default 'x'")
+	tk.MustQuery("select * from multi_change").Check(testkit.Rows("1 5 x", "2 5 x"))
+
+	// The added columns and indices are dropped if a schema change fails.
+	tk.MustExec("insert multi_change values (1, 6, 'y')")
+	_, err = tk.Exec("alter table multi_change add column e int, add index ie(e), add unique index ua(a), drop column d")
+	c.Assert(err, NotNil)
+	c.Assert(err.Error(), Matches, ".*Duplicate.*")
+	tk.MustQuery("select * from multi_change where a = 1").Check(testkit.Rows("1 5 x", "1 6 y"))
+	tbl, err = sessionctx.GetDomain(tk.Se).InfoSchema().TableByName(model.NewCIStr("test"), model.NewCIStr("multi_change"))
+	c.Assert(err, IsNil)
+	c.Assert(tbl.Meta().Columns, HasLen, 3)
+	c.Assert(tbl.Meta().Indices, HasLen, 1)
+	_, err = tk.Exec("alter table multi_change add column e int, add column e int")
+	c.Assert(err, NotNil)
+	_, err = tk.Exec("alter table multi_change add column e int, add column d int")
+	c.Assert(err, NotNil)
+	c.Assert(tbl.Meta().Columns, HasLen, 3)
+	_, err = tk.Exec("alter table multi_change drop column c, drop column c")
+	c.Assert(err, NotNil)
+
+	// The drops are checked before any of them runs, a column isn't dropped if a later drop fails.
+	_, err = tk.Exec("alter table multi_change add index ia(a), drop column d, drop column a")
+	c.Assert(err, NotNil)
+	c.Assert(err.Error(), Matches, ".*can't drop column a with index covered now")
+	// The schema isn't reloaded when the job fails, reload it to see the dropped columns.
+	c.Assert(sessionctx.GetDomain(tk.Se).Reload(), IsNil)
+	tk.MustQuery("select * from multi_change where a = 1").Check(testkit.Rows("1 5 x", "1 6 y"))
+	_, err = tk.Exec("alter table multi_change drop column d, drop index ic, drop column a, drop column c")
+	c.Assert(err, NotNil)
+	c.Assert(sessionctx.GetDomain(tk.Se).Reload(), IsNil)
+	tk.MustQuery("select * from multi_change where a = 1").Check(testkit.Rows("1 5 x", "1 6 y"))
+	tbl, err = sessionctx.GetDomain(tk.Se).InfoSchema().TableByName(model.NewCIStr("test"), model.NewCIStr("multi_change"))
+	c.Assert(err, IsNil)
+	c.Assert(tbl.Meta().Columns, HasLen, 3)
+	c.Assert(tbl.Meta().Indices, HasLen, 1)
+
+	// Only adding and dropping columns and indices are supported.
+	_, err = tk.Exec("alter table multi_change add column f int, modify column a bigint")
+	c.Assert(err, NotNil)
+	c.Assert(err.Error(), Equals, "[ddl:6]can't run multi schema change")
+}
+
+func (s *testSuite) TestAlterTableRenameIndex(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("create table rename_index (a int primary key, b int, c int, index idx_b(b), unique key idx_c(c))")
+	tk.MustExec("insert rename_index values (1, 1, 1)")
+	tk.MustExec("alter table rename_index rename index idx_b to idx_b1")
+	tk.MustExec("alter table rename_index rename key IDX_C to idx_c1")
+	tk.MustQuery("select b from rename_index use index(idx_b1) where b = 1").Check(testkit.Rows("1"))
+	_, err := tk.Exec("insert rename_index values (2, 2, 1)")
+	c.Assert(err, NotNil)
+	// The case of the name can be changed.
+	tk.MustExec("alter table rename_index rename index idx_b1 to IDX_B1")
+
+	_, err = tk.Exec("alter table rename_index rename index idx_b to idx_b2")
+	c.Assert(err, NotNil)
+	c.Assert(err.Error(), Equals, "[ddl:1176]Key 'idx_b' doesn't exist in table 'rename_index'")
+	_, err = tk.Exec("alter table rename_index rename index idx_b1 to idx_c1")
+	c.Assert(err, NotNil)
+	_, err = tk.Exec("alter table rename_index rename index idx_b1 to primary")
+	c.Assert(err, NotNil)
+
+	is := sessionctx.GetDomain(tk.Se).InfoSchema()
+	tbl, err := is.TableByName(model.NewCIStr("test"), model.NewCIStr("rename_index"))
+	c.Assert(err, IsNil)
+	var names []string
+	for _, idx := range tbl.Meta().Indices {
+		names = append(names, idx.Name.O)
+	}
+	c.Assert(names, DeepEquals, []string{"IDX_B1", "idx_c1"})
+}
+
+func (s *testSuite) TestAlterIndexVisibility(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("create table invisible_index (a int primary key, b int, c int, unique key idx_b(b), key idx_c(c) invisible)")
+	tk.MustExec("insert invisible_index values (1, 1, 1), (2, 2, 2)")
+	usesIndex := func(sql, index string) bool {
+		rows := fmt.Sprintf("%v", tk.MustQuery("explain "+sql).Rows())
+		return strings.Contains(rows, "index:"+index)
+	}
+	c.Assert(usesIndex("select c from invisible_index where c = 1", "c"), IsFalse)
+	c.Assert(usesIndex("select b from invisible_index where b = 1", "b"), IsTrue)
+
+	tk.MustExec("alter table invisible_index alter index idx_b invisible")
+	c.Assert(usesIndex("select b from invisible_index where b = 1", "b"), IsFalse)
+	c.Assert(usesIndex("select b from invisible_index use index(idx_b) where b = 1", "b"), IsFalse)
+	tk.MustQuery("select b from invisible_index where b = 1").Check(testkit.Rows("1"))
+	// The invisible index is still maintained.
+	_, err := tk.Exec("insert invisible_index values (3, 1, 3)")
+	c.Assert(err, NotNil)
+	tk.MustQuery("show index from invisible_index where Key_name = 'idx_b'").Check(testkit.Rows(
+		"invisible_index 0 idx_b 1 b utf8_bin 0 <nil> <nil> YES BTREE   NO <nil>"))
+	tk.MustQuery("show create table invisible_index").Check(testkit.Rows("invisible_index CREATE TABLE `invisible_index` (\n" +
+		"  `a` int(11) NOT NULL,\n" +
+		"  `b` int(11) DEFAULT NULL,\n" +
+		"  `c` int(11) DEFAULT NULL,\n" +
+		"  PRIMARY KEY (`a`),\n" +
+		"  UNIQUE KEY `idx_b` (`b`) /*!80000 INVISIBLE */,\n" +
+		"  KEY `idx_c` (`c`) /*!80000 INVISIBLE */\n" +
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_bin"))
+
+	// The session can consider the invisible indices.
+	tk.MustExec("set @@tidb_opt_use_invisible_indexes = 1")
+	c.Assert(usesIndex("select c from invisible_index where c = 1", "c"), IsTrue)
+	tk.MustExec("set @@tidb_opt_use_invisible_indexes = 0")
+
+	tk.MustExec("alter table invisible_index alter index idx_c visible")
+	c.Assert(usesIndex("select c from invisible_index where c = 1", "c"), IsTrue)
+	tk.MustExec("create index idx_bc on invisible_index (b, c) invisible")
+	tk.MustQuery("select is_visible from information_schema.statistics where table_name = 'invisible_index' and index_name = 'idx_bc'").Check(
+		testkit.Rows("NO", "NO"))
+
+	_, err = tk.Exec("alter table invisible_index alter index `primary` invisible")
+	c.Assert(err, NotNil)
+	c.Assert(err.Error(), Equals, "[ddl:3522]A primary key index cannot be invisible")
+	_, err = tk.Exec("alter table invisible_index alter index idx_d invisible")
+	c.Assert(err, NotNil)
+	_, err = tk.Exec("create table invisible_pk (a int, primary key (a) invisible)")
+	c.Assert(err, NotNil)
+}
+
+func (s *testSuite) TestExpressionIndex(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("create table expr_index (a int primary key, name varchar(20))")
+	tk.MustExec("insert expr_index values (1, 'Abc'), (2, 'aBC'), (3, 'xyz')")
+	tk.MustExec("create index idx_lower on expr_index ((lower(name)))")
+	// The key of the index is the hidden column.
+	rows := fmt.Sprintf("%v", tk.MustQuery("explain select a from expr_index where lower(name) = 'abc'").Rows())
+	c.Assert(strings.Contains(rows, "index:_V$_idx_lower_0, range:[abc,abc]"), IsTrue, Commentf("%s", rows))
+	tk.MustQuery("select a from expr_index where lower(name) = 'abc'").Check(testkit.Rows("1", "2"))
+	tk.MustExec("admin check table expr_index")
+
+	// The index is maintained by the writes.
+	tk.MustExec("insert expr_index values (4, 'ABC')")
+	tk.MustExec("update expr_index set name = 'XYZ' where a = 1")
+	tk.MustExec("delete from expr_index where a = 2")
+	tk.MustQuery("select a from expr_index where lower(name) = 'abc'").Check(testkit.Rows("4"))
+	tk.MustQuery("select a from expr_index where lower(name) = 'xyz'").Check(testkit.Rows("1", "3"))
+	tk.MustExec("admin check table expr_index")
+
+	// The hidden column can't be seen.
+	tk.MustQuery("select * from expr_index where a = 4").Check(testkit.Rows("4 ABC"))
+	tk.MustQuery("show columns from expr_index").Check(testkit.Rows(
+		"a int(11) NO PRI <nil> ", "name varchar(20) YES  <nil> "))
+	tk.MustQuery("show index from expr_index where Key_name = 'idx_lower'").Check(testkit.Rows(
+		"expr_index 1 idx_lower 1 <nil> utf8_bin 0 <nil> <nil> YES BTREE   YES lower(name)"))
+	tk.MustQuery("show create table expr_index").Check(testkit.Rows("expr_index CREATE TABLE `expr_index` (\n" +
+		"  `a` int(11) NOT NULL,\n" +
+		"  `name` varchar(20) DEFAULT NULL,\n" +
+		"  PRIMARY KEY (`a`),\n" +
+		"  KEY `idx_lower` ((lower(name)))\n" +
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_bin"))
+
+	// The index is created with the table.
+	tk.MustExec("create table expr_index2 (a int, b int, unique key ((a + b)))")
+	tk.MustExec("insert expr_index2 values (1, 2)")
+	_, err := tk.Exec("insert expr_index2 values (2, 1)")
+	c.Assert(err, NotNil)
+	tk.MustQuery("select a from expr_index2 where a + b = 3").Check(testkit.Rows("1"))
+
+	_, err = tk.Exec("create index idx_a on expr_index ((a))")
+	c.Assert(err, NotNil)
+	c.Assert(err.Error(), Equals, "[ddl:3756]Functional index on a column is not supported. Consider using a regular index instead.")
+	_, err = tk.Exec("create index idx_rand on expr_index ((a + rand()))")
+	c.Assert(err, NotNil)
+	c.Assert(err.Error(), Equals, "[ddl:3758]Expression of functional index 'idx_rand' contains a disallowed function.")
+	_, err = tk.Exec("create table expr_pk (a int, primary key ((a + 1)))")
+	c.Assert(err, NotNil)
+
+	// The hidden column is dropped with the index.
+	tk.MustExec("drop index idx_lower on expr_index")
+	tbl, err := sessionctx.GetDomain(tk.Se).InfoSchema().TableByName(model.NewCIStr("test"), model.NewCIStr("expr_index"))
+	c.Assert(err, IsNil)
+	c.Assert(tbl.Meta().Columns, HasLen, 2)
+	tk.MustExec("admin check table expr_index")
+}
+
+func (s *testSuite) TestClusteredPrimaryKey(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("create table clustered_pk (a int primary key clustered, b int)")
+	tbl, err := sessionctx.GetDomain(tk.Se).InfoSchema().TableByName(model.NewCIStr("test"), model.NewCIStr("clustered_pk"))
+	c.Assert(err, IsNil)
+	c.Assert(tbl.Meta().PKIsHandle, IsTrue)
+
+	// A nonclustered integer primary key is a unique index.
+	tk.MustExec("create table nonclustered_pk (a int, b int, primary key (a) nonclustered)")
+	tbl, err = sessionctx.GetDomain(tk.Se).InfoSchema().TableByName(model.NewCIStr("test"), model.NewCIStr("nonclustered_pk"))
+	c.Assert(err, IsNil)
+	c.Assert(tbl.Meta().PKIsHandle, IsFalse)
+	c.Assert(tbl.Meta().Indices, HasLen, 1)
+	c.Assert(tbl.Meta().Indices[0].Primary, IsTrue)
+	tk.MustExec("insert nonclustered_pk values (1, 1), (2, 2)")
+	_, err = tk.Exec("insert nonclustered_pk values (1, 3)")
+	c.Assert(err, NotNil)
+	tk.MustQuery("select b from nonclustered_pk where a = 2").Check(testkit.Rows("2"))
+	tk.MustExec("admin check table nonclustered_pk")
+	createSQL := "CREATE TABLE `nonclustered_pk` (\n" +
+		"  `a` int(11) NOT NULL,\n" +
+		"  `b` int(11) DEFAULT NULL,\n" +
+		"  PRIMARY KEY (`a`) /*T![clustered_index] NONCLUSTERED */\n" +
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_bin"
+	tk.MustQuery("show create table nonclustered_pk").Check(testkit.Rows("nonclustered_pk " + createSQL))
+	// The statement shown by SHOW CREATE TABLE creates the same table.
+	tk.MustExec("drop table nonclustered_pk")
+	tk.MustExec(createSQL)
+	tbl, err = sessionctx.GetDomain(tk.Se).InfoSchema().TableByName(model.NewCIStr("test"), model.NewCIStr("nonclustered_pk"))
+	c.Assert(err, IsNil)
+	c.Assert(tbl.Meta().PKIsHandle, IsFalse)
+
+	_, err = tk.Exec("create table clustered_varchar_pk (a varchar(10) primary key clustered)")
+	c.Assert(terror.ErrorEqual(err, ddl.ErrUnsupportedClusteredPrimaryKey), IsTrue, Commentf("err %v", err))
+	_, err = tk.Exec("create table clustered_composite_pk (a int, b int, primary key (a, b) clustered)")
+	c.Assert(terror.ErrorEqual(err, ddl.ErrUnsupportedClusteredPrimaryKey), IsTrue, Commentf("err %v", err))
+	_, err = tk.Exec("create table clustered_key (a int, unique key (a) clustered)")
+	c.Assert(terror.ErrorEqual(err, ddl.ErrUnsupportedClusteredSecondaryKey), IsTrue, Commentf("err %v", err))
+	_, err = tk.Exec("create index idx_b on clustered_pk (b) nonclustered")
+	c.Assert(terror.ErrorEqual(err, ddl.ErrUnsupportedClusteredSecondaryKey), IsTrue, Commentf("err %v", err))
+}
+
+func (s *testSuite) TestDescIndex(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("create table desc_index (a int, b int, c varchar(10), key idx_b (b desc), key idx_ab (a, b desc), unique key idx_c (c desc))")
+	tk.MustExec("insert desc_index values (1, 1, 'a'), (1, 3, 'b'), (2, 2, 'c'), (1, null, 'd'), (2, 5, 'e'), (1, 4, 'f')")
+	tk.MustExec("admin check table desc_index")
+	tk.MustQuery("select b from desc_index use index (idx_b) where b > 2").Sort().Check(testkit.Rows("3", "4", "5"))
+	tk.MustQuery("select b from desc_index use index (idx_b) where b <= 2").Sort().Check(testkit.Rows("1", "2"))
+	tk.MustQuery("select b from desc_index use index (idx_b) where b is null").Check(testkit.Rows("<nil>"))
+	tk.MustQuery("select count(*) from desc_index use index (idx_b) where b is not null").Check(testkit.Rows("5"))
+	tk.MustQuery("select b from desc_index use index (idx_b) where b in (1, 5, 3)").Sort().Check(testkit.Rows("1", "3", "5"))
+
+	// The order of the index satisfies the order by, the keys are scanned in the reverse order for the ascending order.
+	tk.MustQuery("select b from desc_index use index (idx_b) order by b desc").Check(testkit.Rows("5", "4", "3", "2", "1", "<nil>"))
+	tk.MustQuery("select b from desc_index use index (idx_b) where b < 5 order by b").Check(testkit.Rows("1", "2", "3", "4"))
+	tk.MustQuery("select b from desc_index use index (idx_ab) where a = 1 and b > 1 order by b desc").Check(testkit.Rows("4", "3"))
+	tk.MustQuery("select c from desc_index use index (idx_c) where c < 'd' order by c desc").Check(testkit.Rows("c", "b", "a"))
+	rows := fmt.Sprintf("%v", tk.MustQuery("explain select b from desc_index use index (idx_b) order by b desc").Rows())
+	c.Assert(strings.Contains(rows, "Sort"), IsFalse, Commentf("%s", rows))
+	tk.MustExec("set @@tidb_cbo = 0")
+	tk.MustQuery("select b from desc_index use index (idx_b) where b < 5 order by b").Check(testkit.Rows("1", "2", "3", "4"))
+	tk.MustQuery("select b from desc_index use index (idx_ab) where a = 1 and b > 1 order by b desc").Check(testkit.Rows("4", "3"))
+	tk.MustExec("set @@tidb_cbo = 1")
+
+	// The rows written in the transaction are merged in the order of the index.
+	tk.MustExec("begin")
+	tk.MustExec("insert desc_index values (3, 6, 'g'), (3, 0, 'h')")
+	tk.MustQuery("select b from desc_index use index (idx_b) where b >= 3 order by b desc").Check(testkit.Rows("6", "5", "4", "3"))
+	tk.MustQuery("select b from desc_index use index (idx_b) where b <= 1 order by b").Check(testkit.Rows("0", "1"))
+	tk.MustExec("rollback")
+
+	_, err := tk.Exec("insert desc_index values (3, 3, 'a')")
+	c.Assert(err, NotNil)
+	tk.MustExec("update desc_index set b = 10 where b = 1")
+	tk.MustExec("delete from desc_index where b = 3")
+	tk.MustQuery("select b from desc_index use index (idx_b) order by b desc").Check(testkit.Rows("10", "5", "4", "2", "<nil>"))
+	tk.MustExec("admin check table desc_index")
+	tk.MustExec("analyze table desc_index")
+	tk.MustQuery("select b from desc_index use index (idx_b) where b > 3 and b < 10").Sort().Check(testkit.Rows("4", "5"))
+
+	tk.MustQuery("show create table desc_index").Check(testkit.Rows("desc_index CREATE TABLE `desc_index` (\n" +
+		"  `a` int(11) DEFAULT NULL,\n" +
+		"  `b` int(11) DEFAULT NULL,\n" +
+		"  `c` varchar(10) DEFAULT NULL,\n" +
+		"  KEY `idx_b` (`b` DESC),\n" +
+		"  KEY `idx_ab` (`a`,`b` DESC),\n" +
+		"  UNIQUE KEY `idx_c` (`c` DESC)\n" +
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_bin"))
+
+	// The handle is ascending, a descending primary key is a unique index.
+	tk.MustExec("create table desc_pk (a int, primary key (a desc))")
+	tbl, err := sessionctx.GetDomain(tk.Se).InfoSchema().TableByName(model.NewCIStr("test"), model.NewCIStr("desc_pk"))
+	c.Assert(err, IsNil)
+	c.Assert(tbl.Meta().PKIsHandle, IsFalse)
+	tk.MustExec("insert desc_pk values (1), (2)")
+	tk.MustQuery("select a from desc_pk order by a desc").Check(testkit.Rows("2", "1"))
+}
+
 func (s *testSuite) TestAlterTableModifyColumn(c *C) {
 	defer testleak.AfterTest(c)()
 	tk := testkit.NewTestKit(c, s.store)
@@ -278,3 +579,97 @@ func (s *testSuite) TestUnsupportedCharset(c *C) {
 	}
 	tk.MustExec("drop database " + dbName)
 }
+
+func (s *testSuite) TestLockTables(c *C) {
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk1 := testkit.NewTestKit(c, s.store)
+	tk1.MustExec("use test")
+	tk1.MustExec("create table t1 (a int)")
+	tk1.MustExec("create table t2 (a int)")
+	tk1.MustExec("create table t3 (a int)")
+	tk1.Se.SetConnectionID(1)
+	tk2 := testkit.NewTestKit(c, s.store)
+	tk2.MustExec("use test")
+	tk2.Se.SetConnectionID(2)
+
+	// LOCK TABLES does nothing unless the table locks are enabled.
+	tk1.MustExec("lock tables t1 write")
+	tk2.MustExec("insert into t1 values (1)")
+	tk1.MustExec("unlock tables")
+
+	cfg := config.GetGlobalConfig()
+	cfg.EnableTableLock = true
+	defer func() {
+		cfg.EnableTableLock = false
+	}()
+	tk1.MustExec("lock tables t1 write, t2 read, mysql.user read")
+	tk1.MustExec("insert into t1 values (2)")
+	tk1.MustQuery("select count(*) from t1, t2").Check(testkit.Rows("0"))
+	tk1.MustQuery("select count(*) > 0 from mysql.user").Check(testkit.Rows("1"))
+	_, err := tk1.Exec("insert into t2 values (1)")
+	c.Assert(terror.ErrorEqual(err, infoschema.ErrTableNotLockedForWrite), IsTrue)
+	_, err = tk1.Exec("select * from t3")
+	c.Assert(err.Error(), Equals, "[schema:1100]Table 't3' was not locked with LOCK TABLES")
+
+	// The other sessions can read the READ locked tables only.
+	_, err = tk2.Exec("select * from t1")
+	c.Assert(terror.ErrorEqual(err, infoschema.ErrTableLocked), IsTrue)
+	_, err = tk2.Exec("update t2 set a = 1")
+	c.Assert(terror.ErrorEqual(err, infoschema.ErrTableLocked), IsTrue)
+	_, err = tk2.Exec("alter table t1 add column b int")
+	c.Assert(terror.ErrorEqual(err, infoschema.ErrTableLocked), IsTrue)
+	tk2.MustQuery("select count(*) from t2").Check(testkit.Rows("0"))
+	tk2.MustExec("insert into t3 values (1)")
+	_, err = tk2.Exec("lock tables t3 read, t1 read")
+	c.Assert(terror.ErrorEqual(err, infoschema.ErrTableLocked), IsTrue)
+	// The lock of t3 is released after the statement fails.
+	tk1.MustExec("unlock tables")
+	tk1.MustExec("insert into t3 values (2)")
+	_, err = tk1.Exec("lock tables t1 read, t1 write")
+	c.Assert(terror.ErrorEqual(err, executor.ErrNonUniqTable), IsTrue)
+
+	// A READ lock is shared, and a new LOCK TABLES releases the locks of the session first.
+	tk1.MustExec("lock tables t1 read")
+	tk2.MustExec("lock tables t1 read local, t2 write")
+	tk1.MustQuery("select count(*) from t1").Check(testkit.Rows("2"))
+	_, err = tk1.Exec("lock tables t2 read")
+	c.Assert(terror.ErrorEqual(err, infoschema.ErrTableLocked), IsTrue)
+	tk2.MustExec("lock tables t2 write")
+	tk1.MustExec("lock tables t1 write")
+	is := sessionctx.GetDomain(tk1.Se).InfoSchema()
+	tbl, err := is.TableByName(model.NewCIStr("test"), model.NewCIStr("t1"))
+	c.Assert(err, IsNil)
+	c.Assert(tbl.Meta().Lock.Tp, Equals, model.TableLockWrite)
+	c.Assert(tbl.Meta().Lock.Sessions, HasLen, 1)
+	c.Assert(tbl.Meta().Lock.Sessions[0].SessionID, Equals, uint64(1))
+
+	// The locks are released when the session is closed, a dropped table releases its lock.
+	tk1.Se.Close()
+	tk2.MustExec("unlock tables")
+	tk2.MustQuery("select count(*) from t1").Check(testkit.Rows("2"))
+	tk2.MustExec("lock tables t1 write, t2 write")
+	tk2.MustExec("drop table t1")
+	tk2.MustExec("unlock tables")
+	tk1 = testkit.NewTestKit(c, s.store)
+	tk1.MustExec("use test")
+	tk1.MustExec("insert into t2 values (3)")
+	tk1.MustExec("drop table t2, t3")
+
+	// The locks left by the sessions of a crashed server can be removed by "admin cleanup table lock".
+	tk1.MustExec("create table t1 (a int)")
+	tk1.MustExec("lock tables t1 write")
+	_, err = tk2.Exec("insert into t1 values (1)")
+	c.Assert(terror.ErrorEqual(err, infoschema.ErrTableLocked), IsTrue)
+	tk2.MustExec("admin cleanup table lock t1")
+	tk2.MustExec("insert into t1 values (1)")
+	tbl, err = sessionctx.GetDomain(tk2.Se).InfoSchema().TableByName(model.NewCIStr("test"), model.NewCIStr("t1"))
+	c.Assert(err, IsNil)
+	c.Assert(tbl.Meta().Lock, IsNil)
+	_, err = tk2.Exec("admin cleanup table lock t4")
+	c.Assert(infoschema.ErrTableNotExists.Equal(err), IsTrue)
+	tk1.MustExec("unlock tables")
+	tk1.MustExec("drop table t1")
+}
@@ -36,7 +36,6 @@ import (
 	"github.com/pingcap/tidb/util/codec"
 	"github.com/pingcap/tidb/util/types"
 	"github.com/pingcap/tipb/go-tipb"
-	goctx "golang.org/x/net/context"
 )
 
 const (
@@ -101,11 +100,11 @@ func (s *rowsSorter) Swap(i, j int) {
 	s.rows[i], s.rows[j] = s.rows[j], s.rows[i]
 }
 
-func tableRangesToKVRanges(tid int64, tableRanges []types.IntColumnRange) []kv.KeyRange {
+func tableRangesToKVRanges(tid int64, tableRanges []*types.Range) []kv.KeyRange {
 	krs := make([]kv.KeyRange, 0, len(tableRanges))
 	for _, tableRange := range tableRanges {
-		startKey := tablecodec.EncodeRowKeyWithHandle(tid, tableRange.LowVal)
-		hi := tableRange.HighVal
+		low, hi := tableRange.IntBounds()
+		startKey := tablecodec.EncodeRowKeyWithHandle(tid, low)
 		if hi != math.MaxInt64 {
 			hi++
 		}
@@ -147,52 +146,67 @@ func tableHandlesToKVRanges(tid int64, handles []int64) []kv.KeyRange {
 }
 
 // indexValuesToKVRanges will convert the index datums to kv ranges.
-func indexValuesToKVRanges(tid, idxID int64, values [][]types.Datum) ([]kv.KeyRange, error) {
+func indexValuesToKVRanges(tid int64, idx *model.IndexInfo, values [][]types.Datum) ([]kv.KeyRange, error) {
+	desc := idx.DescColumns()
 	krs := make([]kv.KeyRange, 0, len(values))
 	for _, vals := range values {
 		// TODO: We don't process the case that equal key has different types.
-		valKey, err := codec.EncodeKey(nil, vals...)
+		valKey, err := codec.EncodeKeyWithDesc(nil, desc, vals...)
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
 		valKeyNext := []byte(kv.Key(valKey).PrefixNext())
-		rangeBeginKey := tablecodec.EncodeIndexSeekKey(tid, idxID, valKey)
-		rangeEndKey := tablecodec.EncodeIndexSeekKey(tid, idxID, valKeyNext)
+		rangeBeginKey := tablecodec.EncodeIndexSeekKey(tid, idx.ID, valKey)
+		rangeEndKey := tablecodec.EncodeIndexSeekKey(tid, idx.ID, valKeyNext)
 		krs = append(krs, kv.KeyRange{StartKey: rangeBeginKey, EndKey: rangeEndKey})
 	}
+	if desc != nil {
+		tablecodec.SortKeyRanges(krs)
+	}
 	return krs, nil
 }
 
-func indexRangesToKVRanges(sc *variable.StatementContext, tid, idxID int64, ranges []*types.IndexRange, fieldTypes []*types.FieldType) ([]kv.KeyRange, error) {
+func indexRangesToKVRanges(sc *variable.StatementContext, tid int64, idx *model.IndexInfo, ranges []*types.Range, fieldTypes []*types.FieldType) ([]kv.KeyRange, error) {
+	desc := idx.DescColumns()
 	krs := make([]kv.KeyRange, 0, len(ranges))
 	for _, ran := range ranges {
 		err := convertIndexRangeTypes(sc, ran, fieldTypes)
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
+		if desc != nil {
+			ran, err = ran.ReverseRange(sc, desc)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
 
-		low, err := codec.EncodeKey(nil, ran.LowVal...)
+		low, err := codec.EncodeKeyWithDesc(nil, desc, ran.LowVal...)
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
 		if ran.LowExclude {
 			low = []byte(kv.Key(low).PrefixNext())
 		}
-		high, err := codec.EncodeKey(nil, ran.HighVal...)
+		high, err := codec.EncodeKeyWithDesc(nil, desc, ran.HighVal...)
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
 		if !ran.HighExclude {
 			high = []byte(kv.Key(high).PrefixNext())
 		}
-		startKey := tablecodec.EncodeIndexSeekKey(tid, idxID, low)
-		endKey := tablecodec.EncodeIndexSeekKey(tid, idxID, high)
+		startKey := tablecodec.EncodeIndexSeekKey(tid, idx.ID, low)
+		endKey := tablecodec.EncodeIndexSeekKey(tid, idx.ID, high)
 		krs = append(krs, kv.KeyRange{StartKey: startKey, EndKey: endKey})
 	}
+	// The ranges are in the order of the values, they're sorted in the order of the keys.
+	if desc != nil {
+		tablecodec.SortKeyRanges(krs)
+	}
 	return krs, nil
 }
 
-func convertIndexRangeTypes(sc *variable.StatementContext, ran *types.IndexRange, fieldTypes []*types.FieldType) error {
+func convertIndexRangeTypes(sc *variable.StatementContext, ran *types.Range, fieldTypes []*types.FieldType) error {
 	for i := range ran.LowVal {
 		if ran.LowVal[i].Kind() == types.KindMinNotNull || ran.LowVal[i].Kind() == types.KindMaxValue {
 			continue
@@ -274,11 +288,11 @@ func extractHandlesFromIndexSubResult(subResult distsql.PartialResult) ([]int64,
 	defer subResult.Close()
 	var handles []int64
 	for {
-		h, data, err := subResult.Next()
+		h, ok, err := subResult.NextRow(nil)
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
-		if data == nil {
+		if !ok {
 			break
 		}
 		handles = append(handles, h)
@@ -363,7 +377,7 @@ type XSelectIndexExec struct {
 	startTS              uint64
 	returnedRows         uint64 // returned row count
 	schema               *expression.Schema
-	ranges               []*types.IndexRange
+	ranges               []*types.Range
 	limitCount           *int64
 	sortItemsPB          []*tipb.ByItem
 	columns              []*model.ColumnInfo
@@ -664,7 +678,7 @@ func (e *XSelectIndexExec) doIndexRequest() (distsql.SelectResult, error) {
 	}
 	sv := e.ctx.GetSessionVars()
 	sc := sv.StmtCtx
-	keyRanges, err := indexRangesToKVRanges(sc, e.table.Meta().ID, e.index.ID, e.ranges, fieldTypes)
+	keyRanges, err := indexRangesToKVRanges(sc, e.table.Meta().ID, e.index, e.ranges, fieldTypes)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
@@ -841,8 +855,8 @@ func (e *XSelectIndexExec) doTableRequest(handles []int64) (distsql.SelectResult
 	selTableReq.GroupBy = e.byItems
 	keyRanges := tableHandlesToKVRanges(e.table.Meta().ID, handles)
 	// Use the table scan concurrency variable to do table request.
-	concurrency := e.ctx.GetSessionVars().DistSQLScanConcurrency
-	resp, err := distsql.Select(e.ctx.GetClient(), goctx.Background(), selTableReq, keyRanges, concurrency, false, getIsolationLevel(e.ctx.GetSessionVars()), e.priority)
+	concurrency := distSQLScanConcurrency(e.ctx, e.priority)
+	resp, err := distsql.Select(e.ctx.GetClient(), e.ctx.GoCtx(), selTableReq, keyRanges, concurrency, false, getIsolationLevel(e.ctx.GetSessionVars()), e.priority)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
@@ -867,7 +881,7 @@ type XSelectTableExec struct {
 	where        *tipb.Expr
 	Columns      []*model.ColumnInfo
 	schema       *expression.Schema
-	ranges       []types.IntColumnRange
+	ranges       []*types.Range
 	desc         bool
 	limitCount   *int64
 	returnedRows uint64 // returned rowCount
@@ -926,7 +940,7 @@ func (e *XSelectTableExec) doRequest() error {
 	selReq.GroupBy = e.byItems
 
 	kvRanges := tableRangesToKVRanges(e.table.Meta().ID, e.ranges)
-	e.result, err = distsql.Select(e.ctx.GetClient(), goctx.Background(), selReq, kvRanges, e.ctx.GetSessionVars().DistSQLScanConcurrency, e.keepOrder, getIsolationLevel(e.ctx.GetSessionVars()), e.priority)
+	e.result, err = distsql.Select(e.ctx.GetClient(), e.ctx.GoCtx(), selReq, kvRanges, distSQLScanConcurrency(e.ctx, e.priority), e.keepOrder, getIsolationLevel(e.ctx.GetSessionVars()), e.priority)
 	if err != nil {
 		return errors.Trace(err)
 	}
@@ -1032,6 +1046,26 @@ func timeZoneOffset(ctx context.Context) int64 {
 	return int64(offset)
 }
 
+// distSQLScanConcurrency returns the concurrency of a distsql scan task with the priority.
+func distSQLScanConcurrency(ctx context.Context, priority int) int {
+	vars := ctx.GetSessionVars()
+	concurrency := vars.DistSQLScanConcurrency
+	if priority == kv.PriorityLow && vars.DistSQLLowPriorityConcurrency > 0 && vars.DistSQLLowPriorityConcurrency < concurrency {
+		concurrency = vars.DistSQLLowPriorityConcurrency
+	}
+	return concurrency
+}
+
+// dagFlags returns the flags of the DAG requests, the results are wanted in chunk encoded format
+// if it is enabled and supported by the storage.
+func dagFlags(ctx context.Context) uint64 {
+	flags := statementContextToFlags(ctx.GetSessionVars().StmtCtx)
+	if ctx.GetSessionVars().EnableChunkRPC && ctx.GetClient().IsRequestTypeSupported(kv.ReqTypeDAG, kv.ReqSubTypeChunk) {
+		flags |= xeval.FlagChunkEncode
+	}
+	return flags
+}
+
 // statementContextToFlags converts StatementContext to tipb.SelectRequest.Flags.
 func statementContextToFlags(sc *variable.StatementContext) uint64 {
 	var flags uint64
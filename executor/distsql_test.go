@@ -26,6 +26,7 @@ import (
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/sessionctx"
 	"github.com/pingcap/tidb/store/tikv"
+	"github.com/pingcap/tidb/store/tikv/mock-tikv"
 	"github.com/pingcap/tidb/tablecodec"
 	"github.com/pingcap/tidb/util/testkit"
 )
@@ -133,3 +134,76 @@ func (s *testSuite) TestCopClientSend(c *C) {
 	keyword := "(*copIterator).work"
 	c.Check(checkGoroutineExists(keyword), IsFalse)
 }
+
+func (s *testSuite) TestPushDownWithInjectedFailures(c *C) {
+	if _, ok := s.store.GetClient().(*tikv.CopClient); !ok || s.cluster == nil {
+		// Make sure the store is mock tikv store.
+		return
+	}
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("create table pushdown (id int primary key, a int)")
+	var values []string
+	for i := 0; i < 100; i++ {
+		values = append(values, fmt.Sprintf("(%d, %d)", i, i))
+	}
+	tk.MustExec("insert pushdown values " + strings.Join(values, ","))
+	is := sessionctx.GetDomain(tk.Se).InfoSchema()
+	tbl, err := is.TableByName(model.NewCIStr("test"), model.NewCIStr("pushdown"))
+	c.Assert(err, IsNil)
+	tblID := tbl.Meta().ID
+
+	// The regions are split when the coprocessor requests are being sent.
+	for _, handle := range []int64{30, 60, 90} {
+		s.cluster.SplitOnRequest(tablecodec.EncodeRowKeyWithHandle(tblID, handle))
+	}
+	tk.MustQuery("select count(*), sum(a) from pushdown").Check(testkit.Rows("100 4950"))
+	tk.MustQuery("select id from pushdown order by a desc limit 3").Check(testkit.Rows("99", "98", "97"))
+	tk.MustQuery("select id from pushdown where id > 40 order by id limit 2").Check(testkit.Rows("41", "42"))
+	firstRegion, _ := s.cluster.GetRegionByKey(mocktikv.NewMvccKey(tablecodec.EncodeRowKeyWithHandle(tblID, 0)))
+	lastRegion, _ := s.cluster.GetRegionByKey(mocktikv.NewMvccKey(tablecodec.EncodeRowKeyWithHandle(tblID, 90)))
+	c.Assert(firstRegion.GetId(), Not(Equals), lastRegion.GetId())
+
+	// The requests meet a lock of a concurrent transaction, it's resolved before reading.
+	ver, err := s.store.CurrentVersion()
+	c.Assert(err, IsNil)
+	key := tablecodec.EncodeRowKeyWithHandle(tblID, 10)
+	s.cluster.LockOnRead(key, ver.Ver, 1)
+	tk.MustQuery("select sum(a) from pushdown where id < 50").Check(testkit.Rows("1225"))
+	info, _ := s.mvccStore.MvccGetByStartTS(nil, nil, ver.Ver)
+	c.Assert(info, NotNil)
+	c.Assert(s.mvccStore.MvccGetByKey(key).GetLock(), IsNil)
+}
+
+// TestIndexLookUpLimit checks that the index lookup under a limit returns the right rows when it stops reading the
+// index early.
+func (s *testSuite) TestIndexLookUpLimit(c *C) {
+	if _, ok := s.store.GetClient().(*tikv.CopClient); !ok {
+		// Make sure the store is tikv store.
+		return
+	}
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("set @@tidb_index_lookup_size = '4'")
+	tk.MustExec("use test")
+	tk.MustExec("create table lookup_limit (id int primary key, c int, d int, index (c))")
+	var values []string
+	for i := 0; i < 100; i++ {
+		values = append(values, fmt.Sprintf("(%d, %d, %d)", i, 99-i, i%10))
+	}
+	tk.MustExec("insert lookup_limit values " + strings.Join(values, ","))
+	dom := sessionctx.GetDomain(tk.Se)
+	tbl, err := dom.InfoSchema().TableByName(model.NewCIStr("test"), model.NewCIStr("lookup_limit"))
+	c.Assert(err, IsNil)
+	s.cluster.SplitIndex(s.mvccStore, tbl.Meta().ID, tbl.Meta().Indices[0].ID, 10)
+
+	tk.MustQuery("select * from lookup_limit where c > 10 order by c limit 5").Check(testkit.Rows(
+		"88 11 8", "87 12 7", "86 13 6", "85 14 5", "84 15 4"))
+	tk.MustQuery("select * from lookup_limit where c < 90 order by c desc limit 3, 4").Check(testkit.Rows(
+		"13 86 3", "14 85 4", "15 84 5", "16 83 6"))
+	// The limit isn't pushed to the executor if the table side filters the rows.
+	tk.MustQuery("select * from lookup_limit where c > 10 and d = 3 order by c limit 3").Check(testkit.Rows(
+		"83 16 3", "73 26 3", "63 36 3"))
+	c.Assert(tk.MustQuery("select * from lookup_limit use index (c) where c > 10 limit 7").Rows(), HasLen, 7)
+	time.Sleep(time.Millisecond * 50)
+	c.Check(checkGoroutineExists("pickAndExecTask"), IsFalse)
+}
@@ -0,0 +1,316 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/juju/errors"
+	"github.com/ngaut/log"
+	"github.com/pingcap/tidb/config"
+	"github.com/pingcap/tidb/infoschema"
+	"github.com/pingcap/tidb/inspectkv"
+	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/table"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// DumpRowsPerFile is the max number of rows in a data file of the dump.
+var DumpRowsPerFile = 100000
+
+// dumpRowsPerStmt is the max number of rows in an insert statement of the dump.
+const dumpRowsPerStmt = 1000
+
+// DumpDatabaseExec represents a dump database executor.
+// It is built from the "admin dump database" statement, it writes the schema and the data of the
+// tables in the database at a consistent snapshot to the files in the mydumper layout:
+//
+//	db-schema-create.sql      the create database statement.
+//	db.table-schema.sql       the create table statement.
+//	db.table.00001.sql, ...   the insert statements of the table data, in the order of the handles.
+//
+// The tables are dumped concurrently, the data of a table is split into files of DumpRowsPerFile rows.
+// The path is relative to the dump directory of the server config, the existing files are never overwritten.
+type DumpDatabaseExec struct {
+	baseExecutor
+
+	dbName  model.CIStr
+	path    string
+	startTS uint64
+	is      infoschema.InfoSchema
+	rows    []Row
+}
+
+// dumpResult is the result of dumping a table.
+type dumpResult struct {
+	rows  int64
+	files int64
+	err   error
+}
+
+// Open implements the Executor Open interface.
+// The data is dumped here because the transaction is committed before Next is called.
+func (e *DumpDatabaseExec) Open() error {
+	db, ok := e.is.SchemaByName(e.dbName)
+	if !ok {
+		return infoschema.ErrDatabaseNotExists.GenByArgs(e.dbName.O)
+	}
+	path, err := dumpPath(e.path)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	e.path = path
+	if err = os.MkdirAll(e.path, 0755); err != nil {
+		return errors.Trace(err)
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "CREATE DATABASE %s", dumpIdent(db.Name.O))
+	if s := db.Charset; len(s) > 0 {
+		fmt.Fprintf(&buf, " /* !40100 DEFAULT CHARACTER SET %s */", s)
+	}
+	buf.WriteString(";\n")
+	if err = e.writeFile(fmt.Sprintf("%s-schema-create.sql", dumpFileName(db.Name.O)), buf.Bytes()); err != nil {
+		return errors.Trace(err)
+	}
+
+	snapshot, err := e.ctx.GetStore().GetSnapshot(kv.Version{Ver: e.startTS})
+	if err != nil {
+		return errors.Trace(err)
+	}
+	tables := e.is.SchemaTables(e.dbName)
+	sort.Slice(tables, func(i, j int) bool { return tables[i].Meta().Name.L < tables[j].Meta().Name.L })
+
+	results := make([]dumpResult, len(tables))
+	taskCh := make(chan int, len(tables))
+	for i := range tables {
+		taskCh <- i
+	}
+	close(taskCh)
+	concurrency := e.ctx.GetSessionVars().DistSQLScanConcurrency
+	if concurrency > len(tables) {
+		concurrency = len(tables)
+	}
+	var wg sync.WaitGroup
+	wg.Add(concurrency)
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			defer wg.Done()
+			for i := range taskCh {
+				r := &results[i]
+				r.rows, r.files, r.err = e.dumpTable(snapshot, db.Name.O, tables[i])
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i, tb := range tables {
+		if results[i].err != nil {
+			return errors.Trace(results[i].err)
+		}
+		e.rows = append(e.rows, types.MakeDatums(tb.Meta().Name.O, results[i].rows, results[i].files))
+	}
+	return nil
+}
+
+// dumpPath returns the directory to write the dump files to, the path must be a relative path under the
+// dump directory of the server.
+func dumpPath(path string) (string, error) {
+	dir := config.GetGlobalConfig().DumpDir
+	if dir == "" {
+		return "", ErrInvalidDumpPath.GenByArgs(path, "the dump directory of the server is not set")
+	}
+	cleaned := filepath.Clean(path)
+	if filepath.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+		return "", ErrInvalidDumpPath.GenByArgs(path, "it must be a relative path under the dump directory")
+	}
+	return filepath.Join(dir, cleaned), nil
+}
+
+// dumpFileName encodes the name of a database or a table to be a part of a file name, the path separators
+// are encoded so the files are always written in the dump path.
+func dumpFileName(name string) string {
+	return strings.NewReplacer("%", "%25", "/", "%2F", "\\", "%5C").Replace(name)
+}
+
+// dumpIdent quotes the name of a database, a table or a column in the dumped SQL.
+func dumpIdent(name string) string {
+	return "`" + strings.Replace(name, "`", "``", -1) + "`"
+}
+
+// writeFile writes a new file, it fails if the file exists.
+func (e *DumpDatabaseExec) writeFile(name string, data []byte) error {
+	path := filepath.Join(e.path, name)
+	if filepath.Dir(path) != filepath.Clean(e.path) {
+		return ErrInvalidDumpPath.GenByArgs(path, "the file must be in the dump path")
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if _, err = f.Write(data); err != nil {
+		f.Close()
+		return errors.Trace(err)
+	}
+	return errors.Trace(f.Close())
+}
+
+// dumpTable dumps the schema and the data of the table, it returns the numbers of the rows and the data files.
+func (e *DumpDatabaseExec) dumpTable(snapshot kv.Snapshot, dbName string, tb table.Table) (int64, int64, error) {
+	tblName := tb.Meta().Name.O
+	schema := showCreateTable(tb) + ";\n"
+	fileName := dumpFileName(dbName) + "." + dumpFileName(tblName)
+	if err := e.writeFile(fileName+"-schema.sql", []byte(schema)); err != nil {
+		return 0, 0, errors.Trace(err)
+	}
+
+	// The generated columns can't be inserted, they are calculated again when the data is loaded.
+	var colOffsets []int
+	var colNames bytes.Buffer
+	for i, col := range tb.Cols() {
+		if len(col.GeneratedExprString) != 0 {
+			continue
+		}
+		if len(colOffsets) > 0 {
+			colNames.WriteString(",")
+		}
+		colNames.WriteString(dumpIdent(col.Name.O))
+		colOffsets = append(colOffsets, i)
+	}
+	stmtPrefix := fmt.Sprintf("INSERT INTO %s (%s) VALUES\n", dumpIdent(tblName), colNames.String())
+
+	var totalRows, files int64
+	handle := int64(math.MinInt64)
+	for done := false; !done; {
+		var buf bytes.Buffer
+		fileRows := 0
+		for fileRows < DumpRowsPerFile {
+			limit := DumpRowsPerFile - fileRows
+			if limit > dumpRowsPerStmt {
+				limit = dumpRowsPerStmt
+			}
+			records, next, err := inspectkv.ScanTableRecord(snapshot, tb, handle, int64(limit))
+			if err != nil {
+				return 0, 0, errors.Trace(err)
+			}
+			if len(records) > 0 {
+				buf.WriteString(stmtPrefix)
+				for i, r := range records {
+					if i > 0 {
+						buf.WriteString(",\n")
+					}
+					if err = writeDumpRow(&buf, r.Values, colOffsets); err != nil {
+						return 0, 0, errors.Trace(err)
+					}
+				}
+				buf.WriteString(";\n")
+				fileRows += len(records)
+			}
+			// The next handle overflows if the last handle is math.MaxInt64.
+			if len(records) < limit || records[len(records)-1].Handle == math.MaxInt64 {
+				done = true
+				break
+			}
+			handle = next
+		}
+		if fileRows == 0 {
+			break
+		}
+		files++
+		if err := e.writeFile(fmt.Sprintf("%s.%05d.sql", fileName, files), buf.Bytes()); err != nil {
+			return 0, 0, errors.Trace(err)
+		}
+		totalRows += int64(fileRows)
+	}
+	log.Infof("[dump] table %s.%s, %d rows are dumped to %d files", dbName, tblName, totalRows, files)
+	return totalRows, files, nil
+}
+
+func writeDumpRow(buf *bytes.Buffer, row []types.Datum, colOffsets []int) error {
+	buf.WriteString("(")
+	for i, offset := range colOffsets {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		d := row[offset]
+		switch d.Kind() {
+		case types.KindNull:
+			buf.WriteString("NULL")
+		case types.KindInt64, types.KindUint64, types.KindFloat32, types.KindFloat64, types.KindMysqlDecimal:
+			s, err := d.ToString()
+			if err != nil {
+				return errors.Trace(err)
+			}
+			buf.WriteString(s)
+		case types.KindMysqlBit:
+			buf.WriteString(strconv.FormatUint(d.GetMysqlBit().Value, 10))
+		default:
+			s, err := d.ToString()
+			if err != nil {
+				return errors.Trace(err)
+			}
+			writeQuotedString(buf, s)
+		}
+	}
+	buf.WriteString(")")
+	return nil
+}
+
+// writeQuotedString writes the string as a quoted string literal with the special characters escaped.
+func writeQuotedString(buf *bytes.Buffer, s string) {
+	buf.WriteByte('\'')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case 0:
+			buf.WriteString(`\0`)
+		case '\'':
+			buf.WriteString(`\'`)
+		case '"':
+			buf.WriteString(`\"`)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		case 0x1a:
+			buf.WriteString(`\Z`)
+		case '\\':
+			buf.WriteString(`\\`)
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	buf.WriteByte('\'')
+}
+
+// Next implements the Executor Next interface.
+func (e *DumpDatabaseExec) Next() (Row, error) {
+	if len(e.rows) == 0 {
+		return nil, nil
+	}
+	row := e.rows[0]
+	e.rows = e.rows[1:]
+	return row, nil
+}
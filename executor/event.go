@@ -0,0 +1,168 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/event"
+	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/infoschema"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// loadEvent loads the event from the mysql.event table.
+func loadEvent(ctx context.Context, db, name string) (*event.Event, error) {
+	events, err := event.Load(ctx, fmt.Sprintf(`db = "%s" AND name = "%s"`, escapeSQLString(db), escapeSQLString(name)))
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if len(events) == 0 {
+		return nil, ErrEventDoesNotExist.GenByArgs(name)
+	}
+	return events[0], nil
+}
+
+func (e *SimpleExec) executeCreateEvent(s *ast.CreateEventStmt) error {
+	// The events are named like the procedures.
+	db, name, err := ProcedureName(e.ctx, s.Name)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if _, ok := GetInfoSchema(e.ctx).SchemaByName(model.NewCIStr(db)); !ok {
+		return infoschema.ErrDatabaseNotExists.GenByArgs(db)
+	}
+	if err = checkProcStmts([]ast.StmtNode{s.Body}, nil); err != nil {
+		return errors.Trace(err)
+	}
+	_, err = loadEvent(e.ctx, db, name)
+	if err == nil {
+		if s.IfNotExists {
+			return nil
+		}
+		return ErrEventAlreadyExists.GenByArgs(s.Name.Name.O)
+	}
+	if !ErrEventDoesNotExist.Equal(err) {
+		return errors.Trace(err)
+	}
+
+	now := time.Now().Truncate(time.Second)
+	ev := &event.Event{
+		DB:         db,
+		Name:       name,
+		Definer:    e.ctx.GetSessionVars().User,
+		Body:       s.Text(),
+		Definition: s.Body.Text(),
+		Status:     event.StatusEnabled,
+		Preserve:   s.Preserve,
+		Comment:    s.Comment,
+		Created:    now,
+	}
+	if s.Disabled {
+		ev.Status = event.StatusDisabled
+	}
+	if err = e.evalEventSchedule(s.Schedule, ev, now); err != nil {
+		return errors.Trace(err)
+	}
+	ev.NextRun = ev.NextTime(now)
+	if ev.NextRun.IsZero() {
+		// Like MySQL, an event which has expired is dropped, or disabled if it's preserved.
+		sc := e.ctx.GetSessionVars().StmtCtx
+		if !ev.Preserve {
+			sc.AppendWarning(ErrEventPastDropped)
+			return nil
+		}
+		sc.AppendWarning(ErrEventTimeInThePast)
+		ev.Status = event.StatusDisabled
+	}
+	return errors.Trace(event.Insert(e.ctx, ev))
+}
+
+// evalEventSchedule evaluates the schedule of the event, a recurring event starts now if STARTS
+// isn't specified.
+func (e *SimpleExec) evalEventSchedule(schedule *ast.EventSchedule, ev *event.Event, now time.Time) error {
+	var err error
+	if schedule.At != nil {
+		ev.ExecuteAt, err = evalEventTime(e.ctx, schedule.At)
+		return errors.Trace(err)
+	}
+	d, err := expression.EvalAstExpr(schedule.Every, e.ctx)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	value, err := d.ToInt64(e.ctx.GetSessionVars().StmtCtx)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if !event.ValidInterval(value, schedule.Unit) {
+		return ErrEventBadInterval
+	}
+	ev.IntervalValue, ev.IntervalField = value, schedule.Unit
+	ev.Starts = now
+	if schedule.Starts != nil {
+		if ev.Starts, err = evalEventTime(e.ctx, schedule.Starts); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	if schedule.Ends != nil {
+		if ev.Ends, err = evalEventTime(e.ctx, schedule.Ends); err != nil {
+			return errors.Trace(err)
+		}
+		if ev.Ends.Before(ev.Starts) {
+			return ErrEventEndsBeforeStart
+		}
+	}
+	return nil
+}
+
+// evalEventTime evaluates the time of the event schedule in the local time zone, the fractional
+// seconds are truncated.
+func evalEventTime(ctx context.Context, expr ast.ExprNode) (time.Time, error) {
+	d, err := expression.EvalAstExpr(expr, ctx)
+	if err != nil {
+		return time.Time{}, errors.Trace(err)
+	}
+	if !d.IsNull() {
+		d, err = d.ConvertTo(ctx.GetSessionVars().StmtCtx, types.NewFieldType(mysql.TypeDatetime))
+		if err != nil {
+			return time.Time{}, errors.Trace(err)
+		}
+	}
+	if d.IsNull() || d.GetMysqlTime().IsZero() {
+		return time.Time{}, errors.Trace(types.ErrInvalidTimeFormat)
+	}
+	t, err := d.GetMysqlTime().Time.GoTime(time.Local)
+	return t.Truncate(time.Second), errors.Trace(err)
+}
+
+func (e *SimpleExec) executeDropEvent(s *ast.DropEventStmt) error {
+	db, name, err := ProcedureName(e.ctx, s.Name)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	_, err = loadEvent(e.ctx, db, name)
+	if err != nil {
+		if s.IfExists && ErrEventDoesNotExist.Equal(err) {
+			return nil
+		}
+		return errors.Trace(err)
+	}
+	return errors.Trace(event.Delete(e.ctx, db, name))
+}
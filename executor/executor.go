@@ -14,12 +14,14 @@
 package executor
 
 import (
+	"strings"
 	"sync"
 	"sync/atomic"
 
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/distsql"
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/infoschema"
 	"github.com/pingcap/tidb/inspectkv"
@@ -27,9 +29,13 @@ import (
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/plan"
+	"github.com/pingcap/tidb/plugin"
+	"github.com/pingcap/tidb/sessionctx"
+	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/table"
 	"github.com/pingcap/tidb/tablecodec"
 	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/failpoint"
 	"github.com/pingcap/tidb/util/ranger"
 	"github.com/pingcap/tidb/util/types"
 )
@@ -40,11 +46,22 @@ var (
 	_ Executor = &ExistsExec{}
 	_ Executor = &HashAggExec{}
 	_ Executor = &LimitExec{}
+	_ Executor = &TraceExec{}
 	_ Executor = &MaxOneRowExec{}
+	_ Executor = &ExpandExec{}
 	_ Executor = &ProjectionExec{}
 	_ Executor = &SelectionExec{}
 	_ Executor = &SelectLockExec{}
 	_ Executor = &ShowDDLExec{}
+	_ Executor = &ChecksumTableExec{}
+	_ Executor = &DumpDatabaseExec{}
+	_ Executor = &CheckIndexExec{}
+	_ Executor = &RepairIndexExec{}
+	_ Executor = &SetFailpointExec{}
+	_ Executor = &ShowFailpointsExec{}
+	_ Executor = &CalibrateExec{}
+	_ Executor = &SetPluginsExec{}
+	_ Executor = &CleanupTableLockExec{}
 	_ Executor = &SortExec{}
 	_ Executor = &StreamAggExec{}
 	_ Executor = &TableDualExec{}
@@ -66,7 +83,38 @@ var (
 	ErrResultIsEmpty        = terror.ClassExecutor.New(codeResultIsEmpty, "result is empty")
 	ErrBuildExecutor        = terror.ClassExecutor.New(codeErrBuildExec, "Failed to build executor")
 	ErrBatchInsertFail      = terror.ClassExecutor.New(codeBatchInsertFail, "Batch insert failed, please clean the table and try again.")
+	ErrBindingNotMatch      = terror.ClassExecutor.New(codeBindingNotMatch, "The hinted statement doesn't match the original statement")
+	ErrBindingNotExists     = terror.ClassExecutor.New(codeBindingNotExists, "There is no binding for the statement in database '%s'")
+	ErrPolicyExists         = terror.ClassExecutor.New(codePolicyExists, "Policy '%s' already exists on table '%s.%s'")
+	ErrPolicyNotExists      = terror.ClassExecutor.New(codePolicyNotExists, "Policy '%s' doesn't exist on table '%s.%s'")
+	ErrMaskExists           = terror.ClassExecutor.New(codeMaskExists, "Masking policy '%s' already exists on table '%s.%s'")
+	ErrMaskNotExists        = terror.ClassExecutor.New(codeMaskNotExists, "Masking policy '%s' doesn't exist on table '%s.%s'")
+	ErrColumnMasked         = terror.ClassExecutor.New(codeColumnMasked, "Column '%s' of table '%s.%s' already has a masking policy")
 	ErrWrongValueCountOnRow = terror.ClassExecutor.New(codeWrongValueCountOnRow, "Column count doesn't match value count at row %d")
+	ErrCantInitializeUDF    = terror.ClassExecutor.New(codeCantInitializeUDF, mysql.MySQLErrName[mysql.ErrCantInitializeUdf])
+	ErrUDFExists            = terror.ClassExecutor.New(codeUDFExists, mysql.MySQLErrName[mysql.ErrUdfExists])
+	ErrFunctionNotDefined   = terror.ClassExecutor.New(codeFunctionNotDefined, mysql.MySQLErrName[mysql.ErrFunctionNotDefined])
+	ErrUDFNameCollision     = terror.ClassExecutor.New(codeUDFNameCollision, mysql.MySQLErrName[mysql.ErrNativeFctNameCollision])
+	ErrSPAlreadyExists      = terror.ClassExecutor.New(codeSPAlreadyExists, mysql.MySQLErrName[mysql.ErrSpAlreadyExists])
+	ErrSPDoesNotExist       = terror.ClassExecutor.New(codeSPDoesNotExist, mysql.MySQLErrName[mysql.ErrSpDoesNotExist])
+	ErrSPLabelMismatch      = terror.ClassExecutor.New(codeSPLabelMismatch, mysql.MySQLErrName[mysql.ErrSpLilabelMismatch])
+	ErrSPWrongNoOfArgs      = terror.ClassExecutor.New(codeSPWrongNoOfArgs, "Incorrect number of arguments for %s %s; expected %d, got %d")
+	ErrSPDupParam           = terror.ClassExecutor.New(codeSPDupParam, mysql.MySQLErrName[mysql.ErrSpDupParam])
+	ErrSPDupVar             = terror.ClassExecutor.New(codeSPDupVar, mysql.MySQLErrName[mysql.ErrSpDupVar])
+	ErrSPNotVarArg          = terror.ClassExecutor.New(codeSPNotVarArg, "OUT or INOUT argument %d for routine %s is not a variable")
+	ErrSPRecursionLimit     = terror.ClassExecutor.New(codeSPRecursionLimit, "Recursive limit %d (as set by the max_sp_recursion_depth variable) was exceeded for routine %s")
+	ErrEventAlreadyExists   = terror.ClassExecutor.New(codeEventAlreadyExists, mysql.MySQLErrName[mysql.ErrEventAlreadyExists])
+	ErrEventDoesNotExist    = terror.ClassExecutor.New(codeEventDoesNotExist, mysql.MySQLErrName[mysql.ErrEventDoesNotExist])
+	ErrEventBadInterval     = terror.ClassExecutor.New(codeEventBadInterval, mysql.MySQLErrName[mysql.ErrEventIntervalNotPositiveOrTooBig])
+	ErrEventEndsBeforeStart = terror.ClassExecutor.New(codeEventEndsBeforeStart, mysql.MySQLErrName[mysql.ErrEventEndsBeforeStarts])
+	ErrEventTimeInThePast   = terror.ClassExecutor.New(codeEventTimeInThePast, mysql.MySQLErrName[mysql.ErrEventExecTimeInThePast])
+	ErrEventPastDropped     = terror.ClassExecutor.New(codeEventPastDropped, mysql.MySQLErrName[mysql.ErrEventCannotCreateInThePast])
+	ErrNonUniqTable         = terror.ClassExecutor.New(codeNonUniqTable, mysql.MySQLErrName[mysql.ErrNonuniqTable])
+	ErrCannotMigrateSession = terror.ClassExecutor.New(codeCannotMigrateSession, "The session can't be migrated: %s")
+	ErrInvalidSessionStates = terror.ClassExecutor.New(codeInvalidSessionStates, "Invalid session states: %s")
+	ErrRangesSkipped        = terror.ClassExecutor.New(codeRangesSkipped, "The partial result is returned, the ranges of the unavailable regions are skipped: %s")
+	ErrInvalidDumpPath      = terror.ClassExecutor.New(codeInvalidDumpPath, "Invalid dump path '%s': %s")
+	ErrUnknownResourceGroup = terror.ClassExecutor.New(codeUnknownResourceGroup, "Unknown resource group '%s'")
 )
 
 // Error codes.
@@ -81,9 +129,40 @@ const (
 	codeResultIsEmpty        terror.ErrCode = 8
 	codeErrBuildExec         terror.ErrCode = 9
 	codeBatchInsertFail      terror.ErrCode = 10
+	codeBindingNotMatch      terror.ErrCode = 11
+	codeBindingNotExists     terror.ErrCode = 12
+	codePolicyExists         terror.ErrCode = 13
+	codePolicyNotExists      terror.ErrCode = 14
+	codeMaskExists           terror.ErrCode = 15
+	codeMaskNotExists        terror.ErrCode = 16
+	codeColumnMasked         terror.ErrCode = 17
+	codeCannotMigrateSession terror.ErrCode = 18
+	codeInvalidSessionStates terror.ErrCode = 19
+	codeRangesSkipped        terror.ErrCode = 20
+	codeInvalidDumpPath      terror.ErrCode = 21
+	codeUnknownResourceGroup terror.ErrCode = 22
 	CodePasswordNoMatch      terror.ErrCode = 1133 // MySQL error code
 	CodeCannotUser           terror.ErrCode = 1396 // MySQL error code
 	codeWrongValueCountOnRow terror.ErrCode = 1136 // MySQL error code
+	codeCantInitializeUDF    terror.ErrCode = 1123 // MySQL error code
+	codeUDFExists            terror.ErrCode = 1125 // MySQL error code
+	codeFunctionNotDefined   terror.ErrCode = 1128 // MySQL error code
+	codeUDFNameCollision     terror.ErrCode = 1585 // MySQL error code
+	codeSPAlreadyExists      terror.ErrCode = 1304 // MySQL error code
+	codeSPDoesNotExist       terror.ErrCode = 1305 // MySQL error code
+	codeSPLabelMismatch      terror.ErrCode = 1308 // MySQL error code
+	codeSPWrongNoOfArgs      terror.ErrCode = 1318 // MySQL error code
+	codeSPDupParam           terror.ErrCode = 1330 // MySQL error code
+	codeSPDupVar             terror.ErrCode = 1331 // MySQL error code
+	codeSPNotVarArg          terror.ErrCode = 1414 // MySQL error code
+	codeSPRecursionLimit     terror.ErrCode = 1456 // MySQL error code
+	codeEventAlreadyExists   terror.ErrCode = 1537 // MySQL error code
+	codeEventDoesNotExist    terror.ErrCode = 1539 // MySQL error code
+	codeEventBadInterval     terror.ErrCode = 1542 // MySQL error code
+	codeEventEndsBeforeStart terror.ErrCode = 1543 // MySQL error code
+	codeEventTimeInThePast   terror.ErrCode = 1544 // MySQL error code
+	codeEventPastDropped     terror.ErrCode = 1588 // MySQL error code
+	codeNonUniqTable         terror.ErrCode = 1066 // MySQL error code
 )
 
 // Row represents a result set row, it may be returned from a table, a join, or a projection.
@@ -97,10 +176,43 @@ const (
 // Otherwise the executor's returned rows don't need to store the handle information.
 type Row []types.Datum
 
+// rowSlabSize is the number of datums allocated at once by a rowAllocator.
+const rowSlabSize = 1024
+
+// rowAllocator allocates the rows returned by Next from a shared slab of datums, so an executor
+// doesn't need an allocation for every row.
+//
+// A row returned by alloc is owned by the caller of Next, it may be retained and modified freely.
+// The allocator never returns or touches the memory of a returned row again, the slab is released
+// by GC after all the rows in it become unreachable. Buffers only used within an executor itself,
+// like the values to compute a key, should be reused directly instead.
+type rowAllocator struct {
+	slab []types.Datum
+}
+
+// alloc returns a row of n zero datums, the row is never nil even if n is 0.
+func (a *rowAllocator) alloc(n int) Row {
+	if n > len(a.slab) || a.slab == nil {
+		if n > rowSlabSize/4 {
+			return make([]types.Datum, n)
+		}
+		a.slab = make([]types.Datum, rowSlabSize)
+	}
+	// Limit the capacity, so appending to the row never overwrites the next one.
+	row := a.slab[:n:n]
+	a.slab = a.slab[n:]
+	return row
+}
+
 type baseExecutor struct {
 	children []Executor
 	ctx      context.Context
 	schema   *expression.Schema
+	rowAlloc rowAllocator
+}
+
+func (e *baseExecutor) base() *baseExecutor {
+	return e
 }
 
 // Open implements the Executor Open interface.
@@ -165,10 +277,13 @@ func (e *ShowDDLExec) Next() (Row, error) {
 		return nil, nil
 	}
 
-	var ddlJob string
-	if e.ddlInfo.Job != nil {
-		ddlJob = e.ddlInfo.Job.String()
+	var jobs []string
+	for _, job := range []*model.Job{e.ddlInfo.Job, e.ddlInfo.ReorgJob} {
+		if job != nil {
+			jobs = append(jobs, job.String())
+		}
 	}
+	ddlJob := strings.Join(jobs, "\n")
 
 	row := types.MakeDatums(
 		e.ddlInfo.SchemaVer,
@@ -181,6 +296,263 @@ func (e *ShowDDLExec) Next() (Row, error) {
 	return row, nil
 }
 
+// ChecksumTableExec represents a checksum table executor.
+// It is built from the "admin checksum table" statement, it calculates the checksum of all the
+// data and index data of the tables at the snapshot of the transaction.
+type ChecksumTableExec struct {
+	baseExecutor
+
+	tables []*ast.TableName
+	is     infoschema.InfoSchema
+	rows   []Row
+}
+
+// Open implements the Executor Open interface.
+// The checksum is calculated here because the transaction is committed before Next is called.
+func (e *ChecksumTableExec) Open() error {
+	for _, tn := range e.tables {
+		dbName := tn.Schema
+		if dbName.L == "" {
+			dbName = model.NewCIStr(e.ctx.GetSessionVars().CurrentDB)
+		}
+		tb, err := e.is.TableByName(dbName, tn.Name)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		checksum, err := e.checksum(tb.Meta().ID)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		e.rows = append(e.rows, types.MakeDatums(dbName.O, tn.Name.O, checksum.Checksum, checksum.TotalKvs, checksum.TotalBytes))
+	}
+	return nil
+}
+
+func (e *ChecksumTableExec) checksum(tableID int64) (*distsql.ChecksumResponse, error) {
+	startKey := tablecodec.EncodeTablePrefix(tableID)
+	endKey := startKey.PrefixNext()
+	startTS := e.ctx.Txn().StartTS()
+	client := e.ctx.GetClient()
+	if client != nil && client.IsRequestTypeSupported(kv.ReqTypeChecksum, kv.ReqSubTypeBasic) {
+		ranges := []kv.KeyRange{{StartKey: startKey, EndKey: endKey}}
+		concurrency := e.ctx.GetSessionVars().DistSQLScanConcurrency
+		checksum, err := distsql.Checksum(client, e.ctx.GoCtx(), startTS, ranges, concurrency, kv.PriorityLow)
+		return checksum, errors.Trace(err)
+	}
+
+	// The storage doesn't support the checksum request, scan the data here.
+	snapshot, err := e.ctx.GetStore().GetSnapshot(kv.Version{Ver: startTS})
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	it, err := snapshot.Seek(startKey)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer it.Close()
+	checksum := &distsql.ChecksumResponse{}
+	for it.Valid() && it.Key().Cmp(endKey) < 0 {
+		checksum.Update(it.Key(), it.Value())
+		if err = it.Next(); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	return checksum, nil
+}
+
+// Next implements the Executor Next interface.
+func (e *ChecksumTableExec) Next() (Row, error) {
+	if len(e.rows) == 0 {
+		return nil, nil
+	}
+	row := e.rows[0]
+	e.rows = e.rows[1:]
+	return row, nil
+}
+
+// getTableIndex gets the table and its index for the admin index statements.
+func getTableIndex(ctx context.Context, is infoschema.InfoSchema, tn *ast.TableName, indexName string) (table.Table, table.Index, error) {
+	dbName := tn.Schema
+	if dbName.L == "" {
+		dbName = model.NewCIStr(ctx.GetSessionVars().CurrentDB)
+	}
+	tb, err := is.TableByName(dbName, tn.Name)
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	for _, idx := range tb.Indices() {
+		if idx.Meta().Name.L == strings.ToLower(indexName) {
+			return tb, idx, nil
+		}
+	}
+	return nil, nil, errors.Errorf("index %s not found in table %s", indexName, tn.Name)
+}
+
+// CheckIndexExec represents a check index executor.
+// It is built from the "admin check index" statement, and it checks if the index
+// matches the records in the handle ranges.
+type CheckIndexExec struct {
+	baseExecutor
+
+	table     *ast.TableName
+	indexName string
+	ranges    []ast.HandleRange
+	is        infoschema.InfoSchema
+	done      bool
+}
+
+// Next implements the Executor Next interface.
+func (e *CheckIndexExec) Next() (Row, error) {
+	if e.done {
+		return nil, nil
+	}
+	e.done = true
+
+	tb, idx, err := getTableIndex(e.ctx, e.is, e.table, e.indexName)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	err = inspectkv.CompareIndexDataInRanges(e.ctx.Txn(), tb, idx, e.ranges)
+	if err != nil {
+		return nil, errors.Errorf("%v err:%v", e.table.Name, err)
+	}
+	return nil, nil
+}
+
+// RepairIndexExec represents a repair index executor.
+// It is built from the "admin repair index" statement, it removes the index data that doesn't
+// match the records, and adds the missing index data of the records in the handle ranges.
+type RepairIndexExec struct {
+	baseExecutor
+
+	table     *ast.TableName
+	indexName string
+	ranges    []ast.HandleRange
+	is        infoschema.InfoSchema
+	row       Row
+}
+
+// Open implements the Executor Open interface.
+// The index is repaired here because the transaction is committed before Next is called.
+func (e *RepairIndexExec) Open() error {
+	tb, idx, err := getTableIndex(e.ctx, e.is, e.table, e.indexName)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	added, removed, err := inspectkv.RepairIndex(e.ctx.Txn(), tb, idx, e.ranges)
+	if err != nil {
+		return errors.Errorf("%v err:%v", e.table.Name, err)
+	}
+	e.row = types.MakeDatums(added, removed)
+	return nil
+}
+
+// Next implements the Executor Next interface.
+func (e *RepairIndexExec) Next() (Row, error) {
+	row := e.row
+	e.row = nil
+	return row, nil
+}
+
+// SetFailpointExec represents an executor that enables or disables a failpoint.
+// It is built from the "admin enable failpoint" and "admin disable failpoint" statements.
+type SetFailpointExec struct {
+	baseExecutor
+
+	name   string
+	terms  string
+	enable bool
+	done   bool
+}
+
+// Next implements the Executor Next interface.
+func (e *SetFailpointExec) Next() (Row, error) {
+	if e.done {
+		return nil, nil
+	}
+	e.done = true
+	if e.enable {
+		return nil, errors.Trace(failpoint.Enable(e.name, e.terms))
+	}
+	return nil, errors.Trace(failpoint.Disable(e.name))
+}
+
+// ShowFailpointsExec represents a show failpoints executor.
+type ShowFailpointsExec struct {
+	baseExecutor
+
+	failpoints []failpoint.Status
+	done       bool
+}
+
+// Next implements the Executor Next interface.
+func (e *ShowFailpointsExec) Next() (Row, error) {
+	if !e.done {
+		e.failpoints = failpoint.Enabled()
+		e.done = true
+	}
+	if len(e.failpoints) == 0 {
+		return nil, nil
+	}
+	fp := e.failpoints[0]
+	e.failpoints = e.failpoints[1:]
+	return types.MakeDatums(fp.Name, fp.Terms), nil
+}
+
+// SetPluginsExec represents an executor that enables or disables plugins.
+// It is built from the "admin plugins enable" and "admin plugins disable" statements.
+type SetPluginsExec struct {
+	baseExecutor
+
+	names  []string
+	enable bool
+	done   bool
+}
+
+// Next implements the Executor Next interface.
+func (e *SetPluginsExec) Next() (Row, error) {
+	if e.done {
+		return nil, nil
+	}
+	e.done = true
+	for _, name := range e.names {
+		var err error
+		if e.enable {
+			err = plugin.Enable(name)
+		} else {
+			err = plugin.Disable(name)
+		}
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	return nil, nil
+}
+
+// CleanupTableLockExec represents an executor that removes the table locks held by any session.
+// It is built from the "admin cleanup table lock" statement, it's used to release the locks of the
+// sessions on a crashed server.
+type CleanupTableLockExec struct {
+	baseExecutor
+
+	tables []*ast.TableName
+	done   bool
+}
+
+// Next implements the Executor Next interface.
+func (e *CleanupTableLockExec) Next() (Row, error) {
+	if e.done {
+		return nil, nil
+	}
+	e.done = true
+	locks := make([]variable.TableLock, 0, len(e.tables))
+	for _, tn := range e.tables {
+		locks = append(locks, variable.TableLock{SchemaID: tn.DBInfo.ID, TableID: tn.TableInfo.ID})
+	}
+	err := sessionctx.GetDomain(e.ctx).DDL().CleanupTableLock(e.ctx, locks)
+	return nil, errors.Trace(err)
+}
+
 // CheckTableExec represents a check table executor.
 // It is built from the "admin check table" statement, and it checks if the
 // index matches the records in the table.
@@ -275,10 +647,13 @@ type LimitExec struct {
 	Offset uint64
 	Count  uint64
 	Idx    uint64
+	// CalcFoundRows indicates the rows skipped by the offset and the rows beyond the limit are counted as the found rows.
+	CalcFoundRows bool
 }
 
 // Next implements the Executor Next interface.
 func (e *LimitExec) Next() (Row, error) {
+	sc := e.ctx.GetSessionVars().StmtCtx
 	for e.Idx < e.Offset {
 		srcRow, err := e.children[0].Next()
 		if err != nil {
@@ -288,9 +663,24 @@ func (e *LimitExec) Next() (Row, error) {
 			return nil, nil
 		}
 		e.Idx++
+		if e.CalcFoundRows {
+			sc.AddFoundRows(1)
+		}
 	}
 	if e.Idx >= e.Count+e.Offset {
-		return nil, nil
+		if !e.CalcFoundRows {
+			return nil, nil
+		}
+		for {
+			srcRow, err := e.children[0].Next()
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			if srcRow == nil {
+				return nil, nil
+			}
+			sc.AddFoundRows(1)
+		}
 	}
 	srcRow, err := e.children[0].Next()
 	if err != nil {
@@ -342,6 +732,25 @@ func init() {
 		CodeCannotUser:           mysql.ErrCannotUser,
 		CodePasswordNoMatch:      mysql.ErrPasswordNoMatch,
 		codeWrongValueCountOnRow: mysql.ErrWrongValueCountOnRow,
+		codeCantInitializeUDF:    mysql.ErrCantInitializeUdf,
+		codeUDFExists:            mysql.ErrUdfExists,
+		codeFunctionNotDefined:   mysql.ErrFunctionNotDefined,
+		codeUDFNameCollision:     mysql.ErrNativeFctNameCollision,
+		codeSPAlreadyExists:      mysql.ErrSpAlreadyExists,
+		codeSPDoesNotExist:       mysql.ErrSpDoesNotExist,
+		codeSPLabelMismatch:      mysql.ErrSpLilabelMismatch,
+		codeSPWrongNoOfArgs:      mysql.ErrSpWrongNoOfArgs,
+		codeSPDupParam:           mysql.ErrSpDupParam,
+		codeSPDupVar:             mysql.ErrSpDupVar,
+		codeSPNotVarArg:          mysql.ErrSpNotVarArg,
+		codeSPRecursionLimit:     mysql.ErrSpRecursionLimit,
+		codeEventAlreadyExists:   mysql.ErrEventAlreadyExists,
+		codeEventDoesNotExist:    mysql.ErrEventDoesNotExist,
+		codeEventBadInterval:     mysql.ErrEventIntervalNotPositiveOrTooBig,
+		codeEventEndsBeforeStart: mysql.ErrEventEndsBeforeStarts,
+		codeEventTimeInThePast:   mysql.ErrEventExecTimeInThePast,
+		codeEventPastDropped:     mysql.ErrEventCannotCreateInThePast,
+		codeNonUniqTable:         mysql.ErrNonuniqTable,
 	}
 	terror.ErrClassToMySQLCodes[terror.ClassExecutor] = tableMySQLErrCodes
 }
@@ -351,10 +760,24 @@ type ProjectionExec struct {
 	baseExecutor
 
 	exprs []expression.Expression
+	// concurrency is the number of the workers evaluating the expressions, the executor evaluates them itself if it's 1.
+	concurrency int
+
+	prepared bool
+	// taskCh sends the tasks to the workers, and outputCh keeps the tasks in the order of the rows.
+	taskCh   chan *projectionTask
+	outputCh chan *projectionTask
+	closeCh  chan struct{}
+	wg       sync.WaitGroup
+	rows     []Row
+	cursor   int
 }
 
 // Next implements the Executor Next interface.
 func (e *ProjectionExec) Next() (retRow Row, err error) {
+	if e.concurrency > 1 {
+		return e.parallelNext()
+	}
 	srcRow, err := e.children[0].Next()
 	if err != nil {
 		return nil, errors.Trace(err)
@@ -362,13 +785,12 @@ func (e *ProjectionExec) Next() (retRow Row, err error) {
 	if srcRow == nil {
 		return nil, nil
 	}
-	row := make([]types.Datum, 0, len(e.exprs))
-	for _, expr := range e.exprs {
-		val, err := expr.Eval(srcRow)
+	row := e.rowAlloc.alloc(len(e.exprs))
+	for i, expr := range e.exprs {
+		row[i], err = expr.Eval(srcRow)
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
-		row = append(row, val)
 	}
 	return row, nil
 }
@@ -493,7 +915,7 @@ type TableScanExec struct {
 	t          table.Table
 	asName     *model.CIStr
 	ctx        context.Context
-	ranges     []types.IntColumnRange
+	ranges     []*types.Range
 	seekHandle int64
 	iter       kv.Iterator
 	cursor     int
@@ -519,11 +941,11 @@ func (e *TableScanExec) Next() (Row, error) {
 		if e.cursor >= len(e.ranges) {
 			return nil, nil
 		}
-		ran := e.ranges[e.cursor]
-		if e.seekHandle < ran.LowVal {
-			e.seekHandle = ran.LowVal
+		low, high := e.ranges[e.cursor].IntBounds()
+		if e.seekHandle < low {
+			e.seekHandle = low
 		}
-		if e.seekHandle > ran.HighVal {
+		if e.seekHandle > high {
 			e.cursor++
 			continue
 		}
@@ -534,7 +956,7 @@ func (e *TableScanExec) Next() (Row, error) {
 		if !found {
 			return nil, nil
 		}
-		if handle > ran.HighVal {
+		if handle > high {
 			// The handle is out of the current range, but may be in following ranges.
 			// We seek to the range that may contains the handle, so we
 			// don't need to seek key again.
@@ -584,11 +1006,11 @@ func (e *TableScanExec) seekRange(handle int64) (inRange bool) {
 		if e.cursor >= len(e.ranges) {
 			return false
 		}
-		ran := e.ranges[e.cursor]
-		if handle < ran.LowVal {
+		low, high := e.ranges[e.cursor].IntBounds()
+		if handle < low {
 			return false
 		}
-		if handle > ran.HighVal {
+		if handle > high {
 			continue
 		}
 		return true
@@ -679,17 +1101,73 @@ func (e *MaxOneRowExec) Next() (Row, error) {
 	return nil, nil
 }
 
+// ExpandExec outputs every row of its child once for each grouping set, followed by the copies of the group-by
+// columns, which are null if they aren't in the grouping set, and the grouping id.
+type ExpandExec struct {
+	baseExecutor
+
+	groupByCols []*expression.Column
+	// inSets records whether the group-by columns are in each grouping set.
+	inSets      [][]bool
+	groupingIDs []int64
+
+	srcRow Row
+	// setIdx is the index of the grouping set for the next output row of srcRow.
+	setIdx int
+}
+
+// Open implements the Executor Open interface.
+func (e *ExpandExec) Open() error {
+	e.srcRow = nil
+	e.setIdx = 0
+	return errors.Trace(e.children[0].Open())
+}
+
+// Next implements the Executor Next interface.
+func (e *ExpandExec) Next() (Row, error) {
+	if e.srcRow == nil || e.setIdx == len(e.inSets) {
+		srcRow, err := e.children[0].Next()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if srcRow == nil {
+			return nil, nil
+		}
+		e.srcRow, e.setIdx = srcRow, 0
+	}
+	row := e.rowAlloc.alloc(e.schema.Len())
+	offset := copy(row, e.srcRow)
+	for i, col := range e.groupByCols {
+		if e.inSets[e.setIdx][i] {
+			row[offset+i] = e.srcRow[col.Index]
+		}
+	}
+	row[offset+len(e.groupByCols)].SetInt64(e.groupingIDs[e.setIdx])
+	e.setIdx++
+	return row, nil
+}
+
 // UnionExec represents union executor.
-// UnionExec has multiple source Executors, it executes them sequentially, and do conversion to the same type
+// UnionExec has multiple source Executors, it executes them concurrently, and do conversion to the same type
 // as source Executors may has different field type, we need to do conversion.
+// The results are streamed through the bounded channels, so a child is blocked if its results aren't read.
 type UnionExec struct {
 	baseExecutor
 
+	// KeepOrder means the rows are returned in the order of the children. The children are still executed
+	// concurrently, but the results of a child are read after all the previous children are finished.
+	KeepOrder bool
+
 	finished atomic.Value
-	resultCh chan *execResult
-	rows     []Row
-	cursor   int
-	wg       sync.WaitGroup
+	// resultChs are the channels the children send their results to. All the children share one channel unless
+	// the order is kept, and current is the index of the channel being read.
+	resultChs []chan *execResult
+	current   int
+	rows      []Row
+	cursor    int
+	wg        sync.WaitGroup
+	// stopCh is closed when the executor is closed, so the children blocked on sending the results quit.
+	stopCh   chan struct{}
 	closedCh chan struct{}
 }
 
@@ -705,12 +1183,27 @@ func (e *UnionExec) Schema() *expression.Schema {
 
 func (e *UnionExec) waitAllFinished() {
 	e.wg.Wait()
-	close(e.resultCh)
+	if !e.KeepOrder {
+		close(e.resultChs[0])
+	}
 	close(e.closedCh)
 }
 
-func (e *UnionExec) fetchData(idx int) {
+// send sends the result to the channel, it returns false if the executor is closed.
+func (e *UnionExec) send(ch chan *execResult, result *execResult) bool {
+	select {
+	case ch <- result:
+		return true
+	case <-e.stopCh:
+		return false
+	}
+}
+
+func (e *UnionExec) fetchData(idx int, ch chan *execResult) {
 	defer e.wg.Done()
+	if e.KeepOrder {
+		defer close(ch)
+	}
 	for {
 		result := &execResult{
 			rows: make([]Row, 0, batchSize),
@@ -724,12 +1217,12 @@ func (e *UnionExec) fetchData(idx int) {
 			if err != nil {
 				e.finished.Store(true)
 				result.err = err
-				e.resultCh <- result
+				e.send(ch, result)
 				return
 			}
 			if row == nil {
 				if len(result.rows) > 0 {
-					e.resultCh <- result
+					e.send(ch, result)
 				}
 				return
 			}
@@ -740,31 +1233,53 @@ func (e *UnionExec) fetchData(idx int) {
 				if err != nil {
 					e.finished.Store(true)
 					result.err = err
-					e.resultCh <- result
+					e.send(ch, result)
 					return
 				}
 				row[j] = val
 			}
 			result.rows = append(result.rows, row)
 		}
-		e.resultCh <- result
+		if !e.send(ch, result) {
+			return
+		}
 	}
 }
 
 // Open implements the Executor Open interface.
 func (e *UnionExec) Open() error {
 	e.finished.Store(false)
-	e.resultCh = make(chan *execResult, len(e.children))
+	if e.KeepOrder {
+		// Every child keeps at most one batch of its results before they're read.
+		e.resultChs = make([]chan *execResult, len(e.children))
+		for i := range e.resultChs {
+			e.resultChs[i] = make(chan *execResult, 1)
+		}
+	} else {
+		e.resultChs = []chan *execResult{make(chan *execResult, len(e.children))}
+	}
+	e.stopCh = make(chan struct{})
 	e.closedCh = make(chan struct{})
+	e.current = 0
+	e.rows = nil
 	e.cursor = 0
 	var err error
 	for i, child := range e.children {
 		err = child.Open()
 		if err != nil {
+			if e.KeepOrder {
+				for _, ch := range e.resultChs[i:] {
+					close(ch)
+				}
+			}
 			break
 		}
 		e.wg.Add(1)
-		go e.fetchData(i)
+		if e.KeepOrder {
+			go e.fetchData(i, e.resultChs[i])
+		} else {
+			go e.fetchData(i, e.resultChs[0])
+		}
 	}
 	go e.waitAllFinished()
 	return errors.Trace(err)
@@ -772,17 +1287,18 @@ func (e *UnionExec) Open() error {
 
 // Next implements the Executor Next interface.
 func (e *UnionExec) Next() (Row, error) {
-	if e.cursor >= len(e.rows) {
-		result, ok := <-e.resultCh
-		if !ok {
+	for e.cursor >= len(e.rows) {
+		if e.current >= len(e.resultChs) {
 			return nil, nil
 		}
+		result, ok := <-e.resultChs[e.current]
+		if !ok {
+			e.current++
+			continue
+		}
 		if result.err != nil {
 			return nil, errors.Trace(result.err)
 		}
-		if len(result.rows) == 0 {
-			return nil, nil
-		}
 		e.rows = result.rows
 		e.cursor = 0
 	}
@@ -794,6 +1310,12 @@ func (e *UnionExec) Next() (Row, error) {
 // Close implements the Executor Close interface.
 func (e *UnionExec) Close() error {
 	e.finished.Store(true)
+	// The executor may be closed more than once.
+	select {
+	case <-e.stopCh:
+	default:
+		close(e.stopCh)
+	}
 	<-e.closedCh
 	e.rows = nil
 	return errors.Trace(e.baseExecutor.Close())
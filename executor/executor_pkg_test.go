@@ -14,9 +14,13 @@
 package executor
 
 import (
+	"testing"
+
 	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/tablecodec"
+	"github.com/pingcap/tidb/util/types"
 )
 
 var _ = Suite(&testExecSuite{})
@@ -61,3 +65,76 @@ func (s *testExecSuite) TestMergeHandles(c *C) {
 		c.Assert(kr.EndKey, DeepEquals, ekr.EndKey)
 	}
 }
+
+func (s *testExecSuite) TestRowAllocator(c *C) {
+	var alloc rowAllocator
+	// A nil row means no more rows in Next, so an empty row must not be nil.
+	c.Assert(alloc.alloc(0), NotNil)
+	rows := make([]Row, 0, rowSlabSize)
+	for i := 0; i < rowSlabSize; i++ {
+		row := alloc.alloc(3)
+		c.Assert(row, HasLen, 3)
+		c.Assert(cap(row), Equals, 3)
+		for j := range row {
+			c.Assert(row[j].IsNull(), IsTrue)
+			row[j].SetInt64(int64(i))
+		}
+		rows = append(rows, row)
+	}
+	// Appending to a row doesn't overwrite the next one.
+	rows[0] = append(rows[0], types.NewIntDatum(-1))
+	for i, row := range rows[1:] {
+		for _, d := range row {
+			c.Assert(d.GetInt64(), Equals, int64(i+1))
+		}
+	}
+	c.Assert(alloc.alloc(rowSlabSize), HasLen, rowSlabSize)
+}
+
+// constRowExec returns the same row for count times.
+type constRowExec struct {
+	baseExecutor
+	row   Row
+	count int
+}
+
+func (e *constRowExec) Next() (Row, error) {
+	if e.count == 0 {
+		return nil, nil
+	}
+	e.count--
+	return e.row, nil
+}
+
+func BenchmarkProjectionExec(b *testing.B) {
+	cols := make([]*expression.Column, 8)
+	exprs := make([]expression.Expression, len(cols))
+	row := make(Row, len(cols))
+	for i := range cols {
+		cols[i] = &expression.Column{Index: i, RetType: types.NewFieldType(0)}
+		exprs[i] = cols[i]
+		row[i].SetInt64(int64(i))
+	}
+	child := &constRowExec{row: row, count: b.N}
+	e := &ProjectionExec{
+		baseExecutor: newBaseExecutor(expression.NewSchema(cols...), nil, child),
+		exprs:        exprs,
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for {
+		row, err := e.Next()
+		if err != nil {
+			b.Fatal(err)
+		}
+		if row == nil {
+			break
+		}
+	}
+}
+
+func (s *testExecSuite) TestConnectAttrsLogField(c *C) {
+	c.Assert(connectAttrsLogField(nil), Equals, "")
+	attrs := map[string]string{"program_name": "mysql", "_client_name": "libmysql"}
+	c.Assert(connectAttrsLogField(attrs), Equals, ` attrs="_client_name=libmysql,program_name=mysql"`)
+}
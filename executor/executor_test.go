@@ -14,9 +14,13 @@
 package executor_test
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -26,9 +30,13 @@ import (
 	"github.com/juju/errors"
 	"github.com/ngaut/log"
 	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/coprocessor"
+	"github.com/pingcap/kvproto/pkg/errorpb"
 	pb "github.com/pingcap/kvproto/pkg/kvrpcpb"
 	"github.com/pingcap/tidb"
+	"github.com/pingcap/tidb/config"
 	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/distsql"
 	"github.com/pingcap/tidb/domain"
 	"github.com/pingcap/tidb/executor"
 	"github.com/pingcap/tidb/expression"
@@ -38,15 +46,19 @@ import (
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/parser"
 	"github.com/pingcap/tidb/plan"
+	"github.com/pingcap/tidb/plugin"
 	"github.com/pingcap/tidb/sessionctx"
 	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/store/tikv"
 	mocktikv "github.com/pingcap/tidb/store/tikv/mock-tikv"
 	"github.com/pingcap/tidb/store/tikv/tikvrpc"
+	"github.com/pingcap/tidb/tablecodec"
 	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util"
 	"github.com/pingcap/tidb/util/testkit"
 	"github.com/pingcap/tidb/util/testleak"
 	"github.com/pingcap/tidb/util/testutil"
+	"github.com/pingcap/tidb/util/tracing"
 	"github.com/pingcap/tidb/util/types"
 	goctx "golang.org/x/net/context"
 )
@@ -162,6 +174,533 @@ func (s *testSuite) TestAdmin(c *C) {
 	c.Assert(err, IsNil)
 	r, err = tk.Exec("admin check table admin_test")
 	c.Assert(err, NotNil)
+
+	// check index test
+	_, err = tk.Exec("admin check index admin_test c1")
+	c.Assert(err, NotNil)
+	_, err = tk.Exec("admin check index admin_test c2")
+	c.Assert(err, NotNil)
+	// The wrong index data points to the row of handle 1.
+	tk.MustExec("admin check index admin_test c1 (2, 10)")
+	_, err = tk.Exec("admin check index test.admin_test c1 (2, 10), (0, 2)")
+	c.Assert(err, NotNil)
+	tk.MustQuery("admin repair index admin_test c1 (2, 10)").Check(testkit.Rows("0 0"))
+	tk.MustQuery("admin repair index admin_test c1").Check(testkit.Rows("0 1"))
+	tk.MustExec("admin check index admin_test c1")
+	tk.MustExec("admin check table admin_test")
+
+	tk.MustQuery("admin show failpoints").Check(testkit.Rows())
+}
+
+func (s *testSuite) TestAdminPlugins(c *C) {
+	defer func() {
+		plugin.Shutdown()
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	c.Assert(plugin.Register(&plugin.Manifest{
+		Name:           "schema_log",
+		Version:        0x0100,
+		Kind:           plugin.Schema,
+		Description:    "log the schema changes",
+		OnSchemaChange: func(event *plugin.SchemaEvent) {},
+	}), IsNil)
+	c.Assert(plugin.Init(), IsNil)
+	tk := testkit.NewTestKit(c, s.store)
+	query := "select plugin_name, plugin_version, plugin_status, plugin_type, plugin_library, plugin_description from information_schema.plugins"
+	tk.MustQuery(query).Check(testkit.Rows("schema_log 1.0 ACTIVE SCHEMA <nil> log the schema changes"))
+	tk.MustExec("admin plugins disable schema_log")
+	tk.MustQuery(query).Check(testkit.Rows("schema_log 1.0 DISABLED SCHEMA <nil> log the schema changes"))
+	tk.MustExec("admin plugins enable schema_log")
+	tk.MustQuery(query).Check(testkit.Rows("schema_log 1.0 ACTIVE SCHEMA <nil> log the schema changes"))
+	_, err := tk.Exec("admin plugins disable not_exists")
+	c.Assert(err, NotNil)
+}
+
+func (s *testSuite) TestChecksumTable(c *C) {
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("create table checksum_test (id int primary key, a int, index (a))")
+	for i := 0; i < 10; i++ {
+		tk.MustExec(fmt.Sprintf("insert checksum_test values (%d, %d)", i, i*10))
+	}
+	is := sessionctx.GetDomain(tk.Se).InfoSchema()
+	tbl, err := is.TableByName(model.NewCIStr("test"), model.NewCIStr("checksum_test"))
+	c.Assert(err, IsNil)
+
+	// Calculate the checksum of the table data and index data.
+	ver, err := s.store.CurrentVersion()
+	c.Assert(err, IsNil)
+	snapshot, err := s.store.GetSnapshot(ver)
+	c.Assert(err, IsNil)
+	prefix := tablecodec.EncodeTablePrefix(tbl.Meta().ID)
+	it, err := snapshot.Seek(prefix)
+	c.Assert(err, IsNil)
+	expected := &distsql.ChecksumResponse{}
+	for it.Valid() && it.Key().HasPrefix(prefix) {
+		expected.Update(it.Key(), it.Value())
+		c.Assert(it.Next(), IsNil)
+	}
+	it.Close()
+	c.Assert(expected.TotalKvs, Equals, uint64(20))
+	result := fmt.Sprintf("test checksum_test %d %d %d", expected.Checksum, expected.TotalKvs, expected.TotalBytes)
+	tk.MustQuery("admin checksum table checksum_test").Check(testkit.Rows(result))
+
+	// The checksum doesn't change if the data is in multiple regions.
+	if s.cluster != nil {
+		s.cluster.SplitOnRequest(tablecodec.EncodeRowKeyWithHandle(tbl.Meta().ID, 5))
+	}
+	tk.MustQuery("admin checksum table test.checksum_test").Check(testkit.Rows(result))
+
+	tk.MustExec("update checksum_test set a = 1 where id = 1")
+	rows := tk.MustQuery("admin checksum table checksum_test").Rows()
+	c.Assert(rows[0][2], Not(Equals), fmt.Sprintf("%d", expected.Checksum))
+	c.Assert(rows[0][3], Equals, "20")
+	_, err = tk.Exec("admin checksum table checksum_test_error")
+	c.Assert(err, NotNil)
+}
+
+func (s *testSuite) TestTrace(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (a int primary key, b int)")
+	tk.MustExec("insert into t values (1, 1), (2, 2)")
+
+	rows := tk.MustQuery("trace select b from t where a > 0").Rows()
+	var ops []string
+	for _, row := range rows {
+		c.Assert(row, HasLen, 3)
+		ops = append(ops, row[0].(string))
+	}
+	c.Assert(ops[0], Equals, "trace")
+	c.Assert(ops[1], Equals, "├─parse")
+	c.Assert(ops[2], Equals, "├─plan")
+	c.Assert(ops[3], Equals, "└─execute")
+	c.Assert(strings.TrimLeft(ops[4], " └─"), Equals, "ProjectionExec")
+	var hasCop bool
+	for _, op := range ops[4:] {
+		if strings.Contains(op, "rpc.Cop") {
+			hasCop = true
+		}
+	}
+	c.Assert(hasCop, IsTrue, Commentf("%v", ops))
+	c.Assert(tk.Se.GetSessionVars().StmtCtx.TraceSpan(), IsNil)
+
+	_, err := tk.Exec("trace select * from t_not_exists")
+	c.Assert(err, NotNil)
+}
+
+func (s *testSuite) TestTracePlan(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (a int primary key, b int, c int, index b(b), index c(c))")
+	tk.MustExec("insert into t values (1, 1, 1), (2, 2, 2)")
+
+	rows := tk.MustQuery("trace plan select b from t where b > 0 order by a").Rows()
+	c.Assert(rows, HasLen, 1)
+	trace := &tracing.OptimizerTrace{}
+	c.Assert(json.Unmarshal([]byte(rows[0][0].(string)), trace), IsNil)
+	var rules []string
+	for _, rule := range trace.Rules {
+		rules = append(rules, rule.Name)
+	}
+	c.Assert(rules[0], Equals, "columnPruner")
+	c.Assert(strings.Join(rules, ","), Matches, ".*ppdSolver.*")
+	var chosen, rejected, skipped int
+	for _, candidate := range trace.Candidates {
+		switch {
+		case candidate.Chosen:
+			chosen++
+		case candidate.Plan == "Index(t.c)":
+			skipped++
+			c.Assert(candidate.Reason, Not(Equals), "")
+		default:
+			rejected++
+			c.Assert(candidate.Reason, Not(Equals), "")
+		}
+	}
+	c.Assert(chosen > 0, IsTrue)
+	c.Assert(rejected > 0, IsTrue)
+	c.Assert(skipped > 0, IsTrue)
+	c.Assert(trace.FinalPlan, Not(Equals), "")
+	c.Assert(trace.FinalCost > 0, IsTrue)
+	c.Assert(tk.Se.GetSessionVars().StmtCtx.OptimizerTrace, IsNil)
+
+	// The statement isn't executed.
+	tk.MustQuery("trace plan select * from t union select * from t")
+	tk.MustQuery("select count(*) from t").Check(testkit.Rows("2"))
+	_, err := tk.Exec("trace plan select * from t_not_exists")
+	c.Assert(err, NotNil)
+}
+
+func (s *testSuite) TestCalibrate(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (a int primary key, b int)")
+
+	finalCost := func() float64 {
+		rows := tk.MustQuery("trace plan select * from t where b > 0").Rows()
+		trace := &tracing.OptimizerTrace{}
+		c.Assert(json.Unmarshal([]byte(rows[0][0].(string)), trace), IsNil)
+		return trace.FinalCost
+	}
+	cost := finalCost()
+	tk.MustExec("set @@tidb_opt_scan_factor = 4")
+	c.Assert(finalCost() > cost, IsTrue)
+	tk.MustExec("set @@tidb_opt_scan_factor = 2")
+	c.Assert(finalCost(), Equals, cost)
+
+	tk.MustExec("set @@tidb_opt_cpu_factor = 0.5")
+	rows := tk.MustQuery("admin calibrate").Rows()
+	c.Assert(rows, HasLen, 6)
+	c.Assert(rows[0][0], Equals, variable.TiDBOptCPUFactor)
+	// The CPU factor is the unit of the others.
+	c.Assert(rows[0][1], Equals, "0.5")
+	c.Assert(rows[0][2], Equals, "0.50")
+	for _, row := range rows {
+		calibrated, err := strconv.ParseFloat(row[2].(string), 64)
+		c.Assert(err, IsNil)
+		c.Assert(calibrated >= 0, IsTrue)
+	}
+	c.Assert(rows[4][0], Equals, variable.TiDBOptSeekFactor)
+	c.Assert(rows[4][1], Equals, strconv.FormatFloat(variable.DefOptSeekFactor, 'f', -1, 64))
+	// The variables aren't changed.
+	tk.MustQuery("select @@tidb_opt_cpu_factor").Check(testkit.Rows("0.5"))
+}
+
+func (s *testSuite) TestCascadesPlanner(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t1, t2")
+	tk.MustExec("create table t1 (a int, b int, c int)")
+	tk.MustExec("create table t2 (a int, b int, index a(a))")
+	tk.MustExec("insert into t1 values (1, 1, 1), (1, 2, 1), (2, 3, 2), (3, 4, 2), (null, 5, 3)")
+	tk.MustExec("insert into t2 values (1, 10), (1, 20), (2, 30), (4, 40), (null, 50)")
+
+	sqls := []string{
+		"select t1.c, sum(t1.b) from t1 join t2 on t1.a = t2.a group by t1.c order by t1.c",
+		"select sum(t2.b), count(t1.b) from t1 join t2 on t1.a = t2.a",
+		"select t1.a, max(t2.b) from t1 left join t2 on t1.a = t2.a group by t1.a order by t1.a",
+		"select t1.b, t2.b from t1 join t2 on t1.a = t2.a where t2.b > 10 order by t1.b, t2.b",
+		"select t1.b, t2.b from t1, t2 where t1.a = t2.a and t1.b > 1 order by t1.b, t2.b",
+	}
+	var expected [][][]interface{}
+	for _, sql := range sqls {
+		expected = append(expected, tk.MustQuery(sql).Rows())
+	}
+	tk.MustExec("set @@session.tidb_enable_cascades_planner = 1")
+	for i, sql := range sqls {
+		tk.MustQuery(sql).Check(expected[i])
+	}
+
+	// The plans of the aggregation pushed across the join are compared with the others. The memory of the
+	// hash tables is ignored, so the aggregation below the join reduces the cost.
+	tk.MustExec("set @@session.tidb_opt_memory_factor = 0")
+	rows := tk.MustQuery("trace plan select t1.c, sum(t1.b) from t1 join t2 on t1.a = t2.a group by t1.c").Rows()
+	trace := &tracing.OptimizerTrace{}
+	c.Assert(json.Unmarshal([]byte(rows[0][0].(string)), trace), IsNil)
+	rejected := false
+	for _, candidate := range trace.Candidates {
+		if strings.HasPrefix(candidate.Plan, "LeftHashJoin{TableReader(Table(t1))->TableReader(Table(t2))}") && strings.HasSuffix(candidate.Plan, "->HashAgg") {
+			rejected = !candidate.Chosen
+		}
+	}
+	c.Assert(rejected, IsTrue)
+	c.Assert(trace.FinalPlan, Matches, `.*Join\{TableReader\(Table\(t1\)->HashAgg\)->HashAgg->.*`)
+}
+
+// mockClusterManager is a session manager which has the infos of two servers, the second one fails to respond.
+type mockClusterManager struct{}
+
+func (m mockClusterManager) ShowProcessList() []util.ProcessInfo { return nil }
+
+func (m mockClusterManager) Kill(connectionID uint64, query bool) {}
+
+func (m mockClusterManager) ClusterProcessList() (map[string][]util.ProcessInfo, []error) {
+	return map[string][]util.ProcessInfo{
+		"tidb1:4000": {{ID: 1, User: "root", Host: "127.0.0.1", DB: "test", Command: "Query"}},
+	}, []error{errors.New("server tidb2:4000: connection refused")}
+}
+
+func (m mockClusterManager) ClusterSlowQueries() (map[string][]util.SlowQueryInfo, []error) {
+	start := time.Date(2017, 10, 1, 12, 0, 0, 0, time.Local)
+	return map[string][]util.SlowQueryInfo{
+		"tidb1:4000": {{SQL: "select ?", Digest: "abc", Start: start, Duration: 2 * time.Second, ConnID: 1, User: "root", DB: "test"}},
+	}, []error{errors.New("server tidb2:4000: connection refused")}
+}
+
+func (m mockClusterManager) ClusterConfig() (map[string]map[string]string, []error) {
+	return map[string]map[string]string{
+		"tidb1:4000": {"store": "tikv", "addr": ":4000"},
+	}, []error{errors.New("server tidb2:4000: connection refused")}
+}
+
+func (m mockClusterManager) ClusterHotRegions() (map[string][]util.HotRegionStat, []error) {
+	return map[string][]util.HotRegionStat{
+		"tidb1:4000": {{RegionID: 2, TableID: 1000, ReadQPS: 1.5}, {RegionID: 3, TableID: 1000, IndexID: 1, WriteQPS: 2}},
+		"tidb3:4000": {{RegionID: 2, TableID: 1000, ReadQPS: 1, WriteQPS: 0.5}},
+	}, []error{errors.New("server tidb2:4000: connection refused")}
+}
+
+func (s *testSuite) TestClusterTables(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use information_schema")
+	// The tables are empty if the session manager can't get the infos of the cluster.
+	tk.MustQuery("select * from cluster_processlist").Check(testkit.Rows())
+
+	tk.Se.SetSessionManager(mockClusterManager{})
+	tk.MustQuery("select instance, id, user, db, command from cluster_processlist").Check(testkit.Rows(
+		"tidb1:4000 1 root test Query"))
+	c.Assert(tk.Se.GetSessionVars().StmtCtx.WarningCount(), Equals, uint16(1))
+	tk.MustQuery("select * from cluster_slow_query").Check(testkit.Rows(
+		"tidb1:4000 2017-10-01 12:00:00.000000 2 1 root test abc select ?"))
+	tk.MustQuery("select * from cluster_config").Check(testkit.Rows(
+		"tidb1:4000 addr :4000", "tidb1:4000 store tikv"))
+	// The QPS of the same region collected by the servers are summed.
+	tk.MustQuery("select table_id, index_id, db_name, region_id, type, qps from tidb_hot_regions").Check(testkit.Rows(
+		"1000 0 <nil> 2 read 2.5", "1000 1 <nil> 3 write 2", "1000 0 <nil> 2 write 0.5"))
+	tk.MustQuery("select * from tidb_hot_tables").Check(testkit.Rows(
+		"1000 <nil> <nil> read 2.5 1", "1000 <nil> <nil> write 2.5 2"))
+}
+
+func (s *testSuite) TestHotTables(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists hot")
+	tk.MustExec("create table hot (a int primary key, b int, index idx(b))")
+	tk.MustExec("insert into hot values (1, 1), (2, 2)")
+	tk.MustQuery("select * from hot where a = 1").Check(testkit.Rows("1 1"))
+	tk.MustQuery("select a from hot use index(idx) where b > 0").Check(testkit.Rows("1", "2"))
+
+	// The QPS collected by the storage of this server is shown without the cluster manager.
+	tk.MustQuery("select type from information_schema.tidb_hot_tables where db_name = 'test' and table_name = 'hot' order by type").Check(
+		testkit.Rows("read", "write"))
+	tk.MustQuery("select distinct type from information_schema.tidb_hot_regions where table_name = 'hot' and index_name = 'idx' order by type").Check(
+		testkit.Rows("read", "write"))
+}
+
+// mockKiller is a session manager which records the killed connection.
+type mockKiller struct {
+	mockClusterManager
+	global bool
+	killed uint64
+}
+
+func (m *mockKiller) Kill(connectionID uint64, query bool) {
+	m.killed = connectionID
+}
+
+func (m *mockKiller) GlobalConnID() bool {
+	return m.global
+}
+
+func (s *testSuite) TestKillStmt(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	sm := &mockKiller{}
+	tk.Se.SetSessionManager(sm)
+	// The standard KILL is ignored unless the connection IDs are global.
+	tk.MustExec("kill 1")
+	c.Assert(sm.killed, Equals, uint64(0))
+	tk.MustExec("kill tidb 2")
+	c.Assert(sm.killed, Equals, uint64(2))
+	sm.global = true
+	tk.MustExec("kill query 3")
+	c.Assert(sm.killed, Equals, uint64(3))
+}
+
+// mockProcessManager is a session manager which shows the processes of the sessions.
+type mockProcessManager struct {
+	mockClusterManager
+	sessions []tidb.Session
+}
+
+func (m *mockProcessManager) ShowProcessList() []util.ProcessInfo {
+	pl := make([]util.ProcessInfo, 0, len(m.sessions))
+	for _, se := range m.sessions {
+		pl = append(pl, se.ShowProcess())
+	}
+	return pl
+}
+
+func (s *testSuite) TestExplainForConnection(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (a int, b int, index idx(a))")
+	tk1 := testkit.NewTestKit(c, s.store)
+	var err error
+	tk1.Se, err = tidb.CreateSession(s.store)
+	c.Assert(err, IsNil)
+	tk1.Se.GetSessionVars().ConnectionID = 100
+	tk1.MustExec("use test")
+	sm := &mockProcessManager{sessions: []tidb.Session{tk.Se, tk1.Se}}
+	tk.Se.SetSessionManager(sm)
+
+	_, err = tk.Exec("explain for connection 101")
+	c.Assert(terror.ErrorEqual(err, plan.ErrNoSuchThread), IsTrue, Commentf("err %v", err))
+	_, err = tk.Exec("explain for connection 100")
+	c.Assert(terror.ErrorEqual(err, plan.ErrExplainNotSupported), IsTrue, Commentf("err %v", err))
+
+	// The plan of the last statement is kept after the statement finishes.
+	tk1.MustQuery("select * from t where a > 1").Check(testkit.Rows())
+	tk.MustQuery("explain for connection 100").Check(tk.MustQuery("explain select * from t where a > 1").Rows())
+	tk1.MustExec(`prepare stmt from "select b from t where a = ?"`)
+	tk1.MustExec("set @a = 3")
+	tk1.MustQuery("execute stmt using @a").Check(testkit.Rows())
+	tk.MustQuery("explain for connection 100").Check(tk.MustQuery("explain select b from t where a = 3").Rows())
+	tk1.MustQuery("show tables")
+	_, err = tk.Exec("explain for connection 100")
+	c.Assert(terror.ErrorEqual(err, plan.ErrExplainNotSupported), IsTrue, Commentf("err %v", err))
+}
+
+func (s *testSuite) TestPerfSchemaThreads(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	// The table is empty if there is no session manager.
+	tk.MustQuery("select * from performance_schema.threads").Check(testkit.Rows())
+
+	tk1 := testkit.NewTestKit(c, s.store)
+	var err error
+	tk1.Se, err = tidb.CreateSession(s.store)
+	c.Assert(err, IsNil)
+	tk1.Se.GetSessionVars().ConnectionID = 100
+	tk1.MustExec("use test")
+	tk.Se.SetSessionManager(&mockProcessManager{sessions: []tidb.Session{tk1.Se}})
+	tk.MustQuery("select thread_id, name, type, processlist_id, processlist_db, processlist_info, instrumented from performance_schema.threads").Check(
+		testkit.Rows("100 thread/sql/one_connection FOREGROUND 100 test <nil> YES"))
+	tk.MustQuery("select count(*) from performance_schema.threads where processlist_id = 100").Check(testkit.Rows("1"))
+	_, err = tk.Exec("insert into performance_schema.threads (thread_id, name, type) values (1, 'a', 'b')")
+	c.Assert(err, NotNil)
+}
+
+func (s *testSuite) TestPerfSchemaSessionConnectAttrs(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustQuery("select * from performance_schema.session_connect_attrs").Check(testkit.Rows())
+
+	tk1 := testkit.NewTestKit(c, s.store)
+	var err error
+	tk1.Se, err = tidb.CreateSession(s.store)
+	c.Assert(err, IsNil)
+	tk1.Se.GetSessionVars().ConnectionID = 100
+	tk1.Se.GetSessionVars().ConnectAttrs = map[string]string{"program_name": "app", "_client_name": "libmysql"}
+	tk1.MustExec("use test")
+	tk.Se.SetSessionManager(&mockProcessManager{sessions: []tidb.Session{tk1.Se}})
+	tk.MustQuery("select * from performance_schema.session_connect_attrs").Check(
+		testkit.Rows("100 _client_name libmysql 0", "100 program_name app 1"))
+	tk.MustQuery("select attr_value from performance_schema.session_connect_attrs where attr_name = 'program_name'").Check(
+		testkit.Rows("app"))
+}
+
+func (s *testSuite) TestStmtLogFields(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("set @@tidb_session_alias = 'job-1'")
+	vars := tk.Se.GetSessionVars()
+	sql := "select * from t where a = 'secret' and b = 10"
+	_, digest := parser.NormalizeDigest(sql)
+
+	fields := executor.StmtLogFields(vars, sql, 1024)
+	c.Assert(fields, Equals, fmt.Sprintf(`conn=%d alias="job-1" digest=%s sql="%s"`, vars.ConnectionID, digest,
+		"select * from t where a = 'secret' and b = 10"))
+	c.Assert(executor.StmtLogFields(vars, sql, 6), Matches, `.* sql="select\(len:45\)"`)
+
+	// tidb_redact_log is global only, the new sessions load it.
+	_, err := tk.Exec("set @@tidb_redact_log = 1")
+	c.Assert(err, NotNil)
+	tk.MustExec("set @@global.tidb_redact_log = 1")
+	defer tk.MustExec("set @@global.tidb_redact_log = 0")
+	tk1 := testkit.NewTestKit(c, s.store)
+	tk1.MustExec("set @@tidb_session_alias = 'job-1'")
+	vars = tk1.Se.GetSessionVars()
+	fields = executor.StmtLogFields(vars, sql, 1024)
+	c.Assert(fields, Equals, fmt.Sprintf(`conn=%d alias="job-1" digest=%s sql="%s"`, vars.ConnectionID, digest,
+		"select * from t where a = ? and b = ?"))
+}
+
+func (s *testSuite) TestDumpDatabase(c *C) {
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	defer func(n int) { executor.DumpRowsPerFile = n }(executor.DumpRowsPerFile)
+	executor.DumpRowsPerFile = 2
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("drop database if exists dump_test")
+	tk.MustExec("create database dump_test")
+	tk.MustExec("use dump_test")
+	tk.MustExec("create table t1 (id int primary key, a varchar(20), b decimal(5,2), c int as (id + 1), index (a))")
+	tk.MustExec(`insert t1 (id, a, b) values (1, 'a''b', 1.5), (2, 'c\\d', null), (3, 'e\nf', -2), (-1, '', 0)`)
+	tk.MustExec("create table t2 (a int)")
+
+	// For mocktikv, safe point is not initialized, we manually insert it for snapshot to use.
+	tk.MustExec(`INSERT INTO mysql.tidb VALUES ('tikv_gc_safe_point', '20060102-15:04:05 -0700 MST', '')
+	ON DUPLICATE KEY UPDATE variable_value = '20060102-15:04:05 -0700 MST'`)
+	time.Sleep(time.Millisecond)
+	snapshotTime := time.Now()
+	time.Sleep(time.Millisecond)
+	tk.MustExec("insert t2 values (1)")
+
+	cfg := config.GetGlobalConfig()
+	defer func(dir string) { cfg.DumpDir = dir }(cfg.DumpDir)
+	_, err := tk.Exec("admin dump database dump_test to 'd1'")
+	c.Assert(terror.ErrorEqual(err, executor.ErrInvalidDumpPath), IsTrue)
+	cfg.DumpDir = c.MkDir()
+	// The path must be under the dump directory.
+	for _, path := range []string{c.MkDir(), "..", "d1/../../d1"} {
+		_, err = tk.Exec(fmt.Sprintf("admin dump database dump_test to '%s'", path))
+		c.Assert(terror.ErrorEqual(err, executor.ErrInvalidDumpPath), IsTrue, Commentf("path %s", path))
+	}
+
+	dir := filepath.Join(cfg.DumpDir, "d1")
+	tk.MustQuery("admin dump database dump_test to 'd1'").Check(testkit.Rows("t1 4 2", "t2 1 1"))
+	data, err := ioutil.ReadFile(filepath.Join(dir, "dump_test.t1.00001.sql"))
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, "INSERT INTO `t1` (`id`,`a`,`b`) VALUES\n(-1,'',0.00),\n(1,'a\\'b',1.50);\n")
+	// The existing files are not overwritten.
+	_, err = tk.Exec("admin dump database dump_test to 'd1'")
+	c.Assert(os.IsExist(errors.Cause(err)), IsTrue)
+
+	// Load the dump into another database, the data is the same.
+	tk.MustExec("create database dump_load")
+	tk.MustExec("use dump_load")
+	for _, name := range []string{"dump_test.t1-schema.sql", "dump_test.t1.00001.sql", "dump_test.t1.00002.sql",
+		"dump_test.t2-schema.sql", "dump_test.t2.00001.sql"} {
+		data, err = ioutil.ReadFile(filepath.Join(dir, name))
+		c.Assert(err, IsNil)
+		tk.MustExec(string(data))
+	}
+	tk.MustQuery("select * from t1").Check(tk.MustQuery("select * from dump_test.t1").Rows())
+	tk.MustQuery("select * from t2").Check(testkit.Rows("1"))
+
+	// The data of the snapshot is dumped if tidb_snapshot is set.
+	dir = filepath.Join(cfg.DumpDir, "d2")
+	tk.MustExec("set @@tidb_snapshot = '" + snapshotTime.Format("2006-01-02 15:04:05.999999") + "'")
+	tk.MustQuery("admin dump database dump_test to 'd2'").Check(testkit.Rows("t1 4 2", "t2 0 0"))
+	tk.MustExec("set @@tidb_snapshot = ''")
+	_, err = os.Stat(filepath.Join(dir, "dump_test.t2.00001.sql"))
+	c.Assert(os.IsNotExist(err), IsTrue)
+
+	_, err = tk.Exec("admin dump database dump_test_error to 'd3'")
+	c.Assert(err, NotNil)
+
+	// The path separators in the names are encoded, the backquotes in the identifiers are doubled.
+	tk.MustExec("create database dump_quote")
+	tk.MustExec("create table dump_quote.`../../t3` (`a``b` int)")
+	tk.MustExec("insert dump_quote.`../../t3` values (1)")
+	dir = filepath.Join(cfg.DumpDir, "d4")
+	tk.MustQuery("admin dump database dump_quote to 'd4'").Check(testkit.Rows("../../t3 1 1"))
+	data, err = ioutil.ReadFile(filepath.Join(dir, "dump_quote...%2F..%2Ft3.00001.sql"))
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, "INSERT INTO `../../t3` (`a``b`) VALUES\n(1);\n")
+	tk.MustExec("drop database dump_quote")
+	tk.MustExec("drop database dump_load")
+	tk.MustExec("drop database dump_test")
 }
 
 func (s *testSuite) fillData(tk *testkit.TestKit, table string) {
@@ -498,6 +1037,36 @@ func (s *testSuite) TestSelectLimit(c *C) {
 	c.Assert(err, NotNil)
 }
 
+func (s *testSuite) TestParallelProjection(c *C) {
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (a int primary key, b varchar(20))")
+	for i := 0; i < 500; i++ {
+		tk.MustExec(fmt.Sprintf("insert t values(%d, '{\"k\": %d}')", i, i*i))
+	}
+	sql := "select a, md5(b), json_extract(b, '$.k') + a, cast(a as char) from t"
+	tk.MustExec("set @@tidb_projection_concurrency = 1")
+	expected := tk.MustQuery(sql).Rows()
+	tk.MustExec("set @@tidb_projection_concurrency = 4")
+	// The rows are returned in the order of the child.
+	tk.MustQuery(sql).Check(expected)
+	tk.MustQuery("select a, md5(b), json_extract(b, '$.k') + a, cast(a as char) from t limit 300, 2").Check(expected[300:302])
+	// The user variables are evaluated in the order of the rows.
+	tk.MustExec("set @x = 0")
+	tk.MustQuery("select a, upper(b), @x := @x + 1 from t where a in (3, 7)").Check(testkit.Rows(`3 {"K": 9} 1`, `7 {"K": 49} 2`))
+
+	tk.MustExec("update t set b = 'not json' where a = 400")
+	rs, err := tk.Exec("select a, json_extract(b, '$.k') from t")
+	c.Assert(err, IsNil)
+	_, err = tidb.GetRows(rs)
+	c.Assert(err, NotNil)
+}
+
 func (s *testSuite) TestDAG(c *C) {
 	tk := testkit.NewTestKit(c, s.store)
 	defer func() {
@@ -815,6 +1384,25 @@ func (s *testSuite) TestUnion(c *C) {
 
 	// test race
 	tk.MustQuery("SELECT @x:=0 UNION ALL SELECT @x:=0 UNION ALL SELECT @x")
+
+	// The children are executed concurrently, but the rows are returned in the order of the children.
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (a int primary key)")
+	var rows []string
+	for i := 0; i < 300; i++ {
+		tk.MustExec(fmt.Sprintf("insert t values(%d)", i))
+		rows = append(rows, strconv.Itoa(i))
+	}
+	var expected []string
+	for i := 3; i > 0; i-- {
+		for _, row := range rows {
+			expected = append(expected, strconv.Itoa(i)+" "+row)
+		}
+	}
+	tk.MustQuery("select 3, a from t union all select 2, a from t union all select 1, a from t").Check(testkit.Rows(expected...))
+	// The children blocked on sending the results quit when the union is closed.
+	tk.MustQuery("(select a from t) union all (select a from t) union all (select a from t) limit 299, 2").Check(testkit.Rows("299", "0"))
+	tk.MustQuery("(select 3, a from t where a < 2) union all (select 2, a from t where a < 2) order by 1, 2").Check(testkit.Rows("2 0", "2 1", "3 0", "3 1"))
 }
 
 func (s *testSuite) TestIn(c *C) {
@@ -979,6 +1567,118 @@ func (s *testSuite) TestIndexScan(c *C) {
 	result.Check(testkit.Rows("5"))
 }
 
+func (s *testSuite) TestPrefixIndexScan(c *C) {
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (id int primary key, a varchar(10), b int, index idx_ab(a(3), b))")
+	tk.MustExec("insert t values (1, 'a', 1), (2, 'ab', 1), (3, 'abc', 1), (4, 'abcd', 1), (5, 'abd', 2), (6, 'abcd', 2), (7, null, 1)")
+	tk.MustQuery("select id from t use index(idx_ab) where a like 'ab%' order by id").Check(testkit.Rows("2", "3", "4", "5", "6"))
+	tk.MustQuery("select id from t use index(idx_ab) where a like 'abc%' order by id").Check(testkit.Rows("3", "4", "6"))
+	tk.MustQuery("select id from t use index(idx_ab) where a like 'abcd%' order by id").Check(testkit.Rows("4", "6"))
+	tk.MustQuery("select id from t use index(idx_ab) where a > 'ab' order by id").Check(testkit.Rows("3", "4", "5", "6"))
+	tk.MustQuery("select id from t use index(idx_ab) where a < 'abd' order by id").Check(testkit.Rows("1", "2", "3", "4", "6"))
+	tk.MustQuery("select id from t use index(idx_ab) where a < 'abc' order by id").Check(testkit.Rows("1", "2"))
+	tk.MustQuery("select id from t use index(idx_ab) where a <= 'abc' order by id").Check(testkit.Rows("1", "2", "3"))
+	tk.MustQuery("select id from t use index(idx_ab) where a > 'abc' and a < 'abd' order by id").Check(testkit.Rows("4", "6"))
+	tk.MustQuery("select id from t use index(idx_ab) where a = 'abcd' and b > 1 order by id").Check(testkit.Rows("6"))
+	tk.MustQuery("select id from t use index(idx_ab) where a in ('a', 'ab') and b = 1 order by id").Check(testkit.Rows("1", "2"))
+	tk.MustQuery("select count(*) from t use index(idx_ab) where a like 'ab%' and b = 1").Check(testkit.Rows("3"))
+	tk.MustQuery("select a from t use index(idx_ab) where a like 'ab%' and b = 2 order by id").Check(testkit.Rows("abd", "abcd"))
+
+	// The index is read without the table if the prefix column is only used by the exact conditions.
+	rows := fmt.Sprintf("%v", tk.MustQuery("explain select count(*) from t use index(idx_ab) where a like 'ab%' and b = 1").Rows())
+	c.Assert(strings.Contains(rows, "IndexReader"), IsTrue, Commentf("%s", rows))
+	rows = fmt.Sprintf("%v", tk.MustQuery("explain select count(*) from t use index(idx_ab) where a like 'abc%' and b = 1").Rows())
+	c.Assert(strings.Contains(rows, "IndexLookUp"), IsTrue, Commentf("%s", rows))
+
+	// The rows in the transaction are filtered by the full values.
+	tk.MustExec("begin")
+	tk.MustExec("insert t values (8, 'abce', 1), (9, 'ac', 1)")
+	tk.MustQuery("select id from t use index(idx_ab) where a like 'ab%' and b = 1 order by id").Check(testkit.Rows("2", "3", "4", "8"))
+	tk.MustExec("rollback")
+
+	// The prefix of a binary string isn't shorter than the column, it keeps the full values.
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (id int primary key, a varbinary(5), b varchar(5), index idx_a(a(5)), index idx_b(b(5)))")
+	tk.MustExec("insert t values (1, 'abc', 'abc'), (2, 'abcde', 'abcde'), (3, 'b', 'b')")
+	tk.MustQuery("select a from t use index(idx_a) where a > 'abc'").Check(testkit.Rows("abcde", "b"))
+	tk.MustQuery("select a from t use index(idx_a) order by a").Check(testkit.Rows("abc", "abcde", "b"))
+	tk.MustQuery("select id from t use index(idx_a) where a = 'abcde'").Check(testkit.Rows("2"))
+	tk.MustQuery("select b from t use index(idx_b) where b > 'abc' order by id").Check(testkit.Rows("abcde", "b"))
+	rows = fmt.Sprintf("%v", tk.MustQuery("explain select a from t use index(idx_a) where a > 'abc' order by a").Rows())
+	c.Assert(strings.Contains(rows, "IndexReader") && !strings.Contains(rows, "Sort"), IsTrue, Commentf("%s", rows))
+	// The non-binary strings may have multi-byte characters longer than the prefix.
+	rows = fmt.Sprintf("%v", tk.MustQuery("explain select b from t use index(idx_b) where b > 'abc'").Rows())
+	c.Assert(strings.Contains(rows, "IndexLookUp"), IsTrue, Commentf("%s", rows))
+}
+
+func (s *testSuite) TestMultiColumnPointRange(c *C) {
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (id int primary key, a int, b int, c int, index idx_abc(a, b, c))")
+	tk.MustExec("insert t values (1, 1, 2, 1), (2, 3, 4, 2), (3, 1, 3, 3), (4, null, 2, 4), (5, 3, 4, 5)")
+	tk.MustQuery("select id from t where (a, b) in ((1, 2), (3, 4)) order by id").Check(testkit.Rows("1", "2", "5"))
+	tk.MustQuery("select id from t where (a, b) not in ((1, 2), (3, 4)) order by id").Check(testkit.Rows("3"))
+	tk.MustQuery("select id from t where (a = 1 and b = 2) or (a = 3 and b = 4) order by id").Check(testkit.Rows("1", "2", "5"))
+	tk.MustQuery("select id from t where (a, b) in ((1, 2), (3, 4)) and c > 1 order by id").Check(testkit.Rows("2", "5"))
+	tk.MustQuery("select id from t where (a = 1 or a = 3) and b = 4 order by id").Check(testkit.Rows("2", "5"))
+	tk.MustQuery("select id from t where a <=> 1 and b in (2, 3) order by id").Check(testkit.Rows("1", "3"))
+	tk.MustQuery("select id from t where a <=> null").Check(testkit.Rows("4"))
+	tk.MustQuery("select id from t where (a, b) <=> (null, 2) or (a, b) <=> (1, 3) order by id").Check(testkit.Rows("3", "4"))
+
+	for _, cond := range []string{
+		"(a, b) in ((1, 2), (3, 4))",
+		"(a = 1 and b = 2) or (a = 3 and b = 4)",
+		"a <=> null and b = 2",
+	} {
+		rows := fmt.Sprintf("%v", tk.MustQuery("explain select id from t where "+cond).Rows())
+		c.Assert(strings.Contains(rows, "IndexReader") && !strings.Contains(rows, "Selection"), IsTrue, Commentf("%s", rows))
+	}
+}
+
+func (s *testSuite) TestCompareColumnWithConstant(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (a varchar(10), b date, c int, index ia(a), index ib(b), index ic(c))")
+	tk.MustExec("insert into t values ('1', '2017-01-01', 1), (' 1', '2017-01-02', 2), ('1abc', '2016-12-31', 3), ('01', null, null)")
+
+	// The strings are compared with the numbers as doubles, the index on the string column can't be used.
+	tk.MustQuery("select a from t use index(ia) where a in (1, 2) order by a").Check(testkit.Rows(" 1", "01", "1", "1abc"))
+	tk.MustQuery("show warnings").Check(testutil.RowsWithSep("|",
+		"Warning|1739|Cannot use range access on index 'ia' due to type or collation conversion on field 'a'"))
+	tk.MustQuery("select a from t use index(ia) where a = 1 order by a").Check(testkit.Rows(" 1", "01", "1", "1abc"))
+	tk.MustQuery("show warnings").Check(testutil.RowsWithSep("|",
+		"Warning|1739|Cannot use ref access on index 'ia' due to type or collation conversion on field 'a'",
+		"Warning|1265|Data Truncated"))
+	tk.MustQuery("select a from t where a = '1'").Check(testkit.Rows("1"))
+	tk.MustQuery("show warnings").Check(testkit.Rows())
+
+	// The date column is compared with the constants as datetime.
+	tk.MustQuery("select b from t use index(ib) where b > '2017-1-1 10:10'").Check(testkit.Rows("2017-01-02"))
+	tk.MustQuery("select b from t use index(ib) where b = '2017-01-01 00:00:01'").Check(testkit.Rows())
+	tk.MustQuery("select b from t use index(ib) where b <= 20170101 order by b").Check(testkit.Rows("2016-12-31", "2017-01-01"))
+
+	// The int column is compared with the rounded int constants.
+	tk.MustQuery("select c from t use index(ic) where c > '1.5' order by c").Check(testkit.Rows("2", "3"))
+	tk.MustQuery("select c from t use index(ic) where 2.5 > c order by c").Check(testkit.Rows("1", "2"))
+	tk.MustQuery("select c from t use index(ic) where c = 1.5").Check(testkit.Rows())
+	for _, cond := range []string{"c > '1.5'", "2.5 > c", "c = '2.0'"} {
+		rows := fmt.Sprintf("%v", tk.MustQuery("explain select c from t where "+cond).Rows())
+		c.Assert(strings.Contains(rows, "IndexReader") && !strings.Contains(rows, "Selection"), IsTrue, Commentf("%s", rows))
+	}
+}
+
 func (s *testSuite) TestIndexReverseOrder(c *C) {
 	defer func() {
 		s.cleanEnv(c)
@@ -1371,6 +2071,49 @@ func (s *testSuite) TestTableScan(c *C) {
 	result.Check(testkit.Rows(rowStr1, rowStr2))
 }
 
+func (s *testSuite) TestInfoSchemaKeys(c *C) {
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists keys_child, keys_parent")
+	tk.MustExec("create table keys_parent (id int primary key, a int, b varchar(20), c int, unique key uk_ab (a, b), key idx_b (b(5) desc) comment 'prefix', key idx_c (c) using hash, key idx_expr ((c + 1)))")
+	tk.MustExec("create table keys_child (id int, pa int, pb varchar(20), pid int, constraint fk_ab foreign key (pa, pb) references keys_parent (a, b) on delete cascade, constraint fk_id foreign key (pid) references keys_parent (id) on delete no action on update cascade)")
+
+	tk.MustQuery(`select index_name, seq_in_index, column_name, collation, sub_part, index_type, index_comment, expression
+		from information_schema.statistics where table_name = 'keys_parent' order by index_name, seq_in_index`).Check(testkit.Rows(
+		"PRIMARY 1 id A <nil> BTREE  <nil>",
+		"idx_b 1 b D 5 BTREE prefix <nil>",
+		"idx_c 1 c A <nil> HASH  <nil>",
+		"idx_expr 1 <nil> A <nil> BTREE  c + 1",
+		"uk_ab 1 a A <nil> BTREE  <nil>",
+		"uk_ab 2 b A <nil> BTREE  <nil>",
+	))
+	tk.MustQuery(`select constraint_name, column_name, ordinal_position, position_in_unique_constraint, referenced_table_schema, referenced_table_name, referenced_column_name
+		from information_schema.key_column_usage where table_name like 'keys_%' order by table_name, constraint_name, ordinal_position`).Check(testkit.Rows(
+		"fk_ab pa 1 1 test keys_parent a",
+		"fk_ab pb 2 2 test keys_parent b",
+		"fk_id pid 1 1 test keys_parent id",
+		"PRIMARY id 1 <nil> <nil> <nil> <nil>",
+		"uk_ab a 1 <nil> <nil> <nil> <nil>",
+		"uk_ab b 2 <nil> <nil> <nil> <nil>",
+	))
+	tk.MustQuery(`select constraint_schema, constraint_name, unique_constraint_name, match_option, update_rule, delete_rule, table_name, referenced_table_name
+		from information_schema.referential_constraints where table_name = 'keys_child' order by constraint_name`).Check(testkit.Rows(
+		"test fk_ab uk_ab NONE RESTRICT CASCADE keys_child keys_parent",
+		"test fk_id PRIMARY NONE CASCADE NO ACTION keys_child keys_parent",
+	))
+	tk.MustQuery(`select constraint_name, constraint_type from information_schema.table_constraints
+		where table_name like 'keys_%' order by table_name, constraint_name`).Check(testkit.Rows(
+		"fk_ab FOREIGN KEY",
+		"fk_id FOREIGN KEY",
+		"PRIMARY PRIMARY KEY",
+		"uk_ab UNIQUE",
+	))
+}
+
 func (s *testSuite) TestAdapterStatement(c *C) {
 	defer testleak.AfterTest(c)()
 	se, err := tidb.CreateSession(s.store)
@@ -1507,6 +2250,41 @@ func (s *testSuite) TestSelectVar(c *C) {
 	result.Check(testkit.Rows("<nil> 2", "2 3", "3 2"))
 }
 
+func (s *testSuite) TestUserVarType(c *C) {
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (d int)")
+	tk.MustExec("insert into t values(1), (2), (3)")
+
+	// The values keep their types and cases.
+	tk.MustExec("set @i = 1, @f = 1.5e0, @dec = 1.50, @s = 'AbC'")
+	tk.MustQuery("select @i + 1, @f * 2, @dec + 1, @s").Check(testkit.Rows("2 3 2.50 AbC"))
+	tk.MustQuery("select @b := 'DeF', @b").Check(testkit.Rows("DeF DeF"))
+	tk.MustQuery("select @i = 1, @s = 'AbC'").Check(testkit.Rows("1 1"))
+	tk.MustExec("set @i = null")
+	tk.MustQuery("select @i").Check(testkit.Rows("<nil>"))
+
+	// The variables can be used as the counters.
+	tk.MustExec("set @n = 0")
+	tk.MustQuery("select d, @n := @n + 1 from t order by d").Check(testkit.Rows("1 1", "2 2", "3 3"))
+	tk.MustQuery("select @n").Check(testkit.Rows("3"))
+	tk.MustQuery("select d from t where d > @n - 2 order by d").Check(testkit.Rows("2", "3"))
+
+	// The statement can be prepared from a variable.
+	tk.MustExec("set @sql = 'select d from t where d > ? order by d'")
+	tk.MustExec("prepare stmt from @sql")
+	tk.MustExec("set @a = 1")
+	tk.MustQuery("execute stmt using @a").Check(testkit.Rows("2", "3"))
+	tk.MustExec("set @sql = null")
+	_, err := tk.Exec("prepare stmt from @sql")
+	c.Assert(err, NotNil)
+}
+
 func (s *testSuite) TestHistoryRead(c *C) {
 	defer func() {
 		s.cleanEnv(c)
@@ -1802,6 +2580,53 @@ func (s *testSuite) TestEmptyEnum(c *C) {
 	tk.MustQuery("select * from t").Check(testkit.Rows("", "", "<nil>"))
 }
 
+func (s *testSuite) TestEnumSet(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (id int, e enum('c', 'a', 'b') default 'a', s set('x', 'y', 'z'), index idx_e(e), index idx_s(s))")
+	tk.MustExec("insert into t values (1, 'a', 'x,y'), (2, 'b', 'z'), (3, 'c', ''), (4, null, null)")
+	tk.MustExec("insert into t(id) values (5)")
+
+	// Order by the index of the element in the definition.
+	tk.MustQuery("select id, e from t order by e, id").Check(testkit.Rows("4 <nil>", "3 c", "1 a", "5 a", "2 b"))
+	tk.MustQuery("select id, e+0, s+0 from t order by id").Check(testkit.Rows("1 2 3", "2 3 4", "3 1 0", "4 <nil> <nil>", "5 2 <nil>"))
+	// Compare as string with strings and as number with numbers.
+	tk.MustQuery("select id from t where e = 'a' order by id").Check(testkit.Rows("1", "5"))
+	tk.MustQuery("select id from t where e = 2 order by id").Check(testkit.Rows("1", "5"))
+	tk.MustQuery("select id from t where e < 3 order by id").Check(testkit.Rows("1", "3", "5"))
+	tk.MustQuery("select id from t where s = 3").Check(testkit.Rows("1"))
+	tk.MustQuery("select id from t where s = 'x,y'").Check(testkit.Rows("1"))
+	for _, hint := range []string{"use index(idx_e, idx_s)", "ignore index(idx_e, idx_s)"} {
+		tk.MustQuery("select id from t " + hint + " where e > 'a' order by id").Check(testkit.Rows("2", "3"))
+		tk.MustQuery("select id from t " + hint + " where e <= 'b' order by id").Check(testkit.Rows("1", "2", "5"))
+		tk.MustQuery("select id from t " + hint + " where e in ('a', 'c') order by id").Check(testkit.Rows("1", "3", "5"))
+		tk.MustQuery("select id from t " + hint + " where s like 'x%'").Check(testkit.Rows("1"))
+	}
+	// MAX() and MIN() compare by string value.
+	tk.MustQuery("select max(e), min(e), max(s), min(s) from t").Check(testkit.Rows("c a z "))
+
+	// New elements can only be appended to the end of the definition.
+	_, err := tk.Exec("alter table t modify e enum('c', 'b', 'a')")
+	c.Assert(err, NotNil)
+	_, err = tk.Exec("alter table t modify e enum('c', 'a')")
+	c.Assert(err, NotNil)
+	_, err = tk.Exec("alter table t modify s set('x', 'z')")
+	c.Assert(err, NotNil)
+	_, err = tk.Exec("alter table t modify e set('c', 'a', 'b')")
+	c.Assert(err, NotNil)
+	tk.MustExec("alter table t modify e enum('c', 'a', 'b', 'd') default 'd'")
+	tk.MustExec("alter table t modify s set('x', 'y', 'z', 'w')")
+	tk.MustExec("insert into t(id, s) values (6, 'w,x')")
+	tk.MustQuery("select id, e, s from t where id > 4 order by id").Check(testkit.Rows("5 a <nil>", "6 d x,w"))
+	tk.MustQuery("select id from t use index(idx_e) where e = 'd'").Check(testkit.Rows("6"))
+	tk.MustQuery("select id, e from t order by e desc, id limit 2").Check(testkit.Rows("6 d", "2 b"))
+}
+
 // This tests https://github.com/pingcap/tidb/issues/4024
 func (s *testSuite) TestIssue4024(c *C) {
 	defer func() {
@@ -1825,6 +2650,72 @@ func (s *testSuite) TestIssue4024(c *C) {
 	tk.MustQuery("select * from test2.t").Check(testkit.Rows("2"))
 }
 
+func (s *testSuite) TestChunkRPC(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec(`create table t (id int primary key, a bigint unsigned, b double, c float, d decimal(10, 3), e varchar(20),
+		f datetime(2), g timestamp null, h time(3), i enum('x', 'y'), j set('x', 'y'), k bit(8), l json, index idx_a(a), index idx_d(d))`)
+	tk.MustExec("set @@time_zone = '+08:00'")
+	tk.MustExec(`insert into t values (1, 18446744073709551615, 1.5, 2.5, 3.125, 'abc', '2017-10-01 12:30:00.25', '2017-10-01 12:30:00',
+		'12:30:00.123', 'y', 'x,y', b'101', '{"a": 1}')`)
+	tk.MustExec("insert into t values (2, 0, -1, -2, -3, '', '0000-00-00 00:00:00', null, '-1:00:00', 'x', '', 0, '[]')")
+	tk.MustExec("insert into t(id) values (3)")
+
+	queries := []string{
+		"select * from t",
+		"select * from t where a > 0 or d < 0",
+		"select id, d from t use index(idx_d) where d > 0",
+		"select a from t use index(idx_a) order by a",
+		"select count(*), sum(d), max(f), min(h), avg(b), bit_or(k) from t",
+		"select e, count(*) from t group by e",
+		"select * from t order by b limit 2",
+	}
+	for _, sql := range queries {
+		tk.MustExec("set @@tidb_enable_chunk_rpc = 0")
+		expected := tk.MustQuery(sql).Rows()
+		tk.MustExec("set @@tidb_enable_chunk_rpc = 1")
+		tk.MustQuery(sql).Check(expected)
+	}
+	tk.MustQuery("select g, h, i, j, k + 0, l from t where id = 1").Check(testkit.Rows(`2017-10-01 12:30:00 12:30:00.123 y x,y 5 {"a":1}`))
+}
+
+func (s *testSuite) TestBit(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (id int, b bit(10), c bit(64), index idx_b(b))")
+	tk.MustExec("insert into t values (1, b'1010', b'1111111111111111111111111111111111111111111111111111111111111111')")
+	tk.MustExec("insert into t values (2, 3, 18446744073709551615), (3, b'1', 0), (4, null, null)")
+
+	// Bit values are unsigned integers in numeric context.
+	tk.MustQuery("select b+0, c+0, cast(c as unsigned), hex(b), bin(b) from t where id = 1").Check(testkit.Rows(
+		"10 18446744073709551615 18446744073709551615 A 1010"))
+	tk.MustQuery("select c+0 from t where id = 2").Check(testkit.Rows("18446744073709551615"))
+	rs, err := tk.Exec("select b - 2 from t where id = 3")
+	c.Assert(err, IsNil)
+	_, err = tidb.GetRows(rs)
+	c.Assert(terror.ErrorEqual(err, types.ErrOverflow), IsTrue)
+	tk.MustQuery("select id from t where c = 18446744073709551615 order by id").Check(testkit.Rows("1", "2"))
+	tk.MustQuery("select id from t where b & 2 order by id").Check(testkit.Rows("1", "2"))
+	tk.MustQuery("select id from t where b > 2 order by id").Check(testkit.Rows("1", "2"))
+	tk.MustQuery("select id from t use index(idx_b) where b > 2 order by id").Check(testkit.Rows("1", "2"))
+	tk.MustQuery("select b+0 from t order by b").Check(testkit.Rows("<nil>", "1", "3", "10"))
+
+	// Bit literals are binary strings padded to whole bytes.
+	tk.MustQuery("select hex(b'1010'), b'1010'+0, b'1010' = 10, 0b11+1, hex(b'000001'), length(b'000000001')").Check(testkit.Rows(
+		"0A 10 1 4 01 2"))
+	tk.MustQuery("select hex(b'000000000000000000000000000000000000000000000000000000000001000001')").Check(testkit.Rows("000000000000000041"))
+}
+
 func (s *testSuite) TestMiscellaneousBuiltin(c *C) {
 	defer func() {
 		s.cleanEnv(c)
@@ -1997,4 +2888,173 @@ func (s testPrioritySuite) TestCoprocessorPriority(c *C) {
 
 	cli.priority = pb.CommandPri_Low
 	tk.MustQuery("select LOW_PRIORITY id from t where id = 1")
+
+	cli.priority = pb.CommandPri_Low
+	tk.MustExec("insert DELAYED into t select id + 10 from t")
+
+	// Test priority forced by tidb_force_priority, it is overridden by the priority of the statement.
+	tk.MustExec("set @@tidb_force_priority = 'low_priority'")
+	cli.priority = pb.CommandPri_Low
+	tk.MustQuery("select id from t where id = 1")
+	cli.priority = pb.CommandPri_High
+	tk.MustQuery("select HIGH_PRIORITY id from t where id = 1")
+	tk.MustExec("set @@tidb_force_priority = 'HIGH_PRIORITY'")
+	cli.priority = pb.CommandPri_High
+	tk.MustQuery("select count(*) from t")
+	cli.priority = pb.CommandPri_Low
+	tk.MustExec("delete LOW_PRIORITY from t where id > 10")
+	tk.MustExec("set @@tidb_force_priority = 'NO_PRIORITY'")
+	cli.priority = pb.CommandPri_Low
+	tk.MustQuery("select count(*) from t")
+}
+
+// unavailableRegionClient fails the coprocessor requests to a region as if the region is unavailable.
+type unavailableRegionClient struct {
+	tikv.Client
+	mu struct {
+		sync.Mutex
+		regionID uint64
+		// failures is the number of the requests to fail, the requests always fail if it's negative.
+		failures int
+	}
+}
+
+func (c *unavailableRegionClient) setFailures(regionID uint64, failures int) {
+	c.mu.Lock()
+	c.mu.regionID, c.mu.failures = regionID, failures
+	c.mu.Unlock()
+}
+
+func (c *unavailableRegionClient) SendReq(ctx goctx.Context, addr string, req *tikvrpc.Request) (*tikvrpc.Response, error) {
+	if req.Type == tikvrpc.CmdCop {
+		c.mu.Lock()
+		fail := req.Cop.GetContext().GetRegionId() == c.mu.regionID && c.mu.failures != 0
+		if fail && c.mu.failures > 0 {
+			c.mu.failures--
+		}
+		c.mu.Unlock()
+		if fail {
+			regionErr := &errorpb.Error{NotLeader: &errorpb.NotLeader{}}
+			return &tikvrpc.Response{Type: tikvrpc.CmdCop, Cop: &coprocessor.Response{RegionError: regionErr}}, nil
+		}
+	}
+	return c.Client.SendReq(ctx, addr, req)
+}
+
+type testPartialResultSuite struct{}
+
+var _ = Suite(testPartialResultSuite{})
+
+func (s testPartialResultSuite) TestUnavailableRegion(c *C) {
+	cli := &unavailableRegionClient{}
+	cluster := mocktikv.NewCluster()
+	mocktikv.BootstrapWithSingleStore(cluster)
+	mvccStore := mocktikv.NewMvccStore()
+	store, err := tikv.NewMockTikvStore(
+		tikv.WithCluster(cluster),
+		tikv.WithMVCCStore(mvccStore),
+		tikv.WithHijackClient(func(c tikv.Client) tikv.Client {
+			cli.Client = c
+			return cli
+		}),
+	)
+	c.Assert(err, IsNil)
+	defer store.Close()
+	dom, err := tidb.BootstrapSession(store)
+	c.Assert(err, IsNil)
+	defer dom.Close()
+	// A request fails as soon as its region is unavailable, unless the failed ranges are retried.
+	c.Assert(tikv.SetBackoffConfig(map[string]int{"cop_next": 1}, nil), IsNil)
+	defer tikv.SetBackoffConfig(map[string]int{"cop_next": 20000, "cop_retry": 5000}, nil)
+
+	tk := testkit.NewTestKit(c, store)
+	tk.MustExec("use test")
+	tk.MustExec("create table t (a int primary key)")
+	tk.MustExec("create table t1 (a int primary key)")
+	tk.MustExec("insert into t values (1), (2), (3), (4), (5), (6), (7), (8), (9), (10)")
+	tbl, err := dom.InfoSchema().TableByName(model.NewCIStr("test"), model.NewCIStr("t"))
+	c.Assert(err, IsNil)
+	tableID := tbl.Meta().ID
+	cluster.SplitTable(mvccStore, tableID, 5)
+	region, _ := cluster.GetRegionByKey(mocktikv.NewMvccKey(tablecodec.EncodeRowKeyWithHandle(tableID, 1)))
+
+	// The ranges of the region are retried until the region is available.
+	cli.setFailures(region.GetId(), 2)
+	tk.MustQuery("select count(*) from t").Check(testkit.Rows("10"))
+	c.Assert(tk.Se.GetSessionVars().StmtCtx.WarningCount(), Equals, uint16(0))
+
+	c.Assert(tikv.SetBackoffConfig(map[string]int{"cop_retry": 1}, nil), IsNil)
+	cli.setFailures(region.GetId(), -1)
+	rs, err := tk.Exec("select count(*) from t")
+	c.Assert(err, IsNil)
+	_, err = tidb.GetRows(rs)
+	c.Assert(err, NotNil)
+
+	// The ranges of the unavailable region are skipped with a warning.
+	tk.MustExec("set @@tidb_allow_partial_result = 1")
+	tk.MustQuery("select count(*) from t").Check(testkit.Rows("8"))
+	warnings := tk.Se.GetSessionVars().StmtCtx.GetWarnings()
+	c.Assert(warnings, HasLen, 1)
+	c.Assert(executor.ErrRangesSkipped.Equal(warnings[0].Err), IsTrue)
+	tk.MustQuery("select a from t where a < 5").Check(testkit.Rows("3", "4"))
+	// The other statements never write the partial result.
+	_, err = tk.Exec("insert into t1 select * from t")
+	c.Assert(err, NotNil)
+	tk.MustQuery("select count(*) from t1").Check(testkit.Rows("0"))
+
+	cli.setFailures(0, 0)
+	tk.MustQuery("select count(*) from t").Check(testkit.Rows("10"))
+	c.Assert(tk.Se.GetSessionVars().StmtCtx.WarningCount(), Equals, uint16(0))
+}
+
+func (s *testSuite) TestTableSample(c *C) {
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t, t1")
+	tk.MustExec("create table t(a int primary key, b int)")
+	values := make([]string, 0, 100)
+	for i := 1; i <= 100; i++ {
+		values = append(values, fmt.Sprintf("(%d, %d)", i, i*2))
+	}
+	tk.MustExec("insert into t values " + strings.Join(values, ","))
+	tbl, err := sessionctx.GetDomain(tk.Se).InfoSchema().TableByName(model.NewCIStr("test"), model.NewCIStr("t"))
+	c.Assert(err, IsNil)
+	s.cluster.SplitTable(s.mvccStore, tbl.Meta().ID, 4)
+	// The cached regions are refreshed by the request to all the regions.
+	tk.MustQuery("select count(*) from t").Check(testkit.Rows("100"))
+
+	// The first row of every region is read.
+	tk.MustQuery("select * from t tablesample regions()").Check(testkit.Rows("1 2", "26 52", "51 102", "76 152"))
+	tk.MustQuery("select a from t tablesample regions() where a > 30").Check(testkit.Rows("51", "76"))
+	tk.MustQuery("select count(*) from t tablesample regions() join t t1 on t.a = t1.a").Check(testkit.Rows("4"))
+	tk.MustQuery("select count(*) from t tablesample bernoulli(0)").Check(testkit.Rows("0"))
+	tk.MustQuery("select count(*), sum(b) from t tablesample bernoulli(100)").Check(testkit.Rows("100 10100"))
+	count, err := strconv.Atoi(tk.MustQuery("select count(*) from t tablesample bernoulli(50)").Rows()[0][0].(string))
+	c.Assert(err, IsNil)
+	c.Assert(count > 0 && count < 100, IsTrue, Commentf("count %d", count))
+
+	rows := tk.MustQuery("explain select * from t tablesample bernoulli(2.5)").Rows()
+	c.Assert(fmt.Sprint(rows), Matches, ".*table:t, sample:BERNOULLI\\(2.5\\).*")
+	rows = tk.MustQuery("explain select * from t as t1 tablesample regions()").Rows()
+	c.Assert(fmt.Sprint(rows), Matches, ".*table:t1, sample:REGIONS\\(\\).*")
+
+	// The rows written by the transaction are sampled too.
+	tk.MustExec("create table t1(a int, b int default 7)")
+	tk.MustExec("insert into t1 values (1, 1)")
+	tk.MustExec("begin")
+	tk.MustExec("insert into t1(a) values (2)")
+	tk.MustQuery("select * from t1 tablesample bernoulli(100)").Check(testkit.Rows("1 1", "2 7"))
+	tk.MustQuery("select * from t1 tablesample regions()").Check(testkit.Rows("1 1"))
+	tk.MustExec("rollback")
+	tk.MustExec("alter table t1 add column c int default 3")
+	tk.MustQuery("select * from t1 tablesample bernoulli(100)").Check(testkit.Rows("1 1 3"))
+
+	_, err = tk.Exec("select * from t tablesample bernoulli(100.5)")
+	c.Assert(terror.ErrorEqual(err, plan.ErrBadSamplePercentage), IsTrue, Commentf("err %v", err))
+	_, err = tk.Exec("select * from information_schema.tables tablesample regions()")
+	c.Assert(terror.ErrorEqual(err, plan.ErrSampleUnsupported), IsTrue, Commentf("err %v", err))
 }
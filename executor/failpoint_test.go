@@ -0,0 +1,64 @@
+// Copyright 2015 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// +build failpoint
+
+package executor_test
+
+import (
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/util/testkit"
+	"github.com/pingcap/tidb/util/testleak"
+)
+
+func (s *testSuite) TestFailpoint(c *C) {
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("create table failpoint_test (id int primary key, v int)")
+	tk.MustExec("insert failpoint_test values (1, 1), (2, 2)")
+
+	_, err := tk.Exec("admin enable failpoint 'tikv/regionMiss' = 'abc'")
+	c.Assert(err, NotNil)
+	_, err = tk.Exec("admin disable failpoint 'tikv/regionMiss'")
+	c.Assert(err, NotNil)
+
+	// The transaction fails after the keys are prewritten, nothing is committed. The failpoints
+	// are shared by all the transactions, including the ones of the background jobs.
+	tk.MustExec("begin")
+	tk.MustExec("update failpoint_test set v = v + 10")
+	tk.MustExec("admin enable failpoint 'tikv/commitFailAfterPrewrite' = 'return'")
+	tk.MustQuery("admin show failpoints").Check(testkit.Rows("tikv/commitFailAfterPrewrite return"))
+	_, err = tk.Exec("commit")
+	c.Assert(err, NotNil)
+	tk.MustExec("admin disable failpoint 'tikv/commitFailAfterPrewrite'")
+	tk.MustQuery("select v from failpoint_test").Check(testkit.Rows("1", "2"))
+	// The prewritten locks don't block the next transaction.
+	tk.MustExec("update failpoint_test set v = v + 10")
+	tk.MustQuery("select v from failpoint_test").Check(testkit.Rows("11", "12"))
+
+	// The requests retry after the Regions are reloaded.
+	tk.MustExec("admin enable failpoint 'tikv/regionMiss' = '1*off->2*return'")
+	tk.MustQuery("select v from failpoint_test where id = 2").Check(testkit.Rows("12"))
+	tk.MustQuery("select sum(v) from failpoint_test").Check(testkit.Rows("23"))
+	tk.MustExec("admin disable failpoint 'tikv/regionMiss'")
+
+	// The DDL job goes on after the worker restarts.
+	tk.MustExec("admin enable failpoint 'ddl/crashBetweenJobSteps' = '2*return'")
+	tk.MustExec("alter table failpoint_test add column c int default 3")
+	tk.MustQuery("select c from failpoint_test").Check(testkit.Rows("3", "3"))
+	tk.MustExec("admin disable failpoint 'ddl/crashBetweenJobSteps'")
+	tk.MustQuery("admin show failpoints").Check(testkit.Rows())
+}
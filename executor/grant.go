@@ -114,6 +114,9 @@ func (e *GrantExec) Next() (Row, error) {
 		}
 		// Grant each priv to the user.
 		for _, priv := range privs {
+			if priv.Priv == mysql.UsagePriv {
+				continue
+			}
 			if len(priv.Cols) > 0 {
 				// Check column scope privilege entry.
 				// TODO: Check validity before insert new entry.
@@ -372,6 +375,7 @@ func composeTablePrivUpdateForGrant(ctx context.Context, priv mysql.PrivilegeTyp
 		}
 		newTablePriv = addToSet(currTablePriv, p)
 
+		newColumnPriv = currColumnPriv
 		for _, cp := range mysql.AllColumnPrivs {
 			if priv == cp {
 				newColumnPriv = addToSet(currColumnPriv, p)
@@ -398,6 +402,7 @@ func composeTablePrivUpdateForRevoke(ctx context.Context, priv mysql.PrivilegeTy
 		}
 		newTablePriv = deleteFromSet(currTablePriv, p)
 
+		newColumnPriv = currColumnPriv
 		for _, cp := range mysql.AllColumnPrivs {
 			if priv == cp {
 				newColumnPriv = deleteFromSet(currColumnPriv, p)
@@ -410,10 +415,16 @@ func composeTablePrivUpdateForRevoke(ctx context.Context, priv mysql.PrivilegeTy
 
 // addToSet add a value to the set, e.g:
 // addToSet("Select,Insert", "Update") returns "Select,Insert,Update".
+// The set is returned unchanged if the value is already in it.
 func addToSet(set string, value string) string {
 	if set == "" {
 		return value
 	}
+	for _, v := range strings.Split(set, ",") {
+		if v == value {
+			return set
+		}
+	}
 	return fmt.Sprintf("%s,%s", set, value)
 }
 
@@ -31,6 +31,7 @@ var (
 	_ Executor = &HashJoinExec{}
 	_ joinExec = &HashSemiJoinExec{}
 	_ Executor = &ApplyJoinExec{}
+	_ Executor = &applyCacheExec{}
 )
 
 // HashJoinExec implements the hash join algorithm.
@@ -633,6 +634,8 @@ type HashSemiJoinExec struct {
 	smallTableHasNull bool
 	// anti is true, semi join only output the unmatched row.
 	anti bool
+	// hashKeyBuf is reused to compute the hash key of every row.
+	hashKeyBuf []types.Datum
 }
 
 // Close implements the Executor Close interface.
@@ -683,7 +686,7 @@ func (e *HashSemiJoinExec) prepare() error {
 		if !matched {
 			continue
 		}
-		hasNull, hashcode, err := getJoinKey(e.smallHashKey, row, make([]types.Datum, len(e.smallHashKey)), nil)
+		hasNull, hashcode, err := getJoinKey(e.smallHashKey, row, e.hashKeyBuf, nil)
 		if err != nil {
 			return errors.Trace(err)
 		}
@@ -700,7 +703,7 @@ func (e *HashSemiJoinExec) prepare() error {
 }
 
 func (e *HashSemiJoinExec) rowIsMatched(bigRow Row) (matched bool, hasNull bool, err error) {
-	hasNull, hashcode, err := getJoinKey(e.bigHashKey, bigRow, make([]types.Datum, len(e.smallHashKey)), nil)
+	hasNull, hashcode, err := getJoinKey(e.bigHashKey, bigRow, e.hashKeyBuf, nil)
 	if err != nil {
 		return false, false, errors.Trace(err)
 	}
@@ -810,6 +813,8 @@ type ApplyJoinExec struct {
 	cursor      int
 	resultRows  []Row
 	schema      *expression.Schema
+	// cache is the inner executor of the join if the inner rows are cached.
+	cache *applyCacheExec
 }
 
 // Schema implements the Executor interface.
@@ -826,6 +831,11 @@ func (e *ApplyJoinExec) Close() error {
 func (e *ApplyJoinExec) Open() error {
 	e.cursor = 0
 	e.resultRows = nil
+	if e.cache != nil {
+		// The inner rows may depend on the correlated columns of the outer applies, which are changed
+		// when this apply is opened again.
+		e.cache.reset()
+	}
 	return errors.Trace(e.join.Open())
 }
 
@@ -855,3 +865,103 @@ func (e *ApplyJoinExec) Next() (Row, error) {
 		e.cursor = 0
 	}
 }
+
+// applyCacheExec caches the rows of the inner executor of an apply by the values of the correlated columns,
+// so the inner executor is executed only once for the outer rows with the same values. It's only used for
+// the deterministic inner plans, see plan.IsDeterministic.
+type applyCacheExec struct {
+	baseExecutor
+
+	corCols []*expression.CorrelatedColumn
+	cache   map[string][]Row
+	// capacity is the max number of the cached rows, an empty result is counted as a row. cachedRows is the
+	// number of the rows in the cache.
+	capacity   int
+	cachedRows int
+
+	key  string
+	hit  bool
+	rows []Row
+	// overflow means the rows of the current key can't be put into the cache.
+	overflow bool
+	cursor   int
+}
+
+func newApplyCacheExec(inner Executor, corCols []*expression.CorrelatedColumn, capacity int) *applyCacheExec {
+	return &applyCacheExec{
+		baseExecutor: newBaseExecutor(inner.Schema(), nil, inner),
+		corCols:      corCols,
+		cache:        make(map[string][]Row),
+		capacity:     capacity,
+	}
+}
+
+func (e *applyCacheExec) reset() {
+	e.cache = make(map[string][]Row)
+	e.cachedRows = 0
+}
+
+// Open implements the Executor Open interface.
+func (e *applyCacheExec) Open() error {
+	values := make([]types.Datum, 0, len(e.corCols))
+	for _, col := range e.corCols {
+		values = append(values, *col.Data)
+	}
+	key, err := codec.EncodeValue(nil, values...)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	e.key = string(key)
+	e.cursor = 0
+	e.rows, e.hit = e.cache[e.key]
+	if e.hit {
+		return nil
+	}
+	e.overflow = e.cachedRows >= e.capacity
+	return errors.Trace(e.children[0].Open())
+}
+
+// Next implements the Executor Next interface.
+func (e *applyCacheExec) Next() (Row, error) {
+	if e.hit {
+		if e.cursor >= len(e.rows) {
+			return nil, nil
+		}
+		row := e.rows[e.cursor]
+		e.cursor++
+		return row, nil
+	}
+	row, err := e.children[0].Next()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if row == nil {
+		// Only the complete results are cached.
+		if !e.overflow {
+			e.cache[e.key] = e.rows
+			e.cachedRows += len(e.rows)
+			if len(e.rows) == 0 {
+				e.cachedRows++
+			}
+		}
+		e.rows = nil
+		return nil, nil
+	}
+	if !e.overflow {
+		if e.cachedRows+len(e.rows) >= e.capacity {
+			e.overflow = true
+			e.rows = nil
+		} else {
+			e.rows = append(e.rows, row)
+		}
+	}
+	return row, nil
+}
+
+// Close implements the Executor Close interface.
+func (e *applyCacheExec) Close() error {
+	if e.hit {
+		return nil
+	}
+	return errors.Trace(e.children[0].Close())
+}
@@ -287,6 +287,35 @@ func (s *testSuite) TestJoinCast(c *C) {
 	result.Sort().Check(testkit.Rows("0.0 0.00", "2.0 <nil>"))
 }
 
+func (s *testSuite) TestJoinPredicateInference(c *C) {
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk := testkit.NewTestKit(c, s.store)
+
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t, t1")
+	tk.MustExec("create table t(a int, b varchar(10))")
+	tk.MustExec("create table t1(a int, b int)")
+	tk.MustExec("insert into t values (1, '9'), (3, '10'), (4, '4'), (null, null)")
+	tk.MustExec("insert into t1 values (1, 9), (3, 10), (4, 4), (4, 5), (null, null)")
+	tk.MustQuery("select * from t left join t1 on t.a = t1.a where t.a > 2").Sort().Check(testkit.Rows(
+		"3 10 3 10", "4 4 4 4", "4 4 4 5"))
+	tk.MustQuery("select * from t left join t1 on t.a = t1.a and t.a in (1, 3)").Sort().Check(testkit.Rows(
+		"3 10 3 10", "4 4 <nil> <nil>", "1 9 1 9", "<nil> <nil> <nil> <nil>"))
+	tk.MustQuery("select * from t1 right join t on t.a = t1.a where t.a = 4").Sort().Check(testkit.Rows(
+		"4 4 4 4", "4 5 4 4"))
+	// The columns of different types aren't inferred from each other.
+	tk.MustQuery("select t.b, t1.b from t left join t1 on t.b = t1.b where t.b > '3'").Sort().Check(testkit.Rows(
+		"4 4", "9 9"))
+	tk.MustQuery("select t.b, t1.b from t join t1 on t.b = t1.b and t.a = t1.a where t.a = 1 or t.a = 3").Sort().Check(testkit.Rows(
+		"10 10", "9 9"))
+	// The always true and false filters.
+	tk.MustQuery("select count(*) from t join t1 on t.a = t1.a where 1 = 1 or t.a > 1").Check(testkit.Rows("4"))
+	tk.MustQuery("select count(*) from t join t1 on t.a = t1.a where t.a = 1 and t1.a = 3").Check(testkit.Rows("0"))
+}
+
 func (s *testSuite) TestUsing(c *C) {
 	defer func() {
 		s.cleanEnv(c)
@@ -590,6 +619,43 @@ func (s *testSuite) TestSubquery(c *C) {
 	result.Check(testkit.Rows("2", "2"))
 }
 
+func (s *testSuite) TestApplyCache(c *C) {
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t, s")
+	tk.MustExec("create table t (a int, b int)")
+	tk.MustExec("create table s (a int, b int)")
+	tk.MustExec("insert t values (1, 1), (1, 2), (2, 1), (2, 2), (3, 3), (null, 1), (null, 2)")
+	tk.MustExec("insert s values (1, 1), (1, 2), (2, 2), (3, 1), (null, 1)")
+	sqls := []string{
+		"select t.a, t.b, (select count(*) from s where s.a = t.a and s.b <= t.b) from t order by t.a, t.b",
+		"select t.a, t.b, t.a in (select count(*) from s where s.b = t.b) from t order by t.a, t.b",
+		// The inner rows of the inner apply depend on the correlated column of the outer apply.
+		"select t.a, t.b, (select count(*) from s where s.a = t.a and s.b > (select count(*) from t t1 where t1.a = s.b and t1.b >= t.b)) from t order by t.a, t.b",
+	}
+	tk.MustExec("set @@tidb_apply_cache_capacity = 0")
+	var expected [][][]interface{}
+	for _, sql := range sqls {
+		expected = append(expected, tk.MustQuery(sql).Rows())
+	}
+	// The capacity of 2 rows keeps some of the results of the correlated subqueries only.
+	for _, capacity := range []string{"2", "100000"} {
+		tk.MustExec("set @@tidb_apply_cache_capacity = " + capacity)
+		for i, sql := range sqls {
+			tk.MustQuery(sql).Check(expected[i])
+		}
+	}
+	tk.MustQuery(sqls[0]).Check(testkit.Rows("<nil> 1 0", "<nil> 2 0", "1 1 1", "1 2 2", "2 1 0", "2 2 1", "3 3 1"))
+
+	// The results of the non-deterministic inner plans aren't cached.
+	tk.MustExec("set @n = 0")
+	tk.MustQuery("select count(distinct x) from (select (select @n := @n + 1 from s where s.a = t.b limit 1) x from t) y").Check(testkit.Rows("7"))
+}
+
 func (s *testSuite) TestInSubquery(c *C) {
 	defer func() {
 		s.cleanEnv(c)
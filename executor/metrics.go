@@ -14,10 +14,8 @@
 package executor
 
 import (
-	"fmt"
 	"strings"
 
-	"github.com/ngaut/log"
 	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
@@ -40,20 +38,32 @@ var (
 			Name:      "expensive_query_total",
 			Help:      "Counter of expensive query.",
 		}, []string{"type"})
+	stmtDurationHistogram = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "tidb",
+			Subsystem: "executor",
+			Name:      "statement_duration_seconds",
+			Help:      "Bucketed histogram of processing time (s) of statements by type.",
+			Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 22),
+		}, []string{"type"})
 )
 
 func init() {
 	prometheus.MustRegister(stmtNodeCounter)
 	prometheus.MustRegister(expensiveQueryCounter)
+	prometheus.MustRegister(stmtDurationHistogram)
 }
 
-func stmtCount(node ast.StmtNode, p plan.Plan, inRestrictedSQL bool) bool {
+// stmtCount counts the statement, it returns the label of the statement, which is empty if the
+// statement is not counted, and whether the statement is expensive.
+func stmtCount(node ast.StmtNode, p plan.Plan, inRestrictedSQL bool) (string, bool) {
 	var isExpensive bool
 	stmtLabel := StatementLabel(node, p, &isExpensive)
-	if !inRestrictedSQL && stmtLabel != IGNORE {
-		stmtNodeCounter.WithLabelValues(stmtLabel).Inc()
+	if inRestrictedSQL || stmtLabel == IGNORE {
+		return "", isExpensive
 	}
-	return isExpensive
+	stmtNodeCounter.WithLabelValues(stmtLabel).Inc()
+	return stmtLabel, isExpensive
 }
 
 const (
@@ -69,20 +79,44 @@ const (
 	Begin = "Begin"
 	// Commit represents commit statements.
 	Commit = "Commit"
+	// CreateBinding represents create binding statements.
+	CreateBinding = "CreateBinding"
 	// CreateDatabase represents create database statements.
 	CreateDatabase = "CreateDatabase"
+	// CreateEvent represents create event statements.
+	CreateEvent = "CreateEvent"
+	// CreateFunction represents create function statements.
+	CreateFunction = "CreateFunction"
 	// CreateIndex represents create index statements.
 	CreateIndex = "CreateIndex"
+	// CreateMaskingPolicy represents create masking policy statements.
+	CreateMaskingPolicy = "CreateMaskingPolicy"
+	// CreatePolicy represents create policy statements.
+	CreatePolicy = "CreatePolicy"
+	// CreateProcedure represents create procedure statements.
+	CreateProcedure = "CreateProcedure"
 	// CreateTable represents create table statements.
 	CreateTable = "CreateTable"
 	// CreateUser represents create user statements.
 	CreateUser = "CreateUser"
 	// Delete represents delete statements.
 	Delete = "Delete"
+	// DropBinding represents drop binding statements.
+	DropBinding = "DropBinding"
 	// DropDatabase represents drop database statements.
 	DropDatabase = "DropDatabase"
+	// DropEvent represents drop event statements.
+	DropEvent = "DropEvent"
+	// DropFunction represents drop function statements.
+	DropFunction = "DropFunction"
 	// DropIndex represents drop index statements.
 	DropIndex = "DropIndex"
+	// DropMaskingPolicy represents drop masking policy statements.
+	DropMaskingPolicy = "DropMaskingPolicy"
+	// DropPolicy represents drop policy statements.
+	DropPolicy = "DropPolicy"
+	// DropProcedure represents drop procedure statements.
+	DropProcedure = "DropProcedure"
 	// DropTable represents drop table statements.
 	DropTable = "DropTable"
 	// Explain represents explain statements.
@@ -93,14 +127,20 @@ const (
 	Insert = "Insert"
 	// LoadDataStmt represents load data statements.
 	LoadDataStmt = "LoadData"
+	// LockTables represents lock tables statements.
+	LockTables = "LockTables"
 	// RollBack represents roll back statements.
 	RollBack = "RollBack"
 	// Set represents set statements.
 	Set = "Set"
 	// Show represents show statements.
 	Show = "Show"
+	// Trace represents trace statements.
+	Trace = "Trace"
 	// TruncateTable represents truncate table statements.
 	TruncateTable = "TruncateTable"
+	// UnlockTables represents unlock tables statements.
+	UnlockTables = "UnlockTables"
 	// Update represents update statements.
 	Update = "Update"
 	// Grant represents grant statements.
@@ -120,23 +160,47 @@ func StatementLabel(node ast.StmtNode, p plan.Plan, isExpensive *bool) string {
 		return Begin
 	case *ast.CommitStmt:
 		return Commit
+	case *ast.CreateBindingStmt:
+		return CreateBinding
 	case *ast.CreateDatabaseStmt:
 		return CreateDatabase
+	case *ast.CreateEventStmt:
+		return CreateEvent
+	case *ast.CreateFunctionStmt:
+		return CreateFunction
 	case *ast.CreateIndexStmt:
 		return CreateIndex
+	case *ast.CreateMaskingPolicyStmt:
+		return CreateMaskingPolicy
+	case *ast.CreatePolicyStmt:
+		return CreatePolicy
+	case *ast.CreateProcedureStmt:
+		return CreateProcedure
 	case *ast.CreateTableStmt:
 		return CreateTable
 	case *ast.CreateUserStmt:
 		return CreateUser
 	case *ast.DeleteStmt:
 		return getDeleteStmtLabel(x, p, isExpensive)
+	case *ast.DropBindingStmt:
+		return DropBinding
 	case *ast.DropDatabaseStmt:
 		return DropDatabase
+	case *ast.DropEventStmt:
+		return DropEvent
+	case *ast.DropFunctionStmt:
+		return DropFunction
 	case *ast.DropIndexStmt:
 		return DropIndex
+	case *ast.DropMaskingPolicyStmt:
+		return DropMaskingPolicy
+	case *ast.DropPolicyStmt:
+		return DropPolicy
+	case *ast.DropProcedureStmt:
+		return DropProcedure
 	case *ast.DropTableStmt:
 		return DropTable
-	case *ast.ExplainStmt:
+	case *ast.ExplainStmt, *ast.ExplainForStmt:
 		return Explain
 	case *ast.InsertStmt:
 		if x.IsReplace {
@@ -145,16 +209,22 @@ func StatementLabel(node ast.StmtNode, p plan.Plan, isExpensive *bool) string {
 		return Insert
 	case *ast.LoadDataStmt:
 		return LoadDataStmt
+	case *ast.LockTablesStmt:
+		return LockTables
 	case *ast.RollbackStmt:
 		return RollBack
 	case *ast.SelectStmt:
 		return getSelectStmtLabel(x, p, isExpensive)
-	case *ast.SetStmt, *ast.SetPwdStmt:
+	case *ast.SetStmt, *ast.SetPwdStmt, *ast.SetSessionStatesStmt:
 		return Set
 	case *ast.ShowStmt:
 		return Show
+	case *ast.TraceStmt:
+		return Trace
 	case *ast.TruncateTableStmt:
 		return TruncateTable
+	case *ast.UnlockTablesStmt:
+		return UnlockTables
 	case *ast.UpdateStmt:
 		return getUpdateStmtLabel(x, p, isExpensive)
 	case *ast.GrantStmt:
@@ -172,7 +242,7 @@ func getSelectStmtLabel(stmt *ast.SelectStmt, p plan.Plan, isExpensive *bool) st
 	attributes.fromSelectStmt(stmt)
 	attributes.fromPlan(p)
 	stmtLabel := Select + attributes.toLabel()
-	attributes.logExpensiveStmt(stmtLabel, stmt.Text(), isExpensive)
+	attributes.countExpensiveStmt(stmtLabel, isExpensive)
 	return stmtLabel
 }
 
@@ -181,7 +251,7 @@ func getDeleteStmtLabel(stmt *ast.DeleteStmt, p plan.Plan, isExpensive *bool) st
 	attributes.fromDeleteStmt(stmt)
 	attributes.fromPlan(p)
 	stmtLabel := Delete + attributes.toLabel()
-	attributes.logExpensiveStmt(stmtLabel, stmt.Text(), isExpensive)
+	attributes.countExpensiveStmt(stmtLabel, isExpensive)
 	return stmtLabel
 }
 
@@ -190,7 +260,7 @@ func getUpdateStmtLabel(stmt *ast.UpdateStmt, p plan.Plan, isExpensive *bool) st
 	attributes.fromUpdateStmt(stmt)
 	attributes.fromPlan(p)
 	stmtLabel := Update + attributes.toLabel()
-	attributes.logExpensiveStmt(stmtLabel, stmt.Text(), isExpensive)
+	attributes.countExpensiveStmt(stmtLabel, isExpensive)
 	return stmtLabel
 }
 
@@ -346,13 +416,10 @@ func (pa *stmtAttributes) isExpensiveStmt() bool {
 	return true
 }
 
-func (pa *stmtAttributes) logExpensiveStmt(stmtLabel string, sql string, isExpensive *bool) {
+// countExpensiveStmt counts the statement if it's expensive, the statement is logged by the caller
+// with the fields of the session.
+func (pa *stmtAttributes) countExpensiveStmt(stmtLabel string, isExpensive *bool) {
 	if pa.isExpensiveStmt() {
-		const logSQLLen = 1024
-		if len(sql) > logSQLLen {
-			sql = sql[:logSQLLen] + fmt.Sprintf("len(%d)", len(sql))
-		}
-		log.Warnf("[EXPENSIVE_QUERY] %s", sql)
 		expensiveQueryCounter.WithLabelValues(stmtLabel).Inc()
 		*isExpensive = true
 	}
@@ -14,7 +14,9 @@
 package executor
 
 import (
+	"fmt"
 	"sort"
+	"strings"
 	"sync/atomic"
 
 	"github.com/juju/errors"
@@ -23,7 +25,6 @@ import (
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/table"
-	"github.com/pingcap/tidb/util/codec"
 	"github.com/pingcap/tidb/util/types"
 	"github.com/pingcap/tipb/go-tipb"
 	goctx "golang.org/x/net/context"
@@ -50,6 +51,41 @@ func handleIsExtra(col *expression.Column) bool {
 	return false
 }
 
+// resultFieldTypes returns the field types of the values returned by the coprocessor,
+// the extra handle column is returned as the row handle instead of a value.
+func resultFieldTypes(schema *expression.Schema, handleCol *expression.Column) []*types.FieldType {
+	cols := schema.Columns
+	if handleIsExtra(handleCol) {
+		cols = cols[:len(cols)-1]
+	}
+	fieldTypes := make([]*types.FieldType, 0, len(cols))
+	for _, col := range cols {
+		fieldTypes = append(fieldTypes, col.RetType)
+	}
+	return fieldTypes
+}
+
+// allowPartialResult returns if the requests skip the ranges of the unavailable regions. Only the SELECT statements
+// may return the partial result, the rows written by the other statements must be complete.
+func allowPartialResult(ctx context.Context) bool {
+	vars := ctx.GetSessionVars()
+	return vars.AllowPartialResult && vars.StmtCtx.InSelectStmt
+}
+
+// appendSkippedRangesWarning warns the ranges skipped by the finished request, so the user knows the result is partial.
+func appendSkippedRangesWarning(ctx context.Context, result distsql.SelectResult) {
+	ranges := result.SkippedRanges()
+	if len(ranges) == 0 {
+		return
+	}
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].StartKey.Cmp(ranges[j].StartKey) < 0 })
+	strs := make([]string, 0, len(ranges))
+	for _, r := range ranges {
+		strs = append(strs, fmt.Sprintf("[%X, %X)", []byte(r.StartKey), []byte(r.EndKey)))
+	}
+	ctx.GetSessionVars().StmtCtx.AppendWarning(ErrRangesSkipped.GenByArgs(strings.Join(strs, ", ")))
+}
+
 // TableReaderExecutor sends dag request and reads table data from kv layer.
 type TableReaderExecutor struct {
 	asName    *model.CIStr
@@ -57,7 +93,7 @@ type TableReaderExecutor struct {
 	tableID   int64
 	keepOrder bool
 	desc      bool
-	ranges    []types.IntColumnRange
+	ranges    []*types.Range
 	dagPB     *tipb.DAGRequest
 	ctx       context.Context
 	schema    *expression.Schema
@@ -70,6 +106,7 @@ type TableReaderExecutor struct {
 	result        distsql.SelectResult
 	partialResult distsql.PartialResult
 	priority      int
+	rowAlloc      rowAllocator
 }
 
 // Schema implements the Executor Schema interface.
@@ -97,33 +134,31 @@ func (e *TableReaderExecutor) Next() (Row, error) {
 			}
 			if e.partialResult == nil {
 				// Finished.
+				appendSkippedRangesWarning(e.ctx, e.result)
 				return nil, nil
 			}
 		}
 		// Get a row from partial result.
-		h, rowData, err := e.partialResult.Next()
-		if err != nil {
-			return nil, errors.Trace(err)
-		}
-		if rowData == nil {
-			// Finish the current partial result and get the next one.
-			e.partialResult.Close()
-			e.partialResult = nil
-			continue
-		}
-		values := make([]types.Datum, e.schema.Len())
+		values := e.rowAlloc.alloc(e.schema.Len())
+		var (
+			h   int64
+			ok  bool
+			err error
+		)
 		if handleIsExtra(e.handleCol) {
-			err = codec.SetRawValues(rowData, values[:len(values)-1])
+			h, ok, err = e.partialResult.NextRow(values[:len(values)-1])
 			values[len(values)-1].SetInt64(h)
 		} else {
-			err = codec.SetRawValues(rowData, values)
+			_, ok, err = e.partialResult.NextRow(values)
 		}
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
-		err = decodeRawValues(values, e.schema, e.ctx.GetSessionVars().GetTimeZone())
-		if err != nil {
-			return nil, errors.Trace(err)
+		if !ok {
+			// Finish the current partial result and get the next one.
+			e.partialResult.Close()
+			e.partialResult = nil
+			continue
 		}
 		return values, nil
 	}
@@ -133,10 +168,11 @@ func (e *TableReaderExecutor) Next() (Row, error) {
 func (e *TableReaderExecutor) Open() error {
 	kvRanges := tableRangesToKVRanges(e.tableID, e.ranges)
 	var err error
-	e.result, err = distsql.SelectDAG(e.ctx.GetClient(), goctx.Background(), e.dagPB, kvRanges, e.ctx.GetSessionVars().DistSQLScanConcurrency, e.keepOrder, e.desc, getIsolationLevel(e.ctx.GetSessionVars()), e.priority)
+	e.result, err = distsql.SelectDAG(e.ctx.GetClient(), e.ctx.GoCtx(), e.dagPB, kvRanges, distSQLScanConcurrency(e.ctx, e.priority), e.keepOrder, e.desc, getIsolationLevel(e.ctx.GetSessionVars()), e.priority, allowPartialResult(e.ctx))
 	if err != nil {
 		return errors.Trace(err)
 	}
+	e.result.SetFields(resultFieldTypes(e.schema, e.handleCol), e.ctx.GetSessionVars().GetTimeZone())
 	e.result.Fetch(e.ctx.GoCtx())
 	return nil
 }
@@ -146,10 +182,11 @@ func (e *TableReaderExecutor) doRequestForHandles(handles []int64, goCtx goctx.C
 	sort.Sort(int64Slice(handles))
 	kvRanges := tableHandlesToKVRanges(e.tableID, handles)
 	var err error
-	e.result, err = distsql.SelectDAG(e.ctx.GetClient(), goCtx, e.dagPB, kvRanges, e.ctx.GetSessionVars().DistSQLScanConcurrency, e.keepOrder, e.desc, getIsolationLevel(e.ctx.GetSessionVars()), e.priority)
+	e.result, err = distsql.SelectDAG(e.ctx.GetClient(), goCtx, e.dagPB, kvRanges, distSQLScanConcurrency(e.ctx, e.priority), e.keepOrder, e.desc, getIsolationLevel(e.ctx.GetSessionVars()), e.priority, allowPartialResult(e.ctx))
 	if err != nil {
 		return errors.Trace(err)
 	}
+	e.result.SetFields(resultFieldTypes(e.schema, e.handleCol), e.ctx.GetSessionVars().GetTimeZone())
 	e.result.Fetch(goCtx)
 	return nil
 }
@@ -172,7 +209,7 @@ type IndexReaderExecutor struct {
 	tableID   int64
 	keepOrder bool
 	desc      bool
-	ranges    []*types.IndexRange
+	ranges    []*types.Range
 	dagPB     *tipb.DAGRequest
 	ctx       context.Context
 	schema    *expression.Schema
@@ -185,6 +222,7 @@ type IndexReaderExecutor struct {
 	// columns are only required by union scan.
 	columns  []*model.ColumnInfo
 	priority int
+	rowAlloc rowAllocator
 }
 
 // Schema implements the Executor Schema interface.
@@ -212,33 +250,31 @@ func (e *IndexReaderExecutor) Next() (Row, error) {
 			}
 			if e.partialResult == nil {
 				// Finished.
+				appendSkippedRangesWarning(e.ctx, e.result)
 				return nil, nil
 			}
 		}
 		// Get a row from partial result.
-		h, rowData, err := e.partialResult.Next()
-		if err != nil {
-			return nil, errors.Trace(err)
-		}
-		if rowData == nil {
-			// Finish the current partial result and get the next one.
-			e.partialResult.Close()
-			e.partialResult = nil
-			continue
-		}
-		values := make([]types.Datum, e.schema.Len())
+		values := e.rowAlloc.alloc(e.schema.Len())
+		var (
+			h   int64
+			ok  bool
+			err error
+		)
 		if handleIsExtra(e.handleCol) {
-			err = codec.SetRawValues(rowData, values[:len(values)-1])
+			h, ok, err = e.partialResult.NextRow(values[:len(values)-1])
 			values[len(values)-1].SetInt64(h)
 		} else {
-			err = codec.SetRawValues(rowData, values)
+			_, ok, err = e.partialResult.NextRow(values)
 		}
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
-		err = decodeRawValues(values, e.schema, e.ctx.GetSessionVars().GetTimeZone())
-		if err != nil {
-			return nil, errors.Trace(err)
+		if !ok {
+			// Finish the current partial result and get the next one.
+			e.partialResult.Close()
+			e.partialResult = nil
+			continue
 		}
 		return values, nil
 	}
@@ -250,28 +286,30 @@ func (e *IndexReaderExecutor) Open() error {
 	for i, v := range e.index.Columns {
 		fieldTypes[i] = &(e.table.Cols()[v.Offset].FieldType)
 	}
-	kvRanges, err := indexRangesToKVRanges(e.ctx.GetSessionVars().StmtCtx, e.tableID, e.index.ID, e.ranges, fieldTypes)
+	kvRanges, err := indexRangesToKVRanges(e.ctx.GetSessionVars().StmtCtx, e.tableID, e.index, e.ranges, fieldTypes)
 	if err != nil {
 		return errors.Trace(err)
 	}
-	e.result, err = distsql.SelectDAG(e.ctx.GetClient(), e.ctx.GoCtx(), e.dagPB, kvRanges, e.ctx.GetSessionVars().DistSQLScanConcurrency, e.keepOrder, e.desc, getIsolationLevel(e.ctx.GetSessionVars()), e.priority)
+	e.result, err = distsql.SelectDAG(e.ctx.GetClient(), e.ctx.GoCtx(), e.dagPB, kvRanges, distSQLScanConcurrency(e.ctx, e.priority), e.keepOrder, e.desc, getIsolationLevel(e.ctx.GetSessionVars()), e.priority, allowPartialResult(e.ctx))
 	if err != nil {
 		return errors.Trace(err)
 	}
+	e.result.SetFields(resultFieldTypes(e.schema, e.handleCol), e.ctx.GetSessionVars().GetTimeZone())
 	e.result.Fetch(e.ctx.GoCtx())
 	return nil
 }
 
 // doRequestForDatums constructs kv ranges by datums. It is used by index look up executor.
 func (e *IndexReaderExecutor) doRequestForDatums(values [][]types.Datum, goCtx goctx.Context) error {
-	kvRanges, err := indexValuesToKVRanges(e.tableID, e.index.ID, values)
+	kvRanges, err := indexValuesToKVRanges(e.tableID, e.index, values)
 	if err != nil {
 		return errors.Trace(err)
 	}
-	e.result, err = distsql.SelectDAG(e.ctx.GetClient(), e.ctx.GoCtx(), e.dagPB, kvRanges, e.ctx.GetSessionVars().DistSQLScanConcurrency, e.keepOrder, e.desc, getIsolationLevel(e.ctx.GetSessionVars()), e.priority)
+	e.result, err = distsql.SelectDAG(e.ctx.GetClient(), e.ctx.GoCtx(), e.dagPB, kvRanges, distSQLScanConcurrency(e.ctx, e.priority), e.keepOrder, e.desc, getIsolationLevel(e.ctx.GetSessionVars()), e.priority, allowPartialResult(e.ctx))
 	if err != nil {
 		return errors.Trace(err)
 	}
+	e.result.SetFields(resultFieldTypes(e.schema, e.handleCol), e.ctx.GetSessionVars().GetTimeZone())
 	e.result.Fetch(goCtx)
 	return nil
 }
@@ -284,7 +322,7 @@ type IndexLookUpExecutor struct {
 	tableID   int64
 	keepOrder bool
 	desc      bool
-	ranges    []*types.IndexRange
+	ranges    []*types.Range
 	dagPB     *tipb.DAGRequest
 	ctx       context.Context
 	schema    *expression.Schema
@@ -297,6 +335,10 @@ type IndexLookUpExecutor struct {
 	taskChan chan *lookupTableTask
 	tasksErr error
 	taskCurr *lookupTableTask
+	// cancel stops fetching the handles and executing the tasks when the executor is closed.
+	cancel goctx.CancelFunc
+	// handleLimit is the max number of the handles to fetch, 0 means no limit.
+	handleLimit uint64
 
 	tableRequest *tipb.DAGRequest
 	// columns are only required by union scan.
@@ -310,37 +352,40 @@ func (e *IndexLookUpExecutor) Open() error {
 	for i, v := range e.index.Columns {
 		fieldTypes[i] = &(e.table.Cols()[v.Offset].FieldType)
 	}
-	kvRanges, err := indexRangesToKVRanges(e.ctx.GetSessionVars().StmtCtx, e.tableID, e.index.ID, e.ranges, fieldTypes)
+	kvRanges, err := indexRangesToKVRanges(e.ctx.GetSessionVars().StmtCtx, e.tableID, e.index, e.ranges, fieldTypes)
 	if err != nil {
 		return errors.Trace(err)
 	}
-	e.result, err = distsql.SelectDAG(e.ctx.GetClient(), e.ctx.GoCtx(), e.dagPB, kvRanges, e.ctx.GetSessionVars().DistSQLScanConcurrency, e.keepOrder, e.desc, getIsolationLevel(e.ctx.GetSessionVars()), e.priority)
+	e.result, err = distsql.SelectDAG(e.ctx.GetClient(), e.ctx.GoCtx(), e.dagPB, kvRanges, distSQLScanConcurrency(e.ctx, e.priority), e.keepOrder, e.desc, getIsolationLevel(e.ctx.GetSessionVars()), e.priority, allowPartialResult(e.ctx))
 	if err != nil {
 		return errors.Trace(err)
 	}
-	e.result.Fetch(e.ctx.GoCtx())
+	goCtx, cancel := goctx.WithCancel(e.ctx.GoCtx())
+	e.cancel = cancel
+	e.result.Fetch(goCtx)
 
 	// Use a background goroutine to fetch index and put the result in e.taskChan.
 	// e.taskChan serves as a pipeline, so fetching index and getting table data can
 	// run concurrently.
 	e.taskChan = make(chan *lookupTableTask, atomic.LoadInt32(&LookupTableTaskChannelSize))
-	go e.fetchHandlesAndStartWorkers()
+	go e.fetchHandlesAndStartWorkers(goCtx)
 	return nil
 }
 
 // doRequestForDatums constructs kv ranges by datums. It is used by index look up executor.
 func (e *IndexLookUpExecutor) doRequestForDatums(values [][]types.Datum, goCtx goctx.Context) error {
-	kvRanges, err := indexValuesToKVRanges(e.tableID, e.index.ID, values)
+	kvRanges, err := indexValuesToKVRanges(e.tableID, e.index, values)
 	if err != nil {
 		return errors.Trace(err)
 	}
-	e.result, err = distsql.SelectDAG(e.ctx.GetClient(), e.ctx.GoCtx(), e.dagPB, kvRanges, e.ctx.GetSessionVars().DistSQLScanConcurrency, e.keepOrder, e.desc, getIsolationLevel(e.ctx.GetSessionVars()), e.priority)
+	e.result, err = distsql.SelectDAG(e.ctx.GetClient(), e.ctx.GoCtx(), e.dagPB, kvRanges, distSQLScanConcurrency(e.ctx, e.priority), e.keepOrder, e.desc, getIsolationLevel(e.ctx.GetSessionVars()), e.priority, allowPartialResult(e.ctx))
 	if err != nil {
 		return errors.Trace(err)
 	}
+	goCtx, e.cancel = goctx.WithCancel(goCtx)
 	e.result.Fetch(goCtx)
 	e.taskChan = make(chan *lookupTableTask, atomic.LoadInt32(&LookupTableTaskChannelSize))
-	go e.fetchHandlesAndStartWorkers()
+	go e.fetchHandlesAndStartWorkers(goCtx)
 	return nil
 }
 
@@ -415,7 +460,8 @@ func (e *IndexLookUpExecutor) pickAndExecTask(workCh <-chan *lookupTableTask, tx
 
 // fetchHandlesAndStartWorkers fetches a batch of handles from index data and builds the index lookup tasks.
 // We initialize some workers to execute this tasks concurrently and put the task to taskCh by order.
-func (e *IndexLookUpExecutor) fetchHandlesAndStartWorkers() {
+// It stops when the handleLimit handles are fetched or the txnCtx is canceled.
+func (e *IndexLookUpExecutor) fetchHandlesAndStartWorkers(txnCtx goctx.Context) {
 	// The tasks in workCh will be consumed by workers. When all workers are busy, we should stop to push tasks to channel.
 	// So its length is one.
 	workCh := make(chan *lookupTableTask, 1)
@@ -425,17 +471,25 @@ func (e *IndexLookUpExecutor) fetchHandlesAndStartWorkers() {
 	}()
 
 	lookupConcurrencyLimit := e.ctx.GetSessionVars().IndexLookupConcurrency
-	txnCtx := e.ctx.GoCtx()
 	for i := 0; i < lookupConcurrencyLimit; i++ {
 		go e.pickAndExecTask(workCh, txnCtx)
 	}
 
+	var fetched uint64
 	for {
 		handles, finish, err := extractHandlesFromIndexResult(e.result)
+		if finish {
+			appendSkippedRangesWarning(e.ctx, e.result)
+		}
 		if err != nil || finish {
 			e.tasksErr = errors.Trace(err)
 			return
 		}
+		if e.handleLimit > 0 && fetched+uint64(len(handles)) >= e.handleLimit {
+			handles = handles[:e.handleLimit-fetched]
+			finish = true
+		}
+		fetched += uint64(len(handles))
 		tasks := e.buildTableTasks(handles)
 		for _, task := range tasks {
 			select {
@@ -443,7 +497,14 @@ func (e *IndexLookUpExecutor) fetchHandlesAndStartWorkers() {
 				return
 			case workCh <- task:
 			}
-			e.taskChan <- task
+			select {
+			case <-txnCtx.Done():
+				return
+			case e.taskChan <- task:
+			}
+		}
+		if finish {
+			return
 		}
 	}
 }
@@ -494,8 +555,8 @@ func (e *IndexLookUpExecutor) Close() error {
 	if e.taskChan == nil {
 		return nil
 	}
-	// TODO: It's better to notify fetchHandles to close instead of fetching all index handle.
-	// Consume the task channel in case channel is full.
+	// Notify fetchHandles to stop, then consume the task channel in case channel is full.
+	e.cancel()
 	for range e.taskChan {
 	}
 	e.taskChan = nil
@@ -0,0 +1,197 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/plan"
+	"github.com/pingcap/tidb/policy"
+	"github.com/pingcap/tidb/sessionctx"
+	"github.com/pingcap/tidb/table"
+	"github.com/pingcap/tidb/util/sqlexec"
+)
+
+func (e *SimpleExec) executeCreatePolicy(s *ast.CreatePolicyStmt) error {
+	db := policyDB(e.ctx, s.Table)
+	tbl, err := GetInfoSchema(e.ctx).TableByName(db, s.Table.Name)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	// The stored text is checked, it's parsed again when the table is planned.
+	predicate := strings.TrimSpace(s.Predicate.Text())
+	expr, err := policy.ParsePredicate(predicate)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err = plan.CheckPolicyPredicate(e.ctx, expr, tbl.Meta()); err != nil {
+		return errors.Trace(err)
+	}
+	exists, err := policyExists(e.ctx, db.L, s.Table.Name.L, s.Name.L)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if exists {
+		if s.IfNotExists {
+			return nil
+		}
+		return ErrPolicyExists.GenByArgs(s.Name.O, db.O, s.Table.Name.O)
+	}
+	sql := fmt.Sprintf(`INSERT INTO %s.%s VALUES ("%s", "%s", "%s", "%s", NOW());`, mysql.SystemDB, mysql.RowPolicyTable,
+		escapeSQLString(db.L), escapeSQLString(s.Table.Name.L), escapeSQLString(s.Name.L), escapeSQLString(predicate))
+	_, _, err = e.ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(e.ctx, sql)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(e.reloadPolicies())
+}
+
+func (e *SimpleExec) executeDropPolicy(s *ast.DropPolicyStmt) error {
+	// The policies of a dropped table can be dropped, so the table isn't checked.
+	db := policyDB(e.ctx, s.Table)
+	exists, err := policyExists(e.ctx, db.L, s.Table.Name.L, s.Name.L)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if !exists {
+		if s.IfExists {
+			return nil
+		}
+		return ErrPolicyNotExists.GenByArgs(s.Name.O, db.O, s.Table.Name.O)
+	}
+	sql := fmt.Sprintf(`DELETE FROM %s.%s WHERE db = "%s" AND table_name = "%s" AND name = "%s";`, mysql.SystemDB,
+		mysql.RowPolicyTable, escapeSQLString(db.L), escapeSQLString(s.Table.Name.L), escapeSQLString(s.Name.L))
+	_, _, err = e.ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(e.ctx, sql)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(e.reloadPolicies())
+}
+
+// reloadPolicies reloads the policies of this server at once, and notifies the other servers to
+// reload them.
+func (e *SimpleExec) reloadPolicies() error {
+	dom := sessionctx.GetDomain(e.ctx)
+	sysSessionPool := dom.SysSessionPool()
+	ctx, err := sysSessionPool.Get()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer sysSessionPool.Put(ctx)
+	err = dom.PolicyHandle().Update(ctx.(context.Context))
+	dom.NotifyUpdatePolicy(e.ctx)
+	return errors.Trace(err)
+}
+
+// policyDB returns the database of the table of a policy, it's the current database if it isn't specified.
+func policyDB(ctx context.Context, tn *ast.TableName) model.CIStr {
+	if tn.Schema.L != "" {
+		return tn.Schema
+	}
+	return model.NewCIStr(ctx.GetSessionVars().CurrentDB)
+}
+
+func policyExists(ctx context.Context, db, table, name string) (bool, error) {
+	sql := fmt.Sprintf(`SELECT name FROM %s.%s WHERE db = "%s" AND table_name = "%s" AND name = "%s";`,
+		mysql.SystemDB, mysql.RowPolicyTable, escapeSQLString(db), escapeSQLString(table), escapeSQLString(name))
+	rows, _, err := ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(ctx, sql)
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	return len(rows) > 0, nil
+}
+
+func (e *SimpleExec) executeCreateMaskingPolicy(s *ast.CreateMaskingPolicyStmt) error {
+	db := policyDB(e.ctx, s.Table)
+	tbl, err := GetInfoSchema(e.ctx).TableByName(db, s.Table.Name)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if table.FindCol(tbl.Cols(), s.Column.L) == nil {
+		return plan.ErrUnknownColumn.GenByArgs(s.Column.O, "policy")
+	}
+	// The stored text is checked, it's parsed again when the table is planned.
+	maskExpr := strings.TrimSpace(s.MaskExpr.Text())
+	expr, err := policy.ParsePredicate(maskExpr)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err = plan.CheckMaskExpr(e.ctx, expr, tbl.Meta()); err != nil {
+		return errors.Trace(err)
+	}
+	exists, err := maskExists(e.ctx, db.L, s.Table.Name.L, "name", s.Name.L)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if exists {
+		if s.IfNotExists {
+			return nil
+		}
+		return ErrMaskExists.GenByArgs(s.Name.O, db.O, s.Table.Name.O)
+	}
+	exists, err = maskExists(e.ctx, db.L, s.Table.Name.L, "column_name", s.Column.L)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if exists {
+		return ErrColumnMasked.GenByArgs(s.Column.O, db.O, s.Table.Name.O)
+	}
+	sql := fmt.Sprintf(`INSERT INTO %s.%s VALUES ("%s", "%s", "%s", "%s", "%s", NOW());`, mysql.SystemDB,
+		mysql.ColumnMaskTable, escapeSQLString(db.L), escapeSQLString(s.Table.Name.L), escapeSQLString(s.Column.L),
+		escapeSQLString(s.Name.L), escapeSQLString(maskExpr))
+	_, _, err = e.ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(e.ctx, sql)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(e.reloadPolicies())
+}
+
+func (e *SimpleExec) executeDropMaskingPolicy(s *ast.DropMaskingPolicyStmt) error {
+	db := policyDB(e.ctx, s.Table)
+	exists, err := maskExists(e.ctx, db.L, s.Table.Name.L, "name", s.Name.L)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if !exists {
+		if s.IfExists {
+			return nil
+		}
+		return ErrMaskNotExists.GenByArgs(s.Name.O, db.O, s.Table.Name.O)
+	}
+	sql := fmt.Sprintf(`DELETE FROM %s.%s WHERE db = "%s" AND table_name = "%s" AND name = "%s";`, mysql.SystemDB,
+		mysql.ColumnMaskTable, escapeSQLString(db.L), escapeSQLString(s.Table.Name.L), escapeSQLString(s.Name.L))
+	_, _, err = e.ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(e.ctx, sql)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(e.reloadPolicies())
+}
+
+// maskExists returns whether the table has a masking policy whose field is the value.
+func maskExists(ctx context.Context, db, table, field, value string) (bool, error) {
+	sql := fmt.Sprintf(`SELECT name FROM %s.%s WHERE db = "%s" AND table_name = "%s" AND %s = "%s";`,
+		mysql.SystemDB, mysql.ColumnMaskTable, escapeSQLString(db), escapeSQLString(table), field,
+		escapeSQLString(value))
+	rows, _, err := ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(ctx, sql)
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	return len(rows) > 0, nil
+}
@@ -18,15 +18,18 @@ import (
 	"sort"
 
 	"github.com/juju/errors"
+	"github.com/ngaut/log"
 	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/infoschema"
 	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/parser"
 	"github.com/pingcap/tidb/plan"
 	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/util/sqlexec"
+	"github.com/pingcap/tidb/util/types"
 )
 
 var (
@@ -71,6 +74,10 @@ type Prepared struct {
 	Stmt          ast.StmtNode
 	Params        []*ast.ParamMarkerExpr
 	SchemaVersion int64
+	// SQLText and DB are the statement text and the current database when it's prepared, they're exported
+	// with the session states.
+	SQLText string
+	DB      string
 }
 
 // PrepareExec represents a PREPARE executor.
@@ -84,6 +91,8 @@ type PrepareExec struct {
 	ParamCount int
 	Err        error
 	Fields     []*ast.ResultField
+	// ParamTypes are the types of the parameter markers inferred at prepare time.
+	ParamTypes []*types.FieldType
 }
 
 // Schema implements the Executor Schema interface.
@@ -164,6 +173,8 @@ func (e *PrepareExec) DoPrepare() {
 		Stmt:          stmt,
 		Params:        sorter.markers,
 		SchemaVersion: e.IS.SchemaMetaVersion(),
+		SQLText:       e.SQLText,
+		DB:            vars.CurrentDB,
 	}
 
 	err = plan.PrepareStmt(e.IS, e.Ctx, stmt)
@@ -171,16 +182,39 @@ func (e *PrepareExec) DoPrepare() {
 		e.Err = errors.Trace(err)
 		return
 	}
+	e.inferTypes(stmt, sorter.markers)
 
 	if e.ID == 0 {
 		e.ID = vars.GetNextPreparedStmtID()
 	}
 	if e.Name != "" {
 		vars.PreparedStmtNameToID[e.Name] = e.ID
+		vars.TrackStateChange()
 	}
 	vars.PreparedStmts[e.ID] = prepared
 }
 
+// inferTypes infers the types of the result columns and the parameter markers of the DML statements without
+// executing them, some drivers check them strictly. The result fields of the other statements are resolved by
+// the preprocessor, their parameter markers are taken as binary strings, so are the ones of a statement whose
+// types can't be inferred.
+func (e *PrepareExec) inferTypes(stmt ast.StmtNode, params []*ast.ParamMarkerExpr) {
+	switch stmt.(type) {
+	case *ast.SelectStmt, *ast.UnionStmt, *ast.InsertStmt, *ast.UpdateStmt, *ast.DeleteStmt:
+		schema, paramTypes, err := plan.InferPrepareTypes(e.Ctx, stmt, e.IS, params)
+		if err == nil {
+			e.Fields = schemaResultFields(schema)
+			e.ParamTypes = paramTypes
+			return
+		}
+		log.Debugf("[%d] infer the types of the prepared statement error: %v", e.Ctx.GetSessionVars().ConnectionID, err)
+	}
+	e.ParamTypes = make([]*types.FieldType, len(params))
+	for i := range e.ParamTypes {
+		e.ParamTypes[i] = plan.NewUnknownParamType()
+	}
+}
+
 // ExecuteExec represents an EXECUTE executor.
 // It cannot be executed by itself, all it needs to do is to build
 // another Executor from a prepared statement.
@@ -193,6 +227,8 @@ type ExecuteExec struct {
 	StmtExec  Executor
 	Stmt      ast.StmtNode
 	Plan      plan.Plan
+
+	stmtLabel string
 }
 
 // Schema implements the Executor Schema interface.
@@ -272,7 +308,11 @@ func (e *ExecuteExec) Build() error {
 	e.Stmt = prepared.Stmt
 	e.Plan = p
 	ResetStmtCtx(e.Ctx, e.Stmt)
-	stmtCount(e.Stmt, e.Plan, e.Ctx.GetSessionVars().InRestrictedSQL)
+	var isExpensive bool
+	e.stmtLabel, isExpensive = stmtCount(e.Stmt, e.Plan, e.Ctx.GetSessionVars().InRestrictedSQL)
+	if isExpensive {
+		logExpensiveStmt(e.Ctx, e.Stmt.Text())
+	}
 	return nil
 }
 
@@ -297,6 +337,7 @@ func (e *DeallocateExec) Next() (Row, error) {
 	}
 	delete(vars.PreparedStmtNameToID, e.Name)
 	delete(vars.PreparedStmts, id)
+	vars.TrackStateChange()
 	return nil, nil
 }
 
@@ -336,15 +377,27 @@ func ResetStmtCtx(ctx context.Context, s ast.StmtNode) {
 	sc.TimeZone = sessVars.GetTimeZone()
 
 	switch stmt := s.(type) {
-	case *ast.UpdateStmt, *ast.DeleteStmt:
+	case *ast.UpdateStmt:
 		sc.IgnoreTruncate = false
 		sc.OverflowAsWarning = false
 		sc.TruncateAsWarning = !sessVars.StrictSQLMode
 		sc.InUpdateOrDeleteStmt = true
+		if stmt.LowPriority {
+			sc.Priority = mysql.LowPriority
+		}
+	case *ast.DeleteStmt:
+		sc.IgnoreTruncate = false
+		sc.OverflowAsWarning = false
+		sc.TruncateAsWarning = !sessVars.StrictSQLMode
+		sc.InUpdateOrDeleteStmt = true
+		if stmt.LowPriority {
+			sc.Priority = mysql.LowPriority
+		}
 	case *ast.InsertStmt:
 		sc.IgnoreTruncate = false
 		sc.TruncateAsWarning = !sessVars.StrictSQLMode
 		sc.InInsertStmt = true
+		sc.Priority = stmt.Priority
 	case *ast.CreateTableStmt, *ast.AlterTableStmt:
 		// Make sure the sql_mode is strict when checking column default value.
 		sc.IgnoreTruncate = false
@@ -372,13 +425,32 @@ func ResetStmtCtx(ctx context.Context, s ast.StmtNode) {
 	default:
 		sc.IgnoreTruncate = true
 		sc.OverflowAsWarning = false
-		if show, ok := s.(*ast.ShowStmt); ok {
-			if show.Tp == ast.ShowWarnings {
+		switch x := s.(type) {
+		case *ast.ShowStmt:
+			if x.Tp == ast.ShowWarnings || x.Tp == ast.ShowErrors {
 				sc.InShowWarning = true
 				sc.SetWarnings(sessVars.StmtCtx.GetWarnings())
 			}
+		case *ast.ExecuteStmt:
+			sc.InExecuteStmt = true
+		}
+	}
+	// The statement context of EXECUTE is reset again for the prepared statement, the previous statement
+	// is the one before EXECUTE.
+	if prevSC := sessVars.StmtCtx; !prevSC.InExecuteStmt {
+		switch {
+		case prevSC.InInsertStmt, prevSC.InUpdateOrDeleteStmt:
+			sessVars.PrevAffectedRows = int64(prevSC.AffectedRows())
+		case prevSC.InSelectStmt:
+			sessVars.PrevAffectedRows = -1
+		default:
+			sessVars.PrevAffectedRows = 0
 		}
 	}
+	if sc.Priority == mysql.NoPriority {
+		sc.Priority = sessVars.ForcePriority
+	}
+	sc.IgnoreNotes = !sessVars.SQLNotes
 	if sessVars.LastInsertID > 0 {
 		sessVars.PrevLastInsertID = sessVars.LastInsertID
 		sessVars.LastInsertID = 0
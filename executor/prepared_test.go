@@ -15,8 +15,12 @@ package executor_test
 
 import (
 	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/executor"
+	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/plan"
+	"github.com/pingcap/tidb/util/charset"
 	"github.com/pingcap/tidb/util/testkit"
 	"github.com/pingcap/tidb/util/testleak"
 )
@@ -119,3 +123,111 @@ func (s *testSuite) TestPreparedLimitOffset(c *C) {
 	_, err = tk.Se.ExecutePreparedStmt(stmtID, 1)
 	c.Assert(err, IsNil)
 }
+
+func (s *testSuite) TestPreparedRange(c *C) {
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists prepare_test")
+	tk.MustExec("create table prepare_test (id int primary key, a int, index ia(a))")
+	tk.MustExec("insert prepare_test values (1, null), (2, 2), (3, 3)")
+
+	// The ranges are built from the values of the parameter markers when the statement is executed.
+	tests := []struct {
+		sql    string
+		params []interface{}
+		plan   string
+		result []string
+	}{
+		{"select * from prepare_test where id > ?", []interface{}{1}, "TableReader(Table(prepare_test))", []string{"2 2", "3 3"}},
+		{"select * from prepare_test where a > ?", []interface{}{"2"}, "IndexReader(Index(prepare_test.ia)[(2,+inf]])", []string{"3 3"}},
+		{"select * from prepare_test where a in (?, ?)", []interface{}{"2", 3}, "IndexReader(Index(prepare_test.ia)[[2,2] [3,3]])", []string{"2 2", "3 3"}},
+		{"select * from prepare_test where a in (?, ?)", []interface{}{nil, 2}, "IndexReader(Index(prepare_test.ia)[[2,2]])", []string{"2 2"}},
+		{"select * from prepare_test where a in (?, ?)", []interface{}{uint64(2), -1}, "IndexReader(Index(prepare_test.ia)[[-1,-1] [2,2]])", []string{"2 2"}},
+	}
+	buildPlan := func(stmtID uint32, params ...interface{}) string {
+		tk.MustExec("begin")
+		defer tk.MustExec("rollback")
+		exec := &executor.ExecuteExec{IS: executor.GetInfoSchema(tk.Se), Ctx: tk.Se, ID: stmtID}
+		for _, param := range params {
+			value := ast.NewValueExpr(param)
+			exec.UsingVars = append(exec.UsingVars, &expression.Constant{Value: value.Datum, RetType: &value.Type})
+		}
+		c.Assert(exec.Build(), IsNil)
+		return plan.ToString(exec.Plan)
+	}
+	for _, tt := range tests {
+		stmtID, _, _, err := tk.Se.PrepareStmt(tt.sql)
+		c.Assert(err, IsNil)
+		c.Assert(buildPlan(stmtID, tt.params...), Equals, tt.plan, Commentf("for %s", tt.sql))
+		tk.MustQuery(tt.sql, tt.params...).Check(testkit.Rows(tt.result...))
+	}
+
+	// The type of the last value isn't kept, "-1" isn't converted to an unsigned value.
+	stmtID, _, _, err := tk.Se.PrepareStmt("select * from prepare_test where a > ?")
+	c.Assert(err, IsNil)
+	c.Assert(buildPlan(stmtID, uint64(2)), Equals, "IndexReader(Index(prepare_test.ia)[(2,+inf]])")
+	c.Assert(buildPlan(stmtID, -1), Equals, "IndexReader(Index(prepare_test.ia)[(-1,+inf]])")
+}
+
+func (s *testSuite) TestPreparedTypes(c *C) {
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists prepare_test")
+	tk.MustExec("create table prepare_test (id int primary key, a varchar(20), b decimal(10, 2), c datetime)")
+	tk.MustExec("insert prepare_test values (1, 'x', 1.5, '2017-01-01')")
+
+	// The types of the parameter markers are inferred from the expressions and the columns.
+	tests := []struct {
+		sql    string
+		params []byte
+		fields []byte
+	}{
+		{"select id, a, b + ? from prepare_test where c > ? and a like ?", []byte{mysql.TypeNewDecimal, mysql.TypeDatetime, mysql.TypeVarchar},
+			[]byte{mysql.TypeLong, mysql.TypeVarchar, mysql.TypeNewDecimal}},
+		{"select count(*), ? from prepare_test where id in (?, ?) limit ?", []byte{mysql.TypeVarString, mysql.TypeLong, mysql.TypeLong, mysql.TypeLonglong},
+			[]byte{mysql.TypeLonglong, mysql.TypeNull}},
+		{"select id from prepare_test where ? between b and id and a = (select a from prepare_test where id = ?)",
+			[]byte{mysql.TypeNewDecimal, mysql.TypeLong}, []byte{mysql.TypeLong}},
+		{"insert prepare_test (b, id) values (?, ?) on duplicate key update c = ?", []byte{mysql.TypeNewDecimal, mysql.TypeLong, mysql.TypeDatetime}, nil},
+		{"update prepare_test set a = ? where id = ?", []byte{mysql.TypeVarchar, mysql.TypeLong}, nil},
+		{"delete from prepare_test where b < ?", []byte{mysql.TypeNewDecimal}, nil},
+		{"show tables like ?", []byte{mysql.TypeVarString}, nil},
+	}
+	for _, tt := range tests {
+		comment := Commentf("for %s", tt.sql)
+		_, paramTypes, fields, err := tk.Se.PrepareStmt(tt.sql)
+		c.Assert(err, IsNil, comment)
+		c.Assert(paramTypes, HasLen, len(tt.params), comment)
+		for i, tp := range paramTypes {
+			c.Assert(tp.Tp, Equals, tt.params[i], comment)
+		}
+		if tt.fields == nil {
+			continue
+		}
+		c.Assert(fields, HasLen, len(tt.fields), comment)
+		for i, field := range fields {
+			c.Assert(field.Column.Tp, Equals, tt.fields[i], comment)
+		}
+	}
+
+	// The unknown types are binary strings, the types of the columns are kept.
+	_, paramTypes, fields, err := tk.Se.PrepareStmt("select b, ? from prepare_test where id = ?")
+	c.Assert(err, IsNil)
+	c.Assert(paramTypes[0].Charset, Equals, charset.CharsetBin)
+	c.Assert(mysql.HasBinaryFlag(paramTypes[0].Flag), IsTrue)
+	c.Assert(fields[0].Column.Flen, Equals, 10)
+	c.Assert(fields[0].Column.Decimal, Equals, 2)
+	c.Assert(fields[0].ColumnAsName.O, Equals, "b")
+
+	// Nothing is evaluated at prepare time, the parameter markers still take the values at execution.
+	tk.MustQuery("select id, a, b + ? from prepare_test where c > ? and a like ?", 1, "2016-01-01", "x%").Check(testkit.Rows("1 x 2.50"))
+	tk.MustQuery("select id from prepare_test limit ?", 1).Check(testkit.Rows("1"))
+}
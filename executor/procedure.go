@@ -0,0 +1,188 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/infoschema"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/plan"
+	"github.com/pingcap/tidb/util/sqlexec"
+)
+
+// ProcedureName returns the database and the name of the procedure in lower case, the database is
+// the current database if it's not specified.
+func ProcedureName(ctx context.Context, tn *ast.TableName) (db string, name string, err error) {
+	db = tn.Schema.L
+	if db == "" {
+		db = strings.ToLower(ctx.GetSessionVars().CurrentDB)
+	}
+	if db == "" {
+		return "", "", errors.Trace(plan.ErrNoDB)
+	}
+	return db, tn.Name.L, nil
+}
+
+// LoadProcedure loads the CREATE PROCEDURE statement of the procedure from the mysql.proc table.
+func LoadProcedure(ctx context.Context, db, name string) (string, error) {
+	sql := fmt.Sprintf(`SELECT body FROM %s.%s WHERE db = "%s" AND name = "%s";`, mysql.SystemDB, mysql.ProcTable,
+		escapeSQLString(db), escapeSQLString(name))
+	rows, _, err := ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(ctx, sql)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	if len(rows) == 0 {
+		return "", ErrSPDoesNotExist.GenByArgs("PROCEDURE", db+"."+name)
+	}
+	return rows[0].Data[0].GetString(), nil
+}
+
+func (e *SimpleExec) executeCreateProcedure(s *ast.CreateProcedureStmt) error {
+	db, name, err := ProcedureName(e.ctx, s.Name)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if _, ok := GetInfoSchema(e.ctx).SchemaByName(model.NewCIStr(db)); !ok {
+		return infoschema.ErrDatabaseNotExists.GenByArgs(db)
+	}
+	if err = checkProcedure(s); err != nil {
+		return errors.Trace(err)
+	}
+	_, err = LoadProcedure(e.ctx, db, name)
+	if err == nil {
+		if s.IfNotExists {
+			return nil
+		}
+		return ErrSPAlreadyExists.GenByArgs("PROCEDURE", s.Name.Name.O)
+	}
+	if !ErrSPDoesNotExist.Equal(err) {
+		return errors.Trace(err)
+	}
+	// The body is parsed again from the statement when the procedure is called.
+	sql := fmt.Sprintf(`INSERT INTO %s.%s VALUES ("%s", "%s", "%s");`, mysql.SystemDB, mysql.ProcTable,
+		escapeSQLString(db), escapeSQLString(name), escapeSQLString(s.Text()))
+	_, _, err = e.ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(e.ctx, sql)
+	return errors.Trace(err)
+}
+
+func (e *SimpleExec) executeDropProcedure(s *ast.DropProcedureStmt) error {
+	db, name, err := ProcedureName(e.ctx, s.Name)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	_, err = LoadProcedure(e.ctx, db, name)
+	if err != nil {
+		if s.IfExists && ErrSPDoesNotExist.Equal(err) {
+			return nil
+		}
+		return errors.Trace(err)
+	}
+	sql := fmt.Sprintf(`DELETE FROM %s.%s WHERE db = "%s" AND name = "%s";`, mysql.SystemDB, mysql.ProcTable,
+		escapeSQLString(db), escapeSQLString(name))
+	_, _, err = e.ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(e.ctx, sql)
+	return errors.Trace(err)
+}
+
+// dropProcedures drops all the procedures of the database.
+func dropProcedures(ctx context.Context, db string) error {
+	sql := fmt.Sprintf(`DELETE FROM %s.%s WHERE db = "%s";`, mysql.SystemDB, mysql.ProcTable, escapeSQLString(db))
+	_, _, err := ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(ctx, sql)
+	return errors.Trace(err)
+}
+
+// checkProcedure checks the parameters, the variables and the labels of the procedure, so a call
+// doesn't fail on them.
+func checkProcedure(s *ast.CreateProcedureStmt) error {
+	params := make(map[string]struct{}, len(s.Params))
+	for _, p := range s.Params {
+		name := strings.ToLower(p.Name)
+		if _, ok := params[name]; ok {
+			return ErrSPDupParam.GenByArgs(p.Name)
+		}
+		params[name] = struct{}{}
+	}
+	return errors.Trace(checkProcStmts([]ast.StmtNode{s.Body}, nil))
+}
+
+// procLabel is a label of a block or a loop in a procedure.
+type procLabel struct {
+	name string
+	loop bool
+}
+
+// checkProcStmts checks a statement list, the labels are the labels of the enclosing blocks and
+// loops from the outermost to the innermost.
+func checkProcStmts(stmts []ast.StmtNode, labels []procLabel) error {
+	vars := make(map[string]struct{})
+	for _, stmt := range stmts {
+		var err error
+		switch x := stmt.(type) {
+		case *ast.ProcBlockStmt:
+			err = checkProcStmts(x.Stmts, pushProcLabel(labels, x.Label, false))
+		case *ast.ProcDeclareStmt:
+			for _, name := range x.Names {
+				lower := strings.ToLower(name)
+				if _, ok := vars[lower]; ok {
+					return ErrSPDupVar.GenByArgs(name)
+				}
+				vars[lower] = struct{}{}
+			}
+		case *ast.ProcIfStmt:
+			err = checkProcStmts(x.Then, labels)
+			if err == nil {
+				err = checkProcStmts(x.Else, labels)
+			}
+		case *ast.ProcWhileStmt:
+			err = checkProcStmts(x.Body, pushProcLabel(labels, x.Label, true))
+		case *ast.ProcLoopStmt:
+			err = checkProcStmts(x.Body, pushProcLabel(labels, x.Label, true))
+		case *ast.ProcLeaveStmt:
+			if _, ok := findProcLabel(labels, x.Label); !ok {
+				return ErrSPLabelMismatch.GenByArgs("LEAVE", x.Label)
+			}
+		case *ast.ProcIterateStmt:
+			// ITERATE can only appear within a loop.
+			if label, ok := findProcLabel(labels, x.Label); !ok || !label.loop {
+				return ErrSPLabelMismatch.GenByArgs("ITERATE", x.Label)
+			}
+		}
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+func pushProcLabel(labels []procLabel, name string, loop bool) []procLabel {
+	if name == "" {
+		return labels
+	}
+	return append(labels[:len(labels):len(labels)], procLabel{name: name, loop: loop})
+}
+
+// findProcLabel finds the innermost label with the name, the labels are case insensitive.
+func findProcLabel(labels []procLabel, name string) (procLabel, bool) {
+	for i := len(labels) - 1; i >= 0; i-- {
+		if strings.EqualFold(labels[i].name, name) {
+			return labels[i], true
+		}
+	}
+	return procLabel{}, false
+}
@@ -0,0 +1,187 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// The parallel projection evaluates the expressions of the rows by several workers, so the heavy functions like
+// regexp and the encryption functions don't make the projection a single-core bottleneck:
+//
+//  1. The fetcher reads the rows of the child in batches. A batch is a task, it's sent to the output channel
+//     first, so the tasks are kept in the order of the rows, then it's sent to the task channel.
+//  2. The workers take the tasks from the task channel, every worker evaluates its own copies of the expressions,
+//     because the functions keep the buffers of their arguments.
+//  3. Next reads the tasks from the output channel in order, and waits for the result of every task.
+//
+// The output channel is bounded by the concurrency, so at most concurrency batches are read ahead.
+
+// projectionTask is a batch of the rows of the child, the result is the evaluated rows.
+type projectionTask struct {
+	rows   []Row
+	result chan *execResult
+}
+
+// canEvalConcurrently checks whether the expressions are worth evaluating by the workers and the results don't
+// depend on the order of the evaluation, e.g. "@a := @a + 1" can't be evaluated concurrently.
+func canEvalConcurrently(exprs []expression.Expression) bool {
+	hasFunc := false
+	for _, expr := range exprs {
+		if !expression.IsDeterministic(expr) {
+			return false
+		}
+		if _, ok := expr.(*expression.ScalarFunction); ok {
+			hasFunc = true
+		}
+	}
+	return hasFunc
+}
+
+// Open implements the Executor Open interface.
+func (e *ProjectionExec) Open() error {
+	e.prepared = false
+	e.rows = nil
+	e.cursor = 0
+	return errors.Trace(e.baseExecutor.Open())
+}
+
+// Close implements the Executor Close interface.
+func (e *ProjectionExec) Close() error {
+	if e.prepared {
+		close(e.closeCh)
+		e.wg.Wait()
+		e.prepared = false
+	}
+	e.rows = nil
+	return errors.Trace(e.baseExecutor.Close())
+}
+
+func (e *ProjectionExec) prepare() {
+	e.prepared = true
+	e.taskCh = make(chan *projectionTask, e.concurrency)
+	e.outputCh = make(chan *projectionTask, e.concurrency)
+	e.closeCh = make(chan struct{})
+	e.wg.Add(e.concurrency + 1)
+	go e.fetchRows()
+	for i := 0; i < e.concurrency; i++ {
+		exprs := make([]expression.Expression, 0, len(e.exprs))
+		for _, expr := range e.exprs {
+			exprs = append(exprs, expr.Clone())
+		}
+		go e.runWorker(exprs)
+	}
+}
+
+// fetchRows reads the rows of the child and makes the tasks.
+func (e *ProjectionExec) fetchRows() {
+	defer func() {
+		close(e.taskCh)
+		close(e.outputCh)
+		e.wg.Done()
+	}()
+	for {
+		task := &projectionTask{
+			rows:   make([]Row, 0, batchSize),
+			result: make(chan *execResult, 1),
+		}
+		for len(task.rows) < batchSize {
+			row, err := e.children[0].Next()
+			if err != nil {
+				task.result <- &execResult{err: errors.Trace(err)}
+				select {
+				case e.outputCh <- task:
+				case <-e.closeCh:
+				}
+				return
+			}
+			if row == nil {
+				break
+			}
+			task.rows = append(task.rows, row)
+		}
+		if len(task.rows) == 0 {
+			return
+		}
+		select {
+		case e.outputCh <- task:
+		case <-e.closeCh:
+			return
+		}
+		select {
+		case e.taskCh <- task:
+		case <-e.closeCh:
+			return
+		}
+		if len(task.rows) < batchSize {
+			return
+		}
+	}
+}
+
+func (e *ProjectionExec) runWorker(exprs []expression.Expression) {
+	defer e.wg.Done()
+	for {
+		select {
+		case task, ok := <-e.taskCh:
+			if !ok {
+				return
+			}
+			task.result <- evalProjection(exprs, task.rows)
+		case <-e.closeCh:
+			return
+		}
+	}
+}
+
+func evalProjection(exprs []expression.Expression, srcRows []Row) *execResult {
+	result := &execResult{rows: make([]Row, 0, len(srcRows))}
+	slab := make([]types.Datum, len(srcRows)*len(exprs))
+	for _, srcRow := range srcRows {
+		row := slab[:len(exprs):len(exprs)]
+		slab = slab[len(exprs):]
+		for i, expr := range exprs {
+			var err error
+			row[i], err = expr.Eval(srcRow)
+			if err != nil {
+				return &execResult{err: errors.Trace(err)}
+			}
+		}
+		result.rows = append(result.rows, row)
+	}
+	return result
+}
+
+func (e *ProjectionExec) parallelNext() (Row, error) {
+	if !e.prepared {
+		e.prepare()
+	}
+	for e.cursor >= len(e.rows) {
+		task, ok := <-e.outputCh
+		if !ok {
+			return nil, nil
+		}
+		result := <-task.result
+		if result.err != nil {
+			return nil, errors.Trace(result.err)
+		}
+		e.rows = result.rows
+		e.cursor = 0
+	}
+	row := e.rows[e.cursor]
+	e.cursor++
+	return row, nil
+}
@@ -106,6 +106,9 @@ func (e *RevokeExec) revokeOneUser(user, host string) error {
 	}
 
 	for _, priv := range e.Privs {
+		if priv.Priv == mysql.UsagePriv {
+			continue
+		}
 		err := e.revokePriv(priv, user, host)
 		if err != nil {
 			return errors.Trace(err)
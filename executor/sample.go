@@ -0,0 +1,190 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/table"
+	"github.com/pingcap/tidb/tablecodec"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// TableSampleExec reads the sampled rows of a table for the TABLESAMPLE clause. REGIONS reads the first row of
+// every region of the table, BERNOULLI reads every row of the table with the probability of the percentage.
+// The rows written by the transaction are sampled too.
+type TableSampleExec struct {
+	baseExecutor
+
+	t       table.Table
+	columns []*model.ColumnInfo
+	sample  *ast.TableSample
+	startTS uint64
+
+	retriever kv.Retriever
+
+	// ranges are the record ranges of the regions for REGIONS, or the whole record range for BERNOULLI.
+	ranges []kv.KeyRange
+	cursor int
+	iter   kv.Iterator
+	rand   *rand.Rand
+}
+
+// Open implements the Executor Open interface.
+func (e *TableSampleExec) Open() error {
+	e.closeIter()
+	// The transaction of an auto-commit statement is committed before the rows are read, so the rows are read
+	// by the snapshot unless the transaction has written some rows.
+	txn := e.ctx.Txn()
+	if txn.IsReadOnly() || e.ctx.GetSessionVars().SnapshotTS != 0 {
+		snapshot, err := e.ctx.GetStore().GetSnapshot(kv.Version{Ver: e.startTS})
+		if err != nil {
+			return errors.Trace(err)
+		}
+		e.retriever = snapshot
+	} else {
+		e.retriever = txn
+	}
+	prefix := e.t.RecordPrefix()
+	r := kv.KeyRange{StartKey: prefix, EndKey: prefix.PrefixNext()}
+	e.ranges = []kv.KeyRange{r}
+	e.cursor = 0
+	e.rand = rand.New(rand.NewSource(time.Now().UnixNano()))
+	if e.sample.Method != ast.SampleRegions {
+		return nil
+	}
+	// The storage without the regions is taken as a single region.
+	getter, ok := e.ctx.GetStore().(kv.SplitKeysGetter)
+	if !ok {
+		return nil
+	}
+	keys, err := getter.GetSplitKeys(r)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	e.ranges = make([]kv.KeyRange, 0, len(keys)+1)
+	start := r.StartKey
+	for _, key := range keys {
+		e.ranges = append(e.ranges, kv.KeyRange{StartKey: start, EndKey: key})
+		start = key
+	}
+	e.ranges = append(e.ranges, kv.KeyRange{StartKey: start, EndKey: r.EndKey})
+	return nil
+}
+
+// Next implements the Executor Next interface.
+func (e *TableSampleExec) Next() (Row, error) {
+	for e.cursor < len(e.ranges) {
+		if e.iter == nil {
+			iter, err := e.retriever.Seek(e.ranges[e.cursor].StartKey)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			e.iter = iter
+		}
+		if !e.iter.Valid() || e.iter.Key().Cmp(e.ranges[e.cursor].EndKey) >= 0 {
+			e.nextRange()
+			continue
+		}
+		if e.sample.Method == ast.SampleRegions {
+			row, err := e.decodeRow(e.iter.Key(), e.iter.Value())
+			e.nextRange()
+			return row, errors.Trace(err)
+		}
+		var (
+			row Row
+			err error
+		)
+		sampled := e.rand.Float64()*100 < e.sample.Percent
+		if sampled {
+			row, err = e.decodeRow(e.iter.Key(), e.iter.Value())
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
+		if err = e.iter.Next(); err != nil {
+			return nil, errors.Trace(err)
+		}
+		if sampled {
+			return row, nil
+		}
+	}
+	return nil, nil
+}
+
+// nextRange moves to the next range, the rows left in the current range are skipped.
+func (e *TableSampleExec) nextRange() {
+	e.closeIter()
+	e.cursor++
+}
+
+func (e *TableSampleExec) closeIter() {
+	if e.iter != nil {
+		e.iter.Close()
+		e.iter = nil
+	}
+}
+
+func (e *TableSampleExec) decodeRow(key kv.Key, value []byte) (Row, error) {
+	handle, err := tablecodec.DecodeRowKey(key)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	tblInfo := e.t.Meta()
+	isHandle := func(col *model.ColumnInfo) bool {
+		return col.ID == model.ExtraHandleID || (tblInfo.PKIsHandle && mysql.HasPriKeyFlag(col.Flag))
+	}
+	colTps := make(map[int64]*types.FieldType, len(e.columns))
+	for _, col := range e.columns {
+		if !isHandle(col) {
+			colTps[col.ID] = &col.FieldType
+		}
+	}
+	rowMap, err := tablecodec.DecodeRow(value, colTps, e.ctx.GetSessionVars().GetTimeZone())
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	row := make([]types.Datum, len(e.columns))
+	for i, col := range e.columns {
+		if isHandle(col) {
+			if mysql.HasUnsignedFlag(col.Flag) {
+				row[i].SetUint64(uint64(handle))
+			} else {
+				row[i].SetInt64(handle)
+			}
+			continue
+		}
+		if d, ok := rowMap[col.ID]; ok {
+			row[i] = d
+			continue
+		}
+		row[i], err = table.GetColDefaultValue(e.ctx, col)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	return row, nil
+}
+
+// Close implements the Executor Close interface.
+func (e *TableSampleExec) Close() error {
+	e.closeIter()
+	return nil
+}
@@ -0,0 +1,229 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/infoschema"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/sessionctx/varsutil"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// sessionStates are the states of a session exported by SHOW SESSION_STATES. A proxy imports them into a new
+// session on another server by SET SESSION_STATES, so the connection is migrated without being noticed by the
+// client, e.g. during a rolling upgrade.
+type sessionStates struct {
+	SystemVars    map[string]string             `json:"system_vars,omitempty"`
+	UserVars      map[string]*userVarState      `json:"user_vars,omitempty"`
+	PreparedStmts map[uint32]*preparedStmtState `json:"prepared_stmts,omitempty"`
+	CurrentDB     string                        `json:"current_db,omitempty"`
+	LastInsertID  uint64                        `json:"last_insert_id,omitempty"`
+}
+
+// userVarState is a user variable, the value is encoded by its kind.
+type userVarState struct {
+	Kind  byte   `json:"kind"`
+	Value []byte `json:"value"`
+}
+
+// preparedStmtState is a prepared statement, it's prepared again with the same ID when it's imported.
+type preparedStmtState struct {
+	Name    string `json:"name,omitempty"`
+	SQLText string `json:"sql"`
+	DB      string `json:"db,omitempty"`
+}
+
+func encodeUserVar(d types.Datum) (*userVarState, error) {
+	var value []byte
+	switch d.Kind() {
+	case types.KindInt64:
+		value = strconv.AppendInt(nil, d.GetInt64(), 10)
+	case types.KindUint64:
+		value = strconv.AppendUint(nil, d.GetUint64(), 10)
+	case types.KindFloat64:
+		value = strconv.AppendFloat(nil, d.GetFloat64(), 'g', -1, 64)
+	case types.KindMysqlDecimal:
+		value = d.GetMysqlDecimal().ToString()
+	case types.KindString, types.KindBytes:
+		value = d.GetBytes()
+	default:
+		return nil, errors.Errorf("unsupported kind %d of user variable", d.Kind())
+	}
+	return &userVarState{Kind: d.Kind(), Value: value}, nil
+}
+
+func (u *userVarState) decode() (types.Datum, error) {
+	var (
+		d   types.Datum
+		err error
+	)
+	switch u.Kind {
+	case types.KindInt64:
+		var v int64
+		v, err = strconv.ParseInt(string(u.Value), 10, 64)
+		d.SetInt64(v)
+	case types.KindUint64:
+		var v uint64
+		v, err = strconv.ParseUint(string(u.Value), 10, 64)
+		d.SetUint64(v)
+	case types.KindFloat64:
+		var v float64
+		v, err = strconv.ParseFloat(string(u.Value), 64)
+		d.SetFloat64(v)
+	case types.KindMysqlDecimal:
+		dec := new(types.MyDecimal)
+		err = dec.FromString(u.Value)
+		d.SetMysqlDecimal(dec)
+	case types.KindString:
+		d.SetString(string(u.Value))
+	case types.KindBytes:
+		d.SetBytes(u.Value)
+	default:
+		err = errors.Errorf("unsupported kind %d of user variable", u.Kind)
+	}
+	return d, errors.Trace(err)
+}
+
+// exportSessionStates encodes the states of the session. A session can't be migrated in a transaction or when
+// it holds table locks, because they're bound to the server.
+func exportSessionStates(vars *variable.SessionVars) (string, error) {
+	if vars.InTxn() {
+		return "", ErrCannotMigrateSession.GenByArgs("the session is in a transaction")
+	}
+	if len(vars.TableLocks) > 0 {
+		return "", ErrCannotMigrateSession.GenByArgs("the session holds table locks")
+	}
+	states := &sessionStates{
+		SystemVars:    make(map[string]string),
+		UserVars:      make(map[string]*userVarState),
+		PreparedStmts: make(map[uint32]*preparedStmtState),
+		CurrentDB:     vars.CurrentDB,
+		LastInsertID:  vars.PrevLastInsertID,
+	}
+	for name, value := range vars.Systems {
+		if migratableSysVar(name) {
+			states.SystemVars[name] = value
+		}
+	}
+	vars.UsersLock.RLock()
+	for name, v := range vars.Users {
+		u, err := encodeUserVar(v.(types.Datum))
+		if err != nil {
+			vars.UsersLock.RUnlock()
+			return "", errors.Trace(err)
+		}
+		states.UserVars[name] = u
+	}
+	vars.UsersLock.RUnlock()
+	for id, v := range vars.PreparedStmts {
+		prepared := v.(*Prepared)
+		states.PreparedStmts[id] = &preparedStmtState{SQLText: prepared.SQLText, DB: prepared.DB}
+	}
+	for name, id := range vars.PreparedStmtNameToID {
+		if stmt, ok := states.PreparedStmts[id]; ok {
+			stmt.Name = name
+		}
+	}
+	b, err := json.Marshal(states)
+	return string(b), errors.Trace(err)
+}
+
+// migratableSysVar returns whether the system variable is migrated with the session states, the global only and
+// the read only variables aren't.
+func migratableSysVar(name string) bool {
+	sysVar := variable.SysVars[name]
+	return sysVar != nil && sysVar.Scope&variable.ScopeSession != 0 && name != variable.TiDBCurrentTS
+}
+
+func (e *ShowExec) fetchShowSessionStates() error {
+	states, err := exportSessionStates(e.ctx.GetSessionVars())
+	if err != nil {
+		return errors.Trace(err)
+	}
+	e.rows = append(e.rows, types.MakeDatums(states))
+	return nil
+}
+
+// executeSetSessionStates imports the session states, the system variables unknown by this server are ignored,
+// so the states can be imported by a newer server. The global only and the read only variables are ignored too,
+// they aren't exported.
+func (e *SimpleExec) executeSetSessionStates(s *ast.SetSessionStatesStmt) error {
+	vars := e.ctx.GetSessionVars()
+	if vars.InTxn() {
+		return ErrCannotMigrateSession.GenByArgs("the session is in a transaction")
+	}
+	states := &sessionStates{}
+	if err := json.Unmarshal([]byte(s.States), states); err != nil {
+		return ErrInvalidSessionStates.GenByArgs(err.Error())
+	}
+	if states.CurrentDB != "" {
+		if _, ok := e.is.SchemaByName(model.NewCIStr(states.CurrentDB)); !ok {
+			return infoschema.ErrDatabaseNotExists.GenByArgs(states.CurrentDB)
+		}
+	}
+
+	names := make([]string, 0, len(states.SystemVars))
+	for name := range states.SystemVars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !migratableSysVar(name) {
+			continue
+		}
+		err := varsutil.SetSessionSystemVar(vars, name, types.NewStringDatum(states.SystemVars[name]))
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+	for name, u := range states.UserVars {
+		d, err := u.decode()
+		if err != nil {
+			return ErrInvalidSessionStates.GenByArgs(err.Error())
+		}
+		if err = expression.SetUserVar(vars, name, d); err != nil {
+			return errors.Trace(err)
+		}
+	}
+
+	ids := make([]int, 0, len(states.PreparedStmts))
+	for id := range states.PreparedStmts {
+		ids = append(ids, int(id))
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		stmt := states.PreparedStmts[uint32(id)]
+		delete(vars.PreparedStmts, uint32(id))
+		// The statement is prepared in its original database, so the unqualified tables are resolved the same.
+		vars.CurrentDB = stmt.DB
+		exec := &PrepareExec{IS: e.is, Ctx: e.ctx, Name: stmt.Name, SQLText: stmt.SQLText, ID: uint32(id)}
+		exec.DoPrepare()
+		if exec.Err != nil {
+			vars.CurrentDB = states.CurrentDB
+			return errors.Trace(exec.Err)
+		}
+		vars.UpdatePreparedStmtID(uint32(id))
+	}
+	vars.CurrentDB = states.CurrentDB
+	vars.PrevLastInsertID = states.LastInsertID
+	return nil
+}
@@ -14,7 +14,6 @@
 package executor
 
 import (
-	"fmt"
 	"strings"
 	"time"
 
@@ -77,16 +76,10 @@ func (e *SetExecutor) executeSet() error {
 			if err != nil {
 				return errors.Trace(err)
 			}
-
-			if value.IsNull() {
-				delete(sessionVars.Users, name)
-			} else {
-				svalue, err1 := value.ToString()
-				if err1 != nil {
-					return errors.Trace(err1)
-				}
-				sessionVars.Users[name] = fmt.Sprintf("%v", svalue)
+			if err = expression.SetUserVar(sessionVars, name, value); err != nil {
+				return errors.Trace(err)
 			}
+			sessionVars.TrackStateChange()
 			continue
 		}
 
@@ -100,7 +93,7 @@ func (e *SetExecutor) executeSet() error {
 		}
 		if v.IsGlobal {
 			// Set global scope system variable.
-			if sysVar.Scope&variable.ScopeGlobal == 0 {
+			if sysVar.Scope&(variable.ScopeGlobal|variable.ScopeInstance) == 0 {
 				return errors.Errorf("Variable '%s' is a SESSION variable and can't be used with SET GLOBAL", name)
 			}
 			value, err := e.getVarValue(v, sysVar)
@@ -114,7 +107,7 @@ func (e *SetExecutor) executeSet() error {
 			if err != nil {
 				return errors.Trace(err)
 			}
-			err = sessionVars.GlobalVarsAccessor.SetGlobalSysVar(name, svalue)
+			err = varsutil.SetGlobalSystemVar(sessionVars, name, svalue)
 			if err != nil {
 				return errors.Trace(err)
 			}
@@ -141,6 +134,7 @@ func (e *SetExecutor) executeSet() error {
 				}
 			}
 			e.loadSnapshotInfoSchemaIfNeeded(name)
+			sessionVars.TrackSysVar(name, sessionVars.Systems[name])
 			valStr, _ := value.ToString()
 			log.Infof("[%d] set system variable %s = %s", sessionVars.ConnectionID, name, valStr)
 		}
@@ -188,8 +182,10 @@ func (e *SetExecutor) setCharset(cs, co string) error {
 	sessionVars := e.ctx.GetSessionVars()
 	for _, v := range variable.SetNamesVariables {
 		sessionVars.Systems[v] = cs
+		sessionVars.TrackSysVar(v, cs)
 	}
 	sessionVars.Systems[variable.CollationConnection] = co
+	sessionVars.TrackSysVar(variable.CollationConnection, co)
 	return nil
 }
 
@@ -15,9 +15,12 @@ package executor_test
 
 import (
 	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/config"
 	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/sessionctx/varsutil"
+	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util/testkit"
 	"github.com/pingcap/tidb/util/testleak"
 )
@@ -37,16 +40,15 @@ func (s *testSuite) TestSetVar(c *C) {
 	testSQL = "SET @@global.autocommit = 1;"
 	tk.MustExec(testSQL)
 
-	// TODO: this test case should returns error.
-	// testSQL = "SET @@global.autocommit = null;"
-	// _, err := tk.Exec(testSQL)
-	// c.Assert(err, NotNil)
+	testSQL = "SET @@global.autocommit = null;"
+	_, err := tk.Exec(testSQL)
+	c.Assert(err, NotNil)
 
 	testSQL = "SET @@autocommit = 1;"
 	tk.MustExec(testSQL)
 
 	testSQL = "SET @@autocommit = null;"
-	_, err := tk.Exec(testSQL)
+	_, err = tk.Exec(testSQL)
 	c.Assert(err, NotNil)
 
 	errTestSql := "SET @@date_format = 1;"
@@ -150,6 +152,29 @@ func (s *testSuite) TestSetVar(c *C) {
 	tk.MustQuery(`select @@session.sql_log_bin;`).Check(testkit.Rows("off"))
 	tk.MustExec("set @@sql_log_bin = on")
 	tk.MustQuery(`select @@session.sql_log_bin;`).Check(testkit.Rows("ON"))
+
+	// Test the validation of the values.
+	_, err = tk.Exec("set @@tidb_index_lookup_size = 'abc'")
+	c.Assert(terror.ErrorEqual(err, variable.ErrWrongTypeForVar), IsTrue)
+	_, err = tk.Exec("set @@global.tx_isolation = 'SNAPSHOT'")
+	c.Assert(terror.ErrorEqual(err, variable.ErrWrongValueForVar), IsTrue)
+	tk.MustQuery("select @@global.tx_isolation").Check(testkit.Rows("SERIALIZABLE"))
+	tk.MustExec("set @@global.tidb_force_priority = 'low_priority'")
+	tk.MustQuery("select @@global.tidb_force_priority").Check(testkit.Rows("LOW_PRIORITY"))
+	tk.MustExec("set @@global.tidb_force_priority = default")
+	tk.MustExec("set @@tidb_index_lookup_size = 0")
+	tk.MustQuery("show warnings").Check(testkit.Rows("Warning 1292 Truncated incorrect tidb_index_lookup_size value: '0'"))
+	tk.MustQuery("select @@tidb_index_lookup_size").Check(testkit.Rows("1"))
+
+	// Test the instance variables.
+	tk.MustExec("set @@global.tidb_slow_log_threshold = 200")
+	tk.MustQuery("select @@tidb_slow_log_threshold, @@global.tidb_slow_log_threshold").Check(testkit.Rows("200 200"))
+	c.Assert(config.GetGlobalConfig().SlowThreshold, Equals, 200)
+	tk.MustQuery("select count(*) from mysql.global_variables where variable_name = 'tidb_slow_log_threshold'").Check(testkit.Rows("0"))
+	_, err = tk.Exec("set @@session.tidb_slow_log_threshold = 100")
+	c.Assert(err, NotNil)
+	tk.MustExec("set @@global.tidb_slow_log_threshold = default")
+	c.Assert(config.GetGlobalConfig().SlowThreshold, Equals, 300)
 }
 
 func (s *testSuite) TestSetCharset(c *C) {
@@ -177,3 +202,61 @@ func (s *testSuite) TestSetCharset(c *C) {
 	// Issue 1523
 	tk.MustExec(`SET NAMES binary`)
 }
+
+func (s *testSuite) TestSessionTrack(c *C) {
+	defer testleak.AfterTest(c)()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	sessionVars := tk.Se.(context.Context).GetSessionVars()
+	sessionVars.TakeSessionStateChanges()
+
+	// The system variables not listed in session_track_system_variables are not sent.
+	tk.MustExec("set @@session.time_zone = '+08:00', @@session.sql_mode = ''")
+	c.Assert(sessionVars.TakeSessionStateChanges(), DeepEquals, []variable.SessionStateChange{
+		{Type: mysql.SessionTrackSystemVariables, Name: variable.TimeZone, Value: "+08:00"},
+	})
+	c.Assert(sessionVars.TakeSessionStateChanges(), HasLen, 0)
+
+	tk.MustExec("set session_track_system_variables = '*', session_track_state_change = ON")
+	sessionVars.TakeSessionStateChanges()
+	tk.MustExec("set names utf8")
+	changes := sessionVars.TakeSessionStateChanges()
+	c.Assert(changes, HasLen, 5)
+	c.Assert(changes[0], DeepEquals, variable.SessionStateChange{Type: mysql.SessionTrackSystemVariables, Name: "character_set_client", Value: "utf8"})
+	c.Assert(changes[4].Type, Equals, mysql.SessionTrackStateChange)
+
+	tk.MustExec("use test")
+	c.Assert(sessionVars.TakeSessionStateChanges(), DeepEquals, []variable.SessionStateChange{
+		{Type: mysql.SessionTrackSchema, Value: "test"},
+		{Type: mysql.SessionTrackStateChange, Value: "1"},
+	})
+	tk.MustExec("set @a = 1")
+	c.Assert(sessionVars.TakeSessionStateChanges(), DeepEquals, []variable.SessionStateChange{
+		{Type: mysql.SessionTrackStateChange, Value: "1"},
+	})
+	tk.MustExec("select 1")
+	c.Assert(sessionVars.TakeSessionStateChanges(), HasLen, 0)
+
+	// The transaction state is sent when it's changed.
+	tk.MustExec("set session_track_system_variables = '', session_track_transaction_info = 'state', session_track_state_change = OFF")
+	c.Assert(sessionVars.TakeSessionStateChanges(), DeepEquals, []variable.SessionStateChange{
+		{Type: mysql.SessionTrackTransactionState, Value: "________"},
+	})
+	tk.MustExec("drop table if exists track")
+	tk.MustExec("create table track (a int)")
+	sessionVars.TakeSessionStateChanges()
+	tk.MustExec("begin")
+	c.Assert(sessionVars.TakeSessionStateChanges(), DeepEquals, []variable.SessionStateChange{
+		{Type: mysql.SessionTrackTransactionState, Value: "T_______"},
+	})
+	tk.MustExec("insert track values (1)")
+	c.Assert(sessionVars.TakeSessionStateChanges(), DeepEquals, []variable.SessionStateChange{
+		{Type: mysql.SessionTrackTransactionState, Value: "T___W___"},
+	})
+	tk.MustExec("commit")
+	c.Assert(sessionVars.TakeSessionStateChanges(), DeepEquals, []variable.SessionStateChange{
+		{Type: mysql.SessionTrackTransactionState, Value: "________"},
+	})
+	_, err := tk.Exec("set session_track_transaction_info = 'all'")
+	c.Assert(err, NotNil)
+}
@@ -17,6 +17,7 @@ import (
 	"bytes"
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -48,6 +49,8 @@ type ShowExec struct {
 
 	// GlobalScope is used by show variables
 	GlobalScope bool
+	// CountWarningsOrErrors is used by show warnings and errors
+	CountWarningsOrErrors bool
 
 	is infoschema.InfoSchema
 
@@ -105,7 +108,9 @@ func (e *ShowExec) fetchAll() error {
 	case ast.ShowVariables:
 		return e.fetchShowVariables()
 	case ast.ShowWarnings:
-		return e.fetchShowWarnings()
+		return e.fetchShowWarnings(false)
+	case ast.ShowErrors:
+		return e.fetchShowWarnings(true)
 	case ast.ShowProcessList:
 		return e.fetchShowProcessList()
 	case ast.ShowEvents:
@@ -116,6 +121,10 @@ func (e *ShowExec) fetchAll() error {
 		return e.fetchShowStatsHistogram()
 	case ast.ShowStatsBuckets:
 		return e.fetchShowStatsBuckets()
+	case ast.ShowBindings:
+		return e.fetchShowBindings()
+	case ast.ShowSessionStates:
+		return e.fetchShowSessionStates()
 	}
 	return nil
 }
@@ -225,7 +234,7 @@ func (e *ShowExec) fetchShowColumns() error {
 	if err != nil {
 		return errors.Trace(err)
 	}
-	cols := tb.Cols()
+	cols := table.VisibleCols(tb.Cols())
 	for _, col := range cols {
 		if e.Column != nil && e.Column.Name.L != col.Name.L {
 			continue
@@ -290,6 +299,8 @@ func (e *ShowExec) fetchShowIndex() error {
 			"BTREE",          // Index_type
 			"",               // Comment
 			"",               // Index_comment
+			"YES",            // Visible
+			nil,              // Expression
 		)
 		e.rows = append(e.rows, data)
 	}
@@ -303,12 +314,21 @@ func (e *ShowExec) fetchShowIndex() error {
 			if col.Length != types.UnspecifiedLength {
 				subPart = col.Length
 			}
+			visible := "YES"
+			if idx.Meta().Invisible {
+				visible = "NO"
+			}
+			// The key of an expression index has no column name, the expression is shown instead.
+			var colName, expr interface{} = col.Name.O, nil
+			if tblCol := tb.Cols()[col.Offset]; tblCol.Hidden {
+				colName, expr = nil, tblCol.GeneratedExprString
+			}
 			data := types.MakeDatums(
 				tb.Meta().Name.O,  // Table
 				nonUniq,           // Non_unique
 				idx.Meta().Name.O, // Key_name
 				i+1,               // Seq_in_index
-				col.Name.O,        // Column_name
+				colName,           // Column_name
 				"utf8_bin",        // Colation
 				0,                 // Cardinality
 				subPart,           // Sub_part
@@ -317,6 +337,8 @@ func (e *ShowExec) fetchShowIndex() error {
 				idx.Meta().Tp.String(), // Index_type
 				"",                 // Comment
 				idx.Meta().Comment, // Index_comment
+				visible,            // Visible
+				expr,               // Expression
 			)
 			e.rows = append(e.rows, data)
 		}
@@ -390,11 +412,19 @@ func (e *ShowExec) fetchShowCreateTable() error {
 		return errors.Trace(err)
 	}
 
+	data := types.MakeDatums(tb.Meta().Name.O, showCreateTable(tb))
+	e.rows = append(e.rows, data)
+	return nil
+}
+
+// showCreateTable composes the create table statement of the table.
+func showCreateTable(tb table.Table) string {
 	// TODO: let the result more like MySQL.
 	var buf bytes.Buffer
 	buf.WriteString(fmt.Sprintf("CREATE TABLE `%s` (\n", tb.Meta().Name.O))
 	var pkCol *table.Column
-	for i, col := range tb.Cols() {
+	tblCols := table.VisibleCols(tb.Cols())
+	for i, col := range tblCols {
 		buf.WriteString(fmt.Sprintf("  `%s` %s", col.Name.O, col.GetTypeDesc()))
 		if len(col.GeneratedExprString) != 0 {
 			// It's a generated column.
@@ -433,7 +463,7 @@ func (e *ShowExec) fetchShowCreateTable() error {
 		if len(col.Comment) > 0 {
 			buf.WriteString(fmt.Sprintf(" COMMENT '%s'", col.Comment))
 		}
-		if i != len(tb.Cols())-1 {
+		if i != len(tblCols)-1 {
 			buf.WriteString(",\n")
 		}
 		if tb.Meta().PKIsHandle && mysql.HasPriKeyFlag(col.Flag) {
@@ -463,9 +493,26 @@ func (e *ShowExec) fetchShowCreateTable() error {
 
 		cols := make([]string, 0, len(idxInfo.Columns))
 		for _, c := range idxInfo.Columns {
-			cols = append(cols, c.Name.O)
+			var colStr string
+			if col := tb.Cols()[c.Offset]; col.Hidden {
+				// The key of an expression index is shown as the expression.
+				colStr = fmt.Sprintf("(%s)", col.GeneratedExprString)
+			} else {
+				colStr = fmt.Sprintf("`%s`", c.Name.O)
+			}
+			if c.Desc {
+				colStr += " DESC"
+			}
+			cols = append(cols, colStr)
+		}
+		buf.WriteString(fmt.Sprintf("(%s)", strings.Join(cols, ",")))
+		if idxInfo.Primary && isNonClusteredIntPK(tb, idxInfo) {
+			// The primary key would be the handle of the rows without the keyword.
+			buf.WriteString(" /*T![clustered_index] NONCLUSTERED */")
+		}
+		if idxInfo.Invisible {
+			buf.WriteString(" /*!80000 INVISIBLE */")
 		}
-		buf.WriteString(fmt.Sprintf("(`%s`)", strings.Join(cols, "`,`")))
 		if i != len(tb.Indices())-1 {
 			buf.WriteString(",\n")
 		}
@@ -524,13 +571,27 @@ func (e *ShowExec) fetchShowCreateTable() error {
 		buf.WriteString(fmt.Sprintf(" AUTO_INCREMENT=%d", tb.Meta().AutoIncID))
 	}
 
+	if tb.Meta().AutoIDCache > 0 {
+		buf.WriteString(fmt.Sprintf(" AUTO_ID_CACHE=%d", tb.Meta().AutoIDCache))
+	}
+
 	if len(tb.Meta().Comment) > 0 {
 		buf.WriteString(fmt.Sprintf(" COMMENT='%s'", tb.Meta().Comment))
 	}
+	return buf.String()
+}
 
-	data := types.MakeDatums(tb.Meta().Name.O, buf.String())
-	e.rows = append(e.rows, data)
-	return nil
+// isNonClusteredIntPK checks whether the primary key is on a single integer column, which is the handle of
+// the rows unless the primary key is NONCLUSTERED.
+func isNonClusteredIntPK(tb table.Table, idxInfo *model.IndexInfo) bool {
+	if len(idxInfo.Columns) != 1 {
+		return false
+	}
+	switch tb.Cols()[idxInfo.Columns[0].Offset].Tp {
+	case mysql.TypeLong, mysql.TypeLonglong, mysql.TypeTiny, mysql.TypeShort, mysql.TypeInt24:
+		return true
+	}
+	return false
 }
 
 // fetchShowCreateDatabase composes show create database result.
@@ -596,12 +657,37 @@ func (e *ShowExec) fetchShowProcedureStatus() error {
 	return nil
 }
 
-func (e *ShowExec) fetchShowWarnings() error {
-	warns := e.ctx.GetSessionVars().StmtCtx.GetWarnings()
-	for _, warn := range warns {
+// fetchShowWarnings shows the warnings of the last statement, or only the errors if errOnly is true.
+// At most max_error_count messages are shown, but all of them are counted.
+func (e *ShowExec) fetchShowWarnings(errOnly bool) error {
+	sessionVars := e.ctx.GetSessionVars()
+	warns := sessionVars.StmtCtx.GetWarnings()
+	if e.CountWarningsOrErrors {
+		count := len(warns)
+		if errOnly {
+			count = int(sessionVars.StmtCtx.ErrorCount())
+		}
+		e.rows = append(e.rows, []types.Datum{types.NewUintDatum(uint64(count))})
+		return nil
+	}
+	value, err := varsutil.GetSessionSystemVar(sessionVars, variable.MaxErrorCount)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	maxCount, err := strconv.Atoi(value)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	for _, w := range warns {
+		if len(e.rows) >= maxCount {
+			break
+		}
+		if errOnly && w.Level != variable.WarnLevelError {
+			continue
+		}
 		datums := make([]types.Datum, 3)
-		datums[0] = types.NewStringDatum("Warning")
-		warn = errors.Cause(warn)
+		datums[0] = types.NewStringDatum(w.Level)
+		warn := errors.Cause(w.Err)
 		switch x := warn.(type) {
 		case *terror.Error:
 			sqlErr := x.ToSQLError()
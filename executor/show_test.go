@@ -18,6 +18,8 @@ import (
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb"
+	"github.com/pingcap/tidb/executor"
+	"github.com/pingcap/tidb/infoschema"
 	"github.com/pingcap/tidb/plan"
 	"github.com/pingcap/tidb/privilege/privileges"
 	"github.com/pingcap/tidb/sessionctx/variable"
@@ -72,6 +74,12 @@ func (s *testSuite) TestShow(c *C) {
 		c.Check(r, Equals, expectedRow[i])
 	}
 
+	tk.MustExec("create table auto_id_cache_test (id int primary key auto_increment) auto_id_cache = 1")
+	tk.MustQuery("show create table auto_id_cache_test").Check(testkit.Rows(
+		"auto_id_cache_test CREATE TABLE `auto_id_cache_test` (\n  `id` int(11) NOT NULL AUTO_INCREMENT,\n  PRIMARY KEY (`id`)\n) ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_bin AUTO_ID_CACHE=1"))
+	tk.MustExec("insert auto_id_cache_test values (), (), ()")
+	tk.MustQuery("select id from auto_id_cache_test").Check(testkit.Rows("1", "2", "3"))
+
 	testSQL = "SHOW VARIABLES LIKE 'character_set_results';"
 	result = tk.MustQuery(testSQL)
 	c.Check(result.Rows(), HasLen, 1)
@@ -87,15 +95,15 @@ func (s *testSuite) TestShow(c *C) {
 	tk.MustExec(`create index idx7 on show_index (id);`)
 	testSQL = "SHOW index from show_index;"
 	tk.MustQuery(testSQL).Check(testutil.RowsWithSep("|",
-		"show_index|0|PRIMARY|1|id|utf8_bin|0|<nil>|<nil>||BTREE||",
-		"show_index|1|cIdx|1|c|utf8_bin|0|<nil>|<nil>|YES|HASH||index_comment_for_cIdx",
-		"show_index|1|idx1|1|id|utf8_bin|0|<nil>|<nil>|YES|HASH||",
-		"show_index|1|idx2|1|id|utf8_bin|0|<nil>|<nil>|YES|BTREE||idx",
-		"show_index|1|idx3|1|id|utf8_bin|0|<nil>|<nil>|YES|HASH||idx",
-		"show_index|1|idx4|1|id|utf8_bin|0|<nil>|<nil>|YES|BTREE||idx",
-		"show_index|1|idx5|1|id|utf8_bin|0|<nil>|<nil>|YES|BTREE||idx",
-		"show_index|1|idx6|1|id|utf8_bin|0|<nil>|<nil>|YES|HASH||",
-		"show_index|1|idx7|1|id|utf8_bin|0|<nil>|<nil>|YES|BTREE||",
+		"show_index|0|PRIMARY|1|id|utf8_bin|0|<nil>|<nil>||BTREE|||YES|<nil>",
+		"show_index|1|cIdx|1|c|utf8_bin|0|<nil>|<nil>|YES|HASH||index_comment_for_cIdx|YES|<nil>",
+		"show_index|1|idx1|1|id|utf8_bin|0|<nil>|<nil>|YES|HASH|||YES|<nil>",
+		"show_index|1|idx2|1|id|utf8_bin|0|<nil>|<nil>|YES|BTREE||idx|YES|<nil>",
+		"show_index|1|idx3|1|id|utf8_bin|0|<nil>|<nil>|YES|HASH||idx|YES|<nil>",
+		"show_index|1|idx4|1|id|utf8_bin|0|<nil>|<nil>|YES|BTREE||idx|YES|<nil>",
+		"show_index|1|idx5|1|id|utf8_bin|0|<nil>|<nil>|YES|BTREE||idx|YES|<nil>",
+		"show_index|1|idx6|1|id|utf8_bin|0|<nil>|<nil>|YES|HASH|||YES|<nil>",
+		"show_index|1|idx7|1|id|utf8_bin|0|<nil>|<nil>|YES|BTREE|||YES|<nil>",
 	))
 
 	// For show like with escape
@@ -273,6 +281,39 @@ func (s *testSuite) TestShowWarnings(c *C) {
 	c.Assert(tk.Se.GetSessionVars().StmtCtx.WarningCount(), Equals, uint16(0))
 	tk.MustQuery("show warnings").Check(testutil.RowsWithSep("|", "Warning|1265|Data Truncated"))
 	c.Assert(tk.Se.GetSessionVars().StmtCtx.WarningCount(), Equals, uint16(0))
+	tk.MustQuery("show count(*) warnings").Check(testkit.Rows("1"))
+	tk.MustQuery("show count(*) errors").Check(testkit.Rows("0"))
+	tk.MustQuery("show errors").Check(testkit.Rows())
+
+	tk.MustExec("insert show_warnings values ('a'), ('b'), ('c')")
+	tk.MustQuery("show warnings limit 1, 1").Check(testutil.RowsWithSep("|", "Warning|1265|Data Truncated"))
+	tk.MustExec("set @@max_error_count = 2")
+	tk.MustExec("insert show_warnings values ('a'), ('b'), ('c')")
+	c.Assert(tk.Se.GetSessionVars().StmtCtx.WarningCount(), Equals, uint16(3))
+	tk.MustQuery("show warnings").Check(testutil.RowsWithSep("|", "Warning|1265|Data Truncated", "Warning|1265|Data Truncated"))
+	tk.MustQuery("show count(*) warnings").Check(testkit.Rows("3"))
+	tk.MustExec("set @@max_error_count = 64")
+
+	tk.MustQuery("select 1 / 0, 1 div 0, 1 % 0").Check(testkit.Rows("<nil> <nil> <nil>"))
+	tk.MustQuery("show warnings").Check(testutil.RowsWithSep("|",
+		"Warning|1365|Division by 0", "Warning|1365|Division by 0", "Warning|1365|Division by 0"))
+
+	// The error which fails the statement is shown as well.
+	_, err := tk.Exec("select * from show_warnings_not_exist")
+	c.Assert(err, NotNil)
+	tk.MustQuery("show errors").Check(testutil.RowsWithSep("|", "Error|1146|Table 'test.show_warnings_not_exist' doesn't exist"))
+	tk.MustQuery("show count(*) errors").Check(testkit.Rows("1"))
+	tk.MustQuery("show warnings").Check(testutil.RowsWithSep("|", "Error|1146|Table 'test.show_warnings_not_exist' doesn't exist"))
+
+	tk.MustExec("drop table if exists show_warnings_not_exist, show_warnings")
+	tk.MustQuery("show warnings").Check(testutil.RowsWithSep("|", "Note|1051|Unknown table 'test.show_warnings_not_exist'"))
+	tk.MustExec("create database if not exists test")
+	tk.MustQuery("show warnings").Check(testutil.RowsWithSep("|", "Note|1007|Can't create database 'test'; database exists"))
+	tk.MustExec("drop database if exists show_warnings_not_exist")
+	tk.MustQuery("show warnings").Check(testutil.RowsWithSep("|", "Note|1008|Can't drop database 'show_warnings_not_exist'; database doesn't exist"))
+	tk.MustExec("set @@sql_notes = 0")
+	tk.MustExec("drop database if exists show_warnings_not_exist")
+	tk.MustQuery("show warnings").Check(testkit.Rows())
 }
 
 func (s *testSuite) TestIssue3641(c *C) {
@@ -282,3 +323,57 @@ func (s *testSuite) TestIssue3641(c *C) {
 	_, err = tk.Exec("show table status;")
 	c.Assert(err.Error(), Equals, plan.ErrNoDB.Error())
 }
+
+func (s *testSuite) TestShowSessionStates(c *C) {
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists session_states")
+	tk.MustExec("create table session_states (id int primary key auto_increment, c varchar(10))")
+	tk.MustExec("insert session_states (c) values ('a'), ('b'), ('c')")
+	tk.MustExec("set @i = 1, @f = 1.5e1, @d = 1.25, @s = 'it''s', @n = null")
+	tk.MustExec("set @@tidb_index_lookup_size = 100, @@sql_mode = 'STRICT_TRANS_TABLES'")
+	tk.MustExec("prepare stmt from 'select c from session_states where id > ?'")
+	id, _, _, err := tk.Se.PrepareStmt("select count(*) from session_states where c < ?")
+	c.Assert(err, IsNil)
+	tk.MustExec("use mysql")
+
+	tk.MustExec("begin")
+	rs, err := tk.Exec("show session_states")
+	c.Assert(err, IsNil)
+	_, err = tidb.GetRows(rs)
+	c.Assert(executor.ErrCannotMigrateSession.Equal(err), IsTrue)
+	tk.MustExec("rollback")
+	states := tk.MustQuery("show session_states").Rows()[0][0].(string)
+
+	tk1 := testkit.NewTestKit(c, s.store)
+	_, err = tk1.Exec("set session_states 'abc'")
+	c.Assert(executor.ErrInvalidSessionStates.Equal(err), IsTrue)
+	tk1.MustExec("set session_states '" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(states) + "'")
+	tk1.MustQuery("select database(), @i, @f, @d, @s, @n").Check(testkit.Rows(
+		"mysql 1 15 1.25 it's <nil>"))
+	tk1.MustQuery("select @@tidb_index_lookup_size, @@sql_mode").Check(testkit.Rows("100 STRICT_TRANS_TABLES"))
+	// The prepared statements are prepared in the original database.
+	tk1.MustQuery("execute stmt using @i").Check(testkit.Rows("b", "c"))
+	rs, err = tk1.Se.ExecutePreparedStmt(id, "c")
+	c.Assert(err, IsNil)
+	rows, err := tidb.GetRows(rs)
+	c.Assert(err, IsNil)
+	c.Assert(rows[0][0].GetInt64(), Equals, int64(2))
+	// The statements prepared later don't reuse the imported IDs.
+	newID, _, _, err := tk1.Se.PrepareStmt("select 1")
+	c.Assert(err, IsNil)
+	c.Assert(newID, Greater, id)
+
+	// The global only and the read only variables aren't imported, the current database must exist.
+	tk2 := testkit.NewTestKit(c, s.store)
+	tk2.MustExec(`set session_states '{"system_vars": {"tidb_redact_log": "1", "tidb_current_ts": "1", "tidb_index_lookup_size": "100"}}'`)
+	tk2.MustQuery("select @@tidb_index_lookup_size, @@tidb_current_ts").Check(testkit.Rows("100 0"))
+	c.Assert(tk2.Se.GetSessionVars().RedactLog, IsFalse)
+	_, err = tk2.Exec(`set session_states '{"current_db": "session_states_not_exist", "system_vars": {"tidb_index_lookup_size": "200"}}'`)
+	c.Assert(infoschema.ErrDatabaseNotExists.Equal(err), IsTrue)
+	tk2.MustQuery("select database(), @@tidb_index_lookup_size").Check(testkit.Rows("<nil> 100"))
+}
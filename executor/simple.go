@@ -15,20 +15,25 @@ package executor
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/juju/errors"
 	"github.com/ngaut/log"
 	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/config"
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/ddl"
+	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/infoschema"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/sessionctx"
 	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/udf"
 	"github.com/pingcap/tidb/util"
+	"github.com/pingcap/tidb/util/resource"
 	"github.com/pingcap/tidb/util/sqlexec"
 )
 
@@ -77,6 +82,32 @@ func (e *SimpleExec) Next() (Row, error) {
 		return nil, nil
 	case *ast.DropStatsStmt:
 		err = e.executeDropStats(x)
+	case *ast.CreateFunctionStmt:
+		err = e.executeCreateFunction(x)
+	case *ast.DropFunctionStmt:
+		err = e.executeDropFunction(x)
+	case *ast.CreateProcedureStmt:
+		err = e.executeCreateProcedure(x)
+	case *ast.DropProcedureStmt:
+		err = e.executeDropProcedure(x)
+	case *ast.CreateEventStmt:
+		err = e.executeCreateEvent(x)
+	case *ast.DropEventStmt:
+		err = e.executeDropEvent(x)
+	case *ast.CreateBindingStmt:
+		err = e.executeCreateBinding(x)
+	case *ast.DropBindingStmt:
+		err = e.executeDropBinding(x)
+	case *ast.CreatePolicyStmt:
+		err = e.executeCreatePolicy(x)
+	case *ast.DropPolicyStmt:
+		err = e.executeDropPolicy(x)
+	case *ast.CreateMaskingPolicyStmt:
+		err = e.executeCreateMaskingPolicy(x)
+	case *ast.DropMaskingPolicyStmt:
+		err = e.executeDropMaskingPolicy(x)
+	case *ast.SetSessionStatesStmt:
+		err = e.executeSetSessionStates(x)
 	}
 	if err != nil {
 		return nil, errors.Trace(err)
@@ -92,6 +123,7 @@ func (e *SimpleExec) executeUse(s *ast.UseStmt) error {
 		return infoschema.ErrDatabaseNotExists.GenByArgs(dbname)
 	}
 	e.ctx.GetSessionVars().CurrentDB = dbname.O
+	e.ctx.GetSessionVars().TrackSchema()
 	// character_set_database is the character set used by the default database.
 	// The server sets this variable whenever the default database changes.
 	// See http://dev.mysql.com/doc/refman/5.7/en/server-system-variables.html#sysvar_character_set_database
@@ -132,7 +164,44 @@ func (e *SimpleExec) executeRollback(s *ast.RollbackStmt) error {
 	return nil
 }
 
+// resourceOptionColumns are the columns of mysql.user for the resource options.
+var resourceOptionColumns = map[ast.ResourceOptionType]string{
+	ast.MaxQueriesPerHour:     "max_questions",
+	ast.MaxUpdatesPerHour:     "max_updates",
+	ast.MaxConnectionsPerHour: "max_connections",
+	ast.MaxUserConnections:    "max_user_connections",
+}
+
+// userResourceColumns returns the columns of mysql.user and their values for the resource options and the
+// resource group, the resource group "default" is stored as empty. Other groups must be configured on the server.
+func userResourceColumns(opts []*ast.ResourceOption, group string) (cols []string, values []string, err error) {
+	for _, opt := range opts {
+		cols = append(cols, resourceOptionColumns[opt.Type])
+		values = append(values, strconv.FormatInt(opt.Count, 10))
+	}
+	if group != "" {
+		if strings.EqualFold(group, "default") {
+			group = ""
+		} else {
+			groups, err := resource.ParseGroups(config.GetGlobalConfig().ResourceGroups)
+			if err != nil {
+				return nil, nil, errors.Trace(err)
+			}
+			if !groups.Exists(group) {
+				return nil, nil, ErrUnknownResourceGroup.GenByArgs(group)
+			}
+		}
+		cols = append(cols, "Resource_group")
+		values = append(values, fmt.Sprintf(`"%s"`, escapeSQLString(group)))
+	}
+	return cols, values, nil
+}
+
 func (e *SimpleExec) executeCreateUser(s *ast.CreateUserStmt) error {
+	resCols, resValues, err := userResourceColumns(s.ResourceOptions, s.ResourceGroup)
+	if err != nil {
+		return errors.Trace(err)
+	}
 	users := make([]string, 0, len(s.Specs))
 	for _, spec := range s.Specs {
 		userName, host := parseUser(spec.User)
@@ -154,14 +223,15 @@ func (e *SimpleExec) executeCreateUser(s *ast.CreateUserStmt) error {
 				pwd = util.EncodePassword(spec.AuthOpt.HashString)
 			}
 		}
-		user := fmt.Sprintf(`("%s", "%s", "%s")`, host, userName, pwd)
-		users = append(users, user)
+		values := append([]string{fmt.Sprintf(`"%s", "%s", "%s"`, host, userName, pwd)}, resValues...)
+		users = append(users, "("+strings.Join(values, ", ")+")")
 	}
 	if len(users) == 0 {
 		return nil
 	}
-	sql := fmt.Sprintf(`INSERT INTO %s.%s (Host, User, Password) VALUES %s;`, mysql.SystemDB, mysql.UserTable, strings.Join(users, ", "))
-	_, err := e.ctx.(sqlexec.SQLExecutor).Execute(sql)
+	cols := strings.Join(append([]string{"Host, User, Password"}, resCols...), ", ")
+	sql := fmt.Sprintf(`INSERT INTO %s.%s (%s) VALUES %s;`, mysql.SystemDB, mysql.UserTable, cols, strings.Join(users, ", "))
+	_, err = e.ctx.(sqlexec.SQLExecutor).Execute(sql)
 	if err != nil {
 		return errors.Trace(err)
 	}
@@ -182,6 +252,10 @@ func (e *SimpleExec) executeAlterUser(s *ast.AlterUserStmt) error {
 		s.Specs = []*ast.UserSpec{spec}
 	}
 
+	resCols, resValues, err := userResourceColumns(s.ResourceOptions, s.ResourceGroup)
+	if err != nil {
+		return errors.Trace(err)
+	}
 	failedUsers := make([]string, 0, len(s.Specs))
 	for _, spec := range s.Specs {
 		userName, host := parseUser(spec.User)
@@ -196,16 +270,24 @@ func (e *SimpleExec) executeAlterUser(s *ast.AlterUserStmt) error {
 			}
 			continue
 		}
-		pwd := ""
-		if spec.AuthOpt != nil {
-			if spec.AuthOpt.ByAuthString {
-				pwd = util.EncodePassword(spec.AuthOpt.AuthString)
-			} else {
-				pwd = util.EncodePassword(spec.AuthOpt.HashString)
+		var assignments []string
+		// The password is reset by ALTER USER without IDENTIFIED unless only the resource options are altered.
+		if spec.AuthOpt != nil || len(resCols) == 0 {
+			pwd := ""
+			if spec.AuthOpt != nil {
+				if spec.AuthOpt.ByAuthString {
+					pwd = util.EncodePassword(spec.AuthOpt.AuthString)
+				} else {
+					pwd = util.EncodePassword(spec.AuthOpt.HashString)
+				}
 			}
+			assignments = append(assignments, fmt.Sprintf(`Password = "%s"`, pwd))
+		}
+		for i, col := range resCols {
+			assignments = append(assignments, col+" = "+resValues[i])
 		}
-		sql := fmt.Sprintf(`UPDATE %s.%s SET Password = "%s" WHERE Host = "%s" and User = "%s";`,
-			mysql.SystemDB, mysql.UserTable, pwd, host, userName)
+		sql := fmt.Sprintf(`UPDATE %s.%s SET %s WHERE Host = "%s" and User = "%s";`,
+			mysql.SystemDB, mysql.UserTable, strings.Join(assignments, ", "), host, userName)
 		_, _, err = e.ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(e.ctx, sql)
 		if err != nil {
 			failedUsers = append(failedUsers, spec.User)
@@ -220,6 +302,7 @@ func (e *SimpleExec) executeAlterUser(s *ast.AlterUserStmt) error {
 		errMsg := "Operation ALTER USER failed for " + strings.Join(failedUsers, ",")
 		return terror.ClassExecutor.New(CodeCannotUser, errMsg)
 	}
+	sessionctx.GetDomain(e.ctx).NotifyUpdatePrivilege(e.ctx)
 	return nil
 }
 
@@ -296,13 +379,17 @@ func (e *SimpleExec) executeSetPwd(s *ast.SetPwdStmt) error {
 }
 
 func (e *SimpleExec) executeKillStmt(s *ast.KillStmt) error {
-	if s.TiDBExtension {
-		sm := e.ctx.GetSessionManager()
-		if sm == nil {
+	sm := e.ctx.GetSessionManager()
+	if sm == nil {
+		return nil
+	}
+	if !s.TiDBExtension {
+		// The standard KILL may be sent to a wrong server by a proxy, so it's ignored unless the connection IDs are global.
+		if gk, ok := sm.(util.GlobalKiller); !ok || !gk.GlobalConnID() {
 			return nil
 		}
-		sm.Kill(s.ConnectionID, s.Query)
 	}
+	sm.Kill(s.ConnectionID, s.Query)
 	return nil
 }
 
@@ -320,10 +407,109 @@ func (e *SimpleExec) executeFlush(s *ast.FlushStmt) error {
 		defer sysSessionPool.Put(ctx)
 		err = dom.PrivilegeHandle().Update(ctx.(context.Context))
 		return errors.Trace(err)
+	case ast.FlushUserResources:
+		resource.GlobalTracker.Reset()
 	}
 	return nil
 }
 
+func (e *SimpleExec) executeCreateFunction(s *ast.CreateFunctionStmt) error {
+	if expression.IsBuiltinFunction(s.Name.L) {
+		return ErrUDFNameCollision.GenByArgs(s.Name.O)
+	}
+	fn := &udf.Function{
+		Name:          s.Name.L,
+		Language:      s.Language,
+		ReturnType:    udf.TypeOf(s.ReturnType),
+		Deterministic: s.Deterministic,
+		Body:          s.Body,
+	}
+	if fn.ReturnType == "" {
+		return ErrCantInitializeUDF.GenByArgs(s.Name.O, fmt.Sprintf("return type %s isn't supported", s.ReturnType.CompactStr()))
+	}
+	for _, p := range s.Params {
+		tp := udf.TypeOf(p.Tp)
+		if tp == "" {
+			return ErrCantInitializeUDF.GenByArgs(s.Name.O, fmt.Sprintf("type %s of parameter %s isn't supported", p.Tp.CompactStr(), p.Name))
+		}
+		fn.Params = append(fn.Params, udf.Param{Name: p.Name, Type: tp})
+	}
+	if err := fn.Compile(); err != nil {
+		return ErrCantInitializeUDF.GenByArgs(s.Name.O, errors.Cause(err).Error())
+	}
+
+	exists, err := functionExists(e.ctx, fn.Name)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if exists {
+		if s.IfNotExists {
+			return nil
+		}
+		return ErrUDFExists.GenByArgs(s.Name.O)
+	}
+	deterministic := 0
+	if fn.Deterministic {
+		deterministic = 1
+	}
+	sql := fmt.Sprintf(`INSERT INTO %s.%s VALUES ("%s", "%s", "%s", "%s", %d, "%s");`, mysql.SystemDB, mysql.FuncTable,
+		escapeSQLString(fn.Name), escapeSQLString(fn.Language), escapeSQLString(udf.ParamsString(fn.Params)),
+		escapeSQLString(fn.ReturnType), deterministic, escapeSQLString(fn.Body))
+	_, _, err = e.ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(e.ctx, sql)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(e.reloadFunctions())
+}
+
+func (e *SimpleExec) executeDropFunction(s *ast.DropFunctionStmt) error {
+	exists, err := functionExists(e.ctx, s.Name.L)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if !exists {
+		if s.IfExists {
+			return nil
+		}
+		return ErrFunctionNotDefined.GenByArgs(s.Name.O)
+	}
+	sql := fmt.Sprintf(`DELETE FROM %s.%s WHERE name = "%s";`, mysql.SystemDB, mysql.FuncTable, escapeSQLString(s.Name.L))
+	_, _, err = e.ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(e.ctx, sql)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(e.reloadFunctions())
+}
+
+// reloadFunctions reloads the user-defined functions of this server at once, and notifies the
+// other servers to reload them.
+func (e *SimpleExec) reloadFunctions() error {
+	dom := sessionctx.GetDomain(e.ctx)
+	sysSessionPool := dom.SysSessionPool()
+	ctx, err := sysSessionPool.Get()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer sysSessionPool.Put(ctx)
+	err = dom.UDFHandle().Update(ctx.(context.Context))
+	dom.NotifyUpdateUDF(e.ctx)
+	return errors.Trace(err)
+}
+
+func functionExists(ctx context.Context, name string) (bool, error) {
+	sql := fmt.Sprintf(`SELECT name FROM %s.%s WHERE name = "%s";`, mysql.SystemDB, mysql.FuncTable, escapeSQLString(name))
+	rows, _, err := ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(ctx, sql)
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	return len(rows) > 0, nil
+}
+
+// escapeSQLString escapes str to be quoted by double quotes in a SQL.
+func escapeSQLString(str string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(str)
+}
+
 func (e *SimpleExec) executeDropStats(s *ast.DropStatsStmt) error {
 	h := sessionctx.GetDomain(e.ctx).StatsHandle()
 	if h.Lease <= 0 {
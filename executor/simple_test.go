@@ -14,12 +14,17 @@
 package executor_test
 
 import (
+	"fmt"
+	"time"
+
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb"
+	"github.com/pingcap/tidb/config"
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/executor"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/plan"
 	"github.com/pingcap/tidb/privilege/privileges"
 	"github.com/pingcap/tidb/sessionctx"
 	"github.com/pingcap/tidb/terror"
@@ -151,6 +156,41 @@ func (s *testSuite) TestUser(c *C) {
 	dropUserSQL = `DROP USER 'test1'@'localhost', 'test2'@'localhost', 'test3'@'localhost';`
 	tk.MustExec(dropUserSQL)
 
+	// Test the resource options.
+	tk.MustExec(`CREATE USER 'test1'@'localhost' IDENTIFIED BY '123' WITH MAX_USER_CONNECTIONS 3;`)
+	result = tk.MustQuery(`SELECT Password, max_user_connections FROM mysql.User WHERE User="test1" and Host="localhost"`)
+	result.Check(testkit.Rows(util.EncodePassword("123") + " 3"))
+	// Altering only the resource options keeps the password.
+	tk.MustExec(`ALTER USER 'test1'@'localhost' WITH MAX_USER_CONNECTIONS 5;`)
+	result = tk.MustQuery(`SELECT Password, max_user_connections FROM mysql.User WHERE User="test1" and Host="localhost"`)
+	result.Check(testkit.Rows(util.EncodePassword("123") + " 5"))
+	tk.MustExec(`ALTER USER 'test1'@'localhost' IDENTIFIED BY '111' WITH MAX_USER_CONNECTIONS 0;`)
+	result = tk.MustQuery(`SELECT Password, max_user_connections FROM mysql.User WHERE User="test1" and Host="localhost"`)
+	result.Check(testkit.Rows(util.EncodePassword("111") + " 0"))
+	cfg := config.GetGlobalConfig()
+	cfg.ResourceGroups = `etl=4,report=8,x"y=1`
+	defer func() {
+		cfg.ResourceGroups = ""
+	}()
+	_, err = tk.Exec(`ALTER USER 'test1'@'localhost' RESOURCE GROUP unknown;`)
+	c.Assert(terror.ErrorEqual(err, executor.ErrUnknownResourceGroup), IsTrue, Commentf("err %v", err))
+	_, err = tk.Exec("ALTER USER 'test1'@'localhost' RESOURCE GROUP `x\", Super_priv=\"Y`;")
+	c.Assert(terror.ErrorEqual(err, executor.ErrUnknownResourceGroup), IsTrue, Commentf("err %v", err))
+	tk.MustExec("ALTER USER 'test1'@'localhost' RESOURCE GROUP `x\"y`;")
+	result = tk.MustQuery(`SELECT Super_priv, Resource_group FROM mysql.User WHERE User="test1" and Host="localhost"`)
+	result.Check(testkit.Rows(`N x"y`))
+	tk.MustExec(`ALTER USER 'test1'@'localhost' WITH MAX_QUERIES_PER_HOUR 10 MAX_UPDATES_PER_HOUR 5 MAX_CONNECTIONS_PER_HOUR 2 RESOURCE GROUP etl;`)
+	result = tk.MustQuery(`SELECT max_questions, max_updates, max_connections, Resource_group FROM mysql.User WHERE User="test1" and Host="localhost"`)
+	result.Check(testkit.Rows("10 5 2 etl"))
+	tk.MustExec(`ALTER USER 'test1'@'localhost' RESOURCE GROUP default;`)
+	result = tk.MustQuery(`SELECT Password, Resource_group FROM mysql.User WHERE User="test1" and Host="localhost"`)
+	result.Check(testkit.Rows(util.EncodePassword("111") + " "))
+	tk.MustExec(`DROP USER 'test1'@'localhost';`)
+	tk.MustExec(`CREATE USER 'test1'@'localhost', 'test2'@'localhost' WITH MAX_QUERIES_PER_HOUR 1 RESOURCE GROUP report;`)
+	result = tk.MustQuery(`SELECT User, max_questions, Resource_group FROM mysql.User WHERE User like "test_" and Host="localhost" order by User`)
+	result.Check(testkit.Rows("test1 1 report", "test2 1 report"))
+	tk.MustExec(`DROP USER 'test1'@'localhost', 'test2'@'localhost';`)
+
 	// Test drop user if exists.
 	createUserSQL = `CREATE USER 'test1'@'localhost', 'test3'@'localhost';`
 	tk.MustExec(createUserSQL)
@@ -258,3 +298,473 @@ func (s *testSuite) TestDropStats(c *C) {
 	statsTbl = h.GetTableStats(tableInfo.ID)
 	c.Assert(statsTbl.Pseudo, IsTrue)
 }
+
+func (s *testSuite) TestUDF(c *C) {
+	defer testleak.AfterTest(c)()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec(`create function add_one(a int) returns int language lua deterministic as 'return a and a + 1'`)
+	tk.MustExec(`create function if not exists add_one(a int) returns int language lua as 'return a'`)
+	_, err := tk.Exec(`create function ADD_ONE(a int) returns int language lua as 'return a'`)
+	c.Assert(terror.ErrorEqual(err, executor.ErrUDFExists), IsTrue)
+	tk.MustExec(`create function greet(name varchar(20), n int) returns varchar(64) language lua as '
+		local s = "hello"
+		for i = 1, n do s = s .. ", " .. name end
+		return s'`)
+	tk.MustExec(`create function half(a decimal(10, 2)) returns decimal(10, 2) language lua deterministic as "return a / 2"`)
+	tk.MustQuery(`select name, language, params, returns, deterministic from mysql.func order by name`).Check(testkit.Rows(
+		"add_one LUA a INT INT 1",
+		"greet LUA name STRING,n INT STRING 0",
+		"half LUA a DECIMAL DECIMAL 1",
+	))
+
+	tk.MustExec("create table t (a int, b varchar(10))")
+	tk.MustExec(`insert into t values (1, "x"), (2, "y"), (0, null)`)
+	tk.MustQuery("select add_one(a), greet(b, a), half(a) from t order by a").Check(testkit.Rows(
+		"1 hello 0",
+		"2 hello, x 0.5",
+		"3 hello, y, y 1",
+	))
+	tk.MustQuery("select a from t where Add_One(a) = 3").Check(testkit.Rows("2"))
+	tk.MustQuery("select add_one('41'), add_one(1.6), add_one(null), greet(1, 0)").Check(testkit.Rows("42 3 <nil> hello"))
+
+	// The deterministic functions with constant arguments are folded.
+	tk.MustQuery("explain select add_one(1) from t").Check(testkit.Rows(
+		"TableScan_3   cop table:t, range:(-inf,+inf), keep order:false 8000",
+		"TableReader_4 Projection_2  root data:TableScan_3 8000",
+		"Projection_2  TableReader_4 root 2 8000",
+	))
+	tk.MustQuery("explain select greet('x', 1) from t").Check(testkit.Rows(
+		"TableScan_3   cop table:t, range:(-inf,+inf), keep order:false 8000",
+		"TableReader_4 Projection_2  root data:TableScan_3 8000",
+		"Projection_2  TableReader_4 root greet(x, 1) 8000",
+	))
+
+	_, err = tk.Exec("select add_one(1, 2)")
+	c.Assert(err, NotNil)
+	tk.MustExec(`create function forever() returns int language lua as 'while true do end'`)
+	tk.MustExec("set @@tidb_udf_max_steps = 1000")
+	rs, err := tk.Exec("select forever()")
+	c.Assert(err, IsNil)
+	_, err = tidb.GetRows(rs)
+	c.Assert(err.Error(), Equals, "[expression:2]user-defined function forever fails: the script exceeds the step limit")
+	tk.MustExec(`create function str(n int) returns varchar(10) language lua as 'return ("x"):rep(n)'`)
+	tk.MustExec("set @@tidb_udf_max_string_len = 5")
+	tk.MustQuery("select str(5)").Check(testkit.Rows("xxxxx"))
+	rs, err = tk.Exec("select str(a + 5) from t")
+	c.Assert(err, IsNil)
+	_, err = tidb.GetRows(rs)
+	c.Assert(err.Error(), Equals, "[expression:2]user-defined function str fails: the script creates a too long string")
+
+	_, err = tk.Exec(`create function abs(a int) returns int language lua as 'return a'`)
+	c.Assert(terror.ErrorEqual(err, executor.ErrUDFNameCollision), IsTrue)
+	_, err = tk.Exec(`create function f(a datetime) returns int language lua as 'return 1'`)
+	c.Assert(terror.ErrorEqual(err, executor.ErrCantInitializeUDF), IsTrue)
+	_, err = tk.Exec(`create function f() returns int language wasm as 'x'`)
+	c.Assert(err.Error(), Equals, "[executor:1123]Can't initialize function 'f'; language WASM isn't supported")
+	_, err = tk.Exec(`create function f() returns int language lua as 'return +'`)
+	c.Assert(err.Error(), Equals, "[executor:1123]Can't initialize function 'f'; line 1: unexpected symbol near '+'")
+
+	tk.MustExec("drop function add_one")
+	tk.MustExec("drop function if exists add_one")
+	_, err = tk.Exec("drop function add_one")
+	c.Assert(terror.ErrorEqual(err, executor.ErrFunctionNotDefined), IsTrue)
+	_, err = tk.Exec("select add_one(1)")
+	c.Assert(err.Error(), Equals, "[expression:1305]FUNCTION add_one does not exist")
+	tk.MustExec("drop function greet")
+	tk.MustExec("drop function half")
+	tk.MustExec("drop function forever")
+	tk.MustExec("drop function str")
+
+	// The names are escaped in the SQLs of the system table.
+	tk.MustExec("create function `q\\\"f`() returns int language lua as 'return 7'")
+	tk.MustQuery(`select name from mysql.func`).Check(testkit.Rows(`q\"f`))
+	tk.MustQuery("select `q\\\"f`()").Check(testkit.Rows("7"))
+	tk.MustExec("drop function `q\\\"f`")
+	tk.MustQuery(`select count(*) from mysql.func`).Check(testkit.Rows("0"))
+}
+
+func (s *testSuite) TestProcedure(c *C) {
+	defer testleak.AfterTest(c)()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec(`create procedure sum_to(in n int, out s int)
+	begin
+		declare i int default 0;
+		set s = 0;
+		l: while i < n do
+			set i = i + 1;
+			if i % 2 = 0 then
+				iterate l;
+			elseif i > 7 then
+				leave l;
+			end if;
+			set s = s + i;
+		end while l;
+	end`)
+	tk.MustExec("call sum_to(10, @s)")
+	tk.MustQuery("select @s").Check(testkit.Rows("16"))
+	tk.MustExec("call sum_to(0, @s)")
+	tk.MustQuery("select @s").Check(testkit.Rows("0"))
+	tk.MustExec("call sum_to(null, @s)")
+	tk.MustQuery("select @s").Check(testkit.Rows("0"))
+	tk.MustExec("create procedure if not exists sum_to() begin end")
+	_, err := tk.Exec("create procedure SUM_TO() begin end")
+	c.Assert(terror.ErrorEqual(err, executor.ErrSPAlreadyExists), IsTrue)
+	tk.MustQuery("select db, name from mysql.proc").Check(testkit.Rows("test sum_to"))
+
+	// The statements in the procedure see the local variables and the result sets are returned.
+	tk.MustExec("create table t (a int, b varchar(10))")
+	_, err = tk.Exec(`create procedure fill(n int, b varchar(10))
+	begin
+		declare i int default 1;
+		declare cnt int;
+		loop
+			insert into t values (i, b);
+			set i = i + 1;
+			if i > n then
+				begin
+					set cnt = (select count(*) from t);
+					select cnt, b;
+				end;
+				leave fill_loop;
+			end if;
+		end loop;
+	end`)
+	c.Assert(err.Error(), Equals, "[executor:1308]LEAVE with no matching label: fill_loop")
+	_, err = tk.Exec("call fill(3, 'x')")
+	c.Assert(terror.ErrorEqual(err, executor.ErrSPDoesNotExist), IsTrue)
+	tk.MustExec(`create procedure fill(n int, b varchar(10))
+	begin
+		declare i int default 1;
+		declare cnt int;
+		fill_loop: loop
+			insert into t values (i, b);
+			set i = i + 1;
+			if i > n then
+				begin
+					set cnt = (select count(*) from t);
+					select cnt, b;
+				end;
+				leave fill_loop;
+			end if;
+		end loop;
+	end`)
+	tk.MustQuery("call fill(3, 'x')").Check(testkit.Rows("3 x"))
+	tk.MustQuery("call fill(2, 'y')").Check(testkit.Rows("5 y"))
+	tk.MustQuery("select a, b from t order by b, a").Check(testkit.Rows("1 x", "2 x", "3 x", "1 y", "2 y"))
+
+	// All the result sets are returned if the client supports multiple results.
+	tk.MustExec(`create procedure two(a int)
+	begin
+		select b from t where t.a = a order by b;
+		update t set b = 'z' where t.a = a;
+		select count(*) from t where b = 'z';
+	end`)
+	tk.Se.GetSessionVars().ClientCapability |= mysql.ClientMultiResults
+	rss, err := tk.Se.Execute("call two(1)")
+	c.Assert(err, IsNil)
+	c.Assert(rss, HasLen, 2)
+	rows, err := tidb.GetRows(rss[0])
+	c.Assert(err, IsNil)
+	c.Assert(rows, HasLen, 2)
+	c.Assert(rows[1][0].GetString(), Equals, "y")
+	rows, err = tidb.GetRows(rss[1])
+	c.Assert(err, IsNil)
+	c.Assert(rows[0][0].GetInt64(), Equals, int64(2))
+
+	// A nested call can set the variables of the caller, and the procedure runs in its database.
+	tk.MustExec(`create procedure fact(n int, out r bigint)
+	begin
+		declare x bigint;
+		if n <= 1 then
+			set r = 1;
+		else
+			call fact(n - 1, x);
+			set r = n * x;
+		end if;
+	end`)
+	tk.MustExec("create database proc_db")
+	tk.MustExec("use proc_db")
+	_, err = tk.Exec("call fact(5, @f)")
+	c.Assert(terror.ErrorEqual(err, executor.ErrSPDoesNotExist), IsTrue)
+	_, err = tk.Exec("call test.fact(5, @f)")
+	c.Assert(err.Error(), Equals, "[executor:1456]Recursive limit 0 (as set by the max_sp_recursion_depth variable) was exceeded for routine test.fact")
+	tk.MustExec("set @@max_sp_recursion_depth = 10")
+	tk.MustExec("call test.fact(5, @f)")
+	tk.MustQuery("select @f, database()").Check(testkit.Rows("120 proc_db"))
+	tk.MustExec("create procedure inout_twice(inout v int) begin set v = v * 2; end")
+	tk.MustExec("set @v = 21")
+	tk.MustExec("call inout_twice(@v)")
+	tk.MustQuery("select @v").Check(testkit.Rows("42"))
+	tk.MustExec("create procedure p() begin call test.fill(1, database()); end")
+	tk.MustQuery("call p").Check(testkit.Rows("6 proc_db"))
+
+	_, err = tk.Exec("call test.sum_to(1)")
+	c.Assert(err.Error(), Equals, "[executor:1318]Incorrect number of arguments for PROCEDURE test.sum_to; expected 2, got 1")
+	_, err = tk.Exec("call test.sum_to(1, 2)")
+	c.Assert(terror.ErrorEqual(err, executor.ErrSPNotVarArg), IsTrue)
+	_, err = tk.Exec("create procedure bad() begin l: begin iterate l; end l; end")
+	c.Assert(err.Error(), Equals, "[executor:1308]ITERATE with no matching label: l")
+	_, err = tk.Exec("create procedure bad() begin l: loop select 1; end loop; leave l; end")
+	c.Assert(terror.ErrorEqual(err, executor.ErrSPLabelMismatch), IsTrue)
+	_, err = tk.Exec("create procedure bad(a int, A int) begin end")
+	c.Assert(terror.ErrorEqual(err, executor.ErrSPDupParam), IsTrue)
+	_, err = tk.Exec("create procedure bad() begin declare a int; declare b, a int; end")
+	c.Assert(terror.ErrorEqual(err, executor.ErrSPDupVar), IsTrue)
+	_, err = tk.Exec("create procedure no_db.bad() begin end")
+	c.Assert(err.Error(), Equals, "[schema:1049]Unknown database 'no_db'")
+
+	tk.MustExec("drop procedure test.sum_to")
+	tk.MustExec("drop procedure if exists test.sum_to")
+	_, err = tk.Exec("drop procedure test.sum_to")
+	c.Assert(err.Error(), Equals, "[executor:1305]PROCEDURE test.sum_to does not exist")
+	tk.MustExec("drop database proc_db")
+	tk.MustQuery("select name from mysql.proc order by name").Check(testkit.Rows("fact", "fill", "two"))
+	_, err = tk.Exec("call p()")
+	c.Assert(terror.ErrorEqual(err, plan.ErrNoDB), IsTrue)
+	tk.MustExec("use test")
+	tk.MustExec("drop procedure fact")
+	tk.MustExec("drop procedure fill")
+	tk.MustExec("drop procedure two")
+}
+
+func (s *testSuite) TestEvent(c *C) {
+	defer testleak.AfterTest(c)()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("create table event_log (a int)")
+	tk.MustExec(`create event e1 on schedule every 1 day starts '2030-01-01 00:00:00' ends '2030-12-31 00:00:00'
+	on completion preserve disable comment 'daily' do insert into event_log values (1)`)
+	tk.MustExec("create event if not exists e1 on schedule at '2030-01-01 00:00:00' do begin end")
+	_, err := tk.Exec("create event E1 on schedule at '2030-01-01 00:00:00' do begin end")
+	c.Assert(terror.ErrorEqual(err, executor.ErrEventAlreadyExists), IsTrue)
+	tk.MustQuery(`select event_schema, event_name, event_definition, event_type, interval_value, interval_field, starts,
+	ends, status, on_completion, event_comment from information_schema.events`).Check(testkit.Rows(
+		"test e1 insert into event_log values (1) RECURRING 1 DAY 2030-01-01 00:00:00 2030-12-31 00:00:00 DISABLED PRESERVE daily"))
+	tk.MustQuery("select next_run from mysql.event where name = 'e1'").Check(testkit.Rows("2030-01-01 00:00:00"))
+
+	_, err = tk.Exec("create event bad on schedule every 0 second do begin end")
+	c.Assert(terror.ErrorEqual(err, executor.ErrEventBadInterval), IsTrue)
+	_, err = tk.Exec("create event bad on schedule every 101 year do begin end")
+	c.Assert(terror.ErrorEqual(err, executor.ErrEventBadInterval), IsTrue)
+	_, err = tk.Exec("create event bad on schedule every 1 hour starts '2030-01-02' ends '2030-01-01' do begin end")
+	c.Assert(terror.ErrorEqual(err, executor.ErrEventEndsBeforeStart), IsTrue)
+	_, err = tk.Exec("create event no_db.bad on schedule at '2030-01-01 00:00:00' do begin end")
+	c.Assert(err.Error(), Equals, "[schema:1049]Unknown database 'no_db'")
+	_, err = tk.Exec("create event bad on schedule at '2030-01-01 00:00:00' do begin leave l; end")
+	c.Assert(err.Error(), Equals, "[executor:1308]LEAVE with no matching label: l")
+
+	// An event in the past is dropped at once, or disabled if it's preserved.
+	tk.MustExec("create event past on schedule at '2000-01-01 00:00:00' do begin end")
+	tk.MustQuery("show warnings").Check(testkit.Rows(
+		"Warning 1588 Event execution time is in the past and ON COMPLETION NOT PRESERVE is set. The event was dropped immediately after creation."))
+	tk.MustExec("create event past on schedule at '2000-01-01 00:00:00' on completion preserve do begin end")
+	tk.MustQuery("select name, status from mysql.event order by name").Check(testkit.Rows("e1 DISABLED", "past DISABLED"))
+
+	// The scheduler runs the event in its database.
+	tk.MustExec("create database event_db")
+	tk.MustExec("use event_db")
+	tk.MustExec("create event test.now on schedule at now() do insert into event_log select count(*) from mysql.event")
+	tk.MustExec("use test")
+	for i := 0; i < 50; i++ {
+		if len(tk.MustQuery("select a from event_log").Rows()) > 0 {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	tk.MustQuery("select a from event_log").Check(testkit.Rows("2"))
+	tk.MustQuery("select name from mysql.event order by name").Check(testkit.Rows("e1", "past"))
+
+	tk.MustExec("create event event_db.e on schedule every 1 minute do begin end")
+	tk.MustExec("drop database event_db")
+	tk.MustExec("drop event e1")
+	tk.MustExec("drop event if exists e1")
+	_, err = tk.Exec("drop event e1")
+	c.Assert(err.Error(), Equals, "[executor:1539]Unknown event 'e1'")
+	tk.MustExec("drop event past")
+	tk.MustQuery("select name from mysql.event").Check(testkit.Rows())
+}
+
+func (s *testSuite) TestBinding(c *C) {
+	defer testleak.AfterTest(c)()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (a int, b int, index idx_b(b))")
+	tk.MustExec("insert into t values (1, 1), (2, 2)")
+	firstPlan := func(sql string) string {
+		return fmt.Sprint(tk.MustQuery("explain " + sql).Rows()[0][0])
+	}
+	c.Assert(firstPlan("select * from t where b = 1"), Matches, "IndexScan.*")
+
+	// The binding is used by the statements which only differ in the literals.
+	tk.MustExec("create binding for select * from t where b = 1 using select * from t ignore index(idx_b) where b = 1")
+	c.Assert(firstPlan("SELECT * FROM t WHERE b = 2"), Matches, "TableScan.*")
+	tk.MustQuery("select * from t where b = 2").Check(testkit.Rows("2 2"))
+	c.Assert(firstPlan("select * from t where b = 1 and a = 1"), Matches, "IndexScan.*")
+	c.Assert(tk.MustQuery("show bindings").Rows(), DeepEquals, tk.MustQuery("select * from mysql.bind_info").Rows())
+	tk.MustQuery("select original_sql, bind_sql, default_db, status from mysql.bind_info").Check(testkit.Rows(
+		"select * from t where b = ? select * from t ignore index(idx_b) where b = 1 test using"))
+
+	// Creating the binding again replaces it.
+	tk.MustExec("create binding for select * from t where b = 1 using select * from t use index(idx_b) where b = 1")
+	c.Assert(firstPlan("select * from t where b = 3"), Matches, "IndexScan.*")
+	tk.MustQuery("select bind_sql from mysql.bind_info").Check(testkit.Rows("select * from t use index(idx_b) where b = 1"))
+
+	// The binding is only used in its database.
+	tk.MustExec("create database binding_db")
+	tk.MustExec("create table binding_db.t (a int, b int, index idx_b(b))")
+	tk.MustExec("use binding_db")
+	tk.MustExec("create binding for select * from t where b = 1 using select * from t ignore index(idx_b) where b = 1")
+	c.Assert(firstPlan("select * from t where b = 1"), Matches, "TableScan.*")
+	tk.MustExec("use test")
+	c.Assert(firstPlan("select * from t where b = 1"), Matches, "IndexScan.*")
+	tk.MustExec("drop database binding_db")
+
+	_, err := tk.Exec("create binding for select * from t where b = 1 using select * from mysql.user where b = 1")
+	c.Assert(terror.ErrorEqual(err, executor.ErrBindingNotMatch), IsTrue)
+	_, err = tk.Exec("create binding for select * from no_table using select * from no_table use index(idx_b)")
+	c.Assert(err.Error(), Equals, "[schema:1146]Table 'test.no_table' doesn't exist")
+
+	tk.MustExec("drop binding for select * from t where b = 10")
+	tk.MustQuery("select default_db from mysql.bind_info").Check(testkit.Rows("binding_db"))
+	_, err = tk.Exec("drop binding for select * from t where b = 10")
+	c.Assert(terror.ErrorEqual(err, executor.ErrBindingNotExists), IsTrue)
+}
+
+func (s *testSuite) TestRowPolicy(c *C) {
+	defer testleak.AfterTest(c)()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t, t1")
+	tk.MustExec("create table t (id int, tenant varchar(20))")
+	tk.MustExec("create table t1 (id int)")
+	tk.MustExec("insert into t values (1, 'tenant1'), (2, 'tenant2'), (3, 'tenant1')")
+	tk.MustExec("insert into t1 values (1), (2), (3)")
+	tk.MustExec("create policy tenant_only on t using (tenant = substring_index(current_user(), '@', 1))")
+	tk.MustQuery("select db, table_name, name, predicate from mysql.row_policy").Check(testkit.Rows(
+		"test t tenant_only tenant = substring_index(current_user(), '@', 1)"))
+	tk.MustExec("create user 'tenant1'@'localhost'")
+	tk.MustExec("grant select, update, delete on test.* to 'tenant1'@'localhost'")
+	tk.MustExec("flush privileges")
+
+	tk1 := testkit.NewTestKit(c, s.store)
+	se, err := tidb.CreateSession(s.store)
+	c.Assert(err, IsNil)
+	defer se.Close()
+	c.Assert(se.Auth("tenant1@localhost", nil, nil), IsTrue)
+	tk1.Se = se
+	tk1.MustExec("use test")
+	tk1.MustQuery("select id from t order by id").Check(testkit.Rows("1", "3"))
+	tk1.MustQuery("select count(*) from t where id > 1").Check(testkit.Rows("1"))
+	tk1.MustQuery("select x.id from t as x join t1 on x.id = t1.id order by x.id").Check(testkit.Rows("1", "3"))
+	tk1.MustQuery("select id from t1 where id in (select id from t) order by id").Check(testkit.Rows("1", "3"))
+	tk1.MustExec("update t set id = id + 10")
+	c.Assert(tk1.Se.AffectedRows(), Equals, uint64(2))
+	tk1.MustExec("delete from t where id = 11")
+	c.Assert(tk1.Se.AffectedRows(), Equals, uint64(1))
+	tk1.MustQuery("select id from t").Check(testkit.Rows("13"))
+
+	// A row is visible if any policy accepts it.
+	tk.MustExec("create policy small_ids on test.t using (id < 10)")
+	tk1.MustQuery("select id from t order by id").Check(testkit.Rows("2", "13"))
+	tk.MustExec("create policy if not exists small_ids on t using (id < 20)")
+	_, err = tk.Exec("create policy small_ids on t using (id < 20)")
+	c.Assert(terror.ErrorEqual(err, executor.ErrPolicyExists), IsTrue)
+
+	_, err = tk.Exec("create policy p on t using (t.id = 1)")
+	c.Assert(terror.ErrorEqual(err, plan.ErrBadPolicyPredicate), IsTrue)
+	_, err = tk.Exec("create policy p on t using (id in (select id from t1))")
+	c.Assert(terror.ErrorEqual(err, plan.ErrBadPolicyPredicate), IsTrue)
+	_, err = tk.Exec("create policy p on t using (no_column = 1)")
+	c.Assert(err.Error(), Equals, "[plan:1054]Unknown column 'no_column' in 'policy'")
+	_, err = tk.Exec("create policy p on no_table using (id = 1)")
+	c.Assert(err.Error(), Equals, "[schema:1146]Table 'test.no_table' doesn't exist")
+
+	tk.MustExec("drop policy tenant_only on t")
+	tk1.MustQuery("select id from t order by id").Check(testkit.Rows("2"))
+	tk.MustExec("drop policy small_ids on t")
+	tk1.MustQuery("select id from t order by id").Check(testkit.Rows("2", "13"))
+	_, err = tk.Exec("drop policy small_ids on t")
+	c.Assert(terror.ErrorEqual(err, executor.ErrPolicyNotExists), IsTrue)
+	tk.MustExec("drop policy if exists small_ids on t")
+	tk.MustExec("drop user 'tenant1'@'localhost'")
+}
+
+func (s *testSuite) TestMaskingPolicy(c *C) {
+	defer testleak.AfterTest(c)()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (id int primary key, ssn varchar(20), email varchar(50))")
+	tk.MustExec("insert into t values (1, '909-63-6922', 'john.doe@example.com'), (2, '123-45-6789', 'amy@example.com')")
+	tk.MustExec("create masking policy ssn_mask on t (ssn) using (mask_ssn(ssn))")
+	tk.MustExec("create masking policy email_mask on test.t (email) using (mask_email(email))")
+	tk.MustQuery("select db, table_name, column_name, name, mask_expr from mysql.column_mask order by name").Check(testkit.Rows(
+		"test t email email_mask mask_email(email)", "test t ssn ssn_mask mask_ssn(ssn)"))
+	tk.MustExec("create user 'analyst'@'localhost'")
+	tk.MustExec("grant select, update, delete on test.* to 'analyst'@'localhost'")
+	tk.MustExec("flush privileges")
+
+	// The users with the SUPER privilege read the real values.
+	tk.MustQuery("select ssn from t where id = 1").Check(testkit.Rows("909-63-6922"))
+
+	tk1 := testkit.NewTestKit(c, s.store)
+	se, err := tidb.CreateSession(s.store)
+	c.Assert(err, IsNil)
+	defer se.Close()
+	c.Assert(se.Auth("analyst@localhost", nil, nil), IsTrue)
+	tk1.Se = se
+	tk1.MustExec("use test")
+	tk1.MustQuery("select * from t order by id").Check(testkit.Rows(
+		"1 XXX-XX-6922 jXXXXXXX@example.com", "2 XXX-XX-6789 aXX@example.com"))
+	tk1.MustQuery("select x.id, x.ssn from t as x where x.id = 2").Check(testkit.Rows("2 XXX-XX-6789"))
+	tk1.MustQuery("select count(*) from t where ssn = '909-63-6922'").Check(testkit.Rows("0"))
+	tk1.MustQuery("select id from t where ssn = 'XXX-XX-6922'").Check(testkit.Rows("1"))
+	tk1.MustQuery("select ssn from t where id in (select id from t where email like 'a%')").Check(testkit.Rows("XXX-XX-6789"))
+	tk1.MustQuery("select id, ssn from t where id = 1 for update").Check(testkit.Rows("1 XXX-XX-6922"))
+	// The statements writing the table read the masked values too, but write the real ones.
+	tk1.MustExec("update t set email = 'johnd@example.com' where ssn = '909-63-6922'")
+	c.Assert(tk1.Se.AffectedRows(), Equals, uint64(0))
+	tk1.MustExec("update t set email = 'johnd@example.com' where ssn = 'XXX-XX-6922'")
+	c.Assert(tk1.Se.AffectedRows(), Equals, uint64(1))
+	tk.MustQuery("select ssn, email from t where id = 1").Check(testkit.Rows("909-63-6922 johnd@example.com"))
+	tk1.MustExec("update t set email = ssn where id = 2")
+	tk.MustQuery("select ssn, email from t where id = 2").Check(testkit.Rows("123-45-6789 XXX-XX-6789"))
+	tk1.MustExec("update t as x, t as y set x.email = 'amy@example.com' where x.id = 2 and x.ssn = y.ssn and y.ssn like '123%'")
+	c.Assert(tk1.Se.AffectedRows(), Equals, uint64(0))
+	tk1.MustExec("update t set ssn = '111-22-3333', email = concat('amy', '@example.com') where id = 2")
+	tk.MustQuery("select ssn, email from t where id = 2").Check(testkit.Rows("111-22-3333 amy@example.com"))
+	tk1.MustExec("delete from t where ssn like '111%'")
+	c.Assert(tk1.Se.AffectedRows(), Equals, uint64(0))
+	tk1.MustExec("delete t from t, t as y where t.id = y.id and y.ssn like '111%'")
+	c.Assert(tk1.Se.AffectedRows(), Equals, uint64(0))
+	tk.MustQuery("select count(*) from t").Check(testkit.Rows("2"))
+	tk.MustExec("insert into t values (3, '555-55-5555', 'c@example.com')")
+	tk1.MustExec("delete from t where ssn = 'XXX-XX-5555'")
+	c.Assert(tk1.Se.AffectedRows(), Equals, uint64(1))
+	tk.MustQuery("select id from t order by id").Check(testkit.Rows("1", "2"))
+	tk.MustExec("admin check table t")
+
+	_, err = tk.Exec("create masking policy ssn_mask on t (email) using (mask_inner(email, 1, 1))")
+	c.Assert(terror.ErrorEqual(err, executor.ErrMaskExists), IsTrue)
+	tk.MustExec("create masking policy if not exists ssn_mask on t (email) using (mask_inner(email, 1, 1))")
+	_, err = tk.Exec("create masking policy m on t (ssn) using (mask_inner(ssn, 1, 1))")
+	c.Assert(terror.ErrorEqual(err, executor.ErrColumnMasked), IsTrue)
+	_, err = tk.Exec("create masking policy m on t (id) using ((select 1))")
+	c.Assert(terror.ErrorEqual(err, plan.ErrBadMaskExpr), IsTrue)
+	_, err = tk.Exec("create masking policy m on t (no_column) using (mask_ssn(ssn))")
+	c.Assert(err.Error(), Equals, "[plan:1054]Unknown column 'no_column' in 'policy'")
+	_, err = tk.Exec("create masking policy m on t (id) using (no_column)")
+	c.Assert(err.Error(), Equals, "[plan:1054]Unknown column 'no_column' in 'policy'")
+
+	tk.MustExec("drop masking policy ssn_mask on t")
+	tk1.MustQuery("select ssn, email from t where id = 1").Check(testkit.Rows("909-63-6922 jXXXX@example.com"))
+	tk.MustExec("drop masking policy email_mask on t")
+	_, err = tk.Exec("drop masking policy email_mask on t")
+	c.Assert(terror.ErrorEqual(err, executor.ErrMaskNotExists), IsTrue)
+	tk.MustExec("drop masking policy if exists email_mask on t")
+	tk1.MustQuery("select email from t where id = 1").Check(testkit.Rows("johnd@example.com"))
+	tk.MustExec("drop user 'analyst'@'localhost'")
+}
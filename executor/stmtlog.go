@@ -0,0 +1,70 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ngaut/log"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/parser"
+	"github.com/pingcap/tidb/sessionctx/variable"
+)
+
+// StmtLogFields formats the fields of a statement log, they are the connection id, the session alias,
+// the digest of the statement and the sql, like:
+//
+//	conn=1 alias="job" digest=4a5c... sql="select * from t where a = 1"
+//
+// The literals in the sql are replaced by "?" if tidb_redact_log is on, the sql longer than maxLen is truncated.
+func StmtLogFields(vars *variable.SessionVars, sql string, maxLen int) string {
+	sql, digest := redactSQL(vars, sql)
+	if len(sql) > maxLen {
+		sql = sql[:maxLen] + fmt.Sprintf("(len:%d)", len(sql))
+	}
+	return fmt.Sprintf("conn=%d alias=%q digest=%s sql=%q", vars.ConnectionID, vars.SessionAlias, digest, sql)
+}
+
+// redactSQL returns the sql to be logged and its digest, the literals in the sql are replaced by "?"
+// if tidb_redact_log is on.
+func redactSQL(vars *variable.SessionVars, sql string) (string, string) {
+	normalized, digest := parser.NormalizeDigest(sql)
+	if vars.RedactLog {
+		return normalized, digest
+	}
+	return sql, digest
+}
+
+// connectAttrsLogField formats the connection attributes for the slow query log, so the slow queries can be
+// attributed to the applications, e.g. ` attrs="_client_name=libmysql,program_name=mysql"`.
+func connectAttrsLogField(attrs map[string]string) string {
+	if len(attrs) == 0 {
+		return ""
+	}
+	pairs := make([]string, 0, len(attrs))
+	for name, value := range attrs {
+		pairs = append(pairs, name+"="+value)
+	}
+	sort.Strings(pairs)
+	return fmt.Sprintf(" attrs=%q", strings.Join(pairs, ","))
+}
+
+// expensiveStmtLogLen is the max length of the sql in the expensive statement log.
+const expensiveStmtLogLen = 1024
+
+func logExpensiveStmt(ctx context.Context, sql string) {
+	log.Warnf("[EXPENSIVE_QUERY] %s", StmtLogFields(ctx.GetSessionVars(), sql, expensiveStmtLogLen))
+}
@@ -0,0 +1,267 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/infoschema"
+	"github.com/pingcap/tidb/parser"
+	"github.com/pingcap/tidb/plan"
+	"github.com/pingcap/tidb/util/sqlexec"
+	"github.com/pingcap/tidb/util/tracing"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// TraceExec represents a trace executor.
+// It is built from the "trace" statement, it parses, plans and executes the traced statement,
+// the result rows of the statement are discarded, and the spans of the execution are returned
+// as a tree, one span a row:
+//
+//	trace
+//	├─parse
+//	├─plan
+//	└─execute
+//	  └─ProjectionExec
+//	    └─TableReaderExecutor
+//	      └─rpc.Cop region:2 store:store1
+//
+// There is a span for every executor, its duration is the total time spent in its Open, Next and Close.
+// The kv and coprocessor requests are the children of the executor that sends them.
+//
+// For "trace plan", the statement is only planned, the optimizer trace is returned as a JSON document in one row.
+type TraceExec struct {
+	baseExecutor
+
+	stmtText  string
+	tracePlan bool
+	is        infoschema.InfoSchema
+	priority  int
+	rows      []Row
+}
+
+// Open implements the Executor Open interface.
+// The statement is executed here because the transaction is committed before Next is called.
+func (e *TraceExec) Open() error {
+	if e.tracePlan {
+		return errors.Trace(e.traceOptimizer())
+	}
+	root := tracing.NewSpan("trace")
+	if err := e.execute(root); err != nil {
+		return errors.Trace(err)
+	}
+	root.Finish()
+	e.appendSpanRows(root, "", "")
+	return nil
+}
+
+func (e *TraceExec) parse() (*ast.TraceStmt, error) {
+	charset, collation := e.ctx.GetSessionVars().GetCharsetInfo()
+	var stmts []ast.StmtNode
+	var err error
+	if sqlParser, ok := e.ctx.(sqlexec.SQLParser); ok {
+		stmts, err = sqlParser.ParseSQL(e.stmtText, charset, collation)
+	} else {
+		stmts, err = parser.New().Parse(e.stmtText, charset, collation)
+	}
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if len(stmts) != 1 {
+		return nil, errors.Errorf("%s is not a trace statement", e.stmtText)
+	}
+	trace, ok := stmts[0].(*ast.TraceStmt)
+	if !ok {
+		return nil, errors.Errorf("%s is not a trace statement", e.stmtText)
+	}
+	return trace, nil
+}
+
+// optimize plans the traced statement, ResetStmtCtx is called before, so the caller can set up
+// the statement context by f.
+func (e *TraceExec) optimize(stmt ast.StmtNode, f func()) (plan.Plan, error) {
+	ResetStmtCtx(e.ctx, stmt)
+	if f != nil {
+		f()
+	}
+	if err := plan.Preprocess(stmt, e.is, e.ctx); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if err := plan.Validate(stmt, false); err != nil {
+		return nil, errors.Trace(err)
+	}
+	p, err := plan.Optimize(e.ctx, stmt, e.is)
+	return p, errors.Trace(err)
+}
+
+// traceOptimizer plans the traced statement with the optimizer trace enabled, the trace is the only row.
+func (e *TraceExec) traceOptimizer() error {
+	trace, err := e.parse()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	optTrace := &tracing.OptimizerTrace{}
+	_, err = e.optimize(trace.Stmt, func() {
+		e.ctx.GetSessionVars().StmtCtx.OptimizerTrace = optTrace
+	})
+	if err != nil {
+		return errors.Trace(err)
+	}
+	// The trace mustn't be recorded by the other statements in this statement context.
+	e.ctx.GetSessionVars().StmtCtx.OptimizerTrace = nil
+	doc, err := optTrace.JSON()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	e.rows = append(e.rows, types.MakeDatums(doc))
+	return nil
+}
+
+func (e *TraceExec) execute(root *tracing.Span) error {
+	span := root.StartChild("parse")
+	trace, err := e.parse()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	span.Finish()
+
+	span = root.StartChild("plan")
+	p, err := e.optimize(trace.Stmt, nil)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	span.Finish()
+
+	span = root.StartChild("execute")
+	defer span.Finish()
+	sc := e.ctx.GetSessionVars().StmtCtx
+	sc.SetTraceSpan(span)
+	defer sc.SetTraceSpan(nil)
+	b := newExecutorBuilder(e.ctx, e.is, e.priority)
+	exec := b.build(p)
+	if b.err != nil {
+		return errors.Trace(b.err)
+	}
+	exec = newTracedExec(e.ctx, exec, span)
+	if err = exec.Open(); err != nil {
+		return errors.Trace(err)
+	}
+	for {
+		row, err := exec.Next()
+		if err != nil {
+			exec.Close()
+			return errors.Trace(err)
+		}
+		if row == nil {
+			break
+		}
+	}
+	return errors.Trace(exec.Close())
+}
+
+// appendSpanRows appends the rows of the span and its children in depth first order, the operations
+// are indented to show the tree.
+func (e *TraceExec) appendSpanRows(span *tracing.Span, prefix, childPrefix string) {
+	e.rows = append(e.rows, types.MakeDatums(
+		prefix+span.Operation,
+		span.Start.Format("15:04:05.000000"),
+		span.Duration.String(),
+	))
+	children := span.Children()
+	for i, child := range children {
+		if i == len(children)-1 {
+			e.appendSpanRows(child, childPrefix+"└─", childPrefix+"  ")
+		} else {
+			e.appendSpanRows(child, childPrefix+"├─", childPrefix+"│ ")
+		}
+	}
+}
+
+// Next implements the Executor Next interface.
+func (e *TraceExec) Next() (Row, error) {
+	if len(e.rows) == 0 {
+		return nil, nil
+	}
+	row := e.rows[0]
+	e.rows = e.rows[1:]
+	return row, nil
+}
+
+// tracedExec records the time spent in the executor into its span. The span is the trace span of the
+// statement while the executor works, so the requests it sends are traced as the children of the span.
+type tracedExec struct {
+	Executor
+
+	ctx    context.Context
+	span   *tracing.Span
+	parent *tracing.Span
+	opened bool
+}
+
+// newTracedExec wraps the executor and its children recursively, the span of the executor is a child of the parent.
+func newTracedExec(ctx context.Context, e Executor, parent *tracing.Span) Executor {
+	name := strings.TrimPrefix(fmt.Sprintf("%T", e), "*executor.")
+	t := &tracedExec{Executor: e, ctx: ctx, span: parent.StartChild(name), parent: parent}
+	// The selection checks the type of its child to push the conditions down, so its child isn't wrapped.
+	if _, ok := e.(*SelectionExec); ok {
+		return t
+	}
+	if b, ok := e.(interface {
+		base() *baseExecutor
+	}); ok {
+		children := b.base().children
+		for i, child := range children {
+			children[i] = newTracedExec(ctx, child, t.span)
+		}
+	}
+	return t
+}
+
+func (e *tracedExec) trace(f func() error) error {
+	sc := e.ctx.GetSessionVars().StmtCtx
+	sc.SetTraceSpan(e.span)
+	start := time.Now()
+	if !e.opened {
+		e.span.Start = start
+		e.opened = true
+	}
+	err := f()
+	e.span.Duration += time.Since(start)
+	sc.SetTraceSpan(e.parent)
+	return err
+}
+
+// Open implements the Executor Open interface.
+func (e *tracedExec) Open() error {
+	return e.trace(e.Executor.Open)
+}
+
+// Next implements the Executor Next interface.
+func (e *tracedExec) Next() (row Row, err error) {
+	err = e.trace(func() error {
+		row, err = e.Executor.Next()
+		return err
+	})
+	return
+}
+
+// Close implements the Executor Close interface.
+func (e *tracedExec) Close() error {
+	return e.trace(e.Executor.Close)
+}
@@ -92,6 +92,7 @@ type UnionScanExec struct {
 	dirty *dirtyTable
 	// usedIndex is the column offsets of the index which Src executor has used.
 	usedIndex     []int
+	usedIndexDesc []bool // Whether the used index columns are descending.
 	desc          bool
 	conditions    []expression.Expression
 	columns       []*model.ColumnInfo
@@ -220,7 +221,7 @@ func (us *UnionScanExec) pickRow(a, b Row) (Row, error) {
 
 func (us *UnionScanExec) compare(a, b Row) (int, error) {
 	sc := us.ctx.GetSessionVars().StmtCtx
-	for _, colOff := range us.usedIndex {
+	for i, colOff := range us.usedIndex {
 		aColumn := a[colOff]
 		bColumn := b[colOff]
 		cmp, err := aColumn.CompareDatum(sc, bColumn)
@@ -228,6 +229,10 @@ func (us *UnionScanExec) compare(a, b Row) (int, error) {
 			return 0, errors.Trace(err)
 		}
 		if cmp != 0 {
+			// The rows are in the order of the index keys.
+			if us.usedIndexDesc[i] {
+				cmp = -cmp
+			}
 			return cmp, nil
 		}
 	}
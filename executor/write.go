@@ -257,12 +257,11 @@ func (e *DeleteExec) deleteSingleTable() error {
 		if row == nil {
 			break
 		}
-		end := len(row)
-		if handleIsExtra(handleCol) {
-			end--
-		}
+		// The row may have the masked columns after the columns of the table.
+		offset := getTableOffset(e.SelectExec.Schema(), handleCol)
+		end := offset + len(tbl.Cols())
 		handle := row[handleCol.Index].GetInt64()
-		err = e.removeRow(e.ctx, tbl, handle, row[:end])
+		err = e.removeRow(e.ctx, tbl, handle, row[offset:end])
 		if err != nil {
 			return errors.Trace(err)
 		}
@@ -757,6 +756,8 @@ func (e *InsertValues) getColumns(tableCols []*table.Column) ([]*table.Column, e
 	var cols []*table.Column
 	var err error
 
+	// The hidden columns of the expression indices can't be inserted.
+	tableCols = table.VisibleCols(tableCols)
 	if len(e.Setlist) > 0 {
 		// Process `set` type column.
 		columns := make([]string, 0, len(e.Setlist))
@@ -991,12 +992,13 @@ func (e *InsertValues) adjustAutoIncrementDatum(row []types.Datum, i int, c *tab
 	// Change NULL to auto id.
 	// Change value 0 to auto id, if NoAutoValueOnZero SQL mode is not set.
 	if row[i].IsNull() || e.ctx.GetSessionVars().SQLMode&mysql.ModeNoAutoValueOnZero == 0 {
-		recordID, err = e.Table.AllocAutoID()
+		recordID, err = e.Table.AllocAutoID(e.ctx)
 		if e.filterErr(errors.Trace(err), ignoreErr) != nil {
 			return errors.Trace(err)
 		}
-		// It's compatible with mysql. So it sets last insert id to the first row.
-		if e.currRow == 0 {
+		// It's compatible with mysql. So it sets last insert id to the first generated id, the rows
+		// with the given ids don't change it.
+		if e.lastInsertID == 0 {
 			e.lastInsertID = uint64(recordID)
 		}
 	}
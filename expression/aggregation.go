@@ -17,6 +17,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"math"
 	"strings"
 
 	"github.com/juju/errors"
@@ -25,6 +26,7 @@ import (
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/util/sketch"
 	"github.com/pingcap/tidb/util/types"
 	tipb "github.com/pingcap/tipb/go-tipb"
 )
@@ -95,6 +97,11 @@ type aggEvaluateContext struct {
 	Value           types.Datum
 	Buffer          *bytes.Buffer // Buffer is used for group_concat.
 	GotFirstRow     bool          // It will check if the agg has met the first row key.
+	Mean            float64       // Mean and M2 are the running mean and the sum of squared differences from it for the variance functions.
+	M2              float64
+	Datums          []types.Datum       // Datums are the collected values for json_arrayagg and json_objectagg.
+	HLL             *sketch.HyperLogLog // HLL is the sketch of approx_count_distinct.
+	TDigest         *sketch.TDigest     // TDigest is the digest of approx_percentile.
 }
 
 // NewAggFunction creates a new AggregationFunction.
@@ -114,6 +121,28 @@ func NewAggFunction(funcType string, funcArgs []Expression, distinct bool) Aggre
 		return &maxMinFunction{aggFunction: newAggFunc(tp, funcArgs, distinct), isMax: false}
 	case ast.AggFuncFirstRow:
 		return &firstRowFunction{aggFunction: newAggFunc(tp, funcArgs, distinct)}
+	case ast.AggFuncBitAnd:
+		return &bitFunction{aggFunction: newAggFunc(tp, funcArgs, distinct), tp: ExprTypeAggBitAnd}
+	case ast.AggFuncBitOr:
+		return &bitFunction{aggFunction: newAggFunc(tp, funcArgs, distinct), tp: ExprTypeAggBitOr}
+	case ast.AggFuncBitXor:
+		return &bitFunction{aggFunction: newAggFunc(tp, funcArgs, distinct), tp: ExprTypeAggBitXor}
+	case ast.AggFuncVarPop:
+		return &varianceFunction{aggFunction: newAggFunc(tp, funcArgs, distinct)}
+	case ast.AggFuncVarSamp:
+		return &varianceFunction{aggFunction: newAggFunc(tp, funcArgs, distinct), sample: true}
+	case ast.AggFuncStddevPop:
+		return &varianceFunction{aggFunction: newAggFunc(tp, funcArgs, distinct), sqrt: true}
+	case ast.AggFuncStddevSamp:
+		return &varianceFunction{aggFunction: newAggFunc(tp, funcArgs, distinct), sample: true, sqrt: true}
+	case ast.AggFuncJSONArrayAgg:
+		return &jsonArrayAggFunction{aggFunction: newAggFunc(tp, funcArgs, distinct)}
+	case ast.AggFuncJSONObjectAgg:
+		return &jsonObjectAggFunction{aggFunction: newAggFunc(tp, funcArgs, distinct)}
+	case ast.AggFuncApproxCountDistinct:
+		return &approxCountDistinctFunction{aggFunction: newAggFunc(tp, funcArgs, distinct)}
+	case ast.AggFuncApproxPercentile:
+		return &approxPercentileFunction{aggFunction: newAggFunc(tp, funcArgs, distinct)}
 	}
 	return nil
 }
@@ -143,6 +172,16 @@ func NewDistAggFunc(expr *tipb.Expr, fieldTps []*types.FieldType, sc *variable.S
 		return &maxMinFunction{aggFunction: newAggFunc(ast.AggFuncMin, args, false)}, nil
 	case tipb.ExprType_First:
 		return &firstRowFunction{aggFunction: newAggFunc(ast.AggFuncFirstRow, args, false)}, nil
+	case ExprTypeAggBitAnd:
+		return &bitFunction{aggFunction: newAggFunc(ast.AggFuncBitAnd, args, false), tp: expr.Tp}, nil
+	case ExprTypeAggBitOr:
+		return &bitFunction{aggFunction: newAggFunc(ast.AggFuncBitOr, args, false), tp: expr.Tp}, nil
+	case ExprTypeAggBitXor:
+		return &bitFunction{aggFunction: newAggFunc(ast.AggFuncBitXor, args, false), tp: expr.Tp}, nil
+	case ExprTypeApproxCountDistinct:
+		return &approxCountDistinctFunction{aggFunction: newAggFunc(ast.AggFuncApproxCountDistinct, args, false)}, nil
+	case ExprTypeApproxPercentile:
+		return &approxPercentileFunction{aggFunction: newAggFunc(ast.AggFuncApproxPercentile, args, false)}, nil
 	}
 	return nil, errors.Errorf("Unknown aggregate function type %v", expr.Tp)
 }
@@ -843,7 +882,7 @@ func (mmf *maxMinFunction) Update(row []types.Datum, groupKey []byte, sc *variab
 		return nil
 	}
 	var c int
-	c, err = ctx.Value.CompareDatum(sc, value)
+	c, err = ctx.Value.CompareDatumForMaxMin(sc, value)
 	if err != nil {
 		return errors.Trace(err)
 	}
@@ -871,7 +910,7 @@ func (mmf *maxMinFunction) StreamUpdate(row []types.Datum, sc *variable.Statemen
 		return nil
 	}
 	var c int
-	c, err = ctx.Value.CompareDatum(sc, value)
+	c, err = ctx.Value.CompareDatumForMaxMin(sc, value)
 	if err != nil {
 		return errors.Trace(err)
 	}
@@ -969,3 +1008,264 @@ func (ff *firstRowFunction) CalculateDefaultValue(schema *Schema, ctx context.Co
 	}
 	return d, false
 }
+
+type bitFunction struct {
+	aggFunction
+	tp tipb.ExprType
+}
+
+// Clone implements AggregationFunction interface.
+func (bf *bitFunction) Clone() AggregationFunction {
+	nf := *bf
+	for i, arg := range bf.Args {
+		nf.Args[i] = arg.Clone()
+	}
+	nf.resultMapper = make(aggCtxMapper)
+	return &nf
+}
+
+// CalculateDefaultValue implements AggregationFunction interface.
+func (bf *bitFunction) CalculateDefaultValue(schema *Schema, ctx context.Context) (d types.Datum, valid bool) {
+	arg := bf.Args[0]
+	result, err := EvaluateExprWithNull(ctx, schema, arg)
+	if err != nil {
+		log.Warnf("Evaluate expr with null failed in function %s, err msg is %s", bf, err.Error())
+		return d, false
+	}
+	con, ok := result.(*Constant)
+	if !ok {
+		return d, false
+	}
+	if con.Value.IsNull() {
+		d.SetUint64(bf.initValue())
+		return d, true
+	}
+	val, err := bitValue(ctx.GetSessionVars().StmtCtx, con.Value)
+	if err != nil {
+		log.Warnf("Evaluate expr with null failed in function %s, err msg is %s", bf, err.Error())
+		return d, false
+	}
+	d.SetUint64(val)
+	return d, true
+}
+
+// GetType implements AggregationFunction interface.
+func (bf *bitFunction) GetType() *types.FieldType {
+	ft := types.NewFieldType(mysql.TypeLonglong)
+	ft.Flen = 21
+	types.SetBinChsClnFlag(ft)
+	ft.Flag |= mysql.UnsignedFlag | mysql.NotNullFlag
+	return ft
+}
+
+// initValue returns the result of the function when there is no non-null input.
+func (bf *bitFunction) initValue() uint64 {
+	if bf.tp == ExprTypeAggBitAnd {
+		return math.MaxUint64
+	}
+	return 0
+}
+
+// bitValue converts a datum to the uint64 value used by the bit aggregate functions.
+func bitValue(sc *variable.StatementContext, d types.Datum) (uint64, error) {
+	switch d.Kind() {
+	case types.KindUint64:
+		return d.GetUint64(), nil
+	case types.KindMysqlBit:
+		return d.GetMysqlBit().Value, nil
+	}
+	val, err := d.ToInt64(sc)
+	return uint64(val), errors.Trace(err)
+}
+
+func (bf *bitFunction) updateBit(ctx *aggEvaluateContext, row []types.Datum, sc *variable.StatementContext) error {
+	if len(bf.Args) != 1 {
+		return errors.New("Wrong number of args for AggFuncBit")
+	}
+	if !ctx.GotFirstRow {
+		ctx.Value.SetUint64(bf.initValue())
+		ctx.GotFirstRow = true
+	}
+	value, err := bf.Args[0].Eval(row)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if value.IsNull() {
+		return nil
+	}
+	val, err := bitValue(sc, value)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	result := ctx.Value.GetUint64()
+	switch bf.tp {
+	case ExprTypeAggBitAnd:
+		result &= val
+	case ExprTypeAggBitOr:
+		result |= val
+	case ExprTypeAggBitXor:
+		result ^= val
+	}
+	ctx.Value.SetUint64(result)
+	return nil
+}
+
+// Update implements AggregationFunction interface.
+func (bf *bitFunction) Update(row []types.Datum, groupKey []byte, sc *variable.StatementContext) error {
+	return bf.updateBit(bf.getContext(groupKey), row, sc)
+}
+
+// StreamUpdate implements AggregationFunction interface.
+func (bf *bitFunction) StreamUpdate(row []types.Datum, sc *variable.StatementContext) error {
+	return bf.updateBit(bf.getStreamedContext(), row, sc)
+}
+
+// GetGroupResult implements AggregationFunction interface.
+func (bf *bitFunction) GetGroupResult(groupKey []byte) (d types.Datum) {
+	ctx := bf.getContext(groupKey)
+	if !ctx.GotFirstRow {
+		d.SetUint64(bf.initValue())
+		return
+	}
+	return ctx.Value
+}
+
+// GetPartialResult implements AggregationFunction interface.
+func (bf *bitFunction) GetPartialResult(groupKey []byte) []types.Datum {
+	return []types.Datum{bf.GetGroupResult(groupKey)}
+}
+
+// GetStreamResult implements AggregationFunction interface.
+func (bf *bitFunction) GetStreamResult() (d types.Datum) {
+	if bf.streamCtx == nil {
+		d.SetUint64(bf.initValue())
+		return
+	}
+	d = bf.streamCtx.Value
+	bf.streamCtx = nil
+	return
+}
+
+// varianceFunction calculates var_pop, var_samp, stddev_pop and stddev_samp. The variance is updated by Welford's
+// algorithm, which is numerically stable and needs only one pass of the input.
+type varianceFunction struct {
+	aggFunction
+	// sample is true for the sample variance, which is divided by count - 1 instead of count.
+	sample bool
+	// sqrt is true for the standard deviation, which is the square root of the variance.
+	sqrt bool
+}
+
+// Clone implements AggregationFunction interface.
+func (vf *varianceFunction) Clone() AggregationFunction {
+	nf := *vf
+	for i, arg := range vf.Args {
+		nf.Args[i] = arg.Clone()
+	}
+	nf.resultMapper = make(aggCtxMapper)
+	return &nf
+}
+
+// CalculateDefaultValue implements AggregationFunction interface.
+func (vf *varianceFunction) CalculateDefaultValue(schema *Schema, ctx context.Context) (d types.Datum, valid bool) {
+	arg := vf.Args[0]
+	result, err := EvaluateExprWithNull(ctx, schema, arg)
+	if err != nil {
+		log.Warnf("Evaluate expr with null failed in function %s, err msg is %s", vf, err.Error())
+		return d, false
+	}
+	con, ok := result.(*Constant)
+	if !ok {
+		return d, false
+	}
+	// The single row has no variance, it's 0 for the population and NULL for the sample.
+	if !con.Value.IsNull() && !vf.sample {
+		d.SetFloat64(0)
+	}
+	return d, true
+}
+
+// GetType implements AggregationFunction interface.
+func (vf *varianceFunction) GetType() *types.FieldType {
+	ft := types.NewFieldType(mysql.TypeDouble)
+	ft.Flen, ft.Decimal = mysql.MaxRealWidth, types.UnspecifiedLength
+	types.SetBinChsClnFlag(ft)
+	return ft
+}
+
+func (vf *varianceFunction) updateVariance(ctx *aggEvaluateContext, row []types.Datum, sc *variable.StatementContext) error {
+	if len(vf.Args) != 1 {
+		return errors.New("Wrong number of args for AggFuncVariance")
+	}
+	value, err := vf.Args[0].Eval(row)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if value.IsNull() {
+		return nil
+	}
+	if vf.Distinct {
+		d, err1 := ctx.DistinctChecker.Check([]types.Datum{value})
+		if err1 != nil {
+			return errors.Trace(err1)
+		}
+		if !d {
+			return nil
+		}
+	}
+	x, err := value.ToFloat64(sc)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	ctx.Count++
+	delta := x - ctx.Mean
+	ctx.Mean += delta / float64(ctx.Count)
+	ctx.M2 += delta * (x - ctx.Mean)
+	return nil
+}
+
+func (vf *varianceFunction) calculateResult(ctx *aggEvaluateContext) (d types.Datum) {
+	n := ctx.Count
+	if vf.sample {
+		n--
+	}
+	if n <= 0 {
+		return
+	}
+	variance := ctx.M2 / float64(n)
+	if vf.sqrt {
+		variance = math.Sqrt(variance)
+	}
+	d.SetFloat64(variance)
+	return
+}
+
+// Update implements AggregationFunction interface.
+func (vf *varianceFunction) Update(row []types.Datum, groupKey []byte, sc *variable.StatementContext) error {
+	return vf.updateVariance(vf.getContext(groupKey), row, sc)
+}
+
+// StreamUpdate implements AggregationFunction interface.
+func (vf *varianceFunction) StreamUpdate(row []types.Datum, sc *variable.StatementContext) error {
+	return vf.updateVariance(vf.getStreamedContext(), row, sc)
+}
+
+// GetGroupResult implements AggregationFunction interface.
+func (vf *varianceFunction) GetGroupResult(groupKey []byte) types.Datum {
+	return vf.calculateResult(vf.getContext(groupKey))
+}
+
+// GetPartialResult implements AggregationFunction interface.
+func (vf *varianceFunction) GetPartialResult(groupKey []byte) []types.Datum {
+	return []types.Datum{vf.GetGroupResult(groupKey)}
+}
+
+// GetStreamResult implements AggregationFunction interface.
+func (vf *varianceFunction) GetStreamResult() (d types.Datum) {
+	if vf.streamCtx == nil {
+		return
+	}
+	d = vf.calculateResult(vf.streamCtx)
+	vf.streamCtx = nil
+	return
+}
@@ -0,0 +1,270 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package expression
+
+import (
+	"github.com/juju/errors"
+	"github.com/ngaut/log"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/util/codec"
+	"github.com/pingcap/tidb/util/sketch"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// approxCountDistinctFunction estimates count(distinct) by a HyperLogLog sketch. The partial result is the
+// encoded sketch, the sketches of the partial results are merged in the final mode.
+type approxCountDistinctFunction struct {
+	aggFunction
+}
+
+// Clone implements AggregationFunction interface.
+func (af *approxCountDistinctFunction) Clone() AggregationFunction {
+	nf := *af
+	for i, arg := range af.Args {
+		nf.Args[i] = arg.Clone()
+	}
+	nf.resultMapper = make(aggCtxMapper)
+	return &nf
+}
+
+// CalculateDefaultValue implements AggregationFunction interface.
+func (af *approxCountDistinctFunction) CalculateDefaultValue(schema *Schema, ctx context.Context) (d types.Datum, valid bool) {
+	for _, arg := range af.Args {
+		result, err := EvaluateExprWithNull(ctx, schema, arg)
+		if err != nil {
+			log.Warnf("Evaluate expr with null failed in function %s, err msg is %s", af, err.Error())
+			return d, false
+		}
+		con, ok := result.(*Constant)
+		if !ok {
+			return d, false
+		}
+		if con.Value.IsNull() {
+			return types.NewDatum(0), true
+		}
+	}
+	return types.NewDatum(1), true
+}
+
+// GetType implements AggregationFunction interface.
+func (af *approxCountDistinctFunction) GetType() *types.FieldType {
+	ft := types.NewFieldType(mysql.TypeLonglong)
+	ft.Flen = 21
+	types.SetBinChsClnFlag(ft)
+	return ft
+}
+
+func (af *approxCountDistinctFunction) updateSketch(ctx *aggEvaluateContext, row []types.Datum) error {
+	if ctx.HLL == nil {
+		ctx.HLL = sketch.NewHyperLogLog()
+	}
+	if af.mode == FinalMode {
+		value, err := af.Args[0].Eval(row)
+		if err != nil || value.IsNull() {
+			return errors.Trace(err)
+		}
+		h, err := sketch.UnmarshalHyperLogLog(value.GetBytes())
+		if err != nil {
+			return errors.Trace(err)
+		}
+		ctx.HLL.Merge(h)
+		return nil
+	}
+	af.datumBuf = af.datumBuf[:0]
+	for _, arg := range af.Args {
+		value, err := arg.Eval(row)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if value.IsNull() {
+			return nil
+		}
+		af.datumBuf = append(af.datumBuf, value)
+	}
+	encoded, err := codec.EncodeValue(nil, af.datumBuf...)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	ctx.HLL.Insert(encoded)
+	return nil
+}
+
+func (af *approxCountDistinctFunction) calculateResult(ctx *aggEvaluateContext) (d types.Datum) {
+	if ctx.HLL == nil {
+		d.SetInt64(0)
+		return
+	}
+	d.SetInt64(ctx.HLL.Estimate())
+	return
+}
+
+// Update implements AggregationFunction interface.
+func (af *approxCountDistinctFunction) Update(row []types.Datum, groupKey []byte, sc *variable.StatementContext) error {
+	return af.updateSketch(af.getContext(groupKey), row)
+}
+
+// StreamUpdate implements AggregationFunction interface.
+func (af *approxCountDistinctFunction) StreamUpdate(row []types.Datum, sc *variable.StatementContext) error {
+	return af.updateSketch(af.getStreamedContext(), row)
+}
+
+// GetGroupResult implements AggregationFunction interface.
+func (af *approxCountDistinctFunction) GetGroupResult(groupKey []byte) types.Datum {
+	return af.calculateResult(af.getContext(groupKey))
+}
+
+// GetPartialResult implements AggregationFunction interface.
+func (af *approxCountDistinctFunction) GetPartialResult(groupKey []byte) []types.Datum {
+	ctx := af.getContext(groupKey)
+	if ctx.HLL == nil {
+		return []types.Datum{{}}
+	}
+	return []types.Datum{types.NewBytesDatum(ctx.HLL.Marshal())}
+}
+
+// GetStreamResult implements AggregationFunction interface.
+func (af *approxCountDistinctFunction) GetStreamResult() (d types.Datum) {
+	if af.streamCtx == nil {
+		return types.NewDatum(0)
+	}
+	d = af.calculateResult(af.streamCtx)
+	af.streamCtx = nil
+	return
+}
+
+// approxPercentileFunction estimates the percentile of the first argument by a t-digest, the second argument is
+// the percentage between 0 and 100, which is converted to a float64 constant by the plan builder.
+// The partial result is the encoded digest, the digests of the partial results are merged in the final mode.
+// The function built by the coprocessor has no percentage, because it only returns the partial result.
+type approxPercentileFunction struct {
+	aggFunction
+}
+
+// Clone implements AggregationFunction interface.
+func (af *approxPercentileFunction) Clone() AggregationFunction {
+	nf := *af
+	for i, arg := range af.Args {
+		nf.Args[i] = arg.Clone()
+	}
+	nf.resultMapper = make(aggCtxMapper)
+	return &nf
+}
+
+// CalculateDefaultValue implements AggregationFunction interface.
+func (af *approxPercentileFunction) CalculateDefaultValue(schema *Schema, ctx context.Context) (d types.Datum, valid bool) {
+	result, err := EvaluateExprWithNull(ctx, schema, af.Args[0])
+	if err != nil {
+		log.Warnf("Evaluate expr with null failed in function %s, err msg is %s", af, err.Error())
+		return d, false
+	}
+	con, ok := result.(*Constant)
+	if !ok {
+		return d, false
+	}
+	// The percentile of a single row is the value itself.
+	if !con.Value.IsNull() {
+		f, err1 := con.Value.ToFloat64(ctx.GetSessionVars().StmtCtx)
+		if err1 != nil {
+			return d, false
+		}
+		d.SetFloat64(f)
+	}
+	return d, true
+}
+
+// GetType implements AggregationFunction interface.
+func (af *approxPercentileFunction) GetType() *types.FieldType {
+	ft := types.NewFieldType(mysql.TypeDouble)
+	ft.Flen, ft.Decimal = mysql.MaxRealWidth, types.UnspecifiedLength
+	types.SetBinChsClnFlag(ft)
+	return ft
+}
+
+func (af *approxPercentileFunction) updateDigest(ctx *aggEvaluateContext, row []types.Datum, sc *variable.StatementContext) error {
+	if len(af.Args) == 0 {
+		return errors.New("Wrong number of args for AggFuncApproxPercentile")
+	}
+	if ctx.TDigest == nil {
+		ctx.TDigest = sketch.NewTDigest()
+	}
+	value, err := af.Args[0].Eval(row)
+	if err != nil || value.IsNull() {
+		return errors.Trace(err)
+	}
+	if af.mode == FinalMode {
+		t, err1 := sketch.UnmarshalTDigest(value.GetBytes())
+		if err1 != nil {
+			return errors.Trace(err1)
+		}
+		ctx.TDigest.Merge(t)
+		return nil
+	}
+	x, err := value.ToFloat64(sc)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	ctx.TDigest.Add(x)
+	return nil
+}
+
+func (af *approxPercentileFunction) calculateResult(ctx *aggEvaluateContext) (d types.Datum) {
+	if ctx.TDigest == nil || len(af.Args) != 2 {
+		return
+	}
+	percentage, err := af.Args[1].Eval(nil)
+	if err != nil {
+		log.Warnf("Evaluate percentage failed in function %s, err msg is %s", af, err.Error())
+		return
+	}
+	if v, ok := ctx.TDigest.Quantile(percentage.GetFloat64() / 100); ok {
+		d.SetFloat64(v)
+	}
+	return
+}
+
+// Update implements AggregationFunction interface.
+func (af *approxPercentileFunction) Update(row []types.Datum, groupKey []byte, sc *variable.StatementContext) error {
+	return af.updateDigest(af.getContext(groupKey), row, sc)
+}
+
+// StreamUpdate implements AggregationFunction interface.
+func (af *approxPercentileFunction) StreamUpdate(row []types.Datum, sc *variable.StatementContext) error {
+	return af.updateDigest(af.getStreamedContext(), row, sc)
+}
+
+// GetGroupResult implements AggregationFunction interface.
+func (af *approxPercentileFunction) GetGroupResult(groupKey []byte) types.Datum {
+	return af.calculateResult(af.getContext(groupKey))
+}
+
+// GetPartialResult implements AggregationFunction interface.
+func (af *approxPercentileFunction) GetPartialResult(groupKey []byte) []types.Datum {
+	ctx := af.getContext(groupKey)
+	if ctx.TDigest == nil {
+		return []types.Datum{{}}
+	}
+	return []types.Datum{types.NewBytesDatum(ctx.TDigest.Marshal())}
+}
+
+// GetStreamResult implements AggregationFunction interface.
+func (af *approxPercentileFunction) GetStreamResult() (d types.Datum) {
+	if af.streamCtx == nil {
+		return
+	}
+	d = af.calculateResult(af.streamCtx)
+	af.streamCtx = nil
+	return
+}
@@ -0,0 +1,203 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package expression
+
+import (
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/util/charset"
+	"github.com/pingcap/tidb/util/types"
+	"github.com/pingcap/tidb/util/types/json"
+)
+
+// jsonAggFieldType is the field type of the json aggregate functions.
+func jsonAggFieldType() *types.FieldType {
+	ft := types.NewFieldType(mysql.TypeJSON)
+	ft.Flen = mysql.MaxBlobWidth
+	ft.Charset, ft.Collate = charset.CharsetUTF8, charset.CollationUTF8
+	ft.Flag |= mysql.BinaryFlag
+	return ft
+}
+
+// jsonArrayAggFunction aggregates the values into a json array, the nulls are kept as the json nulls.
+type jsonArrayAggFunction struct {
+	aggFunction
+}
+
+// Clone implements AggregationFunction interface.
+func (jf *jsonArrayAggFunction) Clone() AggregationFunction {
+	nf := *jf
+	for i, arg := range jf.Args {
+		nf.Args[i] = arg.Clone()
+	}
+	nf.resultMapper = make(aggCtxMapper)
+	return &nf
+}
+
+// GetType implements AggregationFunction interface.
+func (jf *jsonArrayAggFunction) GetType() *types.FieldType {
+	return jsonAggFieldType()
+}
+
+func (jf *jsonArrayAggFunction) updateArray(ctx *aggEvaluateContext, row []types.Datum) error {
+	if len(jf.Args) != 1 {
+		return errors.New("Wrong number of args for AggFuncJSONArrayAgg")
+	}
+	value, err := jf.Args[0].Eval(row)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	j, err := value.ToMysqlJSON()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	var d types.Datum
+	d.SetMysqlJSON(j)
+	ctx.Datums = append(ctx.Datums, d)
+	return nil
+}
+
+func (jf *jsonArrayAggFunction) calculateResult(ctx *aggEvaluateContext) (d types.Datum) {
+	if len(ctx.Datums) == 0 {
+		return
+	}
+	jsons := make([]json.JSON, 0, len(ctx.Datums))
+	for _, datum := range ctx.Datums {
+		jsons = append(jsons, datum.GetMysqlJSON())
+	}
+	d.SetMysqlJSON(json.CreateJSON(jsons))
+	return
+}
+
+// Update implements AggregationFunction interface.
+func (jf *jsonArrayAggFunction) Update(row []types.Datum, groupKey []byte, sc *variable.StatementContext) error {
+	return jf.updateArray(jf.getContext(groupKey), row)
+}
+
+// StreamUpdate implements AggregationFunction interface.
+func (jf *jsonArrayAggFunction) StreamUpdate(row []types.Datum, sc *variable.StatementContext) error {
+	return jf.updateArray(jf.getStreamedContext(), row)
+}
+
+// GetGroupResult implements AggregationFunction interface.
+func (jf *jsonArrayAggFunction) GetGroupResult(groupKey []byte) types.Datum {
+	return jf.calculateResult(jf.getContext(groupKey))
+}
+
+// GetPartialResult implements AggregationFunction interface.
+func (jf *jsonArrayAggFunction) GetPartialResult(groupKey []byte) []types.Datum {
+	return []types.Datum{jf.GetGroupResult(groupKey)}
+}
+
+// GetStreamResult implements AggregationFunction interface.
+func (jf *jsonArrayAggFunction) GetStreamResult() (d types.Datum) {
+	if jf.streamCtx == nil {
+		return
+	}
+	d = jf.calculateResult(jf.streamCtx)
+	jf.streamCtx = nil
+	return
+}
+
+// jsonObjectAggFunction aggregates the key-value pairs into a json object, the value of a duplicate key is the last one.
+type jsonObjectAggFunction struct {
+	aggFunction
+}
+
+// Clone implements AggregationFunction interface.
+func (jf *jsonObjectAggFunction) Clone() AggregationFunction {
+	nf := *jf
+	for i, arg := range jf.Args {
+		nf.Args[i] = arg.Clone()
+	}
+	nf.resultMapper = make(aggCtxMapper)
+	return &nf
+}
+
+// GetType implements AggregationFunction interface.
+func (jf *jsonObjectAggFunction) GetType() *types.FieldType {
+	return jsonAggFieldType()
+}
+
+// updateObject appends the key converted to a string and the value converted to a json to the context.
+func (jf *jsonObjectAggFunction) updateObject(ctx *aggEvaluateContext, row []types.Datum, sc *variable.StatementContext) error {
+	if len(jf.Args) != 2 {
+		return errors.New("Wrong number of args for AggFuncJSONObjectAgg")
+	}
+	key, err := jf.Args[0].Eval(row)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if key.IsNull() {
+		return errors.New("JSON documents may not contain NULL member names")
+	}
+	key, err = key.ConvertTo(sc, types.NewFieldType(mysql.TypeVarchar))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	value, err := jf.Args[1].Eval(row)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	j, err := value.ToMysqlJSON()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	value.SetMysqlJSON(j)
+	ctx.Datums = append(ctx.Datums, key, value)
+	return nil
+}
+
+func (jf *jsonObjectAggFunction) calculateResult(ctx *aggEvaluateContext) (d types.Datum) {
+	if len(ctx.Datums) == 0 {
+		return
+	}
+	jsonMap := make(map[string]json.JSON, len(ctx.Datums)/2)
+	for i := 0; i < len(ctx.Datums); i += 2 {
+		jsonMap[ctx.Datums[i].GetString()] = ctx.Datums[i+1].GetMysqlJSON()
+	}
+	d.SetMysqlJSON(json.CreateJSON(jsonMap))
+	return
+}
+
+// Update implements AggregationFunction interface.
+func (jf *jsonObjectAggFunction) Update(row []types.Datum, groupKey []byte, sc *variable.StatementContext) error {
+	return jf.updateObject(jf.getContext(groupKey), row, sc)
+}
+
+// StreamUpdate implements AggregationFunction interface.
+func (jf *jsonObjectAggFunction) StreamUpdate(row []types.Datum, sc *variable.StatementContext) error {
+	return jf.updateObject(jf.getStreamedContext(), row, sc)
+}
+
+// GetGroupResult implements AggregationFunction interface.
+func (jf *jsonObjectAggFunction) GetGroupResult(groupKey []byte) types.Datum {
+	return jf.calculateResult(jf.getContext(groupKey))
+}
+
+// GetPartialResult implements AggregationFunction interface.
+func (jf *jsonObjectAggFunction) GetPartialResult(groupKey []byte) []types.Datum {
+	return []types.Datum{jf.GetGroupResult(groupKey)}
+}
+
+// GetStreamResult implements AggregationFunction interface.
+func (jf *jsonObjectAggFunction) GetStreamResult() (d types.Datum) {
+	if jf.streamCtx == nil {
+		return
+	}
+	d = jf.calculateResult(jf.streamCtx)
+	jf.streamCtx = nil
+	return
+}
@@ -909,6 +909,13 @@ var funcs = map[string]functionClass{
 	ast.UncompressedLength:       &uncompressedLengthFunctionClass{baseFunctionClass{ast.UncompressedLength, 1, 1}},
 	ast.ValidatePasswordStrength: &validatePasswordStrengthFunctionClass{baseFunctionClass{ast.ValidatePasswordStrength, 1, 1}},
 
+	// data masking functions
+	ast.MaskEmail:  &maskEmailFunctionClass{baseFunctionClass{ast.MaskEmail, 1, 1}},
+	ast.MaskInner:  &maskInnerFunctionClass{baseFunctionClass{ast.MaskInner, 3, 4}},
+	ast.MaskOuter:  &maskOuterFunctionClass{baseFunctionClass{ast.MaskOuter, 3, 4}},
+	ast.MaskRandom: &maskRandomFunctionClass{baseFunctionClass{ast.MaskRandom, 1, 1}},
+	ast.MaskSSN:    &maskSSNFunctionClass{baseFunctionClass{ast.MaskSSN, 1, 1}},
+
 	// json functions
 	ast.JSONType:    &jsonTypeFunctionClass{baseFunctionClass{ast.JSONType, 1, 1}},
 	ast.JSONExtract: &jsonExtractFunctionClass{baseFunctionClass{ast.JSONExtract, 2, -1}},
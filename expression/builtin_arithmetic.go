@@ -62,6 +62,12 @@ func numericContextResultType(ft *types.FieldType) types.TypeClass {
 	return ft.ToClass()
 }
 
+// isUnsignedArithmetic checks whether ft should be treated as unsigned integer in arithmetic,
+// BIT values are always unsigned in MySQL.
+func isUnsignedArithmetic(ft *types.FieldType) bool {
+	return mysql.HasUnsignedFlag(ft.Flag) || ft.Tp == mysql.TypeBit
+}
+
 // setFlenDecimal4Int is called to set proper `Flen` and `Decimal` of return
 // type according to the two input parameter's types.
 func setFlenDecimal4Int(retTp, a, b *types.FieldType) {
@@ -122,7 +128,7 @@ func (c *arithmeticPlusFunctionClass) getFunction(args []Expression, ctx context
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
-		if mysql.HasUnsignedFlag(tpA.Flag) || mysql.HasUnsignedFlag(tpB.Flag) {
+		if isUnsignedArithmetic(tpA) || isUnsignedArithmetic(tpB) {
 			bf.tp.Flag |= mysql.UnsignedFlag
 			setFlenDecimal4Int(bf.tp, args[0].GetType(), args[1].GetType())
 			sig := &builtinArithmeticPlusIntUnsignedSig{baseIntBuiltinFunc{bf}}
@@ -249,7 +255,7 @@ func (c *arithmeticMinusFunctionClass) getFunction(args []Expression, ctx contex
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
-		if mysql.HasUnsignedFlag(tpA.Flag) || mysql.HasUnsignedFlag(tpB.Flag) {
+		if isUnsignedArithmetic(tpA) || isUnsignedArithmetic(tpB) {
 			bf.tp.Flag |= mysql.UnsignedFlag
 			setFlenDecimal4Int(bf.tp, args[0].GetType(), args[1].GetType())
 			sig := &builtinArithmeticMinusIntUnsignedSig{baseIntBuiltinFunc{bf}}
@@ -365,7 +371,7 @@ func (c *arithmeticMultiplyFunctionClass) getFunction(args []Expression, ctx con
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
-		if mysql.HasUnsignedFlag(tpA.Flag) || mysql.HasUnsignedFlag(tpB.Flag) {
+		if isUnsignedArithmetic(tpA) || isUnsignedArithmetic(tpB) {
 			bf.tp.Flag |= mysql.UnsignedFlag
 			setFlenDecimal4Int(bf.tp, args[0].GetType(), args[1].GetType())
 			sig := &builtinArithmeticMultiplyIntUnsignedSig{baseIntBuiltinFunc{bf}}
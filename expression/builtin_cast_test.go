@@ -95,7 +95,7 @@ func (s *testEvaluatorSuite) TestCast(c *C) {
 	c.Assert(res.GetUint64() == math.MaxUint64, IsTrue)
 
 	warnings := sc.GetWarnings()
-	lastWarn := warnings[len(warnings)-1]
+	lastWarn := warnings[len(warnings)-1].Err
 	c.Assert(terror.ErrorEqual(types.ErrTruncatedWrongVal, lastWarn), IsTrue)
 
 	f = NewCastFunc(tp1, &Constant{Value: types.NewDatum("-1"), RetType: types.NewFieldType(mysql.TypeString)}, ctx)
@@ -104,7 +104,7 @@ func (s *testEvaluatorSuite) TestCast(c *C) {
 	c.Assert(res.GetUint64() == 18446744073709551615, IsTrue)
 
 	warnings = sc.GetWarnings()
-	lastWarn = warnings[len(warnings)-1]
+	lastWarn = warnings[len(warnings)-1].Err
 	c.Assert(terror.ErrorEqual(types.ErrCastNegIntAsUnsigned, lastWarn), IsTrue)
 
 	f = NewCastFunc(tp1, &Constant{Value: types.NewDatum("-18446744073709551616"), RetType: types.NewFieldType(mysql.TypeString)}, ctx)
@@ -115,7 +115,7 @@ func (s *testEvaluatorSuite) TestCast(c *C) {
 	c.Assert(res.GetUint64() == uint64(t), IsTrue)
 
 	warnings = sc.GetWarnings()
-	lastWarn = warnings[len(warnings)-1]
+	lastWarn = warnings[len(warnings)-1].Err
 	c.Assert(terror.ErrorEqual(types.ErrTruncatedWrongVal, lastWarn), IsTrue)
 
 	// cast('18446744073709551616' as signed);
@@ -127,7 +127,7 @@ func (s *testEvaluatorSuite) TestCast(c *C) {
 	c.Check(res.GetInt64(), Equals, int64(-1))
 
 	warnings = sc.GetWarnings()
-	lastWarn = warnings[len(warnings)-1]
+	lastWarn = warnings[len(warnings)-1].Err
 	c.Assert(terror.ErrorEqual(types.ErrTruncatedWrongVal, lastWarn), IsTrue)
 
 	// cast('18446744073709551614' as signed);
@@ -137,7 +137,7 @@ func (s *testEvaluatorSuite) TestCast(c *C) {
 	c.Check(res.GetInt64(), Equals, int64(-2))
 
 	warnings = sc.GetWarnings()
-	lastWarn = warnings[len(warnings)-1]
+	lastWarn = warnings[len(warnings)-1].Err
 	c.Assert(terror.ErrorEqual(types.ErrCastAsSignedOverflow, lastWarn), IsTrue)
 
 	// create table t1(s1 time);
@@ -159,7 +159,7 @@ func (s *testEvaluatorSuite) TestCast(c *C) {
 	c.Assert(res.GetMysqlDecimal().Compare(resDecimal), Equals, 0)
 
 	warnings = sc.GetWarnings()
-	lastWarn = warnings[len(warnings)-1]
+	lastWarn = warnings[len(warnings)-1].Err
 	c.Assert(terror.ErrorEqual(types.ErrOverflow, lastWarn), IsTrue)
 	sc = origSc
 
@@ -294,27 +294,28 @@ func isTemporalColumn(expr Expression) bool {
 	return true
 }
 
-// getFunction sets compare built-in function signatures for various types.
-func (c *compareFunctionClass) getFunction(args []Expression, ctx context.Context) (sig builtinFunc, err error) {
-	if err = c.verifyArgs(args); err != nil {
-		return nil, errors.Trace(err)
-	}
+// getCmpTp gets the type that the two args are compared in, it follows the rules of MySQL:
+// https://dev.mysql.com/doc/refman/5.7/en/type-conversion.html
+func getCmpTp(args []Expression) evalTp {
 	ft0, ft1 := args[0].GetType(), args[1].GetType()
 	tc0, tc1 := ft0.ToClass(), ft1.ToClass()
 	cmpType := getCmpType(tc0, tc1)
 	if (tc0 == types.ClassString && ft1.Tp == mysql.TypeJSON) ||
 		(ft0.Tp == mysql.TypeJSON && tc1 == types.ClassString) {
-		sig, err = c.generateCmpSigs(args, tpJSON, ctx)
-	} else if cmpType == types.ClassString && (types.IsTypeTime(ft0.Tp) || types.IsTypeTime(ft1.Tp)) {
+		return tpJSON
+	}
+	if cmpType == types.ClassString && (types.IsTypeTime(ft0.Tp) || types.IsTypeTime(ft1.Tp)) {
 		// date[time] <cmp> date[time]
 		// string <cmp> date[time]
 		// compare as time
-		sig, err = c.generateCmpSigs(args, tpTime, ctx)
-	} else if ft0.Tp == mysql.TypeDuration && ft1.Tp == mysql.TypeDuration {
+		return tpTime
+	}
+	if ft0.Tp == mysql.TypeDuration && ft1.Tp == mysql.TypeDuration {
 		// duration <cmp> duration
 		// compare as duration
-		sig, err = c.generateCmpSigs(args, tpDuration, ctx)
-	} else if cmpType == types.ClassReal || cmpType == types.ClassString {
+		return tpDuration
+	}
+	if cmpType == types.ClassReal || cmpType == types.ClassString {
 		_, isConst0 := args[0].(*Constant)
 		_, isConst1 := args[1].(*Constant)
 		if (tc0 == types.ClassDecimal && !isConst0 && tc1 == types.ClassString && isConst1) ||
@@ -326,7 +327,7 @@ func (c *compareFunctionClass) getFunction(args []Expression, ctx context.Contex
 
 				Do comparision as decimal rather than float, in order not to lose precision.
 			)*/
-			cmpType = types.ClassDecimal
+			return tpDecimal
 		} else if isTemporalColumn(args[0]) && isConst1 ||
 			isTemporalColumn(args[1]) && isConst0 {
 			/*
@@ -341,36 +342,134 @@ func (c *compareFunctionClass) getFunction(args []Expression, ctx context.Contex
 				col = args[1].(*Column)
 			}
 			if col.GetType().Tp == mysql.TypeDuration {
-				sig, err = c.generateCmpSigs(args, tpDuration, ctx)
-			} else {
-				sig, err = c.generateCmpSigs(args, tpTime, ctx)
+				return tpDuration
 			}
+			return tpTime
 		}
 	}
+	switch cmpType {
+	case types.ClassString:
+		return tpString
+	case types.ClassInt:
+		return tpInt
+	case types.ClassDecimal:
+		return tpDecimal
+	}
+	return tpReal
+}
+
+// IsComparedInColumnType checks whether the column is compared with the constant in the type of the column, only
+// then the order of the column values in an index is the order of the comparison. A string column compared with a
+// number or a time isn't, e.g. "varchar_col = 123" compares them as doubles, the strings '123', '0123' and '123abc'
+// are all equal to 123 but they aren't adjacent in the index. The other columns always are, because the constants
+// are converted to the types of them when building the ranges.
+func IsComparedInColumnType(col *Column, con *Constant) bool {
+	ft := col.GetType()
+	if ft.ToClass() != types.ClassString || isTemporalColumn(col) || ft.Tp == mysql.TypeEnum || ft.Tp == mysql.TypeSet {
+		return true
+	}
+	return getCmpTp([]Expression{col, con}) == tpString
+}
+
+// refineArgs converts the non-int constant compared with the int column to an int constant, so the comparison is
+// done as int and the index on the column can be used. The constant is kept if the conversion isn't exact for
+// "=", "<=>" and "!=", e.g. "int_col = 1.5" is always false. Otherwise it's rounded up for "<" and ">=", and rounded
+// down for "<=" and ">", e.g. "int_col < 1.5" is "int_col < 2", "int_col > 1.5" is "int_col > 1".
+func (c *compareFunctionClass) refineArgs(args []Expression, ctx context.Context) []Expression {
+	col, con, op := args[0], args[1], c.op
+	if _, ok := col.(*Constant); ok {
+		col, con = con, col
+		op = reverseCmpOp(op)
+	}
+	constant, ok := con.(*Constant)
+	if !ok || col.GetTypeClass() != types.ClassInt || constant.GetTypeClass() == types.ClassInt {
+		return args
+	}
+	if _, ok := col.(*Constant); ok {
+		return args
+	}
+	ft := types.NewFieldType(mysql.TypeLonglong)
+	types.SetBinChsClnFlag(ft)
+	refined := &Constant{RetType: ft}
+	if !constant.Value.IsNull() {
+		f, err := constant.Value.ToFloat64(ctx.GetSessionVars().StmtCtx)
+		// The doubles beyond 2^53 can't keep all the digits of the constant.
+		if err != nil || math.Abs(f) >= 1<<53 {
+			return args
+		}
+		if f != math.Trunc(f) {
+			switch op {
+			case opcode.LT, opcode.GE:
+				f = math.Ceil(f)
+			case opcode.LE, opcode.GT:
+				f = math.Floor(f)
+			default:
+				return args
+			}
+		}
+		refined.Value.SetInt64(int64(f))
+	}
+	if args[0] == con {
+		return []Expression{refined, col}
+	}
+	return []Expression{col, refined}
+}
+
+// reverseCmpOp gets the operator of the comparison whose args are swapped, e.g. "1 < a" is "a > 1".
+func reverseCmpOp(op opcode.Op) opcode.Op {
+	switch op {
+	case opcode.LT:
+		return opcode.GT
+	case opcode.LE:
+		return opcode.GE
+	case opcode.GT:
+		return opcode.LT
+	case opcode.GE:
+		return opcode.LE
+	}
+	return op
+}
+
+// getFunction sets compare built-in function signatures for various types.
+func (c *compareFunctionClass) getFunction(args []Expression, ctx context.Context) (sig builtinFunc, err error) {
+	if err = c.verifyArgs(args); err != nil {
+		return nil, errors.Trace(err)
+	}
+	args = c.refineArgs(args, ctx)
+	sig, err = c.generateCmpSigs(args, getCmpTp(args), ctx)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
-	if sig == nil {
-		switch cmpType {
-		case types.ClassString:
-			sig, err = c.generateCmpSigs(args, tpString, ctx)
-		case types.ClassInt:
-			sig, err = c.generateCmpSigs(args, tpInt, ctx)
-		case types.ClassDecimal:
-			sig, err = c.generateCmpSigs(args, tpDecimal, ctx)
-		case types.ClassReal:
-			sig, err = c.generateCmpSigs(args, tpReal, ctx)
+	return sig.setSelf(sig), nil
+}
+
+// newBaseCmpFunc creates the base function of the comparison whose args are compared in tp. The date, datetime and
+// timestamp args aren't casted when they're compared as time, e.g. the date '2017-01-01' equals the datetime
+// '2017-01-01 00:00:00' as they are, then the time columns compared with the constants can build the ranges.
+func newBaseCmpFunc(args []Expression, ctx context.Context, tp evalTp) (bf baseBuiltinFunc, err error) {
+	if tp != tpTime {
+		return newBaseBuiltinFuncWithTp(args, ctx, tpInt, tp, tp)
+	}
+	for i := range args {
+		if types.IsTypeTime(args[i].GetType().Tp) {
+			continue
 		}
+		args[i], err = WrapWithCastAsTime(args[i], types.NewFieldType(mysql.TypeDatetime), ctx)
 		if err != nil {
-			return nil, errors.Trace(err)
+			return bf, errors.Trace(err)
 		}
 	}
-	return sig.setSelf(sig), nil
+	bf, err = newBaseBuiltinFuncWithTp(nil, ctx, tpInt)
+	if err != nil {
+		return bf, errors.Trace(err)
+	}
+	bf.args, bf.argValues = args, make([]types.Datum, len(args))
+	return bf, nil
 }
 
 // genCmpSigs generates compare function signatures.
 func (c *compareFunctionClass) generateCmpSigs(args []Expression, tp evalTp, ctx context.Context) (sig builtinFunc, err error) {
-	bf, err := newBaseBuiltinFuncWithTp(args, ctx, tpInt, tp, tp)
+	bf, err := newBaseCmpFunc(args, ctx, tp)
 	if err != nil {
 		return sig, errors.Trace(err)
 	}
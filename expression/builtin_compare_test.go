@@ -106,6 +106,83 @@ func (s *testEvaluatorSuite) TestCompare(c *C) {
 	args = bf.getArgs()
 	c.Assert(args[0].GetType().Tp, Equals, mysql.TypeDatetime)
 	c.Assert(args[1].GetType().Tp, Equals, mysql.TypeDatetime)
+
+	// test <date column> <cmp> <non-time const>, the date column isn't casted.
+	dateCol := &Column{RetType: types.NewFieldType(mysql.TypeDate)}
+	bf, err = funcs[ast.LT].getFunction([]Expression{dateCol, stringCon}, s.ctx)
+	c.Assert(err, IsNil)
+	args = bf.getArgs()
+	c.Assert(args[0], Equals, dateCol)
+	c.Assert(args[1].GetType().Tp, Equals, mysql.TypeDatetime)
+
+	// test <int column> <cmp> <non-int const>, the const is converted to int if the comparison keeps.
+	intCol := &Column{RetType: types.NewFieldType(mysql.TypeLonglong)}
+	refineTests := []struct {
+		arg      interface{}
+		funcName string
+		colFirst bool
+		refined  interface{}
+	}{
+		{1.5, ast.LT, true, int64(2)},
+		{1.5, ast.GE, true, int64(2)},
+		{1.5, ast.LE, true, int64(1)},
+		{1.5, ast.GT, true, int64(1)},
+		{-1.5, ast.GT, true, int64(-2)},
+		{1.5, ast.LT, false, int64(1)},
+		{1.5, ast.GE, false, int64(1)},
+		{"1.0", ast.EQ, true, int64(1)},
+		{types.NewDecFromFloatForTest(2), ast.NullEQ, true, int64(2)},
+		{nil, ast.GT, true, nil},
+		{1.5, ast.EQ, true, 1.5},
+		{1.5, ast.NE, false, 1.5},
+		{1e20, ast.LT, true, 1e20},
+	}
+	for _, t := range refineTests {
+		con := primitiveValsToConstants([]interface{}{t.arg})[0]
+		conArg := 1
+		args = []Expression{intCol, con}
+		if !t.colFirst {
+			conArg = 0
+			args = []Expression{con, intCol}
+		}
+		bf, err = funcs[t.funcName].getFunction(args, s.ctx)
+		c.Assert(err, IsNil)
+		args = bf.getArgs()
+		refined, ok := args[conArg].(*Constant)
+		c.Assert(ok, IsTrue, Commentf("%v", t))
+		c.Assert(refined.Value.GetValue(), DeepEquals, t.refined, Commentf("%v", t))
+		_, isCol := args[1-conArg].(*Column)
+		c.Assert(isCol, Equals, refined.GetTypeClass() == types.ClassInt, Commentf("%v", t))
+	}
+}
+
+func (s *testEvaluatorSuite) TestIsComparedInColumnType(c *C) {
+	defer testleak.AfterTest(c)()
+	intCol := &Column{RetType: types.NewFieldType(mysql.TypeLonglong)}
+	stringCol := &Column{RetType: types.NewFieldType(mysql.TypeVarchar)}
+	dateCol := &Column{RetType: types.NewFieldType(mysql.TypeDate)}
+	enumCol := &Column{RetType: types.NewFieldType(mysql.TypeEnum)}
+	tests := []struct {
+		col      *Column
+		arg      interface{}
+		expected bool
+	}{
+		{intCol, "1", true},
+		{intCol, 1.5, true},
+		{stringCol, "1", true},
+		{stringCol, nil, true},
+		{stringCol, 1, false},
+		{stringCol, 1.5, false},
+		{stringCol, types.NewDecFromFloatForTest(1.5), false},
+		{stringCol, types.Time{Time: types.FromGoTime(time.Now()), Type: mysql.TypeDatetime}, false},
+		{dateCol, "2017-01-01 10:10", true},
+		{dateCol, 20170101, true},
+		{enumCol, 1, true},
+	}
+	for _, t := range tests {
+		con := primitiveValsToConstants([]interface{}{t.arg})[0].(*Constant)
+		c.Assert(IsComparedInColumnType(t.col, con), Equals, t.expected, Commentf("%v", t))
+	}
 }
 
 func (s *testEvaluatorSuite) TestCoalesce(c *C) {
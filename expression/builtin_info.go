@@ -101,7 +101,6 @@ type builtinFoundRowsSig struct {
 
 // eval evals a builtinFoundRowsSig.
 // See https://dev.mysql.com/doc/refman/5.6/en/information-functions.html#function_found-rows
-// TODO: SQL_CALC_FOUND_ROWS and LIMIT not support for now, We will finish in another PR.
 func (b *builtinFoundRowsSig) eval(_ []types.Datum) (d types.Datum, err error) {
 	data := b.ctx.GetSessionVars()
 	if data == nil {
@@ -244,7 +243,10 @@ func (b *builtinLastInsertIDWithIDSig) evalInt(row []types.Datum) (res int64, is
 		return res, isNull, errors.Trace(err)
 	}
 
-	b.ctx.GetSessionVars().SetLastInsertID(uint64(res))
+	// The value is returned by LAST_INSERT_ID() since now, it's also the insert ID sent to the client.
+	sessVars := b.ctx.GetSessionVars()
+	sessVars.PrevLastInsertID = uint64(res)
+	sessVars.SetLastInsertID(uint64(res))
 	return res, false, nil
 }
 
@@ -403,5 +405,6 @@ type builtinRowCountSig struct {
 // eval evals a builtinRowCountSig.
 // See https://dev.mysql.com/doc/refman/5.7/en/information-functions.html#function_row-count
 func (b *builtinRowCountSig) eval(row []types.Datum) (d types.Datum, err error) {
-	return d, errFunctionNotExists.GenByArgs("ROW_COUNT")
+	d.SetInt64(b.ctx.GetSessionVars().PrevAffectedRows)
+	return d, nil
 }
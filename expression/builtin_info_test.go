@@ -154,11 +154,14 @@ func (s *testEvaluatorSuite) TestCollation(c *C) {
 
 func (s *testEvaluatorSuite) TestRowCount(c *C) {
 	defer testleak.AfterTest(c)()
+	s.ctx.GetSessionVars().PrevAffectedRows = 3
 	fc := funcs[ast.RowCount]
 	f, err := fc.getFunction(datumsToConstants(types.MakeDatums()), s.ctx)
 	c.Assert(err, IsNil)
-	_, err = f.eval(nil)
-	c.Assert(err, ErrorMatches, "*FUNCTION ROW_COUNT does not exist")
+	d, err := f.eval(nil)
+	c.Assert(err, IsNil)
+	c.Assert(d.GetInt64(), Equals, int64(3))
+	c.Assert(f.isDeterministic(), IsFalse)
 }
 
 // Test case for tidb_server().
@@ -0,0 +1,303 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package expression
+
+import (
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// The masking functions hide the sensitive parts of the strings, they're usually used in the masking policies.
+// MASK_INNER, MASK_OUTER and MASK_SSN are like the functions of the MySQL Enterprise Data Masking.
+// See https://dev.mysql.com/doc/refman/8.0/en/data-masking-functions.html
+var (
+	_ functionClass = &maskInnerFunctionClass{}
+	_ functionClass = &maskOuterFunctionClass{}
+	_ functionClass = &maskSSNFunctionClass{}
+	_ functionClass = &maskEmailFunctionClass{}
+	_ functionClass = &maskRandomFunctionClass{}
+)
+
+var (
+	_ builtinFunc = &builtinMaskInnerSig{}
+	_ builtinFunc = &builtinMaskOuterSig{}
+	_ builtinFunc = &builtinMaskSSNSig{}
+	_ builtinFunc = &builtinMaskEmailSig{}
+	_ builtinFunc = &builtinMaskRandomSig{}
+)
+
+// defaultMaskChar is the character replaces the masked characters.
+const defaultMaskChar = 'X'
+
+type maskInnerFunctionClass struct {
+	baseFunctionClass
+}
+
+func (c *maskInnerFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
+	bf, err := newMaskMarginsBuiltinFunc(c.baseFunctionClass, args, ctx)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	sig := &builtinMaskInnerSig{baseStringBuiltinFunc{bf}}
+	return sig.setSelf(sig), nil
+}
+
+type maskOuterFunctionClass struct {
+	baseFunctionClass
+}
+
+func (c *maskOuterFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
+	bf, err := newMaskMarginsBuiltinFunc(c.baseFunctionClass, args, ctx)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	sig := &builtinMaskOuterSig{baseStringBuiltinFunc{bf}}
+	return sig.setSelf(sig), nil
+}
+
+// newMaskMarginsBuiltinFunc creates the function of (str, margin1, margin2[, mask_char]).
+func newMaskMarginsBuiltinFunc(c baseFunctionClass, args []Expression, ctx context.Context) (bf baseBuiltinFunc, err error) {
+	if err = c.verifyArgs(args); err != nil {
+		return bf, errors.Trace(err)
+	}
+	argTps := []evalTp{tpString, tpInt, tpInt}
+	if len(args) == 4 {
+		argTps = append(argTps, tpString)
+	}
+	bf, err = newBaseBuiltinFuncWithTp(args, ctx, tpString, argTps...)
+	if err != nil {
+		return bf, errors.Trace(err)
+	}
+	bf.tp.Flen = args[0].GetType().Flen
+	return bf, nil
+}
+
+type builtinMaskInnerSig struct {
+	baseStringBuiltinFunc
+}
+
+// evalString evals MASK_INNER(str, margin1, margin2[, mask_char]), it masks the characters except the margin1
+// leftmost and the margin2 rightmost ones.
+func (b *builtinMaskInnerSig) evalString(row []types.Datum) (string, bool, error) {
+	runes, margin1, margin2, maskChar, isNull, err := evalMaskMargins(&b.baseBuiltinFunc, row, "mask_inner")
+	if isNull || err != nil {
+		return "", isNull, errors.Trace(err)
+	}
+	for i := margin1; i < len(runes)-margin2; i++ {
+		runes[i] = maskChar
+	}
+	return string(runes), false, nil
+}
+
+type builtinMaskOuterSig struct {
+	baseStringBuiltinFunc
+}
+
+// evalString evals MASK_OUTER(str, margin1, margin2[, mask_char]), it masks the margin1 leftmost and the margin2
+// rightmost characters.
+func (b *builtinMaskOuterSig) evalString(row []types.Datum) (string, bool, error) {
+	runes, margin1, margin2, maskChar, isNull, err := evalMaskMargins(&b.baseBuiltinFunc, row, "mask_outer")
+	if isNull || err != nil {
+		return "", isNull, errors.Trace(err)
+	}
+	for i := range runes {
+		if i < margin1 || i >= len(runes)-margin2 {
+			runes[i] = maskChar
+		}
+	}
+	return string(runes), false, nil
+}
+
+// evalMaskMargins evaluates the arguments of MASK_INNER and MASK_OUTER, the margins are limited to the length of
+// the string.
+func evalMaskMargins(b *baseBuiltinFunc, row []types.Datum, name string) (runes []rune, margin1, margin2 int,
+	maskChar rune, isNull bool, err error) {
+	sc := b.ctx.GetSessionVars().StmtCtx
+	str, isNull, err := b.args[0].EvalString(row, sc)
+	if isNull || err != nil {
+		return nil, 0, 0, 0, isNull, errors.Trace(err)
+	}
+	var margins [2]int64
+	for i := range margins {
+		margins[i], isNull, err = b.args[i+1].EvalInt(row, sc)
+		if isNull || err != nil {
+			return nil, 0, 0, 0, isNull, errors.Trace(err)
+		}
+		if margins[i] < 0 {
+			return nil, 0, 0, 0, false, errIncorrectArgs.GenByArgs(name)
+		}
+	}
+	maskChar = defaultMaskChar
+	if len(b.args) == 4 {
+		var s string
+		s, isNull, err = b.args[3].EvalString(row, sc)
+		if isNull || err != nil {
+			return nil, 0, 0, 0, isNull, errors.Trace(err)
+		}
+		if utf8.RuneCountInString(s) != 1 {
+			return nil, 0, 0, 0, false, errIncorrectArgs.GenByArgs(name)
+		}
+		maskChar, _ = utf8.DecodeRuneInString(s)
+	}
+	runes = []rune(str)
+	margin1, margin2 = len(runes), len(runes)
+	if margins[0] < int64(len(runes)) {
+		margin1 = int(margins[0])
+	}
+	if margins[1] < int64(len(runes)) {
+		margin2 = int(margins[1])
+	}
+	return runes, margin1, margin2, maskChar, false, nil
+}
+
+type maskSSNFunctionClass struct {
+	baseFunctionClass
+}
+
+func (c *maskSSNFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
+	if err := c.verifyArgs(args); err != nil {
+		return nil, errors.Trace(err)
+	}
+	bf, err := newBaseBuiltinFuncWithTp(args, ctx, tpString, tpString)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	bf.tp.Flen = args[0].GetType().Flen
+	sig := &builtinMaskSSNSig{baseStringBuiltinFunc{bf}}
+	return sig.setSelf(sig), nil
+}
+
+type builtinMaskSSNSig struct {
+	baseStringBuiltinFunc
+}
+
+// evalString evals MASK_SSN(str), it masks the digits except the last 4 ones, so '909-63-6922' is
+// masked as 'XXX-XX-6922'.
+func (b *builtinMaskSSNSig) evalString(row []types.Datum) (string, bool, error) {
+	str, isNull, err := b.args[0].EvalString(row, b.ctx.GetSessionVars().StmtCtx)
+	if isNull || err != nil {
+		return "", isNull, errors.Trace(err)
+	}
+	runes := []rune(str)
+	kept := 0
+	for i := len(runes) - 1; i >= 0; i-- {
+		if !unicode.IsDigit(runes[i]) {
+			continue
+		}
+		if kept < 4 {
+			kept++
+			continue
+		}
+		runes[i] = defaultMaskChar
+	}
+	return string(runes), false, nil
+}
+
+type maskEmailFunctionClass struct {
+	baseFunctionClass
+}
+
+func (c *maskEmailFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
+	if err := c.verifyArgs(args); err != nil {
+		return nil, errors.Trace(err)
+	}
+	bf, err := newBaseBuiltinFuncWithTp(args, ctx, tpString, tpString)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	bf.tp.Flen = args[0].GetType().Flen
+	sig := &builtinMaskEmailSig{baseStringBuiltinFunc{bf}}
+	return sig.setSelf(sig), nil
+}
+
+type builtinMaskEmailSig struct {
+	baseStringBuiltinFunc
+}
+
+// evalString evals MASK_EMAIL(str), it keeps the first character of the local part and the domain, so
+// 'john.doe@example.com' is masked as 'jXXXXXXX@example.com'. A string without '@' is masked except its
+// first character.
+func (b *builtinMaskEmailSig) evalString(row []types.Datum) (string, bool, error) {
+	str, isNull, err := b.args[0].EvalString(row, b.ctx.GetSessionVars().StmtCtx)
+	if isNull || err != nil {
+		return "", isNull, errors.Trace(err)
+	}
+	local, domain := str, ""
+	if i := strings.LastIndexByte(str, '@'); i >= 0 {
+		local, domain = str[:i], str[i:]
+	}
+	runes := []rune(local)
+	for i := 1; i < len(runes); i++ {
+		runes[i] = defaultMaskChar
+	}
+	return string(runes) + domain, false, nil
+}
+
+type maskRandomFunctionClass struct {
+	baseFunctionClass
+}
+
+func (c *maskRandomFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
+	if err := c.verifyArgs(args); err != nil {
+		return nil, errors.Trace(err)
+	}
+	bf, err := newBaseBuiltinFuncWithTp(args, ctx, tpString, tpString)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	bf.tp.Flen = args[0].GetType().Flen
+	bf.deterministic = false
+	sig := &builtinMaskRandomSig{baseStringBuiltinFunc{bf}}
+	return sig.setSelf(sig), nil
+}
+
+type builtinMaskRandomSig struct {
+	baseStringBuiltinFunc
+}
+
+var (
+	maskRandMu sync.Mutex
+	maskRand   = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// evalString evals MASK_RANDOM(str), it replaces the digits and the letters by the random ones of the same kind
+// and case, the other characters are kept, so the format of the value like a phone number is kept.
+func (b *builtinMaskRandomSig) evalString(row []types.Datum) (string, bool, error) {
+	str, isNull, err := b.args[0].EvalString(row, b.ctx.GetSessionVars().StmtCtx)
+	if isNull || err != nil {
+		return "", isNull, errors.Trace(err)
+	}
+	runes := []rune(str)
+	maskRandMu.Lock()
+	for i, r := range runes {
+		switch {
+		case r >= '0' && r <= '9':
+			runes[i] = '0' + rune(maskRand.Intn(10))
+		case r >= 'a' && r <= 'z':
+			runes[i] = 'a' + rune(maskRand.Intn(26))
+		case r >= 'A' && r <= 'Z':
+			runes[i] = 'A' + rune(maskRand.Intn(26))
+		}
+	}
+	maskRandMu.Unlock()
+	return string(runes), false, nil
+}
@@ -0,0 +1,91 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package expression
+
+import (
+	"unicode"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/util/testleak"
+	"github.com/pingcap/tidb/util/types"
+)
+
+func (s *testEvaluatorSuite) TestMaskFunctions(c *C) {
+	defer testleak.AfterTest(c)()
+	tests := []struct {
+		fn     string
+		args   []interface{}
+		expect interface{}
+		getErr bool
+	}{
+		{ast.MaskInner, []interface{}{"abcdef", 1, 2}, "aXXXef", false},
+		{ast.MaskInner, []interface{}{"abcdef", 0, 5, "*"}, "*bcdef", false},
+		{ast.MaskInner, []interface{}{"abcdef", 3, 4}, "abcdef", false},
+		{ast.MaskInner, []interface{}{"数据库测试", 1, 1}, "数XXX试", false},
+		{ast.MaskInner, []interface{}{"abcdef", -1, 2}, nil, true},
+		{ast.MaskInner, []interface{}{"abcdef", 1, 2, "**"}, nil, true},
+		{ast.MaskInner, []interface{}{nil, 1, 2}, nil, false},
+		{ast.MaskOuter, []interface{}{"abcdef", 1, 2}, "XbcdXX", false},
+		{ast.MaskOuter, []interface{}{"abcdef", 10, 0, "#"}, "######", false},
+		{ast.MaskOuter, []interface{}{"abcdef", 1, nil}, nil, false},
+		{ast.MaskSSN, []interface{}{"909-63-6922"}, "XXX-XX-6922", false},
+		{ast.MaskSSN, []interface{}{"909636922"}, "XXXXX6922", false},
+		{ast.MaskSSN, []interface{}{"692"}, "692", false},
+		{ast.MaskSSN, []interface{}{nil}, nil, false},
+		{ast.MaskEmail, []interface{}{"john.doe@example.com"}, "jXXXXXXX@example.com", false},
+		{ast.MaskEmail, []interface{}{"a@b@example.com"}, "aXX@example.com", false},
+		{ast.MaskEmail, []interface{}{"johndoe"}, "jXXXXXX", false},
+		{ast.MaskEmail, []interface{}{""}, "", false},
+		{ast.MaskEmail, []interface{}{nil}, nil, false},
+	}
+	for _, t := range tests {
+		f, err := newFunctionForTest(s.ctx, t.fn, primitiveValsToConstants(t.args)...)
+		c.Assert(err, IsNil)
+		d, err := f.Eval(nil)
+		if t.getErr {
+			c.Assert(err, NotNil, Commentf("%s%v", t.fn, t.args))
+			continue
+		}
+		c.Assert(err, IsNil, Commentf("%s%v", t.fn, t.args))
+		if t.expect == nil {
+			c.Assert(d.Kind(), Equals, types.KindNull, Commentf("%s%v", t.fn, t.args))
+		} else {
+			c.Assert(d.GetString(), Equals, t.expect, Commentf("%s%v", t.fn, t.args))
+		}
+	}
+}
+
+func (s *testEvaluatorSuite) TestMaskRandom(c *C) {
+	defer testleak.AfterTest(c)()
+	f, err := funcs[ast.MaskRandom].getFunction(datumsToConstants(types.MakeDatums("Ab-12 c@")), s.ctx)
+	c.Assert(err, IsNil)
+	c.Assert(f.isDeterministic(), IsFalse)
+	d, err := f.eval(nil)
+	c.Assert(err, IsNil)
+	masked := []rune(d.GetString())
+	c.Assert(masked, HasLen, 8)
+	// The kinds and the cases of the characters are kept.
+	c.Assert(unicode.IsUpper(masked[0]), IsTrue)
+	c.Assert(unicode.IsLower(masked[1]), IsTrue)
+	c.Assert(unicode.IsDigit(masked[3]) && unicode.IsDigit(masked[4]), IsTrue)
+	c.Assert(string(masked[2])+string(masked[5])+string(masked[7]), Equals, "- @")
+	c.Assert(unicode.IsLower(masked[6]), IsTrue)
+
+	f, err = funcs[ast.MaskRandom].getFunction(datumsToConstants(types.MakeDatums(nil)), s.ctx)
+	c.Assert(err, IsNil)
+	d, err = f.eval(nil)
+	c.Assert(err, IsNil)
+	c.Assert(d.Kind(), Equals, types.KindNull)
+}
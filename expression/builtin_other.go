@@ -14,8 +14,6 @@
 package expression
 
 import (
-	"strings"
-
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/sessionctx/variable"
@@ -154,18 +152,54 @@ func (b *builtinSetVarSig) eval(row []types.Datum) (types.Datum, error) {
 	if err != nil {
 		return types.Datum{}, errors.Trace(err)
 	}
-	sessionVars := b.ctx.GetSessionVars()
 	varName, _ := args[0].ToString()
-	if !args[1].IsNull() {
-		strVal, err := args[1].ToString()
-		if err != nil {
-			return types.Datum{}, errors.Trace(err)
-		}
+	err = SetUserVar(b.ctx.GetSessionVars(), varName, args[1])
+	return args[1], errors.Trace(err)
+}
+
+// SetUserVar sets the user variable. Like MySQL, the value is stored as an integer, a double, a decimal or
+// a string, the values of the other types are converted to strings. A NULL value removes the variable.
+func SetUserVar(sessionVars *variable.SessionVars, name string, d types.Datum) error {
+	var val types.Datum
+	switch d.Kind() {
+	case types.KindNull:
 		sessionVars.UsersLock.Lock()
-		sessionVars.Users[varName] = strings.ToLower(strVal)
+		delete(sessionVars.Users, name)
 		sessionVars.UsersLock.Unlock()
+		return nil
+	case types.KindInt64, types.KindUint64, types.KindFloat64:
+		val = d
+	case types.KindFloat32:
+		val = types.NewFloat64Datum(d.GetFloat64())
+	case types.KindMysqlDecimal:
+		dec := *d.GetMysqlDecimal()
+		val = types.NewDecimalDatum(&dec)
+	case types.KindString:
+		// The bytes of the datum may be reused, so they are copied.
+		val = types.NewStringDatum(string(d.GetBytes()))
+	case types.KindBytes:
+		val = types.NewBytesDatum(append([]byte(nil), d.GetBytes()...))
+	default:
+		str, err := d.ToString()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		val = types.NewStringDatum(str)
 	}
-	return args[1], nil
+	sessionVars.UsersLock.Lock()
+	sessionVars.Users[name] = val
+	sessionVars.UsersLock.Unlock()
+	return nil
+}
+
+// GetUserVar gets the user variable, it's NULL if the variable isn't defined.
+func GetUserVar(sessionVars *variable.SessionVars, name string) types.Datum {
+	sessionVars.UsersLock.RLock()
+	defer sessionVars.UsersLock.RUnlock()
+	if v, ok := sessionVars.Users[name]; ok {
+		return v.(types.Datum)
+	}
+	return types.Datum{}
 }
 
 type getVarFunctionClass struct {
@@ -188,14 +222,8 @@ func (b *builtinGetVarSig) eval(row []types.Datum) (types.Datum, error) {
 	if err != nil {
 		return types.Datum{}, errors.Trace(err)
 	}
-	sessionVars := b.ctx.GetSessionVars()
 	varName, _ := args[0].ToString()
-	sessionVars.UsersLock.RLock()
-	defer sessionVars.UsersLock.RUnlock()
-	if v, ok := sessionVars.Users[varName]; ok {
-		return types.NewDatum(v), nil
-	}
-	return types.Datum{}, nil
+	return GetUserVar(b.ctx.GetSessionVars(), varName), nil
 }
 
 type valuesFunctionClass struct {
@@ -16,7 +16,6 @@ package expression
 import (
 	"fmt"
 	"math"
-	"strings"
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb/ast"
@@ -115,7 +114,8 @@ func (s *testEvaluatorSuite) TestSetVar(c *C) {
 			c.Assert(ok, Equals, true)
 			val, ok := tc.res.(string)
 			c.Assert(ok, Equals, true)
-			c.Assert(s.ctx.GetSessionVars().Users[key], Equals, strings.ToLower(val))
+			d = GetUserVar(s.ctx.GetSessionVars(), key)
+			c.Assert(d.GetString(), Equals, val)
 		}
 	}
 }
@@ -133,7 +133,7 @@ func (s *testEvaluatorSuite) TestGetVar(c *C) {
 		{"c", ""},
 	}
 	for _, kv := range sessionVars {
-		s.ctx.GetSessionVars().Users[kv.key] = kv.val
+		s.ctx.GetSessionVars().Users[kv.key] = types.NewStringDatum(kv.val)
 	}
 
 	testCases := []struct {
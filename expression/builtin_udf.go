@@ -0,0 +1,139 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package expression
+
+import (
+	"strings"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/udf"
+	"github.com/pingcap/tidb/util/lua"
+	"github.com/pingcap/tidb/util/types"
+)
+
+var (
+	_ functionClass = &udfFunctionClass{}
+)
+
+var (
+	_ builtinFunc = &builtinUDFSig{}
+)
+
+// IsBuiltinFunction checks whether name is the name of a builtin function, the user-defined
+// functions can't have such names.
+func IsBuiltinFunction(name string) bool {
+	name = strings.ToLower(name)
+	_, ok := funcs[name]
+	return ok || name == ast.Cast
+}
+
+// udfFunctionClassOf returns the function class of the user-defined function bound to ctx.
+func udfFunctionClassOf(ctx context.Context, name string) (functionClass, bool) {
+	h := udf.GetHandle(ctx)
+	if h == nil {
+		return nil, false
+	}
+	fn := h.Get(name)
+	if fn == nil {
+		return nil, false
+	}
+	return &udfFunctionClass{baseFunctionClass{fn.Name, len(fn.Params), len(fn.Params)}, fn}, true
+}
+
+type udfFunctionClass struct {
+	baseFunctionClass
+
+	fn *udf.Function
+}
+
+func udfEvalTp(tp string) evalTp {
+	switch tp {
+	case udf.TypeInt:
+		return tpInt
+	case udf.TypeReal:
+		return tpReal
+	case udf.TypeDecimal:
+		return tpDecimal
+	}
+	return tpString
+}
+
+func (c *udfFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
+	if err := c.verifyArgs(args); err != nil {
+		return nil, errors.Trace(err)
+	}
+	argTps := make([]evalTp, 0, len(c.fn.Params))
+	for _, p := range c.fn.Params {
+		argTps = append(argTps, udfEvalTp(p.Type))
+	}
+	retTp := udfEvalTp(c.fn.ReturnType)
+	bf, err := newBaseBuiltinFuncWithTp(args, ctx, retTp, argTps...)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if retTp == tpDecimal {
+		// The scripts may return decimals of any precision.
+		bf.tp.Flen, bf.tp.Decimal = types.UnspecifiedLength, types.UnspecifiedLength
+	}
+	bf.deterministic = c.fn.Deterministic
+	sig := &builtinUDFSig{bf, c.fn, retTp}
+	return sig.setSelf(sig), nil
+}
+
+type builtinUDFSig struct {
+	baseBuiltinFunc
+
+	fn    *udf.Function
+	retTp evalTp
+}
+
+// eval evals a builtinUDFSig, the script is run with the limits of the session variables
+// tidb_udf_max_steps and tidb_udf_max_string_len.
+func (b *builtinUDFSig) eval(row []types.Datum) (d types.Datum, err error) {
+	args, err := b.evalArgs(row)
+	if err != nil {
+		return d, errors.Trace(err)
+	}
+	vars := b.ctx.GetSessionVars()
+	limits := lua.Limits{MaxSteps: int64(vars.UDFMaxSteps), MaxStringLen: vars.UDFMaxStringLen}
+	res, err := b.fn.Call(limits, args)
+	if err != nil {
+		return d, errUDFFailed.GenByArgs(b.fn.Name, errors.Cause(err).Error())
+	}
+	if res.IsNull() {
+		return d, nil
+	}
+	sc := vars.StmtCtx
+	switch b.retTp {
+	case tpInt:
+		var i int64
+		i, err = res.ToInt64(sc)
+		d.SetInt64(i)
+	case tpReal:
+		var f float64
+		f, err = res.ToFloat64(sc)
+		d.SetFloat64(f)
+	case tpDecimal:
+		var dec *types.MyDecimal
+		dec, err = res.ToDecimal(sc)
+		d.SetMysqlDecimal(dec)
+	default:
+		var s string
+		s, err = res.ToString()
+		d.SetString(s)
+	}
+	return d, errors.Trace(err)
+}
@@ -126,6 +126,9 @@ type Column struct {
 	// IsAggOrSubq means if this column is referenced to a Aggregation column or a Subquery column.
 	// If so, this column's name will be the plain sql text.
 	IsAggOrSubq bool
+	// IsHidden means if this column is a hidden column of an expression index, it can't be referred
+	// by name or expanded by the wildcard.
+	IsHidden bool
 
 	// Index is only used for execution.
 	Index int
@@ -292,7 +295,12 @@ func IndexInfo2Cols(cols []*Column, index *model.IndexInfo) ([]*Column, []int) {
 			return retCols, lengths
 		}
 		retCols = append(retCols, col)
-		lengths = append(lengths, c.Length)
+		if c.IsFullColumn(col.RetType) {
+			// The prefix keeps the full values, the ranges needn't be cut.
+			lengths = append(lengths, types.UnspecifiedLength)
+		} else {
+			lengths = append(lengths, c.Length)
+		}
 	}
 	return retCols, lengths
 }
@@ -155,6 +155,85 @@ func (s *propagateConstantSolver) validPropagateCond(cond Expression, funNameMap
 	return nil, nil
 }
 
+// propagateColumnConds propagates the other conditions on a single column to its equivalent columns.
+// e.g. For expression a = b and a like 'x%' and (a = 1 or a = 2), we can get extra b like 'x%' and (b = 1 or b = 2).
+// The conditions with non-deterministic functions aren't propagated, e.g. for a = b and a > rand(), b > rand()
+// is another random filter, which may filter out the rows that a > rand() returns.
+func (s *propagateConstantSolver) propagateColumnConds() {
+	exists := make(map[string]bool, len(s.conditions))
+	for _, cond := range s.conditions {
+		exists[string(cond.HashCode())] = true
+	}
+	condsLen := len(s.conditions)
+	for i := 0; i < condsLen; i++ {
+		cond := s.conditions[i]
+		col := s.validColumnCond(cond)
+		if col == nil {
+			continue
+		}
+		id := s.getColID(col)
+		for j := range s.columns {
+			// The equal columns of different type classes may be different values, e.g. 1 = '1.0'.
+			if id == j || s.unionSet.findRoot(id) != s.unionSet.findRoot(j) || col.RetType.ToClass() != s.columns[j].RetType.ToClass() {
+				continue
+			}
+			newCond := ColumnSubstitute(cond, NewSchema(col), []Expression{s.columns[j]})
+			code := string(newCond.HashCode())
+			if !exists[code] {
+				exists[code] = true
+				s.conditions = append(s.conditions, newCond)
+			}
+		}
+	}
+}
+
+// validColumnCond checks if the cond is a deterministic function of a single column, which isn't propagated by
+// propagateEQ or propagateInEQ, and returns the column.
+func (s *propagateConstantSolver) validColumnCond(cond Expression) *Column {
+	if _, ok := cond.(*ScalarFunction); !ok || !IsDeterministic(cond) {
+		return nil
+	}
+	if col, _ := s.validPropagateCond(cond, eqFuncNameMap); col != nil {
+		return nil
+	}
+	if col, _ := s.validPropagateCond(cond, inEqFuncNameMap); col != nil {
+		return nil
+	}
+	cols := ExtractColumns(cond)
+	if len(cols) == 0 {
+		return nil
+	}
+	for _, col := range cols[1:] {
+		if !col.Equal(cols[0], s.ctx) {
+			return nil
+		}
+	}
+	return cols[0]
+}
+
+// removeConstConds removes the always true conditions. If a condition is always false or null, the whole
+// condition is false.
+func (s *propagateConstantSolver) removeConstConds() {
+	conditions := s.conditions[:0]
+	for _, cond := range s.conditions {
+		con, ok := cond.(*Constant)
+		if !ok {
+			conditions = append(conditions, cond)
+			continue
+		}
+		value, err := EvalBool([]Expression{con}, nil, s.ctx)
+		if err != nil {
+			conditions = append(conditions, cond)
+			continue
+		}
+		if !value {
+			s.setConds2ConstFalse()
+			return
+		}
+	}
+	s.conditions = conditions
+}
+
 func (s *propagateConstantSolver) setConds2ConstFalse() {
 	s.conditions = []Expression{&Constant{
 		Value:   types.NewDatum(false),
@@ -225,15 +304,23 @@ func (s *propagateConstantSolver) solve(conditions []Expression) []Expression {
 	}
 	s.propagateEQ()
 	s.propagateInEQ()
+	s.propagateColumnConds()
 	for i, cond := range s.conditions {
 		if dnf, ok := cond.(*ScalarFunction); ok && dnf.FuncName.L == ast.LogicOr {
 			dnfItems := SplitDNFItems(cond)
 			for j, item := range dnfItems {
-				dnfItems[j] = ComposeCNFCondition(s.ctx, PropagateConstant(s.ctx, []Expression{item})...)
+				itemConds := PropagateConstant(s.ctx, []Expression{item})
+				if len(itemConds) == 0 {
+					// The item is always true, so is the DNF condition.
+					dnfItems = []Expression{One.Clone()}
+					break
+				}
+				dnfItems[j] = ComposeCNFCondition(s.ctx, itemConds...)
 			}
 			s.conditions[i] = ComposeDNFCondition(s.ctx, dnfItems...)
 		}
 	}
+	s.removeConstConds()
 	return s.conditions
 }
 
@@ -252,6 +339,7 @@ func (s *propagateConstantSolver) insertCol(col *Column) {
 }
 
 // PropagateConstant propagate constant values of equality predicates and inequality predicates in a condition.
+// The always true conditions are removed, so the result may be empty.
 func PropagateConstant(ctx context.Context, conditions []Expression) []Expression {
 	solver := &propagateConstantSolver{
 		colMapper: make(map[string]int),
@@ -67,7 +67,7 @@ func (*testExpressionSuite) TestConstantPropagation(c *C) {
 				newFunction(ast.EQ, newColumn("d"), newLonglong(1)),
 				newFunction(ast.LogicOr, newLonglong(1), newColumn("a")),
 			},
-			result: "1, eq(test.t.a, 1), eq(test.t.b, 1), eq(test.t.c, 1), eq(test.t.d, 1)",
+			result: "eq(test.t.a, 1), eq(test.t.b, 1), eq(test.t.c, 1), eq(test.t.d, 1)",
 		},
 		{
 			conditions: []Expression{
@@ -113,6 +113,39 @@ func (*testExpressionSuite) TestConstantPropagation(c *C) {
 			},
 			result: "0",
 		},
+		{
+			conditions: []Expression{
+				newFunction(ast.EQ, newColumn("a"), newColumn("b")),
+				newFunction(ast.In, newColumn("a"), newLonglong(1), newLonglong(2)),
+				newFunction(ast.LogicOr, newFunction(ast.LT, newColumn("b"), newLonglong(0)), newFunction(ast.GT, newColumn("b"), newLonglong(9))),
+				newFunction(ast.GT, newFunction(ast.Plus, newColumn("a"), newLonglong(1)), newLonglong(2)),
+			},
+			result: "eq(test.t.a, test.t.b), gt(plus(test.t.a, 1), 2), gt(plus(test.t.b, 1), 2), in(test.t.a, 1, 2), in(test.t.b, 1, 2), or(lt(test.t.a, 0), gt(test.t.a, 9)), or(lt(test.t.b, 0), gt(test.t.b, 9))",
+		},
+		{
+			conditions: []Expression{
+				newFunction(ast.EQ, newColumn("a"), newColumn("b")),
+				newFunction(ast.GT, newColumn("a"), newFunction(ast.Rand)),
+				newFunction(ast.LT, newFunction(ast.Plus, newColumn("a"), newFunction(ast.Rand)), newLonglong(1)),
+				newFunction(ast.GT, newColumn("a"), newColumn("c")),
+			},
+			result: "eq(test.t.a, test.t.b), gt(test.t.a, rand()), gt(test.t.a, test.t.c), lt(plus(test.t.a, rand()), 1)",
+		},
+		{
+			conditions: []Expression{
+				newFunction(ast.EQ, newColumn("a"), newLonglong(1)),
+				newFunction(ast.LogicOr, newFunction(ast.EQ, newColumn("a"), newLonglong(1)), newFunction(ast.EQ, newColumn("c"), newLonglong(2))),
+				newFunction(ast.GT, newLonglong(2), newLonglong(1)),
+			},
+			result: "eq(test.t.a, 1)",
+		},
+		{
+			conditions: []Expression{
+				newFunction(ast.EQ, newColumn("a"), newLonglong(1)),
+				newFunction(ast.GT, newColumn("a"), newLonglong(2)),
+			},
+			result: "0",
+		},
 	}
 	for _, tt := range tests {
 		ctx := mock.NewContext()
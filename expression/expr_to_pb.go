@@ -24,6 +24,16 @@ import (
 	"github.com/pingcap/tipb/go-tipb"
 )
 
+// The expression types of the aggregate functions that tipb doesn't define, they are only pushed down to the
+// mock TiKV and the local store. The codes are out of the range of tipb so they never collide with it.
+const (
+	ExprTypeAggBitAnd tipb.ExprType = 20001 + iota
+	ExprTypeAggBitOr
+	ExprTypeAggBitXor
+	ExprTypeApproxCountDistinct
+	ExprTypeApproxPercentile
+)
+
 // ExpressionsToPB converts expression to tipb.Expr.
 func ExpressionsToPB(sc *variable.StatementContext, exprs []Expression, client kv.Client) (pbExpr *tipb.Expr, pushed []Expression, remained []Expression) {
 	pc := pbConverter{client: client, sc: sc}
@@ -117,8 +127,16 @@ func (pc pbConverter) columnToPBExpr(column *Column) *tipb.Expr {
 		return nil
 	}
 	switch column.GetType().Tp {
-	case mysql.TypeBit, mysql.TypeSet, mysql.TypeEnum, mysql.TypeGeometry, mysql.TypeUnspecified:
+	case mysql.TypeGeometry, mysql.TypeUnspecified:
 		return nil
+	case mysql.TypeEnum, mysql.TypeSet:
+		if !pc.client.IsRequestTypeSupported(kv.ReqTypeSelect, kv.ReqSubTypeEnumSet) {
+			return nil
+		}
+	case mysql.TypeBit:
+		if !pc.client.IsRequestTypeSupported(kv.ReqTypeSelect, kv.ReqSubTypeBit) {
+			return nil
+		}
 	}
 
 	if pc.client.IsRequestTypeSupported(kv.ReqTypeDAG, kv.ReqSubTypeBasic) {
@@ -384,13 +402,31 @@ func AggFuncToPBExpr(sc *variable.StatementContext, client kv.Client, aggFunc Ag
 		tp = tipb.ExprType_Sum
 	case ast.AggFuncAvg:
 		tp = tipb.ExprType_Avg
+	case ast.AggFuncBitOr:
+		tp = ExprTypeAggBitOr
+	case ast.AggFuncBitXor:
+		tp = ExprTypeAggBitXor
+	case ast.AggFuncBitAnd:
+		tp = ExprTypeAggBitAnd
+	case ast.AggFuncApproxCountDistinct:
+		tp = ExprTypeApproxCountDistinct
+	case ast.AggFuncApproxPercentile:
+		tp = ExprTypeApproxPercentile
+	default:
+		// The coprocessor protocol has no expression type for the others, e.g. var_pop and json_arrayagg.
+		return nil
 	}
 	if !client.IsRequestTypeSupported(kv.ReqTypeSelect, int64(tp)) {
 		return nil
 	}
 
-	children := make([]*tipb.Expr, 0, len(aggFunc.GetArgs()))
-	for _, arg := range aggFunc.GetArgs() {
+	args := aggFunc.GetArgs()
+	if tp == ExprTypeApproxPercentile {
+		// The coprocessor only builds the digest, the percentage is used by the final function.
+		args = args[:1]
+	}
+	children := make([]*tipb.Expr, 0, len(args))
+	for _, arg := range args {
 		pbArg := pc.exprToPB(arg)
 		if pbArg == nil {
 			return nil
@@ -30,6 +30,7 @@ import (
 
 // mockKvClient is mocked from tikv.CopClient to avoid circular dependency.
 type mockKvClient struct {
+	mock bool
 }
 
 // IsRequestTypeSupported implements the kv.Client interface..
@@ -39,6 +40,8 @@ func (c *mockKvClient) IsRequestTypeSupported(reqType, subType int64) bool {
 		switch subType {
 		case kv.ReqSubTypeGroupBy, kv.ReqSubTypeBasic, kv.ReqSubTypeTopN:
 			return true
+		case kv.ReqSubTypeEnumSet, kv.ReqSubTypeBit:
+			return c.mock
 		default:
 			return c.supportExpr(tipb.ExprType(subType))
 		}
@@ -66,7 +69,9 @@ func (c *mockKvClient) supportExpr(exprType tipb.ExprType) bool {
 		return true
 	case tipb.ExprType_Case, tipb.ExprType_If:
 		return true
-	case tipb.ExprType_Count, tipb.ExprType_First, tipb.ExprType_Max, tipb.ExprType_Min, tipb.ExprType_Sum, tipb.ExprType_Avg:
+	case tipb.ExprType_Count, tipb.ExprType_First, tipb.ExprType_Max, tipb.ExprType_Min, tipb.ExprType_Sum, tipb.ExprType_Avg,
+		ExprTypeAggBitAnd, ExprTypeAggBitOr, ExprTypeAggBitXor,
+		ExprTypeApproxCountDistinct, ExprTypeApproxPercentile:
 		return true
 	case tipb.ExprType_JsonType, tipb.ExprType_JsonExtract, tipb.ExprType_JsonUnquote, tipb.ExprType_JsonValid,
 		tipb.ExprType_JsonObject, tipb.ExprType_JsonArray, tipb.ExprType_JsonMerge, tipb.ExprType_JsonSet,
@@ -181,9 +186,6 @@ func (s *testEvaluatorSuite) TestColumn2Pb(c *C) {
 	client := new(mockKvClient)
 	dg := new(dataGen4Expr2PbTest)
 
-	colExprs = append(colExprs, dg.genColumn(mysql.TypeBit, 1))
-	colExprs = append(colExprs, dg.genColumn(mysql.TypeSet, 2))
-	colExprs = append(colExprs, dg.genColumn(mysql.TypeEnum, 3))
 	colExprs = append(colExprs, dg.genColumn(mysql.TypeGeometry, 4))
 	colExprs = append(colExprs, dg.genColumn(mysql.TypeUnspecified, 5))
 	colExprs = append(colExprs, dg.genColumn(mysql.TypeDecimal, 6))
@@ -275,6 +277,28 @@ func (s *testEvaluatorSuite) TestColumn2Pb(c *C) {
 	for _, pbExpr := range pbExprs {
 		c.Assert(pbExpr, IsNil)
 	}
+
+	// Enum and set columns are stored as their index values and bit columns are stored as
+	// uint64 values, so they can be pushed down, but only the mock TiKV decodes them.
+	colExprs = colExprs[:0]
+	colExprs = append(colExprs, dg.genColumn(mysql.TypeSet, 1))
+	colExprs = append(colExprs, dg.genColumn(mysql.TypeEnum, 2))
+	colExprs = append(colExprs, dg.genColumn(mysql.TypeBit, 3))
+	pbExprs = ExpressionsToPBList(sc, colExprs, client)
+	for _, pbExpr := range pbExprs {
+		c.Assert(pbExpr, IsNil)
+	}
+	pbExprs = ExpressionsToPBList(sc, colExprs, &mockKvClient{mock: true})
+	jsons = []string{
+		"{\"tp\":201,\"val\":\"gAAAAAAAAAE=\"}",
+		"{\"tp\":201,\"val\":\"gAAAAAAAAAI=\"}",
+		"{\"tp\":201,\"val\":\"gAAAAAAAAAM=\"}",
+	}
+	for i, pbExpr := range pbExprs {
+		js, err := json.Marshal(pbExpr)
+		c.Assert(err, IsNil)
+		c.Assert(string(js), Equals, jsons[i])
+	}
 }
 
 func (s *testEvaluatorSuite) TestCompareFunc2Pb(c *C) {
@@ -609,7 +633,8 @@ func (s *testEvaluatorSuite) TestAggFunc2Pb(c *C) {
 	client := new(mockKvClient)
 	dg := new(dataGen4Expr2PbTest)
 
-	funcNames := []string{ast.AggFuncSum, ast.AggFuncCount, ast.AggFuncAvg, ast.AggFuncGroupConcat, ast.AggFuncMax, ast.AggFuncMin, ast.AggFuncFirstRow}
+	funcNames := []string{ast.AggFuncSum, ast.AggFuncCount, ast.AggFuncAvg, ast.AggFuncGroupConcat, ast.AggFuncMax, ast.AggFuncMin, ast.AggFuncFirstRow,
+		ast.AggFuncBitAnd, ast.AggFuncBitOr, ast.AggFuncBitXor, ast.AggFuncApproxCountDistinct}
 	for _, funcName := range funcNames {
 		aggFunc := NewAggFunction(
 			funcName,
@@ -630,6 +655,10 @@ func (s *testEvaluatorSuite) TestAggFunc2Pb(c *C) {
 		"{\"tp\":3005,\"children\":[{\"tp\":201,\"val\":\"gAAAAAAAAAE=\"}]}",
 		"{\"tp\":3004,\"children\":[{\"tp\":201,\"val\":\"gAAAAAAAAAE=\"}]}",
 		"{\"tp\":3006,\"children\":[{\"tp\":201,\"val\":\"gAAAAAAAAAE=\"}]}",
+		"{\"tp\":20001,\"children\":[{\"tp\":201,\"val\":\"gAAAAAAAAAE=\"}]}",
+		"{\"tp\":20002,\"children\":[{\"tp\":201,\"val\":\"gAAAAAAAAAE=\"}]}",
+		"{\"tp\":20003,\"children\":[{\"tp\":201,\"val\":\"gAAAAAAAAAE=\"}]}",
+		"{\"tp\":20004,\"children\":[{\"tp\":201,\"val\":\"gAAAAAAAAAE=\"}]}",
 	}
 	for i, funcName := range funcNames {
 		aggFunc := NewAggFunction(
@@ -37,6 +37,7 @@ var (
 	errFunctionNotExists       = terror.ClassExpression.New(codeFunctionNotExists, "FUNCTION %s does not exist")
 	errZlibZData               = terror.ClassTypes.New(codeZlibZData, "ZLIB: Input data corrupted")
 	errIncorrectArgs           = terror.ClassExpression.New(codeIncorrectArgs, mysql.MySQLErrName[mysql.ErrWrongArguments])
+	errUDFFailed               = terror.ClassExpression.New(codeUDFFailed, "user-defined function %s fails: %s")
 )
 
 // Error codes.
@@ -46,6 +47,7 @@ const (
 	codeFunctionNotExists                      = 1305
 	codeZlibZData                              = mysql.ErrZlibZData
 	codeIncorrectArgs                          = mysql.ErrWrongArguments
+	codeUDFFailed                              = 2
 )
 
 func init() {
@@ -64,6 +66,10 @@ var TurnOnNewExprEval int32
 // EvalAstExpr evaluates ast expression directly.
 var EvalAstExpr func(expr ast.ExprNode, ctx context.Context) (types.Datum, error)
 
+// RewriteAstExprOnTable rewrites the ast expression of a generated column to an expression which is
+// evaluated on a row of the table, the columns of the expression are indexed by their offsets.
+var RewriteAstExprOnTable func(expr ast.ExprNode, tblInfo *model.TableInfo, ctx context.Context) (Expression, error)
+
 // Expression represents all scalar expression in SQL.
 type Expression interface {
 	fmt.Stringer
@@ -1164,6 +1164,18 @@ func (s *testIntegrationSuite) TestBuiltin(c *C) {
 	tk.MustQuery("select count(*) from t") // Test ProjectionExec
 	result = tk.MustQuery("select found_rows()")
 	result.Check(testkit.Rows("1"))
+	tk.MustQuery("select * from t limit 1")
+	result = tk.MustQuery("select found_rows()")
+	result.Check(testkit.Rows("1"))
+	tk.MustQuery("select sql_calc_found_rows * from t limit 1").Check(testkit.Rows("1"))
+	result = tk.MustQuery("select found_rows()")
+	result.Check(testkit.Rows("3"))
+	tk.MustQuery("select sql_calc_found_rows * from t order by a desc limit 1, 1").Check(testkit.Rows("2"))
+	result = tk.MustQuery("select found_rows()")
+	result.Check(testkit.Rows("3"))
+	tk.MustQuery("select sql_calc_found_rows * from t where a = 2 limit 5, 1").Check(testkit.Rows())
+	result = tk.MustQuery("select found_rows()")
+	result.Check(testkit.Rows("2"))
 }
 
 func (s *testIntegrationSuite) TestInfoBuiltin(c *C) {
@@ -1190,6 +1202,40 @@ func (s *testIntegrationSuite) TestInfoBuiltin(c *C) {
 	result.Check(testkit.Rows("5"))
 	result = tk.MustQuery("select last_insert_id();")
 	result.Check(testkit.Rows("5"))
+
+	// for row_count
+	tk.MustExec("drop table if exists t")
+	result = tk.MustQuery("select row_count();")
+	result.Check(testkit.Rows("0"))
+	tk.MustExec("create table t (id int, a int, PRIMARY KEY (id))")
+	tk.MustExec("insert into t values(1, 1), (2, 1), (3, 2)")
+	result = tk.MustQuery("select row_count();")
+	result.Check(testkit.Rows("3"))
+	result = tk.MustQuery("select row_count();")
+	result.Check(testkit.Rows("-1"))
+	tk.MustExec("update t set a = 2 where a = 1")
+	result = tk.MustQuery("select row_count();")
+	result.Check(testkit.Rows("2"))
+	tk.MustExec("update t set a = 2 where a = 2")
+	result = tk.MustQuery("select row_count();")
+	result.Check(testkit.Rows("0"))
+	tk.MustExec("insert into t values(3, 2) on duplicate key update a = 3")
+	result = tk.MustQuery("select row_count();")
+	result.Check(testkit.Rows("2"))
+	tk.MustExec("delete from t where a = 2")
+	result = tk.MustQuery("select row_count();")
+	result.Check(testkit.Rows("2"))
+	tk.MustExec(`prepare stmt from "select row_count()"`)
+	tk.MustExec("insert into t values(4, 4)")
+	result = tk.MustQuery("execute stmt")
+	result.Check(testkit.Rows("1"))
+
+	// The unchanged rows are counted as the affected rows with CLIENT_FOUND_ROWS.
+	tk.Se.GetSessionVars().ClientCapability |= mysql.ClientFoundRows
+	tk.MustExec("update t set a = 4")
+	tk.CheckExecResult(2, 0)
+	result = tk.MustQuery("select row_count();")
+	result.Check(testkit.Rows("2"))
 }
 
 func (s *testIntegrationSuite) TestControlBuiltin(c *C) {
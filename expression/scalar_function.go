@@ -73,6 +73,9 @@ func NewFunction(ctx context.Context, funcName string, retType *types.FieldType,
 		return NewCastFunc(retType, args[0], ctx), nil
 	}
 	fc, ok := funcs[funcName]
+	if !ok {
+		fc, ok = udfFunctionClassOf(ctx, funcName)
+	}
 	if !ok {
 		return nil, errFunctionNotExists.GenByArgs(funcName)
 	}
@@ -113,7 +116,7 @@ func (sf *ScalarFunction) Clone() Expression {
 	}
 	switch sf.FuncName.L {
 	case ast.Cast:
-		newFunc, _ := buildCastFunction(sf.GetArgs()[0], sf.GetType(), sf.GetCtx())
+		newFunc, _ := buildCastFunction(newArgs[0], sf.GetType(), sf.GetCtx())
 		return newFunc
 	case ast.Values:
 		v := sf.Function.(*builtinValuesSig)
@@ -0,0 +1,152 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package expression
+
+import (
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// Simplify rewrites an expression into its canonical form: the deterministic functions over the constants are
+// folded, the boolean expressions with the constant operands are simplified and the casts which don't change
+// the values are removed from the arguments. isCond tells whether only the truth value of the expression matters,
+// e.g. the conditions of the selections and the joins, in that case "a > 1 and 1" can be simplified to "a > 1".
+func Simplify(ctx context.Context, expr Expression, isCond bool) Expression {
+	sf, ok := expr.(*ScalarFunction)
+	if !ok {
+		return expr
+	}
+	args := sf.GetArgs()
+	argIsCond := isCond && (sf.FuncName.L == ast.LogicAnd || sf.FuncName.L == ast.LogicOr || sf.FuncName.L == ast.UnaryNot)
+	for i, arg := range args {
+		args[i] = removeRedundantCast(Simplify(ctx, arg, argIsCond))
+	}
+	newExpr := FoldConstant(sf)
+	if sf, ok = newExpr.(*ScalarFunction); !ok {
+		return newExpr
+	}
+	switch sf.FuncName.L {
+	case ast.LogicAnd, ast.LogicOr:
+		return simplifyLogicOp(ctx, sf, isCond)
+	case ast.UnaryNot:
+		if inner, ok := args[0].(*ScalarFunction); ok && inner.FuncName.L == ast.UnaryNot {
+			if x := inner.GetArgs()[0]; isCond || isBooleanExpr(x) {
+				return x
+			}
+		}
+	}
+	return sf
+}
+
+// simplifyLogicOp simplifies "and" and "or" with a constant operand. "x and true" and "x or false" are
+// simplified to "x" only when x is boolean or the truth value is all that matters, because "2 and true" is 1.
+// "x and false" and "x or true" are simplified to the constants only when x is deterministic.
+func simplifyLogicOp(ctx context.Context, sf *ScalarFunction, isCond bool) Expression {
+	isAnd := sf.FuncName.L == ast.LogicAnd
+	args := sf.GetArgs()
+	sc := ctx.GetSessionVars().StmtCtx
+	for i, arg := range args {
+		con, ok := arg.(*Constant)
+		if !ok || con.Value.IsNull() {
+			continue
+		}
+		isTrue, err := con.Value.ToBool(sc)
+		if err != nil {
+			continue
+		}
+		other := args[1-i]
+		if (isTrue == 1) == isAnd {
+			if isCond || isBooleanExpr(other) {
+				return other
+			}
+			continue
+		}
+		if IsDeterministic(other) {
+			return &Constant{Value: types.NewIntDatum(isTrue), RetType: sf.RetType}
+		}
+	}
+	return sf
+}
+
+// isBooleanExpr checks whether the expression only returns 1, 0 or null.
+func isBooleanExpr(expr Expression) bool {
+	sf, ok := expr.(*ScalarFunction)
+	if !ok {
+		return false
+	}
+	switch sf.FuncName.L {
+	case ast.EQ, ast.NE, ast.LT, ast.LE, ast.GT, ast.GE, ast.NullEQ, ast.LogicAnd, ast.LogicOr, ast.LogicXor,
+		ast.UnaryNot, ast.IsNull, ast.IsTruth, ast.IsFalsity, ast.In, ast.Like, ast.Regexp:
+		return true
+	}
+	return false
+}
+
+// removeRedundantCast removes the cast whose argument is evaluated by the same type and whose result is always
+// the same as the argument, e.g. "cast(a as signed)" where a is a signed int column. The must-have casts like
+// "cast(a as unsigned)", "cast(a as decimal(5, 1))" and "cast(a as char(3))" are kept.
+func removeRedundantCast(expr Expression) Expression {
+	sf, ok := expr.(*ScalarFunction)
+	if !ok || sf.FuncName.L != ast.Cast {
+		return expr
+	}
+	arg := sf.GetArgs()[0]
+	from, to := arg.GetType(), sf.RetType
+	if isRedundantCast(from, to) {
+		return arg
+	}
+	return expr
+}
+
+func isRedundantCast(from, to *types.FieldType) bool {
+	fromClass, toClass := from.ToClass(), to.ToClass()
+	if fromClass != toClass {
+		return false
+	}
+	switch fromClass {
+	case types.ClassInt:
+		return from.Tp != mysql.TypeBit && from.Tp != mysql.TypeYear &&
+			mysql.HasUnsignedFlag(from.Flag) == mysql.HasUnsignedFlag(to.Flag)
+	case types.ClassReal:
+		return to.Tp == mysql.TypeDouble
+	case types.ClassDecimal:
+		if to.Flen == types.UnspecifiedLength || to.Decimal == types.UnspecifiedLength {
+			return true
+		}
+		return from.Flen != types.UnspecifiedLength && from.Decimal != types.UnspecifiedLength &&
+			to.Decimal >= from.Decimal && to.Flen-to.Decimal >= from.Flen-from.Decimal
+	case types.ClassString:
+		switch {
+		case types.IsTypeTime(from.Tp) || types.IsTypeTime(to.Tp):
+			return from.Tp == to.Tp && to.Decimal >= from.Decimal
+		case from.Tp == mysql.TypeDuration || to.Tp == mysql.TypeDuration:
+			return from.Tp == to.Tp && to.Decimal >= from.Decimal
+		case from.Tp == mysql.TypeJSON || to.Tp == mysql.TypeJSON:
+			return from.Tp == to.Tp
+		case from.Tp == mysql.TypeEnum || from.Tp == mysql.TypeSet || from.Tp == mysql.TypeNull:
+			return false
+		}
+		if from.Charset != to.Charset {
+			return false
+		}
+		if to.Flen == types.UnspecifiedLength {
+			return true
+		}
+		// The binary strings of fixed length are padded.
+		return to.Tp != mysql.TypeString && from.Flen != types.UnspecifiedLength && to.Flen >= from.Flen
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package expression
+
+import (
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/util/charset"
+	"github.com/pingcap/tidb/util/testleak"
+	"github.com/pingcap/tidb/util/types"
+)
+
+func (s *testEvaluatorSuite) TestSimplify(c *C) {
+	defer testleak.AfterTest(c)()
+	intCol := &Column{RetType: types.NewFieldType(mysql.TypeLonglong), FromID: "t", ColName: model.NewCIStr("a")}
+	one := &Constant{Value: types.NewIntDatum(1), RetType: types.NewFieldType(mysql.TypeLonglong)}
+	zero := &Constant{Value: types.NewIntDatum(0), RetType: types.NewFieldType(mysql.TypeLonglong)}
+	two := &Constant{Value: types.NewIntDatum(2), RetType: types.NewFieldType(mysql.TypeLonglong)}
+	gt := newFunction(ast.GT, intCol, one)
+	tests := []struct {
+		expr   Expression
+		isCond bool
+		result string
+	}{
+		{newFunction(ast.LogicAnd, gt, one), false, "gt(a, 1)"},
+		{newFunction(ast.LogicAnd, intCol, one), false, "and(a, 1)"},
+		{newFunction(ast.LogicAnd, intCol, one), true, "a"},
+		{newFunction(ast.LogicAnd, intCol, zero), false, "0"},
+		{newFunction(ast.LogicOr, zero, gt), false, "gt(a, 1)"},
+		{newFunction(ast.LogicOr, intCol, two), false, "1"},
+		{newFunction(ast.LogicOr, intCol, newFunction(ast.Rand)), false, "or(a, rand())"},
+		{newFunction(ast.LogicAnd, newFunction(ast.Rand), zero), false, "and(rand(), 0)"},
+		{newFunction(ast.UnaryNot, newFunction(ast.UnaryNot, gt)), false, "gt(a, 1)"},
+		{newFunction(ast.UnaryNot, newFunction(ast.UnaryNot, intCol)), false, "not(not(a))"},
+		{newFunction(ast.UnaryNot, newFunction(ast.UnaryNot, intCol)), true, "a"},
+		{newFunction(ast.GT, intCol, newFunction(ast.Plus, one, one)), false, "gt(a, 2)"},
+	}
+	for _, t := range tests {
+		c.Assert(Simplify(s.ctx, t.expr, t.isCond).String(), Equals, t.result)
+	}
+}
+
+func (s *testEvaluatorSuite) TestIsRedundantCast(c *C) {
+	defer testleak.AfterTest(c)()
+	newTp := func(tp byte, flen, decimal int, flag uint) *types.FieldType {
+		ft := types.NewFieldType(tp)
+		ft.Flen, ft.Decimal, ft.Flag = flen, decimal, flag
+		ft.Charset = charset.CharsetUTF8
+		return ft
+	}
+	tests := []struct {
+		from      *types.FieldType
+		to        *types.FieldType
+		redundant bool
+	}{
+		{newTp(mysql.TypeLong, 11, 0, 0), newTp(mysql.TypeLonglong, 20, 0, 0), true},
+		{newTp(mysql.TypeLong, 11, 0, 0), newTp(mysql.TypeLonglong, 20, 0, mysql.UnsignedFlag), false},
+		{newTp(mysql.TypeBit, 8, 0, 0), newTp(mysql.TypeLonglong, 20, 0, 0), false},
+		{newTp(mysql.TypeLong, 11, 0, 0), newTp(mysql.TypeDouble, 22, -1, 0), false},
+		{newTp(mysql.TypeFloat, 12, -1, 0), newTp(mysql.TypeDouble, 22, -1, 0), true},
+		{newTp(mysql.TypeNewDecimal, 5, 2, 0), newTp(mysql.TypeNewDecimal, 6, 2, 0), true},
+		{newTp(mysql.TypeNewDecimal, 5, 2, 0), newTp(mysql.TypeNewDecimal, 5, 1, 0), false},
+		{newTp(mysql.TypeNewDecimal, 5, 2, 0), newTp(mysql.TypeNewDecimal, 10, -1, 0), true},
+		{newTp(mysql.TypeVarchar, 10, -1, 0), newTp(mysql.TypeVarString, -1, -1, 0), true},
+		{newTp(mysql.TypeVarchar, 10, -1, 0), newTp(mysql.TypeVarString, 5, -1, 0), false},
+		{newTp(mysql.TypeVarchar, 10, -1, 0), newTp(mysql.TypeVarString, 10, -1, 0), true},
+		{newTp(mysql.TypeVarchar, 10, -1, 0), newTp(mysql.TypeString, 10, -1, 0), false},
+		{newTp(mysql.TypeEnum, 10, -1, 0), newTp(mysql.TypeVarString, -1, -1, 0), false},
+		{newTp(mysql.TypeDatetime, 19, 0, 0), newTp(mysql.TypeDatetime, 23, 3, 0), true},
+		{newTp(mysql.TypeDatetime, 23, 3, 0), newTp(mysql.TypeDatetime, 19, 0, 0), false},
+		{newTp(mysql.TypeDatetime, 19, 0, 0), newTp(mysql.TypeDate, 10, 0, 0), false},
+		{newTp(mysql.TypeDatetime, 19, 0, 0), newTp(mysql.TypeVarString, -1, -1, 0), false},
+		{newTp(mysql.TypeJSON, -1, -1, 0), newTp(mysql.TypeJSON, -1, -1, 0), true},
+	}
+	for _, t := range tests {
+		c.Assert(isRedundantCast(t.from, t.to), Equals, t.redundant, Commentf("cast %s as %s", t.from, t.to))
+	}
+}
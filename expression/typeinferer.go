@@ -42,7 +42,8 @@ type typeInferrer struct {
 
 func (v *typeInferrer) Enter(in ast.Node) (out ast.Node, skipChildren bool) {
 	switch in.(type) {
-	case *ast.ColumnOption:
+	case *ast.ColumnOption, *ast.IndexColName:
+		// The expressions of the generated columns and the expression indices are inferred by the DDL.
 		return in, true
 	}
 	return in, false
@@ -84,7 +85,10 @@ func (v *typeInferrer) Leave(in ast.Node) (out ast.Node, ok bool) {
 		x.SetType(types.NewFieldType(mysql.TypeLonglong))
 		types.SetBinChsClnFlag(&x.Type)
 	case *ast.ParamMarkerExpr:
-		types.DefaultTypeForValue(x.GetValue(), x.GetType())
+		// The type is inferred again for every execution, the flags of the last value mustn't be kept.
+		var tp types.FieldType
+		types.DefaultTypeForValue(x.GetValue(), &tp)
+		x.SetType(&tp)
 	case *ast.ParenthesesExpr:
 		x.SetType(x.Expr.GetType())
 	case *ast.PatternInExpr:
@@ -342,7 +346,7 @@ func (v *typeInferrer) handleFuncCallExpr(x *ast.FuncCallExpr) {
 		tp = types.NewFieldType(mysql.TypeDouble)
 	case ast.MicroSecond, ast.Second, ast.Minute, ast.Hour, ast.Day, ast.Week, ast.Month, ast.Year,
 		ast.DayOfWeek, ast.DayOfMonth, ast.DayOfYear, ast.Weekday, ast.WeekOfYear, ast.YearWeek, ast.DateDiff,
-		ast.FoundRows, ast.Length, ast.ASCII, ast.Extract, ast.Locate, ast.UnixTimestamp, ast.Quarter, ast.IsIPv4, ast.ToDays,
+		ast.FoundRows, ast.RowCount, ast.Length, ast.ASCII, ast.Extract, ast.Locate, ast.UnixTimestamp, ast.Quarter, ast.IsIPv4, ast.ToDays,
 		ast.ToSeconds, ast.Strcmp, ast.IsNull, ast.BitLength, ast.CharLength, ast.CRC32, ast.TimestampDiff,
 		ast.Sign, ast.IsIPv6, ast.Ord, ast.Instr, ast.BitCount, ast.TimeToSec, ast.FindInSet, ast.Field,
 		ast.GetLock, ast.ReleaseLock, ast.Interval, ast.Position, ast.PeriodAdd, ast.PeriodDiff, ast.IsIPv4Mapped, ast.IsIPv4Compat, ast.UncompressedLength:
@@ -396,7 +400,8 @@ func (v *typeInferrer) handleFuncCallExpr(x *ast.FuncCallExpr) {
 		ast.DateFormat, ast.Rpad, ast.Lpad, ast.CharFunc, ast.Conv, ast.MakeSet, ast.Oct, ast.UUID,
 		ast.InsertFunc, ast.Bin, ast.Quote, ast.Format, ast.FromBase64, ast.ToBase64,
 		ast.ExportSet, ast.AesEncrypt, ast.AesDecrypt, ast.SHA2, ast.InetNtoa, ast.Inet6Aton,
-		ast.Inet6Ntoa, ast.PasswordFunc, ast.TiDBVersion:
+		ast.Inet6Ntoa, ast.PasswordFunc, ast.TiDBVersion, ast.MaskEmail, ast.MaskInner, ast.MaskOuter,
+		ast.MaskRandom, ast.MaskSSN:
 		tp = types.NewFieldType(mysql.TypeVarString)
 		chs = v.defaultCharset
 	case ast.RandomBytes:
@@ -504,25 +509,40 @@ func (v *typeInferrer) addCastToString(expr ast.ExprNode) ast.ExprNode {
 }
 
 // convertValueToColumnTypeIfNeeded checks if the expr in PatternInExpr is column name,
-// and casts function to the items in the list.
+// and casts function to the items in the list. The values which aren't compared with the
+// column in the type of it are kept, e.g. "varchar_col in (1, 2)" compares them as doubles.
 func (v *typeInferrer) convertValueToColumnTypeIfNeeded(x *ast.PatternInExpr) {
 	if cn, ok := x.Expr.(*ast.ColumnNameExpr); ok && cn.Refer != nil {
 		ft := cn.Refer.Column.FieldType
+		col := &Column{RetType: &ft}
 		for _, expr := range x.List {
-			if valueExpr, ok := expr.(*ast.ValueExpr); ok {
-				newDatum, err := valueExpr.Datum.ConvertTo(v.sc, &ft)
-				if err != nil {
-					v.err = errors.Trace(err)
-				}
-				cmp, err := newDatum.CompareDatum(v.sc, valueExpr.Datum)
-				if err != nil {
-					v.err = errors.Trace(err)
-				}
-				if cmp != 0 {
-					// The value will never match the column, do not set newDatum.
-					continue
-				}
-				valueExpr.SetDatum(newDatum)
+			switch expr.(type) {
+			case *ast.ValueExpr, *ast.ParamMarkerExpr:
+			default:
+				continue
+			}
+			// The parameter markers are converted when the statement is executed, their values are known then.
+			datum := expr.GetDatum()
+			if datum.IsNull() || !IsComparedInColumnType(col, &Constant{Value: *datum, RetType: expr.GetType()}) {
+				continue
+			}
+			newDatum, err := datum.ConvertTo(v.sc, &ft)
+			if err != nil {
+				v.err = errors.Trace(err)
+			}
+			cmp, err := newDatum.CompareDatum(v.sc, *datum)
+			if err != nil {
+				v.err = errors.Trace(err)
+			}
+			if cmp != 0 {
+				// The value will never match the column, do not set newDatum.
+				continue
+			}
+			expr.SetDatum(newDatum)
+			if _, ok := expr.(*ast.ParamMarkerExpr); ok {
+				var tp types.FieldType
+				types.DefaultTypeForValue(newDatum.GetValue(), &tp)
+				expr.SetType(&tp)
 			}
 		}
 		if v.err != nil {
@@ -42,6 +42,24 @@ func ExtractColumns(expr Expression) (cols []*Column) {
 	return
 }
 
+// IsDeterministic checks if the expression returns the same result for the same row, the functions like
+// rand() and the user variables are non-deterministic.
+func IsDeterministic(expr Expression) bool {
+	fun, ok := expr.(*ScalarFunction)
+	if !ok {
+		return true
+	}
+	if !fun.Function.isDeterministic() {
+		return false
+	}
+	for _, arg := range fun.GetArgs() {
+		if !IsDeterministic(arg) {
+			return false
+		}
+	}
+	return true
+}
+
 // ColumnSubstitute substitutes the columns in filter to expressions in select fields.
 // e.g. select * from (select b as a from t) k where a < 10 => select * from (select b as a from t where b < 10) k.
 func ColumnSubstitute(expr Expression, schema *Schema, newExprs []Expression) Expression {
@@ -106,35 +124,34 @@ var kindToMysqlType = map[byte]byte{
 }
 
 // calculateSum adds v to sum.
+// A decimal sum is accumulated in place, so the returned datum owns its decimal
+// and must not be shared with the input rows.
 func calculateSum(sc *variable.StatementContext, sum, v types.Datum) (data types.Datum, err error) {
 	// for avg and sum calculation
 	// avg and sum use decimal for integer and decimal type, use float for others
 	// see https://dev.mysql.com/doc/refman/5.7/en/group-by-functions.html
 
+	var dec types.MyDecimal
 	switch v.Kind() {
 	case types.KindNull:
-	case types.KindInt64, types.KindUint64:
-		var d *types.MyDecimal
-		d, err = v.ToDecimal(sc)
-		if err == nil {
-			data = types.NewDecimalDatum(d)
-		}
+		return sum, nil
+	case types.KindInt64:
+		dec.FromInt(v.GetInt64())
+		return addDecimalToSum(sum, &dec)
+	case types.KindUint64:
+		dec.FromUint(v.GetUint64())
+		return addDecimalToSum(sum, &dec)
 	case types.KindMysqlDecimal:
-		data = v
+		return addDecimalToSum(sum, v.GetMysqlDecimal())
 	default:
 		var f float64
 		f, err = v.ToFloat64(sc)
-		if err == nil {
-			data = types.NewFloat64Datum(f)
+		if err != nil {
+			return data, errors.Trace(err)
 		}
+		data = types.NewFloat64Datum(f)
 	}
 
-	if err != nil {
-		return data, errors.Trace(err)
-	}
-	if data.IsNull() {
-		return sum, nil
-	}
 	switch sum.Kind() {
 	case types.KindNull:
 		return data, nil
@@ -145,6 +162,25 @@ func calculateSum(sc *variable.StatementContext, sum, v types.Datum) (data types
 	}
 }
 
+// addDecimalToSum adds dec to sum without allocating a new decimal for every row.
+func addDecimalToSum(sum types.Datum, dec *types.MyDecimal) (types.Datum, error) {
+	switch sum.Kind() {
+	case types.KindNull:
+		to := new(types.MyDecimal)
+		*to = *dec
+		return types.NewDecimalDatum(to), nil
+	case types.KindMysqlDecimal:
+		to := sum.GetMysqlDecimal()
+		err := types.DecimalAdd(to, dec, to)
+		return sum, errors.Trace(err)
+	case types.KindFloat64:
+		f, err := dec.ToFloat64()
+		return types.NewFloat64Datum(sum.GetFloat64() + f), errors.Trace(err)
+	default:
+		return sum, errors.Errorf("invalid value %v for aggregate", sum.Kind())
+	}
+}
+
 // getValidPrefix gets a prefix of string which can parsed to a number with base. the minimum base is 2 and the maximum is 36.
 func getValidPrefix(s string, base int64) string {
 	var (
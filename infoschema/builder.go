@@ -40,6 +40,8 @@ func (b *Builder) ApplyDiff(m *meta.Meta, diff *model.SchemaDiff) ([]int64, erro
 	} else if diff.Type == model.ActionDropSchema {
 		tblIDs := b.applyDropSchema(diff.SchemaID)
 		return tblIDs, nil
+	} else if diff.Type == model.ActionRenameTables {
+		return b.applyRenameTables(m, diff)
 	}
 
 	roDBInfo, ok := b.is.SchemaByID(diff.SchemaID)
@@ -73,7 +75,7 @@ func (b *Builder) ApplyDiff(m *meta.Meta, diff *model.SchemaDiff) ([]int64, erro
 	var alloc autoid.Allocator
 	if tableIDIsValid(oldTableID) {
 		if oldTableID == newTableID {
-			alloc, _ = b.is.AllocByID(oldTableID)
+			alloc = b.loadedAllocByID(oldTableID)
 		}
 		if diff.Type == model.ActionRenameTable {
 			oldRoDBInfo, ok := b.is.SchemaByID(diff.OldSchemaID)
@@ -97,6 +99,55 @@ func (b *Builder) ApplyDiff(m *meta.Meta, diff *model.SchemaDiff) ([]int64, erro
 	return tblIDs, nil
 }
 
+// applyRenameTables renames the tables at once, all the tables are dropped before they are created
+// again, so the tables can swap their names.
+func (b *Builder) applyRenameTables(m *meta.Meta, diff *model.SchemaDiff) ([]int64, error) {
+	tblIDs := make([]int64, 0, len(diff.AffectedOpts))
+	allocs := make([]autoid.Allocator, len(diff.AffectedOpts))
+	newDBInfos := make([]*model.DBInfo, len(diff.AffectedOpts))
+	copied := make(map[string]struct{})
+	for i, opt := range diff.AffectedOpts {
+		oldRoDBInfo, ok := b.is.SchemaByID(opt.OldSchemaID)
+		if !ok {
+			return nil, ErrDatabaseNotExists.GenByArgs(fmt.Sprintf("(Schema ID %d)", opt.OldSchemaID))
+		}
+		newDBInfos[i], ok = b.is.SchemaByID(opt.SchemaID)
+		if !ok {
+			return nil, ErrDatabaseNotExists.GenByArgs(fmt.Sprintf("(Schema ID %d)", opt.SchemaID))
+		}
+		for _, name := range []string{oldRoDBInfo.Name.L, newDBInfos[i].Name.L} {
+			if _, ok = copied[name]; !ok {
+				b.copySchemaTables(name)
+				copied[name] = struct{}{}
+			}
+		}
+		b.copySortedTables(opt.TableID, opt.TableID)
+		allocs[i] = b.loadedAllocByID(opt.TableID)
+		b.applyDropTable(oldRoDBInfo, opt.TableID)
+		tblIDs = append(tblIDs, opt.TableID)
+	}
+	for i, opt := range diff.AffectedOpts {
+		if err := b.applyCreateTable(m, newDBInfos[i], opt.TableID, allocs[i]); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	return tblIDs, nil
+}
+
+// loadedAllocByID returns the allocator of the table to reuse, it's nil if the table isn't loaded, a lazy
+// table isn't loaded only for the allocator.
+func (b *Builder) loadedAllocByID(id int64) autoid.Allocator {
+	slice := b.is.sortedTablesBuckets[tableBucketIdx(id)]
+	idx := slice.searchTable(id)
+	if idx == -1 {
+		return nil
+	}
+	if _, ok := slice[idx].(*lazyTable); ok {
+		return nil
+	}
+	return slice[idx].Allocator()
+}
+
 // copySortedTables copies sortedTables for old table and new table for later modification.
 func (b *Builder) copySortedTables(oldTableID, newTableID int64) {
 	buckets := b.is.sortedTablesBuckets
@@ -164,7 +215,7 @@ func (b *Builder) applyCreateTable(m *meta.Meta, roDBInfo *model.DBInfo, tableID
 		if tblInfo.OldSchemaID != 0 {
 			schemaID = tblInfo.OldSchemaID
 		}
-		alloc = autoid.NewAllocator(b.handle.store, schemaID)
+		alloc = autoid.NewAllocatorWithCache(b.handle.store, schemaID, tblInfo.AutoIDCache)
 	}
 	tbl, err := tables.TableFromMeta(alloc, tblInfo)
 	if err != nil {
@@ -258,6 +309,33 @@ func (b *Builder) InitWithDBInfos(dbInfos []*model.DBInfo, schemaVersion int64)
 	return b, nil
 }
 
+// InitWithTableStubs initializes an empty new InfoSchema with the databases whose tables are listed by
+// meta.ListTableStubs, the tables are loaded on demand by the TableLoader of the Handle. The hashes are keyed by
+// the table IDs, and the tables are listed at startTS.
+func (b *Builder) InitWithTableStubs(dbInfos []*model.DBInfo, hashes map[int64]uint64, startTS uint64, schemaVersion int64) *Builder {
+	info := b.is
+	info.schemaMetaVersion = schemaVersion
+	for _, di := range dbInfos {
+		schTbls := &schemaTables{
+			dbInfo: di,
+			tables: make(map[string]table.Table, len(di.Tables)),
+		}
+		b.is.schemaMap[di.Name.L] = schTbls
+		for _, t := range di.Tables {
+			tbl := &lazyTable{meta: t, dbID: di.ID, hash: hashes[t.ID], ts: startTS}
+			schTbls.tables[t.Name.L] = tbl
+			sortedTbls := b.is.sortedTablesBuckets[tableBucketIdx(t.ID)]
+			b.is.sortedTablesBuckets[tableBucketIdx(t.ID)] = append(sortedTbls, tbl)
+		}
+	}
+	b.createSchemaTablesForPerfSchemaDB()
+	b.createSchemaTablesForInfoSchemaDB()
+	for _, v := range info.sortedTablesBuckets {
+		sort.Sort(v)
+	}
+	return b
+}
+
 func (b *Builder) createSchemaTablesForDB(di *model.DBInfo) error {
 	schTbls := &schemaTables{
 		dbInfo: di,
@@ -269,7 +347,7 @@ func (b *Builder) createSchemaTablesForDB(di *model.DBInfo) error {
 		if t.OldSchemaID != 0 {
 			schemaID = t.OldSchemaID
 		}
-		alloc := autoid.NewAllocator(b.handle.store, schemaID)
+		alloc := autoid.NewAllocatorWithCache(b.handle.store, schemaID, t.AutoIDCache)
 		var tbl table.Table
 		tbl, err := tables.TableFromMeta(alloc, t)
 		if err != nil {
@@ -327,6 +405,7 @@ func NewBuilder(handle *Handle) *Builder {
 	b.is = &infoSchema{
 		schemaMap:           map[string]*schemaTables{},
 		sortedTablesBuckets: make([]sortedTables, bucketCount),
+		loader:              handle.loader,
 	}
 	return b
 }
@@ -18,6 +18,7 @@ import (
 	"sync/atomic"
 
 	"github.com/juju/errors"
+	"github.com/ngaut/log"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/meta/autoid"
 	"github.com/pingcap/tidb/model"
@@ -54,6 +55,13 @@ var (
 	ErrIndexExists = terror.ClassSchema.New(codeIndexExists, "Duplicate Index")
 	// ErrMultiplePriKey returns for multiple primary keys.
 	ErrMultiplePriKey = terror.ClassSchema.New(codeMultiplePriKey, "Multiple primary key defined")
+	// ErrTableLocked returns for accessing a table locked by another session.
+	// The session doesn't wait for the lock, so it returns the lock wait timeout error at once.
+	ErrTableLocked = terror.ClassSchema.New(codeTableLocked, "Table '%s' was locked in %s by %v")
+	// ErrTableNotLocked returns for accessing a table not locked by the session which holds table locks.
+	ErrTableNotLocked = terror.ClassSchema.New(codeTableNotLocked, mysql.MySQLErrName[mysql.ErrTableNotLocked])
+	// ErrTableNotLockedForWrite returns for writing a table locked for READ by the session.
+	ErrTableNotLockedForWrite = terror.ClassSchema.New(codeTableNotLockedForWrite, mysql.MySQLErrName[mysql.ErrTableNotLockedForWrite])
 )
 
 // InfoSchema is the interface used to retrieve the schema information.
@@ -119,6 +127,9 @@ type infoSchema struct {
 
 	// schemaMetaVersion is the version of schema, and we should check version when change schema.
 	schemaMetaVersion int64
+
+	// loader loads the lazy tables, it's nil if all the tables are loaded.
+	loader *TableLoader
 }
 
 // MockInfoSchema only serves for test.
@@ -166,12 +177,22 @@ func (is *infoSchema) SchemaExists(schema model.CIStr) bool {
 func (is *infoSchema) TableByName(schema, table model.CIStr) (t table.Table, err error) {
 	if tbNames, ok := is.schemaMap[schema.L]; ok {
 		if t, ok = tbNames.tables[table.L]; ok {
-			return
+			return is.loadTable(t)
 		}
 	}
 	return nil, ErrTableNotExists.GenByArgs(schema, table)
 }
 
+// loadTable returns the loaded table of a lazy table, the other tables are returned as they are.
+func (is *infoSchema) loadTable(t table.Table) (table.Table, error) {
+	lt, ok := t.(*lazyTable)
+	if !ok {
+		return t, nil
+	}
+	t, err := is.loader.load(lt)
+	return t, errors.Trace(err)
+}
+
 func (is *infoSchema) TableExists(schema, table model.CIStr) bool {
 	if tbNames, ok := is.schemaMap[schema.L]; ok {
 		if _, ok = tbNames.tables[table.L]; ok {
@@ -196,7 +217,12 @@ func (is *infoSchema) TableByID(id int64) (val table.Table, ok bool) {
 	if idx == -1 {
 		return nil, false
 	}
-	return slice[idx], true
+	tbl, err := is.loadTable(slice[idx])
+	if err != nil {
+		log.Errorf("[ddl] load table %d failed %v", id, errors.ErrorStack(err))
+		return nil, false
+	}
+	return tbl, true
 }
 
 func (is *infoSchema) AllocByID(id int64) (autoid.Allocator, bool) {
@@ -227,11 +253,35 @@ func (is *infoSchema) SchemaTables(schema model.CIStr) (tables []table.Table) {
 		return
 	}
 	for _, tbl := range schemaTables.tables {
-		tables = append(tables, tbl)
+		loaded, err := is.loadTable(tbl)
+		if err != nil {
+			log.Errorf("[ddl] load table %s.%s failed %v", schema, tbl.Meta().Name, errors.ErrorStack(err))
+			continue
+		}
+		tables = append(tables, loaded)
 	}
 	return
 }
 
+// AllSchemasWithTables returns all the databases like AllSchemas, the Tables of the databases have the full
+// infos of the tables even if they are loaded on demand.
+func AllSchemasWithTables(is InfoSchema) []*model.DBInfo {
+	schemas := is.AllSchemas()
+	if v, ok := is.(*infoSchema); !ok || v.loader == nil {
+		return schemas
+	}
+	for i, di := range schemas {
+		newInfo := *di
+		tbls := is.SchemaTables(di.Name)
+		newInfo.Tables = make([]*model.TableInfo, 0, len(tbls))
+		for _, tbl := range tbls {
+			newInfo.Tables = append(newInfo.Tables, tbl.Meta())
+		}
+		schemas[i] = &newInfo
+	}
+	return schemas
+}
+
 func (is *infoSchema) Clone() (result []*model.DBInfo) {
 	for _, v := range is.schemaMap {
 		result = append(result, v.dbInfo.Clone())
@@ -244,6 +294,7 @@ type Handle struct {
 	value      atomic.Value
 	store      kv.Storage
 	perfHandle perfschema.PerfSchema
+	loader     *TableLoader
 }
 
 // NewHandle creates a new Handle.
@@ -267,6 +318,16 @@ func (h *Handle) Get() InfoSchema {
 	return schema
 }
 
+// SetTableLoader makes the InfoSchema built later load the tables on demand by the loader.
+func (h *Handle) SetTableLoader(loader *TableLoader) {
+	h.loader = loader
+}
+
+// TableLoader returns the loader of the lazy tables, it's nil if the tables aren't loaded on demand.
+func (h *Handle) TableLoader() *TableLoader {
+	return h.loader
+}
+
 // GetPerfHandle gets performance schema from handle.
 func (h *Handle) GetPerfHandle() perfschema.PerfSchema {
 	return h.perfHandle
@@ -277,6 +338,7 @@ func (h *Handle) EmptyClone() *Handle {
 	newHandle := &Handle{
 		store:      h.store,
 		perfHandle: h.perfHandle,
+		loader:     h.loader,
 	}
 	return newHandle
 }
@@ -298,6 +360,10 @@ const (
 	codeColumnExists   = 1060
 	codeIndexExists    = 1831
 	codeMultiplePriKey = 1068
+
+	codeTableLocked            = 1205
+	codeTableNotLocked         = 1100
+	codeTableNotLockedForWrite = 1099
 )
 
 func init() {
@@ -315,6 +381,10 @@ func init() {
 		codeColumnExists:        mysql.ErrDupFieldName,
 		codeIndexExists:         mysql.ErrDupIndex,
 		codeMultiplePriKey:      mysql.ErrMultiplePriKey,
+
+		codeTableLocked:            mysql.ErrLockWaitTimeout,
+		codeTableNotLocked:         mysql.ErrTableNotLocked,
+		codeTableNotLockedForWrite: mysql.ErrTableNotLockedForWrite,
 	}
 	terror.ErrClassToMySQLCodes[terror.ClassSchema] = schemaMySQLErrCodes
 	initInfoSchemaDB()
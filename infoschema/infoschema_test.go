@@ -278,6 +278,98 @@ func (*testSuite) TestInfoTables(c *C) {
 	}
 }
 
+func (*testSuite) TestLazyTables(c *C) {
+	defer testleak.AfterTest(c)()
+	driver := localstore.Driver{Driver: goleveldb.MemoryDriver{}}
+	store, err := driver.Open("memory")
+	c.Assert(err, IsNil)
+	defer store.Close()
+
+	handle, err := infoschema.NewHandle(store)
+	c.Assert(err, IsNil)
+	loader := infoschema.NewTableLoader(store, 2)
+	handle.SetTableLoader(loader)
+
+	dbInfo := &model.DBInfo{ID: 1, Name: model.NewCIStr("test"), State: model.StatePublic}
+	newTable := func(id int64, name string) *model.TableInfo {
+		col := &model.ColumnInfo{
+			ID:        1,
+			Name:      model.NewCIStr("a"),
+			FieldType: *types.NewFieldType(mysql.TypeLonglong),
+			State:     model.StatePublic,
+		}
+		return &model.TableInfo{ID: id, Name: model.NewCIStr(name), Columns: []*model.ColumnInfo{col}, State: model.StatePublic}
+	}
+	err = kv.RunInNewTxn(store, true, func(txn kv.Transaction) error {
+		m := meta.NewMeta(txn)
+		if err1 := m.CreateDatabase(dbInfo); err1 != nil {
+			return errors.Trace(err1)
+		}
+		for i := int64(1); i <= 3; i++ {
+			if err1 := m.CreateTable(dbInfo.ID, newTable(i+1, fmt.Sprintf("t%d", i))); err1 != nil {
+				return errors.Trace(err1)
+			}
+		}
+		return nil
+	})
+	c.Assert(err, IsNil)
+
+	ver, err := store.CurrentVersion()
+	c.Assert(err, IsNil)
+	snapshot, err := store.GetSnapshot(ver)
+	c.Assert(err, IsNil)
+	tables, hashes, err := meta.NewSnapshotMeta(snapshot).ListTableStubs(dbInfo.ID)
+	c.Assert(err, IsNil)
+	dbInfo.Tables = tables
+	hashMap := make(map[int64]uint64)
+	for i, tbl := range tables {
+		hashMap[tbl.ID] = hashes[i]
+	}
+	infoschema.NewBuilder(handle).InitWithTableStubs([]*model.DBInfo{dbInfo}, hashMap, ver.Ver, 1).Build()
+	is := handle.Get()
+	c.Assert(loader.Len(), Equals, 0)
+	c.Assert(is.TableExists(model.NewCIStr("test"), model.NewCIStr("t1")), IsTrue)
+	c.Assert(loader.Len(), Equals, 0)
+
+	tbl, err := is.TableByName(model.NewCIStr("test"), model.NewCIStr("t1"))
+	c.Assert(err, IsNil)
+	c.Assert(tbl.Cols(), HasLen, 1)
+	tbl2, ok := is.TableByID(2)
+	c.Assert(ok, IsTrue)
+	c.Assert(tbl2, Equals, tbl)
+	_, ok = is.AllocByID(3)
+	c.Assert(ok, IsTrue)
+	c.Assert(loader.Len(), Equals, 2)
+	// The least recently used table is evicted.
+	c.Assert(is.SchemaTables(model.NewCIStr("test")), HasLen, 3)
+	c.Assert(loader.Len(), Equals, 2)
+	schemas := infoschema.AllSchemasWithTables(is)
+	for _, di := range schemas {
+		if di.Name.L == "test" {
+			c.Assert(di.Tables, HasLen, 3)
+			for _, tblInfo := range di.Tables {
+				c.Assert(tblInfo.Columns, HasLen, 1)
+			}
+		}
+	}
+	c.Assert(dbInfo.Tables[0].Columns, HasLen, 0)
+
+	// The table changed after it's listed is loaded at the listed version.
+	err = kv.RunInNewTxn(store, true, func(txn kv.Transaction) error {
+		tblInfo := newTable(4, "t3")
+		tblInfo.Comment = "changed"
+		return errors.Trace(meta.NewMeta(txn).UpdateTable(dbInfo.ID, tblInfo))
+	})
+	c.Assert(err, IsNil)
+	for i := int64(2); i <= 3; i++ {
+		_, ok = is.TableByID(i)
+		c.Assert(ok, IsTrue)
+	}
+	tbl, err = is.TableByName(model.NewCIStr("test"), model.NewCIStr("t3"))
+	c.Assert(err, IsNil)
+	c.Assert(tbl.Meta().Comment, Equals, "")
+}
+
 func genGlobalID(store kv.Storage) (int64, error) {
 	var globalID int64
 	err := kv.RunInNewTxn(store, true, func(txn kv.Transaction) error {
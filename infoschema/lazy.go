@@ -0,0 +1,141 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package infoschema
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/meta"
+	"github.com/pingcap/tidb/meta/autoid"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/table"
+	"github.com/pingcap/tidb/table/tables"
+)
+
+// lazyTable is the placeholder of a table which isn't loaded, it only has the ID, the name and the state of the
+// table. Only the Meta method can be called, the InfoSchema replaces it by the table loaded by the TableLoader
+// before returning it.
+type lazyTable struct {
+	table.Table
+	meta *model.TableInfo
+	dbID int64
+	// hash is the hash of the encoded table info listed at ts, the table info loaded later must have the same hash.
+	hash uint64
+	ts   uint64
+}
+
+// Meta implements table.Table Meta interface.
+func (t *lazyTable) Meta() *model.TableInfo {
+	return t.meta
+}
+
+// TableLoader loads the tables of the InfoSchema on demand, and keeps the recently used tables in a LRU cache.
+// So the servers of the clusters with a great many tables only keep the names of the tables in memory.
+type TableLoader struct {
+	store    kv.Storage
+	capacity int
+
+	mu struct {
+		sync.Mutex
+		lru *list.List
+		// tables is keyed by the table ID, the value is the element of lru.
+		tables map[int64]*list.Element
+	}
+}
+
+type loadedTable struct {
+	hash uint64
+	tbl  table.Table
+}
+
+// NewTableLoader creates a TableLoader which keeps at most capacity tables in memory.
+func NewTableLoader(store kv.Storage, capacity int) *TableLoader {
+	l := &TableLoader{store: store, capacity: capacity}
+	l.mu.lru = list.New()
+	l.mu.tables = make(map[int64]*list.Element)
+	return l
+}
+
+// load returns the table of the placeholder, it's loaded from the storage if it isn't in the cache.
+func (l *TableLoader) load(t *lazyTable) (table.Table, error) {
+	l.mu.Lock()
+	if e, ok := l.mu.tables[t.meta.ID]; ok && e.Value.(*loadedTable).hash == t.hash {
+		l.mu.lru.MoveToFront(e)
+		l.mu.Unlock()
+		tableCacheCounter.WithLabelValues("hit").Inc()
+		return e.Value.(*loadedTable).tbl, nil
+	}
+	l.mu.Unlock()
+	tableCacheCounter.WithLabelValues("miss").Inc()
+
+	ver, err := l.store.CurrentVersion()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	tblInfo, hash, err := l.fetch(t, ver.Ver)
+	if err == nil && hash != t.hash {
+		// The table is changed after it's listed, the InfoSchema is older than the latest one and it needs the
+		// listed version of the table.
+		tblInfo, hash, err = l.fetch(t, t.ts)
+	}
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if tblInfo == nil || hash != t.hash {
+		return nil, ErrTableNotExists.GenByArgs(fmt.Sprintf("(Schema ID %d)", t.dbID),
+			fmt.Sprintf("(Table ID %d)", t.meta.ID))
+	}
+	schemaID := t.dbID
+	if tblInfo.OldSchemaID != 0 {
+		schemaID = tblInfo.OldSchemaID
+	}
+	alloc := autoid.NewAllocatorWithCache(l.store, schemaID, tblInfo.AutoIDCache)
+	tbl, err := tables.TableFromMeta(alloc, tblInfo)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if e, ok := l.mu.tables[t.meta.ID]; ok {
+		l.mu.lru.Remove(e)
+	}
+	l.mu.tables[t.meta.ID] = l.mu.lru.PushFront(&loadedTable{hash: hash, tbl: tbl})
+	for l.mu.lru.Len() > l.capacity {
+		e := l.mu.lru.Back()
+		l.mu.lru.Remove(e)
+		delete(l.mu.tables, e.Value.(*loadedTable).tbl.Meta().ID)
+	}
+	return tbl, nil
+}
+
+func (l *TableLoader) fetch(t *lazyTable, ts uint64) (*model.TableInfo, uint64, error) {
+	snapshot, err := l.store.GetSnapshot(kv.NewVersion(ts))
+	if err != nil {
+		return nil, 0, errors.Trace(err)
+	}
+	tblInfo, hash, err := meta.NewSnapshotMeta(snapshot).GetTableWithHash(t.dbID, t.meta.ID)
+	return tblInfo, hash, errors.Trace(err)
+}
+
+// Len returns the number of the tables in the cache.
+func (l *TableLoader) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.mu.lru.Len()
+}
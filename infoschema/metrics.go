@@ -0,0 +1,32 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package infoschema
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	tableCacheCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "tidb",
+			Subsystem: "infoschema",
+			Name:      "table_cache_total",
+			Help:      "Counter of the hits and the misses of the lazily loaded tables.",
+		}, []string{"type"})
+)
+
+func init() {
+	prometheus.MustRegister(tableCacheCounter)
+}
@@ -16,17 +16,22 @@ package infoschema
 import (
 	"fmt"
 	"sort"
+	"time"
 
 	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/event"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/meta/autoid"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/plugin"
 	"github.com/pingcap/tidb/privilege"
 	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/sessionctx/varsutil"
 	"github.com/pingcap/tidb/table"
+	"github.com/pingcap/tidb/util"
 	"github.com/pingcap/tidb/util/charset"
 	"github.com/pingcap/tidb/util/types"
 )
@@ -63,6 +68,11 @@ const (
 	tableOptimizerTrace                     = "OPTIMIZER_TRACE"
 	tableTableSpaces                        = "TABLESPACES"
 	tableCollationCharacterSetApplicability = "COLLATION_CHARACTER_SET_APPLICABILITY"
+	tableClusterProcessList                 = "CLUSTER_PROCESSLIST"
+	tableClusterSlowQuery                   = "CLUSTER_SLOW_QUERY"
+	tableClusterConfig                      = "CLUSTER_CONFIG"
+	tableTiDBHotRegions                     = "TIDB_HOT_REGIONS"
+	tableTiDBHotTables                      = "TIDB_HOT_TABLES"
 )
 
 type columnInfo struct {
@@ -176,7 +186,7 @@ var statisticsCols = []columnInfo{
 	{"INDEX_SCHEMA", mysql.TypeVarchar, 64, 0, nil, nil},
 	{"INDEX_NAME", mysql.TypeVarchar, 64, 0, nil, nil},
 	{"SEQ_IN_INDEX", mysql.TypeLonglong, 2, 0, nil, nil},
-	{"COLUMN_NAME", mysql.TypeVarchar, 21, 0, nil, nil},
+	{"COLUMN_NAME", mysql.TypeVarchar, 64, 0, nil, nil},
 	{"COLLATION", mysql.TypeVarchar, 1, 0, nil, nil},
 	{"CARDINALITY", mysql.TypeLonglong, 21, 0, nil, nil},
 	{"SUB_PART", mysql.TypeLonglong, 3, 0, nil, nil},
@@ -185,6 +195,8 @@ var statisticsCols = []columnInfo{
 	{"INDEX_TYPE", mysql.TypeVarchar, 16, 0, nil, nil},
 	{"COMMENT", mysql.TypeVarchar, 16, 0, nil, nil},
 	{"INDEX_COMMENT", mysql.TypeVarchar, 1024, 0, nil, nil},
+	{"IS_VISIBLE", mysql.TypeVarchar, 3, 0, nil, nil},
+	{"EXPRESSION", mysql.TypeBlob, 196606, 0, nil, nil},
 }
 
 var profilingCols = []columnInfo{
@@ -520,6 +532,59 @@ var tableCollationCharacterSetApplicabilityCols = []columnInfo{
 	{"TABLESPACE_COMMENT", mysql.TypeVarchar, 2048, 0, nil, nil},
 }
 
+// The cluster tables have the infos of all the TiDB servers in the cluster, the INSTANCE column is the
+// address of the server.
+var tableClusterProcessListCols = []columnInfo{
+	{"INSTANCE", mysql.TypeVarchar, 64, 0, nil, nil},
+	{"ID", mysql.TypeLonglong, 21, mysql.UnsignedFlag, nil, nil},
+	{"USER", mysql.TypeVarchar, 16, 0, nil, nil},
+	{"HOST", mysql.TypeVarchar, 64, 0, nil, nil},
+	{"DB", mysql.TypeVarchar, 64, 0, nil, nil},
+	{"COMMAND", mysql.TypeVarchar, 16, 0, nil, nil},
+	{"TIME", mysql.TypeLonglong, 21, mysql.UnsignedFlag, nil, nil},
+	{"STATE", mysql.TypeVarchar, 64, 0, nil, nil},
+	{"INFO", mysql.TypeLongBlob, types.UnspecifiedLength, 0, nil, nil},
+}
+
+var tableClusterSlowQueryCols = []columnInfo{
+	{"INSTANCE", mysql.TypeVarchar, 64, 0, nil, nil},
+	{"START_TIME", mysql.TypeDatetime, 26, 0, nil, nil},
+	{"DURATION", mysql.TypeDouble, 22, 0, nil, nil},
+	{"CONN_ID", mysql.TypeLonglong, 21, mysql.UnsignedFlag, nil, nil},
+	{"USER", mysql.TypeVarchar, 16, 0, nil, nil},
+	{"DB", mysql.TypeVarchar, 64, 0, nil, nil},
+	{"DIGEST", mysql.TypeVarchar, 64, 0, nil, nil},
+	{"QUERY", mysql.TypeLongBlob, types.UnspecifiedLength, 0, nil, nil},
+}
+
+var tableClusterConfigCols = []columnInfo{
+	{"INSTANCE", mysql.TypeVarchar, 64, 0, nil, nil},
+	{"KEY", mysql.TypeVarchar, 256, 0, nil, nil},
+	{"VALUE", mysql.TypeVarchar, 1024, 0, nil, nil},
+}
+
+// The hot tables have the QPS of the requests the TiDB servers in the cluster send to the tables and the
+// indices, TYPE is "read" or "write".
+var tableTiDBHotRegionsCols = []columnInfo{
+	{"TABLE_ID", mysql.TypeLonglong, 21, 0, nil, nil},
+	{"INDEX_ID", mysql.TypeLonglong, 21, 0, nil, nil},
+	{"DB_NAME", mysql.TypeVarchar, 64, 0, nil, nil},
+	{"TABLE_NAME", mysql.TypeVarchar, 64, 0, nil, nil},
+	{"INDEX_NAME", mysql.TypeVarchar, 64, 0, nil, nil},
+	{"REGION_ID", mysql.TypeLonglong, 21, mysql.UnsignedFlag, nil, nil},
+	{"TYPE", mysql.TypeVarchar, 16, 0, nil, nil},
+	{"QPS", mysql.TypeDouble, 22, 0, nil, nil},
+}
+
+var tableTiDBHotTablesCols = []columnInfo{
+	{"TABLE_ID", mysql.TypeLonglong, 21, 0, nil, nil},
+	{"DB_NAME", mysql.TypeVarchar, 64, 0, nil, nil},
+	{"TABLE_NAME", mysql.TypeVarchar, 64, 0, nil, nil},
+	{"TYPE", mysql.TypeVarchar, 16, 0, nil, nil},
+	{"QPS", mysql.TypeDouble, 22, 0, nil, nil},
+	{"REGION_COUNT", mysql.TypeLonglong, 21, 0, nil, nil},
+}
+
 func dataForCharacterSets() (records [][]types.Datum) {
 	records = append(records,
 		types.MakeDatums("ascii", "ascii_general_ci", "US ASCII", 1),
@@ -561,6 +626,348 @@ func dataForUserPrivileges(ctx context.Context) [][]types.Datum {
 	return pm.UserPrivilegesTable()
 }
 
+// getClusterManager returns the cluster manager of the session, it returns nil if the session manager
+// can't get the infos of the cluster.
+func getClusterManager(ctx context.Context) util.ClusterManager {
+	cm, ok := ctx.GetSessionManager().(util.ClusterManager)
+	if !ok {
+		return nil
+	}
+	return cm
+}
+
+// appendClusterErrors reports the servers which fail to respond as warnings, so the infos of the other
+// servers are still returned.
+func appendClusterErrors(ctx context.Context, errs []error) {
+	sc := ctx.GetSessionVars().StmtCtx
+	for _, err := range errs {
+		sc.AppendWarning(err)
+	}
+}
+
+func dataForClusterProcessList(ctx context.Context) (records [][]types.Datum) {
+	cm := getClusterManager(ctx)
+	if cm == nil {
+		return nil
+	}
+	processes, errs := cm.ClusterProcessList()
+	appendClusterErrors(ctx, errs)
+	instances := make([]string, 0, len(processes))
+	for instance := range processes {
+		instances = append(instances, instance)
+	}
+	sort.Strings(instances)
+	for _, instance := range instances {
+		for _, pi := range processes[instance] {
+			var t uint64
+			if len(pi.Info) != 0 {
+				t = uint64(time.Since(pi.Time) / time.Second)
+			}
+			records = append(records, types.MakeDatums(
+				instance,
+				pi.ID,
+				pi.User,
+				pi.Host,
+				pi.DB,
+				pi.Command,
+				t,
+				fmt.Sprintf("%d", pi.State),
+				pi.Info,
+			))
+		}
+	}
+	return
+}
+
+func dataForClusterSlowQuery(ctx context.Context) (records [][]types.Datum) {
+	cm := getClusterManager(ctx)
+	if cm == nil {
+		return nil
+	}
+	queries, errs := cm.ClusterSlowQueries()
+	appendClusterErrors(ctx, errs)
+	instances := make([]string, 0, len(queries))
+	for instance := range queries {
+		instances = append(instances, instance)
+	}
+	sort.Strings(instances)
+	for _, instance := range instances {
+		for _, q := range queries[instance] {
+			start := types.Time{Time: types.FromGoTime(q.Start), Type: mysql.TypeDatetime, Fsp: types.MaxFsp}
+			records = append(records, types.MakeDatums(
+				instance,
+				start,
+				q.Duration.Seconds(),
+				q.ConnID,
+				q.User,
+				q.DB,
+				q.Digest,
+				q.SQL,
+			))
+		}
+	}
+	return
+}
+
+func dataForClusterConfig(ctx context.Context) (records [][]types.Datum) {
+	cm := getClusterManager(ctx)
+	if cm == nil {
+		return nil
+	}
+	configs, errs := cm.ClusterConfig()
+	appendClusterErrors(ctx, errs)
+	instances := make([]string, 0, len(configs))
+	for instance := range configs {
+		instances = append(instances, instance)
+	}
+	sort.Strings(instances)
+	for _, instance := range instances {
+		cfg := configs[instance]
+		keys := make([]string, 0, len(cfg))
+		for key := range cfg {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			records = append(records, types.MakeDatums(instance, key, cfg[key]))
+		}
+	}
+	return
+}
+
+// hotRegion is the QPS of the requests all the servers send to a table or an index in a region.
+type hotRegion struct {
+	regionID uint64
+	tableID  int64
+	indexID  int64
+	tp       string
+	qps      float64
+}
+
+// clusterHotRegions sums the QPS of the regions collected by all the servers in the cluster, only the QPS
+// collected by this server is available if the session manager can't get the infos of the cluster. The regions
+// are sorted by the QPS in descending order.
+func clusterHotRegions(ctx context.Context) []*hotRegion {
+	var stats map[string][]util.HotRegionStat
+	if cm := getClusterManager(ctx); cm != nil {
+		var errs []error
+		stats, errs = cm.ClusterHotRegions()
+		appendClusterErrors(ctx, errs)
+	} else if c, ok := ctx.GetStore().(util.HotRegionCollector); ok {
+		stats = map[string][]util.HotRegionStat{"": c.HotRegions()}
+	}
+	type regionKey struct {
+		regionID uint64
+		tableID  int64
+		indexID  int64
+		tp       string
+	}
+	regions := make(map[regionKey]*hotRegion)
+	add := func(stat util.HotRegionStat, tp string, qps float64) {
+		if qps <= 0 {
+			return
+		}
+		key := regionKey{regionID: stat.RegionID, tableID: stat.TableID, indexID: stat.IndexID, tp: tp}
+		r, ok := regions[key]
+		if !ok {
+			r = &hotRegion{regionID: stat.RegionID, tableID: stat.TableID, indexID: stat.IndexID, tp: tp}
+			regions[key] = r
+		}
+		r.qps += qps
+	}
+	for _, instanceStats := range stats {
+		for _, stat := range instanceStats {
+			add(stat, "read", stat.ReadQPS)
+			add(stat, "write", stat.WriteQPS)
+		}
+	}
+	hotRegions := make([]*hotRegion, 0, len(regions))
+	for _, r := range regions {
+		hotRegions = append(hotRegions, r)
+	}
+	sort.Slice(hotRegions, func(i, j int) bool {
+		a, b := hotRegions[i], hotRegions[j]
+		if a.qps != b.qps {
+			return a.qps > b.qps
+		}
+		if a.regionID != b.regionID {
+			return a.regionID < b.regionID
+		}
+		if a.tableID != b.tableID {
+			return a.tableID < b.tableID
+		}
+		if a.indexID != b.indexID {
+			return a.indexID < b.indexID
+		}
+		return a.tp < b.tp
+	})
+	return hotRegions
+}
+
+// qualifiedTableNames returns the schema and table names keyed by the table IDs.
+func qualifiedTableNames(dbs []*model.DBInfo) map[int64][2]string {
+	names := make(map[int64][2]string)
+	for _, db := range dbs {
+		for _, tbl := range db.Tables {
+			names[tbl.ID] = [2]string{db.Name.O, tbl.Name.O}
+		}
+	}
+	return names
+}
+
+func dataForTiDBHotRegions(ctx context.Context, is InfoSchema, dbs []*model.DBInfo) (records [][]types.Datum) {
+	names := qualifiedTableNames(dbs)
+	for _, r := range clusterHotRegions(ctx) {
+		var dbName, tableName, indexName interface{}
+		if name, ok := names[r.tableID]; ok {
+			dbName, tableName = name[0], name[1]
+		}
+		if tbl, ok := is.TableByID(r.tableID); ok && r.indexID != 0 {
+			for _, idx := range tbl.Meta().Indices {
+				if idx.ID == r.indexID {
+					indexName = idx.Name.O
+				}
+			}
+		}
+		records = append(records, types.MakeDatums(
+			r.tableID,
+			r.indexID,
+			dbName,
+			tableName,
+			indexName,
+			r.regionID,
+			r.tp,
+			r.qps,
+		))
+	}
+	return
+}
+
+// dataForTiDBHotTables sums the QPS of the regions by the tables, the requests to the indices are included.
+func dataForTiDBHotTables(ctx context.Context, dbs []*model.DBInfo) (records [][]types.Datum) {
+	type tableKey struct {
+		tableID int64
+		tp      string
+	}
+	type hotTable struct {
+		tableKey
+		qps     float64
+		regions map[uint64]struct{}
+	}
+	var tables []*hotTable
+	tableMap := make(map[tableKey]*hotTable)
+	for _, r := range clusterHotRegions(ctx) {
+		key := tableKey{tableID: r.tableID, tp: r.tp}
+		t, ok := tableMap[key]
+		if !ok {
+			t = &hotTable{tableKey: key, regions: make(map[uint64]struct{})}
+			tableMap[key] = t
+			tables = append(tables, t)
+		}
+		t.qps += r.qps
+		t.regions[r.regionID] = struct{}{}
+	}
+	sort.SliceStable(tables, func(i, j int) bool {
+		return tables[i].qps > tables[j].qps
+	})
+	names := qualifiedTableNames(dbs)
+	for _, t := range tables {
+		var dbName, tableName interface{}
+		if name, ok := names[t.tableID]; ok {
+			dbName, tableName = name[0], name[1]
+		}
+		records = append(records, types.MakeDatums(
+			t.tableID,
+			dbName,
+			tableName,
+			t.tp,
+			t.qps,
+			len(t.regions),
+		))
+	}
+	return
+}
+
+func dataForPlugins() (records [][]types.Datum) {
+	for _, p := range plugin.Plugins() {
+		version := fmt.Sprintf("%d.%d", p.Version>>8, p.Version&0xff)
+		var library interface{}
+		if p.Library != "" {
+			library = p.Library
+		}
+		record := types.MakeDatums(
+			p.Name,           // PLUGIN_NAME
+			version,          // PLUGIN_VERSION
+			p.State.String(), // PLUGIN_STATUS
+			p.Kind.String(),  // PLUGIN_TYPE
+			nil,              // PLUGIN_TYPE_VERSION
+			library,          // PLUGIN_LIBRARY
+			nil,              // PLUGIN_LIBRARY_VERSION
+			nil,              // PLUGIN_AUTHOR
+			p.Description,    // PLUGIN_DESCRIPTION
+			nil,              // PLUGIN_LICENSE
+			"ON",             // LOAD_OPTION
+		)
+		records = append(records, record)
+	}
+	return records
+}
+
+// eventTime returns the datum of the event time, it's NULL for the zero time.
+func eventTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return types.Time{Time: types.FromGoTime(t), Type: mysql.TypeDatetime}
+}
+
+func dataForEvents(ctx context.Context) (records [][]types.Datum, err error) {
+	events, err := event.Load(ctx, "")
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	for _, e := range events {
+		tp, executeAt, starts := "ONE TIME", eventTime(e.ExecuteAt), interface{}(nil)
+		var value, field interface{}
+		if e.Recurring() {
+			tp, executeAt, starts = "RECURRING", nil, eventTime(e.Starts)
+			value, field = fmt.Sprintf("%d", e.IntervalValue), e.IntervalField
+		}
+		completion := "NOT PRESERVE"
+		if e.Preserve {
+			completion = "PRESERVE"
+		}
+		record := types.MakeDatums(
+			catalogVal,                 // EVENT_CATALOG
+			e.DB,                       // EVENT_SCHEMA
+			e.Name,                     // EVENT_NAME
+			e.Definer,                  // DEFINER
+			"SYSTEM",                   // TIME_ZONE
+			"SQL",                      // EVENT_BODY
+			e.Definition,               // EVENT_DEFINITION
+			tp,                         // EVENT_TYPE
+			executeAt,                  // EXECUTE_AT
+			value,                      // INTERVAL_VALUE
+			field,                      // INTERVAL_FIELD
+			"",                         // SQL_MODE
+			starts,                     // STARTS
+			eventTime(e.Ends),          // ENDS
+			e.Status,                   // STATUS
+			completion,                 // ON_COMPLETION
+			eventTime(e.Created),       // CREATED
+			eventTime(e.Created),       // LAST_ALTERED
+			eventTime(e.LastExecuted),  // LAST_EXECUTED
+			e.Comment,                  // EVENT_COMMENT
+			0,                          // ORIGINATOR
+			mysql.DefaultCharset,       // CHARACTER_SET_CLIENT
+			mysql.DefaultCollationName, // COLLATION_CONNECTION
+			mysql.DefaultCollationName, // DATABASE_COLLATION
+		)
+		records = append(records, record)
+	}
+	return records, nil
+}
+
 func dataForEngines() (records [][]types.Datum) {
 	records = append(records,
 		types.MakeDatums("InnoDB", "DEFAULT", "Supports transactions, row-level locking, and foreign keys", "YES", "YES", "YES"),
@@ -678,6 +1085,9 @@ func dataForColumns(schemas []*model.DBInfo) [][]types.Datum {
 func dataForColumnsInTable(schema *model.DBInfo, tbl *model.TableInfo) [][]types.Datum {
 	rows := [][]types.Datum{}
 	for i, col := range tbl.Columns {
+		if col.Hidden {
+			continue
+		}
 		colLen := col.Flen
 		if colLen == types.UnspecifiedLength {
 			colLen = mysql.GetDefaultFieldLength(col.Tp)
@@ -701,18 +1111,18 @@ func dataForColumnsInTable(schema *model.DBInfo, tbl *model.TableInfo) [][]types
 			columnDefault,                        // COLUMN_DEFAULT
 			columnDesc.Null,                      // IS_NULLABLE
 			types.TypeToStr(col.Tp, col.Charset), // DATA_TYPE
-			colLen,                            // CHARACTER_MAXIMUM_LENGTH
-			colLen,                            // CHARACTER_OCTET_LENGTH
-			decimal,                           // NUMERIC_PRECISION
-			0,                                 // NUMERIC_SCALE
-			0,                                 // DATETIME_PRECISION
-			col.Charset,                       // CHARACTER_SET_NAME
-			col.Collate,                       // COLLATION_NAME
-			columnType,                        // COLUMN_TYPE
-			columnDesc.Key,                    // COLUMN_KEY
-			columnDesc.Extra,                  // EXTRA
-			"select,insert,update,references", // PRIVILEGES
-			"", // COLUMN_COMMENT
+			colLen,                               // CHARACTER_MAXIMUM_LENGTH
+			colLen,                               // CHARACTER_OCTET_LENGTH
+			decimal,                              // NUMERIC_PRECISION
+			0,                                    // NUMERIC_SCALE
+			0,                                    // DATETIME_PRECISION
+			col.Charset,                          // CHARACTER_SET_NAME
+			col.Collate,                          // COLLATION_NAME
+			columnType,                           // COLUMN_TYPE
+			columnDesc.Key,                       // COLUMN_KEY
+			columnDesc.Extra,                     // EXTRA
+			"select,insert,update,references",    // PRIVILEGES
+			"",                                   // COLUMN_COMMENT
 		)
 		rows = append(rows, record)
 	}
@@ -754,6 +1164,8 @@ func dataForStatisticsInTable(schema *model.DBInfo, table *model.TableInfo) [][]
 					"BTREE",       // INDEX_TYPE
 					"",            // COMMENT
 					"",            // INDEX_COMMENT
+					"YES",         // IS_VISIBLE
+					nil,           // EXPRESSION
 				)
 				rows = append(rows, record)
 			}
@@ -764,16 +1176,40 @@ func dataForStatisticsInTable(schema *model.DBInfo, table *model.TableInfo) [][]
 		nameToCol[c.Name.L] = c
 	}
 	for _, index := range table.Indices {
+		if index.State != model.StatePublic {
+			continue
+		}
 		nonUnique := "1"
 		if index.Unique {
 			nonUnique = "0"
 		}
+		visible := "YES"
+		if index.Invisible {
+			visible = "NO"
+		}
+		indexType := index.Tp.String()
+		if indexType == "" {
+			indexType = "BTREE"
+		}
 		for i, key := range index.Columns {
 			col := nameToCol[key.Name.L]
 			nullable := "YES"
 			if mysql.HasNotNullFlag(col.Flag) {
 				nullable = ""
 			}
+			// The key of an expression index has no column name, the expression is shown instead.
+			var colName, expr interface{} = col.Name.O, nil
+			if col.Hidden {
+				colName, expr = nil, col.GeneratedExprString
+			}
+			var subPart interface{}
+			if key.Length != types.UnspecifiedLength {
+				subPart = key.Length
+			}
+			collation := "A"
+			if key.Desc {
+				collation = "D"
+			}
 			record := types.MakeDatums(
 				catalogVal,    // TABLE_CATALOG
 				schema.Name.O, // TABLE_SCHEMA
@@ -782,15 +1218,17 @@ func dataForStatisticsInTable(schema *model.DBInfo, table *model.TableInfo) [][]
 				schema.Name.O, // INDEX_SCHEMA
 				index.Name.O,  // INDEX_NAME
 				i+1,           // SEQ_IN_INDEX
-				key.Name.O,    // COLUMN_NAME
-				"A",           // COLLATION
+				colName,       // COLUMN_NAME
+				collation,     // COLLATION
 				0,             // CARDINALITY
-				nil,           // SUB_PART
+				subPart,       // SUB_PART
 				nil,           // PACKED
 				nullable,      // NULLABLE
-				"BTREE",       // INDEX_TYPE
+				indexType,     // INDEX_TYPE
 				"",            // COMMENT
-				"",            // INDEX_COMMENT
+				index.Comment, // INDEX_COMMENT
+				visible,       // IS_VISIBLE
+				expr,          // EXPRESSION
 			)
 			rows = append(rows, record)
 		}
@@ -802,6 +1240,7 @@ const (
 	primaryKeyType    = "PRIMARY KEY"
 	primaryConstraint = "PRIMARY"
 	uniqueKeyType     = "UNIQUE"
+	foreignKeyType    = "FOREIGN KEY"
 )
 
 // dataForTableConstraints constructs data for table information_schema.constraints.See https://dev.mysql.com/doc/refman/5.7/en/table-constraints-table.html
@@ -822,6 +1261,9 @@ func dataForTableConstraints(schemas []*model.DBInfo) [][]types.Datum {
 			}
 
 			for _, idx := range tbl.Indices {
+				if idx.State != model.StatePublic {
+					continue
+				}
 				var cname, ctype string
 				if idx.Primary {
 					cname = mysql.PrimaryKeyName
@@ -843,6 +1285,17 @@ func dataForTableConstraints(schemas []*model.DBInfo) [][]types.Datum {
 				)
 				rows = append(rows, record)
 			}
+			for _, fk := range tbl.ForeignKeys {
+				record := types.MakeDatums(
+					catalogVal,     // CONSTRAINT_CATALOG
+					schema.Name.O,  // CONSTRAINT_SCHEMA
+					fk.Name.O,      // CONSTRAINT_NAME
+					schema.Name.O,  // TABLE_SCHEMA
+					tbl.Name.O,     // TABLE_NAME
+					foreignKeyType, // CONSTRAINT_TYPE
+				)
+				rows = append(rows, record)
+			}
 		}
 	}
 	return rows
@@ -873,7 +1326,7 @@ func keyColumnUsageInTable(schema *model.DBInfo, table *model.TableInfo) [][]typ
 					table.Name.O,      // TABLE_NAME
 					col.Name.O,        // COLUMN_NAME
 					1,                 // ORDINAL_POSITION
-					1,                 // POSITION_IN_UNIQUE_CONSTRAINT
+					nil,               // POSITION_IN_UNIQUE_CONSTRAINT
 					nil,               // REFERENCED_TABLE_SCHEMA
 					nil,               // REFERENCED_TABLE_NAME
 					nil,               // REFERENCED_COLUMN_NAME
@@ -888,6 +1341,9 @@ func keyColumnUsageInTable(schema *model.DBInfo, table *model.TableInfo) [][]typ
 		nameToCol[c.Name.L] = c
 	}
 	for _, index := range table.Indices {
+		if index.State != model.StatePublic {
+			continue
+		}
 		var idxName string
 		if index.Primary {
 			idxName = primaryConstraint
@@ -899,6 +1355,10 @@ func keyColumnUsageInTable(schema *model.DBInfo, table *model.TableInfo) [][]typ
 		}
 		for i, key := range index.Columns {
 			col := nameToCol[key.Name.L]
+			// The key of an expression index isn't a column.
+			if col.Hidden {
+				continue
+			}
 			record := types.MakeDatums(
 				catalogVal,    // CONSTRAINT_CATALOG
 				schema.Name.O, // CONSTRAINT_SCHEMA
@@ -917,12 +1377,12 @@ func keyColumnUsageInTable(schema *model.DBInfo, table *model.TableInfo) [][]typ
 		}
 	}
 	for _, fk := range table.ForeignKeys {
-		fkRefCol := ""
-		if len(fk.RefCols) > 0 {
-			fkRefCol = fk.RefCols[0].O
-		}
 		for i, key := range fk.Cols {
 			col := nameToCol[key.L]
+			var fkRefCol interface{}
+			if i < len(fk.RefCols) {
+				fkRefCol = fk.RefCols[i].O
+			}
 			record := types.MakeDatums(
 				catalogVal,    // CONSTRAINT_CATALOG
 				schema.Name.O, // CONSTRAINT_SCHEMA
@@ -932,7 +1392,7 @@ func keyColumnUsageInTable(schema *model.DBInfo, table *model.TableInfo) [][]typ
 				table.Name.O,  // TABLE_NAME
 				col.Name.O,    // COLUMN_NAME
 				i+1,           // ORDINAL_POSITION,
-				1,             // POSITION_IN_UNIQUE_CONSTRAINT
+				i+1,           // POSITION_IN_UNIQUE_CONSTRAINT
 				schema.Name.O, // REFERENCED_TABLE_SCHEMA
 				fk.RefTable.O, // REFERENCED_TABLE_NAME
 				fkRefCol,      // REFERENCED_COLUMN_NAME
@@ -943,6 +1403,72 @@ func keyColumnUsageInTable(schema *model.DBInfo, table *model.TableInfo) [][]typ
 	return rows
 }
 
+// dataForReferentialConstraints constructs data for table information_schema.referential_constraints.
+func dataForReferentialConstraints(schemas []*model.DBInfo) [][]types.Datum {
+	rows := [][]types.Datum{}
+	for _, schema := range schemas {
+		for _, tbl := range schema.Tables {
+			for _, fk := range tbl.ForeignKeys {
+				record := types.MakeDatums(
+					catalogVal,                         // CONSTRAINT_CATALOG
+					schema.Name.O,                      // CONSTRAINT_SCHEMA
+					fk.Name.O,                          // CONSTRAINT_NAME
+					catalogVal,                         // UNIQUE_CONSTRAINT_CATALOG
+					schema.Name.O,                      // UNIQUE_CONSTRAINT_SCHEMA
+					referredConstraintName(schema, fk), // UNIQUE_CONSTRAINT_NAME
+					"NONE",                             // MATCH_OPTION
+					referRuleString(fk.OnUpdate),       // UPDATE_RULE
+					referRuleString(fk.OnDelete),       // DELETE_RULE
+					tbl.Name.O,                         // TABLE_NAME
+					fk.RefTable.O,                      // REFERENCED_TABLE_NAME
+				)
+				rows = append(rows, record)
+			}
+		}
+	}
+	return rows
+}
+
+// referredConstraintName returns the name of the primary key or the unique key on the referenced columns of
+// the foreign key, it's nil if the referenced table or the key doesn't exist.
+func referredConstraintName(schema *model.DBInfo, fk *model.FKInfo) interface{} {
+	for _, tbl := range schema.Tables {
+		if tbl.Name.L != fk.RefTable.L {
+			continue
+		}
+		if tbl.PKIsHandle && len(fk.RefCols) == 1 {
+			if pk := tbl.GetPkColInfo(); pk != nil && pk.Name.L == fk.RefCols[0].L {
+				return primaryConstraint
+			}
+		}
+		for _, index := range tbl.Indices {
+			if !index.Unique || index.State != model.StatePublic || len(index.Columns) != len(fk.RefCols) {
+				continue
+			}
+			match := true
+			for i, key := range index.Columns {
+				match = match && key.Name.L == fk.RefCols[i].L
+			}
+			if !match {
+				continue
+			}
+			if index.Primary {
+				return primaryConstraint
+			}
+			return index.Name.O
+		}
+	}
+	return nil
+}
+
+// referRuleString returns the ON UPDATE/ON DELETE rule of the foreign key, it's RESTRICT if not specified.
+func referRuleString(opt int) string {
+	if rule := ast.ReferOptionType(opt).String(); rule != "" {
+		return rule
+	}
+	return ast.ReferOptionRestrict.String()
+}
+
 var tableNameToColumns = map[string]([]columnInfo){
 	tableSchemata:                           schemataCols,
 	tableTables:                             tablesCols,
@@ -974,6 +1500,11 @@ var tableNameToColumns = map[string]([]columnInfo){
 	tableOptimizerTrace:                     tableOptimizerTraceCols,
 	tableTableSpaces:                        tableTableSpacesCols,
 	tableCollationCharacterSetApplicability: tableCollationCharacterSetApplicabilityCols,
+	tableClusterProcessList:                 tableClusterProcessListCols,
+	tableClusterSlowQuery:                   tableClusterSlowQueryCols,
+	tableClusterConfig:                      tableClusterConfigCols,
+	tableTiDBHotRegions:                     tableTiDBHotRegionsCols,
+	tableTiDBHotTables:                      tableTiDBHotTablesCols,
 }
 
 func createInfoSchemaTable(handle *Handle, meta *model.TableInfo) *infoschemaTable {
@@ -1012,7 +1543,7 @@ func (s schemasSorter) Less(i, j int) bool {
 
 func (it *infoschemaTable) getRows(ctx context.Context, cols []*table.Column) (fullRows [][]types.Datum, err error) {
 	is := it.handle.Get()
-	dbs := is.AllSchemas()
+	dbs := AllSchemasWithTables(is)
 	sort.Sort(schemasSorter(dbs))
 	switch it.meta.Name.O {
 	case tableSchemata:
@@ -1037,25 +1568,39 @@ func (it *infoschemaTable) getRows(ctx context.Context, cols []*table.Column) (f
 	case tableKeyColumm:
 		fullRows = dataForKeyColumnUsage(dbs)
 	case tableReferConst:
-	case tablePlugins, tableTriggers:
+		fullRows = dataForReferentialConstraints(dbs)
+	case tablePlugins:
+		fullRows = dataForPlugins()
+	case tableTriggers:
 	case tableUserPrivileges:
 		fullRows = dataForUserPrivileges(ctx)
 	case tableEngines:
 		fullRows = dataForEngines()
 	case tableViews:
 	case tableRoutines:
+	case tableEvents:
+		fullRows, err = dataForEvents(ctx)
 	// TODO: Fill the following tables.
 	case tableSchemaPrivileges:
 	case tableTablePrivileges:
 	case tableColumnPrivileges:
 	case tableParameters:
-	case tableEvents:
 	case tableGlobalStatus:
 	case tableGlobalVariables:
 	case tableSessionStatus:
 	case tableOptimizerTrace:
 	case tableTableSpaces:
 	case tableCollationCharacterSetApplicability:
+	case tableClusterProcessList:
+		fullRows = dataForClusterProcessList(ctx)
+	case tableClusterSlowQuery:
+		fullRows = dataForClusterSlowQuery(ctx)
+	case tableClusterConfig:
+		fullRows = dataForClusterConfig(ctx)
+	case tableTiDBHotRegions:
+		fullRows = dataForTiDBHotRegions(ctx, is, dbs)
+	case tableTiDBHotTables:
+		fullRows = dataForTiDBHotTables(ctx, dbs)
 	}
 	if err != nil {
 		return nil, errors.Trace(err)
@@ -1152,7 +1697,7 @@ func (it *infoschemaTable) UpdateRecord(ctx context.Context, h int64, oldData, n
 	return table.ErrUnsupportedOp
 }
 
-func (it *infoschemaTable) AllocAutoID() (int64, error) {
+func (it *infoschemaTable) AllocAutoID(ctx context.Context) (int64, error) {
 	return 0, table.ErrUnsupportedOp
 }
 
@@ -20,14 +20,17 @@ import (
 
 	"github.com/juju/errors"
 	"github.com/ngaut/log"
+	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/meta"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/table"
+	"github.com/pingcap/tidb/table/tables"
 	"github.com/pingcap/tidb/tablecodec"
 	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util"
+	"github.com/pingcap/tidb/util/mock"
 	"github.com/pingcap/tidb/util/types"
 )
 
@@ -35,7 +38,10 @@ import (
 type DDLInfo struct {
 	SchemaVer   int64
 	ReorgHandle int64 // it's only used for DDL information.
-	Job         *model.Job
+	// Job is the first job of the general job queue.
+	Job *model.Job
+	// ReorgJob is the first job of the reorganization job queue, ReorgHandle is its handle.
+	ReorgJob *model.Job
 }
 
 // GetDDLInfo returns DDL information.
@@ -48,15 +54,19 @@ func GetDDLInfo(txn kv.Transaction) (*DDLInfo, error) {
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
+	info.ReorgJob, err = meta.NewMeta(txn, meta.ReorgJobListKey).GetDDLJob(0)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
 	info.SchemaVer, err = t.GetSchemaVersion()
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
-	if info.Job == nil {
+	if info.ReorgJob == nil {
 		return info, nil
 	}
 
-	info.ReorgHandle, err = t.GetDDLReorgHandle(info.Job)
+	info.ReorgHandle, err = t.GetDDLReorgHandle(info.ReorgJob)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
@@ -156,26 +166,111 @@ func ScanIndexData(txn kv.Transaction, kvIndex table.Index, startVals []types.Da
 // It returns nil if the data from the index is equal to the data from the table columns,
 // otherwise it returns an error with a different set of records.
 func CompareIndexData(txn kv.Transaction, t table.Table, idx table.Index) error {
-	err := checkIndexAndRecord(txn, t, idx)
+	return CompareIndexDataInRanges(txn, t, idx, nil)
+}
+
+// CompareIndexDataInRanges is like CompareIndexData, but it only compares the records whose
+// handles are in the ranges, and the index data pointing to them. All the records are
+// compared if ranges is empty.
+func CompareIndexDataInRanges(txn kv.Transaction, t table.Table, idx table.Index, ranges []ast.HandleRange) error {
+	err := checkIndexAndRecord(txn, t, idx, ranges)
 	if err != nil {
 		return errors.Trace(err)
 	}
 
-	return checkRecordAndIndex(txn, t, idx)
+	return checkRecordAndIndex(txn, t, idx, ranges)
 }
 
-func checkIndexAndRecord(txn kv.Transaction, t table.Table, idx table.Index) error {
-	it, err := idx.SeekFirst(txn)
-	if err != nil {
-		return errors.Trace(err)
+func inHandleRanges(h int64, ranges []ast.HandleRange) bool {
+	if len(ranges) == 0 {
+		return true
 	}
-	defer it.Close()
+	for _, r := range ranges {
+		if h >= r.Begin && h < r.End {
+			return true
+		}
+	}
+	return false
+}
 
+func indexColumns(t table.Table, idx table.Index) []*table.Column {
 	cols := make([]*table.Column, len(idx.Meta().Columns))
 	for i, col := range idx.Meta().Columns {
 		cols[i] = t.Cols()[col.Offset]
 	}
+	return cols
+}
+
+// isExpressionIndex checks whether the index has a key on a hidden column, the values of the hidden
+// columns aren't stored in the records.
+func isExpressionIndex(t table.Table, idx table.Index) bool {
+	for _, col := range indexColumns(t, idx) {
+		if col.Hidden {
+			return true
+		}
+	}
+	return false
+}
+
+// recordColumns returns the columns of the records to get the index values, all the columns are
+// needed to compute the hidden columns of an expression index.
+func recordColumns(t table.Table, idx table.Index) []*table.Column {
+	if isExpressionIndex(t, idx) {
+		return t.Cols()
+	}
+	return indexColumns(t, idx)
+}
+
+// recordIndexValues returns the index values of the record from the values of the record columns.
+func recordIndexValues(t table.Table, idx table.Index, vals []types.Datum) ([]types.Datum, error) {
+	if vals == nil || !isExpressionIndex(t, idx) {
+		return vals, nil
+	}
+	if err := tables.FillHiddenColumns(mock.NewContext(), t, vals); err != nil {
+		return nil, errors.Trace(err)
+	}
+	idxVals := make([]types.Datum, len(idx.Meta().Columns))
+	for i, ic := range idx.Meta().Columns {
+		col := t.Cols()[ic.Offset]
+		if !col.Hidden {
+			idxVals[i] = vals[ic.Offset]
+			continue
+		}
+		// The computed value is encoded and decoded like a stored value, so it can be compared with the index value.
+		b, err := tablecodec.EncodeValue(vals[ic.Offset], time.UTC)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if idxVals[i], err = tablecodec.DecodeColumnValue(b, &col.FieldType, time.UTC); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	return idxVals, nil
+}
 
+// iterRecordIndexValues is like iterRecordsInRanges, but fn is called with the index values of the records.
+func iterRecordIndexValues(txn kv.Transaction, t table.Table, idx table.Index, ranges []ast.HandleRange,
+	fn func(h int64, vals []types.Datum) (bool, error)) error {
+	return iterRecordsInRanges(txn, t, recordColumns(t, idx), ranges, func(h int64, data []types.Datum, cols []*table.Column) (bool, error) {
+		vals, err := recordIndexValues(t, idx, data)
+		if err != nil {
+			return false, errors.Trace(err)
+		}
+		return fn(h, vals)
+	})
+}
+
+// iterIndexAndRecord calls fn with the index data in the ranges and the values of the
+// record it points to, the values are nil if the record doesn't exist.
+func iterIndexAndRecord(txn kv.Transaction, t table.Table, idx table.Index, ranges []ast.HandleRange,
+	fn func(h int64, idxVals, recordVals []types.Datum) error) error {
+	it, err := idx.SeekFirst(txn)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer it.Close()
+
+	cols := recordColumns(t, idx)
 	for {
 		vals1, h, err := it.Next()
 		if terror.ErrorEqual(err, io.EOF) {
@@ -183,33 +278,69 @@ func checkIndexAndRecord(txn kv.Transaction, t table.Table, idx table.Index) err
 		} else if err != nil {
 			return errors.Trace(err)
 		}
+		if !inHandleRanges(h, ranges) {
+			continue
+		}
 
 		vals2, err := rowWithCols(txn, t, h, cols)
 		if terror.ErrorEqual(err, kv.ErrNotExist) {
-			record := &RecordData{Handle: h, Values: vals1}
-			err = errDateNotEqual.Gen("index:%v != record:%v", record, nil)
+			vals2, err = nil, nil
 		}
 		if err != nil {
 			return errors.Trace(err)
 		}
-		if !reflect.DeepEqual(vals1, vals2) {
-			record1 := &RecordData{Handle: h, Values: vals1}
-			record2 := &RecordData{Handle: h, Values: vals2}
-			return errDateNotEqual.Gen("index:%v != record:%v", record1, record2)
+		if vals2, err = recordIndexValues(t, idx, vals2); err != nil {
+			return errors.Trace(err)
+		}
+		if err = fn(h, vals1, vals2); err != nil {
+			return errors.Trace(err)
 		}
 	}
 
 	return nil
 }
 
-func checkRecordAndIndex(txn kv.Transaction, t table.Table, idx table.Index) error {
-	cols := make([]*table.Column, len(idx.Meta().Columns))
-	for i, col := range idx.Meta().Columns {
-		cols[i] = t.Cols()[col.Offset]
+// iterRecordsInRanges iterates the records whose handles are in the ranges.
+func iterRecordsInRanges(txn kv.Transaction, t table.Table, cols []*table.Column, ranges []ast.HandleRange,
+	fn table.RecordIterFunc) error {
+	if len(ranges) == 0 {
+		return iterRecords(txn, t, t.RecordKey(0), cols, fn)
+	}
+	for _, r := range ranges {
+		stopped := false
+		end := r.End
+		err := iterRecords(txn, t, t.RecordKey(r.Begin), cols, func(h int64, data []types.Datum, cols []*table.Column) (bool, error) {
+			if h >= end {
+				return false, nil
+			}
+			more, err := fn(h, data, cols)
+			stopped = !more
+			return more, errors.Trace(err)
+		})
+		if err != nil || stopped {
+			return errors.Trace(err)
+		}
 	}
+	return nil
+}
 
-	startKey := t.RecordKey(0)
-	filterFunc := func(h1 int64, vals1 []types.Datum, cols []*table.Column) (bool, error) {
+func checkIndexAndRecord(txn kv.Transaction, t table.Table, idx table.Index, ranges []ast.HandleRange) error {
+	return iterIndexAndRecord(txn, t, idx, ranges, func(h int64, vals1, vals2 []types.Datum) error {
+		if vals2 == nil {
+			record := &RecordData{Handle: h, Values: vals1}
+			return errDateNotEqual.Gen("index:%v != record:%v", record, nil)
+		}
+		if !reflect.DeepEqual(vals1, vals2) {
+			record1 := &RecordData{Handle: h, Values: vals1}
+			record2 := &RecordData{Handle: h, Values: vals2}
+			return errDateNotEqual.Gen("index:%v != record:%v", record1, record2)
+		}
+		return nil
+	})
+}
+
+func checkRecordAndIndex(txn kv.Transaction, t table.Table, idx table.Index, ranges []ast.HandleRange) error {
+	filterFunc := func(h1 int64, vals1 []types.Datum) (bool, error) {
 		isExist, h2, err := idx.Exist(txn, vals1, h1)
 		if terror.ErrorEqual(err, kv.ErrKeyExists) {
 			record1 := &RecordData{Handle: h1, Values: vals1}
@@ -226,8 +357,7 @@ func checkRecordAndIndex(txn kv.Transaction, t table.Table, idx table.Index) err
 
 		return true, nil
 	}
-	err := iterRecords(txn, t, startKey, cols, filterFunc)
-
+	err := iterRecordIndexValues(txn, t, idx, ranges, filterFunc)
 	if err != nil {
 		return errors.Trace(err)
 	}
@@ -235,6 +365,56 @@ func checkRecordAndIndex(txn kv.Transaction, t table.Table, idx table.Index) err
 	return nil
 }
 
+// RepairIndex makes the index data consistent with the records whose handles are in the ranges.
+// The index data that doesn't match its record is removed, then the missing index data of the
+// records are added. It returns the number of the added and removed index data.
+// All the records are repaired if ranges is empty.
+func RepairIndex(txn kv.Transaction, t table.Table, idx table.Index, ranges []ast.HandleRange) (added, removed int64, err error) {
+	var stale []*RecordData
+	err = iterIndexAndRecord(txn, t, idx, ranges, func(h int64, vals1, vals2 []types.Datum) error {
+		if vals2 == nil || !reflect.DeepEqual(vals1, vals2) {
+			stale = append(stale, &RecordData{Handle: h, Values: vals1})
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, 0, errors.Trace(err)
+	}
+	for _, r := range stale {
+		if err = idx.Delete(txn, r.Values, r.Handle); err != nil {
+			return 0, 0, errors.Trace(err)
+		}
+		log.Warnf("[inspectkv] remove index %s data %v of table %s", idx.Meta().Name, r, t.Meta().Name)
+	}
+	removed = int64(len(stale))
+
+	err = iterRecordIndexValues(txn, t, idx, ranges, func(h int64, vals []types.Datum) (bool, error) {
+		isExist, h2, err1 := idx.Exist(txn, vals, h)
+		if terror.ErrorEqual(err1, kv.ErrKeyExists) {
+			// Both of the records have the values of the unique index, it can't be repaired.
+			record1 := &RecordData{Handle: h, Values: vals}
+			record2 := &RecordData{Handle: h2, Values: vals}
+			return false, errDateNotEqual.Gen("index:%v != record:%v", record2, record1)
+		}
+		if err1 != nil {
+			return false, errors.Trace(err1)
+		}
+		if isExist {
+			return true, nil
+		}
+		if _, err1 = idx.Create(txn, vals, h); err1 != nil {
+			return false, errors.Trace(err1)
+		}
+		log.Warnf("[inspectkv] add index %s data %v of table %s", idx.Meta().Name, &RecordData{Handle: h, Values: vals}, t.Meta().Name)
+		added++
+		return true, nil
+	})
+	if err != nil {
+		return 0, 0, errors.Trace(err)
+	}
+	return added, removed, nil
+}
+
 func scanTableData(retriever kv.Retriever, t table.Table, cols []*table.Column, startHandle, limit int64) (
 	[]*RecordData, int64, error) {
 	var records []*RecordData
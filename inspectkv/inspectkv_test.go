@@ -19,6 +19,7 @@ import (
 	"time"
 
 	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/meta"
 	"github.com/pingcap/tidb/meta/autoid"
@@ -362,7 +363,7 @@ func (s *testSuite) testIndex(c *C, tb table.Table, idx table.Index) {
 
 	txn, err = s.store.Begin()
 	c.Assert(err, IsNil)
-	err = checkRecordAndIndex(txn, tb, idx)
+	err = checkRecordAndIndex(txn, tb, idx, nil)
 	c.Assert(err, NotNil)
 	record2 = &RecordData{Handle: int64(5), Values: types.MakeDatums(int64(30))}
 	diffMsg = newDiffRetError("index", record1, record2)
@@ -402,6 +403,42 @@ func (s *testSuite) testIndex(c *C, tb table.Table, idx table.Index) {
 	c.Assert(err, NotNil)
 	diffMsg = newDiffRetError("index", nil, record1)
 	c.Assert(err.Error(), DeepEquals, diffMsg)
+
+	// The inconsistent record is out of the ranges.
+	err = CompareIndexDataInRanges(txn, tb, idx, []ast.HandleRange{{Begin: 1, End: 2}, {Begin: 3, End: 4}})
+	c.Assert(err, IsNil)
+	err = CompareIndexDataInRanges(txn, tb, idx, []ast.HandleRange{{Begin: 2, End: 5}})
+	c.Assert(err, NotNil)
+
+	// set data to:
+	// index     data (handle, data): (1, 10), (2, 20), (3, 30), (4, 40), (5, 50)
+	// table     data (handle, data): (1, 10), (2, 20), (3, 30), (4, 40)
+	added, removed, err := RepairIndex(txn, tb, idx, []ast.HandleRange{{Begin: 4, End: 5}})
+	c.Assert(err, IsNil)
+	c.Assert(added, Equals, int64(1))
+	c.Assert(removed, Equals, int64(0))
+	_, err = idx.Create(txn, types.MakeDatums(int64(50)), 5)
+	c.Assert(err, IsNil)
+	key = tablecodec.EncodeRowKey(tb.Meta().ID, codec.EncodeInt(nil, 5))
+	txn.Delete(key)
+	err = txn.Commit()
+	c.Assert(err, IsNil)
+
+	txn, err = s.store.Begin()
+	c.Assert(err, IsNil)
+	err = CompareIndexData(txn, tb, idx)
+	c.Assert(err, NotNil)
+	record1 = &RecordData{Handle: int64(5), Values: types.MakeDatums(int64(50))}
+	diffMsg = newDiffRetError("index", record1, nil)
+	c.Assert(err.Error(), DeepEquals, diffMsg)
+	added, removed, err = RepairIndex(txn, tb, idx, nil)
+	c.Assert(err, IsNil)
+	c.Assert(added, Equals, int64(0))
+	c.Assert(removed, Equals, int64(1))
+	err = CompareIndexData(txn, tb, idx)
+	c.Assert(err, IsNil)
+	err = txn.Commit()
+	c.Assert(err, IsNil)
 }
 
 func setColValue(c *C, txn kv.Transaction, key kv.Key, v types.Datum) {
@@ -144,14 +144,19 @@ type Client interface {
 
 // ReqTypes.
 const (
-	ReqTypeSelect = 101
-	ReqTypeIndex  = 102
-	ReqTypeDAG    = 103
+	ReqTypeSelect   = 101
+	ReqTypeIndex    = 102
+	ReqTypeDAG      = 103
+	ReqTypeChecksum = 104
 
 	ReqSubTypeBasic   = 0
 	ReqSubTypeDesc    = 10000
 	ReqSubTypeGroupBy = 10001
 	ReqSubTypeTopN    = 10002
+	ReqSubTypeChunk   = 10003
+	ReqSubTypeDescKey = 10004
+	ReqSubTypeEnumSet = 10005
+	ReqSubTypeBit     = 10006
 )
 
 // Request represents a kv request.
@@ -172,6 +177,9 @@ type Request struct {
 	IsolationLevel IsoLevel
 	// Priority is the priority of this KV request, its value may be PriorityNormal/PriorityLow/PriorityHigh.
 	Priority int
+	// AllowPartialResult is true, if the key ranges which still fail to be read after the retries are skipped
+	// instead of failing the request.
+	AllowPartialResult bool
 }
 
 // Response represents the response returned from KV layer.
@@ -184,6 +192,18 @@ type Response interface {
 	Close() error
 }
 
+// SkippedRangesReporter is implemented by the Response of a request which allows the partial result.
+// SkippedRanges returns the key ranges skipped for the failures, it's called after Next returns nil.
+type SkippedRangesReporter interface {
+	SkippedRanges() []KeyRange
+}
+
+// SplitKeysGetter is implemented by the Storage which splits the keys into the regions.
+// GetSplitKeys returns the start keys of the regions in the key range, the start key of the range isn't returned.
+type SplitKeysGetter interface {
+	GetSplitKeys(r KeyRange) ([]Key, error)
+}
+
 // Snapshot defines the interface for the snapshot fetched from KV store.
 type Snapshot interface {
 	Retriever
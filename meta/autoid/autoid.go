@@ -36,6 +36,9 @@ type Allocator interface {
 	// Alloc allocs the next autoID for table with tableID.
 	// It gets a batch of autoIDs at a time. So it does not need to access storage for each call.
 	Alloc(tableID int64) (int64, error)
+	// AllocWithIncrement allocs the next autoID in the sequence "offset + N * increment" for table with tableID,
+	// it's used for auto_increment_increment and auto_increment_offset. The skipped IDs are never allocated.
+	AllocWithIncrement(tableID, increment, offset int64) (int64, error)
 	// Rebase rebases the autoID base for table with tableID and the new base value.
 	// If allocIDs is true, it will allocate some IDs and save to the cache.
 	// If allocIDs is false, it will not allocate IDs.
@@ -48,6 +51,8 @@ type allocator struct {
 	end   int64
 	store kv.Storage
 	dbID  int64
+	// cache is the count of the IDs allocated from the storage at a time, the default step is used if it's 0.
+	cache int64
 }
 
 // GetStep is only used by tests
@@ -70,6 +75,10 @@ func (alloc *allocator) Rebase(tableID, newBase int64, allocIDs bool) error {
 		alloc.base = newBase
 		return nil
 	}
+	// No ID is cached if the cache is 1, so the next ID is allocated in the order of the allocations.
+	if alloc.batchSize() == 1 {
+		allocIDs = false
+	}
 
 	return kv.RunInNewTxn(alloc.store, true, func(txn kv.Transaction) error {
 		m := meta.NewMeta(txn)
@@ -81,7 +90,7 @@ func (alloc *allocator) Rebase(tableID, newBase int64, allocIDs bool) error {
 		if newBase < end {
 			newBase = end
 		}
-		newStep := newBase - end + step
+		newStep := newBase - end + alloc.batchSize()
 		if !allocIDs {
 			newStep = newBase - end
 		}
@@ -101,28 +110,39 @@ func (alloc *allocator) Rebase(tableID, newBase int64, allocIDs bool) error {
 
 // Alloc implements autoid.Allocator Alloc interface.
 func (alloc *allocator) Alloc(tableID int64) (int64, error) {
+	return alloc.AllocWithIncrement(tableID, 1, 1)
+}
+
+// AllocWithIncrement implements autoid.Allocator AllocWithIncrement interface.
+func (alloc *allocator) AllocWithIncrement(tableID, increment, offset int64) (int64, error) {
 	if tableID == 0 {
 		return 0, errInvalidTableID.Gen("Invalid tableID")
 	}
 	alloc.mu.Lock()
 	defer alloc.mu.Unlock()
-	if alloc.base == alloc.end { // step
+	id := nextIDInSequence(alloc.base+1, increment, offset)
+	if id > alloc.end { // step
+		// The batch holds at least one ID of the sequence.
+		batch := alloc.batchSize()
+		if batch < increment {
+			batch = increment
+		}
 		err := kv.RunInNewTxn(alloc.store, true, func(txn kv.Transaction) error {
 			m := meta.NewMeta(txn)
 			base, err1 := m.GetAutoTableID(alloc.dbID, tableID)
 			if err1 != nil {
 				return errors.Trace(err1)
 			}
-			end, err1 := m.GenAutoTableID(alloc.dbID, tableID, step)
+			end, err1 := m.GenAutoTableID(alloc.dbID, tableID, batch)
 			if err1 != nil {
 				return errors.Trace(err1)
 			}
 
 			alloc.end = end
-			if end == step {
+			if end == batch {
 				alloc.base = base
 			} else {
-				alloc.base = end - step
+				alloc.base = end - batch
 			}
 			return nil
 		})
@@ -130,13 +150,37 @@ func (alloc *allocator) Alloc(tableID int64) (int64, error) {
 		if err != nil {
 			return 0, errors.Trace(err)
 		}
+		id = nextIDInSequence(alloc.base+1, increment, offset)
 	}
 
-	alloc.base++
+	alloc.base = id
 	log.Debugf("[kv] Alloc id %d, table ID:%d, from %p, database ID:%d", alloc.base, tableID, alloc, alloc.dbID)
 	return alloc.base, nil
 }
 
+// batchSize returns the count of the IDs allocated from the storage at a time.
+func (alloc *allocator) batchSize() int64 {
+	if alloc.cache > 0 {
+		return alloc.cache
+	}
+	return step
+}
+
+// nextIDInSequence returns the smallest ID in the sequence "offset + N * increment" which isn't less than id.
+// The offset is ignored if it's greater than the increment like MySQL.
+func nextIDInSequence(id, increment, offset int64) int64 {
+	if increment <= 1 {
+		return id
+	}
+	if offset > increment {
+		offset = increment
+	}
+	if id <= offset {
+		return offset
+	}
+	return offset + (id-offset+increment-1)/increment*increment
+}
+
 var (
 	memID     int64
 	memIDLock sync.Mutex
@@ -157,19 +201,30 @@ func (alloc *memoryAllocator) Rebase(tableID, newBase int64, allocIDs bool) erro
 
 // Alloc implements autoid.Allocator Alloc interface.
 func (alloc *memoryAllocator) Alloc(tableID int64) (int64, error) {
+	return alloc.AllocWithIncrement(tableID, 1, 1)
+}
+
+// AllocWithIncrement implements autoid.Allocator AllocWithIncrement interface.
+func (alloc *memoryAllocator) AllocWithIncrement(tableID, increment, offset int64) (int64, error) {
 	if tableID == 0 {
 		return 0, errInvalidTableID.Gen("Invalid tableID")
 	}
 	alloc.mu.Lock()
 	defer alloc.mu.Unlock()
-	if alloc.base == alloc.end { // step
+	id := nextIDInSequence(alloc.base+1, increment, offset)
+	if id > alloc.end { // step
+		batch := step
+		if batch < increment {
+			batch = increment
+		}
 		memIDLock.Lock()
-		memID = memID + step
+		memID = memID + batch
 		alloc.end = memID
-		alloc.base = alloc.end - step
+		alloc.base = alloc.end - batch
 		memIDLock.Unlock()
+		id = nextIDInSequence(alloc.base+1, increment, offset)
 	}
-	alloc.base++
+	alloc.base = id
 	return alloc.base, nil
 }
 
@@ -181,6 +236,17 @@ func NewAllocator(store kv.Storage, dbID int64) Allocator {
 	}
 }
 
+// NewAllocatorWithCache returns a new auto increment id generator on the store which allocates cache IDs from
+// the store at a time. The IDs allocated by different TiDB servers are monotonic if cache is 1, but every
+// allocation accesses the store.
+func NewAllocatorWithCache(store kv.Storage, dbID, cache int64) Allocator {
+	return &allocator{
+		store: store,
+		dbID:  dbID,
+		cache: cache,
+	}
+}
+
 // NewMemoryAllocator returns a new auto increment id generator in memory.
 func NewMemoryAllocator(dbID int64) Allocator {
 	return &memoryAllocator{
@@ -186,3 +186,89 @@ func (*testSuite) TestConcurrentAlloc(c *C) {
 	err = <-errCh
 	c.Assert(err, IsNil)
 }
+
+func (*testSuite) TestAllocWithIncrement(c *C) {
+	driver := localstore.Driver{Driver: goleveldb.MemoryDriver{}}
+	store, err := driver.Open("memory")
+	c.Assert(err, IsNil)
+	defer store.Close()
+	step = 10
+	defer func() {
+		step = 5000
+	}()
+
+	err = kv.RunInNewTxn(store, false, func(txn kv.Transaction) error {
+		m := meta.NewMeta(txn)
+		err = m.CreateDatabase(&model.DBInfo{ID: 1, Name: model.NewCIStr("a")})
+		c.Assert(err, IsNil)
+		err = m.CreateTable(1, &model.TableInfo{ID: 1, Name: model.NewCIStr("t")})
+		c.Assert(err, IsNil)
+		return nil
+	})
+	c.Assert(err, IsNil)
+
+	for _, alloc := range []Allocator{NewAllocator(store, 1), NewMemoryAllocator(1)} {
+		base, err := alloc.Alloc(1)
+		c.Assert(err, IsNil)
+		id, err := alloc.AllocWithIncrement(1, 5, 3)
+		c.Assert(err, IsNil)
+		c.Assert((id-3)%5, Equals, int64(0))
+		c.Assert(id > base && id <= base+5, IsTrue)
+		// The batch is larger than the step to hold an id of the sequence.
+		id, err = alloc.AllocWithIncrement(1, 25, 3)
+		c.Assert(err, IsNil)
+		c.Assert((id-3)%25, Equals, int64(0))
+		next, err := alloc.AllocWithIncrement(1, 25, 3)
+		c.Assert(err, IsNil)
+		c.Assert(next, Equals, id+25)
+		// The offset is ignored if it's greater than the increment.
+		id, err = alloc.AllocWithIncrement(1, 4, 6)
+		c.Assert(err, IsNil)
+		c.Assert(id%4, Equals, int64(0))
+		c.Assert(id > next && id <= next+4, IsTrue)
+		next, err = alloc.Alloc(1)
+		c.Assert(err, IsNil)
+		c.Assert(next, Equals, id+1)
+	}
+}
+
+func (*testSuite) TestAllocWithCache(c *C) {
+	driver := localstore.Driver{Driver: goleveldb.MemoryDriver{}}
+	store, err := driver.Open("memory")
+	c.Assert(err, IsNil)
+	defer store.Close()
+
+	err = kv.RunInNewTxn(store, false, func(txn kv.Transaction) error {
+		m := meta.NewMeta(txn)
+		err = m.CreateDatabase(&model.DBInfo{ID: 1, Name: model.NewCIStr("a")})
+		c.Assert(err, IsNil)
+		err = m.CreateTable(1, &model.TableInfo{ID: 1, Name: model.NewCIStr("t")})
+		c.Assert(err, IsNil)
+		return nil
+	})
+	c.Assert(err, IsNil)
+
+	// The allocators of different servers allocate the IDs in the order of the allocations if the cache is 1.
+	allocs := []Allocator{NewAllocatorWithCache(store, 1, 1), NewAllocatorWithCache(store, 1, 1)}
+	for i := int64(1); i <= 6; i++ {
+		id, err := allocs[i%2].Alloc(1)
+		c.Assert(err, IsNil)
+		c.Assert(id, Equals, i)
+	}
+	err = allocs[0].Rebase(1, 10, true)
+	c.Assert(err, IsNil)
+	id, err := allocs[1].Alloc(1)
+	c.Assert(err, IsNil)
+	c.Assert(id, Equals, int64(11))
+	id, err = allocs[0].Alloc(1)
+	c.Assert(err, IsNil)
+	c.Assert(id, Equals, int64(12))
+
+	alloc := NewAllocatorWithCache(store, 1, 100)
+	id, err = alloc.Alloc(1)
+	c.Assert(err, IsNil)
+	c.Assert(id, Equals, int64(13))
+	id, err = allocs[0].Alloc(1)
+	c.Assert(err, IsNil)
+	c.Assert(id, Equals, int64(113))
+}
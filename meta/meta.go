@@ -17,6 +17,7 @@ import (
 	"encoding/binary"
 	"encoding/json"
 	"fmt"
+	"hash/fnv"
 	"sort"
 	"strconv"
 	"strings"
@@ -78,20 +79,26 @@ var (
 
 // Meta is for handling meta information in a transaction.
 type Meta struct {
-	txn *structure.TxStructure
+	txn        *structure.TxStructure
+	jobListKey JobListKeyType // the DDL job queue the DDL job methods operate on.
 }
 
 // NewMeta creates a Meta in transaction txn.
-func NewMeta(txn kv.Transaction) *Meta {
+// If the jobListKeys isn't specified, the DDL job methods operate on the general DDL job queue.
+func NewMeta(txn kv.Transaction, jobListKeys ...JobListKeyType) *Meta {
 	txn.SetOption(kv.Priority, kv.PriorityHigh)
 	t := structure.NewStructure(txn, txn, mMetaPrefix)
-	return &Meta{txn: t}
+	listKey := DefaultJobListKey
+	if len(jobListKeys) != 0 {
+		listKey = jobListKeys[0]
+	}
+	return &Meta{txn: t, jobListKey: listKey}
 }
 
 // NewSnapshotMeta creates a Meta with snapshot.
 func NewSnapshotMeta(snapshot kv.Snapshot) *Meta {
 	t := structure.NewStructure(snapshot, nil, mMetaPrefix)
-	return &Meta{txn: t}
+	return &Meta{txn: t, jobListKey: DefaultJobListKey}
 }
 
 // GenGlobalID generates next id globally.
@@ -373,6 +380,71 @@ func (m *Meta) ListTables(dbID int64) ([]*model.TableInfo, error) {
 	return tables, nil
 }
 
+// tableStub is the part of the encoded TableInfo decoded by ListTableStubs.
+type tableStub struct {
+	ID    int64             `json:"id"`
+	Name  model.CIStr       `json:"name"`
+	State model.SchemaState `json:"state"`
+}
+
+// ListTableStubs shows the tables of a database like ListTables, but only the ID, the name and the state of
+// the tables are decoded. It also returns the hashes of the encoded tables, so a table decoded later can be
+// checked to be the same as the listed one.
+func (m *Meta) ListTableStubs(dbID int64) ([]*model.TableInfo, []uint64, error) {
+	dbKey := m.dbKey(dbID)
+	if err := m.checkDBExists(dbKey); err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+
+	res, err := m.txn.HGetAll(dbKey)
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+
+	tables := make([]*model.TableInfo, 0, len(res)/2)
+	hashes := make([]uint64, 0, len(res)/2)
+	for _, r := range res {
+		// only handle table meta
+		if !strings.HasPrefix(string(r.Field), mTablePrefix) {
+			continue
+		}
+
+		stub := &tableStub{}
+		err = json.Unmarshal(r.Value, stub)
+		if err != nil {
+			return nil, nil, errors.Trace(err)
+		}
+
+		tables = append(tables, &model.TableInfo{ID: stub.ID, Name: stub.Name, State: stub.State})
+		hashes = append(hashes, hashTableValue(r.Value))
+	}
+
+	return tables, hashes, nil
+}
+
+// GetTableWithHash gets the table value in database with tableID, and the hash of the encoded table.
+func (m *Meta) GetTableWithHash(dbID int64, tableID int64) (*model.TableInfo, uint64, error) {
+	dbKey := m.dbKey(dbID)
+	if err := m.checkDBExists(dbKey); err != nil {
+		return nil, 0, errors.Trace(err)
+	}
+
+	value, err := m.txn.HGet(dbKey, m.tableKey(tableID))
+	if err != nil || value == nil {
+		return nil, 0, errors.Trace(err)
+	}
+
+	tableInfo := &model.TableInfo{}
+	err = json.Unmarshal(value, tableInfo)
+	return tableInfo, hashTableValue(value), errors.Trace(err)
+}
+
+func hashTableValue(value []byte) uint64 {
+	h := fnv.New64a()
+	h.Write(value)
+	return h.Sum64()
+}
+
 // ListDatabases shows all databases.
 func (m *Meta) ListDatabases() ([]*model.DBInfo, error) {
 	res, err := m.txn.HGetAll(mDBs)
@@ -427,18 +499,30 @@ func (m *Meta) GetTable(dbID int64, tableID int64) (*model.TableInfo, error) {
 // DDL job structure
 //	DDLOnwer: []byte
 //	DDLJobList: list jobs
+//	DDLJobReorgList: list jobs
 //	DDLJobHistory: hash
 //	DDLJobReorg: hash
 //
 // for multi DDL workers, only one can become the owner
 // to operate DDL jobs, and dispatch them to MR Jobs.
+// The jobs which reorganize the data are in DDLJobReorgList, the others are in DDLJobList,
+// so a long running job doesn't block the jobs of the other queue.
 
 var (
-	mDDLJobListKey    = []byte("DDLJobList")
 	mDDLJobHistoryKey = []byte("DDLJobHistory")
 	mDDLJobReorgKey   = []byte("DDLJobReorg")
 )
 
+// JobListKeyType is the key of a DDL job queue.
+type JobListKeyType []byte
+
+var (
+	// DefaultJobListKey keeps all the DDL jobs except the ones reorganizing the data.
+	DefaultJobListKey JobListKeyType = []byte("DDLJobList")
+	// ReorgJobListKey keeps the DDL jobs reorganizing the data, like adding an index.
+	ReorgJobListKey JobListKeyType = []byte("DDLJobReorgList")
+)
+
 func (m *Meta) enQueueDDLJob(key []byte, job *model.Job, updateRawArgs bool) error {
 	b, err := job.Encode(updateRawArgs)
 	if err != nil {
@@ -449,7 +533,7 @@ func (m *Meta) enQueueDDLJob(key []byte, job *model.Job, updateRawArgs bool) err
 
 // EnQueueDDLJob adds a DDL job to the list.
 func (m *Meta) EnQueueDDLJob(job *model.Job) error {
-	return m.enQueueDDLJob(mDDLJobListKey, job, true)
+	return m.enQueueDDLJob(m.jobListKey, job, true)
 }
 
 func (m *Meta) deQueueDDLJob(key []byte) (*model.Job, error) {
@@ -465,7 +549,7 @@ func (m *Meta) deQueueDDLJob(key []byte) (*model.Job, error) {
 
 // DeQueueDDLJob pops a DDL job from the list.
 func (m *Meta) DeQueueDDLJob() (*model.Job, error) {
-	return m.deQueueDDLJob(mDDLJobListKey)
+	return m.deQueueDDLJob(m.jobListKey)
 }
 
 func (m *Meta) getDDLJob(key []byte, index int64) (*model.Job, error) {
@@ -481,7 +565,7 @@ func (m *Meta) getDDLJob(key []byte, index int64) (*model.Job, error) {
 
 // GetDDLJob returns the DDL job with index.
 func (m *Meta) GetDDLJob(index int64) (*model.Job, error) {
-	job, err := m.getDDLJob(mDDLJobListKey, index)
+	job, err := m.getDDLJob(m.jobListKey, index)
 	return job, errors.Trace(err)
 }
 
@@ -495,12 +579,31 @@ func (m *Meta) updateDDLJob(index int64, job *model.Job, key []byte) error {
 
 // UpdateDDLJob updates the DDL job with index.
 func (m *Meta) UpdateDDLJob(index int64, job *model.Job) error {
-	return m.updateDDLJob(index, job, mDDLJobListKey)
+	return m.updateDDLJob(index, job, m.jobListKey)
 }
 
 // DDLJobQueueLen returns the DDL job queue length.
 func (m *Meta) DDLJobQueueLen() (int64, error) {
-	return m.txn.LLen(mDDLJobListKey)
+	return m.txn.LLen(m.jobListKey)
+}
+
+// GetAllDDLJobs gets all the DDL jobs in the queue.
+func (m *Meta) GetAllDDLJobs() ([]*model.Job, error) {
+	n, err := m.txn.LLen(m.jobListKey)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	jobs := make([]*model.Job, 0, n)
+	for i := int64(0); i < n; i++ {
+		job, err := m.getDDLJob(m.jobListKey, i)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if job != nil {
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs, nil
 }
 
 func (m *Meta) jobIDKey(id int64) []byte {
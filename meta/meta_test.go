@@ -135,6 +135,29 @@ func (s *testSuite) TestMeta(c *C) {
 	tables, err := t.ListTables(1)
 	c.Assert(err, IsNil)
 	c.Assert(tables, DeepEquals, []*model.TableInfo{tbInfo, tbInfo2})
+
+	tbInfo2.Comment = "only the stub fields are listed"
+	err = t.UpdateTable(1, tbInfo2)
+	c.Assert(err, IsNil)
+	stubs, hashes, err := t.ListTableStubs(1)
+	c.Assert(err, IsNil)
+	c.Assert(stubs, DeepEquals, []*model.TableInfo{
+		{ID: 1, Name: model.NewCIStr("tt")},
+		{ID: 2, Name: model.NewCIStr("bb")},
+	})
+	table, hash, err := t.GetTableWithHash(1, 2)
+	c.Assert(err, IsNil)
+	c.Assert(table, DeepEquals, tbInfo2)
+	c.Assert(hash, Equals, hashes[1])
+	tbInfo2.Comment = ""
+	err = t.UpdateTable(1, tbInfo2)
+	c.Assert(err, IsNil)
+	_, hash, err = t.GetTableWithHash(1, 2)
+	c.Assert(err, IsNil)
+	c.Assert(hash, Not(Equals), hashes[1])
+	table, _, err = t.GetTableWithHash(1, 3)
+	c.Assert(err, IsNil)
+	c.Assert(table, IsNil)
 	// Generate an auto id.
 	n, err = t.GenAutoTableID(1, 2, 10)
 	c.Assert(err, IsNil)
@@ -338,6 +361,27 @@ func (s *testSuite) TestDDL(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(v, DeepEquals, bgJob)
 
+	// The reorganization job queue is independent of the general one.
+	reorgMeta := meta.NewMeta(txn, meta.ReorgJobListKey)
+	for i := int64(3); i < 5; i++ {
+		err = reorgMeta.EnQueueDDLJob(&model.Job{ID: i})
+		c.Assert(err, IsNil)
+	}
+	n, err = reorgMeta.DDLJobQueueLen()
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, int64(2))
+	n, err = t.DDLJobQueueLen()
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, int64(0))
+	jobs, err := reorgMeta.GetAllDDLJobs()
+	c.Assert(err, IsNil)
+	c.Assert(jobs, HasLen, 2)
+	c.Assert(jobs[0].ID, Equals, int64(3))
+	c.Assert(jobs[1].ID, Equals, int64(4))
+	jobs, err = t.GetAllDDLJobs()
+	c.Assert(err, IsNil)
+	c.Assert(jobs, HasLen, 0)
+
 	err = txn.Commit()
 	c.Assert(err, IsNil)
 }
@@ -57,6 +57,13 @@ var (
 			Help:      "Bucketed histogram of session retry count.",
 			Buckets:   prometheus.LinearBuckets(0, 1, 10),
 		})
+	resultCacheCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "tidb",
+			Subsystem: "server",
+			Name:      "result_cache_total",
+			Help:      "Counter of the query result cache hits and misses.",
+		}, []string{"type"})
 )
 
 func init() {
@@ -65,4 +72,5 @@ func init() {
 	prometheus.MustRegister(sessionExecuteRunDuration)
 	prometheus.MustRegister(schemaLeaseErrorCounter)
 	prometheus.MustRegister(sessionRetry)
+	prometheus.MustRegister(resultCacheCounter)
 }
@@ -42,6 +42,13 @@ const (
 	ActionModifyColumn
 	ActionRenameTable
 	ActionSetDefaultValue
+	ActionLockTable
+	ActionUnlockTable
+	ActionMultiSchemaChange
+	ActionRenameIndex
+	ActionRenameTables
+	ActionAlterIndexVisibility
+	ActionCleanupTableLock
 )
 
 func (action ActionType) String() string {
@@ -74,6 +81,20 @@ func (action ActionType) String() string {
 		return "rename table"
 	case ActionSetDefaultValue:
 		return "set default value"
+	case ActionLockTable:
+		return "lock table"
+	case ActionUnlockTable:
+		return "unlock table"
+	case ActionMultiSchemaChange:
+		return "multi-schema change"
+	case ActionRenameIndex:
+		return "rename index"
+	case ActionRenameTables:
+		return "rename tables"
+	case ActionAlterIndexVisibility:
+		return "alter index visibility"
+	case ActionCleanupTableLock:
+		return "cleanup table lock"
 	default:
 		return "none"
 	}
@@ -128,6 +149,72 @@ type Job struct {
 
 	// Version indicates the DDL job version. For old jobs, it will be 0.
 	Version int64 `json:"version"`
+
+	// MultiSchemaInfo keeps the sub-jobs of a multi-schema change job.
+	MultiSchemaInfo *MultiSchemaInfo `json:"multi_schema_info,omitempty"`
+}
+
+// MultiSchemaInfo keeps the sub-jobs of a multi-schema change job, which runs the schema changes of
+// an ALTER TABLE statement one by one in a single job.
+type MultiSchemaInfo struct {
+	SubJobs []*SubJob `json:"sub_jobs"`
+}
+
+// SubJob is a schema change of a multi-schema change job.
+type SubJob struct {
+	Type        ActionType      `json:"type"`
+	Args        []interface{}   `json:"-"`
+	RawArgs     json.RawMessage `json:"raw_args"`
+	SchemaState SchemaState     `json:"schema_state"`
+	SnapshotVer uint64          `json:"snapshot_ver"`
+	State       JobState        `json:"state"`
+}
+
+// ToProxyJob returns a job to run the sub-job by the handler of its type.
+func (sub *SubJob) ToProxyJob(job *Job) *Job {
+	proxy := &Job{
+		ID:           job.ID,
+		Type:         sub.Type,
+		SchemaID:     job.SchemaID,
+		TableID:      job.TableID,
+		State:        sub.State,
+		Args:         sub.Args,
+		RawArgs:      sub.RawArgs,
+		SchemaState:  sub.SchemaState,
+		SnapshotVer:  sub.SnapshotVer,
+		LastUpdateTS: job.LastUpdateTS,
+		Query:        job.Query,
+		BinlogInfo:   job.BinlogInfo,
+		Version:      job.Version,
+	}
+	proxy.SetRowCount(job.GetRowCount())
+	return proxy
+}
+
+// FromProxyJob updates the sub-job and the job after the proxy job runs.
+func (sub *SubJob) FromProxyJob(job, proxy *Job) error {
+	sub.Type = proxy.Type
+	sub.State = proxy.State
+	sub.SchemaState = proxy.SchemaState
+	sub.SnapshotVer = proxy.SnapshotVer
+	sub.Args = proxy.Args
+	job.SetRowCount(proxy.GetRowCount())
+	return errors.Trace(sub.encodeArgs())
+}
+
+// UpdateArgs replaces the args of the sub-job.
+func (sub *SubJob) UpdateArgs(args ...interface{}) error {
+	sub.Args = args
+	return errors.Trace(sub.encodeArgs())
+}
+
+func (sub *SubJob) encodeArgs() error {
+	if sub.Args == nil {
+		return nil
+	}
+	var err error
+	sub.RawArgs, err = json.Marshal(sub.Args)
+	return errors.Trace(err)
 }
 
 // SetRowCount sets the number of rows. Make sure it can pass `make race`.
@@ -155,6 +242,13 @@ func (job *Job) Encode(updateRawArgs bool) ([]byte, error) {
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
+		if job.MultiSchemaInfo != nil {
+			for _, sub := range job.MultiSchemaInfo.SubJobs {
+				if err = sub.encodeArgs(); err != nil {
+					return nil, errors.Trace(err)
+				}
+			}
+		}
 	}
 
 	var b []byte
@@ -186,6 +280,132 @@ func (job *Job) String() string {
 		job.ID, job.Type, job.State, job.SchemaState, job.SchemaID, job.TableID, rowCount, len(job.Args))
 }
 
+// IsReorgJob returns whether the job reorganizes the data, it's put in the reorganization job queue.
+func (job *Job) IsReorgJob() bool {
+	if job.Type == ActionAddIndex {
+		return true
+	}
+	if job.Type == ActionMultiSchemaChange && job.MultiSchemaInfo != nil {
+		for _, sub := range job.MultiSchemaInfo.SubJobs {
+			if sub.Type == ActionAddIndex {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// The DDL versions of the servers. A server registers the DDL version it supports, a job which needs a higher
+// version than some server in the cluster is refused, because the server can't run the job or load the schema
+// changed by it, e.g. during a rolling upgrade.
+const (
+	// DDLVersion1 is the version of the servers which don't register their DDL versions.
+	DDLVersion1 int64 = 1
+	// DDLVersion2 adds the table locks, the multi-schema changes, renaming the indexes and multiple tables,
+	// the index visibility, the reorganization job queue, the descending index columns and the expression
+	// indexes.
+	DDLVersion2 int64 = 2
+	// CurrentDDLVersion is the DDL version of this server.
+	CurrentDDLVersion = DDLVersion2
+)
+
+// RequiredDDLVersion returns the DDL version all the servers need to run the job.
+func (job *Job) RequiredDDLVersion() int64 {
+	switch job.Type {
+	case ActionLockTable, ActionUnlockTable, ActionMultiSchemaChange, ActionRenameIndex, ActionRenameTables,
+		ActionAlterIndexVisibility, ActionCleanupTableLock:
+		return DDLVersion2
+	case ActionCreateTable:
+		// The servers at DDLVersion1 can't decode the keys of the indices with descending columns,
+		// or fill the hidden columns of the expression indexes.
+		tblInfo := &TableInfo{}
+		if job.peekArgs(tblInfo) == nil {
+			for _, idx := range tblInfo.Indices {
+				if idx.DescColumns() != nil {
+					return DDLVersion2
+				}
+			}
+			for _, col := range tblInfo.Columns {
+				if col.Hidden {
+					return DDLVersion2
+				}
+			}
+		}
+	case ActionAddIndex:
+		// The keys are ast.IndexColName, only the fields needed here are decoded. The hidden columns
+		// are added for the expression index keys.
+		var (
+			keys       []struct{ Desc bool }
+			hiddenCols []*ColumnInfo
+		)
+		if job.peekArgs(new(bool), new(CIStr), &keys, new(json.RawMessage), &hiddenCols) == nil {
+			if len(hiddenCols) > 0 {
+				return DDLVersion2
+			}
+			for _, key := range keys {
+				if key.Desc {
+					return DDLVersion2
+				}
+			}
+		}
+	}
+	return DDLVersion1
+}
+
+// peekArgs decodes the leading args of the job into args without changing job.Args, the args may be
+// not encoded yet.
+func (job *Job) peekArgs(args ...interface{}) error {
+	rawArgs := job.RawArgs
+	if job.Args != nil {
+		var err error
+		rawArgs, err = json.Marshal(job.Args)
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return errors.Trace(json.Unmarshal(rawArgs, &args))
+}
+
+// IsDependentOn returns whether the job must run after the other job, that is they change the same schema or
+// the same table. The raw args of the jobs must be encoded.
+func (job *Job) IsDependentOn(other *Job) (bool, error) {
+	if job.isSchemaJob() || other.isSchemaJob() {
+		return job.SchemaID == other.SchemaID, nil
+	}
+	ids, err := job.tableIDs()
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	otherIDs, err := other.tableIDs()
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	for _, id := range ids {
+		for _, otherID := range otherIDs {
+			if id == otherID {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func (job *Job) isSchemaJob() bool {
+	return job.Type == ActionCreateSchema || job.Type == ActionDropSchema
+}
+
+// tableIDs returns the IDs of the tables the job changes.
+func (job *Job) tableIDs() ([]int64, error) {
+	if job.Type != ActionRenameTables {
+		return []int64{job.TableID}, nil
+	}
+	var oldSchemaIDs, newSchemaIDs, tableIDs []int64
+	var newNames []CIStr
+	args := []interface{}{&oldSchemaIDs, &newSchemaIDs, &newNames, &tableIDs}
+	err := json.Unmarshal(job.RawArgs, &args)
+	return tableIDs, errors.Trace(err)
+}
+
 // IsFinished returns whether job is finished or not.
 // If the job state is Done or Cancelled, it is finished.
 func (job *Job) IsFinished() bool {
@@ -263,4 +483,14 @@ type SchemaDiff struct {
 	OldTableID int64 `json:"old_table_id"`
 	// OldSchemaID is the schema ID before rename table, only used by rename table DDL.
 	OldSchemaID int64 `json:"old_schema_id"`
+
+	// AffectedOpts are the tables renamed by a rename tables DDL.
+	AffectedOpts []*AffectedOption `json:"affected_options"`
+}
+
+// AffectedOption is a table affected by a DDL which changes several tables at once.
+type AffectedOption struct {
+	SchemaID    int64 `json:"schema_id"`
+	TableID     int64 `json:"table_id"`
+	OldSchemaID int64 `json:"old_schema_id"`
 }
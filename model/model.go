@@ -14,6 +14,7 @@
 package model
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/pingcap/tidb/mysql"
@@ -70,6 +71,9 @@ type ColumnInfo struct {
 	types.FieldType     `json:"type"`
 	State               SchemaState `json:"state"`
 	Comment             string      `json:"comment"`
+	// Hidden indicates the column is the virtual generated column of an expression index key, it
+	// can't be seen or referred by the users.
+	Hidden bool `json:"hidden,omitempty"`
 }
 
 // Clone clones ColumnInfo.
@@ -103,6 +107,11 @@ type TableInfo struct {
 	// We need to save original schemaID to keep autoID unchanged
 	// while renaming a table from one database to another.
 	OldSchemaID int64 `json:"old_schema_id,omitempty"`
+	// AutoIDCache is the count of the auto IDs cached by a TiDB server at a time, 0 means the default.
+	// A small cache makes the IDs allocated by the servers closer to the order of the allocations.
+	AutoIDCache int64 `json:"auto_id_cache,omitempty"`
+	// Lock is the lock taken by LOCK TABLES, it's nil if the table isn't locked.
+	Lock *TableLockInfo `json:"lock,omitempty"`
 }
 
 // Clone clones TableInfo.
@@ -124,6 +133,10 @@ func (t *TableInfo) Clone() *TableInfo {
 		nt.ForeignKeys[i] = t.ForeignKeys[i].Clone()
 	}
 
+	if t.Lock != nil {
+		nt.Lock = t.Lock.Clone()
+	}
+
 	return &nt
 }
 
@@ -162,6 +175,53 @@ func (t *TableInfo) ColumnIsInIndex(c *ColumnInfo) bool {
 	return false
 }
 
+// TableLockType is the type of the table lock.
+type TableLockType byte
+
+// List table lock types.
+const (
+	TableLockNone TableLockType = iota
+	TableLockRead
+	TableLockWrite
+)
+
+func (t TableLockType) String() string {
+	switch t {
+	case TableLockRead:
+		return "READ"
+	case TableLockWrite:
+		return "WRITE"
+	default:
+		return "NONE"
+	}
+}
+
+// SessionInfo identifies a session in the cluster.
+type SessionInfo struct {
+	// ServerID is the DDL ID of the server.
+	ServerID  string `json:"server_id"`
+	SessionID uint64 `json:"session_id"`
+}
+
+func (s SessionInfo) String() string {
+	return fmt.Sprintf("server: %s, session: %d", s.ServerID, s.SessionID)
+}
+
+// TableLockInfo is the lock of a table, a READ lock may be held by several sessions and a WRITE
+// lock is held by one session.
+type TableLockInfo struct {
+	Tp       TableLockType `json:"tp"`
+	Sessions []SessionInfo `json:"sessions"`
+}
+
+// Clone clones TableLockInfo.
+func (l *TableLockInfo) Clone() *TableLockInfo {
+	nl := *l
+	nl.Sessions = make([]SessionInfo, len(l.Sessions))
+	copy(nl.Sessions, l.Sessions)
+	return &nl
+}
+
 // IndexColumn provides index column info.
 type IndexColumn struct {
 	Name   CIStr `json:"name"`   // Index name
@@ -170,6 +230,8 @@ type IndexColumn struct {
 	// for indexing;
 	// UnspecifedLength if not using prefix indexing
 	Length int `json:"length"`
+	// Desc indicates the column is in descending order in the index keys.
+	Desc bool `json:"desc"`
 }
 
 // Clone clones IndexColumn.
@@ -178,6 +240,25 @@ func (i *IndexColumn) Clone() *IndexColumn {
 	return &ni
 }
 
+// maxBytesOfChar is the max byte length of a character of the non-binary strings.
+const maxBytesOfChar = 4
+
+// IsFullColumn checks whether the index column keeps the full values of the column of the type. A prefix
+// keeps the full values if it isn't shorter than the max byte length of the values, then it's not truncated.
+func (i *IndexColumn) IsFullColumn(tp *types.FieldType) bool {
+	if i.Length == types.UnspecifiedLength {
+		return true
+	}
+	if tp == nil || tp.Flen <= 0 {
+		return false
+	}
+	maxLen := tp.Flen
+	if !types.IsBinaryStr(tp) {
+		maxLen *= maxBytesOfChar
+	}
+	return i.Length >= maxLen
+}
+
 // IndexType is the type of index
 type IndexType int
 
@@ -213,6 +294,8 @@ type IndexInfo struct {
 	State   SchemaState    `json:"state"`
 	Comment string         `json:"comment"`    // Comment
 	Tp      IndexType      `json:"index_type"` // Index type: Btree or Hash
+	// Invisible indicates the index is ignored by the optimizer, but it's still maintained by the writes.
+	Invisible bool `json:"is_invisible"`
 }
 
 // Clone clones IndexInfo.
@@ -225,6 +308,22 @@ func (index *IndexInfo) Clone() *IndexInfo {
 	return &ni
 }
 
+// DescColumns returns whether the columns of this index are descending, it returns nil if all the
+// columns are ascending.
+func (index *IndexInfo) DescColumns() []bool {
+	var desc []bool
+	for i, ic := range index.Columns {
+		if !ic.Desc {
+			continue
+		}
+		if desc == nil {
+			desc = make([]bool, len(index.Columns))
+		}
+		desc[i] = true
+	}
+	return desc
+}
+
 // HasPrefixIndex returns whether any columns of this index uses prefix length.
 func (index *IndexInfo) HasPrefixIndex() bool {
 	for _, ic := range index.Columns {
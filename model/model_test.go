@@ -18,6 +18,7 @@ import (
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/util/charset"
 	"github.com/pingcap/tidb/util/types"
 )
 
@@ -98,7 +99,7 @@ func (*testModelSuite) TestModelBasic(c *C) {
 	c.Assert(tp.String(), Equals, "BTREE")
 	tp = IndexTypeHash
 	c.Assert(tp.String(), Equals, "HASH")
-	tp = 1E5
+	tp = 1e5
 	c.Assert(tp.String(), Equals, "")
 	has := index.HasPrefixIndex()
 	c.Assert(has, Equals, true)
@@ -121,6 +122,23 @@ func (*testModelSuite) TestModelBasic(c *C) {
 	c.Assert(no, Equals, false)
 }
 
+func (*testModelSuite) TestIndexColumnIsFullColumn(c *C) {
+	binaryTp := types.NewFieldType(mysql.TypeVarchar)
+	binaryTp.Flen = 5
+	binaryTp.Charset, binaryTp.Collate = charset.CharsetBin, charset.CollationBin
+	strTp := types.NewFieldType(mysql.TypeVarchar)
+	strTp.Flen = 5
+	strTp.Charset, strTp.Collate = charset.CharsetUTF8, charset.CollationUTF8
+
+	c.Assert((&IndexColumn{Length: types.UnspecifiedLength}).IsFullColumn(strTp), IsTrue)
+	c.Assert((&IndexColumn{Length: 5}).IsFullColumn(binaryTp), IsTrue)
+	c.Assert((&IndexColumn{Length: 4}).IsFullColumn(binaryTp), IsFalse)
+	// The non-binary strings may have multi-byte characters.
+	c.Assert((&IndexColumn{Length: 5}).IsFullColumn(strTp), IsFalse)
+	c.Assert((&IndexColumn{Length: 20}).IsFullColumn(strTp), IsTrue)
+	c.Assert((&IndexColumn{Length: 5}).IsFullColumn(types.NewFieldType(mysql.TypeBlob)), IsFalse)
+}
+
 func (*testModelSuite) TestJobCodec(c *C) {
 	type A struct {
 		Name string
@@ -183,6 +201,73 @@ func (*testModelSuite) TestJobCodec(c *C) {
 	c.Assert(job.GetRowCount(), Equals, int64(3))
 }
 
+func (*testModelSuite) TestSubJobCodec(c *C) {
+	job := &Job{
+		ID:              1,
+		Type:            ActionMultiSchemaChange,
+		BinlogInfo:      &HistoryInfo{},
+		MultiSchemaInfo: &MultiSchemaInfo{SubJobs: []*SubJob{{Type: ActionDropIndex, Args: []interface{}{NewCIStr("a")}}}},
+	}
+	b, err := job.Encode(true)
+	c.Assert(err, IsNil)
+	newJob := &Job{}
+	c.Assert(newJob.Decode(b), IsNil)
+	sub := newJob.MultiSchemaInfo.SubJobs[0]
+	proxy := sub.ToProxyJob(newJob)
+	c.Assert(proxy.ID, Equals, int64(1))
+	c.Assert(proxy.Type, Equals, ActionDropIndex)
+	name := CIStr{}
+	c.Assert(proxy.DecodeArgs(&name), IsNil)
+	c.Assert(name, DeepEquals, NewCIStr("a"))
+
+	// The sub-job is updated by the proxy job.
+	proxy.State = JobDone
+	proxy.SchemaState = StateNone
+	proxy.Args = append(proxy.Args, int64(2))
+	proxy.SetRowCount(3)
+	c.Assert(sub.FromProxyJob(newJob, proxy), IsNil)
+	c.Assert(sub.State, Equals, JobDone)
+	c.Assert(newJob.GetRowCount(), Equals, int64(3))
+	var id int64
+	proxy = sub.ToProxyJob(newJob)
+	c.Assert(proxy.DecodeArgs(&name, &id), IsNil)
+	c.Assert(id, Equals, int64(2))
+	c.Assert(sub.UpdateArgs(NewCIStr("b")), IsNil)
+	c.Assert(sub.ToProxyJob(newJob).DecodeArgs(&name), IsNil)
+	c.Assert(name, DeepEquals, NewCIStr("b"))
+}
+
+func (*testModelSuite) TestJobDependence(c *C) {
+	addIndex := &Job{ID: 1, Type: ActionAddIndex, SchemaID: 1, TableID: 2}
+	c.Assert(addIndex.IsReorgJob(), IsTrue)
+	multi := &Job{ID: 2, Type: ActionMultiSchemaChange, SchemaID: 1, TableID: 3,
+		MultiSchemaInfo: &MultiSchemaInfo{SubJobs: []*SubJob{{Type: ActionDropColumn}}}}
+	c.Assert(multi.IsReorgJob(), IsFalse)
+	multi.MultiSchemaInfo.SubJobs = append(multi.MultiSchemaInfo.SubJobs, &SubJob{Type: ActionAddIndex})
+	c.Assert(multi.IsReorgJob(), IsTrue)
+
+	renameTables := &Job{ID: 3, Type: ActionRenameTables, SchemaID: 1, TableID: 4,
+		Args: []interface{}{[]int64{1, 1}, []int64{1, 1}, []CIStr{NewCIStr("a"), NewCIStr("b")}, []int64{4, 2}}}
+	_, err := renameTables.Encode(true)
+	c.Assert(err, IsNil)
+	tests := []struct {
+		job       *Job
+		dependent bool
+	}{
+		{&Job{Type: ActionAddColumn, SchemaID: 1, TableID: 2}, true},
+		{&Job{Type: ActionAddColumn, SchemaID: 1, TableID: 5}, false},
+		{&Job{Type: ActionCreateTable, SchemaID: 6, TableID: 7}, false},
+		{&Job{Type: ActionDropSchema, SchemaID: 1}, true},
+		{&Job{Type: ActionDropSchema, SchemaID: 6}, false},
+		{renameTables, true},
+	}
+	for _, t := range tests {
+		dependent, err := t.job.IsDependentOn(addIndex)
+		c.Assert(err, IsNil)
+		c.Assert(dependent, Equals, t.dependent, Commentf("%s", t.job))
+	}
+}
+
 func (testModelSuite) TestState(c *C) {
 	schemaTbl := []SchemaState{
 		StateDeleteOnly,
@@ -237,3 +322,46 @@ func (testModelSuite) TestString(c *C) {
 		c.Assert(str, Equals, v.result)
 	}
 }
+
+func (*testModelSuite) TestRequiredDDLVersion(c *C) {
+	c.Assert((&Job{Type: ActionAddIndex}).RequiredDDLVersion(), Equals, DDLVersion1)
+	c.Assert((&Job{Type: ActionCreateTable}).RequiredDDLVersion(), Equals, DDLVersion1)
+	c.Assert((&Job{Type: ActionRenameIndex}).RequiredDDLVersion(), Equals, DDLVersion2)
+	c.Assert((&Job{Type: ActionMultiSchemaChange}).RequiredDDLVersion(), Equals, DDLVersion2)
+
+	// The indices with descending columns.
+	type indexKey struct {
+		Length int
+		Desc   bool
+	}
+	job := &Job{Type: ActionAddIndex, Args: []interface{}{false, NewCIStr("idx"), []*indexKey{{}, {Length: 1}}}}
+	c.Assert(job.RequiredDDLVersion(), Equals, DDLVersion1)
+	job.Args = []interface{}{false, NewCIStr("idx"), []*indexKey{{}, {Desc: true}}}
+	c.Assert(job.RequiredDDLVersion(), Equals, DDLVersion2)
+	_, err := job.Encode(true)
+	c.Assert(err, IsNil)
+	job.Args = nil
+	c.Assert(job.RequiredDDLVersion(), Equals, DDLVersion2)
+	tblInfo := &TableInfo{Indices: []*IndexInfo{{Columns: []*IndexColumn{{Name: NewCIStr("a")}}}}}
+	job = &Job{Type: ActionCreateTable, Args: []interface{}{tblInfo}}
+	c.Assert(job.RequiredDDLVersion(), Equals, DDLVersion1)
+	tblInfo.Indices[0].Columns[0].Desc = true
+	c.Assert(job.RequiredDDLVersion(), Equals, DDLVersion2)
+
+	// The expression indexes on the hidden generated columns.
+	hiddenCol := &ColumnInfo{Name: NewCIStr("_V$_idx_0"), Hidden: true}
+	job = &Job{Type: ActionAddIndex, Args: []interface{}{false, NewCIStr("idx"), []*indexKey{{}}, nil, []*ColumnInfo{}}}
+	c.Assert(job.RequiredDDLVersion(), Equals, DDLVersion1)
+	job.Args = []interface{}{false, NewCIStr("idx"), []*indexKey{{}}, nil, []*ColumnInfo{hiddenCol}}
+	c.Assert(job.RequiredDDLVersion(), Equals, DDLVersion2)
+	_, err = job.Encode(true)
+	c.Assert(err, IsNil)
+	job.Args = nil
+	c.Assert(job.RequiredDDLVersion(), Equals, DDLVersion2)
+	tblInfo = &TableInfo{Columns: []*ColumnInfo{{Name: NewCIStr("a")}}}
+	job = &Job{Type: ActionCreateTable, Args: []interface{}{tblInfo}}
+	c.Assert(job.RequiredDDLVersion(), Equals, DDLVersion1)
+	tblInfo.Columns = append(tblInfo.Columns, hiddenCol)
+	c.Assert(job.RequiredDDLVersion(), Equals, DDLVersion2)
+	c.Assert(CurrentDDLVersion, GreaterEqual, DDLVersion2)
+}
@@ -48,6 +48,18 @@ const (
 	ServerStatusMetadataChanged    uint16 = 0x0400
 	ServerStatusWasSlow            uint16 = 0x0800
 	ServerPSOutParams              uint16 = 0x1000
+	ServerStatusInTransReadonly    uint16 = 0x2000
+	ServerSessionStateChanged      uint16 = 0x4000
+)
+
+// Session state information types, they're the types of the session state changes sent in the OK packet.
+const (
+	SessionTrackSystemVariables byte = iota
+	SessionTrackSchema
+	SessionTrackStateChange
+	SessionTrackGtids
+	SessionTrackTransactionCharacteristics
+	SessionTrackTransactionState
 )
 
 // Identifier length limitations.
@@ -117,6 +129,17 @@ const (
 	ClientPluginAuth
 	ClientConnectAtts
 	ClientPluginAuthLenencClientData
+	ClientCanHandleExpiredPasswords
+	ClientSessionTrack
+	ClientDeprecateEOF
+	ClientOptionalResultsetMetadata
+)
+
+// The values of the metadata_follows field of a result set, which tell the client with
+// CLIENT_OPTIONAL_RESULTSET_METADATA whether the column definitions are sent.
+const (
+	ResultsetMetadataNone byte = iota
+	ResultsetMetadataFull
 )
 
 // Cache type information.
@@ -147,6 +170,18 @@ const (
 	GlobalStatusTable = "GLOBAL_STATUS"
 	// TiDBTable is the table contains tidb info.
 	TiDBTable = "tidb"
+	// FuncTable is the table contains the user-defined functions.
+	FuncTable = "func"
+	// ProcTable is the table contains the stored procedures.
+	ProcTable = "proc"
+	// EventTable is the table contains the events.
+	EventTable = "event"
+	// BindInfoTable is the table contains the SQL bindings.
+	BindInfoTable = "bind_info"
+	// RowPolicyTable is the table contains the row-level security policies.
+	RowPolicyTable = "row_policy"
+	// ColumnMaskTable is the table contains the masking policies of the columns.
+	ColumnMaskTable = "column_mask"
 )
 
 // PrivilegeType  privilege
@@ -193,6 +228,9 @@ const (
 // AllPrivMask is the mask for PrivilegeType with all bits set to 1.
 const AllPrivMask = AllPriv - 1
 
+// UsagePriv is the privilege which grants nothing, "GRANT USAGE" only creates the user.
+const UsagePriv PrivilegeType = 0
+
 // MySQL type maximum length.
 const (
 	// For arguments that have no fixed number of decimals, the decimals value is set to 31,
@@ -484,6 +522,25 @@ const (
 	DelayedPriority
 )
 
+// Priority2Str is used to convert the statement priority to string.
+var Priority2Str = map[PriorityEnum]string{
+	NoPriority:      "NO_PRIORITY",
+	LowPriority:     "LOW_PRIORITY",
+	HighPriority:    "HIGH_PRIORITY",
+	DelayedPriority: "DELAYED",
+}
+
+// Str2Priority is used to convert a string to a priority.
+func Str2Priority(val string) (PriorityEnum, bool) {
+	val = strings.ToUpper(val)
+	for pri, str := range Priority2Str {
+		if str == val {
+			return pri, true
+		}
+	}
+	return NoPriority, false
+}
+
 // PrimaryKeyName defines primary key name.
 const (
 	PrimaryKeyName = "PRIMARY"
@@ -882,6 +882,7 @@ const (
 	ErrMustChangePasswordLogin                                      = 1862
 	ErrRowInWrongPartition                                          = 1863
 	ErrErrorLast                                                    = 1863
+	ErrExplainNotSupported                                          = 3012
 	ErrBadGeneratedColumn                                           = 3105
 	ErrUnsupportedOnGeneratedColumn                                 = 3106
 	ErrGeneratedColumnNonPrior                                      = 3107
@@ -890,4 +891,9 @@ const (
 	ErrInvalidJSONPath                                              = 3143
 	ErrInvalidJSONData                                              = 3146
 	ErrJSONUsedAsKey                                                = 3152
+	ErrPKIndexCantBeInvisible                                       = 3522
+	ErrFieldInGroupingNotGroupBy                                    = 3580
+	ErrFunctionalIndexOnField                                       = 3756
+	ErrFunctionalIndexPrimaryKey                                    = 3757
+	ErrFunctionalIndexFunctionIsNotAllowed                          = 3758
 )
@@ -109,7 +109,7 @@ var MySQLErrName = map[uint16]string{
 	ErrCantDropFieldOrKey:                       "Can't DROP '%-.192s'; check that column/key exists",
 	ErrInsertInfo:                               "Records: %ld  Duplicates: %ld  Warnings: %ld",
 	ErrUpdateTableUsed:                          "You can't specify target table '%-.192s' for update in FROM clause",
-	ErrNoSuchThread:                             "Unknown thread id: %lu",
+	ErrNoSuchThread:                             "Unknown thread id: %v",
 	ErrKillDenied:                               "You are not owner of thread %lu",
 	ErrNoTablesUsed:                             "No tables used",
 	ErrTooBigSet:                                "Too many strings for column %-.192s and SET",
@@ -879,6 +879,7 @@ var MySQLErrName = map[uint16]string{
 	ErrAlterOperationNotSupportedReasonNotNull:               "cannot silently convert NULL values, as required in this SQLMODE",
 	ErrMustChangePasswordLogin:                               "Your password has expired. To log in you must change it using a client that supports expired passwords.",
 	ErrRowInWrongPartition:                                   "Found a row in wrong partition %s",
+	ErrExplainNotSupported:                                   "EXPLAIN FOR CONNECTION command is supported only for SELECT/UPDATE/INSERT/DELETE/REPLACE",
 	ErrBadGeneratedColumn:                                    "The value specified for generated column '%s' in table '%s' is not allowed.",
 	ErrUnsupportedOnGeneratedColumn:                          "'%s' is not supported for generated columns.",
 	ErrGeneratedColumnNonPrior:                               "Generated column can refer only to generated columns defined prior to it.",
@@ -887,4 +888,9 @@ var MySQLErrName = map[uint16]string{
 	ErrInvalidJSONPath:                                       "Invalid JSON path expression %s.",
 	ErrInvalidJSONData:                                       "Invalid data type for JSON data",
 	ErrJSONUsedAsKey:                                         "JSON column '%-.192s' cannot be used in key specification.",
+	ErrPKIndexCantBeInvisible:                                "A primary key index cannot be invisible",
+	ErrFieldInGroupingNotGroupBy:                             "Argument #%d of GROUPING function is not in GROUP BY",
+	ErrFunctionalIndexOnField:                                "Functional index on a column is not supported. Consider using a regular index instead.",
+	ErrFunctionalIndexPrimaryKey:                             "The primary key cannot be a functional index",
+	ErrFunctionalIndexFunctionIsNotAllowed:                   "Expression of functional index '%s' contains a disallowed function.",
 }
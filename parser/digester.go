@@ -0,0 +1,54 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Normalize returns the normalized form of the sql, the literals are replaced by "?",
+// the keywords and identifiers are lower cased, and the tokens are separated by a single space.
+// The statements which only differ in the literals, the cases and the spaces have the same normalized form.
+func Normalize(sql string) string {
+	s := NewScanner(sql)
+	var buf bytes.Buffer
+	for {
+		tok, _, lit := s.scan()
+		if tok == 0 || (tok == unicode.ReplacementChar && s.r.eof()) {
+			break
+		}
+		if buf.Len() > 0 {
+			buf.WriteByte(' ')
+		}
+		switch tok {
+		case stringLit, intLit, floatLit, decLit, hexLit, bitLit:
+			buf.WriteByte('?')
+		case quotedIdentifier:
+			buf.WriteString("`" + strings.ToLower(lit) + "`")
+		default:
+			buf.WriteString(strings.ToLower(lit))
+		}
+	}
+	return buf.String()
+}
+
+// NormalizeDigest returns the normalized sql and its digest, the digest is the hex encoded sha256 of the normalized sql.
+func NormalizeDigest(sql string) (normalized, digest string) {
+	normalized = Normalize(sql)
+	return normalized, fmt.Sprintf("%x", sha256.Sum256([]byte(normalized)))
+}
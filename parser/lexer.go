@@ -366,12 +366,35 @@ func startWithSlash(s *Scanner) (tok int, pos Pos, lit string) {
 			}
 		}
 
+		// Convert "/*T![feature_id] TiDB-specific-code */" to "TiDB-specific-code", the code is
+		// ignored if the feature isn't supported.
+		if strings.HasPrefix(comment, "/*T![") {
+			if end := strings.Index(comment, "]"); end > 0 {
+				if _, ok := tidbFeatureIDs[comment[len("/*T!["):end]]; ok {
+					s.specialComment = &mysqlSpecificCodeScanner{
+						Scanner: NewScanner(comment[end+1 : len(comment)-2]),
+						Pos: Pos{
+							pos.Line,
+							pos.Col,
+							pos.Offset + end + 1,
+						},
+					}
+				}
+			}
+		}
+
 		return s.scan()
 	}
 	tok = int('/')
 	return
 }
 
+// tidbFeatureIDs are the features which can be used in the TiDB-specific comments like
+// "/*T![clustered_index] CLUSTERED */".
+var tidbFeatureIDs = map[string]struct{}{
+	"clustered_index": {},
+}
+
 func sqlOffsetInComment(comment string) int {
 	// find the first SQL token offset in pattern like "/*!40101 mysql specific code */"
 	offset := 0
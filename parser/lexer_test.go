@@ -57,7 +57,7 @@ func (s *testLexerSuite) TestSingleCharOther(c *C) {
 	defer testleak.AfterTest(c)()
 	table := []testCaseItem{
 		{"@", at},
-		{"AT", identifier},
+		{"AT", atKwd},
 		{"?", placeholder},
 		{"PLACEHOLDER", identifier},
 		{"=", eq},
@@ -154,6 +154,8 @@ func (s *testLexerSuite) TestComment(c *C) {
 	table := []testCaseItem{
 		{"-- select --\n1", intLit},
 		{"/*!40101 SET character_set_client = utf8 */;", set},
+		{"/*T![clustered_index] CLUSTERED */", clustered},
+		{"/*T![unknown_feature] CLUSTERED */;", int(';')},
 		{"/*+ BKA(t1) */", hintBegin},
 		{"/* SET character_set_client = utf8 */;", int(';')},
 		{"/* some comments */ SELECT ", selectKwd},
@@ -341,3 +343,25 @@ func (s *testLexerSuite) TestIllegal(c *C) {
 	}
 	runTest(c, table)
 }
+
+func (s *testLexerSuite) TestNormalize(c *C) {
+	defer testleak.AfterTest(c)()
+	table := []struct {
+		sql        string
+		normalized string
+	}{
+		{"SELECT * FROM t WHERE a = 1", "select * from t where a = ?"},
+		{"select  *\nfrom T where a='x' and b = 1.5e3", "select * from t where a = ? and b = ?"},
+		{"insert into `T` values (0x1f, b'01', 1.5, \"abc\")", "insert into `t` values ( ? , ? , ? , ? )"},
+		{"select a from t /* comment */ where b > ? -- comment", "select a from t where b > ?"},
+		{"select @@autocommit, @a", "select @@autocommit , @a"},
+	}
+	for _, t := range table {
+		c.Check(Normalize(t.sql), Equals, t.normalized, Commentf("sql %s", t.sql))
+	}
+	_, digest1 := NormalizeDigest("select 1")
+	_, digest2 := NormalizeDigest("SELECT   2")
+	_, digest3 := NormalizeDigest("select a")
+	c.Assert(digest1, Equals, digest2)
+	c.Assert(digest1, Not(Equals), digest3)
+}
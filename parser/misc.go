@@ -155,19 +155,26 @@ var tokenMap = map[string]int{
 	"ASC":                        asc,
 	"ASIN":                       asin,
 	"ASCII":                      ascii,
+	"AT":                         atKwd,
 	"ATAN":                       atan,
 	"ATAN2":                      atan2,
+	"AUTO_ID_CACHE":              autoIDCache,
 	"AUTO_INCREMENT":             autoIncrement,
 	"AVG":                        avg,
 	"AVG_ROW_LENGTH":             avgRowLength,
 	"BEGIN":                      begin,
+	"BERNOULLI":                  bernoulli,
 	"BETWEEN":                    between,
 	"BIN":                        bin,
+	"BINDING":                    binding,
+	"BINDINGS":                   bindings,
 	"BINLOG":                     binlog,
 	"BOTH":                       both,
 	"BTREE":                      btree,
 	"BY":                         by,
 	"BYTE":                       byteType,
+	"CALIBRATE":                  calibrate,
+	"CALL":                       call,
 	"CASE":                       caseKwd,
 	"CAST":                       cast,
 	"CEIL":                       ceil,
@@ -176,7 +183,9 @@ var tokenMap = map[string]int{
 	"CHARACTER":                  character,
 	"CHARSET":                    charsetKwd,
 	"CHECK":                      check,
+	"CLUSTERED":                  clustered,
 	"CHECKSUM":                   checksum,
+	"CLEANUP":                    cleanup,
 	"COALESCE":                   coalesce,
 	"COLLATE":                    collate,
 	"COLLATION":                  collation,
@@ -186,6 +195,7 @@ var tokenMap = map[string]int{
 	"COMMIT":                     commit,
 	"COMMITTED":                  committed,
 	"COMPACT":                    compact,
+	"COMPLETION":                 completion,
 	"COMPRESSED":                 compressed,
 	"COMPRESSION":                compression,
 	"CONCAT":                     concat,
@@ -222,6 +232,7 @@ var tokenMap = map[string]int{
 	"DAYOFYEAR":                  dayofyear,
 	"DDL":                        ddl,
 	"DEALLOCATE":                 deallocate,
+	"DECLARE":                    declare,
 	"DEGREES":                    degrees,
 	"DEFAULT":                    defaultKwd,
 	"DELAYED":                    delayed,
@@ -229,6 +240,7 @@ var tokenMap = map[string]int{
 	"DELETE":                     deleteKwd,
 	"DESC":                       desc,
 	"DESCRIBE":                   describe,
+	"DETERMINISTIC":              deterministic,
 	"DISABLE":                    disable,
 	"DISTINCT":                   distinct,
 	"DISTINCTROW":                distinctRow,
@@ -239,21 +251,27 @@ var tokenMap = map[string]int{
 	"DO":                         do,
 	"DROP":                       drop,
 	"DUAL":                       dual,
+	"DUMP":                       dump,
 	"DUPLICATE":                  duplicate,
 	"DYNAMIC":                    dynamic,
 	"FROM_DAYS":                  fromDays,
 	"ELSE":                       elseKwd,
+	"ELSEIF":                     elseIfKwd,
 	"ELT":                        elt,
 	"ENABLE":                     enable,
 	"ENCLOSED":                   enclosed,
 	"END":                        end,
+	"ENDS":                       ends,
 	"ENGINE":                     engine,
 	"ENGINES":                    engines,
+	"ERRORS":                     errorsKwd,
 	"ENUM":                       enum,
 	"ESCAPE":                     escape,
 	"ESCAPED":                    escaped,
 	"EXCLUSIVE":                  exclusive,
 	"EVENTS":                     events,
+	"EVENT":                      event,
+	"EVERY":                      every,
 	"EXECUTE":                    execute,
 	"EXISTS":                     exists,
 	"EXP":                        exp,
@@ -262,6 +280,8 @@ var tokenMap = map[string]int{
 	"EXTRACT":                    extract,
 	"FALSE":                      falseKwd,
 	"FIELD":                      fieldKwd,
+	"FAILPOINT":                  failpoint,
+	"FAILPOINTS":                 failpoints,
 	"FIELDS":                     fields,
 	"FIND_IN_SET":                findInSet,
 	"FIRST":                      first,
@@ -287,6 +307,7 @@ var tokenMap = map[string]int{
 	"GRANTS":                     grants,
 	"GREATEST":                   greatest,
 	"GROUP":                      group,
+	"GROUPING":                   grouping,
 	"GROUP_CONCAT":               groupConcat,
 	"HASH":                       hash,
 	"HAVING":                     having,
@@ -303,21 +324,26 @@ var tokenMap = map[string]int{
 	"INDEXES":                    indexes,
 	"INFILE":                     infile,
 	"INNER":                      inner,
+	"INOUT":                      inout,
 	"INSERT":                     insert,
 	"INSERT_FUNC":                insertFunc,
 	"INSTR":                      instr,
 	"INTERVAL":                   interval,
 	"INTO":                       into,
+	"INVISIBLE":                  invisible,
 	"IS":                         is,
 	"ISNULL":                     isNull,
 	"ISOLATION":                  isolation,
+	"ITERATE":                    iterate,
 	"JOIN":                       join,
 	"KEY":                        key,
 	"KEY_BLOCK_SIZE":             keyBlockSize,
 	"KEYS":                       keys,
+	"LANGUAGE":                   language,
 	"LAST_INSERT_ID":             lastInsertID,
 	"LEADING":                    leading,
 	"LEAST":                      least,
+	"LEAVE":                      leave,
 	"LEFT":                       left,
 	"LENGTH":                     length,
 	"LESS":                       less,
@@ -333,6 +359,7 @@ var tokenMap = map[string]int{
 	"LOCK":                       lock,
 	"LOG":                        log,
 	"LOG2":                       log2,
+	"LOOP":                       loop,
 	"LOG10":                      log10,
 	"LOWER":                      lower,
 	"LCASE":                      lcase,
@@ -344,7 +371,12 @@ var tokenMap = map[string]int{
 	"MAKE_SET":                   makeSet,
 	"MAX":                        max,
 	"MAXVALUE":                   maxValue,
+	"MAX_CONNECTIONS_PER_HOUR":   maxConnectionsPerHour,
+	"MAX_QUERIES_PER_HOUR":       maxQueriesPerHour,
+	"MASKING":                    masking,
 	"MAX_ROWS":                   maxRows,
+	"MAX_UPDATES_PER_HOUR":       maxUpdatesPerHour,
+	"MAX_USER_CONNECTIONS":       maxUserConnections,
 	"MICROSECOND":                microsecond,
 	"MID":                        mid,
 	"MIN":                        min,
@@ -357,6 +389,7 @@ var tokenMap = map[string]int{
 	"MONTHNAME":                  monthname,
 	"NAMES":                      names,
 	"NATIONAL":                   national,
+	"NONCLUSTERED":               nonclustered,
 	"NONE":                       none,
 	"NOT":                        not,
 	"NO_WRITE_TO_BINLOG":         noWriteToBinLog,
@@ -371,15 +404,20 @@ var tokenMap = map[string]int{
 	"OR":                         or,
 	"ORD":                        ord,
 	"ORDER":                      order,
+	"OUT":                        out,
 	"OUTER":                      outer,
 	"PASSWORD":                   password,
 	"PERIOD_ADD":                 periodAdd,
 	"PERIOD_DIFF":                periodDiff,
 	"PI":                         pi,
+	"PLAN":                       plan,
+	"PLUGINS":                    plugins,
+	"POLICY":                     policy,
 	"POSITION":                   position,
 	"POW":                        pow,
 	"POWER":                      power,
 	"PREPARE":                    prepare,
+	"PRESERVE":                   preserve,
 	"PRIMARY":                    primary,
 	"PRIVILEGES":                 privileges,
 	"PROCEDURE":                  procedure,
@@ -396,15 +434,20 @@ var tokenMap = map[string]int{
 	"REDUNDANT":                  redundant,
 	"REFERENCES":                 references,
 	"REGEXP":                     regexpKwd,
+	"REGIONS":                    regions,
 	"RELEASE_LOCK":               releaseLock,
 	"RENAME":                     rename,
 	"REPEAT":                     repeat,
+	"REPAIR":                     repair,
 	"REPEATABLE":                 repeatable,
 	"REPLACE":                    replace,
+	"RESOURCE":                   resource,
+	"RETURNS":                    returns,
 	"REVOKE":                     revoke,
 	"RIGHT":                      right,
 	"RLIKE":                      rlike,
 	"ROLLBACK":                   rollback,
+	"ROLLUP":                     rollup,
 	"ROUND":                      round,
 	"ROW":                        row,
 	"ROW_FORMAT":                 rowFormat,
@@ -412,11 +455,14 @@ var tokenMap = map[string]int{
 	"REVERSE":                    reverse,
 	"SCHEMA":                     schema,
 	"SCHEMAS":                    schemas,
+	"SCHEDULE":                   schedule,
 	"SEC_TO_TIME":                secToTime,
 	"SECOND":                     second,
 	"SELECT":                     selectKwd,
 	"SERIALIZABLE":               serializable,
 	"SESSION":                    session,
+	"SESSION_STATES":             sessionStates,
+	"SETS":                       sets,
 	"SET":                        set,
 	"SHARE":                      share,
 	"SHARED":                     shared,
@@ -430,6 +476,7 @@ var tokenMap = map[string]int{
 	"SPACE":                      space,
 	"SQRT":                       sqrt,
 	"START":                      start,
+	"STARTS":                     starts,
 	"STARTING":                   starting,
 	"STATS":                      stats,
 	"STATS_BUCKETS":              statsBuckets,
@@ -451,6 +498,7 @@ var tokenMap = map[string]int{
 	"TIDB":                       tidb,
 	"TABLE":                      tableKwd,
 	"TABLES":                     tables,
+	"TABLESAMPLE":                tableSample,
 	"TAN":                        tan,
 	"TERMINATED":                 terminated,
 	"TIMEDIFF":                   timediff,
@@ -464,6 +512,7 @@ var tokenMap = map[string]int{
 	"TO_BASE64":                  toBase64,
 	"TO_DAYS":                    toDays,
 	"TO_SECONDS":                 toSeconds,
+	"TRACE":                      trace,
 	"TRAILING":                   trailing,
 	"TRANSACTION":                transaction,
 	"TRIGGER":                    trigger,
@@ -482,8 +531,10 @@ var tokenMap = map[string]int{
 	"UPPER":                      upper,
 	"UCASE":                      ucase,
 	"UTC_TIME":                   utcTime,
+	"USAGE":                      usage,
 	"USE":                        use,
 	"USER":                       user,
+	"USER_RESOURCES":             userResources,
 	"USING":                      using,
 	"VALUE":                      value,
 	"VALUES":                     values,
@@ -491,12 +542,14 @@ var tokenMap = map[string]int{
 	"VERSION":                    version,
 	"VIEW":                       view,
 	"VIRTUAL":                    virtual,
+	"VISIBLE":                    visible,
 	"WARNINGS":                   warnings,
 	"WEEK":                       week,
 	"WEEKDAY":                    weekday,
 	"WEEKOFYEAR":                 weekofyear,
 	"WHEN":                       when,
 	"WHERE":                      where,
+	"WHILE":                      while,
 	"WITH":                       with,
 	"WRITE":                      write,
 	"XOR":                        xor,
@@ -553,6 +606,10 @@ var tokenMap = map[string]int{
 	"JSON_MERGE":                 jsonMerge,
 	"JSON_OBJECT":                jsonObject,
 	"JSON_ARRAY":                 jsonArray,
+	"JSON_ARRAYAGG":              jsonArrayAgg,
+	"JSON_OBJECTAGG":             jsonObjectAgg,
+	"APPROX_COUNT_DISTINCT":      approxCountDistinct,
+	"APPROX_PERCENTILE":          approxPercentile,
 	"SECOND_MICROSECOND":         secondMicrosecond,
 	"MINUTE_MICROSECOND":         minuteMicrosecond,
 	"MINUTE_SECOND":              minuteSecond,
@@ -577,7 +634,16 @@ var tokenMap = map[string]int{
 	"CHAR_LENGTH":                charLength,
 	"CHARACTER_LENGTH":           charLength,
 	"CONV":                       conv,
+	"BIT_AND":                    bitAnd,
+	"BIT_OR":                     bitOr,
 	"BIT_XOR":                    bitXor,
+	"STD":                        std,
+	"STDDEV":                     stddev,
+	"STDDEV_POP":                 stddevPop,
+	"STDDEV_SAMP":                stddevSamp,
+	"VAR_POP":                    varPop,
+	"VAR_SAMP":                   varSamp,
+	"VARIANCE":                   variance,
 	"BENCHMARK":                  benchmark,
 	"COERCIBILITY":               coercibility,
 	"ROW_COUNT":                  rowCount,
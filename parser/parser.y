@@ -69,6 +69,7 @@ import (
 	blobType		"BLOB"
 	both			"BOTH"
 	by			"BY"
+	call			"CALL"
 	cascade			"CASCADE"
 	caseKwd			"CASE"
 	change        		"CHANGE"
@@ -92,6 +93,7 @@ import (
 	dayMinute		"DAY_MINUTE"
 	daySecond 		"DAY_SECOND"
 	decimalType		"DECIMAL"
+	declare			"DECLARE"
 	defaultKwd		"DEFAULT"
 	delayed			"DELAYED"
 	deleteKwd		"DELETE"
@@ -107,6 +109,7 @@ import (
 	drop			"DROP"
 	dual 			"DUAL"
 	elseKwd			"ELSE"
+	elseIfKwd		"ELSEIF"
 	enclosed		"ENCLOSED"
 	escaped 		"ESCAPED"
 	exists			"EXISTS"
@@ -121,6 +124,7 @@ import (
 	generated		"GENERATED"
 	grants			"GRANTS"
 	group			"GROUP"
+	grouping		"GROUPING"
 	having			"HAVING"
 	highPriority		"HIGH_PRIORITY"
 	hourMicrosecond		"HOUR_MICROSECOND"
@@ -132,16 +136,19 @@ import (
 	index			"INDEX"
 	infile			"INFILE"
 	inner 			"INNER"
+	inout			"INOUT"
 	integerType		"INTEGER"
 	interval		"INTERVAL"
 	into			"INTO"
 	is			"IS"
+	iterate			"ITERATE"
 	insert			"INSERT"
 	intType			"INT"
 	join			"JOIN"
 	key			"KEY"
 	keys			"KEYS"
 	leading			"LEADING"
+	leave			"LEAVE"
 	left			"LEFT"
 	like			"LIKE"
 	limit			"LIMIT"
@@ -152,6 +159,7 @@ import (
 	lock			"LOCK"
 	longblobType		"LONGBLOB"
 	longtextType		"LONGTEXT"
+	loop			"LOOP"
 	lowPriority		"LOW_PRIORITY"
 	makeSet			"MAKE_SET"
 	maxValue		"MAXVALUE"
@@ -173,6 +181,7 @@ import (
 	or			"OR"
 	ord			"ORD"
 	order			"ORDER"
+	out			"OUT"
 	outer			"OUTER"
 	partition		"PARTITION"
 	partitions		"PARTITIONS"
@@ -202,6 +211,7 @@ import (
 	smallIntType		"SMALLINT"
 	starting		"STARTING"
 	tableKwd		"TABLE"
+	tableSample		"TABLESAMPLE"
 	stored			"STORED"
 	terminated		"TERMINATED"
 	then			"THEN"
@@ -226,6 +236,7 @@ import (
 	virtual			"VIRTUAL"
 	when			"WHEN"
 	where			"WHERE"
+	while			"WHILE"
 	write			"WRITE"
 	with			"WITH"
 	xor 			"XOR"
@@ -301,6 +312,10 @@ import (
 	jsonMerge			"JSON_MERGE"
 	jsonObject			"JSON_OBJECT"
 	jsonArray			"JSON_ARRAY"
+	jsonArrayAgg			"JSON_ARRAYAGG"
+	jsonObjectAgg			"JSON_OBJECTAGG"
+	approxCountDistinct		"APPROX_COUNT_DISTINCT"
+	approxPercentile		"APPROX_PERCENTILE"
 	kill				"KILL"
 	lastInsertID			"LAST_INSERT_ID"
 	lcase				"LCASE"
@@ -381,7 +396,16 @@ import (
 	charLength			"CHAR_LENGTH"
 	characterLength			"CHARACTER_LENGTH"
 	conv				"CONV"
+	bitAnd				"BIT_AND"
+	bitOr				"BIT_OR"
 	bitXor				"BIT_XOR"
+	std				"STD"
+	stddev				"STDDEV"
+	stddevPop			"STDDEV_POP"
+	stddevSamp			"STDDEV_SAMP"
+	varPop				"VAR_POP"
+	varSamp				"VAR_SAMP"
+	variance			"VARIANCE"
 	crc32				"CRC32"
 	compress			"COMPRESS"
 	decode				"DECODE"
@@ -423,24 +447,33 @@ import (
 	any 		"ANY"
 	ascii		"ASCII"
 	at		"AT"
+	atKwd
+	autoIDCache	"AUTO_ID_CACHE"
 	autoIncrement	"AUTO_INCREMENT"
 	avgRowLength	"AVG_ROW_LENGTH"
 	avg		"AVG"
 	begin		"BEGIN"
+	bernoulli	"BERNOULLI"
+	binding		"BINDING"
+	bindings	"BINDINGS"
 	binlog		"BINLOG"
 	bitType		"BIT"
 	booleanType	"BOOLEAN"
 	boolType	"BOOL"
 	btree		"BTREE"
 	byteType	"BYTE"
+	calibrate	"CALIBRATE"
 	charsetKwd	"CHARSET"
 	checksum	"CHECKSUM"
+	cleanup		"CLEANUP"
 	collation	"COLLATION"
 	columns		"COLUMNS"
 	comment 	"COMMENT"
 	commit		"COMMIT"
 	committed	"COMMITTED"
+	clustered	"CLUSTERED"
 	compact		"COMPACT"
+	completion	"COMPLETION"
 	compressed	"COMPRESSED"
 	compression	"COMPRESSION"
 	connection 	"CONNECTION"
@@ -450,17 +483,25 @@ import (
 	datetimeType	"DATETIME"
 	deallocate	"DEALLOCATE"
 	delayKeyWrite	"DELAY_KEY_WRITE"
+	deterministic	"DETERMINISTIC"
 	disable		"DISABLE"
 	do		"DO"
+	dump		"DUMP"
 	duplicate	"DUPLICATE"
 	dynamic		"DYNAMIC"
 	enable		"ENABLE"
 	end		"END"
 	engine		"ENGINE"
+	ends		"ENDS"
 	engines		"ENGINES"
+	errorsKwd	"ERRORS"
 	escape 		"ESCAPE"
+	event		"EVENT"
+	every		"EVERY"
 	exclusive       "EXCLUSIVE"
 	execute		"EXECUTE"
+	failpoint	"FAILPOINT"
+	failpoints	"FAILPOINTS"
 	fields		"FIELDS"
 	first		"FIRST"
 	fixed		"FIXED"
@@ -469,37 +510,57 @@ import (
 	function	"FUNCTION"
 	hash		"HASH"
 	identified	"IDENTIFIED"
+	invisible	"INVISIBLE"
 	isolation	"ISOLATION"
 	indexes		"INDEXES"
 	jsonType	"JSON"
 	keyBlockSize	"KEY_BLOCK_SIZE"
+	language	"LANGUAGE"
 	local		"LOCAL"
 	less		"LESS"
 	level		"LEVEL"
 	mode		"MODE"
 	modify		"MODIFY"
+	maxConnectionsPerHour	"MAX_CONNECTIONS_PER_HOUR"
+	maxQueriesPerHour	"MAX_QUERIES_PER_HOUR"
+	masking		"MASKING"
 	maxRows		"MAX_ROWS"
+	maxUpdatesPerHour	"MAX_UPDATES_PER_HOUR"
+	maxUserConnections	"MAX_USER_CONNECTIONS"
 	minRows		"MIN_ROWS"
 	names		"NAMES"
 	national	"NATIONAL"
 	no		"NO"
+	nonclustered	"NONCLUSTERED"
 	none		"NONE"
 	offset		"OFFSET"
 	only		"ONLY"
 	password	"PASSWORD"
+	plan		"PLAN"
+	plugins		"PLUGINS"
+	policy		"POLICY"
 	prepare		"PREPARE"
+	preserve	"PRESERVE"
 	privileges	"PRIVILEGES"
 	processlist	"PROCESSLIST"
 	quarter		"QUARTER"
 	quick		"QUICK"
 	redundant	"REDUNDANT"
+	regions		"REGIONS"
+	repair		"REPAIR"
 	repeatable	"REPEATABLE"
+	resource	"RESOURCE"
+	returns		"RETURNS"
 	reverse		"REVERSE"
 	rollback	"ROLLBACK"
+	rollup		"ROLLUP"
 	row 		"ROW"
 	rowFormat	"ROW_FORMAT"
+	schedule	"SCHEDULE"
 	serializable	"SERIALIZABLE"
 	session		"SESSION"
+	sessionStates	"SESSION_STATES"
+	sets		"SETS"
 	share		"SHARE"
 	shared       	"SHARED"
 	signed		"SIGNED"
@@ -508,6 +569,7 @@ import (
 	sqlCache	"SQL_CACHE"
 	sqlNoCache	"SQL_NO_CACHE"
 	start		"START"
+	starts		"STARTS"
 	stats		"STATS"
 	statsBuckets	"STATS_BUCKETS"
 	statsHistograms	"STATS_HISTOGRAMS"
@@ -523,16 +585,20 @@ import (
 	timeType	"TIME"
 	timestampType	"TIMESTAMP"
 	timestampDiff	"TIMESTAMPDIFF"
+	trace		"TRACE"
 	transaction	"TRANSACTION"
 	trigger		"TRIGGER"
 	triggers	"TRIGGERS"
 	truncate	"TRUNCATE"
 	uncommitted	"UNCOMMITTED"
 	unknown 	"UNKNOWN"
+	usage		"USAGE"
 	user		"USER"
+	userResources	"USER_RESOURCES"
 	value		"VALUE"
 	variables	"VARIABLES"
 	view		"VIEW"
+	visible		"VISIBLE"
 	warnings	"WARNINGS"
 	week		"WEEK"
 	yearType	"YEAR"
@@ -577,9 +643,14 @@ import (
 	AssignmentList		"assignment list"
 	AssignmentListOpt	"assignment list opt"
 	AuthOption		"User auth option"
+	ResourceOption		"User resource option"
+	ResourceOptionList	"User resource option list"
+	ResourceOptionListOpt	"Optional user resource option list"
+	ResourceGroupOpt	"Optional user resource group"
 	AuthString		"Password string value"
 	BeginTransactionStmt	"BEGIN TRANSACTION statement"
 	BinlogStmt		"Binlog base64 statement"
+	CallStmt		"CALL statement"
 	CastType		"Cast function target type"
 	CharsetName		"Character set name"
 	ColumnDef		"table column definition"
@@ -599,8 +670,15 @@ import (
 	ConstraintElem		"table constraint element"
 	ConstraintKeywordOpt	"Constraint Keyword or empty"
 	CreateDatabaseStmt	"Create Database Statement"
+	CreateFunctionStmt	"CREATE FUNCTION statement"
 	CreateIndexStmt		"CREATE INDEX statement"
 	CreateIndexStmtUnique	"CREATE INDEX optional UNIQUE clause"
+	CreateProcedureStmt	"CREATE PROCEDURE statement"
+	CreateBindingStmt	"CREATE BINDING statement"
+	BindableStmt		"SELECT or UNION statement which can be bound"
+	CreateEventStmt		"CREATE EVENT statement"
+	CreateMaskingPolicyStmt	"CREATE MASKING POLICY statement"
+	CreatePolicyStmt	"CREATE POLICY statement"
 	DatabaseOption		"CREATE Database specification"
 	DatabaseOptionList	"CREATE Database specification list"
 	DatabaseOptionListOpt	"CREATE Database specification list opt"
@@ -610,13 +688,20 @@ import (
 	DeallocateStmt		"Deallocate prepared statement"
 	DefaultValueExpr	"DefaultValueExpr(Now or Signed Literal)"
 	DeleteFromStmt		"DELETE FROM statement"
+	DeterministicOpt	"DETERMINISTIC or NOT DETERMINISTIC or empty"
 	DistinctOpt		"Explicit distinct option"
 	DefaultFalseDistinctOpt		"Distinct option which defaults to false"
 	DefaultTrueDistinctOpt		"Distinct option which defaults to true"
 	BuggyDefaultFalseDistinctOpt		"Distinct option which accepts DISTINCT ALL and defaults to false"
 	DoStmt			"Do statement"
 	DropDatabaseStmt	"DROP DATABASE statement"
+	DropFunctionStmt	"DROP FUNCTION statement"
 	DropIndexStmt		"DROP INDEX statement"
+	DropProcedureStmt	"DROP PROCEDURE statement"
+	DropBindingStmt		"DROP BINDING statement"
+	DropEventStmt		"DROP EVENT statement"
+	DropMaskingPolicyStmt	"DROP MASKING POLICY statement"
+	DropPolicyStmt		"DROP POLICY statement"
 	DropStatsStmt		"DROP STATS statement"
 	DropTableStmt		"DROP TABLE statement"
 	DropUserStmt		"DROP USER"
@@ -626,6 +711,11 @@ import (
 	EqOpt			"= or empty"
 	EscapedTableRef 	"escaped table reference"
 	Escaped			"Escaped by"
+	EventDisabledOpt	"ENABLE, DISABLE or empty"
+	EventEndsOpt		"ENDS of event schedule or empty"
+	EventPreserveOpt	"ON COMPLETION [NOT] PRESERVE or empty"
+	EventSchedule		"event schedule"
+	EventStartsOpt		"STARTS of event schedule or empty"
 	ExecuteStmt		"Execute statement"
 	ExplainStmt		"EXPLAIN statement"
 	Expression		"expression"
@@ -650,9 +740,16 @@ import (
 	FunctionCallKeyword	"Function call with keyword as function name"
 	FunctionCallNonKeyword	"Function call with nonkeyword as function name"
 	FuncDatetimePrec	"Function datetime precision"
+	FuncParam		"user-defined function parameter"
+	FuncParamList		"user-defined function parameter list"
+	FuncParamListOpt	"user-defined function parameter list opt"
 	GlobalScope		"The scope of variable"
 	GrantStmt		"Grant statement"
 	GroupByClause		"GROUP BY clause"
+	GroupingSet		"Grouping set"
+	GroupingSetList		"Grouping set list"
+	HandleRange		"handle range"
+	HandleRangeList		"handle range list"
 	HashString		"Hashed string"
 	HavingClause		"HAVING clause"
 	IfExists		"If Exists"
@@ -669,6 +766,9 @@ import (
 	IndexNameList		"index name list"
 	IndexOption		"Index Option"
 	IndexOptionList		"Index Option List or empty"
+	IndexVisibility		"Index visibility"
+	PrimaryKeyType		"Primary key type"
+	PrimaryKeyTypeOpt	"Optional primary key type"
 	IndexType		"index type"
 	IndexTypeOpt		"Optional index type"
 	InsertIntoStmt		"INSERT INTO statement"
@@ -687,6 +787,7 @@ import (
 	LocalOpt		"Local opt"
 	LockTablesStmt		"Lock tables statement"
 	LockClause         	"Alter table lock clause"
+	LockType		"Table locks type"
 	LowPriorityOptional	"LOW_PRIORITY or empty"
 	NumLiteral		"Num/Int/Float/Decimal Literal"
 	NoWriteToBinLogAliasOpt "NO_WRITE_TO_BINLOG alias LOCAL or empty"
@@ -710,6 +811,7 @@ import (
 	PartDefStorageOpt	"ENGINE = xxx or empty"
 	PasswordOpt		"Password option"
 	ColumnPosition		"Column position [First|After ColumnName]"
+	PluginNameList		"plugin name list"
 	PreparedStmt		"PreparedStmt"
 	PrepareSQL		"Prepare statement sql string"
 	PrimaryExpression	"primary expression"
@@ -719,6 +821,20 @@ import (
 	PrivElemList		"Privilege element list"
 	PrivLevel		"Privilege scope"
 	PrivType		"Privilege type"
+	ProcBlock		"stored procedure BEGIN ... END block"
+	ProcDefaultOpt		"stored procedure DECLARE DEFAULT clause or empty"
+	ProcElseOpt		"stored procedure ELSEIF or ELSE clause or empty"
+	ProcIfBody		"stored procedure IF condition and branches"
+	ProcLoopStmt		"stored procedure LOOP statement"
+	ProcParam		"stored procedure parameter"
+	ProcParamList		"stored procedure parameter list"
+	ProcParamListOpt	"stored procedure parameter list opt"
+	ProcParamMode		"stored procedure parameter mode"
+	ProcStmt		"stored procedure statement"
+	ProcStmtList		"stored procedure statement list"
+	ProcStmtListOpt		"stored procedure statement list opt"
+	ProcVarNameList		"stored procedure variable name list"
+	ProcWhileStmt		"stored procedure WHILE statement"
 	ReferDef		"Reference definition"
 	OnDeleteOpt		"optional ON DELETE clause"
 	OnUpdateOpt		"optional ON UPDATE clause"
@@ -768,11 +884,14 @@ import (
 	TableOption		"create table option"
 	TableOptionList		"create table option list"
 	TableOptionListOpt	"create table option list opt"
+	TableSampleOpt		"table sample opt"
 	TableRef 		"table reference"
 	TableRefs 		"table references"
 	TableToTable 		"rename table to table"
 	TableToTableList 	"rename table to table by list"
 
+	TraceStmt		"TRACE statement"
+	TraceableStmt		"traceable statement"
 	TransactionChar		"Transaction characteristic"
 	TransactionChars	"Transaction characteristic list"
 	TrimDirection		"Trim string direction"
@@ -838,6 +957,7 @@ import (
 %type	<ident>
 	KeyOrIndex		"{KEY|INDEX}"
 	ColumnKeywordOpt	"Column keyword or empty"
+	GlobalBindingOpt	"GLOBAL keyword or empty"
 	PrimaryOpt		"Optional primary keyword"
 	NowSym			"CURRENT_TIMESTAMP/LOCALTIME/LOCALTIMESTAMP"
 	NowSymFunc		"CURRENT_TIMESTAMP/LOCALTIME/LOCALTIMESTAMP/NOW"
@@ -862,11 +982,12 @@ import (
 	NationalOpt		"National option"
 	CharsetKw		"charset or charater set"
 	CommaOpt		"optional comma"
-	LockType		"Table locks type"
 	logAnd			"logical and operator"
 	logOr			"logical or operator"
 	FieldsOrColumns 	"Fields or columns"
 	GetFormatSelector	"{DATE|DATETIME|TIME|TIMESTAMP}"
+	ProcEndLabelOpt		"stored procedure end label or empty"
+	EventCommentOpt		"event comment or empty"
 
 %type	<ident>
 	Identifier			"identifier or unreserved keyword"
@@ -877,6 +998,8 @@ import (
 	FunctionNameConflict		"Built-in function call names which are conflict with keywords"
 	FunctionNameDateArith		"Date arith function call names (date_add or date_sub)"
 	FunctionNameDateArithMultiForms	"Date arith function call names (adddate or subdate)"
+	FunctionNameStddevPop		"Population standard deviation function call names (std, stddev or stddev_pop)"
+	FunctionNameVarPop		"Population variance function call names (variance or var_pop)"
 
 %precedence lowestOpt
 %token	tableRefPriority
@@ -1052,6 +1175,14 @@ AlterTableSpec:
 			},
 		}
 	}
+|	"ALTER" "INDEX" Identifier IndexVisibility
+	{
+		$$ = &ast.AlterTableSpec{
+			Tp:		ast.AlterTableIndexVisibility,
+			Name:		$3,
+			Visibility:	$4.(ast.IndexVisibility),
+		}
+	}
 |	"RENAME" "TO" TableName
 	{
 		$$ = &ast.AlterTableSpec{
@@ -1073,6 +1204,14 @@ AlterTableSpec:
 			NewTable:      $3.(*ast.TableName),
 		}
 	}
+|	"RENAME" KeyOrIndex Identifier "TO" Identifier
+	{
+		$$ = &ast.AlterTableSpec{
+			Tp:    		ast.AlterTableRenameIndex,
+			FromKey:	model.NewCIStr($3),
+			ToKey:		model.NewCIStr($5),
+		}
+	}
 |	LockClause
 	{
 		$$ = &ast.AlterTableSpec{
@@ -1320,12 +1459,12 @@ ColumnOption:
 	{
 		$$ = &ast.ColumnOption{Tp: ast.ColumnOptionAutoIncrement}
 	}
-|	PrimaryOpt "KEY"
+|	PrimaryOpt "KEY" PrimaryKeyTypeOpt
 	{
 		// KEY is normally a synonym for INDEX. The key attribute PRIMARY KEY
 		// can also be specified as just KEY when given in a column definition.
 		// See http://dev.mysql.com/doc/refman/5.7/en/create-table.html
-		$$ = &ast.ColumnOption{Tp: ast.ColumnOptionPrimaryKey}
+		$$ = &ast.ColumnOption{Tp: ast.ColumnOptionPrimaryKey, PrimaryKeyTp: $3.(ast.PrimaryKeyType)}
 	}
 |	"UNIQUE" %prec lowerThanKey
 	{
@@ -1630,8 +1769,16 @@ CreateIndexStmtUnique:
 IndexColName:
 	ColumnName OptFieldLen Order
 	{
-		//Order is parsed but just ignored as MySQL did
-		$$ = &ast.IndexColName{Column: $1.(*ast.ColumnName), Length: $2.(int)}
+		$$ = &ast.IndexColName{Column: $1.(*ast.ColumnName), Length: $2.(int), Desc: $3.(bool)}
+	}
+|	'(' Expression ')' Order
+	{
+		// The key of an expression index, the text of the expression is saved in the index.
+		startOffset := parser.startOffset(&yyS[yypt-2])
+		endOffset := parser.endOffset(&yyS[yypt-1])
+		expr := $2.(ast.ExprNode)
+		expr.SetText(parser.src[startOffset:endOffset])
+		$$ = &ast.IndexColName{Expr: expr, Length: types.UnspecifiedLength, Desc: $4.(bool)}
 	}
 
 IndexColNameList:
@@ -1906,6 +2053,118 @@ DropUserStmt:
         $$ = &ast.DropUserStmt{IfExists: true, UserList: $5.([]string)}
 	}
 
+DropFunctionStmt:
+	"DROP" "FUNCTION" IfExists Identifier
+	{
+		$$ = &ast.DropFunctionStmt{IfExists: $3.(bool), Name: model.NewCIStr($4)}
+	}
+
+DropProcedureStmt:
+	"DROP" "PROCEDURE" IfExists TableName
+	{
+		$$ = &ast.DropProcedureStmt{IfExists: $3.(bool), Name: $4.(*ast.TableName)}
+	}
+
+DropEventStmt:
+	"DROP" "EVENT" IfExists TableName
+	{
+		$$ = &ast.DropEventStmt{IfExists: $3.(bool), Name: $4.(*ast.TableName)}
+	}
+
+/*******************************************************************
+ *
+ *  Create Binding Statement
+ *
+ *  Example:
+ *      CREATE [GLOBAL] BINDING FOR select_statement USING hinted_select_statement
+ *
+ *******************************************************************/
+CreateBindingStmt:
+	"CREATE" GlobalBindingOpt "BINDING" "FOR" BindableStmt "USING" BindableStmt
+	{
+		originStmt := $5.(ast.StmtNode)
+		originStmt.SetText(parser.src[parser.startOffset(&yyS[yypt-2]):parser.endOffset(&yyS[yypt-1])])
+		// The hinted statement is the last part of the statement, so it ends before the lookahead token.
+		hintedStmt := $7.(ast.StmtNode)
+		hintedStmt.SetText(parser.src[parser.startOffset(&yyS[yypt]):parser.endOffset(&parser.yylval)])
+		$$ = &ast.CreateBindingStmt{OriginStmt: originStmt, HintedStmt: hintedStmt}
+	}
+
+DropBindingStmt:
+	"DROP" GlobalBindingOpt "BINDING" "FOR" BindableStmt
+	{
+		originStmt := $5.(ast.StmtNode)
+		originStmt.SetText(parser.src[parser.startOffset(&yyS[yypt]):parser.endOffset(&parser.yylval)])
+		$$ = &ast.DropBindingStmt{OriginStmt: originStmt}
+	}
+
+GlobalBindingOpt:
+	{}
+|	"GLOBAL"
+
+BindableStmt:
+	SelectStmt
+|	UnionStmt
+
+/*******************************************************************
+ *
+ *  Create Policy Statement
+ *
+ *  Example:
+ *      CREATE POLICY [IF NOT EXISTS] policy_name ON tbl_name USING (predicate)
+ *
+ *******************************************************************/
+CreatePolicyStmt:
+	"CREATE" "POLICY" IfNotExists Identifier "ON" TableName "USING" '(' Expression ')'
+	{
+		startOffset := parser.startOffset(&yyS[yypt-1])
+		endOffset := parser.endOffset(&yyS[yypt])
+		expr := $9.(ast.ExprNode)
+		expr.SetText(parser.src[startOffset:endOffset])
+		$$ = &ast.CreatePolicyStmt{
+			IfNotExists: $3.(bool),
+			Name:        model.NewCIStr($4),
+			Table:       $6.(*ast.TableName),
+			Predicate:   expr,
+		}
+	}
+
+DropPolicyStmt:
+	"DROP" "POLICY" IfExists Identifier "ON" TableName
+	{
+		$$ = &ast.DropPolicyStmt{IfExists: $3.(bool), Name: model.NewCIStr($4), Table: $6.(*ast.TableName)}
+	}
+
+/*******************************************************************
+ *
+ *  Create Masking Policy Statement
+ *
+ *  Example:
+ *      CREATE MASKING POLICY [IF NOT EXISTS] policy_name ON tbl_name (col_name) USING (mask_expr)
+ *
+ *******************************************************************/
+CreateMaskingPolicyStmt:
+	"CREATE" "MASKING" "POLICY" IfNotExists Identifier "ON" TableName '(' Identifier ')' "USING" '(' Expression ')'
+	{
+		startOffset := parser.startOffset(&yyS[yypt-1])
+		endOffset := parser.endOffset(&yyS[yypt])
+		expr := $13.(ast.ExprNode)
+		expr.SetText(parser.src[startOffset:endOffset])
+		$$ = &ast.CreateMaskingPolicyStmt{
+			IfNotExists: $4.(bool),
+			Name:        model.NewCIStr($5),
+			Table:       $7.(*ast.TableName),
+			Column:      model.NewCIStr($9),
+			MaskExpr:    expr,
+		}
+	}
+
+DropMaskingPolicyStmt:
+	"DROP" "MASKING" "POLICY" IfExists Identifier "ON" TableName
+	{
+		$$ = &ast.DropMaskingPolicyStmt{IfExists: $4.(bool), Name: model.NewCIStr($5), Table: $7.(*ast.TableName)}
+	}
+
 DropStatsStmt:
 	"DROP" "STATS" TableName
 	{
@@ -1951,7 +2210,14 @@ ExplainStmt:
 	}
 |	ExplainSym ExplainableStmt
 	{
-		$$ = &ast.ExplainStmt{Stmt: $2.(ast.StmtNode)}
+		stmt := $2.(ast.StmtNode)
+		// The explained statement is the last part of the statement, its text is used to find the binding.
+		stmt.SetText(parser.src[parser.startOffset(&yyS[yypt]):parser.endOffset(&parser.yylval)])
+		$$ = &ast.ExplainStmt{Stmt: stmt}
+	}
+|	ExplainSym "FOR" "CONNECTION" NUM
+	{
+		$$ = &ast.ExplainForStmt{ConnectionID: getUint64FromNUM($4)}
 	}
 
 LengthNum:
@@ -2287,6 +2553,43 @@ GroupByClause:
 	{
 		$$ = &ast.GroupByClause{Items: $3.([]*ast.ByItem)}
 	}
+|	"GROUP" "BY" ByList "WITH" "ROLLUP"
+	{
+		$$ = &ast.GroupByClause{Items: $3.([]*ast.ByItem), Rollup: true}
+	}
+|	"GROUP" "BY" "GROUPING" "SETS" '(' GroupingSetList ')'
+	{
+		gby := &ast.GroupByClause{}
+		for _, set := range $6.([][]*ast.ByItem) {
+			positions := make([]int, 0, len(set))
+			for _, item := range set {
+				positions = append(positions, len(gby.Items))
+				gby.Items = append(gby.Items, item)
+			}
+			gby.GroupingSets = append(gby.GroupingSets, positions)
+		}
+		$$ = gby
+	}
+
+GroupingSetList:
+	GroupingSet
+	{
+		$$ = [][]*ast.ByItem{$1.([]*ast.ByItem)}
+	}
+|	GroupingSetList ',' GroupingSet
+	{
+		$$ = append($1.([][]*ast.ByItem), $3.([]*ast.ByItem))
+	}
+
+GroupingSet:
+	'(' ')'
+	{
+		$$ = []*ast.ByItem{}
+	}
+|	'(' ByList ')'
+	{
+		$$ = $2
+	}
 
 HavingClause:
 	{
@@ -2354,6 +2657,10 @@ IndexOptionList:
 				opt1.Comment = opt2.Comment
 			} else if opt2.Tp != 0 {
 				opt1.Tp = opt2.Tp
+			} else if opt2.Visibility != ast.IndexVisibilityDefault {
+				opt1.Visibility = opt2.Visibility
+			} else if opt2.PrimaryKeyTp != ast.PrimaryKeyTypeDefault {
+				opt1.PrimaryKeyTp = opt2.PrimaryKeyTp
 			}
 			$$ = opt1
 		}
@@ -2380,6 +2687,47 @@ IndexOption:
 			Comment: $2,
 		}
 	}
+|	IndexVisibility
+	{
+		$$ = &ast.IndexOption {
+			Visibility: $1.(ast.IndexVisibility),
+		}
+	}
+|	PrimaryKeyType
+	{
+		$$ = &ast.IndexOption {
+			PrimaryKeyTp: $1.(ast.PrimaryKeyType),
+		}
+	}
+
+IndexVisibility:
+	"VISIBLE"
+	{
+		$$ = ast.IndexVisibilityVisible
+	}
+|	"INVISIBLE"
+	{
+		$$ = ast.IndexVisibilityInvisible
+	}
+
+PrimaryKeyType:
+	"CLUSTERED"
+	{
+		$$ = ast.PrimaryKeyTypeClustered
+	}
+|	"NONCLUSTERED"
+	{
+		$$ = ast.PrimaryKeyTypeNonClustered
+	}
+
+PrimaryKeyTypeOpt:
+	{
+		$$ = ast.PrimaryKeyTypeDefault
+	}
+|	PrimaryKeyType
+	{
+		$$ = $1
+	}
 
 IndexType:
 	"USING" "BTREE"
@@ -2408,45 +2756,47 @@ IdentifierOrReservedKeyword:
 Identifier | ReservedKeyword
 
 UnReservedKeyword:
- "ACTION" | "ASCII" | "AUTO_INCREMENT" | "AFTER" | "ALWAYS" | "AT" | "AVG" | "BEGIN" | "BIT" | "BOOL" | "BOOLEAN" | "BTREE" | "CHARSET"
+ "ACTION" | "ASCII" | "AUTO_ID_CACHE" | "AUTO_INCREMENT" | "AFTER" | "ALWAYS" | "AT" | "AVG" | "BEGIN" | "BIT" | "BOOL" | "BOOLEAN" | "BTREE" | "CHARSET"
 | "COLUMNS" | "COMMIT" | "COMPACT" | "COMPRESSED" | "CONSISTENT" | "DATA" | "DATE" | "DATETIME" | "DEALLOCATE" | "DO"
-| "DYNAMIC"| "END" | "ENGINE" | "ENGINES" | "ESCAPE" | "EXECUTE" | "FIELDS" | "FIRST" | "FIXED" | "FORMAT" | "FULL" |"GLOBAL"
+| "DYNAMIC"| "END" | "ENGINE" | "ENGINES" | "ERRORS" | "ESCAPE" | "EXECUTE" | "FIELDS" | "FIRST" | "FIXED" | "FORMAT" | "FULL" |"GLOBAL"
 | "HASH" | "LESS" | "LOCAL" | "NAMES" | "OFFSET" | "PASSWORD" %prec lowerThanEq | "PREPARE" | "QUICK" | "REDUNDANT"
-| "ROLLBACK" | "SESSION" | "SIGNED" | "SNAPSHOT" | "START" | "STATUS" | "TABLES" | "TEXT" | "THAN" | "TIDB" | "TIME" | "TIMESTAMP"
-| "TRANSACTION" | "TRUNCATE" | "UNKNOWN" | "VALUE" | "WARNINGS" | "YEAR" | "MODE"  | "WEEK"  | "ANY" | "SOME" | "USER" | "IDENTIFIED"
-| "COLLATION" | "COMMENT" | "AVG_ROW_LENGTH" | "CONNECTION" | "CHECKSUM" | "COMPRESSION" | "KEY_BLOCK_SIZE" | "MAX_ROWS"
+| "ROLLBACK" | "ROLLUP" | "SESSION" | "SETS" | "SIGNED" | "SNAPSHOT" | "START" | "STATUS" | "TABLES" | "TEXT" | "THAN" | "TIDB" | "TIME" | "TIMESTAMP"
+| "TRANSACTION" | "TRUNCATE" | "UNKNOWN" | "VALUE" | "WARNINGS" | "YEAR" | "MODE"  | "WEEK"  | "ANY" | "SOME" | "USAGE" | "USER" | "IDENTIFIED"
+| "COLLATION" | "COMMENT" | "AVG_ROW_LENGTH" | "CONNECTION" | "CHECKSUM" | "COMPRESSION" | "KEY_BLOCK_SIZE" | "MAX_ROWS" | "MAX_USER_CONNECTIONS"
+| "MAX_QUERIES_PER_HOUR" | "MAX_UPDATES_PER_HOUR" | "MAX_CONNECTIONS_PER_HOUR" | "RESOURCE" | "USER_RESOURCES"
 | "MIN_ROWS" | "NATIONAL" | "ROW" | "ROW_FORMAT" | "QUARTER" | "GRANTS" | "TRIGGERS" | "DELAY_KEY_WRITE" | "ISOLATION" | "JSON"
 | "REPEATABLE" | "COMMITTED" | "UNCOMMITTED" | "ONLY" | "SERIALIZABLE" | "LEVEL" | "VARIABLES" | "SQL_CACHE" | "INDEXES" | "PROCESSLIST"
-| "SQL_NO_CACHE" | "DISABLE"  | "ENABLE" | "REVERSE" | "SPACE" | "PRIVILEGES" | "NO" | "BINLOG" | "FUNCTION" | "VIEW" | "MODIFY" | "EVENTS" | "PARTITIONS"
-| "TIMESTAMPDIFF" | "NONE" | "SUPER" | "SHARED" | "EXCLUSIVE" | "STATS" | "STATS_META" | "STATS_HISTOGRAMS" | "STATS_BUCKETS"
+| "SQL_NO_CACHE" | "DISABLE"  | "ENABLE" | "REVERSE" | "SPACE" | "PRIVILEGES" | "NO" | "BINLOG" | "BINDING" | "BINDINGS" | "FUNCTION" | "VIEW" | "MODIFY" | "EVENTS" | "PARTITIONS"
+| "TIMESTAMPDIFF" | "NONE" | "SUPER" | "SHARED" | "EXCLUSIVE" | "STATS" | "STATS_META" | "STATS_HISTOGRAMS" | "STATS_BUCKETS" | "FAILPOINT" | "FAILPOINTS" | "REPAIR" | "DUMP" | "TRACE" | "PLUGINS" | "PLAN" | "CALIBRATE"
+| "DETERMINISTIC" | "LANGUAGE" | "RETURNS" | atKwd | "COMPLETION" | "ENDS" | "EVENT" | "EVERY" | "PRESERVE" | "SCHEDULE" | "STARTS" | "VISIBLE" | "INVISIBLE" | "CLUSTERED" | "NONCLUSTERED" | "POLICY" | "MASKING" | "SESSION_STATES" | "REGIONS" | "BERNOULLI" | "CLEANUP"
 
 ReservedKeyword:
 "ADD" | "ALL" | "ALTER" | "ANALYZE" | "AND" | "AS" | "ASC" | "BETWEEN" | "BIGINT"
-| "BINARY" | "BLOB" | "BOTH" | "BY" | "CASCADE" | "CASE" | "CHANGE" | "CHARACTER" | "CHECK" | "COLLATE"
+| "BINARY" | "BLOB" | "BOTH" | "BY" | "CALL" | "CASCADE" | "CASE" | "CHANGE" | "CHARACTER" | "CHECK" | "COLLATE"
 | "COLUMN" | "CONSTRAINT" | "CONVERT" | "CREATE" | "CROSS" | "CURRENT_DATE" | "CURRENT_TIME"
 | "CURRENT_TIMESTAMP" | "CURRENT_USER" | "DATABASE" | "DATABASES" | "DAY_HOUR" | "DAY_MICROSECOND"
-| "DAY_MINUTE" | "DAY_SECOND" | "DECIMAL" | "DEFAULT" | "DELETE" | "DESC" | "DESCRIBE"
-| "DISTINCT" | "DISTINCTROW" | "DIV" | "DOUBLE" | "DROP" | "DUAL" | "ELSE" | "ENCLOSED" | "ESCAPED"
+| "DAY_MINUTE" | "DAY_SECOND" | "DECIMAL" | "DECLARE" | "DEFAULT" | "DELETE" | "DESC" | "DESCRIBE"
+| "DISTINCT" | "DISTINCTROW" | "DIV" | "DOUBLE" | "DROP" | "DUAL" | "ELSE" | "ELSEIF" | "ENCLOSED" | "ESCAPED"
 | "EXISTS" | "EXPLAIN" | "FALSE" | "FLOAT" | "FOR" | "FORCE" | "FOREIGN" | "FROM"
-| "FULLTEXT" | "GENERATED" | "GRANT" | "GROUP" | "HAVING" | "HOUR_MICROSECOND" | "HOUR_MINUTE"
-| "HOUR_SECOND" | "IF" | "IGNORE" | "IN" | "INDEX" | "INFILE" | "INNER" | "INSERT" | "INT" | "INTO" | "INTEGER"
-| "INTERVAL" | "IS" | "JOIN" | "KEY" | "KEYS" | "KILL" | "LEADING" | "LEFT" | "LIKE" | "LIMIT" | "LINES" | "LOAD"
-| "LOCALTIME" | "LOCALTIMESTAMP" | "LOCK" | "LONGBLOB" | "LONGTEXT" | "MAXVALUE" | "MEDIUMBLOB" | "MEDIUMINT" | "MEDIUMTEXT"
+| "FULLTEXT" | "GENERATED" | "GRANT" | "GROUP" | "GROUPING" | "HAVING" | "HOUR_MICROSECOND" | "HOUR_MINUTE"
+| "HOUR_SECOND" | "IF" | "IGNORE" | "IN" | "INDEX" | "INFILE" | "INNER" | "INOUT" | "INSERT" | "INT" | "INTO" | "INTEGER"
+| "INTERVAL" | "IS" | "ITERATE" | "JOIN" | "KEY" | "KEYS" | "KILL" | "LEADING" | "LEAVE" | "LEFT" | "LIKE" | "LIMIT" | "LINES" | "LOAD"
+| "LOCALTIME" | "LOCALTIMESTAMP" | "LOCK" | "LONGBLOB" | "LONGTEXT" | "LOOP" | "MAXVALUE" | "MEDIUMBLOB" | "MEDIUMINT" | "MEDIUMTEXT"
 | "MINUTE_MICROSECOND" | "MINUTE_SECOND" | "MOD" | "NOT" | "NO_WRITE_TO_BINLOG" | "NULL" | "NUMERIC"
-| "ON" | "OPTION" | "OR" | "ORDER" | "OUTER" | "PARTITION" | "PRECISION" | "PRIMARY" | "PROCEDURE" | "RANGE" | "READ"
+| "ON" | "OPTION" | "OR" | "ORDER" | "OUT" | "OUTER" | "PARTITION" | "PRECISION" | "PRIMARY" | "PROCEDURE" | "RANGE" | "READ"
 | "REAL" | "REFERENCES" | "REGEXP" | "RENAME" | "REPEAT" | "REPLACE" | "RESTRICT" | "REVOKE" | "RIGHT" | "RLIKE"
 | "SCHEMA" | "SCHEMAS" | "SECOND_MICROSECOND" | "SELECT" | "SET" | "SHOW" | "SMALLINT"
-| "STARTING" | "TABLE" | "STORED" | "TERMINATED" | "THEN" | "TINYBLOB" | "TINYINT" | "TINYTEXT" | "TO"
+| "STARTING" | "TABLE" | "TABLESAMPLE" | "STORED" | "TERMINATED" | "THEN" | "TINYBLOB" | "TINYINT" | "TINYTEXT" | "TO"
 | "TRAILING" | "TRIGGER" | "TRUE" | "UNION" | "UNIQUE" | "UNLOCK" | "UNSIGNED"
 | "UPDATE" | "USE" | "USING" | "UTC_DATE" | "UTC_TIMESTAMP" | "VALUES" | "VARBINARY" | "VARCHAR" | "VIRTUAL"
-| "WHEN" | "WHERE" | "WRITE" | "XOR" | "YEAR_MONTH" | "ZEROFILL" | "NATURAL"
+| "WHEN" | "WHERE" | "WHILE" | "WRITE" | "XOR" | "YEAR_MONTH" | "ZEROFILL" | "NATURAL"
  /*
 | "DELAYED" | "HIGH_PRIORITY" | "LOW_PRIORITY"| "WITH"
  */
 
 
 NotKeywordToken:
-	"ABS" | "ACOS" | "ADDTIME" | "ADDDATE" | "ADMIN" | "ASIN" | "ATAN" | "ATAN2" | "BENCHMARK" | "BIN" | "BIT_COUNT" | "BIT_LENGTH" | "COALESCE" | "COERCIBILITY" | "CONCAT" | "CONCAT_WS" | "CONNECTION_ID" | "CONVERT_TZ" | "CUR_TIME"| "COS" | "COT" | "COUNT" | "DAY"
+	"ABS" | "ACOS" | "ADDTIME" | "ADDDATE" | "ADMIN" | "ASIN" | "ATAN" | "ATAN2" | "BENCHMARK" | "BIN" | "BIT_AND" | "BIT_COUNT" | "BIT_LENGTH" | "BIT_OR" | "BIT_XOR" | "COALESCE" | "COERCIBILITY" | "CONCAT" | "CONCAT_WS" | "CONNECTION_ID" | "CONVERT_TZ" | "CUR_TIME"| "COS" | "COT" | "COUNT" | "DAY"
 |	"DATEDIFF" | "DATE_ADD" | "DATE_FORMAT" | "DATE_SUB" | "DAYNAME" | "DAYOFMONTH" | "DAYOFWEEK" | "DAYOFYEAR" | "DEGREES" | "ELT" | "EXP" | "EXPORT_SET" | "FROM_DAYS" | "FROM_BASE64" | "FIND_IN_SET" | "FOUND_ROWS"
 |	"GET_FORMAT" | "GROUP_CONCAT" | "GREATEST" | "LEAST" | "HOUR" | "HEX" | "UNHEX" | "IFNULL" | "INSTR" | "ISNULL" | "LAST_INSERT_ID" | "LCASE" | "LENGTH" | "LOAD_FILE" | "LOCATE" | "LOWER" | "LPAD" | "LTRIM"
 |	"MAKE_SET" | "MAX" | "MAKEDATE" | "MAKETIME" | "MICROSECOND" | "MID" | "MIN" |	"MINUTE" | "NULLIF" | "MONTH" | "MONTHNAME" | "NOW" |  "OCT" | "OCTET_LENGTH" | "ORD" | "POSITION" | "PERIOD_ADD" | "PERIOD_DIFF" | "PI" | "POW" | "POWER" | "RAND" | "RADIANS" | "ROW_COUNT"
@@ -2457,6 +2807,8 @@ NotKeywordToken:
 |	"ANY_VALUE" | "INET_ATON" | "INET_NTOA" | "INET6_ATON" | "INET6_NTOA" | "IS_FREE_LOCK" | "IS_IPV4" | "IS_IPV4_COMPAT" | "IS_IPV4_MAPPED" | "IS_IPV6" | "IS_USED_LOCK" | "MASTER_POS_WAIT" | "NAME_CONST" | "RELEASE_ALL_LOCKS" | "UUID" | "UUID_SHORT"
 |	"COMPRESS" | "DECODE" | "DES_DECRYPT" | "DES_ENCRYPT" | "ENCODE" | "ENCRYPT" | "MD5" | "OLD_PASSWORD" | "RANDOM_BYTES" | "SHA1" | "SHA" | "SHA2" | "UNCOMPRESS" | "UNCOMPRESSED_LENGTH" | "VALIDATE_PASSWORD_STRENGTH"
 |	"JSON_EXTRACT" | "JSON_UNQUOTE" | "JSON_TYPE" | "JSON_MERGE" | "JSON_SET" | "JSON_INSERT" | "JSON_REPLACE" | "JSON_REMOVE" | "JSON_OBJECT" | "JSON_ARRAY" | "TIDB_VERSION"
+|	"JSON_ARRAYAGG" | "JSON_OBJECTAGG" | "STD" | "STDDEV" | "STDDEV_POP" | "STDDEV_SAMP" | "VAR_POP" | "VAR_SAMP" | "VARIANCE"
+|	"APPROX_COUNT_DISTINCT" | "APPROX_PERCENTILE"
 
 /************************************************************************************
  *
@@ -2795,6 +3147,11 @@ Function:
 |	FunctionCallNonKeyword
 |	FunctionCallConflict
 |	FunctionCallAgg
+|	identifier '(' ExpressionListOpt ')'
+	{
+		// The user-defined functions.
+		$$ = &ast.FuncCallExpr{FnName: model.NewCIStr($1), Args: $3.([]ast.ExprNode)}
+	}
 |	Identifier jss stringLit
 	{
 	    col := &ast.ColumnNameExpr{Name: &ast.ColumnName{Name: model.NewCIStr($1)}}
@@ -3881,6 +4238,14 @@ FunctionCallAgg:
 	{
 		$$ = &ast.AggregateFuncExpr{F: $1, Args: []ast.ExprNode{$4.(ast.ExprNode)}, Distinct: $3.(bool)}
 	}
+|	"BIT_AND" '(' Expression ')'
+	{
+		$$ = &ast.AggregateFuncExpr{F: $1, Args: []ast.ExprNode{$3.(ast.ExprNode)}}
+	}
+|	"BIT_OR" '(' Expression ')'
+	{
+		$$ = &ast.AggregateFuncExpr{F: $1, Args: []ast.ExprNode{$3.(ast.ExprNode)}}
+	}
 |	"BIT_XOR" '(' Expression ')'
 	{
 		$$ = &ast.AggregateFuncExpr{F: $1, Args: []ast.ExprNode{$3.(ast.ExprNode)}}
@@ -3918,6 +4283,51 @@ FunctionCallAgg:
 	{
 		$$ = &ast.AggregateFuncExpr{F: $1, Args: []ast.ExprNode{$4.(ast.ExprNode)}, Distinct: $3.(bool)}
 	}
+|	"GROUPING" '(' ExpressionList ')'
+	{
+		$$ = &ast.AggregateFuncExpr{F: ast.AggFuncGrouping, Args: $3.([]ast.ExprNode)}
+	}
+|	"JSON_ARRAYAGG" '(' Expression ')'
+	{
+		$$ = &ast.AggregateFuncExpr{F: $1, Args: []ast.ExprNode{$3.(ast.ExprNode)}}
+	}
+|	"JSON_OBJECTAGG" '(' Expression ',' Expression ')'
+	{
+		$$ = &ast.AggregateFuncExpr{F: $1, Args: []ast.ExprNode{$3.(ast.ExprNode), $5.(ast.ExprNode)}}
+	}
+|	"APPROX_COUNT_DISTINCT" '(' ExpressionList ')'
+	{
+		$$ = &ast.AggregateFuncExpr{F: ast.AggFuncApproxCountDistinct, Args: $3.([]ast.ExprNode)}
+	}
+|	"APPROX_PERCENTILE" '(' Expression ',' Expression ')'
+	{
+		$$ = &ast.AggregateFuncExpr{F: ast.AggFuncApproxPercentile, Args: []ast.ExprNode{$3.(ast.ExprNode), $5.(ast.ExprNode)}}
+	}
+|	FunctionNameStddevPop '(' Expression ')'
+	{
+		$$ = &ast.AggregateFuncExpr{F: ast.AggFuncStddevPop, Args: []ast.ExprNode{$3.(ast.ExprNode)}}
+	}
+|	"STDDEV_SAMP" '(' Expression ')'
+	{
+		$$ = &ast.AggregateFuncExpr{F: $1, Args: []ast.ExprNode{$3.(ast.ExprNode)}}
+	}
+|	FunctionNameVarPop '(' Expression ')'
+	{
+		$$ = &ast.AggregateFuncExpr{F: ast.AggFuncVarPop, Args: []ast.ExprNode{$3.(ast.ExprNode)}}
+	}
+|	"VAR_SAMP" '(' Expression ')'
+	{
+		$$ = &ast.AggregateFuncExpr{F: $1, Args: []ast.ExprNode{$3.(ast.ExprNode)}}
+	}
+
+FunctionNameStddevPop:
+	"STD"
+|	"STDDEV"
+|	"STDDEV_POP"
+
+FunctionNameVarPop:
+	"VARIANCE"
+|	"VAR_POP"
 
 FuncDatetimePrec:
 	{
@@ -4543,10 +4953,13 @@ TableRef:
 	}
 
 TableFactor:
-	TableName TableAsNameOpt IndexHintListOpt
+	TableName TableAsNameOpt IndexHintListOpt TableSampleOpt
 	{
 		tn := $1.(*ast.TableName)
 		tn.IndexHints = $3.([]*ast.IndexHint)
+		if $4 != nil {
+			tn.TableSample = $4.(*ast.TableSample)
+		}
 		$$ = &ast.TableSource{Source: tn, AsName: $2.(model.CIStr)}
 	}
 |	'(' SelectStmt ')' TableAsName
@@ -4661,6 +5074,19 @@ IndexHintListOpt:
 		$$ = $1
 	}
 
+TableSampleOpt:
+	{
+		$$ = nil
+	}
+|	"TABLESAMPLE" "REGIONS" '(' ')'
+	{
+		$$ = &ast.TableSample{Method: ast.SampleRegions}
+	}
+|	"TABLESAMPLE" "BERNOULLI" '(' NumLiteral ')'
+	{
+		$$ = &ast.TableSample{Method: ast.SampleBernoulli, Percent: getFloat64FromNUM($4)}
+	}
+
 JoinTable:
 	/* Use %prec to evaluate production TableRef before cross join */
 	TableRef CrossOpt TableRef %prec tableRefPriority
@@ -4827,7 +5253,7 @@ SelectStmtCalcFoundRows:
 SelectStmtSQLCache:
 	%prec lowerThanSQLCache
 	{
-		$$ = false
+		$$ = true
 	}
 |	"SQL_CACHE"
 	{
@@ -4978,6 +5404,10 @@ SetStmt:
 	{
 		$$ = &ast.SetStmt{Variables: $4.([]*ast.VariableAssignment)}
 	}
+|	"SET" "SESSION_STATES" stringLit
+	{
+		$$ = &ast.SetSessionStatesStmt{States: $3}
+	}
 
 TransactionChars:
 	TransactionChar
@@ -5216,6 +5646,125 @@ AdminStmt:
 			Tables: $4.([]*ast.TableName),
 		}
 	}
+|	"ADMIN" "CHECKSUM" "TABLE" TableNameList
+	{
+		$$ = &ast.AdminStmt{
+			Tp:	ast.AdminChecksumTable,
+			Tables:	$4.([]*ast.TableName),
+		}
+	}
+|	"ADMIN" "CHECK" "INDEX" TableName Identifier
+	{
+		$$ = &ast.AdminStmt{
+			Tp:	ast.AdminCheckIndex,
+			Tables:	[]*ast.TableName{$4.(*ast.TableName)},
+			Index:	string($5),
+		}
+	}
+|	"ADMIN" "CHECK" "INDEX" TableName Identifier HandleRangeList
+	{
+		$$ = &ast.AdminStmt{
+			Tp:		ast.AdminCheckIndex,
+			Tables:		[]*ast.TableName{$4.(*ast.TableName)},
+			Index:		string($5),
+			HandleRanges:	$6.([]ast.HandleRange),
+		}
+	}
+|	"ADMIN" "REPAIR" "INDEX" TableName Identifier
+	{
+		$$ = &ast.AdminStmt{
+			Tp:	ast.AdminRepairIndex,
+			Tables:	[]*ast.TableName{$4.(*ast.TableName)},
+			Index:	string($5),
+		}
+	}
+|	"ADMIN" "REPAIR" "INDEX" TableName Identifier HandleRangeList
+	{
+		$$ = &ast.AdminStmt{
+			Tp:		ast.AdminRepairIndex,
+			Tables:		[]*ast.TableName{$4.(*ast.TableName)},
+			Index:		string($5),
+			HandleRanges:	$6.([]ast.HandleRange),
+		}
+	}
+|	"ADMIN" "ENABLE" "FAILPOINT" stringLit eq stringLit
+	{
+		$$ = &ast.AdminStmt{
+			Tp:		ast.AdminEnableFailpoint,
+			Failpoint:	$4,
+			FailpointTerms:	$6,
+		}
+	}
+|	"ADMIN" "DISABLE" "FAILPOINT" stringLit
+	{
+		$$ = &ast.AdminStmt{
+			Tp:		ast.AdminDisableFailpoint,
+			Failpoint:	$4,
+		}
+	}
+|	"ADMIN" "SHOW" "FAILPOINTS"
+	{
+		$$ = &ast.AdminStmt{Tp: ast.AdminShowFailpoints}
+	}
+|	"ADMIN" "DUMP" "DATABASE" DBName "TO" stringLit
+	{
+		$$ = &ast.AdminStmt{
+			Tp:		ast.AdminDumpDatabase,
+			DBName:		$4.(string),
+			DumpPath:	$6,
+		}
+	}
+|	"ADMIN" "CALIBRATE"
+	{
+		$$ = &ast.AdminStmt{Tp: ast.AdminCalibrate}
+	}
+|	"ADMIN" "CLEANUP" "TABLE" "LOCK" TableNameList
+	{
+		$$ = &ast.AdminStmt{
+			Tp:	ast.AdminCleanupTableLock,
+			Tables:	$5.([]*ast.TableName),
+		}
+	}
+|	"ADMIN" "PLUGINS" "ENABLE" PluginNameList
+	{
+		$$ = &ast.AdminStmt{
+			Tp:		ast.AdminPluginEnable,
+			Plugins:	$4.([]string),
+		}
+	}
+|	"ADMIN" "PLUGINS" "DISABLE" PluginNameList
+	{
+		$$ = &ast.AdminStmt{
+			Tp:		ast.AdminPluginDisable,
+			Plugins:	$4.([]string),
+		}
+	}
+
+PluginNameList:
+	Identifier
+	{
+		$$ = []string{$1}
+	}
+|	PluginNameList ',' Identifier
+	{
+		$$ = append($1.([]string), $3)
+	}
+
+HandleRangeList:
+	HandleRange
+	{
+		$$ = []ast.HandleRange{$1.(ast.HandleRange)}
+	}
+|	HandleRangeList ',' HandleRange
+	{
+		$$ = append($1.([]ast.HandleRange), $3.(ast.HandleRange))
+	}
+
+HandleRange:
+	'(' NUM ',' NUM ')'
+	{
+		$$ = ast.HandleRange{Begin: int64(getUint64FromNUM($2)), End: int64(getUint64FromNUM($4))}
+	}
 
 /****************************Show Statement*******************************/
 ShowStmt:
@@ -5258,6 +5807,30 @@ ShowStmt:
 			User:	$4.(string),
 		}
 	}
+|	"SHOW" "WARNINGS" SelectStmtLimit
+	{
+		stmt := &ast.ShowStmt{Tp: ast.ShowWarnings}
+		if $3 != nil {
+			stmt.Limit = $3.(*ast.Limit)
+		}
+		$$ = stmt
+	}
+|	"SHOW" "ERRORS" SelectStmtLimit
+	{
+		stmt := &ast.ShowStmt{Tp: ast.ShowErrors}
+		if $3 != nil {
+			stmt.Limit = $3.(*ast.Limit)
+		}
+		$$ = stmt
+	}
+|	"SHOW" "COUNT" '(' '*' ')' "WARNINGS"
+	{
+		$$ = &ast.ShowStmt{Tp: ast.ShowWarnings, CountWarningsOrErrors: true}
+	}
+|	"SHOW" "COUNT" '(' '*' ')' "ERRORS"
+	{
+		$$ = &ast.ShowStmt{Tp: ast.ShowErrors, CountWarningsOrErrors: true}
+	}
 |	"SHOW" "PROCESSLIST"
 	{
 		$$ = &ast.ShowStmt{
@@ -5381,10 +5954,6 @@ ShowTargetFilterable:
 			Full:	$1.(bool),
 		}
 	}
-|	"WARNINGS"
-	{
-		$$ = &ast.ShowStmt{Tp: ast.ShowWarnings}
-	}
 |	GlobalScope "VARIABLES"
 	{
 		$$ = &ast.ShowStmt{
@@ -5435,6 +6004,17 @@ ShowTargetFilterable:
         	DBName:	$2.(string),
        	}
 	}
+|	GlobalBindingOpt "BINDINGS"
+	{
+		$$ = &ast.ShowStmt{
+			Tp:		ast.ShowBindings,
+			GlobalScope:	true,
+		}
+	}
+|	"SESSION_STATES"
+	{
+		$$ = &ast.ShowStmt{Tp: ast.ShowSessionStates}
+	}
 ShowLikeOrWhereOpt:
 	{
 		$$ = nil
@@ -5511,6 +6091,12 @@ FlushOption:
 			Tp: ast.FlushPrivileges,
 		}
 	}
+|	"USER_RESOURCES"
+	{
+		$$ = &ast.FlushStmt{
+			Tp: ast.FlushUserResources,
+		}
+	}
 |	TableOrTables TableNameListOpt WithReadLockOpt
 	{
 		$$ = &ast.FlushStmt{
@@ -5559,6 +6145,7 @@ Statement:
 |	AnalyzeTableStmt
 |	BeginTransactionStmt
 |	BinlogStmt
+|	CallStmt
 |	CommitStmt
 |	DeallocateStmt
 |	DeleteFromStmt
@@ -5568,12 +6155,24 @@ Statement:
 |	CreateIndexStmt
 |	CreateTableStmt
 |	CreateUserStmt
+|	CreateFunctionStmt
+|	CreateProcedureStmt
+|	CreateEventStmt
+|	CreateBindingStmt
+|	CreatePolicyStmt
+|	CreateMaskingPolicyStmt
 |	DoStmt
 |	DropDatabaseStmt
 |	DropIndexStmt
 |	DropTableStmt
 |	DropViewStmt
 |	DropUserStmt
+|	DropFunctionStmt
+|	DropProcedureStmt
+|	DropEventStmt
+|	DropBindingStmt
+|	DropPolicyStmt
+|	DropMaskingPolicyStmt
 |	DropStatsStmt
 |	FlushStmt
 |	GrantStmt
@@ -5589,6 +6188,7 @@ Statement:
 |	UnionStmt
 |	SetStmt
 |	ShowStmt
+|	TraceStmt
 |	TruncateTableStmt
 |	UpdateStmt
 |	UseStmt
@@ -5601,7 +6201,21 @@ Statement:
 |	UnlockTablesStmt
 |	LockTablesStmt
 
-ExplainableStmt:
+TraceStmt:
+	"TRACE" TraceableStmt
+	{
+		$$ = &ast.TraceStmt{Stmt: $2.(ast.StmtNode)}
+	}
+|	"TRACE" "PLAN" TraceableStmt
+	{
+		$$ = &ast.TraceStmt{Stmt: $3.(ast.StmtNode), Plan: true}
+	}
+
+TraceableStmt:
+	SelectStmt
+|	UnionStmt
+
+ExplainableStmt:
 	SelectStmt
 |	DeleteFromStmt
 |	UpdateStmt
@@ -5695,6 +6309,10 @@ TableOption:
 	{
 		$$ = &ast.TableOption{Tp: ast.TableOptionAutoIncrement, UintValue: $3.(uint64)}
 	}
+|	"AUTO_ID_CACHE" EqOpt LengthNum
+	{
+		$$ = &ast.TableOption{Tp: ast.TableOptionAutoIDCache, UintValue: $3.(uint64)}
+	}
 |	"COMMENT" EqOpt stringLit
 	{
 		$$ = &ast.TableOption{Tp: ast.TableOptionComment, StrValue: $3}
@@ -6311,23 +6929,423 @@ CommaOpt:
  *  Account Management Statements
  *  https://dev.mysql.com/doc/refman/5.7/en/account-management-sql.html
  ************************************************************************************/
+/*******************************************************************
+ *
+ *  Create Function Statement
+ *
+ *  Example:
+ *	CREATE FUNCTION add_one(a INT) RETURNS INT LANGUAGE LUA DETERMINISTIC AS 'return a + 1'
+ *******************************************************************/
+CreateFunctionStmt:
+	"CREATE" "FUNCTION" IfNotExists Identifier '(' FuncParamListOpt ')' "RETURNS" Type "LANGUAGE" Identifier DeterministicOpt "AS" stringLit
+	{
+		$$ = &ast.CreateFunctionStmt{
+			IfNotExists:   $3.(bool),
+			Name:          model.NewCIStr($4),
+			Params:        $6.([]*ast.FuncParam),
+			ReturnType:    $9.(*types.FieldType),
+			Language:      strings.ToUpper($11),
+			Deterministic: $12.(bool),
+			Body:          $14,
+		}
+	}
+
+FuncParamListOpt:
+	{
+		$$ = []*ast.FuncParam{}
+	}
+|	FuncParamList
+	{
+		$$ = $1
+	}
+
+FuncParamList:
+	FuncParam
+	{
+		$$ = []*ast.FuncParam{$1.(*ast.FuncParam)}
+	}
+|	FuncParamList ',' FuncParam
+	{
+		$$ = append($1.([]*ast.FuncParam), $3.(*ast.FuncParam))
+	}
+
+FuncParam:
+	Identifier Type
+	{
+		$$ = &ast.FuncParam{Name: $1, Tp: $2.(*types.FieldType)}
+	}
+
+DeterministicOpt:
+	{
+		$$ = false
+	}
+|	"DETERMINISTIC"
+	{
+		$$ = true
+	}
+|	"NOT" "DETERMINISTIC"
+	{
+		$$ = false
+	}
+
+/*******************************************************************
+ *
+ *  Create Procedure Statement
+ *
+ *  Example:
+ *	CREATE PROCEDURE p(IN n INT, OUT s INT)
+ *	BEGIN
+ *		DECLARE i INT DEFAULT 0;
+ *		SET s = 0;
+ *		WHILE i < n DO
+ *			SET i = i + 1, s = s + i;
+ *		END WHILE;
+ *	END
+ *******************************************************************/
+/*******************************************************************
+ *
+ *  Create Event Statement
+ *
+ *  CREATE EVENT [IF NOT EXISTS] event_name
+ *      ON SCHEDULE schedule
+ *      [ON COMPLETION [NOT] PRESERVE]
+ *      [ENABLE | DISABLE]
+ *      [COMMENT 'string']
+ *      DO event_body
+ *
+ *  schedule:
+ *      AT timestamp
+ *    | EVERY interval unit [STARTS timestamp] [ENDS timestamp]
+ *******************************************************************/
+CreateEventStmt:
+	"CREATE" "EVENT" IfNotExists TableName "ON" "SCHEDULE" EventSchedule EventPreserveOpt EventDisabledOpt EventCommentOpt "DO" ProcStmt
+	{
+		body := $12.(ast.StmtNode)
+		// The body is the last part of the statement, so it ends before the lookahead token.
+		body.SetText(parser.src[parser.startOffset(&yyS[yypt]):parser.endOffset(&parser.yylval)])
+		$$ = &ast.CreateEventStmt{
+			IfNotExists: $3.(bool),
+			Name:        $4.(*ast.TableName),
+			Schedule:    $7.(*ast.EventSchedule),
+			Preserve:    $8.(bool),
+			Disabled:    $9.(bool),
+			Comment:     $10,
+			Body:        body,
+		}
+	}
+
+EventSchedule:
+	atKwd Expression
+	{
+		$$ = &ast.EventSchedule{At: $2.(ast.ExprNode)}
+	}
+|	"EVERY" Expression TimeUnit EventStartsOpt EventEndsOpt
+	{
+		schedule := &ast.EventSchedule{Every: $2.(ast.ExprNode), Unit: $3}
+		if $4 != nil {
+			schedule.Starts = $4.(ast.ExprNode)
+		}
+		if $5 != nil {
+			schedule.Ends = $5.(ast.ExprNode)
+		}
+		$$ = schedule
+	}
+
+EventStartsOpt:
+	{
+		$$ = nil
+	}
+|	"STARTS" Expression
+	{
+		$$ = $2
+	}
+
+EventEndsOpt:
+	{
+		$$ = nil
+	}
+|	"ENDS" Expression
+	{
+		$$ = $2
+	}
+
+EventPreserveOpt:
+	{
+		$$ = false
+	}
+|	"ON" "COMPLETION" "PRESERVE"
+	{
+		$$ = true
+	}
+|	"ON" "COMPLETION" "NOT" "PRESERVE"
+	{
+		$$ = false
+	}
+
+EventDisabledOpt:
+	{
+		$$ = false
+	}
+|	"ENABLE"
+	{
+		$$ = false
+	}
+|	"DISABLE"
+	{
+		$$ = true
+	}
+
+EventCommentOpt:
+	{
+		$$ = ""
+	}
+|	"COMMENT" stringLit
+	{
+		$$ = $2
+	}
+
+CreateProcedureStmt:
+	"CREATE" "PROCEDURE" IfNotExists TableName '(' ProcParamListOpt ')' ProcBlock
+	{
+		$$ = &ast.CreateProcedureStmt{
+			IfNotExists: $3.(bool),
+			Name:        $4.(*ast.TableName),
+			Params:      $6.([]*ast.ProcParam),
+			Body:        $8.(*ast.ProcBlockStmt),
+		}
+	}
+
+ProcParamListOpt:
+	{
+		$$ = []*ast.ProcParam{}
+	}
+|	ProcParamList
+	{
+		$$ = $1
+	}
+
+ProcParamList:
+	ProcParam
+	{
+		$$ = []*ast.ProcParam{$1.(*ast.ProcParam)}
+	}
+|	ProcParamList ',' ProcParam
+	{
+		$$ = append($1.([]*ast.ProcParam), $3.(*ast.ProcParam))
+	}
+
+ProcParam:
+	ProcParamMode Identifier Type
+	{
+		$$ = &ast.ProcParam{Mode: $1.(ast.ProcParamMode), Name: $2, Tp: $3.(*types.FieldType)}
+	}
+
+ProcParamMode:
+	{
+		$$ = ast.ProcParamIn
+	}
+|	"IN"
+	{
+		$$ = ast.ProcParamIn
+	}
+|	"OUT"
+	{
+		$$ = ast.ProcParamOut
+	}
+|	"INOUT"
+	{
+		$$ = ast.ProcParamInOut
+	}
+
+ProcBlock:
+	"BEGIN" ProcStmtListOpt "END"
+	{
+		$$ = &ast.ProcBlockStmt{Stmts: $2.([]ast.StmtNode)}
+	}
+
+ProcStmtListOpt:
+	{
+		$$ = []ast.StmtNode{}
+	}
+|	ProcStmtList
+	{
+		$$ = $1
+	}
+
+ProcStmtList:
+	ProcStmt ';'
+	{
+		$$ = []ast.StmtNode{$1.(ast.StmtNode)}
+	}
+|	ProcStmtList ProcStmt ';'
+	{
+		$$ = append($1.([]ast.StmtNode), $2.(ast.StmtNode))
+	}
+
+ProcStmt:
+	ProcBlock
+|	identifier ':' ProcBlock ProcEndLabelOpt
+	{
+		if $4 != "" && !strings.EqualFold($4, $1) {
+			yylex.Errorf("end label %s doesn't match %s", $4, $1)
+			return 1
+		}
+		block := $3.(*ast.ProcBlockStmt)
+		block.Label = $1
+		$$ = block
+	}
+|	"DECLARE" ProcVarNameList Type ProcDefaultOpt
+	{
+		stmt := &ast.ProcDeclareStmt{Names: $2.([]string), Tp: $3.(*types.FieldType)}
+		if $4 != nil {
+			stmt.Default = $4.(ast.ExprNode)
+		}
+		$$ = stmt
+	}
+|	"IF" ProcIfBody "END" "IF"
+	{
+		$$ = $2
+	}
+|	ProcWhileStmt
+|	identifier ':' ProcWhileStmt ProcEndLabelOpt
+	{
+		if $4 != "" && !strings.EqualFold($4, $1) {
+			yylex.Errorf("end label %s doesn't match %s", $4, $1)
+			return 1
+		}
+		stmt := $3.(*ast.ProcWhileStmt)
+		stmt.Label = $1
+		$$ = stmt
+	}
+|	ProcLoopStmt
+|	identifier ':' ProcLoopStmt ProcEndLabelOpt
+	{
+		if $4 != "" && !strings.EqualFold($4, $1) {
+			yylex.Errorf("end label %s doesn't match %s", $4, $1)
+			return 1
+		}
+		stmt := $3.(*ast.ProcLoopStmt)
+		stmt.Label = $1
+		$$ = stmt
+	}
+|	"LEAVE" identifier
+	{
+		$$ = &ast.ProcLeaveStmt{Label: $2}
+	}
+|	"ITERATE" identifier
+	{
+		$$ = &ast.ProcIterateStmt{Label: $2}
+	}
+|	CallStmt
+|	DeleteFromStmt
+|	InsertIntoStmt
+|	ReplaceIntoStmt
+|	SelectStmt
+|	SetStmt
+|	UnionStmt
+|	UpdateStmt
+
+ProcVarNameList:
+	Identifier
+	{
+		$$ = []string{$1}
+	}
+|	ProcVarNameList ',' Identifier
+	{
+		$$ = append($1.([]string), $3)
+	}
+
+ProcDefaultOpt:
+	{
+		$$ = nil
+	}
+|	"DEFAULT" Expression
+	{
+		$$ = $2
+	}
+
+ProcEndLabelOpt:
+	{
+		$$ = ""
+	}
+|	identifier
+	{
+		$$ = $1
+	}
+
+ProcIfBody:
+	Expression "THEN" ProcStmtList ProcElseOpt
+	{
+		stmt := &ast.ProcIfStmt{Cond: $1.(ast.ExprNode), Then: $3.([]ast.StmtNode)}
+		if $4 != nil {
+			stmt.Else = $4.([]ast.StmtNode)
+		}
+		$$ = stmt
+	}
+
+ProcElseOpt:
+	{
+		$$ = nil
+	}
+|	"ELSEIF" ProcIfBody
+	{
+		$$ = []ast.StmtNode{$2.(ast.StmtNode)}
+	}
+|	"ELSE" ProcStmtList
+	{
+		$$ = $2
+	}
+
+ProcWhileStmt:
+	"WHILE" Expression "DO" ProcStmtList "END" "WHILE"
+	{
+		$$ = &ast.ProcWhileStmt{Cond: $2.(ast.ExprNode), Body: $4.([]ast.StmtNode)}
+	}
+
+ProcLoopStmt:
+	"LOOP" ProcStmtList "END" "LOOP"
+	{
+		$$ = &ast.ProcLoopStmt{Body: $2.([]ast.StmtNode)}
+	}
+
+/*******************************************************************
+ *
+ *  Call Statement
+ *
+ *  Example:
+ *	CALL p(10, @s)
+ *******************************************************************/
+CallStmt:
+	"CALL" TableName
+	{
+		$$ = &ast.CallStmt{Name: $2.(*ast.TableName)}
+	}
+|	"CALL" TableName '(' ExpressionListOpt ')'
+	{
+		$$ = &ast.CallStmt{Name: $2.(*ast.TableName), Args: $4.([]ast.ExprNode)}
+	}
+
 CreateUserStmt:
-	"CREATE" "USER" IfNotExists UserSpecList
+	"CREATE" "USER" IfNotExists UserSpecList ResourceOptionListOpt ResourceGroupOpt
 	{
  		// See https://dev.mysql.com/doc/refman/5.7/en/create-user.html
 		$$ = &ast.CreateUserStmt{
 			IfNotExists: $3.(bool),
 			Specs: $4.([]*ast.UserSpec),
+			ResourceOptions: $5.([]*ast.ResourceOption),
+			ResourceGroup: $6.(string),
 		}
 	}
 
 /* See http://dev.mysql.com/doc/refman/5.7/en/alter-user.html */
 AlterUserStmt:
-	"ALTER" "USER" IfExists UserSpecList
+	"ALTER" "USER" IfExists UserSpecList ResourceOptionListOpt ResourceGroupOpt
 	{
 		$$ = &ast.AlterUserStmt{
 			IfExists: $3.(bool),
 			Specs: $4.([]*ast.UserSpec),
+			ResourceOptions: $5.([]*ast.ResourceOption),
+			ResourceGroup: $6.(string),
 		}
 	}
 | 	"ALTER" "USER" IfExists "USER" '(' ')' "IDENTIFIED" "BY" AuthString
@@ -6342,6 +7360,57 @@ AlterUserStmt:
 		}
 	}
 
+ResourceOptionListOpt:
+	{
+		$$ = []*ast.ResourceOption{}
+	}
+|	"WITH" ResourceOptionList
+	{
+		$$ = $2
+	}
+
+ResourceOptionList:
+	ResourceOption
+	{
+		$$ = []*ast.ResourceOption{$1.(*ast.ResourceOption)}
+	}
+|	ResourceOptionList ResourceOption
+	{
+		$$ = append($1.([]*ast.ResourceOption), $2.(*ast.ResourceOption))
+	}
+
+ResourceOption:
+	"MAX_USER_CONNECTIONS" LengthNum
+	{
+		$$ = &ast.ResourceOption{Type: ast.MaxUserConnections, Count: int64($2.(uint64))}
+	}
+|	"MAX_QUERIES_PER_HOUR" LengthNum
+	{
+		$$ = &ast.ResourceOption{Type: ast.MaxQueriesPerHour, Count: int64($2.(uint64))}
+	}
+|	"MAX_UPDATES_PER_HOUR" LengthNum
+	{
+		$$ = &ast.ResourceOption{Type: ast.MaxUpdatesPerHour, Count: int64($2.(uint64))}
+	}
+|	"MAX_CONNECTIONS_PER_HOUR" LengthNum
+	{
+		$$ = &ast.ResourceOption{Type: ast.MaxConnectionsPerHour, Count: int64($2.(uint64))}
+	}
+
+/* The resource group name "default" removes the user from its resource group. */
+ResourceGroupOpt:
+	{
+		$$ = ""
+	}
+|	"RESOURCE" "GROUP" Identifier
+	{
+		$$ = $3
+	}
+|	"RESOURCE" "GROUP" "DEFAULT"
+	{
+		$$ = "default"
+	}
+
 UserSpec:
 	Username AuthOption
 	{
@@ -6511,6 +7580,10 @@ PrivType:
 	{
 		$$ = mysql.ReferencesPriv
 	}
+|	"USAGE"
+	{
+		$$ = mysql.UsagePriv
+	}
 
 ObjectType:
 	{
@@ -6698,11 +7771,16 @@ LinesTerminated:
  *********************************************************************/
 
 UnlockTablesStmt:
-	"UNLOCK" TablesTerminalSym {}
+	"UNLOCK" TablesTerminalSym
+	{
+		$$ = &ast.UnlockTablesStmt{}
+	}
 
 LockTablesStmt:
 	"LOCK" TablesTerminalSym TableLockList
-	{}
+	{
+		$$ = &ast.LockTablesStmt{TableLocks: $3.([]ast.TableLock)}
+	}
 
 TablesTerminalSym:
 	"TABLES"
@@ -6710,15 +7788,36 @@ TablesTerminalSym:
 
 TableLock:
 	TableName LockType
+	{
+		$$ = ast.TableLock{
+			Table:	$1.(*ast.TableName),
+			Type:	$2.(model.TableLockType),
+		}
+	}
 
 LockType:
 	"READ"
+	{
+		$$ = model.TableLockRead
+	}
 |	"READ" "LOCAL"
+	{
+		$$ = model.TableLockRead
+	}
 |	"WRITE"
+	{
+		$$ = model.TableLockWrite
+	}
 
 TableLockList:
 	TableLock
+	{
+		$$ = []ast.TableLock{$1.(ast.TableLock)}
+	}
 |	TableLockList ',' TableLock
+	{
+		$$ = append($1.([]ast.TableLock), $3.(ast.TableLock))
+	}
 
 
 /********************************************************************
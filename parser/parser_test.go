@@ -21,6 +21,7 @@ import (
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/util/charset"
 	"github.com/pingcap/tidb/util/testleak"
@@ -42,24 +43,24 @@ func (s *testParserSuite) TestSimple(c *C) {
 
 	reservedKws := []string{
 		"add", "all", "alter", "analyze", "and", "as", "asc", "between", "bigint",
-		"binary", "blob", "both", "by", "cascade", "case", "change", "character", "check", "collate",
+		"binary", "blob", "both", "by", "call", "cascade", "case", "change", "character", "check", "collate",
 		"column", "constraint", "convert", "create", "cross", "current_date", "current_time",
 		"current_timestamp", "current_user", "database", "databases", "day_hour", "day_microsecond",
-		"day_minute", "day_second", "decimal", "default", "delete", "desc", "describe",
-		"distinct", "distinctRow", "div", "double", "drop", "dual", "else", "enclosed", "escaped",
+		"day_minute", "day_second", "decimal", "declare", "default", "delete", "desc", "describe",
+		"distinct", "distinctRow", "div", "double", "drop", "dual", "else", "elseif", "enclosed", "escaped",
 		"exists", "explain", "false", "float", "for", "force", "foreign", "from",
 		"fulltext", "grant", "group", "having", "hour_microsecond", "hour_minute",
-		"hour_second", "if", "ignore", "in", "index", "infile", "inner", "insert", "int", "into", "integer",
-		"interval", "is", "join", "key", "keys", "kill", "leading", "left", "like", "limit", "lines", "load",
-		"localtime", "localtimestamp", "lock", "longblob", "longtext", "mediumblob", "maxvalue", "mediumint", "mediumtext",
+		"hour_second", "if", "ignore", "in", "index", "infile", "inner", "inout", "insert", "int", "into", "integer",
+		"interval", "is", "iterate", "join", "key", "keys", "kill", "leading", "leave", "left", "like", "limit", "lines", "load",
+		"localtime", "localtimestamp", "lock", "longblob", "longtext", "loop", "mediumblob", "maxvalue", "mediumint", "mediumtext",
 		"minute_microsecond", "minute_second", "mod", "not", "no_write_to_binlog", "null", "numeric",
-		"on", "option", "or", "order", "outer", "partition", "precision", "primary", "procedure", "range", "read", "real",
+		"on", "option", "or", "order", "out", "outer", "partition", "precision", "primary", "procedure", "range", "read", "real",
 		"references", "regexp", "rename", "repeat", "replace", "revoke", "restrict", "right", "rlike",
 		"schema", "schemas", "second_microsecond", "select", "set", "show", "smallint",
 		"starting", "table", "terminated", "then", "tinyblob", "tinyint", "tinytext", "to",
 		"trailing", "true", "union", "unique", "unlock", "unsigned",
 		"update", "use", "using", "utc_date", "values", "varbinary", "varchar",
-		"when", "where", "write", "xor", "year_month", "zerofill",
+		"when", "where", "while", "write", "xor", "year_month", "zerofill",
 		"generated", "virtual", "stored",
 		// TODO: support the following keywords
 		// "delayed" , "high_priority" , "low_priority", "with",
@@ -84,16 +85,19 @@ func (s *testParserSuite) TestSimple(c *C) {
 		"date", "datediff", "datetime", "deallocate", "do", "from_days", "end", "engine", "engines", "execute", "first", "full",
 		"local", "names", "offset", "password", "prepare", "quick", "rollback", "session", "signed",
 		"start", "global", "tables", "text", "time", "timestamp", "tidb", "transaction", "truncate", "unknown",
-		"value", "warnings", "year", "now", "substr", "substring", "mode", "any", "some", "user", "identified",
+		"value", "warnings", "errors", "year", "now", "substr", "substring", "mode", "any", "some", "user", "identified",
 		"collation", "comment", "avg_row_length", "checksum", "compression", "connection", "key_block_size",
 		"max_rows", "min_rows", "national", "row", "quarter", "escape", "grants", "status", "fields", "triggers",
-		"delay_key_write", "isolation", "partitions", "repeatable", "committed", "uncommitted", "only", "serializable", "level",
+		"delay_key_write", "isolation", "partitions", "failpoint", "failpoints", "repair", "dump", "trace", "plugins", "plan", "calibrate", "cleanup", "deterministic", "language", "returns", "repeatable", "committed", "uncommitted", "only", "serializable", "level",
 		"curtime", "variables", "dayname", "version", "btree", "hash", "row_format", "dynamic", "fixed", "compressed",
 		"compact", "redundant", "sql_no_cache sql_no_cache", "sql_cache sql_cache", "action", "round",
 		"enable", "disable", "reverse", "space", "privileges", "get_lock", "release_lock", "sleep", "no", "greatest", "least",
 		"binlog", "hex", "unhex", "function", "indexes", "from_unixtime", "processlist", "events", "less", "than", "timediff",
 		"ln", "log", "log2", "log10", "timestampdiff", "pi", "quote", "none", "super", "default", "shared", "exclusive",
-		"always", "stats", "stats_meta", "stats_histogram", "stats_buckets", "tidb_version",
+		"always", "stats", "stats_meta", "stats_histogram", "stats_buckets", "tidb_version", "event", "schedule",
+		"every", "starts", "ends", "completion", "preserve", "at", "visible", "invisible",
+		"clustered", "nonclustered", "usage", "max_user_connections",
+		"max_queries_per_hour", "max_updates_per_hour", "max_connections_per_hour", "resource", "user_resources",
 	}
 	for _, kw := range unreservedKws {
 		src := fmt.Sprintf("SELECT %s FROM tbl;", kw)
@@ -204,7 +208,7 @@ func (s *testParserSuite) TestDMLStmt(c *C) {
 		{"INSERT INTO foo VALUES (1 || 2)", true},
 		{"INSERT INTO foo VALUES (1 | 2)", true},
 		{"INSERT INTO foo VALUES (false || true)", true},
-		{"INSERT INTO foo VALUES (bar(5678))", false},
+		{"INSERT INTO foo VALUES (bar(5678))", true},
 		// 20
 		{"INSERT INTO foo VALUES ()", true},
 		{"SELECT * FROM t", true},
@@ -237,7 +241,7 @@ func (s *testParserSuite) TestDMLStmt(c *C) {
 		{"REPLACE INTO foo VALUES (1 || 2)", true},
 		{"REPLACE INTO foo VALUES (1 | 2)", true},
 		{"REPLACE INTO foo VALUES (false || true)", true},
-		{"REPLACE INTO foo VALUES (bar(5678))", false},
+		{"REPLACE INTO foo VALUES (bar(5678))", true},
 		{"REPLACE INTO foo VALUES ()", true},
 		{"REPLACE INTO foo (a,b) VALUES (42,314)", true},
 		{"REPLACE INTO foo (a,b,) VALUES (42,314)", false},
@@ -325,6 +329,25 @@ func (s *testParserSuite) TestDMLStmt(c *C) {
 		// for admin
 		{"admin show ddl;", true},
 		{"admin check table t1, t2;", true},
+		{"admin checksum table t1, t2;", true},
+		{"admin check index t idx;", true},
+		{"admin check index test.t idx (1, 10), (20, 30);", true},
+		{"admin check index t idx (1);", false},
+		{"admin repair index t idx;", true},
+		{"admin repair index t idx (1, 10);", true},
+		{"admin dump database test to '/tmp/dump';", true},
+		{"admin dump database test;", false},
+		{"admin enable failpoint 'tikv/regionMiss' = '2*off->return';", true},
+		{"admin disable failpoint 'tikv/regionMiss';", true},
+		{"admin show failpoints;", true},
+		{"admin enable failpoint 'tikv/regionMiss';", false},
+		{"admin plugins enable audit_log;", true},
+		{"admin plugins disable audit_log, ldap_auth;", true},
+		{"admin plugins enable;", false},
+		{"admin calibrate;", true},
+		{"admin calibrate cpu;", false},
+		{"admin cleanup table lock t1, test.t2;", true},
+		{"admin cleanup table lock;", false},
 
 		// for on duplicate key update
 		{"INSERT INTO t (a,b,c) VALUES (1,2,3),(4,5,6) ON DUPLICATE KEY UPDATE c=VALUES(a)+VALUES(b);", true},
@@ -425,6 +448,16 @@ func (s *testParserSuite) TestDBAStmt(c *C) {
 		// for show stats_buckets
 		{"show stats_buckets", true},
 		{"show stats_buckets where col_name = 'a'", true},
+		// for show warnings and errors
+		{"show warnings", true},
+		{"show warnings limit 10", true},
+		{"show warnings limit 1, 10", true},
+		{"show errors", true},
+		{"show errors limit 10 offset 1", true},
+		{"show count(*) warnings", true},
+		{"show count(*) errors", true},
+		{"show count(a) warnings", false},
+		{"show warnings where Level = 'Note'", false},
 
 		// set
 		// user defined
@@ -1022,10 +1055,27 @@ func (s *testParserSuite) TestBuiltin(c *C) {
 		{`select avg(distinct all c1) from t;`, true},
 		{`select avg(distinctrow all c1) from t;`, true},
 		{`select avg(c2) from t;`, true},
+		{`select bit_and(c1) from t;`, true},
+		{`select bit_and(), bit_and(distinct c1) from t;`, false},
+		{`select bit_or(c1) from t;`, true},
+		{`select bit_or(), bit_or(distinct c1) from t;`, false},
 		{`select bit_xor(c1) from t;`, true},
 		{`select bit_xor(), bit_xor(distinct c1) from t;`, false},
 		{`select bit_xor(), bit_xor(distinctrow c1) from t;`, false},
 		{`select bit_xor(), bit_xor(all c1) from t;`, false},
+		{`select var_pop(c1), variance(c1), var_samp(c1) from t;`, true},
+		{`select std(c1), stddev(c1), stddev_pop(c1), stddev_samp(c1) from t;`, true},
+		{`select var_pop(), var_samp(c1, c2) from t;`, false},
+		{`select stddev(distinct c1) from t;`, false},
+		{`select json_arrayagg(c1), json_objectagg(c1, c2) from t;`, true},
+		{`select json_arrayagg(c1, c2) from t;`, false},
+		{`select json_objectagg(c1) from t;`, false},
+		{`select approx_count_distinct(c1), approx_count_distinct(c1, c2), approx_percentile(c1, 50) from t;`, true},
+		{`select approx_count_distinct() from t;`, false},
+		{`select approx_count_distinct(distinct c1) from t;`, false},
+		{`select approx_percentile(c1) from t;`, false},
+		{`select approx_count_distinct, approx_percentile from t;`, true},
+		{`select variance, std from t;`, true},
 		{`select max(c1,c2) from t;`, false},
 		{`select max(distinct c1) from t;`, true},
 		{`select max(distinctrow c1) from t;`, true},
@@ -1203,6 +1253,9 @@ func (s *testParserSuite) TestDDL(c *C) {
 		{"create table t (c int) STATS_PERSISTENT = default", true},
 		{"create table t (c int) STATS_PERSISTENT = 0", true},
 		{"create table t (c int) STATS_PERSISTENT = 1", true},
+		{"create table t (c int) AUTO_ID_CACHE = 1", true},
+		{"create table t (c int) AUTO_ID_CACHE 100", true},
+		{"create table auto_id_cache (auto_id_cache int)", true},
 		// partition option
 		{"create table t (c int) PARTITION BY HASH (c) PARTITIONS 32;", true},
 		{"create table t (c int) PARTITION BY RANGE (Year(VDate)) (PARTITION p1980 VALUES LESS THAN (1980) ENGINE = MyISAM, PARTITION p1990 VALUES LESS THAN (1990) ENGINE = MyISAM, PARTITION pothers VALUES LESS THAN MAXVALUE ENGINE = MyISAM)", true},
@@ -1351,6 +1404,21 @@ func (s *testParserSuite) TestDDL(c *C) {
 		{"ALTER TABLE db.t RENAME to db1.t1", true},
 		{"ALTER TABLE db.t RENAME db1.t1", true},
 		{"ALTER TABLE t RENAME as t1", true},
+		{"ALTER TABLE t RENAME INDEX a TO b", true},
+		{"ALTER TABLE t RENAME KEY `a` TO `b`", true},
+		{"ALTER TABLE t RENAME INDEX a", false},
+		{"ALTER TABLE t ALTER INDEX a INVISIBLE", true},
+		{"ALTER TABLE t ALTER INDEX a VISIBLE", true},
+		{"ALTER TABLE t ALTER INDEX a", false},
+		{"ALTER TABLE t ADD INDEX a(b) INVISIBLE", true},
+		{"CREATE INDEX a ON t(b) COMMENT 'a' INVISIBLE", true},
+		{"CREATE TABLE t (a int, b int, KEY (a) VISIBLE, UNIQUE KEY (b) INVISIBLE)", true},
+		{"create table invisible_pk (a int, primary key (a) invisible)", true},
+		{"CREATE TABLE t (a varchar(10), b int, PRIMARY KEY (a, b) CLUSTERED)", true},
+		{"CREATE TABLE t (a int PRIMARY KEY NONCLUSTERED, b int)", true},
+		{"CREATE TABLE t (a int KEY CLUSTERED)", true},
+		{"CREATE TABLE t (a int, PRIMARY KEY (a) /*T![clustered_index] NONCLUSTERED */)", true},
+		{"RENAME TABLE t TO t1, db.t2 TO db1.t3", true},
 		{"ALTER TABLE t ALTER COLUMN a SET DEFAULT 1", true},
 		{"ALTER TABLE t ALTER a SET DEFAULT 1", true},
 		{"ALTER TABLE t ALTER COLUMN a SET DEFAULT CURRENT_TIMESTAMP", false},
@@ -1382,6 +1450,12 @@ func (s *testParserSuite) TestDDL(c *C) {
 		{"CREATE INDEX idx ON t (a) USING HASH COMMENT 'foo'", true},
 		{"CREATE INDEX idx USING BTREE ON t (a) USING HASH COMMENT 'foo'", true},
 		{"CREATE INDEX idx USING BTREE ON t (a)", true},
+		{"CREATE INDEX idx ON t ((lower(a)))", true},
+		{"CREATE INDEX idx ON t (a, (a + b) DESC)", true},
+		{"CREATE INDEX idx ON t (a DESC, b ASC, c(10) DESC)", true},
+		{"CREATE INDEX idx ON t (lower(a))", false},
+		{"ALTER TABLE t ADD INDEX ((lower(a)))", true},
+		{"CREATE TABLE t (a varchar(10), INDEX idx ((upper(a))))", true},
 
 		// for rename table statement
 		{"RENAME TABLE t TO t1", true},
@@ -1452,6 +1526,7 @@ func (s *testParserSuite) TestType(c *C) {
 
 		// for bit
 		{"select 0b01, 0b0, b'11', B'11'", true},
+		{"select b'000000000000000000000000000000000000000000000000000000000000000001'", true},
 		{"select 0B01", false},
 		{"select 0b21", false},
 
@@ -1508,6 +1583,14 @@ func (s *testParserSuite) TestPrivilege(c *C) {
 		{`ALTER USER 'root'@'localhost' IDENTIFIED BY 'new-password', 'root'@'127.0.0.1' IDENTIFIED BY PASSWORD 'hashstring'`, true},
 		{`ALTER USER USER() IDENTIFIED BY 'new-password'`, true},
 		{`ALTER USER IF EXISTS USER() IDENTIFIED BY 'new-password'`, true},
+		{`CREATE USER 'u'@'%' IDENTIFIED BY 'pwd' WITH MAX_USER_CONNECTIONS 10`, true},
+		{`ALTER USER 'u'@'%' WITH MAX_USER_CONNECTIONS 0`, true},
+		{`ALTER USER 'u'@'%' WITH MAX_USER_CONNECTIONS -1`, false},
+		{`CREATE USER 'u'@'%' WITH MAX_QUERIES_PER_HOUR 100 MAX_UPDATES_PER_HOUR 10 MAX_CONNECTIONS_PER_HOUR 5`, true},
+		{`CREATE USER 'u'@'%' IDENTIFIED BY 'pwd' RESOURCE GROUP etl`, true},
+		{`ALTER USER 'u'@'%' WITH MAX_QUERIES_PER_HOUR 0 RESOURCE GROUP default`, true},
+		{`ALTER USER 'u'@'%' RESOURCE GROUP`, false},
+		{`FLUSH USER_RESOURCES`, true},
 		{`DROP USER 'root'@'localhost', 'root1'@'localhost'`, true},
 		{`DROP USER IF EXISTS 'root'@'localhost'`, true},
 
@@ -1517,6 +1600,8 @@ func (s *testParserSuite) TestPrivilege(c *C) {
 		{"GRANT SELECT ON db2.invoice TO 'jeffrey'@'localhost';", true},
 		{"GRANT ALL ON *.* TO 'someuser'@'somehost';", true},
 		{"GRANT SELECT, INSERT ON *.* TO 'someuser'@'somehost';", true},
+		{"GRANT USAGE ON *.* TO 'someuser'@'somehost';", true},
+		{"REVOKE GRANT OPTION ON `db\\_%`.* FROM 'someuser'@'%';", true},
 		{"GRANT ALL ON mydb.* TO 'someuser'@'somehost';", true},
 		{"GRANT SELECT, INSERT ON mydb.* TO 'someuser'@'somehost';", true},
 		{"GRANT ALL ON mydb.mytbl TO 'someuser'@'somehost';", true},
@@ -1628,8 +1713,24 @@ func (s *testParserSuite) TestMysqlDump(c *C) {
 		{`show table status like 't'`, true},
 		{`LOCK TABLE t2 WRITE`, true},
 		{`LOCK TABLE t1 WRITE, t3 READ`, true},
+		{`LOCK TABLES t1 READ LOCAL, test.t2 WRITE`, true},
+		{`LOCK TABLES`, false},
 	}
 	s.RunTest(c, table)
+
+	parser := New()
+	stmt, err := parser.ParseOneStmt("lock tables t1 read local, test.t2 write", "", "")
+	c.Assert(err, IsNil)
+	lock := stmt.(*ast.LockTablesStmt)
+	c.Assert(lock.TableLocks, HasLen, 2)
+	c.Assert(lock.TableLocks[0].Table.Name.L, Equals, "t1")
+	c.Assert(lock.TableLocks[0].Type, Equals, model.TableLockRead)
+	c.Assert(lock.TableLocks[1].Table.Schema.L, Equals, "test")
+	c.Assert(lock.TableLocks[1].Type, Equals, model.TableLockWrite)
+	stmt, err = parser.ParseOneStmt("unlock tables", "", "")
+	c.Assert(err, IsNil)
+	_, ok := stmt.(*ast.UnlockTablesStmt)
+	c.Assert(ok, IsTrue)
 }
 
 func (s *testParserSuite) TestIndexHint(c *C) {
@@ -1647,6 +1748,38 @@ func (s *testParserSuite) TestIndexHint(c *C) {
 	s.RunTest(c, table)
 }
 
+func (s *testParserSuite) TestTableSample(c *C) {
+	defer testleak.AfterTest(c)()
+	table := []testCase{
+		{`select * from t tablesample regions()`, true},
+		{`select * from t as t1 use index (idx) tablesample bernoulli(10)`, true},
+		{`select count(*) from t tablesample bernoulli(0.5) where a > 1`, true},
+		{`select * from t1 tablesample regions(), t2 tablesample bernoulli(1e1)`, true},
+		{`select * from t tablesample regions(10)`, false},
+		{`select * from t tablesample bernoulli()`, false},
+		{`select * from t tablesample bernoulli(a)`, false},
+		{`select * from t tablesample`, false},
+		// REGIONS and BERNOULLI are not reserved.
+		{`create table regions (bernoulli int)`, true},
+	}
+	s.RunTest(c, table)
+
+	parser := New()
+	stmt, err := parser.ParseOneStmt("select * from t tablesample bernoulli(12.5)", "", "")
+	c.Assert(err, IsNil)
+	tn := stmt.(*ast.SelectStmt).From.TableRefs.Left.(*ast.TableSource).Source.(*ast.TableName)
+	c.Assert(tn.TableSample.Method, Equals, ast.SampleBernoulli)
+	c.Assert(tn.TableSample.Percent, Equals, 12.5)
+	stmt, err = parser.ParseOneStmt("select * from t tablesample regions()", "", "")
+	c.Assert(err, IsNil)
+	tn = stmt.(*ast.SelectStmt).From.TableRefs.Left.(*ast.TableSource).Source.(*ast.TableName)
+	c.Assert(tn.TableSample.Method, Equals, ast.SampleRegions)
+	stmt, err = parser.ParseOneStmt("select * from t", "", "")
+	c.Assert(err, IsNil)
+	tn = stmt.(*ast.SelectStmt).From.TableRefs.Left.(*ast.TableSource).Source.(*ast.TableName)
+	c.Assert(tn.TableSample, IsNil)
+}
+
 func (s *testParserSuite) TestPriority(c *C) {
 	defer testleak.AfterTest(c)()
 	table := []testCase{
@@ -1716,6 +1849,29 @@ func (s *testParserSuite) TestExplain(c *C) {
 		{"explain replace into foo values (1 || 2)", true},
 		{"explain update t set id = id + 1 order by id desc;", true},
 		{"explain select c1 from t1 union (select c2 from t2) limit 1, 1", true},
+		{"explain for connection 42", true},
+		{"desc for connection 1", true},
+		{"explain for connection", false},
+		{"explain for connection a", false},
+	}
+	s.RunTest(c, table)
+
+	stmt, err := New().ParseOneStmt("explain for connection 42", "", "")
+	c.Assert(err, IsNil)
+	c.Assert(stmt.(*ast.ExplainForStmt).ConnectionID, Equals, uint64(42))
+}
+
+func (s *testParserSuite) TestTrace(c *C) {
+	defer testleak.AfterTest(c)()
+	table := []testCase{
+		{"trace select c1 from t1", true},
+		{"trace select c1 from t1 union (select c2 from t2) limit 1, 1", true},
+		{"trace insert into t values (1), (2), (3)", false},
+		{"select trace from t", true},
+		{"trace plan select c1 from t1 where c2 = 1", true},
+		{"trace plan select c1 from t1 union select c2 from t2", true},
+		{"trace plan delete from t1", false},
+		{"select plan from plan", true},
 	}
 	s.RunTest(c, table)
 }
@@ -1895,3 +2051,268 @@ func (s *testParserSuite) TestSetTransaction(c *C) {
 		c.Assert(vars.Value.GetValue(), Equals, t.value)
 	}
 }
+
+func (s *testParserSuite) TestUDF(c *C) {
+	defer testleak.AfterTest(c)()
+	table := []testCase{
+		{"create function f() returns int language lua as 'return 1'", true},
+		{"create function if not exists f(a int, b varchar(10)) returns double language lua deterministic as 'return a'", true},
+		{"create function f(a int) returns int language lua not deterministic as 'return a'", true},
+		{"create function f(a int) returns int language wasm as 'x'", true},
+		{"create function f(a int) returns int as 'return a'", false},
+		{"create function f(a) returns int language lua as 'return a'", false},
+		{"create function f(a int) returns int language lua", false},
+		{"drop function f", true},
+		{"drop function if exists f", true},
+		{"select f(), f(1, a), my_func(b) + 1 from t", true},
+		{"select f(distinct a) from t", false},
+		{"select language, returns, deterministic from t", true},
+	}
+	s.RunTest(c, table)
+
+	parser := New()
+	stmt, err := parser.ParseOneStmt("create function F(a int, b text) returns decimal(10, 2) language lua deterministic as 'return a'", "", "")
+	c.Assert(err, IsNil)
+	create := stmt.(*ast.CreateFunctionStmt)
+	c.Assert(create.Name.L, Equals, "f")
+	c.Assert(create.Params, HasLen, 2)
+	c.Assert(create.Params[1].Name, Equals, "b")
+	c.Assert(create.Params[1].Tp.Tp, Equals, mysql.TypeBlob)
+	c.Assert(create.ReturnType.Tp, Equals, mysql.TypeNewDecimal)
+	c.Assert(create.Language, Equals, "LUA")
+	c.Assert(create.Deterministic, IsTrue)
+	c.Assert(create.Body, Equals, "return a")
+}
+
+func (s *testParserSuite) TestProcedure(c *C) {
+	defer testleak.AfterTest(c)()
+	table := []testCase{
+		{"create procedure p() begin end", true},
+		{"create procedure if not exists db.p(a int, in b int, out c varchar(10), inout d double) begin select a; end", true},
+		{"create procedure p() select 1", false},
+		{"create procedure p(a) begin end", false},
+		{"create procedure p() begin select 1 end", false},
+		{"create procedure p() begin declare a, b int default 1; declare c text; set a = b + 1, @x = a; end", true},
+		{"create procedure p() begin if a > 1 then select 1; elseif a > 0 then select 2; else select 3; end if; end", true},
+		{"create procedure p() begin if a then end if; end", false},
+		{"create procedure p() begin while a < 10 do set a = a + 1; end while; end", true},
+		{"create procedure p() begin l: while a < 10 do iterate l; end while l; end", true},
+		{"create procedure p() begin l: loop leave l; end loop; end", true},
+		{"create procedure p() begin l: loop leave l; end loop m; end", false},
+		{"create procedure p() b: begin c: begin leave b; end c; end", false},
+		{"create procedure p() begin b: begin leave b; end b; end", true},
+		{"create procedure p() begin insert into t values (a); update t set c = a; delete from t where c = a; replace into t values (1); end", true},
+		{"create procedure p() begin select 1 union select 2; call q(a); end", true},
+		{"create procedure p() begin create table t (a int); end", false},
+		{"call p", true},
+		{"call db.p()", true},
+		{"call p(1, @a, a + 1)", true},
+		{"drop procedure p", true},
+		{"drop procedure if exists db.p", true},
+	}
+	s.RunTest(c, table)
+
+	parser := New()
+	src := "create procedure P(n int, out s int) begin declare i int default 0; set s = 0; l: while i < n do set i = i + 1; if i = 3 then iterate l; elseif i > 5 then leave l; end if; set s = s + i; end while l; end"
+	stmt, err := parser.ParseOneStmt(src, "", "")
+	c.Assert(err, IsNil)
+	create := stmt.(*ast.CreateProcedureStmt)
+	c.Assert(create.Name.Name.L, Equals, "p")
+	c.Assert(create.Params, HasLen, 2)
+	c.Assert(create.Params[0].Mode, Equals, ast.ProcParamIn)
+	c.Assert(create.Params[1].Mode, Equals, ast.ProcParamOut)
+	c.Assert(create.Params[1].Tp.Tp, Equals, mysql.TypeLong)
+	c.Assert(create.Body.Stmts, HasLen, 3)
+	declare := create.Body.Stmts[0].(*ast.ProcDeclareStmt)
+	c.Assert(declare.Names, DeepEquals, []string{"i"})
+	c.Assert(declare.Default, NotNil)
+	while := create.Body.Stmts[2].(*ast.ProcWhileStmt)
+	c.Assert(while.Label, Equals, "l")
+	c.Assert(while.Body, HasLen, 3)
+	ifStmt := while.Body[1].(*ast.ProcIfStmt)
+	c.Assert(ifStmt.Then[0].(*ast.ProcIterateStmt).Label, Equals, "l")
+	c.Assert(ifStmt.Else[0].(*ast.ProcIfStmt).Then[0].(*ast.ProcLeaveStmt).Label, Equals, "l")
+
+	stmts, err := parser.Parse(src+"; call p(10, @s)", "", "")
+	c.Assert(err, IsNil)
+	c.Assert(stmts, HasLen, 2)
+	c.Assert(stmts[0].Text(), Equals, src+";")
+	call := stmts[1].(*ast.CallStmt)
+	c.Assert(call.Args, HasLen, 2)
+}
+
+func (s *testParserSuite) TestEvent(c *C) {
+	defer testleak.AfterTest(c)()
+	table := []testCase{
+		{"create event e on schedule at '2017-10-01 00:00:00' do select 1", true},
+		{"create event if not exists db.e on schedule at date_add(now(), interval 1 hour) do insert into t values (1)", true},
+		{"create event e on schedule every 1 day do delete from t", true},
+		{"create event e on schedule every 10 minute starts now() ends '2018-01-01' on completion preserve disable comment 'c' do update t set a = 1", true},
+		{"create event e on schedule every 1 hour on completion not preserve enable do begin declare a int default 1; set a = a + 1; end", true},
+		{"create event e on schedule every 1 day do call p()", true},
+		{"create event e on schedule every 1 do select 1", false},
+		{"create event e do select 1", false},
+		{"create event e on schedule at now() comment 'c' enable do select 1", false},
+		{"create event e on schedule at now() do create table t (a int)", false},
+		{"drop event e", true},
+		{"drop event if exists db.e", true},
+	}
+	s.RunTest(c, table)
+
+	parser := New()
+	src := "create event E on schedule every 2 hour starts '2017-01-01' on completion preserve comment 'cleanup' do begin delete from t; end"
+	stmts, err := parser.Parse(src+" ;select 1", "", "")
+	c.Assert(err, IsNil)
+	c.Assert(stmts, HasLen, 2)
+	create := stmts[0].(*ast.CreateEventStmt)
+	c.Assert(create.Name.Name.L, Equals, "e")
+	c.Assert(create.Schedule.At, IsNil)
+	c.Assert(create.Schedule.Unit, Equals, "HOUR")
+	c.Assert(create.Schedule.Starts, NotNil)
+	c.Assert(create.Schedule.Ends, IsNil)
+	c.Assert(create.Preserve, IsTrue)
+	c.Assert(create.Disabled, IsFalse)
+	c.Assert(create.Comment, Equals, "cleanup")
+	c.Assert(create.Body.Text(), Equals, "begin delete from t; end")
+
+	stmt, err := parser.ParseOneStmt("create event e on schedule at now() disable do select 1", "", "")
+	c.Assert(err, IsNil)
+	create = stmt.(*ast.CreateEventStmt)
+	c.Assert(create.Schedule.At, NotNil)
+	c.Assert(create.Preserve, IsFalse)
+	c.Assert(create.Disabled, IsTrue)
+	c.Assert(create.Body.Text(), Equals, "select 1")
+}
+
+func (s *testParserSuite) TestBinding(c *C) {
+	defer testleak.AfterTest(c)()
+	table := []testCase{
+		{"create binding for select * from t where a = 1 using select * from t use index(idx) where a = 1", true},
+		{"create global binding for select * from t using select /*+ TIDB_INLJ(t) */ * from t", true},
+		{"create binding for select a from t union select a from t1 using select a from t use index(a) union select a from t1", true},
+		{"create session binding for select * from t using select * from t", false},
+		{"create binding for select * from t", false},
+		{"create binding for insert into t values (1) using insert into t values (1)", false},
+		{"drop binding for select * from t where a = 1", true},
+		{"drop global binding for select * from t", true},
+		{"show bindings", true},
+		{"show global bindings", true},
+		{"show global bindings where original_sql like '%t%'", true},
+		{"create table binding (bindings int)", true},
+	}
+	s.RunTest(c, table)
+
+	parser := New()
+	stmts, err := parser.Parse("create binding for select * from t where a = 1 using select * from t use index(a) where a = 1 ; select 1", "", "")
+	c.Assert(err, IsNil)
+	c.Assert(stmts, HasLen, 2)
+	create := stmts[0].(*ast.CreateBindingStmt)
+	c.Assert(create.OriginStmt.Text(), Equals, "select * from t where a = 1")
+	c.Assert(create.HintedStmt.Text(), Equals, "select * from t use index(a) where a = 1")
+
+	stmt, err := parser.ParseOneStmt("drop binding for select * from t", "", "")
+	c.Assert(err, IsNil)
+	c.Assert(stmt.(*ast.DropBindingStmt).OriginStmt.Text(), Equals, "select * from t")
+
+	stmt, err = parser.ParseOneStmt("explain select * from t where a = 1", "", "")
+	c.Assert(err, IsNil)
+	c.Assert(stmt.(*ast.ExplainStmt).Stmt.Text(), Equals, "select * from t where a = 1")
+}
+
+func (s *testParserSuite) TestPolicy(c *C) {
+	defer testleak.AfterTest(c)()
+	table := []testCase{
+		{"create policy p on t using (tenant = current_user())", true},
+		{"create policy if not exists p on test.t using (a > 1 and b in (1, 2))", true},
+		{"create policy p on t using a > 1", false},
+		{"create policy p on t", false},
+		{"drop policy p on t", true},
+		{"drop policy if exists p on test.t", true},
+		{"drop policy p", false},
+		{"create table policy (policy int)", true},
+	}
+	s.RunTest(c, table)
+
+	parser := New()
+	stmt, err := parser.ParseOneStmt("create policy tenant_only on test.t using ( tenant = substring_index(current_user(), '@', 1) )", "", "")
+	c.Assert(err, IsNil)
+	create := stmt.(*ast.CreatePolicyStmt)
+	c.Assert(create.Name.O, Equals, "tenant_only")
+	c.Assert(create.Table.Schema.O, Equals, "test")
+	c.Assert(create.Table.Name.O, Equals, "t")
+	c.Assert(create.Predicate.Text(), Equals, "tenant = substring_index(current_user(), '@', 1)")
+}
+
+func (s *testParserSuite) TestMaskingPolicy(c *C) {
+	defer testleak.AfterTest(c)()
+	table := []testCase{
+		{"create masking policy m on t (ssn) using (mask_ssn(ssn))", true},
+		{"create masking policy if not exists m on test.t (email) using (mask_email(email))", true},
+		{"create masking policy m on t (a, b) using (mask_inner(a, 1, 1))", false},
+		{"create masking policy m on t using (mask_ssn(ssn))", false},
+		{"drop masking policy m on t", true},
+		{"drop masking policy if exists m on test.t", true},
+		{"create table masking (masking int)", true},
+	}
+	s.RunTest(c, table)
+
+	parser := New()
+	stmt, err := parser.ParseOneStmt("create masking policy m on test.t (phone) using ( mask_outer(phone, 3, 2, '*') )", "", "")
+	c.Assert(err, IsNil)
+	create := stmt.(*ast.CreateMaskingPolicyStmt)
+	c.Assert(create.Name.O, Equals, "m")
+	c.Assert(create.Table.Schema.O, Equals, "test")
+	c.Assert(create.Table.Name.O, Equals, "t")
+	c.Assert(create.Column.O, Equals, "phone")
+	c.Assert(create.MaskExpr.Text(), Equals, "mask_outer(phone, 3, 2, '*')")
+}
+
+func (s *testParserSuite) TestGroupingSets(c *C) {
+	defer testleak.AfterTest(c)()
+	table := []testCase{
+		{"select a, b, sum(c) from t group by a, b with rollup", true},
+		{"select a, sum(c) from t group by a desc with rollup order by a", true},
+		{"select a, b, sum(c) from t group by grouping sets ((a, b), (a), ())", true},
+		{"select a, grouping(a), grouping(a, b) from t group by a, b with rollup having grouping(a) = 0", true},
+		{"select a from t group by grouping sets (a, b)", false},
+		{"select a from t group by grouping sets ()", false},
+		{"select a from t group by with rollup", false},
+		{"select grouping() from t group by a with rollup", false},
+		{"select rollup, sets from t", true},
+		{"create table rollup (sets int)", true},
+	}
+	s.RunTest(c, table)
+
+	parser := New()
+	stmt, err := parser.ParseOneStmt("select a from t group by grouping sets ((a, b), (c), ())", "", "")
+	c.Assert(err, IsNil)
+	gby := stmt.(*ast.SelectStmt).GroupBy
+	c.Assert(gby.Rollup, IsFalse)
+	c.Assert(gby.Items, HasLen, 3)
+	c.Assert(gby.GroupingSets, DeepEquals, [][]int{{0, 1}, {2}, {}})
+
+	stmt, err = parser.ParseOneStmt("select a from t group by a, b with rollup", "", "")
+	c.Assert(err, IsNil)
+	gby = stmt.(*ast.SelectStmt).GroupBy
+	c.Assert(gby.Rollup, IsTrue)
+	c.Assert(gby.Items, HasLen, 2)
+	c.Assert(gby.GroupingSets, IsNil)
+}
+
+func (s *testParserSuite) TestSessionStates(c *C) {
+	defer testleak.AfterTest(c)()
+	table := []testCase{
+		{"show session_states", true},
+		{"show session_states where session_states like '%a%'", true},
+		{`set session_states '{"current_db":"test"}'`, true},
+		{"set session_states", false},
+		{"set session_states = 'a'", true},
+		{"create table session_states (session_states int)", true},
+	}
+	s.RunTest(c, table)
+
+	parser := New()
+	stmt, err := parser.ParseOneStmt(`set session_states '{"current_db":"test"}'`, "", "")
+	c.Assert(err, IsNil)
+	c.Assert(stmt.(*ast.SetSessionStatesStmt).States, Equals, `{"current_db":"test"}`)
+}
@@ -231,3 +231,18 @@ func getUint64FromNUM(num interface{}) uint64 {
 	}
 	return 0
 }
+
+func getFloat64FromNUM(num interface{}) float64 {
+	switch v := num.(type) {
+	case int64:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case float64:
+		return v
+	case *types.MyDecimal:
+		f, _ := v.ToFloat64()
+		return f
+	}
+	return 0
+}
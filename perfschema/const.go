@@ -36,6 +36,8 @@ const (
 	TableStagesCurrent          = "EVENTS_STAGES_CURRENT"
 	TableStagesHistory          = "EVENTS_STAGES_HISTORY"
 	TableStagesHistoryLong      = "EVENTS_STAGES_HISTORY_LONG"
+	TableThreads                = "THREADS"
+	TableSessionConnectAttrs    = "SESSION_CONNECT_ATTRS"
 )
 
 // PerfSchemaTables is a shortcut to involve all table names.
@@ -55,6 +57,8 @@ var PerfSchemaTables = []string{
 	TableStagesCurrent,
 	TableStagesHistory,
 	TableStagesHistoryLong,
+	TableThreads,
+	TableSessionConnectAttrs,
 }
 
 // ColumnSetupActors contains the column name definitions for table setup_actors, same as MySQL.
@@ -690,3 +694,57 @@ var ColumnStagesHistoryLong = []string{
 	"NESTING_EVENT_ID",
 	"NESTING_EVENT_TYPE",
 }
+
+// ColumnThreads contains the column name definitions for table threads, same as MySQL.
+//
+// CREATE TABLE if not exists performance_schema.threads (
+// 		THREAD_ID			BIGINT(20) UNSIGNED NOT NULL,
+// 		NAME				VARCHAR(128) NOT NULL,
+// 		TYPE				VARCHAR(10) NOT NULL,
+// 		PROCESSLIST_ID		BIGINT(20) UNSIGNED,
+// 		PROCESSLIST_USER	VARCHAR(32),
+// 		PROCESSLIST_HOST	VARCHAR(60),
+// 		PROCESSLIST_DB		VARCHAR(64),
+// 		PROCESSLIST_COMMAND	VARCHAR(16),
+// 		PROCESSLIST_TIME	BIGINT(20),
+// 		PROCESSLIST_STATE	VARCHAR(64),
+// 		PROCESSLIST_INFO	LONGTEXT,
+// 		PARENT_THREAD_ID	BIGINT(20) UNSIGNED,
+// 		ROLE				VARCHAR(64),
+// 		INSTRUMENTED		ENUM('YES','NO') NOT NULL,
+// 		HISTORY				ENUM('YES','NO') NOT NULL,
+// 		CONNECTION_TYPE		VARCHAR(16),
+// 		THREAD_OS_ID		BIGINT(20) UNSIGNED);
+var ColumnThreads = []string{
+	"THREAD_ID",
+	"NAME",
+	"TYPE",
+	"PROCESSLIST_ID",
+	"PROCESSLIST_USER",
+	"PROCESSLIST_HOST",
+	"PROCESSLIST_DB",
+	"PROCESSLIST_COMMAND",
+	"PROCESSLIST_TIME",
+	"PROCESSLIST_STATE",
+	"PROCESSLIST_INFO",
+	"PARENT_THREAD_ID",
+	"ROLE",
+	"INSTRUMENTED",
+	"HISTORY",
+	"CONNECTION_TYPE",
+	"THREAD_OS_ID",
+}
+
+// ColumnSessionConnectAttrs contains the column name definitions for table session_connect_attrs, same as MySQL.
+//
+// CREATE TABLE if not exists performance_schema.session_connect_attrs (
+// 		PROCESSLIST_ID		INT(11) NOT NULL,
+// 		ATTR_NAME			VARCHAR(32) NOT NULL,
+// 		ATTR_VALUE			VARCHAR(1024),
+// 		ORDINAL_POSITION	INT(11));
+var ColumnSessionConnectAttrs = []string{
+	"PROCESSLIST_ID",
+	"ATTR_NAME",
+	"ATTR_VALUE",
+	"ORDINAL_POSITION",
+}
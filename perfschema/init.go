@@ -197,6 +197,33 @@ var stagesCurrentCols = []columnInfo{
 	{mysql.TypeEnum, -1, 0, nil, []string{"TRANSACTION", "STATEMENT", "STAGE"}},
 }
 
+var threadsCols = []columnInfo{
+	{mysql.TypeLonglong, 20, mysql.NotNullFlag | mysql.UnsignedFlag, nil, nil},
+	{mysql.TypeVarchar, 128, mysql.NotNullFlag, nil, nil},
+	{mysql.TypeVarchar, 10, mysql.NotNullFlag, nil, nil},
+	{mysql.TypeLonglong, 20, mysql.UnsignedFlag, nil, nil},
+	{mysql.TypeVarchar, 32, 0, nil, nil},
+	{mysql.TypeVarchar, 60, 0, nil, nil},
+	{mysql.TypeVarchar, 64, 0, nil, nil},
+	{mysql.TypeVarchar, 16, 0, nil, nil},
+	{mysql.TypeLonglong, 20, 0, nil, nil},
+	{mysql.TypeVarchar, 64, 0, nil, nil},
+	{mysql.TypeLongBlob, -1, 0, nil, nil},
+	{mysql.TypeLonglong, 20, mysql.UnsignedFlag, nil, nil},
+	{mysql.TypeVarchar, 64, 0, nil, nil},
+	{mysql.TypeEnum, -1, mysql.NotNullFlag, nil, []string{"YES", "NO"}},
+	{mysql.TypeEnum, -1, mysql.NotNullFlag, nil, []string{"YES", "NO"}},
+	{mysql.TypeVarchar, 16, 0, nil, nil},
+	{mysql.TypeLonglong, 20, mysql.UnsignedFlag, nil, nil},
+}
+
+var sessionConnectAttrsCols = []columnInfo{
+	{mysql.TypeLong, 11, mysql.NotNullFlag, nil, nil},
+	{mysql.TypeVarchar, 32, mysql.NotNullFlag, nil, nil},
+	{mysql.TypeVarchar, 1024, 0, nil, nil},
+	{mysql.TypeLong, 11, 0, nil, nil},
+}
+
 func createMemoryTable(meta *model.TableInfo, alloc autoid.Allocator) (table.Table, error) {
 	tbl, _ := tables.MemoryTableFromMeta(alloc, meta)
 	return tbl, nil
@@ -224,6 +251,10 @@ func (ps *perfSchema) buildTables() error {
 			tbl = createBoundedTable(meta, alloc, currentElemMax)
 		case TableStmtsHistory, TableStmtsHistoryLong, TableTransHistory, TableTransHistoryLong, TableStagesHistory, TableStagesHistoryLong:
 			tbl = createBoundedTable(meta, alloc, historyElemMax)
+		case TableThreads:
+			tbl = createVirtualTable(meta, dataForThreads)
+		case TableSessionConnectAttrs:
+			tbl = createVirtualTable(meta, dataForSessionConnectAttrs)
 		default:
 			var err error
 			tbl, err = createMemoryTable(meta, alloc)
@@ -355,6 +386,8 @@ func (ps *perfSchema) initialize() (err error) {
 		stagesCurrentCols,
 		stagesCurrentCols, // same as above
 		stagesCurrentCols, // same as above
+		threadsCols,
+		sessionConnectAttrsCols,
 	}
 
 	allColNames := [][]string{
@@ -367,12 +400,14 @@ func (ps *perfSchema) initialize() (err error) {
 		ColumnStmtsHistory,
 		ColumnStmtsHistoryLong,
 		ColumnPreparedStmtsInstances,
-		ColumnStmtsCurrent,
-		ColumnStmtsHistory,
-		ColumnStmtsHistoryLong,
+		ColumnTransCurrent,
+		ColumnTransHistory,
+		ColumnTransHistoryLong,
 		ColumnStagesCurrent,
 		ColumnStagesHistory,
 		ColumnStagesHistoryLong,
+		ColumnThreads,
+		ColumnSessionConnectAttrs,
 	}
 
 	// initialize all table, column and result field definitions
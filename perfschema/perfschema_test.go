@@ -118,6 +118,13 @@ func (p *testPerfSchemaSuit) TestInstrument(c *C) {
 	cnt := mustQuery(c, se, "select * from performance_schema.setup_instruments")
 	c.Assert(cnt, Greater, 0)
 
+	cnt = mustQuery(c, se, "select thread_id, state, trx_id from performance_schema.events_transactions_current")
+	c.Assert(cnt, Equals, 0)
+	cnt = mustQuery(c, se, "select thread_id, name, processlist_id from performance_schema.threads")
+	c.Assert(cnt, Equals, 0)
+	cnt = mustQuery(c, se, "select processlist_id, attr_name, attr_value from performance_schema.session_connect_attrs")
+	c.Assert(cnt, Equals, 0)
+
 	mustExec(c, se, "drop database test_instrument_db")
 }
 
@@ -0,0 +1,170 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package perfschema
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/meta/autoid"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/table"
+	"github.com/pingcap/tidb/table/tables"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// IsVirtualTable checks whether the rows of the perfschema table are generated when it's read, these tables
+// can only be iterated by IterRecords.
+func IsVirtualTable(name string) bool {
+	return strings.EqualFold(name, TableThreads) || strings.EqualFold(name, TableSessionConnectAttrs)
+}
+
+// virtualTable is the table whose rows are generated by dataFunc when it's read, e.g. the rows of the table
+// threads are the connections registered in the session manager.
+// The embedded memory table only provides the meta and the columns, it's always empty.
+type virtualTable struct {
+	table.Table
+	dataFunc func(ctx context.Context) [][]types.Datum
+}
+
+func createVirtualTable(meta *model.TableInfo, dataFunc func(ctx context.Context) [][]types.Datum) table.Table {
+	tbl, _ := tables.MemoryTableFromMeta(autoid.NewMemoryAllocator(0), meta)
+	return &virtualTable{Table: tbl, dataFunc: dataFunc}
+}
+
+func dataForThreads(ctx context.Context) [][]types.Datum {
+	sm := ctx.GetSessionManager()
+	if sm == nil {
+		return nil
+	}
+	pl := sm.ShowProcessList()
+	rows := make([][]types.Datum, 0, len(pl))
+	for _, pi := range pl {
+		var t int64
+		var info interface{}
+		if len(pi.Info) != 0 {
+			t = int64(time.Since(pi.Time) / time.Second)
+			info = pi.Info
+		}
+		var db interface{}
+		if len(pi.DB) != 0 {
+			db = pi.DB
+		}
+		record := types.MakeDatums(
+			pi.ID,                             // THREAD_ID
+			"thread/sql/one_connection",       // NAME
+			"FOREGROUND",                      // TYPE
+			pi.ID,                             // PROCESSLIST_ID
+			pi.User,                           // PROCESSLIST_USER
+			pi.Host,                           // PROCESSLIST_HOST
+			db,                                // PROCESSLIST_DB
+			pi.Command,                        // PROCESSLIST_COMMAND
+			t,                                 // PROCESSLIST_TIME
+			fmt.Sprintf("%d", pi.State),       // PROCESSLIST_STATE
+			info,                              // PROCESSLIST_INFO
+			nil,                               // PARENT_THREAD_ID
+			nil,                               // ROLE
+			types.Enum{Name: "YES", Value: 1}, // INSTRUMENTED
+			types.Enum{Name: "YES", Value: 1}, // HISTORY
+			nil,                               // CONNECTION_TYPE
+			nil,                               // THREAD_OS_ID
+		)
+		rows = append(rows, record)
+	}
+	return rows
+}
+
+// dataForSessionConnectAttrs returns the connection attributes sent by the clients in the handshakes, the
+// attributes of a connection are ordered by their names.
+func dataForSessionConnectAttrs(ctx context.Context) [][]types.Datum {
+	sm := ctx.GetSessionManager()
+	if sm == nil {
+		return nil
+	}
+	var rows [][]types.Datum
+	for _, pi := range sm.ShowProcessList() {
+		names := make([]string, 0, len(pi.ConnectAttrs))
+		for name := range pi.ConnectAttrs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for i, name := range names {
+			record := types.MakeDatums(
+				pi.ID,                 // PROCESSLIST_ID
+				name,                  // ATTR_NAME
+				pi.ConnectAttrs[name], // ATTR_VALUE
+				i,                     // ORDINAL_POSITION
+			)
+			rows = append(rows, record)
+		}
+	}
+	return rows
+}
+
+// IterRecords implements table.Table IterRecords interface.
+func (t *virtualTable) IterRecords(ctx context.Context, startKey kv.Key, cols []*table.Column,
+	fn table.RecordIterFunc) error {
+	if len(startKey) != 0 {
+		return table.ErrUnsupportedOp
+	}
+	for i, fullRow := range t.dataFunc(ctx) {
+		row := make([]types.Datum, len(cols))
+		for j, col := range cols {
+			row[j] = fullRow[col.Offset]
+		}
+		more, err := fn(int64(i), row, cols)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if !more {
+			break
+		}
+	}
+	return nil
+}
+
+// RowWithCols implements table.Table RowWithCols interface.
+func (t *virtualTable) RowWithCols(ctx context.Context, h int64, cols []*table.Column) ([]types.Datum, error) {
+	return nil, table.ErrUnsupportedOp
+}
+
+// Row implements table.Table Row interface.
+func (t *virtualTable) Row(ctx context.Context, h int64) ([]types.Datum, error) {
+	return nil, table.ErrUnsupportedOp
+}
+
+// AddRecord implements table.Table AddRecord interface.
+func (t *virtualTable) AddRecord(ctx context.Context, r []types.Datum) (recordID int64, err error) {
+	return 0, table.ErrUnsupportedOp
+}
+
+// UpdateRecord implements table.Table UpdateRecord interface.
+func (t *virtualTable) UpdateRecord(ctx context.Context, h int64, oldData, newData []types.Datum, touched []bool) error {
+	return table.ErrUnsupportedOp
+}
+
+// RemoveRecord implements table.Table RemoveRecord interface.
+func (t *virtualTable) RemoveRecord(ctx context.Context, h int64, r []types.Datum) error {
+	return table.ErrUnsupportedOp
+}
+
+// Seek implements table.Table Seek interface.
+func (t *virtualTable) Seek(ctx context.Context, h int64) (int64, bool, error) {
+	return 0, false, table.ErrUnsupportedOp
+}
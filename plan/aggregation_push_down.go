@@ -14,6 +14,7 @@ package plan
 
 import (
 	"fmt"
+	"math"
 
 	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/context"
@@ -31,15 +32,16 @@ type aggregationOptimizer struct {
 // isDecomposable checks if an aggregate function is decomposable. An aggregation function $F$ is decomposable
 // if there exist aggregation functions F_1 and F_2 such that F(S_1 union all S_2) = F_2(F_1(S_1),F_1(S_2)),
 // where S_1 and S_2 are two sets of values. We call S_1 and S_2 partial groups.
-// It's easy to see that max, min, first row is decomposable, no matter whether it's distinct, but sum(distinct) and
-// count(distinct) is not.
-// Currently we don't support avg and concat.
+// It's easy to see that max, min, first row, bit_and and bit_or is decomposable, no matter whether it's distinct,
+// but sum(distinct) and count(distinct) is not.
+// Currently we don't support avg, concat and bit_xor. The variance, json and approx functions aren't decomposable
+// into the functions of the same names, so they're never pushed down.
 func (a *aggregationOptimizer) isDecomposable(fun expression.AggregationFunction) bool {
 	switch fun.GetName() {
-	case ast.AggFuncAvg, ast.AggFuncGroupConcat:
+	case ast.AggFuncAvg, ast.AggFuncGroupConcat, ast.AggFuncBitXor:
 		// TODO: Support avg push down.
 		return false
-	case ast.AggFuncMax, ast.AggFuncMin, ast.AggFuncFirstRow:
+	case ast.AggFuncMax, ast.AggFuncMin, ast.AggFuncFirstRow, ast.AggFuncBitAnd, ast.AggFuncBitOr:
 		return true
 	case ast.AggFuncSum, ast.AggFuncCount:
 		return !fun.IsDistinct()
@@ -199,15 +201,14 @@ func (a *aggregationOptimizer) allFirstRow(aggFuncs []expression.AggregationFunc
 // tryToPushDownAgg tries to push down an aggregate function into a join path. If all aggFuncs are first row, we won't
 // process it temporarily. If not, We will add additional group by columns and first row functions. We make a new aggregation operator.
 // If the pushed aggregation is grouped by unique key, it's no need to push it down.
+// If the join path is a join too, the new aggregation is pushed across it later, so for the star schema query like
+// "select sum(f.x) from f, d1, d2 where f.d1 = d1.id and f.d2 = d2.id group by d1.y, d2.z", the fact table is
+// aggregated by "f.d1, f.d2" before it's joined with the dimension tables.
 func (a *aggregationOptimizer) tryToPushDownAgg(aggFuncs []expression.AggregationFunction, gbyCols []*expression.Column, join *LogicalJoin, childIdx int) LogicalPlan {
 	child := join.children[childIdx].(LogicalPlan)
 	if a.allFirstRow(aggFuncs) {
 		return child
 	}
-	// If the join is multiway-join, we forbid pushing down.
-	if _, ok := join.children[childIdx].(*LogicalJoin); ok {
-		return child
-	}
 	tmpSchema := expression.NewSchema(gbyCols...)
 	for _, key := range child.Schema().Keys {
 		if tmpSchema.ColumnsIndices(key) != nil {
@@ -316,45 +317,24 @@ func (a *aggregationOptimizer) pushAggCrossUnion(agg *LogicalAggregation, unionS
 }
 
 func (a *aggregationOptimizer) optimize(p LogicalPlan, ctx context.Context, alloc *idAllocator) (LogicalPlan, error) {
-	if !ctx.GetSessionVars().AllowAggPushDown {
-		return p, nil
-	}
 	a.ctx = ctx
 	a.allocator = alloc
-	a.aggPushDown(p)
-	return p, nil
+	return a.aggPushDown(p), nil
 }
 
-// aggPushDown tries to push down aggregate functions to join paths.
+// aggPushDown tries to push down aggregate functions to join paths if tidb_opt_agg_push_down is set. The aggregations
+// grouped by unique keys are always eliminated, e.g. "select distinct a from t" doesn't remove the duplicates if a is
+// the primary key.
 func (a *aggregationOptimizer) aggPushDown(p LogicalPlan) LogicalPlan {
 	if agg, ok := p.(*LogicalAggregation); ok {
 		proj := a.tryToEliminateAggregation(agg)
 		if proj != nil {
 			p = proj
-		} else {
+		} else if a.ctx.GetSessionVars().AllowAggPushDown {
 			child := agg.children[0]
-			if join, ok1 := child.(*LogicalJoin); ok1 && a.checkValidJoin(join) {
-				if valid, leftAggFuncs, rightAggFuncs, leftGbyCols, rightGbyCols := a.splitAggFuncsAndGbyCols(agg, join); valid {
-					var lChild, rChild LogicalPlan
-					// If there exist count or sum functions in left join path, we can't push any
-					// aggregate function into right join path.
-					rightInvalid := a.checkAnyCountAndSum(leftAggFuncs)
-					leftInvalid := a.checkAnyCountAndSum(rightAggFuncs)
-					if rightInvalid {
-						rChild = join.children[1].(LogicalPlan)
-					} else {
-						rChild = a.tryToPushDownAgg(rightAggFuncs, rightGbyCols, join, 1)
-					}
-					if leftInvalid {
-						lChild = join.children[0].(LogicalPlan)
-					} else {
-						lChild = a.tryToPushDownAgg(leftAggFuncs, leftGbyCols, join, 0)
-					}
-					join.SetChildren(lChild, rChild)
-					lChild.SetParents(join)
-					rChild.SetParents(join)
-					join.SetSchema(expression.MergeSchema(lChild.Schema(), rChild.Schema()))
-					join.buildKeyInfo()
+			if join, ok1 := child.(*LogicalJoin); ok1 {
+				// The cascades planner pushes the aggregation across the join if it's cheaper.
+				if !a.ctx.GetSessionVars().EnableCascadesPlanner && a.pushAggAcrossJoin(agg, join) {
 					proj := a.tryToEliminateAggregation(agg)
 					if proj != nil {
 						p = proj
@@ -377,6 +357,8 @@ func (a *aggregationOptimizer) aggPushDown(p LogicalPlan) LogicalPlan {
 				projChild := proj.children[0]
 				agg.SetChildren(projChild)
 				projChild.SetParents(agg)
+				// The aggregation may be pushed across the child of the projection.
+				return a.aggPushDown(agg)
 			} else if union, ok1 := child.(*Union); ok1 {
 				var gbyCols []*expression.Column
 				for _, gbyExpr := range agg.GroupByItems {
@@ -404,6 +386,39 @@ func (a *aggregationOptimizer) aggPushDown(p LogicalPlan) LogicalPlan {
 	return p
 }
 
+// pushAggAcrossJoin pushes the aggregate functions of the aggregation into the children of the join, which is the
+// child of the aggregation. It returns false if they can't be pushed down.
+func (a *aggregationOptimizer) pushAggAcrossJoin(agg *LogicalAggregation, join *LogicalJoin) bool {
+	if !a.checkValidJoin(join) {
+		return false
+	}
+	valid, leftAggFuncs, rightAggFuncs, leftGbyCols, rightGbyCols := a.splitAggFuncsAndGbyCols(agg, join)
+	if !valid {
+		return false
+	}
+	var lChild, rChild LogicalPlan
+	// If there exist count or sum functions in left join path, we can't push any
+	// aggregate function into right join path.
+	rightInvalid := a.checkAnyCountAndSum(leftAggFuncs)
+	leftInvalid := a.checkAnyCountAndSum(rightAggFuncs)
+	if rightInvalid {
+		rChild = join.children[1].(LogicalPlan)
+	} else {
+		rChild = a.tryToPushDownAgg(rightAggFuncs, rightGbyCols, join, 1)
+	}
+	if leftInvalid {
+		lChild = join.children[0].(LogicalPlan)
+	} else {
+		lChild = a.tryToPushDownAgg(leftAggFuncs, leftGbyCols, join, 0)
+	}
+	join.SetChildren(lChild, rChild)
+	lChild.SetParents(join)
+	rChild.SetParents(join)
+	join.SetSchema(expression.MergeSchema(lChild.Schema(), rChild.Schema()))
+	join.buildKeyInfo()
+	return true
+}
+
 // tryToEliminateAggregation will eliminate aggregation grouped by unique key.
 // e.g. select min(b) from t group by a. If a is a unique key, then this sql is equal to `select b from t group by a`.
 // For count(expr), sum(expr), avg(expr), count(distinct expr, [expr...]) we may need to rewrite the expr. Details are shown below.
@@ -471,6 +486,55 @@ func (a *aggregationOptimizer) rewriteSumOrAvg(exprs []expression.Expression) ex
 	}
 }
 
+// rewriteBitFunc rewrites bit_and, bit_or and bit_xor over a single row to ifnull(cast(expr as unsigned), init value),
+// the init value is 18446744073709551615 for bit_and and 0 for the others.
+func (a *aggregationOptimizer) rewriteBitFunc(funcName string, exprs []expression.Expression) expression.Expression {
+	ft := types.NewFieldType(mysql.TypeLonglong)
+	ft.Flag |= mysql.UnsignedFlag
+	innerExpr := expression.NewCastFunc(ft, exprs[0].Clone(), a.ctx)
+	initValue := uint64(0)
+	if funcName == ast.AggFuncBitAnd {
+		initValue = math.MaxUint64
+	}
+	initExpr := &expression.Constant{Value: types.NewUintDatum(initValue), RetType: ft}
+	newExpr, _ := expression.NewFunction(a.ctx, ast.Ifnull, ft, innerExpr, initExpr)
+	return newExpr
+}
+
+// rewriteVarianceFunc rewrites the variance and standard deviation functions over a single row. The population
+// functions are rewritten to if(isnull(expr), NULL, 0), and the sample functions are always NULL.
+func (a *aggregationOptimizer) rewriteVarianceFunc(funcName string, exprs []expression.Expression) expression.Expression {
+	ft := types.NewFieldType(mysql.TypeDouble)
+	nullExpr := &expression.Constant{Value: types.Datum{}, RetType: ft}
+	if funcName == ast.AggFuncVarSamp || funcName == ast.AggFuncStddevSamp {
+		return nullExpr
+	}
+	isNullExpr, _ := expression.NewFunction(a.ctx, ast.IsNull, types.NewFieldType(mysql.TypeTiny), exprs[0].Clone())
+	zeroExpr := &expression.Constant{Value: types.NewFloat64Datum(0), RetType: ft}
+	newExpr, _ := expression.NewFunction(a.ctx, ast.If, ft, isNullExpr, nullExpr, zeroExpr)
+	return newExpr
+}
+
+// rewriteJSONAggFunc rewrites json_arrayagg and json_objectagg over a single row to json_array and json_object.
+func (a *aggregationOptimizer) rewriteJSONAggFunc(funcName string, exprs []expression.Expression) expression.Expression {
+	args := make([]expression.Expression, 0, len(exprs))
+	for _, expr := range exprs {
+		args = append(args, expr.Clone())
+	}
+	name := ast.JSONArray
+	if funcName == ast.AggFuncJSONObjectAgg {
+		name = ast.JSONObject
+	}
+	newExpr, _ := expression.NewFunction(a.ctx, name, types.NewFieldType(mysql.TypeJSON), args...)
+	return newExpr
+}
+
+// rewriteApproxPercentile rewrites approx_percentile over a single row to cast(expr as double).
+func (a *aggregationOptimizer) rewriteApproxPercentile(exprs []expression.Expression) expression.Expression {
+	ft := types.NewFieldType(mysql.TypeDouble)
+	return expression.NewCastFunc(ft, exprs[0].Clone(), a.ctx)
+}
+
 // rewriteExpr will rewrite the aggregate function to expression doesn't contain aggregate function.
 func (a *aggregationOptimizer) rewriteExpr(aggFunc expression.AggregationFunction) expression.Expression {
 	switch aggFunc.GetName() {
@@ -481,6 +545,16 @@ func (a *aggregationOptimizer) rewriteExpr(aggFunc expression.AggregationFunctio
 		return a.rewriteCount(aggFunc.GetArgs())
 	case ast.AggFuncSum, ast.AggFuncAvg:
 		return a.rewriteSumOrAvg(aggFunc.GetArgs())
+	case ast.AggFuncBitAnd, ast.AggFuncBitOr, ast.AggFuncBitXor:
+		return a.rewriteBitFunc(aggFunc.GetName(), aggFunc.GetArgs())
+	case ast.AggFuncVarPop, ast.AggFuncVarSamp, ast.AggFuncStddevPop, ast.AggFuncStddevSamp:
+		return a.rewriteVarianceFunc(aggFunc.GetName(), aggFunc.GetArgs())
+	case ast.AggFuncJSONArrayAgg, ast.AggFuncJSONObjectAgg:
+		return a.rewriteJSONAggFunc(aggFunc.GetName(), aggFunc.GetArgs())
+	case ast.AggFuncApproxCountDistinct:
+		return a.rewriteCount(aggFunc.GetArgs())
+	case ast.AggFuncApproxPercentile:
+		return a.rewriteApproxPercentile(aggFunc.GetArgs())
 	default:
 		// Default we do nothing about expr.
 		return aggFunc.GetArgs()[0].Clone()
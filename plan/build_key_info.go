@@ -166,7 +166,8 @@ func (p *LogicalJoin) buildKeyInfo() {
 
 func (p *DataSource) buildKeyInfo() {
 	p.baseLogicalPlan.buildKeyInfo()
-	indices, _ := availableIndices(p.indexHints, p.tableInfo)
+	// The unique invisible indices still guarantee the uniqueness.
+	indices, _ := availableIndices(p.indexHints, p.tableInfo, true, true)
 	for _, idx := range indices {
 		if !idx.Unique {
 			continue
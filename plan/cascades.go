@@ -0,0 +1,221 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plan
+
+import (
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/expression"
+)
+
+// The cascades planner explores the plans which are equivalent to the logical plan by the transformation rules,
+// and chooses the cheapest one of them. It's used if tidb_enable_cascades_planner is set.
+//
+// The plans are kept in a memo, which is a set of groups. A group is a set of the logically equivalent group
+// expressions, and a group expression is a logical operator whose children are groups, so the memo holds many
+// plans without copying the shared parts. The search works in two phases:
+//
+//  1. Exploration. The transformation rules are applied to the group expressions, a rule adds the new equivalent
+//     expressions to the group of the expression it matches, e.g. "Agg(Join(A, B))" is transformed into
+//     "Agg(Join(Agg(A), B))".
+//  2. Implementation. The children of the expressions are replaced by the groups, then the physical plan of the root
+//     group is built like the plan of a logical plan, a group converts all its expressions to physical plans and
+//     returns the cheapest one for every required property.
+
+// cascadesMaxGroups is the max number of the groups in a memo, the exploration stops when the memo is full.
+const cascadesMaxGroups = 1000
+
+// group is a set of the logically equivalent expressions, they have the same output columns.
+type group struct {
+	exprs []*groupExpr
+	// plan is the logical plan which stands for the group as a child of the expressions.
+	plan LogicalPlan
+	// tasks are the best tasks of the group for the required properties.
+	tasks map[string]task
+	// profileReady and propsReady record the stats profile and the possible properties of the expressions are prepared.
+	profileReady bool
+	propsReady   bool
+}
+
+// hasJoin checks whether there is a join in the group.
+func (g *group) hasJoin() bool {
+	for _, expr := range g.exprs {
+		if _, ok := expr.node.(*LogicalJoin); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// groupExpr is an expression of a group, its node is a logical operator whose children are the child groups.
+type groupExpr struct {
+	node     LogicalPlan
+	children []*group
+	// applied records the transformation rules which have been applied to the expression, so a rule works once.
+	applied map[string]bool
+}
+
+// memo holds the groups of a plan.
+type memo struct {
+	ctx       context.Context
+	allocator *idAllocator
+	groups    []*group
+}
+
+func newMemo(ctx context.Context, allocator *idAllocator) *memo {
+	return &memo{ctx: ctx, allocator: allocator}
+}
+
+// newGroup adds a group of the expression to the memo.
+func (m *memo) newGroup(node LogicalPlan, children ...*group) *group {
+	g := &group{}
+	g.addExpr(node, children...)
+	m.groups = append(m.groups, g)
+	return g
+}
+
+func (g *group) addExpr(node LogicalPlan, children ...*group) *groupExpr {
+	expr := &groupExpr{node: node, children: children, applied: make(map[string]bool)}
+	g.exprs = append(g.exprs, expr)
+	return expr
+}
+
+// insert adds the groups of the plan and its children to the memo, and returns the group of the plan.
+func (m *memo) insert(p LogicalPlan) *group {
+	children := make([]*group, 0, len(p.Children()))
+	for _, child := range p.Children() {
+		children = append(children, m.insert(child.(LogicalPlan)))
+	}
+	return m.newGroup(p, children...)
+}
+
+// explore applies the transformation rules to all the expressions, including the new ones, until no rule can be
+// applied or the memo is full.
+func (m *memo) explore() {
+	for i := 0; i < len(m.groups); i++ {
+		g := m.groups[i]
+		for j := 0; j < len(g.exprs); j++ {
+			expr := g.exprs[j]
+			for _, rule := range transformationRules {
+				if len(m.groups) >= cascadesMaxGroups {
+					return
+				}
+				name := rule.name()
+				if expr.applied[name] {
+					continue
+				}
+				expr.applied[name] = true
+				if rule.match(expr) {
+					rule.apply(m, g, expr)
+				}
+			}
+		}
+	}
+}
+
+// groupPlan returns the logical plan which stands for the group. The plan of a group of one expression is the
+// node of the expression itself.
+func (m *memo) groupPlan(g *group) LogicalPlan {
+	if g.plan != nil {
+		return g.plan
+	}
+	for _, expr := range g.exprs {
+		children := make([]Plan, 0, len(expr.children))
+		for _, child := range expr.children {
+			children = append(children, m.groupPlan(child))
+		}
+		expr.node.SetChildren(children...)
+	}
+	if len(g.exprs) == 1 {
+		g.plan = g.exprs[0].node
+	} else {
+		g.plan = &groupPlan{LogicalPlan: g.exprs[0].node, group: g}
+	}
+	return g.plan
+}
+
+// groupPlan is the child of the expressions that stands for a group of several expressions. It delegates to the
+// first expression of the group, except that its physical plan is the cheapest one of all the expressions.
+type groupPlan struct {
+	LogicalPlan
+
+	group *group
+}
+
+// SetParents implements the Plan SetParents interface. An expression may be a child of many expressions,
+// so the parents aren't recorded.
+func (p *groupPlan) SetParents(...Plan) {}
+
+// buildKeyInfo implements the LogicalPlan buildKeyInfo interface. The keys of the group are built before it's
+// inserted into the memo.
+func (p *groupPlan) buildKeyInfo() {}
+
+// prepareStatsProfile implements the LogicalPlan prepareStatsProfile interface.
+func (p *groupPlan) prepareStatsProfile() *statsProfile {
+	if !p.group.profileReady {
+		for _, expr := range p.group.exprs[1:] {
+			expr.node.prepareStatsProfile()
+		}
+		p.group.profileReady = true
+	}
+	// The stats of the group are the ones of its first expression, which comes from the original plan.
+	return p.LogicalPlan.prepareStatsProfile()
+}
+
+// preparePossibleProperties implements the LogicalPlan preparePossibleProperties interface.
+func (p *groupPlan) preparePossibleProperties() [][]*expression.Column {
+	if !p.group.propsReady {
+		for _, expr := range p.group.exprs[1:] {
+			expr.node.preparePossibleProperties()
+		}
+		p.group.propsReady = true
+	}
+	return p.LogicalPlan.preparePossibleProperties()
+}
+
+// convert2NewPhysicalPlan implements the LogicalPlan convert2NewPhysicalPlan interface.
+func (p *groupPlan) convert2NewPhysicalPlan(prop *requiredProp) (task, error) {
+	key, err := prop.getHashKey()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if t, ok := p.group.tasks[string(key)]; ok {
+		return t, nil
+	}
+	var candidates []task
+	best := task(invalidTask)
+	for _, expr := range p.group.exprs {
+		t, err := expr.node.convert2NewPhysicalPlan(prop)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		candidates = append(candidates, t)
+		if t.cost() < best.cost() {
+			best = t
+		}
+	}
+	traceCandidates(p.LogicalPlan, prop, candidates, best)
+	if p.group.tasks == nil {
+		p.group.tasks = make(map[string]task)
+	}
+	p.group.tasks[string(key)] = best
+	return best, nil
+}
+
+func cascadesPhysicalOptimize(logic LogicalPlan, ctx context.Context, allocator *idAllocator) (PhysicalPlan, error) {
+	m := newMemo(ctx, allocator)
+	root := m.insert(logic)
+	m.explore()
+	return dagPhysicalOptimize(m.groupPlan(root))
+}
@@ -254,6 +254,55 @@ func (s *testAnalyzeSuite) TestAnalyze(c *C) {
 	}
 }
 
+func (s *testAnalyzeSuite) TestSkylinePruning(c *C) {
+	defer testleak.AfterTest(c)()
+	store, err := newStoreWithBootstrap()
+	c.Assert(err, IsNil)
+	testKit := testkit.NewTestKit(c, store)
+	defer func() {
+		store.Close()
+	}()
+	testKit.MustExec("use test")
+	testKit.MustExec("drop table if exists t")
+	testKit.MustExec("create table t (a int primary key, b int, c int, d int, index b(b), index bc(b, c), index c(c), index cd(c, d))")
+
+	tests := []struct {
+		sql  string
+		best string
+	}{
+		// Index b is dominated by index bc which uses more columns.
+		{
+			sql:  "select * from t where b = 1 and c > 1",
+			best: "IndexLookUp(Index(t.bc)[(1 1,1 +inf]], Table(t))",
+		},
+		// Index c is dominated by the covering index cd.
+		{
+			sql:  "select c, d from t where c = 1",
+			best: "IndexReader(Index(t.cd)[[1,1]])",
+		},
+		// The table scan on the handle dominates the indices which use no more columns.
+		{
+			sql:  "select * from t where a = 1 and d = 1",
+			best: "TableReader(Table(t)->Sel([eq(test.t.d, 1)]))",
+		},
+	}
+	for _, tt := range tests {
+		ctx := testKit.Se.(context.Context)
+		stmts, err := tidb.Parse(ctx, tt.sql)
+		c.Assert(err, IsNil)
+		c.Assert(stmts, HasLen, 1)
+		stmt := stmts[0]
+		is := sessionctx.GetDomain(ctx).InfoSchema()
+		err = plan.ResolveName(stmt, is, ctx)
+		c.Assert(err, IsNil)
+		err = expression.InferType(ctx.GetSessionVars().StmtCtx, stmt)
+		c.Assert(err, IsNil)
+		p, err := plan.Optimize(ctx, stmt, is)
+		c.Assert(err, IsNil)
+		c.Assert(plan.ToString(p), Equals, tt.best, Commentf("for %s", tt.sql))
+	}
+}
+
 func newStoreWithBootstrap() (kv.Storage, error) {
 	store, err := tikv.NewMockTikvStore()
 	if err != nil {
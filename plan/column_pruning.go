@@ -77,6 +77,9 @@ func (p *Projection) PruneColumns(parentUsedCols []*expression.Column) {
 // PruneColumns implements LogicalPlan interface.
 func (p *Selection) PruneColumns(parentUsedCols []*expression.Column) {
 	child := p.children[0].(LogicalPlan)
+	if ds, ok := child.(*DataSource); ok {
+		ds.parentUsedCols = append([]*expression.Column{}, parentUsedCols...)
+	}
 	for _, cond := range p.Conditions {
 		parentUsedCols = append(parentUsedCols, expression.ExtractColumns(cond)...)
 	}
@@ -197,6 +200,22 @@ func (p *Exists) PruneColumns(parentUsedCols []*expression.Column) {
 	p.children[0].(LogicalPlan).PruneColumns(nil)
 }
 
+// PruneColumns implements LogicalPlan interface.
+func (p *Expand) PruneColumns(parentUsedCols []*expression.Column) {
+	child := p.children[0].(LogicalPlan)
+	// The copies of the group-by columns and the grouping id are always kept.
+	childUsedCols := make([]*expression.Column, 0, len(parentUsedCols)+len(p.GroupByCols))
+	for _, col := range parentUsedCols {
+		if child.Schema().Contains(col) {
+			childUsedCols = append(childUsedCols, col)
+		}
+	}
+	child.PruneColumns(append(childUsedCols, p.GroupByCols...))
+	groupingCols := p.groupingCols()
+	p.SetSchema(child.Schema().Clone())
+	p.schema.Append(groupingCols...)
+}
+
 // PruneColumns implements LogicalPlan interface.
 func (p *Insert) PruneColumns(_ []*expression.Column) {
 	if len(p.Children()) == 0 {
@@ -106,6 +106,15 @@ func (s *testPlanSuite) TestDAGPlanBuilderSimpleCase(c *C) {
 			sql:  "select c from t where c = 1 limit 1",
 			best: "IndexReader(Index(t.c_d_e)[[1,1]]->Limit)->Limit",
 		},
+		// Test Limit isn't pushed down with SQL_CALC_FOUND_ROWS.
+		{
+			sql:  "select sql_calc_found_rows c from t where c = 1 limit 1",
+			best: "IndexReader(Index(t.c_d_e)[[1,1]])->Limit",
+		},
+		{
+			sql:  "select sql_calc_found_rows c from t order by t.a + t.b limit 1",
+			best: "TableReader(Table(t))->Sort->Limit->Projection",
+		},
 		// Test index single read and Selection.
 		{
 			sql:  "select c from t where c = 1",
@@ -162,6 +171,19 @@ func (s *testPlanSuite) TestDAGPlanBuilderSimpleCase(c *C) {
 			sql:  "select * from t use index(e_d_c_str_prefix) where t.c_str = 'abcdefghijk' and t.d_str = 'd' and t.e_str = 'e'",
 			best: "IndexLookUp(Index(t.e_d_c_str_prefix)[[e d [97 98 99 100 101 102 103 104 105 106],e d [97 98 99 100 101 102 103 104 105 106]]], Table(t)->Sel([eq(test.t.c_str, abcdefghijk)]))",
 		},
+		// Test prefix index without double read.
+		{
+			sql:  "select a from t use index(e_d_c_str_prefix) where t.e_str = 'e' and t.d_str = 'd' and t.c_str like 'abc%'",
+			best: "IndexReader(Index(t.e_d_c_str_prefix)[[e d abc,e d abd)])->Projection",
+		},
+		{
+			sql:  "select c_str from t use index(e_d_c_str_prefix) where t.e_str = 'e' and t.d_str = 'd' and t.c_str = 'abc'",
+			best: "IndexLookUp(Index(t.e_d_c_str_prefix)[[e d abc,e d abc]], Table(t))->Projection",
+		},
+		{
+			sql:  "select a from t use index(e_d_c_str_prefix) where t.e_str = 'e' and t.d_str = 'd' and t.c_str > 'abcdefghijk'",
+			best: "IndexLookUp(Index(t.e_d_c_str_prefix)[[e d [97 98 99 100 101 102 103 104 105 106],e d +inf]], Table(t)->Sel([gt(test.t.c_str, abcdefghijk)]))->Projection",
+		},
 	}
 	for _, tt := range tests {
 		comment := Commentf("for %s", tt.sql)
@@ -385,15 +407,15 @@ func (s *testPlanSuite) TestDAGPlanBuilderSubquery(c *C) {
 		// Test Apply.
 		{
 			sql:  "select t.c in (select count(*) from t s , t t1 where s.a = t.a and s.a = t1.a) from t",
-			best: "Apply{TableReader(Table(t))->MergeJoin{TableReader(Table(t))->Sel([eq(s.a, test.t.a)])->TableReader(Table(t))}(s.a,t1.a)->HashAgg}->Projection",
+			best: "Apply{TableReader(Table(t))->MergeJoin{TableReader(Table(t))->Sel([eq(s.a, test.t.a)])->TableReader(Table(t))->Sel([eq(t1.a, test.t.a)])}(s.a,t1.a)->HashAgg}->Projection",
 		},
 		{
 			sql:  "select (select count(*) from t s , t t1 where s.a = t.a and s.a = t1.a) from t",
-			best: "Apply{TableReader(Table(t))->MergeJoin{TableReader(Table(t))->Sel([eq(s.a, test.t.a)])->TableReader(Table(t))}(s.a,t1.a)->HashAgg}->Projection",
+			best: "Apply{TableReader(Table(t))->MergeJoin{TableReader(Table(t))->Sel([eq(s.a, test.t.a)])->TableReader(Table(t))->Sel([eq(t1.a, test.t.a)])}(s.a,t1.a)->HashAgg}->Projection",
 		},
 		{
 			sql:  "select (select count(*) from t s , t t1 where s.a = t.a and s.a = t1.a) from t order by t.a",
-			best: "Apply{TableReader(Table(t))->MergeJoin{TableReader(Table(t))->Sel([eq(s.a, test.t.a)])->TableReader(Table(t))}(s.a,t1.a)->HashAgg}->Projection",
+			best: "Apply{TableReader(Table(t))->MergeJoin{TableReader(Table(t))->Sel([eq(s.a, test.t.a)])->TableReader(Table(t))->Sel([eq(t1.a, test.t.a)])}(s.a,t1.a)->HashAgg}->Projection",
 		},
 	}
 	for _, tt := range tests {
@@ -680,11 +702,50 @@ func (s *testPlanSuite) TestDAGPlanBuilderAgg(c *C) {
 			sql:  "select sum(e) as k, avg(b + c) from t where c = 1 and b = 1 and e = 1 group by d order by k",
 			best: "IndexLookUp(Index(t.c_d_e)[[1,1]]->Sel([eq(test.t.e, 1)]), Table(t)->Sel([eq(test.t.b, 1)])->HashAgg)->HashAgg->Sort",
 		},
+		// Test the limit of the distinct agg is pushed down.
+		{
+			sql:  "select distinct b from t limit 10",
+			best: "TableReader(Table(t)->HashAgg->Limit)->HashAgg->Limit",
+		},
+		{
+			sql:  "select count(*) from t group by b limit 10",
+			best: "TableReader(Table(t)->HashAgg)->HashAgg->Limit",
+		},
+		// Test stream agg.
+		{
+			sql:  "select count(*) from t group by c",
+			best: "IndexReader(Index(t.c_d_e)[[<nil>,+inf]])->StreamAgg",
+		},
+		{
+			sql:  "select count(distinct e), max(c) from t group by d, c",
+			best: "IndexReader(Index(t.c_d_e)[[<nil>,+inf]])->StreamAgg",
+		},
+		{
+			sql:  "select distinct c from t where c > 1 limit 2, 10",
+			best: "IndexReader(Index(t.c_d_e)[(1,+inf]])->StreamAgg->Limit",
+		},
+		{
+			sql:  "select count(*) from t group by c + 1",
+			best: "TableReader(Table(t)->HashAgg)->HashAgg",
+		},
+		{
+			sql:  "select count(*) from t group by e",
+			best: "TableReader(Table(t)->HashAgg)->HashAgg",
+		},
 		// Test agg can't push down.
 		{
 			sql:  "select sum(to_base64(e)) from t where c = 1",
 			best: "IndexReader(Index(t.c_d_e)[[1,1]])->HashAgg",
 		},
+		// Test the rows are expanded for the grouping sets before the agg, which can't push down.
+		{
+			sql:  "select c, sum(a) from t group by c with rollup",
+			best: "TableReader(Table(t))->Expand->HashAgg->Projection",
+		},
+		{
+			sql:  "select c, d, count(*) from t where c = 1 group by grouping sets ((c, d), ()) order by c",
+			best: "IndexReader(Index(t.c_d_e)[[1,1]])->Expand->HashAgg->Projection->Sort",
+		},
 	}
 	for _, tt := range tests {
 		comment := Commentf("for %s", tt.sql)
@@ -107,6 +107,10 @@ func (s *decorrelateSolver) optimize(p LogicalPlan, _ context.Context, _ *idAllo
 			}
 			apply.attachOnConds(newConds)
 			innerPlan = sel.children[0].(LogicalPlan)
+			if ds, ok := innerPlan.(*DataSource); ok {
+				// The conditions of the selection are used by the join now.
+				ds.parentUsedCols = nil
+			}
 			apply.SetChildren(outerPlan, innerPlan)
 			innerPlan.SetParents(apply)
 			return s.optimize(p, nil, nil)
@@ -182,7 +186,10 @@ func (p *Selection) checkScanController() int {
 		pkCol       *expression.Column
 	)
 	ds := p.children[0].(*DataSource)
-	indices, includeTableScan := availableIndices(ds.indexHints, ds.tableInfo)
+	if ds.sample != nil {
+		return notController
+	}
+	indices, includeTableScan := availableIndices(ds.indexHints, ds.tableInfo, ds.ctx.GetSessionVars().OptimizerUseInvisibleIndexes, supportDescIndex(ds.ctx))
 	for _, expr := range p.Conditions {
 		if !expr.IsCorrelated() {
 			continue
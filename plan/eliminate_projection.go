@@ -196,6 +196,12 @@ func (p *LogicalAggregation) replaceExprColumns(replace map[string]*expression.C
 	p.collectGroupByColumns()
 }
 
+func (p *Expand) replaceExprColumns(replace map[string]*expression.Column) {
+	for _, col := range p.GroupByCols {
+		resolveColumnAndReplace(col, replace)
+	}
+}
+
 func (p *Selection) replaceExprColumns(replace map[string]*expression.Column) {
 	for _, expr := range p.Conditions {
 		resolveExprAndReplace(expr, replace)
@@ -17,6 +17,7 @@ import (
 	"bytes"
 	"fmt"
 
+	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/expression"
 )
 
@@ -121,6 +122,21 @@ func (p *PhysicalTableScan) ExplainInfo() string {
 	return buffer.String()
 }
 
+// ExplainInfo implements PhysicalPlan interface.
+func (p *PhysicalMemTable) ExplainInfo() string {
+	if p.Sample == nil {
+		return ""
+	}
+	tblName := p.Table.Name.O
+	if p.TableAsName != nil && p.TableAsName.O != "" {
+		tblName = p.TableAsName.O
+	}
+	if p.Sample.Method == ast.SampleBernoulli {
+		return fmt.Sprintf("table:%s, sample:BERNOULLI(%v)", tblName, p.Sample.Percent)
+	}
+	return fmt.Sprintf("table:%s, sample:%s()", tblName, p.Sample.Method)
+}
+
 // ExplainInfo implements PhysicalPlan interface.
 func (p *PhysicalTableReader) ExplainInfo() string {
 	return fmt.Sprintf("data:%s", p.tablePlan.ID())
@@ -151,6 +167,22 @@ func (p *Projection) ExplainInfo() string {
 	return string(expression.ExplainExpressionList(p.Exprs))
 }
 
+// ExplainInfo implements PhysicalPlan interface.
+func (p *Expand) ExplainInfo() string {
+	buffer := bytes.NewBufferString("grouping sets:")
+	for i, set := range p.GroupingSets {
+		cols := make([]expression.Expression, 0, len(set))
+		for _, idx := range set {
+			cols = append(cols, p.GroupByCols[idx])
+		}
+		buffer.WriteString(fmt.Sprintf("(%s)", expression.ExplainExpressionList(cols)))
+		if i+1 < len(p.GroupingSets) {
+			buffer.WriteString(", ")
+		}
+	}
+	return buffer.String()
+}
+
 // ExplainInfo implements PhysicalPlan interface.
 func (p *TableDual) ExplainInfo() string {
 	return fmt.Sprintf("rows:%v", p.RowCount)
@@ -99,9 +99,9 @@ func (s *testExplainSuite) TestExplain(c *C) {
 			[]string{
 				"TableScan_22   cop table:t1, range:[2,+inf), keep order:false 3333.333333333333",
 				"TableReader_23 HashLeftJoin_8  root data:TableScan_22 3333.333333333333",
-				"TableScan_37   cop table:t2, range:(-inf,+inf), keep order:false 8000",
-				"TableReader_38 HashLeftJoin_8  root data:TableScan_37 8000",
-				"HashLeftJoin_8  TableReader_23,TableReader_38 root left outer join, small:TableReader_38, equal:[eq(test.t1.c2, test.t2.c1)] 4166.666666666666",
+				"TableScan_33   cop table:t2, range:(-inf,+inf), keep order:false 8000",
+				"TableReader_34 HashLeftJoin_8  root data:TableScan_33 8000",
+				"HashLeftJoin_8  TableReader_23,TableReader_34 root left outer join, small:TableReader_34, equal:[eq(test.t1.c2, test.t2.c1)] 4166.666666666666",
 			},
 		},
 		{
@@ -124,13 +124,13 @@ func (s *testExplainSuite) TestExplain(c *C) {
 		{
 			"select count(b.c2) from t1 a, t2 b where a.c1 = b.c2 group by a.c1",
 			[]string{
-				"TableScan_25   cop table:a, range:(-inf,+inf), keep order:false 8000",
-				"TableReader_26 HashLeftJoin_10  root data:TableScan_25 8000",
-				"TableScan_17 HashAgg_16  cop table:b, range:(-inf,+inf), keep order:false 8000",
-				"HashAgg_16  TableScan_17 cop type:complete, group by:b.c2, funcs:count(b.c2), firstrow(b.c2) 6400",
-				"TableReader_19 HashAgg_18  root data:HashAgg_16 6400",
-				"HashAgg_18 HashLeftJoin_10 TableReader_19 root type:final, group by:, funcs:count(col_0), firstrow(col_1) 6400",
-				"HashLeftJoin_10 Projection_8 TableReader_26,HashAgg_18 root inner join, small:HashAgg_18, equal:[eq(a.c1, b.c2)] 8000",
+				"TableScan_22   cop table:a, range:(-inf,+inf), keep order:false 8000",
+				"TableReader_23 HashLeftJoin_10  root data:TableScan_22 8000",
+				"TableScan_14 HashAgg_13  cop table:b, range:(-inf,+inf), keep order:false 8000",
+				"HashAgg_13  TableScan_14 cop type:complete, group by:b.c2, funcs:count(b.c2), firstrow(b.c2) 6400",
+				"TableReader_16 HashAgg_15  root data:HashAgg_13 6400",
+				"HashAgg_15 HashLeftJoin_10 TableReader_16 root type:final, group by:, funcs:count(col_0), firstrow(col_1) 6400",
+				"HashLeftJoin_10 Projection_8 TableReader_23,HashAgg_15 root inner join, small:HashAgg_15, equal:[eq(a.c1, b.c2)] 8000",
 				"Projection_8  HashLeftJoin_10 root cast(join_agg_0) 8000",
 			},
 		},
@@ -215,10 +215,21 @@ func (s *testExplainSuite) TestExplain(c *C) {
 		{
 			"select * from t1 order by c1 desc limit 1",
 			[]string{
-				"TableScan_11 Limit_14  cop table:t1, range:(-inf,+inf), keep order:true, desc 1.25",
-				"Limit_14  TableScan_11 cop offset:0, count:1 1",
-				"TableReader_15 Limit_6  root data:Limit_14 1",
-				"Limit_6  TableReader_15 root offset:0, count:1 1",
+				"TableScan_11 Limit_12  cop table:t1, range:(-inf,+inf), keep order:true, desc 1.25",
+				"Limit_12  TableScan_11 cop offset:0, count:1 1",
+				"TableReader_13 Limit_6  root data:Limit_12 1",
+				"Limit_6  TableReader_13 root offset:0, count:1 1",
+			},
+		},
+		{
+			"select distinct c2 from t2 limit 1, 2",
+			[]string{
+				"TableScan_8 HashAgg_7  cop table:t2, range:(-inf,+inf), keep order:false 8000",
+				"HashAgg_7 Limit_13 TableScan_8 cop type:complete, group by:test.t2.c2, funcs:firstrow(test.t2.c2) 6400",
+				"Limit_13  HashAgg_7 cop offset:0, count:3 2",
+				"TableReader_10 HashAgg_9  root data:Limit_13 6400",
+				"HashAgg_9 Limit_6 TableReader_10 root type:final, group by:, funcs:firstrow(col_0) 6400",
+				"Limit_6  HashAgg_9 root offset:1, count:2 2",
 			},
 		},
 	}
@@ -0,0 +1,140 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plan
+
+import (
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/parser"
+	"github.com/pingcap/tidb/table"
+)
+
+// parseGeneratedExpr parses the expression of a generated column, the ast node of the table column
+// isn't used because it's shared by the sessions.
+func parseGeneratedExpr(expr string) (ast.ExprNode, error) {
+	stmt, err := parser.New().ParseOneStmt("select "+expr, mysql.DefaultCharset, mysql.DefaultCollationName)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return stmt.(*ast.SelectStmt).Fields.Fields[0].Expr, nil
+}
+
+// buildHiddenColumnExprs rewrites the expressions of the hidden columns of the expression indices on
+// the schema of the data source.
+func (b *planBuilder) buildHiddenColumnExprs(p *DataSource, columns []*table.Column, schema *expression.Schema) error {
+	var mockTablePlan LogicalPlan
+	for _, col := range columns {
+		if !col.Hidden {
+			continue
+		}
+		if mockTablePlan == nil {
+			mockTablePlan = TableDual{}.init(b.allocator, b.ctx)
+			mockTablePlan.SetSchema(schema)
+		}
+		node, err := parseGeneratedExpr(col.GeneratedExprString)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		expr, err := b.rewriteGeneratedExpr(node, p.tableInfo, mockTablePlan)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if p.hiddenExprs == nil {
+			p.hiddenExprs = make(map[int64]expression.Expression)
+		}
+		p.hiddenExprs[col.ID] = expr
+	}
+	return nil
+}
+
+// isExpressionIndex checks whether the index has a key on a hidden column.
+func isExpressionIndex(tblInfo *model.TableInfo, idx *model.IndexInfo) bool {
+	for _, idxCol := range idx.Columns {
+		if tblInfo.Columns[idxCol.Offset].Hidden {
+			return true
+		}
+	}
+	return false
+}
+
+// expressionIndexCols returns the columns of the expression index and the conditions in which the
+// expressions of the hidden columns are substituted by the hidden columns, so the ranges of the index
+// can be built from the conditions. Like IndexInfo2Cols, only the columns before the first column not in
+// the schema are returned.
+func (p *DataSource) expressionIndexCols(idx *model.IndexInfo, conds []expression.Expression) ([]*expression.Column,
+	[]int, []expression.Expression) {
+	cols := make([]*expression.Column, 0, len(idx.Columns))
+	lengths := make([]int, 0, len(idx.Columns))
+	for _, idxCol := range idx.Columns {
+		colInfo := p.tableInfo.Columns[idxCol.Offset]
+		var col *expression.Column
+		if colInfo.Hidden {
+			expr, ok := p.hiddenExprs[colInfo.ID]
+			if !ok {
+				break
+			}
+			col = &expression.Column{
+				FromID:   p.id,
+				ColName:  colInfo.Name,
+				TblName:  p.tableInfo.Name,
+				DBName:   p.DBName,
+				RetType:  &colInfo.FieldType,
+				Position: colInfo.Offset,
+				ID:       colInfo.ID,
+				IsHidden: true,
+			}
+			for i, cond := range conds {
+				conds[i] = substituteExpr(cond, expr, col, p.ctx)
+			}
+		} else {
+			col = expression.ColInfo2Col(p.schema.Columns, colInfo)
+			if col == nil {
+				break
+			}
+		}
+		cols = append(cols, col)
+		lengths = append(lengths, idxCol.Length)
+	}
+	return cols, lengths, conds
+}
+
+// substituteExpr substitutes the sub-expressions of expr which equal to target by col.
+func substituteExpr(expr, target expression.Expression, col *expression.Column, ctx context.Context) expression.Expression {
+	if expr.Equal(target, ctx) {
+		return col
+	}
+	sf, ok := expr.(*expression.ScalarFunction)
+	if !ok {
+		return expr
+	}
+	changed := false
+	newArgs := make([]expression.Expression, 0, len(sf.GetArgs()))
+	for _, arg := range sf.GetArgs() {
+		newArg := substituteExpr(arg, target, col, ctx)
+		changed = changed || newArg != arg
+		newArgs = append(newArgs, newArg)
+	}
+	if !changed {
+		return expr
+	}
+	newFunc, err := expression.NewFunction(sf.GetCtx(), sf.FuncName.L, sf.RetType, newArgs...)
+	if err != nil {
+		return expr
+	}
+	return newFunc
+}
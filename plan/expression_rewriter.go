@@ -55,6 +55,76 @@ func evalAstExpr(expr ast.ExprNode, ctx context.Context) (types.Datum, error) {
 	return newExpr.Eval(nil)
 }
 
+// rewriteAstExprOnTable rewrites the ast expression of a generated column to an expression on the
+// columns of the table, the columns are indexed by their offsets.
+func rewriteAstExprOnTable(expr ast.ExprNode, tblInfo *model.TableInfo, ctx context.Context) (expression.Expression, error) {
+	b := &planBuilder{
+		ctx:       ctx,
+		allocator: new(idAllocator),
+		colMapper: make(map[*ast.ColumnNameExpr]int),
+	}
+	schema := expression.NewSchema(make([]*expression.Column, 0, len(tblInfo.Columns))...)
+	for _, col := range tblInfo.Columns {
+		schema.Append(&expression.Column{
+			ColName:  col.Name,
+			TblName:  tblInfo.Name,
+			RetType:  &col.FieldType,
+			Position: col.Offset,
+			Index:    col.Offset,
+			ID:       col.ID,
+		})
+	}
+	mockTablePlan := TableDual{}.init(b.allocator, ctx)
+	mockTablePlan.SetSchema(schema)
+	return b.rewriteGeneratedExpr(expr, tblInfo, mockTablePlan)
+}
+
+// rewriteGeneratedExpr rewrites the expression of a generated column of the table on the schema of p.
+func (b *planBuilder) rewriteGeneratedExpr(expr ast.ExprNode, tblInfo *model.TableInfo, p LogicalPlan) (expression.Expression, error) {
+	return b.rewriteTableExpr(expr, tblInfo, p, "generated column function")
+}
+
+// rewriteTableExpr rewrites the expression on the columns of the table on the schema of p, the clause is
+// where the expression is used in the error of the unknown columns.
+func (b *planBuilder) rewriteTableExpr(expr ast.ExprNode, tblInfo *model.TableInfo, p LogicalPlan, clause string) (expression.Expression, error) {
+	resolver := &tableColumnResolver{tblInfo: tblInfo, clause: clause}
+	expr.Accept(resolver)
+	if resolver.err != nil {
+		return nil, errors.Trace(resolver.err)
+	}
+	err := expression.InferType(b.ctx.GetSessionVars().StmtCtx, expr)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	newExpr, _, err := b.rewrite(expr, p, nil, true)
+	return newExpr, errors.Trace(err)
+}
+
+// tableColumnResolver resolves the column names in the expression on the columns of a table.
+type tableColumnResolver struct {
+	tblInfo *model.TableInfo
+	clause  string
+	err     error
+}
+
+func (r *tableColumnResolver) Enter(inNode ast.Node) (ast.Node, bool) {
+	return inNode, false
+}
+
+func (r *tableColumnResolver) Leave(inNode ast.Node) (ast.Node, bool) {
+	if v, ok := inNode.(*ast.ColumnNameExpr); ok {
+		for _, col := range r.tblInfo.Columns {
+			if col.Name.L == v.Name.Name.L {
+				v.Refer = &ast.ResultField{Column: col, Table: r.tblInfo}
+				return inNode, true
+			}
+		}
+		r.err = ErrUnknownColumn.GenByArgs(v.Name.Name.O, r.clause)
+		return inNode, false
+	}
+	return inNode, true
+}
+
 // rewrite function rewrites ast expr to expression.Expression.
 // aggMapper maps ast.AggregateFuncExpr to the columns offset in p's output schema.
 // asScalar means whether this expression must be treated as a scalar expression.
@@ -131,6 +201,11 @@ func getRowArg(e expression.Expression, idx int) expression.Expression {
 	return &expression.Constant{Value: d, RetType: c.GetType()}
 }
 
+func isNullConstant(e expression.Expression) bool {
+	c, ok := e.(*expression.Constant)
+	return ok && c.Value.IsNull()
+}
+
 // popRowArg pops the first element and return the rest of row.
 // e.g. After this function (1, 2, 3) becomes (2, 3).
 func popRowArg(ctx context.Context, e expression.Expression) (ret expression.Expression, err error) {
@@ -164,6 +239,15 @@ func popRowArg(ctx context.Context, e expression.Expression) (ret expression.Exp
 func (er *expressionRewriter) constructBinaryOpFunction(l expression.Expression, r expression.Expression, op string) (expression.Expression, error) {
 	lLen, rLen := getRowLen(l), getRowLen(r)
 	if lLen == 1 && rLen == 1 {
+		if op == ast.NullEQ {
+			// The null constant compared by "<=>" takes the type of the other side, so the other side isn't casted
+			// and the range of the column can be built from "a <=> null".
+			if isNullConstant(r) {
+				r = &expression.Constant{RetType: l.GetType()}
+			} else if isNullConstant(l) {
+				l = &expression.Constant{RetType: r.GetType()}
+			}
+		}
 		return expression.NewFunction(er.ctx, op, types.NewFieldType(mysql.TypeTiny), l, r)
 	} else if rLen != lLen {
 		return nil, ErrOperandColumns.GenByArgs(lLen)
@@ -506,7 +590,7 @@ func (er *expressionRewriter) handleExistSubquery(v *ast.ExistsSubqueryExpr) (as
 		return v, true
 	}
 	np = er.b.buildExists(np)
-	if len(np.extractCorrelatedCols()) > 0 {
+	if len(np.extractCorrelatedCols()) > 0 || er.b.paramTypes != nil {
 		er.p = er.b.buildSemiApply(er.p, np.Children()[0].(LogicalPlan), nil, er.asScalar, false)
 		if !er.asScalar {
 			return v, true
@@ -551,7 +635,8 @@ func (er *expressionRewriter) handleInSubquery(v *ast.PatternInExpr) (ast.Node,
 	// Sometimes we can unfold the in subquery. For example, a in (select * from t) can rewrite to `a in (1,2,3,4)`.
 	// TODO: Now we cannot add it to CBO framework. Instead, user can set a session variable to open this optimization.
 	// We will improve our CBO framework in future.
-	if lLen == 1 && er.ctx.GetSessionVars().AllowInSubqueryUnFolding && len(np.extractCorrelatedCols()) == 0 {
+	if lLen == 1 && er.ctx.GetSessionVars().AllowInSubqueryUnFolding && len(np.extractCorrelatedCols()) == 0 &&
+		er.b.paramTypes == nil {
 		physicalPlan, err := doOptimize(er.b.optFlag, np, er.b.ctx, er.b.allocator)
 		if err != nil {
 			er.err = errors.Trace(err)
@@ -617,7 +702,7 @@ func (er *expressionRewriter) handleScalarSubquery(v *ast.SubqueryExpr) (ast.Nod
 		return v, true
 	}
 	np = er.b.buildMaxOneRow(np)
-	if len(np.extractCorrelatedCols()) > 0 {
+	if len(np.extractCorrelatedCols()) > 0 || er.b.paramTypes != nil {
 		er.p = er.b.buildApplyWithJoinType(er.p, np, LeftOuterJoin)
 		if np.Schema().Len() > 1 {
 			newCols := make([]expression.Expression, 0, np.Schema().Len())
@@ -686,6 +771,9 @@ func (er *expressionRewriter) Leave(originInNode ast.Node) (retNode ast.Node, ok
 		er.ctxStack = append(er.ctxStack, value)
 	case *ast.ParamMarkerExpr:
 		value := &expression.Constant{Value: v.Datum, RetType: &v.Type}
+		if er.b.paramConsts != nil {
+			er.b.paramConsts[value] = v
+		}
 		er.ctxStack = append(er.ctxStack, value)
 	case *ast.VariableExpr:
 		er.rewriteVariable(v)
@@ -696,8 +784,12 @@ func (er *expressionRewriter) Leave(originInNode ast.Node) (retNode ast.Node, ok
 	case *ast.UnaryOperationExpr:
 		er.unaryOpToExpression(v)
 	case *ast.BinaryOperationExpr:
+		if _, ok := paramInferableOps[v.Op]; ok {
+			er.inferParamTypes(2)
+		}
 		er.binaryOpToExpression(v)
 	case *ast.BetweenExpr:
+		er.inferParamTypes(3)
 		er.betweenToExpression(v)
 	case *ast.CaseExpr:
 		er.caseToExpression(v)
@@ -709,6 +801,7 @@ func (er *expressionRewriter) Leave(originInNode ast.Node) (retNode ast.Node, ok
 		}
 		er.ctxStack[len(er.ctxStack)-1] = expression.NewCastFunc(v.Tp, arg, er.ctx)
 	case *ast.PatternLikeExpr:
+		er.inferParamTypes(2)
 		er.likeToScalarFunc(v)
 	case *ast.PatternRegexpExpr:
 		er.regexpToScalarFunc(v)
@@ -716,6 +809,7 @@ func (er *expressionRewriter) Leave(originInNode ast.Node) (retNode ast.Node, ok
 		er.rowToScalarFunc(v)
 	case *ast.PatternInExpr:
 		if v.Sel == nil {
+			er.inferParamTypes(len(v.List) + 1)
 			er.inToExpression(len(v.List), v.Not, &v.Type)
 		}
 	case *ast.PositionExpr:
@@ -735,6 +829,41 @@ func (er *expressionRewriter) Leave(originInNode ast.Node) (retNode ast.Node, ok
 	return originInNode, true
 }
 
+// paramInferableOps are the operators whose parameter markers take the type of the other operand at prepare time.
+var paramInferableOps = map[opcode.Op]struct{}{
+	opcode.EQ: {}, opcode.NE: {}, opcode.NullEQ: {}, opcode.GT: {}, opcode.GE: {}, opcode.LT: {}, opcode.LE: {},
+	opcode.Plus: {}, opcode.Minus: {}, opcode.Mul: {}, opcode.Div: {}, opcode.IntDiv: {}, opcode.Mod: {},
+}
+
+// inferParamTypes infers the types of the parameter markers in the last n arguments on the stack from the type of
+// the first argument which isn't a parameter marker, when the statement is built for its metadata at prepare time.
+func (er *expressionRewriter) inferParamTypes(n int) {
+	if er.b.paramConsts == nil || len(er.ctxStack) < n {
+		return
+	}
+	args := er.ctxStack[len(er.ctxStack)-n:]
+	var tp *types.FieldType
+	for _, arg := range args {
+		if c, ok := arg.(*expression.Constant); !ok || er.b.paramConsts[c] == nil {
+			tp = arg.GetType()
+			break
+		}
+	}
+	if tp == nil || tp.Tp == mysql.TypeNull {
+		return
+	}
+	for _, arg := range args {
+		c, ok := arg.(*expression.Constant)
+		if !ok || er.b.paramConsts[c] == nil {
+			continue
+		}
+		er.b.inferParamType(er.b.paramConsts[c], tp)
+		// The function built on the argument takes the inferred type too.
+		newTp := *tp
+		c.RetType = &newTp
+	}
+}
+
 func datumToConstant(d types.Datum, tp byte) *expression.Constant {
 	return &expression.Constant{Value: d, RetType: types.NewFieldType(tp)}
 }
@@ -754,8 +883,7 @@ func (er *expressionRewriter) rewriteVariable(v *ast.VariableExpr) {
 		}
 		f, err := expression.NewFunction(er.ctx,
 			ast.GetVar,
-			// TODO: Here is wrong, the sessionVars should store a name -> Datum map. Will fix it later.
-			types.NewFieldType(mysql.TypeString),
+			userVarFieldType(expression.GetUserVar(sessionVars, name)),
 			datumToConstant(types.NewStringDatum(name), mysql.TypeString))
 		if err != nil {
 			er.err = errors.Trace(err)
@@ -779,6 +907,27 @@ func (er *expressionRewriter) rewriteVariable(v *ast.VariableExpr) {
 	return
 }
 
+// userVarFieldType returns the type of the user variable by its current value like MySQL, the type doesn't change
+// in the statement even if the variable is assigned a value of another type.
+func userVarFieldType(d types.Datum) *types.FieldType {
+	var tp *types.FieldType
+	switch d.Kind() {
+	case types.KindInt64:
+		tp = types.NewFieldType(mysql.TypeLonglong)
+	case types.KindUint64:
+		tp = types.NewFieldType(mysql.TypeLonglong)
+		tp.Flag |= mysql.UnsignedFlag
+	case types.KindFloat64:
+		tp = types.NewFieldType(mysql.TypeDouble)
+	case types.KindMysqlDecimal:
+		tp = types.NewFieldType(mysql.TypeNewDecimal)
+		tp.Decimal = int(d.GetMysqlDecimal().GetDigitsFrac())
+	default:
+		tp = types.NewFieldType(mysql.TypeString)
+	}
+	return tp
+}
+
 func (er *expressionRewriter) unaryOpToExpression(v *ast.UnaryOperationExpr) {
 	stkLen := len(er.ctxStack)
 	var op string
@@ -891,11 +1040,46 @@ func (er *expressionRewriter) inToExpression(lLen int, not bool, tp *types.Field
 			return
 		}
 	}
-	function := er.notToExpression(not, ast.In, tp, er.ctxStack[stkLen-lLen-1:]...)
+	var function expression.Expression
+	if l > 1 {
+		function = er.rowInToExpression(lLen, not, tp)
+	} else {
+		function = er.notToExpression(not, ast.In, tp, er.ctxStack[stkLen-lLen-1:]...)
+	}
+	if er.err != nil {
+		return
+	}
 	er.ctxStack = er.ctxStack[:stkLen-lLen-1]
 	er.ctxStack = append(er.ctxStack, function)
 }
 
+// rowInToExpression converts the in expression of rows to the DNF of the row equal conditions, e.g.
+// (a, b) in ((1, 2), (3, 4)) is converted to (a = 1 and b = 2) or (a = 3 and b = 4), so the point ranges
+// of the index on (a, b) can be built.
+func (er *expressionRewriter) rowInToExpression(lLen int, not bool, tp *types.FieldType) expression.Expression {
+	stkLen := len(er.ctxStack)
+	left := er.ctxStack[stkLen-lLen-1]
+	eqFuncs := make([]expression.Expression, 0, lLen)
+	for _, row := range er.ctxStack[stkLen-lLen:] {
+		eqFunc, err := er.constructBinaryOpFunction(left.Clone(), row, ast.EQ)
+		if err != nil {
+			er.err = errors.Trace(err)
+			return nil
+		}
+		eqFuncs = append(eqFuncs, eqFunc)
+	}
+	function := expression.ComposeDNFCondition(er.ctx, eqFuncs...)
+	if !not {
+		return function
+	}
+	function, err := expression.NewFunction(er.ctx, ast.UnaryNot, tp, function)
+	if err != nil {
+		er.err = errors.Trace(err)
+		return nil
+	}
+	return function
+}
+
 func (er *expressionRewriter) caseToExpression(v *ast.CaseExpr) {
 	stkLen := len(er.ctxStack)
 	argsLen := 2 * len(v.WhenClauses)
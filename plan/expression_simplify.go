@@ -0,0 +1,113 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plan
+
+import (
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/expression"
+)
+
+// exprSimplifier simplifies the expressions of the plans before the predicates are pushed down, so the push down
+// and the range building see the canonical expressions, e.g. "a > 1 + 1 and 1" becomes "a > 2".
+type exprSimplifier struct {
+	ctx context.Context
+}
+
+// optimize implements the logicalOptRule interface.
+func (s *exprSimplifier) optimize(lp LogicalPlan, ctx context.Context, _ *idAllocator) (LogicalPlan, error) {
+	s.ctx = ctx
+	return s.simplifyPlan(lp)
+}
+
+func (s *exprSimplifier) simplifyPlan(p LogicalPlan) (LogicalPlan, error) {
+	children := make([]Plan, 0, len(p.Children()))
+	for _, child := range p.Children() {
+		newChild, err := s.simplifyPlan(child.(LogicalPlan))
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		children = append(children, newChild)
+	}
+	p.SetChildren(children...)
+	switch x := p.(type) {
+	case *Selection:
+		x.Conditions = s.simplifyConds(x.Conditions)
+		if len(x.Conditions) == 0 {
+			child := x.children[0]
+			if err := RemovePlan(x); err != nil {
+				return nil, errors.Trace(err)
+			}
+			return child.(LogicalPlan), nil
+		}
+	case *LogicalJoin:
+		s.simplifyJoin(x)
+	case *LogicalApply:
+		s.simplifyJoin(&x.LogicalJoin)
+	case *Projection:
+		s.simplifyExprs(x.Exprs)
+	case *LogicalAggregation:
+		s.simplifyExprs(x.GroupByItems)
+		for _, aggFunc := range x.AggFuncs {
+			s.simplifyExprs(aggFunc.GetArgs())
+		}
+	case *Sort:
+		for _, item := range x.ByItems {
+			item.Expr = expression.Simplify(s.ctx, item.Expr, false)
+		}
+	}
+	return p, nil
+}
+
+func (s *exprSimplifier) simplifyJoin(p *LogicalJoin) {
+	p.LeftConditions = s.simplifyConds(p.LeftConditions)
+	p.RightConditions = s.simplifyConds(p.RightConditions)
+	p.OtherConditions = s.simplifyConds(p.OtherConditions)
+}
+
+// simplifyExprs simplifies the expressions whose values are used, the top-level casts are kept because they
+// decide the types of the results.
+func (s *exprSimplifier) simplifyExprs(exprs []expression.Expression) {
+	for i, expr := range exprs {
+		exprs[i] = expression.Simplify(s.ctx, expr, false)
+	}
+}
+
+// simplifyConds simplifies the CNF conditions, the "and" items are split and the conditions which are always true
+// are removed. The deterministic conditions are replaced by the one which is always false if there is.
+func (s *exprSimplifier) simplifyConds(conds []expression.Expression) []expression.Expression {
+	sc := s.ctx.GetSessionVars().StmtCtx
+	newConds := make([]expression.Expression, 0, len(conds))
+	var falseCond expression.Expression
+	deterministic := true
+	for _, cond := range conds {
+		for _, item := range expression.SplitCNFItems(expression.Simplify(s.ctx, cond, true)) {
+			if con, ok := item.(*expression.Constant); ok && !con.Value.IsNull() {
+				isTrue, err := con.Value.ToBool(sc)
+				if err == nil && isTrue == 1 {
+					continue
+				}
+				if err == nil {
+					falseCond = con
+				}
+			}
+			deterministic = deterministic && expression.IsDeterministic(item)
+			newConds = append(newConds, item)
+		}
+	}
+	if falseCond != nil && deterministic {
+		return []expression.Expression{falseCond}
+	}
+	return newConds
+}
@@ -67,6 +67,8 @@ const (
 	TypeApply = "Apply"
 	// TypeMaxOneRow is the type of MaxOneRow.
 	TypeMaxOneRow = "MaxOneRow"
+	// TypeExpand is the type of Expand.
+	TypeExpand = "Expand"
 	// TypeExists is the type of Exists.
 	TypeExists = "Exists"
 	// TypeDual is the type of TableDual.
@@ -174,6 +176,13 @@ func (p MaxOneRow) init(allocator *idAllocator, ctx context.Context) *MaxOneRow
 	return &p
 }
 
+func (p Expand) init(allocator *idAllocator, ctx context.Context) *Expand {
+	p.basePlan = newBasePlan(TypeExpand, allocator, ctx, &p)
+	p.baseLogicalPlan = newBaseLogicalPlan(p.basePlan)
+	p.basePhysicalPlan = newBasePhysicalPlan(p.basePlan)
+	return &p
+}
+
 func (p Update) init(allocator *idAllocator, ctx context.Context) *Update {
 	p.basePlan = newBasePlan(TypeUpate, allocator, ctx, &p)
 	p.baseLogicalPlan = newBaseLogicalPlan(p.basePlan)
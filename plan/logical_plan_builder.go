@@ -22,6 +22,7 @@ import (
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/infoschema"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/parser"
@@ -57,7 +58,8 @@ func (p *LogicalAggregation) collectGroupByColumns() {
 	}
 }
 
-func (b *planBuilder) buildAggregation(p LogicalPlan, aggFuncList []*ast.AggregateFuncExpr, gbyItems []expression.Expression) (LogicalPlan, map[int]int) {
+// buildAggregation builds the aggregation, expand is the Expand below it if the query has grouping sets, or nil.
+func (b *planBuilder) buildAggregation(p LogicalPlan, aggFuncList []*ast.AggregateFuncExpr, gbyItems []expression.Expression, expand *Expand) (LogicalPlan, map[int]int) {
 	b.optFlag = b.optFlag | flagBuildKeyInfo
 	b.optFlag = b.optFlag | flagAggregationOptimize
 
@@ -76,7 +78,23 @@ func (b *planBuilder) buildAggregation(p LogicalPlan, aggFuncList []*ast.Aggrega
 			p = np
 			newArgList = append(newArgList, newArg)
 		}
-		newFunc := expression.NewAggFunction(aggFunc.F, newArgList, aggFunc.Distinct)
+		var newFunc expression.AggregationFunction
+		if aggFunc.F == ast.AggFuncGrouping {
+			var err error
+			newFunc, err = b.buildGroupingFunc(expand, newArgList)
+			if err != nil {
+				b.err = errors.Trace(err)
+				return nil, nil
+			}
+		} else {
+			if aggFunc.F == ast.AggFuncApproxPercentile {
+				if err := b.checkPercentage(newArgList); err != nil {
+					b.err = errors.Trace(err)
+					return nil, nil
+				}
+			}
+			newFunc = expression.NewAggFunction(aggFunc.F, newArgList, aggFunc.Distinct)
+		}
 		combined := false
 		for j, oldFunc := range agg.AggFuncs {
 			if oldFunc.Equal(newFunc, b.ctx) {
@@ -98,9 +116,15 @@ func (b *planBuilder) buildAggregation(p LogicalPlan, aggFuncList []*ast.Aggrega
 		}
 	}
 	for _, col := range p.Schema().Columns {
-		newFunc := expression.NewAggFunction(ast.AggFuncFirstRow, []expression.Expression{col.Clone()}, false)
+		arg, newCol := col.Clone(), col.Clone().(*expression.Column)
+		// A group-by column of the grouping sets is null if it isn't in the grouping set, so its copy is used.
+		if idx := expand.groupByColIndex(col); idx != -1 {
+			arg = expand.groupingCols()[idx].Clone()
+			newCol.RetType = arg.GetType()
+		}
+		newFunc := expression.NewAggFunction(ast.AggFuncFirstRow, []expression.Expression{arg}, false)
 		agg.AggFuncs = append(agg.AggFuncs, newFunc)
-		schema.Append(col.Clone().(*expression.Column))
+		schema.Append(newCol)
 	}
 	addChild(agg, p)
 	agg.GroupByItems = gbyItems
@@ -109,6 +133,129 @@ func (b *planBuilder) buildAggregation(p LogicalPlan, aggFuncList []*ast.Aggrega
 	return agg, aggIndexMap
 }
 
+// checkPercentage checks the percentage of approx_percentile is a constant between 0 and 100, it's converted to
+// a float64 constant, so it's evaluated without a row by both the complete and the final aggregate functions.
+func (b *planBuilder) checkPercentage(args []expression.Expression) error {
+	con, ok := args[1].(*expression.Constant)
+	if !ok || con.Value.IsNull() {
+		return ErrBadPercentage.GenByArgs(args[1])
+	}
+	percentage, err := con.Value.ToFloat64(b.ctx.GetSessionVars().StmtCtx)
+	if err != nil || percentage < 0 || percentage > 100 {
+		return ErrBadPercentage.GenByArgs(args[1])
+	}
+	args[1] = &expression.Constant{Value: types.NewFloat64Datum(percentage), RetType: types.NewFieldType(mysql.TypeDouble)}
+	return nil
+}
+
+// buildGroupingFunc builds GROUPING(cols) as the first row of the bits of the grouping id for the columns, the bit of
+// a column is set if it's null because it isn't in the grouping set.
+func (b *planBuilder) buildGroupingFunc(expand *Expand, args []expression.Expression) (expression.AggregationFunction, error) {
+	if expand == nil {
+		return nil, ErrInvalidGroupFuncUse
+	}
+	n := len(expand.GroupByCols)
+	gid := expand.groupingCols()[n]
+	retType := types.NewFieldType(mysql.TypeLonglong)
+	var result expression.Expression
+	for i, arg := range args {
+		col, ok := arg.(*expression.Column)
+		idx := -1
+		if ok {
+			idx = expand.groupByColIndex(col)
+		}
+		if idx == -1 {
+			return nil, ErrFieldNotInGrouping.GenByArgs(i + 1)
+		}
+		shift := &expression.Constant{Value: types.NewIntDatum(int64(n - 1 - idx)), RetType: retType}
+		bit, err := expression.NewFunction(b.ctx, ast.RightShift, retType, gid.Clone(), shift)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		bit, err = expression.NewFunction(b.ctx, ast.And, retType, bit, expression.One)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if result == nil {
+			result = bit
+			continue
+		}
+		result, err = expression.NewFunction(b.ctx, ast.LeftShift, retType, result, expression.One)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		result, err = expression.NewFunction(b.ctx, ast.Or, retType, result, bit)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	return expression.NewAggFunction(ast.AggFuncFirstRow, []expression.Expression{result}, false), nil
+}
+
+// buildExpand builds the Expand for "GROUP BY ... WITH ROLLUP" or "GROUP BY GROUPING SETS (...)". The group-by items
+// must be columns, the same columns in the items are merged.
+func (b *planBuilder) buildExpand(p LogicalPlan, gby *ast.GroupByClause, gbyItems []expression.Expression) *Expand {
+	expand := Expand{}.init(b.allocator, b.ctx)
+	positions := make([]int, 0, len(gbyItems))
+	for _, item := range gbyItems {
+		col, ok := item.(*expression.Column)
+		if !ok {
+			b.err = ErrUnsupportedType.Gen("unsupported group-by expression %s with grouping sets", item)
+			return nil
+		}
+		idx := expand.groupByColIndex(col)
+		if idx == -1 {
+			idx = len(expand.GroupByCols)
+			expand.GroupByCols = append(expand.GroupByCols, col.Clone().(*expression.Column))
+		}
+		positions = append(positions, idx)
+	}
+	if len(expand.GroupByCols) > 64 {
+		b.err = ErrUnsupportedType.Gen("too many group-by columns with grouping sets")
+		return nil
+	}
+	sets := gby.GroupingSets
+	if gby.Rollup {
+		// ROLLUP groups by every prefix of the items, from the longest to the empty one.
+		for i := len(gbyItems); i >= 0; i-- {
+			set := make([]int, 0, i)
+			for j := 0; j < i; j++ {
+				set = append(set, j)
+			}
+			sets = append(sets, set)
+		}
+	}
+	for _, set := range sets {
+		newSet := make([]int, 0, len(set))
+		for _, position := range set {
+			newSet = append(newSet, positions[position])
+		}
+		expand.GroupingSets = append(expand.GroupingSets, newSet)
+	}
+	schema := p.Schema().Clone()
+	for i, col := range expand.GroupByCols {
+		retType := *col.RetType
+		retType.Flag &^= mysql.NotNullFlag
+		schema.Append(&expression.Column{
+			FromID:   expand.id,
+			ColName:  model.NewCIStr(fmt.Sprintf("%s_gby_%d", expand.id, i)),
+			Position: i,
+			RetType:  &retType,
+		})
+	}
+	gidType := types.NewFieldType(mysql.TypeLonglong)
+	gidType.Flag |= mysql.NotNullFlag
+	schema.Append(&expression.Column{
+		FromID:   expand.id,
+		ColName:  model.NewCIStr(fmt.Sprintf("%s_gid", expand.id)),
+		Position: len(expand.GroupByCols),
+		RetType:  gidType,
+	})
+	addChild(expand, p)
+	expand.SetSchema(schema)
+	return expand
+}
+
 func (b *planBuilder) buildResultSetNode(node ast.ResultSetNode) LogicalPlan {
 	switch x := node.(type) {
 	case *ast.Join:
@@ -138,6 +285,15 @@ func (b *planBuilder) buildResultSetNode(node ast.ResultSetNode) LogicalPlan {
 				col.DBName = model.NewCIStr("")
 			}
 		}
+		if v, ok := p.(*DataSource); ok {
+			p = b.buildRowPolicies(v)
+			if b.err == nil {
+				p = b.buildMaskingPolicies(p, v)
+			}
+		}
+		if b.err != nil {
+			return nil
+		}
 		return p
 	case *ast.SelectStmt:
 		return b.buildSelect(x)
@@ -221,6 +377,7 @@ func (b *planBuilder) buildJoin(join *ast.Join) LogicalPlan {
 		return b.buildResultSetNode(join.Left)
 	}
 	b.optFlag = b.optFlag | flagPredicatePushDown
+	b.optFlag = b.optFlag | flagSimplifyExpression
 	leftPlan := b.buildResultSetNode(join.Left)
 	rightPlan := b.buildResultSetNode(join.Right)
 	leftAlias := extractTableAlias(leftPlan)
@@ -330,6 +487,9 @@ func (b *planBuilder) coalesceCommonColumns(p *LogicalJoin, leftPlan, rightPlan
 	// Find out all the common columns and put them ahead.
 	commonLen := 0
 	for i, lCol := range lColumns {
+		if lCol.IsHidden {
+			continue
+		}
 		for j := commonLen; j < len(rColumns); j++ {
 			if lCol.ColName.L != rColumns[j].ColName.L {
 				continue
@@ -387,6 +547,7 @@ func (b *planBuilder) coalesceCommonColumns(p *LogicalJoin, leftPlan, rightPlan
 
 func (b *planBuilder) buildSelection(p LogicalPlan, where ast.ExprNode, AggMapper map[*ast.AggregateFuncExpr]int) LogicalPlan {
 	b.optFlag = b.optFlag | flagPredicatePushDown
+	b.optFlag = b.optFlag | flagSimplifyExpression
 	conditions := splitWhere(where)
 	expressions := make([]expression.Expression, 0, len(conditions))
 	selection := Selection{}.init(b.allocator, b.ctx)
@@ -480,6 +641,7 @@ func (b *planBuilder) buildProjectionField(id string, position int, field *ast.S
 // buildProjection returns a Projection plan and non-aux columns length.
 func (b *planBuilder) buildProjection(p LogicalPlan, fields []*ast.SelectField, mapper map[*ast.AggregateFuncExpr]int) (LogicalPlan, int) {
 	b.optFlag |= flagEliminateProjection
+	b.optFlag |= flagSimplifyExpression
 	proj := Projection{Exprs: make([]expression.Expression, 0, len(fields))}.init(b.allocator, b.ctx)
 	schema := expression.NewSchema(make([]*expression.Column, 0, len(fields))...)
 	oldLen := 0
@@ -574,6 +736,7 @@ func (b *planBuilder) buildUnion(union *ast.UnionStmt) LogicalPlan {
 	}
 
 	u.SetSchema(firstSchema)
+	u.KeepOrder = !union.Distinct && union.OrderBy == nil
 	var p LogicalPlan
 	p = u
 	if union.Distinct {
@@ -622,7 +785,7 @@ func (b *planBuilder) buildSort(p LogicalPlan, byItems []*ast.ByItem, aggMapper
 
 // getUintForLimitOffset gets uint64 value for limit/offset.
 // For ordinary statement, limit/offset should be uint64 constant value.
-// For prepared statement, limit/offset is string. We should convert it to uint64.
+// For prepared statement, limit/offset is the value of the user variable. We should convert it to uint64.
 func getUintForLimitOffset(sc *variable.StatementContext, val interface{}) (uint64, error) {
 	switch v := val.(type) {
 	case uint64:
@@ -634,6 +797,14 @@ func getUintForLimitOffset(sc *variable.StatementContext, val interface{}) (uint
 	case string:
 		uVal, err := types.StrToUint(sc, v)
 		return uVal, errors.Trace(err)
+	case float64, *types.MyDecimal:
+		d := types.NewDatum(v)
+		str, err := d.ToString()
+		if err != nil {
+			return 0, errors.Trace(err)
+		}
+		uVal, err := types.StrToUint(sc, str)
+		return uVal, errors.Trace(err)
 	}
 	return 0, errors.Errorf("Invalid type %T for Limit/Offset", val)
 }
@@ -647,14 +818,21 @@ func (b *planBuilder) buildLimit(src LogicalPlan, limit *ast.Limit) LogicalPlan
 		err           error
 	)
 	sc := b.ctx.GetSessionVars().StmtCtx
-	if limit.Offset != nil {
+	if b.paramTypes != nil {
+		// The values of the parameter markers are unknown at prepare time, they're taken as 0.
+		tp := types.NewFieldType(mysql.TypeLonglong)
+		tp.Flag |= mysql.UnsignedFlag
+		b.inferParamType(limit.Offset, tp)
+		b.inferParamType(limit.Count, tp)
+	}
+	if limit.Offset != nil && (b.paramTypes == nil || limit.Offset.GetValue() != nil) {
 		offset, err = getUintForLimitOffset(sc, limit.Offset.GetValue())
 		if err != nil {
 			b.err = ErrWrongArguments
 			return nil
 		}
 	}
-	if limit.Count != nil {
+	if limit.Count != nil && (b.paramTypes == nil || limit.Count.GetValue() != nil) {
 		count, err = getUintForLimitOffset(sc, limit.Count.GetValue())
 		if err != nil {
 			b.err = ErrWrongArguments
@@ -989,7 +1167,7 @@ func (b *planBuilder) unfoldWildStar(p LogicalPlan, selectFields []*ast.SelectFi
 		for _, col := range p.Schema().Columns {
 			if (dbName.L == "" || dbName.L == col.DBName.L) &&
 				(tblName.L == "" || tblName.L == col.TblName.L) &&
-				col.ID != model.ExtraHandleID {
+				col.ID != model.ExtraHandleID && !col.IsHidden {
 				colName := &ast.ColumnNameExpr{
 					Name: &ast.ColumnName{
 						Schema: col.DBName,
@@ -1096,8 +1274,16 @@ func (b *planBuilder) buildSelect(sel *ast.SelectStmt) LogicalPlan {
 		if b.err != nil {
 			return nil
 		}
+		var expand *Expand
+		if sel.GroupBy != nil && (sel.GroupBy.Rollup || sel.GroupBy.GroupingSets != nil) {
+			expand = b.buildExpand(p, sel.GroupBy, gbyCols)
+			if b.err != nil {
+				return nil
+			}
+			p, gbyCols = expand, expression.Column2Exprs(expand.groupingCols())
+		}
 		var aggIndexMap map[int]int
-		p, aggIndexMap = b.buildAggregation(p, aggFuncs, gbyCols)
+		p, aggIndexMap = b.buildAggregation(p, aggFuncs, gbyCols, expand)
 		for k, v := range totalMap {
 			totalMap[k] = aggIndexMap[v]
 		}
@@ -1133,6 +1319,9 @@ func (b *planBuilder) buildSelect(sel *ast.SelectStmt) LogicalPlan {
 		if b.err != nil {
 			return nil
 		}
+		if sel.SelectStmtOpts != nil && sel.SelectStmtOpts.CalcFoundRows {
+			p.(*Limit).CalcFoundRows = true
+		}
 	}
 	sel.Fields.Fields = originalFields
 	if sel.LockTp == ast.SelectLockForUpdate {
@@ -1178,9 +1367,20 @@ func (b *planBuilder) buildDataSource(tn *ast.TableName) LogicalPlan {
 		return nil
 	}
 	tableInfo := tbl.Meta()
+	if sample := tn.TableSample; sample != nil {
+		if infoschema.IsMemoryDB(schemaName.L) {
+			b.err = ErrSampleUnsupported.GenByArgs(schemaName.O, tableInfo.Name.O)
+			return nil
+		}
+		if sample.Method == ast.SampleBernoulli && (sample.Percent < 0 || sample.Percent > 100) {
+			b.err = ErrBadSamplePercentage.GenByArgs(sample.Percent)
+			return nil
+		}
+	}
 
 	p := DataSource{
 		indexHints:     tn.IndexHints,
+		sample:         tn.TableSample,
 		tableInfo:      tableInfo,
 		statisticTable: statisticTable,
 		DBName:         schemaName,
@@ -1207,11 +1407,16 @@ func (b *planBuilder) buildDataSource(tn *ast.TableName) LogicalPlan {
 			DBName:   schemaName,
 			RetType:  &col.FieldType,
 			Position: i,
-			ID:       col.ID})
+			ID:       col.ID,
+			IsHidden: col.Hidden})
 		if tableInfo.PKIsHandle && mysql.HasPriKeyFlag(col.Flag) {
 			pkCol = schema.Columns[schema.Len()-1]
 		}
 	}
+	if err = b.buildHiddenColumnExprs(p, columns, schema); err != nil {
+		b.err = errors.Trace(err)
+		return nil
+	}
 	needUnionScan := b.ctx.Txn() != nil && !b.ctx.Txn().IsReadOnly()
 	if b.needColHandle == 0 && !needUnionScan {
 		p.SetSchema(schema)
@@ -1411,6 +1616,7 @@ func (b *planBuilder) buildUpdateLists(tableList []*ast.TableName, list []*ast.A
 			b.err = errors.Trace(err)
 			return nil, nil
 		}
+		col = b.unmaskedColumn(col)
 		columnFullName := fmt.Sprintf("%s.%s.%s", col.DBName.L, col.TblName.L, col.ColName)
 		modifyColumns[columnFullName] = struct{}{}
 	}
@@ -1436,8 +1642,10 @@ func (b *planBuilder) buildUpdateLists(tableList []*ast.TableName, list []*ast.A
 			b.err = errors.Trace(err)
 			return nil, nil
 		}
+		col = b.unmaskedColumn(col)
 		var newExpr expression.Expression
 		var np LogicalPlan
+		b.inferParamType(assign.Expr, col.RetType)
 		newExpr, np, err = b.rewrite(assign.Expr, p, nil, false)
 		if err != nil {
 			b.err = errors.Trace(err)
@@ -1450,6 +1658,7 @@ func (b *planBuilder) buildUpdateLists(tableList []*ast.TableName, list []*ast.A
 }
 
 func (b *planBuilder) buildDelete(delete *ast.DeleteStmt) LogicalPlan {
+	b.inDeleteStmt = true
 	b.needColHandle++
 	sel := &ast.SelectStmt{Fields: &ast.FieldList{}, From: delete.TableRefs, Where: delete.Where, OrderBy: delete.Order, Limit: delete.Limit}
 	p := b.buildResultSetNode(sel.From.TableRefs)
@@ -14,7 +14,9 @@
 package plan
 
 import (
+	"fmt"
 	"sort"
+	"strings"
 	"testing"
 
 	. "github.com/pingcap/check"
@@ -468,8 +470,24 @@ func (s *testPlanSuite) TestPredicatePushDown(c *C) {
 		},
 		{
 			sql:  "select * from t ta left outer join t tb on ta.d = tb.d and ta.a > 1 where ta.d = 0",
+			best: "Join{DataScan(ta)->Selection->DataScan(tb)->Selection}(ta.d,tb.d)->Projection",
+		},
+		{
+			sql:  "select * from t ta left outer join t tb on ta.d = tb.d and ta.d > 1",
+			best: "Join{DataScan(ta)->DataScan(tb)->Selection}(ta.d,tb.d)->Projection",
+		},
+		{
+			sql:  "select * from t ta left outer join t tb on ta.d = tb.d where ta.d > rand()",
 			best: "Join{DataScan(ta)->Selection->DataScan(tb)}(ta.d,tb.d)->Projection",
 		},
+		{
+			sql:  "select * from t ta right outer join t tb on ta.d = tb.d where tb.d in (1, 2)",
+			best: "Join{DataScan(ta)->Selection->DataScan(tb)->Selection}(ta.d,tb.d)->Projection",
+		},
+		{
+			sql:  "select * from t ta where 1 = 1 and 2 > 1",
+			best: "DataScan(ta)->Projection",
+		},
 		{
 			sql:  "select * from t ta left outer join t tb on ta.d = tb.d and ta.a > 1 where tb.d = 0",
 			best: "Join{DataScan(ta)->Selection->DataScan(tb)->Selection}->Projection",
@@ -557,7 +575,7 @@ func (s *testPlanSuite) TestPredicatePushDown(c *C) {
 		// issue #3873
 		{
 			sql:  "select t1.a, t2.a from t as t1 left join t as t2 on t1.a = t2.a where t1.a < 1.0",
-			best: "Join{DataScan(t1)->Selection->DataScan(t2)}(t1.a,t2.a)->Projection",
+			best: "Join{DataScan(t1)->Selection->DataScan(t2)->Selection}(t1.a,t2.a)->Projection",
 		},
 	}
 	for _, ca := range tests {
@@ -729,7 +747,7 @@ func (s *testPlanSuite) TestJoinReOrder(c *C) {
 		},
 		{
 			sql:  "select * from t o where o.b in (select t3.c from t t1, t t2, t t3 where t1.a = t3.a and t2.a = t3.a and t2.a = o.a)",
-			best: "Apply{DataScan(o)->Join{Join{DataScan(t2)->Selection->DataScan(t3)}(t2.a,t3.a)->DataScan(t1)}(t3.a,t1.a)->Projection}->Projection",
+			best: "Apply{DataScan(o)->Join{Join{DataScan(t2)->Selection->DataScan(t3)->Selection}(t2.a,t3.a)->DataScan(t1)->Selection}(t3.a,t1.a)->Projection}->Projection",
 		},
 		{
 			sql:  "select * from t o where o.b in (select t3.c from t t1, t t2, t t3 where t1.a = t3.a and t2.a = t3.a and t2.a = o.a and t1.a = 1)",
@@ -803,7 +821,15 @@ func (s *testPlanSuite) TestAggPushDown(c *C) {
 		},
 		{
 			sql:  "select sum(a.a) from t a, t b, t c where a.c = b.c and b.c = c.c",
-			best: "Join{Join{DataScan(a)->DataScan(b)}(a.c,b.c)->DataScan(c)}(b.c,c.c)->Aggr(sum(a.a))->Projection",
+			best: "Join{Join{DataScan(a)->Aggr(sum(a.a),firstrow(a.c))->DataScan(b)}(a.c,b.c)->Aggr(sum(join_agg_0),firstrow(b.c))->DataScan(c)}(b.c,c.c)->Aggr(sum(join_agg_0))->Projection",
+		},
+		{
+			sql:  "select sum(a.a) from t a, t b, t c where a.c = b.c and a.d = c.d group by b.b, c.b",
+			best: "Join{Join{DataScan(a)->Aggr(sum(a.a),firstrow(a.d),firstrow(a.d),firstrow(a.c))->DataScan(b)}(a.c,b.c)->Aggr(sum(join_agg_0),firstrow(b.b),firstrow(join_agg_1))->DataScan(c)}(a.d,c.d)->Aggr(sum(join_agg_0))->Projection",
+		},
+		{
+			sql:  "select sum(x.a) from (select a.a + 1 as a, b.b from t a, t b where a.c = b.c) x group by x.b",
+			best: "Join{DataScan(a)->Aggr(sum(plus(a.a, 1)),firstrow(a.c))->DataScan(b)}(a.c,b.c)->Aggr(sum(join_agg_0))->Projection",
 		},
 		{
 			sql:  "select sum(b.a) from t a left join t b on a.c = b.c",
@@ -866,6 +892,81 @@ func (s *testPlanSuite) TestAggPushDown(c *C) {
 	}
 }
 
+// memoString describes the groups of the memo like "0:Projection(1) 1:Aggregation(2),Aggregation(5)", the numbers are
+// the indices of the groups.
+func memoString(m *memo) string {
+	indices := make(map[*group]int, len(m.groups))
+	for i, g := range m.groups {
+		indices[g] = i
+	}
+	strs := make([]string, 0, len(m.groups))
+	for i, g := range m.groups {
+		exprs := make([]string, 0, len(g.exprs))
+		for _, expr := range g.exprs {
+			name := strings.TrimPrefix(strings.TrimPrefix(fmt.Sprintf("%T", expr.node), "*plan."), "Logical")
+			children := make([]string, 0, len(expr.children))
+			for _, child := range expr.children {
+				children = append(children, fmt.Sprint(indices[child]))
+			}
+			if len(children) > 0 {
+				name += "(" + strings.Join(children, ",") + ")"
+			}
+			exprs = append(exprs, name)
+		}
+		strs = append(strs, fmt.Sprintf("%d:%s", i, strings.Join(exprs, ",")))
+	}
+	return strings.Join(strs, " ")
+}
+
+func (s *testPlanSuite) TestCascadesExplore(c *C) {
+	defer testleak.AfterTest(c)()
+	tests := []struct {
+		sql  string
+		memo string
+	}{
+		{
+			sql:  "select sum(a.a) from t a, t b where a.c = b.c",
+			memo: "0:DataSource 1:DataSource 2:Join(0,1),Projection(5) 3:Aggregation(2),Aggregation(7) 4:Projection(3) 5:Join(1,0) 6:Aggregation(0) 7:Join(6,1),Projection(8) 8:Join(1,6)",
+		},
+		{
+			sql:  "select sum(a.a), sum(b.a) from t a, t b where a.c = b.c",
+			memo: "0:DataSource 1:DataSource 2:Join(0,1),Projection(5) 3:Aggregation(2) 4:Projection(3) 5:Join(1,0)",
+		},
+		{
+			sql:  "select sum(b.a) from t a left join t b on a.c = b.c",
+			memo: "0:DataSource 1:DataSource 2:Join(0,1) 3:Aggregation(2),Aggregation(6) 4:Projection(3) 5:Aggregation(1) 6:Join(0,5)",
+		},
+		{
+			sql:  "select a.a from t a, t b, t c where a.c = b.c and b.c = c.c",
+			memo: "0:DataSource 1:DataSource 2:Join(0,1),Projection(6) 3:DataSource 4:Join(2,3),Projection(7) 5:Projection(4) 6:Join(1,0) 7:Join(3,2)",
+		},
+	}
+	for _, tt := range tests {
+		comment := Commentf("for %s", tt.sql)
+		stmt, err := s.ParseOneStmt(tt.sql, "", "")
+		c.Assert(err, IsNil, comment)
+
+		is, err := MockResolve(stmt)
+		c.Assert(err, IsNil)
+
+		builder := &planBuilder{
+			allocator: new(idAllocator),
+			ctx:       mockContext(),
+			colMapper: make(map[*ast.ColumnNameExpr]int),
+			is:        is,
+		}
+		builder.ctx.GetSessionVars().EnableCascadesPlanner = true
+		p := builder.build(stmt)
+		c.Assert(builder.err, IsNil)
+		lp, err := logicalOptimize(flagBuildKeyInfo|flagPredicatePushDown|flagPrunColumns|flagAggregationOptimize, p.(LogicalPlan), builder.ctx, builder.allocator)
+		c.Assert(err, IsNil)
+		m := newMemo(builder.ctx, builder.allocator)
+		m.insert(lp)
+		m.explore()
+		c.Assert(memoString(m), Equals, tt.memo, comment)
+	}
+}
+
 func (s *testPlanSuite) TestRefine(c *C) {
 	defer testleak.AfterTest(c)()
 	tests := []struct {
@@ -1492,6 +1593,15 @@ func (s *testPlanSuite) TestAggPrune(c *C) {
 			sql:  "select count(1) from (select count(1), a as b from t group by a) tt group by b",
 			best: "DataScan(t)->Projection->Projection->Projection->Projection",
 		},
+		// The distinct values of the primary key needn't be deduplicated.
+		{
+			sql:  "select distinct a, b from t",
+			best: "DataScan(t)->Projection->Projection",
+		},
+		{
+			sql:  "select distinct b from t",
+			best: "DataScan(t)->Aggr(firstrow(test.t.b))",
+		},
 	}
 	for _, tt := range tests {
 		comment := Commentf("for %s", tt.sql)
@@ -1655,6 +1765,73 @@ func (s *testPlanSuite) TestVisitInfo(c *C) {
 				{mysql.SuperPriv, "", "", ""},
 			},
 		},
+		{
+			sql: `admin plugins enable audit_log`,
+			ans: []visitInfo{
+				{mysql.SuperPriv, "", "", ""},
+			},
+		},
+		{
+			sql: `admin dump database test to 'test'`,
+			ans: []visitInfo{
+				{mysql.SuperPriv, "", "", ""},
+			},
+		},
+		{
+			sql: `admin enable failpoint 'tikv/regionMiss' = '2*off->return'`,
+			ans: []visitInfo{
+				{mysql.SuperPriv, "", "", ""},
+			},
+		},
+		{
+			sql: `admin disable failpoint 'tikv/regionMiss'`,
+			ans: []visitInfo{
+				{mysql.SuperPriv, "", "", ""},
+			},
+		},
+		{
+			sql: `set @@global.tidb_redact_log = 0`,
+			ans: []visitInfo{
+				{mysql.SuperPriv, "", "", ""},
+			},
+		},
+		{
+			sql: `set @@tidb_session_alias = 'a', @a = 1`,
+			ans: []visitInfo{},
+		},
+		{
+			sql: `lock tables t read`,
+			ans: []visitInfo{
+				{mysql.SelectPriv, "test", "t", ""},
+			},
+		},
+		{
+			sql: `lock tables test.t write`,
+			ans: []visitInfo{
+				{mysql.SelectPriv, "test", "t", ""},
+				{mysql.InsertPriv, "test", "t", ""},
+				{mysql.UpdatePriv, "test", "t", ""},
+				{mysql.DeletePriv, "test", "t", ""},
+			},
+		},
+		{
+			sql: `admin cleanup table lock t`,
+			ans: []visitInfo{
+				{mysql.SuperPriv, "", "", ""},
+			},
+		},
+		{
+			sql: `admin check index t c_d_e`,
+			ans: []visitInfo{
+				{mysql.SuperPriv, "", "", ""},
+			},
+		},
+		{
+			sql: `admin repair index t c_d_e (1, 10)`,
+			ans: []visitInfo{
+				{mysql.SuperPriv, "", "", ""},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -1850,3 +2027,72 @@ func (s *testPlanSuite) TestTopNPushDown(c *C) {
 		c.Assert(ToString(p), Equals, tt.best, comment)
 	}
 }
+
+func (s *testPlanSuite) TestSimplifyExpression(c *C) {
+	defer testleak.AfterTest(c)()
+	tests := []struct {
+		sql   string
+		conds string
+	}{
+		{
+			sql:   "select * from t where a > 1 and 1",
+			conds: "[gt(test.t.a, 1)]",
+		},
+		{
+			sql:   "select * from t where (b > 1 or 0) and not not c < 2",
+			conds: "[gt(test.t.b, 1) lt(test.t.c, 2)]",
+		},
+		{
+			sql:   "select * from t where b > 3 or 1 = 1",
+			conds: "",
+		},
+		{
+			sql:   "select * from t where b > 3 and 1 = 0",
+			conds: "[0]",
+		},
+		{
+			sql:   "select * from t where b > rand() and 0",
+			conds: "[gt(cast(test.t.b), rand()) 0]",
+		},
+		{
+			sql:   "select * from t where cast(b as signed) = 1",
+			conds: "[eq(test.t.b, 1)]",
+		},
+		{
+			sql:   "select * from t where cast(b as unsigned) = 1",
+			conds: "[eq(cast(test.t.b), 1)]",
+		},
+		{
+			sql:   "select * from t where cast(c_str as char) = 'a'",
+			conds: "[eq(test.t.c_str, a)]",
+		},
+		{
+			sql:   "select * from t where cast(c_str as char(1)) = 'a'",
+			conds: "[eq(cast(test.t.c_str), a)]",
+		},
+	}
+	for _, tt := range tests {
+		comment := Commentf("for %s", tt.sql)
+		stmt, err := s.ParseOneStmt(tt.sql, "", "")
+		c.Assert(err, IsNil, comment)
+
+		is, err := MockResolve(stmt)
+		c.Assert(err, IsNil)
+
+		builder := &planBuilder{
+			allocator: new(idAllocator),
+			ctx:       mockContext(),
+			is:        is,
+		}
+		p := builder.build(stmt).(LogicalPlan)
+		c.Assert(builder.err, IsNil)
+		c.Assert(builder.optFlag&flagSimplifyExpression, Greater, uint64(0))
+		p, err = logicalOptimize(flagSimplifyExpression, p, builder.ctx, builder.allocator)
+		c.Assert(err, IsNil)
+		conds := ""
+		if sel, ok := p.Children()[0].(*Selection); ok {
+			conds = fmt.Sprintf("%s", sel.Conditions)
+		}
+		c.Assert(conds, Equals, tt.conds, comment)
+	}
+}
@@ -31,6 +31,7 @@ var (
 	_ LogicalPlan = &LogicalApply{}
 	_ LogicalPlan = &Exists{}
 	_ LogicalPlan = &MaxOneRow{}
+	_ LogicalPlan = &Expand{}
 	_ LogicalPlan = &TableDual{}
 	_ LogicalPlan = &DataSource{}
 	_ LogicalPlan = &Union{}
@@ -257,6 +258,50 @@ type MaxOneRow struct {
 	basePhysicalPlan
 }
 
+// Expand duplicates every row of its child for each grouping set of "GROUP BY ... WITH ROLLUP" or
+// "GROUP BY GROUPING SETS (...)". Besides the columns of its child, it outputs a copy of each group-by column which
+// is null if the column isn't in the grouping set, and a grouping id, so the aggregation above it groups by them.
+type Expand struct {
+	*basePlan
+	baseLogicalPlan
+	basePhysicalPlan
+
+	GroupByCols []*expression.Column
+	// GroupingSets are the indices of the group-by columns in each grouping set.
+	GroupingSets [][]int
+}
+
+// groupingCols returns the copies of the group-by columns and the grouping id column, which are the last columns of
+// the schema.
+func (p *Expand) groupingCols() []*expression.Column {
+	return p.schema.Columns[p.schema.Len()-len(p.GroupByCols)-1:]
+}
+
+// groupByColIndex returns the index of the column in the group-by columns, or -1 if it isn't a group-by column or
+// the Expand is nil.
+func (p *Expand) groupByColIndex(col *expression.Column) int {
+	if p == nil {
+		return -1
+	}
+	for i, gbyCol := range p.GroupByCols {
+		if gbyCol.Equal(col, p.ctx) {
+			return i
+		}
+	}
+	return -1
+}
+
+// GroupingID returns the grouping id of a grouping set, the (n-1-i)-th bit of it is set if the i-th of the n
+// group-by columns isn't in the set, which is the result of GROUPING() on the columns.
+func (p *Expand) GroupingID(set []int) int64 {
+	n := len(p.GroupByCols)
+	id := int64(1)<<uint(n) - 1
+	for _, i := range set {
+		id &^= 1 << uint(n-1-i)
+	}
+	return id
+}
+
 // TableDual represents a dual table plan.
 type TableDual struct {
 	*basePlan
@@ -285,11 +330,24 @@ type DataSource struct {
 
 	statisticTable *statistics.Table
 
+	// sample is the TABLESAMPLE clause of the table, the sampled rows are read by a PhysicalMemTable.
+	sample *ast.TableSample
+
 	// NeedColHandle is used in execution phase.
 	NeedColHandle bool
 
 	// This is schema the PhysicalUnionScan should be.
 	unionScanSchema *expression.Schema
+
+	// hiddenExprs maps the IDs of the hidden columns of the expression indices to their expressions
+	// on the schema, they are used to match the conditions on the index keys.
+	hiddenExprs map[int64]expression.Expression
+	// remainedConds are the conditions which can't be pushed down, they are filtered above the data source,
+	// but they can build the ranges of the expression indices.
+	remainedConds []expression.Expression
+	// parentUsedCols are the columns used by the parents except the conditions of the selection above the data
+	// source, it's nil if they are unknown. A prefix index column only used by the conditions may not be read.
+	parentUsedCols []*expression.Column
 }
 
 func (p *DataSource) getPKIsHandleCol() *expression.Column {
@@ -322,6 +380,10 @@ type Union struct {
 	*basePlan
 	baseLogicalPlan
 	basePhysicalPlan
+
+	// KeepOrder means the rows of the children are returned in the order of the children. It's false if the union
+	// result is sorted or deduplicated, so the rows can be returned as soon as any child gets them.
+	KeepOrder bool
 }
 
 // Sort stands for the order by plan.
@@ -369,6 +431,9 @@ type Limit struct {
 
 	Offset uint64
 	Count  uint64
+	// CalcFoundRows is true if the statement has SQL_CALC_FOUND_ROWS, the rows beyond the limit are counted
+	// as the found rows too, so the limit can't be pushed down.
+	CalcFoundRows bool
 
 	// partial is true if this topn is generated by push-down optimization.
 	partial bool
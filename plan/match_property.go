@@ -17,7 +17,6 @@ import (
 	"math"
 
 	"github.com/pingcap/tidb/model"
-	"github.com/pingcap/tidb/util/types"
 )
 
 // matchProperty implements PhysicalPlan matchProperty interface.
@@ -123,12 +122,15 @@ func (is *PhysicalIndexScan) matchProperty(prop *requiredProperty, infos ...*phy
 	}
 	matchedIdx := 0
 	matchedList := make([]bool, len(prop.props))
+	// reverseList indicates whether the matched columns are in the reverse order of the index keys.
+	reverseList := make([]bool, len(prop.props))
 	for i, idxCol := range is.Index.Columns {
-		if idxCol.Length != types.UnspecifiedLength {
+		if !idxCol.IsFullColumn(&is.Table.Columns[idxCol.Offset].FieldType) {
 			break
 		}
 		if idx := matchPropColumn(prop, matchedIdx, idxCol); idx >= 0 {
 			matchedList[idx] = true
+			reverseList[idx] = prop.props[idx].desc != idxCol.Desc
 			matchedIdx++
 		} else if i >= is.accessEqualCount {
 			break
@@ -137,7 +139,7 @@ func (is *PhysicalIndexScan) matchProperty(prop *requiredProperty, infos ...*phy
 	if allMatch(matchedList) {
 		allDesc, allAsc := true, true
 		for i := 0; i < prop.sortKeyLen; i++ {
-			if prop.props[i].desc {
+			if reverseList[i] {
 				allAsc = false
 			} else {
 				allDesc = false
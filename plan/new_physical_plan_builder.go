@@ -26,7 +26,6 @@ import (
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/util/ranger"
-	"github.com/pingcap/tidb/util/types"
 )
 
 // wholeTaskTypes records all possible kinds of task that a plan can return. For Agg, TopN and Limit, we will try to get
@@ -84,12 +83,9 @@ func joinKeysMatchIndex(keys []*expression.Column, index *model.IndexInfo) []int
 	}
 	matchOffsets := make([]int, len(keys))
 	for i, idxCol := range index.Columns {
-		if idxCol.Length != types.UnspecifiedLength {
-			return nil
-		}
 		found := false
 		for j, key := range keys {
-			if idxCol.Name.L == key.ColName.L {
+			if idxCol.Name.L == key.ColName.L && idxCol.IsFullColumn(key.RetType) {
 				matchOffsets[i] = j
 				found = true
 				break
@@ -151,10 +147,11 @@ func (p *LogicalJoin) getIndexJoinByOuterIdx(outerIdx int) []PhysicalPlan {
 		outerJoinKeys = p.RightJoinKeys
 	}
 	x, ok := innerChild.(*DataSource)
-	if !ok {
+	// The sampled inner table can't be read by the index join.
+	if !ok || x.sample != nil {
 		return nil
 	}
-	indices, includeTableScan := availableIndices(x.indexHints, x.tableInfo)
+	indices, includeTableScan := availableIndices(x.indexHints, x.tableInfo, x.ctx.GetSessionVars().OptimizerUseInvisibleIndexes, supportDescIndex(x.ctx))
 	if includeTableScan && len(innerJoinKeys) == 1 {
 		pkCol := x.getPKIsHandleCol()
 		if pkCol != nil && innerJoinKeys[0].Equal(pkCol, nil) {
@@ -472,6 +469,7 @@ func (p *Sort) convert2NewPhysicalPlan(prop *requiredProp) (task, error) {
 		return nil, errors.Trace(err)
 	}
 	t = p.attach2Task(t)
+	candidates := []task{t}
 	newProp, canPassProp := getPropByOrderByItems(p.ByItems)
 	if canPassProp {
 		newProp.expectedCnt = prop.expectedCnt
@@ -479,10 +477,12 @@ func (p *Sort) convert2NewPhysicalPlan(prop *requiredProp) (task, error) {
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
+		candidates = append(candidates, orderedTask)
 		if orderedTask.cost() < t.cost() {
 			t = orderedTask
 		}
 	}
+	traceCandidates(p, prop, candidates, t)
 	t = prop.enforceProperty(t, p.ctx, p.allocator)
 	return t, p.storeTask(prop, t)
 }
@@ -510,24 +510,32 @@ func (p *baseLogicalPlan) convert2NewPhysicalPlan(prop *requiredProp) (task, err
 		return t, p.storeTask(prop, t)
 	}
 	// Else we suppose it only has one child.
+	var candidates []task
 	for _, pp := range p.basePlan.self.(LogicalPlan).generatePhysicalPlans() {
 		// We consider to add enforcer firstly.
-		t, err = p.getBestTask(t, prop, pp, true)
+		candidates, err = p.appendCandidateTasks(candidates, prop, pp, true)
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
 		if prop.isEmpty() {
 			continue
 		}
-		t, err = p.getBestTask(t, prop, pp, false)
+		candidates, err = p.appendCandidateTasks(candidates, prop, pp, false)
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
 	}
+	for _, candidate := range candidates {
+		if candidate.cost() < t.cost() {
+			t = candidate
+		}
+	}
+	traceCandidates(p.basePlan.self.(LogicalPlan), prop, candidates, t)
 	return t, p.storeTask(prop, t)
 }
 
-func (p *baseLogicalPlan) getBestTask(bestTask task, prop *requiredProp, pp PhysicalPlan, enforced bool) (task, error) {
+// appendCandidateTasks appends the tasks of the physical plan for all the possible properties of its children.
+func (p *baseLogicalPlan) appendCandidateTasks(candidates []task, prop *requiredProp, pp PhysicalPlan, enforced bool) ([]task, error) {
 	var newProps [][]*requiredProp
 	if enforced {
 		newProps = pp.getChildrenPossibleProps(&requiredProp{taskTp: rootTaskType, expectedCnt: math.MaxFloat64})
@@ -547,11 +555,9 @@ func (p *baseLogicalPlan) getBestTask(bestTask task, prop *requiredProp, pp Phys
 		if enforced {
 			resultTask = prop.enforceProperty(resultTask, p.basePlan.ctx, p.basePlan.allocator)
 		}
-		if resultTask.cost() < bestTask.cost() {
-			bestTask = resultTask
-		}
+		candidates = append(candidates, resultTask)
 	}
-	return bestTask, nil
+	return candidates, nil
 }
 
 func addUnionScan(cop *copTask, ds *DataSource) task {
@@ -570,7 +576,8 @@ func (p *DataSource) tryToGetMemTask(prop *requiredProp) (task task, err error)
 	client := p.ctx.GetClient()
 	memDB := infoschema.IsMemoryDB(p.DBName.L)
 	isDistReq := !memDB && client != nil && client.IsRequestTypeSupported(kv.ReqTypeSelect, 0)
-	if isDistReq {
+	// The sampled rows are read by the mem table, because the coprocessor can't sample the rows.
+	if isDistReq && p.sample == nil {
 		return nil, nil
 	}
 	memTable := PhysicalMemTable{
@@ -578,6 +585,7 @@ func (p *DataSource) tryToGetMemTask(prop *requiredProp) (task task, err error)
 		Table:       p.tableInfo,
 		Columns:     p.Columns,
 		TableAsName: p.TableAsName,
+		Sample:      p.sample,
 	}.init(p.allocator, p.ctx)
 	memTable.SetSchema(p.schema)
 	memTable.Ranges = ranger.FullIntRange()
@@ -619,7 +627,7 @@ func (p *DataSource) tryToGetDualTask() (task, error) {
 }
 
 // convert2NewPhysicalPlan implements the PhysicalPlan interface.
-// It will enumerate all the available indices and choose a plan with least cost.
+// It will enumerate the available indices which aren't pruned by skylinePruning and choose a plan with least cost.
 func (p *DataSource) convert2NewPhysicalPlan(prop *requiredProp) (task, error) {
 	t, err := p.getTask(prop)
 	if err != nil {
@@ -643,25 +651,35 @@ func (p *DataSource) convert2NewPhysicalPlan(prop *requiredProp) (task, error) {
 		return t, p.storeTask(prop, t)
 	}
 	// TODO: We have not checked if this table has a predicate. If not, we can only consider table scan.
-	indices, includeTableScan := availableIndices(p.indexHints, p.tableInfo)
+	indices, includeTableScan := availableIndices(p.indexHints, p.tableInfo, p.ctx.GetSessionVars().OptimizerUseInvisibleIndexes, supportDescIndex(p.ctx))
 	t = invalidTask
+	var candidates []task
 	if includeTableScan {
 		t, err = p.convertToTableScan(prop)
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
+		candidates = append(candidates, t)
 	}
-	if !includeTableScan || len(p.pushedDownConds) > 0 || len(prop.cols) > 0 {
-		for _, idx := range indices {
+	if !includeTableScan || len(p.pushedDownConds) > 0 || (len(p.hiddenExprs) > 0 && len(p.remainedConds) > 0) || len(prop.cols) > 0 {
+		remained := p.skylinePruning(prop, indices, includeTableScan)
+		for _, idx := range remained {
 			idxTask, err := p.convertToIndexScan(prop, idx)
 			if err != nil {
 				return nil, errors.Trace(err)
 			}
+			candidates = append(candidates, idxTask)
 			if idxTask.cost() < t.cost() {
 				t = idxTask
 			}
 		}
+		if len(remained) < len(indices) {
+			traceSkippedIndices(p, prop, prunedIndices(indices, remained), "dominated by another access path in the skyline pruning")
+		}
+	} else {
+		traceSkippedIndices(p, prop, indices, "no condition or order can use the index")
 	}
+	traceCandidates(p, prop, candidates, t)
 	return t, p.storeTask(prop, t)
 }
 
@@ -679,20 +697,26 @@ func (p *DataSource) convertToIndexScan(prop *requiredProp, idx *model.IndexInfo
 	statsTbl := p.statisticTable
 	rowCount := float64(statsTbl.Count)
 	sc := p.ctx.GetSessionVars().StmtCtx
+	exprIndex := isExpressionIndex(p.tableInfo, idx)
 	idxCols, colLengths := expression.IndexInfo2Cols(p.Schema().Columns, idx)
 	is.Ranges = ranger.FullIndexRange()
-	if len(p.pushedDownConds) > 0 {
+	if len(p.pushedDownConds) > 0 || (exprIndex && len(p.remainedConds) > 0) {
 		conds := make([]expression.Expression, 0, len(p.pushedDownConds))
 		for _, cond := range p.pushedDownConds {
 			conds = append(conds, cond.Clone())
 		}
+		if exprIndex {
+			// The conditions which can't be pushed down are filtered above, they only build the ranges.
+			for _, cond := range p.remainedConds {
+				conds = append(conds, cond.Clone())
+			}
+			idxCols, colLengths, conds = p.expressionIndexCols(idx, conds)
+		}
 		if len(idxCols) > 0 {
-			var ranges []types.Range
-			ranges, is.AccessCondition, is.filterCondition, err = ranger.BuildRange(sc, conds, ranger.IndexRangeType, idxCols, colLengths)
+			is.Ranges, is.AccessCondition, is.filterCondition, err = ranger.BuildRange(sc, conds, ranger.IndexRangeType, idxCols, colLengths)
 			if err != nil {
 				return nil, errors.Trace(err)
 			}
-			is.Ranges = ranger.Ranges2IndexRanges(ranges)
 			rowCount, err = statsTbl.GetRowCountByIndexRanges(sc, is.Index.ID, is.Ranges)
 			if err != nil {
 				return nil, errors.Trace(err)
@@ -700,12 +724,22 @@ func (p *DataSource) convertToIndexScan(prop *requiredProp, idx *model.IndexInfo
 		} else {
 			is.filterCondition = conds
 		}
+		if exprIndex {
+			// The substituted conditions only build the ranges, the values of the hidden columns aren't
+			// stored in the rows, so all the pushed down conditions are checked on the rows.
+			is.AccessCondition = nil
+			is.filterCondition = make([]expression.Expression, 0, len(p.pushedDownConds))
+			for _, cond := range p.pushedDownConds {
+				is.filterCondition = append(is.filterCondition, cond.Clone())
+			}
+		}
 	}
 	is.profile = p.getStatsProfileByFilter(p.pushedDownConds)
 	cop := &copTask{
 		indexPlan: is,
 	}
-	if !isCoveringIndex(is.Columns, is.Index.Columns, is.Table.PKIsHandle) {
+	// The expression index is always double read, because the columns of the expressions aren't in the index.
+	if exprIndex || (!isCoveringIndex(is.Columns, is.Index.Columns, is.Table.PKIsHandle) && !p.isCoveredByPrefixIndex(is.Index, is.filterCondition)) {
 		// On this way, it's double read case.
 		cop.tablePlan = PhysicalTableScan{Columns: p.Columns, Table: is.Table}.init(p.allocator, p.ctx)
 		cop.tablePlan.SetSchema(is.dataSourceSchema)
@@ -731,20 +765,7 @@ func (p *DataSource) convertToIndexScan(prop *requiredProp, idx *model.IndexInfo
 	}
 	is.SetSchema(expression.NewSchema(indexCols...))
 	// Check if this plan matches the property.
-	matchProperty := false
-	if !prop.isEmpty() {
-		for i, col := range idx.Columns {
-			// not matched
-			if col.Name.L == prop.cols[0].ColName.L {
-				matchProperty = matchIndicesProp(idx.Columns[i:], prop.cols)
-				break
-			} else if i >= len(is.AccessCondition) {
-				break
-			} else if sf, ok := is.AccessCondition[i].(*expression.ScalarFunction); !ok || sf.FuncName.L != ast.EQ {
-				break
-			}
-		}
-	}
+	matchProperty, reverse := matchIndexProp(idx, is.AccessCondition, prop)
 	if matchProperty && prop.expectedCnt < math.MaxFloat64 {
 		selectivity, err := p.statisticTable.Selectivity(p.ctx, is.filterCondition)
 		if err != nil {
@@ -754,12 +775,12 @@ func (p *DataSource) convertToIndexScan(prop *requiredProp, idx *model.IndexInfo
 		rowCount = math.Min(prop.expectedCnt/selectivity, rowCount)
 	}
 	is.expectedCnt = rowCount
-	cop.cst = rowCount * scanFactor
+	cop.cst = rowCount * costFactors(p.ctx).Scan
 	task = cop
 	if matchProperty {
-		if prop.desc {
+		if reverse {
 			is.Desc = true
-			cop.cst = rowCount * descScanFactor
+			cop.cst = rowCount * costFactors(p.ctx).DescScan
 		}
 		is.addPushedDownSelection(cop, p, prop.expectedCnt)
 		if p.unionScanSchema != nil {
@@ -785,6 +806,61 @@ func (p *DataSource) convertToIndexScan(prop *requiredProp, idx *model.IndexInfo
 	return task, nil
 }
 
+// matchIndexProp checks whether the index matches the property, the index columns before the property columns
+// should be used by the equal access conditions.
+func matchIndexProp(idx *model.IndexInfo, accessConds []expression.Expression, prop *requiredProp) (matched bool, reverse bool) {
+	if prop.isEmpty() {
+		return false, false
+	}
+	for i, col := range idx.Columns {
+		if col.Name.L == prop.cols[0].ColName.L {
+			return matchIndicesProp(idx.Columns[i:], prop.cols, prop.desc)
+		} else if i >= len(accessConds) {
+			break
+		} else if sf, ok := accessConds[i].(*expression.ScalarFunction); !ok || sf.FuncName.L != ast.EQ {
+			break
+		}
+	}
+	return false, false
+}
+
+// isCoveredByPrefixIndex checks whether the index scan needn't read the table though some columns are prefix
+// index columns. The full values of a prefix column aren't needed if it's only used by the access conditions,
+// which are checked exactly by the ranges on the prefix keys.
+func (p *DataSource) isCoveredByPrefixIndex(idx *model.IndexInfo, filterConds []expression.Expression) bool {
+	if p.parentUsedCols == nil || p.unionScanSchema != nil || !idx.HasPrefixIndex() {
+		return false
+	}
+	neededCols := make([]*expression.Column, 0, len(p.parentUsedCols))
+	neededCols = append(neededCols, p.parentUsedCols...)
+	for _, cond := range filterConds {
+		neededCols = append(neededCols, expression.ExtractColumns(cond)...)
+	}
+	for _, cond := range p.remainedConds {
+		neededCols = append(neededCols, expression.ExtractColumns(cond)...)
+	}
+	neededSchema := expression.NewSchema(neededCols...)
+	for i, colInfo := range p.Columns {
+		if isCoveringIndex([]*model.ColumnInfo{colInfo}, idx.Columns, p.tableInfo.PKIsHandle) {
+			continue
+		}
+		if findIndexColumn(idx, colInfo) == nil || neededSchema.Contains(p.schema.Columns[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// findIndexColumn finds the index column of the table column.
+func findIndexColumn(idx *model.IndexInfo, colInfo *model.ColumnInfo) *model.IndexColumn {
+	for _, idxCol := range idx.Columns {
+		if idxCol.Name.L == colInfo.Name.L {
+			return idxCol
+		}
+	}
+	return nil
+}
+
 func (is *PhysicalIndexScan) addPushedDownSelection(copTask *copTask, p *DataSource, expectedCnt float64) {
 	// Add filter condition to table plan now.
 	if len(is.filterCondition) > 0 {
@@ -802,7 +878,7 @@ func (is *PhysicalIndexScan) addPushedDownSelection(copTask *copTask, p *DataSou
 			// FIXME: It is not precise.
 			indexSel.expectedCnt = expectedCnt
 			copTask.indexPlan = indexSel
-			copTask.cst += copTask.count() * cpuFactor
+			copTask.cst += copTask.count() * costFactors(p.ctx).CPU
 		}
 		if tableConds != nil {
 			copTask.finishIndexPlan()
@@ -812,21 +888,29 @@ func (is *PhysicalIndexScan) addPushedDownSelection(copTask *copTask, p *DataSou
 			tableSel.profile = p.profile
 			tableSel.expectedCnt = expectedCnt
 			copTask.tablePlan = tableSel
-			copTask.cst += copTask.count() * cpuFactor
+			copTask.cst += copTask.count() * costFactors(p.ctx).CPU
 		}
 	}
 }
 
-func matchIndicesProp(idxCols []*model.IndexColumn, propCols []*expression.Column) bool {
+// matchIndicesProp checks whether the index columns match the property columns. If they match, reverse indicates
+// the index should be scanned in the reverse order of the keys, the keys of a descending column are in the reverse
+// order of the values.
+func matchIndicesProp(idxCols []*model.IndexColumn, propCols []*expression.Column, desc bool) (matched bool, reverse bool) {
 	if len(idxCols) < len(propCols) {
-		return false
+		return false, false
 	}
 	for i, col := range propCols {
-		if idxCols[i].Length != types.UnspecifiedLength || col.ColName.L != idxCols[i].Name.L {
-			return false
+		if !idxCols[i].IsFullColumn(col.RetType) || col.ColName.L != idxCols[i].Name.L {
+			return false, false
+		}
+		colReverse := desc != idxCols[i].Desc
+		if i > 0 && colReverse != reverse {
+			return false, false
 		}
+		reverse = colReverse
 	}
-	return true
+	return true, reverse
 }
 
 // convertToTableScan converts the DataSource to table scan.
@@ -856,9 +940,7 @@ func (p *DataSource) convertToTableScan(prop *requiredProp) (task task, err erro
 			conds = append(conds, cond.Clone())
 		}
 		if pkCol != nil {
-			var ranges []types.Range
-			ranges, ts.AccessCondition, ts.filterCondition, err = ranger.BuildRange(sc, conds, ranger.IntRangeType, []*expression.Column{pkCol}, nil)
-			ts.Ranges = ranger.Ranges2IntRanges(ranges)
+			ts.Ranges, ts.AccessCondition, ts.filterCondition, err = ranger.BuildRange(sc, conds, ranger.IntRangeType, []*expression.Column{pkCol}, nil)
 			if err != nil {
 				return nil, errors.Trace(err)
 			}
@@ -891,11 +973,11 @@ func (p *DataSource) convertToTableScan(prop *requiredProp) (task task, err erro
 		rowCount = math.Min(prop.expectedCnt/selectivity, rowCount)
 	}
 	ts.expectedCnt = rowCount
-	copTask.cst = rowCount * scanFactor
+	copTask.cst = rowCount * costFactors(p.ctx).Scan
 	if matchProperty {
 		if prop.desc {
 			ts.Desc = true
-			copTask.cst = rowCount * descScanFactor
+			copTask.cst = rowCount * costFactors(p.ctx).DescScan
 		}
 		ts.KeepOrder = true
 		ts.addPushedDownSelection(copTask, p.profile, prop.expectedCnt)
@@ -931,7 +1013,7 @@ func (ts *PhysicalTableScan) addPushedDownSelection(copTask *copTask, profile *s
 		sel.expectedCnt = expectedCnt
 		copTask.tablePlan = sel
 		// FIXME: It seems wrong...
-		copTask.cst += copTask.count() * cpuFactor
+		copTask.cst += copTask.count() * costFactors(ts.ctx).CPU
 	}
 }
 
@@ -967,6 +1049,19 @@ func (p *basePhysicalPlan) getChildrenPossibleProps(prop *requiredProp) [][]*req
 	return [][]*requiredProp{props}
 }
 
+// getChildrenPossibleProps pushes the property to the child only if it's on the columns of the child, because the
+// duplicated rows of a child row are output together, but the copies of the group-by columns aren't ordered.
+func (p *Expand) getChildrenPossibleProps(prop *requiredProp) [][]*requiredProp {
+	for _, col := range prop.cols {
+		if p.children[0].Schema().ColumnIndex(col) == -1 {
+			return nil
+		}
+	}
+	p.expectedCnt = prop.expectedCnt
+	newProp := &requiredProp{taskTp: rootTaskType, cols: prop.cols, desc: prop.desc, expectedCnt: math.MaxFloat64}
+	return [][]*requiredProp{{newProp}}
+}
+
 func (p *PhysicalHashJoin) getChildrenPossibleProps(prop *requiredProp) [][]*requiredProp {
 	if !prop.isEmpty() {
 		return nil
@@ -1006,6 +1101,9 @@ func (p *Limit) getChildrenPossibleProps(prop *requiredProp) [][]*requiredProp {
 	props := make([][]*requiredProp, 0, len(wholeTaskTypes))
 	for _, tp := range wholeTaskTypes {
 		newProp := &requiredProp{taskTp: tp, expectedCnt: float64(p.Count + p.Offset)}
+		if p.CalcFoundRows {
+			newProp.expectedCnt = math.MaxFloat64
+		}
 		if p.expectedProp != nil {
 			newProp.cols = p.expectedProp.cols
 			newProp.desc = p.expectedProp.desc
@@ -1036,7 +1134,44 @@ func (p *LogicalAggregation) generatePhysicalPlans() []PhysicalPlan {
 	}.init(p.allocator, p.ctx)
 	ha.SetSchema(p.schema)
 	ha.profile = p.profile
-	return []PhysicalPlan{ha}
+	return append(p.generateStreamAggs(), ha)
+}
+
+// generateStreamAggs generates a stream aggregation for every possible property of the child which starts with
+// all the group-by columns, the child is read in that order, so the groups are emitted one by one without a hash table.
+func (p *LogicalAggregation) generateStreamAggs() []PhysicalPlan {
+	gbyCols := p.groupByCols
+	// group by a + b is not interested in any order.
+	if len(gbyCols) == 0 || len(gbyCols) != len(p.GroupByItems) {
+		return nil
+	}
+	for _, aggFunc := range p.AggFuncs {
+		if aggFunc.GetMode() == expression.FinalMode {
+			return nil
+		}
+	}
+	gbySchema := expression.NewSchema(gbyCols...)
+	var aggs []PhysicalPlan
+	for _, cols := range p.possibleProperties {
+		if len(cols) < len(gbyCols) {
+			continue
+		}
+		keys := cols[:len(gbyCols)]
+		if len(gbySchema.ColumnsIndices(keys)) == 0 {
+			continue
+		}
+		agg := PhysicalAggregation{
+			GroupByItems: p.GroupByItems,
+			AggFuncs:     p.AggFuncs,
+			HasGby:       true,
+			AggType:      StreamedAgg,
+			propKeys:     keys,
+		}.init(p.allocator, p.ctx)
+		agg.SetSchema(p.schema)
+		agg.profile = p.profile
+		aggs = append(aggs, agg)
+	}
+	return aggs
 }
 
 func (p *PhysicalAggregation) getChildrenPossibleProps(prop *requiredProp) [][]*requiredProp {
@@ -1044,6 +1179,9 @@ func (p *PhysicalAggregation) getChildrenPossibleProps(prop *requiredProp) [][]*
 	if !prop.isEmpty() {
 		return nil
 	}
+	if p.AggType == StreamedAgg {
+		return [][]*requiredProp{{&requiredProp{taskTp: rootTaskType, cols: p.propKeys, expectedCnt: math.MaxFloat64}}}
+	}
 	props := make([][]*requiredProp, 0, len(wholeTaskTypes))
 	for _, tp := range wholeTaskTypes {
 		props = append(props, []*requiredProp{{taskTp: tp, expectedCnt: math.MaxFloat64}})
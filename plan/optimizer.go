@@ -15,15 +15,20 @@ package plan
 
 import (
 	"math"
+	"strings"
 
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/config"
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/infoschema"
+	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/privilege"
+	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/types"
 )
 
 // AllowCartesianProduct means whether tidb allows cartesian join without equal conditions.
@@ -34,6 +39,7 @@ const (
 	flagEliminateProjection
 	flagBuildKeyInfo
 	flagDecorrelate
+	flagSimplifyExpression
 	flagPredicatePushDown
 	flagAggregationOptimize
 	flagPushDownTopN
@@ -44,6 +50,7 @@ var optRuleList = []logicalOptRule{
 	&projectionEliminater{},
 	&buildKeySolver{},
 	&decorrelateSolver{},
+	&exprSimplifier{},
 	&ppdSolver{},
 	&aggregationOptimizer{},
 	&pushDownTopNOptimizer{},
@@ -80,6 +87,11 @@ func Optimize(ctx context.Context, node ast.Node, is infoschema.InfoSchema) (Pla
 			return nil, errors.New("privilege check fail")
 		}
 	}
+	if _, ok := node.(*ast.LockTablesStmt); !ok {
+		if err := checkTableLock(ctx, is, builder.visitInfo); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
 
 	if logic, ok := p.(LogicalPlan); ok {
 		return doOptimize(builder.optFlag, logic, ctx, allocator)
@@ -106,6 +118,48 @@ func BuildLogicalPlan(ctx context.Context, node ast.Node, is infoschema.InfoSche
 	return p, nil
 }
 
+// InferPrepareTypes builds the logical plan of a prepared statement without evaluating anything, it returns the
+// schema of the result and the types of the parameter markers. The type of a parameter marker is inferred from the
+// expression it's compared or computed with, or the column it's assigned to, the unknown ones are binary strings.
+func InferPrepareTypes(ctx context.Context, node ast.Node, is infoschema.InfoSchema, params []*ast.ParamMarkerExpr) (*expression.Schema, []*types.FieldType, error) {
+	if err := expression.InferType(ctx.GetSessionVars().StmtCtx, node); err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	builder := &planBuilder{
+		ctx:         ctx,
+		is:          is,
+		colMapper:   make(map[*ast.ColumnNameExpr]int),
+		allocator:   new(idAllocator),
+		paramTypes:  make(map[*ast.ParamMarkerExpr]*types.FieldType),
+		paramConsts: make(map[*expression.Constant]*ast.ParamMarkerExpr),
+	}
+	p := builder.build(node)
+	if builder.err != nil {
+		return nil, nil, errors.Trace(builder.err)
+	}
+	paramTypes := make([]*types.FieldType, 0, len(params))
+	for _, param := range params {
+		tp, ok := builder.paramTypes[param]
+		if !ok {
+			tp = NewUnknownParamType()
+		}
+		paramTypes = append(paramTypes, tp)
+	}
+	schema := p.Schema()
+	if schema == nil {
+		schema = expression.NewSchema()
+	}
+	return schema, paramTypes, nil
+}
+
+// NewUnknownParamType returns the type of a parameter marker whose type can't be inferred, any value can be
+// sent for it as a binary string.
+func NewUnknownParamType() *types.FieldType {
+	tp := types.NewFieldType(mysql.TypeVarString)
+	types.SetBinChsClnFlag(tp)
+	return tp
+}
+
 func checkPrivilege(pm privilege.Manager, vs []visitInfo) bool {
 	for _, v := range vs {
 		if !pm.RequestVerification(v.db, v.table, v.column, v.privilege) {
@@ -115,6 +169,59 @@ func checkPrivilege(pm privilege.Manager, vs []visitInfo) bool {
 	return true
 }
 
+// checkTableLock checks the tables visited by the statement against the table locks if they are
+// enabled. A session holding table locks can only visit the tables it locked, and only write the
+// tables it locked for WRITE. The other sessions can't write a locked table, or read a table locked
+// for WRITE. The system and memory tables are never locked.
+func checkTableLock(ctx context.Context, is infoschema.InfoSchema, vs []visitInfo) error {
+	if !config.GetGlobalConfig().EnableTableLock {
+		return nil
+	}
+	vars := ctx.GetSessionVars()
+	for _, v := range vs {
+		if v.table == "" {
+			continue
+		}
+		db := strings.ToLower(v.db)
+		if db == "" {
+			db = strings.ToLower(vars.CurrentDB)
+		}
+		if db == mysql.SystemDB || infoschema.IsMemoryDB(db) {
+			continue
+		}
+		tbl, err := is.TableByName(model.NewCIStr(db), model.NewCIStr(v.table))
+		if err != nil {
+			// The table of CREATE TABLE doesn't exist.
+			continue
+		}
+		tblInfo := tbl.Meta()
+		write := v.privilege != mysql.SelectPriv
+		if len(vars.TableLocks) > 0 {
+			if err = checkLockedTable(vars.TableLocks, tblInfo, write); err != nil {
+				return errors.Trace(err)
+			}
+			continue
+		}
+		if tblInfo.Lock != nil && (write || tblInfo.Lock.Tp == model.TableLockWrite) {
+			return infoschema.ErrTableLocked.GenByArgs(tblInfo.Name.O, tblInfo.Lock.Tp, tblInfo.Lock.Sessions[0])
+		}
+	}
+	return nil
+}
+
+func checkLockedTable(locks []variable.TableLock, tblInfo *model.TableInfo, write bool) error {
+	for _, l := range locks {
+		if l.TableID != tblInfo.ID {
+			continue
+		}
+		if write && !l.Write {
+			return infoschema.ErrTableNotLockedForWrite.GenByArgs(tblInfo.Name.O)
+		}
+		return nil
+	}
+	return infoschema.ErrTableNotLocked.GenByArgs(tblInfo.Name.O)
+}
+
 func doOptimize(flag uint64, logic LogicalPlan, ctx context.Context, allocator *idAllocator) (PhysicalPlan, error) {
 	logic, err := logicalOptimize(flag, logic, ctx, allocator)
 	if err != nil {
@@ -124,7 +231,9 @@ func doOptimize(flag uint64, logic LogicalPlan, ctx context.Context, allocator *
 		return nil, errors.Trace(ErrCartesianProductUnsupported)
 	}
 	var physical PhysicalPlan
-	if UseDAGPlanBuilder(ctx) {
+	if UseDAGPlanBuilder(ctx) && ctx.GetSessionVars().EnableCascadesPlanner {
+		physical, err = cascadesPhysicalOptimize(logic, ctx, allocator)
+	} else if UseDAGPlanBuilder(ctx) {
 		physical, err = dagPhysicalOptimize(logic)
 	} else {
 		physical, err = physicalOptimize(flag, logic, allocator)
@@ -133,11 +242,15 @@ func doOptimize(flag uint64, logic LogicalPlan, ctx context.Context, allocator *
 		return nil, errors.Trace(err)
 	}
 	finalPlan := eliminatePhysicalProjection(physical)
+	if trace := ctx.GetSessionVars().StmtCtx.OptimizerTrace; trace != nil {
+		trace.FinalPlan = ToString(finalPlan)
+	}
 	return finalPlan, nil
 }
 
 func logicalOptimize(flag uint64, logic LogicalPlan, ctx context.Context, alloc *idAllocator) (LogicalPlan, error) {
 	var err error
+	trace := ctx.GetSessionVars().StmtCtx.OptimizerTrace
 	for i, rule := range optRuleList {
 		// The order of flags is same as the order of optRule in the list.
 		// We use a bitmask to record which opt rules should be used. If the i-th bit is 1, it means we should
@@ -145,10 +258,17 @@ func logicalOptimize(flag uint64, logic LogicalPlan, ctx context.Context, alloc
 		if flag&(1<<uint(i)) == 0 {
 			continue
 		}
+		var before string
+		if trace != nil {
+			before = ToString(logic)
+		}
 		logic, err = rule.optimize(logic, ctx, alloc)
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
+		if trace != nil {
+			trace.AppendRule(ruleName(rule), before, ToString(logic))
+		}
 	}
 	return logic, errors.Trace(err)
 }
@@ -160,6 +280,9 @@ func dagPhysicalOptimize(logic LogicalPlan) (PhysicalPlan, error) {
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
+	if trace := optimizerTrace(logic); trace != nil {
+		trace.FinalCost = t.cost()
+	}
 	p := t.plan()
 	rebuildSchema(p)
 	p.ResolveIndices()
@@ -236,4 +359,5 @@ func init() {
 	}
 	terror.ErrClassToMySQLCodes[terror.ClassOptimizer] = mySQLErrCodes
 	expression.EvalAstExpr = evalAstExpr
+	expression.RewriteAstExprOnTable = rewriteAstExprOnTable
 }
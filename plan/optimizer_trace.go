@@ -0,0 +1,120 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plan
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/util/tracing"
+)
+
+func optimizerTrace(p Plan) *tracing.OptimizerTrace {
+	return p.context().GetSessionVars().StmtCtx.OptimizerTrace
+}
+
+func ruleName(rule logicalOptRule) string {
+	return strings.TrimPrefix(fmt.Sprintf("%T", rule), "*plan.")
+}
+
+// propString describes the required property like "copSingleReadTask order by [test.t.a] desc limit 10".
+func propString(prop *requiredProp) string {
+	str := prop.taskTp.String()
+	if len(prop.cols) > 0 {
+		str += fmt.Sprintf(" order by %v", prop.cols)
+		if prop.desc {
+			str += " desc"
+		}
+	}
+	if prop.expectedCnt < math.MaxFloat64 {
+		str += fmt.Sprintf(" expected count %v", prop.expectedCnt)
+	}
+	return str
+}
+
+func taskString(t task) string {
+	if cop, ok := t.(*copTask); ok {
+		var strs []string
+		for _, p := range []PhysicalPlan{cop.indexPlan, cop.tablePlan} {
+			if p != nil {
+				strs = append(strs, ToString(p))
+			}
+		}
+		return "Cop{" + strings.Join(strs, "}{") + "}"
+	}
+	return ToString(t.plan())
+}
+
+// traceCandidates records the valid candidate tasks of the logical plan under the property, the best one is chosen.
+func traceCandidates(p LogicalPlan, prop *requiredProp, candidates []task, best task) {
+	trace := optimizerTrace(p)
+	if trace == nil {
+		return
+	}
+	for _, t := range candidates {
+		if t.invalid() {
+			continue
+		}
+		c := &tracing.CandidateTrace{
+			Logical:  p.ID(),
+			Property: propString(prop),
+			Plan:     taskString(t),
+			Cost:     t.cost(),
+			RowCount: t.count(),
+			Chosen:   t == best,
+		}
+		if !c.Chosen {
+			c.Reason = "higher cost"
+			if t.cost() == best.cost() {
+				c.Reason = "same cost as the chosen one which is found earlier"
+			}
+		}
+		trace.AppendCandidates(c)
+	}
+}
+
+// traceSkippedIndices records the indices which are not costed for the DataSource, the reason tells why.
+func traceSkippedIndices(p *DataSource, prop *requiredProp, indices []*model.IndexInfo, reason string) {
+	trace := optimizerTrace(p)
+	if trace == nil {
+		return
+	}
+	for _, idx := range indices {
+		trace.AppendCandidates(&tracing.CandidateTrace{
+			Logical:  p.ID(),
+			Property: propString(prop),
+			Plan:     fmt.Sprintf("Index(%s.%s)", p.tableInfo.Name.L, idx.Name.L),
+			Reason:   reason,
+		})
+	}
+}
+
+func prunedIndices(indices, remained []*model.IndexInfo) []*model.IndexInfo {
+	pruned := make([]*model.IndexInfo, 0, len(indices)-len(remained))
+	for _, idx := range indices {
+		found := false
+		for _, r := range remained {
+			if r == idx {
+				found = true
+				break
+			}
+		}
+		if !found {
+			pruned = append(pruned, idx)
+		}
+	}
+	return pruned
+}
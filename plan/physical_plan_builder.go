@@ -24,20 +24,23 @@ import (
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util/ranger"
 	"github.com/pingcap/tidb/util/types"
 )
 
+// The cost factors are the defaults of the session, they're used by the old planner. The new planner uses
+// the cost factors of the session which can be tuned, see costFactors.
 const (
-	netWorkFactor      = 1.5
-	netWorkStartFactor = 20.0
-	scanFactor         = 2.0
-	descScanFactor     = 5 * scanFactor
-	memoryFactor       = 5.0
+	netWorkFactor      = variable.DefOptNetworkFactor
+	netWorkStartFactor = variable.DefOptSeekFactor
+	scanFactor         = variable.DefOptScanFactor
+	descScanFactor     = variable.DefOptDescScanFactor
+	memoryFactor       = variable.DefOptMemoryFactor
 	selectionFactor    = 0.8
 	distinctFactor     = 0.8
-	cpuFactor          = 0.9
+	cpuFactor          = variable.DefOptCPUFactor
 	aggFactor          = 0.1
 	joinFactor         = 0.3
 )
@@ -69,7 +72,7 @@ func (p *DataSource) convert2TableScan(prop *requiredProperty) (*physicalPlanInf
 	resultPlan = ts
 	table := p.tableInfo
 	sc := p.ctx.GetSessionVars().StmtCtx
-	ts.Ranges = []types.IntColumnRange{{LowVal: math.MinInt64, HighVal: math.MaxInt64}}
+	ts.Ranges = ranger.FullIntRange()
 	if len(p.parents) > 0 {
 		if sel, ok := p.parents[0].(*Selection); ok {
 			newSel := sel.Copy().(*Selection)
@@ -190,7 +193,7 @@ func isCoveringIndex(columns []*model.ColumnInfo, indexColumns []*model.IndexCol
 		}
 		isIndexColumn := false
 		for _, indexCol := range indexColumns {
-			if colInfo.Name.L == indexCol.Name.L && indexCol.Length == types.UnspecifiedLength {
+			if colInfo.Name.L == indexCol.Name.L && indexCol.IsFullColumn(&colInfo.FieldType) {
 				isIndexColumn = true
 				break
 			}
@@ -232,12 +235,13 @@ func (p *DataSource) convert2PhysicalPlan(prop *requiredProperty) (*physicalPlan
 	client := p.ctx.GetClient()
 	memDB := infoschema.IsMemoryDB(p.DBName.L)
 	isDistReq := !memDB && client != nil && client.IsRequestTypeSupported(kv.ReqTypeSelect, 0)
-	if !isDistReq {
+	if !isDistReq || p.sample != nil {
 		memTable := PhysicalMemTable{
 			DBName:      p.DBName,
 			Table:       p.tableInfo,
 			Columns:     p.Columns,
 			TableAsName: p.TableAsName,
+			Sample:      p.sample,
 		}.init(p.allocator, p.ctx)
 		memTable.SetSchema(p.schema)
 		memTable.Ranges = ranger.FullIntRange()
@@ -246,7 +250,7 @@ func (p *DataSource) convert2PhysicalPlan(prop *requiredProperty) (*physicalPlan
 		p.storePlanInfo(prop, info)
 		return info, nil
 	}
-	indices, includeTableScan := availableIndices(p.indexHints, p.tableInfo)
+	indices, includeTableScan := availableIndices(p.indexHints, p.tableInfo, p.ctx.GetSessionVars().OptimizerUseInvisibleIndexes, supportDescIndex(p.ctx))
 	if includeTableScan {
 		info, err = p.convert2TableScan(prop)
 		if err != nil {
@@ -395,9 +399,21 @@ func (p *Limit) convert2PhysicalPlan(prop *requiredProperty) (*physicalPlanInfo,
 	if info != nil {
 		return info, nil
 	}
-	info, err = p.children[0].(LogicalPlan).convert2PhysicalPlan(limitProperty(&Limit{Offset: p.Offset, Count: p.Count}))
-	if err != nil {
-		return nil, errors.Trace(err)
+	if p.CalcFoundRows {
+		// All the rows are read to count the found rows, so the limit isn't pushed to the child.
+		info, err = p.children[0].(LogicalPlan).convert2PhysicalPlan(&requiredProperty{})
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		limit := Limit{Offset: p.Offset, Count: p.Count, CalcFoundRows: true}.init(p.allocator, p.ctx)
+		limit.SetSchema(p.schema)
+		info = addPlanToResponse(limit, info)
+		info.count = math.Min(info.count, float64(p.Count))
+	} else {
+		info, err = p.children[0].(LogicalPlan).convert2PhysicalPlan(limitProperty(&Limit{Offset: p.Offset, Count: p.Count}))
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
 	}
 	info = enforceProperty(prop, info)
 	p.storePlanInfo(prop, info)
@@ -646,8 +662,11 @@ func (p *LogicalJoin) convert2IndexNestedLoopJoinLeft(prop *requiredProperty, in
 	lChild := p.children[0].(LogicalPlan)
 	switch x := p.children[1].(type) {
 	case *DataSource:
+		if x.sample != nil {
+			return nil, nil
+		}
 	case *Selection:
-		if _, ok := x.children[0].(*DataSource); !ok {
+		if ds, ok := x.children[0].(*DataSource); !ok || ds.sample != nil {
 			return nil, nil
 		}
 	default:
@@ -722,8 +741,11 @@ func (p *LogicalJoin) convert2IndexNestedLoopJoinRight(prop *requiredProperty, i
 	rChild := p.children[1].(LogicalPlan)
 	switch x := p.children[0].(type) {
 	case *DataSource:
+		if x.sample != nil {
+			return nil, nil
+		}
 	case *Selection:
-		if _, ok := x.children[0].(*DataSource); !ok {
+		if ds, ok := x.children[0].(*DataSource); !ok || ds.sample != nil {
 			return nil, nil
 		}
 	default:
@@ -1246,7 +1268,7 @@ func (p *Selection) makeScanController() *physicalPlanInfo {
 		corColConds []expression.Expression
 	)
 	ds := p.children[0].(*DataSource)
-	indices, _ := availableIndices(ds.indexHints, ds.tableInfo)
+	indices, _ := availableIndices(ds.indexHints, ds.tableInfo, ds.ctx.GetSessionVars().OptimizerUseInvisibleIndexes, supportDescIndex(ds.ctx))
 	for _, expr := range p.Conditions {
 		if !expr.IsCorrelated() {
 			continue
@@ -1318,6 +1340,27 @@ func (p *Selection) makeScanController() *physicalPlanInfo {
 	return info
 }
 
+// convert2PhysicalPlan implements the LogicalPlan convert2PhysicalPlan interface.
+// The required property is enforced, because the limit can't be pushed to the child.
+func (p *Expand) convert2PhysicalPlan(prop *requiredProperty) (*physicalPlanInfo, error) {
+	info, err := p.getPlanInfo(prop)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if info != nil {
+		return info, nil
+	}
+	info, err = p.children[0].(LogicalPlan).convert2PhysicalPlan(&requiredProperty{})
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	info = addPlanToResponse(p, info)
+	info.count *= float64(len(p.GroupingSets))
+	info.cost += info.count * cpuFactor
+	info = enforceProperty(prop, info)
+	return info, p.storePlanInfo(prop, info)
+}
+
 // convert2PhysicalPlan implements the LogicalPlan convert2PhysicalPlan interface.
 func (p *Selection) convert2PhysicalPlan(prop *requiredProperty) (*physicalPlanInfo, error) {
 	info, err := p.getPlanInfo(prop)
@@ -1390,7 +1433,7 @@ func (p *Selection) convert2PhysicalPlanPushOrder(prop *requiredProperty) (*phys
 		client := p.ctx.GetClient()
 		memDB := infoschema.IsMemoryDB(ds.DBName.L)
 		isDistReq := !memDB && client != nil && client.IsRequestTypeSupported(kv.ReqTypeSelect, 0)
-		if !isDistReq {
+		if !isDistReq || ds.sample != nil {
 			info = p.appendSelToInfo(info)
 		}
 	}
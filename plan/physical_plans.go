@@ -47,6 +47,7 @@ var (
 	_ PhysicalPlan = &Projection{}
 	_ PhysicalPlan = &Exists{}
 	_ PhysicalPlan = &MaxOneRow{}
+	_ PhysicalPlan = &Expand{}
 	_ PhysicalPlan = &TableDual{}
 	_ PhysicalPlan = &Union{}
 	_ PhysicalPlan = &Sort{}
@@ -146,7 +147,7 @@ type PhysicalIndexScan struct {
 
 	Table      *model.TableInfo
 	Index      *model.IndexInfo
-	Ranges     []*types.IndexRange
+	Ranges     []*types.Range
 	Columns    []*model.ColumnInfo
 	DBName     model.CIStr
 	Desc       bool
@@ -175,8 +176,10 @@ type PhysicalMemTable struct {
 	DBName      model.CIStr
 	Table       *model.TableInfo
 	Columns     []*model.ColumnInfo
-	Ranges      []types.IntColumnRange
+	Ranges      []*types.Range
 	TableAsName *model.CIStr
+	// Sample is the TABLESAMPLE clause, only the sampled rows of the table are read if it's set.
+	Sample *ast.TableSample
 
 	// NeedColHandle is used in execution phase.
 	NeedColHandle bool
@@ -333,7 +336,20 @@ func needCount(af expression.AggregationFunction) bool {
 
 func needValue(af expression.AggregationFunction) bool {
 	return af.GetName() == ast.AggFuncSum || af.GetName() == ast.AggFuncAvg || af.GetName() == ast.AggFuncFirstRow ||
-		af.GetName() == ast.AggFuncMax || af.GetName() == ast.AggFuncMin || af.GetName() == ast.AggFuncGroupConcat
+		af.GetName() == ast.AggFuncMax || af.GetName() == ast.AggFuncMin || af.GetName() == ast.AggFuncGroupConcat ||
+		af.GetName() == ast.AggFuncBitOr || af.GetName() == ast.AggFuncBitXor || af.GetName() == ast.AggFuncBitAnd
+}
+
+// needSketch checks whether the partial result of the aggregate function is an encoded sketch.
+func needSketch(af expression.AggregationFunction) bool {
+	return af.GetName() == ast.AggFuncApproxCountDistinct || af.GetName() == ast.AggFuncApproxPercentile
+}
+
+func sketchFieldType() *types.FieldType {
+	ft := types.NewFieldType(mysql.TypeBlob)
+	ft.Charset = charset.CharsetBin
+	ft.Collate = charset.CollationBin
+	return ft
 }
 
 func (p *physicalTableSource) tryToAddUnionScan(resultPlan PhysicalPlan, s *expression.Schema) PhysicalPlan {
@@ -438,6 +454,15 @@ func (p *physicalTableSource) addAggregation(ctx context.Context, agg *PhysicalA
 			schema.Append(&expression.Column{Index: cursor, ColName: colName, RetType: ft})
 			args = append(args, schema.Columns[cursor])
 		}
+		if needSketch(fun) {
+			cursor++
+			schema.Append(&expression.Column{Index: cursor, ColName: colName, RetType: sketchFieldType()})
+			args = append(args, schema.Columns[cursor])
+			// The final approx_percentile needs the percentage besides the digest.
+			if fun.GetName() == ast.AggFuncApproxPercentile {
+				args = append(args, aggFun.GetArgs()[1].Clone())
+			}
+		}
 		fun.SetArgs(args)
 		fun.SetMode(expression.FinalMode)
 		newAggFuncs[i] = fun
@@ -454,7 +479,7 @@ type PhysicalTableScan struct {
 	Columns []*model.ColumnInfo
 	DBName  model.CIStr
 	Desc    bool
-	Ranges  []types.IntColumnRange
+	Ranges  []*types.Range
 	pkCol   *expression.Column
 
 	TableAsName *model.CIStr
@@ -578,6 +603,10 @@ type PhysicalAggregation struct {
 	AggType      AggregationType
 	AggFuncs     []expression.AggregationFunction
 	GroupByItems []expression.Expression
+
+	// propKeys is the order of the child required by the stream aggregation, they're the group-by columns
+	// ordered like a possible property of the child.
+	propKeys []*expression.Column
 }
 
 // PhysicalUnionScan represents a union scan operator.
@@ -995,6 +1024,15 @@ func (p *MaxOneRow) Copy() PhysicalPlan {
 	return &np
 }
 
+// Copy implements the PhysicalPlan Copy interface.
+func (p *Expand) Copy() PhysicalPlan {
+	np := *p
+	np.basePlan = p.basePlan.copy()
+	np.baseLogicalPlan = newBaseLogicalPlan(np.basePlan)
+	np.basePhysicalPlan = newBasePhysicalPlan(np.basePlan)
+	return &np
+}
+
 // Copy implements the PhysicalPlan Copy interface.
 func (p *Insert) Copy() PhysicalPlan {
 	np := *p
@@ -1165,6 +1203,10 @@ func buildSchema(p PhysicalPlan) {
 		p.SetSchema(p.Children()[0].Schema())
 	case *PhysicalHashJoin, *PhysicalMergeJoin, *PhysicalIndexJoin:
 		p.SetSchema(expression.MergeSchema(p.Children()[0].Schema(), p.Children()[1].Schema()))
+	case *Expand:
+		groupingCols := x.groupingCols()
+		x.SetSchema(x.children[0].Schema().Clone())
+		x.schema.Append(groupingCols...)
 	case *PhysicalApply:
 		buildSchema(x.PhysicalJoin)
 		x.schema = x.PhysicalJoin.Schema()
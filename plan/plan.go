@@ -355,7 +355,7 @@ func (p *baseLogicalPlan) buildKeyInfo() {
 	}
 	if len(p.basePlan.children) == 1 {
 		switch p.basePlan.self.(type) {
-		case *Exists, *LogicalAggregation, *Projection:
+		case *Exists, *LogicalAggregation, *Projection, *Expand:
 			p.basePlan.schema.Keys = nil
 		case *SelectLock:
 			p.basePlan.schema.Keys = p.basePlan.children[0].Schema().Keys
@@ -15,12 +15,14 @@ package plan
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/infoschema"
+	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/parser/opcode"
@@ -40,28 +42,50 @@ var (
 	ErrAnalyzeMissIndex     = terror.ClassOptimizerPlan.New(CodeAnalyzeMissIndex, "Index '%s' in field list does not exist in table '%s'")
 	ErrAlterAutoID          = terror.ClassAutoid.New(CodeAlterAutoID, "No support for setting auto_increment using alter_table")
 	ErrBadGeneratedColumn   = terror.ClassOptimizerPlan.New(CodeBadGeneratedColumn, mysql.MySQLErrName[mysql.ErrBadGeneratedColumn])
+	ErrFieldNotInGrouping   = terror.ClassOptimizerPlan.New(CodeFieldNotInGrouping, mysql.MySQLErrName[mysql.ErrFieldInGroupingNotGroupBy])
+	ErrIndexNotApplicable   = terror.ClassOptimizerPlan.New(CodeIndexNotApplicable, mysql.MySQLErrName[mysql.ErrWarnIndexNotApplicable])
+	ErrNoSuchThread         = terror.ClassOptimizerPlan.New(CodeNoSuchThread, mysql.MySQLErrName[mysql.ErrNoSuchThread])
+	ErrExplainNotSupported  = terror.ClassOptimizerPlan.New(CodeExplainNotSupported, mysql.MySQLErrName[mysql.ErrExplainNotSupported])
+	ErrBadPolicyPredicate   = terror.ClassOptimizerPlan.New(CodeBadPolicyPredicate, "The predicate of a policy can't contain %s")
+	ErrBadMaskExpr          = terror.ClassOptimizerPlan.New(CodeBadMaskExpr, "The mask expression of a policy can't contain %s")
+	ErrBadPercentage        = terror.ClassOptimizerPlan.New(CodeBadPercentage, "The percentage of APPROX_PERCENTILE must be a constant between 0 and 100, but got %s")
+	ErrBadSamplePercentage  = terror.ClassOptimizerPlan.New(CodeBadSamplePercentage, "The percentage of TABLESAMPLE BERNOULLI must be between 0 and 100, but got %v")
+	ErrSampleUnsupported    = terror.ClassOptimizerPlan.New(CodeSampleUnsupported, "TABLESAMPLE is not supported on the table %s.%s")
 )
 
 // Error codes.
 const (
-	CodeUnsupportedType    terror.ErrCode = 1
-	SystemInternalError                   = 2
-	CodeAlterAutoID                       = 3
-	CodeAnalyzeMissIndex                  = 4
-	CodeAmbiguous                         = 1052
-	CodeUnknownColumn                     = mysql.ErrBadField
-	CodeUnknownTable                      = mysql.ErrBadTable
-	CodeWrongArguments                    = 1210
-	CodeBadGeneratedColumn                = mysql.ErrBadGeneratedColumn
+	CodeUnsupportedType     terror.ErrCode = 1
+	SystemInternalError                    = 2
+	CodeAlterAutoID                        = 3
+	CodeAnalyzeMissIndex                   = 4
+	CodeBadPolicyPredicate                 = 5
+	CodeBadMaskExpr                        = 6
+	CodeBadPercentage                      = 7
+	CodeBadSamplePercentage                = 8
+	CodeSampleUnsupported                  = 9
+	CodeAmbiguous                          = 1052
+	CodeUnknownColumn                      = mysql.ErrBadField
+	CodeUnknownTable                       = mysql.ErrBadTable
+	CodeWrongArguments                     = 1210
+	CodeBadGeneratedColumn                 = mysql.ErrBadGeneratedColumn
+	CodeFieldNotInGrouping                 = mysql.ErrFieldInGroupingNotGroupBy
+	CodeIndexNotApplicable                 = mysql.ErrWarnIndexNotApplicable
+	CodeNoSuchThread                       = mysql.ErrNoSuchThread
+	CodeExplainNotSupported                = mysql.ErrExplainNotSupported
 )
 
 func init() {
 	tableMySQLErrCodes := map[terror.ErrCode]uint16{
-		CodeUnknownColumn:      mysql.ErrBadField,
-		CodeUnknownTable:       mysql.ErrBadTable,
-		CodeAmbiguous:          mysql.ErrNonUniq,
-		CodeWrongArguments:     mysql.ErrWrongArguments,
-		CodeBadGeneratedColumn: mysql.ErrBadGeneratedColumn,
+		CodeUnknownColumn:       mysql.ErrBadField,
+		CodeUnknownTable:        mysql.ErrBadTable,
+		CodeAmbiguous:           mysql.ErrNonUniq,
+		CodeWrongArguments:      mysql.ErrWrongArguments,
+		CodeBadGeneratedColumn:  mysql.ErrBadGeneratedColumn,
+		CodeFieldNotInGrouping:  mysql.ErrFieldInGroupingNotGroupBy,
+		CodeIndexNotApplicable:  mysql.ErrWarnIndexNotApplicable,
+		CodeNoSuchThread:        mysql.ErrNoSuchThread,
+		CodeExplainNotSupported: mysql.ErrExplainNotSupported,
 	}
 	terror.ErrClassToMySQLCodes[terror.ClassOptimizerPlan] = tableMySQLErrCodes
 }
@@ -124,13 +148,34 @@ type planBuilder struct {
 	is            infoschema.InfoSchema
 	outerSchemas  []*expression.Schema
 	inUpdateStmt  bool
+	inDeleteStmt  bool
 	needColHandle int
 	// colMapper stores the column that must be pre-resolved.
 	colMapper map[*ast.ColumnNameExpr]int
+	// maskedColumns are the masked columns of the tables written by the UPDATE or DELETE statement.
+	maskedColumns []maskedColumn
 	// Collect the visit information for privilege check.
 	visitInfo     []visitInfo
 	tableHintInfo []tableHintInfo
 	optFlag       uint64
+	// paramTypes are the types of the parameter markers inferred from the expressions they're in, when the
+	// statement is built for its metadata at prepare time. The uncorrelated subqueries aren't evaluated then.
+	// paramConsts maps the constants rewritten from the parameter markers to the markers.
+	// They're nil if the statement is built to be executed.
+	paramTypes  map[*ast.ParamMarkerExpr]*types.FieldType
+	paramConsts map[*expression.Constant]*ast.ParamMarkerExpr
+}
+
+// inferParamType sets the type of the expression to tp if it's a parameter marker whose type isn't inferred yet.
+func (b *planBuilder) inferParamType(expr ast.ExprNode, tp *types.FieldType) {
+	if b.paramTypes == nil {
+		return
+	}
+	if marker, ok := expr.(*ast.ParamMarkerExpr); ok {
+		if _, ok = b.paramTypes[marker]; !ok {
+			b.paramTypes[marker] = tp
+		}
+	}
 }
 
 func (b *planBuilder) build(node ast.Node) Plan {
@@ -146,6 +191,10 @@ func (b *planBuilder) build(node ast.Node) Plan {
 		return b.buildExecute(x)
 	case *ast.ExplainStmt:
 		return b.buildExplain(x)
+	case *ast.ExplainForStmt:
+		return b.buildExplainFor(x)
+	case *ast.TraceStmt:
+		return b.buildTrace(x)
 	case *ast.InsertStmt:
 		return b.buildInsert(x)
 	case *ast.LoadDataStmt:
@@ -168,7 +217,11 @@ func (b *planBuilder) build(node ast.Node) Plan {
 		return b.buildAnalyze(x)
 	case *ast.BinlogStmt, *ast.FlushStmt, *ast.UseStmt,
 		*ast.BeginStmt, *ast.CommitStmt, *ast.RollbackStmt, *ast.CreateUserStmt, *ast.SetPwdStmt,
-		*ast.GrantStmt, *ast.DropUserStmt, *ast.AlterUserStmt, *ast.RevokeStmt, *ast.KillStmt, *ast.DropStatsStmt:
+		*ast.GrantStmt, *ast.DropUserStmt, *ast.AlterUserStmt, *ast.RevokeStmt, *ast.KillStmt, *ast.DropStatsStmt,
+		*ast.CreateFunctionStmt, *ast.DropFunctionStmt, *ast.CreateProcedureStmt, *ast.DropProcedureStmt,
+		*ast.CreateEventStmt, *ast.DropEventStmt, *ast.CreateBindingStmt, *ast.DropBindingStmt,
+		*ast.CreatePolicyStmt, *ast.DropPolicyStmt, *ast.CreateMaskingPolicyStmt, *ast.DropMaskingPolicyStmt,
+		*ast.SetSessionStatesStmt:
 		return b.buildSimple(node.(ast.StmtNode))
 	case ast.DDLNode:
 		return b.buildDDL(x)
@@ -218,6 +271,10 @@ func (b *planBuilder) buildSet(v *ast.SetStmt) Plan {
 			IsGlobal: vars.IsGlobal,
 			IsSystem: vars.IsSystem,
 		}
+		if vars.IsGlobal && vars.IsSystem {
+			// Like MySQL, setting the global system variables requires the SUPER privilege.
+			b.visitInfo = appendVisitInfo(b.visitInfo, mysql.SuperPriv, "", "", "")
+		}
 		if _, ok := vars.Value.(*ast.DefaultExpr); !ok {
 			assign.Expr, _, b.err = b.rewrite(vars.Value, nil, nil, true)
 			if b.err != nil {
@@ -263,7 +320,16 @@ func (b *planBuilder) detectSelectAgg(sel *ast.SelectStmt) bool {
 	return false
 }
 
-func availableIndices(hints []*ast.IndexHint, tableInfo *model.TableInfo) (indices []*model.IndexInfo, includeTableScan bool) {
+// supportDescIndex checks whether the indices with descending columns can be read from the storage, their
+// keys can't be decoded by TiKV, so they're only maintained but not read.
+func supportDescIndex(ctx context.Context) bool {
+	client := ctx.GetClient()
+	return client == nil || client.IsRequestTypeSupported(kv.ReqTypeIndex, kv.ReqSubTypeDescKey)
+}
+
+// availableIndices returns the indices which can be used by the hints, the invisible indices are ignored
+// unless useInvisible is true, and the indices with descending columns are ignored unless useDesc is true.
+func availableIndices(hints []*ast.IndexHint, tableInfo *model.TableInfo, useInvisible, useDesc bool) (indices []*model.IndexInfo, includeTableScan bool) {
 	var usableHints []*ast.IndexHint
 	for _, hint := range hints {
 		if hint.HintScope == ast.HintForScan {
@@ -272,7 +338,7 @@ func availableIndices(hints []*ast.IndexHint, tableInfo *model.TableInfo) (indic
 	}
 	publicIndices := make([]*model.IndexInfo, 0, len(tableInfo.Indices))
 	for _, index := range tableInfo.Indices {
-		if index.State == model.StatePublic {
+		if index.State == model.StatePublic && (useInvisible || !index.Invisible) && (useDesc || index.DescColumns() == nil) {
 			publicIndices = append(publicIndices, index)
 		}
 	}
@@ -348,7 +414,13 @@ func (b *planBuilder) buildPrepare(x *ast.PrepareStmt) Plan {
 		Name: x.Name,
 	}
 	if x.SQLVar != nil {
-		p.SQLText, _ = x.SQLVar.GetValue().(string)
+		// Like MySQL, a NULL variable is prepared as "NULL" which is a syntax error.
+		d := expression.GetUserVar(b.ctx.GetSessionVars(), strings.ToLower(x.SQLVar.Name))
+		if d.IsNull() {
+			p.SQLText = "NULL"
+		} else {
+			p.SQLText, b.err = d.ToString()
+		}
 	} else {
 		p.SQLText = x.SQLText
 	}
@@ -363,9 +435,51 @@ func (b *planBuilder) buildAdmin(as *ast.AdminStmt) Plan {
 	case ast.AdminCheckTable:
 		p = &CheckTable{Tables: as.Tables}
 		p.SetSchema(expression.NewSchema())
+	case ast.AdminChecksumTable:
+		p = &ChecksumTable{Tables: as.Tables}
+		p.SetSchema(buildChecksumTableFields())
+	case ast.AdminDumpDatabase:
+		p = &DumpDatabase{DBName: as.DBName, Path: as.DumpPath}
+		p.SetSchema(buildDumpDatabaseFields())
+		// The dump reads all the tables of the database and writes files on the server.
+		b.visitInfo = appendVisitInfo(b.visitInfo, mysql.SuperPriv, "", "", "")
+	case ast.AdminCheckIndex:
+		p = &CheckIndex{Table: as.Tables[0], IndexName: as.Index, HandleRanges: as.HandleRanges}
+		p.SetSchema(expression.NewSchema())
+		b.visitInfo = appendVisitInfo(b.visitInfo, mysql.SuperPriv, "", "", "")
+	case ast.AdminRepairIndex:
+		p = &RepairIndex{Table: as.Tables[0], IndexName: as.Index, HandleRanges: as.HandleRanges}
+		p.SetSchema(buildRepairIndexFields())
+		// The repair writes the index entries of the table.
+		b.visitInfo = appendVisitInfo(b.visitInfo, mysql.SuperPriv, "", "", "")
 	case ast.AdminShowDDL:
 		p = &ShowDDL{}
 		p.SetSchema(buildShowDDLFields())
+	case ast.AdminEnableFailpoint, ast.AdminDisableFailpoint:
+		p = &SetFailpoint{
+			Name:   as.Failpoint,
+			Terms:  as.FailpointTerms,
+			Enable: as.Tp == ast.AdminEnableFailpoint,
+		}
+		p.SetSchema(expression.NewSchema())
+		// The failpoints change the behaviors of the whole server.
+		b.visitInfo = appendVisitInfo(b.visitInfo, mysql.SuperPriv, "", "", "")
+	case ast.AdminShowFailpoints:
+		p = &ShowFailpoints{}
+		p.SetSchema(buildShowFailpointsFields())
+	case ast.AdminPluginEnable, ast.AdminPluginDisable:
+		p = &SetPlugins{Names: as.Plugins, Enable: as.Tp == ast.AdminPluginEnable}
+		p.SetSchema(expression.NewSchema())
+		b.visitInfo = appendVisitInfo(b.visitInfo, mysql.SuperPriv, "", "", "")
+	case ast.AdminCalibrate:
+		p = &Calibrate{}
+		p.SetSchema(buildCalibrateFields())
+		b.visitInfo = appendVisitInfo(b.visitInfo, mysql.SuperPriv, "", "", "")
+	case ast.AdminCleanupTableLock:
+		p = &CleanupTableLock{Tables: as.Tables}
+		p.SetSchema(expression.NewSchema())
+		// The locks of the other sessions are removed.
+		b.visitInfo = appendVisitInfo(b.visitInfo, mysql.SuperPriv, "", "", "")
 	default:
 		b.err = ErrUnsupportedType.Gen("Unsupported type %T", as)
 	}
@@ -373,7 +487,7 @@ func (b *planBuilder) buildAdmin(as *ast.AdminStmt) Plan {
 }
 
 // getColsInfo returns the info of index columns, normal columns and primary key.
-func getColsInfo(tn *ast.TableName) (indicesInfo []*model.IndexInfo, colsInfo []*model.ColumnInfo, pkCol *model.ColumnInfo) {
+func getColsInfo(tn *ast.TableName, useDesc bool) (indicesInfo []*model.IndexInfo, colsInfo []*model.ColumnInfo, pkCol *model.ColumnInfo) {
 	tbl := tn.TableInfo
 	// idxNames contains all the normal columns that can be analyzed more effectively, because those columns occur as index
 	// columns or primary key columns with integer type.
@@ -386,7 +500,7 @@ func getColsInfo(tn *ast.TableName) (indicesInfo []*model.IndexInfo, colsInfo []
 			}
 		}
 	}
-	indices, _ := availableIndices(tn.IndexHints, tn.TableInfo)
+	indices, _ := availableIndices(tn.IndexHints, tn.TableInfo, true, useDesc)
 	for _, index := range indices {
 		for _, idx := range tn.TableInfo.Indices {
 			if index.Name.L == idx.Name.L {
@@ -416,7 +530,7 @@ func getColsInfo(tn *ast.TableName) (indicesInfo []*model.IndexInfo, colsInfo []
 func (b *planBuilder) buildAnalyzeTable(as *ast.AnalyzeTableStmt) Plan {
 	p := &Analyze{}
 	for _, tbl := range as.TableNames {
-		idxInfo, colInfo, pkInfo := getColsInfo(tbl)
+		idxInfo, colInfo, pkInfo := getColsInfo(tbl, supportDescIndex(b.ctx))
 		for _, idx := range idxInfo {
 			p.IdxTasks = append(p.IdxTasks, AnalyzeIndexTask{TableInfo: tbl.TableInfo, IndexInfo: idx})
 		}
@@ -437,6 +551,10 @@ func (b *planBuilder) buildAnalyzeIndex(as *ast.AnalyzeTableStmt) Plan {
 			b.err = ErrAnalyzeMissIndex.GenByArgs(idxName.O, tblInfo.Name.O)
 			break
 		}
+		// The index isn't read by the queries either.
+		if idx.DescColumns() != nil && !supportDescIndex(b.ctx) {
+			continue
+		}
 		p.IdxTasks = append(p.IdxTasks, AnalyzeIndexTask{TableInfo: tblInfo, IndexInfo: idx})
 	}
 	p.SetSchema(&expression.Schema{})
@@ -460,6 +578,51 @@ func buildShowDDLFields() *expression.Schema {
 	return schema
 }
 
+func buildChecksumTableFields() *expression.Schema {
+	schema := expression.NewSchema(make([]*expression.Column, 0, 5)...)
+	schema.Append(buildColumn("", "Db_name", mysql.TypeVarchar, 128))
+	schema.Append(buildColumn("", "Table_name", mysql.TypeVarchar, 128))
+	schema.Append(buildColumn("", "Checksum_crc64_xor", mysql.TypeLonglong, 22))
+	schema.Append(buildColumn("", "Total_kvs", mysql.TypeLonglong, 22))
+	schema.Append(buildColumn("", "Total_bytes", mysql.TypeLonglong, 22))
+
+	return schema
+}
+
+func buildDumpDatabaseFields() *expression.Schema {
+	schema := expression.NewSchema(make([]*expression.Column, 0, 3)...)
+	schema.Append(buildColumn("", "Table_name", mysql.TypeVarchar, 128))
+	schema.Append(buildColumn("", "Rows", mysql.TypeLonglong, 22))
+	schema.Append(buildColumn("", "Files", mysql.TypeLonglong, 22))
+
+	return schema
+}
+
+func buildRepairIndexFields() *expression.Schema {
+	schema := expression.NewSchema(make([]*expression.Column, 0, 2)...)
+	schema.Append(buildColumn("", "ADDED_COUNT", mysql.TypeLonglong, 4))
+	schema.Append(buildColumn("", "REMOVED_COUNT", mysql.TypeLonglong, 4))
+
+	return schema
+}
+
+func buildShowFailpointsFields() *expression.Schema {
+	schema := expression.NewSchema(make([]*expression.Column, 0, 2)...)
+	schema.Append(buildColumn("", "NAME", mysql.TypeVarchar, 64))
+	schema.Append(buildColumn("", "TERMS", mysql.TypeVarchar, 128))
+
+	return schema
+}
+
+func buildCalibrateFields() *expression.Schema {
+	schema := expression.NewSchema(make([]*expression.Column, 0, 3)...)
+	schema.Append(buildColumn("", "Variable_name", mysql.TypeVarchar, 64))
+	schema.Append(buildColumn("", "Value", mysql.TypeVarchar, 64))
+	schema.Append(buildColumn("", "Calibrated_value", mysql.TypeVarchar, 64))
+
+	return schema
+}
+
 func buildColumn(tableName, name string, tp byte, size int) *expression.Column {
 	cs, cl := types.DefaultCharsetForType(tp)
 	flag := mysql.UnsignedFlag
@@ -514,6 +677,8 @@ func (b *planBuilder) buildShow(show *ast.ShowStmt) Plan {
 		Flag:   show.Flag,
 		Full:   show.Full,
 		User:   show.User,
+
+		CountWarningsOrErrors: show.CountWarningsOrErrors,
 	}.init(b.allocator, b.ctx)
 	resultPlan = p
 	switch show.Tp {
@@ -523,8 +688,12 @@ func (b *planBuilder) buildShow(show *ast.ShowStmt) Plan {
 		p.SetSchema(buildShowTriggerSchema())
 	case ast.ShowEvents:
 		p.SetSchema(buildShowEventsSchema())
-	case ast.ShowWarnings:
-		p.SetSchema(buildShowWarningsSchema())
+	case ast.ShowWarnings, ast.ShowErrors:
+		if show.CountWarningsOrErrors {
+			p.SetSchema(buildShowCountWarningsSchema(show.Tp))
+		} else {
+			p.SetSchema(buildShowWarningsSchema())
+		}
 	default:
 		p.SetSchema(buildShowSchema(show))
 	}
@@ -557,6 +726,9 @@ func (b *planBuilder) buildShow(show *ast.ShowStmt) Plan {
 		sel.SetSchema(p.Schema())
 		resultPlan = sel
 	}
+	if show.Limit != nil {
+		resultPlan = b.buildLimit(resultPlan.(LogicalPlan), show.Limit)
+	}
 	return resultPlan
 }
 
@@ -571,6 +743,34 @@ func (b *planBuilder) buildSimple(node ast.StmtNode) Plan {
 		b.visitInfo = collectVisitInfoFromGrantStmt(b.visitInfo, raw)
 	case *ast.SetPwdStmt, *ast.RevokeStmt, *ast.KillStmt:
 		b.visitInfo = appendVisitInfo(b.visitInfo, mysql.SuperPriv, "", "", "")
+	case *ast.CreateFunctionStmt:
+		// Like MySQL, creating a function needs the INSERT privilege for the mysql database.
+		b.visitInfo = appendVisitInfo(b.visitInfo, mysql.InsertPriv, mysql.SystemDB, "", "")
+	case *ast.DropFunctionStmt:
+		b.visitInfo = appendVisitInfo(b.visitInfo, mysql.DeletePriv, mysql.SystemDB, "", "")
+	case *ast.CreateProcedureStmt:
+		// The procedures are stored in mysql.proc, the statements in a procedure are checked by the
+		// privileges of the caller when it's called.
+		b.visitInfo = appendVisitInfo(b.visitInfo, mysql.InsertPriv, mysql.SystemDB, "", "")
+	case *ast.DropProcedureStmt:
+		b.visitInfo = appendVisitInfo(b.visitInfo, mysql.DeletePriv, mysql.SystemDB, "", "")
+	case *ast.CreateEventStmt:
+		// The events are stored in mysql.event and run by the scheduler without the privilege checks,
+		// so only the users who can write the mysql database can create them.
+		b.visitInfo = appendVisitInfo(b.visitInfo, mysql.InsertPriv, mysql.SystemDB, "", "")
+	case *ast.DropEventStmt:
+		b.visitInfo = appendVisitInfo(b.visitInfo, mysql.DeletePriv, mysql.SystemDB, "", "")
+	case *ast.CreateBindingStmt:
+		// The bindings are stored in mysql.bind_info and change the plans of all the users.
+		b.visitInfo = appendVisitInfo(b.visitInfo, mysql.InsertPriv, mysql.SystemDB, "", "")
+	case *ast.DropBindingStmt:
+		b.visitInfo = appendVisitInfo(b.visitInfo, mysql.DeletePriv, mysql.SystemDB, "", "")
+	case *ast.CreatePolicyStmt, *ast.CreateMaskingPolicyStmt:
+		// The policies are stored in mysql.row_policy, the users who can change the table mustn't be able to
+		// drop its policies, so the policies are managed by the users who can write the mysql database.
+		b.visitInfo = appendVisitInfo(b.visitInfo, mysql.InsertPriv, mysql.SystemDB, "", "")
+	case *ast.DropPolicyStmt, *ast.DropMaskingPolicyStmt:
+		b.visitInfo = appendVisitInfo(b.visitInfo, mysql.DeletePriv, mysql.SystemDB, "", "")
 	}
 	return p
 }
@@ -676,11 +876,18 @@ func (b *planBuilder) buildInsert(insert *ast.InsertStmt) Plan {
 		}
 	}
 
-	cols := insertPlan.Table.Cols()
+	cols := table.VisibleCols(insertPlan.Table.Cols())
 	maxValuesItemLength := 0 // the max length of items in VALUES list.
 	for _, valuesItem := range insert.Lists {
 		exprList := make([]expression.Expression, 0, len(valuesItem))
 		for i, valueItem := range valuesItem {
+			if len(insert.Columns) > i {
+				if col, ok := columnByName[insert.Columns[i].Name.L]; ok {
+					b.inferParamType(valueItem, &col.FieldType)
+				}
+			} else if len(insert.Columns) == 0 && len(cols) > i {
+				b.inferParamType(valueItem, &cols[i].FieldType)
+			}
 			var expr expression.Expression
 			var err error
 			if dft, ok := valueItem.(*ast.DefaultExpr); ok {
@@ -713,13 +920,13 @@ func (b *planBuilder) buildInsert(insert *ast.InsertStmt) Plan {
 		// The length of VALUES list maybe exceed table width,
 		// we ignore this here but do checking in executor.
 		var effectiveValuesLen int
-		if maxValuesItemLength <= len(tableInfo.Columns) {
+		if maxValuesItemLength <= len(cols) {
 			effectiveValuesLen = maxValuesItemLength
 		} else {
-			effectiveValuesLen = len(tableInfo.Columns)
+			effectiveValuesLen = len(cols)
 		}
 		for i := 0; i < effectiveValuesLen; i++ {
-			col := tableInfo.Columns[i]
+			col := cols[i]
 			if len(col.GeneratedExprString) != 0 {
 				b.err = ErrBadGeneratedColumn.GenByArgs(col.Name.O, tableInfo.Name.O)
 				return nil
@@ -746,6 +953,7 @@ func (b *planBuilder) buildInsert(insert *ast.InsertStmt) Plan {
 		}
 		// Here we keep different behaviours with MySQL. MySQL allow set a = b, b = a and the result is NULL, NULL.
 		// It's unreasonable.
+		b.inferParamType(assign.Expr, col.RetType)
 		expr, _, err := b.rewrite(assign.Expr, mockTablePlan, nil, true)
 		if err != nil {
 			b.err = errors.Trace(err)
@@ -773,6 +981,7 @@ func (b *planBuilder) buildInsert(insert *ast.InsertStmt) Plan {
 			b.err = ErrBadGeneratedColumn.GenByArgs(assign.Column.Name.O, tableInfo.Name.O)
 			return nil
 		}
+		b.inferParamType(assign.Expr, col.RetType)
 		expr, _, err := b.rewrite(assign.Expr, mockTablePlan, nil, true)
 		if err != nil {
 			b.err = errors.Trace(err)
@@ -790,13 +999,13 @@ func (b *planBuilder) buildInsert(insert *ast.InsertStmt) Plan {
 		}
 		// If the schema of selectPlan contains any generated column, raises error.
 		var effectiveSelectLen int
-		if selectPlan.Schema().Len() <= len(tableInfo.Columns) {
+		if selectPlan.Schema().Len() <= len(cols) {
 			effectiveSelectLen = selectPlan.Schema().Len()
 		} else {
-			effectiveSelectLen = len(tableInfo.Columns)
+			effectiveSelectLen = len(cols)
 		}
 		for i := 0; i < effectiveSelectLen; i++ {
-			col := tableInfo.Columns[i]
+			col := cols[i]
 			if len(col.GeneratedExprString) != 0 {
 				b.err = ErrBadGeneratedColumn.GenByArgs(col.Name.O, tableInfo.Name.O)
 				return nil
@@ -872,6 +1081,21 @@ func (b *planBuilder) buildDDL(node ast.DDLNode) Plan {
 				table:     table.Name.L,
 			})
 		}
+	case *ast.LockTablesStmt:
+		for _, tl := range v.TableLocks {
+			privs := []mysql.PrivilegeType{mysql.SelectPriv}
+			if tl.Type == model.TableLockWrite {
+				// A WRITE lock keeps the other sessions from reading and writing the table.
+				privs = append(privs, mysql.InsertPriv, mysql.UpdatePriv, mysql.DeletePriv)
+			}
+			for _, priv := range privs {
+				b.visitInfo = append(b.visitInfo, visitInfo{
+					privilege: priv,
+					db:        tl.Table.Schema.L,
+					table:     tl.Table.Name.L,
+				})
+			}
+		}
 	case *ast.TruncateTableStmt:
 		b.visitInfo = append(b.visitInfo, visitInfo{
 			privilege: mysql.DeletePriv,
@@ -879,16 +1103,18 @@ func (b *planBuilder) buildDDL(node ast.DDLNode) Plan {
 			table:     v.Table.Name.L,
 		})
 	case *ast.RenameTableStmt:
-		b.visitInfo = append(b.visitInfo, visitInfo{
-			privilege: mysql.AlterPriv,
-			db:        v.OldTable.Schema.L,
-			table:     v.OldTable.Name.L,
-		})
-		b.visitInfo = append(b.visitInfo, visitInfo{
-			privilege: mysql.AlterPriv,
-			db:        v.NewTable.Schema.L,
-			table:     v.NewTable.Name.L,
-		})
+		for _, tt := range v.TableToTables {
+			b.visitInfo = append(b.visitInfo, visitInfo{
+				privilege: mysql.AlterPriv,
+				db:        tt.OldTable.Schema.L,
+				table:     tt.OldTable.Name.L,
+			})
+			b.visitInfo = append(b.visitInfo, visitInfo{
+				privilege: mysql.AlterPriv,
+				db:        tt.NewTable.Schema.L,
+				table:     tt.NewTable.Name.L,
+			})
+		}
 	}
 
 	p := &DDL{Statement: node}
@@ -905,7 +1131,35 @@ func (b *planBuilder) buildExplain(explain *ast.ExplainStmt) Plan {
 		b.err = errors.Trace(err)
 		return nil
 	}
-	setParents4FinalPlan(targetPlan.(PhysicalPlan))
+	return b.buildExplainPlan(targetPlan.(PhysicalPlan))
+}
+
+// buildExplainFor explains the plan of the statement being executed in another connection, or the plan of
+// the last statement if the connection is idle. The plan is kept in the process info of the connection.
+func (b *planBuilder) buildExplainFor(explainFor *ast.ExplainForStmt) Plan {
+	sm := b.ctx.GetSessionManager()
+	if sm == nil {
+		b.err = ErrNoSuchThread.GenByArgs(explainFor.ConnectionID)
+		return nil
+	}
+	for _, pi := range sm.ShowProcessList() {
+		if pi.ID != explainFor.ConnectionID {
+			continue
+		}
+		// Only the plans of the DML statements are explainable, the SHOW statements have the physical plans too.
+		targetPlan, ok := pi.Plan.(PhysicalPlan)
+		if _, isShow := pi.Plan.(*Show); !ok || isShow {
+			b.err = ErrExplainNotSupported.GenByArgs()
+			return nil
+		}
+		return b.buildExplainPlan(targetPlan)
+	}
+	b.err = ErrNoSuchThread.GenByArgs(explainFor.ConnectionID)
+	return nil
+}
+
+func (b *planBuilder) buildExplainPlan(targetPlan PhysicalPlan) Plan {
+	setParents4FinalPlan(targetPlan)
 	p := &Explain{StmtPlan: targetPlan}
 	if UseDAGPlanBuilder(b.ctx) {
 		retFields := []string{"id", "parents", "children", "task", "operator info"}
@@ -928,6 +1182,23 @@ func (b *planBuilder) buildExplain(explain *ast.ExplainStmt) Plan {
 	return p
 }
 
+// buildTrace builds a trace plan, the traced statement is parsed, planned and executed by the trace executor,
+// so the time of the parse and plan is traced too.
+func (b *planBuilder) buildTrace(trace *ast.TraceStmt) Plan {
+	p := &Trace{StmtText: trace.Text(), Plan: trace.Plan}
+	if trace.Plan {
+		schema := expression.NewSchema(buildColumn("", "optimizer_trace", mysql.TypeString, mysql.MaxBlobWidth))
+		p.SetSchema(schema)
+		return p
+	}
+	schema := expression.NewSchema(make([]*expression.Column, 0, 3)...)
+	schema.Append(buildColumn("", "operation", mysql.TypeString, mysql.MaxBlobWidth))
+	schema.Append(buildColumn("", "startTS", mysql.TypeString, mysql.MaxBlobWidth))
+	schema.Append(buildColumn("", "duration", mysql.TypeString, mysql.MaxBlobWidth))
+	p.SetSchema(schema)
+	return p
+}
+
 func buildShowProcedureSchema() *expression.Schema {
 	tblName := "ROUTINES"
 	schema := expression.NewSchema(make([]*expression.Column, 0, 11)...)
@@ -992,6 +1263,16 @@ func buildShowWarningsSchema() *expression.Schema {
 	return schema
 }
 
+// buildShowCountWarningsSchema builds the schema of show count(*) warnings and errors, the column is named
+// like MySQL, as the statements are the same as selecting the variables.
+func buildShowCountWarningsSchema(tp ast.ShowStmtType) *expression.Schema {
+	name := "@@session.warning_count"
+	if tp == ast.ShowErrors {
+		name = "@@session.error_count"
+	}
+	return expression.NewSchema(buildColumn("", name, mysql.TypeLonglong, mysql.MaxIntWidth))
+}
+
 func composeShowSchema(names []string, ftypes []byte) *expression.Schema {
 	schema := expression.NewSchema(make([]*expression.Column, 0, len(names))...)
 	for i, name := range names {
@@ -1045,7 +1326,7 @@ func buildShowSchema(s *ast.ShowStmt) (schema *expression.Schema) {
 			mysql.TypeVarchar, mysql.TypeVarchar}
 	case ast.ShowColumns:
 		names = table.ColDescFieldNames(s.Full)
-	case ast.ShowWarnings:
+	case ast.ShowWarnings, ast.ShowErrors:
 		names = []string{"Level", "Code", "Message"}
 		ftypes = []byte{mysql.TypeVarchar, mysql.TypeLong, mysql.TypeVarchar}
 	case ast.ShowCharset:
@@ -1066,10 +1347,11 @@ func buildShowSchema(s *ast.ShowStmt) (schema *expression.Schema) {
 	case ast.ShowIndex:
 		names = []string{"Table", "Non_unique", "Key_name", "Seq_in_index",
 			"Column_name", "Collation", "Cardinality", "Sub_part", "Packed",
-			"Null", "Index_type", "Comment", "Index_comment"}
+			"Null", "Index_type", "Comment", "Index_comment", "Visible", "Expression"}
 		ftypes = []byte{mysql.TypeVarchar, mysql.TypeLonglong, mysql.TypeVarchar, mysql.TypeLonglong,
 			mysql.TypeVarchar, mysql.TypeVarchar, mysql.TypeLonglong, mysql.TypeLonglong,
-			mysql.TypeVarchar, mysql.TypeVarchar, mysql.TypeVarchar, mysql.TypeVarchar, mysql.TypeVarchar}
+			mysql.TypeVarchar, mysql.TypeVarchar, mysql.TypeVarchar, mysql.TypeVarchar, mysql.TypeVarchar,
+			mysql.TypeVarchar, mysql.TypeVarchar}
 	case ast.ShowProcessList:
 		names = []string{"Id", "User", "Host", "db", "Command", "Time", "State", "Info"}
 		ftypes = []byte{mysql.TypeLonglong, mysql.TypeVarchar, mysql.TypeVarchar,
@@ -1086,6 +1368,14 @@ func buildShowSchema(s *ast.ShowStmt) (schema *expression.Schema) {
 			"Repeats", "Lower_Bound", "Upper_Bound"}
 		ftypes = []byte{mysql.TypeVarchar, mysql.TypeVarchar, mysql.TypeVarchar, mysql.TypeTiny, mysql.TypeLonglong,
 			mysql.TypeLonglong, mysql.TypeLonglong, mysql.TypeVarchar, mysql.TypeVarchar}
+	case ast.ShowBindings:
+		names = []string{"Original_sql", "Bind_sql", "Default_db", "Status", "Create_time", "Update_time",
+			"Charset", "Collation"}
+		ftypes = []byte{mysql.TypeVarchar, mysql.TypeVarchar, mysql.TypeVarchar, mysql.TypeVarchar, mysql.TypeDatetime,
+			mysql.TypeDatetime, mysql.TypeVarchar, mysql.TypeVarchar}
+	case ast.ShowSessionStates:
+		names = []string{"Session_states"}
+		ftypes = []byte{mysql.TypeLongBlob}
 	}
 	return composeShowSchema(names, ftypes)
 }
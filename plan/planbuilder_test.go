@@ -47,6 +47,7 @@ func (s *testPlanBuilderSuite) TestShow(c *C) {
 		ast.ShowProcessList,
 		ast.ShowCreateDatabase,
 		ast.ShowEvents,
+		ast.ShowBindings,
 	}
 	for _, tp := range tps {
 		node.Tp = tp
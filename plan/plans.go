@@ -38,6 +38,74 @@ type CheckTable struct {
 	Tables []*ast.TableName
 }
 
+// ChecksumTable is used for calculating the checksum of the tables, built from the 'admin checksum table' statement.
+type ChecksumTable struct {
+	basePlan
+
+	Tables []*ast.TableName
+}
+
+// DumpDatabase is used for dumping the data of a database to files, built from the 'admin dump database' statement.
+type DumpDatabase struct {
+	basePlan
+
+	DBName string
+	Path   string
+}
+
+// CheckIndex is used for checking index data, built from the 'admin check index' statement.
+type CheckIndex struct {
+	basePlan
+
+	Table        *ast.TableName
+	IndexName    string
+	HandleRanges []ast.HandleRange
+}
+
+// RepairIndex is used for repairing index data, built from the 'admin repair index' statement.
+type RepairIndex struct {
+	basePlan
+
+	Table        *ast.TableName
+	IndexName    string
+	HandleRanges []ast.HandleRange
+}
+
+// SetFailpoint is used for enabling or disabling a failpoint, built from the 'admin enable/disable failpoint' statement.
+type SetFailpoint struct {
+	basePlan
+
+	Name   string
+	Terms  string
+	Enable bool
+}
+
+// SetPlugins is used for enabling or disabling plugins, built from the 'admin plugins enable/disable' statement.
+type SetPlugins struct {
+	basePlan
+
+	Names  []string
+	Enable bool
+}
+
+// ShowFailpoints is for showing the enabled failpoints.
+type ShowFailpoints struct {
+	basePlan
+}
+
+// Calibrate is for measuring the cost factors on the current hardware, built from the 'admin calibrate' statement.
+type Calibrate struct {
+	basePlan
+}
+
+// CleanupTableLock is used for removing the table locks held by any session, built from the
+// 'admin cleanup table lock' statement.
+type CleanupTableLock struct {
+	basePlan
+
+	Tables []*ast.TableName
+}
+
 // SelectLock represents a select lock plan.
 type SelectLock struct {
 	*basePlan
@@ -87,6 +155,8 @@ type Show struct {
 
 	// Used by show variables
 	GlobalScope bool
+	// Used by show warnings and errors
+	CountWarningsOrErrors bool
 }
 
 // Set represents a plan for set stmt.
@@ -161,6 +231,15 @@ type DDL struct {
 	Statement ast.DDLNode
 }
 
+// Trace represents a trace plan, StmtText is the text of the trace statement.
+// If Plan is true, the optimizer trace of the statement is returned.
+type Trace struct {
+	basePlan
+
+	StmtText string
+	Plan     bool
+}
+
 // Explain represents a explain plan.
 type Explain struct {
 	basePlan
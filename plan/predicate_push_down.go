@@ -19,6 +19,7 @@ import (
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/util/ranger"
 	"github.com/pingcap/tidb/util/types"
 )
 
@@ -31,6 +32,9 @@ func (s *ppdSolver) optimize(lp LogicalPlan, _ context.Context, _ *idAllocator)
 
 func addSelection(p Plan, child LogicalPlan, conditions []expression.Expression, allocator *idAllocator) error {
 	conditions = expression.PropagateConstant(p.context(), conditions)
+	if len(conditions) == 0 {
+		return nil
+	}
 	selection := Selection{Conditions: conditions}.init(allocator, p.context())
 	selection.SetSchema(child.Schema().Clone())
 	return InsertPlan(p, child, selection)
@@ -42,8 +46,9 @@ func (p *Selection) PredicatePushDown(predicates []expression.Expression) ([]exp
 	if err != nil {
 		return nil, nil, errors.Trace(err)
 	}
+	retConditions = expression.PropagateConstant(p.ctx, retConditions)
 	if len(retConditions) > 0 {
-		p.Conditions = expression.PropagateConstant(p.ctx, retConditions)
+		p.Conditions = retConditions
 		return nil, p, nil
 	}
 	err = RemovePlan(p)
@@ -55,12 +60,35 @@ func (p *Selection) PredicatePushDown(predicates []expression.Expression) ([]exp
 
 // PredicatePushDown implements LogicalPlan PredicatePushDown interface.
 func (p *DataSource) PredicatePushDown(predicates []expression.Expression) ([]expression.Expression, LogicalPlan, error) {
+	p.warnIndexNotApplicable(predicates)
 	if UseDAGPlanBuilder(p.ctx) {
 		_, p.pushedDownConds, predicates = expression.ExpressionsToPB(p.ctx.GetSessionVars().StmtCtx, predicates, p.ctx.GetClient())
+		p.remainedConds = predicates
 	}
 	return predicates, p, nil
 }
 
+// warnIndexNotApplicable appends the warnings for the indices which can't be used by the conditions because of the
+// conversions on their columns, see ranger.ConvertedColumn.
+func (p *DataSource) warnIndexNotApplicable(conds []expression.Expression) {
+	sc := p.ctx.GetSessionVars().StmtCtx
+	indices, _ := availableIndices(p.indexHints, p.tableInfo, p.ctx.GetSessionVars().OptimizerUseInvisibleIndexes, supportDescIndex(p.ctx))
+	for _, cond := range conds {
+		col, access := ranger.ConvertedColumn(cond)
+		if col == nil {
+			continue
+		}
+		for _, idx := range indices {
+			for _, idxCol := range idx.Columns {
+				if idxCol.Name.L == col.ColName.L {
+					sc.AppendWarning(ErrIndexNotApplicable.GenByArgs(access, idx.Name.O, col.ColName.O))
+					break
+				}
+			}
+		}
+	}
+}
+
 // PredicatePushDown implements LogicalPlan PredicatePushDown interface.
 func (p *TableDual) PredicatePushDown(predicates []expression.Expression) ([]expression.Expression, LogicalPlan, error) {
 	return predicates, p, nil
@@ -109,12 +137,16 @@ func (p *LogicalJoin) PredicatePushDown(predicates []expression.Expression) (ret
 	case LeftOuterJoin, LeftOuterSemiJoin:
 		rightCond = p.RightConditions
 		p.RightConditions = nil
+		if p.JoinType == LeftOuterJoin {
+			rightCond = append(rightCond, p.deriveInnerConds(append(leftPushCond, p.LeftConditions...), rightPlan)...)
+		}
 		leftCond = leftPushCond
 		ret = append(expression.ScalarFuncs2Exprs(equalCond), otherCond...)
 		ret = append(ret, rightPushCond...)
 	case RightOuterJoin:
 		leftCond = p.LeftConditions
 		p.LeftConditions = nil
+		leftCond = append(leftCond, p.deriveInnerConds(append(rightPushCond, p.RightConditions...), leftPlan)...)
 		rightCond = rightPushCond
 		ret = append(expression.ScalarFuncs2Exprs(equalCond), otherCond...)
 		ret = append(ret, leftPushCond...)
@@ -162,6 +194,31 @@ func (p *LogicalJoin) PredicatePushDown(predicates []expression.Expression) (ret
 	return
 }
 
+// deriveInnerConds derives the conditions on the inner child of the outer join from the equal conditions and the
+// conditions on the outer child. e.g. For "t1 left join t2 on t1.a = t2.a where t1.a > 3", the rows of t2 whose
+// t2.a <= 3 can't be joined with any row of t1, so "t2.a > 3" can be pushed to t2.
+func (p *LogicalJoin) deriveInnerConds(outerConds []expression.Expression, inner LogicalPlan) []expression.Expression {
+	conds := make([]expression.Expression, 0, len(p.EqualConditions)+len(outerConds))
+	for _, eqCond := range p.EqualConditions {
+		// The equal columns of different type classes may be different values, e.g. '10' and 10.0.
+		args := eqCond.GetArgs()
+		if args[0].GetType().ToClass() == args[1].GetType().ToClass() {
+			conds = append(conds, eqCond)
+		}
+	}
+	if len(conds) == 0 || len(outerConds) == 0 {
+		return nil
+	}
+	conds = append(conds, outerConds...)
+	var innerConds []expression.Expression
+	for _, cond := range expression.PropagateConstant(p.ctx, conds) {
+		if len(expression.ExtractColumns(cond)) > 0 && expression.ExprFromSchema(cond, inner.Schema()) {
+			innerConds = append(innerConds, cond)
+		}
+	}
+	return innerConds
+}
+
 // updateEQCond will extract the arguments of a equal condition that connect two expressions.
 func (p *LogicalJoin) updateEQCond() {
 	lChild, rChild := p.children[0], p.children[1]
@@ -427,6 +484,13 @@ func (p *Limit) PredicatePushDown(predicates []expression.Expression) ([]express
 	return predicates, p, errors.Trace(err)
 }
 
+// PredicatePushDown implements LogicalPlan PredicatePushDown interface.
+func (p *Expand) PredicatePushDown(predicates []expression.Expression) ([]expression.Expression, LogicalPlan, error) {
+	// Expand forbids any condition to push down, because a row of the child is output for every grouping set.
+	_, _, err := p.baseLogicalPlan.PredicatePushDown(nil)
+	return predicates, p, errors.Trace(err)
+}
+
 // PredicatePushDown implements LogicalPlan PredicatePushDown interface.
 func (p *MaxOneRow) PredicatePushDown(predicates []expression.Expression) ([]expression.Expression, LogicalPlan, error) {
 	// MaxOneRow forbids any condition to push down.
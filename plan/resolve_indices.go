@@ -166,6 +166,14 @@ func (p *Selection) ResolveIndices() {
 	}
 }
 
+// ResolveIndices implements Plan interface.
+func (p *Expand) ResolveIndices() {
+	p.basePlan.ResolveIndices()
+	for _, col := range p.GroupByCols {
+		col.ResolveIndices(p.children[0].Schema())
+	}
+}
+
 // ResolveIndices implements Plan interface.
 func (p *LogicalAggregation) ResolveIndices() {
 	p.basePlan.ResolveIndices()
@@ -0,0 +1,248 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plan
+
+import (
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/policy"
+	"github.com/pingcap/tidb/privilege"
+	"github.com/pingcap/tidb/terror"
+)
+
+// policyPredicateChecker checks the predicate or the mask expression of a policy only uses the columns of its
+// table by the unqualified names, so it can be rewritten on the table whatever its alias is.
+type policyPredicateChecker struct {
+	// badExpr is the error returned for the expressions which can't be used.
+	badExpr *terror.Error
+	err     error
+}
+
+func (c *policyPredicateChecker) Enter(inNode ast.Node) (ast.Node, bool) {
+	switch x := inNode.(type) {
+	case *ast.SubqueryExpr, *ast.ExistsSubqueryExpr, *ast.CompareSubqueryExpr:
+		c.err = c.badExpr.GenByArgs("subqueries")
+	case *ast.AggregateFuncExpr:
+		c.err = c.badExpr.GenByArgs("aggregate functions")
+	case *ast.ParamMarkerExpr:
+		c.err = c.badExpr.GenByArgs("parameter markers")
+	case *ast.ColumnNameExpr:
+		if x.Name.Table.L != "" {
+			c.err = c.badExpr.GenByArgs("qualified column names")
+		}
+	}
+	return inNode, c.err != nil
+}
+
+func (c *policyPredicateChecker) Leave(inNode ast.Node) (ast.Node, bool) {
+	return inNode, c.err == nil
+}
+
+func checkPolicyPredicate(expr ast.ExprNode) error {
+	checker := &policyPredicateChecker{badExpr: ErrBadPolicyPredicate}
+	expr.Accept(checker)
+	return errors.Trace(checker.err)
+}
+
+func checkMaskExpr(expr ast.ExprNode) error {
+	checker := &policyPredicateChecker{badExpr: ErrBadMaskExpr}
+	expr.Accept(checker)
+	return errors.Trace(checker.err)
+}
+
+// CheckPolicyPredicate checks the predicate of a policy can be rewritten on the columns of the table.
+func CheckPolicyPredicate(ctx context.Context, expr ast.ExprNode, tblInfo *model.TableInfo) error {
+	if err := checkPolicyPredicate(expr); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(checkTableExpr(ctx, expr, tblInfo))
+}
+
+// CheckMaskExpr checks the mask expression of a masking policy can be rewritten on the columns of the table.
+func CheckMaskExpr(ctx context.Context, expr ast.ExprNode, tblInfo *model.TableInfo) error {
+	if err := checkMaskExpr(expr); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(checkTableExpr(ctx, expr, tblInfo))
+}
+
+func checkTableExpr(ctx context.Context, expr ast.ExprNode, tblInfo *model.TableInfo) error {
+	b := &planBuilder{
+		ctx:       ctx,
+		allocator: new(idAllocator),
+		colMapper: make(map[*ast.ColumnNameExpr]int),
+	}
+	schema := expression.NewSchema(make([]*expression.Column, 0, len(tblInfo.Columns))...)
+	for _, col := range tblInfo.Columns {
+		schema.Append(&expression.Column{
+			ColName:  col.Name,
+			TblName:  tblInfo.Name,
+			RetType:  &col.FieldType,
+			Position: col.Offset,
+			Index:    col.Offset,
+			ID:       col.ID,
+		})
+	}
+	mockTablePlan := TableDual{}.init(b.allocator, ctx)
+	mockTablePlan.SetSchema(schema)
+	_, err := b.rewriteTableExpr(expr, tblInfo, mockTablePlan, "policy")
+	return errors.Trace(err)
+}
+
+// buildRowPolicies filters the rows of the data source by the row-level security policies of its table, a row
+// is visible if the predicate of any policy is true for it. The internal SQLs aren't filtered.
+func (b *planBuilder) buildRowPolicies(p *DataSource) LogicalPlan {
+	h := policy.GetHandle(b.ctx)
+	if h == nil || b.ctx.GetSessionVars().InRestrictedSQL {
+		return p
+	}
+	policies := h.Get(p.DBName.L, p.tableInfo.Name.L)
+	if len(policies) == 0 {
+		return p
+	}
+	predicates := make([]expression.Expression, 0, len(policies))
+	for _, pol := range policies {
+		node, err := pol.ParsePredicate()
+		if err == nil {
+			err = checkPolicyPredicate(node)
+		}
+		var expr expression.Expression
+		if err == nil {
+			expr, err = b.rewriteTableExpr(node, p.tableInfo, p, "policy")
+		}
+		if err != nil {
+			b.err = errors.Annotatef(err, "policy %s on %s.%s", pol.Name, pol.DB, pol.Table)
+			return nil
+		}
+		predicates = append(predicates, expr)
+	}
+	b.optFlag = b.optFlag | flagPredicatePushDown
+	b.optFlag = b.optFlag | flagSimplifyExpression
+	selection := Selection{
+		Conditions: expression.SplitCNFItems(expression.ComposeDNFCondition(b.ctx, predicates...)),
+	}.init(b.allocator, b.ctx)
+	selection.SetSchema(p.Schema().Clone())
+	addChild(selection, p)
+	return selection
+}
+
+// buildMaskingPolicies replaces the masked columns of the data source by their mask expressions with a projection
+// on p, which is the data source or the selection of its row policies. The columns aren't masked for the users
+// with the SUPER privilege and the internal SQLs.
+// The UPDATE and DELETE statements write the real values of the rows, so the real columns are kept at their
+// positions but hidden from the names, and the mask expressions are appended with the names of the masked columns.
+// Every read of a masked column, e.g. in the SET expressions, the WHERE clause or the join conditions, gets the
+// masked value, while the assigned columns are mapped back to the real columns by unmaskedColumn.
+func (b *planBuilder) buildMaskingPolicies(p LogicalPlan, ds *DataSource) LogicalPlan {
+	h := policy.GetHandle(b.ctx)
+	if h == nil || b.ctx.GetSessionVars().InRestrictedSQL {
+		return p
+	}
+	masks := h.Masks(ds.DBName.L, ds.tableInfo.Name.L)
+	if len(masks) == 0 {
+		return p
+	}
+	pm := privilege.GetPrivilegeManager(b.ctx)
+	if pm == nil || pm.RequestVerification("", "", "", mysql.SuperPriv) {
+		return p
+	}
+	schema := p.Schema()
+	exprs := make([]expression.Expression, 0, schema.Len())
+	for _, col := range schema.Columns {
+		exprs = append(exprs, col)
+	}
+	writing := b.inUpdateStmt || b.inDeleteStmt
+	// maskedIdx are the offsets of the masked columns in the schema, only used for writing.
+	var maskedIdx []int
+	masked := false
+	for _, m := range masks {
+		idx := -1
+		for i, col := range schema.Columns {
+			if col.ColName.L == m.Column && col.ID != model.ExtraHandleID {
+				idx = i
+				break
+			}
+		}
+		// The masked column may have been dropped.
+		if idx == -1 {
+			continue
+		}
+		node, err := m.ParseMaskExpr()
+		if err == nil {
+			err = checkMaskExpr(node)
+		}
+		var expr expression.Expression
+		if err == nil {
+			expr, err = b.rewriteTableExpr(node, ds.tableInfo, p, "policy")
+		}
+		if err != nil {
+			b.err = errors.Annotatef(err, "masking policy %s on %s.%s", m.Name, m.DB, m.Table)
+			return nil
+		}
+		if writing {
+			exprs = append(exprs, expr)
+			maskedIdx = append(maskedIdx, idx)
+		} else {
+			exprs[idx] = expr
+		}
+		masked = true
+	}
+	if !masked {
+		return p
+	}
+	b.optFlag = b.optFlag | flagSimplifyExpression
+	proj := Projection{Exprs: exprs}.init(b.allocator, b.ctx)
+	newSchema := schema.Clone()
+	for _, idx := range maskedIdx {
+		col := newSchema.Columns[idx].Clone().(*expression.Column)
+		newSchema.Append(col)
+		newSchema.Columns[idx].IsHidden = true
+		b.maskedColumns = append(b.maskedColumns, maskedColumn{masked: col, real: newSchema.Columns[idx]})
+	}
+	for i, col := range newSchema.Columns {
+		col.FromID = proj.ID()
+		col.Position = i
+		col.RetType = exprs[i].GetType()
+	}
+	for tblID, cols := range schema.TblID2Handle {
+		handles := make([]*expression.Column, 0, len(cols))
+		for _, col := range cols {
+			handles = append(handles, newSchema.Columns[schema.ColumnIndex(col)])
+		}
+		newSchema.TblID2Handle[tblID] = handles
+	}
+	proj.SetSchema(newSchema)
+	addChild(proj, p)
+	return proj
+}
+
+// maskedColumn is a masked column of the UPDATE or DELETE statements, and the real column hidden from the names.
+type maskedColumn struct {
+	masked *expression.Column
+	real   *expression.Column
+}
+
+// unmaskedColumn returns the real column if col is a masked column, so the assignments write the real columns.
+func (b *planBuilder) unmaskedColumn(col *expression.Column) *expression.Column {
+	for _, m := range b.maskedColumns {
+		if m.masked.Equal(col, b.ctx) {
+			return m.real
+		}
+	}
+	return col
+}
@@ -0,0 +1,176 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plan
+
+import (
+	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/util/ranger"
+)
+
+// candidatePath is an access path of the DataSource, the paths are compared by the skyline pruning.
+type candidatePath struct {
+	// index is nil for the table scan.
+	index *model.IndexInfo
+	// accessCols are the IDs of the columns used by the access conditions.
+	accessCols   map[int64]struct{}
+	isSingleScan bool
+	isMatchProp  bool
+}
+
+// compareColumnSet compares the column sets by the inclusion, the result is meaningful only if comparable is true.
+func compareColumnSet(l, r map[int64]struct{}) (result int, comparable bool) {
+	switch {
+	case len(l) < len(r):
+		return -1, isSubset(l, r)
+	case len(l) == len(r):
+		return 0, isSubset(l, r)
+	default:
+		return 1, isSubset(r, l)
+	}
+}
+
+func isSubset(l, r map[int64]struct{}) bool {
+	for id := range l {
+		if _, ok := r[id]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func compareBool(l, r bool) int {
+	if l == r {
+		return 0
+	}
+	if l {
+		return 1
+	}
+	return -1
+}
+
+// compareCandidates compares the two paths on the columns used by the access conditions, whether the index is
+// covering and whether the property is matched. It returns 1 if lhs isn't worse than rhs on all of them and better
+// on one of them, -1 if rhs is better in the same way, and 0 if neither is better.
+func compareCandidates(lhs, rhs *candidatePath) int {
+	setsResult, comparable := compareColumnSet(lhs.accessCols, rhs.accessCols)
+	if !comparable {
+		return 0
+	}
+	scanResult := compareBool(lhs.isSingleScan, rhs.isSingleScan)
+	matchResult := compareBool(lhs.isMatchProp, rhs.isMatchProp)
+	sum := setsResult + scanResult + matchResult
+	if setsResult >= 0 && scanResult >= 0 && matchResult >= 0 && sum > 0 {
+		return 1
+	}
+	if setsResult <= 0 && scanResult <= 0 && matchResult <= 0 && sum < 0 {
+		return -1
+	}
+	return 0
+}
+
+func columnIDSet(conds []expression.Expression) map[int64]struct{} {
+	set := make(map[int64]struct{}, len(conds))
+	for _, cond := range conds {
+		for _, col := range expression.ExtractColumns(cond) {
+			set[col.ID] = struct{}{}
+		}
+	}
+	return set
+}
+
+// getTableCandidate returns the candidate path of the table scan.
+func (p *DataSource) getTableCandidate(prop *requiredProp) *candidatePath {
+	candidate := &candidatePath{isSingleScan: true, accessCols: map[int64]struct{}{}}
+	pkCol := p.getPKIsHandleCol()
+	if pkCol == nil {
+		return candidate
+	}
+	candidate.isMatchProp = len(prop.cols) == 1 && prop.cols[0].Equal(pkCol, nil)
+	if len(p.pushedDownConds) > 0 {
+		conds := make([]expression.Expression, 0, len(p.pushedDownConds))
+		for _, cond := range p.pushedDownConds {
+			conds = append(conds, cond.Clone())
+		}
+		accessConds, _ := ranger.DetachColumnConditions(conds, pkCol.ColName)
+		candidate.accessCols = columnIDSet(accessConds)
+	}
+	return candidate
+}
+
+// getIndexCandidate returns the candidate path of the index scan. The ranges aren't built, only the access
+// conditions are detached.
+func (p *DataSource) getIndexCandidate(prop *requiredProp, idx *model.IndexInfo) *candidatePath {
+	candidate := &candidatePath{index: idx}
+	idxCols, colLengths := expression.IndexInfo2Cols(p.Schema().Columns, idx)
+	var accessConds, filterConds []expression.Expression
+	if len(idxCols) > 0 {
+		accessConds, filterConds = ranger.DetachIndexConditions(p.pushedDownConds, idxCols, colLengths)
+	} else {
+		filterConds = p.pushedDownConds
+	}
+	candidate.accessCols = columnIDSet(accessConds)
+	candidate.isSingleScan = isCoveringIndex(p.Columns, idx.Columns, p.tableInfo.PKIsHandle) ||
+		p.isCoveredByPrefixIndex(idx, filterConds)
+	candidate.isMatchProp, _ = matchIndexProp(idx, accessConds, prop)
+	return candidate
+}
+
+// skylinePruning removes the indices which are dominated by the other access paths, the dominated index isn't
+// better on any of the dimensions compared by compareCandidates, so it's hardly the best one and it's worse
+// than the others obviously even if the statistics are stale. Either the table scan or an index prunes the
+// others, but the table scan is always kept. The expression indices aren't pruned because their conditions
+// are substituted to build the ranges.
+func (p *DataSource) skylinePruning(prop *requiredProp, indices []*model.IndexInfo, includeTableScan bool) []*model.IndexInfo {
+	if prop.taskTp == copDoubleReadTaskType {
+		// Only the index lookups are valid, a covering index isn't better.
+		return indices
+	}
+	candidates := make([]*candidatePath, 0, len(indices)+1)
+	if includeTableScan {
+		candidates = append(candidates, p.getTableCandidate(prop))
+	}
+	for _, idx := range indices {
+		if isExpressionIndex(p.tableInfo, idx) {
+			candidates = append(candidates, &candidatePath{index: idx})
+			continue
+		}
+		current := p.getIndexCandidate(prop, idx)
+		pruned := false
+		for i := len(candidates) - 1; i >= 0; i-- {
+			if candidates[i].accessCols == nil {
+				// The expression index isn't compared.
+				continue
+			}
+			result := compareCandidates(candidates[i], current)
+			if result == 1 {
+				pruned = true
+				// The indices dominated by the current one are dominated by candidates[i] too, they are pruned already.
+				break
+			} else if result == -1 && candidates[i].index != nil {
+				candidates = append(candidates[:i], candidates[i+1:]...)
+			}
+		}
+		if !pruned {
+			candidates = append(candidates, current)
+		}
+	}
+	result := make([]*model.IndexInfo, 0, len(candidates))
+	for _, candidate := range candidates {
+		if candidate.index != nil {
+			result = append(result, candidate.index)
+		}
+	}
+	return result
+}
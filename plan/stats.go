@@ -277,4 +277,20 @@ func (p *LogicalApply) prepareStatsProfile() *statsProfile {
 	return p.profile
 }
 
+func (p *Expand) prepareStatsProfile() *statsProfile {
+	childProfile := p.children[0].(LogicalPlan).prepareStatsProfile()
+	sets := float64(len(p.GroupingSets))
+	p.profile = &statsProfile{
+		count:       childProfile.count * sets,
+		cardinality: make([]float64, 0, p.schema.Len()),
+	}
+	p.profile.cardinality = append(p.profile.cardinality, childProfile.cardinality...)
+	for _, col := range p.GroupByCols {
+		// The copy of a column has one more distinct value: null.
+		p.profile.cardinality = append(p.profile.cardinality, getCardinality([]*expression.Column{col}, p.children[0].Schema(), childProfile)+1)
+	}
+	p.profile.cardinality = append(p.profile.cardinality, sets)
+	return p.profile
+}
+
 // TODO: Implement Exists, MaxOneRow plan.
@@ -99,6 +99,8 @@ func toString(in Plan, strs []string, idxs []int) ([]string, []int) {
 		str = "Exists"
 	case *MaxOneRow:
 		str = "MaxOneRow"
+	case *Expand:
+		str = "Expand"
 	case *Limit:
 		str = "Limit"
 	case *SelectLock:
@@ -17,10 +17,12 @@ import (
 	"fmt"
 	"math"
 
+	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/util/charset"
 	"github.com/pingcap/tidb/util/types"
 )
@@ -101,7 +103,8 @@ func attachPlan2Task(p PhysicalPlan, t task) task {
 // finishIndexPlan means we no longer add plan to index plan, and compute the network cost for it.
 func (t *copTask) finishIndexPlan() {
 	if !t.indexPlanFinished {
-		t.cst += t.count() * (netWorkFactor + scanFactor)
+		factors := costFactors(t.indexPlan.context())
+		t.cst += t.count() * (factors.Network + factors.Scan)
 		t.indexPlanFinished = true
 		if t.tablePlan != nil {
 			t.tablePlan.(*PhysicalTableScan).profile = t.indexPlan.statsProfile()
@@ -145,13 +148,14 @@ func (p *PhysicalIndexJoin) getCost(lCnt float64) float64 {
 	if lCnt < 1 {
 		lCnt = 1
 	}
-	cst := lCnt * netWorkFactor
+	factors := costFactors(p.ctx)
+	cst := lCnt * factors.Network
 	batchSize := p.ctx.GetSessionVars().IndexJoinBatchSize
 	if p.KeepOrder {
 		batchSize = 1
 	}
 	cst += lCnt * math.Log2(math.Min(float64(batchSize), lCnt)) * 2
-	cst += lCnt / float64(batchSize) * netWorkStartFactor
+	cst += lCnt / float64(batchSize) * factors.Seek
 	if p.KeepOrder {
 		return cst * 2
 	}
@@ -214,6 +218,11 @@ func (p *PhysicalHashSemiJoin) attach2Task(tasks ...task) task {
 	return task
 }
 
+// costFactors returns the cost factors of the session.
+func costFactors(ctx context.Context) *variable.CostFactors {
+	return &ctx.GetSessionVars().CostFactors
+}
+
 // finishCopTask means we close the coprocessor task and create a root task.
 func finishCopTask(task task, ctx context.Context, allocator *idAllocator) task {
 	t, ok := task.(*copTask)
@@ -224,7 +233,7 @@ func finishCopTask(task task, ctx context.Context, allocator *idAllocator) task
 	// `NetWorkStartCost` * (totalCount / perCountIndexRead)
 	t.finishIndexPlan()
 	if t.tablePlan != nil {
-		t.cst += t.count() * netWorkFactor
+		t.cst += t.count() * costFactors(ctx).Network
 	}
 	newTask := &rootTask{
 		cst: t.cst,
@@ -280,7 +289,10 @@ func (p *Limit) attach2Task(tasks ...task) task {
 		return tasks[0]
 	}
 	t := tasks[0].copy()
-	if cop, ok := t.(*copTask); ok {
+	if p.CalcFoundRows {
+		// All the rows are read to count the found rows.
+		t = finishCopTask(t, p.ctx, p.allocator)
+	} else if cop, ok := t.(*copTask); ok {
 		// If the task is copTask, the Limit can always be pushed down.
 		// When limit be pushed down, it should remove its offset.
 		pushedDownLimit := Limit{Count: p.Offset + p.Count}.init(p.allocator, p.ctx)
@@ -292,6 +304,8 @@ func (p *Limit) attach2Task(tasks ...task) task {
 		}
 		cop = attachPlan2Task(pushedDownLimit, cop).(*copTask)
 		t = finishCopTask(cop, p.ctx, p.allocator)
+	} else {
+		t = p.pushDownDistinctAgg(t)
 	}
 	if !p.partial {
 		t = attachPlan2Task(p.Copy(), t)
@@ -299,15 +313,66 @@ func (p *Limit) attach2Task(tasks ...task) task {
 	return t
 }
 
+// pushDownDistinctAgg pushes the limit across the final aggregation which only gets the distinct values, and puts it
+// above the partial aggregation in the coprocessor. e.g. For "select distinct b from t limit 10", every coprocessor task
+// returns at most 10 distinct values of its region, because any 10 of them are also distinct in the final result.
+func (p *Limit) pushDownDistinctAgg(t task) task {
+	root, ok := t.(*rootTask)
+	if !ok {
+		return t
+	}
+	finalAgg, ok := root.p.(*PhysicalAggregation)
+	if !ok || finalAgg.AggType != FinalAgg || len(finalAgg.GroupByItems) == 0 {
+		return t
+	}
+	for _, aggFunc := range finalAgg.AggFuncs {
+		if aggFunc.GetName() != ast.AggFuncFirstRow {
+			return t
+		}
+	}
+	var reader PhysicalPlan
+	var partialAgg PhysicalPlan
+	switch x := finalAgg.children[0].(type) {
+	case *PhysicalTableReader:
+		partialAgg = x.tablePlan
+		reader = x.Copy()
+	case *PhysicalIndexReader:
+		partialAgg = x.indexPlan
+		reader = x.Copy()
+	default:
+		return t
+	}
+	if _, ok := partialAgg.(*PhysicalAggregation); !ok {
+		return t
+	}
+	pushedDownLimit := Limit{Count: p.Offset + p.Count}.init(p.allocator, p.ctx)
+	pushedDownLimit.profile = p.profile
+	pushedDownLimit.SetSchema(partialAgg.Schema())
+	pushedDownLimit.SetChildren(partialAgg)
+	if tableReader, ok := reader.(*PhysicalTableReader); ok {
+		tableReader.tablePlan = pushedDownLimit
+		tableReader.TablePlans = flattenPushDownPlan(pushedDownLimit)
+	} else {
+		indexReader := reader.(*PhysicalIndexReader)
+		indexReader.indexPlan = pushedDownLimit
+		indexReader.IndexPlans = flattenPushDownPlan(pushedDownLimit)
+	}
+	newAgg := finalAgg.Copy()
+	newAgg.SetChildren(reader)
+	return &rootTask{p: newAgg, cst: root.cst}
+}
+
 func (p *Sort) getCost(count float64) float64 {
 	if count < 2.0 {
 		count = 2.0
 	}
-	return count*cpuFactor + count*memoryFactor
+	factors := costFactors(p.ctx)
+	return count*factors.CPU + count*factors.Memory
 }
 
 func (p *TopN) getCost(count float64) float64 {
-	return count*cpuFactor + float64(p.Count)*memoryFactor
+	factors := costFactors(p.ctx)
+	return count*factors.CPU + float64(p.Count)*factors.Memory
 }
 
 // canPushDown checks if this topN can be pushed down. If each of the expression can be converted to pb, it can be pushed.
@@ -400,11 +465,18 @@ func (p *Union) attach2Task(tasks ...task) task {
 
 func (sel *Selection) attach2Task(tasks ...task) task {
 	t := finishCopTask(tasks[0].copy(), sel.ctx, sel.allocator)
-	t.addCost(t.count() * cpuFactor)
+	t.addCost(t.count() * costFactors(sel.ctx).CPU)
 	t = attachPlan2Task(sel.Copy(), t)
 	return t
 }
 
+func (p *Expand) attach2Task(tasks ...task) task {
+	t := finishCopTask(tasks[0].copy(), p.ctx, p.allocator)
+	t = attachPlan2Task(p.Copy(), t)
+	t.addCost(t.count() * costFactors(p.ctx).CPU)
+	return t
+}
+
 func (p *PhysicalAggregation) newPartialAggregate() (partialAgg, finalAgg *PhysicalAggregation) {
 	finalAgg = p.Copy().(*PhysicalAggregation)
 	// Check if this aggregation can push down.
@@ -448,6 +520,15 @@ func (p *PhysicalAggregation) newPartialAggregate() (partialAgg, finalAgg *Physi
 			args = append(args, partialSchema.Columns[cursor].Clone())
 			cursor++
 		}
+		if needSketch(fun) {
+			partialSchema.Append(&expression.Column{FromID: partialAgg.id, Position: cursor, ColName: colName, RetType: sketchFieldType()})
+			args = append(args, partialSchema.Columns[cursor].Clone())
+			cursor++
+			// The final approx_percentile needs the percentage besides the digest.
+			if fun.GetName() == ast.AggFuncApproxPercentile {
+				args = append(args, aggFun.GetArgs()[1].Clone())
+			}
+		}
 		fun.SetArgs(args)
 		fun.SetMode(expression.FinalMode)
 		finalAggFuncs[i] = fun
@@ -477,8 +558,13 @@ func (p *PhysicalAggregation) attach2Task(tasks ...task) task {
 	if tasks[0].plan() == nil {
 		return tasks[0]
 	}
-	// TODO: We only consider hash aggregation here.
 	task := tasks[0].copy()
+	if p.AggType == StreamedAgg {
+		// The child of the stream aggregation is always a root task in order.
+		attachPlan2Task(p.Copy(), task)
+		task.addCost(task.count() * costFactors(p.ctx).CPU)
+		return task
+	}
 	if cop, ok := task.(*copTask); ok {
 		partialAgg, finalAgg := p.newPartialAggregate()
 		if partialAgg != nil {
@@ -486,11 +572,11 @@ func (p *PhysicalAggregation) attach2Task(tasks ...task) task {
 				cop.finishIndexPlan()
 				partialAgg.SetChildren(cop.tablePlan)
 				cop.tablePlan = partialAgg
-				cop.cst += cop.count() * cpuFactor
+				cop.cst += cop.count() * costFactors(p.ctx).CPU
 			} else {
 				partialAgg.SetChildren(cop.indexPlan)
 				cop.indexPlan = partialAgg
-				cop.cst += cop.count() * cpuFactor
+				cop.cst += cop.count() * costFactors(p.ctx).CPU
 			}
 		}
 		task = finishCopTask(cop, p.ctx, p.allocator)
@@ -498,7 +584,9 @@ func (p *PhysicalAggregation) attach2Task(tasks ...task) task {
 	} else {
 		np := p.Copy()
 		attachPlan2Task(np, task)
-		task.addCost(task.count() * cpuFactor)
+		task.addCost(task.count() * costFactors(p.ctx).CPU)
 	}
+	// The hash table holds all the groups, the stream aggregation doesn't need it.
+	task.addCost(p.statsProfile().count * costFactors(p.ctx).Memory)
 	return task
 }
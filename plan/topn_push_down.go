@@ -78,6 +78,9 @@ func (p *Limit) convertToTopN() *TopN {
 }
 
 func (p *Limit) pushDownTopN(topN *TopN) LogicalPlan {
+	if p.CalcFoundRows {
+		return p.baseLogicalPlan.pushDownTopN(topN)
+	}
 	child := p.children[0].(LogicalPlan).pushDownTopN(p.convertToTopN())
 	if topN != nil {
 		return topN.setChild(child, false)
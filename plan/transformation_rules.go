@@ -0,0 +1,140 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plan
+
+import (
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/expression"
+)
+
+// transformationRule transforms a group expression to the equivalent expressions of the cascades planner.
+type transformationRule interface {
+	// name is the unique name of the rule.
+	name() string
+	// match checks whether the rule can be applied to the expression.
+	match(expr *groupExpr) bool
+	// apply adds the equivalent expressions of the expression to its group.
+	apply(m *memo, g *group, expr *groupExpr)
+}
+
+var transformationRules = []transformationRule{
+	&aggPushDownJoinRule{},
+	&joinCommuteRule{},
+}
+
+// placeholder returns a child plan which stands for the group before the children of the expressions are
+// replaced by the groups, it's used to build the new expressions.
+func placeholder(g *group) *groupPlan {
+	return &groupPlan{LogicalPlan: g.exprs[0].node, group: g}
+}
+
+// aggPushDownJoinRule pushes the aggregation across the join: "Agg(Join(A, B))" is transformed into
+// "Agg(Join(Agg(A), B))", "Agg(Join(A, Agg(B)))" or "Agg(Join(Agg(A), Agg(B)))", the aggregate functions are
+// decomposed into the partial ones below the join and the final ones above it. The aggregation below the join
+// may reduce the rows to be joined a lot, but it's more expensive if the join filters most of the rows, so the
+// decision is made by the cost.
+type aggPushDownJoinRule struct{}
+
+func (r *aggPushDownJoinRule) name() string {
+	return "agg_push_down_join"
+}
+
+func (r *aggPushDownJoinRule) match(expr *groupExpr) bool {
+	_, ok := expr.node.(*LogicalAggregation)
+	return ok && expr.children[0].hasJoin()
+}
+
+func (r *aggPushDownJoinRule) apply(m *memo, g *group, expr *groupExpr) {
+	agg := expr.node.(*LogicalAggregation)
+	child := expr.children[0]
+	for _, childExpr := range child.exprs {
+		join, ok := childExpr.node.(*LogicalJoin)
+		if !ok {
+			continue
+		}
+		// The aggregation and the join are copied, because pushing down changes them.
+		newJoin := (*join).init(m.allocator, m.ctx)
+		newJoin.SetSchema(join.Schema().Clone())
+		placeholders := []Plan{placeholder(childExpr.children[0]), placeholder(childExpr.children[1])}
+		newJoin.SetChildren(placeholders...)
+		newAgg := LogicalAggregation{
+			AggFuncs:     make([]expression.AggregationFunction, 0, len(agg.AggFuncs)),
+			GroupByItems: agg.GroupByItems,
+			groupByCols:  agg.groupByCols,
+		}.init(m.allocator, m.ctx)
+		for _, aggFunc := range agg.AggFuncs {
+			newAgg.AggFuncs = append(newAgg.AggFuncs, aggFunc.Clone())
+		}
+		newAgg.SetSchema(agg.Schema().Clone())
+		newAgg.SetChildren(newJoin)
+		a := &aggregationOptimizer{ctx: m.ctx, allocator: m.allocator}
+		if !a.pushAggAcrossJoin(newAgg, newJoin) {
+			continue
+		}
+		pushed := false
+		joinChildren := make([]*group, 0, 2)
+		for i, joinChild := range newJoin.Children() {
+			if joinChild == placeholders[i] {
+				joinChildren = append(joinChildren, childExpr.children[i])
+				continue
+			}
+			pushed = true
+			joinChildren = append(joinChildren, m.newGroup(joinChild.(LogicalPlan), childExpr.children[i]))
+		}
+		if !pushed {
+			continue
+		}
+		newExpr := g.addExpr(newAgg, m.newGroup(newJoin, joinChildren...))
+		newExpr.applied[r.name()] = true
+	}
+}
+
+// joinCommuteRule swaps the children of the inner join: "Join(A, B)" is transformed into "Proj(Join(B, A))",
+// the projection keeps the order of the output columns.
+type joinCommuteRule struct{}
+
+func (r *joinCommuteRule) name() string {
+	return "join_commute"
+}
+
+func (r *joinCommuteRule) match(expr *groupExpr) bool {
+	join, ok := expr.node.(*LogicalJoin)
+	// The hint of index join refers to the children by their positions.
+	return ok && join.JoinType == InnerJoin && join.preferINLJ == 0
+}
+
+func (r *joinCommuteRule) apply(m *memo, g *group, expr *groupExpr) {
+	join := expr.node.(*LogicalJoin)
+	left, right := expr.children[0], expr.children[1]
+	newJoin := (*join).init(m.allocator, m.ctx)
+	newJoin.LeftConditions, newJoin.RightConditions = join.RightConditions, join.LeftConditions
+	newJoin.LeftJoinKeys, newJoin.RightJoinKeys = join.RightJoinKeys, join.LeftJoinKeys
+	newJoin.EqualConditions = make([]*expression.ScalarFunction, 0, len(join.EqualConditions))
+	for _, eqCond := range join.EqualConditions {
+		args := eqCond.GetArgs()
+		newCond, err := expression.NewFunction(m.ctx, ast.EQ, eqCond.GetType(), args[1], args[0])
+		if err != nil {
+			return
+		}
+		newJoin.EqualConditions = append(newJoin.EqualConditions, newCond.(*expression.ScalarFunction))
+	}
+	newJoin.SetSchema(expression.MergeSchema(right.exprs[0].node.Schema(), left.exprs[0].node.Schema()))
+	joinGroup := m.newGroup(newJoin, right, left)
+	// Swapping it again gets the original join.
+	joinGroup.exprs[0].applied[r.name()] = true
+
+	proj := Projection{Exprs: expression.Column2Exprs(join.Schema().Columns)}.init(m.allocator, m.ctx)
+	proj.SetSchema(join.Schema().Clone())
+	g.addExpr(proj, joinGroup)
+}
@@ -15,6 +15,7 @@ package plan
 
 import (
 	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/expression"
 )
 
 // AggregateFuncExtractor visits Expr tree.
@@ -45,3 +46,98 @@ func (a *AggregateFuncExtractor) Leave(n ast.Node) (ast.Node, bool) {
 	}
 	return n, true
 }
+
+// IsDeterministic checks if the plan returns the same rows every time it's executed with the same values of
+// the correlated columns, the plans evaluating the non-deterministic expressions like rand() don't.
+func IsDeterministic(p PhysicalPlan) bool {
+	var exprs []expression.Expression
+	switch x := p.(type) {
+	case *Selection:
+		exprs = x.Conditions
+	case *Projection:
+		exprs = x.Exprs
+	case *Sort:
+		exprs = byItemsExprs(x.ByItems)
+	case *TopN:
+		exprs = byItemsExprs(x.ByItems)
+	case *PhysicalAggregation:
+		exprs = aggregationExprs(x.AggFuncs, x.GroupByItems)
+	case *PhysicalHashJoin:
+		exprs = joinExprs(x.EqualConditions, x.LeftConditions, x.RightConditions, x.OtherConditions)
+	case *PhysicalMergeJoin:
+		exprs = joinExprs(x.EqualConditions, x.LeftConditions, x.RightConditions, x.OtherConditions)
+	case *PhysicalHashSemiJoin:
+		exprs = joinExprs(x.EqualConditions, x.LeftConditions, x.RightConditions, x.OtherConditions)
+	case *PhysicalIndexJoin:
+		exprs = joinExprs(nil, x.LeftConditions, x.RightConditions, x.OtherConditions)
+	case *PhysicalApply:
+		return IsDeterministic(x.PhysicalJoin)
+	case *PhysicalUnionScan:
+		exprs = x.Conditions
+	case *PhysicalTableScan:
+		exprs = x.physicalTableSource.exprs()
+	case *PhysicalIndexScan:
+		exprs = x.physicalTableSource.exprs()
+	case *PhysicalTableReader:
+		return physicalPlansDeterministic(x.TablePlans)
+	case *PhysicalIndexReader:
+		return physicalPlansDeterministic(x.IndexPlans)
+	case *PhysicalIndexLookUpReader:
+		return physicalPlansDeterministic(x.IndexPlans) && physicalPlansDeterministic(x.TablePlans)
+	}
+	for _, expr := range exprs {
+		if !expression.IsDeterministic(expr) {
+			return false
+		}
+	}
+	return plansDeterministic(p.Children())
+}
+
+func plansDeterministic(plans []Plan) bool {
+	for _, p := range plans {
+		if pp, ok := p.(PhysicalPlan); ok && !IsDeterministic(pp) {
+			return false
+		}
+	}
+	return true
+}
+
+// physicalPlansDeterministic checks the flattened plans pushed down to the coprocessor.
+func physicalPlansDeterministic(plans []PhysicalPlan) bool {
+	for _, p := range plans {
+		if !IsDeterministic(p) {
+			return false
+		}
+	}
+	return true
+}
+
+func byItemsExprs(items []*ByItems) []expression.Expression {
+	exprs := make([]expression.Expression, 0, len(items))
+	for _, item := range items {
+		exprs = append(exprs, item.Expr)
+	}
+	return exprs
+}
+
+func aggregationExprs(aggFuncs []expression.AggregationFunction, gbyItems []expression.Expression) []expression.Expression {
+	exprs := append([]expression.Expression(nil), gbyItems...)
+	for _, fun := range aggFuncs {
+		exprs = append(exprs, fun.GetArgs()...)
+	}
+	return exprs
+}
+
+func joinExprs(eqConds []*expression.ScalarFunction, conds ...[]expression.Expression) []expression.Expression {
+	exprs := expression.ScalarFuncs2Exprs(eqConds)
+	for _, c := range conds {
+		exprs = append(exprs, c...)
+	}
+	return exprs
+}
+
+func (p *physicalTableSource) exprs() []expression.Expression {
+	exprs := aggregationExprs(p.aggFuncs, p.gbyItems)
+	exprs = append(exprs, byItemsExprs(p.sortItems)...)
+	return joinExprs(nil, exprs, p.AccessCondition, p.indexFilterConditions, p.tableFilterConditions, p.filterCondition)
+}
@@ -154,7 +154,7 @@ func isConstraintKeyTp(constraints []*ast.Constraint, colDef *ast.ColumnDef) boo
 		}
 		// If the constraint as follows: primary key(c1, c2)
 		// we only support c1 column can be auto_increment.
-		if colDef.Name.Name.L != c.Keys[0].Column.Name.L {
+		if c.Keys[0].Column == nil || colDef.Name.Name.L != c.Keys[0].Column.Name.L {
 			continue
 		}
 		switch c.Tp {
@@ -272,15 +272,34 @@ func (v *validator) checkCreateTableGrammar(stmt *ast.CreateTableStmt) {
 				return
 			}
 			countPrimaryKey++
+			if hasExpressionKey(constraint.Keys) {
+				v.err = ddl.ErrFunctionalIndexPrimaryKey
+				return
+			}
 			err := checkIndexInfo(constraint.Name, constraint.Keys)
 			if err != nil {
 				v.err = err
 				return
 			}
+		case ast.ConstraintForeignKey:
+			if hasExpressionKey(constraint.Keys) || (constraint.Refer != nil && hasExpressionKey(constraint.Refer.IndexColNames)) {
+				v.err = infoschema.ErrCannotAddForeign
+				return
+			}
 		}
 	}
 }
 
+// hasExpressionKey checks whether there is an expression key in the index column names.
+func hasExpressionKey(indexColNames []*ast.IndexColName) bool {
+	for _, ic := range indexColNames {
+		if ic.Expr != nil {
+			return true
+		}
+	}
+	return false
+}
+
 func (v *validator) checkDropTableGrammar(stmt *ast.DropTableStmt) {
 	if stmt.Tables == nil {
 		v.err = ddl.ErrWrongTableName.GenByArgs("")
@@ -365,11 +384,17 @@ func (v *validator) checkAlterTableGrammar(stmt *ast.AlterTableStmt) {
 	}
 }
 
-// checkDuplicateColumnName checks if index exists duplicated columns.
+// checkDuplicateColumnName checks if index exists duplicated columns, the expression keys are not checked.
 func checkDuplicateColumnName(indexColNames []*ast.IndexColName) error {
 	for i := 0; i < len(indexColNames); i++ {
+		if indexColNames[i].Expr != nil {
+			continue
+		}
 		name1 := indexColNames[i].Column.Name
 		for j := i + 1; j < len(indexColNames); j++ {
+			if indexColNames[j].Expr != nil {
+				continue
+			}
 			name2 := indexColNames[j].Column.Name
 			if name1.L == name2.L {
 				return infoschema.ErrColumnExists.GenByArgs(name2)
@@ -0,0 +1,347 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package plugin manages the plugins which extend the server.
+//
+// A plugin is described by a Manifest, it's either registered by the code built into the server
+// or loaded from a Go plugin file which exports the symbol "PluginManifest" of the type
+// func() *Manifest. The lifecycle of a plugin:
+//
+//	registered --Init: Validate, OnInit--> active <--Enable/Disable--> disabled
+//
+// All the plugins are shut down by Shutdown with OnShutdown when the server shuts down.
+//
+// The hooks of a plugin are called only when it's active, the hook called depends on the Kind.
+package plugin
+
+import (
+	goplugin "plugin"
+	"sort"
+	"sync"
+
+	"github.com/juju/errors"
+	"github.com/ngaut/log"
+	"github.com/pingcap/tidb/util/audit"
+)
+
+// Kind is the kind of a plugin, it decides which hook of the plugin is called.
+type Kind uint8
+
+// Plugin kinds.
+const (
+	// Audit plugins receive the audit events by the AuditSink.
+	Audit Kind = iota + 1
+	// Authentication plugins authenticate the connections by Authenticate.
+	Authentication
+	// Schema plugins are notified of the schema changes by OnSchemaChange.
+	Schema
+)
+
+// String implements fmt.Stringer interface.
+func (k Kind) String() string {
+	switch k {
+	case Audit:
+		return "AUDIT"
+	case Authentication:
+		return "AUTHENTICATION"
+	case Schema:
+		return "SCHEMA"
+	}
+	return "UNKNOWN"
+}
+
+// State is the state of a plugin.
+type State uint8
+
+// Plugin states.
+const (
+	Registered State = iota
+	Active
+	Disabled
+)
+
+// String implements fmt.Stringer interface, the strings are the PLUGIN_STATUS of the information schema.
+func (s State) String() string {
+	switch s {
+	case Registered:
+		return "INACTIVE"
+	case Active:
+		return "ACTIVE"
+	}
+	return "DISABLED"
+}
+
+// SchemaEvent is the event of a schema change on this server, it's sent after the information schema
+// of the new version is loaded.
+type SchemaEvent struct {
+	OldVersion int64
+	NewVersion int64
+	// ChangedTableIDs are the IDs of the changed tables, it's empty if the whole schema is reloaded.
+	ChangedTableIDs []int64
+}
+
+// Manifest describes a plugin and its hooks.
+type Manifest struct {
+	Name string
+	// Version is the version of the plugin like MySQL, the major version is in the high byte
+	// and the minor version is in the low byte.
+	Version     uint16
+	Kind        Kind
+	Description string
+
+	// Validate checks whether the plugin can be initialized, it's called before OnInit.
+	Validate func(m *Manifest) error
+	// OnInit initializes the plugin when the server starts.
+	OnInit func(m *Manifest) error
+	// OnShutdown releases the resources of the plugin when the server shuts down.
+	OnShutdown func(m *Manifest) error
+
+	// AuditSink receives the audit events, it's used by the Audit plugins.
+	AuditSink audit.Sink
+	// Authenticate authenticates the user who connects from the host, it's used by the Authentication
+	// plugins. The plugin returns handled as false if it doesn't know the user, then the user is
+	// authenticated by the next plugin or the privilege tables.
+	Authenticate func(user, host string, auth, salt []byte) (ok bool, handled bool)
+	// OnSchemaChange is called when the schema is changed, it's used by the Schema plugins.
+	OnSchemaChange func(event *SchemaEvent)
+}
+
+// Info is the information of a plugin.
+type Info struct {
+	Name        string
+	Version     uint16
+	Kind        Kind
+	Description string
+	State       State
+	// Library is the path of the Go plugin file, it's empty for a built-in plugin.
+	Library string
+}
+
+type plugin struct {
+	*Manifest
+	library string
+	state   State
+}
+
+func (p *plugin) info() Info {
+	return Info{
+		Name:        p.Name,
+		Version:     p.Version,
+		Kind:        p.Kind,
+		Description: p.Description,
+		State:       p.state,
+		Library:     p.library,
+	}
+}
+
+var plugins = struct {
+	sync.RWMutex
+	byName map[string]*plugin
+	// list keeps the plugins in the order of registration, the hooks are called in this order.
+	list []*plugin
+}{byName: make(map[string]*plugin)}
+
+// manifestSymbol is the symbol exported by a Go plugin file, it's of the type func() *Manifest.
+const manifestSymbol = "PluginManifest"
+
+// Register registers a built-in plugin, it's initialized by Init.
+func Register(m *Manifest) error {
+	return errors.Trace(register(m, ""))
+}
+
+// Load loads the plugin from the Go plugin file at path, it's initialized by Init.
+func Load(path string) error {
+	p, err := goplugin.Open(path)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	sym, err := p.Lookup(manifestSymbol)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	fn, ok := sym.(func() *Manifest)
+	if !ok {
+		return errors.Errorf("symbol %s of plugin %s should be a func() *plugin.Manifest", manifestSymbol, path)
+	}
+	return errors.Trace(register(fn(), path))
+}
+
+func register(m *Manifest, library string) error {
+	if m == nil || m.Name == "" {
+		return errors.New("the plugin has no name")
+	}
+	switch m.Kind {
+	case Audit:
+		if m.AuditSink == nil {
+			return errors.Errorf("audit plugin %s has no AuditSink", m.Name)
+		}
+	case Authentication:
+		if m.Authenticate == nil {
+			return errors.Errorf("authentication plugin %s has no Authenticate", m.Name)
+		}
+	case Schema:
+		if m.OnSchemaChange == nil {
+			return errors.Errorf("schema plugin %s has no OnSchemaChange", m.Name)
+		}
+	default:
+		return errors.Errorf("plugin %s has unknown kind %d", m.Name, m.Kind)
+	}
+	plugins.Lock()
+	defer plugins.Unlock()
+	if _, ok := plugins.byName[m.Name]; ok {
+		return errors.Errorf("plugin %s is already registered", m.Name)
+	}
+	p := &plugin{Manifest: m, library: library}
+	plugins.byName[m.Name] = p
+	plugins.list = append(plugins.list, p)
+	return nil
+}
+
+// Init validates and initializes the registered plugins, the plugins are active after they are initialized.
+// It stops at the first plugin which fails and returns the error.
+func Init() error {
+	plugins.Lock()
+	defer plugins.Unlock()
+	for _, p := range plugins.list {
+		if p.state != Registered {
+			continue
+		}
+		if p.Validate != nil {
+			if err := p.Validate(p.Manifest); err != nil {
+				return errors.Annotatef(err, "validate plugin %s", p.Name)
+			}
+		}
+		if p.OnInit != nil {
+			if err := p.OnInit(p.Manifest); err != nil {
+				return errors.Annotatef(err, "init plugin %s", p.Name)
+			}
+		}
+		p.activate()
+		log.Infof("[plugin] %s %s plugin is initialized", p.Name, p.Kind)
+	}
+	return nil
+}
+
+// Shutdown shuts down the initialized plugins in the reverse order of registration, and clears the
+// registered plugins.
+func Shutdown() {
+	plugins.Lock()
+	defer plugins.Unlock()
+	for i := len(plugins.list) - 1; i >= 0; i-- {
+		p := plugins.list[i]
+		if p.state != Active && p.state != Disabled {
+			continue
+		}
+		p.deactivate()
+		if p.OnShutdown != nil {
+			if err := p.OnShutdown(p.Manifest); err != nil {
+				log.Errorf("[plugin] shutdown plugin %s fail: %v", p.Name, err)
+			}
+		}
+	}
+	plugins.list = plugins.list[:0]
+	plugins.byName = make(map[string]*plugin)
+}
+
+// activate makes the plugin active, the audit sink is registered for an Audit plugin.
+func (p *plugin) activate() {
+	p.state = Active
+	if p.Kind == Audit {
+		audit.Register(p.AuditSink)
+	}
+}
+
+// deactivate stops calling the hooks of the plugin.
+func (p *plugin) deactivate() {
+	if p.state == Active && p.Kind == Audit {
+		audit.Unregister(p.AuditSink)
+	}
+	p.state = Disabled
+}
+
+// Enable enables the disabled plugin.
+func Enable(name string) error {
+	plugins.Lock()
+	defer plugins.Unlock()
+	p, ok := plugins.byName[name]
+	if !ok {
+		return errors.Errorf("plugin %s doesn't exist", name)
+	}
+	switch p.state {
+	case Registered:
+		return errors.Errorf("plugin %s isn't initialized", name)
+	case Disabled:
+		p.activate()
+	}
+	return nil
+}
+
+// Disable disables the active plugin, its hooks are not called until it's enabled.
+func Disable(name string) error {
+	plugins.Lock()
+	defer plugins.Unlock()
+	p, ok := plugins.byName[name]
+	if !ok {
+		return errors.Errorf("plugin %s doesn't exist", name)
+	}
+	switch p.state {
+	case Registered:
+		return errors.Errorf("plugin %s isn't initialized", name)
+	case Active:
+		p.deactivate()
+	}
+	return nil
+}
+
+// Plugins returns the information of the plugins, in the order of the name.
+func Plugins() []Info {
+	plugins.RLock()
+	infos := make([]Info, 0, len(plugins.list))
+	for _, p := range plugins.list {
+		infos = append(infos, p.info())
+	}
+	plugins.RUnlock()
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+// activePlugins returns the active plugins of the kind.
+func activePlugins(kind Kind) []*plugin {
+	plugins.RLock()
+	defer plugins.RUnlock()
+	var ps []*plugin
+	for _, p := range plugins.list {
+		if p.state == Active && p.Kind == kind {
+			ps = append(ps, p)
+		}
+	}
+	return ps
+}
+
+// Authenticate authenticates the user by the active Authentication plugins, handled is false if no plugin
+// handles the user.
+func Authenticate(user, host string, auth, salt []byte) (ok bool, handled bool) {
+	for _, p := range activePlugins(Authentication) {
+		if ok, handled = p.Authenticate(user, host, auth, salt); handled {
+			return ok, true
+		}
+	}
+	return false, false
+}
+
+// NotifySchemaChange sends the schema change event to the active Schema plugins.
+func NotifySchemaChange(event *SchemaEvent) {
+	for _, p := range activePlugins(Schema) {
+		p.OnSchemaChange(event)
+	}
+}
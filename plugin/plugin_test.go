@@ -0,0 +1,172 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"testing"
+
+	"github.com/juju/errors"
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/util/audit"
+	"github.com/pingcap/tidb/util/testleak"
+)
+
+func TestT(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testPluginSuite{})
+
+type testPluginSuite struct{}
+
+type mockSink struct {
+	events int
+}
+
+func (s *mockSink) Audit(event *audit.Event) {
+	s.events++
+}
+
+func (s *testPluginSuite) TestLifecycle(c *C) {
+	defer testleak.AfterTest(c)()
+	defer Shutdown()
+
+	var calls []string
+	sink := &mockSink{}
+	auditPlugin := &Manifest{
+		Name:      "audit_mock",
+		Version:   0x0102,
+		Kind:      Audit,
+		AuditSink: sink,
+		OnInit: func(m *Manifest) error {
+			calls = append(calls, "init "+m.Name)
+			return nil
+		},
+		OnShutdown: func(m *Manifest) error {
+			calls = append(calls, "shutdown "+m.Name)
+			return nil
+		},
+	}
+	var schemaEvents []*SchemaEvent
+	schemaPlugin := &Manifest{
+		Name: "schema_mock",
+		Kind: Schema,
+		Validate: func(m *Manifest) error {
+			calls = append(calls, "validate "+m.Name)
+			return nil
+		},
+		OnSchemaChange: func(event *SchemaEvent) {
+			schemaEvents = append(schemaEvents, event)
+		},
+		OnShutdown: func(m *Manifest) error {
+			calls = append(calls, "shutdown "+m.Name)
+			return nil
+		},
+	}
+	c.Assert(Register(auditPlugin), IsNil)
+	c.Assert(Register(schemaPlugin), IsNil)
+	c.Assert(Register(auditPlugin), NotNil)
+	c.Assert(Register(&Manifest{Name: "no_hook", Kind: Authentication}), NotNil)
+	c.Assert(Register(&Manifest{Name: "no_kind"}), NotNil)
+
+	// The hooks are not called before the plugins are initialized.
+	c.Assert(Enable("audit_mock"), NotNil)
+	audit.Log(&audit.Event{})
+	NotifySchemaChange(&SchemaEvent{OldVersion: 1, NewVersion: 2})
+	c.Assert(sink.events, Equals, 0)
+	c.Assert(schemaEvents, HasLen, 0)
+	c.Assert(Plugins()[0].State, Equals, Registered)
+
+	c.Assert(Init(), IsNil)
+	c.Assert(calls, DeepEquals, []string{"init audit_mock", "validate schema_mock"})
+	infos := Plugins()
+	c.Assert(infos, HasLen, 2)
+	c.Assert(infos[0].Name, Equals, "audit_mock")
+	c.Assert(infos[0].Version, Equals, uint16(0x0102))
+	c.Assert(infos[0].State, Equals, Active)
+	c.Assert(infos[1].Kind, Equals, Schema)
+	audit.Log(&audit.Event{})
+	NotifySchemaChange(&SchemaEvent{OldVersion: 1, NewVersion: 2})
+	c.Assert(sink.events, Equals, 1)
+	c.Assert(schemaEvents, DeepEquals, []*SchemaEvent{{OldVersion: 1, NewVersion: 2}})
+
+	// The hooks of the disabled plugins are not called.
+	c.Assert(Disable("audit_mock"), IsNil)
+	c.Assert(Disable("audit_mock"), IsNil)
+	c.Assert(Disable("not_exists"), NotNil)
+	audit.Log(&audit.Event{})
+	c.Assert(sink.events, Equals, 1)
+	c.Assert(Plugins()[0].State, Equals, Disabled)
+	c.Assert(Enable("audit_mock"), IsNil)
+	audit.Log(&audit.Event{})
+	c.Assert(sink.events, Equals, 2)
+
+	calls = calls[:0]
+	Shutdown()
+	c.Assert(calls, DeepEquals, []string{"shutdown schema_mock", "shutdown audit_mock"})
+	c.Assert(Plugins(), HasLen, 0)
+	c.Assert(audit.Enabled(), IsFalse)
+}
+
+func (s *testPluginSuite) TestInitFail(c *C) {
+	defer testleak.AfterTest(c)()
+	defer Shutdown()
+
+	var inited bool
+	c.Assert(Register(&Manifest{
+		Name:     "invalid",
+		Kind:     Schema,
+		Validate: func(m *Manifest) error { return errors.New("missing config") },
+		OnInit: func(m *Manifest) error {
+			inited = true
+			return nil
+		},
+		OnSchemaChange: func(event *SchemaEvent) {},
+	}), IsNil)
+	c.Assert(Init(), NotNil)
+	c.Assert(inited, IsFalse)
+	c.Assert(Plugins()[0].State, Equals, Registered)
+}
+
+func (s *testPluginSuite) TestAuthenticate(c *C) {
+	defer testleak.AfterTest(c)()
+	defer Shutdown()
+
+	ok, handled := Authenticate("u1", "127.0.0.1", nil, nil)
+	c.Assert(handled, IsFalse)
+	auth := func(name string) func(user, host string, auth, salt []byte) (bool, bool) {
+		return func(user, host string, auth, salt []byte) (bool, bool) {
+			if user != name {
+				return false, false
+			}
+			return string(auth) == "secret", true
+		}
+	}
+	c.Assert(Register(&Manifest{Name: "auth1", Kind: Authentication, Authenticate: auth("u1")}), IsNil)
+	c.Assert(Register(&Manifest{Name: "auth2", Kind: Authentication, Authenticate: auth("u2")}), IsNil)
+	c.Assert(Init(), IsNil)
+
+	ok, handled = Authenticate("u1", "127.0.0.1", []byte("secret"), nil)
+	c.Assert(ok && handled, IsTrue)
+	ok, handled = Authenticate("u2", "127.0.0.1", []byte("wrong"), nil)
+	c.Assert(ok, IsFalse)
+	c.Assert(handled, IsTrue)
+	_, handled = Authenticate("u3", "127.0.0.1", []byte("secret"), nil)
+	c.Assert(handled, IsFalse)
+	c.Assert(Disable("auth1"), IsNil)
+	_, handled = Authenticate("u1", "127.0.0.1", []byte("secret"), nil)
+	c.Assert(handled, IsFalse)
+
+	c.Assert(Load("/not/exists.so"), NotNil)
+}
@@ -0,0 +1,245 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package policy manages the row-level security policies of the tables.
+//
+// A policy is created by CREATE POLICY and stored in the mysql.row_policy table, it has a predicate on the
+// columns of its table, which may call functions like CURRENT_USER() to isolate the rows of the tenants.
+// Every server caches the policies in a Handle which is reloaded when a policy is created or dropped. When
+// a table with policies is read, the planner filters its rows by the predicates of the policies, a row is
+// visible if any of the predicates is true for it.
+//
+// A masking policy is created by CREATE MASKING POLICY and stored in the mysql.column_mask table, it has a mask
+// expression like MASK_SSN(ssn) which replaces a column when it's read by the users without the SUPER privilege,
+// so the sensitive data is hidden from them.
+package policy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/parser"
+	"github.com/pingcap/tidb/util/sqlexec"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// Policy is a row-level security policy.
+type Policy struct {
+	DB    string
+	Table string
+	Name  string
+	// Predicate is the text of the predicate expression.
+	Predicate  string
+	CreateTime types.Time
+}
+
+// ParsePredicate parses the predicate of the policy. The predicate is parsed every time it's used, because
+// the nodes are changed when it's rewritten.
+func (p *Policy) ParsePredicate() (ast.ExprNode, error) {
+	return ParsePredicate(p.Predicate)
+}
+
+// ParsePredicate parses the text of a predicate expression.
+func ParsePredicate(predicate string) (ast.ExprNode, error) {
+	stmt, err := parser.New().ParseOneStmt("select "+predicate, mysql.DefaultCharset, mysql.DefaultCollationName)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return stmt.(*ast.SelectStmt).Fields.Fields[0].Expr, nil
+}
+
+// Mask is a masking policy of a column.
+type Mask struct {
+	DB     string
+	Table  string
+	Column string
+	Name   string
+	// MaskExpr is the text of the mask expression.
+	MaskExpr   string
+	CreateTime types.Time
+}
+
+// ParseMaskExpr parses the mask expression of the masking policy.
+func (m *Mask) ParseMaskExpr() (ast.ExprNode, error) {
+	return ParsePredicate(m.MaskExpr)
+}
+
+// Handle caches the policies.
+type Handle struct {
+	// policies is a map[string][]*Policy keyed by the database and the table, it's replaced by Update.
+	policies atomic.Value
+	// masks is a map[string][]*Mask keyed by the database and the table, it's replaced by Update.
+	masks atomic.Value
+}
+
+// NewHandle creates a Handle without policies.
+func NewHandle() *Handle {
+	h := &Handle{}
+	h.policies.Store(make(map[string][]*Policy))
+	h.masks.Store(make(map[string][]*Mask))
+	return h
+}
+
+func tableKey(db, table string) string {
+	return strings.ToLower(db) + "." + strings.ToLower(table)
+}
+
+// Get returns the policies of the table ordered by the names.
+func (h *Handle) Get(db, table string) []*Policy {
+	return h.policies.Load().(map[string][]*Policy)[tableKey(db, table)]
+}
+
+// All returns all the policies ordered by the databases, the tables and the names.
+func (h *Handle) All() []*Policy {
+	var all []*Policy
+	for _, policies := range h.policies.Load().(map[string][]*Policy) {
+		all = append(all, policies...)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return lessPolicy(all[i], all[j])
+	})
+	return all
+}
+
+func lessPolicy(a, b *Policy) bool {
+	if a.DB != b.DB {
+		return a.DB < b.DB
+	}
+	if a.Table != b.Table {
+		return a.Table < b.Table
+	}
+	return a.Name < b.Name
+}
+
+// Masks returns the masking policies of the table ordered by the columns.
+func (h *Handle) Masks(db, table string) []*Mask {
+	return h.masks.Load().(map[string][]*Mask)[tableKey(db, table)]
+}
+
+var (
+	loadSQL = fmt.Sprintf(`select db, table_name, name, predicate, create_time from %s.%s`,
+		mysql.SystemDB, mysql.RowPolicyTable)
+	loadMaskSQL = fmt.Sprintf(`select db, table_name, column_name, name, mask_expr, create_time from %s.%s`,
+		mysql.SystemDB, mysql.ColumnMaskTable)
+)
+
+// Update loads all the policies from the mysql.row_policy and the mysql.column_mask tables.
+func (h *Handle) Update(ctx context.Context) error {
+	policies, err := loadPolicies(ctx)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	masks, err := loadMasks(ctx)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	h.policies.Store(policies)
+	h.masks.Store(masks)
+	return nil
+}
+
+func loadPolicies(ctx context.Context) (map[string][]*Policy, error) {
+	rss, err := ctx.(sqlexec.SQLExecutor).Execute(loadSQL)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	rs := rss[0]
+	defer rs.Close()
+	policies := make(map[string][]*Policy)
+	for {
+		row, err := rs.Next()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if row == nil {
+			break
+		}
+		p := &Policy{
+			DB:         row.Data[0].GetString(),
+			Table:      row.Data[1].GetString(),
+			Name:       row.Data[2].GetString(),
+			Predicate:  row.Data[3].GetString(),
+			CreateTime: row.Data[4].GetMysqlTime(),
+		}
+		key := tableKey(p.DB, p.Table)
+		policies[key] = append(policies[key], p)
+	}
+	for _, tablePolicies := range policies {
+		sort.Slice(tablePolicies, func(i, j int) bool {
+			return lessPolicy(tablePolicies[i], tablePolicies[j])
+		})
+	}
+	return policies, nil
+}
+
+func loadMasks(ctx context.Context) (map[string][]*Mask, error) {
+	rss, err := ctx.(sqlexec.SQLExecutor).Execute(loadMaskSQL)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	rs := rss[0]
+	defer rs.Close()
+	masks := make(map[string][]*Mask)
+	for {
+		row, err := rs.Next()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if row == nil {
+			break
+		}
+		m := &Mask{
+			DB:         row.Data[0].GetString(),
+			Table:      row.Data[1].GetString(),
+			Column:     row.Data[2].GetString(),
+			Name:       row.Data[3].GetString(),
+			MaskExpr:   row.Data[4].GetString(),
+			CreateTime: row.Data[5].GetMysqlTime(),
+		}
+		key := tableKey(m.DB, m.Table)
+		masks[key] = append(masks[key], m)
+	}
+	for _, tableMasks := range masks {
+		sort.Slice(tableMasks, func(i, j int) bool {
+			return tableMasks[i].Column < tableMasks[j].Column
+		})
+	}
+	return masks, nil
+}
+
+type keyType int
+
+func (k keyType) String() string {
+	return "policy-key"
+}
+
+const key keyType = 0
+
+// BindHandle binds the Handle to context.
+func BindHandle(ctx context.Context, h *Handle) {
+	ctx.SetValue(key, h)
+}
+
+// GetHandle gets the Handle from context, it returns nil if no Handle is bound.
+func GetHandle(ctx context.Context) *Handle {
+	if v, ok := ctx.Value(key).(*Handle); ok {
+		return v
+	}
+	return nil
+}
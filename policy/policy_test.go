@@ -0,0 +1,79 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/util/testleak"
+)
+
+func TestT(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testPolicySuite{})
+
+type testPolicySuite struct{}
+
+func (s *testPolicySuite) TestParsePredicate(c *C) {
+	defer testleak.AfterTest(c)()
+	p := &Policy{Predicate: "tenant = current_user() or a > 1"}
+	expr, err := p.ParsePredicate()
+	c.Assert(err, IsNil)
+	c.Assert(expr.(*ast.BinaryOperationExpr).L, FitsTypeOf, &ast.BinaryOperationExpr{})
+
+	// The predicate is parsed again, so it can be rewritten again.
+	expr2, err := p.ParsePredicate()
+	c.Assert(err, IsNil)
+	c.Assert(expr2 != expr, IsTrue)
+
+	_, err = ParsePredicate("a >")
+	c.Assert(err, NotNil)
+}
+
+func (s *testPolicySuite) TestHandle(c *C) {
+	defer testleak.AfterTest(c)()
+	h := NewHandle()
+	c.Assert(h.Get("test", "t"), HasLen, 0)
+	c.Assert(h.All(), HasLen, 0)
+
+	p1 := &Policy{DB: "test", Table: "t", Name: "b"}
+	p2 := &Policy{DB: "test", Table: "t", Name: "a"}
+	p3 := &Policy{DB: "db", Table: "t", Name: "c"}
+	h.policies.Store(map[string][]*Policy{
+		tableKey("test", "t"): {p2, p1},
+		tableKey("db", "t"):   {p3},
+	})
+	c.Assert(h.Get("TEST", "T"), DeepEquals, []*Policy{p2, p1})
+	c.Assert(h.Get("test", "t1"), HasLen, 0)
+	c.Assert(h.All(), DeepEquals, []*Policy{p3, p2, p1})
+}
+
+func (s *testPolicySuite) TestMasks(c *C) {
+	defer testleak.AfterTest(c)()
+	h := NewHandle()
+	c.Assert(h.Masks("test", "t"), HasLen, 0)
+
+	m := &Mask{DB: "test", Table: "t", Column: "ssn", Name: "m", MaskExpr: "mask_ssn(ssn)"}
+	h.masks.Store(map[string][]*Mask{tableKey("test", "t"): {m}})
+	c.Assert(h.Masks("Test", "T"), DeepEquals, []*Mask{m})
+	c.Assert(h.Masks("test", "t1"), HasLen, 0)
+
+	expr, err := m.ParseMaskExpr()
+	c.Assert(err, IsNil)
+	c.Assert(expr, FitsTypeOf, &ast.FuncCallExpr{})
+}
@@ -16,6 +16,7 @@ package privilege
 import (
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/util/resource"
 	"github.com/pingcap/tidb/util/types"
 )
 
@@ -36,6 +37,8 @@ type Manager interface {
 	RequestVerification(db, table, column string, priv mysql.PrivilegeType) bool
 	// ConnectionVerification verifies user privilege for connection.
 	ConnectionVerification(host, user string, auth, salt []byte) bool
+	// ConnectionAccount returns the account matched by ConnectionVerification and its resource limits.
+	ConnectionAccount() (user, host string, limits resource.Limits)
 
 	// DBIsVisible returns true is the database is visible to current user.
 	DBIsVisible(db string) bool
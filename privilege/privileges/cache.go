@@ -15,6 +15,8 @@ package privileges
 
 import (
 	"fmt"
+	"math"
+	"sort"
 	"strings"
 	"sync/atomic"
 	"time"
@@ -25,6 +27,7 @@ import (
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/resource"
 	"github.com/pingcap/tidb/util/sqlexec"
 	"github.com/pingcap/tidb/util/stringutil"
 	"github.com/pingcap/tidb/util/types"
@@ -50,6 +53,8 @@ type userRecord struct {
 	User       string // max length 16, primary key
 	Password   string // max length 41
 	Privileges mysql.PrivilegeType
+	// Limits is the resource limits of the account like MAX_USER_CONNECTIONS.
+	Limits resource.Limits
 
 	// patChars is compiled from Host, cached for pattern match performance.
 	patChars []byte
@@ -65,6 +70,10 @@ type dbRecord struct {
 	// patChars is compiled from Host, cached for pattern match performance.
 	patChars []byte
 	patTypes []byte
+
+	// dbPatChars is compiled from the lower case DB, DB may contain wildcards like "db\_%".
+	dbPatChars []byte
+	dbPatTypes []byte
 }
 
 type tablesPrivRecord struct {
@@ -147,14 +156,63 @@ func noSuchTable(err error) bool {
 	return false
 }
 
+func noSuchColumn(err error) bool {
+	e1 := errors.Cause(err)
+	if e2, ok := e1.(*terror.Error); ok {
+		if e2.Code() == terror.ErrCode(mysql.ErrBadField) {
+			return true
+		}
+	}
+	return false
+}
+
 // LoadUserTable loads the mysql.user table from database.
 func (p *MySQLPrivilege) LoadUserTable(ctx context.Context) error {
-	return p.loadTable(ctx, "select Host,User,Password,Select_priv,Insert_priv,Update_priv,Delete_priv,Create_priv,Drop_priv,Process_priv,Grant_priv,References_priv,Alter_priv,Show_db_priv,Super_priv,Execute_priv,Index_priv,Create_user_priv,Trigger_priv from mysql.user order by host, user;", p.decodeUserTableRow)
+	err := p.loadTable(ctx, "select Host,User,Password,Select_priv,Insert_priv,Update_priv,Delete_priv,Create_priv,Drop_priv,Process_priv,Grant_priv,References_priv,Alter_priv,Show_db_priv,Super_priv,Execute_priv,Index_priv,Create_user_priv,Trigger_priv,max_questions,max_updates,max_connections,max_user_connections from mysql.user order by host, user;", p.decodeUserTableRow)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	// The first matched record is used, so the more specific hosts must be checked first like MySQL does,
+	// e.g. 'u'@'localhost' is checked before 'u'@'%'.
+	sort.SliceStable(p.User, func(i, j int) bool {
+		return patternSpecificity(p.User[i].Host) > patternSpecificity(p.User[j].Host)
+	})
+	// The resource groups are specific to TiDB, a mysql.user table copied from MySQL has no Resource_group column.
+	err = p.loadTable(ctx, "select Host,User,Resource_group from mysql.user where Resource_group != '';", p.decodeUserResourceGroupRow)
+	if err != nil && !noSuchColumn(err) {
+		return errors.Trace(err)
+	}
+	return nil
 }
 
 // LoadDBTable loads the mysql.db table from database.
 func (p *MySQLPrivilege) LoadDBTable(ctx context.Context) error {
-	return p.loadTable(ctx, "select Host,DB,User,Select_priv,Insert_priv,Update_priv,Delete_priv,Create_priv,Drop_priv,Grant_priv,Index_priv,Alter_priv,Execute_priv from mysql.db order by host, db, user;", p.decodeDBTableRow)
+	err := p.loadTable(ctx, "select Host,DB,User,Select_priv,Insert_priv,Update_priv,Delete_priv,Create_priv,Drop_priv,Grant_priv,Index_priv,Alter_priv,Execute_priv from mysql.db order by host, db, user;", p.decodeDBTableRow)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	sort.SliceStable(p.DB, func(i, j int) bool {
+		hi, hj := patternSpecificity(p.DB[i].Host), patternSpecificity(p.DB[j].Host)
+		if hi != hj {
+			return hi > hj
+		}
+		return patternSpecificity(p.DB[i].DB) > patternSpecificity(p.DB[j].DB)
+	})
+	return nil
+}
+
+// patternSpecificity returns the number of the characters before the first wildcard of the pattern, the pattern
+// without wildcards is more specific than all the patterns which have.
+func patternSpecificity(pattern string) int {
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '\\':
+			i++
+		case '%', '_':
+			return i
+		}
+	}
+	return math.MaxInt32
 }
 
 // LoadTablesPrivTable loads the mysql.tables_priv table from database.
@@ -209,6 +267,14 @@ func (p *MySQLPrivilege) decodeUserTableRow(row *ast.Row, fs []*ast.ResultField)
 			value.patChars, value.patTypes = stringutil.CompilePattern(value.Host, '\\')
 		case f.ColumnAsName.L == "password":
 			value.Password = d.GetString()
+		case f.ColumnAsName.L == "max_questions":
+			value.Limits.MaxQueriesPerHour = d.GetInt64()
+		case f.ColumnAsName.L == "max_updates":
+			value.Limits.MaxUpdatesPerHour = d.GetInt64()
+		case f.ColumnAsName.L == "max_connections":
+			value.Limits.MaxConnectionsPerHour = d.GetInt64()
+		case f.ColumnAsName.L == "max_user_connections":
+			value.Limits.MaxUserConnections = d.GetInt64()
 		case d.Kind() == types.KindMysqlEnum:
 			ed := d.GetMysqlEnum()
 			if ed.String() != "Y" {
@@ -225,6 +291,17 @@ func (p *MySQLPrivilege) decodeUserTableRow(row *ast.Row, fs []*ast.ResultField)
 	return nil
 }
 
+func (p *MySQLPrivilege) decodeUserResourceGroupRow(row *ast.Row, fs []*ast.ResultField) error {
+	host, user, group := row.Data[0].GetString(), row.Data[1].GetString(), row.Data[2].GetString()
+	for i := range p.User {
+		if p.User[i].Host == host && p.User[i].User == user {
+			p.User[i].Limits.Group = group
+			break
+		}
+	}
+	return nil
+}
+
 func (p *MySQLPrivilege) decodeDBTableRow(row *ast.Row, fs []*ast.ResultField) error {
 	var value dbRecord
 	for i, f := range fs {
@@ -237,6 +314,7 @@ func (p *MySQLPrivilege) decodeDBTableRow(row *ast.Row, fs []*ast.ResultField) e
 			value.patChars, value.patTypes = stringutil.CompilePattern(value.Host, '\\')
 		case f.ColumnAsName.L == "db":
 			value.DB = d.GetString()
+			value.dbPatChars, value.dbPatTypes = stringutil.CompilePattern(strings.ToLower(value.DB), '\\')
 		case d.Kind() == types.KindMysqlEnum:
 			ed := d.GetMysqlEnum()
 			if ed.String() != "Y" {
@@ -324,7 +402,7 @@ func (record *userRecord) match(user, host string) bool {
 }
 
 func (record *dbRecord) match(user, host, db string) bool {
-	return record.User == user && strings.EqualFold(record.DB, db) &&
+	return record.User == user && patternMatch(strings.ToLower(db), record.dbPatChars, record.dbPatTypes) &&
 		patternMatch(host, record.patChars, record.patTypes)
 }
 
@@ -469,69 +547,132 @@ func (p *MySQLPrivilege) DBIsVisible(user, host, db string) bool {
 	return false
 }
 
+// showGrants returns the grants of the user in the format of MySQL, so the output can be executed again to
+// recreate the privileges, e.g. "GRANT SELECT, INSERT ON `test`.* TO 'u'@'%' WITH GRANT OPTION".
 func (p *MySQLPrivilege) showGrants(user, host string) []string {
 	var gs []string
-	// Show global grants
+	// Show global grants, every user has one even if it has no privileges.
 	for _, record := range p.User {
 		if record.User == user && record.Host == host {
 			g := userPrivToString(record.Privileges)
-			s := fmt.Sprintf(`GRANT %s ON *.* TO '%s'@'%s'`, g, record.User, record.Host)
-			gs = append(gs, s)
+			gs = append(gs, grantString(g, "*.*", record.User, record.Host, record.Privileges))
 			break // it's unique
 		}
 	}
 
 	// Show db scope grants
 	for _, record := range p.DB {
-		if record.User == user && record.Host == host {
+		if record.User == user && record.Host == host && record.Privileges != 0 {
 			g := dbPrivToString(record.Privileges)
-			s := fmt.Sprintf(`GRANT %s ON %s.* TO '%s'@'%s'`, g, record.DB, record.User, record.Host)
-			gs = append(gs, s)
+			level := fmt.Sprintf("%s.*", quoteName(record.DB))
+			gs = append(gs, grantString(g, level, record.User, record.Host, record.Privileges))
 		}
 	}
 
-	// Show table scope grants
-	for _, record := range p.TablesPriv {
+	// Show table scope grants, the column privileges are shown in the grant of their table.
+	tables := make(map[string]*tablesPrivRecord)
+	var levels []string
+	for i := range p.TablesPriv {
+		record := &p.TablesPriv[i]
 		if record.User == user && record.Host == host {
-			g := tablePrivToString(record.TablePriv)
-			s := fmt.Sprintf(`GRANT %s ON %s.%s TO '%s'@'%s'`, g, record.DB, record.TableName, record.User, record.Host)
-			gs = append(gs, s)
+			level := fmt.Sprintf("%s.%s", quoteName(record.DB), quoteName(record.TableName))
+			tables[level] = record
+			levels = append(levels, level)
+		}
+	}
+	columns := make(map[string]map[mysql.PrivilegeType][]string)
+	for _, record := range p.ColumnsPriv {
+		if record.User != user || record.Host != host || record.ColumnPriv == 0 {
+			continue
+		}
+		level := fmt.Sprintf("%s.%s", quoteName(record.DB), quoteName(record.TableName))
+		if _, ok := columns[level]; !ok {
+			columns[level] = make(map[mysql.PrivilegeType][]string)
+			if _, ok := tables[level]; !ok {
+				levels = append(levels, level)
+			}
+		}
+		for _, priv := range mysql.AllColumnPrivs {
+			if record.ColumnPriv&priv != 0 {
+				columns[level][priv] = append(columns[level][priv], record.ColumnName)
+			}
 		}
 	}
+	for _, level := range levels {
+		var privs mysql.PrivilegeType
+		if record, ok := tables[level]; ok {
+			privs = record.TablePriv
+		}
+		g := tablePrivToString(privs, columns[level])
+		if g == "" {
+			if privs&mysql.GrantPriv == 0 {
+				continue
+			}
+			g = "USAGE"
+		}
+		gs = append(gs, grantString(g, level, user, host, privs))
+	}
 	return gs
 }
 
+func grantString(privs, level, user, host string, priv mysql.PrivilegeType) string {
+	s := fmt.Sprintf(`GRANT %s ON %s TO '%s'@'%s'`, privs, level, user, host)
+	if priv&mysql.GrantPriv != 0 {
+		s += " WITH GRANT OPTION"
+	}
+	return s
+}
+
+func quoteName(name string) string {
+	return "`" + strings.Replace(name, "`", "``", -1) + "`"
+}
+
 func userPrivToString(privs mysql.PrivilegeType) string {
-	if privs == userTablePrivilegeMask {
+	privs &^= mysql.GrantPriv
+	if privs == userTablePrivilegeMask&^mysql.GrantPriv {
 		return mysql.AllPrivilegeLiteral
 	}
-	return privToString(privs, mysql.AllGlobalPrivs, mysql.Priv2Str)
+	if privs == 0 {
+		return "USAGE"
+	}
+	return privToString(privs, mysql.AllGlobalPrivs, nil)
 }
 
 func dbPrivToString(privs mysql.PrivilegeType) string {
-	if privs == dbTablePrivilegeMask {
+	privs &^= mysql.GrantPriv
+	if privs == dbTablePrivilegeMask&^mysql.GrantPriv {
 		return mysql.AllPrivilegeLiteral
 	}
-	return privToString(privs, mysql.AllDBPrivs, mysql.Priv2SetStr)
+	if privs == 0 {
+		return "USAGE"
+	}
+	return privToString(privs, mysql.AllDBPrivs, nil)
 }
 
-func tablePrivToString(privs mysql.PrivilegeType) string {
-	if privs == tablePrivMask {
+// tablePrivToString returns the privileges of the table, columns lists the columns of the column privileges,
+// e.g. "SELECT, UPDATE (a, b)". An empty string is returned if there are no privileges.
+func tablePrivToString(privs mysql.PrivilegeType, columns map[mysql.PrivilegeType][]string) string {
+	privs &^= mysql.GrantPriv
+	if privs == tablePrivMask&^mysql.GrantPriv {
 		return mysql.AllPrivilegeLiteral
 	}
-	return privToString(privs, mysql.AllTablePrivs, mysql.Priv2Str)
+	return privToString(privs, mysql.AllTablePrivs, columns)
 }
 
-func privToString(priv mysql.PrivilegeType, allPrivs []mysql.PrivilegeType, allPrivNames map[mysql.PrivilegeType]string) string {
+func privToString(priv mysql.PrivilegeType, allPrivs []mysql.PrivilegeType, columns map[mysql.PrivilegeType][]string) string {
 	pstrs := make([]string, 0, 20)
 	for _, p := range allPrivs {
-		if priv&p == 0 {
+		if p == mysql.GrantPriv {
 			continue
 		}
-		s, _ := allPrivNames[p]
-		pstrs = append(pstrs, s)
+		s := strings.ToUpper(mysql.Priv2Str[p])
+		if priv&p != 0 {
+			pstrs = append(pstrs, s)
+		} else if cols := columns[p]; len(cols) > 0 {
+			pstrs = append(pstrs, fmt.Sprintf("%s (%s)", s, strings.Join(cols, ", ")))
+		}
 	}
-	return strings.Join(pstrs, ",")
+	return strings.Join(pstrs, ", ")
 }
 
 // UserPrivilegesTable provide data for INFORMATION_SCHEMA.USERS_PRIVILEGE table.
@@ -23,6 +23,7 @@ import (
 	"github.com/pingcap/tidb/privilege"
 	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util"
+	"github.com/pingcap/tidb/util/resource"
 	"github.com/pingcap/tidb/util/types"
 )
 
@@ -120,6 +121,19 @@ func (p *UserPrivileges) ConnectionVerification(user, host string, auth, salt []
 	return true
 }
 
+// ConnectionAccount implements the Manager interface.
+func (p *UserPrivileges) ConnectionAccount() (string, string, resource.Limits) {
+	if SkipWithGrant {
+		return p.user, p.host, resource.Limits{}
+	}
+	mysqlPriv := p.Handle.Get()
+	record := mysqlPriv.matchUser(p.user, p.host)
+	if record == nil {
+		return p.user, p.host, resource.Limits{}
+	}
+	return record.User, record.Host, record.Limits
+}
+
 // DBIsVisible implements the Manager interface.
 func (p *UserPrivileges) DBIsVisible(db string) bool {
 	if !Enable || SkipWithGrant {
@@ -15,6 +15,7 @@ package privileges_test
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	. "github.com/pingcap/check"
@@ -149,14 +150,14 @@ func (s *testPrivilegeSuite) TestShowGrants(c *C) {
 	gs, err := pc.ShowGrants(se, `show@localhost`)
 	c.Assert(err, IsNil)
 	c.Assert(gs, HasLen, 1)
-	c.Assert(gs[0], Equals, `GRANT Index ON *.* TO 'show'@'localhost'`)
+	c.Assert(gs[0], Equals, `GRANT INDEX ON *.* TO 'show'@'localhost'`)
 
 	mustExec(c, se, `GRANT Select ON *.* TO  'show'@'localhost';`)
 	mustExec(c, se, `FLUSH PRIVILEGES;`)
 	gs, err = pc.ShowGrants(se, `show@localhost`)
 	c.Assert(err, IsNil)
 	c.Assert(gs, HasLen, 1)
-	c.Assert(gs[0], Equals, `GRANT Select,Index ON *.* TO 'show'@'localhost'`)
+	c.Assert(gs[0], Equals, `GRANT SELECT, INDEX ON *.* TO 'show'@'localhost'`)
 
 	// The order of privs is the same with AllGlobalPrivs
 	mustExec(c, se, `GRANT Update ON *.* TO  'show'@'localhost';`)
@@ -164,7 +165,7 @@ func (s *testPrivilegeSuite) TestShowGrants(c *C) {
 	gs, err = pc.ShowGrants(se, `show@localhost`)
 	c.Assert(err, IsNil)
 	c.Assert(gs, HasLen, 1)
-	c.Assert(gs[0], Equals, `GRANT Select,Update,Index ON *.* TO 'show'@'localhost'`)
+	c.Assert(gs[0], Equals, `GRANT SELECT, UPDATE, INDEX ON *.* TO 'show'@'localhost'`)
 
 	// All privileges
 	mustExec(c, se, `GRANT ALL ON *.* TO  'show'@'localhost';`)
@@ -172,7 +173,7 @@ func (s *testPrivilegeSuite) TestShowGrants(c *C) {
 	gs, err = pc.ShowGrants(se, `show@localhost`)
 	c.Assert(err, IsNil)
 	c.Assert(gs, HasLen, 1)
-	c.Assert(gs[0], Equals, `GRANT ALL PRIVILEGES ON *.* TO 'show'@'localhost'`)
+	c.Assert(gs[0], Equals, `GRANT ALL PRIVILEGES ON *.* TO 'show'@'localhost' WITH GRANT OPTION`)
 
 	// Add db scope privileges
 	mustExec(c, se, `GRANT Select ON test.* TO  'show'@'localhost';`)
@@ -180,8 +181,8 @@ func (s *testPrivilegeSuite) TestShowGrants(c *C) {
 	gs, err = pc.ShowGrants(se, `show@localhost`)
 	c.Assert(err, IsNil)
 	c.Assert(gs, HasLen, 2)
-	expected := []string{`GRANT ALL PRIVILEGES ON *.* TO 'show'@'localhost'`,
-		`GRANT Select ON test.* TO 'show'@'localhost'`}
+	expected := []string{`GRANT ALL PRIVILEGES ON *.* TO 'show'@'localhost' WITH GRANT OPTION`,
+		"GRANT SELECT ON `test`.* TO 'show'@'localhost'"}
 	c.Assert(testutil.CompareUnorderedStringSlice(gs, expected), IsTrue)
 
 	mustExec(c, se, `GRANT Index ON test1.* TO  'show'@'localhost';`)
@@ -189,9 +190,9 @@ func (s *testPrivilegeSuite) TestShowGrants(c *C) {
 	gs, err = pc.ShowGrants(se, `show@localhost`)
 	c.Assert(err, IsNil)
 	c.Assert(gs, HasLen, 3)
-	expected = []string{`GRANT ALL PRIVILEGES ON *.* TO 'show'@'localhost'`,
-		`GRANT Select ON test.* TO 'show'@'localhost'`,
-		`GRANT Index ON test1.* TO 'show'@'localhost'`}
+	expected = []string{`GRANT ALL PRIVILEGES ON *.* TO 'show'@'localhost' WITH GRANT OPTION`,
+		"GRANT SELECT ON `test`.* TO 'show'@'localhost'",
+		"GRANT INDEX ON `test1`.* TO 'show'@'localhost'"}
 	c.Assert(testutil.CompareUnorderedStringSlice(gs, expected), IsTrue)
 
 	mustExec(c, se, `GRANT ALL ON test1.* TO  'show'@'localhost';`)
@@ -199,9 +200,9 @@ func (s *testPrivilegeSuite) TestShowGrants(c *C) {
 	gs, err = pc.ShowGrants(se, `show@localhost`)
 	c.Assert(err, IsNil)
 	c.Assert(gs, HasLen, 3)
-	expected = []string{`GRANT ALL PRIVILEGES ON *.* TO 'show'@'localhost'`,
-		`GRANT Select ON test.* TO 'show'@'localhost'`,
-		`GRANT ALL PRIVILEGES ON test1.* TO 'show'@'localhost'`}
+	expected = []string{`GRANT ALL PRIVILEGES ON *.* TO 'show'@'localhost' WITH GRANT OPTION`,
+		"GRANT SELECT ON `test`.* TO 'show'@'localhost'",
+		"GRANT ALL PRIVILEGES ON `test1`.* TO 'show'@'localhost' WITH GRANT OPTION"}
 	c.Assert(testutil.CompareUnorderedStringSlice(gs, expected), IsTrue)
 
 	// Add table scope privileges
@@ -210,13 +211,86 @@ func (s *testPrivilegeSuite) TestShowGrants(c *C) {
 	gs, err = pc.ShowGrants(se, `show@localhost`)
 	c.Assert(err, IsNil)
 	c.Assert(gs, HasLen, 4)
-	expected = []string{`GRANT ALL PRIVILEGES ON *.* TO 'show'@'localhost'`,
-		`GRANT Select ON test.* TO 'show'@'localhost'`,
-		`GRANT ALL PRIVILEGES ON test1.* TO 'show'@'localhost'`,
-		`GRANT Update ON test.test TO 'show'@'localhost'`}
+	expected = []string{`GRANT ALL PRIVILEGES ON *.* TO 'show'@'localhost' WITH GRANT OPTION`,
+		"GRANT SELECT ON `test`.* TO 'show'@'localhost'",
+		"GRANT ALL PRIVILEGES ON `test1`.* TO 'show'@'localhost' WITH GRANT OPTION",
+		"GRANT UPDATE ON `test`.`test` TO 'show'@'localhost'"}
 	c.Assert(testutil.CompareUnorderedStringSlice(gs, expected), IsTrue)
 }
 
+func (s *testPrivilegeSuite) TestShowGrantsRoundTrip(c *C) {
+	defer testleak.AfterTest(c)()
+	se := newSession(c, s.store, s.dbName)
+	mustExec(c, se, `CREATE USER 'rt'@'%';`)
+	mustExec(c, se, `FLUSH PRIVILEGES;`)
+	pc := privilege.GetPrivilegeManager(se)
+
+	// The user without privileges has the USAGE grant.
+	gs, err := pc.ShowGrants(se, `rt@%`)
+	c.Assert(err, IsNil)
+	c.Assert(gs, DeepEquals, []string{`GRANT USAGE ON *.* TO 'rt'@'%'`})
+
+	mustExec(c, se, "GRANT SELECT, INSERT ON `test\\_%`.* TO 'rt'@'%' WITH GRANT OPTION;")
+	mustExec(c, se, `GRANT DELETE ON test.test TO 'rt'@'%';`)
+	mustExec(c, se, `GRANT SELECT (id), UPDATE (id, name) ON test.test TO 'rt'@'%';`)
+	mustExec(c, se, `GRANT SELECT, UPDATE ON test1.* TO 'rt'@'%';`)
+	// Revoke a part of the privileges.
+	mustExec(c, se, `REVOKE UPDATE ON test1.* FROM 'rt'@'%';`)
+	mustExec(c, se, `REVOKE UPDATE (name) ON test.test FROM 'rt'@'%';`)
+	mustExec(c, se, `GRANT INSERT ON test.test TO 'rt'@'%';`)
+	mustExec(c, se, `REVOKE INSERT ON test.test FROM 'rt'@'%';`)
+	mustExec(c, se, `FLUSH PRIVILEGES;`)
+	gs, err = pc.ShowGrants(se, `rt@%`)
+	c.Assert(err, IsNil)
+	expected := []string{`GRANT USAGE ON *.* TO 'rt'@'%'`,
+		"GRANT SELECT, INSERT ON `test\\_%`.* TO 'rt'@'%' WITH GRANT OPTION",
+		"GRANT SELECT ON `test1`.* TO 'rt'@'%'",
+		"GRANT SELECT (id), UPDATE (id), DELETE ON `test`.`test` TO 'rt'@'%'"}
+	c.Assert(testutil.CompareUnorderedStringSlice(gs, expected), IsTrue, Commentf("%v", gs))
+
+	// Executing the output for another user grants the same privileges.
+	for _, g := range gs {
+		mustExec(c, se, strings.Replace(g, `'rt'@'%'`, `'rt2'@'%'`, 1))
+	}
+	mustExec(c, se, `FLUSH PRIVILEGES;`)
+	gs2, err := pc.ShowGrants(se, `rt2@%`)
+	c.Assert(err, IsNil)
+	for i := range gs2 {
+		gs2[i] = strings.Replace(gs2[i], `'rt2'@'%'`, `'rt'@'%'`, 1)
+	}
+	c.Assert(testutil.CompareUnorderedStringSlice(gs2, expected), IsTrue, Commentf("%v", gs2))
+}
+
+func (s *testPrivilegeSuite) TestCheckWildcardPrivilege(c *C) {
+	defer testleak.AfterTest(c)()
+	rootSe := newSession(c, s.store, s.dbName)
+	mustExec(c, rootSe, `CREATE USER 'wild'@'%';`)
+	mustExec(c, rootSe, `CREATE USER 'wild'@'localhost';`)
+	mustExec(c, rootSe, "GRANT SELECT ON `te%`.* TO 'wild'@'%';")
+	mustExec(c, rootSe, "GRANT INSERT ON `te%`.* TO 'wild'@'localhost';")
+	mustExec(c, rootSe, "GRANT UPDATE ON `Test\\_db`.* TO 'wild'@'localhost';")
+	mustExec(c, rootSe, `FLUSH PRIVILEGES;`)
+
+	// The grants of 'wild'@'localhost' are more specific than the ones of 'wild'@'%', and the literal db name is
+	// more specific than the pattern, the first matched one is used.
+	se := newSession(c, s.store, s.dbName)
+	c.Assert(se.Auth("wild@localhost", nil, nil), IsTrue)
+	pc := privilege.GetPrivilegeManager(se)
+	c.Assert(pc.RequestVerification("test_db", "", "", mysql.UpdatePriv), IsTrue)
+	c.Assert(pc.RequestVerification("test_db", "", "", mysql.InsertPriv), IsFalse)
+	c.Assert(pc.RequestVerification("testxdb", "", "", mysql.UpdatePriv), IsFalse)
+	c.Assert(pc.RequestVerification("test", "", "", mysql.InsertPriv), IsTrue)
+	c.Assert(pc.RequestVerification("test", "", "", mysql.SelectPriv), IsFalse)
+
+	se = newSession(c, s.store, s.dbName)
+	c.Assert(se.Auth("wild@127.0.0.1", nil, nil), IsTrue)
+	pc = privilege.GetPrivilegeManager(se)
+	c.Assert(pc.RequestVerification("test", "", "", mysql.SelectPriv), IsTrue)
+	c.Assert(pc.RequestVerification("TEST1", "", "", mysql.SelectPriv), IsTrue)
+	c.Assert(pc.RequestVerification("mysql", "", "", mysql.SelectPriv), IsFalse)
+	c.Assert(pc.RequestVerification("test_db", "", "", mysql.UpdatePriv), IsFalse)
+}
+
 func (s *testPrivilegeSuite) TestDropTablePriv(c *C) {
 	defer testleak.AfterTest(c)()
 	se := newSession(c, s.store, s.dbName)
@@ -0,0 +1,526 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tidb
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/executor"
+	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/sessionctx/varsutil"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// procVar is a parameter or a local variable of a stored procedure.
+type procVar struct {
+	tp    *types.FieldType
+	value types.Datum
+	// refs are the values substituted for the references to the variable in the statements,
+	// they are updated when the variable is set.
+	refs []*ast.ValueExpr
+}
+
+func newProcVar(tp *types.FieldType) *procVar {
+	// Like the column types, the lengths default to the lengths of the types.
+	ft := *tp
+	if ft.Flen == types.UnspecifiedLength {
+		ft.Flen = mysql.GetDefaultFieldLength(ft.Tp)
+	}
+	if ft.Decimal == types.UnspecifiedLength {
+		ft.Decimal = mysql.GetDefaultDecimal(ft.Tp)
+	}
+	return &procVar{tp: &ft}
+}
+
+func (v *procVar) set(sc *variable.StatementContext, d types.Datum) error {
+	if !d.IsNull() {
+		var err error
+		d, err = d.ConvertTo(sc, v.tp)
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+	v.value = d
+	for _, ref := range v.refs {
+		v.setRef(ref)
+	}
+	return nil
+}
+
+func (v *procVar) setRef(ref *ast.ValueExpr) {
+	ref.SetDatum(v.value)
+	types.DefaultTypeForValue(v.value.GetValue(), ref.GetType())
+}
+
+// procScope is a statement list of a stored procedure, the variables declared in it are visible
+// to the statements after the declaration.
+type procScope struct {
+	parent *procScope
+	vars   map[string]*procVar
+}
+
+func newProcScope(parent *procScope) *procScope {
+	return &procScope{parent: parent, vars: make(map[string]*procVar)}
+}
+
+func (s *procScope) lookup(name string) *procVar {
+	name = strings.ToLower(name)
+	for ; s != nil; s = s.parent {
+		if v, ok := s.vars[name]; ok {
+			return v
+		}
+	}
+	return nil
+}
+
+// procJump is a LEAVE or an ITERATE to the labeled block or loop.
+type procJump struct {
+	label   string
+	iterate bool
+}
+
+// procCall is a running call of a stored procedure.
+type procCall struct {
+	s      *session
+	caller *procCall
+	// name is the procedure name like "db.name".
+	name string
+	// declares are the variables declared by the DECLARE statements.
+	declares map[*ast.ProcDeclareStmt][]*procVar
+	// assigns are the variables set by the assignments in the SET statements.
+	assigns map[*ast.VariableAssignment]*procVar
+	// refVars are the variables of the substituted values, so a nested call can set the
+	// variables passed as OUT arguments.
+	refVars map[*ast.ValueExpr]*procVar
+	results []ast.RecordSet
+}
+
+func newProcCall(s *session, caller *procCall, name string) *procCall {
+	return &procCall{
+		s:        s,
+		caller:   caller,
+		name:     name,
+		declares: make(map[*ast.ProcDeclareStmt][]*procVar),
+		assigns:  make(map[*ast.VariableAssignment]*procVar),
+		refVars:  make(map[*ast.ValueExpr]*procVar),
+	}
+}
+
+// procBinder substitutes the values for the references to the variables in scope, the columns
+// with the same names are shadowed like MySQL.
+type procBinder struct {
+	call  *procCall
+	scope *procScope
+}
+
+// Enter implements ast.Visitor interface.
+func (b *procBinder) Enter(in ast.Node) (ast.Node, bool) {
+	return in, false
+}
+
+// Leave implements ast.Visitor interface.
+func (b *procBinder) Leave(in ast.Node) (ast.Node, bool) {
+	switch x := in.(type) {
+	case *ast.ColumnNameExpr:
+		if x.Name.Table.L != "" {
+			break
+		}
+		if v := b.scope.lookup(x.Name.Name.L); v != nil {
+			ref := &ast.ValueExpr{}
+			v.setRef(ref)
+			v.refs = append(v.refs, ref)
+			b.call.refVars[ref] = v
+			return ref, true
+		}
+	case *ast.VariableAssignment:
+		if x.IsSystem && !x.IsGlobal {
+			if v := b.scope.lookup(x.Name); v != nil {
+				b.call.assigns[x] = v
+			}
+		}
+	}
+	return in, true
+}
+
+func (c *procCall) bindExpr(expr ast.ExprNode, scope *procScope) ast.ExprNode {
+	node, _ := expr.Accept(&procBinder{call: c, scope: scope})
+	return node.(ast.ExprNode)
+}
+
+// bindStmts binds the variables in the statement list, the variables are resolved when the
+// procedure is loaded, so the statements in a loop are not bound again.
+func (c *procCall) bindStmts(stmts []ast.StmtNode, parent *procScope) {
+	scope := newProcScope(parent)
+	for _, stmt := range stmts {
+		switch x := stmt.(type) {
+		case *ast.ProcBlockStmt:
+			c.bindStmts(x.Stmts, scope)
+		case *ast.ProcDeclareStmt:
+			if x.Default != nil {
+				x.Default = c.bindExpr(x.Default, scope)
+			}
+			vars := make([]*procVar, 0, len(x.Names))
+			for _, name := range x.Names {
+				v := newProcVar(x.Tp)
+				scope.vars[strings.ToLower(name)] = v
+				vars = append(vars, v)
+			}
+			c.declares[x] = vars
+		case *ast.ProcIfStmt:
+			x.Cond = c.bindExpr(x.Cond, scope)
+			c.bindStmts(x.Then, scope)
+			c.bindStmts(x.Else, scope)
+		case *ast.ProcWhileStmt:
+			x.Cond = c.bindExpr(x.Cond, scope)
+			c.bindStmts(x.Body, scope)
+		case *ast.ProcLoopStmt:
+			c.bindStmts(x.Body, scope)
+		case *ast.ProcLeaveStmt, *ast.ProcIterateStmt:
+		default:
+			stmt.Accept(&procBinder{call: c, scope: scope})
+		}
+	}
+}
+
+func (c *procCall) execStmts(stmts []ast.StmtNode) (*procJump, error) {
+	for _, stmt := range stmts {
+		jump, err := c.execStmt(stmt)
+		if err != nil || jump != nil {
+			return jump, errors.Trace(err)
+		}
+	}
+	return nil, nil
+}
+
+func (c *procCall) execStmt(stmt ast.StmtNode) (*procJump, error) {
+	switch x := stmt.(type) {
+	case *ast.ProcBlockStmt:
+		jump, err := c.execStmts(x.Stmts)
+		if jump != nil && x.Label != "" && strings.EqualFold(jump.label, x.Label) {
+			// The labels are checked by CREATE PROCEDURE, so it's a LEAVE.
+			jump = nil
+		}
+		return jump, errors.Trace(err)
+	case *ast.ProcDeclareStmt:
+		var d types.Datum
+		if x.Default != nil {
+			var err error
+			d, err = c.s.evalProcExpr(x.Default)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
+		for _, v := range c.declares[x] {
+			if err := v.set(c.s.sessionVars.StmtCtx, d); err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
+	case *ast.ProcIfStmt:
+		ok, err := c.s.evalProcCond(x.Cond)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if ok {
+			return c.execStmts(x.Then)
+		}
+		return c.execStmts(x.Else)
+	case *ast.ProcWhileStmt:
+		return c.execLoop(x.Label, x.Cond, x.Body)
+	case *ast.ProcLoopStmt:
+		return c.execLoop(x.Label, nil, x.Body)
+	case *ast.ProcLeaveStmt:
+		return &procJump{label: x.Label}, nil
+	case *ast.ProcIterateStmt:
+		return &procJump{label: x.Label, iterate: true}, nil
+	case *ast.SetStmt:
+		return nil, errors.Trace(c.execSet(x))
+	case *ast.CallStmt:
+		rss, err := c.s.executeCall(x, c)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		c.results = append(c.results, rss...)
+	default:
+		rs, err := c.s.runProcStmt(stmt)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if rs != nil {
+			rs, err = readProcRecordSet(rs)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			c.results = append(c.results, rs)
+		}
+	}
+	return nil, nil
+}
+
+// execLoop runs a WHILE loop, or a LOOP loop if cond is nil.
+func (c *procCall) execLoop(label string, cond ast.ExprNode, body []ast.StmtNode) (*procJump, error) {
+	for {
+		if cond != nil {
+			ok, err := c.s.evalProcCond(cond)
+			if err != nil || !ok {
+				return nil, errors.Trace(err)
+			}
+		}
+		jump, err := c.execStmts(body)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if jump == nil {
+			continue
+		}
+		if label == "" || !strings.EqualFold(jump.label, label) {
+			return jump, nil
+		}
+		if !jump.iterate {
+			return nil, nil
+		}
+	}
+}
+
+// execSet runs a SET statement, the local variables are set by the procedure and the other
+// variables are set by SET statements.
+func (c *procCall) execSet(stmt *ast.SetStmt) error {
+	for _, assign := range stmt.Variables {
+		if v, ok := c.assigns[assign]; ok {
+			d, err := c.s.evalProcExpr(assign.Value)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			if err = v.set(c.s.sessionVars.StmtCtx, d); err != nil {
+				return errors.Trace(err)
+			}
+			continue
+		}
+		set := &ast.SetStmt{Variables: []*ast.VariableAssignment{assign}}
+		if _, err := c.s.runProcStmt(set); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// runProcStmt runs a statement of a stored procedure like a statement in Execute.
+func (s *session) runProcStmt(stmt ast.StmtNode) (ast.RecordSet, error) {
+	s.PrepareTxnCtx()
+	executor.ResetStmtCtx(s, stmt)
+	st, err := Compile(s, stmt)
+	if err != nil {
+		s.RollbackTxn()
+		return nil, errors.Trace(err)
+	}
+	rs, err := runStmt(s, st)
+	return rs, errors.Trace(err)
+}
+
+// evalProcExpr evaluates the expression by a SELECT statement, so it can contain subqueries.
+func (s *session) evalProcExpr(expr ast.ExprNode) (types.Datum, error) {
+	stmt := &ast.SelectStmt{Fields: &ast.FieldList{Fields: []*ast.SelectField{{Expr: expr}}}}
+	rs, err := s.runProcStmt(stmt)
+	if err != nil {
+		return types.Datum{}, errors.Trace(err)
+	}
+	rows, err := drainRecordSet(rs)
+	if closeErr := rs.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return types.Datum{}, errors.Trace(err)
+	}
+	return rows[0].Data[0], nil
+}
+
+// evalProcCond evaluates the condition of IF and WHILE, NULL is false.
+func (s *session) evalProcCond(expr ast.ExprNode) (bool, error) {
+	d, err := s.evalProcExpr(expr)
+	if err != nil || d.IsNull() {
+		return false, errors.Trace(err)
+	}
+	b, err := d.ToBool(s.sessionVars.StmtCtx)
+	return b != 0, errors.Trace(err)
+}
+
+// executeCall calls the stored procedure, caller is the procedure which runs the CALL statement,
+// or nil if it's called by a client. It returns the result sets of the statements in the procedure.
+func (s *session) executeCall(stmt *ast.CallStmt, caller *procCall) ([]ast.RecordSet, error) {
+	db, name, err := executor.ProcedureName(s, stmt.Name)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	c := newProcCall(s, caller, db+"."+name)
+	if err = c.checkRecursion(); err != nil {
+		return nil, errors.Trace(err)
+	}
+	body, err := executor.LoadProcedure(s, db, name)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	charset, collation := s.sessionVars.GetCharsetInfo()
+	stmts, err := s.ParseSQL(body, charset, collation)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	create := stmts[0].(*ast.CreateProcedureStmt)
+	if len(stmt.Args) != len(create.Params) {
+		return nil, executor.ErrSPWrongNoOfArgs.GenByArgs("PROCEDURE", c.name, len(create.Params), len(stmt.Args))
+	}
+
+	// The arguments are evaluated in the database of the caller.
+	scope := newProcScope(nil)
+	params := make([]*procVar, len(create.Params))
+	args := make([]types.Datum, len(create.Params))
+	outs := make([]func(types.Datum) error, len(create.Params))
+	for i, p := range create.Params {
+		params[i] = newProcVar(p.Tp)
+		scope.vars[strings.ToLower(p.Name)] = params[i]
+		arg := stmt.Args[i]
+		if p.Mode != ast.ProcParamIn {
+			if outs[i] = s.procOutArg(arg, caller); outs[i] == nil {
+				return nil, executor.ErrSPNotVarArg.GenByArgs(i+1, c.name)
+			}
+		}
+		if p.Mode != ast.ProcParamOut {
+			if args[i], err = s.evalProcExpr(arg); err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
+	}
+	c.bindStmts([]ast.StmtNode{create.Body}, scope)
+	// The body isn't visited by CreateProcedureStmt, so the flags are set here.
+	ast.SetFlag(create.Body)
+	for i, v := range params {
+		if err = v.set(s.sessionVars.StmtCtx, args[i]); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+
+	// Like MySQL, the database of the procedure is the default database when it runs.
+	currentDB := s.sessionVars.CurrentDB
+	s.sessionVars.CurrentDB = db
+	_, err = c.execStmt(create.Body)
+	s.sessionVars.CurrentDB = currentDB
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	for i, out := range outs {
+		if out == nil {
+			continue
+		}
+		if err = out(params[i].value); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	return c.results, nil
+}
+
+// procOutArg returns the function to set the OUT or INOUT argument, or nil if the argument isn't
+// a user variable or a variable of the caller.
+func (s *session) procOutArg(arg ast.ExprNode, caller *procCall) func(types.Datum) error {
+	switch x := arg.(type) {
+	case *ast.VariableExpr:
+		if x.IsSystem {
+			return nil
+		}
+		name := strings.ToLower(x.Name)
+		return func(d types.Datum) error {
+			return errors.Trace(expression.SetUserVar(s.sessionVars, name, d))
+		}
+	case *ast.ValueExpr:
+		if caller == nil {
+			return nil
+		}
+		if v, ok := caller.refVars[x]; ok {
+			return func(d types.Datum) error {
+				return errors.Trace(v.set(s.sessionVars.StmtCtx, d))
+			}
+		}
+	}
+	return nil
+}
+
+// checkRecursion checks the recursive calls of the procedure by the max_sp_recursion_depth variable.
+func (c *procCall) checkRecursion() error {
+	var depth int
+	for caller := c.caller; caller != nil; caller = caller.caller {
+		if caller.name == c.name {
+			depth++
+		}
+	}
+	if depth == 0 {
+		return nil
+	}
+	val, err := varsutil.GetSessionSystemVar(c.s.sessionVars, "max_sp_recursion_depth")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	maxDepth, err := strconv.Atoi(val)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if depth > maxDepth {
+		return executor.ErrSPRecursionLimit.GenByArgs(maxDepth, c.name)
+	}
+	return nil
+}
+
+// procRecordSet is a result set of a statement in a stored procedure, the rows are read before
+// the next statement runs.
+type procRecordSet struct {
+	fields []*ast.ResultField
+	rows   []*ast.Row
+	cursor int
+}
+
+func readProcRecordSet(rs ast.RecordSet) (ast.RecordSet, error) {
+	fields, err := rs.Fields()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	rows, err := drainRecordSet(rs)
+	if closeErr := rs.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &procRecordSet{fields: fields, rows: rows}, nil
+}
+
+// Fields implements ast.RecordSet Fields interface.
+func (rs *procRecordSet) Fields() ([]*ast.ResultField, error) {
+	return rs.fields, nil
+}
+
+// Next implements ast.RecordSet Next interface.
+func (rs *procRecordSet) Next() (*ast.Row, error) {
+	if rs.cursor >= len(rs.rows) {
+		return nil, nil
+	}
+	rs.cursor++
+	return rs.rows[rs.cursor-1], nil
+}
+
+// Close implements ast.RecordSet Close interface.
+func (rs *procRecordSet) Close() error {
+	rs.cursor = 0
+	return nil
+}
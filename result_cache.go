@@ -0,0 +1,293 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tidb
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/config"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/executor"
+	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/infoschema"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/policy"
+	"github.com/pingcap/tidb/privilege"
+	"github.com/pingcap/tidb/sessionctx"
+	"github.com/pingcap/tidb/util/codec"
+	"github.com/pingcap/tidb/util/resultcache"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// nonDeterministicFuncs are the functions whose results depend on the time, the session or nothing at all,
+// the statements calling them are not cached.
+var nonDeterministicFuncs = map[string]struct{}{
+	ast.Now:              {},
+	ast.CurrentTimestamp: {},
+	ast.Curdate:          {},
+	ast.CurrentDate:      {},
+	ast.Curtime:          {},
+	ast.CurrentTime:      {},
+	ast.Sysdate:          {},
+	ast.UnixTimestamp:    {},
+	ast.UTCDate:          {},
+	ast.UTCTime:          {},
+	ast.UTCTimestamp:     {},
+	ast.LocalTime:        {},
+	ast.LocalTimestamp:   {},
+	ast.Rand:             {},
+	ast.RandomBytes:      {},
+	ast.UUID:             {},
+	ast.UUIDShort:        {},
+	ast.ConnectionID:     {},
+	ast.LastInsertId:     {},
+	ast.FoundRows:        {},
+	ast.RowCount:         {},
+	ast.Database:         {},
+	ast.Schema:           {},
+	ast.User:             {},
+	ast.CurrentUser:      {},
+	ast.SessionUser:      {},
+	ast.SystemUser:       {},
+	ast.Sleep:            {},
+	ast.Benchmark:        {},
+	ast.GetLock:          {},
+	ast.ReleaseLock:      {},
+	ast.ReleaseAllLocks:  {},
+	ast.IsFreeLock:       {},
+	ast.IsUsedLock:       {},
+	ast.LoadFile:         {},
+	ast.MaskRandom:       {},
+}
+
+// resultCacheChecker checks whether the result of a statement can be cached, and collects the IDs of the tables
+// read by the statement.
+type resultCacheChecker struct {
+	ctx       context.Context
+	is        infoschema.InfoSchema
+	tableIDs  []int64
+	cacheable bool
+}
+
+func (c *resultCacheChecker) Enter(in ast.Node) (ast.Node, bool) {
+	switch x := in.(type) {
+	case *ast.SelectStmt:
+		if x.LockTp != ast.SelectLockNone || (x.SelectStmtOpts != nil && (!x.SQLCache || x.CalcFoundRows)) {
+			c.cacheable = false
+		}
+	case *ast.VariableExpr:
+		c.cacheable = false
+	case *ast.FuncCallExpr:
+		name := x.FnName.L
+		if _, ok := nonDeterministicFuncs[name]; ok || !expression.IsBuiltinFunction(name) {
+			c.cacheable = false
+		}
+	case *ast.TableName:
+		c.checkTable(x)
+	}
+	return in, !c.cacheable
+}
+
+// checkTable checks the table is a table of the storage and the rows of it can be read by every user who can
+// select it, the tables with the policies or the masks are not cached.
+func (c *resultCacheChecker) checkTable(tn *ast.TableName) {
+	if tn.TableSample != nil {
+		c.cacheable = false
+		return
+	}
+	db := tn.Schema
+	if db.L == "" {
+		db = model.NewCIStr(c.ctx.GetSessionVars().CurrentDB)
+	}
+	tbl, err := c.is.TableByName(db, tn.Name)
+	if err != nil || infoschema.IsMemoryDB(db.L) {
+		c.cacheable = false
+		return
+	}
+	if pm := privilege.GetPrivilegeManager(c.ctx); pm != nil && !pm.RequestVerification(db.L, tn.Name.L, "", mysql.SelectPriv) {
+		c.cacheable = false
+		return
+	}
+	if h := policy.GetHandle(c.ctx); h != nil && (len(h.Get(db.L, tn.Name.L)) > 0 || len(h.Masks(db.L, tn.Name.L)) > 0) {
+		c.cacheable = false
+		return
+	}
+	c.tableIDs = append(c.tableIDs, tbl.Meta().ID)
+}
+
+func (c *resultCacheChecker) Leave(in ast.Node) (ast.Node, bool) {
+	return in, c.cacheable
+}
+
+// useResultCache returns the statement which reads its result from the result cache of the server if its result
+// can be cached, otherwise it returns st. The key of the result is the text of the statement with the arguments,
+// and the user, the database, the schema version and the session variables which affect the result.
+func (s *session) useResultCache(st ast.Statement, node ast.StmtNode, text string, args ...interface{}) ast.Statement {
+	vars := s.sessionVars
+	cfg := config.GetGlobalConfig()
+	// The partial results skipping the unavailable regions are not cached.
+	if !vars.EnableResultCache || cfg.ResultCacheSize <= 0 || vars.InTxn() || vars.SnapshotTS != 0 ||
+		vars.InRestrictedSQL || vars.AllowPartialResult || s.executeDepth > 1 {
+		return st
+	}
+	switch node.(type) {
+	case *ast.SelectStmt, *ast.UnionStmt:
+	default:
+		return st
+	}
+	is := executor.GetInfoSchema(s)
+	checker := &resultCacheChecker{ctx: s, is: is, cacheable: true}
+	node.Accept(checker)
+	if !checker.cacheable || len(checker.tableIDs) == 0 {
+		return st
+	}
+	encodedArgs, err := codec.EncodeValue(nil, types.MakeDatums(args...)...)
+	if err != nil {
+		return st
+	}
+	charset, collation := vars.GetCharsetInfo()
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%d\x00%d\x00%s\x00%s\x00%s\x00%s\x00", vars.User, vars.CurrentDB, is.SchemaMetaVersion(),
+		vars.SQLMode, vars.GetTimeZone(), charset, collation, text)
+	h.Write(encodedArgs)
+	return &resultCacheStmt{
+		Statement:  st,
+		cache:      sessionctx.GetDomain(s).ResultCache(),
+		key:        string(h.Sum(nil)),
+		tableIDs:   checker.tableIDs,
+		generation: s.resultCacheGen,
+		capacity:   cfg.ResultCacheSize,
+		ttl:        time.Duration(cfg.ResultCacheTTL) * time.Second,
+	}
+}
+
+// resultCacheStmt reads the result of the statement from the result cache, the result is put into the cache
+// after all the rows are read if it's not cached.
+type resultCacheStmt struct {
+	ast.Statement
+
+	cache    *resultcache.Cache
+	key      string
+	tableIDs []int64
+	// generation is the generation of the cache before the statement starts to read.
+	generation uint64
+	capacity   int64
+	ttl        time.Duration
+}
+
+// Exec implements the ast.Statement Exec interface.
+func (st *resultCacheStmt) Exec(ctx context.Context) (ast.RecordSet, error) {
+	if entry, ok := st.cache.Get(st.key); ok {
+		resultCacheCounter.WithLabelValues("hit").Inc()
+		// The statement doesn't read the storage, the start timestamp got for it is dropped, so the next
+		// statement doesn't use the stale one.
+		if se, ok := ctx.(*session); ok && se.txn == nil {
+			se.txnFuture = nil
+		}
+		return &cachedRecordSet{ctx: ctx, entry: entry}, nil
+	}
+	resultCacheCounter.WithLabelValues("miss").Inc()
+	rs, err := st.Statement.Exec(ctx)
+	if err != nil || rs == nil {
+		return rs, errors.Trace(err)
+	}
+	return &cachingRecordSet{RecordSet: rs, ctx: ctx, stmt: st}, nil
+}
+
+// cachedRecordSet returns the rows of a cached result.
+type cachedRecordSet struct {
+	ctx    context.Context
+	entry  *resultcache.Entry
+	cursor int
+}
+
+func (rs *cachedRecordSet) Fields() ([]*ast.ResultField, error) {
+	return rs.entry.Fields, nil
+}
+
+func (rs *cachedRecordSet) Next() (*ast.Row, error) {
+	vars := rs.ctx.GetSessionVars()
+	if rs.cursor >= len(rs.entry.Rows) {
+		vars.LastFoundRows = vars.StmtCtx.FoundRows()
+		return nil, nil
+	}
+	row := rs.entry.Rows[rs.cursor]
+	rs.cursor++
+	vars.StmtCtx.AddFoundRows(1)
+	return &ast.Row{Data: row}, nil
+}
+
+func (rs *cachedRecordSet) Close() error {
+	rs.cursor = 0
+	return nil
+}
+
+// cachingRecordSet collects the rows of the result, and puts the result into the cache after all the rows are read.
+type cachingRecordSet struct {
+	ast.RecordSet
+
+	ctx  context.Context
+	stmt *resultCacheStmt
+	rows [][]types.Datum
+	size int64
+	// skip means the result can't be cached, because it's too large or it fails.
+	skip bool
+}
+
+func (rs *cachingRecordSet) Next() (*ast.Row, error) {
+	row, err := rs.RecordSet.Next()
+	if err != nil {
+		rs.skip = true
+		return nil, errors.Trace(err)
+	}
+	if rs.skip {
+		return row, nil
+	}
+	if row == nil {
+		rs.skip = true
+		// The warnings are not cached, so the result with warnings is not cached either.
+		if rs.ctx.GetSessionVars().StmtCtx.WarningCount() > 0 {
+			rs.rows = nil
+			return nil, nil
+		}
+		fields, err1 := rs.RecordSet.Fields()
+		if err1 != nil {
+			return nil, errors.Trace(err1)
+		}
+		entry := &resultcache.Entry{Fields: fields, Rows: rs.rows, TableIDs: rs.stmt.tableIDs}
+		rs.stmt.cache.Put(rs.stmt.key, entry, rs.stmt.generation, rs.stmt.capacity, rs.stmt.ttl)
+		rs.rows = nil
+		return nil, nil
+	}
+	rs.size += resultcache.EstimateSize(row.Data)
+	if rs.size > resultcache.MaxEntrySize(rs.stmt.capacity) {
+		rs.skip = true
+		rs.rows = nil
+		return row, nil
+	}
+	rs.rows = append(rs.rows, row.Data)
+	return row, nil
+}
+
+func (rs *cachingRecordSet) Close() error {
+	// The result isn't cached if it's closed before all the rows are read.
+	rs.skip = true
+	rs.rows = nil
+	return errors.Trace(rs.RecordSet.Close())
+}
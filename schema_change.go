@@ -0,0 +1,122 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tidb
+
+import (
+	"encoding/json"
+	"hash/fnv"
+
+	"github.com/ngaut/log"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/domain"
+	"github.com/pingcap/tidb/executor"
+	"github.com/pingcap/tidb/infoschema"
+	"github.com/pingcap/tidb/model"
+)
+
+// usedTable is a table used by the statements of a session.
+type usedTable struct {
+	db   string
+	name string
+	// hash is the hash of the table info when the table is used or it's found changed.
+	hash uint64
+}
+
+// usedTables keeps the tables used by a session which notifies the schema changes, the session is warned
+// when a table it used is changed by another session.
+type usedTables struct {
+	// schemaVer is the schema version the tables are checked at.
+	schemaVer int64
+	tables    map[int64]*usedTable
+}
+
+// maxUsedTables is the max number of the tables a session keeps, the tables used later are not kept.
+const maxUsedTables = 1024
+
+func hashTableInfo(tblInfo *model.TableInfo) uint64 {
+	b, err := json.Marshal(tblInfo)
+	if err != nil {
+		log.Warnf("[schema change] marshal table %s failed %v", tblInfo.Name, err)
+		return 0
+	}
+	h := fnv.New64a()
+	h.Write(b)
+	return h.Sum64()
+}
+
+// tableNameCollector collects the resolved tables of a statement.
+type tableNameCollector struct {
+	tables []*ast.TableName
+}
+
+// Enter implements ast.Visitor interface.
+func (c *tableNameCollector) Enter(in ast.Node) (ast.Node, bool) {
+	if tn, ok := in.(*ast.TableName); ok && tn.TableInfo != nil && tn.DBInfo != nil {
+		c.tables = append(c.tables, tn)
+	}
+	return in, false
+}
+
+// Leave implements ast.Visitor interface.
+func (c *tableNameCollector) Leave(in ast.Node) (ast.Node, bool) {
+	return in, true
+}
+
+// notifySchemaChange appends a warning for every table used by the session which is changed or dropped since
+// the schema version the tables are checked at, then it records the tables used by the compiled statement.
+func (s *session) notifySchemaChange(stmt ast.StmtNode) {
+	if !s.sessionVars.NotifySchemaChange || s.sessionVars.InRestrictedSQL {
+		s.usedTables = nil
+		return
+	}
+	is := executor.GetInfoSchema(s)
+	if s.usedTables == nil {
+		s.usedTables = &usedTables{schemaVer: is.SchemaMetaVersion(), tables: make(map[int64]*usedTable)}
+	}
+	used := s.usedTables
+	if used.schemaVer != is.SchemaMetaVersion() {
+		s.checkUsedTables(is)
+		used.schemaVer = is.SchemaMetaVersion()
+	}
+
+	c := &tableNameCollector{}
+	stmt.Accept(c)
+	for _, tn := range c.tables {
+		if _, ok := used.tables[tn.TableInfo.ID]; ok || len(used.tables) >= maxUsedTables {
+			continue
+		}
+		used.tables[tn.TableInfo.ID] = &usedTable{
+			db:   tn.DBInfo.Name.O,
+			name: tn.TableInfo.Name.O,
+			hash: hashTableInfo(tn.TableInfo),
+		}
+	}
+}
+
+func (s *session) checkUsedTables(is infoschema.InfoSchema) {
+	sc := s.sessionVars.StmtCtx
+	for id, tbl := range s.usedTables.tables {
+		t, ok := is.TableByID(id)
+		if !ok {
+			sc.AppendWarning(domain.ErrUsedTableDropped.GenByArgs(tbl.db, tbl.name, is.SchemaMetaVersion()))
+			delete(s.usedTables.tables, id)
+			continue
+		}
+		hash := hashTableInfo(t.Meta())
+		if hash != tbl.hash {
+			sc.AppendWarning(domain.ErrUsedTableChanged.GenByArgs(tbl.db, tbl.name, is.SchemaMetaVersion()))
+			tbl.hash = hash
+		}
+	}
+}
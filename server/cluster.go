@@ -0,0 +1,355 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/domain"
+	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/util"
+)
+
+// The status API paths of the infos of a server, they are requested by the other servers in the cluster.
+const (
+	infoPathProcessList = "/info/processlist"
+	infoPathSlowQuery   = "/info/slow_query"
+	infoPathConfig      = "/info/config"
+	infoPathHotRegions  = "/info/hot_regions"
+	infoPathKill        = "/info/kill"
+)
+
+// The connection ID is made of the server ID in the higher bits and the local connection ID in the lower bits
+// after the server is registered, so the connections of the servers in the cluster have different IDs, and a
+// connection can be killed on any server.
+const (
+	localConnIDBits = 22
+	localConnIDMask = 1<<localConnIDBits - 1
+)
+
+// clusterRequestTimeout is the timeout of a request to the status server of another server in the cluster.
+const clusterRequestTimeout = 5 * time.Second
+
+var (
+	_ util.ClusterManager = &Server{}
+	_ util.GlobalKiller   = &Server{}
+)
+
+// GlobalConnID implements the GlobalKiller interface.
+func (s *Server) GlobalConnID() bool {
+	return atomic.LoadUint32(&s.serverID) != 0
+}
+
+// SetServerID sets the server ID allocated in the cluster, the IDs of the new connections contain it.
+func (s *Server) SetServerID(serverID uint64) {
+	atomic.StoreUint32(&s.serverID, uint32(serverID))
+}
+
+func (s *Server) nextConnID() uint32 {
+	connID := atomic.AddUint32(&baseConnID, 1)
+	serverID := atomic.LoadUint32(&s.serverID)
+	if serverID == 0 {
+		return connID
+	}
+	return serverID<<localConnIDBits | connID&localConnIDMask
+}
+
+// killRemote kills the connection on another server by the status API of the server, the request is authenticated
+// by the token of the server registered in etcd.
+func (s *Server) killRemote(connectionID uint64, query bool) error {
+	drv, ok := s.driver.(*TiDBDriver)
+	if !ok {
+		return errors.New("the cluster infos are only available with the TiDB driver")
+	}
+	dom, err := getDomain(drv.store)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	infos, err := dom.ServerInfos()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	serverID := connectionID >> localConnIDBits
+	for _, info := range infos {
+		if info.ServerID != serverID {
+			continue
+		}
+		client := &http.Client{Timeout: clusterRequestTimeout}
+		resp, err := client.PostForm(fmt.Sprintf("http://%s%s", info.StatusAddr(), infoPathKill), url.Values{
+			"id":    {strconv.FormatUint(connectionID, 10)},
+			"query": {strconv.FormatBool(query)},
+			"token": {info.Token},
+		})
+		if err != nil {
+			return errors.Trace(err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			body, _ := ioutil.ReadAll(resp.Body)
+			return errors.Errorf("server %s: %s: %s", info.Addr(), resp.Status, body)
+		}
+		return nil
+	}
+	return errors.Errorf("server %d isn't found", serverID)
+}
+
+// ClusterProcessList implements the ClusterManager interface.
+func (s *Server) ClusterProcessList() (map[string][]util.ProcessInfo, []error) {
+	data, errs := s.fetchCluster(infoPathProcessList, func(*domain.Domain) interface{} {
+		return s.ShowProcessList()
+	})
+	processes := make(map[string][]util.ProcessInfo, len(data))
+	for addr, js := range data {
+		var ps []util.ProcessInfo
+		if err := json.Unmarshal(js, &ps); err != nil {
+			errs = append(errs, errors.Errorf("server %s: %v", addr, err))
+			continue
+		}
+		processes[addr] = ps
+	}
+	return processes, errs
+}
+
+// ClusterSlowQueries implements the ClusterManager interface.
+func (s *Server) ClusterSlowQueries() (map[string][]util.SlowQueryInfo, []error) {
+	data, errs := s.fetchCluster(infoPathSlowQuery, func(dom *domain.Domain) interface{} {
+		return dom.SlowQueries()
+	})
+	queries := make(map[string][]util.SlowQueryInfo, len(data))
+	for addr, js := range data {
+		var qs []util.SlowQueryInfo
+		if err := json.Unmarshal(js, &qs); err != nil {
+			errs = append(errs, errors.Errorf("server %s: %v", addr, err))
+			continue
+		}
+		queries[addr] = qs
+	}
+	return queries, errs
+}
+
+// ClusterConfig implements the ClusterManager interface.
+func (s *Server) ClusterConfig() (map[string]map[string]string, []error) {
+	data, errs := s.fetchCluster(infoPathConfig, func(*domain.Domain) interface{} {
+		return flattenConfig(s.cfg)
+	})
+	configs := make(map[string]map[string]string, len(data))
+	for addr, js := range data {
+		var cfg map[string]string
+		if err := json.Unmarshal(js, &cfg); err != nil {
+			errs = append(errs, errors.Errorf("server %s: %v", addr, err))
+			continue
+		}
+		configs[addr] = cfg
+	}
+	return configs, errs
+}
+
+// ClusterHotRegions implements the ClusterManager interface.
+func (s *Server) ClusterHotRegions() (map[string][]util.HotRegionStat, []error) {
+	data, errs := s.fetchCluster(infoPathHotRegions, func(dom *domain.Domain) interface{} {
+		return localHotRegions(dom.Store())
+	})
+	stats := make(map[string][]util.HotRegionStat, len(data))
+	for addr, js := range data {
+		var rs []util.HotRegionStat
+		if err := json.Unmarshal(js, &rs); err != nil {
+			errs = append(errs, errors.Errorf("server %s: %v", addr, err))
+			continue
+		}
+		stats[addr] = rs
+	}
+	return stats, errs
+}
+
+// localHotRegions returns the QPS of the regions collected by the storage of this server, it's empty if the
+// storage doesn't collect them.
+func localHotRegions(store kv.Storage) []util.HotRegionStat {
+	if c, ok := store.(util.HotRegionCollector); ok {
+		return c.HotRegions()
+	}
+	return []util.HotRegionStat{}
+}
+
+// fetchCluster gets the infos of all the registered servers in JSON, keyed by the server address.
+// The infos of this server are got by local, the others are requested from their status servers at path
+// concurrently, a server which fails to respond is reported in the errors and skipped.
+func (s *Server) fetchCluster(path string, local func(*domain.Domain) interface{}) (map[string][]byte, []error) {
+	drv, ok := s.driver.(*TiDBDriver)
+	if !ok {
+		return nil, []error{errors.New("the cluster infos are only available with the TiDB driver")}
+	}
+	dom, err := getDomain(drv.store)
+	if err != nil {
+		return nil, []error{errors.Trace(err)}
+	}
+	infos, err := dom.ServerInfos()
+	if err != nil {
+		return nil, []error{errors.Trace(err)}
+	}
+	self := dom.ServerInfo()
+	if self == nil {
+		// The server isn't registered, so only the infos of itself is available.
+		js, err := json.Marshal(local(dom))
+		if err != nil {
+			return nil, []error{errors.Trace(err)}
+		}
+		return map[string][]byte{s.cfg.Addr: js}, nil
+	}
+
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		data = make(map[string][]byte, len(infos))
+		errs []error
+	)
+	client := &http.Client{Timeout: clusterRequestTimeout}
+	for _, info := range infos {
+		wg.Add(1)
+		go func(info *domain.ServerInfo) {
+			defer wg.Done()
+			var js []byte
+			var err error
+			if info.ID == self.ID {
+				js, err = json.Marshal(local(dom))
+			} else {
+				js, err = fetchServerInfo(client, fmt.Sprintf("http://%s%s", info.StatusAddr(), path), info.Token)
+			}
+			mu.Lock()
+			if err != nil {
+				errs = append(errs, errors.Errorf("server %s: %v", info.Addr(), err))
+			} else {
+				data[info.Addr()] = js
+			}
+			mu.Unlock()
+		}(info)
+	}
+	wg.Wait()
+	return data, errs
+}
+
+// fetchServerInfo requests the infos from the status server at url with the token of that server, the token is
+// required by the infos which contain the SQLs of the users.
+func fetchServerInfo(client *http.Client, addr string, token string) ([]byte, error) {
+	resp, err := client.PostForm(addr, url.Values{"token": {token}})
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("%s: %s", resp.Status, body)
+	}
+	return body, nil
+}
+
+// flattenConfig returns the config items keyed by their JSON names, the names of the nested items
+// are joined by ".".
+func flattenConfig(cfg interface{}) map[string]string {
+	items := make(map[string]string)
+	js, err := json.Marshal(cfg)
+	if err != nil {
+		return items
+	}
+	var m map[string]interface{}
+	if err = json.Unmarshal(js, &m); err != nil {
+		return items
+	}
+	flattenItems(items, "", m)
+	return items
+}
+
+func flattenItems(items map[string]string, prefix string, m map[string]interface{}) {
+	for k, v := range m {
+		if nested, ok := v.(map[string]interface{}); ok {
+			flattenItems(items, prefix+k+".", nested)
+			continue
+		}
+		items[prefix+k] = fmt.Sprintf("%v", v)
+	}
+}
+
+// infoHandler is the handler for getting the infos of this server, the infos are requested by the other
+// servers in the cluster for the cluster tables of the information schema.
+type infoHandler struct {
+	server *Server
+	store  kv.Storage
+	path   string
+}
+
+// ServeHTTP handles request of the processes, the recent slow queries, the config or the hot regions of this server,
+// or a POST request to kill a connection of this server. The requests of the processes and the slow queries, which
+// contain the SQLs of the users, and the kill request must be POST requests with the token of this server, which is
+// only known by the servers in the cluster.
+func (h infoHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	switch h.path {
+	case infoPathKill, infoPathProcessList, infoPathSlowQuery:
+		if req.Method != http.MethodPost {
+			writeError(w, errors.Errorf("%s isn't allowed", req.Method))
+			return
+		}
+		dom, err := getDomain(h.store)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		info := dom.ServerInfo()
+		if info == nil || len(info.Token) == 0 ||
+			subtle.ConstantTimeCompare([]byte(req.PostFormValue("token")), []byte(info.Token)) != 1 {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte("invalid token"))
+			return
+		}
+	}
+
+	switch h.path {
+	case infoPathKill:
+		connectionID, err := strconv.ParseUint(req.FormValue("id"), 10, 64)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		query, err := strconv.ParseBool(req.FormValue("query"))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeData(w, h.server.killLocal(connectionID, query))
+	case infoPathProcessList:
+		writeData(w, h.server.ShowProcessList())
+	case infoPathSlowQuery:
+		dom, err := getDomain(h.store)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeData(w, dom.SlowQueries())
+	case infoPathConfig:
+		writeData(w, flattenConfig(h.server.cfg))
+	case infoPathHotRegions:
+		writeData(w, localHotRegions(h.store))
+	}
+}
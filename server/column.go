@@ -15,6 +15,7 @@ package server
 
 import (
 	"github.com/pingcap/tidb/util/arena"
+	"github.com/pingcap/tidb/util/protocol"
 )
 
 // ColumnInfo contains information of a column
@@ -65,3 +66,8 @@ func (column *ColumnInfo) Dump(alloc arena.Allocator) []byte {
 
 	return data
 }
+
+// protocolColumn returns the type of the column by which its values are encoded.
+func (column *ColumnInfo) protocolColumn() protocol.Column {
+	return protocol.Column{Type: column.Type, Flag: column.Flag, Decimal: column.Decimal}
+}
@@ -43,6 +43,7 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/juju/errors"
@@ -50,8 +51,10 @@ import (
 	"github.com/pingcap/tidb/executor"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/parser"
 	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util/arena"
+	"github.com/pingcap/tidb/util/audit"
 	"github.com/pingcap/tidb/util/hack"
 )
 
@@ -59,7 +62,8 @@ var defaultCapability = mysql.ClientLongPassword | mysql.ClientLongFlag |
 	mysql.ClientConnectWithDB | mysql.ClientProtocol41 |
 	mysql.ClientTransactions | mysql.ClientSecureConnection | mysql.ClientFoundRows |
 	mysql.ClientMultiStatements | mysql.ClientMultiResults | mysql.ClientLocalFiles |
-	mysql.ClientConnectAtts
+	mysql.ClientConnectAtts | mysql.ClientSessionTrack | mysql.ClientDeprecateEOF |
+	mysql.ClientOptionalResultsetMetadata
 
 // clientConn represents a connection between server and client, it maintains connection specific state,
 // handles client query.
@@ -71,13 +75,31 @@ type clientConn struct {
 	connectionID uint32            // atomically allocated by a global variable, unique in process scope.
 	collation    uint8             // collation used by client, may be different from the collation used by database.
 	user         string            // user of the client.
+	account      string            // account the user logged in as, the connections are limited per account.
 	dbname       string            // default database name.
 	salt         []byte            // random bytes used for authentication.
 	alloc        arena.Allocator   // an memory allocator for reducing memory allocation.
 	lastCmd      string            // latest sql query string, currently used for logging error.
 	ctx          QueryCtx          // an interface to execute sql statements.
-	attrs        map[string]string // attributes parsed from client handshake response, not used for now.
+	attrs        map[string]string // attributes parsed from client handshake response.
 	killed       bool
+	status       int32 // one of the connStatus, it's accessed atomically.
+
+	// resourceGroup is the resource group of the account, which limits the concurrent statements.
+	resourceGroup string
+}
+
+// The status of a client connection, a connection reading the next command is idle, an idle connection
+// out of a transaction is closed when the server is shutting down.
+const (
+	connStatusDispatching int32 = iota
+	connStatusReading
+	connStatusShutdown
+)
+
+// inTxn returns whether the connection is in a transaction.
+func (cc *clientConn) inTxn() bool {
+	return cc.ctx != nil && cc.ctx.Status()&mysql.ServerStatusInTrans > 0
 }
 
 func (cc *clientConn) String() string {
@@ -116,18 +138,34 @@ func (cc *clientConn) handshake() error {
 }
 
 func (cc *clientConn) Close() error {
-	cc.server.rwlock.Lock()
-	delete(cc.server.clients, cc.connectionID)
-	connections := len(cc.server.clients)
-	cc.server.rwlock.Unlock()
-	connGauge.Set(float64(connections))
+	cc.server.unregisterConn(cc)
 	cc.conn.Close()
+	cc.audit(audit.EventDisconnect, nil)
 	if cc.ctx != nil {
 		return cc.ctx.Close()
 	}
 	return nil
 }
 
+// audit sends the audit event of the connection.
+func (cc *clientConn) audit(eventType audit.EventType, err error) {
+	if !audit.Enabled() {
+		return
+	}
+	host, _, _ := net.SplitHostPort(cc.conn.RemoteAddr().String())
+	event := &audit.Event{
+		Type:     eventType,
+		ConnID:   uint64(cc.connectionID),
+		User:     cc.user,
+		ClientIP: host,
+		DB:       cc.dbname,
+	}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	audit.Log(event)
+}
+
 // writeInitialHandshake sends server version, connection ID, server capability, collation, server status
 // and auth salt to the client.
 func (cc *clientConn) writeInitialHandshake() error {
@@ -305,6 +343,7 @@ func (cc *clientConn) readHandshakeResponse() error {
 	if err != nil {
 		return errors.Trace(err)
 	}
+	cc.ctx.SetConnectAttrs(cc.attrs)
 	if !cc.server.skipAuth() {
 		// Do Auth
 		addr := cc.conn.RemoteAddr().String()
@@ -324,7 +363,7 @@ func (cc *clientConn) readHandshakeResponse() error {
 		}
 	}
 	cc.ctx.SetSessionManager(cc.server)
-	return nil
+	return errors.Trace(cc.server.registerConn(cc))
 }
 
 // Run reads client query and writes query result to client in for loop, if there is a panic during query handling,
@@ -345,7 +384,12 @@ func (cc *clientConn) Run() {
 
 	for !cc.killed {
 		cc.alloc.Reset()
+		atomic.StoreInt32(&cc.status, connStatusReading)
 		data, err := cc.readPacket()
+		if !atomic.CompareAndSwapInt32(&cc.status, connStatusReading, connStatusDispatching) {
+			log.Infof("[%d] close the idle connection for the server is shutting down", cc.connectionID)
+			return
+		}
 		if err != nil || cc.killed {
 			if terror.ErrorNotEqual(err, io.EOF) {
 				log.Errorf("[%d] read packet error, close this connection %s",
@@ -377,14 +421,30 @@ func (cc *clientConn) Run() {
 				return
 			}
 			log.Warnf("[%d] dispatch error:\n%s\n%s\n%s",
-				cc.connectionID, cc, queryStrForLog(string(data[1:])), errStrForLog(err))
+				cc.connectionID, cc, cc.dispatchQueryForLog(data), errStrForLog(err))
 			cc.writeError(err)
 		}
 		cc.addMetrics(data[0], startTime, err)
 		cc.pkt.sequence = 0
+		if cc.server.isDraining() && !cc.inTxn() {
+			log.Infof("[%d] close the connection for the server is shutting down", cc.connectionID)
+			return
+		}
 	}
 }
 
+// dispatchQueryForLog returns the query of the dispatched command for the dispatch error log. If tidb_redact_log
+// is on, the literals in the query are replaced by "?" and the arguments of the other commands are omitted.
+func (cc *clientConn) dispatchQueryForLog(data []byte) string {
+	if !cc.ctx.RedactLog() {
+		return queryStrForLog(string(data[1:]))
+	}
+	if data[0] != mysql.ComQuery {
+		return "(redacted)"
+	}
+	return queryStrForLog(parser.Normalize(string(data[1:])))
+}
+
 func queryStrForLog(query string) string {
 	const size = 4096
 	if len(query) > size {
@@ -436,7 +496,7 @@ func (cc *clientConn) addMetrics(cmd byte, startTime time.Time, err error) {
 	} else {
 		queryCounter.WithLabelValues(label, "OK").Inc()
 	}
-	queryHistogram.Observe(time.Since(startTime).Seconds())
+	queryHistogram.WithLabelValues(label).Observe(time.Since(startTime).Seconds())
 }
 
 // dispatch handles client request based on command which is the first byte of the data.
@@ -446,6 +506,10 @@ func (cc *clientConn) dispatch(data []byte) error {
 	cmd := data[0]
 	data = data[1:]
 	cc.lastCmd = hack.String(data)
+	// The statements of a resource group wait for the group's slot before taking the server's token, so a busy
+	// group doesn't hold the tokens the other groups need.
+	release := cc.server.resourceGroups.Acquire(cc.resourceGroup)
+	defer release()
 	token := cc.server.getToken()
 	defer func() {
 		cc.server.releaseToken(token)
@@ -510,21 +574,38 @@ func (cc *clientConn) flush() error {
 }
 
 func (cc *clientConn) writeOK() error {
+	if err := cc.writeOKPacket(mysql.OKHeader, 0); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(cc.flush())
+}
+
+// writeOKPacket writes an OK packet with the header and the extra status flags, the header is mysql.EOFHeader if
+// it ends a result set for the client with the CLIENT_DEPRECATE_EOF capability. The changes of the session state
+// are sent in it if the client has the CLIENT_SESSION_TRACK capability, they're dropped otherwise.
+func (cc *clientConn) writeOKPacket(header byte, extraStatus uint16) error {
+	changes := cc.ctx.SessionStateChanges()
 	data := cc.alloc.AllocWithLen(4, 32)
-	data = append(data, mysql.OKHeader)
+	data = append(data, header)
 	data = append(data, dumpLengthEncodedInt(uint64(cc.ctx.AffectedRows()))...)
 	data = append(data, dumpLengthEncodedInt(uint64(cc.ctx.LastInsertID()))...)
+	status := cc.ctx.Status() | extraStatus
+	tracked := cc.capability&mysql.ClientSessionTrack > 0 && len(changes) > 0
+	if tracked {
+		status |= mysql.ServerSessionStateChanged
+	}
 	if cc.capability&mysql.ClientProtocol41 > 0 {
-		data = append(data, dumpUint16(cc.ctx.Status())...)
+		data = append(data, dumpUint16(status)...)
 		data = append(data, dumpUint16(cc.ctx.WarningCount())...)
 	}
-
-	err := cc.writePacket(data)
-	if err != nil {
-		return errors.Trace(err)
+	if cc.capability&mysql.ClientSessionTrack > 0 {
+		// The info is empty.
+		data = append(data, 0)
+		if tracked {
+			data = append(data, dumpLengthEncodedString(dumpSessionStateChanges(changes, cc.alloc), cc.alloc)...)
+		}
 	}
-
-	return errors.Trace(cc.flush())
+	return errors.Trace(cc.writePacket(data))
 }
 
 func (cc *clientConn) writeError(e error) error {
@@ -557,12 +638,20 @@ func (cc *clientConn) writeError(e error) error {
 	return errors.Trace(cc.flush())
 }
 
-// writeEOF writes an EOF packet.
+// writeEOF writes an EOF packet, or an OK packet with the EOF header if the client has the CLIENT_DEPRECATE_EOF
+// capability.
 // Note this function won't flush the stream because maybe there are more
 // packets following it, the "more" argument would indicates that case.
 // If "more" is true, a mysql.ServerMoreResultsExists bit would be set
 // in the packet.
 func (cc *clientConn) writeEOF(more bool) error {
+	if cc.capability&mysql.ClientDeprecateEOF > 0 {
+		var status uint16
+		if more {
+			status = mysql.ServerMoreResultsExists
+		}
+		return errors.Trace(cc.writeOKPacket(mysql.EOFHeader, status))
+	}
 	data := cc.alloc.AllocWithLen(4, 9)
 
 	data = append(data, mysql.EOFHeader)
@@ -572,13 +661,46 @@ func (cc *clientConn) writeEOF(more bool) error {
 		if more {
 			status |= mysql.ServerMoreResultsExists
 		}
-		data = append(data, dumpUint16(cc.ctx.Status())...)
+		data = append(data, dumpUint16(status)...)
 	}
 
 	err := cc.writePacket(data)
 	return errors.Trace(err)
 }
 
+// writeColumnsEOF writes the EOF packet after the column definitions, it's omitted if the client has the
+// CLIENT_DEPRECATE_EOF capability.
+func (cc *clientConn) writeColumnsEOF() error {
+	if cc.capability&mysql.ClientDeprecateEOF > 0 {
+		return nil
+	}
+	data := cc.alloc.AllocWithLen(4, 9)
+	data = append(data, mysql.EOFHeader)
+	if cc.capability&mysql.ClientProtocol41 > 0 {
+		data = append(data, dumpUint16(cc.ctx.WarningCount())...)
+		data = append(data, dumpUint16(cc.ctx.Status())...)
+	}
+	return errors.Trace(cc.writePacket(data))
+}
+
+// sendMetadata returns whether the column definitions are sent, they aren't if the client has the
+// CLIENT_OPTIONAL_RESULTSET_METADATA capability and resultset_metadata is NONE.
+func (cc *clientConn) sendMetadata() bool {
+	return cc.capability&mysql.ClientOptionalResultsetMetadata == 0 || !cc.ctx.SkipResultsetMetadata()
+}
+
+// dumpMetadataFollows appends the metadata_follows field if the client has the CLIENT_OPTIONAL_RESULTSET_METADATA
+// capability.
+func (cc *clientConn) dumpMetadataFollows(data []byte, sendMetadata bool) []byte {
+	if cc.capability&mysql.ClientOptionalResultsetMetadata == 0 {
+		return data
+	}
+	if sendMetadata {
+		return append(data, mysql.ResultsetMetadataFull)
+	}
+	return append(data, mysql.ResultsetMetadataNone)
+}
+
 func (cc *clientConn) writeReq(filePath string) error {
 	data := cc.alloc.AllocWithLen(4, 5+len(filePath))
 	data = append(data, mysql.LocalInFileHeader)
@@ -745,22 +867,25 @@ func (cc *clientConn) writeResultset(rs ResultSet, binary bool, more bool) error
 	columnLen := dumpLengthEncodedInt(uint64(len(columns)))
 	data := cc.alloc.AllocWithLen(4, 1024)
 	data = append(data, columnLen...)
+	sendMetadata := cc.sendMetadata()
+	data = cc.dumpMetadataFollows(data, sendMetadata)
 	if err = cc.writePacket(data); err != nil {
 		return errors.Trace(err)
 	}
 
-	for _, v := range columns {
-		data = data[0:4]
-		data = append(data, v.Dump(cc.alloc)...)
-		if err = cc.writePacket(data); err != nil {
+	if sendMetadata {
+		for _, v := range columns {
+			data = data[0:4]
+			data = append(data, v.Dump(cc.alloc)...)
+			if err = cc.writePacket(data); err != nil {
+				return errors.Trace(err)
+			}
+		}
+		if err = cc.writeColumnsEOF(); err != nil {
 			return errors.Trace(err)
 		}
 	}
 
-	if err = cc.writeEOF(false); err != nil {
-		return errors.Trace(err)
-	}
-
 	for {
 		if err != nil {
 			return errors.Trace(err)
@@ -771,7 +896,7 @@ func (cc *clientConn) writeResultset(rs ResultSet, binary bool, more bool) error
 		data = data[0:4]
 		if binary {
 			var rowData []byte
-			rowData, err = dumpRowValuesBinary(cc.alloc, columns, row)
+			rowData, err = dumpRowValuesBinary(columns, row)
 			if err != nil {
 				return errors.Trace(err)
 			}
@@ -783,7 +908,7 @@ func (cc *clientConn) writeResultset(rs ResultSet, binary bool, more bool) error
 					continue
 				}
 				var valData []byte
-				valData, err = dumpTextValue(columns[i].Type, value)
+				valData, err = dumpTextValue(columns[i], value)
 				if err != nil {
 					return errors.Trace(err)
 				}
@@ -42,6 +42,7 @@ import (
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/util/hack"
+	"github.com/pingcap/tidb/util/protocol"
 )
 
 func (cc *clientConn) handleStmtPrepare(sql string) error {
@@ -63,10 +64,15 @@ func (cc *clientConn) handleStmtPrepare(sql string) error {
 	data = append(data, 0)
 	//warning count
 	data = append(data, 0, 0) //TODO support warning count
+	sendMetadata := cc.sendMetadata()
+	data = cc.dumpMetadataFollows(data, sendMetadata)
 
 	if err := cc.writePacket(data); err != nil {
 		return errors.Trace(err)
 	}
+	if !sendMetadata {
+		return errors.Trace(cc.flush())
+	}
 
 	if len(params) > 0 {
 		for i := 0; i < len(params); i++ {
@@ -78,7 +84,7 @@ func (cc *clientConn) handleStmtPrepare(sql string) error {
 			}
 		}
 
-		if err := cc.writeEOF(false); err != nil {
+		if err := cc.writeColumnsEOF(); err != nil {
 			return errors.Trace(err)
 		}
 	}
@@ -93,7 +99,7 @@ func (cc *clientConn) handleStmtPrepare(sql string) error {
 			}
 		}
 
-		if err := cc.writeEOF(false); err != nil {
+		if err := cc.writeColumnsEOF(); err != nil {
 			return errors.Trace(err)
 		}
 
@@ -279,12 +285,26 @@ func parseStmtArgs(args []interface{}, boundParams [][]byte, nullBitmap, paramTy
 			pos += 8
 			continue
 
+		case mysql.TypeDate, mysql.TypeNewDate, mysql.TypeTimestamp, mysql.TypeDatetime:
+			args[i], n, err = protocol.ParseBinaryTime(paramValues[pos:], tp)
+			if err != nil {
+				return
+			}
+			pos += n
+			continue
+
+		case mysql.TypeDuration:
+			args[i], n, err = protocol.ParseBinaryDuration(paramValues[pos:])
+			if err != nil {
+				return
+			}
+			pos += n
+			continue
+
 		case mysql.TypeUnspecified, mysql.TypeNewDecimal, mysql.TypeVarchar,
 			mysql.TypeBit, mysql.TypeEnum, mysql.TypeSet, mysql.TypeTinyBlob,
 			mysql.TypeMediumBlob, mysql.TypeLongBlob, mysql.TypeBlob,
-			mysql.TypeVarString, mysql.TypeString, mysql.TypeGeometry,
-			mysql.TypeDate, mysql.TypeNewDate,
-			mysql.TypeTimestamp, mysql.TypeDatetime, mysql.TypeDuration:
+			mysql.TypeVarString, mysql.TypeString, mysql.TypeGeometry:
 			if len(paramValues) < (pos + 1) {
 				err = mysql.ErrMalformPacket
 				return
@@ -16,7 +16,9 @@ package server
 import (
 	"fmt"
 
+	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/util"
+	"github.com/pingcap/tidb/util/resource"
 	"github.com/pingcap/tidb/util/types"
 )
 
@@ -55,6 +57,18 @@ type QueryCtx interface {
 	// CurrentDB returns current DB.
 	CurrentDB() string
 
+	// SkipResultsetMetadata returns whether the column definitions of the result sets are not sent to the client
+	// with the CLIENT_OPTIONAL_RESULTSET_METADATA capability.
+	SkipResultsetMetadata() bool
+
+	// SessionStateChanges returns the changes of the session state since the last call, which are tracked by the
+	// session_track_* variables.
+	SessionStateChanges() []variable.SessionStateChange
+
+	// RedactLog returns whether the literals in the SQL of the logs are replaced by "?", it's the tidb_redact_log
+	// system variable.
+	RedactLog() bool
+
 	// Execute executes a SQL statement.
 	Execute(sql string) ([]ResultSet, error)
 
@@ -81,6 +95,13 @@ type QueryCtx interface {
 
 	SetSessionManager(util.SessionManager)
 
+	// SetConnectAttrs sets the connection attributes sent by the client in the handshake.
+	SetConnectAttrs(attrs map[string]string)
+
+	// ConnectionLimits returns the account the user logged in as, the max connections of the server and the
+	// resource limits of the account, 0 means no limit.
+	ConnectionLimits() (account string, maxConns int64, limits resource.Limits, err error)
+
 	// Cancel the execution of current transaction.
 	Cancel()
 }
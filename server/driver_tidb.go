@@ -15,13 +15,19 @@ package server
 
 import (
 	"fmt"
+	"strconv"
 
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb"
 	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/executor"
 	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/sessionctx/varsutil"
 	"github.com/pingcap/tidb/util"
+	"github.com/pingcap/tidb/util/resource"
 	"github.com/pingcap/tidb/util/types"
 )
 
@@ -182,6 +188,21 @@ func (tc *TiDBContext) WarningCount() uint16 {
 	return tc.session.GetSessionVars().StmtCtx.WarningCount()
 }
 
+// SkipResultsetMetadata implements QueryCtx SkipResultsetMetadata method.
+func (tc *TiDBContext) SkipResultsetMetadata() bool {
+	return tc.session.GetSessionVars().SkipResultsetMetadata
+}
+
+// SessionStateChanges implements QueryCtx SessionStateChanges method.
+func (tc *TiDBContext) SessionStateChanges() []variable.SessionStateChange {
+	return tc.session.GetSessionVars().TakeSessionStateChanges()
+}
+
+// RedactLog implements QueryCtx RedactLog method.
+func (tc *TiDBContext) RedactLog() bool {
+	return tc.session.GetSessionVars().RedactLog
+}
+
 // Execute implements QueryCtx Execute method.
 func (tc *TiDBContext) Execute(sql string) (rs []ResultSet, err error) {
 	rsList, err := tc.session.Execute(sql)
@@ -205,6 +226,38 @@ func (tc *TiDBContext) SetSessionManager(sm util.SessionManager) {
 	tc.session.SetSessionManager(sm)
 }
 
+// SetConnectAttrs implements QueryCtx SetConnectAttrs method.
+func (tc *TiDBContext) SetConnectAttrs(attrs map[string]string) {
+	tc.session.GetSessionVars().ConnectAttrs = attrs
+}
+
+// ConnectionLimits implements QueryCtx ConnectionLimits method.
+// The MAX_USER_CONNECTIONS of the account takes precedence over the max_user_connections system variable.
+func (tc *TiDBContext) ConnectionLimits() (string, int64, resource.Limits, error) {
+	sessionVars := tc.session.GetSessionVars()
+	limits := sessionVars.ResourceLimits
+	maxConns, err := getIntSysVar(sessionVars, variable.MaxConnections)
+	if err != nil {
+		return "", 0, limits, errors.Trace(err)
+	}
+	if limits.MaxUserConnections == 0 {
+		limits.MaxUserConnections, err = getIntSysVar(sessionVars, variable.MaxUserConnections)
+		if err != nil {
+			return "", 0, limits, errors.Trace(err)
+		}
+	}
+	return sessionVars.Account, maxConns, limits, nil
+}
+
+func getIntSysVar(sessionVars *variable.SessionVars, name string) (int64, error) {
+	value, err := varsutil.GetGlobalSystemVar(sessionVars, name)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	v, err := strconv.ParseInt(value, 10, 64)
+	return v, errors.Trace(err)
+}
+
 // SetClientCapability implements QueryCtx SetClientCapability method.
 func (tc *TiDBContext) SetClientCapability(flags uint32) {
 	tc.session.SetClientCapability(flags)
@@ -240,19 +293,30 @@ func (tc *TiDBContext) GetStatement(stmtID int) PreparedStatement {
 	if tcStmt != nil {
 		return tcStmt
 	}
+	// The statement may be imported by SET SESSION_STATES when the connection is migrated from another server.
+	if prepared, ok := tc.session.GetSessionVars().PreparedStmts[uint32(stmtID)].(*executor.Prepared); ok {
+		tcStmt = &TiDBStatement{
+			id:          uint32(stmtID),
+			numParams:   len(prepared.Params),
+			boundParams: make([][]byte, len(prepared.Params)),
+			ctx:         tc,
+		}
+		tc.stmts[stmtID] = tcStmt
+		return tcStmt
+	}
 	return nil
 }
 
 // Prepare implements QueryCtx Prepare method.
 func (tc *TiDBContext) Prepare(sql string) (statement PreparedStatement, columns, params []*ColumnInfo, err error) {
-	stmtID, paramCount, fields, err := tc.session.PrepareStmt(sql)
+	stmtID, paramTypes, fields, err := tc.session.PrepareStmt(sql)
 	if err != nil {
 		return
 	}
 	stmt := &TiDBStatement{
 		id:          stmtID,
-		numParams:   paramCount,
-		boundParams: make([][]byte, paramCount),
+		numParams:   len(paramTypes),
+		boundParams: make([][]byte, len(paramTypes)),
 		ctx:         tc,
 	}
 	statement = stmt
@@ -260,11 +324,12 @@ func (tc *TiDBContext) Prepare(sql string) (statement PreparedStatement, columns
 	for i := range fields {
 		columns[i] = convertColumnInfo(fields[i])
 	}
-	params = make([]*ColumnInfo, paramCount)
-	for i := range params {
-		params[i] = &ColumnInfo{
-			Type: mysql.TypeBlob,
-		}
+	params = make([]*ColumnInfo, len(paramTypes))
+	for i, tp := range paramTypes {
+		params[i] = convertColumnInfo(&ast.ResultField{
+			ColumnAsName: model.NewCIStr("?"),
+			Column:       &model.ColumnInfo{Name: model.NewCIStr("?"), FieldType: *tp},
+		})
 	}
 	tc.stmts[int(stmtID)] = stmt
 	return
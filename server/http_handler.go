@@ -0,0 +1,302 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/juju/errors"
+	"github.com/ngaut/log"
+	"github.com/pingcap/tidb"
+	"github.com/pingcap/tidb/config"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/domain"
+	"github.com/pingcap/tidb/infoschema"
+	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/sessionctx"
+	"github.com/pingcap/tidb/tablecodec"
+	"github.com/pingcap/tidb/util/codec"
+)
+
+const (
+	pTableID = "table_id"
+	pHexKey  = "hexKey"
+)
+
+// Settings that can be changed by the settings handler.
+const (
+	settingLogLevel      = "log_level"
+	settingGCRunInterval = "gc_run_interval"
+)
+
+// settingGlobalVarsVersion is the read-only version of the global variables, see Domain.GlobalVarsVersion.
+const settingGlobalVarsVersion = "global_vars_version"
+
+// The GC run interval is saved in the mysql.tidb table by the GC worker, it's at least 10 minutes.
+const (
+	gcRunIntervalKey = "tikv_gc_run_interval"
+	gcMinRunInterval = 10 * time.Minute
+)
+
+var logLevels = map[string]log.LogLevel{
+	"fatal": log.LOG_LEVEL_FATAL,
+	"error": log.LOG_LEVEL_ERROR,
+	"warn":  log.LOG_LEVEL_WARN,
+	"info":  log.LOG_LEVEL_INFO,
+	"debug": log.LOG_LEVEL_DEBUG,
+}
+
+// schemaHandler is the handler for getting the schemas of the databases and tables.
+type schemaHandler struct {
+	store kv.Storage
+}
+
+// keyHandler is the handler for decoding a key into table, index and row form.
+type keyHandler struct{}
+
+// settingsHandler is the handler for viewing and changing the runtime settings.
+type settingsHandler struct {
+	store kv.Storage
+	cfg   *config.Config
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	w.WriteHeader(http.StatusBadRequest)
+	w.Write([]byte(err.Error()))
+}
+
+func writeData(w http.ResponseWriter, data interface{}) {
+	js, err := json.Marshal(data)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set(headerContentType, contentTypeJSON)
+	w.WriteHeader(http.StatusOK)
+	w.Write(js)
+}
+
+func getDomain(store kv.Storage) (*domain.Domain, error) {
+	session, err := tidb.CreateSession(store)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer session.Close()
+	return sessionctx.GetDomain(session.(context.Context)), nil
+}
+
+func getInfoSchema(store kv.Storage) (infoschema.InfoSchema, error) {
+	dom, err := getDomain(store)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return dom.InfoSchema(), nil
+}
+
+// ServeHTTP handles request of getting the schemas.
+// It returns all the databases without a db name, the tables of the database without a table name,
+// and the table with the table name or the table_id in the form.
+func (h schemaHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	schema, err := getInfoSchema(h.store)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if tableID := req.FormValue(pTableID); len(tableID) > 0 {
+		id, err := strconv.ParseInt(tableID, 0, 64)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		tbl, ok := schema.TableByID(id)
+		if !ok {
+			writeError(w, infoschema.ErrTableNotExists.Gen("table which ID = %d does not exist.", id))
+			return
+		}
+		writeData(w, tbl.Meta())
+		return
+	}
+
+	params := mux.Vars(req)
+	dbName, ok := params[pDBName]
+	if !ok {
+		writeData(w, schema.AllSchemas())
+		return
+	}
+	cDBName := model.NewCIStr(dbName)
+	if tableName, ok := params[pTableName]; ok {
+		tbl, err := schema.TableByName(cDBName, model.NewCIStr(tableName))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeData(w, tbl.Meta())
+		return
+	}
+	if !schema.SchemaExists(cDBName) {
+		writeError(w, infoschema.ErrDatabaseNotExists.GenByArgs(dbName))
+		return
+	}
+	tbls := schema.SchemaTables(cDBName)
+	tblInfos := make([]*model.TableInfo, 0, len(tbls))
+	for _, tbl := range tbls {
+		tblInfos = append(tblInfos, tbl.Meta())
+	}
+	writeData(w, tblInfos)
+}
+
+// KeyDetail is the response data for decoding a key.
+type KeyDetail struct {
+	TableID  int64 `json:"table_id"`
+	IsRecord bool  `json:"is_record"`
+	// Handle is for the record key.
+	Handle int64 `json:"handle,omitempty"`
+	// IndexID and IndexValues are for the index key.
+	IndexID     int64    `json:"index_id,omitempty"`
+	IndexValues []string `json:"index_values,omitempty"`
+}
+
+// ServeHTTP handles request of decoding a key in hex, the key can be a raw key or
+// a memcomparable encoded key like the region keys.
+func (h keyHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	key, err := hex.DecodeString(mux.Vars(req)[pHexKey])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	// A raw key is hardly a valid memcomparable encoded key.
+	if remain, decoded, err := codec.DecodeBytes(key); err == nil && len(remain) == 0 {
+		key = decoded
+	}
+	detail, err := decodeKey(key)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeData(w, detail)
+}
+
+func decodeKey(key kv.Key) (*KeyDetail, error) {
+	tableID, indexID, isRecord, err := tablecodec.DecodeKeyHead(key)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	detail := &KeyDetail{TableID: tableID, IsRecord: isRecord}
+	if isRecord {
+		_, detail.Handle, err = tablecodec.DecodeRecordKey(key)
+		return detail, errors.Trace(err)
+	}
+	detail.IndexID = indexID
+	values, err := tablecodec.DecodeIndexKey(key)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	for _, v := range values {
+		s, err := v.ToString()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		detail.IndexValues = append(detail.IndexValues, s)
+	}
+	return detail, nil
+}
+
+// ServeHTTP handles request of the runtime settings, it changes the settings in the form for
+// a POST request, then returns the current settings.
+func (h settingsHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method == http.MethodPost {
+		if err := req.ParseForm(); err != nil {
+			writeError(w, err)
+			return
+		}
+		if level := req.Form.Get(settingLogLevel); len(level) > 0 {
+			l, ok := logLevels[strings.ToLower(level)]
+			if !ok {
+				writeError(w, errors.Errorf("invalid log level %s", level))
+				return
+			}
+			log.SetLevel(l)
+			h.cfg.LogLevel = strings.ToLower(level)
+		}
+		if interval := req.Form.Get(settingGCRunInterval); len(interval) > 0 {
+			if err := h.setGCRunInterval(interval); err != nil {
+				writeError(w, err)
+				return
+			}
+		}
+	}
+
+	settings := make(map[string]string)
+	for name, l := range logLevels {
+		if l == log.GetLogLevel() {
+			settings[settingLogLevel] = name
+		}
+	}
+	interval, err := h.execSQL(fmt.Sprintf(`SELECT variable_value FROM mysql.tidb WHERE variable_name = '%s'`, gcRunIntervalKey))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	settings[settingGCRunInterval] = interval
+	dom, err := getDomain(h.store)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	settings[settingGlobalVarsVersion] = strconv.FormatInt(dom.GlobalVarsVersion(), 10)
+	writeData(w, settings)
+}
+
+func (h settingsHandler) setGCRunInterval(interval string) error {
+	d, err := time.ParseDuration(interval)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if d < gcMinRunInterval {
+		return errors.Errorf("GC run interval should be at least %v", gcMinRunInterval)
+	}
+	_, err = h.execSQL(fmt.Sprintf(`UPDATE mysql.tidb SET variable_value = '%s' WHERE variable_name = '%s'`, d, gcRunIntervalKey))
+	return errors.Trace(err)
+}
+
+// execSQL executes the sql in a new session, it returns the first column of the first row in the result.
+func (h settingsHandler) execSQL(sql string) (string, error) {
+	se, err := tidb.CreateSession(h.store)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	defer se.Close()
+	rs, err := se.Execute(sql)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	if len(rs) == 0 {
+		return "", nil
+	}
+	defer rs[0].Close()
+	row, err := rs[0].Next()
+	if err != nil || row == nil {
+		return "", errors.Trace(err)
+	}
+	return row.Data[0].ToString()
+}
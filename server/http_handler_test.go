@@ -0,0 +1,224 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/ngaut/log"
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/domain"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/tablecodec"
+	"github.com/pingcap/tidb/util"
+	"github.com/pingcap/tidb/util/codec"
+	"github.com/pingcap/tidb/util/types"
+)
+
+func getJSON(c *C, path string, data interface{}) int {
+	resp, err := http.Get("http://127.0.0.1:10090" + path)
+	c.Assert(err, IsNil)
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK && data != nil {
+		c.Assert(json.NewDecoder(resp.Body).Decode(data), IsNil)
+	}
+	return resp.StatusCode
+}
+
+func (ts *TidbRegionHandlerTestSuite) TestSchemaAPI(c *C) {
+	ts.startServer(c)
+	defer ts.stopServer(c)
+
+	var dbs []*model.DBInfo
+	c.Assert(getJSON(c, "/schema", &dbs), Equals, http.StatusOK)
+	found := false
+	for _, db := range dbs {
+		found = found || db.Name.L == "mysql"
+	}
+	c.Assert(found, IsTrue)
+
+	var tbls []*model.TableInfo
+	c.Assert(getJSON(c, "/schema/mysql", &tbls), Equals, http.StatusOK)
+	c.Assert(len(tbls), Greater, 0)
+
+	var tbl model.TableInfo
+	c.Assert(getJSON(c, "/schema/mysql/user", &tbl), Equals, http.StatusOK)
+	c.Assert(tbl.Name.L, Equals, "user")
+	var tblByID model.TableInfo
+	c.Assert(getJSON(c, fmt.Sprintf("/schema?table_id=%d", tbl.ID), &tblByID), Equals, http.StatusOK)
+	c.Assert(tblByID.Name.L, Equals, "user")
+
+	c.Assert(getJSON(c, "/schema/mysql/xxx", nil), Equals, http.StatusBadRequest)
+	c.Assert(getJSON(c, "/schema/xxx", nil), Equals, http.StatusBadRequest)
+	c.Assert(getJSON(c, "/schema?table_id=1000000", nil), Equals, http.StatusBadRequest)
+}
+
+func (ts *TidbRegionHandlerTestSuite) TestDecodeKeyAPI(c *C) {
+	ts.startServer(c)
+	defer ts.stopServer(c)
+
+	recordKey := tablecodec.EncodeRowKeyWithHandle(5, 10)
+	var detail KeyDetail
+	c.Assert(getJSON(c, "/keys/"+hex.EncodeToString(recordKey), &detail), Equals, http.StatusOK)
+	c.Assert(detail, DeepEquals, KeyDetail{TableID: 5, IsRecord: true, Handle: 10})
+	// The memcomparable encoded key is decoded too.
+	detail = KeyDetail{}
+	c.Assert(getJSON(c, "/keys/"+hex.EncodeToString(codec.EncodeBytes(nil, recordKey)), &detail), Equals, http.StatusOK)
+	c.Assert(detail, DeepEquals, KeyDetail{TableID: 5, IsRecord: true, Handle: 10})
+
+	values, err := codec.EncodeKey(nil, types.NewIntDatum(3), types.NewStringDatum("abc"))
+	c.Assert(err, IsNil)
+	indexKey := tablecodec.EncodeIndexSeekKey(5, 2, values)
+	detail = KeyDetail{}
+	c.Assert(getJSON(c, "/keys/"+hex.EncodeToString(indexKey), &detail), Equals, http.StatusOK)
+	c.Assert(detail, DeepEquals, KeyDetail{TableID: 5, IndexID: 2, IndexValues: []string{"3", "abc"}})
+
+	c.Assert(getJSON(c, "/keys/xyz", nil), Equals, http.StatusBadRequest)
+	c.Assert(getJSON(c, "/keys/"+hex.EncodeToString([]byte("m_abc")), nil), Equals, http.StatusBadRequest)
+}
+
+func (ts *TidbRegionHandlerTestSuite) TestSettingsAPI(c *C) {
+	ts.startServer(c)
+	defer ts.stopServer(c)
+	defer log.SetLevel(log.GetLogLevel())
+
+	postSettings := func(values url.Values) int {
+		resp, err := http.PostForm("http://127.0.0.1:10090/settings", values)
+		c.Assert(err, IsNil)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	c.Assert(postSettings(url.Values{settingLogLevel: {"warn"}}), Equals, http.StatusOK)
+	settings := make(map[string]string)
+	c.Assert(getJSON(c, "/settings", &settings), Equals, http.StatusOK)
+	c.Assert(settings[settingLogLevel], Equals, "warn")
+	c.Assert(log.GetLogLevel(), Equals, log.LOG_LEVEL_WARN)
+
+	c.Assert(postSettings(url.Values{settingLogLevel: {"xxx"}}), Equals, http.StatusBadRequest)
+	c.Assert(postSettings(url.Values{settingGCRunInterval: {"xxx"}}), Equals, http.StatusBadRequest)
+	c.Assert(postSettings(url.Values{settingGCRunInterval: {"1m"}}), Equals, http.StatusBadRequest)
+	c.Assert(postSettings(url.Values{settingGCRunInterval: {"20m"}}), Equals, http.StatusOK)
+
+	// The version of the global variables is increased by SET GLOBAL.
+	version := settings[settingGlobalVarsVersion]
+	h := settingsHandler{store: ts.server.driver.(*TiDBDriver).store}
+	_, err := h.execSQL("set @@global.tidb_index_lookup_size = 1000")
+	c.Assert(err, IsNil)
+	c.Assert(getJSON(c, "/settings", &settings), Equals, http.StatusOK)
+	c.Assert(settings[settingGlobalVarsVersion], Not(Equals), version)
+}
+
+func (ts *TidbRegionHandlerTestSuite) TestPprofAPI(c *C) {
+	ts.startServer(c)
+	defer ts.stopServer(c)
+	c.Assert(getJSON(c, "/debug/pprof/", nil), Equals, http.StatusOK)
+	c.Assert(getJSON(c, "/debug/pprof/goroutine?debug=1", nil), Equals, http.StatusOK)
+}
+
+func (ts *TidbRegionHandlerTestSuite) TestClusterAPI(c *C) {
+	ts.startServer(c)
+	defer ts.stopServer(c)
+
+	// The processes and the slow queries require the token of the registered server.
+	c.Assert(getJSON(c, infoPathProcessList, nil), Equals, http.StatusBadRequest)
+	c.Assert(getJSON(c, infoPathSlowQuery, nil), Equals, http.StatusBadRequest)
+	client := &http.Client{Timeout: clusterRequestTimeout}
+	_, err := fetchServerInfo(client, "http://127.0.0.1:10090"+infoPathProcessList, "")
+	c.Assert(err, ErrorMatches, ".*403 Forbidden.*")
+	cfg := make(map[string]string)
+	c.Assert(getJSON(c, infoPathConfig, &cfg), Equals, http.StatusOK)
+	c.Assert(cfg["status_addr"], Equals, ":10090")
+	var hotRegions []util.HotRegionStat
+	c.Assert(getJSON(c, infoPathHotRegions, &hotRegions), Equals, http.StatusOK)
+
+	// The infos of this server are returned before it's registered.
+	configs, errs := ts.server.ClusterConfig()
+	c.Assert(errs, HasLen, 0)
+	c.Assert(configs[":4001"]["addr"], Equals, ":4001")
+
+	dom, err := getDomain(ts.server.driver.(*TiDBDriver).store)
+	c.Assert(err, IsNil)
+	err = dom.RegisterServerInfo(&domain.ServerInfo{IP: "127.0.0.1", Port: 4001, StatusPort: 10090})
+	c.Assert(err, IsNil)
+	token := dom.ServerInfo().Token
+	dom.LogSlowQuery(util.SlowQueryInfo{SQL: "select 1", ConnID: 1})
+	queries, errs := ts.server.ClusterSlowQueries()
+	c.Assert(errs, HasLen, 0)
+	c.Assert(queries, HasLen, 1)
+	c.Assert(queries["127.0.0.1:4001"], HasLen, 1)
+	c.Assert(queries["127.0.0.1:4001"][0].SQL, Equals, "select 1")
+	_, errs = ts.server.ClusterProcessList()
+	c.Assert(errs, HasLen, 0)
+	_, errs = ts.server.ClusterHotRegions()
+	c.Assert(errs, HasLen, 0)
+
+	// The infos of the other servers are requested from their status servers.
+	js, err := fetchServerInfo(client, "http://127.0.0.1:10090"+infoPathConfig, token)
+	c.Assert(err, IsNil)
+	c.Assert(json.Unmarshal(js, &cfg), IsNil)
+	c.Assert(cfg["addr"], Equals, ":4001")
+	js, err = fetchServerInfo(client, "http://127.0.0.1:10090"+infoPathSlowQuery, token)
+	c.Assert(err, IsNil)
+	var slowQueries []util.SlowQueryInfo
+	c.Assert(json.Unmarshal(js, &slowQueries), IsNil)
+	c.Assert(slowQueries, HasLen, 1)
+	var processes []util.ProcessInfo
+	js, err = fetchServerInfo(client, "http://127.0.0.1:10090"+infoPathProcessList, token)
+	c.Assert(err, IsNil)
+	c.Assert(json.Unmarshal(js, &processes), IsNil)
+	_, err = fetchServerInfo(client, "http://127.0.0.1:10090"+infoPathProcessList, "xxx")
+	c.Assert(err, NotNil)
+	_, err = fetchServerInfo(client, "http://127.0.0.1:10090/info/xxx", token)
+	c.Assert(err, NotNil)
+}
+
+func (ts *TidbRegionHandlerTestSuite) TestKillAPI(c *C) {
+	ts.startServer(c)
+	defer ts.stopServer(c)
+
+	// The connection IDs contain the server ID after the server is registered.
+	c.Assert(ts.server.nextConnID()>>localConnIDBits, Equals, uint32(0))
+	ts.server.SetServerID(3)
+	c.Assert(ts.server.nextConnID()>>localConnIDBits, Equals, uint32(3))
+
+	postKill := func(values url.Values) int {
+		resp, err := http.PostForm("http://127.0.0.1:10090"+infoPathKill, values)
+		c.Assert(err, IsNil)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	c.Assert(getJSON(c, infoPathKill, nil), Equals, http.StatusBadRequest)
+	// The request without the token of the registered server is rejected.
+	c.Assert(postKill(url.Values{"id": {"1"}, "query": {"true"}}), Equals, http.StatusForbidden)
+
+	dom, err := getDomain(ts.server.driver.(*TiDBDriver).store)
+	c.Assert(err, IsNil)
+	err = dom.RegisterServerInfo(&domain.ServerInfo{IP: "127.0.0.1", Port: 4001, StatusPort: 10090, ServerID: 5})
+	c.Assert(err, IsNil)
+	token := dom.ServerInfo().Token
+	c.Assert(token, HasLen, 32)
+	c.Assert(postKill(url.Values{"id": {"1"}, "query": {"true"}}), Equals, http.StatusForbidden)
+	c.Assert(postKill(url.Values{"id": {"1"}, "query": {"true"}, "token": {"xxx"}}), Equals, http.StatusForbidden)
+	c.Assert(postKill(url.Values{"id": {"1"}, "query": {"true"}, "token": {token}}), Equals, http.StatusOK)
+	c.Assert(postKill(url.Values{"id": {"xxx"}, "query": {"true"}, "token": {token}}), Equals, http.StatusBadRequest)
+	c.Assert(postKill(url.Values{"id": {"1"}, "token": {token}}), Equals, http.StatusBadRequest)
+
+	// The connection on another server is killed by the status API of that server.
+	c.Assert(ts.server.killRemote(5<<localConnIDBits|1, false), IsNil)
+	c.Assert(ts.server.killRemote(6<<localConnIDBits|1, false), NotNil)
+}
@@ -16,7 +16,7 @@ package server
 import (
 	"encoding/json"
 	"net/http"
-	"sync"
+	"net/http/pprof"
 
 	"github.com/gorilla/mux"
 	"github.com/ngaut/log"
@@ -25,21 +25,64 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 )
 
-var once sync.Once
-
-const defaultStatusAddr = ":10080"
+const (
+	defaultStatusAddr  = ":10080"
+	defaultMetricsPath = "/metrics"
+)
 
+// startStatusHTTP starts the status HTTP server, it's closed when the server is closed.
 func (s *Server) startStatusHTTP() {
-	once.Do(func() {
-		go s.startHTTPServer()
-	})
+	addr := s.cfg.StatusAddr
+	if len(addr) == 0 {
+		addr = defaultStatusAddr
+	}
+	serverMux := http.NewServeMux()
+	serverMux.Handle("/", s.newStatusRouter())
+	statusServer := &http.Server{Addr: addr, Handler: serverMux}
+	s.rwlock.Lock()
+	s.statusServer = statusServer
+	s.rwlock.Unlock()
+
+	go func() {
+		log.Infof("Listening on %v for status and metrics report.", addr)
+		err := statusServer.ListenAndServe()
+		if err != nil && err != http.ErrServerClosed {
+			log.Fatal(err)
+		}
+	}()
 }
 
-func (s *Server) startHTTPServer() {
+func (s *Server) newStatusRouter() *mux.Router {
 	router := mux.NewRouter()
 	router.HandleFunc("/status", s.handleStatus)
 	// HTTP path for prometheus.
-	router.Handle("/metrics", prometheus.Handler())
+	metricsPath := s.cfg.MetricsPath
+	if len(metricsPath) == 0 {
+		metricsPath = defaultMetricsPath
+	}
+	router.Handle(metricsPath, prometheus.Handler())
+
+	router.Handle("/keys/{hexKey}", keyHandler{})
+	if drv, ok := s.driver.(*TiDBDriver); ok {
+		// HTTP path for schemas and settings
+		router.Handle("/schema", schemaHandler{drv.store})
+		router.Handle("/schema/{db}", schemaHandler{drv.store})
+		router.Handle("/schema/{db}/{table}", schemaHandler{drv.store})
+		router.Handle("/settings", settingsHandler{drv.store, s.cfg})
+		// HTTP path for the infos requested by the other servers in the cluster
+		router.Handle(infoPathProcessList, infoHandler{s, drv.store, infoPathProcessList})
+		router.Handle(infoPathSlowQuery, infoHandler{s, drv.store, infoPathSlowQuery})
+		router.Handle(infoPathConfig, infoHandler{s, drv.store, infoPathConfig})
+		router.Handle(infoPathHotRegions, infoHandler{s, drv.store, infoPathHotRegions})
+		router.Handle(infoPathKill, infoHandler{s, drv.store, infoPathKill})
+	}
+	if s.cfg.EnablePprof {
+		router.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		router.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		router.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		router.HandleFunc("/debug/pprof/trace", pprof.Trace)
+		router.PathPrefix("/debug/pprof/").HandlerFunc(pprof.Index)
+	}
 
 	if s.cfg.Store == "tikv" {
 		tikvHandler := s.newRegionHandler()
@@ -50,16 +93,7 @@ func (s *Server) startHTTPServer() {
 		router.Handle("/mvcc/txn/{startTS}/{db}/{table}", mvccTxnHandler{tikvHandler, opMvccGetByTxn})
 		router.Handle("/mvcc/txn/{startTS}", mvccTxnHandler{tikvHandler, opMvccGetByTxn})
 	}
-	addr := s.cfg.StatusAddr
-	if len(addr) == 0 {
-		addr = defaultStatusAddr
-	}
-	log.Infof("Listening on %v for status and metrics report.", addr)
-	http.Handle("/", router)
-	err := http.ListenAndServe(addr, nil)
-	if err != nil {
-		log.Fatal(err)
-	}
+	return router
 }
 
 // TiDB status
@@ -22,14 +22,14 @@ import (
 )
 
 var (
-	queryHistogram = prometheus.NewHistogram(
+	queryHistogram = prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Namespace: "tidb",
 			Subsystem: "server",
 			Name:      "handle_query_duration_seconds",
-			Help:      "Bucketed histogram of processing time (s) of handled queries.",
+			Help:      "Bucketed histogram of processing time (s) of handled queries by command type.",
 			Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 22),
-		})
+		}, []string{"type"})
 
 	queryCounter = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
@@ -47,6 +47,14 @@ var (
 			Help:      "Number of connections.",
 		})
 
+	rejectedConnCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "tidb",
+			Subsystem: "server",
+			Name:      "rejected_connections",
+			Help:      "Counter of connections rejected by the connection limits.",
+		}, []string{"type"})
+
 	executeErrorCounter = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Namespace: "tidb",
@@ -68,6 +76,8 @@ func init() {
 	prometheus.MustRegister(queryHistogram)
 	prometheus.MustRegister(queryCounter)
 	prometheus.MustRegister(connGauge)
+	prometheus.MustRegister(rejectedConnCounter)
+	prometheus.MustRegister(executeErrorCounter)
 	prometheus.MustRegister(criticalErrorCounter)
 }
 
@@ -0,0 +1,222 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+)
+
+// The PROXY protocol lets a proxy like HAProxy pass the address of the real client in a header sent before the
+// MySQL protocol data. See https://www.haproxy.org/download/1.8/doc/proxy-protocol.txt
+var (
+	proxyV1Prefix    = []byte("PROXY ")
+	proxyV2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+)
+
+const (
+	// proxyV1MaxLen is the max length of a v1 header including the "\r\n".
+	proxyV1MaxLen = 107
+	// proxyHeaderTimeout is the max time to read the PROXY header of a connection.
+	proxyHeaderTimeout = 5 * time.Second
+)
+
+var errInvalidProxyHeader = errors.New("invalid PROXY protocol header")
+
+// parseProxyNetworks parses the comma separated networks allowed to send the PROXY header, like
+// "192.168.1.0/24,10.0.0.1", "*" allows all the networks.
+func parseProxyNetworks(s string) ([]*net.IPNet, error) {
+	var networks []*net.IPNet
+	for _, str := range strings.Split(s, ",") {
+		str = strings.TrimSpace(str)
+		if str == "" {
+			continue
+		}
+		if str == "*" {
+			_, v4, _ := net.ParseCIDR("0.0.0.0/0")
+			_, v6, _ := net.ParseCIDR("::/0")
+			networks = append(networks, v4, v6)
+			continue
+		}
+		if !strings.Contains(str, "/") {
+			ip := net.ParseIP(str)
+			if ip == nil {
+				return nil, errors.Errorf("invalid proxy protocol network %s", str)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(str)
+		if err != nil {
+			return nil, errors.Errorf("invalid proxy protocol network %s", str)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// proxyConn is a connection whose remote address is the client address in the PROXY header.
+type proxyConn struct {
+	net.Conn
+	reader     *bufio.Reader
+	remoteAddr net.Addr
+}
+
+// Read implements the net.Conn Read interface, the data buffered when reading the header is read first.
+func (c *proxyConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
+
+// RemoteAddr implements the net.Conn RemoteAddr interface.
+func (c *proxyConn) RemoteAddr() net.Addr {
+	return c.remoteAddr
+}
+
+// unwrapConn returns the connection under a proxyConn, the other connections are returned as they are.
+func unwrapConn(conn net.Conn) net.Conn {
+	if pc, ok := conn.(*proxyConn); ok {
+		return pc.Conn
+	}
+	return conn
+}
+
+// isTrustedProxy returns whether the connection comes from a network allowed to send the PROXY header.
+func (s *Server) isTrustedProxy(addr net.Addr) bool {
+	tcpAddr, ok := addr.(*net.TCPAddr)
+	if !ok {
+		return false
+	}
+	for _, network := range s.proxyNetworks {
+		if network.Contains(tcpAddr.IP) {
+			return true
+		}
+	}
+	return false
+}
+
+// wrapProxyConn reads the PROXY header of a connection from the trusted proxies, and returns the connection which
+// reports the real client address. The connections from the other networks are returned as they are.
+func (s *Server) wrapProxyConn(conn net.Conn) (net.Conn, error) {
+	if !s.isTrustedProxy(conn.RemoteAddr()) {
+		return conn, nil
+	}
+	if err := conn.SetReadDeadline(time.Now().Add(proxyHeaderTimeout)); err != nil {
+		return nil, errors.Trace(err)
+	}
+	reader := bufio.NewReader(conn)
+	addr, err := readProxyHeader(reader)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if err = conn.SetReadDeadline(time.Time{}); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if addr == nil {
+		// The proxy sends the header of its own connections like health checks without the client address.
+		addr = conn.RemoteAddr()
+	}
+	return &proxyConn{Conn: conn, reader: reader, remoteAddr: addr}, nil
+}
+
+// readProxyHeader reads a v1 or v2 PROXY header, it returns nil address if the header has no client address.
+func readProxyHeader(r *bufio.Reader) (net.Addr, error) {
+	prefix, err := r.Peek(len(proxyV1Prefix))
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if bytes.Equal(prefix, proxyV1Prefix) {
+		return readProxyV1Header(r)
+	}
+	return readProxyV2Header(r)
+}
+
+// readProxyV1Header reads the header like "PROXY TCP4 192.168.0.1 192.168.0.11 56324 4000\r\n".
+func readProxyV1Header(r *bufio.Reader) (net.Addr, error) {
+	var line []byte
+	for !bytes.HasSuffix(line, []byte("\r\n")) {
+		if len(line) >= proxyV1MaxLen {
+			return nil, errInvalidProxyHeader
+		}
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		line = append(line, b)
+	}
+	fields := strings.Split(string(line[:len(line)-2]), " ")
+	if len(fields) >= 2 && fields[1] == "UNKNOWN" {
+		return nil, nil
+	}
+	if len(fields) != 6 || (fields[1] != "TCP4" && fields[1] != "TCP6") {
+		return nil, errInvalidProxyHeader
+	}
+	ip := net.ParseIP(fields[2])
+	port, err := strconv.ParseUint(fields[4], 10, 16)
+	if ip == nil || err != nil {
+		return nil, errInvalidProxyHeader
+	}
+	return &net.TCPAddr{IP: ip, Port: int(port)}, nil
+}
+
+// readProxyV2Header reads the binary header which starts with the v2 signature.
+func readProxyV2Header(r *bufio.Reader) (net.Addr, error) {
+	header := make([]byte, len(proxyV2Signature)+4)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if !bytes.Equal(header[:len(proxyV2Signature)], proxyV2Signature) {
+		return nil, errInvalidProxyHeader
+	}
+	verCmd, family := header[12], header[13]
+	if verCmd>>4 != 2 {
+		return nil, errInvalidProxyHeader
+	}
+	payload := make([]byte, binary.BigEndian.Uint16(header[14:]))
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, errors.Trace(err)
+	}
+	// The LOCAL command is sent by the proxy for its own connections.
+	if verCmd&0xf == 0 {
+		return nil, nil
+	}
+	var ipLen int
+	switch family >> 4 {
+	case 1: // AF_INET
+		ipLen = net.IPv4len
+	case 2: // AF_INET6
+		ipLen = net.IPv6len
+	default:
+		// The unix sockets and the unspecified families have no client IP.
+		return nil, nil
+	}
+	// The payload has the source address, the destination address, the source port and the destination port.
+	if len(payload) < 2*ipLen+4 {
+		return nil, errInvalidProxyHeader
+	}
+	ip := net.IP(payload[:ipLen])
+	port := binary.BigEndian.Uint16(payload[2*ipLen:])
+	return &net.TCPAddr{IP: ip, Port: int(port)}, nil
+}
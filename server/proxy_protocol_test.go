@@ -0,0 +1,135 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"net"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/util/testleak"
+)
+
+var _ = Suite(&testProxyProtocolSuite{})
+
+type testProxyProtocolSuite struct {
+}
+
+func (s *testProxyProtocolSuite) TestParseProxyNetworks(c *C) {
+	defer testleak.AfterTest(c)()
+	networks, err := parseProxyNetworks("")
+	c.Assert(err, IsNil)
+	c.Assert(networks, HasLen, 0)
+
+	networks, err = parseProxyNetworks("192.168.1.0/24, 10.0.0.1,::1")
+	c.Assert(err, IsNil)
+	srv := &Server{proxyNetworks: networks}
+	c.Assert(srv.isTrustedProxy(&net.TCPAddr{IP: net.ParseIP("192.168.1.20")}), IsTrue)
+	c.Assert(srv.isTrustedProxy(&net.TCPAddr{IP: net.ParseIP("10.0.0.1")}), IsTrue)
+	c.Assert(srv.isTrustedProxy(&net.TCPAddr{IP: net.ParseIP("::1")}), IsTrue)
+	c.Assert(srv.isTrustedProxy(&net.TCPAddr{IP: net.ParseIP("10.0.0.2")}), IsFalse)
+	c.Assert(srv.isTrustedProxy(&net.UnixAddr{Name: "/tmp/tidb.sock", Net: "unix"}), IsFalse)
+
+	networks, err = parseProxyNetworks("*")
+	c.Assert(err, IsNil)
+	srv = &Server{proxyNetworks: networks}
+	c.Assert(srv.isTrustedProxy(&net.TCPAddr{IP: net.ParseIP("172.16.0.1")}), IsTrue)
+	c.Assert(srv.isTrustedProxy(&net.TCPAddr{IP: net.ParseIP("fe80::1")}), IsTrue)
+
+	_, err = parseProxyNetworks("192.168.1.0/33")
+	c.Assert(err, NotNil)
+	_, err = parseProxyNetworks("not-an-ip")
+	c.Assert(err, NotNil)
+}
+
+func (s *testProxyProtocolSuite) TestReadProxyHeader(c *C) {
+	defer testleak.AfterTest(c)()
+	v2Header := func(verCmd, family byte, payload []byte) []byte {
+		b := append([]byte{}, proxyV2Signature...)
+		b = append(b, verCmd, family, byte(len(payload)>>8), byte(len(payload)))
+		return append(b, payload...)
+	}
+	v4Payload := []byte{192, 168, 0, 1, 192, 168, 0, 11, 0xdc, 0x04, 0x0f, 0xa0}
+	v6Payload := append(append(net.ParseIP("2001:db8::1").To16(), net.ParseIP("2001:db8::2").To16()...), 0xdc, 0x04, 0x0f, 0xa0)
+	tests := []struct {
+		header []byte
+		addr   string
+		err    bool
+	}{
+		{[]byte("PROXY TCP4 192.168.0.1 192.168.0.11 56324 4000\r\n"), "192.168.0.1:56324", false},
+		{[]byte("PROXY TCP6 2001:db8::1 2001:db8::2 56324 4000\r\n"), "[2001:db8::1]:56324", false},
+		{[]byte("PROXY UNKNOWN\r\n"), "", false},
+		{[]byte("PROXY TCP4 192.168.0.1 192.168.0.11\r\n"), "", true},
+		{[]byte("PROXY TCP4 abc 192.168.0.11 56324 4000\r\n"), "", true},
+		{append([]byte("PROXY "), bytes.Repeat([]byte("x"), 200)...), "", true},
+		{v2Header(0x21, 0x11, v4Payload), "192.168.0.1:56324", false},
+		{v2Header(0x21, 0x21, v6Payload), "[2001:db8::1]:56324", false},
+		{v2Header(0x20, 0x00, nil), "", false},
+		{v2Header(0x21, 0x31, make([]byte, 216)), "", false},
+		{v2Header(0x21, 0x11, v4Payload[:8]), "", true},
+		{v2Header(0x11, 0x11, v4Payload), "", true},
+		{[]byte("\x0a\x00\x00\x00\x0a5.7.1-TiDB\x00"), "", true},
+	}
+	for _, t := range tests {
+		// The data after the header is kept for the MySQL protocol.
+		r := bufio.NewReader(bytes.NewReader(append(t.header, "data"...)))
+		addr, err := readProxyHeader(r)
+		if t.err {
+			c.Assert(err, NotNil, Commentf("%q", t.header))
+			continue
+		}
+		c.Assert(err, IsNil, Commentf("%q", t.header))
+		if t.addr == "" {
+			c.Assert(addr, IsNil)
+		} else {
+			c.Assert(addr.String(), Equals, t.addr)
+		}
+		rest, err := ioutil.ReadAll(r)
+		c.Assert(err, IsNil)
+		c.Assert(string(rest), Equals, "data")
+	}
+}
+
+func (s *testProxyProtocolSuite) TestWrapProxyConn(c *C) {
+	defer testleak.AfterTest(c)()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, IsNil)
+	defer ln.Close()
+	client, err := net.Dial("tcp", ln.Addr().String())
+	c.Assert(err, IsNil)
+	defer client.Close()
+	conn, err := ln.Accept()
+	c.Assert(err, IsNil)
+	defer conn.Close()
+
+	_, err = client.Write([]byte("PROXY TCP4 192.168.0.1 192.168.0.11 56324 4000\r\ndata"))
+	c.Assert(err, IsNil)
+	networks, err := parseProxyNetworks("127.0.0.1")
+	c.Assert(err, IsNil)
+	srv := &Server{proxyNetworks: networks}
+	pc, err := srv.wrapProxyConn(conn)
+	c.Assert(err, IsNil)
+	c.Assert(pc.RemoteAddr().String(), Equals, "192.168.0.1:56324")
+	buf := make([]byte, 4)
+	_, err = pc.Read(buf)
+	c.Assert(err, IsNil)
+	c.Assert(string(buf), Equals, "data")
+	// The keep alive option is set on the TCP connection under it.
+	tcpConn, ok := unwrapConn(pc).(*net.TCPConn)
+	c.Assert(ok, IsTrue)
+	c.Assert(tcpConn, Equals, conn)
+	c.Assert(unwrapConn(conn), Equals, conn)
+}
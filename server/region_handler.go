@@ -15,7 +15,6 @@ package server
 
 import (
 	"bytes"
-	"encoding/json"
 	"math"
 	"net/http"
 	"strconv"
@@ -26,12 +25,9 @@ import (
 	"github.com/ngaut/log"
 	"github.com/pingcap/kvproto/pkg/kvrpcpb"
 	"github.com/pingcap/kvproto/pkg/metapb"
-	"github.com/pingcap/tidb"
-	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/infoschema"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/model"
-	"github.com/pingcap/tidb/sessionctx"
 	"github.com/pingcap/tidb/store/tikv"
 	"github.com/pingcap/tidb/store/tikv/tikvrpc"
 	"github.com/pingcap/tidb/tablecodec"
@@ -323,7 +319,7 @@ func (rh regionHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	// get from table's ID directly. Above all, here do dot process like
 	// 		`for id in [frameRange.firstTableID,frameRange.endTableID]`
 	// on [frameRange.firstTableID,frameRange.endTableID] is small enough.
-	for _, db := range schema.AllSchemas() {
+	for _, db := range infoschema.AllSchemasWithTables(schema) {
 		for _, table := range db.Tables {
 			start, end := frameRange.getIndexRangeForTable(table.ID)
 			regionDetail.addTableInRange(db.Name.String(), table, start, end)
@@ -333,21 +329,11 @@ func (rh regionHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 }
 
 func (rh *regionHandler) writeError(w http.ResponseWriter, err error) {
-	w.WriteHeader(http.StatusBadRequest)
-	w.Write([]byte(err.Error()))
+	writeError(w, err)
 }
 
 func (rh *regionHandler) writeData(w http.ResponseWriter, data interface{}) {
-	js, err := json.Marshal(data)
-	if err != nil {
-		rh.writeError(w, err)
-		return
-	}
-	log.Info(string(js))
-	// write response
-	w.Header().Set(headerContentType, contentTypeJSON)
-	w.WriteHeader(http.StatusOK)
-	w.Write(js)
+	writeData(w, data)
 }
 
 // NewFrameItemFromRegionKey creates a FrameItem with region's startKey or endKey,
@@ -611,10 +597,5 @@ func (t *regionHandlerTool) getTableID(dbName, tableName string) (int64, error)
 }
 
 func (t *regionHandlerTool) schema() (infoschema.InfoSchema, error) {
-	session, err := tidb.CreateSession(t.store.(kv.Storage))
-	if err != nil {
-		err = errors.Trace(err)
-		return nil, err
-	}
-	return sessionctx.GetDomain(session.(context.Context)).InfoSchema(), nil
+	return getInfoSchema(t.store.(kv.Storage))
 }
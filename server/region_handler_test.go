@@ -184,6 +184,7 @@ func (ts *TidbRegionHandlerTestSuite) startServer(c *C) {
 		StatusAddr:   ":10090",
 		ReportStatus: true,
 		Store:        "tikv",
+		EnablePprof:  true,
 	}
 
 	server, err := NewServer(cfg, tidbdrv)
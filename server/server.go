@@ -31,11 +31,10 @@ package server
 import (
 	"math/rand"
 	"net"
+	"net/http"
 	"sync"
 	"sync/atomic"
 	"time"
-	// For pprof
-	_ "net/http/pprof"
 
 	"github.com/juju/errors"
 	"github.com/ngaut/log"
@@ -44,6 +43,8 @@ import (
 	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util"
 	"github.com/pingcap/tidb/util/arena"
+	"github.com/pingcap/tidb/util/audit"
+	"github.com/pingcap/tidb/util/resource"
 )
 
 var (
@@ -57,6 +58,8 @@ var (
 	errInvalidType       = terror.ClassServer.New(codeInvalidType, "invalid type")
 	errNotAllowedCommand = terror.ClassServer.New(codeNotAllowedCommand, "the used command is not allowed with this TiDB version")
 	errAccessDenied      = terror.ClassServer.New(codeAccessDenied, mysql.MySQLErrName[mysql.ErrAccessDenied])
+	errConCount          = terror.ClassServer.New(codeConCount, mysql.MySQLErrName[mysql.ErrConCount])
+	errTooManyUserConns  = terror.ClassServer.New(codeTooManyUserConns, mysql.MySQLErrName[mysql.ErrTooManyUserConnections])
 )
 
 // Server is the MySQL protocol server
@@ -67,6 +70,17 @@ type Server struct {
 	rwlock            *sync.RWMutex
 	concurrentLimiter *TokenLimiter
 	clients           map[uint32]*clientConn
+	statusServer      *http.Server
+	// userConns is the number of the connections of each account, it's protected by rwlock.
+	userConns map[string]int
+	// proxyNetworks are the networks of the proxies allowed to send the PROXY protocol header.
+	proxyNetworks []*net.IPNet
+	// resourceGroups limits the concurrent statements of the resource groups.
+	resourceGroups *resource.Groups
+	// serverID is the server ID allocated in the cluster, it's 0 if the server isn't registered.
+	serverID uint32
+	// draining is 1 when the server is shutting down gracefully, it's accessed atomically.
+	draining int32
 
 	// When a critical error occurred, we don't want to exit the process, because there may be
 	// a supervisor automatically restart it, then new client connection will be created, but we can't server it.
@@ -111,13 +125,14 @@ func (s *Server) newConn(conn net.Conn) *clientConn {
 		conn:         conn,
 		pkt:          newPacketIO(conn),
 		server:       s,
-		connectionID: atomic.AddUint32(&baseConnID, 1),
+		connectionID: s.nextConnID(),
 		collation:    mysql.DefaultCollationID,
 		alloc:        arena.NewAllocator(32 * 1024),
 	}
 	log.Infof("[%d] new connection %s", cc.connectionID, conn.RemoteAddr().String())
 	if s.cfg.TCPKeepAlive {
-		if tcpConn, ok := conn.(*net.TCPConn); ok {
+		// The option is set on the TCP connection under the PROXY protocol connection.
+		if tcpConn, ok := unwrapConn(conn).(*net.TCPConn); ok {
 			if err := tcpConn.SetKeepAlive(true); err != nil {
 				log.Error("failed to set tcp keep alive option:", err)
 			}
@@ -141,10 +156,19 @@ func NewServer(cfg *config.Config, driver IDriver) (*Server, error) {
 		concurrentLimiter: NewTokenLimiter(tokenLimit),
 		rwlock:            &sync.RWMutex{},
 		clients:           make(map[uint32]*clientConn),
+		userConns:         make(map[string]int),
 		stopListenerCh:    make(chan struct{}, 1),
 	}
 
 	var err error
+	s.proxyNetworks, err = parseProxyNetworks(cfg.ProxyProtocolNetworks)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	s.resourceGroups, err = resource.ParseGroups(cfg.ResourceGroups)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
 	if cfg.Socket != "" {
 		cfg.SkipAuth = true
 		s.listener, err = net.Listen("unix", cfg.Socket)
@@ -210,10 +234,73 @@ func (s *Server) Close() {
 		s.listener.Close()
 		s.listener = nil
 	}
+	if s.statusServer != nil {
+		s.statusServer.Close()
+		s.statusServer = nil
+	}
+}
+
+// drainCheckInterval is the interval of closing the idle connections and checking whether all the connections
+// are closed when the server is shutting down gracefully.
+const drainCheckInterval = 100 * time.Millisecond
+
+// GracefulClose stops accepting new connections and closes the server, then waits the connections to finish
+// their transactions for the drain timeout in the config. A connection is closed once it's idle out of a
+// transaction, the connections left after the timeout are killed.
+func (s *Server) GracefulClose() {
+	atomic.StoreInt32(&s.draining, 1)
+	s.Close()
+	deadline := time.Now().Add(time.Duration(s.cfg.DrainTimeout) * time.Second)
+	for {
+		s.closeIdleConns()
+		if s.ConnectionCount() == 0 {
+			return
+		}
+		if !time.Now().Before(deadline) {
+			break
+		}
+		time.Sleep(drainCheckInterval)
+	}
+
+	s.rwlock.Lock()
+	defer s.rwlock.Unlock()
+	log.Warnf("%d connections are killed after the drain timeout %ds", len(s.clients), s.cfg.DrainTimeout)
+	for _, conn := range s.clients {
+		conn.ctx.Cancel()
+		conn.killed = true
+		conn.conn.Close()
+	}
+}
+
+func (s *Server) isDraining() bool {
+	return atomic.LoadInt32(&s.draining) == 1
+}
+
+// closeIdleConns closes the connections which are reading the next command out of a transaction.
+func (s *Server) closeIdleConns() {
+	s.rwlock.RLock()
+	defer s.rwlock.RUnlock()
+	for _, conn := range s.clients {
+		if atomic.LoadInt32(&conn.status) != connStatusReading || conn.inTxn() {
+			continue
+		}
+		if atomic.CompareAndSwapInt32(&conn.status, connStatusReading, connStatusShutdown) {
+			conn.conn.Close()
+		}
+	}
 }
 
 // onConn runs in its own goroutine, handles queries from this connection.
 func (s *Server) onConn(c net.Conn) {
+	if len(s.proxyNetworks) > 0 {
+		pc, err := s.wrapProxyConn(c)
+		if err != nil {
+			log.Warnf("read PROXY protocol header from %s error %v", c.RemoteAddr(), errors.ErrorStack(err))
+			c.Close()
+			return
+		}
+		c = pc
+	}
 	conn := s.newConn(c)
 	defer func() {
 		log.Infof("[%d] close connection", conn.connectionID)
@@ -223,17 +310,91 @@ func (s *Server) onConn(c net.Conn) {
 		// Some keep alive services will send request to TiDB and disconnect immediately.
 		// So we use info log level.
 		log.Infof("handshake error %s", errors.ErrorStack(err))
+		if conn.user != "" {
+			// The client failed to log in.
+			conn.audit(audit.EventConnect, err)
+		}
+		s.unregisterConn(conn)
 		c.Close()
 		return
 	}
+	conn.audit(audit.EventConnect, nil)
+
+	conn.Run()
+}
+
+// connWaitCheckInterval is the interval of checking whether a waiting connection can be registered.
+const connWaitCheckInterval = 100 * time.Millisecond
+
+// registerConn adds the authenticated connection to the clients of the server. If the server or the account has
+// reached its max connections, the connection waits for a free slot for the connection wait timeout in the config,
+// and it's rejected after the timeout.
+func (s *Server) registerConn(cc *clientConn) error {
+	account, maxConns, limits, err := cc.ctx.ConnectionLimits()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	cc.account = account
+	cc.resourceGroup = limits.Group
+	if account != "" {
+		err = resource.GlobalTracker.Consume(account, resource.Connections, limits.MaxConnectionsPerHour)
+		if err != nil {
+			rejectedConnCounter.WithLabelValues("max_connections_per_hour").Inc()
+			return errors.Trace(err)
+		}
+	}
+	deadline := time.Now().Add(time.Duration(s.cfg.ConnectionWait) * time.Second)
+	for {
+		err = s.tryRegisterConn(cc, maxConns, limits.MaxUserConnections)
+		if err == nil || !time.Now().Before(deadline) {
+			break
+		}
+		time.Sleep(connWaitCheckInterval)
+	}
+	if err != nil {
+		if errConCount.Equal(err) {
+			rejectedConnCounter.WithLabelValues("max_connections").Inc()
+		} else {
+			rejectedConnCounter.WithLabelValues("max_user_connections").Inc()
+		}
+		log.Warnf("[%d] reject the connection of %s: %v", cc.connectionID, account, err)
+	}
+	return errors.Trace(err)
+}
 
+func (s *Server) tryRegisterConn(cc *clientConn, maxConns, maxUserConns int64) error {
 	s.rwlock.Lock()
-	s.clients[conn.connectionID] = conn
-	connections := len(s.clients)
-	s.rwlock.Unlock()
-	connGauge.Set(float64(connections))
+	defer s.rwlock.Unlock()
+	if maxConns > 0 && int64(len(s.clients)) >= maxConns {
+		return errConCount
+	}
+	if cc.account != "" {
+		if maxUserConns > 0 && int64(s.userConns[cc.account]) >= maxUserConns {
+			return errTooManyUserConns.GenByArgs(cc.user)
+		}
+		s.userConns[cc.account]++
+	}
+	s.clients[cc.connectionID] = cc
+	connGauge.Set(float64(len(s.clients)))
+	return nil
+}
 
-	conn.Run()
+// unregisterConn removes the connection from the clients of the server, it does nothing if the connection isn't
+// registered.
+func (s *Server) unregisterConn(cc *clientConn) {
+	s.rwlock.Lock()
+	defer s.rwlock.Unlock()
+	if _, ok := s.clients[cc.connectionID]; !ok {
+		return
+	}
+	delete(s.clients, cc.connectionID)
+	if cc.account != "" {
+		s.userConns[cc.account]--
+		if s.userConns[cc.account] <= 0 {
+			delete(s.userConns, cc.account)
+		}
+	}
+	connGauge.Set(float64(len(s.clients)))
 }
 
 // ShowProcessList implements the SessionManager interface.
@@ -251,19 +412,34 @@ func (s *Server) ShowProcessList() []util.ProcessInfo {
 }
 
 // Kill implements the SessionManager interface.
+// The connection on another server in the cluster is killed by the status API of that server,
+// the server is found by the server ID in the connection ID.
 func (s *Server) Kill(connectionID uint64, query bool) {
+	serverID := atomic.LoadUint32(&s.serverID)
+	if serverID == 0 || connectionID>>localConnIDBits == uint64(serverID) {
+		s.killLocal(connectionID, query)
+		return
+	}
+	if err := s.killRemote(connectionID, query); err != nil {
+		log.Warnf("[%d] kill connection on another server failed: %v", connectionID, errors.ErrorStack(err))
+	}
+}
+
+// killLocal kills the connection of this server, it returns false if the connection doesn't exist.
+func (s *Server) killLocal(connectionID uint64, query bool) bool {
 	s.rwlock.Lock()
 	defer s.rwlock.Unlock()
 
 	conn, ok := s.clients[uint32(connectionID)]
 	if !ok {
-		return
+		return false
 	}
 
 	conn.ctx.Cancel()
 	if !query {
 		conn.killed = true
 	}
+	return true
 }
 
 // Server error codes.
@@ -275,12 +451,16 @@ const (
 
 	codeNotAllowedCommand = 1148
 	codeAccessDenied      = mysql.ErrAccessDenied
+	codeConCount          = mysql.ErrConCount
+	codeTooManyUserConns  = mysql.ErrTooManyUserConnections
 )
 
 func init() {
 	serverMySQLErrCodes := map[terror.ErrCode]uint16{
 		codeNotAllowedCommand: mysql.ErrNotAllowedCommand,
 		codeAccessDenied:      mysql.ErrAccessDenied,
+		codeConCount:          mysql.ErrConCount,
+		codeTooManyUserConns:  mysql.ErrTooManyUserConnections,
 	}
 	terror.ErrClassToMySQLCodes[terror.ClassServer] = serverMySQLErrCodes
 }
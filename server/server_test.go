@@ -31,6 +31,7 @@ import (
 	"github.com/pingcap/tidb/executor"
 	tmysql "github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/util/printer"
+	goctx "golang.org/x/net/context"
 )
 
 func TestT(t *testing.T) {
@@ -57,7 +58,8 @@ func runTests(c *C, dsn string, tests ...func(dbt *DBTest)) {
 }
 
 func runTestsOnNewDB(c *C, dbName string, tests ...func(dbt *DBTest)) {
-	db, err := sql.Open("mysql", "root@tcp(localhost:4001)/?strict=true")
+	// The driver turns the warnings into errors in strict mode, so the notes of DROP DATABASE IF EXISTS are turned off.
+	db, err := sql.Open("mysql", "root@tcp(localhost:4001)/?strict=true&sql_notes=0")
 	c.Assert(err, IsNil, Commentf("Error connecting"))
 	defer db.Close()
 
@@ -487,6 +489,31 @@ func runTestAuth(c *C) {
 	})
 }
 
+func runTestConnectionLimits(c *C) {
+	runTests(c, dsn, func(dbt *DBTest) {
+		dbt.mustExec(`CREATE USER 'limited'@'%' IDENTIFIED BY '123' WITH MAX_USER_CONNECTIONS 1;`)
+		dbt.mustExec(`FLUSH PRIVILEGES;`)
+	})
+	db, err := sql.Open("mysql", "limited:123@tcp(localhost:4001)/test?strict=true")
+	c.Assert(err, IsNil)
+	conn1, err := db.Conn(goctx.Background())
+	c.Assert(err, IsNil)
+	_, err = db.Conn(goctx.Background())
+	c.Assert(err, NotNil)
+	c.Assert(err.Error(), Equals, "Error 1203: User limited already has more than 'maxUserConnections' active connections")
+	// The connection is accepted after the account has a free slot.
+	c.Assert(conn1.Close(), IsNil)
+	conn2, err := db.Conn(goctx.Background())
+	c.Assert(err, IsNil)
+	c.Assert(conn2.Close(), IsNil)
+	db.Close()
+
+	runTests(c, dsn, func(dbt *DBTest) {
+		dbt.mustExec(`DROP USER 'limited'@'%';`)
+		dbt.mustExec(`FLUSH PRIVILEGES;`)
+	})
+}
+
 func runTestIssue3682(c *C) {
 	runTestsOnNewDB(c, "issue3682", func(dbt *DBTest) {
 		dbt.mustExec(`CREATE USER 'abc'@'%' IDENTIFIED BY '123';`)
@@ -573,6 +600,7 @@ func runTestMultiStatements(c *C) {
 func runTestStmtCount(t *C) {
 	runTests(t, dsn, func(dbt *DBTest) {
 		originStmtCnt := getStmtCnt(string(getMetrics(t)))
+		originDurationCnt := getStmtDurationCnt(string(getMetrics(t)))
 
 		dbt.mustExec("create table test (a int)")
 
@@ -601,6 +629,12 @@ func runTestStmtCount(t *C) {
 		t.Assert(currentStmtCnt[updateLabel], Equals, originStmtCnt[updateLabel]+2)
 		selectLabel := "SelectTableFull"
 		t.Assert(currentStmtCnt[selectLabel], Equals, originStmtCnt[selectLabel]+2)
+
+		// The durations are observed by the statement types too.
+		currentDurationCnt := getStmtDurationCnt(string(getMetrics(t)))
+		t.Assert(currentDurationCnt[executor.Insert], Equals, originDurationCnt[executor.Insert]+5)
+		t.Assert(currentDurationCnt[updateLabel], Equals, originDurationCnt[updateLabel]+2)
+		t.Assert(currentDurationCnt[selectLabel], Equals, originDurationCnt[selectLabel]+2)
 	})
 }
 
@@ -614,8 +648,17 @@ func getMetrics(t *C) []byte {
 }
 
 func getStmtCnt(content string) (stmtCnt map[string]int) {
+	return getLabelCnt(content, "tidb_executor_statement_node_total")
+}
+
+func getStmtDurationCnt(content string) (stmtCnt map[string]int) {
+	return getLabelCnt(content, "tidb_executor_statement_duration_seconds_count")
+}
+
+// getLabelCnt gets the values of the metric by the type labels.
+func getLabelCnt(content string, metric string) (stmtCnt map[string]int) {
 	stmtCnt = make(map[string]int)
-	r, _ := regexp.Compile("tidb_executor_statement_node_total{type=\"([A-Z|a-z|-]+)\"} (\\d+)")
+	r, _ := regexp.Compile(metric + "{type=\"([A-Z|a-z|-]+)\"} (\\d+)")
 	matchResult := r.FindAllStringSubmatch(content, -1)
 	for _, v := range matchResult {
 		cnt, _ := strconv.Atoi(v[2])
@@ -655,3 +698,65 @@ func waitUntilServerOnline(statusAddr string) {
 		log.Fatalf("Failed to connect http status for %d retries in every 10 ms", retryTime)
 	}
 }
+
+func (ts *TidbRegionHandlerTestSuite) TestGracefulClose(c *C) {
+	ts.startServer(c)
+	defer ts.stopServer(c)
+	ts.server.cfg.DrainTimeout = 10
+
+	idleDB, err := sql.Open("mysql", dsn)
+	c.Assert(err, IsNil)
+	defer idleDB.Close()
+	_, err = idleDB.Exec("select 1")
+	c.Assert(err, IsNil)
+	db, err := sql.Open("mysql", dsn)
+	c.Assert(err, IsNil)
+	defer db.Close()
+	txn, err := db.Begin()
+	c.Assert(err, IsNil)
+	_, err = txn.Exec("select 1")
+	c.Assert(err, IsNil)
+	c.Assert(ts.server.ConnectionCount(), Equals, 2)
+
+	closed := make(chan struct{})
+	go func() {
+		ts.server.GracefulClose()
+		close(closed)
+	}()
+	// The idle connection is closed at once, the connection in the transaction is kept until it's committed.
+	for i := 0; i < 100 && ts.server.ConnectionCount() > 1; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	c.Assert(ts.server.ConnectionCount(), Equals, 1)
+	_, err = txn.Exec("select 1")
+	c.Assert(err, IsNil)
+	c.Assert(txn.Commit(), IsNil)
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		c.Fatal("the server isn't closed after the transaction is committed")
+	}
+	c.Assert(ts.server.ConnectionCount(), Equals, 0)
+}
+
+func (ts *TidbRegionHandlerTestSuite) TestGracefulCloseTimeout(c *C) {
+	ts.startServer(c)
+	defer ts.stopServer(c)
+	ts.server.cfg.DrainTimeout = 0
+
+	db, err := sql.Open("mysql", dsn)
+	c.Assert(err, IsNil)
+	defer db.Close()
+	txn, err := db.Begin()
+	c.Assert(err, IsNil)
+	_, err = txn.Exec("select 1")
+	c.Assert(err, IsNil)
+
+	// The connection in the transaction is killed after the drain timeout.
+	ts.server.GracefulClose()
+	for i := 0; i < 100 && ts.server.ConnectionCount() > 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	c.Assert(ts.server.ConnectionCount(), Equals, 0)
+	c.Assert(txn.Commit(), NotNil)
+}
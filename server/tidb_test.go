@@ -10,6 +10,7 @@
 // distributed under the License is distributed on an "AS IS" BASIS,
 // See the License for the specific language governing permissions and
 // limitations under the License.
+//go:build !race
 // +build !race
 
 package server
@@ -100,6 +101,10 @@ func (ts *TidbTestSuite) TestAuth(c *C) {
 	runTestAuth(c)
 }
 
+func (ts *TidbTestSuite) TestConnectionLimits(c *C) {
+	runTestConnectionLimits(c)
+}
+
 func (ts *TidbTestSuite) TestIssues(c *C) {
 	runTestIssues(c)
 }
@@ -35,16 +35,14 @@
 package server
 
 import (
-	"encoding/binary"
 	"io"
-	"math"
-	"strconv"
-	"time"
 
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/util/arena"
 	"github.com/pingcap/tidb/util/hack"
+	"github.com/pingcap/tidb/util/protocol"
 	"github.com/pingcap/tidb/util/types"
 )
 
@@ -126,6 +124,22 @@ func dumpLengthEncodedString(b []byte, alloc arena.Allocator) []byte {
 	return data
 }
 
+// dumpSessionStateChanges dumps the session state changes of an OK packet, every change is its type and its data
+// as a length encoded string.
+func dumpSessionStateChanges(changes []variable.SessionStateChange, alloc arena.Allocator) []byte {
+	var data []byte
+	for _, change := range changes {
+		var value []byte
+		if change.Type == mysql.SessionTrackSystemVariables {
+			value = dumpLengthEncodedString(hack.Slice(change.Name), alloc)
+		}
+		value = append(value, dumpLengthEncodedString(hack.Slice(change.Value), alloc)...)
+		data = append(data, change.Type)
+		data = append(data, dumpLengthEncodedString(value, alloc)...)
+	}
+	return data
+}
+
 func dumpUint16(n uint16) []byte {
 	return []byte{
 		byte(n),
@@ -163,65 +177,6 @@ func init() {
 	}
 }
 
-func dumpBinaryTime(dur time.Duration) (data []byte) {
-	if dur == 0 {
-		data = tinyIntCache[0]
-		return
-	}
-	data = make([]byte, 13)
-	data[0] = 12
-	if dur < 0 {
-		data[1] = 1
-		dur = -dur
-	}
-	days := dur / (24 * time.Hour)
-	dur -= days * 24 * time.Hour
-	data[2] = byte(days)
-	hours := dur / time.Hour
-	dur -= hours * time.Hour
-	data[6] = byte(hours)
-	minutes := dur / time.Minute
-	dur -= minutes * time.Minute
-	data[7] = byte(minutes)
-	seconds := dur / time.Second
-	dur -= seconds * time.Second
-	data[8] = byte(seconds)
-	if dur == 0 {
-		data[0] = 8
-		return data[:9]
-	}
-	binary.LittleEndian.PutUint32(data[9:13], uint32(dur/time.Microsecond))
-	return
-}
-
-func dumpBinaryDateTime(t types.Time, loc *time.Location) (data []byte, err error) {
-	if t.Type == mysql.TypeTimestamp && loc != nil {
-		// TODO: Consider time_zone variable.
-		t1, err := t.Time.GoTime(time.Local)
-		if err != nil {
-			return nil, errors.Errorf("FATAL: convert timestamp %v go time return error!", t.Time)
-		}
-		t.Time = types.FromGoTime(t1.In(loc))
-	}
-
-	year, mon, day := t.Time.Year(), t.Time.Month(), t.Time.Day()
-	if t.IsZero() {
-		year, mon, day = 1, int(time.January), 1
-	}
-	switch t.Type {
-	case mysql.TypeTimestamp, mysql.TypeDatetime:
-		data = append(data, 11)
-		data = append(data, dumpUint16(uint16(year))...)
-		data = append(data, byte(mon), byte(day), byte(t.Time.Hour()), byte(t.Time.Minute()), byte(t.Time.Second()))
-		data = append(data, dumpUint32(uint32(t.Time.Microsecond()))...)
-	case mysql.TypeDate, mysql.TypeNewDate:
-		data = append(data, 4)
-		data = append(data, dumpUint16(uint16(year))...) //year
-		data = append(data, byte(mon), byte(day))
-	}
-	return
-}
-
 func uniformValue(value interface{}) interface{} {
 	switch v := value.(type) {
 	case int8:
@@ -245,7 +200,7 @@ func uniformValue(value interface{}) interface{} {
 	}
 }
 
-func dumpRowValuesBinary(alloc arena.Allocator, columns []*ColumnInfo, row []types.Datum) (data []byte, err error) {
+func dumpRowValuesBinary(columns []*ColumnInfo, row []types.Datum) (data []byte, err error) {
 	if len(columns) != len(row) {
 		err = mysql.ErrMalformPacket
 		return
@@ -262,99 +217,18 @@ func dumpRowValuesBinary(alloc arena.Allocator, columns []*ColumnInfo, row []typ
 	}
 	data = append(data, nulls...)
 	for i, val := range row {
-		switch val.Kind() {
-		case types.KindInt64:
-			v := val.GetInt64()
-			switch columns[i].Type {
-			case mysql.TypeTiny:
-				data = append(data, byte(v))
-			case mysql.TypeShort, mysql.TypeYear:
-				data = append(data, dumpUint16(uint16(v))...)
-			case mysql.TypeInt24, mysql.TypeLong:
-				data = append(data, dumpUint32(uint32(v))...)
-			case mysql.TypeLonglong:
-				data = append(data, dumpUint64(uint64(v))...)
-			}
-		case types.KindUint64:
-			v := val.GetUint64()
-			switch columns[i].Type {
-			case mysql.TypeTiny:
-				data = append(data, byte(v))
-			case mysql.TypeShort, mysql.TypeYear:
-				data = append(data, dumpUint16(uint16(v))...)
-			case mysql.TypeInt24, mysql.TypeLong:
-				data = append(data, dumpUint32(uint32(v))...)
-			case mysql.TypeLonglong:
-				data = append(data, dumpUint64(uint64(v))...)
-			}
-		case types.KindFloat32:
-			floatBits := math.Float32bits(val.GetFloat32())
-			data = append(data, dumpUint32(floatBits)...)
-		case types.KindFloat64:
-			floatBits := math.Float64bits(val.GetFloat64())
-			data = append(data, dumpUint64(floatBits)...)
-		case types.KindString, types.KindBytes:
-			data = append(data, dumpLengthEncodedString(val.GetBytes(), alloc)...)
-		case types.KindMysqlDecimal:
-			data = append(data, dumpLengthEncodedString(hack.Slice(val.GetMysqlDecimal().String()), alloc)...)
-		case types.KindMysqlTime:
-			tmp, err := dumpBinaryDateTime(val.GetMysqlTime(), nil)
-			if err != nil {
-				return data, errors.Trace(err)
-			}
-			data = append(data, tmp...)
-		case types.KindMysqlDuration:
-			data = append(data, dumpBinaryTime(val.GetMysqlDuration().Duration)...)
-		case types.KindMysqlSet:
-			data = append(data, dumpLengthEncodedString(hack.Slice(val.GetMysqlSet().String()), alloc)...)
-		case types.KindMysqlHex:
-			data = append(data, dumpLengthEncodedString(hack.Slice(val.GetMysqlHex().ToString()), alloc)...)
-		case types.KindMysqlEnum:
-			data = append(data, dumpLengthEncodedString(hack.Slice(val.GetMysqlEnum().String()), alloc)...)
-		case types.KindMysqlBit:
-			data = append(data, dumpLengthEncodedString(hack.Slice(val.GetMysqlBit().ToString()), alloc)...)
+		if val.IsNull() {
+			continue
+		}
+		data, err = protocol.AppendBinaryValue(data, columns[i].protocolColumn(), val)
+		if err != nil {
+			return nil, errors.Trace(err)
 		}
 	}
 	return
 }
 
-func dumpTextValue(mysqlType uint8, value types.Datum) ([]byte, error) {
-	switch value.Kind() {
-	case types.KindInt64:
-		return strconv.AppendInt(nil, value.GetInt64(), 10), nil
-	case types.KindUint64:
-		return strconv.AppendUint(nil, value.GetUint64(), 10), nil
-	case types.KindFloat32:
-		prec := -1
-		if frac := value.Frac(); frac > 0 {
-			prec = frac
-		}
-		return strconv.AppendFloat(nil, value.GetFloat64(), 'f', prec, 32), nil
-	case types.KindFloat64:
-		prec := -1
-		if frac := value.Frac(); frac > 0 {
-			prec = frac
-		}
-		return strconv.AppendFloat(nil, value.GetFloat64(), 'f', prec, 64), nil
-	case types.KindString, types.KindBytes:
-		return value.GetBytes(), nil
-	case types.KindMysqlTime:
-		return hack.Slice(value.GetMysqlTime().String()), nil
-	case types.KindMysqlDuration:
-		return hack.Slice(value.GetMysqlDuration().String()), nil
-	case types.KindMysqlDecimal:
-		return hack.Slice(value.GetMysqlDecimal().String()), nil
-	case types.KindMysqlEnum:
-		return hack.Slice(value.GetMysqlEnum().String()), nil
-	case types.KindMysqlSet:
-		return hack.Slice(value.GetMysqlSet().String()), nil
-	case types.KindMysqlJSON:
-		return hack.Slice(value.GetMysqlJSON().String()), nil
-	case types.KindMysqlBit:
-		return hack.Slice(value.GetMysqlBit().ToString()), nil
-	case types.KindMysqlHex:
-		return hack.Slice(value.GetMysqlHex().ToString()), nil
-	default:
-		return nil, errInvalidType.Gen("invalid type %v", value.Kind())
-	}
+func dumpTextValue(column *ColumnInfo, value types.Datum) ([]byte, error) {
+	data, err := protocol.AppendTextValue(nil, column.protocolColumn(), value)
+	return data, errors.Trace(err)
 }
@@ -16,6 +16,8 @@ package server
 import (
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/util/arena"
 	"github.com/pingcap/tidb/util/testleak"
 	"github.com/pingcap/tidb/util/types"
 )
@@ -25,64 +27,39 @@ var _ = Suite(&testUtilSuite{})
 type testUtilSuite struct {
 }
 
-func (s *testUtilSuite) TestDumpBinaryTime(c *C) {
-	defer testleak.AfterTest(c)()
-	t, err := types.ParseTimestamp("0000-00-00 00:00:00.0000000")
-	c.Assert(err, IsNil)
-	d, err := dumpBinaryDateTime(t, nil)
-	c.Assert(err, IsNil)
-	c.Assert(d, DeepEquals, []byte{11, 1, 0, 1, 1, 0, 0, 0, 0, 0, 0, 0})
-	t, err = types.ParseDatetime("0000-00-00 00:00:00.0000000")
-	c.Assert(err, IsNil)
-	d, err = dumpBinaryDateTime(t, nil)
-	c.Assert(err, IsNil)
-	c.Assert(d, DeepEquals, []byte{11, 1, 0, 1, 1, 0, 0, 0, 0, 0, 0, 0})
-
-	t, err = types.ParseDate("0000-00-00")
-	c.Assert(err, IsNil)
-	d, err = dumpBinaryDateTime(t, nil)
-	c.Assert(err, IsNil)
-	c.Assert(d, DeepEquals, []byte{4, 1, 0, 1, 1})
-
-	myDuration, err := types.ParseDuration("0000-00-00 00:00:00.0000000", 6)
-	c.Assert(err, IsNil)
-	d = dumpBinaryTime(myDuration.Duration)
-	c.Assert(d, DeepEquals, []byte{0})
-}
-
 func (s *testUtilSuite) TestDumpTextValue(c *C) {
 	defer testleak.AfterTest(c)()
-	bs, err := dumpTextValue(mysql.TypeLonglong, types.NewIntDatum(10))
+	bs, err := dumpTextValue(&ColumnInfo{Type: mysql.TypeLonglong, Decimal: mysql.NotFixedDec}, types.NewIntDatum(10))
 	c.Assert(err, IsNil)
 	c.Assert(string(bs), Equals, "10")
 
-	bs, err = dumpTextValue(mysql.TypeLonglong, types.NewUintDatum(11))
+	bs, err = dumpTextValue(&ColumnInfo{Type: mysql.TypeLonglong, Decimal: mysql.NotFixedDec}, types.NewUintDatum(11))
 	c.Assert(err, IsNil)
 	c.Assert(string(bs), Equals, "11")
 
 	f32 := types.NewFloat32Datum(1.2)
-	bs, err = dumpTextValue(mysql.TypeDouble, f32)
+	bs, err = dumpTextValue(&ColumnInfo{Type: mysql.TypeDouble, Decimal: mysql.NotFixedDec}, f32)
 	c.Assert(err, IsNil)
 	c.Assert(string(bs), Equals, "1.2")
 	f32.SetFrac(2)
-	bs, err = dumpTextValue(mysql.TypeDouble, f32)
+	bs, err = dumpTextValue(&ColumnInfo{Type: mysql.TypeDouble, Decimal: mysql.NotFixedDec}, f32)
 	c.Assert(err, IsNil)
 	c.Assert(string(bs), Equals, "1.20")
 
 	f64 := types.NewFloat64Datum(2.2)
-	bs, err = dumpTextValue(mysql.TypeDouble, f64)
+	bs, err = dumpTextValue(&ColumnInfo{Type: mysql.TypeDouble, Decimal: mysql.NotFixedDec}, f64)
 	c.Assert(err, IsNil)
 	c.Assert(string(bs), Equals, "2.2")
 	f64.SetFrac(2)
-	bs, err = dumpTextValue(mysql.TypeDouble, f64)
+	bs, err = dumpTextValue(&ColumnInfo{Type: mysql.TypeDouble, Decimal: mysql.NotFixedDec}, f64)
 	c.Assert(err, IsNil)
 	c.Assert(string(bs), Equals, "2.20")
 
-	bs, err = dumpTextValue(mysql.TypeBlob, types.NewBytesDatum([]byte("foo")))
+	bs, err = dumpTextValue(&ColumnInfo{Type: mysql.TypeBlob, Decimal: mysql.NotFixedDec}, types.NewBytesDatum([]byte("foo")))
 	c.Assert(err, IsNil)
 	c.Assert(string(bs), Equals, "foo")
 
-	bs, err = dumpTextValue(mysql.TypeVarchar, types.NewStringDatum("bar"))
+	bs, err = dumpTextValue(&ColumnInfo{Type: mysql.TypeVarchar, Decimal: mysql.NotFixedDec}, types.NewStringDatum("bar"))
 	c.Assert(err, IsNil)
 	c.Assert(string(bs), Equals, "bar")
 
@@ -91,19 +68,38 @@ func (s *testUtilSuite) TestDumpTextValue(c *C) {
 	time, err := types.ParseTime("2017-01-05 23:59:59.575601", mysql.TypeDatetime, 0)
 	c.Assert(err, IsNil)
 	d.SetMysqlTime(time)
-	bs, err = dumpTextValue(mysql.TypeDatetime, d)
+	bs, err = dumpTextValue(&ColumnInfo{Type: mysql.TypeDatetime, Decimal: mysql.NotFixedDec}, d)
 	c.Assert(err, IsNil)
 	c.Assert(string(bs), Equals, "2017-01-06 00:00:00")
 
 	duration, err := types.ParseDuration("11:30:45", 0)
 	c.Assert(err, IsNil)
 	d.SetMysqlDuration(duration)
-	bs, err = dumpTextValue(mysql.TypeDuration, d)
+	bs, err = dumpTextValue(&ColumnInfo{Type: mysql.TypeDuration, Decimal: mysql.NotFixedDec}, d)
 	c.Assert(err, IsNil)
 	c.Assert(string(bs), Equals, "11:30:45")
 
 	d.SetMysqlDecimal(types.NewDecFromStringForTest("1.23"))
-	bs, err = dumpTextValue(mysql.TypeNewDecimal, d)
+	bs, err = dumpTextValue(&ColumnInfo{Type: mysql.TypeNewDecimal, Decimal: mysql.NotFixedDec}, d)
 	c.Assert(err, IsNil)
 	c.Assert(string(bs), Equals, "1.23")
 }
+
+func (s *testUtilSuite) TestDumpSessionStateChanges(c *C) {
+	defer testleak.AfterTest(c)()
+	changes := []variable.SessionStateChange{
+		{Type: mysql.SessionTrackSystemVariables, Name: "autocommit", Value: "OFF"},
+		{Type: mysql.SessionTrackSchema, Value: "test"},
+		{Type: mysql.SessionTrackStateChange, Value: "1"},
+	}
+	data := dumpSessionStateChanges(changes, arena.NewAllocator(1024))
+	expected := []byte{mysql.SessionTrackSystemVariables, 15, 10}
+	expected = append(expected, "autocommit"...)
+	expected = append(expected, 3)
+	expected = append(expected, "OFF"...)
+	expected = append(expected, mysql.SessionTrackSchema, 5, 4)
+	expected = append(expected, "test"...)
+	expected = append(expected, mysql.SessionTrackStateChange, 2, 1, '1')
+	c.Assert(data, DeepEquals, expected)
+	c.Assert(dumpSessionStateChanges(nil, arena.NewAllocator(1024)), HasLen, 0)
+}
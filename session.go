@@ -30,6 +30,7 @@ import (
 	"github.com/ngaut/log"
 	"github.com/ngaut/pools"
 	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/bindinfo"
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/domain"
 	"github.com/pingcap/tidb/executor"
@@ -38,6 +39,9 @@ import (
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/parser"
 	"github.com/pingcap/tidb/perfschema"
+	"github.com/pingcap/tidb/plan"
+	"github.com/pingcap/tidb/plugin"
+	"github.com/pingcap/tidb/policy"
 	"github.com/pingcap/tidb/privilege"
 	"github.com/pingcap/tidb/privilege/privileges"
 	"github.com/pingcap/tidb/sessionctx"
@@ -48,7 +52,11 @@ import (
 	"github.com/pingcap/tidb/store/localstore"
 	"github.com/pingcap/tidb/store/tikv/oracle"
 	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/udf"
 	"github.com/pingcap/tidb/util"
+	"github.com/pingcap/tidb/util/audit"
+	"github.com/pingcap/tidb/util/resource"
+	"github.com/pingcap/tidb/util/tracing"
 	"github.com/pingcap/tidb/util/types"
 	"github.com/pingcap/tipb/go-binlog"
 	goctx "golang.org/x/net/context"
@@ -64,8 +72,9 @@ type Session interface {
 	String() string                              // String is used to debug.
 	CommitTxn() error
 	RollbackTxn() error
-	// PrepareStmt executes prepare statement in binary protocol.
-	PrepareStmt(sql string) (stmtID uint32, paramCount int, fields []*ast.ResultField, err error)
+	// PrepareStmt executes prepare statement in binary protocol, it returns the inferred types of the parameters
+	// and the result fields.
+	PrepareStmt(sql string) (stmtID uint32, paramTypes []*types.FieldType, fields []*ast.ResultField, err error)
 	// ExecutePreparedStmt executes a prepared statement.
 	ExecutePreparedStmt(stmtID uint32, param ...interface{}) (ast.RecordSet, error)
 	DropPreparedStmt(stmtID uint32) error
@@ -134,6 +143,19 @@ type session struct {
 	sessionManager util.SessionManager
 
 	statsCollector *statistics.SessionStatsCollector
+
+	// executeDepth is the depth of the nested Execute calls, the statements executed by the executors
+	// of a statement are internal and not audited.
+	executeDepth int
+
+	// resultCacheGen is the generation of the result cache got before the start timestamp of the transaction.
+	resultCacheGen uint64
+
+	// mdlID is the ID of the metadata lock held by the current transaction.
+	mdlID uint64
+
+	// usedTables keeps the tables used by the session if the session notifies the schema changes.
+	usedTables *usedTables
 }
 
 // Cancel cancels the execution of current transaction.
@@ -144,7 +166,11 @@ func (s *session) Cancel() {
 }
 
 // GoCtx returns the standard context.Context that bind with current transaction.
+// It carries the trace span if the statement is traced.
 func (s *session) GoCtx() goctx.Context {
+	if span := s.sessionVars.StmtCtx.TraceSpan(); span != nil {
+		return tracing.ContextWithSpan(s.goCtx, span)
+	}
 	return s.goCtx
 }
 
@@ -193,6 +219,8 @@ type schemaLeaseChecker struct {
 	domain.SchemaValidator
 	schemaVer       int64
 	relatedTableIDs []int64
+	mdl             *domain.MetadataLock
+	mdlID           uint64
 }
 
 const (
@@ -224,6 +252,14 @@ func (s *schemaLeaseChecker) checkOnce(txnTS uint64) error {
 		if err != nil {
 			return errors.Trace(err)
 		}
+		if isChanged && s.mdl.Pin(s.mdlID) {
+			// The DDL is blocked by the metadata lock, so the related tables are at most in the next
+			// state, which is compatible with the state used by the transaction.
+			isChanged, err = s.SchemaValidator.IsRelatedTablesChanged(txnTS, s.schemaVer+1, s.relatedTableIDs)
+			if err != nil {
+				return errors.Trace(err)
+			}
+		}
 		if isChanged {
 			return domain.ErrInfoSchemaChanged
 		}
@@ -266,14 +302,18 @@ func (s *session) doCommit() error {
 		tableIDs = append(tableIDs, id)
 	}
 	// Set this option for 2 phase commit to validate schema lease.
+	dom := sessionctx.GetDomain(s)
 	s.txn.SetOption(kv.SchemaLeaseChecker, &schemaLeaseChecker{
-		SchemaValidator: sessionctx.GetDomain(s).SchemaValidator,
+		SchemaValidator: dom.SchemaValidator,
 		schemaVer:       s.sessionVars.TxnCtx.SchemaVersion,
 		relatedTableIDs: tableIDs,
+		mdl:             dom.MetadataLock(),
+		mdlID:           s.mdlID,
 	})
 	if err := s.txn.Commit(); err != nil {
 		return errors.Trace(err)
 	}
+	dom.ResultCache().Invalidate(tableIDs)
 	return nil
 }
 
@@ -294,6 +334,8 @@ func (s *session) doCommitWithRetry() error {
 		}
 	}
 	s.cleanRetryInfo()
+	// The transaction and its retries are finished, an idle session mustn't hold back the DDL.
+	s.releaseMetadataLock()
 	if err != nil {
 		log.Warnf("[%d] finished txn:%v, %v", s.sessionVars.ConnectionID, s.txn, err)
 		return errors.Trace(err)
@@ -320,9 +362,18 @@ func (s *session) RollbackTxn() error {
 	s.txn = nil
 	s.txnFuture = nil
 	s.sessionVars.SetStatusFlag(mysql.ServerStatusInTrans, false)
+	s.releaseMetadataLock()
 	return errors.Trace(err)
 }
 
+// releaseMetadataLock releases the metadata lock when the transaction ends.
+func (s *session) releaseMetadataLock() {
+	if s.mdlID != 0 {
+		sessionctx.GetDomain(s).MetadataLock().Release(s.mdlID)
+		s.mdlID = 0
+	}
+}
+
 func (s *session) GetClient() kv.Client {
 	return s.store.GetClient()
 }
@@ -341,6 +392,9 @@ func (s *session) String() string {
 		// if txn is committed or rolled back, txn is nil.
 		data["txn"] = s.txn.String()
 	}
+	if sessVars.SessionAlias != "" {
+		data["alias"] = sessVars.SessionAlias
+	}
 	if sessVars.SnapshotTS != 0 {
 		data["snapshotTS"] = sessVars.SnapshotTS
 	}
@@ -397,7 +451,7 @@ func (s *session) retry(maxCnt int, infoSchemaChanged bool) error {
 			if retryCnt == 0 {
 				// We do not have to log the query every time.
 				// We print the queries at the first try only.
-				log.Warnf("[%d] Retry [%d] query [%d] %s", connID, retryCnt, i, sqlForLog(txt))
+				log.Warnf("Retry [%d] query [%d] %s", retryCnt, i, executor.StmtLogFields(s.sessionVars, txt, sqlLogMaxLen))
 			} else {
 				log.Warnf("[%d] Retry [%d] query [%d]", connID, retryCnt, i)
 			}
@@ -453,13 +507,6 @@ func updateStatement(st ast.Statement, s *session, txt string) (ast.Statement, e
 	return st, nil
 }
 
-func sqlForLog(sql string) string {
-	if len(sql) > sqlLogMaxLen {
-		return sql[:sqlLogMaxLen] + fmt.Sprintf("(len:%d)", len(sql))
-	}
-	return sql
-}
-
 func (s *session) sysSessionPool() *pools.ResourcePool {
 	return sessionctx.GetDomain(s).SysSessionPool()
 }
@@ -571,6 +618,9 @@ func (s *session) GetGlobalSysVar(name string) (string, error) {
 		// When running bootstrap or upgrade, we should not access global storage.
 		return "", nil
 	}
+	if value, ok := sessionctx.GetDomain(s).GlobalVar(name); ok {
+		return value, nil
+	}
 	sql := fmt.Sprintf(`SELECT VARIABLE_VALUE FROM %s.%s WHERE VARIABLE_NAME="%s";`,
 		mysql.SystemDB, mysql.GlobalVariablesTable, name)
 	sysVar, err := s.getExecRet(s, sql)
@@ -589,11 +639,19 @@ func (s *session) GetGlobalSysVar(name string) (string, error) {
 }
 
 // SetGlobalSysVar implements GlobalVarAccessor.SetGlobalSysVar interface.
+// The value is written through the global variables cache of the domain, and the other servers are notified to reload.
 func (s *session) SetGlobalSysVar(name string, value string) error {
+	name = strings.ToLower(name)
 	sql := fmt.Sprintf(`REPLACE %s.%s VALUES ('%s', '%s');`,
-		mysql.SystemDB, mysql.GlobalVariablesTable, strings.ToLower(name), value)
+		mysql.SystemDB, mysql.GlobalVariablesTable, name, value)
 	_, _, err := s.ExecRestrictedSQL(s, sql)
-	return errors.Trace(err)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	dom := sessionctx.GetDomain(s)
+	dom.SetGlobalVar(name, value)
+	dom.NotifyUpdateGlobalVar()
+	return nil
 }
 
 func (s *session) ParseSQL(sql, charset, collation string) ([]ast.StmtNode, error) {
@@ -601,14 +659,21 @@ func (s *session) ParseSQL(sql, charset, collation string) ([]ast.StmtNode, erro
 	return s.parser.Parse(sql, charset, collation)
 }
 
-func (s *session) SetProcessInfo(sql string) {
+// SetProcessInfo sets the info of the statement being executed, the plan of the last statement is kept when
+// the statement finishes so EXPLAIN FOR CONNECTION can explain it.
+func (s *session) SetProcessInfo(sql string, p plan.Plan) {
 	pi := util.ProcessInfo{
-		ID:      s.sessionVars.ConnectionID,
-		DB:      s.sessionVars.CurrentDB,
-		Command: "Query",
-		Time:    time.Now(),
-		State:   s.Status(),
-		Info:    sql,
+		ID:           s.sessionVars.ConnectionID,
+		DB:           s.sessionVars.CurrentDB,
+		Command:      "Query",
+		Time:         time.Now(),
+		State:        s.Status(),
+		Info:         sql,
+		Plan:         p,
+		ConnectAttrs: s.sessionVars.ConnectAttrs,
+	}
+	if last, ok := s.processInfo.Load().(util.ProcessInfo); ok && p == nil {
+		pi.Plan = last.Plan
 	}
 	strs := strings.Split(s.sessionVars.User, "@")
 	if len(strs) == 2 {
@@ -619,6 +684,8 @@ func (s *session) SetProcessInfo(sql string) {
 }
 
 func (s *session) Execute(sql string) ([]ast.RecordSet, error) {
+	s.executeDepth++
+	defer func() { s.executeDepth-- }()
 	s.PrepareTxnCtx()
 	startTS := time.Now()
 
@@ -626,7 +693,12 @@ func (s *session) Execute(sql string) ([]ast.RecordSet, error) {
 	connID := s.sessionVars.ConnectionID
 	rawStmts, err := s.ParseSQL(sql, charset, collation)
 	if err != nil {
-		log.Warnf("[%d] parse error:\n%v\n%s", connID, err, sql)
+		if s.sessionVars.RedactLog {
+			// The error contains the sql text near the error position.
+			log.Warnf("[PARSE_ERROR] %s", executor.StmtLogFields(s.sessionVars, sql, sqlLogMaxLen))
+		} else {
+			log.Warnf("[PARSE_ERROR] %s err=%v", executor.StmtLogFields(s.sessionVars, sql, sqlLogMaxLen), err)
+		}
 		return nil, errors.Trace(err)
 	}
 	sessionExecuteParseDuration.Observe(time.Since(startTS).Seconds())
@@ -634,17 +706,35 @@ func (s *session) Execute(sql string) ([]ast.RecordSet, error) {
 	var rs []ast.RecordSet
 	ph := sessionctx.GetDomain(s).PerfSchema()
 	for i, rst := range rawStmts {
+		if err1 := s.consumeResources(rst); err1 != nil {
+			return nil, errors.Trace(err1)
+		}
+		if call, ok := rst.(*ast.CallStmt); ok {
+			// The statements of the procedure are compiled and run one by one.
+			rss, err1 := s.executeCall(call, nil)
+			s.auditStatement(rst, err1)
+			if err1 != nil {
+				log.Warnf("[%d] call procedure error:\n%v\n%s", connID, errors.ErrorStack(err1), s)
+				return nil, errors.Trace(err1)
+			}
+			rs = append(rs, rss...)
+			continue
+		}
 		s.PrepareTxnCtx()
 		startTS := time.Now()
 		// Some executions are done in compile stage, so we reset them before compile.
 		executor.ResetStmtCtx(s, rst)
 		st, err1 := Compile(s, rst)
 		if err1 != nil {
-			log.Warnf("[%d] compile error:\n%v\n%s", connID, err1, sql)
+			log.Warnf("[COMPILE_ERROR] %s err=%v", executor.StmtLogFields(s.sessionVars, rst.Text(), sqlLogMaxLen), err1)
+			s.sessionVars.StmtCtx.AppendError(err1)
+			s.auditStatement(rst, err1)
 			s.RollbackTxn()
 			return nil, errors.Trace(err1)
 		}
 		sessionExecuteCompileDuration.Observe(time.Since(startTS).Seconds())
+		s.notifySchemaChange(rst)
+		st = s.useResultCache(st, rst, rst.Text())
 
 		s.stmtState = ph.StartStatement(sql, connID, perfschema.CallerNameSessionExecute, rawStmts[i])
 		s.SetValue(context.QueryString, st.OriginText())
@@ -652,6 +742,7 @@ func (s *session) Execute(sql string) ([]ast.RecordSet, error) {
 		startTS = time.Now()
 		r, err := runStmt(s, st)
 		ph.EndStatement(s.stmtState)
+		s.auditStatement(rst, err)
 		if err != nil {
 			if !terror.ErrorEqual(err, kv.ErrKeyExists) {
 				log.Warnf("[%d] session error:\n%v\n%s", connID, errors.ErrorStack(err), s)
@@ -674,7 +765,7 @@ func (s *session) Execute(sql string) ([]ast.RecordSet, error) {
 }
 
 // PrepareStmt is used for executing prepare statement in binary protocol
-func (s *session) PrepareStmt(sql string) (stmtID uint32, paramCount int, fields []*ast.ResultField, err error) {
+func (s *session) PrepareStmt(sql string) (stmtID uint32, paramTypes []*types.FieldType, fields []*ast.ResultField, err error) {
 	if s.sessionVars.TxnCtx.InfoSchema == nil {
 		// We don't need to create a transaction for prepare statement, just get information schema will do.
 		s.sessionVars.TxnCtx.InfoSchema = sessionctx.GetDomain(s).InfoSchema()
@@ -685,7 +776,7 @@ func (s *session) PrepareStmt(sql string) (stmtID uint32, paramCount int, fields
 		SQLText: sql,
 	}
 	prepareExec.DoPrepare()
-	return prepareExec.ID, prepareExec.ParamCount, prepareExec.Fields, prepareExec.Err
+	return prepareExec.ID, prepareExec.ParamTypes, prepareExec.Fields, prepareExec.Err
 }
 
 // checkArgs makes sure all the arguments' types are known and can be handled.
@@ -740,8 +831,16 @@ func (s *session) ExecutePreparedStmt(stmtID uint32, args ...interface{}) (ast.R
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
+	if prepared, ok := s.sessionVars.PreparedStmts[stmtID].(*executor.Prepared); ok {
+		if err = s.consumeResources(prepared.Stmt); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
 	s.PrepareTxnCtx()
 	st := executor.CompileExecutePreparedStmt(s, stmtID, args...)
+	if prepared, ok := s.sessionVars.PreparedStmts[stmtID].(*executor.Prepared); ok {
+		st = s.useResultCache(st, prepared.Stmt, prepared.SQLText, args...)
+	}
 
 	r, err := runStmt(s, st)
 	return r, errors.Trace(err)
@@ -837,6 +936,16 @@ func (s *session) Close() {
 	if err := s.RollbackTxn(); err != nil {
 		log.Error("session Close error:", errors.ErrorStack(err))
 	}
+	if len(s.sessionVars.TableLocks) > 0 {
+		// The table locks are released when the session ends, the DDL jobs begin a new transaction.
+		if err := sessionctx.GetDomain(s).DDL().UnlockTables(s, s.sessionVars.TableLocks); err != nil {
+			log.Error("session Close error:", errors.ErrorStack(err))
+		}
+		s.sessionVars.TableLocks = nil
+		if err := s.RollbackTxn(); err != nil {
+			log.Error("session Close error:", errors.ErrorStack(err))
+		}
+	}
 	return
 }
 
@@ -871,9 +980,21 @@ func (s *session) Auth(user string, auth []byte, salt []byte) bool {
 	host := strs[1]
 	pm := privilege.GetPrivilegeManager(s)
 
+	// The user is authenticated by the plugins first.
+	if ok, handled := plugin.Authenticate(name, host, auth, salt); handled {
+		if ok {
+			s.sessionVars.User = name + "@" + host
+			s.sessionVars.Account = s.sessionVars.User
+		} else {
+			log.Errorf("User connection verification failed by plugins %v", user)
+		}
+		return ok
+	}
+
 	// Check IP.
 	if pm.ConnectionVerification(name, host, auth, salt) {
 		s.sessionVars.User = name + "@" + host
+		s.setAccount(pm)
 		return true
 	}
 
@@ -881,6 +1002,7 @@ func (s *session) Auth(user string, auth []byte, salt []byte) bool {
 	for _, addr := range getHostByIP(host) {
 		if pm.ConnectionVerification(name, addr, auth, salt) {
 			s.sessionVars.User = name + "@" + addr
+			s.setAccount(pm)
 			return true
 		}
 	}
@@ -889,6 +1011,39 @@ func (s *session) Auth(user string, auth []byte, salt []byte) bool {
 	return false
 }
 
+// setAccount records the account the session logged in as and its resource limits.
+func (s *session) setAccount(pm privilege.Manager) {
+	user, host, limits := pm.ConnectionAccount()
+	s.sessionVars.Account = user + "@" + host
+	s.sessionVars.ResourceLimits = limits
+}
+
+// consumeResources counts the statement in the hourly queries and updates of the account.
+func (s *session) consumeResources(stmt ast.StmtNode) error {
+	vars := s.sessionVars
+	if vars.Account == "" || s.executeDepth > 1 {
+		return nil
+	}
+	err := resource.GlobalTracker.Consume(vars.Account, resource.Queries, vars.ResourceLimits.MaxQueriesPerHour)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if !isUpdateStmt(stmt) {
+		return nil
+	}
+	err = resource.GlobalTracker.Consume(vars.Account, resource.Updates, vars.ResourceLimits.MaxUpdatesPerHour)
+	return errors.Trace(err)
+}
+
+// isUpdateStmt returns whether the statement modifies the tables or the databases.
+func isUpdateStmt(stmt ast.StmtNode) bool {
+	switch stmt.(type) {
+	case *ast.InsertStmt, *ast.UpdateStmt, *ast.DeleteStmt, *ast.LoadDataStmt, ast.DDLNode:
+		return true
+	}
+	return false
+}
+
 func getHostByIP(ip string) []string {
 	if ip == "127.0.0.1" {
 		return []string{"localhost"}
@@ -925,6 +1080,9 @@ func CreateSession(store kv.Storage) (Session, error) {
 		Handle: do.PrivilegeHandle(),
 	}
 	privilege.BindPrivilegeManager(s, pm)
+	udf.BindHandle(s, do.UDFHandle())
+	bindinfo.BindHandle(s, do.BindHandle())
+	policy.BindHandle(s, do.PolicyHandle())
 
 	// Add statsUpdateHandle.
 	if do.StatsHandle() != nil {
@@ -939,8 +1097,13 @@ func BootstrapSession(store kv.Storage) (*domain.Domain, error) {
 	ver := getStoreBootstrapVersion(store)
 	if ver == notBootstrapped {
 		runInBootstrapSession(store, bootstrap)
-	} else if ver < currentBootstrapVersion {
-		runInBootstrapSession(store, upgrade)
+	} else {
+		if BootstrapMode == InitializeSecure {
+			log.Infof("[bootstrap] the store is already bootstrapped, the secure initialization is ignored")
+		}
+		if ver < currentBootstrapVersion {
+			runInBootstrapSession(store, upgrade)
+		}
 	}
 
 	se, err := createSession(store)
@@ -952,6 +1115,46 @@ func BootstrapSession(store kv.Storage) (*domain.Domain, error) {
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
+	se2, err := createSession(store)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	err = dom.LoadGlobalVarsLoop(se2)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	se3, err := createSession(store)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	err = dom.LoadUDFLoop(se3)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	se4, err := createSession(store)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	err = dom.EventSchedulerLoop(se4, eventRunner(store))
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	se5, err := createSession(store)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	err = dom.LoadBindInfoLoop(se5)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	se6, err := createSession(store)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	err = dom.LoadPolicyLoop(se6)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
 	se1, err := createSession(store)
 	if err != nil {
 		return nil, errors.Trace(err)
@@ -1023,7 +1226,7 @@ func createSessionWithDomain(store kv.Storage, dom *domain.Domain) (*session, er
 
 const (
 	notBootstrapped         = 0
-	currentBootstrapVersion = 15
+	currentBootstrapVersion = 23
 )
 
 func getStoreBootstrapVersion(store kv.Storage) int64 {
@@ -1067,20 +1270,48 @@ func finishBootstrap(store kv.Storage) {
 	}
 }
 
-const quoteCommaQuote = "', '"
-const loadCommonGlobalVarsSQL = "select HIGH_PRIORITY * from mysql.global_variables where variable_name in ('" +
-	variable.AutocommitVar + quoteCommaQuote +
-	variable.SQLModeVar + quoteCommaQuote +
-	variable.MaxAllowedPacket + quoteCommaQuote +
+// commonGlobalVars are the commonly used global variables, they are loaded for the session when it starts.
+var commonGlobalVars = []string{
+	variable.AutocommitVar,
+	variable.SQLModeVar,
+	variable.MaxAllowedPacket,
+	variable.AutoIncrementIncrement,
+	variable.AutoIncrementOffset,
+	variable.SessionTrackSystemVariables,
+	variable.SessionTrackSchema,
+	variable.SessionTrackStateChange,
+	variable.SessionTrackTransactionInfo,
 	/* TiDB specific global variables: */
-	variable.TiDBSkipUTF8Check + quoteCommaQuote +
-	variable.TiDBIndexJoinBatchSize + quoteCommaQuote +
-	variable.TiDBIndexLookupSize + quoteCommaQuote +
-	variable.TiDBIndexLookupConcurrency + quoteCommaQuote +
-	variable.TiDBIndexSerialScanConcurrency + quoteCommaQuote +
-	variable.TiDBMaxRowCountForINLJ + quoteCommaQuote +
-	variable.TiDBCBO + quoteCommaQuote +
-	variable.TiDBDistSQLScanConcurrency + "')"
+	variable.TiDBSkipUTF8Check,
+	variable.TiDBIndexJoinBatchSize,
+	variable.TiDBIndexLookupSize,
+	variable.TiDBIndexLookupConcurrency,
+	variable.TiDBIndexSerialScanConcurrency,
+	variable.TiDBMaxRowCountForINLJ,
+	variable.TiDBCBO,
+	variable.TiDBOptCPUFactor,
+	variable.TiDBOptNetworkFactor,
+	variable.TiDBOptScanFactor,
+	variable.TiDBOptDescScanFactor,
+	variable.TiDBOptSeekFactor,
+	variable.TiDBOptMemoryFactor,
+	variable.TiDBEnableCascadesPlanner,
+	variable.TiDBApplyCacheCapacity,
+	variable.TiDBProjectionConcurrency,
+	variable.TiDBEnableChunkRPC,
+	variable.TiDBForcePriority,
+	variable.TiDBRedactLog,
+	variable.TiDBNotifySchemaChange,
+	variable.TiDBAllowPartialResult,
+	variable.TiDBDistSQLScanConcurrency,
+	variable.TiDBDistSQLLowPriorityConcurrency,
+	variable.TiDBUDFMaxSteps,
+	variable.TiDBUDFMaxStringLen,
+	variable.TiDBEnableResultCache,
+}
+
+var loadCommonGlobalVarsSQL = "select HIGH_PRIORITY * from mysql.global_variables where variable_name in ('" +
+	strings.Join(commonGlobalVars, "', '") + "')"
 
 // loadCommonGlobalVariablesIfNeeded loads and applies commonly used global variables for the session.
 func (s *session) loadCommonGlobalVariablesIfNeeded() error {
@@ -1094,6 +1325,16 @@ func (s *session) loadCommonGlobalVariablesIfNeeded() error {
 	}
 	// Set the variable to true to prevent cyclic recursive call.
 	vars.CommonGlobalLoaded = true
+	dom := sessionctx.GetDomain(s)
+	if dom.GlobalVarsLoaded() {
+		for _, varName := range commonGlobalVars {
+			value, ok := dom.GlobalVar(varName)
+			if _, set := vars.Systems[varName]; ok && !set {
+				varsutil.SetSessionSystemVar(s.sessionVars, varName, types.NewStringDatum(value))
+			}
+		}
+		return nil
+	}
 	rows, _, err := s.ExecRestrictedSQL(s, loadCommonGlobalVarsSQL)
 	if err != nil {
 		vars.CommonGlobalLoaded = false
@@ -1142,13 +1383,19 @@ func (s *session) PrepareTxnCtx() {
 		return
 	}
 
+	dom := sessionctx.GetDomain(s)
+	// The generation is got before the start timestamp, so the result read by the timestamp isn't cached if its
+	// tables are written after the generation.
+	s.resultCacheGen = dom.ResultCache().Generation()
 	s.goCtx, s.cancelFunc = util.WithCancel(goctx.Background())
 	s.txnFuture = s.getTxnFuture()
-	is := sessionctx.GetDomain(s).InfoSchema()
+	is := dom.InfoSchema()
 	s.sessionVars.TxnCtx = &variable.TransactionContext{
 		InfoSchema:    is,
 		SchemaVersion: is.SchemaMetaVersion(),
 	}
+	s.releaseMetadataLock()
+	s.mdlID = dom.MetadataLock().Acquire(is.SchemaMetaVersion())
 	if !s.sessionVars.IsAutocommit() {
 		s.sessionVars.SetStatusFlag(mysql.ServerStatusInTrans, true)
 	}
@@ -1225,6 +1472,49 @@ func (s *session) ShowProcess() util.ProcessInfo {
 	return pi
 }
 
+// isPrivilegeStmt returns whether the statement changes the users or the privileges.
+func isPrivilegeStmt(node ast.StmtNode) bool {
+	switch node.(type) {
+	case *ast.CreateUserStmt, *ast.DropUserStmt, *ast.AlterUserStmt, *ast.SetPwdStmt, *ast.GrantStmt, *ast.RevokeStmt:
+		return true
+	}
+	return false
+}
+
+// auditStatement sends the audit events of the executed statement.
+// The literals of the privilege statements are always masked, because they may contain passwords.
+func (s *session) auditStatement(node ast.StmtNode, err error) {
+	if !audit.Enabled() || s.executeDepth > 1 {
+		return
+	}
+	vars := s.sessionVars
+	isPrivilege := isPrivilegeStmt(node)
+	sql, digest := parser.NormalizeDigest(node.Text())
+	if !isPrivilege && !vars.RedactLog {
+		sql = node.Text()
+	}
+	user, host := audit.SplitUser(vars.User)
+	event := &audit.Event{
+		Type:         audit.EventStatement,
+		ConnID:       vars.ConnectionID,
+		User:         user,
+		ClientIP:     host,
+		DB:           vars.CurrentDB,
+		SQL:          sql,
+		Digest:       digest,
+		AffectedRows: vars.StmtCtx.AffectedRows(),
+	}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	audit.Log(event)
+	if isPrivilege && err == nil {
+		privilegeEvent := *event
+		privilegeEvent.Type = audit.EventPrivilege
+		audit.Log(&privilegeEvent)
+	}
+}
+
 // logCrucialStmt logs some crucial SQL including: CREATE USER/GRANT PRIVILEGE/CHANGE PASSWORD etc.
 func logCrucialStmt(node ast.StmtNode) {
 	switch stmt := node.(type) {
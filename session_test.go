@@ -15,11 +15,13 @@ package tidb
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/config"
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/domain"
 	"github.com/pingcap/tidb/executor"
@@ -30,9 +32,12 @@ import (
 	"github.com/pingcap/tidb/plan"
 	"github.com/pingcap/tidb/privilege/privileges"
 	"github.com/pingcap/tidb/sessionctx"
+	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/store/localstore"
 	"github.com/pingcap/tidb/table/tables"
 	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/audit"
+	"github.com/pingcap/tidb/util/resource"
 	"github.com/pingcap/tidb/util/testleak"
 	"github.com/pingcap/tidb/util/types"
 )
@@ -117,6 +122,61 @@ func (s *testSessionSuite) TestSchemaCheckerSimple(c *C) {
 	c.Assert(terror.ErrorEqual(err, domain.ErrInfoSchemaExpired), IsTrue)
 }
 
+func (s *testSessionSuite) TestSchemaCheckerMetadataLock(c *C) {
+	defer testleak.AfterTest(c)()
+	cfg := config.GetGlobalConfig()
+	defer func() { cfg.MetadataLockWait = 0 }()
+	cfg.MetadataLockWait = 10
+	validator := domain.NewSchemaValidator(time.Second)
+	mdl := s.dom.MetadataLock()
+	checker := &schemaLeaseChecker{SchemaValidator: validator, mdl: mdl, relatedTableIDs: []int64{1}}
+
+	ts := uint64(time.Now().UnixNano())
+	validator.Update(ts, 0, 2, nil)
+	validator.Update(ts, 2, 3, []int64{1})
+	checker.schemaVer = 2
+	// The related table is changed without the metadata lock.
+	err := checker.checkOnce(ts)
+	c.Assert(terror.ErrorEqual(err, domain.ErrInfoSchemaChanged), IsTrue)
+	// The related table is changed to the next state when the transaction holds the metadata lock.
+	checker.mdlID = mdl.Acquire(2)
+	defer mdl.Release(checker.mdlID)
+	err = checker.checkOnce(ts)
+	c.Assert(err, IsNil)
+	// The related table is changed twice.
+	validator.Update(ts, 3, 4, []int64{1})
+	err = checker.checkOnce(ts)
+	c.Assert(terror.ErrorEqual(err, domain.ErrInfoSchemaChanged), IsTrue)
+}
+
+func (s *testSessionSuite) TestReleaseMetadataLock(c *C) {
+	defer testleak.AfterTest(c)()
+	cfg := config.GetGlobalConfig()
+	defer func() { cfg.MetadataLockWait = 0 }()
+	cfg.MetadataLockWait = 10
+	mdl := s.dom.MetadataLock()
+	se := newSession(c, s.store, s.dbName).(*session)
+	mustExecSQL(c, se, "drop table if exists t_mdl")
+	mustExecSQL(c, se, "create table t_mdl (a int)")
+
+	// The lock is released once the transaction commits, so the idle session doesn't delay the DDL.
+	mustExecSQL(c, se, "begin")
+	mustExecSQL(c, se, "insert t_mdl values (1)")
+	id := se.mdlID
+	c.Assert(id, Not(Equals), uint64(0))
+	c.Assert(se.CommitTxn(), IsNil)
+	c.Assert(se.mdlID, Equals, uint64(0))
+	c.Assert(mdl.Pin(id), IsFalse)
+	mustExecSQL(c, se, "insert t_mdl values (2)")
+	c.Assert(se.mdlID, Equals, uint64(0))
+
+	se2 := newSession(c, s.store, s.dbName)
+	start := time.Now()
+	mustExecSQL(c, se2, "alter table t_mdl add column b int")
+	c.Assert(time.Since(start), Less, time.Duration(cfg.MetadataLockWait)*time.Second)
+	mustExecSQL(c, se2, "drop table t_mdl")
+}
+
 func (s *testSessionSuite) TestPrepare(c *C) {
 	defer testleak.AfterTest(c)()
 	dbName := "test_prepare"
@@ -131,7 +191,7 @@ func (s *testSessionSuite) TestPrepare(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(fields, HasLen, 1)
 	c.Assert(id, Equals, uint32(1))
-	c.Assert(ps, Equals, 1)
+	c.Assert(ps, HasLen, 1)
 	mustExecSQL(c, se, `set @a=1`)
 	_, err = se.ExecutePreparedStmt(id, "1")
 	c.Assert(err, IsNil)
@@ -143,19 +203,19 @@ func (s *testSessionSuite) TestPrepare(c *C) {
 	rs := mustExecSQL(c, se, "execute stmt using @v1")
 	r, err := rs.Next()
 	c.Assert(err, IsNil)
-	c.Assert(r.Data[0].GetFloat64(), Equals, float64(101))
+	c.Assert(r.Data[0].GetInt64(), Equals, int64(101))
 
 	mustExecSQL(c, se, "set @v2=200")
 	rs = mustExecSQL(c, se, "execute stmt using @v2")
 	r, err = rs.Next()
 	c.Assert(err, IsNil)
-	c.Assert(r.Data[0].GetFloat64(), Equals, float64(201))
+	c.Assert(r.Data[0].GetInt64(), Equals, int64(201))
 
 	mustExecSQL(c, se, "set @v3=300")
 	rs = mustExecSQL(c, se, "execute stmt using @v3")
 	r, err = rs.Next()
 	c.Assert(err, IsNil)
-	c.Assert(r.Data[0].GetFloat64(), Equals, float64(301))
+	c.Assert(r.Data[0].GetInt64(), Equals, int64(301))
 	mustExecSQL(c, se, "deallocate prepare stmt")
 
 	// Execute prepared statements for more than one time.
@@ -346,6 +406,32 @@ func (s *testSessionSuite) TestAutoIncrementID(c *C) {
 
 	mustExecMatch(c, se, "select last_insert_id(20)", [][]interface{}{{20}})
 	mustExecMatch(c, se, "select last_insert_id()", [][]interface{}{{20}})
+	// The argument is returned by last_insert_id() in the same statement too.
+	mustExecMatch(c, se, "select last_insert_id(30), last_insert_id()", [][]interface{}{{30, 30}})
+	mustExecMatch(c, se, "select last_insert_id(0)", [][]interface{}{{0}})
+	mustExecMatch(c, se, "select last_insert_id()", [][]interface{}{{0}})
+
+	// The last insert ID of a multi-row insert is the first generated ID.
+	mustExecSQL(c, se, "drop table if exists t")
+	mustExecSQL(c, se, "create table t (id int primary key auto_increment, c int)")
+	mustExecSQL(c, se, "insert t values (10, 1), (null, 2), (null, 3)")
+	c.Assert(se.LastInsertID(), Equals, uint64(11))
+	mustExecMatch(c, se, "select last_insert_id()", [][]interface{}{{11}})
+
+	// The generated IDs are in the sequence decided by auto_increment_increment and auto_increment_offset.
+	mustExecSQL(c, se, "set @@session.auto_increment_increment = 10, @@session.auto_increment_offset = 5")
+	mustExecSQL(c, se, "insert t (c) values (4), (5)")
+	mustExecMatch(c, se, "select last_insert_id()", [][]interface{}{{15}})
+	mustExecSQL(c, se, "insert t values (27, 6), (null, 7)")
+	mustExecMatch(c, se, "select last_insert_id()", [][]interface{}{{35}})
+	mustExecMatch(c, se, "select id from t where c > 3", [][]interface{}{{15}, {25}, {27}, {35}})
+	// The offset is ignored if it's greater than the increment.
+	mustExecSQL(c, se, "set @@session.auto_increment_increment = 4, @@session.auto_increment_offset = 6")
+	mustExecSQL(c, se, "insert t (c) values (8)")
+	mustExecMatch(c, se, "select last_insert_id()", [][]interface{}{{36}})
+	mustExecSQL(c, se, "set @@session.auto_increment_increment = 1, @@session.auto_increment_offset = 1")
+	mustExecSQL(c, se, "insert t (c) values (9)")
+	mustExecMatch(c, se, "select last_insert_id()", [][]interface{}{{37}})
 
 	mustExecSQL(c, se, dropDBSQL)
 }
@@ -2070,6 +2156,94 @@ func (s *testSessionSuite) TestSessionAuth(c *C) {
 	mustExecSQL(c, se, dropDBSQL)
 }
 
+func (s *testSessionSuite) TestLazyTables(c *C) {
+	defer testleak.AfterTest(c)()
+	domain.TableCacheCapacity = 3
+	defer func() { domain.TableCacheCapacity = 0 }()
+	dbName := "test_lazy_tables"
+	store := newStoreWithBootstrap(c, dbName)
+	se := newSession(c, store, dbName)
+	// The tables are loaded on demand, only the last used ones are kept in memory.
+	for i := 1; i <= 5; i++ {
+		mustExecSQL(c, se, fmt.Sprintf("create table t%d (a int)", i))
+		mustExecSQL(c, se, fmt.Sprintf("insert into t%d values (%d)", i, i))
+	}
+	for i := 1; i <= 5; i++ {
+		mustExecMatch(c, se, fmt.Sprintf("select a from t%d", i), [][]interface{}{{i}})
+	}
+	mustExecSQL(c, se, "alter table t1 add column b int default 10")
+	mustExecMatch(c, se, "select * from t1", [][]interface{}{{1, 10}})
+	mustExecMatch(c, se, "select count(*) from information_schema.columns where table_schema = 'test_lazy_tables'", [][]interface{}{{6}})
+	mustExecMatch(c, se, "select user from mysql.user where user = 'root'", [][]interface{}{{[]byte("root")}})
+	mustExecSQL(c, se, "drop database "+dbName)
+}
+
+func (s *testSessionSuite) TestNotifySchemaChange(c *C) {
+	defer testleak.AfterTest(c)()
+	dbName := "test_notify_schema_change"
+	se := newSession(c, s.store, dbName)
+	defer se.Close()
+	mustExecSQL(c, se, "create table t1 (a int)")
+	mustExecSQL(c, se, "create table t2 (a int)")
+	mustExecSQL(c, se, "create table t3 (a int)")
+
+	se1 := newSession(c, s.store, dbName).(*session)
+	defer se1.Close()
+	mustExecSQL(c, se1, "set @@tidb_notify_schema_change = 1")
+	mustExecSQL(c, se1, "select * from t1, t2")
+	mustExecSQL(c, se, "alter table t1 add column b int")
+	mustExecSQL(c, se, "drop table t2")
+	mustExecSQL(c, se, "alter table t3 add column b int")
+
+	// The session is warned of the tables it used, once for every change.
+	mustExecSQL(c, se1, "select 1")
+	var msgs []string
+	for _, warn := range se1.GetSessionVars().StmtCtx.GetWarnings() {
+		msgs = append(msgs, warn.Err.Error())
+	}
+	sort.Strings(msgs)
+	c.Assert(msgs, HasLen, 2)
+	c.Assert(msgs[0], Matches, ".*Table 'test_notify_schema_change.t1' used by this session is changed.*")
+	c.Assert(msgs[1], Matches, ".*Table 'test_notify_schema_change.t2' used by this session is dropped.*")
+	mustExecSQL(c, se1, "select 1")
+	c.Assert(se1.GetSessionVars().StmtCtx.GetWarnings(), HasLen, 0)
+
+	mustExecSQL(c, se1, "set @@tidb_notify_schema_change = 0")
+	mustExecSQL(c, se1, "select * from t1")
+	mustExecSQL(c, se, "alter table t1 add column c int")
+	mustExecSQL(c, se1, "select 1")
+	c.Assert(se1.GetSessionVars().StmtCtx.GetWarnings(), HasLen, 0)
+	mustExecSQL(c, se, "drop database "+dbName)
+}
+
+func (s *testSessionSuite) TestUserResourceLimits(c *C) {
+	defer testleak.AfterTest(c)()
+	dbName := "test_user_resource_limits"
+	dropDBSQL := fmt.Sprintf("drop database %s;", dbName)
+	se := newSession(c, s.store, dbName)
+	defer se.Close()
+	mustExecSQL(c, se, "create table t (id int)")
+	mustExecSQL(c, se, "create user 'res_user'@'localhost' with max_queries_per_hour 3 max_updates_per_hour 1")
+	mustExecSQL(c, se, "grant all privileges on *.* to 'res_user'@'localhost'")
+	mustExecSQL(c, se, "flush privileges")
+
+	se1 := newSession(c, s.store, dbName)
+	defer se1.Close()
+	c.Assert(se1.Auth("res_user@localhost", []byte(""), []byte("")), IsTrue)
+	mustExecSQL(c, se1, "select * from t")
+	mustExecSQL(c, se1, "insert into t values (1)")
+	// The failed statement is counted as a query.
+	_, err := exec(se1, "insert into t values (2)")
+	c.Assert(terror.ErrorEqual(err, resource.ErrUserLimitReached), IsTrue, Commentf("err %v", err))
+	_, err = exec(se1, "select * from t")
+	c.Assert(terror.ErrorEqual(err, resource.ErrUserLimitReached), IsTrue, Commentf("err %v", err))
+
+	mustExecSQL(c, se, "flush user_resources")
+	mustExecSQL(c, se1, "select * from t")
+	mustExecSQL(c, se, "drop user 'res_user'@'localhost'")
+	mustExecSQL(c, se, dropDBSQL)
+}
+
 func (s *testSessionSuite) TestSkipWithGrant(c *C) {
 	defer testleak.AfterTest(c)()
 	dbName := "test_skip_with_grant"
@@ -2493,6 +2667,26 @@ func (s *testSessionSuite) TestGlobalVarAccessor(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(v, Equals, varValue2)
 
+	// The global variables are cached by the domain, the SetGlobal hook is called when the value is changed.
+	dom := sessionctx.GetDomain(se)
+	v, ok := dom.GlobalVar(varName)
+	c.Assert(ok, IsTrue)
+	c.Assert(v, Equals, varValue2)
+	sysVar := variable.GetSysVar(varName)
+	var hookValue string
+	sysVar.SetGlobal = func(value string) error {
+		hookValue = value
+		return nil
+	}
+	defer func() { sysVar.SetGlobal = nil }()
+	version := dom.GlobalVarsVersion()
+	mustExecSQL(c, se, "set @@global.max_allowed_packet = "+varValue1)
+	c.Assert(hookValue, Equals, varValue1)
+	c.Assert(dom.GlobalVarsVersion(), Greater, version)
+	v, ok = dom.GlobalVar(varName)
+	c.Assert(ok, IsTrue)
+	c.Assert(v, Equals, varValue1)
+
 	mustExecSQL(c, se, dropDBSQL)
 }
 
@@ -2744,3 +2938,140 @@ func (s *testSessionSuite) TestPrepareStmtCommitWhenSchemaChanged(c *C) {
 	// _, err = s2.Execute("commit")
 	// c.Assert(terror.ErrorEqual(err, executor.ErrWrongValueCountOnRow), IsTrue)
 }
+
+type mockAuditSink struct {
+	sync.Mutex
+	connID uint64
+	events []*audit.Event
+}
+
+func (s *mockAuditSink) Audit(event *audit.Event) {
+	s.Lock()
+	if event.ConnID == s.connID {
+		s.events = append(s.events, event)
+	}
+	s.Unlock()
+}
+
+func (s *testSessionSuite) TestAuditStatement(c *C) {
+	defer testleak.AfterTest(c)()
+	dbName := "test_audit_statement"
+	se := newSession(c, s.store, dbName)
+	sink := &mockAuditSink{connID: se.GetSessionVars().ConnectionID}
+	audit.Register(sink)
+	defer audit.Unregister(sink)
+
+	mustExecSQL(c, se, "create table t (a int)")
+	mustExecSQL(c, se, "insert t values (1), (2)")
+	_, err := se.Execute("select * from not_exists")
+	c.Assert(err, NotNil)
+	mustExecSQL(c, se, "create user 'audit_user'@'%' identified by 'secret'")
+	mustExecSQL(c, se, "drop user 'audit_user'@'%'")
+
+	c.Assert(sink.events, HasLen, 7)
+	e := sink.events[1]
+	c.Assert(e.Type, Equals, audit.EventStatement)
+	c.Assert(e.User, Equals, "root")
+	c.Assert(e.ClientIP, Equals, "%")
+	c.Assert(e.DB, Equals, dbName)
+	c.Assert(e.SQL, Equals, "insert t values (1), (2)")
+	c.Assert(e.Digest, Not(Equals), "")
+	c.Assert(e.AffectedRows, Equals, uint64(2))
+	c.Assert(e.Error, Equals, "")
+	c.Assert(sink.events[2].Error, Not(Equals), "")
+	// The password isn't written to the audit events.
+	e = sink.events[3]
+	c.Assert(e.Type, Equals, audit.EventStatement)
+	c.Assert(strings.Contains(e.SQL, "secret"), IsFalse)
+	c.Assert(sink.events[4].Type, Equals, audit.EventPrivilege)
+	c.Assert(sink.events[4].SQL, Equals, e.SQL)
+	c.Assert(sink.events[5].Type, Equals, audit.EventStatement)
+	c.Assert(sink.events[6].Type, Equals, audit.EventPrivilege)
+	mustExecSQL(c, se, "drop database "+dbName)
+}
+
+func (s *testSessionSuite) TestResultCache(c *C) {
+	defer testleak.AfterTest(c)()
+	dbName := "test_result_cache"
+	se := newSession(c, s.store, dbName)
+	defer se.Close()
+	cfg := config.GetGlobalConfig()
+	oldSize := cfg.ResultCacheSize
+	cfg.ResultCacheSize = 1 << 20
+	defer func() { cfg.ResultCacheSize = oldSize }()
+	cache := sessionctx.GetDomain(se).ResultCache()
+	mustExecSQL(c, se, "create table t (a int primary key, b int)")
+	mustExecSQL(c, se, "insert t values (1, 10), (2, 20)")
+
+	// The result cache is disabled by default.
+	n := cache.Len()
+	mustExecMatch(c, se, "select a, b from t order by a", [][]interface{}{{1, 10}, {2, 20}})
+	c.Assert(cache.Len(), Equals, n)
+
+	mustExecSQL(c, se, "set @@tidb_enable_result_cache = 1")
+	mustExecMatch(c, se, "select a, b from t order by a", [][]interface{}{{1, 10}, {2, 20}})
+	c.Assert(cache.Len(), Equals, n+1)
+	mustExecMatch(c, se, "select a, b from t order by a", [][]interface{}{{1, 10}, {2, 20}})
+	c.Assert(cache.Len(), Equals, n+1)
+	mustExecMatch(c, se, "select count(*) from t", [][]interface{}{{2}})
+	c.Assert(cache.Len(), Equals, n+2)
+
+	// The results which depend on the time, the session or the locks are not cached.
+	for _, sql := range []string{
+		"select a, now() from t",
+		"select a, @x from t",
+		"select sql_no_cache a from t",
+		"select sql_calc_found_rows a from t limit 1",
+		"select a from t for update",
+		"select a from t tablesample regions()",
+		"select 1",
+		"select count(*) from information_schema.tables",
+	} {
+		mustExecSQL(c, se, sql)
+		c.Assert(cache.Len(), Equals, n+2, Commentf("sql %s", sql))
+	}
+	mustExecSQL(c, se, "begin")
+	mustExecMatch(c, se, "select a from t where a = 1", [][]interface{}{{1}})
+	mustExecSQL(c, se, "commit")
+	c.Assert(cache.Len(), Equals, n+2)
+	// The results with the warnings or the partial results are not cached.
+	rs := mustExecSQL(c, se, "select a + '1x' from t")
+	_, err := GetRows(rs)
+	c.Assert(err, IsNil)
+	c.Assert(se.GetSessionVars().StmtCtx.WarningCount(), Equals, uint16(1))
+	c.Assert(cache.Len(), Equals, n+2)
+	mustExecSQL(c, se, "set @@tidb_allow_partial_result = 1")
+	mustExecMatch(c, se, "select a from t where a = 2", [][]interface{}{{2}})
+	mustExecSQL(c, se, "set @@tidb_allow_partial_result = 0")
+	c.Assert(cache.Len(), Equals, n+2)
+
+	// The results of the table are removed after the table is written.
+	mustExecSQL(c, se, "insert t values (3, 30)")
+	c.Assert(cache.Len(), Equals, n)
+	mustExecMatch(c, se, "select a, b from t order by a", [][]interface{}{{1, 10}, {2, 20}, {3, 30}})
+	mustExecMatch(c, se, "select count(*) from t", [][]interface{}{{3}})
+	c.Assert(cache.Len(), Equals, n+2)
+	mustExecSQL(c, se, "begin")
+	mustExecSQL(c, se, "update t set b = 0 where a = 3")
+	mustExecSQL(c, se, "commit")
+	c.Assert(cache.Len(), Equals, n)
+	mustExecMatch(c, se, "select a, b from t order by a", [][]interface{}{{1, 10}, {2, 20}, {3, 0}})
+
+	// The schema version is a part of the key.
+	mustExecSQL(c, se, "alter table t add column c int default 5")
+	mustExecMatch(c, se, "select * from t where a = 1", [][]interface{}{{1, 10, 5}})
+
+	// The results of the prepared statements are cached by the arguments.
+	n = cache.Len()
+	for i := 0; i < 2; i++ {
+		for _, a := range []int64{1, 2} {
+			rs, err := exec(se, "select b from t where a = ?", a)
+			c.Assert(err, IsNil)
+			rows, err := GetRows(rs)
+			c.Assert(err, IsNil)
+			match(c, rows[0], a*10)
+		}
+	}
+	c.Assert(cache.Len(), Equals, n+2)
+	mustExecSQL(c, se, "drop database "+dbName)
+}
@@ -28,6 +28,7 @@ import (
 	"github.com/pingcap/tidb/ddl"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/sessionctx"
+	"github.com/pingcap/tidb/sessionctx/binloginfo"
 	"github.com/pingcap/tidb/store/tikv"
 	"github.com/pingcap/tidb/util/codec"
 	"github.com/pingcap/tidb/util/testkit"
@@ -226,6 +227,95 @@ func (s *testBinlogSuite) TestBinlog(c *C) {
 	c.Assert(newBinlogLen, Equals, originBinlogLen)
 }
 
+func (s *testBinlogSuite) TestFileClient(c *C) {
+	path := "/tmp/tidb-binlog-file" + strconv.FormatInt(time.Now().UnixNano(), 10)
+	defer os.Remove(path)
+	client, err := binloginfo.NewFileClient(path)
+	c.Assert(err, IsNil)
+
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("create table file_binlog (id int primary key, name varchar(10))")
+	tk.Se.GetSessionVars().BinlogClient = client
+	tk.MustExec("insert file_binlog values (1, 'abc')")
+
+	var bins []*binlog.Binlog
+	readBinlogs := func() {
+		bins = bins[:0]
+		err = binloginfo.ReadBinlogFile(path, func(bin *binlog.Binlog) error {
+			bins = append(bins, bin)
+			return nil
+		})
+		c.Assert(err, IsNil)
+	}
+	// The commit binlog is written asynchronously.
+	for i := 0; i < 10; i++ {
+		readBinlogs()
+		if len(bins) == 2 {
+			break
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+	c.Assert(bins, HasLen, 2)
+	c.Assert(bins[0].Tp, Equals, binlog.BinlogType_Prewrite)
+	c.Assert(bins[1].Tp, Equals, binlog.BinlogType_Commit)
+	c.Assert(bins[1].StartTs, Equals, bins[0].StartTs)
+	c.Assert(bins[1].CommitTs, Greater, bins[1].StartTs)
+	prewriteVal := new(binlog.PrewriteValue)
+	c.Assert(prewriteVal.Unmarshal(bins[0].PrewriteValue), IsNil)
+	c.Assert(prewriteVal.SchemaVersion, Greater, int64(0))
+	gotRows := mutationRowsToRows(c, prewriteVal.Mutations[0].InsertedRows, 0, 2)
+	c.Assert(gotRows, DeepEquals, [][]types.Datum{{types.NewIntDatum(1), types.NewStringDatum("abc")}})
+
+	// A partially written record is ignored by the reader, and truncated when the file is opened again.
+	fi, err := os.Stat(path)
+	c.Assert(err, IsNil)
+	size := fi.Size()
+	appendFile := func(data []byte) {
+		f, err1 := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+		c.Assert(err1, IsNil)
+		_, err1 = f.Write(data)
+		c.Assert(err1, IsNil)
+		c.Assert(f.Close(), IsNil)
+	}
+	appendFile([]byte{0, 0, 0, 10, 1, 2})
+	readBinlogs()
+	c.Assert(bins, HasLen, 2)
+	client, err = binloginfo.NewFileClient(path)
+	c.Assert(err, IsNil)
+	fi, err = os.Stat(path)
+	c.Assert(err, IsNil)
+	c.Assert(fi.Size(), Equals, size)
+	payload, err := bins[1].Marshal()
+	c.Assert(err, IsNil)
+	_, err = client.WriteBinlog(goctx.Background(), &binlog.WriteBinlogReq{Payload: payload})
+	c.Assert(err, IsNil)
+	readBinlogs()
+	c.Assert(bins, HasLen, 3)
+	c.Assert(bins[2].CommitTs, Equals, bins[1].CommitTs)
+	fi, err = os.Stat(path)
+	c.Assert(err, IsNil)
+	size = fi.Size()
+
+	// A corrupted record is reported.
+	appendFile([]byte{0, 0, 0, 10, 1, 2, 3, 4, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10})
+	err = binloginfo.ReadBinlogFile(path, func(bin *binlog.Binlog) error { return nil })
+	c.Assert(err, ErrorMatches, ".*checksum mismatch")
+	_, err = binloginfo.NewFileClient(path)
+	c.Assert(err, ErrorMatches, ".*checksum mismatch")
+
+	// A record length larger than any binlog is treated as corruption rather than a partial record.
+	c.Assert(os.Truncate(path, size), IsNil)
+	appendFile([]byte{0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0, 1, 2})
+	err = binloginfo.ReadBinlogFile(path, func(bin *binlog.Binlog) error { return nil })
+	c.Assert(err, ErrorMatches, ".*too large")
+	_, err = binloginfo.NewFileClient(path)
+	c.Assert(err, ErrorMatches, ".*too large")
+	fi, err = os.Stat(path)
+	c.Assert(err, IsNil)
+	c.Assert(fi.Size(), Equals, size+10)
+}
+
 func getLatestBinlogPrewriteValue(c *C, pump *mockBinlogPump) *binlog.PrewriteValue {
 	var bin *binlog.Binlog
 	pump.mu.Lock()
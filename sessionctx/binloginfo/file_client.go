@@ -0,0 +1,160 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package binloginfo
+
+import (
+	"bufio"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/juju/errors"
+	"github.com/ngaut/log"
+	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tipb/go-binlog"
+	goctx "golang.org/x/net/context"
+	grpc "google.golang.org/grpc"
+)
+
+// fileClient is a binlog.PumpClient that appends the binlogs to a local file instead of
+// sending them to Pump, so the binlogs can be replicated by a tool that reads the file.
+// Each binlog is written as a record of the payload length, the CRC32 of the payload and the
+// payload, both the length and the CRC32 are 4 bytes in big endian.
+type fileClient struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFileClient creates a binlog client that writes the binlogs to the file at path, the new
+// binlogs are appended if the file exists. The records in the file are validated first, a record
+// partially written at the end of the file, e.g. by a crash, is truncated, so the new records
+// follow the last complete one.
+func NewFileClient(path string) (binlog.PumpClient, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if err = truncatePartialRecord(file); err != nil {
+		file.Close()
+		return nil, errors.Trace(err)
+	}
+	return &fileClient{file: file}, nil
+}
+
+// truncatePartialRecord truncates the partially written record at the end of the file.
+func truncatePartialRecord(file *os.File) error {
+	r := bufio.NewReader(file)
+	var size int64
+	for {
+		payload, err := readFileRecord(r)
+		if err == io.EOF {
+			return nil
+		} else if err == errPartialRecord {
+			log.Warnf("[binlog] truncate the partially written record at offset %d of %s", size, file.Name())
+			return errors.Trace(file.Truncate(size))
+		} else if err != nil {
+			return errors.Trace(err)
+		}
+		size += int64(fileRecordHeaderLen + len(payload))
+	}
+}
+
+const fileRecordHeaderLen = 8
+
+// errPartialRecord means the record at the end of the file is partially written.
+var errPartialRecord = errors.New("partially written binlog record")
+
+// readFileRecord reads the payload of the next record, it returns io.EOF at the end of the file, or
+// errPartialRecord if the file ends in the middle of the record.
+func readFileRecord(r io.Reader) ([]byte, error) {
+	header := make([]byte, fileRecordHeaderLen)
+	_, err := io.ReadFull(r, header)
+	if err == io.EOF {
+		return nil, io.EOF
+	} else if err == io.ErrUnexpectedEOF {
+		return nil, errPartialRecord
+	} else if err != nil {
+		return nil, errors.Trace(err)
+	}
+	// A binlog is about the size of the mutations of its transaction, which are limited by
+	// kv.TxnTotalSizeLimit, a much larger length means the file is corrupted.
+	length := binary.BigEndian.Uint32(header)
+	if uint64(length) > 2*uint64(kv.TxnTotalSizeLimit) {
+		return nil, errors.Errorf("binlog file is corrupted, record length %d is too large", length)
+	}
+	payload := make([]byte, length)
+	_, err = io.ReadFull(r, payload)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return nil, errPartialRecord
+	} else if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[4:]) {
+		return nil, errors.New("binlog file is corrupted, checksum mismatch")
+	}
+	return payload, nil
+}
+
+// WriteBinlog implements binlog.PumpClient interface.
+// The binlog is synced to the disk before it returns, so a committed transaction is never lost.
+func (c *fileClient) WriteBinlog(ctx goctx.Context, in *binlog.WriteBinlogReq, opts ...grpc.CallOption) (*binlog.WriteBinlogResp, error) {
+	record := make([]byte, fileRecordHeaderLen, fileRecordHeaderLen+len(in.Payload))
+	binary.BigEndian.PutUint32(record, uint32(len(in.Payload)))
+	binary.BigEndian.PutUint32(record[4:], crc32.ChecksumIEEE(in.Payload))
+	record = append(record, in.Payload...)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, err := c.file.Write(record); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if err := c.file.Sync(); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &binlog.WriteBinlogResp{}, nil
+}
+
+// PullBinlogs implements binlog.PumpClient interface.
+func (c *fileClient) PullBinlogs(ctx goctx.Context, in *binlog.PullBinlogReq, opts ...grpc.CallOption) (binlog.Pump_PullBinlogsClient, error) {
+	return nil, errors.New("pulling binlogs from a binlog file is not supported, use ReadBinlogFile instead")
+}
+
+// ReadBinlogFile reads the binlogs written by the file client in order, and calls fn with
+// each of them. A record that is partially written is treated as the end of the file.
+func ReadBinlogFile(path string, fn func(*binlog.Binlog) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer file.Close()
+
+	r := bufio.NewReader(file)
+	for {
+		payload, err := readFileRecord(r)
+		if err == io.EOF || err == errPartialRecord {
+			return nil
+		} else if err != nil {
+			return errors.Trace(err)
+		}
+		bin := &binlog.Binlog{}
+		if err = bin.Unmarshal(payload); err != nil {
+			return errors.Trace(err)
+		}
+		if err = fn(bin); err != nil {
+			return errors.Trace(err)
+		}
+	}
+}
@@ -20,6 +20,8 @@ import (
 
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/resource"
+	"github.com/pingcap/tidb/util/tracing"
 )
 
 const (
@@ -102,8 +104,8 @@ func (tc *TransactionContext) UpdateDeltaForTable(tableID int64, delta int64, co
 type SessionVars struct {
 	// UsersLock is a lock for user defined variables.
 	UsersLock sync.RWMutex
-	// Users are user defined variables.
-	Users map[string]string
+	// Users are user defined variables, the values are types.Datum, interface{} is used to avoid the import cycle.
+	Users map[string]interface{}
 	// Systems are system variables.
 	Systems map[string]string
 	// PreparedStmts stores prepared statement.
@@ -130,12 +132,25 @@ type SessionVars struct {
 	// ConnectionID is the connection id of the current session.
 	ConnectionID uint64
 
+	// ConnectAttrs are the connection attributes sent by the client in the handshake, e.g. _client_name
+	// and program_name. They are used to attribute the queries to the applications.
+	ConnectAttrs map[string]string
+
 	// User is the username with which the session login.
 	User string
 
+	// Account is the account matched when the session login, like "u@%", the resources are limited per account.
+	Account string
+
+	// ResourceLimits are the resource limits of the account when the session login.
+	ResourceLimits resource.Limits
+
 	// CurrentDB is the default database of this session.
 	CurrentDB string
 
+	// TableLocks are the tables locked by LOCK TABLES in this session.
+	TableLocks []TableLock
+
 	// StrictSQLMode indicates if the session is in strict mode.
 	StrictSQLMode bool
 
@@ -161,6 +176,9 @@ type SessionVars struct {
 	// LastFoundRows is the number of found rows of last query statement
 	LastFoundRows uint64
 
+	// PrevAffectedRows is the affected rows of the previous statement, it's returned by ROW_COUNT().
+	PrevAffectedRows int64
+
 	// StmtCtx holds variables for current executing statement.
 	StmtCtx *StatementContext
 
@@ -170,6 +188,9 @@ type SessionVars struct {
 	// AllowInSubqueryUnFolding can be set to true to fold in subquery
 	AllowInSubqueryUnFolding bool
 
+	// OptimizerUseInvisibleIndexes can be set to true to let the optimizer use the invisible indices.
+	OptimizerUseInvisibleIndexes bool
+
 	// CurrInsertValues is used to record current ValuesExpr's values.
 	// See http://dev.mysql.com/doc/refman/5.7/en/miscellaneous-functions.html#function_values
 	CurrInsertValues interface{}
@@ -203,6 +224,10 @@ type SessionVars struct {
 	// DistSQLScanConcurrency is the number of concurrent dist SQL scan worker.
 	DistSQLScanConcurrency int
 
+	// DistSQLLowPriorityConcurrency is the max number of concurrent dist SQL scan worker for low priority scans,
+	// 0 means no limit.
+	DistSQLLowPriorityConcurrency int
+
 	// IndexSerialScanConcurrency is the number of concurrent index serial scan worker.
 	IndexSerialScanConcurrency int
 
@@ -214,12 +239,92 @@ type SessionVars struct {
 
 	// CBO indicates if we use new planner with cbo.
 	CBO bool
+
+	// CostFactors are the factors of the cost model of the planner.
+	CostFactors CostFactors
+
+	// EnableCascadesPlanner indicates if the physical plan is built by the cascades planner.
+	EnableCascadesPlanner bool
+
+	// ApplyCacheCapacity is the max number of the inner rows an apply caches.
+	ApplyCacheCapacity int
+
+	// ProjectionConcurrency is the number of the workers of a projection.
+	ProjectionConcurrency int
+
+	// EnableChunkRPC indicates if the DAG request results are wanted in chunk encoded format.
+	EnableChunkRPC bool
+
+	// ForcePriority is the priority of the statements which don't specify one.
+	ForcePriority mysql.PriorityEnum
+
+	// RedactLog indicates if the literals in the SQL of the statement logs are replaced by "?".
+	RedactLog bool
+
+	// NotifySchemaChange indicates if the session is warned when a table used by it is changed.
+	NotifySchemaChange bool
+
+	// AllowPartialResult indicates if the SELECT statements skip the ranges of the unavailable regions.
+	AllowPartialResult bool
+
+	// SessionAlias is the name of the session written to the statement logs.
+	SessionAlias string
+
+	// UDFMaxSteps is the max number of the steps run by a call of a user-defined function.
+	UDFMaxSteps int
+
+	// UDFMaxStringLen is the max length of the strings created by a call of a user-defined function.
+	UDFMaxStringLen int
+
+	// EnableResultCache indicates if the read-only queries use the query result cache of the server.
+	EnableResultCache bool
+
+	// SkipResultsetMetadata indicates if the column definitions of the result sets are not sent to the client
+	// with the CLIENT_OPTIONAL_RESULTSET_METADATA capability.
+	SkipResultsetMetadata bool
+
+	// SQLNotes indicates if the warnings of level 'Note' are recorded.
+	SQLNotes bool
+
+	// AutoIncrementIncrement and AutoIncrementOffset decide the generated auto_increment IDs, they are
+	// AutoIncrementOffset + N * AutoIncrementIncrement.
+	AutoIncrementIncrement int
+	AutoIncrementOffset    int
+
+	// tracker records the changes of the session state to be sent to the client.
+	tracker sessionTracker
+}
+
+// CostFactors are the factors of the cost model, the cost of a plan is the sum of the counts of the rows
+// which are processed by the operators multiplied by the factors.
+type CostFactors struct {
+	// CPU is the cost to process a row, e.g. filter, aggregate or sort it.
+	CPU float64
+	// Network is the cost to send a row from the storage to TiDB.
+	Network float64
+	// Scan and DescScan are the costs to scan a row in the storage, in the order and the reverse order.
+	Scan     float64
+	DescScan float64
+	// Seek is the cost to start a request which seeks a batch of rows, e.g. a lookup of an index join.
+	Seek float64
+	// Memory is the cost to keep a row in the memory, e.g. in the hash table of a join.
+	Memory float64
+}
+
+// DefCostFactors are the default factors of the cost model.
+var DefCostFactors = CostFactors{
+	CPU:      DefOptCPUFactor,
+	Network:  DefOptNetworkFactor,
+	Scan:     DefOptScanFactor,
+	DescScan: DefOptDescScanFactor,
+	Seek:     DefOptSeekFactor,
+	Memory:   DefOptMemoryFactor,
 }
 
 // NewSessionVars creates a session vars object.
 func NewSessionVars() *SessionVars {
 	return &SessionVars{
-		Users:                      make(map[string]string),
+		Users:                      make(map[string]interface{}),
 		Systems:                    make(map[string]string),
 		PreparedStmts:              make(map[uint32]interface{}),
 		PreparedStmtNameToID:       make(map[string]uint32),
@@ -237,6 +342,17 @@ func NewSessionVars() *SessionVars {
 		DistSQLScanConcurrency:     DefDistSQLScanConcurrency,
 		MaxRowCountForINLJ:         DefMaxRowCountForINLJ,
 		CBO:                        true,
+		CostFactors:                DefCostFactors,
+		EnableCascadesPlanner:      DefEnableCascadesPlanner,
+		ApplyCacheCapacity:         DefApplyCacheCapacity,
+		ProjectionConcurrency:      DefProjectionConcurrency,
+		EnableChunkRPC:             DefEnableChunkRPC,
+		ForcePriority:              DefForcePriority,
+		UDFMaxSteps:                DefUDFMaxSteps,
+		UDFMaxStringLen:            DefUDFMaxStringLen,
+		SQLNotes:                   true,
+		AutoIncrementIncrement:     1,
+		AutoIncrementOffset:        1,
 	}
 }
 
@@ -298,6 +414,14 @@ func (s *SessionVars) GetNextPreparedStmtID() uint32 {
 	return s.preparedStmtID
 }
 
+// UpdatePreparedStmtID makes sure the prepared statement ids generated later are larger than id, it's called
+// when a prepared statement is imported with its original id.
+func (s *SessionVars) UpdatePreparedStmtID(id uint32) {
+	if s.preparedStmtID < id {
+		s.preparedStmtID = id
+	}
+}
+
 // GetTimeZone returns the value of time_zone session variable.
 func (s *SessionVars) GetTimeZone() *time.Location {
 	loc := s.TimeZone
@@ -323,6 +447,27 @@ type TableDelta struct {
 	Count int64
 }
 
+// TableLock is a table locked by LOCK TABLES in the session.
+type TableLock struct {
+	SchemaID int64
+	TableID  int64
+	// Write indicates the table is locked for WRITE, or it's locked for READ.
+	Write bool
+}
+
+// SQL warning levels.
+const (
+	WarnLevelError   = "Error"
+	WarnLevelWarning = "Warning"
+	WarnLevelNote    = "Note"
+)
+
+// SQLWarn relates a sql warning and it's level.
+type SQLWarn struct {
+	Level string
+	Err   error
+}
+
 // StatementContext contains variables for a statement.
 // It should be reset before executing a statement.
 type StatementContext struct {
@@ -335,13 +480,18 @@ type StatementContext struct {
 	TruncateAsWarning    bool
 	OverflowAsWarning    bool
 	InShowWarning        bool
+	InExecuteStmt        bool
+	IgnoreNotes          bool
+	// OptimizerTrace records how the plan is chosen if it isn't nil.
+	OptimizerTrace *tracing.OptimizerTrace
 
 	// mu struct holds variables that change during execution.
 	mu struct {
 		sync.Mutex
 		affectedRows uint64
 		foundRows    uint64
-		warnings     []error
+		warnings     []SQLWarn
+		traceSpan    *tracing.Span
 	}
 
 	// Copied from SessionVars.TimeZone.
@@ -349,6 +499,22 @@ type StatementContext struct {
 	Priority mysql.PriorityEnum
 }
 
+// SetTraceSpan sets the span of the work being traced, the requests sent to the storage are
+// traced as its children. A nil span turns the tracing off.
+func (sc *StatementContext) SetTraceSpan(span *tracing.Span) {
+	sc.mu.Lock()
+	sc.mu.traceSpan = span
+	sc.mu.Unlock()
+}
+
+// TraceSpan returns the span of the work being traced, it returns nil if the statement isn't traced.
+func (sc *StatementContext) TraceSpan() *tracing.Span {
+	sc.mu.Lock()
+	span := sc.mu.traceSpan
+	sc.mu.Unlock()
+	return span
+}
+
 // AddAffectedRows adds affected rows.
 func (sc *StatementContext) AddAffectedRows(rows uint64) {
 	sc.mu.Lock()
@@ -380,15 +546,15 @@ func (sc *StatementContext) AddFoundRows(rows uint64) {
 }
 
 // GetWarnings gets warnings.
-func (sc *StatementContext) GetWarnings() []error {
+func (sc *StatementContext) GetWarnings() []SQLWarn {
 	sc.mu.Lock()
-	warns := make([]error, len(sc.mu.warnings))
+	warns := make([]SQLWarn, len(sc.mu.warnings))
 	copy(warns, sc.mu.warnings)
 	sc.mu.Unlock()
 	return warns
 }
 
-// WarningCount gets warning count.
+// WarningCount gets warning count, the notes and the errors are counted too.
 func (sc *StatementContext) WarningCount() uint16 {
 	if sc.InShowWarning {
 		return 0
@@ -399,18 +565,48 @@ func (sc *StatementContext) WarningCount() uint16 {
 	return wc
 }
 
+// ErrorCount gets the count of the errors.
+func (sc *StatementContext) ErrorCount() uint16 {
+	sc.mu.Lock()
+	var ec uint16
+	for _, warn := range sc.mu.warnings {
+		if warn.Level == WarnLevelError {
+			ec++
+		}
+	}
+	sc.mu.Unlock()
+	return ec
+}
+
 // SetWarnings sets warnings.
-func (sc *StatementContext) SetWarnings(warns []error) {
+func (sc *StatementContext) SetWarnings(warns []SQLWarn) {
 	sc.mu.Lock()
 	sc.mu.warnings = warns
 	sc.mu.Unlock()
 }
 
-// AppendWarning appends a warning.
+// AppendWarning appends a warning with level 'Warning'.
 func (sc *StatementContext) AppendWarning(warn error) {
+	sc.appendWarning(WarnLevelWarning, warn)
+}
+
+// AppendNote appends a warning with level 'Note', it's ignored if sql_notes is off.
+func (sc *StatementContext) AppendNote(warn error) {
+	if sc.IgnoreNotes {
+		return
+	}
+	sc.appendWarning(WarnLevelNote, warn)
+}
+
+// AppendError appends a warning with level 'Error', it's the error which fails the statement.
+func (sc *StatementContext) AppendError(warn error) {
+	sc.appendWarning(WarnLevelError, warn)
+}
+
+func (sc *StatementContext) appendWarning(level string, warn error) {
 	sc.mu.Lock()
 	if len(sc.mu.warnings) < math.MaxUint16 {
-		sc.mu.warnings = append(sc.mu.warnings, warn)
+		sc.mu.warnings = append(sc.mu.warnings, SQLWarn{Level: level, Err: warn})
 	}
 	sc.mu.Unlock()
 }
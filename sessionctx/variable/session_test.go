@@ -14,7 +14,10 @@
 package variable_test
 
 import (
+	"errors"
+
 	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/util/mock"
 )
 
@@ -45,6 +48,18 @@ func (*testSessionSuite) TestSession(c *C) {
 	ctx.GetSessionVars().SetLastInsertID(1)
 	c.Assert(ctx.GetSessionVars().LastInsertID, Equals, uint64(1))
 
+	// For warnings
+	ss.AppendWarning(errors.New("warn"))
+	ss.AppendNote(errors.New("note"))
+	ss.AppendError(errors.New("err"))
+	c.Assert(ss.WarningCount(), Equals, uint16(3))
+	c.Assert(ss.ErrorCount(), Equals, uint16(1))
+	warns := ss.GetWarnings()
+	c.Assert(warns[0].Level, Equals, variable.WarnLevelWarning)
+	c.Assert(warns[1].Level, Equals, variable.WarnLevelNote)
+	c.Assert(warns[2].Level, Equals, variable.WarnLevelError)
+	c.Assert(warns[2].Err.Error(), Equals, "err")
+
 	ss.ResetForRetry()
 	c.Assert(ss.AffectedRows(), Equals, uint64(0))
 	c.Assert(ss.FoundRows(), Equals, uint64(0))
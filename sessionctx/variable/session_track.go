@@ -0,0 +1,152 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package variable
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pingcap/tidb/mysql"
+)
+
+// The values of session_track_transaction_info.
+const (
+	TrackTxnInfoOff             = "OFF"
+	TrackTxnInfoState           = "STATE"
+	TrackTxnInfoCharacteristics = "CHARACTERISTICS"
+)
+
+// SessionStateChange is a change of the session state, it's sent to the client in the OK packet if the client
+// has the CLIENT_SESSION_TRACK capability. The proxies use it to know whether a connection can be switched
+// to another server.
+type SessionStateChange struct {
+	// Type is one of the mysql.SessionTrack* types.
+	Type byte
+	// Name is the name of the system variable for SessionTrackSystemVariables.
+	Name string
+	// Value is the new value of the system variable, the new default database, "1" for SessionTrackStateChange,
+	// or the transaction state for SessionTrackTransactionState.
+	Value string
+}
+
+// sessionTracker records the changes of the session state which are not sent to the client yet.
+type sessionTracker struct {
+	sysVars      map[string]string
+	schema       bool
+	stateChanged bool
+	// txnState is the transaction state last sent to the client.
+	txnState string
+}
+
+// TrackSysVar records the change of the session system variable, it's sent to the client if the variable is
+// listed in session_track_system_variables.
+func (s *SessionVars) TrackSysVar(name, value string) {
+	s.tracker.stateChanged = true
+	tracked := s.trackedSystemVar(SessionTrackSystemVariables)
+	if tracked == "" {
+		return
+	}
+	if tracked != "*" {
+		found := false
+		for _, v := range strings.Split(tracked, ",") {
+			if strings.EqualFold(strings.TrimSpace(v), name) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return
+		}
+	}
+	if s.tracker.sysVars == nil {
+		s.tracker.sysVars = make(map[string]string)
+	}
+	s.tracker.sysVars[name] = value
+}
+
+// TrackSchema records the change of the default database.
+func (s *SessionVars) TrackSchema() {
+	s.tracker.stateChanged = true
+	s.tracker.schema = true
+}
+
+// TrackStateChange records the change of the other session states, e.g. the user variables and the prepared
+// statements, the client only knows that the state is changed.
+func (s *SessionVars) TrackStateChange() {
+	s.tracker.stateChanged = true
+}
+
+// TakeSessionStateChanges returns the changes of the session state recorded since the last call, the changes
+// which are not tracked by the session_track_* variables are dropped.
+func (s *SessionVars) TakeSessionStateChanges() []SessionStateChange {
+	var changes []SessionStateChange
+	names := make([]string, 0, len(s.tracker.sysVars))
+	for name := range s.tracker.sysVars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		changes = append(changes, SessionStateChange{Type: mysql.SessionTrackSystemVariables, Name: name, Value: s.tracker.sysVars[name]})
+	}
+	if s.tracker.schema && trackOn(s.trackedSystemVar(SessionTrackSchema)) {
+		changes = append(changes, SessionStateChange{Type: mysql.SessionTrackSchema, Value: s.CurrentDB})
+	}
+	if s.tracker.stateChanged && trackOn(s.trackedSystemVar(SessionTrackStateChange)) {
+		changes = append(changes, SessionStateChange{Type: mysql.SessionTrackStateChange, Value: "1"})
+	}
+	if txnInfo := s.trackedSystemVar(SessionTrackTransactionInfo); !strings.EqualFold(txnInfo, TrackTxnInfoOff) && txnInfo != "" {
+		if state := s.transactionState(); state != s.tracker.txnState {
+			changes = append(changes, SessionStateChange{Type: mysql.SessionTrackTransactionState, Value: state})
+			s.tracker.txnState = state
+		}
+	}
+	s.tracker.sysVars = nil
+	s.tracker.schema = false
+	s.tracker.stateChanged = false
+	return changes
+}
+
+// transactionState returns the transaction state in the format of MySQL, the 8 characters are the type of the
+// transaction, the reads and writes done in it, the unsafe statements, the result sets and the table locks.
+// The reads and the unsafe statements are not tracked, so they're always "_".
+func (s *SessionVars) transactionState() string {
+	state := []byte("________")
+	if s.InTxn() {
+		if s.IsAutocommit() {
+			state[0] = 'T'
+		} else {
+			state[0] = 'I'
+		}
+		if len(s.TxnCtx.TableDeltaMap) > 0 {
+			state[4] = 'W'
+		}
+	}
+	if len(s.TableLocks) > 0 {
+		state[7] = 'L'
+	}
+	return string(state)
+}
+
+// trackedSystemVar returns the value of a session_track_* variable, the default value is returned if it's not
+// loaded for the session.
+func (s *SessionVars) trackedSystemVar(name string) string {
+	if v, ok := s.Systems[name]; ok {
+		return v
+	}
+	return SysVars[name].Value
+}
+
+func trackOn(value string) bool {
+	return strings.EqualFold(value, "ON") || value == "1"
+}
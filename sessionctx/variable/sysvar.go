@@ -14,9 +14,12 @@
 package variable
 
 import (
+	"math"
 	"strconv"
 	"strings"
 
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/config"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/terror"
 )
@@ -31,6 +34,25 @@ const (
 	ScopeGlobal ScopeFlag = 1 << 0
 	// ScopeSession means the system variable can only be changed in current session.
 	ScopeSession ScopeFlag = 1 << 1
+	// ScopeInstance means the system variable is a setting of this TiDB server, it's changed with SET GLOBAL,
+	// but the value is neither persisted nor propagated to the other servers.
+	ScopeInstance ScopeFlag = 1 << 2
+)
+
+// SysVarType is the type of the value of a system variable, the value to be set is validated by the type.
+type SysVarType uint8
+
+const (
+	// TypeStr is the type of the variables whose values aren't validated by type.
+	TypeStr SysVarType = iota
+	// TypeBool is the type of the variables which can be set to ON, OFF, 1 or 0.
+	TypeBool
+	// TypeInt is the type of the integer variables, a value out of [MinValue, MaxValue] is clamped with a warning.
+	TypeInt
+	// TypeEnum is the type of the variables whose value is one of PossibleValues, case insensitive.
+	TypeEnum
+	// TypeFloat is the type of the float variables, a value out of [MinValue, MaxValue] is clamped with a warning.
+	TypeFloat
 )
 
 // SysVar is for system variable.
@@ -43,6 +65,88 @@ type SysVar struct {
 
 	// Value is the variable value.
 	Value string
+
+	// Type is the type of the value, TypeStr by default.
+	Type SysVarType
+
+	// MinValue and MaxValue are the range of a TypeInt or TypeFloat variable.
+	MinValue int64
+	MaxValue int64
+
+	// PossibleValues are the values of a TypeEnum variable.
+	PossibleValues []string
+
+	// Validation checks the value after it's validated by the type, and returns the value to be set.
+	Validation func(vars *SessionVars, value string, scope ScopeFlag) (string, error)
+
+	// SetGlobal is called when the global value of the variable is changed, on every server of the cluster.
+	// For a ScopeInstance variable, it's only called on this server and it has to keep the value.
+	SetGlobal func(value string) error
+
+	// GetGlobal returns the value of a ScopeInstance variable.
+	GetGlobal func() string
+}
+
+// Validate checks the value to be set for the variable in the scope, and returns the normalized value.
+// The vars is used to append the warnings, it may be nil.
+func (sv *SysVar) Validate(vars *SessionVars, value string, scope ScopeFlag) (string, error) {
+	value, err := sv.validateByType(vars, value)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	if sv.Validation != nil {
+		return sv.Validation(vars, value, scope)
+	}
+	return value, nil
+}
+
+func (sv *SysVar) validateByType(vars *SessionVars, value string) (string, error) {
+	switch sv.Type {
+	case TypeBool:
+		if value == "1" || value == "0" {
+			return value, nil
+		}
+		if strings.EqualFold(value, "ON") || strings.EqualFold(value, "OFF") {
+			return strings.ToUpper(value), nil
+		}
+		return "", ErrWrongValueForVar.GenByArgs(sv.Name, value)
+	case TypeInt:
+		val, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return "", ErrWrongTypeForVar.GenByArgs(sv.Name)
+		}
+		if val < sv.MinValue || val > sv.MaxValue {
+			if vars != nil {
+				vars.StmtCtx.AppendWarning(ErrTruncatedWrongValue.GenByArgs(sv.Name, value))
+			}
+			if val < sv.MinValue {
+				val = sv.MinValue
+			} else {
+				val = sv.MaxValue
+			}
+		}
+		return strconv.FormatInt(val, 10), nil
+	case TypeFloat:
+		val, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return "", ErrWrongTypeForVar.GenByArgs(sv.Name)
+		}
+		if val < float64(sv.MinValue) || val > float64(sv.MaxValue) {
+			if vars != nil {
+				vars.StmtCtx.AppendWarning(ErrTruncatedWrongValue.GenByArgs(sv.Name, value))
+			}
+			val = math.Max(float64(sv.MinValue), math.Min(val, float64(sv.MaxValue)))
+		}
+		return strconv.FormatFloat(val, 'f', -1, 64), nil
+	case TypeEnum:
+		for _, v := range sv.PossibleValues {
+			if strings.EqualFold(v, value) {
+				return v, nil
+			}
+		}
+		return "", ErrWrongValueForVar.GenByArgs(sv.Name, value)
+	}
+	return value, nil
 }
 
 // SysVars is global sys vars map.
@@ -56,20 +160,26 @@ func GetSysVar(name string) *SysVar {
 
 // Variable error codes.
 const (
-	CodeUnknownStatusVar terror.ErrCode = 1
-	CodeUnknownSystemVar terror.ErrCode = 1193
-	CodeIncorrectScope   terror.ErrCode = 1238
-	CodeUnknownTimeZone  terror.ErrCode = 1298
-	CodeReadOnly         terror.ErrCode = 1621
+	CodeUnknownStatusVar    terror.ErrCode = 1
+	CodeUnknownSystemVar    terror.ErrCode = 1193
+	CodeIncorrectScope      terror.ErrCode = 1238
+	CodeUnknownTimeZone     terror.ErrCode = 1298
+	CodeReadOnly            terror.ErrCode = 1621
+	CodeWrongValueForVar    terror.ErrCode = 1231
+	CodeWrongTypeForVar     terror.ErrCode = 1232
+	CodeTruncatedWrongValue terror.ErrCode = 1292
 )
 
 // Variable errors
 var (
-	UnknownStatusVar   = terror.ClassVariable.New(CodeUnknownStatusVar, "unknown status variable")
-	UnknownSystemVar   = terror.ClassVariable.New(CodeUnknownSystemVar, "unknown system variable '%s'")
-	ErrIncorrectScope  = terror.ClassVariable.New(CodeIncorrectScope, "Incorrect variable scope")
-	ErrUnknownTimeZone = terror.ClassVariable.New(CodeUnknownTimeZone, "unknown or incorrect time zone: %s")
-	ErrReadOnly        = terror.ClassVariable.New(CodeReadOnly, "variable is read only")
+	UnknownStatusVar       = terror.ClassVariable.New(CodeUnknownStatusVar, "unknown status variable")
+	UnknownSystemVar       = terror.ClassVariable.New(CodeUnknownSystemVar, "unknown system variable '%s'")
+	ErrIncorrectScope      = terror.ClassVariable.New(CodeIncorrectScope, "Incorrect variable scope")
+	ErrUnknownTimeZone     = terror.ClassVariable.New(CodeUnknownTimeZone, "unknown or incorrect time zone: %s")
+	ErrReadOnly            = terror.ClassVariable.New(CodeReadOnly, "variable is read only")
+	ErrWrongValueForVar    = terror.ClassVariable.New(CodeWrongValueForVar, "Variable '%s' can't be set to the value of '%s'")
+	ErrWrongTypeForVar     = terror.ClassVariable.New(CodeWrongTypeForVar, "Incorrect argument type to variable '%s'")
+	ErrTruncatedWrongValue = terror.ClassVariable.New(CodeTruncatedWrongValue, "Truncated incorrect %s value: '%s'")
 )
 
 func init() {
@@ -80,10 +190,13 @@ func init() {
 
 	// Register terror to mysql error map.
 	mySQLErrCodes := map[terror.ErrCode]uint16{
-		CodeUnknownSystemVar: mysql.ErrUnknownSystemVariable,
-		CodeIncorrectScope:   mysql.ErrIncorrectGlobalLocalVar,
-		CodeUnknownTimeZone:  mysql.ErrUnknownTimeZone,
-		CodeReadOnly:         mysql.ErrVariableIsReadonly,
+		CodeUnknownSystemVar:    mysql.ErrUnknownSystemVariable,
+		CodeIncorrectScope:      mysql.ErrIncorrectGlobalLocalVar,
+		CodeUnknownTimeZone:     mysql.ErrUnknownTimeZone,
+		CodeReadOnly:            mysql.ErrVariableIsReadonly,
+		CodeWrongValueForVar:    mysql.ErrWrongValueForVar,
+		CodeWrongTypeForVar:     mysql.ErrWrongTypeForVar,
+		CodeTruncatedWrongValue: mysql.ErrTruncatedWrongValue,
 	}
 	terror.ErrClassToMySQLCodes[terror.ClassVariable] = mySQLErrCodes
 }
@@ -95,523 +208,589 @@ func boolToIntStr(b bool) string {
 	return "0"
 }
 
+func floatToStr(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
 // we only support MySQL now
 var defaultSysVars = []*SysVar{
-	{ScopeGlobal, "gtid_mode", "OFF"},
-	{ScopeGlobal, "flush_time", "0"},
-	{ScopeSession, "pseudo_slave_mode", ""},
-	{ScopeNone, "performance_schema_max_mutex_classes", "200"},
-	{ScopeGlobal | ScopeSession, "low_priority_updates", "OFF"},
-	{ScopeGlobal | ScopeSession, "session_track_gtids", ""},
-	{ScopeGlobal | ScopeSession, "ndbinfo_max_rows", ""},
-	{ScopeGlobal | ScopeSession, "ndb_index_stat_option", ""},
-	{ScopeGlobal | ScopeSession, "old_passwords", "0"},
-	{ScopeNone, "innodb_version", "5.6.25"},
-	{ScopeGlobal, "max_connections", "151"},
-	{ScopeGlobal | ScopeSession, "big_tables", "OFF"},
-	{ScopeNone, "skip_external_locking", "ON"},
-	{ScopeGlobal, "slave_pending_jobs_size_max", "16777216"},
-	{ScopeNone, "innodb_sync_array_size", "1"},
-	{ScopeSession, "rand_seed2", ""},
-	{ScopeGlobal, "validate_password_number_count", "1"},
-	{ScopeSession, "gtid_next", ""},
-	{ScopeGlobal | ScopeSession, "sql_select_limit", "18446744073709551615"},
-	{ScopeGlobal, "ndb_show_foreign_key_mock_tables", ""},
-	{ScopeNone, "multi_range_count", "256"},
-	{ScopeGlobal | ScopeSession, "default_week_format", "0"},
-	{ScopeGlobal | ScopeSession, "binlog_error_action", "IGNORE_ERROR"},
-	{ScopeGlobal, "slave_transaction_retries", "10"},
-	{ScopeGlobal | ScopeSession, "default_storage_engine", "InnoDB"},
-	{ScopeNone, "ft_query_expansion_limit", "20"},
-	{ScopeGlobal, "max_connect_errors", "100"},
-	{ScopeGlobal, "sync_binlog", "0"},
-	{ScopeNone, "max_digest_length", "1024"},
-	{ScopeNone, "innodb_force_load_corrupted", "OFF"},
-	{ScopeNone, "performance_schema_max_table_handles", "4000"},
-	{ScopeGlobal, "innodb_fast_shutdown", "1"},
-	{ScopeNone, "ft_max_word_len", "84"},
-	{ScopeGlobal, "log_backward_compatible_user_definitions", ""},
-	{ScopeNone, "lc_messages_dir", "/usr/local/mysql-5.6.25-osx10.8-x86_64/share/"},
-	{ScopeGlobal, "ft_boolean_syntax", "+ -><()~*:\"\"&|"},
-	{ScopeGlobal, "table_definition_cache", "1400"},
-	{ScopeNone, "skip_name_resolve", "OFF"},
-	{ScopeNone, "performance_schema_max_file_handles", "32768"},
-	{ScopeSession, "transaction_allow_batching", ""},
-	{ScopeGlobal | ScopeSession, SQLModeVar, "STRICT_TRANS_TABLES,NO_ENGINE_SUBSTITUTION"},
-	{ScopeNone, "performance_schema_max_statement_classes", "168"},
-	{ScopeGlobal, "server_id", "0"},
-	{ScopeGlobal, "innodb_flushing_avg_loops", "30"},
-	{ScopeGlobal | ScopeSession, "tmp_table_size", "16777216"},
-	{ScopeGlobal, "innodb_max_purge_lag", "0"},
-	{ScopeGlobal | ScopeSession, "preload_buffer_size", "32768"},
-	{ScopeGlobal, "slave_checkpoint_period", "300"},
-	{ScopeGlobal, "check_proxy_users", ""},
-	{ScopeNone, "have_query_cache", "YES"},
-	{ScopeGlobal, "innodb_flush_log_at_timeout", "1"},
-	{ScopeGlobal, "innodb_max_undo_log_size", ""},
-	{ScopeGlobal | ScopeSession, "range_alloc_block_size", "4096"},
-	{ScopeGlobal, "connect_timeout", "10"},
-	{ScopeGlobal | ScopeSession, "collation_server", "latin1_swedish_ci"},
-	{ScopeNone, "have_rtree_keys", "YES"},
-	{ScopeGlobal, "innodb_old_blocks_pct", "37"},
-	{ScopeGlobal, "innodb_file_format", "Antelope"},
-	{ScopeGlobal, "innodb_compression_failure_threshold_pct", "5"},
-	{ScopeNone, "performance_schema_events_waits_history_long_size", "10000"},
-	{ScopeGlobal, "innodb_checksum_algorithm", "innodb"},
-	{ScopeNone, "innodb_ft_sort_pll_degree", "2"},
-	{ScopeNone, "thread_stack", "262144"},
-	{ScopeGlobal, "relay_log_info_repository", "FILE"},
-	{ScopeGlobal | ScopeSession, "sql_log_bin", "ON"},
-	{ScopeGlobal, "super_read_only", ""},
-	{ScopeGlobal | ScopeSession, "max_delayed_threads", "20"},
-	{ScopeNone, "protocol_version", "10"},
-	{ScopeGlobal | ScopeSession, "new", "OFF"},
-	{ScopeGlobal | ScopeSession, "myisam_sort_buffer_size", "8388608"},
-	{ScopeGlobal | ScopeSession, "optimizer_trace_offset", "-1"},
-	{ScopeGlobal, "innodb_buffer_pool_dump_at_shutdown", "OFF"},
-	{ScopeGlobal | ScopeSession, "sql_notes", "ON"},
-	{ScopeGlobal, "innodb_cmp_per_index_enabled", "OFF"},
-	{ScopeGlobal, "innodb_ft_server_stopword_table", ""},
-	{ScopeNone, "performance_schema_max_file_instances", "7693"},
-	{ScopeNone, "log_output", "FILE"},
-	{ScopeGlobal, "binlog_group_commit_sync_delay", ""},
-	{ScopeGlobal, "binlog_group_commit_sync_no_delay_count", ""},
-	{ScopeNone, "have_crypt", "YES"},
-	{ScopeGlobal, "innodb_log_write_ahead_size", ""},
-	{ScopeNone, "innodb_log_group_home_dir", "./"},
-	{ScopeNone, "performance_schema_events_statements_history_size", "10"},
-	{ScopeGlobal, "general_log", "OFF"},
-	{ScopeGlobal, "validate_password_dictionary_file", ""},
-	{ScopeGlobal, "binlog_order_commits", "ON"},
-	{ScopeGlobal, "master_verify_checksum", "OFF"},
-	{ScopeGlobal, "key_cache_division_limit", "100"},
-	{ScopeGlobal, "rpl_semi_sync_master_trace_level", ""},
-	{ScopeGlobal | ScopeSession, "max_insert_delayed_threads", "20"},
-	{ScopeNone, "performance_schema_session_connect_attrs_size", "512"},
-	{ScopeGlobal | ScopeSession, "time_zone", "SYSTEM"},
-	{ScopeGlobal, "innodb_max_dirty_pages_pct", "75"},
-	{ScopeGlobal, "innodb_file_per_table", "ON"},
-	{ScopeGlobal, "innodb_log_compressed_pages", "ON"},
-	{ScopeGlobal, "master_info_repository", "FILE"},
-	{ScopeGlobal, "rpl_stop_slave_timeout", "31536000"},
-	{ScopeNone, "skip_networking", "OFF"},
-	{ScopeGlobal, "innodb_monitor_reset", ""},
-	{ScopeNone, "have_ssl", "DISABLED"},
-	{ScopeNone, "system_time_zone", "CST"},
-	{ScopeGlobal, "innodb_print_all_deadlocks", "OFF"},
-	{ScopeNone, "innodb_autoinc_lock_mode", "1"},
-	{ScopeGlobal, "slave_net_timeout", "3600"},
-	{ScopeGlobal, "key_buffer_size", "8388608"},
-	{ScopeGlobal | ScopeSession, "foreign_key_checks", "ON"},
-	{ScopeGlobal, "host_cache_size", "279"},
-	{ScopeGlobal, "delay_key_write", "ON"},
-	{ScopeNone, "metadata_locks_cache_size", "1024"},
-	{ScopeNone, "innodb_force_recovery", "0"},
-	{ScopeGlobal, "innodb_file_format_max", "Antelope"},
-	{ScopeGlobal | ScopeSession, "debug", ""},
-	{ScopeGlobal, "log_warnings", "1"},
-	{ScopeGlobal, "offline_mode", ""},
-	{ScopeGlobal | ScopeSession, "innodb_strict_mode", "OFF"},
-	{ScopeGlobal, "innodb_rollback_segments", "128"},
-	{ScopeGlobal | ScopeSession, "join_buffer_size", "262144"},
-	{ScopeNone, "innodb_mirrored_log_groups", "1"},
-	{ScopeGlobal, "max_binlog_size", "1073741824"},
-	{ScopeGlobal, "sync_master_info", "10000"},
-	{ScopeGlobal, "concurrent_insert", "AUTO"},
-	{ScopeGlobal, "innodb_adaptive_hash_index", "ON"},
-	{ScopeGlobal, "innodb_ft_enable_stopword", "ON"},
-	{ScopeGlobal, "general_log_file", "/usr/local/mysql/data/localhost.log"},
-	{ScopeGlobal | ScopeSession, "innodb_support_xa", "ON"},
-	{ScopeGlobal, "innodb_compression_level", "6"},
-	{ScopeNone, "innodb_file_format_check", "ON"},
-	{ScopeNone, "myisam_mmap_size", "18446744073709551615"},
-	{ScopeGlobal, "init_slave", ""},
-	{ScopeNone, "innodb_buffer_pool_instances", "8"},
-	{ScopeGlobal | ScopeSession, "block_encryption_mode", "aes-128-ecb"},
-	{ScopeGlobal | ScopeSession, "max_length_for_sort_data", "1024"},
-	{ScopeNone, "character_set_system", "utf8"},
-	{ScopeGlobal | ScopeSession, "interactive_timeout", "28800"},
-	{ScopeGlobal, "innodb_optimize_fulltext_only", "OFF"},
-	{ScopeNone, "character_sets_dir", "/usr/local/mysql-5.6.25-osx10.8-x86_64/share/charsets/"},
-	{ScopeGlobal | ScopeSession, "query_cache_type", "OFF"},
-	{ScopeNone, "innodb_rollback_on_timeout", "OFF"},
-	{ScopeGlobal | ScopeSession, "query_alloc_block_size", "8192"},
-	{ScopeGlobal, "slave_compressed_protocol", "OFF"},
-	{ScopeGlobal, "init_connect", ""},
-	{ScopeGlobal, "rpl_semi_sync_slave_trace_level", ""},
-	{ScopeNone, "have_compress", "YES"},
-	{ScopeNone, "thread_concurrency", "10"},
-	{ScopeGlobal | ScopeSession, "query_prealloc_size", "8192"},
-	{ScopeNone, "relay_log_space_limit", "0"},
-	{ScopeGlobal | ScopeSession, "max_user_connections", "0"},
-	{ScopeNone, "performance_schema_max_thread_classes", "50"},
-	{ScopeGlobal, "innodb_api_trx_level", "0"},
-	{ScopeNone, "disconnect_on_expired_password", "ON"},
-	{ScopeNone, "performance_schema_max_file_classes", "50"},
-	{ScopeGlobal, "expire_logs_days", "0"},
-	{ScopeGlobal | ScopeSession, "binlog_rows_query_log_events", "OFF"},
-	{ScopeGlobal, "validate_password_policy", "1"},
-	{ScopeGlobal, "default_password_lifetime", ""},
-	{ScopeNone, "pid_file", "/usr/local/mysql/data/localhost.pid"},
-	{ScopeNone, "innodb_undo_tablespaces", "0"},
-	{ScopeGlobal, "innodb_status_output_locks", "OFF"},
-	{ScopeNone, "performance_schema_accounts_size", "100"},
-	{ScopeGlobal | ScopeSession, "max_error_count", "64"},
-	{ScopeGlobal, "max_write_lock_count", "18446744073709551615"},
-	{ScopeNone, "performance_schema_max_socket_instances", "322"},
-	{ScopeNone, "performance_schema_max_table_instances", "12500"},
-	{ScopeGlobal, "innodb_stats_persistent_sample_pages", "20"},
-	{ScopeGlobal, "show_compatibility_56", ""},
-	{ScopeGlobal, "log_slow_slave_statements", "OFF"},
-	{ScopeNone, "innodb_open_files", "2000"},
-	{ScopeGlobal, "innodb_spin_wait_delay", "6"},
-	{ScopeGlobal, "thread_cache_size", "9"},
-	{ScopeGlobal, "log_slow_admin_statements", "OFF"},
-	{ScopeNone, "innodb_checksums", "ON"},
-	{ScopeNone, "hostname", "localhost"},
-	{ScopeGlobal | ScopeSession, "auto_increment_offset", "1"},
-	{ScopeNone, "ft_stopword_file", "(built-in)"},
-	{ScopeGlobal, "innodb_max_dirty_pages_pct_lwm", "0"},
-	{ScopeGlobal, "log_queries_not_using_indexes", "OFF"},
-	{ScopeSession, "timestamp", ""},
-	{ScopeGlobal | ScopeSession, "query_cache_wlock_invalidate", "OFF"},
-	{ScopeGlobal | ScopeSession, "sql_buffer_result", "OFF"},
-	{ScopeGlobal | ScopeSession, "character_set_filesystem", "binary"},
-	{ScopeGlobal | ScopeSession, "collation_database", "latin1_swedish_ci"},
-	{ScopeGlobal | ScopeSession, "auto_increment_increment", "1"},
-	{ScopeGlobal | ScopeSession, "max_heap_table_size", "16777216"},
-	{ScopeGlobal | ScopeSession, "div_precision_increment", "4"},
-	{ScopeGlobal, "innodb_lru_scan_depth", "1024"},
-	{ScopeGlobal, "innodb_purge_rseg_truncate_frequency", ""},
-	{ScopeGlobal | ScopeSession, "sql_auto_is_null", "OFF"},
-	{ScopeNone, "innodb_api_enable_binlog", "OFF"},
-	{ScopeGlobal | ScopeSession, "innodb_ft_user_stopword_table", ""},
-	{ScopeNone, "server_id_bits", "32"},
-	{ScopeGlobal, "innodb_log_checksum_algorithm", ""},
-	{ScopeNone, "innodb_buffer_pool_load_at_startup", "OFF"},
-	{ScopeGlobal | ScopeSession, "sort_buffer_size", "262144"},
-	{ScopeGlobal, "innodb_flush_neighbors", "1"},
-	{ScopeNone, "innodb_use_sys_malloc", "ON"},
-	{ScopeNone, "plugin_dir", "/usr/local/mysql/lib/plugin/"},
-	{ScopeNone, "performance_schema_max_socket_classes", "10"},
-	{ScopeNone, "performance_schema_max_stage_classes", "150"},
-	{ScopeGlobal, "innodb_purge_batch_size", "300"},
-	{ScopeNone, "have_profiling", "YES"},
-	{ScopeGlobal, "slave_checkpoint_group", "512"},
-	{ScopeGlobal | ScopeSession, "character_set_client", "latin1"},
-	{ScopeNone, "slave_load_tmpdir", "/var/tmp/"},
-	{ScopeGlobal, "innodb_buffer_pool_dump_now", "OFF"},
-	{ScopeGlobal, "relay_log_purge", "ON"},
-	{ScopeGlobal, "ndb_distribution", ""},
-	{ScopeGlobal, "myisam_data_pointer_size", "6"},
-	{ScopeGlobal, "ndb_optimization_delay", ""},
-	{ScopeGlobal, "innodb_ft_num_word_optimize", "2000"},
-	{ScopeGlobal | ScopeSession, "max_join_size", "18446744073709551615"},
-	{ScopeNone, "core_file", "OFF"},
-	{ScopeGlobal | ScopeSession, "max_seeks_for_key", "18446744073709551615"},
-	{ScopeNone, "innodb_log_buffer_size", "8388608"},
-	{ScopeGlobal, "delayed_insert_timeout", "300"},
-	{ScopeGlobal, "max_relay_log_size", "0"},
-	{ScopeGlobal | ScopeSession, "max_sort_length", "1024"},
-	{ScopeNone, "metadata_locks_hash_instances", "8"},
-	{ScopeGlobal, "ndb_eventbuffer_free_percent", ""},
-	{ScopeNone, "large_files_support", "ON"},
-	{ScopeGlobal, "binlog_max_flush_queue_time", "0"},
-	{ScopeGlobal, "innodb_fill_factor", ""},
-	{ScopeGlobal, "log_syslog_facility", ""},
-	{ScopeNone, "innodb_ft_min_token_size", "3"},
-	{ScopeGlobal | ScopeSession, "transaction_write_set_extraction", ""},
-	{ScopeGlobal | ScopeSession, "ndb_blob_write_batch_bytes", ""},
-	{ScopeGlobal, "automatic_sp_privileges", "ON"},
-	{ScopeGlobal, "innodb_flush_sync", ""},
-	{ScopeNone, "performance_schema_events_statements_history_long_size", "10000"},
-	{ScopeGlobal, "innodb_monitor_disable", ""},
-	{ScopeNone, "innodb_doublewrite", "ON"},
-	{ScopeGlobal, "slave_parallel_type", ""},
-	{ScopeNone, "log_bin_use_v1_row_events", "OFF"},
-	{ScopeSession, "innodb_optimize_point_storage", ""},
-	{ScopeNone, "innodb_api_disable_rowlock", "OFF"},
-	{ScopeGlobal, "innodb_adaptive_flushing_lwm", "10"},
-	{ScopeNone, "innodb_log_files_in_group", "2"},
-	{ScopeGlobal, "innodb_buffer_pool_load_now", "OFF"},
-	{ScopeNone, "performance_schema_max_rwlock_classes", "40"},
-	{ScopeNone, "binlog_gtid_simple_recovery", "OFF"},
-	{ScopeNone, "port", "3306"},
-	{ScopeNone, "performance_schema_digests_size", "10000"},
-	{ScopeGlobal | ScopeSession, "profiling", "OFF"},
-	{ScopeNone, "lower_case_table_names", "2"},
-	{ScopeSession, "rand_seed1", ""},
-	{ScopeGlobal, "sha256_password_proxy_users", ""},
-	{ScopeGlobal | ScopeSession, "sql_quote_show_create", "ON"},
-	{ScopeGlobal | ScopeSession, "binlogging_impossible_mode", "IGNORE_ERROR"},
-	{ScopeGlobal, "query_cache_size", "1048576"},
-	{ScopeGlobal, "innodb_stats_transient_sample_pages", "8"},
-	{ScopeGlobal, "innodb_stats_on_metadata", "OFF"},
-	{ScopeNone, "server_uuid", "d530594e-1c86-11e5-878b-6b36ce6799ca"},
-	{ScopeNone, "open_files_limit", "5000"},
-	{ScopeGlobal | ScopeSession, "ndb_force_send", ""},
-	{ScopeNone, "skip_show_database", "OFF"},
-	{ScopeGlobal, "log_timestamps", ""},
-	{ScopeNone, "version_compile_machine", "x86_64"},
-	{ScopeGlobal, "slave_parallel_workers", "0"},
-	{ScopeGlobal, "event_scheduler", "OFF"},
-	{ScopeGlobal | ScopeSession, "ndb_deferred_constraints", ""},
-	{ScopeGlobal, "log_syslog_include_pid", ""},
-	{ScopeSession, "last_insert_id", ""},
-	{ScopeNone, "innodb_ft_cache_size", "8000000"},
-	{ScopeNone, "log_bin", "OFF"},
-	{ScopeGlobal, "innodb_disable_sort_file_cache", "OFF"},
-	{ScopeGlobal, "log_error_verbosity", ""},
-	{ScopeNone, "performance_schema_hosts_size", "100"},
-	{ScopeGlobal, "innodb_replication_delay", "0"},
-	{ScopeGlobal, "slow_query_log", "OFF"},
-	{ScopeSession, "debug_sync", ""},
-	{ScopeGlobal, "innodb_stats_auto_recalc", "ON"},
-	{ScopeGlobal, "timed_mutexes", "OFF"},
-	{ScopeGlobal | ScopeSession, "lc_messages", "en_US"},
-	{ScopeGlobal | ScopeSession, "bulk_insert_buffer_size", "8388608"},
-	{ScopeGlobal | ScopeSession, "binlog_direct_non_transactional_updates", "OFF"},
-	{ScopeGlobal, "innodb_change_buffering", "all"},
-	{ScopeGlobal | ScopeSession, "sql_big_selects", "ON"},
-	{ScopeGlobal | ScopeSession, CharacterSetResults, "latin1"},
-	{ScopeGlobal, "innodb_max_purge_lag_delay", "0"},
-	{ScopeGlobal | ScopeSession, "session_track_schema", ""},
-	{ScopeGlobal, "innodb_io_capacity_max", "2000"},
-	{ScopeGlobal, "innodb_autoextend_increment", "64"},
-	{ScopeGlobal | ScopeSession, "binlog_format", "STATEMENT"},
-	{ScopeGlobal | ScopeSession, "optimizer_trace", "enabled=off,one_line=off"},
-	{ScopeGlobal | ScopeSession, "read_rnd_buffer_size", "262144"},
-	{ScopeNone, "version_comment", "MySQL Community Server (Apache License 2.0)"},
-	{ScopeGlobal | ScopeSession, "net_write_timeout", "60"},
-	{ScopeGlobal, "innodb_buffer_pool_load_abort", "OFF"},
-	{ScopeGlobal | ScopeSession, "tx_isolation", "REPEATABLE-READ"},
-	{ScopeGlobal | ScopeSession, "collation_connection", "latin1_swedish_ci"},
-	{ScopeGlobal, "rpl_semi_sync_master_timeout", ""},
-	{ScopeGlobal | ScopeSession, "transaction_prealloc_size", "4096"},
-	{ScopeNone, "slave_skip_errors", "OFF"},
-	{ScopeNone, "performance_schema_setup_objects_size", "100"},
-	{ScopeGlobal, "sync_relay_log", "10000"},
-	{ScopeGlobal, "innodb_ft_result_cache_limit", "2000000000"},
-	{ScopeNone, "innodb_sort_buffer_size", "1048576"},
-	{ScopeGlobal, "innodb_ft_enable_diag_print", "OFF"},
-	{ScopeNone, "thread_handling", "one-thread-per-connection"},
-	{ScopeGlobal, "stored_program_cache", "256"},
-	{ScopeNone, "performance_schema_max_mutex_instances", "15906"},
-	{ScopeGlobal, "innodb_adaptive_max_sleep_delay", "150000"},
-	{ScopeNone, "large_pages", "OFF"},
-	{ScopeGlobal | ScopeSession, "session_track_system_variables", ""},
-	{ScopeGlobal, "innodb_change_buffer_max_size", "25"},
-	{ScopeGlobal, "log_bin_trust_function_creators", "OFF"},
-	{ScopeNone, "innodb_write_io_threads", "4"},
-	{ScopeGlobal, "mysql_native_password_proxy_users", ""},
-	{ScopeGlobal, "read_only", "OFF"},
-	{ScopeNone, "large_page_size", "0"},
-	{ScopeNone, "table_open_cache_instances", "1"},
-	{ScopeGlobal, "innodb_stats_persistent", "ON"},
-	{ScopeGlobal | ScopeSession, "session_track_state_change", ""},
-	{ScopeNone, "optimizer_switch", "index_merge=on,index_merge_union=on,index_merge_sort_union=on,index_merge_intersection=on,engine_condition_pushdown=on,index_condition_pushdown=on,mrr=on,mrr_cost_based=on,block_nested_loop=on,batched_key_access=off,materialization=on,semijoin=on,loosescan=on,firstmatch=on,subquery_materialization_cost_based=on,use_index_extensions=on"},
-	{ScopeGlobal, "delayed_queue_size", "1000"},
-	{ScopeNone, "innodb_read_only", "OFF"},
-	{ScopeNone, "datetime_format", "%Y-%m-%d %H:%i:%s"},
-	{ScopeGlobal, "log_syslog", ""},
-	{ScopeNone, "version", mysql.ServerVersion},
-	{ScopeGlobal | ScopeSession, "transaction_alloc_block_size", "8192"},
-	{ScopeGlobal, "sql_slave_skip_counter", "0"},
-	{ScopeNone, "have_openssl", "DISABLED"},
-	{ScopeGlobal, "innodb_large_prefix", "OFF"},
-	{ScopeNone, "performance_schema_max_cond_classes", "80"},
-	{ScopeGlobal, "innodb_io_capacity", "200"},
-	{ScopeGlobal, "max_binlog_cache_size", "18446744073709547520"},
-	{ScopeGlobal | ScopeSession, "ndb_index_stat_enable", ""},
-	{ScopeGlobal, "executed_gtids_compression_period", ""},
-	{ScopeNone, "time_format", "%H:%i:%s"},
-	{ScopeGlobal | ScopeSession, "old_alter_table", "OFF"},
-	{ScopeGlobal | ScopeSession, "long_query_time", "10.000000"},
-	{ScopeNone, "innodb_use_native_aio", "OFF"},
-	{ScopeGlobal, "log_throttle_queries_not_using_indexes", "0"},
-	{ScopeNone, "locked_in_memory", "OFF"},
-	{ScopeNone, "innodb_api_enable_mdl", "OFF"},
-	{ScopeGlobal, "binlog_cache_size", "32768"},
-	{ScopeGlobal, "innodb_compression_pad_pct_max", "50"},
-	{ScopeGlobal, "innodb_commit_concurrency", "0"},
-	{ScopeNone, "ft_min_word_len", "4"},
-	{ScopeGlobal, "enforce_gtid_consistency", "OFF"},
-	{ScopeGlobal, "secure_auth", "ON"},
-	{ScopeNone, "max_tmp_tables", "32"},
-	{ScopeGlobal, "innodb_random_read_ahead", "OFF"},
-	{ScopeGlobal | ScopeSession, "unique_checks", "ON"},
-	{ScopeGlobal, "internal_tmp_disk_storage_engine", ""},
-	{ScopeGlobal | ScopeSession, "myisam_repair_threads", "1"},
-	{ScopeGlobal, "ndb_eventbuffer_max_alloc", ""},
-	{ScopeGlobal, "innodb_read_ahead_threshold", "56"},
-	{ScopeGlobal, "key_cache_block_size", "1024"},
-	{ScopeGlobal, "rpl_semi_sync_slave_enabled", ""},
-	{ScopeNone, "ndb_recv_thread_cpu_mask", ""},
-	{ScopeGlobal, "gtid_purged", ""},
-	{ScopeGlobal, "max_binlog_stmt_cache_size", "18446744073709547520"},
-	{ScopeGlobal | ScopeSession, "lock_wait_timeout", "31536000"},
-	{ScopeGlobal | ScopeSession, "read_buffer_size", "131072"},
-	{ScopeNone, "innodb_read_io_threads", "4"},
-	{ScopeGlobal | ScopeSession, "max_sp_recursion_depth", "0"},
-	{ScopeNone, "ignore_builtin_innodb", "OFF"},
-	{ScopeGlobal, "rpl_semi_sync_master_enabled", ""},
-	{ScopeGlobal, "slow_query_log_file", "/usr/local/mysql/data/localhost-slow.log"},
-	{ScopeGlobal, "innodb_thread_sleep_delay", "10000"},
-	{ScopeNone, "license", "Apache License 2.0"},
-	{ScopeGlobal, "innodb_ft_aux_table", ""},
-	{ScopeGlobal | ScopeSession, "sql_warnings", "OFF"},
-	{ScopeGlobal | ScopeSession, "keep_files_on_create", "OFF"},
-	{ScopeGlobal, "slave_preserve_commit_order", ""},
-	{ScopeNone, "innodb_data_file_path", "ibdata1:12M:autoextend"},
-	{ScopeNone, "performance_schema_setup_actors_size", "100"},
-	{ScopeNone, "innodb_additional_mem_pool_size", "8388608"},
-	{ScopeNone, "log_error", "/usr/local/mysql/data/localhost.err"},
-	{ScopeGlobal, "slave_exec_mode", "STRICT"},
-	{ScopeGlobal, "binlog_stmt_cache_size", "32768"},
-	{ScopeNone, "relay_log_info_file", "relay-log.info"},
-	{ScopeNone, "innodb_ft_total_cache_size", "640000000"},
-	{ScopeNone, "performance_schema_max_rwlock_instances", "9102"},
-	{ScopeGlobal, "table_open_cache", "2000"},
-	{ScopeNone, "log_slave_updates", "OFF"},
-	{ScopeNone, "performance_schema_events_stages_history_long_size", "10000"},
-	{ScopeGlobal | ScopeSession, "autocommit", "ON"},
-	{ScopeSession, "insert_id", ""},
-	{ScopeGlobal | ScopeSession, "default_tmp_storage_engine", "InnoDB"},
-	{ScopeGlobal | ScopeSession, "optimizer_search_depth", "62"},
-	{ScopeGlobal, "max_points_in_geometry", ""},
-	{ScopeGlobal, "innodb_stats_sample_pages", "8"},
-	{ScopeGlobal | ScopeSession, "profiling_history_size", "15"},
-	{ScopeGlobal | ScopeSession, "character_set_database", "latin1"},
-	{ScopeNone, "have_symlink", "YES"},
-	{ScopeGlobal | ScopeSession, "storage_engine", "InnoDB"},
-	{ScopeGlobal | ScopeSession, "sql_log_off", "OFF"},
-	{ScopeNone, "explicit_defaults_for_timestamp", "OFF"},
-	{ScopeNone, "performance_schema_events_waits_history_size", "10"},
-	{ScopeGlobal, "log_syslog_tag", ""},
-	{ScopeGlobal | ScopeSession, "tx_read_only", "0"},
-	{ScopeGlobal, "rpl_semi_sync_master_wait_point", ""},
-	{ScopeGlobal, "innodb_undo_log_truncate", ""},
-	{ScopeNone, "simplified_binlog_gtid_recovery", "OFF"},
-	{ScopeSession, "innodb_create_intrinsic", ""},
-	{ScopeGlobal, "gtid_executed_compression_period", ""},
-	{ScopeGlobal, "ndb_log_empty_epochs", ""},
-	{ScopeGlobal, "max_prepared_stmt_count", "16382"},
-	{ScopeNone, "have_geometry", "YES"},
-	{ScopeGlobal | ScopeSession, "optimizer_trace_max_mem_size", "16384"},
-	{ScopeGlobal | ScopeSession, "net_retry_count", "10"},
-	{ScopeSession, "ndb_table_no_logging", ""},
-	{ScopeGlobal | ScopeSession, "optimizer_trace_features", "greedy_search=on,range_optimizer=on,dynamic_range=on,repeated_subselect=on"},
-	{ScopeGlobal, "innodb_flush_log_at_trx_commit", "1"},
-	{ScopeGlobal, "rewriter_enabled", ""},
-	{ScopeGlobal, "query_cache_min_res_unit", "4096"},
-	{ScopeGlobal | ScopeSession, "updatable_views_with_limit", "YES"},
-	{ScopeGlobal | ScopeSession, "optimizer_prune_level", "1"},
-	{ScopeGlobal, "slave_sql_verify_checksum", "ON"},
-	{ScopeGlobal | ScopeSession, "completion_type", "NO_CHAIN"},
-	{ScopeGlobal, "binlog_checksum", "CRC32"},
-	{ScopeNone, "report_port", "3306"},
-	{ScopeGlobal | ScopeSession, "show_old_temporals", "OFF"},
-	{ScopeGlobal, "query_cache_limit", "1048576"},
-	{ScopeGlobal, "innodb_buffer_pool_size", "134217728"},
-	{ScopeGlobal, "innodb_adaptive_flushing", "ON"},
-	{ScopeNone, "datadir", "/usr/local/mysql/data/"},
-	{ScopeGlobal | ScopeSession, "wait_timeout", "28800"},
-	{ScopeGlobal, "innodb_monitor_enable", ""},
-	{ScopeNone, "date_format", "%Y-%m-%d"},
-	{ScopeGlobal, "innodb_buffer_pool_filename", "ib_buffer_pool"},
-	{ScopeGlobal, "slow_launch_time", "2"},
-	{ScopeGlobal, "slave_max_allowed_packet", "1073741824"},
-	{ScopeGlobal | ScopeSession, "ndb_use_transactions", ""},
-	{ScopeNone, "innodb_purge_threads", "1"},
-	{ScopeGlobal, "innodb_concurrency_tickets", "5000"},
-	{ScopeGlobal, "innodb_monitor_reset_all", ""},
-	{ScopeNone, "performance_schema_users_size", "100"},
-	{ScopeGlobal, "ndb_log_updated_only", ""},
-	{ScopeNone, "basedir", "/usr/local/mysql"},
-	{ScopeGlobal, "innodb_old_blocks_time", "1000"},
-	{ScopeGlobal, "innodb_stats_method", "nulls_equal"},
-	{ScopeGlobal | ScopeSession, "innodb_lock_wait_timeout", "50"},
-	{ScopeGlobal, "local_infile", "ON"},
-	{ScopeGlobal | ScopeSession, "myisam_stats_method", "nulls_unequal"},
-	{ScopeNone, "version_compile_os", "osx10.8"},
-	{ScopeNone, "relay_log_recovery", "OFF"},
-	{ScopeNone, "old", "OFF"},
-	{ScopeGlobal | ScopeSession, "innodb_table_locks", "ON"},
-	{ScopeNone, "performance_schema", "ON"},
-	{ScopeNone, "myisam_recover_options", "OFF"},
-	{ScopeGlobal | ScopeSession, "net_buffer_length", "16384"},
-	{ScopeGlobal, "rpl_semi_sync_master_wait_for_slave_count", ""},
-	{ScopeGlobal | ScopeSession, "binlog_row_image", "FULL"},
-	{ScopeNone, "innodb_locks_unsafe_for_binlog", "OFF"},
-	{ScopeSession, "rbr_exec_mode", ""},
-	{ScopeGlobal, "myisam_max_sort_file_size", "9223372036853727232"},
-	{ScopeNone, "back_log", "80"},
-	{ScopeNone, "lower_case_file_system", "ON"},
-	{ScopeGlobal, "rpl_semi_sync_master_wait_no_slave", ""},
-	{ScopeGlobal | ScopeSession, "group_concat_max_len", "1024"},
-	{ScopeSession, "pseudo_thread_id", ""},
-	{ScopeNone, "socket", "/tmp/myssock"},
-	{ScopeNone, "have_dynamic_loading", "YES"},
-	{ScopeGlobal, "rewriter_verbose", ""},
-	{ScopeGlobal, "innodb_undo_logs", "128"},
-	{ScopeNone, "performance_schema_max_cond_instances", "3504"},
-	{ScopeGlobal, "delayed_insert_limit", "100"},
-	{ScopeGlobal, "flush", "OFF"},
-	{ScopeGlobal | ScopeSession, "eq_range_index_dive_limit", "10"},
-	{ScopeNone, "performance_schema_events_stages_history_size", "10"},
-	{ScopeGlobal | ScopeSession, "character_set_connection", "latin1"},
-	{ScopeGlobal, "myisam_use_mmap", "OFF"},
-	{ScopeGlobal | ScopeSession, "ndb_join_pushdown", ""},
-	{ScopeGlobal | ScopeSession, "character_set_server", "latin1"},
-	{ScopeGlobal, "validate_password_special_char_count", "1"},
-	{ScopeNone, "performance_schema_max_thread_instances", "402"},
-	{ScopeGlobal, "slave_rows_search_algorithms", "TABLE_SCAN,INDEX_SCAN"},
-	{ScopeGlobal | ScopeSession, "ndbinfo_show_hidden", ""},
-	{ScopeGlobal | ScopeSession, "net_read_timeout", "30"},
-	{ScopeNone, "innodb_page_size", "16384"},
-	{ScopeGlobal, MaxAllowedPacket, "67108864"},
-	{ScopeNone, "innodb_log_file_size", "50331648"},
-	{ScopeGlobal, "sync_relay_log_info", "10000"},
-	{ScopeGlobal | ScopeSession, "optimizer_trace_limit", "1"},
-	{ScopeNone, "innodb_ft_max_token_size", "84"},
-	{ScopeGlobal, "validate_password_length", "8"},
-	{ScopeGlobal, "ndb_log_binlog_index", ""},
-	{ScopeGlobal, "validate_password_mixed_case_count", "1"},
-	{ScopeGlobal, "innodb_api_bk_commit_interval", "5"},
-	{ScopeNone, "innodb_undo_directory", "."},
-	{ScopeNone, "bind_address", "*"},
-	{ScopeGlobal, "innodb_sync_spin_loops", "30"},
-	{ScopeGlobal | ScopeSession, "sql_safe_updates", "OFF"},
-	{ScopeNone, "tmpdir", "/var/tmp/"},
-	{ScopeGlobal, "innodb_thread_concurrency", "0"},
-	{ScopeGlobal, "slave_allow_batching", "OFF"},
-	{ScopeGlobal, "innodb_buffer_pool_dump_pct", ""},
-	{ScopeGlobal | ScopeSession, "lc_time_names", "en_US"},
-	{ScopeGlobal | ScopeSession, "max_statement_time", ""},
-	{ScopeGlobal | ScopeSession, "end_markers_in_json", "OFF"},
-	{ScopeGlobal, "avoid_temporal_upgrade", "OFF"},
-	{ScopeGlobal, "key_cache_age_threshold", "300"},
-	{ScopeGlobal, "innodb_status_output", "OFF"},
-	{ScopeSession, "identity", ""},
-	{ScopeGlobal | ScopeSession, "min_examined_row_limit", "0"},
-	{ScopeGlobal, "sync_frm", "ON"},
-	{ScopeGlobal, "innodb_online_alter_log_max_size", "134217728"},
+	{Scope: ScopeGlobal, Name: "gtid_mode", Value: "OFF"},
+	{Scope: ScopeGlobal, Name: "flush_time", Value: "0"},
+	{Scope: ScopeSession, Name: "pseudo_slave_mode", Value: ""},
+	{Scope: ScopeNone, Name: "performance_schema_max_mutex_classes", Value: "200"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "low_priority_updates", Value: "OFF"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "session_track_gtids", Value: ""},
+	{Scope: ScopeGlobal | ScopeSession, Name: "ndbinfo_max_rows", Value: ""},
+	{Scope: ScopeGlobal | ScopeSession, Name: "ndb_index_stat_option", Value: ""},
+	{Scope: ScopeGlobal | ScopeSession, Name: "old_passwords", Value: "0"},
+	{Scope: ScopeNone, Name: "innodb_version", Value: "5.6.25"},
+	{Scope: ScopeGlobal, Name: MaxConnections, Value: "0"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "big_tables", Value: "OFF"},
+	{Scope: ScopeNone, Name: "skip_external_locking", Value: "ON"},
+	{Scope: ScopeGlobal, Name: "slave_pending_jobs_size_max", Value: "16777216"},
+	{Scope: ScopeNone, Name: "innodb_sync_array_size", Value: "1"},
+	{Scope: ScopeSession, Name: "rand_seed2", Value: ""},
+	{Scope: ScopeGlobal, Name: "validate_password_number_count", Value: "1"},
+	{Scope: ScopeSession, Name: "gtid_next", Value: ""},
+	{Scope: ScopeGlobal | ScopeSession, Name: "sql_select_limit", Value: "18446744073709551615"},
+	{Scope: ScopeGlobal, Name: "ndb_show_foreign_key_mock_tables", Value: ""},
+	{Scope: ScopeNone, Name: "multi_range_count", Value: "256"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "default_week_format", Value: "0"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "binlog_error_action", Value: "IGNORE_ERROR"},
+	{Scope: ScopeGlobal, Name: "slave_transaction_retries", Value: "10"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "default_storage_engine", Value: "InnoDB"},
+	{Scope: ScopeNone, Name: "ft_query_expansion_limit", Value: "20"},
+	{Scope: ScopeGlobal, Name: "max_connect_errors", Value: "100"},
+	{Scope: ScopeGlobal, Name: "sync_binlog", Value: "0"},
+	{Scope: ScopeNone, Name: "max_digest_length", Value: "1024"},
+	{Scope: ScopeNone, Name: "innodb_force_load_corrupted", Value: "OFF"},
+	{Scope: ScopeNone, Name: "performance_schema_max_table_handles", Value: "4000"},
+	{Scope: ScopeGlobal, Name: "innodb_fast_shutdown", Value: "1"},
+	{Scope: ScopeNone, Name: "ft_max_word_len", Value: "84"},
+	{Scope: ScopeGlobal, Name: "log_backward_compatible_user_definitions", Value: ""},
+	{Scope: ScopeNone, Name: "lc_messages_dir", Value: "/usr/local/mysql-5.6.25-osx10.8-x86_64/share/"},
+	{Scope: ScopeGlobal, Name: "ft_boolean_syntax", Value: "+ -><()~*:\"\"&|"},
+	{Scope: ScopeGlobal, Name: "table_definition_cache", Value: "1400"},
+	{Scope: ScopeNone, Name: "skip_name_resolve", Value: "OFF"},
+	{Scope: ScopeNone, Name: "performance_schema_max_file_handles", Value: "32768"},
+	{Scope: ScopeSession, Name: "transaction_allow_batching", Value: ""},
+	{Scope: ScopeGlobal | ScopeSession, Name: SQLModeVar, Value: "STRICT_TRANS_TABLES,NO_ENGINE_SUBSTITUTION"},
+	{Scope: ScopeNone, Name: "performance_schema_max_statement_classes", Value: "168"},
+	{Scope: ScopeGlobal, Name: "server_id", Value: "0"},
+	{Scope: ScopeGlobal, Name: "innodb_flushing_avg_loops", Value: "30"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "tmp_table_size", Value: "16777216"},
+	{Scope: ScopeGlobal, Name: "innodb_max_purge_lag", Value: "0"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "preload_buffer_size", Value: "32768"},
+	{Scope: ScopeGlobal, Name: "slave_checkpoint_period", Value: "300"},
+	{Scope: ScopeGlobal, Name: "check_proxy_users", Value: ""},
+	{Scope: ScopeNone, Name: "have_query_cache", Value: "YES"},
+	{Scope: ScopeGlobal, Name: "innodb_flush_log_at_timeout", Value: "1"},
+	{Scope: ScopeGlobal, Name: "innodb_max_undo_log_size", Value: ""},
+	{Scope: ScopeGlobal | ScopeSession, Name: "range_alloc_block_size", Value: "4096"},
+	{Scope: ScopeGlobal, Name: "connect_timeout", Value: "10"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "collation_server", Value: "latin1_swedish_ci"},
+	{Scope: ScopeNone, Name: "have_rtree_keys", Value: "YES"},
+	{Scope: ScopeGlobal, Name: "innodb_old_blocks_pct", Value: "37"},
+	{Scope: ScopeGlobal, Name: "innodb_file_format", Value: "Antelope"},
+	{Scope: ScopeGlobal, Name: "innodb_compression_failure_threshold_pct", Value: "5"},
+	{Scope: ScopeNone, Name: "performance_schema_events_waits_history_long_size", Value: "10000"},
+	{Scope: ScopeGlobal, Name: "innodb_checksum_algorithm", Value: "innodb"},
+	{Scope: ScopeNone, Name: "innodb_ft_sort_pll_degree", Value: "2"},
+	{Scope: ScopeNone, Name: "thread_stack", Value: "262144"},
+	{Scope: ScopeGlobal, Name: "relay_log_info_repository", Value: "FILE"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "sql_log_bin", Value: "ON"},
+	{Scope: ScopeGlobal, Name: "super_read_only", Value: ""},
+	{Scope: ScopeGlobal | ScopeSession, Name: "max_delayed_threads", Value: "20"},
+	{Scope: ScopeNone, Name: "protocol_version", Value: "10"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "new", Value: "OFF"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "myisam_sort_buffer_size", Value: "8388608"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "optimizer_trace_offset", Value: "-1"},
+	{Scope: ScopeGlobal, Name: "innodb_buffer_pool_dump_at_shutdown", Value: "OFF"},
+	{Scope: ScopeGlobal | ScopeSession, Name: SQLNotes, Value: "ON"},
+	{Scope: ScopeGlobal, Name: "innodb_cmp_per_index_enabled", Value: "OFF"},
+	{Scope: ScopeGlobal, Name: "innodb_ft_server_stopword_table", Value: ""},
+	{Scope: ScopeNone, Name: "performance_schema_max_file_instances", Value: "7693"},
+	{Scope: ScopeNone, Name: "log_output", Value: "FILE"},
+	{Scope: ScopeGlobal, Name: "binlog_group_commit_sync_delay", Value: ""},
+	{Scope: ScopeGlobal, Name: "binlog_group_commit_sync_no_delay_count", Value: ""},
+	{Scope: ScopeNone, Name: "have_crypt", Value: "YES"},
+	{Scope: ScopeGlobal, Name: "innodb_log_write_ahead_size", Value: ""},
+	{Scope: ScopeNone, Name: "innodb_log_group_home_dir", Value: "./"},
+	{Scope: ScopeNone, Name: "performance_schema_events_statements_history_size", Value: "10"},
+	{Scope: ScopeGlobal, Name: "general_log", Value: "OFF"},
+	{Scope: ScopeGlobal, Name: "validate_password_dictionary_file", Value: ""},
+	{Scope: ScopeGlobal, Name: "binlog_order_commits", Value: "ON"},
+	{Scope: ScopeGlobal, Name: "master_verify_checksum", Value: "OFF"},
+	{Scope: ScopeGlobal, Name: "key_cache_division_limit", Value: "100"},
+	{Scope: ScopeGlobal, Name: "rpl_semi_sync_master_trace_level", Value: ""},
+	{Scope: ScopeGlobal | ScopeSession, Name: "max_insert_delayed_threads", Value: "20"},
+	{Scope: ScopeNone, Name: "performance_schema_session_connect_attrs_size", Value: "512"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "time_zone", Value: "SYSTEM"},
+	{Scope: ScopeGlobal, Name: "innodb_max_dirty_pages_pct", Value: "75"},
+	{Scope: ScopeGlobal, Name: "innodb_file_per_table", Value: "ON"},
+	{Scope: ScopeGlobal, Name: "innodb_log_compressed_pages", Value: "ON"},
+	{Scope: ScopeGlobal, Name: "master_info_repository", Value: "FILE"},
+	{Scope: ScopeGlobal, Name: "rpl_stop_slave_timeout", Value: "31536000"},
+	{Scope: ScopeNone, Name: "skip_networking", Value: "OFF"},
+	{Scope: ScopeGlobal, Name: "innodb_monitor_reset", Value: ""},
+	{Scope: ScopeNone, Name: "have_ssl", Value: "DISABLED"},
+	{Scope: ScopeNone, Name: "system_time_zone", Value: "CST"},
+	{Scope: ScopeGlobal, Name: "innodb_print_all_deadlocks", Value: "OFF"},
+	{Scope: ScopeNone, Name: "innodb_autoinc_lock_mode", Value: "1"},
+	{Scope: ScopeGlobal, Name: "slave_net_timeout", Value: "3600"},
+	{Scope: ScopeGlobal, Name: "key_buffer_size", Value: "8388608"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "foreign_key_checks", Value: "ON"},
+	{Scope: ScopeGlobal, Name: "host_cache_size", Value: "279"},
+	{Scope: ScopeGlobal, Name: "delay_key_write", Value: "ON"},
+	{Scope: ScopeNone, Name: "metadata_locks_cache_size", Value: "1024"},
+	{Scope: ScopeNone, Name: "innodb_force_recovery", Value: "0"},
+	{Scope: ScopeGlobal, Name: "innodb_file_format_max", Value: "Antelope"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "debug", Value: ""},
+	{Scope: ScopeGlobal, Name: "log_warnings", Value: "1"},
+	{Scope: ScopeGlobal, Name: "offline_mode", Value: ""},
+	{Scope: ScopeGlobal | ScopeSession, Name: "innodb_strict_mode", Value: "OFF"},
+	{Scope: ScopeGlobal, Name: "innodb_rollback_segments", Value: "128"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "join_buffer_size", Value: "262144"},
+	{Scope: ScopeNone, Name: "innodb_mirrored_log_groups", Value: "1"},
+	{Scope: ScopeGlobal, Name: "max_binlog_size", Value: "1073741824"},
+	{Scope: ScopeGlobal, Name: "sync_master_info", Value: "10000"},
+	{Scope: ScopeGlobal, Name: "concurrent_insert", Value: "AUTO"},
+	{Scope: ScopeGlobal, Name: "innodb_adaptive_hash_index", Value: "ON"},
+	{Scope: ScopeGlobal, Name: "innodb_ft_enable_stopword", Value: "ON"},
+	{Scope: ScopeGlobal, Name: "general_log_file", Value: "/usr/local/mysql/data/localhost.log"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "innodb_support_xa", Value: "ON"},
+	{Scope: ScopeGlobal, Name: "innodb_compression_level", Value: "6"},
+	{Scope: ScopeNone, Name: "innodb_file_format_check", Value: "ON"},
+	{Scope: ScopeNone, Name: "myisam_mmap_size", Value: "18446744073709551615"},
+	{Scope: ScopeGlobal, Name: "init_slave", Value: ""},
+	{Scope: ScopeNone, Name: "innodb_buffer_pool_instances", Value: "8"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "block_encryption_mode", Value: "aes-128-ecb"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "max_length_for_sort_data", Value: "1024"},
+	{Scope: ScopeNone, Name: "character_set_system", Value: "utf8"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "interactive_timeout", Value: "28800"},
+	{Scope: ScopeGlobal, Name: "innodb_optimize_fulltext_only", Value: "OFF"},
+	{Scope: ScopeNone, Name: "character_sets_dir", Value: "/usr/local/mysql-5.6.25-osx10.8-x86_64/share/charsets/"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "query_cache_type", Value: "OFF"},
+	{Scope: ScopeNone, Name: "innodb_rollback_on_timeout", Value: "OFF"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "query_alloc_block_size", Value: "8192"},
+	{Scope: ScopeGlobal, Name: "slave_compressed_protocol", Value: "OFF"},
+	{Scope: ScopeGlobal, Name: "init_connect", Value: ""},
+	{Scope: ScopeGlobal, Name: "rpl_semi_sync_slave_trace_level", Value: ""},
+	{Scope: ScopeNone, Name: "have_compress", Value: "YES"},
+	{Scope: ScopeNone, Name: "thread_concurrency", Value: "10"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "query_prealloc_size", Value: "8192"},
+	{Scope: ScopeNone, Name: "relay_log_space_limit", Value: "0"},
+	{Scope: ScopeGlobal | ScopeSession, Name: MaxUserConnections, Value: "0"},
+	{Scope: ScopeNone, Name: "performance_schema_max_thread_classes", Value: "50"},
+	{Scope: ScopeGlobal, Name: "innodb_api_trx_level", Value: "0"},
+	{Scope: ScopeNone, Name: "disconnect_on_expired_password", Value: "ON"},
+	{Scope: ScopeNone, Name: "performance_schema_max_file_classes", Value: "50"},
+	{Scope: ScopeGlobal, Name: "expire_logs_days", Value: "0"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "binlog_rows_query_log_events", Value: "OFF"},
+	{Scope: ScopeGlobal, Name: "validate_password_policy", Value: "1"},
+	{Scope: ScopeGlobal, Name: "default_password_lifetime", Value: ""},
+	{Scope: ScopeNone, Name: "pid_file", Value: "/usr/local/mysql/data/localhost.pid"},
+	{Scope: ScopeNone, Name: "innodb_undo_tablespaces", Value: "0"},
+	{Scope: ScopeGlobal, Name: "innodb_status_output_locks", Value: "OFF"},
+	{Scope: ScopeNone, Name: "performance_schema_accounts_size", Value: "100"},
+	{Scope: ScopeGlobal | ScopeSession, Name: MaxErrorCount, Value: "64", Type: TypeInt, MinValue: 0, MaxValue: math.MaxUint16},
+	{Scope: ScopeGlobal, Name: "max_write_lock_count", Value: "18446744073709551615"},
+	{Scope: ScopeNone, Name: "performance_schema_max_socket_instances", Value: "322"},
+	{Scope: ScopeNone, Name: "performance_schema_max_table_instances", Value: "12500"},
+	{Scope: ScopeGlobal, Name: "innodb_stats_persistent_sample_pages", Value: "20"},
+	{Scope: ScopeGlobal, Name: "show_compatibility_56", Value: ""},
+	{Scope: ScopeGlobal, Name: "log_slow_slave_statements", Value: "OFF"},
+	{Scope: ScopeNone, Name: "innodb_open_files", Value: "2000"},
+	{Scope: ScopeGlobal, Name: "innodb_spin_wait_delay", Value: "6"},
+	{Scope: ScopeGlobal, Name: "thread_cache_size", Value: "9"},
+	{Scope: ScopeGlobal, Name: "log_slow_admin_statements", Value: "OFF"},
+	{Scope: ScopeNone, Name: "innodb_checksums", Value: "ON"},
+	{Scope: ScopeNone, Name: "hostname", Value: "localhost"},
+	{Scope: ScopeGlobal | ScopeSession, Name: AutoIncrementOffset, Value: "1", Type: TypeInt, MinValue: 1, MaxValue: math.MaxUint16},
+	{Scope: ScopeNone, Name: "ft_stopword_file", Value: "(built-in)"},
+	{Scope: ScopeGlobal, Name: "innodb_max_dirty_pages_pct_lwm", Value: "0"},
+	{Scope: ScopeGlobal, Name: "log_queries_not_using_indexes", Value: "OFF"},
+	{Scope: ScopeSession, Name: "timestamp", Value: ""},
+	{Scope: ScopeGlobal | ScopeSession, Name: "query_cache_wlock_invalidate", Value: "OFF"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "sql_buffer_result", Value: "OFF"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "character_set_filesystem", Value: "binary"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "collation_database", Value: "latin1_swedish_ci"},
+	{Scope: ScopeGlobal | ScopeSession, Name: AutoIncrementIncrement, Value: "1", Type: TypeInt, MinValue: 1, MaxValue: math.MaxUint16},
+	{Scope: ScopeGlobal | ScopeSession, Name: "max_heap_table_size", Value: "16777216"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "div_precision_increment", Value: "4"},
+	{Scope: ScopeGlobal, Name: "innodb_lru_scan_depth", Value: "1024"},
+	{Scope: ScopeGlobal, Name: "innodb_purge_rseg_truncate_frequency", Value: ""},
+	{Scope: ScopeGlobal | ScopeSession, Name: "sql_auto_is_null", Value: "OFF"},
+	{Scope: ScopeNone, Name: "innodb_api_enable_binlog", Value: "OFF"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "innodb_ft_user_stopword_table", Value: ""},
+	{Scope: ScopeNone, Name: "server_id_bits", Value: "32"},
+	{Scope: ScopeGlobal, Name: "innodb_log_checksum_algorithm", Value: ""},
+	{Scope: ScopeNone, Name: "innodb_buffer_pool_load_at_startup", Value: "OFF"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "sort_buffer_size", Value: "262144"},
+	{Scope: ScopeGlobal, Name: "innodb_flush_neighbors", Value: "1"},
+	{Scope: ScopeNone, Name: "innodb_use_sys_malloc", Value: "ON"},
+	{Scope: ScopeNone, Name: "plugin_dir", Value: "/usr/local/mysql/lib/plugin/"},
+	{Scope: ScopeNone, Name: "performance_schema_max_socket_classes", Value: "10"},
+	{Scope: ScopeNone, Name: "performance_schema_max_stage_classes", Value: "150"},
+	{Scope: ScopeGlobal, Name: "innodb_purge_batch_size", Value: "300"},
+	{Scope: ScopeNone, Name: "have_profiling", Value: "YES"},
+	{Scope: ScopeGlobal, Name: "slave_checkpoint_group", Value: "512"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "character_set_client", Value: "latin1"},
+	{Scope: ScopeNone, Name: "slave_load_tmpdir", Value: "/var/tmp/"},
+	{Scope: ScopeGlobal, Name: "innodb_buffer_pool_dump_now", Value: "OFF"},
+	{Scope: ScopeGlobal, Name: "relay_log_purge", Value: "ON"},
+	{Scope: ScopeGlobal, Name: "ndb_distribution", Value: ""},
+	{Scope: ScopeGlobal, Name: "myisam_data_pointer_size", Value: "6"},
+	{Scope: ScopeGlobal, Name: "ndb_optimization_delay", Value: ""},
+	{Scope: ScopeGlobal, Name: "innodb_ft_num_word_optimize", Value: "2000"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "max_join_size", Value: "18446744073709551615"},
+	{Scope: ScopeNone, Name: "core_file", Value: "OFF"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "max_seeks_for_key", Value: "18446744073709551615"},
+	{Scope: ScopeNone, Name: "innodb_log_buffer_size", Value: "8388608"},
+	{Scope: ScopeGlobal, Name: "delayed_insert_timeout", Value: "300"},
+	{Scope: ScopeGlobal, Name: "max_relay_log_size", Value: "0"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "max_sort_length", Value: "1024"},
+	{Scope: ScopeNone, Name: "metadata_locks_hash_instances", Value: "8"},
+	{Scope: ScopeGlobal, Name: "ndb_eventbuffer_free_percent", Value: ""},
+	{Scope: ScopeNone, Name: "large_files_support", Value: "ON"},
+	{Scope: ScopeGlobal, Name: "binlog_max_flush_queue_time", Value: "0"},
+	{Scope: ScopeGlobal, Name: "innodb_fill_factor", Value: ""},
+	{Scope: ScopeGlobal, Name: "log_syslog_facility", Value: ""},
+	{Scope: ScopeNone, Name: "innodb_ft_min_token_size", Value: "3"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "transaction_write_set_extraction", Value: ""},
+	{Scope: ScopeGlobal | ScopeSession, Name: "ndb_blob_write_batch_bytes", Value: ""},
+	{Scope: ScopeGlobal, Name: "automatic_sp_privileges", Value: "ON"},
+	{Scope: ScopeGlobal, Name: "innodb_flush_sync", Value: ""},
+	{Scope: ScopeNone, Name: "performance_schema_events_statements_history_long_size", Value: "10000"},
+	{Scope: ScopeGlobal, Name: "innodb_monitor_disable", Value: ""},
+	{Scope: ScopeNone, Name: "innodb_doublewrite", Value: "ON"},
+	{Scope: ScopeGlobal, Name: "slave_parallel_type", Value: ""},
+	{Scope: ScopeNone, Name: "log_bin_use_v1_row_events", Value: "OFF"},
+	{Scope: ScopeSession, Name: "innodb_optimize_point_storage", Value: ""},
+	{Scope: ScopeNone, Name: "innodb_api_disable_rowlock", Value: "OFF"},
+	{Scope: ScopeGlobal, Name: "innodb_adaptive_flushing_lwm", Value: "10"},
+	{Scope: ScopeNone, Name: "innodb_log_files_in_group", Value: "2"},
+	{Scope: ScopeGlobal, Name: "innodb_buffer_pool_load_now", Value: "OFF"},
+	{Scope: ScopeNone, Name: "performance_schema_max_rwlock_classes", Value: "40"},
+	{Scope: ScopeNone, Name: "binlog_gtid_simple_recovery", Value: "OFF"},
+	{Scope: ScopeNone, Name: "port", Value: "3306"},
+	{Scope: ScopeNone, Name: "performance_schema_digests_size", Value: "10000"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "profiling", Value: "OFF"},
+	{Scope: ScopeNone, Name: "lower_case_table_names", Value: "2"},
+	{Scope: ScopeSession, Name: "rand_seed1", Value: ""},
+	{Scope: ScopeGlobal, Name: "sha256_password_proxy_users", Value: ""},
+	{Scope: ScopeGlobal | ScopeSession, Name: "sql_quote_show_create", Value: "ON"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "binlogging_impossible_mode", Value: "IGNORE_ERROR"},
+	{Scope: ScopeGlobal, Name: "query_cache_size", Value: "1048576"},
+	{Scope: ScopeGlobal, Name: "innodb_stats_transient_sample_pages", Value: "8"},
+	{Scope: ScopeGlobal, Name: "innodb_stats_on_metadata", Value: "OFF"},
+	{Scope: ScopeNone, Name: "server_uuid", Value: "d530594e-1c86-11e5-878b-6b36ce6799ca"},
+	{Scope: ScopeNone, Name: "open_files_limit", Value: "5000"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "ndb_force_send", Value: ""},
+	{Scope: ScopeNone, Name: "skip_show_database", Value: "OFF"},
+	{Scope: ScopeGlobal, Name: "log_timestamps", Value: ""},
+	{Scope: ScopeNone, Name: "version_compile_machine", Value: "x86_64"},
+	{Scope: ScopeGlobal, Name: "slave_parallel_workers", Value: "0"},
+	{Scope: ScopeGlobal, Name: "event_scheduler", Value: "OFF"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "ndb_deferred_constraints", Value: ""},
+	{Scope: ScopeGlobal, Name: "log_syslog_include_pid", Value: ""},
+	{Scope: ScopeSession, Name: "last_insert_id", Value: ""},
+	{Scope: ScopeNone, Name: "innodb_ft_cache_size", Value: "8000000"},
+	{Scope: ScopeNone, Name: "log_bin", Value: "OFF"},
+	{Scope: ScopeGlobal, Name: "innodb_disable_sort_file_cache", Value: "OFF"},
+	{Scope: ScopeGlobal, Name: "log_error_verbosity", Value: ""},
+	{Scope: ScopeNone, Name: "performance_schema_hosts_size", Value: "100"},
+	{Scope: ScopeGlobal, Name: "innodb_replication_delay", Value: "0"},
+	{Scope: ScopeGlobal, Name: "slow_query_log", Value: "OFF"},
+	{Scope: ScopeSession, Name: "debug_sync", Value: ""},
+	{Scope: ScopeGlobal, Name: "innodb_stats_auto_recalc", Value: "ON"},
+	{Scope: ScopeGlobal, Name: "timed_mutexes", Value: "OFF"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "lc_messages", Value: "en_US"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "bulk_insert_buffer_size", Value: "8388608"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "binlog_direct_non_transactional_updates", Value: "OFF"},
+	{Scope: ScopeGlobal, Name: "innodb_change_buffering", Value: "all"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "sql_big_selects", Value: "ON"},
+	{Scope: ScopeGlobal | ScopeSession, Name: CharacterSetResults, Value: "latin1"},
+	{Scope: ScopeGlobal, Name: "innodb_max_purge_lag_delay", Value: "0"},
+	{Scope: ScopeGlobal | ScopeSession, Name: SessionTrackSchema, Value: "ON", Type: TypeBool},
+	{Scope: ScopeGlobal, Name: "innodb_io_capacity_max", Value: "2000"},
+	{Scope: ScopeGlobal, Name: "innodb_autoextend_increment", Value: "64"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "binlog_format", Value: "STATEMENT"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "optimizer_trace", Value: "enabled=off,one_line=off"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "read_rnd_buffer_size", Value: "262144"},
+	{Scope: ScopeNone, Name: "version_comment", Value: "MySQL Community Server (Apache License 2.0)"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "net_write_timeout", Value: "60"},
+	{Scope: ScopeGlobal, Name: "innodb_buffer_pool_load_abort", Value: "OFF"},
+	{Scope: ScopeGlobal | ScopeSession, Name: TxnIsolation, Value: "REPEATABLE-READ", Type: TypeEnum, PossibleValues: []string{"READ-UNCOMMITTED", "READ-COMMITTED", "REPEATABLE-READ", "SERIALIZABLE"}},
+	{Scope: ScopeGlobal | ScopeSession, Name: "collation_connection", Value: "latin1_swedish_ci"},
+	{Scope: ScopeGlobal, Name: "rpl_semi_sync_master_timeout", Value: ""},
+	{Scope: ScopeGlobal | ScopeSession, Name: "transaction_prealloc_size", Value: "4096"},
+	{Scope: ScopeNone, Name: "slave_skip_errors", Value: "OFF"},
+	{Scope: ScopeNone, Name: "performance_schema_setup_objects_size", Value: "100"},
+	{Scope: ScopeGlobal, Name: "sync_relay_log", Value: "10000"},
+	{Scope: ScopeGlobal, Name: "innodb_ft_result_cache_limit", Value: "2000000000"},
+	{Scope: ScopeNone, Name: "innodb_sort_buffer_size", Value: "1048576"},
+	{Scope: ScopeGlobal, Name: "innodb_ft_enable_diag_print", Value: "OFF"},
+	{Scope: ScopeNone, Name: "thread_handling", Value: "one-thread-per-connection"},
+	{Scope: ScopeGlobal, Name: "stored_program_cache", Value: "256"},
+	{Scope: ScopeNone, Name: "performance_schema_max_mutex_instances", Value: "15906"},
+	{Scope: ScopeGlobal, Name: "innodb_adaptive_max_sleep_delay", Value: "150000"},
+	{Scope: ScopeNone, Name: "large_pages", Value: "OFF"},
+	{Scope: ScopeGlobal | ScopeSession, Name: SessionTrackSystemVariables, Value: "time_zone,autocommit,character_set_client,character_set_results,character_set_connection"},
+	{Scope: ScopeGlobal, Name: "innodb_change_buffer_max_size", Value: "25"},
+	{Scope: ScopeGlobal, Name: "log_bin_trust_function_creators", Value: "OFF"},
+	{Scope: ScopeNone, Name: "innodb_write_io_threads", Value: "4"},
+	{Scope: ScopeGlobal, Name: "mysql_native_password_proxy_users", Value: ""},
+	{Scope: ScopeGlobal, Name: "read_only", Value: "OFF"},
+	{Scope: ScopeNone, Name: "large_page_size", Value: "0"},
+	{Scope: ScopeNone, Name: "table_open_cache_instances", Value: "1"},
+	{Scope: ScopeGlobal, Name: "innodb_stats_persistent", Value: "ON"},
+	{Scope: ScopeGlobal | ScopeSession, Name: SessionTrackStateChange, Value: "OFF", Type: TypeBool},
+	{Scope: ScopeSession, Name: ResultsetMetadata, Value: "FULL", Type: TypeEnum, PossibleValues: []string{"FULL", "NONE"}},
+	{Scope: ScopeGlobal | ScopeSession, Name: SessionTrackTransactionInfo, Value: TrackTxnInfoOff, Type: TypeEnum, PossibleValues: []string{TrackTxnInfoOff, TrackTxnInfoState, TrackTxnInfoCharacteristics}},
+	{Scope: ScopeNone, Name: "optimizer_switch", Value: "index_merge=on,index_merge_union=on,index_merge_sort_union=on,index_merge_intersection=on,engine_condition_pushdown=on,index_condition_pushdown=on,mrr=on,mrr_cost_based=on,block_nested_loop=on,batched_key_access=off,materialization=on,semijoin=on,loosescan=on,firstmatch=on,subquery_materialization_cost_based=on,use_index_extensions=on"},
+	{Scope: ScopeGlobal, Name: "delayed_queue_size", Value: "1000"},
+	{Scope: ScopeNone, Name: "innodb_read_only", Value: "OFF"},
+	{Scope: ScopeNone, Name: "datetime_format", Value: "%Y-%m-%d %H:%i:%s"},
+	{Scope: ScopeGlobal, Name: "log_syslog", Value: ""},
+	{Scope: ScopeNone, Name: "version", Value: mysql.ServerVersion},
+	{Scope: ScopeGlobal | ScopeSession, Name: "transaction_alloc_block_size", Value: "8192"},
+	{Scope: ScopeGlobal, Name: "sql_slave_skip_counter", Value: "0"},
+	{Scope: ScopeNone, Name: "have_openssl", Value: "DISABLED"},
+	{Scope: ScopeGlobal, Name: "innodb_large_prefix", Value: "OFF"},
+	{Scope: ScopeNone, Name: "performance_schema_max_cond_classes", Value: "80"},
+	{Scope: ScopeGlobal, Name: "innodb_io_capacity", Value: "200"},
+	{Scope: ScopeGlobal, Name: "max_binlog_cache_size", Value: "18446744073709547520"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "ndb_index_stat_enable", Value: ""},
+	{Scope: ScopeGlobal, Name: "executed_gtids_compression_period", Value: ""},
+	{Scope: ScopeNone, Name: "time_format", Value: "%H:%i:%s"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "old_alter_table", Value: "OFF"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "long_query_time", Value: "10.000000"},
+	{Scope: ScopeNone, Name: "innodb_use_native_aio", Value: "OFF"},
+	{Scope: ScopeGlobal, Name: "log_throttle_queries_not_using_indexes", Value: "0"},
+	{Scope: ScopeNone, Name: "locked_in_memory", Value: "OFF"},
+	{Scope: ScopeNone, Name: "innodb_api_enable_mdl", Value: "OFF"},
+	{Scope: ScopeGlobal, Name: "binlog_cache_size", Value: "32768"},
+	{Scope: ScopeGlobal, Name: "innodb_compression_pad_pct_max", Value: "50"},
+	{Scope: ScopeGlobal, Name: "innodb_commit_concurrency", Value: "0"},
+	{Scope: ScopeNone, Name: "ft_min_word_len", Value: "4"},
+	{Scope: ScopeGlobal, Name: "enforce_gtid_consistency", Value: "OFF"},
+	{Scope: ScopeGlobal, Name: "secure_auth", Value: "ON"},
+	{Scope: ScopeNone, Name: "max_tmp_tables", Value: "32"},
+	{Scope: ScopeGlobal, Name: "innodb_random_read_ahead", Value: "OFF"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "unique_checks", Value: "ON"},
+	{Scope: ScopeGlobal, Name: "internal_tmp_disk_storage_engine", Value: ""},
+	{Scope: ScopeGlobal | ScopeSession, Name: "myisam_repair_threads", Value: "1"},
+	{Scope: ScopeGlobal, Name: "ndb_eventbuffer_max_alloc", Value: ""},
+	{Scope: ScopeGlobal, Name: "innodb_read_ahead_threshold", Value: "56"},
+	{Scope: ScopeGlobal, Name: "key_cache_block_size", Value: "1024"},
+	{Scope: ScopeGlobal, Name: "rpl_semi_sync_slave_enabled", Value: ""},
+	{Scope: ScopeNone, Name: "ndb_recv_thread_cpu_mask", Value: ""},
+	{Scope: ScopeGlobal, Name: "gtid_purged", Value: ""},
+	{Scope: ScopeGlobal, Name: "max_binlog_stmt_cache_size", Value: "18446744073709547520"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "lock_wait_timeout", Value: "31536000"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "read_buffer_size", Value: "131072"},
+	{Scope: ScopeNone, Name: "innodb_read_io_threads", Value: "4"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "max_sp_recursion_depth", Value: "0", Type: TypeInt, MinValue: 0, MaxValue: 255},
+	{Scope: ScopeNone, Name: "ignore_builtin_innodb", Value: "OFF"},
+	{Scope: ScopeGlobal, Name: "rpl_semi_sync_master_enabled", Value: ""},
+	{Scope: ScopeGlobal, Name: "slow_query_log_file", Value: "/usr/local/mysql/data/localhost-slow.log"},
+	{Scope: ScopeGlobal, Name: "innodb_thread_sleep_delay", Value: "10000"},
+	{Scope: ScopeNone, Name: "license", Value: "Apache License 2.0"},
+	{Scope: ScopeGlobal, Name: "innodb_ft_aux_table", Value: ""},
+	{Scope: ScopeGlobal | ScopeSession, Name: "sql_warnings", Value: "OFF"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "keep_files_on_create", Value: "OFF"},
+	{Scope: ScopeGlobal, Name: "slave_preserve_commit_order", Value: ""},
+	{Scope: ScopeNone, Name: "innodb_data_file_path", Value: "ibdata1:12M:autoextend"},
+	{Scope: ScopeNone, Name: "performance_schema_setup_actors_size", Value: "100"},
+	{Scope: ScopeNone, Name: "innodb_additional_mem_pool_size", Value: "8388608"},
+	{Scope: ScopeNone, Name: "log_error", Value: "/usr/local/mysql/data/localhost.err"},
+	{Scope: ScopeGlobal, Name: "slave_exec_mode", Value: "STRICT"},
+	{Scope: ScopeGlobal, Name: "binlog_stmt_cache_size", Value: "32768"},
+	{Scope: ScopeNone, Name: "relay_log_info_file", Value: "relay-log.info"},
+	{Scope: ScopeNone, Name: "innodb_ft_total_cache_size", Value: "640000000"},
+	{Scope: ScopeNone, Name: "performance_schema_max_rwlock_instances", Value: "9102"},
+	{Scope: ScopeGlobal, Name: "table_open_cache", Value: "2000"},
+	{Scope: ScopeNone, Name: "log_slave_updates", Value: "OFF"},
+	{Scope: ScopeNone, Name: "performance_schema_events_stages_history_long_size", Value: "10000"},
+	{Scope: ScopeGlobal | ScopeSession, Name: AutocommitVar, Value: "ON", Type: TypeBool},
+	{Scope: ScopeSession, Name: "insert_id", Value: ""},
+	{Scope: ScopeGlobal | ScopeSession, Name: "default_tmp_storage_engine", Value: "InnoDB"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "optimizer_search_depth", Value: "62"},
+	{Scope: ScopeGlobal, Name: "max_points_in_geometry", Value: ""},
+	{Scope: ScopeGlobal, Name: "innodb_stats_sample_pages", Value: "8"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "profiling_history_size", Value: "15"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "character_set_database", Value: "latin1"},
+	{Scope: ScopeNone, Name: "have_symlink", Value: "YES"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "storage_engine", Value: "InnoDB"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "sql_log_off", Value: "OFF"},
+	{Scope: ScopeNone, Name: "explicit_defaults_for_timestamp", Value: "OFF"},
+	{Scope: ScopeNone, Name: "performance_schema_events_waits_history_size", Value: "10"},
+	{Scope: ScopeGlobal, Name: "log_syslog_tag", Value: ""},
+	{Scope: ScopeGlobal | ScopeSession, Name: "tx_read_only", Value: "0"},
+	{Scope: ScopeGlobal, Name: "rpl_semi_sync_master_wait_point", Value: ""},
+	{Scope: ScopeGlobal, Name: "innodb_undo_log_truncate", Value: ""},
+	{Scope: ScopeNone, Name: "simplified_binlog_gtid_recovery", Value: "OFF"},
+	{Scope: ScopeSession, Name: "innodb_create_intrinsic", Value: ""},
+	{Scope: ScopeGlobal, Name: "gtid_executed_compression_period", Value: ""},
+	{Scope: ScopeGlobal, Name: "ndb_log_empty_epochs", Value: ""},
+	{Scope: ScopeGlobal, Name: "max_prepared_stmt_count", Value: "16382"},
+	{Scope: ScopeNone, Name: "have_geometry", Value: "YES"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "optimizer_trace_max_mem_size", Value: "16384"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "net_retry_count", Value: "10"},
+	{Scope: ScopeSession, Name: "ndb_table_no_logging", Value: ""},
+	{Scope: ScopeGlobal | ScopeSession, Name: "optimizer_trace_features", Value: "greedy_search=on,range_optimizer=on,dynamic_range=on,repeated_subselect=on"},
+	{Scope: ScopeGlobal, Name: "innodb_flush_log_at_trx_commit", Value: "1"},
+	{Scope: ScopeGlobal, Name: "rewriter_enabled", Value: ""},
+	{Scope: ScopeGlobal, Name: "query_cache_min_res_unit", Value: "4096"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "updatable_views_with_limit", Value: "YES"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "optimizer_prune_level", Value: "1"},
+	{Scope: ScopeGlobal, Name: "slave_sql_verify_checksum", Value: "ON"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "completion_type", Value: "NO_CHAIN"},
+	{Scope: ScopeGlobal, Name: "binlog_checksum", Value: "CRC32"},
+	{Scope: ScopeNone, Name: "report_port", Value: "3306"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "show_old_temporals", Value: "OFF"},
+	{Scope: ScopeGlobal, Name: "query_cache_limit", Value: "1048576"},
+	{Scope: ScopeGlobal, Name: "innodb_buffer_pool_size", Value: "134217728"},
+	{Scope: ScopeGlobal, Name: "innodb_adaptive_flushing", Value: "ON"},
+	{Scope: ScopeNone, Name: "datadir", Value: "/usr/local/mysql/data/"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "wait_timeout", Value: "28800"},
+	{Scope: ScopeGlobal, Name: "innodb_monitor_enable", Value: ""},
+	{Scope: ScopeNone, Name: "date_format", Value: "%Y-%m-%d"},
+	{Scope: ScopeGlobal, Name: "innodb_buffer_pool_filename", Value: "ib_buffer_pool"},
+	{Scope: ScopeGlobal, Name: "slow_launch_time", Value: "2"},
+	{Scope: ScopeGlobal, Name: "slave_max_allowed_packet", Value: "1073741824"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "ndb_use_transactions", Value: ""},
+	{Scope: ScopeNone, Name: "innodb_purge_threads", Value: "1"},
+	{Scope: ScopeGlobal, Name: "innodb_concurrency_tickets", Value: "5000"},
+	{Scope: ScopeGlobal, Name: "innodb_monitor_reset_all", Value: ""},
+	{Scope: ScopeNone, Name: "performance_schema_users_size", Value: "100"},
+	{Scope: ScopeGlobal, Name: "ndb_log_updated_only", Value: ""},
+	{Scope: ScopeNone, Name: "basedir", Value: "/usr/local/mysql"},
+	{Scope: ScopeGlobal, Name: "innodb_old_blocks_time", Value: "1000"},
+	{Scope: ScopeGlobal, Name: "innodb_stats_method", Value: "nulls_equal"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "innodb_lock_wait_timeout", Value: "50"},
+	{Scope: ScopeGlobal, Name: "local_infile", Value: "ON"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "myisam_stats_method", Value: "nulls_unequal"},
+	{Scope: ScopeNone, Name: "version_compile_os", Value: "osx10.8"},
+	{Scope: ScopeNone, Name: "relay_log_recovery", Value: "OFF"},
+	{Scope: ScopeNone, Name: "old", Value: "OFF"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "innodb_table_locks", Value: "ON"},
+	{Scope: ScopeNone, Name: "performance_schema", Value: "ON"},
+	{Scope: ScopeNone, Name: "myisam_recover_options", Value: "OFF"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "net_buffer_length", Value: "16384"},
+	{Scope: ScopeGlobal, Name: "rpl_semi_sync_master_wait_for_slave_count", Value: ""},
+	{Scope: ScopeGlobal | ScopeSession, Name: "binlog_row_image", Value: "FULL"},
+	{Scope: ScopeNone, Name: "innodb_locks_unsafe_for_binlog", Value: "OFF"},
+	{Scope: ScopeSession, Name: "rbr_exec_mode", Value: ""},
+	{Scope: ScopeGlobal, Name: "myisam_max_sort_file_size", Value: "9223372036853727232"},
+	{Scope: ScopeNone, Name: "back_log", Value: "80"},
+	{Scope: ScopeNone, Name: "lower_case_file_system", Value: "ON"},
+	{Scope: ScopeGlobal, Name: "rpl_semi_sync_master_wait_no_slave", Value: ""},
+	{Scope: ScopeGlobal | ScopeSession, Name: "group_concat_max_len", Value: "1024"},
+	{Scope: ScopeSession, Name: "pseudo_thread_id", Value: ""},
+	{Scope: ScopeNone, Name: "socket", Value: "/tmp/myssock"},
+	{Scope: ScopeNone, Name: "have_dynamic_loading", Value: "YES"},
+	{Scope: ScopeGlobal, Name: "rewriter_verbose", Value: ""},
+	{Scope: ScopeGlobal, Name: "innodb_undo_logs", Value: "128"},
+	{Scope: ScopeNone, Name: "performance_schema_max_cond_instances", Value: "3504"},
+	{Scope: ScopeGlobal, Name: "delayed_insert_limit", Value: "100"},
+	{Scope: ScopeGlobal, Name: "flush", Value: "OFF"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "eq_range_index_dive_limit", Value: "10"},
+	{Scope: ScopeNone, Name: "performance_schema_events_stages_history_size", Value: "10"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "character_set_connection", Value: "latin1"},
+	{Scope: ScopeGlobal, Name: "myisam_use_mmap", Value: "OFF"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "ndb_join_pushdown", Value: ""},
+	{Scope: ScopeGlobal | ScopeSession, Name: "character_set_server", Value: "latin1"},
+	{Scope: ScopeGlobal, Name: "validate_password_special_char_count", Value: "1"},
+	{Scope: ScopeNone, Name: "performance_schema_max_thread_instances", Value: "402"},
+	{Scope: ScopeGlobal, Name: "slave_rows_search_algorithms", Value: "TABLE_SCAN,INDEX_SCAN"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "ndbinfo_show_hidden", Value: ""},
+	{Scope: ScopeGlobal | ScopeSession, Name: "net_read_timeout", Value: "30"},
+	{Scope: ScopeNone, Name: "innodb_page_size", Value: "16384"},
+	{Scope: ScopeGlobal, Name: MaxAllowedPacket, Value: "67108864"},
+	{Scope: ScopeNone, Name: "innodb_log_file_size", Value: "50331648"},
+	{Scope: ScopeGlobal, Name: "sync_relay_log_info", Value: "10000"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "optimizer_trace_limit", Value: "1"},
+	{Scope: ScopeNone, Name: "innodb_ft_max_token_size", Value: "84"},
+	{Scope: ScopeGlobal, Name: "validate_password_length", Value: "8"},
+	{Scope: ScopeGlobal, Name: "ndb_log_binlog_index", Value: ""},
+	{Scope: ScopeGlobal, Name: "validate_password_mixed_case_count", Value: "1"},
+	{Scope: ScopeGlobal, Name: "innodb_api_bk_commit_interval", Value: "5"},
+	{Scope: ScopeNone, Name: "innodb_undo_directory", Value: "."},
+	{Scope: ScopeNone, Name: "bind_address", Value: "*"},
+	{Scope: ScopeGlobal, Name: "innodb_sync_spin_loops", Value: "30"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "sql_safe_updates", Value: "OFF"},
+	{Scope: ScopeNone, Name: "tmpdir", Value: "/var/tmp/"},
+	{Scope: ScopeGlobal, Name: "innodb_thread_concurrency", Value: "0"},
+	{Scope: ScopeGlobal, Name: "slave_allow_batching", Value: "OFF"},
+	{Scope: ScopeGlobal, Name: "innodb_buffer_pool_dump_pct", Value: ""},
+	{Scope: ScopeGlobal | ScopeSession, Name: "lc_time_names", Value: "en_US"},
+	{Scope: ScopeGlobal | ScopeSession, Name: "max_statement_time", Value: ""},
+	{Scope: ScopeGlobal | ScopeSession, Name: "end_markers_in_json", Value: "OFF"},
+	{Scope: ScopeGlobal, Name: "avoid_temporal_upgrade", Value: "OFF"},
+	{Scope: ScopeGlobal, Name: "key_cache_age_threshold", Value: "300"},
+	{Scope: ScopeGlobal, Name: "innodb_status_output", Value: "OFF"},
+	{Scope: ScopeSession, Name: "identity", Value: ""},
+	{Scope: ScopeGlobal | ScopeSession, Name: "min_examined_row_limit", Value: "0"},
+	{Scope: ScopeGlobal, Name: "sync_frm", Value: "ON"},
+	{Scope: ScopeGlobal, Name: "innodb_online_alter_log_max_size", Value: "134217728"},
 	/* TiDB specific variables */
-	{ScopeSession, TiDBSnapshot, ""},
-	{ScopeSession, TiDBSkipConstraintCheck, "0"},
-	{ScopeSession, TiDBOptAggPushDown, boolToIntStr(DefOptAggPushDown)},
-	{ScopeSession, TiDBOptInSubqUnFolding, boolToIntStr(DefOptInSubqUnfolding)},
-	{ScopeSession, TiDBBuildStatsConcurrency, strconv.Itoa(DefBuildStatsConcurrency)},
-	{ScopeGlobal | ScopeSession, TiDBDistSQLScanConcurrency, strconv.Itoa(DefDistSQLScanConcurrency)},
-	{ScopeGlobal | ScopeSession, TiDBIndexJoinBatchSize, strconv.Itoa(DefIndexJoinBatchSize)},
-	{ScopeGlobal | ScopeSession, TiDBIndexLookupSize, strconv.Itoa(DefIndexLookupSize)},
-	{ScopeGlobal | ScopeSession, TiDBIndexLookupConcurrency, strconv.Itoa(DefIndexLookupConcurrency)},
-	{ScopeGlobal | ScopeSession, TiDBIndexSerialScanConcurrency, strconv.Itoa(DefIndexSerialScanConcurrency)},
-	{ScopeGlobal | ScopeSession, TiDBMaxRowCountForINLJ, strconv.Itoa(DefMaxRowCountForINLJ)},
-	{ScopeGlobal | ScopeSession, TiDBCBO, "ON"},
-	{ScopeGlobal | ScopeSession, TiDBSkipUTF8Check, boolToIntStr(DefSkipUTF8Check)},
-	{ScopeSession, TiDBBatchInsert, boolToIntStr(DefBatchInsert)},
-	{ScopeSession, TiDBCurrentTS, strconv.Itoa(DefCurretTS)},
+	{Scope: ScopeSession, Name: TiDBSnapshot, Value: ""},
+	{Scope: ScopeSession, Name: TiDBSkipConstraintCheck, Value: "0", Type: TypeBool},
+	{Scope: ScopeSession, Name: TiDBOptAggPushDown, Value: boolToIntStr(DefOptAggPushDown), Type: TypeBool},
+	{Scope: ScopeSession, Name: TiDBOptInSubqUnFolding, Value: boolToIntStr(DefOptInSubqUnfolding), Type: TypeBool},
+	{Scope: ScopeSession, Name: TiDBOptUseInvisibleIndexes, Value: boolToIntStr(DefOptUseInvisibleIndexes), Type: TypeBool},
+	{Scope: ScopeSession, Name: TiDBBuildStatsConcurrency, Value: strconv.Itoa(DefBuildStatsConcurrency), Type: TypeInt, MinValue: 1, MaxValue: math.MaxInt32},
+	{Scope: ScopeGlobal | ScopeSession, Name: TiDBDistSQLScanConcurrency, Value: strconv.Itoa(DefDistSQLScanConcurrency), Type: TypeInt, MinValue: 1, MaxValue: math.MaxInt32},
+	{Scope: ScopeGlobal | ScopeSession, Name: TiDBDistSQLLowPriorityConcurrency, Value: strconv.Itoa(DefDistSQLLowPriorityConcurrency), Type: TypeInt, MinValue: 0, MaxValue: math.MaxInt32},
+	{Scope: ScopeGlobal | ScopeSession, Name: TiDBIndexJoinBatchSize, Value: strconv.Itoa(DefIndexJoinBatchSize), Type: TypeInt, MinValue: 1, MaxValue: math.MaxInt32},
+	{Scope: ScopeGlobal | ScopeSession, Name: TiDBIndexLookupSize, Value: strconv.Itoa(DefIndexLookupSize), Type: TypeInt, MinValue: 1, MaxValue: math.MaxInt32},
+	{Scope: ScopeGlobal | ScopeSession, Name: TiDBIndexLookupConcurrency, Value: strconv.Itoa(DefIndexLookupConcurrency), Type: TypeInt, MinValue: 1, MaxValue: math.MaxInt32},
+	{Scope: ScopeGlobal | ScopeSession, Name: TiDBIndexSerialScanConcurrency, Value: strconv.Itoa(DefIndexSerialScanConcurrency), Type: TypeInt, MinValue: 1, MaxValue: math.MaxInt32},
+	{Scope: ScopeGlobal | ScopeSession, Name: TiDBMaxRowCountForINLJ, Value: strconv.Itoa(DefMaxRowCountForINLJ), Type: TypeInt, MinValue: 1, MaxValue: math.MaxInt32},
+	{Scope: ScopeGlobal | ScopeSession, Name: TiDBCBO, Value: "ON", Type: TypeBool},
+	{Scope: ScopeGlobal | ScopeSession, Name: TiDBOptCPUFactor, Value: floatToStr(DefOptCPUFactor), Type: TypeFloat, MinValue: 0, MaxValue: math.MaxInt32},
+	{Scope: ScopeGlobal | ScopeSession, Name: TiDBOptNetworkFactor, Value: floatToStr(DefOptNetworkFactor), Type: TypeFloat, MinValue: 0, MaxValue: math.MaxInt32},
+	{Scope: ScopeGlobal | ScopeSession, Name: TiDBOptScanFactor, Value: floatToStr(DefOptScanFactor), Type: TypeFloat, MinValue: 0, MaxValue: math.MaxInt32},
+	{Scope: ScopeGlobal | ScopeSession, Name: TiDBOptDescScanFactor, Value: floatToStr(DefOptDescScanFactor), Type: TypeFloat, MinValue: 0, MaxValue: math.MaxInt32},
+	{Scope: ScopeGlobal | ScopeSession, Name: TiDBOptSeekFactor, Value: floatToStr(DefOptSeekFactor), Type: TypeFloat, MinValue: 0, MaxValue: math.MaxInt32},
+	{Scope: ScopeGlobal | ScopeSession, Name: TiDBOptMemoryFactor, Value: floatToStr(DefOptMemoryFactor), Type: TypeFloat, MinValue: 0, MaxValue: math.MaxInt32},
+	{Scope: ScopeGlobal | ScopeSession, Name: TiDBEnableCascadesPlanner, Value: boolToIntStr(DefEnableCascadesPlanner), Type: TypeBool},
+	{Scope: ScopeGlobal | ScopeSession, Name: TiDBApplyCacheCapacity, Value: strconv.Itoa(DefApplyCacheCapacity), Type: TypeInt, MinValue: 0, MaxValue: math.MaxInt32},
+	{Scope: ScopeGlobal | ScopeSession, Name: TiDBProjectionConcurrency, Value: strconv.Itoa(DefProjectionConcurrency), Type: TypeInt, MinValue: 1, MaxValue: math.MaxInt32},
+	{Scope: ScopeGlobal | ScopeSession, Name: TiDBSkipUTF8Check, Value: boolToIntStr(DefSkipUTF8Check), Type: TypeBool},
+	{Scope: ScopeSession, Name: TiDBBatchInsert, Value: boolToIntStr(DefBatchInsert), Type: TypeBool},
+	{Scope: ScopeGlobal | ScopeSession, Name: TiDBEnableChunkRPC, Value: boolToIntStr(DefEnableChunkRPC), Type: TypeBool},
+	{Scope: ScopeGlobal | ScopeSession, Name: TiDBForcePriority, Value: mysql.Priority2Str[DefForcePriority], Type: TypeEnum, PossibleValues: priorityValues},
+	{Scope: ScopeSession, Name: TiDBCurrentTS, Value: strconv.Itoa(DefCurretTS)},
+	{Scope: ScopeGlobal, Name: TiDBRedactLog, Value: boolToIntStr(DefRedactLog), Type: TypeBool},
+	{Scope: ScopeGlobal | ScopeSession, Name: TiDBNotifySchemaChange, Value: boolToIntStr(DefNotifySchemaChange), Type: TypeBool},
+	{Scope: ScopeGlobal | ScopeSession, Name: TiDBAllowPartialResult, Value: boolToIntStr(DefAllowPartialResult), Type: TypeBool},
+	{Scope: ScopeSession, Name: TiDBSessionAlias, Value: ""},
+	{Scope: ScopeGlobal | ScopeSession, Name: TiDBUDFMaxSteps, Value: strconv.Itoa(DefUDFMaxSteps), Type: TypeInt, MinValue: 1, MaxValue: math.MaxInt32},
+	{Scope: ScopeGlobal | ScopeSession, Name: TiDBUDFMaxStringLen, Value: strconv.Itoa(DefUDFMaxStringLen), Type: TypeInt, MinValue: 1, MaxValue: math.MaxInt32},
+	{Scope: ScopeGlobal | ScopeSession, Name: TiDBEnableResultCache, Value: boolToIntStr(DefEnableResultCache), Type: TypeBool},
+	{Scope: ScopeInstance, Name: TiDBSlowLogThreshold, Value: strconv.Itoa(DefSlowLogThreshold), Type: TypeInt, MinValue: 0, MaxValue: math.MaxInt32,
+		SetGlobal: func(value string) error {
+			config.GetGlobalConfig().SlowThreshold, _ = strconv.Atoi(value)
+			return nil
+		},
+		GetGlobal: func() string {
+			return strconv.Itoa(config.GetGlobalConfig().SlowThreshold)
+		}},
+	{Scope: ScopeInstance, Name: TiDBQueryLogMaxLen, Value: strconv.Itoa(DefQueryLogMaxLen), Type: TypeInt, MinValue: 0, MaxValue: math.MaxInt32,
+		SetGlobal: func(value string) error {
+			config.GetGlobalConfig().QueryLogMaxlen, _ = strconv.Atoi(value)
+			return nil
+		},
+		GetGlobal: func() string {
+			return strconv.Itoa(config.GetGlobalConfig().QueryLogMaxlen)
+		}},
+	{Scope: ScopeInstance, Name: TiDBResultCacheSize, Value: strconv.Itoa(DefResultCacheSize), Type: TypeInt, MinValue: 0, MaxValue: math.MaxInt64,
+		SetGlobal: func(value string) error {
+			config.GetGlobalConfig().ResultCacheSize, _ = strconv.ParseInt(value, 10, 64)
+			return nil
+		},
+		GetGlobal: func() string {
+			return strconv.FormatInt(config.GetGlobalConfig().ResultCacheSize, 10)
+		}},
+	{Scope: ScopeInstance, Name: TiDBResultCacheTTL, Value: strconv.Itoa(DefResultCacheTTL), Type: TypeInt, MinValue: 1, MaxValue: math.MaxInt32,
+		SetGlobal: func(value string) error {
+			config.GetGlobalConfig().ResultCacheTTL, _ = strconv.Atoi(value)
+			return nil
+		},
+		GetGlobal: func() string {
+			return strconv.Itoa(config.GetGlobalConfig().ResultCacheTTL)
+		}},
+}
+
+// priorityValues are the possible values of tidb_force_priority.
+var priorityValues = []string{
+	mysql.Priority2Str[mysql.NoPriority],
+	mysql.Priority2Str[mysql.LowPriority],
+	mysql.Priority2Str[mysql.HighPriority],
+	mysql.Priority2Str[mysql.DelayedPriority],
 }
 
 // SetNamesVariables is the system variable names related to set names statements.
@@ -628,6 +807,38 @@ const (
 	CharsetDatabase = "character_set_database"
 	// CollationDatabase is the name for collation_database system variable.
 	CollationDatabase = "collation_database"
+	// MaxErrorCount is the name for max_error_count system variable, it limits the count of the messages
+	// shown by show warnings and errors.
+	MaxErrorCount = "max_error_count"
+	// SQLNotes is the name for sql_notes system variable.
+	SQLNotes = "sql_notes"
+	// AutoIncrementIncrement is the name for auto_increment_increment system variable, it's the interval between
+	// the generated auto_increment IDs.
+	AutoIncrementIncrement = "auto_increment_increment"
+	// AutoIncrementOffset is the name for auto_increment_offset system variable, it's the starting point of
+	// the generated auto_increment IDs.
+	AutoIncrementOffset = "auto_increment_offset"
+	// MaxConnections is the name for max_connections system variable, it limits the connections of a server,
+	// 0 means no limit.
+	MaxConnections = "max_connections"
+	// MaxUserConnections is the name for max_user_connections system variable, it limits the connections of an
+	// account to a server if the account has no MAX_USER_CONNECTIONS limit, 0 means no limit.
+	MaxUserConnections = "max_user_connections"
+	// SessionTrackSystemVariables is the name for session_track_system_variables system variable, it's the list
+	// of the system variables whose changes are sent to the client, "*" means all of them.
+	SessionTrackSystemVariables = "session_track_system_variables"
+	// SessionTrackSchema is the name for session_track_schema system variable, it indicates if the changes of the
+	// default database are sent to the client.
+	SessionTrackSchema = "session_track_schema"
+	// SessionTrackStateChange is the name for session_track_state_change system variable, it indicates if the
+	// client is told whether the session state is changed.
+	SessionTrackStateChange = "session_track_state_change"
+	// SessionTrackTransactionInfo is the name for session_track_transaction_info system variable, it indicates if
+	// the changes of the transaction state are sent to the client.
+	SessionTrackTransactionInfo = "session_track_transaction_info"
+	// ResultsetMetadata is the name for resultset_metadata system variable, the column definitions of the result
+	// sets are not sent if it's NONE and the client has the CLIENT_OPTIONAL_RESULTSET_METADATA capability.
+	ResultsetMetadata = "resultset_metadata"
 )
 
 // GlobalVarAccessor is the interface for accessing global scope system and status variables.
@@ -17,6 +17,7 @@ import (
 	"testing"
 
 	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/terror"
 )
 
 func TestT(t *testing.T) {
@@ -36,3 +37,51 @@ func (*testSysVarSuite) TestSysVar(c *C) {
 	f = GetSysVar("wrong-var-name")
 	c.Assert(f, IsNil)
 }
+
+func (*testSysVarSuite) TestValidate(c *C) {
+	vars := NewSessionVars()
+	tbl := []struct {
+		name   string
+		value  string
+		expect string
+		err    *terror.Error
+	}{
+		{AutocommitVar, "on", "ON", nil},
+		{AutocommitVar, "0", "0", nil},
+		{AutocommitVar, "yes", "", ErrWrongValueForVar},
+		{TxnIsolation, "read-committed", "READ-COMMITTED", nil},
+		{TxnIsolation, "READ COMMITTED", "", ErrWrongValueForVar},
+		{TiDBForcePriority, "low_priority", "LOW_PRIORITY", nil},
+		{TiDBIndexLookupSize, "100", "100", nil},
+		{TiDBIndexLookupSize, "0", "1", nil},
+		{TiDBIndexLookupSize, "abc", "", ErrWrongTypeForVar},
+		{TiDBOptScanFactor, "3.50", "3.5", nil},
+		{TiDBOptScanFactor, "-1", "0", nil},
+		{TiDBOptScanFactor, "abc", "", ErrWrongTypeForVar},
+		{TiDBSnapshot, "2017-11-11 20:20:20", "2017-11-11 20:20:20", nil},
+	}
+	for _, t := range tbl {
+		value, err := GetSysVar(t.name).Validate(vars, t.value, ScopeSession)
+		if t.err != nil {
+			c.Assert(terror.ErrorEqual(err, t.err), IsTrue, Commentf("%s = %s", t.name, t.value))
+			continue
+		}
+		c.Assert(err, IsNil)
+		c.Assert(value, Equals, t.expect)
+	}
+	// The clamped values of tidb_index_lookup_size and tidb_opt_scan_factor are warned.
+	c.Assert(vars.StmtCtx.WarningCount(), Equals, uint16(2))
+
+	sv := &SysVar{Scope: ScopeGlobal, Name: "test_validation", Type: TypeInt, MinValue: 1, MaxValue: 10,
+		Validation: func(vars *SessionVars, value string, scope ScopeFlag) (string, error) {
+			if scope == ScopeGlobal && value == "10" {
+				return "", ErrWrongValueForVar.GenByArgs("test_validation", value)
+			}
+			return value, nil
+		}}
+	_, err := sv.Validate(nil, "100", ScopeGlobal)
+	c.Assert(terror.ErrorEqual(err, ErrWrongValueForVar), IsTrue)
+	value, err := sv.Validate(nil, "9", ScopeGlobal)
+	c.Assert(err, IsNil)
+	c.Assert(value, Equals, "9")
+}
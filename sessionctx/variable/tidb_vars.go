@@ -13,12 +13,14 @@
 
 package variable
 
+import "github.com/pingcap/tidb/mysql"
+
 /*
 	Steps to add a new TiDB specific system variable:
 
 	1. Add a new variable name with comment in this file.
 	2. Add the default value of the new variable in this file.
-	3. Add SysVar instance in 'defaultSysVars' slice with the default value.
+	3. Add SysVar instance in 'defaultSysVars' slice with the default value, and the type for validation.
 	4. Add a field in `SessionVars`.
 	5. Update the `NewSessionVars` function to set the field to its default value.
 	6. Update the `varsutil.SetSessionSystemVar` function to use the new value when SET statement is executed.
@@ -44,6 +46,9 @@ const (
 	// tidb_opt_insubquery_unfold is used to enable/disable the optimizer rule of in subquery unfold.
 	TiDBOptInSubqUnFolding = "tidb_opt_insubquery_unfold"
 
+	// tidb_opt_use_invisible_indexes is used to let the optimizer consider the invisible indices in the session.
+	TiDBOptUseInvisibleIndexes = "tidb_opt_use_invisible_indexes"
+
 	// tidb_build_stats_concurrency is used to speed up the ANALYZE statement, when a table has multiple indices,
 	// those indices can be scanned concurrently, with the cost of higher system performance impact.
 	TiDBBuildStatsConcurrency = "tidb_build_stats_concurrency"
@@ -60,6 +65,11 @@ const (
 	// If the query has a LIMIT clause, high concurrency makes the system do much more work than needed.
 	TiDBDistSQLScanConcurrency = "tidb_distsql_scan_concurrency"
 
+	// tidb_distsql_low_priority_concurrency is used to limit the concurrency of the distsql scan tasks with low priority,
+	// such as the scans of expensive queries, so they take less share of the coprocessor requests of the stores.
+	// The value 0 means no more limit than tidb_distsql_scan_concurrency.
+	TiDBDistSQLLowPriorityConcurrency = "tidb_distsql_low_priority_concurrency"
+
 	// tidb_index_join_batch_size is used to set the batch size of a index lookup join.
 	// The index lookup join fetches batches of data from outer executor and constructs ranges for inner executor.
 	// This value controls how much of data in a batch to do the index join.
@@ -99,20 +109,119 @@ const (
 
 	// tidb_cbo uses new planner with cost based optimizer.
 	TiDBCBO = "tidb_cbo"
+
+	// tidb_opt_cpu_factor, tidb_opt_network_factor, tidb_opt_scan_factor, tidb_opt_desc_scan_factor,
+	// tidb_opt_seek_factor and tidb_opt_memory_factor are the factors of the cost model of the planner, they are
+	// the costs to process a row, send a row over the network, scan a row, scan a row in the reverse order,
+	// start a request to seek a batch of rows and keep a row in the memory. The factors are relative to each
+	// other, "admin calibrate" measures them on the current hardware.
+	TiDBOptCPUFactor      = "tidb_opt_cpu_factor"
+	TiDBOptNetworkFactor  = "tidb_opt_network_factor"
+	TiDBOptScanFactor     = "tidb_opt_scan_factor"
+	TiDBOptDescScanFactor = "tidb_opt_desc_scan_factor"
+	TiDBOptSeekFactor     = "tidb_opt_seek_factor"
+	TiDBOptMemoryFactor   = "tidb_opt_memory_factor"
+
+	// tidb_enable_cascades_planner uses the cascades planner to build the physical plan, it explores the
+	// equivalent plans by the transformation rules like pushing the aggregation across the join.
+	TiDBEnableCascadesPlanner = "tidb_enable_cascades_planner"
+
+	// tidb_apply_cache_capacity is the max number of the inner rows an apply caches by the values of the correlated
+	// columns, so the correlated subquery is executed once for the outer rows with the same values. 0 disables the cache.
+	TiDBApplyCacheCapacity = "tidb_apply_cache_capacity"
+
+	// tidb_projection_concurrency is the number of the workers which evaluate the expressions of a projection,
+	// the rows are still returned in their order. 1 evaluates the expressions in the executor itself.
+	TiDBProjectionConcurrency = "tidb_projection_concurrency"
+
+	// tidb_enable_chunk_rpc asks the coprocessor to return the DAG request results in chunk encoded format,
+	// which can be decoded with less CPU. It only takes effect if the coprocessor supports it.
+	TiDBEnableChunkRPC = "tidb_enable_chunk_rpc"
+
+	// tidb_force_priority is used to set the priority of the statements which don't specify one,
+	// the value can be NO_PRIORITY, LOW_PRIORITY, HIGH_PRIORITY or DELAYED.
+	// The priority is sent with the KV and coprocessor requests, so TiKV can favor the latency sensitive statements.
+	TiDBForcePriority = "tidb_force_priority"
+
+	// tidb_redact_log replaces the literals in the SQL of the statement logs by "?",
+	// so the user data isn't written to the log files. It's a global variable so a user without the SUPER
+	// privilege can't turn it off for the session.
+	TiDBRedactLog = "tidb_redact_log"
+
+	// tidb_notify_schema_change warns the session when a table used by its statements is changed or dropped
+	// by another session, so the long-lived connections know the cached metadata of the table is stale.
+	TiDBNotifySchemaChange = "tidb_notify_schema_change"
+
+	// tidb_allow_partial_result makes the SELECT statements skip the ranges of the regions which are still
+	// unavailable after the retries, the rows of the other ranges are returned with a warning listing the
+	// skipped ranges. It's for the approximate analytics which prefer a partial result to an error.
+	TiDBAllowPartialResult = "tidb_allow_partial_result"
+
+	// tidb_session_alias is a name of the session given by the client, it is written to the statement logs,
+	// so the logs of a session can be found by the name.
+	TiDBSessionAlias = "tidb_session_alias"
+
+	// tidb_udf_max_steps is the max number of the statements and the expressions evaluated by a call of a
+	// user-defined function, the call fails if the script runs more steps, so a dead loop can't hang the query.
+	TiDBUDFMaxSteps = "tidb_udf_max_steps"
+
+	// tidb_udf_max_string_len is the max length in bytes of the strings created by a call of a user-defined function.
+	TiDBUDFMaxStringLen = "tidb_udf_max_string_len"
+
+	// tidb_enable_result_cache makes the read-only queries of the session use the query result cache of the server.
+	// A cached result may miss the writes done on the other servers in tidb_result_cache_ttl seconds.
+	TiDBEnableResultCache = "tidb_enable_result_cache"
+
+	/* Instance only */
+
+	// tidb_slow_log_threshold is the threshold in milliseconds of the slow query log of this server.
+	TiDBSlowLogThreshold = "tidb_slow_log_threshold"
+
+	// tidb_query_log_max_len is the max length of the query in the logs of this server.
+	TiDBQueryLogMaxLen = "tidb_query_log_max_len"
+
+	// tidb_result_cache_size is the max bytes of the query results cached on this server, 0 disables the cache.
+	TiDBResultCacheSize = "tidb_result_cache_size"
+
+	// tidb_result_cache_ttl is the max seconds a cached query result is used for on this server.
+	TiDBResultCacheTTL = "tidb_result_cache_ttl"
 )
 
 // Default TiDB system variable values.
 const (
-	DefIndexLookupConcurrency     = 4
-	DefIndexSerialScanConcurrency = 1
-	DefIndexJoinBatchSize         = 25000
-	DefIndexLookupSize            = 20000
-	DefDistSQLScanConcurrency     = 10
-	DefBuildStatsConcurrency      = 4
-	DefMaxRowCountForINLJ         = 128
-	DefSkipUTF8Check              = false
-	DefOptAggPushDown             = true
-	DefOptInSubqUnfolding         = false
-	DefBatchInsert                = false
-	DefCurretTS                   = 0
+	DefIndexLookupConcurrency        = 4
+	DefIndexSerialScanConcurrency    = 1
+	DefIndexJoinBatchSize            = 25000
+	DefIndexLookupSize               = 20000
+	DefDistSQLScanConcurrency        = 10
+	DefDistSQLLowPriorityConcurrency = 0
+	DefBuildStatsConcurrency         = 4
+	DefMaxRowCountForINLJ            = 128
+	DefOptCPUFactor                  = 0.9
+	DefOptNetworkFactor              = 1.5
+	DefOptScanFactor                 = 2.0
+	DefOptDescScanFactor             = 5 * DefOptScanFactor
+	DefOptSeekFactor                 = 20.0
+	DefOptMemoryFactor               = 5.0
+	DefEnableCascadesPlanner         = false
+	DefApplyCacheCapacity            = 100000
+	DefProjectionConcurrency         = 1
+	DefSkipUTF8Check                 = false
+	DefOptAggPushDown                = true
+	DefOptInSubqUnfolding            = false
+	DefOptUseInvisibleIndexes        = false
+	DefBatchInsert                   = false
+	DefEnableChunkRPC                = false
+	DefForcePriority                 = mysql.NoPriority
+	DefRedactLog                     = false
+	DefNotifySchemaChange            = false
+	DefAllowPartialResult            = false
+	DefUDFMaxSteps                   = 1000000
+	DefUDFMaxStringLen               = 1 << 20
+	DefEnableResultCache             = false
+	DefCurretTS                      = 0
+	DefSlowLogThreshold              = 300
+	DefQueryLogMaxLen                = 2048
+	DefResultCacheSize               = 0
+	DefResultCacheTTL                = 60
 )
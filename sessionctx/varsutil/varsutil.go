@@ -39,6 +39,9 @@ func GetSessionSystemVar(s *variable.SessionVars, key string) (string, error) {
 	case variable.TiDBCurrentTS:
 		return fmt.Sprintf("%d", s.TxnCtx.StartTS), nil
 	}
+	if sysVar.Scope == variable.ScopeInstance {
+		return sysVar.GetGlobal(), nil
+	}
 
 	sVal, ok := s.Systems[key]
 	if ok {
@@ -67,10 +70,31 @@ func GetGlobalSystemVar(s *variable.SessionVars, key string) (string, error) {
 		return "", variable.ErrIncorrectScope
 	} else if sysVar.Scope == variable.ScopeNone {
 		return sysVar.Value, nil
+	} else if sysVar.Scope == variable.ScopeInstance {
+		return sysVar.GetGlobal(), nil
 	}
 	return s.GlobalVarsAccessor.GetGlobalSysVar(key)
 }
 
+// SetGlobalSystemVar validates the value and sets a global system variable.
+// The value of a ScopeInstance variable is only set on this server, the others are persisted by the
+// GlobalVarsAccessor and take effect on all the servers.
+func SetGlobalSystemVar(s *variable.SessionVars, key string, value string) error {
+	key = strings.ToLower(key)
+	sysVar := variable.SysVars[key]
+	if sysVar == nil {
+		return variable.UnknownSystemVar.GenByArgs(key)
+	}
+	value, err := sysVar.Validate(s, value, variable.ScopeGlobal)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if sysVar.Scope == variable.ScopeInstance {
+		return errors.Trace(sysVar.SetGlobal(value))
+	}
+	return s.GlobalVarsAccessor.SetGlobalSysVar(key, value)
+}
+
 // epochShiftBits is used to reserve logical part of the timestamp.
 const epochShiftBits = 18
 
@@ -92,6 +116,10 @@ func SetSessionSystemVar(vars *variable.SessionVars, name string, value types.Da
 	if err != nil {
 		return errors.Trace(err)
 	}
+	sVal, err = sysVar.Validate(vars, sVal, variable.ScopeSession)
+	if err != nil {
+		return errors.Trace(err)
+	}
 	switch name {
 	case variable.TimeZone:
 		vars.TimeZone, err = parseTimeZone(sVal)
@@ -132,6 +160,8 @@ func SetSessionSystemVar(vars *variable.SessionVars, name string, value types.Da
 		vars.AllowAggPushDown = tidbOptOn(sVal)
 	case variable.TiDBOptInSubqUnFolding:
 		vars.AllowInSubqueryUnFolding = tidbOptOn(sVal)
+	case variable.TiDBOptUseInvisibleIndexes:
+		vars.OptimizerUseInvisibleIndexes = tidbOptOn(sVal)
 	case variable.TiDBIndexLookupConcurrency:
 		vars.IndexLookupConcurrency = tidbOptPositiveInt(sVal, variable.DefIndexLookupConcurrency)
 	case variable.TiDBIndexJoinBatchSize:
@@ -140,16 +170,67 @@ func SetSessionSystemVar(vars *variable.SessionVars, name string, value types.Da
 		vars.IndexLookupSize = tidbOptPositiveInt(sVal, variable.DefIndexLookupSize)
 	case variable.TiDBDistSQLScanConcurrency:
 		vars.DistSQLScanConcurrency = tidbOptPositiveInt(sVal, variable.DefDistSQLScanConcurrency)
+	case variable.TiDBDistSQLLowPriorityConcurrency:
+		vars.DistSQLLowPriorityConcurrency = tidbOptPositiveInt(sVal, variable.DefDistSQLLowPriorityConcurrency)
 	case variable.TiDBIndexSerialScanConcurrency:
 		vars.IndexSerialScanConcurrency = tidbOptPositiveInt(sVal, variable.DefIndexSerialScanConcurrency)
 	case variable.TiDBBatchInsert:
 		vars.BatchInsert = tidbOptOn(sVal)
+	case variable.TiDBEnableChunkRPC:
+		vars.EnableChunkRPC = tidbOptOn(sVal)
+	case variable.TiDBForcePriority:
+		pri, ok := mysql.Str2Priority(sVal)
+		if !ok {
+			return variable.ErrWrongValueForVar.GenByArgs(name, sVal)
+		}
+		vars.ForcePriority = pri
+		sVal = mysql.Priority2Str[pri]
+	case variable.TiDBRedactLog:
+		vars.RedactLog = tidbOptOn(sVal)
+	case variable.TiDBNotifySchemaChange:
+		vars.NotifySchemaChange = tidbOptOn(sVal)
+	case variable.TiDBAllowPartialResult:
+		vars.AllowPartialResult = tidbOptOn(sVal)
+	case variable.TiDBSessionAlias:
+		vars.SessionAlias = sVal
+	case variable.TiDBUDFMaxSteps:
+		vars.UDFMaxSteps = tidbOptPositiveInt(sVal, variable.DefUDFMaxSteps)
+	case variable.TiDBUDFMaxStringLen:
+		vars.UDFMaxStringLen = tidbOptPositiveInt(sVal, variable.DefUDFMaxStringLen)
+	case variable.TiDBEnableResultCache:
+		vars.EnableResultCache = tidbOptOn(sVal)
 	case variable.TiDBMaxRowCountForINLJ:
 		vars.MaxRowCountForINLJ = tidbOptPositiveInt(sVal, variable.DefMaxRowCountForINLJ)
 	case variable.TiDBCBO:
 		vars.CBO = tidbOptOn(sVal)
+	case variable.TiDBOptCPUFactor:
+		vars.CostFactors.CPU = tidbOptFloat(sVal, variable.DefOptCPUFactor)
+	case variable.TiDBOptNetworkFactor:
+		vars.CostFactors.Network = tidbOptFloat(sVal, variable.DefOptNetworkFactor)
+	case variable.TiDBOptScanFactor:
+		vars.CostFactors.Scan = tidbOptFloat(sVal, variable.DefOptScanFactor)
+	case variable.TiDBOptDescScanFactor:
+		vars.CostFactors.DescScan = tidbOptFloat(sVal, variable.DefOptDescScanFactor)
+	case variable.TiDBOptSeekFactor:
+		vars.CostFactors.Seek = tidbOptFloat(sVal, variable.DefOptSeekFactor)
+	case variable.TiDBOptMemoryFactor:
+		vars.CostFactors.Memory = tidbOptFloat(sVal, variable.DefOptMemoryFactor)
+	case variable.TiDBEnableCascadesPlanner:
+		vars.EnableCascadesPlanner = tidbOptOn(sVal)
+	case variable.TiDBApplyCacheCapacity:
+		vars.ApplyCacheCapacity = tidbOptNonNegativeInt(sVal, variable.DefApplyCacheCapacity)
+	case variable.TiDBProjectionConcurrency:
+		vars.ProjectionConcurrency = tidbOptPositiveInt(sVal, variable.DefProjectionConcurrency)
 	case variable.TiDBCurrentTS:
 		return variable.ErrReadOnly
+	case variable.SQLNotes:
+		vars.SQLNotes = tidbOptOn(sVal)
+	case variable.ResultsetMetadata:
+		vars.SkipResultsetMetadata = sVal == "NONE"
+	case variable.AutoIncrementIncrement:
+		vars.AutoIncrementIncrement = tidbOptPositiveInt(sVal, 1)
+	case variable.AutoIncrementOffset:
+		vars.AutoIncrementOffset = tidbOptPositiveInt(sVal, 1)
 	}
 	vars.Systems[name] = sVal
 	return nil
@@ -168,6 +249,22 @@ func tidbOptPositiveInt(opt string, defaultVal int) int {
 	return val
 }
 
+func tidbOptNonNegativeInt(opt string, defaultVal int) int {
+	val, err := strconv.Atoi(opt)
+	if err != nil || val < 0 {
+		return defaultVal
+	}
+	return val
+}
+
+func tidbOptFloat(opt string, defaultVal float64) float64 {
+	val, err := strconv.ParseFloat(opt, 64)
+	if err != nil {
+		return defaultVal
+	}
+	return val
+}
+
 func parseTimeZone(s string) (*time.Location, error) {
 	if s == "SYSTEM" {
 		// TODO: Support global time_zone variable, it should be set to global time_zone value.
@@ -18,6 +18,7 @@ import (
 	"time"
 
 	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/config"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/terror"
@@ -156,6 +157,93 @@ func (s *testVarsutilSuite) TestVarsutil(c *C) {
 	c.Assert(v.MaxRowCountForINLJ, Equals, 128)
 	SetSessionSystemVar(v, variable.TiDBMaxRowCountForINLJ, types.NewStringDatum("127"))
 	c.Assert(v.MaxRowCountForINLJ, Equals, 127)
+
+	c.Assert(v.EnableChunkRPC, IsFalse)
+	SetSessionSystemVar(v, variable.TiDBEnableChunkRPC, types.NewStringDatum("1"))
+	c.Assert(v.EnableChunkRPC, IsTrue)
+
+	// Test case for tidb_distsql_low_priority_concurrency.
+	c.Assert(v.DistSQLLowPriorityConcurrency, Equals, 0)
+	SetSessionSystemVar(v, variable.TiDBDistSQLLowPriorityConcurrency, types.NewStringDatum("3"))
+	c.Assert(v.DistSQLLowPriorityConcurrency, Equals, 3)
+	SetSessionSystemVar(v, variable.TiDBDistSQLLowPriorityConcurrency, types.NewStringDatum("0"))
+	c.Assert(v.DistSQLLowPriorityConcurrency, Equals, 0)
+
+	// Test case for tidb_force_priority.
+	c.Assert(v.ForcePriority, Equals, mysql.NoPriority)
+	err = SetSessionSystemVar(v, variable.TiDBForcePriority, types.NewStringDatum("high_priority"))
+	c.Assert(err, IsNil)
+	c.Assert(v.ForcePriority, Equals, mysql.HighPriority)
+	val, err = GetSessionSystemVar(v, variable.TiDBForcePriority)
+	c.Assert(err, IsNil)
+	c.Assert(val, Equals, "HIGH_PRIORITY")
+	err = SetSessionSystemVar(v, variable.TiDBForcePriority, types.NewStringDatum("normal"))
+	c.Assert(terror.ErrorEqual(err, variable.ErrWrongValueForVar), IsTrue)
+	c.Assert(v.ForcePriority, Equals, mysql.HighPriority)
+	SetSessionSystemVar(v, variable.TiDBForcePriority, types.NewStringDatum("DELAYED"))
+	c.Assert(v.ForcePriority, Equals, mysql.DelayedPriority)
+
+	// Test case for tidb_redact_log and tidb_session_alias.
+	c.Assert(v.RedactLog, IsFalse)
+	SetSessionSystemVar(v, variable.TiDBRedactLog, types.NewStringDatum("1"))
+	c.Assert(v.RedactLog, IsTrue)
+	SetSessionSystemVar(v, variable.TiDBSessionAlias, types.NewStringDatum("batch-job"))
+	c.Assert(v.SessionAlias, Equals, "batch-job")
+
+	// Test case for the cost factors.
+	c.Assert(v.CostFactors, Equals, variable.DefCostFactors)
+	SetSessionSystemVar(v, variable.TiDBOptCPUFactor, types.NewStringDatum("1.2"))
+	SetSessionSystemVar(v, variable.TiDBOptSeekFactor, types.NewStringDatum("100"))
+	c.Assert(v.CostFactors.CPU, Equals, 1.2)
+	c.Assert(v.CostFactors.Seek, Equals, 100.0)
+	c.Assert(v.Systems[variable.TiDBOptSeekFactor], Equals, "100")
+	c.Assert(v.CostFactors.Scan, Equals, variable.DefOptScanFactor)
+
+	// Test case for tidb_enable_cascades_planner.
+	c.Assert(v.EnableCascadesPlanner, IsFalse)
+	SetSessionSystemVar(v, variable.TiDBEnableCascadesPlanner, types.NewStringDatum("1"))
+	c.Assert(v.EnableCascadesPlanner, IsTrue)
+
+	// Test case for tidb_apply_cache_capacity.
+	c.Assert(v.ApplyCacheCapacity, Equals, variable.DefApplyCacheCapacity)
+	SetSessionSystemVar(v, variable.TiDBApplyCacheCapacity, types.NewStringDatum("0"))
+	c.Assert(v.ApplyCacheCapacity, Equals, 0)
+	SetSessionSystemVar(v, variable.TiDBApplyCacheCapacity, types.NewStringDatum("1024"))
+	c.Assert(v.ApplyCacheCapacity, Equals, 1024)
+
+	// Test case for tidb_projection_concurrency.
+	c.Assert(v.ProjectionConcurrency, Equals, variable.DefProjectionConcurrency)
+	SetSessionSystemVar(v, variable.TiDBProjectionConcurrency, types.NewStringDatum("4"))
+	c.Assert(v.ProjectionConcurrency, Equals, 4)
+
+	// Test case for the validation of the values.
+	err = SetSessionSystemVar(v, variable.TiDBIndexLookupSize, types.NewStringDatum("a lot"))
+	c.Assert(terror.ErrorEqual(err, variable.ErrWrongTypeForVar), IsTrue)
+	err = SetSessionSystemVar(v, variable.TiDBOptAggPushDown, types.NewStringDatum("maybe"))
+	c.Assert(terror.ErrorEqual(err, variable.ErrWrongValueForVar), IsTrue)
+	c.Assert(SetSessionSystemVar(v, variable.TiDBIndexLookupSize, types.NewStringDatum("-1")), IsNil)
+	c.Assert(v.IndexLookupSize, Equals, 1)
+
+	// Test case for the global variables.
+	c.Assert(SetGlobalSystemVar(v, variable.TiDBIndexLookupSize, "500"), IsNil)
+	val, err = GetGlobalSystemVar(v, variable.TiDBIndexLookupSize)
+	c.Assert(err, IsNil)
+	c.Assert(val, Equals, "500")
+	err = SetGlobalSystemVar(v, variable.TxnIsolation, "read committed")
+	c.Assert(terror.ErrorEqual(err, variable.ErrWrongValueForVar), IsTrue)
+
+	// Test case for the instance variables, they aren't stored by the GlobalVarsAccessor.
+	cfg := config.GetGlobalConfig()
+	defer func(threshold int) { cfg.SlowThreshold = threshold }(cfg.SlowThreshold)
+	c.Assert(SetGlobalSystemVar(v, variable.TiDBSlowLogThreshold, "100"), IsNil)
+	c.Assert(cfg.SlowThreshold, Equals, 100)
+	val, err = GetGlobalSystemVar(v, variable.TiDBSlowLogThreshold)
+	c.Assert(err, IsNil)
+	c.Assert(val, Equals, "100")
+	val, err = GetSessionSystemVar(v, variable.TiDBSlowLogThreshold)
+	c.Assert(err, IsNil)
+	c.Assert(val, Equals, "100")
+	c.Assert(v.GlobalVarsAccessor.(*mockGlobalAccessor).vars[variable.TiDBSlowLogThreshold], Equals, "300")
 }
 
 type mockGlobalAccessor struct {
@@ -32,6 +32,8 @@ type SortedBuilder struct {
 	Count           int64
 	isPK            bool
 	Hist            *Histogram
+	// desc indicates whether the index columns are descending, the datums are encoded in the order of the index keys.
+	desc []bool
 }
 
 // NewSortedBuilder creates a new SortedBuilder.
@@ -54,7 +56,7 @@ func (b *SortedBuilder) Iterate(datums []types.Datum) error {
 	if b.isPK {
 		data = datums[0]
 	} else {
-		bytes, err := codec.EncodeKey(nil, datums...)
+		bytes, err := codec.EncodeKeyWithDesc(nil, b.desc, datums...)
 		if err != nil {
 			return errors.Trace(err)
 		}
@@ -112,9 +114,10 @@ func (b *SortedBuilder) Iterate(datums []types.Datum) error {
 	return nil
 }
 
-// BuildIndex builds histogram for index.
-func BuildIndex(ctx context.Context, numBuckets, id int64, records ast.RecordSet) (int64, *Histogram, error) {
+// BuildIndex builds histogram for index, desc indicates whether the index columns are descending.
+func BuildIndex(ctx context.Context, numBuckets, id int64, desc []bool, records ast.RecordSet) (int64, *Histogram, error) {
 	b := NewSortedBuilder(ctx, numBuckets, id, false)
+	b.desc = desc
 	for {
 		row, err := records.Next()
 		if err != nil {
@@ -0,0 +1,88 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statistics
+
+import (
+	"math"
+	"sort"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// maxBackoffSelectivities is the number of the most selective selectivities used by the exponential backoff,
+// the other ones are ignored because their exponents are too small to matter.
+const maxBackoffSelectivities = 4
+
+// StatsRanges is the ranges on a column or an index of the table, it's used to estimate the row count.
+type StatsRanges struct {
+	// IsIndex tells whether ID is the ID of an index or a column.
+	IsIndex bool
+	ID      int64
+	Ranges  []*types.Range
+}
+
+// RangesSelectivity estimates the selectivity of the ranges on a column or an index.
+func (t *Table) RangesSelectivity(sc *variable.StatementContext, r *StatsRanges) (float64, error) {
+	if t.Count == 0 {
+		return 1, nil
+	}
+	var (
+		rowCount float64
+		err      error
+	)
+	if r.IsIndex {
+		rowCount, err = t.GetRowCountByIndexRanges(sc, r.ID, r.Ranges)
+	} else {
+		rowCount, err = t.GetRowCountByColumnRanges(sc, r.ID, r.Ranges)
+	}
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	return math.Min(rowCount/float64(t.Count), 1), nil
+}
+
+// EstimateRowCount estimates the row count of the table which satisfies all the ranges. The ranges should be on
+// different columns and indices, their selectivities are combined by CombineSelectivities.
+func (t *Table) EstimateRowCount(sc *variable.StatementContext, ranges []*StatsRanges) (float64, error) {
+	sels := make([]float64, 0, len(ranges))
+	for _, r := range ranges {
+		sel, err := t.RangesSelectivity(sc, r)
+		if err != nil {
+			return 0, errors.Trace(err)
+		}
+		sels = append(sels, sel)
+	}
+	return CombineSelectivities(sels) * float64(t.Count), nil
+}
+
+// CombineSelectivities combines the selectivities of the conditions on different columns. The columns are often
+// correlated, so multiplying the selectivities as if they were independent underestimates the row count a lot.
+// Exponential backoff is used instead: the selectivities are sorted from the most selective one, and
+// s1 * s2^(1/2) * s3^(1/4) * s4^(1/8) is returned.
+func CombineSelectivities(sels []float64) float64 {
+	sorted := make([]float64, len(sels))
+	copy(sorted, sels)
+	sort.Float64s(sorted)
+	ret, exponent := 1.0, 1.0
+	for i, sel := range sorted {
+		if i == maxBackoffSelectivities {
+			break
+		}
+		ret *= math.Pow(sel, exponent)
+		exponent /= 2
+	}
+	return ret
+}
@@ -346,31 +346,32 @@ func (c *Column) String() string {
 	return c.Histogram.toString(false)
 }
 
-// getIntColumnRowCount estimates the row count by a slice of IntColumnRange.
-func (c *Column) getIntColumnRowCount(sc *variable.StatementContext, intRanges []types.IntColumnRange,
+// getIntColumnRowCount estimates the row count by the ranges of an int column.
+func (c *Column) getIntColumnRowCount(sc *variable.StatementContext, intRanges []*types.Range,
 	totalRowCount float64) (float64, error) {
 	var rowCount float64
 	for _, rg := range intRanges {
 		var cnt float64
 		var err error
-		if rg.LowVal == math.MinInt64 && rg.HighVal == math.MaxInt64 {
+		low, high := rg.IntBounds()
+		if low == math.MinInt64 && high == math.MaxInt64 {
 			cnt = totalRowCount
-		} else if rg.LowVal == math.MinInt64 {
-			cnt, err = c.lessAndEqRowCount(sc, types.NewIntDatum(rg.HighVal))
-		} else if rg.HighVal == math.MaxInt64 {
-			cnt, err = c.greaterAndEqRowCount(sc, types.NewIntDatum(rg.LowVal))
+		} else if low == math.MinInt64 {
+			cnt, err = c.lessAndEqRowCount(sc, types.NewIntDatum(high))
+		} else if high == math.MaxInt64 {
+			cnt, err = c.greaterAndEqRowCount(sc, types.NewIntDatum(low))
 		} else {
-			if rg.LowVal == rg.HighVal {
-				cnt, err = c.equalRowCount(sc, types.NewIntDatum(rg.LowVal))
+			if low == high {
+				cnt, err = c.equalRowCount(sc, types.NewIntDatum(low))
 			} else {
-				cnt, err = c.betweenRowCount(sc, types.NewIntDatum(rg.LowVal), types.NewIntDatum(rg.HighVal+1))
+				cnt, err = c.betweenRowCount(sc, types.NewIntDatum(low), types.NewIntDatum(high+1))
 			}
 		}
 		if err != nil {
 			return 0, errors.Trace(err)
 		}
-		if rg.HighVal-rg.LowVal > 0 && cnt > float64(rg.HighVal-rg.LowVal) {
-			cnt = float64(rg.HighVal - rg.LowVal)
+		if high-low > 0 && cnt > float64(high-low) {
+			cnt = float64(high - low)
 		}
 		rowCount += cnt
 	}
@@ -380,19 +381,20 @@ func (c *Column) getIntColumnRowCount(sc *variable.StatementContext, intRanges [
 	return rowCount, nil
 }
 
-// getColumnRowCount estimates the row count by a slice of ColumnRange.
-func (c *Column) getColumnRowCount(sc *variable.StatementContext, ranges []*types.ColumnRange) (float64, error) {
+// getColumnRowCount estimates the row count by the ranges of a column.
+func (c *Column) getColumnRowCount(sc *variable.StatementContext, ranges []*types.Range) (float64, error) {
 	var rowCount float64
 	for _, rg := range ranges {
-		cmp, err := rg.Low.CompareDatum(sc, rg.High)
+		low, high := rg.LowVal[0], rg.HighVal[0]
+		cmp, err := low.CompareDatum(sc, high)
 		if err != nil {
 			return 0, errors.Trace(err)
 		}
 		if cmp == 0 {
 			// the point case.
-			if !rg.LowExcl && !rg.HighExcl {
+			if !rg.LowExclude && !rg.HighExclude {
 				var cnt float64
-				cnt, err = c.equalRowCount(sc, rg.Low)
+				cnt, err = c.equalRowCount(sc, low)
 				if err != nil {
 					return 0, errors.Trace(err)
 				}
@@ -401,19 +403,19 @@ func (c *Column) getColumnRowCount(sc *variable.StatementContext, ranges []*type
 			continue
 		}
 		// the interval case.
-		cnt, err := c.betweenRowCount(sc, rg.Low, rg.High)
+		cnt, err := c.betweenRowCount(sc, low, high)
 		if err != nil {
 			return 0, errors.Trace(err)
 		}
-		if rg.LowExcl {
-			lowCnt, err := c.equalRowCount(sc, rg.Low)
+		if rg.LowExclude {
+			lowCnt, err := c.equalRowCount(sc, low)
 			if err != nil {
 				return 0, errors.Trace(err)
 			}
 			cnt -= lowCnt
 		}
-		if !rg.HighExcl {
-			highCnt, err := c.equalRowCount(sc, rg.High)
+		if !rg.HighExclude {
+			highCnt, err := c.equalRowCount(sc, high)
 			if err != nil {
 				return 0, errors.Trace(err)
 			}
@@ -439,18 +441,24 @@ func (idx *Index) String() string {
 	return idx.Histogram.toString(true)
 }
 
-func (idx *Index) getRowCount(sc *variable.StatementContext, indexRanges []*types.IndexRange) (float64, error) {
+func (idx *Index) getRowCount(sc *variable.StatementContext, indexRanges []*types.Range) (float64, error) {
 	totalCount := float64(0)
+	desc := idx.Info.DescColumns()
 	for _, indexRange := range indexRanges {
-		indexRange.Align(len(idx.Info.Columns))
-		lb, err := codec.EncodeKey(nil, indexRange.LowVal...)
+		// The buckets are in the order of the index keys.
+		indexRange, err := indexRange.ReverseRange(sc, desc)
+		if err != nil {
+			return 0, errors.Trace(err)
+		}
+		indexRange.Align(len(idx.Info.Columns), desc)
+		lb, err := codec.EncodeKeyWithDesc(nil, desc, indexRange.LowVal...)
 		if err != nil {
 			return 0, errors.Trace(err)
 		}
 		if indexRange.LowExclude {
 			lb = append(lb, 0)
 		}
-		rb, err := codec.EncodeKey(nil, indexRange.HighVal...)
+		rb, err := codec.EncodeKeyWithDesc(nil, desc, indexRange.HighVal...)
 		if err != nil {
 			return 0, errors.Trace(err)
 		}
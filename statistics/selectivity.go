@@ -36,7 +36,7 @@ type exprSet struct {
 	// The ith bit of `mask` will tell whether the ith expression is covered by this index/column.
 	mask int64
 	// This stores ranges we get.
-	ranges []types.Range
+	ranges []*types.Range
 }
 
 // The type of the exprSet.
@@ -80,6 +80,7 @@ func pseudoSelectivity(exprs []expression.Expression) float64 {
 // Selectivity is a function calculate the selectivity of the expressions.
 // The definition of selectivity is (row count after filter / row count before filter).
 // And exprs must be CNF now, in other words, `exprs[0] and exprs[1] and ... and exprs[len - 1]` should be held when you call this.
+// The selectivities of the different columns and indices are combined by exponential backoff.
 // TODO: support expressions that the top layer is a DNF.
 // Currently the time complexity is o(n^2).
 func (t *Table) Selectivity(ctx context.Context, exprs []expression.Expression) (float64, error) {
@@ -123,28 +124,19 @@ func (t *Table) Selectivity(ctx context.Context, exprs []expression.Expression)
 		}
 	}
 	sets = getUsableSetsByGreedy(sets)
-	ret := 1.0
+	sels := make([]float64, 0, len(sets))
 	// Initialize the mask with the full set.
 	mask := (int64(1) << uint(len(exprs))) - 1
 	for _, set := range sets {
 		mask ^= set.mask
-		var (
-			rowCount float64
-			err      error
-		)
-		switch set.tp {
-		case pkType, colType:
-			ranges := ranger.Ranges2ColumnRanges(set.ranges)
-			rowCount, err = t.GetRowCountByColumnRanges(sc, set.ID, ranges)
-		case indexType:
-			ranges := ranger.Ranges2IndexRanges(set.ranges)
-			rowCount, err = t.GetRowCountByIndexRanges(sc, set.ID, ranges)
-		}
+		sel, err := t.RangesSelectivity(sc, &StatsRanges{IsIndex: set.tp == indexType, ID: set.ID, Ranges: set.ranges})
 		if err != nil {
 			return 0, errors.Trace(err)
 		}
-		ret *= rowCount / float64(t.Count)
+		sels = append(sels, sel)
 	}
+	// The columns of the different sets may be correlated, so their selectivities aren't simply multiplied.
+	ret := CombineSelectivities(sels)
 	// If there's still conditions which cannot be calculated, we will multiply a selectionFactor.
 	if mask > 0 {
 		ret *= selectionFactor
@@ -153,7 +145,7 @@ func (t *Table) Selectivity(ctx context.Context, exprs []expression.Expression)
 }
 
 func getMaskAndRanges(sc *variable.StatementContext, exprs []expression.Expression, rangeType int,
-	lengths []int, cols ...*expression.Column) (int64, []types.Range, error) {
+	lengths []int, cols ...*expression.Column) (int64, []*types.Range, error) {
 	exprsClone := make([]expression.Expression, 0, len(exprs))
 	for _, expr := range exprs {
 		exprsClone = append(exprsClone, expr.Clone())
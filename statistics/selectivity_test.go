@@ -21,6 +21,7 @@ import (
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/plan"
+	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/statistics"
 	"github.com/pingcap/tidb/util/codec"
 	"github.com/pingcap/tidb/util/testkit"
@@ -134,15 +135,15 @@ func (s *testSelectivitySuite) TestSelectivity(c *C) {
 		},
 		{
 			exprs:       "a >= 1 and b > 1 and a < 2",
-			selectivity: 0.01783264746,
+			selectivity: 0.01817234746,
 		},
 		{
 			exprs:       "a >= 1 and c > 1 and a < 2",
-			selectivity: 0.00617283950,
+			selectivity: 0.01069167165,
 		},
 		{
 			exprs:       "a >= 1 and c >= 1 and a < 2",
-			selectivity: 0.01234567901,
+			selectivity: 0.01512030705,
 		},
 		{
 			exprs:       "d = 0 and e = 1",
@@ -154,7 +155,7 @@ func (s *testSelectivitySuite) TestSelectivity(c *C) {
 		},
 		{
 			exprs:       "a > 1 and b < 2 and c > 3 and d < 4 and e > 5",
-			selectivity: 0.00123287439,
+			selectivity: 0.00705849434,
 		},
 	}
 	for _, tt := range tests {
@@ -182,3 +183,42 @@ func (s *testSelectivitySuite) TestSelectivity(c *C) {
 		c.Assert(math.Abs(ratio-tt.selectivity) < eps, IsTrue, comment)
 	}
 }
+
+func (s *testSelectivitySuite) TestCombineSelectivities(c *C) {
+	defer testleak.AfterTest(c)()
+	c.Assert(statistics.CombineSelectivities(nil), Equals, 1.0)
+	c.Assert(statistics.CombineSelectivities([]float64{0.5}), Equals, 0.5)
+	// The most selective one is used completely, the others are backed off.
+	ratio := statistics.CombineSelectivities([]float64{0.25, 0.01, 0.81, 0.0625})
+	c.Assert(math.Abs(ratio-0.01*0.25*math.Pow(0.25, 0.25)*math.Pow(0.81, 0.125)) < eps, IsTrue)
+	// Only the four most selective ones are used.
+	c.Assert(statistics.CombineSelectivities([]float64{0.1, 0.1, 0.1, 0.1, 0.0001}), Equals,
+		statistics.CombineSelectivities([]float64{0.1, 0.1, 0.1, 0.0001}))
+}
+
+func (s *testSelectivitySuite) TestEstimateRowCount(c *C) {
+	defer testleak.AfterTest(c)()
+	colValues, err := s.generateIntDatum(1, 10)
+	c.Assert(err, IsNil)
+	statsTbl := &statistics.Table{
+		Count: 100,
+		Columns: map[int64]*statistics.Column{
+			1: {Histogram: *mockStatsHistogram(1, colValues, 10)},
+			2: {Histogram: *mockStatsHistogram(2, colValues, 10)},
+		},
+	}
+	sc := new(variable.StatementContext)
+	ranges := []*statistics.StatsRanges{
+		{ID: 1, Ranges: []*types.Range{{LowVal: []types.Datum{types.NewIntDatum(0)}, HighVal: []types.Datum{types.NewIntDatum(0)}}}},
+		{ID: 2, Ranges: []*types.Range{{LowVal: []types.Datum{types.NewIntDatum(0)}, HighVal: []types.Datum{types.NewIntDatum(3)}}}},
+	}
+	sel, err := statsTbl.RangesSelectivity(sc, ranges[0])
+	c.Assert(err, IsNil)
+	c.Assert(math.Abs(sel-0.1) < eps, IsTrue)
+	sel, err = statsTbl.RangesSelectivity(sc, ranges[1])
+	c.Assert(err, IsNil)
+	c.Assert(math.Abs(sel-0.4) < eps, IsTrue)
+	count, err := statsTbl.EstimateRowCount(sc, ranges)
+	c.Assert(err, IsNil)
+	c.Assert(math.Abs(count-100*0.1*math.Sqrt(0.4)) < eps, IsTrue, Commentf("%v", count))
+}
@@ -185,7 +185,7 @@ func (s *testStatisticsSuite) TestBuild(c *C) {
 	c.Check(err, IsNil)
 	c.Check(int(count), Equals, 9)
 
-	tblCount, col, err := BuildIndex(ctx, bucketCount, 1, ast.RecordSet(s.rc))
+	tblCount, col, err := BuildIndex(ctx, bucketCount, 1, nil, ast.RecordSet(s.rc))
 	c.Check(err, IsNil)
 	c.Check(int(tblCount), Equals, 100000)
 	count, err = col.equalRowCount(sc, encodeKey(types.NewIntDatum(10000)))
@@ -265,53 +265,53 @@ func (s *testStatisticsSuite) TestColumnRange(c *C) {
 		Count:   int64(col.totalRowCount()),
 		Columns: make(map[int64]*Column),
 	}
-	ran := []*types.ColumnRange{{
-		Low:  types.Datum{},
-		High: types.MaxValueDatum(),
+	ran := []*types.Range{{
+		LowVal:  []types.Datum{{}},
+		HighVal: []types.Datum{types.MaxValueDatum()},
 	}}
 	count, err := tbl.GetRowCountByColumnRanges(sc, 0, ran)
 	c.Assert(err, IsNil)
 	c.Assert(int(count), Equals, 100000)
-	ran[0].Low = types.MinNotNullDatum()
+	ran[0].LowVal[0] = types.MinNotNullDatum()
 	count, err = tbl.GetRowCountByColumnRanges(sc, 0, ran)
 	c.Assert(err, IsNil)
 	c.Assert(int(count), Equals, 99900)
-	ran[0].Low = types.NewIntDatum(1000)
-	ran[0].LowExcl = true
-	ran[0].High = types.NewIntDatum(2000)
-	ran[0].HighExcl = true
+	ran[0].LowVal[0] = types.NewIntDatum(1000)
+	ran[0].LowExclude = true
+	ran[0].HighVal[0] = types.NewIntDatum(2000)
+	ran[0].HighExclude = true
 	count, err = tbl.GetRowCountByColumnRanges(sc, 0, ran)
 	c.Assert(err, IsNil)
 	c.Assert(int(count), Equals, 2500)
-	ran[0].LowExcl = false
-	ran[0].HighExcl = false
+	ran[0].LowExclude = false
+	ran[0].HighExclude = false
 	count, err = tbl.GetRowCountByColumnRanges(sc, 0, ran)
 	c.Assert(err, IsNil)
 	c.Assert(int(count), Equals, 2500)
-	ran[0].Low = ran[0].High
+	ran[0].LowVal[0] = ran[0].HighVal[0]
 	count, err = tbl.GetRowCountByColumnRanges(sc, 0, ran)
 	c.Assert(err, IsNil)
 	c.Assert(int(count), Equals, 100)
 
 	tbl.Columns[0] = col
-	ran[0].Low = types.Datum{}
-	ran[0].High = types.MaxValueDatum()
+	ran[0].LowVal[0] = types.Datum{}
+	ran[0].HighVal[0] = types.MaxValueDatum()
 	count, err = tbl.GetRowCountByColumnRanges(sc, 0, ran)
 	c.Assert(err, IsNil)
 	c.Assert(int(count), Equals, 100000)
-	ran[0].Low = types.NewIntDatum(1000)
-	ran[0].LowExcl = true
-	ran[0].High = types.NewIntDatum(2000)
-	ran[0].HighExcl = true
+	ran[0].LowVal[0] = types.NewIntDatum(1000)
+	ran[0].LowExclude = true
+	ran[0].HighVal[0] = types.NewIntDatum(2000)
+	ran[0].HighExclude = true
 	count, err = tbl.GetRowCountByColumnRanges(sc, 0, ran)
 	c.Assert(err, IsNil)
 	c.Assert(int(count), Equals, 9964)
-	ran[0].LowExcl = false
-	ran[0].HighExcl = false
+	ran[0].LowExclude = false
+	ran[0].HighExclude = false
 	count, err = tbl.GetRowCountByColumnRanges(sc, 0, ran)
 	c.Assert(err, IsNil)
 	c.Assert(int(count), Equals, 9965)
-	ran[0].Low = ran[0].High
+	ran[0].LowVal[0] = ran[0].HighVal[0]
 	count, err = tbl.GetRowCountByColumnRanges(sc, 0, ran)
 	c.Assert(err, IsNil)
 	c.Assert(int(count), Equals, 1)
@@ -331,47 +331,37 @@ func (s *testStatisticsSuite) TestIntColumnRanges(c *C) {
 		Count:   int64(col.totalRowCount()),
 		Columns: make(map[int64]*Column),
 	}
-	ran := []types.IntColumnRange{{
-		LowVal:  math.MinInt64,
-		HighVal: math.MaxInt64,
-	}}
+	ran := []*types.Range{types.NewIntRange(math.MinInt64, math.MaxInt64)}
 	count, err := tbl.GetRowCountByIntColumnRanges(sc, 0, ran)
 	c.Assert(err, IsNil)
 	c.Assert(int(count), Equals, 100000)
-	ran[0].LowVal = 1000
-	ran[0].HighVal = 2000
+	ran[0] = types.NewIntRange(1000, 2000)
 	count, err = tbl.GetRowCountByIntColumnRanges(sc, 0, ran)
 	c.Assert(err, IsNil)
 	c.Assert(int(count), Equals, 1000)
-	ran[0].LowVal = 1001
-	ran[0].HighVal = 1999
+	ran[0] = types.NewIntRange(1001, 1999)
 	count, err = tbl.GetRowCountByIntColumnRanges(sc, 0, ran)
 	c.Assert(err, IsNil)
 	c.Assert(int(count), Equals, 998)
-	ran[0].LowVal = 1000
-	ran[0].HighVal = 1000
+	ran[0] = types.NewIntRange(1000, 1000)
 	count, err = tbl.GetRowCountByIntColumnRanges(sc, 0, ran)
 	c.Assert(err, IsNil)
 	c.Assert(int(count), Equals, 100)
 
 	tbl.Columns[0] = col
-	ran[0].LowVal = math.MinInt64
-	ran[0].HighVal = math.MaxInt64
+	ran[0] = types.NewIntRange(math.MinInt64, math.MaxInt64)
 	count, err = tbl.GetRowCountByIntColumnRanges(sc, 0, ran)
 	c.Assert(err, IsNil)
 	c.Assert(int(count), Equals, 100000)
-	ran[0].LowVal = 1000
-	ran[0].HighVal = 2000
+	ran[0] = types.NewIntRange(1000, 2000)
 	count, err = tbl.GetRowCountByIntColumnRanges(sc, 0, ran)
 	c.Assert(err, IsNil)
 	c.Assert(int(count), Equals, 1000)
-	ran[0].LowVal = 1001
-	ran[0].HighVal = 1999
+	ran[0] = types.NewIntRange(1001, 1999)
 	count, err = tbl.GetRowCountByIntColumnRanges(sc, 0, ran)
 	c.Assert(err, IsNil)
 	c.Assert(int(count), Equals, 998)
-	ran[0].LowVal = 1000
-	ran[0].HighVal = 1000
+	ran[0] = types.NewIntRange(1000, 1000)
 	count, err = tbl.GetRowCountByIntColumnRanges(sc, 0, ran)
 	c.Assert(err, IsNil)
 	c.Assert(int(count), Equals, 1)
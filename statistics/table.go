@@ -208,8 +208,8 @@ func (t *Table) ColumnEqualRowCount(sc *variable.StatementContext, value types.D
 	return result, errors.Trace(err)
 }
 
-// GetRowCountByIntColumnRanges estimates the row count by a slice of IntColumnRange.
-func (t *Table) GetRowCountByIntColumnRanges(sc *variable.StatementContext, colID int64, intRanges []types.IntColumnRange) (float64, error) {
+// GetRowCountByIntColumnRanges estimates the row count by the ranges of an int column.
+func (t *Table) GetRowCountByIntColumnRanges(sc *variable.StatementContext, colID int64, intRanges []*types.Range) (float64, error) {
 	c := t.Columns[colID]
 	if t.Pseudo || c == nil || len(c.Buckets) == 0 {
 		return getPseudoRowCountByIntRanges(intRanges, float64(t.Count)), nil
@@ -217,8 +217,8 @@ func (t *Table) GetRowCountByIntColumnRanges(sc *variable.StatementContext, colI
 	return c.getIntColumnRowCount(sc, intRanges, float64(t.Count))
 }
 
-// GetRowCountByColumnRanges estimates the row count by a slice of ColumnRange.
-func (t *Table) GetRowCountByColumnRanges(sc *variable.StatementContext, colID int64, colRanges []*types.ColumnRange) (float64, error) {
+// GetRowCountByColumnRanges estimates the row count by the ranges of a column.
+func (t *Table) GetRowCountByColumnRanges(sc *variable.StatementContext, colID int64, colRanges []*types.Range) (float64, error) {
 	c := t.Columns[colID]
 	if t.Pseudo || c == nil || len(c.Buckets) == 0 {
 		return getPseudoRowCountByColumnRanges(sc, float64(t.Count), colRanges)
@@ -226,8 +226,8 @@ func (t *Table) GetRowCountByColumnRanges(sc *variable.StatementContext, colID i
 	return c.getColumnRowCount(sc, colRanges)
 }
 
-// GetRowCountByIndexRanges estimates the row count by a slice of IndexRange.
-func (t *Table) GetRowCountByIndexRanges(sc *variable.StatementContext, idxID int64, indexRanges []*types.IndexRange) (float64, error) {
+// GetRowCountByIndexRanges estimates the row count by the ranges of an index.
+func (t *Table) GetRowCountByIndexRanges(sc *variable.StatementContext, idxID int64, indexRanges []*types.Range) (float64, error) {
 	idx := t.Indices[idxID]
 	if t.Pseudo || idx == nil || len(idx.Buckets) == 0 {
 		return getPseudoRowCountByIndexRanges(sc, indexRanges, float64(t.Count))
@@ -246,7 +246,7 @@ func PseudoTable(tableID int64) *Table {
 	return t
 }
 
-func getPseudoRowCountByIndexRanges(sc *variable.StatementContext, indexRanges []*types.IndexRange,
+func getPseudoRowCountByIndexRanges(sc *variable.StatementContext, indexRanges []*types.Range,
 	tableRowCount float64) (float64, error) {
 	if tableRowCount == 0 {
 		return 0, nil
@@ -261,7 +261,7 @@ func getPseudoRowCountByIndexRanges(sc *variable.StatementContext, indexRanges [
 		if i >= len(indexRange.LowVal) {
 			i = len(indexRange.LowVal) - 1
 		}
-		colRange := []*types.ColumnRange{{Low: indexRange.LowVal[i], High: indexRange.HighVal[i]}}
+		colRange := []*types.Range{{LowVal: []types.Datum{indexRange.LowVal[i]}, HighVal: []types.Datum{indexRange.HighVal[i]}}}
 		rowCount, err := getPseudoRowCountByColumnRanges(sc, tableRowCount, colRange)
 		if err != nil {
 			return 0, errors.Trace(err)
@@ -280,25 +280,26 @@ func getPseudoRowCountByIndexRanges(sc *variable.StatementContext, indexRanges [
 	return totalCount, nil
 }
 
-func getPseudoRowCountByColumnRanges(sc *variable.StatementContext, tableRowCount float64, columnRanges []*types.ColumnRange) (float64, error) {
+func getPseudoRowCountByColumnRanges(sc *variable.StatementContext, tableRowCount float64, columnRanges []*types.Range) (float64, error) {
 	var rowCount float64
 	var err error
 	for _, ran := range columnRanges {
-		if ran.Low.Kind() == types.KindNull && ran.High.Kind() == types.KindMaxValue {
+		low, high := ran.LowVal[0], ran.HighVal[0]
+		if low.Kind() == types.KindNull && high.Kind() == types.KindMaxValue {
 			rowCount += tableRowCount
-		} else if ran.Low.Kind() == types.KindMinNotNull {
+		} else if low.Kind() == types.KindMinNotNull {
 			var nullCount float64
 			nullCount = tableRowCount / pseudoEqualRate
-			if ran.High.Kind() == types.KindMaxValue {
+			if high.Kind() == types.KindMaxValue {
 				rowCount += tableRowCount - nullCount
 			} else if err == nil {
 				lessCount := tableRowCount / pseudoLessRate
 				rowCount += lessCount - nullCount
 			}
-		} else if ran.High.Kind() == types.KindMaxValue {
+		} else if high.Kind() == types.KindMaxValue {
 			rowCount += tableRowCount / pseudoLessRate
 		} else {
-			compare, err1 := ran.Low.CompareDatum(sc, ran.High)
+			compare, err1 := low.CompareDatum(sc, high)
 			if err1 != nil {
 				return 0, errors.Trace(err1)
 			}
@@ -318,25 +319,26 @@ func getPseudoRowCountByColumnRanges(sc *variable.StatementContext, tableRowCoun
 	return rowCount, nil
 }
 
-func getPseudoRowCountByIntRanges(intRanges []types.IntColumnRange, tableRowCount float64) float64 {
+func getPseudoRowCountByIntRanges(intRanges []*types.Range, tableRowCount float64) float64 {
 	var rowCount float64
 	for _, rg := range intRanges {
 		var cnt float64
-		if rg.LowVal == math.MinInt64 && rg.HighVal == math.MaxInt64 {
+		low, high := rg.IntBounds()
+		if low == math.MinInt64 && high == math.MaxInt64 {
 			cnt = tableRowCount
-		} else if rg.LowVal == math.MinInt64 {
+		} else if low == math.MinInt64 {
 			cnt = tableRowCount / pseudoLessRate
-		} else if rg.HighVal == math.MaxInt64 {
+		} else if high == math.MaxInt64 {
 			cnt = tableRowCount / pseudoLessRate
 		} else {
-			if rg.LowVal == rg.HighVal {
+			if low == high {
 				cnt = tableRowCount / pseudoEqualRate
 			} else {
 				cnt = tableRowCount / pseudoBetweenRate
 			}
 		}
-		if rg.HighVal-rg.LowVal > 0 && cnt > float64(rg.HighVal-rg.LowVal) {
-			cnt = float64(rg.HighVal - rg.LowVal)
+		if high-low > 0 && cnt > float64(high-low) {
+			cnt = float64(high - low)
 		}
 		rowCount += cnt
 	}
@@ -15,9 +15,11 @@ package localstore
 
 import (
 	"bytes"
+	"math"
 
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/distsql/xeval"
+	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/util/codec"
 	"github.com/pingcap/tidb/util/types"
 	"github.com/pingcap/tipb/go-tipb"
@@ -118,6 +120,8 @@ func (n *aggregateFuncExpr) update(ctx *selectContext, args []types.Datum) error
 		return n.updateMaxMin(ctx, args, true)
 	case tipb.ExprType_Min:
 		return n.updateMaxMin(ctx, args, false)
+	case expression.ExprTypeAggBitAnd, expression.ExprTypeAggBitOr, expression.ExprTypeAggBitXor:
+		return n.updateBit(ctx, args)
 	}
 	return errors.Errorf("Unknown AggExpr: %v", n.expr.GetTp())
 }
@@ -128,6 +132,8 @@ func (n *aggregateFuncExpr) toDatums(ctx *selectContext) (ds []types.Datum, err
 		ds = n.getCountDatum()
 	case tipb.ExprType_First, tipb.ExprType_Max, tipb.ExprType_Min:
 		ds = n.getValueDatum()
+	case expression.ExprTypeAggBitAnd, expression.ExprTypeAggBitOr, expression.ExprTypeAggBitXor:
+		ds = n.getBitDatum()
 	case tipb.ExprType_Sum:
 		d, err := getSumValue(ctx, n.getAggItem())
 		if err != nil {
@@ -257,7 +263,7 @@ func (n *aggregateFuncExpr) updateMaxMin(ctx *selectContext, args []types.Datum,
 		aggItem.value = arg
 		return nil
 	}
-	c, err := aggItem.value.CompareDatum(ctx.sc, arg)
+	c, err := aggItem.value.CompareDatumForMaxMin(ctx.sc, arg)
 	if err != nil {
 		return errors.Trace(err)
 	}
@@ -270,3 +276,45 @@ func (n *aggregateFuncExpr) updateMaxMin(ctx *selectContext, args []types.Datum,
 	}
 	return nil
 }
+
+// Convert bit_and/bit_or/bit_xor result to datum list, the result is never null.
+func (n *aggregateFuncExpr) getBitDatum() []types.Datum {
+	item := n.getAggItem()
+	if !item.gotFirstRow {
+		return []types.Datum{types.NewUintDatum(n.initBitValue())}
+	}
+	return []types.Datum{item.value}
+}
+
+func (n *aggregateFuncExpr) initBitValue() uint64 {
+	if n.expr.GetTp() == expression.ExprTypeAggBitAnd {
+		return math.MaxUint64
+	}
+	return 0
+}
+
+var aggBitOps = map[tipb.ExprType]tipb.ExprType{
+	expression.ExprTypeAggBitAnd: tipb.ExprType_BitAnd,
+	expression.ExprTypeAggBitOr:  tipb.ExprType_BitOr,
+	expression.ExprTypeAggBitXor: tipb.ExprType_BitXor,
+}
+
+func (n *aggregateFuncExpr) updateBit(ctx *selectContext, args []types.Datum) error {
+	if len(args) != 1 {
+		// This should not happen. The length of argument list is already checked in the early stage.
+		// This is just in case of error.
+		return errors.Errorf("Wrong number of argument for bit function, need 1 but get %d", len(args))
+	}
+	aggItem := n.getAggItem()
+	if !aggItem.gotFirstRow {
+		aggItem.value = types.NewUintDatum(n.initBitValue())
+		aggItem.gotFirstRow = true
+	}
+	arg := args[0]
+	if arg.IsNull() {
+		return nil
+	}
+	var err error
+	aggItem.value, err = xeval.ComputeBit(ctx.sc, aggBitOps[n.expr.GetTp()], aggItem.value, arg)
+	return errors.Trace(err)
+}
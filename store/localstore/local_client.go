@@ -2,6 +2,7 @@ package localstore
 
 import (
 	"github.com/juju/errors"
+	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tipb/go-tipb"
 	goctx "golang.org/x/net/context"
@@ -70,7 +71,8 @@ func supportExpr(exprType tipb.ExprType) bool {
 		return true
 	// aggregate functions.
 	case tipb.ExprType_Count, tipb.ExprType_First, tipb.ExprType_Sum,
-		tipb.ExprType_Avg, tipb.ExprType_Max, tipb.ExprType_Min:
+		tipb.ExprType_Avg, tipb.ExprType_Max, tipb.ExprType_Min,
+		expression.ExprTypeAggBitAnd, expression.ExprTypeAggBitOr, expression.ExprTypeAggBitXor:
 		return true
 	// bitwise operators.
 	case tipb.ExprType_BitAnd, tipb.ExprType_BitOr, tipb.ExprType_BitXor, tipb.ExprType_BitNeg:
@@ -85,7 +87,7 @@ func supportExpr(exprType tipb.ExprType) bool {
 		tipb.ExprType_JsonObject, tipb.ExprType_JsonArray, tipb.ExprType_JsonMerge, tipb.ExprType_JsonSet,
 		tipb.ExprType_JsonInsert, tipb.ExprType_JsonReplace, tipb.ExprType_JsonRemove, tipb.ExprType_JsonContains:
 		return true
-	case kv.ReqSubTypeDesc:
+	case kv.ReqSubTypeDesc, kv.ReqSubTypeDescKey, kv.ReqSubTypeEnumSet, kv.ReqSubTypeBit:
 		return true
 	default:
 		return false
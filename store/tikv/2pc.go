@@ -29,6 +29,7 @@ import (
 	"github.com/pingcap/tidb/store/tikv/tikvrpc"
 	"github.com/pingcap/tidb/tablecodec"
 	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/failpoint"
 	"github.com/pingcap/tipb/go-binlog"
 	goctx "golang.org/x/net/context"
 )
@@ -194,6 +195,8 @@ func (c *twoPhaseCommitter) doActionOnKeys(bo *Backoffer, action twoPhaseCommitA
 	}
 
 	txnRegionsNumHistogram.WithLabelValues(action.MetricsTag()).Observe(float64(len(groups)))
+	start := time.Now()
+	defer func() { txnActionHistogram.WithLabelValues(action.MetricsTag()).Observe(time.Since(start).Seconds()) }()
 
 	var batches []batchKeys
 	var sizeFunc = c.keySize
@@ -472,6 +475,7 @@ func (c *twoPhaseCommitter) commitSingleBatch(bo *Backoffer, batch batchKeys) er
 		return errors.Annotate(err, txnRetryableMark)
 	}
 
+	c.store.hotRegions.record(batch.region, batch.keys, true)
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	// Group that contains primary key is always the first.
@@ -564,6 +568,9 @@ func (c *twoPhaseCommitter) execute() error {
 		log.Debugf("2PC failed on prewrite: %v, tid: %d", err, c.startTS)
 		return errors.Trace(err)
 	}
+	if _, ok := failpoint.Eval("tikv/commitFailAfterPrewrite"); ok {
+		return errors.Trace(errInjectedCommitFailure)
+	}
 
 	commitTS, err := c.store.getTimestampWithRetry(NewBackoffer(tsoMaxBackoff, ctx))
 	if err != nil {
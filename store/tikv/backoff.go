@@ -77,22 +77,29 @@ const (
 	boPDRPC
 	boRegionMiss
 	boServerBusy
+	boCopRetry
 )
 
+// backoffSleep is the base and cap of the sleep time(in ms) of a backoff type.
+type backoffSleep struct {
+	base   int
+	cap    int
+	jitter int
+}
+
+var backoffSleeps = map[backoffType]*backoffSleep{
+	boTiKVRPC:     {100, 2000, EqualJitter},
+	boTxnLock:     {200, 3000, EqualJitter},
+	boTxnLockFast: {100, 3000, EqualJitter},
+	boPDRPC:       {500, 3000, EqualJitter},
+	boRegionMiss:  {100, 500, NoJitter},
+	boServerBusy:  {2000, 10000, EqualJitter},
+	boCopRetry:    {100, 1000, EqualJitter},
+}
+
 func (t backoffType) createFn() func() int {
-	switch t {
-	case boTiKVRPC:
-		return NewBackoffFn(100, 2000, EqualJitter)
-	case boTxnLock:
-		return NewBackoffFn(200, 3000, EqualJitter)
-	case boTxnLockFast:
-		return NewBackoffFn(100, 3000, EqualJitter)
-	case boPDRPC:
-		return NewBackoffFn(500, 3000, EqualJitter)
-	case boRegionMiss:
-		return NewBackoffFn(100, 500, NoJitter)
-	case boServerBusy:
-		return NewBackoffFn(2000, 10000, EqualJitter)
+	if sleep, ok := backoffSleeps[t]; ok {
+		return NewBackoffFn(sleep.base, sleep.cap, sleep.jitter)
 	}
 	return nil
 }
@@ -111,17 +118,20 @@ func (t backoffType) String() string {
 		return "regionMiss"
 	case boServerBusy:
 		return "serverBusy"
+	case boCopRetry:
+		return "copRetry"
 	}
 	return ""
 }
 
 // Maximum total sleep time(in ms) for kv/cop commands.
-const (
+var (
 	copBuildTaskMaxBackoff  = 5000
 	tsoMaxBackoff           = 5000
 	scannerNextMaxBackoff   = 20000
 	batchGetMaxBackoff      = 20000
 	copNextMaxBackoff       = 20000
+	copRetryMaxBackoff      = 5000
 	getMaxBackoff           = 20000
 	prewriteMaxBackoff      = 20000
 	cleanupMaxBackoff       = 20000
@@ -129,9 +139,66 @@ const (
 	gcResolveLockMaxBackoff = 100000
 	gcDeleteRangeMaxBackoff = 100000
 	rawkvMaxBackoff         = 20000
+	commitMaxBackoff        = 20000
+	regionRefreshMaxBackoff = 5000
 )
 
-var commitMaxBackoff = 20000
+var backoffBudgets = map[string]*int{
+	"cop_build_task":  &copBuildTaskMaxBackoff,
+	"tso":             &tsoMaxBackoff,
+	"scanner_next":    &scannerNextMaxBackoff,
+	"batch_get":       &batchGetMaxBackoff,
+	"cop_next":        &copNextMaxBackoff,
+	"cop_retry":       &copRetryMaxBackoff,
+	"get":             &getMaxBackoff,
+	"prewrite":        &prewriteMaxBackoff,
+	"commit":          &commitMaxBackoff,
+	"cleanup":         &cleanupMaxBackoff,
+	"gc":              &gcMaxBackoff,
+	"gc_resolve_lock": &gcResolveLockMaxBackoff,
+	"gc_delete_range": &gcDeleteRangeMaxBackoff,
+	"rawkv":           &rawkvMaxBackoff,
+	"region_refresh":  &regionRefreshMaxBackoff,
+}
+
+// SetBackoffConfig sets the backoff budgets and sleep caps, it should be called before the store is opened.
+// budgets are the maximum total sleep time(in ms) of the operations, the keys can be "cop_build_task",
+// "tso", "scanner_next", "batch_get", "cop_next", "cop_retry", "get", "prewrite", "commit", "cleanup", "gc",
+// "gc_resolve_lock", "gc_delete_range" and "rawkv".
+// caps are the maximum sleep time(in ms) of a single backoff, the keys can be "tikvRPC", "txnLock",
+// "txnLockFast", "pdRPC", "regionMiss", "serverBusy" and "copRetry".
+func SetBackoffConfig(budgets map[string]int, caps map[string]int) error {
+	for op, ms := range budgets {
+		budget, ok := backoffBudgets[op]
+		if !ok {
+			return errors.Errorf("unknown backoff operation %s", op)
+		}
+		if ms <= 0 {
+			return errors.Errorf("invalid backoff budget %d for %s", ms, op)
+		}
+		*budget = ms
+	}
+	for name, ms := range caps {
+		sleep := findBackoffSleep(name)
+		if sleep == nil {
+			return errors.Errorf("unknown backoff type %s", name)
+		}
+		if ms < sleep.base {
+			return errors.Errorf("backoff cap %d for %s is less than the base %d", ms, name, sleep.base)
+		}
+		sleep.cap = ms
+	}
+	return nil
+}
+
+func findBackoffSleep(name string) *backoffSleep {
+	for t, sleep := range backoffSleeps {
+		if t.String() == name {
+			return sleep
+		}
+	}
+	return nil
+}
 
 // Backoffer is a utility for retrying queries.
 type Backoffer struct {
@@ -171,7 +238,9 @@ func (b *Backoffer) Backoff(typ backoffType, err error) error {
 		b.fn[typ] = f
 	}
 
-	b.totalSleep += f()
+	sleep := f()
+	backoffSleepHistogram.WithLabelValues(typ.String()).Observe(float64(sleep) / 1000)
+	b.totalSleep += sleep
 	b.types = append(b.types, typ)
 
 	log.Debugf("%v, retry later(totalSleep %dms, maxSleep %dms)", err, b.totalSleep, b.maxSleep)
@@ -0,0 +1,47 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tikv
+
+import (
+	. "github.com/pingcap/check"
+)
+
+type testBackoffSuite struct{}
+
+var _ = Suite(&testBackoffSuite{})
+
+func (s *testBackoffSuite) TestSetBackoffConfig(c *C) {
+	defer func(budget, cap int) {
+		getMaxBackoff = budget
+		backoffSleeps[boRegionMiss].cap = cap
+	}(getMaxBackoff, backoffSleeps[boRegionMiss].cap)
+
+	err := SetBackoffConfig(map[string]int{"get": 1000}, map[string]int{"regionMiss": 200})
+	c.Assert(err, IsNil)
+	c.Assert(getMaxBackoff, Equals, 1000)
+	c.Assert(backoffSleeps[boRegionMiss].cap, Equals, 200)
+
+	// The sleep time is limited by the cap.
+	fn := boRegionMiss.createFn()
+	c.Assert(fn(), Equals, 100)
+	c.Assert(fn(), Equals, 200)
+	c.Assert(fn(), Equals, 200)
+
+	c.Assert(SetBackoffConfig(map[string]int{"unknown": 1000}, nil), NotNil)
+	c.Assert(SetBackoffConfig(map[string]int{"get": 0}, nil), NotNil)
+	c.Assert(SetBackoffConfig(nil, map[string]int{"unknown": 1000}), NotNil)
+	// The cap can not be less than the base.
+	c.Assert(SetBackoffConfig(nil, map[string]int{"regionMiss": 10}), NotNil)
+	c.Assert(getMaxBackoff, Equals, 1000)
+}
@@ -21,21 +21,41 @@ import (
 
 	"github.com/grpc-ecosystem/go-grpc-prometheus"
 	"github.com/juju/errors"
+	"github.com/ngaut/log"
 	"github.com/pingcap/kvproto/pkg/tikvpb"
 	"github.com/pingcap/tidb/store/tikv/tikvrpc"
 	goctx "golang.org/x/net/context"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/keepalive"
 )
 
+// MaxConnectionCount is the number of gRPC connections to each TiKV store, the requests
+// are sent on them in round-robin.
+var MaxConnectionCount = 16
+
+// GrpcKeepAliveTime is the duration after which a connection pings the TiKV store if it
+// doesn't see any activity, so a broken connection can be found before it's used.
+var GrpcKeepAliveTime = 10 * time.Second
+
+// GrpcKeepAliveTimeout is how long to wait for the ping response before the connection
+// is closed and reconnected.
+var GrpcKeepAliveTimeout = 3 * time.Second
+
 const (
-	maxConnectionNumber = 16
-	dialTimeout         = 5 * time.Second
-	readTimeoutShort    = 20 * time.Second  // For requests that read/write several key-values.
-	readTimeoutMedium   = 60 * time.Second  // For requests that may need scan region.
-	readTimeoutLong     = 150 * time.Second // For requests that may need scan region multiple times.
+	dialTimeout       = 5 * time.Second
+	readTimeoutShort  = 20 * time.Second  // For requests that read/write several key-values.
+	readTimeoutMedium = 60 * time.Second  // For requests that may need scan region.
+	readTimeoutLong   = 150 * time.Second // For requests that may need scan region multiple times.
 
 	grpcInitialWindowSize     = 1 << 30
 	grpcInitialConnWindowSize = 1 << 30
+	// grpcReconnectMaxDelay is the max delay of the exponential backoff when gRPC reconnects
+	// a broken connection.
+	grpcReconnectMaxDelay = 3 * time.Second
+	// connUnhealthyDuration is how long a connection is skipped after a request on it fails
+	// because the store is unavailable, it gives the connection time to reconnect.
+	connUnhealthyDuration = time.Second
 
 	rpcLabelKV  = "kv"
 	rpcLabelCop = "cop"
@@ -53,12 +73,15 @@ type Client interface {
 type connArray struct {
 	index uint32
 	v     []*grpc.ClientConn
+	// unhealthyUntil is the time in UnixNano until which each connection is skipped.
+	unhealthyUntil []int64
 }
 
 func newConnArray(maxSize uint32, addr string) (*connArray, error) {
 	a := &connArray{
-		index: 0,
-		v:     make([]*grpc.ClientConn, maxSize),
+		index:          0,
+		v:              make([]*grpc.ClientConn, maxSize),
+		unhealthyUntil: make([]int64, maxSize),
 	}
 	if err := a.Init(addr); err != nil {
 		return nil, err
@@ -74,6 +97,12 @@ func (a *connArray) Init(addr string) error {
 			grpc.WithTimeout(dialTimeout),
 			grpc.WithInitialWindowSize(grpcInitialWindowSize),
 			grpc.WithInitialConnWindowSize(grpcInitialConnWindowSize),
+			grpc.WithBackoffMaxDelay(grpcReconnectMaxDelay),
+			grpc.WithKeepaliveParams(keepalive.ClientParameters{
+				Time:                GrpcKeepAliveTime,
+				Timeout:             GrpcKeepAliveTimeout,
+				PermitWithoutStream: true,
+			}),
 			grpc.WithUnaryInterceptor(grpc_prometheus.UnaryClientInterceptor),
 			grpc.WithStreamInterceptor(grpc_prometheus.StreamClientInterceptor))
 		if err != nil {
@@ -86,9 +115,34 @@ func (a *connArray) Init(addr string) error {
 	return nil
 }
 
-func (a *connArray) Get() *grpc.ClientConn {
-	next := atomic.AddUint32(&a.index, 1) % uint32(len(a.v))
-	return a.v[next]
+// Get returns the next healthy connection and its index. If all the connections are
+// unhealthy, it returns the next one anyway.
+func (a *connArray) Get() (*grpc.ClientConn, int) {
+	now := time.Now().UnixNano()
+	for i := 0; i < len(a.v); i++ {
+		next := int(atomic.AddUint32(&a.index, 1) % uint32(len(a.v)))
+		if atomic.LoadInt64(&a.unhealthyUntil[next]) <= now {
+			return a.v[next], next
+		}
+	}
+	next := int(atomic.AddUint32(&a.index, 1) % uint32(len(a.v)))
+	return a.v[next], next
+}
+
+// onSendFail marks the connection unhealthy if the store is unavailable on it.
+func (a *connArray) onSendFail(idx int, err error) {
+	if grpc.Code(errors.Cause(err)) != codes.Unavailable {
+		return
+	}
+	atomic.StoreInt64(&a.unhealthyUntil[idx], time.Now().Add(connUnhealthyDuration).UnixNano())
+	log.Debugf("rpcClient: connection %d is unavailable, err: %v", idx, err)
+}
+
+// onSendSuccess marks the connection healthy.
+func (a *connArray) onSendSuccess(idx int) {
+	if atomic.LoadInt64(&a.unhealthyUntil[idx]) != 0 {
+		atomic.StoreInt64(&a.unhealthyUntil[idx], 0)
+	}
 }
 
 func (a *connArray) Close() {
@@ -118,6 +172,15 @@ func newRPCClient() *rpcClient {
 }
 
 func (c *rpcClient) getConn(addr string) (*grpc.ClientConn, error) {
+	array, err := c.getConnArray(addr)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	conn, _ := array.Get()
+	return conn, nil
+}
+
+func (c *rpcClient) getConnArray(addr string) (*connArray, error) {
 	c.RLock()
 	if c.isClosed {
 		c.RUnlock()
@@ -132,7 +195,7 @@ func (c *rpcClient) getConn(addr string) (*grpc.ClientConn, error) {
 			return nil, err
 		}
 	}
-	return array.Get(), nil
+	return array, nil
 }
 
 func (c *rpcClient) createConnArray(addr string) (*connArray, error) {
@@ -140,8 +203,12 @@ func (c *rpcClient) createConnArray(addr string) (*connArray, error) {
 	defer c.Unlock()
 	array, ok := c.conns[addr]
 	if !ok {
+		size := MaxConnectionCount
+		if size < 1 {
+			size = 1
+		}
 		var err error
-		array, err = newConnArray(maxConnectionNumber, addr)
+		array, err = newConnArray(uint32(size), addr)
 		if err != nil {
 			return nil, err
 		}
@@ -171,15 +238,18 @@ func (c *rpcClient) SendReq(ctx goctx.Context, addr string, req *tikvrpc.Request
 	}
 	defer func() { sendReqHistogram.WithLabelValues(label).Observe(time.Since(start).Seconds()) }()
 
-	conn, err := c.getConn(addr)
+	array, err := c.getConnArray(addr)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
+	conn, idx := array.Get()
 	client := tikvpb.NewTikvClient(conn)
 	resp, err := c.callRPC(ctx, client, req)
 	if err != nil {
+		array.onSendFail(idx, err)
 		return nil, errors.Trace(err)
 	}
+	array.onSendSuccess(idx)
 	return resp, nil
 }
 
@@ -17,6 +17,8 @@ import (
 	"testing"
 
 	. "github.com/pingcap/check"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 )
 
 func TestT(t *testing.T) {
@@ -45,3 +47,36 @@ func (s *testClientSuite) TestConn(c *C) {
 	c.Assert(err, NotNil)
 	c.Assert(conn3, IsNil)
 }
+
+func (s *testClientSuite) TestConnHealth(c *C) {
+	array, err := newConnArray(3, "127.0.0.1:6379")
+	c.Assert(err, IsNil)
+	defer array.Close()
+
+	// The unhealthy connection is skipped.
+	array.onSendFail(1, grpc.Errorf(codes.Unavailable, "unavailable"))
+	for i := 0; i < 6; i++ {
+		conn, idx := array.Get()
+		c.Assert(idx, Not(Equals), 1)
+		c.Assert(conn, Equals, array.v[idx])
+	}
+	// Other errors don't change the health of the connection.
+	array.onSendFail(0, grpc.Errorf(codes.DeadlineExceeded, "timeout"))
+	array.onSendFail(2, grpc.Errorf(codes.Unavailable, "unavailable"))
+	for i := 0; i < 3; i++ {
+		_, idx := array.Get()
+		c.Assert(idx, Equals, 0)
+	}
+	// If all the connections are unhealthy, they are still used in turn.
+	array.onSendFail(0, grpc.Errorf(codes.Unavailable, "unavailable"))
+	seen := make(map[int]bool)
+	for i := 0; i < 3; i++ {
+		_, idx := array.Get()
+		seen[idx] = true
+	}
+	c.Assert(seen, HasLen, 3)
+
+	array.onSendSuccess(1)
+	_, idx := array.Get()
+	c.Assert(idx, Equals, 1)
+}
@@ -0,0 +1,194 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tikv
+
+import (
+	"sync"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/kv"
+	goctx "golang.org/x/net/context"
+)
+
+// CopStoreConcurrency is the max number of coprocessor requests that can be sent to a single
+// store concurrently, it's shared by all the queries. Set it to 0 to remove the limit.
+var CopStoreConcurrency = 64
+
+// copLowPriorityPercent is the percent of the tokens of a store that low priority requests
+// can take, the remaining tokens are kept for high and normal priority requests, so a big
+// scan can't block point gets even if it has a high concurrency.
+const copLowPriorityPercent = 75
+
+// Priority classes of the coprocessor requests, requests of a smaller class are granted first.
+const (
+	copClassHigh = iota
+	copClassNormal
+	copClassLow
+	copClassCount
+)
+
+var copClassNames = [copClassCount]string{"high", "normal", "low"}
+
+func copPriorityClass(priority int) int {
+	switch priority {
+	case kv.PriorityHigh:
+		return copClassHigh
+	case kv.PriorityLow:
+		return copClassLow
+	}
+	return copClassNormal
+}
+
+// copScheduler limits the concurrent coprocessor requests to each store with tokens.
+// A request must acquire a token of the target store before it's sent, and release it
+// after the response is received.
+type copScheduler struct {
+	limit    int
+	lowLimit int
+
+	mu     sync.Mutex
+	stores map[uint64]*storeTokens
+}
+
+type storeTokens struct {
+	inUse    int
+	lowInUse int
+	// waiters are the waiting requests of each priority class in FIFO order.
+	waiters [copClassCount][]chan struct{}
+}
+
+// newCopScheduler creates a copScheduler, it returns nil if limit is not positive, which
+// means no limit at all.
+func newCopScheduler(limit int) *copScheduler {
+	if limit <= 0 {
+		return nil
+	}
+	lowLimit := limit * copLowPriorityPercent / 100
+	if lowLimit < 1 {
+		lowLimit = 1
+	}
+	return &copScheduler{
+		limit:    limit,
+		lowLimit: lowLimit,
+		stores:   make(map[uint64]*storeTokens),
+	}
+}
+
+func (s *copScheduler) canGrant(st *storeTokens, class int) bool {
+	if st.inUse >= s.limit {
+		return false
+	}
+	return class != copClassLow || st.lowInUse < s.lowLimit
+}
+
+func (s *copScheduler) grant(st *storeTokens, class int) {
+	st.inUse++
+	if class == copClassLow {
+		st.lowInUse++
+	}
+}
+
+// acquire waits for a token of the store, the returned release function must be called
+// to give back the token. It returns an error if ctx is done or finished is closed
+// before the token is acquired.
+func (s *copScheduler) acquire(ctx goctx.Context, finished <-chan struct{}, storeID uint64, priority int) (func(), error) {
+	if s == nil {
+		return func() {}, nil
+	}
+	class := copPriorityClass(priority)
+	release := func() { s.release(storeID, class) }
+
+	s.mu.Lock()
+	st, ok := s.stores[storeID]
+	if !ok {
+		st = &storeTokens{}
+		s.stores[storeID] = st
+	}
+	if s.canGrant(st, class) && !st.hasWaiters(class) {
+		s.grant(st, class)
+		s.mu.Unlock()
+		return release, nil
+	}
+	ch := make(chan struct{})
+	st.waiters[class] = append(st.waiters[class], ch)
+	s.mu.Unlock()
+
+	startTime := time.Now()
+	var err error
+	select {
+	case <-ch:
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-finished:
+		err = errors.New("coprocessor iterator is closed")
+	}
+	copSchedulerWaitHistogram.WithLabelValues(copClassNames[class]).Observe(time.Since(startTime).Seconds())
+	if err == nil {
+		return release, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if st.removeWaiter(class, ch) {
+		return nil, errors.Trace(err)
+	}
+	// The token has been granted concurrently, give it back.
+	s.releaseLocked(st, class)
+	return nil, errors.Trace(err)
+}
+
+func (s *copScheduler) release(storeID uint64, class int) {
+	s.mu.Lock()
+	s.releaseLocked(s.stores[storeID], class)
+	s.mu.Unlock()
+}
+
+func (s *copScheduler) releaseLocked(st *storeTokens, class int) {
+	st.inUse--
+	if class == copClassLow {
+		st.lowInUse--
+	}
+	// Wake up the waiters in the order of priority, low priority waiters may be skipped
+	// if they have used up their tokens.
+	for c := 0; c < copClassCount; c++ {
+		for len(st.waiters[c]) > 0 && s.canGrant(st, c) {
+			s.grant(st, c)
+			close(st.waiters[c][0])
+			st.waiters[c] = st.waiters[c][1:]
+		}
+	}
+}
+
+// hasWaiters checks if there are waiters of the same or higher priority class, a new
+// request should queue behind them.
+func (st *storeTokens) hasWaiters(class int) bool {
+	for c := 0; c <= class; c++ {
+		if len(st.waiters[c]) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (st *storeTokens) removeWaiter(class int, ch chan struct{}) bool {
+	waiters := st.waiters[class]
+	for i, w := range waiters {
+		if w == ch {
+			st.waiters[class] = append(waiters[:i], waiters[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,132 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tikv
+
+import (
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/kv"
+	goctx "golang.org/x/net/context"
+)
+
+type testCopSchedulerSuite struct{}
+
+var _ = Suite(&testCopSchedulerSuite{})
+
+type acquireResult struct {
+	name    string
+	release func()
+	err     error
+}
+
+func (s *testCopSchedulerSuite) acquireAsync(ctx goctx.Context, sched *copScheduler, finished chan struct{}, storeID uint64, priority int, name string, ch chan acquireResult) {
+	go func() {
+		release, err := sched.acquire(ctx, finished, storeID, priority)
+		ch <- acquireResult{name: name, release: release, err: err}
+	}()
+	// Make sure the waiters are queued in order.
+	time.Sleep(20 * time.Millisecond)
+}
+
+func (s *testCopSchedulerSuite) mustNotGranted(c *C, ch chan acquireResult) {
+	select {
+	case r := <-ch:
+		c.Fatalf("%s should not get a token", r.name)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func (s *testCopSchedulerSuite) TestPriority(c *C) {
+	c.Assert(newCopScheduler(0), IsNil)
+	// A nil scheduler has no limit.
+	var nilSched *copScheduler
+	release, err := nilSched.acquire(goctx.Background(), nil, 1, kv.PriorityLow)
+	c.Assert(err, IsNil)
+	release()
+
+	sched := newCopScheduler(4)
+	c.Assert(sched.lowLimit, Equals, 3)
+	ctx := goctx.Background()
+	finished := make(chan struct{})
+	var releases []func()
+	for i := 0; i < 3; i++ {
+		release, err = sched.acquire(ctx, finished, 1, kv.PriorityLow)
+		c.Assert(err, IsNil)
+		releases = append(releases, release)
+	}
+	// Low priority requests can't use up all the tokens.
+	ch := make(chan acquireResult, 10)
+	s.acquireAsync(ctx, sched, finished, 1, kv.PriorityLow, "low", ch)
+	s.mustNotGranted(c, ch)
+	// Other stores are not affected.
+	release, err = sched.acquire(ctx, finished, 2, kv.PriorityLow)
+	c.Assert(err, IsNil)
+	release()
+	// The token left is for the high and normal priority requests.
+	release, err = sched.acquire(ctx, finished, 1, kv.PriorityNormal)
+	c.Assert(err, IsNil)
+
+	s.acquireAsync(ctx, sched, finished, 1, kv.PriorityNormal, "normal", ch)
+	s.acquireAsync(ctx, sched, finished, 1, kv.PriorityHigh, "high", ch)
+	s.mustNotGranted(c, ch)
+
+	// High priority waiters are granted first.
+	release()
+	r := <-ch
+	c.Assert(r.name, Equals, "high")
+	c.Assert(r.err, IsNil)
+	r.release()
+	r = <-ch
+	c.Assert(r.name, Equals, "normal")
+	r.release()
+	// The low priority waiter still waits for the other low priority requests.
+	s.mustNotGranted(c, ch)
+	releases[0]()
+	r = <-ch
+	c.Assert(r.name, Equals, "low")
+	r.release()
+	for _, release := range releases[1:] {
+		release()
+	}
+	c.Assert(sched.stores[1].inUse, Equals, 0)
+	c.Assert(sched.stores[1].lowInUse, Equals, 0)
+}
+
+func (s *testCopSchedulerSuite) TestCancel(c *C) {
+	sched := newCopScheduler(1)
+	finished := make(chan struct{})
+	release, err := sched.acquire(goctx.Background(), finished, 1, kv.PriorityNormal)
+	c.Assert(err, IsNil)
+
+	ctx, cancel := goctx.WithCancel(goctx.Background())
+	ch := make(chan acquireResult, 2)
+	s.acquireAsync(ctx, sched, finished, 1, kv.PriorityNormal, "canceled", ch)
+	s.acquireAsync(goctx.Background(), sched, finished, 1, kv.PriorityNormal, "finished", ch)
+	cancel()
+	r := <-ch
+	c.Assert(r.name, Equals, "canceled")
+	c.Assert(r.err, NotNil)
+	close(finished)
+	r = <-ch
+	c.Assert(r.name, Equals, "finished")
+	c.Assert(r.err, NotNil)
+
+	// The canceled waiters don't take the token.
+	release()
+	c.Assert(sched.stores[1].inUse, Equals, 0)
+	release, err = sched.acquire(goctx.Background(), nil, 1, kv.PriorityNormal)
+	c.Assert(err, IsNil)
+	release()
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/juju/errors"
 	"github.com/ngaut/log"
 	"github.com/pingcap/kvproto/pkg/coprocessor"
+	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/store/tikv/tikvrpc"
 	"github.com/pingcap/tidb/util"
@@ -41,11 +42,18 @@ func (c *CopClient) IsRequestTypeSupported(reqType, subType int64) bool {
 		switch subType {
 		case kv.ReqSubTypeGroupBy, kv.ReqSubTypeBasic, kv.ReqSubTypeTopN:
 			return true
+		case kv.ReqSubTypeDescKey, kv.ReqSubTypeEnumSet, kv.ReqSubTypeBit:
+			// The index keys with descending columns and the enum, set and bit columns are only decoded by the mock TiKV.
+			return c.store.mock
 		default:
-			return supportExpr(tipb.ExprType(subType))
+			return supportExpr(tipb.ExprType(subType)) || (c.store.mock && mockSupportExpr(tipb.ExprType(subType)))
 		}
 	case kv.ReqTypeDAG:
-		return true
+		// The chunk encoded responses are only supported by the mock TiKV.
+		return subType != kv.ReqSubTypeChunk || c.store.mock
+	case kv.ReqTypeChecksum:
+		// TiKV doesn't handle the checksum requests, the data are scanned by TiDB instead.
+		return c.store.mock
 	}
 	return false
 }
@@ -78,6 +86,18 @@ func supportExpr(exprType tipb.ExprType) bool {
 	}
 }
 
+// mockSupportExpr checks whether exprType is supported by the mock TiKV only.
+// Their codes are not assigned by tipb yet, so they are never sent to TiKV.
+func mockSupportExpr(exprType tipb.ExprType) bool {
+	switch exprType {
+	case expression.ExprTypeAggBitAnd, expression.ExprTypeAggBitOr, expression.ExprTypeAggBitXor,
+		expression.ExprTypeApproxCountDistinct, expression.ExprTypeApproxPercentile:
+		return true
+	default:
+		return false
+	}
+}
+
 // Send builds the request and gets the coprocessor iterator response.
 func (c *CopClient) Send(ctx goctx.Context, req *kv.Request) kv.Response {
 	coprocessorCounter.WithLabelValues("send").Inc()
@@ -301,11 +321,19 @@ type copIterator struct {
 	// Otherwise, results are stored in respChan.
 	respChan chan copResponse
 	wg       sync.WaitGroup
+
+	mu struct {
+		sync.Mutex
+		// skippedRanges are the ranges skipped for the unavailable regions if the request allows the partial result.
+		skippedRanges []kv.KeyRange
+	}
 }
 
 type copResponse struct {
 	*coprocessor.Response
 	err error
+	// unavailable are the ranges which fail to be read because their regions are unavailable, they're retried.
+	unavailable *copRanges
 }
 
 const minLogCopTaskTime = 300 * time.Millisecond
@@ -317,7 +345,7 @@ func (it *copIterator) work(ctx goctx.Context, taskCh <-chan *copTask) {
 	for task := range taskCh {
 		bo := NewBackoffer(copNextMaxBackoff, ctx)
 		startTime := time.Now()
-		resps := it.handleTask(bo, task)
+		resps := it.retryUnavailable(NewBackoffer(copRetryMaxBackoff, ctx), it.handleTask(bo, task))
 		costTime := time.Since(startTime)
 		if costTime > minLogCopTaskTime {
 			log.Infof("[TIME_COP_TASK] %s%s %s", costTime, bo, task)
@@ -451,14 +479,28 @@ func (it *copIterator) handleTask(bo *Backoffer, task *copTask) []copResponse {
 				Ranges: task.ranges.toPBRanges(),
 			},
 		}
-		resp, err := sender.SendReq(bo, req, task.region, readTimeoutMedium)
+		release, err := it.acquireToken(bo, task)
 		if err != nil {
+			select {
+			case <-it.finished:
+				return nil
+			default:
+			}
 			return []copResponse{{err: errors.Trace(err)}}
 		}
+		start := time.Now()
+		resp, err := sender.SendReq(bo, req, task.region, readTimeoutMedium)
+		release()
+		if err != nil {
+			return []copResponse{{err: errors.Trace(err), unavailable: task.ranges}}
+		}
+		if sender.storeAddr != "" {
+			copStoreHistogram.WithLabelValues(sender.storeAddr).Observe(time.Since(start).Seconds())
+		}
 		if regionErr := resp.Cop.GetRegionError(); regionErr != nil {
 			err = bo.Backoff(boRegionMiss, errors.New(regionErr.String()))
 			if err != nil {
-				return []copResponse{{err: errors.Trace(err)}}
+				return []copResponse{{err: errors.Trace(err), unavailable: task.ranges}}
 			}
 			return it.handleRegionErrorTask(bo, task)
 		}
@@ -482,17 +524,30 @@ func (it *copIterator) handleTask(bo *Backoffer, task *copTask) []copResponse {
 			return []copResponse{{err: errors.Trace(err)}}
 		}
 		task.storeAddr = sender.storeAddr
+		it.store.hotRegions.record(task.region, [][]byte{task.ranges.at(0).StartKey}, false)
 		return []copResponse{{Response: resp.Cop}}
 	}
 }
 
+// acquireToken acquires a token of the store that the task will be sent to, the returned
+// function releases the token.
+func (it *copIterator) acquireToken(bo *Backoffer, task *copTask) (func(), error) {
+	ctx, err := it.store.regionCache.GetRPCContext(bo, task.region)
+	if err != nil || ctx == nil {
+		// The region cache is out of date, the request sender will handle it.
+		return func() {}, nil
+	}
+	release, err := it.store.copScheduler.acquire(bo.ctx, it.finished, ctx.GetStoreID(), it.req.Priority)
+	return release, errors.Trace(err)
+}
+
 // handleRegionErrorTask handles current task. It may be split into multiple tasks (in region split scenario).
 func (it *copIterator) handleRegionErrorTask(bo *Backoffer, task *copTask) []copResponse {
 	coprocessorCounter.WithLabelValues("rebuild_task").Inc()
 
 	newTasks, err := buildCopTasks(bo, it.store.regionCache, task.ranges, it.req.Desc)
 	if err != nil {
-		return []copResponse{{err: errors.Trace(err)}}
+		return []copResponse{{err: errors.Trace(err), unavailable: task.ranges}}
 	}
 	if newTasks == nil {
 		// TODO: check this, this should never happen.
@@ -507,6 +562,46 @@ func (it *copIterator) handleRegionErrorTask(bo *Backoffer, task *copTask) []cop
 	return ret
 }
 
+// retryUnavailable retries the ranges which fail to be read because their regions are unavailable, every retry
+// has a new backoffer after a jittered sleep, so a region which is unavailable for a while doesn't fail the whole
+// scan, and the retries of the concurrent tasks are spread out. The ranges which still fail when the retry
+// budget is used up are skipped if the request allows the partial result.
+func (it *copIterator) retryUnavailable(retryBo *Backoffer, resps []copResponse) []copResponse {
+	var ret []copResponse
+	for _, resp := range resps {
+		if resp.err == nil || resp.unavailable == nil || retryBo.ctx.Err() != nil {
+			ret = append(ret, resp)
+			continue
+		}
+		if retryBo.Backoff(boCopRetry, resp.err) == nil {
+			coprocessorCounter.WithLabelValues("retry_ranges").Inc()
+			bo := NewBackoffer(copNextMaxBackoff, retryBo.ctx)
+			retried := it.handleRegionErrorTask(bo, &copTask{ranges: resp.unavailable})
+			ret = append(ret, it.retryUnavailable(retryBo, retried)...)
+			continue
+		}
+		if !it.req.AllowPartialResult {
+			// The request fails with the error, the responses after it are useless.
+			return append(ret, resp)
+		}
+		log.Warnf("coprocessor skips %d ranges for the unavailable regions: %v", resp.unavailable.len(), resp.err)
+		coprocessorCounter.WithLabelValues("skip_ranges").Inc()
+		it.mu.Lock()
+		resp.unavailable.do(func(r *kv.KeyRange) {
+			it.mu.skippedRanges = append(it.mu.skippedRanges, *r)
+		})
+		it.mu.Unlock()
+	}
+	return ret
+}
+
+// SkippedRanges implements the kv.SkippedRangesReporter interface.
+func (it *copIterator) SkippedRanges() []kv.KeyRange {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+	return append([]kv.KeyRange(nil), it.mu.skippedRanges...)
+}
+
 func (it *copIterator) Close() error {
 	close(it.finished)
 	it.wg.Wait()
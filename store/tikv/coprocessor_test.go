@@ -15,8 +15,10 @@ package tikv
 
 import (
 	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/store/tikv/mock-tikv"
+	"github.com/pingcap/tipb/go-tipb"
 	goctx "golang.org/x/net/context"
 )
 
@@ -24,6 +26,29 @@ type testCoprocessorSuite struct{}
 
 var _ = Suite(&testCoprocessorSuite{})
 
+func (s *testCoprocessorSuite) TestIsRequestTypeSupported(c *C) {
+	store := &tikvStore{mock: true}
+	client := &CopClient{store: store}
+	c.Assert(client.IsRequestTypeSupported(kv.ReqTypeSelect, int64(expression.ExprTypeAggBitAnd)), IsTrue)
+	c.Assert(client.IsRequestTypeSupported(kv.ReqTypeSelect, int64(expression.ExprTypeApproxPercentile)), IsTrue)
+	c.Assert(client.IsRequestTypeSupported(kv.ReqTypeSelect, int64(tipb.ExprType_Sum)), IsTrue)
+	c.Assert(client.IsRequestTypeSupported(kv.ReqTypeDAG, kv.ReqSubTypeChunk), IsTrue)
+	c.Assert(client.IsRequestTypeSupported(kv.ReqTypeIndex, kv.ReqSubTypeDescKey), IsTrue)
+	c.Assert(client.IsRequestTypeSupported(kv.ReqTypeSelect, kv.ReqSubTypeEnumSet), IsTrue)
+	c.Assert(client.IsRequestTypeSupported(kv.ReqTypeSelect, kv.ReqSubTypeBit), IsTrue)
+	c.Assert(client.IsRequestTypeSupported(kv.ReqTypeChecksum, kv.ReqSubTypeBasic), IsTrue)
+	store.mock = false
+	c.Assert(client.IsRequestTypeSupported(kv.ReqTypeSelect, int64(expression.ExprTypeAggBitAnd)), IsFalse)
+	c.Assert(client.IsRequestTypeSupported(kv.ReqTypeSelect, int64(expression.ExprTypeApproxCountDistinct)), IsFalse)
+	c.Assert(client.IsRequestTypeSupported(kv.ReqTypeSelect, int64(tipb.ExprType_Sum)), IsTrue)
+	c.Assert(client.IsRequestTypeSupported(kv.ReqTypeDAG, kv.ReqSubTypeChunk), IsFalse)
+	c.Assert(client.IsRequestTypeSupported(kv.ReqTypeIndex, kv.ReqSubTypeDescKey), IsFalse)
+	c.Assert(client.IsRequestTypeSupported(kv.ReqTypeSelect, kv.ReqSubTypeEnumSet), IsFalse)
+	c.Assert(client.IsRequestTypeSupported(kv.ReqTypeSelect, kv.ReqSubTypeBit), IsFalse)
+	c.Assert(client.IsRequestTypeSupported(kv.ReqTypeChecksum, kv.ReqSubTypeBasic), IsFalse)
+	c.Assert(client.IsRequestTypeSupported(kv.ReqTypeDAG, kv.ReqSubTypeBasic), IsTrue)
+}
+
 func (s *testCoprocessorSuite) TestBuildTasks(c *C) {
 	// nil --- 'g' --- 'n' --- 't' --- nil
 	// <-  0  -> <- 1 -> <- 2 -> <- 3 ->
@@ -24,6 +24,8 @@ var (
 	errInvalidResponse = errors.New("invalid response")
 	// errBodyMissing response body is missing error
 	errBodyMissing = errors.New("response body is missing")
+	// errInjectedCommitFailure is returned by the failpoint that fails the commit after prewrite.
+	errInjectedCommitFailure = errors.New("injected commit failure after prewrite")
 )
 
 // TiDB decides whether to retry transaction by checking if error message contains
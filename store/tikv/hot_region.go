@@ -0,0 +1,135 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tikv
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pingcap/tidb/tablecodec"
+	"github.com/pingcap/tidb/util"
+)
+
+// hotRegionWindow is the time window the requests are counted in, the QPS is computed from the requests of the
+// current window and the last one.
+const hotRegionWindow = time.Minute
+
+// hotRegionKey is a table or an index in a region, the index ID is 0 for the rows of the table.
+type hotRegionKey struct {
+	regionID uint64
+	tableID  int64
+	indexID  int64
+}
+
+type hotRegionCounter struct {
+	reads      uint64
+	writes     uint64
+	lastReads  uint64
+	lastWrites uint64
+}
+
+// hotRegionCollector counts the read and the write requests this server sends to the tables and the indices
+// in every region, so the hot regions and tables can be found by the information schema.
+type hotRegionCollector struct {
+	mu          sync.Mutex
+	windowStart time.Time
+	// lastWindow is the duration of the last window, it's 0 before the first window is over.
+	lastWindow time.Duration
+	counters   map[hotRegionKey]*hotRegionCounter
+}
+
+func newHotRegionCollector() *hotRegionCollector {
+	return &hotRegionCollector{
+		windowStart: time.Now(),
+		counters:    make(map[hotRegionKey]*hotRegionCounter),
+	}
+}
+
+// rotate starts a new window if the current one is over, the counters which are idle in both windows are removed.
+// It must be called with the lock held.
+func (h *hotRegionCollector) rotate(now time.Time) {
+	elapsed := now.Sub(h.windowStart)
+	if elapsed < hotRegionWindow {
+		return
+	}
+	if elapsed >= 2*hotRegionWindow {
+		// There is no request in a whole window, the counts are too old to be kept.
+		h.counters = make(map[hotRegionKey]*hotRegionCounter)
+		h.lastWindow = 0
+	} else {
+		for key, c := range h.counters {
+			if c.reads == 0 && c.writes == 0 {
+				delete(h.counters, key)
+				continue
+			}
+			c.lastReads, c.lastWrites = c.reads, c.writes
+			c.reads, c.writes = 0, 0
+		}
+		h.lastWindow = elapsed
+	}
+	h.windowStart = now
+}
+
+// record counts a request to the region, the request is counted once for every table or index of the keys.
+func (h *hotRegionCollector) record(region RegionVerID, keys [][]byte, write bool) {
+	if len(keys) == 0 {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.rotate(time.Now())
+	var last hotRegionKey
+	for _, k := range keys {
+		tableID, indexID, _, err := tablecodec.DecodeKeyHead(k)
+		key := hotRegionKey{regionID: region.id, tableID: tableID, indexID: indexID}
+		// The keys which aren't of the tables, e.g. the meta keys, are not counted.
+		if err != nil || key == last {
+			continue
+		}
+		last = key
+		c, ok := h.counters[key]
+		if !ok {
+			c = &hotRegionCounter{}
+			h.counters[key] = c
+		}
+		if write {
+			c.writes++
+		} else {
+			c.reads++
+		}
+	}
+}
+
+// HotRegions implements the util.HotRegionCollector interface.
+func (h *hotRegionCollector) HotRegions() []util.HotRegionStat {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	now := time.Now()
+	h.rotate(now)
+	seconds := (now.Sub(h.windowStart) + h.lastWindow).Seconds()
+	if seconds <= 0 {
+		return nil
+	}
+	stats := make([]util.HotRegionStat, 0, len(h.counters))
+	for key, c := range h.counters {
+		stats = append(stats, util.HotRegionStat{
+			RegionID: key.regionID,
+			TableID:  key.tableID,
+			IndexID:  key.indexID,
+			ReadQPS:  float64(c.reads+c.lastReads) / seconds,
+			WriteQPS: float64(c.writes+c.lastWrites) / seconds,
+		})
+	}
+	return stats
+}
@@ -0,0 +1,60 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tikv
+
+import (
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/tablecodec"
+)
+
+type testHotRegionSuite struct{}
+
+var _ = Suite(&testHotRegionSuite{})
+
+func (s *testHotRegionSuite) TestHotRegionCollector(c *C) {
+	h := newHotRegionCollector()
+	region := RegionVerID{id: 2}
+	rowKey := tablecodec.EncodeRowKeyWithHandle(1, 1)
+	indexKey := tablecodec.EncodeIndexSeekKey(1, 3, nil)
+	// The request is counted once for the rows and once for the index, the meta keys are ignored.
+	h.record(region, [][]byte{rowKey, tablecodec.EncodeRowKeyWithHandle(1, 2), indexKey, []byte("m")}, true)
+	h.record(region, [][]byte{rowKey}, false)
+	h.record(RegionVerID{id: 4}, [][]byte{indexKey}, false)
+	c.Assert(h.counters, HasLen, 3)
+	c.Assert(h.counters[hotRegionKey{regionID: 2, tableID: 1}], DeepEquals, &hotRegionCounter{reads: 1, writes: 1})
+	c.Assert(h.counters[hotRegionKey{regionID: 2, tableID: 1, indexID: 3}], DeepEquals, &hotRegionCounter{writes: 1})
+	c.Assert(h.counters[hotRegionKey{regionID: 4, tableID: 1, indexID: 3}], DeepEquals, &hotRegionCounter{reads: 1})
+	stats := h.HotRegions()
+	c.Assert(stats, HasLen, 3)
+	for _, stat := range stats {
+		c.Assert(stat.ReadQPS+stat.WriteQPS, Greater, 0.0)
+	}
+
+	// The counts of the current window are kept in the next one, the idle counters are removed after it.
+	now := h.windowStart.Add(hotRegionWindow)
+	h.rotate(now)
+	c.Assert(h.lastWindow, Equals, hotRegionWindow)
+	c.Assert(h.counters[hotRegionKey{regionID: 2, tableID: 1}], DeepEquals, &hotRegionCounter{lastReads: 1, lastWrites: 1})
+	h.rotate(now.Add(hotRegionWindow))
+	c.Assert(h.counters, HasLen, 0)
+
+	// The counts are dropped if there is no request in a whole window.
+	h.record(region, [][]byte{rowKey}, false)
+	h.windowStart = time.Now().Add(-2 * hotRegionWindow)
+	h.rotate(time.Now())
+	c.Assert(h.counters, HasLen, 0)
+	c.Assert(h.lastWindow, Equals, time.Duration(0))
+}
@@ -14,6 +14,7 @@
 package tikv
 
 import (
+	"bytes"
 	"fmt"
 	"math/rand"
 	"net/url"
@@ -30,6 +31,7 @@ import (
 	"github.com/pingcap/tidb/store/tikv/oracle"
 	"github.com/pingcap/tidb/store/tikv/oracle/oracles"
 	"github.com/pingcap/tidb/store/tikv/tikvrpc"
+	"github.com/pingcap/tidb/util"
 	goctx "golang.org/x/net/context"
 )
 
@@ -107,6 +109,8 @@ type tikvStore struct {
 	gcWorker     *GCWorker
 	etcdAddrs    []string
 	mock         bool
+	copScheduler *copScheduler
+	hotRegions   *hotRegionCollector
 }
 
 func newTikvStore(uuid string, pdClient pd.Client, client Client, enableGC bool) (*tikvStore, error) {
@@ -116,12 +120,14 @@ func newTikvStore(uuid string, pdClient pd.Client, client Client, enableGC bool)
 	}
 	_, mock := client.(*mocktikv.RPCClient)
 	store := &tikvStore{
-		clusterID:   pdClient.GetClusterID(goctx.TODO()),
-		uuid:        uuid,
-		oracle:      oracle,
-		client:      client,
-		regionCache: NewRegionCache(pdClient),
-		mock:        mock,
+		clusterID:    pdClient.GetClusterID(goctx.TODO()),
+		uuid:         uuid,
+		oracle:       oracle,
+		client:       client,
+		regionCache:  NewRegionCache(pdClient),
+		mock:         mock,
+		copScheduler: newCopScheduler(CopStoreConcurrency),
+		hotRegions:   newHotRegionCollector(),
 	}
 	store.lockResolver = newLockResolver(store)
 	if enableGC {
@@ -137,6 +143,29 @@ func (s *tikvStore) EtcdAddrs() []string {
 	return s.etcdAddrs
 }
 
+// HotRegions implements the util.HotRegionCollector interface.
+func (s *tikvStore) HotRegions() []util.HotRegionStat {
+	return s.hotRegions.HotRegions()
+}
+
+// GetSplitKeys implements the kv.SplitKeysGetter interface.
+func (s *tikvStore) GetSplitKeys(r kv.KeyRange) ([]kv.Key, error) {
+	bo := NewBackoffer(copBuildTaskMaxBackoff, goctx.Background())
+	var keys []kv.Key
+	key := r.StartKey
+	for {
+		loc, err := s.regionCache.LocateKey(bo, key)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if len(loc.EndKey) == 0 || bytes.Compare(loc.EndKey, r.EndKey) >= 0 {
+			return keys, nil
+		}
+		keys = append(keys, loc.EndKey)
+		key = loc.EndKey
+	}
+}
+
 type mockOptions struct {
 	cluster        *mocktikv.Cluster
 	mvccStore      mocktikv.MVCCStore
@@ -69,6 +69,31 @@ var (
 			Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 18),
 		})
 
+	backoffSleepHistogram = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "tidb",
+			Subsystem: "tikvclient",
+			Name:      "backoff_sleep_seconds",
+			Help:      "Bucketed histogram of the sleep time of a single backoff.",
+			Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 18),
+		}, []string{"type"})
+
+	storeBreakerCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "tidb",
+			Subsystem: "tikvclient",
+			Name:      "store_breaker_total",
+			Help:      "Counter of the store circuit breaker actions.",
+		}, []string{"type"})
+
+	regionCacheCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "tidb",
+			Subsystem: "tikvclient",
+			Name:      "region_cache_operations_total",
+			Help:      "Counter of the background operations of the region cache.",
+		}, []string{"type", "result"})
+
 	connPoolHistogram = prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Namespace: "tidb",
@@ -104,6 +129,15 @@ var (
 			Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 18),
 		})
 
+	copSchedulerWaitHistogram = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "tidb",
+			Subsystem: "tikvclient",
+			Name:      "cop_scheduler_wait_seconds",
+			Help:      "Bucketed histogram of waiting for the coprocessor request tokens of a store.",
+			Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 18),
+		}, []string{"priority"})
+
 	gcWorkerCounter = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Namespace: "tidb",
@@ -190,6 +224,24 @@ var (
 			Help:      "Number of regions in a transaction.",
 			Buckets:   prometheus.ExponentialBuckets(1, 2, 20),
 		}, []string{"type"})
+
+	txnActionHistogram = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "tidb",
+			Subsystem: "tikvclient",
+			Name:      "txn_action_seconds",
+			Help:      "Bucketed histogram of processing time (s) of the 2PC actions, like prewrite and commit.",
+			Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 20),
+		}, []string{"type"})
+
+	copStoreHistogram = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "tidb",
+			Subsystem: "tikvclient",
+			Name:      "cop_store_seconds",
+			Help:      "Bucketed histogram of processing time (s) of coprocessor requests by store.",
+			Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 20),
+		}, []string{"store"})
 )
 
 func reportRegionError(e *errorpb.Error) {
@@ -219,10 +271,14 @@ func init() {
 	prometheus.MustRegister(txnCmdHistogram)
 	prometheus.MustRegister(backoffCounter)
 	prometheus.MustRegister(backoffHistogram)
+	prometheus.MustRegister(backoffSleepHistogram)
+	prometheus.MustRegister(storeBreakerCounter)
+	prometheus.MustRegister(regionCacheCounter)
 	prometheus.MustRegister(sendReqHistogram)
 	prometheus.MustRegister(connPoolHistogram)
 	prometheus.MustRegister(coprocessorCounter)
 	prometheus.MustRegister(coprocessorHistogram)
+	prometheus.MustRegister(copSchedulerWaitHistogram)
 	prometheus.MustRegister(gcWorkerCounter)
 	prometheus.MustRegister(gcConfigGauge)
 	prometheus.MustRegister(gcHistogram)
@@ -233,4 +289,6 @@ func init() {
 	prometheus.MustRegister(rawkvCmdHistogram)
 	prometheus.MustRegister(rawkvSizeHistogram)
 	prometheus.MustRegister(txnRegionsNumHistogram)
+	prometheus.MustRegister(txnActionHistogram)
+	prometheus.MustRegister(copStoreHistogram)
 }
@@ -15,10 +15,13 @@ package mocktikv
 
 import (
 	"bytes"
+	"math"
 
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/distsql/xeval"
+	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/util/codec"
+	"github.com/pingcap/tidb/util/sketch"
 	"github.com/pingcap/tidb/util/types"
 	"github.com/pingcap/tipb/go-tipb"
 )
@@ -95,6 +98,9 @@ type aggItem struct {
 	buffer *bytes.Buffer // Buffer is used for group_concat.
 	// It will check if the agg has met the first row key.
 	gotFirstRow bool
+	// hll and tdigest are the sketches of approx_count_distinct and approx_percentile.
+	hll     *sketch.HyperLogLog
+	tdigest *sketch.TDigest
 }
 
 // This is similar to ast.AggregateFuncExpr but use tipb.Expr.
@@ -125,6 +131,10 @@ func (n *aggregateFuncExpr) update(eval *xeval.Evaluator, args []types.Datum) er
 		return n.updateMaxMin(eval, args, true)
 	case tipb.ExprType_Min:
 		return n.updateMaxMin(eval, args, false)
+	case expression.ExprTypeAggBitAnd, expression.ExprTypeAggBitOr, expression.ExprTypeAggBitXor:
+		return n.updateBit(eval, args)
+	case expression.ExprTypeApproxCountDistinct, expression.ExprTypeApproxPercentile:
+		return n.updateSketch(eval, args)
 	}
 	return errors.Errorf("Unknown AggExpr: %v", n.expr.GetTp())
 }
@@ -135,6 +145,10 @@ func (n *aggregateFuncExpr) toDatums(eval *xeval.Evaluator) (ds []types.Datum, e
 		ds = n.getCountDatum()
 	case tipb.ExprType_First, tipb.ExprType_Max, tipb.ExprType_Min:
 		ds = n.getValueDatum()
+	case expression.ExprTypeAggBitAnd, expression.ExprTypeAggBitOr, expression.ExprTypeAggBitXor:
+		ds = n.getBitDatum()
+	case expression.ExprTypeApproxCountDistinct, expression.ExprTypeApproxPercentile:
+		ds = n.getSketchDatum()
 	case tipb.ExprType_Sum:
 		d, err := getSumValue(eval, n.getAggItem())
 		if err != nil {
@@ -264,7 +278,7 @@ func (n *aggregateFuncExpr) updateMaxMin(eval *xeval.Evaluator, args []types.Dat
 		aggItem.value = arg
 		return nil
 	}
-	c, err := aggItem.value.CompareDatum(eval.StatementCtx, arg)
+	c, err := aggItem.value.CompareDatumForMaxMin(eval.StatementCtx, arg)
 	if err != nil {
 		return errors.Trace(err)
 	}
@@ -277,3 +291,92 @@ func (n *aggregateFuncExpr) updateMaxMin(eval *xeval.Evaluator, args []types.Dat
 	}
 	return nil
 }
+
+// Convert bit_and/bit_or/bit_xor result to datum list, the result is never null.
+func (n *aggregateFuncExpr) getBitDatum() []types.Datum {
+	item := n.getAggItem()
+	if !item.gotFirstRow {
+		return []types.Datum{types.NewUintDatum(n.initBitValue())}
+	}
+	return []types.Datum{item.value}
+}
+
+func (n *aggregateFuncExpr) initBitValue() uint64 {
+	if n.expr.GetTp() == expression.ExprTypeAggBitAnd {
+		return math.MaxUint64
+	}
+	return 0
+}
+
+var aggBitOps = map[tipb.ExprType]tipb.ExprType{
+	expression.ExprTypeAggBitAnd: tipb.ExprType_BitAnd,
+	expression.ExprTypeAggBitOr:  tipb.ExprType_BitOr,
+	expression.ExprTypeAggBitXor: tipb.ExprType_BitXor,
+}
+
+func (n *aggregateFuncExpr) updateBit(eval *xeval.Evaluator, args []types.Datum) error {
+	if len(args) != 1 {
+		// This should not happen. The length of argument list is already checked in the early stage.
+		// This is just in case of error.
+		return errors.Errorf("Wrong number of argument for bit function, need 1 but get %d", len(args))
+	}
+	aggItem := n.getAggItem()
+	if !aggItem.gotFirstRow {
+		aggItem.value = types.NewUintDatum(n.initBitValue())
+		aggItem.gotFirstRow = true
+	}
+	arg := args[0]
+	if arg.IsNull() {
+		return nil
+	}
+	var err error
+	aggItem.value, err = xeval.ComputeBit(eval.StatementCtx, aggBitOps[n.expr.GetTp()], aggItem.value, arg)
+	return errors.Trace(err)
+}
+
+// Convert the sketch of approx_count_distinct or approx_percentile to datum list, it's null if no row is updated.
+func (n *aggregateFuncExpr) getSketchDatum() []types.Datum {
+	item := n.getAggItem()
+	if item.hll != nil {
+		return []types.Datum{types.NewBytesDatum(item.hll.Marshal())}
+	}
+	if item.tdigest != nil {
+		return []types.Datum{types.NewBytesDatum(item.tdigest.Marshal())}
+	}
+	return []types.Datum{{}}
+}
+
+func (n *aggregateFuncExpr) updateSketch(eval *xeval.Evaluator, args []types.Datum) error {
+	aggItem := n.getAggItem()
+	if n.expr.GetTp() == expression.ExprTypeApproxPercentile {
+		if len(args) != 1 {
+			return errors.Errorf("Wrong number of argument for approx_percentile, need 1 but get %d", len(args))
+		}
+		if aggItem.tdigest == nil {
+			aggItem.tdigest = sketch.NewTDigest()
+		}
+		if args[0].IsNull() {
+			return nil
+		}
+		x, err := args[0].ToFloat64(eval.StatementCtx)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		aggItem.tdigest.Add(x)
+		return nil
+	}
+	if aggItem.hll == nil {
+		aggItem.hll = sketch.NewHyperLogLog()
+	}
+	for _, arg := range args {
+		if arg.IsNull() {
+			return nil
+		}
+	}
+	encoded, err := codec.EncodeValue(nil, args...)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	aggItem.hll.Insert(encoded)
+	return nil
+}
@@ -40,6 +40,8 @@ type Cluster struct {
 	id      uint64
 	stores  map[uint64]*Store
 	regions map[uint64]*Region
+	// injected is the failures injected by the tests.
+	injected injectedFailures
 }
 
 // NewCluster creates an empty cluster. It needs to be bootstrapped before
@@ -65,6 +65,8 @@ func (h *rpcHandler) handleCopRequest(req *coprocessor.Request) (*coprocessor.Re
 	}
 	if req.GetTp() == kv.ReqTypeDAG {
 		return h.handleCopDAGRequest(req)
+	} else if req.GetTp() == kv.ReqTypeChecksum {
+		return h.handleCopChecksumRequest(req)
 	} else if req.GetTp() == kv.ReqTypeSelect || req.GetTp() == kv.ReqTypeIndex {
 		sel := new(tipb.SelectRequest)
 		err := proto.Unmarshal(req.Data, sel)
@@ -159,7 +161,7 @@ func buildResp(chunks []tipb.Chunk, err error) (*coprocessor.Response, error) {
 	if err != nil {
 		if locked, ok := errors.Cause(err).(*ErrLocked); ok {
 			resp.Locked = &kvrpcpb.LockInfo{
-				Key:         locked.Key,
+				Key:         locked.Key.Raw(),
 				PrimaryLock: locked.Primary,
 				LockVersion: locked.StartTS,
 				LockTtl:     locked.TTL,
@@ -0,0 +1,50 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mocktikv
+
+import (
+	"github.com/juju/errors"
+	"github.com/pingcap/kvproto/pkg/coprocessor"
+	"github.com/pingcap/tidb/distsql"
+	"github.com/pingcap/tidb/kv"
+)
+
+// checksumScanBatch is the number of the key-value pairs to scan at a time for checksum.
+const checksumScanBatch = 256
+
+func (h *rpcHandler) handleCopChecksumRequest(req *coprocessor.Request) (*coprocessor.Response, error) {
+	checksumReq := &distsql.ChecksumRequest{}
+	if err := checksumReq.Unmarshal(req.Data); err != nil {
+		return nil, errors.Trace(err)
+	}
+	checksum := &distsql.ChecksumResponse{}
+	for _, ran := range h.extractKVRanges(req.Ranges, false) {
+		startKey := []byte(ran.StartKey)
+		for {
+			pairs := h.mvccStore.Scan(startKey, ran.EndKey, checksumScanBatch, checksumReq.StartTs, h.isolationLevel)
+			for _, pair := range pairs {
+				if pair.Err != nil {
+					// The lock error is sent to the client in the response.
+					return buildResp(nil, errors.Trace(pair.Err))
+				}
+				checksum.Update(pair.Key, pair.Value)
+			}
+			if len(pairs) < checksumScanBatch {
+				break
+			}
+			startKey = kv.Key(pairs[len(pairs)-1].Key).Next()
+		}
+	}
+	return &coprocessor.Response{Data: checksum.Marshal()}, nil
+}
@@ -24,6 +24,8 @@ import (
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/tablecodec"
+	"github.com/pingcap/tidb/util/chunk"
+	"github.com/pingcap/tidb/util/codec"
 	"github.com/pingcap/tidb/util/types"
 	"github.com/pingcap/tipb/go-tipb"
 )
@@ -63,7 +65,11 @@ func (h *rpcHandler) handleCopDAGRequest(req *coprocessor.Request) (*coprocessor
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
-	var chunks []tipb.Chunk
+	var (
+		chunks    []tipb.Chunk
+		colChunks []*chunk.Chunk
+	)
+	chunkEncode := dagReq.Flags&FlagChunkEncode > 0
 	for {
 		var (
 			handle int64
@@ -71,20 +77,93 @@ func (h *rpcHandler) handleCopDAGRequest(req *coprocessor.Request) (*coprocessor
 		)
 		handle, row, err = e.Next()
 		if err != nil {
-			return nil, errors.Trace(err)
+			// The lock and other errors are sent to the client in the response.
+			return buildResp(nil, errors.Trace(err))
 		}
 		if row == nil {
 			break
 		}
+		if chunkEncode {
+			colChunks, err = appendChunkRow(colChunks, handle, row, dagReq.OutputOffsets)
+			if err == nil {
+				continue
+			}
+			// The row can not be stored in the chunks, fall back to the default encode type.
+			chunks, err = chunksToDefault(colChunks)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			chunkEncode = false
+		}
 		data := dummySlice
 		for _, offset := range dagReq.OutputOffsets {
 			data = append(data, row[offset]...)
 		}
 		chunks = appendRow(chunks, handle, data)
 	}
+	if chunkEncode {
+		return buildChunkResp(colChunks)
+	}
 	return buildResp(chunks, err)
 }
 
+const rowsPerColumnarChunk = 1024
+
+// appendChunkRow appends the output values of a row to the last chunk, the row handle is stored in the last column.
+func appendChunkRow(chks []*chunk.Chunk, handle int64, row [][]byte, offsets []uint32) ([]*chunk.Chunk, error) {
+	values := make([]types.Datum, 0, len(offsets)+1)
+	for _, offset := range offsets {
+		_, d, err := codec.DecodeOne(row[offset])
+		if err != nil {
+			return chks, errors.Trace(err)
+		}
+		values = append(values, d)
+	}
+	values = append(values, types.NewIntDatum(handle))
+	if len(chks) == 0 || chks[len(chks)-1].NumRows() >= rowsPerColumnarChunk {
+		chks = append(chks, chunk.NewChunk(len(values)))
+	}
+	return chks, errors.Trace(chks[len(chks)-1].AppendRow(values))
+}
+
+// chunksToDefault converts the rows in the chunks to the default encode type.
+func chunksToDefault(chks []*chunk.Chunk) ([]tipb.Chunk, error) {
+	var chunks []tipb.Chunk
+	for _, chk := range chks {
+		handleIdx := chk.NumCols() - 1
+		for i := 0; i < chk.NumRows(); i++ {
+			row := chk.GetRow(i)
+			data := dummySlice
+			for j := 0; j < handleIdx; j++ {
+				d, err := row.GetDatum(j)
+				if err != nil {
+					return nil, errors.Trace(err)
+				}
+				data, err = codec.EncodeValue(data, d)
+				if err != nil {
+					return nil, errors.Trace(err)
+				}
+			}
+			chunks = appendRow(chunks, row.GetInt64(handleIdx), data)
+		}
+	}
+	return chunks, nil
+}
+
+func buildChunkResp(chks []*chunk.Chunk) (*coprocessor.Response, error) {
+	selResp := &tipb.SelectResponse{
+		Chunks: make([]tipb.Chunk, 0, len(chks)),
+	}
+	for _, chk := range chks {
+		selResp.Chunks = append(selResp.Chunks, tipb.Chunk{RowsData: chunk.Encode(chk)})
+	}
+	data, err := proto.Marshal(selResp)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &coprocessor.Response{Data: data}, nil
+}
+
 func (h *rpcHandler) buildExec(ctx *dagContext, curr *tipb.Executor) (executor, error) {
 	var currExec executor
 	var err error
@@ -298,6 +377,8 @@ const (
 	// This flag only matters if FlagIgnoreTruncate is not set, in strict sql mode, truncate error should
 	// be returned as error, in non-strict sql mode, truncate error should be saved as warning.
 	FlagTruncateAsWarning uint64 = 1 << 1
+	// FlagChunkEncode indicates if the rows of the DAG response are wanted in chunk encoded format.
+	FlagChunkEncode uint64 = 1 << 2
 )
 
 // flagsToStatementContext creates a StatementContext from a `tipb.SelectRequest.Flags`.
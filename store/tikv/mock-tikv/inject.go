@@ -0,0 +1,144 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mocktikv
+
+import (
+	"bytes"
+
+	"github.com/ngaut/log"
+	"github.com/pingcap/kvproto/pkg/kvrpcpb"
+	"github.com/pingcap/tidb/store/tikv/tikvrpc"
+)
+
+// injectedFailures are the failures that happen when the requests are being handled,
+// they make it possible to test the retry logic of the client without a real cluster.
+type injectedFailures struct {
+	// splitKeys are the keys to split the Regions at when they receive requests.
+	splitKeys [][]byte
+	locks     []injectedLock
+}
+
+type injectedLock struct {
+	key     []byte
+	startTS uint64
+	ttl     uint64
+}
+
+// SplitOnRequest makes the next request to the Region containing the key split the
+// Region at the key before it's handled, as if the Region is split concurrently. The
+// request meets a stale epoch error then.
+func (c *Cluster) SplitOnRequest(key []byte) {
+	c.Lock()
+	defer c.Unlock()
+	c.injected.splitKeys = append(c.injected.splitKeys, append([]byte(nil), key...))
+}
+
+// LockOnRead makes the next read request covering the key meet a lock on the key,
+// as if a transaction with startTS prewrites the key concurrently. The lock is its
+// own primary lock. The startTS should be larger than the latest commitTS of the key,
+// otherwise the lock can't be written.
+func (c *Cluster) LockOnRead(key []byte, startTS, ttl uint64) {
+	c.Lock()
+	defer c.Unlock()
+	c.injected.locks = append(c.injected.locks, injectedLock{
+		key:     append([]byte(nil), key...),
+		startTS: startTS,
+		ttl:     ttl,
+	})
+}
+
+// splitOnRequest splits the Region at the injected keys it contains.
+func (c *Cluster) splitOnRequest(regionID uint64) {
+	c.Lock()
+	defer c.Unlock()
+	keys := c.injected.splitKeys[:0]
+	for _, key := range c.injected.splitKeys {
+		r := c.regions[regionID]
+		encoded := NewMvccKey(key)
+		if r == nil || !regionContains(r.Meta.StartKey, r.Meta.EndKey, encoded) ||
+			bytes.Equal(r.Meta.StartKey, encoded) {
+			keys = append(keys, key)
+			continue
+		}
+		peerIDs := make([]uint64, len(r.Meta.Peers))
+		var leaderPeerID uint64
+		for i, p := range r.Meta.Peers {
+			peerIDs[i] = c.allocID()
+			if p.GetId() == r.leader {
+				leaderPeerID = peerIDs[i]
+			}
+		}
+		newRegionID := c.allocID()
+		c.regions[newRegionID] = r.split(newRegionID, encoded, peerIDs, leaderPeerID)
+	}
+	c.injected.splitKeys = keys
+}
+
+// takeLocks removes and returns the injected locks on the keys that match.
+func (c *Cluster) takeLocks(match func(key []byte) bool) []injectedLock {
+	c.Lock()
+	defer c.Unlock()
+	var taken []injectedLock
+	locks := c.injected.locks[:0]
+	for _, l := range c.injected.locks {
+		if match(l.key) {
+			taken = append(taken, l)
+		} else {
+			locks = append(locks, l)
+		}
+	}
+	c.injected.locks = locks
+	return taken
+}
+
+// injectFailures applies the injected failures before the request is handled.
+func (c *RPCClient) injectFailures(req *tikvrpc.Request, reqCtx *kvrpcpb.Context) {
+	c.Cluster.splitOnRequest(reqCtx.GetRegionId())
+
+	var match func(key []byte) bool
+	switch req.Type {
+	case tikvrpc.CmdGet:
+		match = func(key []byte) bool { return bytes.Equal(key, req.Get.Key) }
+	case tikvrpc.CmdBatchGet:
+		match = func(key []byte) bool {
+			for _, k := range req.BatchGet.Keys {
+				if bytes.Equal(key, k) {
+					return true
+				}
+			}
+			return false
+		}
+	case tikvrpc.CmdScan:
+		match = func(key []byte) bool { return bytes.Compare(key, req.Scan.StartKey) >= 0 }
+	case tikvrpc.CmdCop:
+		match = func(key []byte) bool {
+			for _, r := range req.Cop.Ranges {
+				if regionContains(r.Start, r.End, key) {
+					return true
+				}
+			}
+			return false
+		}
+	default:
+		return
+	}
+	for _, l := range c.Cluster.takeLocks(match) {
+		mutation := &kvrpcpb.Mutation{Op: kvrpcpb.Op_Lock, Key: l.key}
+		for _, err := range c.MvccStore.Prewrite([]*kvrpcpb.Mutation{mutation}, l.key, l.startTS, l.ttl) {
+			if err != nil {
+				log.Warnf("mocktikv: inject lock on %q failed: %v", l.key, err)
+			}
+		}
+	}
+}
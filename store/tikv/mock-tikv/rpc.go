@@ -418,6 +418,7 @@ func (c *RPCClient) SendReq(ctx goctx.Context, addr string, req *tikvrpc.Request
 	if err != nil {
 		return nil, err
 	}
+	c.injectFailures(req, reqCtx)
 	resp := &tikvrpc.Response{}
 	resp.Type = req.Type
 	switch req.Type {
@@ -16,6 +16,7 @@ package tikv
 import (
 	"bytes"
 	"sync"
+	"time"
 
 	"github.com/juju/errors"
 	"github.com/ngaut/log"
@@ -23,9 +24,22 @@ import (
 	"github.com/pingcap/kvproto/pkg/kvrpcpb"
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/pd/pd-client"
+	"github.com/pingcap/tidb/util/failpoint"
 	goctx "golang.org/x/net/context"
 )
 
+// RegionCacheTTL is how long a cached Region can be used before it's refreshed. An expired
+// Region is still used, and it's reloaded from PD in background, so the requests don't wait
+// for PD. Set it to 0 to disable the refresh.
+var RegionCacheTTL = 10 * time.Minute
+
+// storeProbeInterval is the interval to check if a failed store is back, the peers on the
+// store are not chosen for the newly loaded Regions until then.
+var storeProbeInterval = time.Second
+
+// storeProbeTimeout is how long to probe a failed store before giving up.
+const storeProbeTimeout = time.Minute
+
 // RegionCache caches Regions loaded from PD.
 type RegionCache struct {
 	pdClient pd.Client
@@ -37,17 +51,28 @@ type RegionCache struct {
 	storeMu struct {
 		sync.RWMutex
 		stores map[uint64]*Store
+		// down is the failed stores that are being probed.
+		down map[uint64]struct{}
 	}
+	refreshMu struct {
+		sync.Mutex
+		// pending is the IDs of the Regions that are being refreshed.
+		pending map[uint64]struct{}
+	}
+	breakers *storeBreakers
 }
 
 // NewRegionCache creates a RegionCache.
 func NewRegionCache(pdClient pd.Client) *RegionCache {
 	c := &RegionCache{
 		pdClient: pdClient,
+		breakers: newStoreBreakers(),
 	}
 	c.mu.regions = make(map[RegionVerID]*Region)
 	c.mu.sorted = llrb.New()
 	c.storeMu.stores = make(map[uint64]*Store)
+	c.storeMu.down = make(map[uint64]struct{})
+	c.refreshMu.pending = make(map[uint64]struct{})
 	return c
 }
 
@@ -69,6 +94,10 @@ func (c *RPCContext) GetStoreID() uint64 {
 // GetRPCContext returns RPCContext for a region. If it returns nil, the region
 // must be out of date and already dropped from cache.
 func (c *RegionCache) GetRPCContext(bo *Backoffer, id RegionVerID) (*RPCContext, error) {
+	if _, ok := failpoint.Eval("tikv/regionMiss"); ok {
+		c.DropRegion(id)
+		return nil, nil
+	}
 	c.mu.RLock()
 	region, ok := c.mu.regions[id]
 	if !ok {
@@ -111,6 +140,7 @@ func (l *KeyLocation) Contains(key []byte) bool {
 func (c *RegionCache) LocateKey(bo *Backoffer, key []byte) (*KeyLocation, error) {
 	c.mu.RLock()
 	if r := c.getRegionFromCache(key); r != nil {
+		c.checkRegionTTL(r)
 		loc := &KeyLocation{
 			Region:   r.VerID(),
 			StartKey: r.StartKey(),
@@ -140,6 +170,7 @@ func (c *RegionCache) LocateKey(bo *Backoffer, key []byte) (*KeyLocation, error)
 func (c *RegionCache) LocateRegionByID(bo *Backoffer, regionID uint64) (*KeyLocation, error) {
 	c.mu.RLock()
 	if r := c.getRegionByIDFromCache(regionID); r != nil {
+		c.checkRegionTTL(r)
 		loc := &KeyLocation{
 			Region:   r.VerID(),
 			StartKey: r.StartKey(),
@@ -248,14 +279,87 @@ func (c *RegionCache) insertRegionToCache(r *Region) *Region {
 	if old, ok := c.mu.regions[r.VerID()]; ok {
 		return old
 	}
-	old := c.mu.sorted.ReplaceOrInsert(newRBItem(r))
-	if old != nil {
-		delete(c.mu.regions, old.(*llrbItem).region.VerID())
-	}
+	c.dropOverlappedRegions(r)
+	c.mu.sorted.ReplaceOrInsert(newRBItem(r))
 	c.mu.regions[r.VerID()] = r
+	r.loadTime = time.Now()
 	return r
 }
 
+// dropOverlappedRegions drops all the cached Regions whose ranges overlap with the new
+// Region in a batch. They are out of date after splits or merges, and would cause another
+// round of region errors if they were dropped one by one when the requests fail.
+func (c *RegionCache) dropOverlappedRegions(r *Region) {
+	var overlapped []RegionVerID
+	c.mu.sorted.DescendLessOrEqual(newRBSearchItem(r.StartKey()), func(item llrb.Item) bool {
+		old := item.(*llrbItem).region
+		if old.Contains(r.StartKey()) {
+			overlapped = append(overlapped, old.VerID())
+		}
+		return false
+	})
+	c.mu.sorted.AscendGreaterOrEqual(newRBSearchItem(r.StartKey()), func(item llrb.Item) bool {
+		old := item.(*llrbItem).region
+		if len(r.EndKey()) > 0 && bytes.Compare(old.StartKey(), r.EndKey()) >= 0 {
+			return false
+		}
+		overlapped = append(overlapped, old.VerID())
+		return true
+	})
+	for _, id := range overlapped {
+		c.dropRegionFromCache(id)
+	}
+}
+
+// checkRegionTTL starts a background refresh if the cached Region is expired.
+func (c *RegionCache) checkRegionTTL(r *Region) {
+	if RegionCacheTTL > 0 && time.Since(r.loadTime) > RegionCacheTTL {
+		c.asyncRefreshRegion(r)
+	}
+}
+
+// asyncRefreshRegion reloads the Region from PD in background. If the Region is not
+// changed, its load time is updated, otherwise it's replaced by the new one.
+func (c *RegionCache) asyncRefreshRegion(r *Region) {
+	id := r.GetID()
+	c.refreshMu.Lock()
+	if _, ok := c.refreshMu.pending[id]; ok {
+		c.refreshMu.Unlock()
+		return
+	}
+	c.refreshMu.pending[id] = struct{}{}
+	c.refreshMu.Unlock()
+
+	go func() {
+		defer func() {
+			c.refreshMu.Lock()
+			delete(c.refreshMu.pending, id)
+			c.refreshMu.Unlock()
+		}()
+
+		bo := NewBackoffer(regionRefreshMaxBackoff, goctx.Background())
+		newRegion, err := c.loadRegion(bo, r.StartKey())
+		if err != nil {
+			regionCacheCounter.WithLabelValues("refresh", "err").Inc()
+			log.Warnf("regionCache: refresh region %d failed, err: %v", id, err)
+			return
+		}
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if old, ok := c.mu.regions[newRegion.VerID()]; ok {
+			old.loadTime = time.Now()
+			if old.peer.GetStoreId() != newRegion.peer.GetStoreId() && len(old.unreachableStores) == 0 {
+				old.SwitchPeer(newRegion.peer.GetStoreId())
+			}
+			regionCacheCounter.WithLabelValues("refresh", "unchanged").Inc()
+			return
+		}
+		c.insertRegionToCache(newRegion)
+		regionCacheCounter.WithLabelValues("refresh", "changed").Inc()
+	}()
+}
+
 // getRegionByIDFromCache tries to get region by regionID from cache
 func (c *RegionCache) getRegionByIDFromCache(regionID uint64) *Region {
 	for v, r := range c.mu.regions {
@@ -305,6 +409,7 @@ func (c *RegionCache) loadRegion(bo *Backoffer, key []byte) (*Region, error) {
 		if leader != nil {
 			region.SwitchPeer(leader.GetStoreId())
 		}
+		c.skipDownStores(region)
 		return region, nil
 	}
 }
@@ -339,6 +444,7 @@ func (c *RegionCache) loadRegionByID(bo *Backoffer, regionID uint64) (*Region, e
 		if leader != nil {
 			region.SwitchPeer(leader.GetStoreId())
 		}
+		c.skipDownStores(region)
 		return region, nil
 	}
 }
@@ -398,6 +504,78 @@ func (c *RegionCache) loadStoreAddr(bo *Backoffer, id uint64) (string, error) {
 	}
 }
 
+// skipDownStores switches the peer of a newly loaded Region away from the stores that are
+// being probed. If all the peers are on such stores, the Region is not changed.
+func (c *RegionCache) skipDownStores(r *Region) {
+	c.storeMu.RLock()
+	defer c.storeMu.RUnlock()
+	if len(c.storeMu.down) == 0 {
+		return
+	}
+	peer := r.peer
+	for {
+		storeID := r.peer.GetStoreId()
+		if _, ok := c.storeMu.down[storeID]; !ok {
+			return
+		}
+		if !r.OnRequestFail(storeID) {
+			r.peer, r.unreachableStores = peer, nil
+			return
+		}
+	}
+}
+
+// markStoreDown marks the store as down and probes it in background until it's back.
+func (c *RegionCache) markStoreDown(id uint64) {
+	c.storeMu.Lock()
+	if _, ok := c.storeMu.down[id]; ok {
+		c.storeMu.Unlock()
+		return
+	}
+	c.storeMu.down[id] = struct{}{}
+	c.storeMu.Unlock()
+	go c.probeStore(id)
+}
+
+// probeStore checks the store in PD periodically. When the store is up, its address is
+// reloaded, so the following requests don't need to wait for PD.
+func (c *RegionCache) probeStore(id uint64) {
+	result := "timeout"
+	defer func() {
+		c.storeMu.Lock()
+		delete(c.storeMu.down, id)
+		c.storeMu.Unlock()
+		regionCacheCounter.WithLabelValues("probe_store", result).Inc()
+	}()
+
+	deadline := time.Now().Add(storeProbeTimeout)
+	for time.Now().Before(deadline) {
+		time.Sleep(storeProbeInterval)
+		ctx, cancel := goctx.WithTimeout(goctx.Background(), storeProbeInterval)
+		store, err := c.pdClient.GetStore(ctx, id)
+		cancel()
+		if err != nil {
+			log.Debugf("regionCache: probe store %d failed, err: %v", id, err)
+			continue
+		}
+		if store == nil || store.GetState() == metapb.StoreState_Tombstone {
+			result = "removed"
+			c.ClearStoreByID(id)
+			return
+		}
+		if store.GetState() == metapb.StoreState_Up {
+			result = "up"
+			c.storeMu.Lock()
+			c.storeMu.stores[id] = &Store{
+				ID:   id,
+				Addr: store.GetAddress(),
+			}
+			c.storeMu.Unlock()
+			return
+		}
+	}
+}
+
 // OnRequestFail is used for clearing cache when a tikv server does not respond.
 func (c *RegionCache) OnRequestFail(ctx *RPCContext, err error) {
 	// Switch region's leader peer to next one.
@@ -415,6 +593,7 @@ func (c *RegionCache) OnRequestFail(ctx *RPCContext, err error) {
 	c.storeMu.Lock()
 	delete(c.storeMu.stores, storeID)
 	c.storeMu.Unlock()
+	c.markStoreDown(storeID)
 
 	log.Infof("drop regions of store %d from cache due to request fail, err: %v", storeID, err)
 
@@ -428,12 +607,10 @@ func (c *RegionCache) OnRequestFail(ctx *RPCContext, err error) {
 }
 
 // OnRegionStale removes the old region and inserts new regions into the cache.
+// The cached regions overlapped with the new regions are dropped together, the
+// reports of the same stale region from concurrent requests are only applied once.
 func (c *RegionCache) OnRegionStale(ctx *RPCContext, newRegions []*metapb.Region) error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	c.dropRegionFromCache(ctx.Region)
-
+	regions := make([]*Region, 0, len(newRegions))
 	for _, meta := range newRegions {
 		if _, ok := c.pdClient.(*codecPDClient); ok {
 			if err := decodeRegionMetaKey(meta); err != nil {
@@ -445,6 +622,14 @@ func (c *RegionCache) OnRegionStale(ctx *RPCContext, newRegions []*metapb.Region
 			peer: meta.Peers[0],
 		}
 		region.SwitchPeer(ctx.KVCtx.GetPeer().GetStoreId())
+		regions = append(regions, region)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.dropRegionFromCache(ctx.Region)
+	for _, region := range regions {
 		c.insertRegionToCache(region)
 	}
 	return nil
@@ -494,6 +679,7 @@ type Region struct {
 	meta              *metapb.Region
 	peer              *metapb.Peer
 	unreachableStores []uint64
+	loadTime          time.Time
 }
 
 // GetID returns id.
@@ -318,3 +318,95 @@ func (s *testRegionCacheSuite) TestListRegionIDsInCache(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(regionIDs, DeepEquals, []uint64{s.region1, region2})
 }
+
+func (s *testRegionCacheSuite) TestRefreshExpiredRegion(c *C) {
+	defer func(ttl time.Duration) { RegionCacheTTL = ttl }(RegionCacheTTL)
+	RegionCacheTTL = 10 * time.Millisecond
+
+	r := s.getRegion(c, []byte("x"))
+	// split to ['' - 'm' - 'z']
+	region2 := s.cluster.AllocID()
+	newPeers := s.cluster.AllocIDs(2)
+	s.cluster.Split(s.region1, region2, []byte("m"), newPeers, newPeers[0])
+	time.Sleep(20 * time.Millisecond)
+
+	// The expired region is still used, and it's refreshed in background.
+	loc, err := s.cache.LocateKey(s.bo, []byte("x"))
+	c.Assert(err, IsNil)
+	c.Assert(loc.Region, Equals, r.VerID())
+	for i := 0; i < 100; i++ {
+		s.cache.mu.RLock()
+		_, ok := s.cache.mu.regions[r.VerID()]
+		s.cache.mu.RUnlock()
+		if !ok {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	s.checkCache(c, 1)
+	c.Assert(s.cache.getRegionFromCache([]byte("x")), IsNil)
+	r = s.cache.getRegionFromCache([]byte("a"))
+	c.Assert(r.GetID(), Equals, s.region1)
+	c.Assert(r.EndKey(), BytesEquals, []byte("m"))
+
+	loc, err = s.cache.LocateKey(s.bo, []byte("x"))
+	c.Assert(err, IsNil)
+	c.Assert(loc.Region.id, Equals, region2)
+	s.checkCache(c, 2)
+}
+
+func (s *testRegionCacheSuite) TestDropOverlappedRegions(c *C) {
+	// key range: ['' - 'm' - 'z']
+	region2 := s.cluster.AllocID()
+	newPeers := s.cluster.AllocIDs(2)
+	s.cluster.Split(s.region1, region2, []byte("m"), newPeers, newPeers[0])
+	s.getRegion(c, []byte("a"))
+	s.getRegion(c, []byte("x"))
+	s.checkCache(c, 2)
+
+	// merge to single region, both the cached regions are dropped at the same time.
+	s.cluster.Merge(s.region1, region2)
+	r, err := s.cache.loadRegion(s.bo, []byte("a"))
+	c.Assert(err, IsNil)
+	s.cache.mu.Lock()
+	s.cache.insertRegionToCache(r)
+	s.cache.mu.Unlock()
+	s.checkCache(c, 1)
+	c.Assert(s.cache.getRegionFromCache([]byte("x")), Equals, r)
+}
+
+func (s *testRegionCacheSuite) TestProbeStore(c *C) {
+	defer func(interval time.Duration) { storeProbeInterval = interval }(storeProbeInterval)
+	storeProbeInterval = 10 * time.Millisecond
+
+	region := s.getRegion(c, []byte("a"))
+	ctx, err := s.cache.GetRPCContext(s.bo, region.VerID())
+	c.Assert(err, IsNil)
+	s.cluster.StopStore(s.store1)
+	s.cache.OnRequestFail(ctx, errors.New("test error"))
+
+	// The newly loaded region avoids the failed store.
+	s.cache.DropRegion(region.VerID())
+	region = s.getRegion(c, []byte("a"))
+	c.Assert(region.peer.GetStoreId(), Equals, s.store2)
+	c.Assert(region.unreachableStores, DeepEquals, []uint64{s.store1})
+
+	s.cluster.StartStore(s.store1)
+	for i := 0; i < 100; i++ {
+		s.cache.storeMu.RLock()
+		n := len(s.cache.storeMu.down)
+		s.cache.storeMu.RUnlock()
+		if n == 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	s.cache.storeMu.RLock()
+	c.Assert(s.cache.storeMu.down, HasLen, 0)
+	c.Assert(s.cache.storeMu.stores[s.store1].Addr, Equals, s.storeAddr(s.store1))
+	s.cache.storeMu.RUnlock()
+
+	s.cache.DropRegion(region.VerID())
+	region = s.getRegion(c, []byte("a"))
+	c.Assert(region.peer.GetStoreId(), Equals, s.store1)
+}
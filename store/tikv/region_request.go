@@ -14,6 +14,7 @@
 package tikv
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/juju/errors"
@@ -23,6 +24,7 @@ import (
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/store/tikv/tikvrpc"
 	"github.com/pingcap/tidb/util"
+	"github.com/pingcap/tidb/util/tracing"
 	goctx "golang.org/x/net/context"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -107,6 +109,17 @@ func (s *RegionRequestSender) sendReqToRegion(bo *Backoffer, ctx *RPCContext, re
 	if e := tikvrpc.SetContext(req, ctx.KVCtx); e != nil {
 		return nil, false, errors.Trace(e)
 	}
+	storeID := ctx.GetStoreID()
+	if !s.regionCache.breakers.allow(storeID) {
+		if e := s.onSendFail(bo, ctx, errors.Trace(errStoreBreakerOpen)); e != nil {
+			return nil, false, errors.Trace(e)
+		}
+		return nil, true, nil
+	}
+	if parent := tracing.SpanFromContext(bo.ctx); parent != nil {
+		span := parent.StartChild(fmt.Sprintf("rpc.%s region:%d store:%s", req.Type, ctx.Region.id, ctx.Addr))
+		defer span.Finish()
+	}
 	context, cancel := util.WithTimeout(bo.ctx, timeout)
 	defer cancel()
 	resp, err = s.client.SendReq(context, ctx.Addr, req)
@@ -116,6 +129,7 @@ func (s *RegionRequestSender) sendReqToRegion(bo *Backoffer, ctx *RPCContext, re
 		}
 		return nil, true, nil
 	}
+	s.regionCache.breakers.onSuccess(storeID)
 	return
 }
 
@@ -125,6 +139,9 @@ func (s *RegionRequestSender) onSendFail(bo *Backoffer, ctx *RPCContext, err err
 		return errors.Trace(err)
 	}
 
+	if errors.Cause(err) != errStoreBreakerOpen {
+		s.regionCache.breakers.onFailure(ctx.GetStoreID())
+	}
 	s.regionCache.OnRequestFail(ctx, err)
 
 	// Retry on request failure when it's not canceled.
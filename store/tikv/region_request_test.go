@@ -123,6 +123,57 @@ func (s *testRegionRequestSuite) TestOnSendFailedWithCancelled(c *C) {
 	c.Assert(resp.RawPut, NotNil)
 }
 
+type countClient struct {
+	Client
+	count int
+}
+
+func (c *countClient) SendReq(ctx goctx.Context, addr string, req *tikvrpc.Request) (*tikvrpc.Response, error) {
+	c.count++
+	return c.Client.SendReq(ctx, addr, req)
+}
+
+func (s *testRegionRequestSuite) TestStoreBreaker(c *C) {
+	defer func(threshold int, duration time.Duration) {
+		StoreBreakerThreshold, StoreBreakerDuration = threshold, duration
+	}(StoreBreakerThreshold, StoreBreakerDuration)
+	StoreBreakerThreshold, StoreBreakerDuration = 2, 500*time.Millisecond
+
+	client := &countClient{Client: mocktikv.NewRPCClient(s.cluster, s.mvccStore)}
+	sender := NewRegionRequestSender(s.cache, client, kvrpcpb.IsolationLevel_SI)
+	req := &tikvrpc.Request{
+		Type: tikvrpc.CmdRawPut,
+		RawPut: &kvrpcpb.RawPutRequest{
+			Key:   []byte("key"),
+			Value: []byte("value"),
+		},
+	}
+	send := func() error {
+		region, err := s.cache.LocateRegionByID(s.bo, s.region)
+		c.Assert(err, IsNil)
+		c.Assert(region, NotNil)
+		_, err = sender.SendReq(s.bo, req, region.Region, time.Second)
+		return err
+	}
+
+	s.cluster.StopStore(s.store)
+	for i := 0; i < 2; i++ {
+		c.Assert(send(), NotNil)
+	}
+	c.Assert(client.count, Equals, 2)
+	// The breaker is open, requests are not sent.
+	c.Assert(send(), NotNil)
+	c.Assert(client.count, Equals, 2)
+
+	s.cluster.StartStore(s.store)
+	time.Sleep(StoreBreakerDuration)
+	// The probe request succeeds and closes the breaker.
+	c.Assert(send(), IsNil)
+	c.Assert(client.count, Equals, 3)
+	c.Assert(send(), IsNil)
+	c.Assert(client.count, Equals, 4)
+}
+
 func (s *testRegionRequestSuite) TestNoReloadRegionWhenCtxCanceled(c *C) {
 	req := &tikvrpc.Request{
 		Type: tikvrpc.CmdRawPut,
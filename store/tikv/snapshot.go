@@ -167,6 +167,7 @@ func (s *tikvSnapshot) batchGetSingleRegion(bo *Backoffer, batch batchKeys, coll
 			lockedKeys = append(lockedKeys, lock.Key)
 			locks = append(locks, lock)
 		}
+		s.store.hotRegions.record(batch.region, pending, false)
 		if len(lockedKeys) > 0 {
 			ok, err := s.store.lockResolver.ResolveLocks(bo, locks)
 			if err != nil {
@@ -250,6 +251,7 @@ func (s *tikvSnapshot) get(bo *Backoffer, k kv.Key) ([]byte, error) {
 			}
 			continue
 		}
+		s.store.hotRegions.record(loc.Region, [][]byte{k}, false)
 		return val, nil
 	}
 }
@@ -97,3 +97,26 @@ func (s *testSplitSuite) TestStaleEpoch(c *C) {
 	_, err = txn.Get([]byte("c"))
 	c.Assert(err, IsNil)
 }
+
+func (s *testSplitSuite) TestSplitOnRequest(c *C) {
+	txn := s.begin(c)
+	for _, k := range []string{"a", "b", "c"} {
+		c.Assert(txn.Set([]byte(k), []byte(k)), IsNil)
+	}
+	c.Assert(txn.Commit(), IsNil)
+	loc, err := s.store.regionCache.LocateKey(s.bo, []byte("a"))
+	c.Assert(err, IsNil)
+
+	// The region is split when the request is received, the cached region becomes stale.
+	s.cluster.SplitOnRequest([]byte("b"))
+	txn = s.begin(c)
+	snapshot := newTiKVSnapshot(s.store, kv.Version{Ver: txn.StartTS()})
+	m, err := snapshot.BatchGet([]kv.Key{kv.Key("a"), kv.Key("b"), kv.Key("c")})
+	c.Assert(err, IsNil)
+	c.Assert(m, HasLen, 3)
+	region, _ := s.cluster.GetRegionByKey(mocktikv.NewMvccKey([]byte("b")))
+	c.Assert(region.GetId(), Not(Equals), loc.Region.id)
+	newLoc, err := s.store.regionCache.LocateKey(s.bo, []byte("a"))
+	c.Assert(err, IsNil)
+	c.Assert(newLoc.EndKey, BytesEquals, []byte("b"))
+}
@@ -0,0 +1,106 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tikv
+
+import (
+	"sync"
+	"time"
+
+	"github.com/juju/errors"
+)
+
+// StoreBreakerThreshold is the number of consecutive request failures to open the circuit breaker of a store.
+// When the breaker is open, the requests to the store fail fast without being sent, so they can try the
+// other peers without waiting for the timeout. Set it to 0 to disable the circuit breaker.
+var StoreBreakerThreshold = 0
+
+// StoreBreakerDuration is how long an open circuit breaker rejects the requests, after that a single request
+// is let through to probe if the store is recovered.
+var StoreBreakerDuration = 3 * time.Second
+
+var errStoreBreakerOpen = errors.New("store circuit breaker is open")
+
+// storeBreakers holds the circuit breakers of the stores.
+type storeBreakers struct {
+	mu     sync.Mutex
+	stores map[uint64]*storeBreaker
+}
+
+type storeBreaker struct {
+	failures int
+	// openUntil is the time to let the next probe request through when the breaker is open.
+	openUntil time.Time
+}
+
+func newStoreBreakers() *storeBreakers {
+	return &storeBreakers{stores: make(map[uint64]*storeBreaker)}
+}
+
+// allow checks if a request can be sent to the store.
+func (b *storeBreakers) allow(storeID uint64) bool {
+	if StoreBreakerThreshold <= 0 {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	br, ok := b.stores[storeID]
+	if !ok || br.failures < StoreBreakerThreshold {
+		return true
+	}
+	now := time.Now()
+	if now.Before(br.openUntil) {
+		storeBreakerCounter.WithLabelValues("reject").Inc()
+		return false
+	}
+	// Let a probe request through, the others keep rejected until it finishes.
+	br.openUntil = now.Add(StoreBreakerDuration)
+	storeBreakerCounter.WithLabelValues("probe").Inc()
+	return true
+}
+
+// onSuccess closes the breaker of the store.
+func (b *storeBreakers) onSuccess(storeID uint64) {
+	if StoreBreakerThreshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if br, ok := b.stores[storeID]; ok {
+		if br.failures >= StoreBreakerThreshold {
+			storeBreakerCounter.WithLabelValues("close").Inc()
+		}
+		delete(b.stores, storeID)
+	}
+}
+
+// onFailure records a failure of the store, the breaker opens after too many consecutive failures.
+func (b *storeBreakers) onFailure(storeID uint64) {
+	if StoreBreakerThreshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	br, ok := b.stores[storeID]
+	if !ok {
+		br = &storeBreaker{}
+		b.stores[storeID] = br
+	}
+	br.failures++
+	if br.failures >= StoreBreakerThreshold {
+		if br.failures == StoreBreakerThreshold {
+			storeBreakerCounter.WithLabelValues("open").Inc()
+		}
+		br.openUntil = time.Now().Add(StoreBreakerDuration)
+	}
+}
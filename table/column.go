@@ -102,6 +102,17 @@ func FindOnUpdateCols(cols []*Column) []*Column {
 	return rcols
 }
 
+// VisibleCols returns the columns which aren't the hidden columns of the expression indices.
+func VisibleCols(cols []*Column) []*Column {
+	visibleCols := make([]*Column, 0, len(cols))
+	for _, col := range cols {
+		if !col.Hidden {
+			visibleCols = append(visibleCols, col)
+		}
+	}
+	return visibleCols
+}
+
 // truncateTrailingSpaces trancates trailing spaces for CHAR[(M)] column.
 // fix: https://github.com/pingcap/tidb/issues/3660
 func truncateTrailingSpaces(v *types.Datum) {
@@ -108,8 +108,9 @@ type Table interface {
 	// RemoveRecord removes a row in the table.
 	RemoveRecord(ctx context.Context, h int64, r []types.Datum) error
 
-	// AllocAutoID allocates an auto_increment ID for a new row.
-	AllocAutoID() (int64, error)
+	// AllocAutoID allocates an auto_increment ID for a new row, the ID follows auto_increment_increment and
+	// auto_increment_offset of the session.
+	AllocAutoID(ctx context.Context) (int64, error)
 
 	// Allocator returns Allocator.
 	Allocator() autoid.Allocator
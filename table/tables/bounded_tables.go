@@ -273,7 +273,7 @@ func (t *BoundedTable) RemoveRecord(ctx context.Context, h int64, r []types.Datu
 }
 
 // AllocAutoID implements table.Table AllocAutoID interface.
-func (t *BoundedTable) AllocAutoID() (int64, error) {
+func (t *BoundedTable) AllocAutoID(ctx context.Context) (int64, error) {
 	recordID, err := t.alloc.Alloc(t.ID)
 	if err != nil {
 		return invalidRecordID, errors.Trace(err)
@@ -18,8 +18,12 @@ import (
 
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/parser"
+	"github.com/pingcap/tidb/table"
+	"github.com/pingcap/tidb/util/types"
 )
 
 // getDefaultCharsetAndCollate is copyed from ddl/ddl_api.go.
@@ -80,3 +84,31 @@ func simpleResolveName(node ast.ExprNode, tblInfo *model.TableInfo) (ast.ExprNod
 	}
 	return node, nil
 }
+
+// FillHiddenColumns computes the values of the hidden columns of the expression indices in the row,
+// the row is indexed by the column offsets.
+func FillHiddenColumns(ctx context.Context, t table.Table, row []types.Datum) error {
+	for _, col := range t.Cols() {
+		if !col.Hidden || col.Offset >= len(row) {
+			continue
+		}
+		// The expression is parsed again, because the ast node of the column is shared by the sessions.
+		node, err := parseExpression(col.GeneratedExprString)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		expr, err := expression.RewriteAstExprOnTable(node, t.Meta(), ctx)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		val, err := expr.Eval(row)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		row[col.Offset], err = table.CastValue(ctx, val, col.ToInfo())
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
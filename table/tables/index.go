@@ -97,6 +97,8 @@ type index struct {
 	tblInfo *model.TableInfo
 	idxInfo *model.IndexInfo
 	prefix  kv.Key
+	// desc indicates whether the columns are descending, it's nil if all the columns are ascending.
+	desc []bool
 }
 
 // NewIndex builds a new Index object.
@@ -105,6 +107,7 @@ func NewIndex(tableInfo *model.TableInfo, indexInfo *model.IndexInfo) table.Inde
 		tblInfo: tableInfo,
 		idxInfo: indexInfo,
 		prefix:  kv.Key(tablecodec.EncodeTableIndexPrefix(tableInfo.ID, indexInfo.ID)),
+		desc:    indexInfo.DescColumns(),
 	}
 	return index
 }
@@ -146,9 +149,9 @@ func (c *index) GenIndexKey(indexedValues []types.Datum, h int64) (key []byte, d
 
 	key = append(key, []byte(c.prefix)...)
 	if distinct {
-		key, err = codec.EncodeKey(key, indexedValues...)
+		key, err = codec.EncodeKeyWithDesc(key, c.desc, indexedValues...)
 	} else {
-		key, err = codec.EncodeKey(key, append(indexedValues, types.NewDatum(h))...)
+		key, err = codec.EncodeKeyWithDesc(key, c.desc, append(indexedValues, types.NewDatum(h))...)
 	}
 	if err != nil {
 		return nil, false, errors.Trace(err)
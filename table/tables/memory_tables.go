@@ -248,8 +248,9 @@ func (t *MemoryTable) RemoveRecord(ctx context.Context, h int64, r []types.Datum
 }
 
 // AllocAutoID implements table.Table AllocAutoID interface.
-func (t *MemoryTable) AllocAutoID() (int64, error) {
-	return t.alloc.Alloc(t.ID)
+func (t *MemoryTable) AllocAutoID(ctx context.Context) (int64, error) {
+	sessVars := ctx.GetSessionVars()
+	return t.alloc.AllocWithIncrement(t.ID, int64(sessVars.AutoIncrementIncrement), int64(sessVars.AutoIncrementOffset))
 }
 
 // Allocator implements table.Table Allocator interface.
@@ -50,6 +50,8 @@ type Table struct {
 	indexPrefix     kv.Key
 	alloc           autoid.Allocator
 	meta            *model.TableInfo
+	// hasHiddenColumns indicates the table has the hidden columns of the expression indices.
+	hasHiddenColumns bool
 }
 
 // MockTableFromMeta only serves for test.
@@ -118,6 +120,9 @@ func newTable(tableID int64, cols []*table.Column, alloc autoid.Allocator) *Tabl
 
 	t.publicColumns = t.Cols()
 	t.writableColumns = t.WritableCols()
+	for _, col := range cols {
+		t.hasHiddenColumns = t.hasHiddenColumns || col.Hidden
+	}
 	return t
 }
 
@@ -214,8 +219,12 @@ func (t *Table) UpdateRecord(ctx context.Context, h int64, oldData, newData []ty
 	txn := ctx.Txn()
 	bs := kv.NewBufferStore(txn)
 
+	err := t.fillHiddenColumnsForUpdate(ctx, oldData, newData, touched)
+	if err != nil {
+		return errors.Trace(err)
+	}
 	// rebuild index
-	err := t.rebuildIndices(bs, h, touched, oldData, newData)
+	err = t.rebuildIndices(bs, h, touched, oldData, newData)
 	if err != nil {
 		return errors.Trace(err)
 	}
@@ -277,6 +286,31 @@ func (t *Table) UpdateRecord(ctx context.Context, h int64, oldData, newData []ty
 	return nil
 }
 
+// fillHiddenColumnsForUpdate computes the hidden columns of the old and the new rows, a hidden column
+// is touched if its value is changed.
+func (t *Table) fillHiddenColumnsForUpdate(ctx context.Context, oldData, newData []types.Datum, touched []bool) error {
+	if !t.hasHiddenColumns {
+		return nil
+	}
+	for _, row := range [][]types.Datum{oldData, newData} {
+		if err := FillHiddenColumns(ctx, t, row); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	sc := ctx.GetSessionVars().StmtCtx
+	for _, col := range t.Cols() {
+		if !col.Hidden || col.Offset >= len(touched) {
+			continue
+		}
+		cmp, err := oldData[col.Offset].CompareDatum(sc, newData[col.Offset])
+		if err != nil {
+			return errors.Trace(err)
+		}
+		touched[col.Offset] = cmp != 0
+	}
+	return nil
+}
+
 func (t *Table) rebuildIndices(rm kv.RetrieverMutator, h int64, touched []bool, oldData []types.Datum, newData []types.Datum) error {
 	for _, idx := range t.DeletableIndices() {
 		for _, ic := range idx.Meta().Columns {
@@ -328,6 +362,12 @@ func (t *Table) AddRecord(ctx context.Context, r []types.Datum) (recordID int64,
 		}
 	}
 
+	if t.hasHiddenColumns {
+		if err = FillHiddenColumns(ctx, t, r); err != nil {
+			return 0, errors.Trace(err)
+		}
+	}
+
 	txn := ctx.Txn()
 	bs := kv.NewBufferStore(txn)
 
@@ -514,6 +554,11 @@ func (t *Table) RemoveRecord(ctx context.Context, h int64, r []types.Datum) erro
 	if err != nil {
 		return errors.Trace(err)
 	}
+	if t.hasHiddenColumns {
+		if err = FillHiddenColumns(ctx, t, r); err != nil {
+			return errors.Trace(err)
+		}
+	}
 	err = t.removeRowIndices(ctx, h, r)
 	if err != nil {
 		return errors.Trace(err)
@@ -707,8 +752,9 @@ func GetColDefaultValue(ctx context.Context, col *table.Column, defaultVals []ty
 }
 
 // AllocAutoID implements table.Table AllocAutoID interface.
-func (t *Table) AllocAutoID() (int64, error) {
-	return t.alloc.Alloc(t.ID)
+func (t *Table) AllocAutoID(ctx context.Context) (int64, error) {
+	sessVars := ctx.GetSessionVars()
+	return t.alloc.AllocWithIncrement(t.ID, int64(sessVars.AutoIncrementIncrement), int64(sessVars.AutoIncrementOffset))
 }
 
 // Allocator implements table.Table Allocator interface.
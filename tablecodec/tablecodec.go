@@ -16,6 +16,7 @@ package tablecodec
 import (
 	"bytes"
 	"math"
+	"sort"
 	"time"
 
 	"github.com/juju/errors"
@@ -229,7 +230,7 @@ func DecodeValues(data []byte, fts []*types.FieldType, loc *time.Location) ([]ty
 	}
 
 	for i := range values {
-		values[i], err = unflatten(values[i], fts[i], loc)
+		values[i], err = Unflatten(values[i], fts[i], loc)
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
@@ -243,7 +244,7 @@ func DecodeColumnValue(data []byte, ft *types.FieldType, loc *time.Location) (ty
 	if err != nil {
 		return types.Datum{}, errors.Trace(err)
 	}
-	colDatum, err := unflatten(d, ft, loc)
+	colDatum, err := Unflatten(d, ft, loc)
 	if err != nil {
 		return types.Datum{}, errors.Trace(err)
 	}
@@ -287,7 +288,7 @@ func DecodeRow(b []byte, cols map[int64]*types.FieldType, loc *time.Location) (m
 			if err != nil {
 				return nil, errors.Trace(err)
 			}
-			v, err = unflatten(v, ft, loc)
+			v, err = Unflatten(v, ft, loc)
 			if err != nil {
 				return nil, errors.Trace(err)
 			}
@@ -383,8 +384,8 @@ func CutRow(data []byte, cols map[int64]*types.FieldType) (map[int64][]byte, err
 	return row, nil
 }
 
-// unflatten converts a raw datum to a column datum.
-func unflatten(datum types.Datum, ft *types.FieldType, loc *time.Location) (types.Datum, error) {
+// Unflatten converts a raw datum to a column datum.
+func Unflatten(datum types.Datum, ft *types.FieldType, loc *time.Location) (types.Datum, error) {
 	if datum.IsNull() {
 		return datum, nil
 	}
@@ -550,6 +551,11 @@ func GetTableIndexKeyRange(tableID, indexID int64) (startKey, endKey []byte) {
 	return
 }
 
+// SortKeyRanges sorts the key ranges by the start keys.
+func SortKeyRanges(ranges []kv.KeyRange) {
+	sort.Sort(&keyRangeSorter{ranges: ranges})
+}
+
 type keyRangeSorter struct {
 	ranges []kv.KeyRange
 }
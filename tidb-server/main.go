@@ -16,10 +16,14 @@ package main
 import (
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"net"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"runtime"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
@@ -27,16 +31,21 @@ import (
 	"github.com/ngaut/log"
 	"github.com/ngaut/systimemon"
 	"github.com/pingcap/tidb"
+	"github.com/pingcap/tidb/cdc"
 	"github.com/pingcap/tidb/config"
 	"github.com/pingcap/tidb/ddl"
+	"github.com/pingcap/tidb/domain"
 	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/perfschema"
 	"github.com/pingcap/tidb/plan"
+	"github.com/pingcap/tidb/plugin"
 	"github.com/pingcap/tidb/privilege/privileges"
 	"github.com/pingcap/tidb/server"
 	"github.com/pingcap/tidb/sessionctx/binloginfo"
 	"github.com/pingcap/tidb/store/localstore/boltdb"
 	"github.com/pingcap/tidb/store/tikv"
+	"github.com/pingcap/tidb/util/audit"
 	"github.com/pingcap/tidb/util/printer"
 	"github.com/pingcap/tipb/go-binlog"
 	"github.com/prometheus/client_golang/prometheus"
@@ -52,6 +61,7 @@ var (
 	host                = flag.String("host", "0.0.0.0", "tidb server host")
 	port                = flag.String("P", "4000", "tidb server port")
 	statusPort          = flag.String("status", "10080", "tidb server status port")
+	advertiseAddr       = flag.String("advertise-address", "", "tidb server IP registered in the cluster for the other servers to access, the host or the host name is used if it's empty.")
 	ddlLease            = flag.String("lease", "10s", "schema lease duration, very dangerous to change only if you know what you do")
 	statsLease          = flag.String("statsLease", "3s", "stats lease duration, which inflences the time of analyze and stats load.")
 	socket              = flag.String("socket", "", "The socket file to use for connection.")
@@ -60,16 +70,47 @@ var (
 	reportStatus        = flagBoolean("report-status", true, "If enable status report HTTP service.")
 	logFile             = flag.String("log-file", "", "log file path")
 	joinCon             = flag.Int("join-concurrency", 5, "the number of goroutines that participate joining.")
+	backoffBudgets      = flag.String("backoff-budgets", "", "the max total backoff time(in ms) of the tikv client operations, in the format of \"get=20000,cop_next=20000\".")
+	backoffCaps         = flag.String("backoff-caps", "", "the max sleep time(in ms) of a single backoff of the tikv client, in the format of \"tikvRPC=2000,serverBusy=10000\".")
+	storeBreakerLimit   = flag.Int("store-breaker-threshold", 0, "the number of consecutive request failures to stop sending requests to a TiKV store for a while, set \"0\" to disable it.")
+	storeBreakerTime    = flag.String("store-breaker-duration", "3s", "how long to stop sending requests to a TiKV store after too many failures.")
+	copStoreCon         = flag.Int("cop-store-concurrency", 64, "the max number of concurrent coprocessor requests sent to a single TiKV store, set \"0\" to disable the limit.")
+	tikvConnCount       = flag.Int("tikv-conn-count", 16, "the number of gRPC connections to each TiKV store.")
+	grpcKeepAliveTime   = flag.String("grpc-keepalive-time", "10s", "the duration after which a gRPC connection pings the TiKV store if it sees no activity.")
+	grpcKeepAliveTO     = flag.String("grpc-keepalive-timeout", "3s", "how long to wait for the ping response before a gRPC connection is closed and reconnected.")
 	crossJoin           = flagBoolean("cross-join", true, "whether support cartesian product or not.")
 	metricsAddr         = flag.String("metrics-addr", "", "prometheus pushgateway address, leaves it empty will disable prometheus push.")
+	metricsPath         = flag.String("metrics-path", "/metrics", "HTTP path of the status server to expose the prometheus metrics.")
+	enablePprof         = flagBoolean("enable-pprof", true, "expose the pprof handlers under /debug/pprof/ of the status server.")
 	metricsInterval     = flag.Int("metrics-interval", 15, "prometheus client push interval in second, set \"0\" to disable prometheus push.")
 	binlogSocket        = flag.String("binlog-socket", "", "socket file to write binlog")
+	binlogFile          = flag.String("binlog-file", "", "local file to append binlog to, it's ignored if binlog-socket is set")
+	pluginDir           = flag.String("plugin-dir", "/data/deploy/plugin", "the directory of the Go plugin files.")
+	pluginLoad          = flag.String("plugin-load", "", "the names of the plugins to load from the plugin directory, in the format of \"audit_x,auth_y\", the file of a plugin is <plugin-dir>/<name>.so.")
+	auditLog            = flag.String("audit-log", "", "local file to append the audit events of connections, statements and privilege changes to in JSON lines, leaves it empty will disable the audit log.")
+	cdcFile             = flag.String("cdc-file", "", "local file to append the row change events of the committed transactions to in JSON lines, leaves it empty will disable the CDC sink.")
+	cdcTopic            = flag.String("cdc-topic", "tidb_cdc", "the topic of the row change events, the events of a table are keyed by \"<db>.<table>\".")
 	runDDL              = flagBoolean("run-ddl", true, "run ddl worker on this tidb-server")
 	retryLimit          = flag.Int("retry-limit", 10, "the maximum number of retries when commit a transaction")
 	skipGrantTable      = flagBoolean("skip-grant-table", false, "This option causes the server to start without using the privilege system at all.")
 	slowThreshold       = flag.Int("slow-threshold", 300, "Queries with execution time greater than this value will be logged. (Milliseconds)")
 	queryLogMaxlen      = flag.Int("query-log-max-len", 2048, "Maximum query length recorded in log")
 	tcpKeepAlive        = flagBoolean("tcp-keep-alive", false, "set keep alive option for tcp connection.")
+	drainTimeout        = flag.Int("drain-timeout", 30, "the max seconds to wait for the connections to finish their transactions on shutdown.")
+	enableTableLock     = flagBoolean("enable-table-lock", false, "enforce the table locks taken by LOCK TABLES in the cluster, LOCK TABLES does nothing if it's disabled.")
+	metadataLockWait    = flag.Int("metadata-lock-wait", 0, "the max seconds a DDL waits for the transactions using the old schema, 0 disables the metadata lock.")
+	proxyNetworks       = flag.String("proxy-protocol-networks", "", "the comma separated networks of the proxies which send the PROXY protocol header like \"192.168.1.0/24,10.0.0.1\", \"*\" allows all, empty disables the PROXY protocol.")
+	resourceGroups      = flag.String("resource-groups", "", "the resource groups and the max concurrent statements of each group on the server, in the format of \"etl=4,report=8\", the users are put into a group by CREATE/ALTER USER ... RESOURCE GROUP.")
+	connectionWait      = flag.Int("connection-wait", 0, "the max seconds a new connection waits for a free slot when the connection limits are reached, 0 rejects it at once.")
+	resultCacheSize     = flag.Int64("result-cache-size", 0, "the max bytes of the query results cached on the server for the sessions with tidb_enable_result_cache, 0 disables the result cache.")
+	resultCacheTTL      = flag.Int("result-cache-ttl", 60, "the max seconds a cached query result is used for, the writes on the other servers are seen after it.")
+	dumpDir             = flag.String("dump-dir", "", "the directory the files of ADMIN DUMP DATABASE are written under, the dump path of the statement is relative to it, empty disables the dump.")
+	tableCache          = flag.Int("table-cache", 0, "the max number of the tables whose schema is kept in memory, the other tables are loaded on demand, 0 keeps all the tables in memory.")
+	initInsecure        = flagBoolean("initialize-insecure", false, "initialize a new store with the root user of any host without a password, it's the default way.")
+	initSecure          = flagBoolean("initialize-secure", false, "initialize a new store with the root user of the initialize-root-host only, its password is read from initialize-root-password-file or generated and written to the log.")
+	initRootHost        = flag.String("initialize-root-host", "127.0.0.1", "the host of the root user created by initialize-secure.")
+	initRootPwdFile     = flag.String("initialize-root-password-file", "", "the file of the root password used by initialize-secure, a random password is generated if it's empty.")
+	schemaCache         = flag.String("schema-cache", "", "local file to cache the information schema in, the server loads the cached schema and the later schema changes on startup instead of all the tables, leaves it empty will disable the cache.")
 	timeJumpBackCounter = prometheus.NewCounter(
 		prometheus.CounterOpts{
 			Namespace: "tidb",
@@ -101,12 +142,18 @@ func main() {
 	statsLeaseDuration := parseLease(*statsLease)
 	tidb.SetStatsLease(statsLeaseDuration)
 	ddl.RunWorker = *runDDL
+	domain.SchemaCachePath = *schemaCache
+	domain.TableCacheCapacity = *tableCache
 	tidb.SetCommitRetryLimit(*retryLimit)
+	setInitializeOptions()
 
 	cfg := config.GetGlobalConfig()
 	cfg.Addr = fmt.Sprintf("%s:%s", *host, *port)
+	cfg.AdvertiseAddr = *advertiseAddr
 	cfg.LogLevel = *logLevel
 	cfg.StatusAddr = fmt.Sprintf(":%s", *statusPort)
+	cfg.MetricsPath = *metricsPath
+	cfg.EnablePprof = *enablePprof
 	cfg.Socket = *socket
 	cfg.ReportStatus = *reportStatus
 	cfg.Store = *store
@@ -114,6 +161,17 @@ func main() {
 	cfg.SlowThreshold = *slowThreshold
 	cfg.QueryLogMaxlen = *queryLogMaxlen
 	cfg.TCPKeepAlive = *tcpKeepAlive
+	cfg.DrainTimeout = *drainTimeout
+	cfg.EnableTableLock = *enableTableLock
+	cfg.MetadataLockWait = *metadataLockWait
+	cfg.ConnectionWait = *connectionWait
+	cfg.ProxyProtocolNetworks = *proxyNetworks
+	cfg.ResourceGroups = *resourceGroups
+	cfg.ResultCacheSize = *resultCacheSize
+	cfg.ResultCacheTTL = *resultCacheTTL
+	cfg.DumpDir = *dumpDir
+	cfg.CDCFile = *cdcFile
+	cfg.CDCTopic = *cdcTopic
 
 	// set log options
 	if len(*logFile) > 0 {
@@ -129,6 +187,13 @@ func main() {
 		plan.JoinConcurrency = *joinCon
 	}
 	plan.AllowCartesianProduct = *crossJoin
+	tikv.CopStoreConcurrency = *copStoreCon
+	if *tikvConnCount > 0 {
+		tikv.MaxConnectionCount = *tikvConnCount
+	}
+	tikv.GrpcKeepAliveTime = parseLease(*grpcKeepAliveTime)
+	tikv.GrpcKeepAliveTimeout = parseLease(*grpcKeepAliveTO)
+	setBackoffConfig()
 	// Call this before setting log level to make sure that TiDB info could be printed.
 	printer.PrintTiDBInfo()
 	log.SetLevelByString(cfg.LogLevel)
@@ -142,13 +207,22 @@ func main() {
 	privileges.SkipWithGrant = *skipGrantTable
 	if *binlogSocket != "" {
 		createBinlogClient()
+	} else if *binlogFile != "" {
+		createBinlogFileClient()
+	}
+	if *auditLog != "" {
+		createAuditFileSink()
 	}
 
 	// Bootstrap a session to load information schema.
-	domain, err := tidb.BootstrapSession(store)
+	dom, err := tidb.BootstrapSession(store)
 	if err != nil {
 		log.Fatal(errors.ErrorStack(err))
 	}
+	if cfg.CDCFile != "" {
+		createCDCSink(dom, cfg)
+	}
+	loadPlugins()
 
 	var driver server.IDriver
 	driver = server.NewTiDBDriver(store)
@@ -157,6 +231,9 @@ func main() {
 	if err != nil {
 		log.Fatal(errors.ErrorStack(err))
 	}
+	// The server ID may be changed if the server info is registered again after its lease is lost.
+	dom.SetServerIDHook(svr.SetServerID)
+	svr.SetServerID(registerServerInfo(dom, cfg))
 
 	sc := make(chan os.Signal, 1)
 	signal.Notify(sc,
@@ -165,10 +242,12 @@ func main() {
 		syscall.SIGTERM,
 		syscall.SIGQUIT)
 
+	drained := make(chan struct{})
 	go func() {
 		sig := <-sc
 		log.Infof("Got signal [%d] to exit.", sig)
-		svr.Close()
+		svr.GracefulClose()
+		close(drained)
 	}()
 
 	prometheus.MustRegister(timeJumpBackCounter)
@@ -180,11 +259,50 @@ func main() {
 
 	if err := svr.Run(); err != nil {
 		log.Error(err)
+	} else {
+		// The server is closed by the signal, wait for the connections to be drained.
+		<-drained
 	}
-	domain.Close()
+	plugin.Shutdown()
+	// Closing the domain releases the DDL owner lease, so another server can be the owner at once.
+	dom.Close()
 	os.Exit(0)
 }
 
+// registerServerInfo registers the info of the server, so the other servers in the cluster can access it,
+// it returns the server ID allocated in the cluster.
+func registerServerInfo(dom *domain.Domain, cfg *config.Config) uint64 {
+	ip := cfg.AdvertiseAddr
+	if len(ip) == 0 {
+		ip = *host
+	}
+	if len(ip) == 0 || ip == "0.0.0.0" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			log.Fatal(errors.ErrorStack(err))
+		}
+		ip = hostname
+	}
+	p, err := strconv.ParseUint(*port, 10, 32)
+	if err != nil {
+		log.Fatal(errors.ErrorStack(err))
+	}
+	sp, err := strconv.ParseUint(*statusPort, 10, 32)
+	if err != nil {
+		log.Fatal(errors.ErrorStack(err))
+	}
+	info := &domain.ServerInfo{
+		IP:         ip,
+		Port:       uint(p),
+		StatusPort: uint(sp),
+		Version:    mysql.ServerVersion,
+	}
+	if err = dom.RegisterServerInfo(info); err != nil {
+		log.Fatal(errors.ErrorStack(err))
+	}
+	return info.ServerID
+}
+
 func createStore() kv.Storage {
 	fullPath := fmt.Sprintf("%s://%s", *store, *storePath)
 	store, err := tidb.NewStore(fullPath)
@@ -206,6 +324,50 @@ func createBinlogClient() {
 	log.Infof("created binlog client at %s", *binlogSocket)
 }
 
+func createBinlogFileClient() {
+	client, err := binloginfo.NewFileClient(*binlogFile)
+	if err != nil {
+		log.Fatal(errors.ErrorStack(err))
+	}
+	binloginfo.SetPumpClient(client)
+	log.Infof("created binlog file client at %s", *binlogFile)
+}
+
+// createCDCSink publishes the row changes of the sessions created after it, the binlogs are still
+// written to the binlog client if it's created.
+func createCDCSink(dom *domain.Domain, cfg *config.Config) {
+	producer, err := cdc.NewFileProducer(cfg.CDCFile)
+	if err != nil {
+		log.Fatal(errors.ErrorStack(err))
+	}
+	binloginfo.SetPumpClient(cdc.NewSink(binloginfo.GetPumpClient(), producer, cfg.CDCTopic, dom.InfoSchema))
+	log.Infof("created CDC sink at %s with topic %s", cfg.CDCFile, cfg.CDCTopic)
+}
+
+// loadPlugins loads the plugins in the plugin-load flag and initializes all the plugins.
+func loadPlugins() {
+	if *pluginLoad != "" {
+		for _, name := range strings.Split(*pluginLoad, ",") {
+			path := filepath.Join(*pluginDir, strings.TrimSpace(name)+".so")
+			if err := plugin.Load(path); err != nil {
+				log.Fatal(errors.ErrorStack(err))
+			}
+		}
+	}
+	if err := plugin.Init(); err != nil {
+		log.Fatal(errors.ErrorStack(err))
+	}
+}
+
+func createAuditFileSink() {
+	sink, err := audit.NewFileSink(*auditLog)
+	if err != nil {
+		log.Fatal(errors.ErrorStack(err))
+	}
+	audit.Register(sink)
+	log.Infof("created audit file sink at %s", *auditLog)
+}
+
 // Prometheus push.
 const zeroDuration = time.Duration(0)
 
@@ -257,6 +419,63 @@ func parseLease(lease string) time.Duration {
 	return dur
 }
 
+func setBackoffConfig() {
+	budgets, err := parseIntMap(*backoffBudgets)
+	if err != nil {
+		log.Fatalf("invalid backoff budgets %s: %v", *backoffBudgets, err)
+	}
+	caps, err := parseIntMap(*backoffCaps)
+	if err != nil {
+		log.Fatalf("invalid backoff caps %s: %v", *backoffCaps, err)
+	}
+	if err = tikv.SetBackoffConfig(budgets, caps); err != nil {
+		log.Fatal(errors.ErrorStack(err))
+	}
+	tikv.StoreBreakerThreshold = *storeBreakerLimit
+	tikv.StoreBreakerDuration = parseLease(*storeBreakerTime)
+}
+
+// parseIntMap parses a string such as "a=1,b=2" to a map.
+func parseIntMap(s string) (map[string]int, error) {
+	m := make(map[string]int)
+	if s == "" {
+		return m, nil
+	}
+	for _, item := range strings.Split(s, ",") {
+		pair := strings.SplitN(strings.TrimSpace(item), "=", 2)
+		if len(pair) != 2 {
+			return nil, errors.Errorf("invalid item %s", item)
+		}
+		val, err := strconv.Atoi(strings.TrimSpace(pair[1]))
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		m[strings.TrimSpace(pair[0])] = val
+	}
+	return m, nil
+}
+
+func setInitializeOptions() {
+	if *initInsecure && *initSecure {
+		log.Fatal("initialize-insecure and initialize-secure can't be both set.")
+	}
+	if !*initSecure {
+		return
+	}
+	tidb.BootstrapMode = tidb.InitializeSecure
+	tidb.BootstrapRootHost = *initRootHost
+	if *initRootPwdFile != "" {
+		b, err := ioutil.ReadFile(*initRootPwdFile)
+		if err != nil {
+			log.Fatal(errors.ErrorStack(err))
+		}
+		tidb.BootstrapRootPassword = strings.TrimSpace(string(b))
+		if tidb.BootstrapRootPassword == "" {
+			log.Fatalf("the root password file %s is empty.", *initRootPwdFile)
+		}
+	}
+}
+
 func hasRootPrivilege() bool {
 	return os.Geteuid() == 0
 }
@@ -170,6 +170,9 @@ func runStmt(ctx context.Context, s ast.Statement) (ast.RecordSet, error) {
 			err = se.CommitTxn()
 		}
 	}
+	if err != nil {
+		se.sessionVars.StmtCtx.AppendError(err)
+	}
 	return rs, errors.Trace(err)
 }
 
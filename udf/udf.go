@@ -0,0 +1,312 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package udf manages the user-defined functions written in a scripting language.
+//
+// The functions are created by CREATE FUNCTION and stored in the mysql.func table, every server
+// caches the compiled functions in a Handle which is reloaded when a function is created or dropped.
+// Only LUA is supported now, the scripts are run by the interpreter in util/lua.
+package udf
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/juju/errors"
+	"github.com/ngaut/log"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/util/lua"
+	"github.com/pingcap/tidb/util/sqlexec"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// LanguageLua is the language of the functions written in Lua.
+const LanguageLua = "LUA"
+
+// The types of the parameters and the return values, the SQL values are converted to the Lua values
+// by the types. The DECIMAL values are converted to numbers, so they may lose precision.
+const (
+	TypeInt     = "INT"
+	TypeReal    = "REAL"
+	TypeDecimal = "DECIMAL"
+	TypeString  = "STRING"
+)
+
+// maxCallDepth is the max depth of the nested function calls in a script.
+const maxCallDepth = 200
+
+// TypeOf returns the type of the parameters and the return values for the field type, it returns ""
+// if the field type isn't supported.
+func TypeOf(ft *types.FieldType) string {
+	switch ft.Tp {
+	case mysql.TypeTiny, mysql.TypeShort, mysql.TypeInt24, mysql.TypeLong, mysql.TypeLonglong, mysql.TypeYear, mysql.TypeBit:
+		return TypeInt
+	case mysql.TypeFloat, mysql.TypeDouble:
+		return TypeReal
+	case mysql.TypeNewDecimal:
+		return TypeDecimal
+	case mysql.TypeVarchar, mysql.TypeVarString, mysql.TypeString, mysql.TypeBlob, mysql.TypeTinyBlob,
+		mysql.TypeMediumBlob, mysql.TypeLongBlob:
+		return TypeString
+	}
+	return ""
+}
+
+// Param is a parameter of a function.
+type Param struct {
+	Name string
+	Type string
+}
+
+// Function is a user-defined function.
+type Function struct {
+	// Name is in lower case.
+	Name       string
+	Language   string
+	Params     []Param
+	ReturnType string
+	// Deterministic indicates the function always returns the same result for the same arguments,
+	// so the calls with constant arguments are folded by the planner.
+	Deterministic bool
+	Body          string
+
+	program *lua.Function
+}
+
+// Compile checks the definition of the function and compiles the body.
+func (f *Function) Compile() error {
+	if f.Language != LanguageLua {
+		return errors.Errorf("language %s isn't supported", f.Language)
+	}
+	if !validType(f.ReturnType) {
+		return errors.Errorf("return type %s isn't supported", f.ReturnType)
+	}
+	names := make([]string, 0, len(f.Params))
+	for i, p := range f.Params {
+		if !validType(p.Type) {
+			return errors.Errorf("type %s of parameter %s isn't supported", p.Type, p.Name)
+		}
+		if !validName(p.Name) {
+			return errors.Errorf("parameter name %s isn't a valid %s name", p.Name, f.Language)
+		}
+		for _, name := range names[:i] {
+			if name == p.Name {
+				return errors.Errorf("duplicate parameter %s", p.Name)
+			}
+		}
+		names = append(names, p.Name)
+	}
+	program, err := lua.Compile(names, f.Body)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	f.program = program
+	return nil
+}
+
+func validType(tp string) bool {
+	switch tp {
+	case TypeInt, TypeReal, TypeDecimal, TypeString:
+		return true
+	}
+	return false
+}
+
+// luaKeywords are the reserved words of Lua, they can't be the names of the parameters.
+var luaKeywords = map[string]bool{
+	"and": true, "break": true, "do": true, "else": true, "elseif": true, "end": true,
+	"false": true, "for": true, "function": true, "goto": true, "if": true, "in": true,
+	"local": true, "nil": true, "not": true, "or": true, "repeat": true, "return": true,
+	"then": true, "true": true, "until": true, "while": true,
+}
+
+func validName(name string) bool {
+	if name == "" || luaKeywords[name] || (name[0] >= '0' && name[0] <= '9') {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if c != '_' && !(c >= 'a' && c <= 'z') && !(c >= 'A' && c <= 'Z') && !(c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// Call calls the compiled function with the args, the result is NULL if the script returns nil.
+// The args are the values of the parameter types, the result is not converted to the return type.
+func (f *Function) Call(limits lua.Limits, args []types.Datum) (types.Datum, error) {
+	var d types.Datum
+	values := make([]lua.Value, len(args))
+	for i, arg := range args {
+		v, err := datumToValue(arg)
+		if err != nil {
+			return d, errors.Trace(err)
+		}
+		values[i] = v
+	}
+	if limits.MaxCallDepth == 0 {
+		limits.MaxCallDepth = maxCallDepth
+	}
+	result, err := f.program.Call(limits, values...)
+	if err != nil {
+		return d, errors.Trace(err)
+	}
+	switch x := result.(type) {
+	case nil:
+	case bool:
+		d.SetInt64(boolToInt64(x))
+	case int64:
+		d.SetInt64(x)
+	case float64:
+		d.SetFloat64(x)
+	case string:
+		d.SetString(x)
+	default:
+		return d, errors.Errorf("can't return a %s value", lua.ToString(result))
+	}
+	return d, nil
+}
+
+func boolToInt64(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func datumToValue(d types.Datum) (lua.Value, error) {
+	switch d.Kind() {
+	case types.KindNull:
+		return nil, nil
+	case types.KindInt64:
+		return d.GetInt64(), nil
+	case types.KindUint64:
+		if u := d.GetUint64(); u <= 1<<63-1 {
+			return int64(u), nil
+		}
+		return float64(d.GetUint64()), nil
+	case types.KindFloat32, types.KindFloat64:
+		return d.GetFloat64(), nil
+	case types.KindMysqlDecimal:
+		f, err := d.GetMysqlDecimal().ToFloat64()
+		return f, errors.Trace(err)
+	}
+	s, err := d.ToString()
+	return s, errors.Trace(err)
+}
+
+// ParamsString encodes the params like "a INT,b STRING" to be stored.
+func ParamsString(params []Param) string {
+	strs := make([]string, 0, len(params))
+	for _, p := range params {
+		strs = append(strs, p.Name+" "+p.Type)
+	}
+	return strings.Join(strs, ",")
+}
+
+func parseParams(s string) ([]Param, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var params []Param
+	for _, str := range strings.Split(s, ",") {
+		fields := strings.Fields(str)
+		if len(fields) != 2 {
+			return nil, errors.Errorf("invalid parameter %q", str)
+		}
+		params = append(params, Param{Name: fields[0], Type: fields[1]})
+	}
+	return params, nil
+}
+
+// Handle caches the compiled user-defined functions.
+type Handle struct {
+	// funcs is a map[string]*Function, it's replaced by Update.
+	funcs atomic.Value
+}
+
+// NewHandle creates a Handle without functions.
+func NewHandle() *Handle {
+	h := &Handle{}
+	h.funcs.Store(make(map[string]*Function))
+	return h
+}
+
+// Get returns the function with the name, or nil if it doesn't exist.
+func (h *Handle) Get(name string) *Function {
+	return h.funcs.Load().(map[string]*Function)[strings.ToLower(name)]
+}
+
+var loadSQL = fmt.Sprintf("select name, language, params, returns, deterministic, body from %s.%s", mysql.SystemDB, mysql.FuncTable)
+
+// Update loads all the functions from the mysql.func table. A function which fails to compile is
+// skipped, so a bad function can't break the others.
+func (h *Handle) Update(ctx context.Context) error {
+	rss, err := ctx.(sqlexec.SQLExecutor).Execute(loadSQL)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	rs := rss[0]
+	defer rs.Close()
+	funcs := make(map[string]*Function)
+	for {
+		row, err := rs.Next()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if row == nil {
+			break
+		}
+		f := &Function{
+			Name:          strings.ToLower(row.Data[0].GetString()),
+			Language:      row.Data[1].GetString(),
+			ReturnType:    row.Data[3].GetString(),
+			Deterministic: row.Data[4].GetInt64() != 0,
+			Body:          row.Data[5].GetString(),
+		}
+		if f.Params, err = parseParams(row.Data[2].GetString()); err == nil {
+			err = f.Compile()
+		}
+		if err != nil {
+			log.Errorf("[udf] load function %s fail: %v", f.Name, err)
+			continue
+		}
+		funcs[f.Name] = f
+	}
+	h.funcs.Store(funcs)
+	return nil
+}
+
+type keyType int
+
+func (k keyType) String() string {
+	return "udf-key"
+}
+
+const key keyType = 0
+
+// BindHandle binds the Handle to context.
+func BindHandle(ctx context.Context, h *Handle) {
+	ctx.SetValue(key, h)
+}
+
+// GetHandle gets the Handle from context, it returns nil if no Handle is bound.
+func GetHandle(ctx context.Context) *Handle {
+	if v, ok := ctx.Value(key).(*Handle); ok {
+		return v
+	}
+	return nil
+}
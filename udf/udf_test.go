@@ -0,0 +1,119 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package udf
+
+import (
+	"testing"
+
+	"github.com/juju/errors"
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/util/lua"
+	"github.com/pingcap/tidb/util/testleak"
+	"github.com/pingcap/tidb/util/types"
+)
+
+func TestT(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testUDFSuite{})
+
+type testUDFSuite struct{}
+
+func (s *testUDFSuite) TestTypeOf(c *C) {
+	defer testleak.AfterTest(c)()
+	tests := []struct {
+		tp  byte
+		res string
+	}{
+		{mysql.TypeLonglong, TypeInt},
+		{mysql.TypeTiny, TypeInt},
+		{mysql.TypeDouble, TypeReal},
+		{mysql.TypeNewDecimal, TypeDecimal},
+		{mysql.TypeVarchar, TypeString},
+		{mysql.TypeBlob, TypeString},
+		{mysql.TypeDatetime, ""},
+		{mysql.TypeJSON, ""},
+	}
+	for _, t := range tests {
+		c.Assert(TypeOf(types.NewFieldType(t.tp)), Equals, t.res)
+	}
+}
+
+func (s *testUDFSuite) TestCompile(c *C) {
+	defer testleak.AfterTest(c)()
+	tests := []struct {
+		f   Function
+		msg string
+	}{
+		{Function{Language: "WASM", ReturnType: TypeInt}, "language WASM isn't supported"},
+		{Function{Language: LanguageLua, ReturnType: "DATE"}, "return type DATE isn't supported"},
+		{Function{Language: LanguageLua, ReturnType: TypeInt, Params: []Param{{"a", "JSON"}}}, "type JSON of parameter a isn't supported"},
+		{Function{Language: LanguageLua, ReturnType: TypeInt, Params: []Param{{"end", TypeInt}}}, "parameter name end isn't a valid LUA name"},
+		{Function{Language: LanguageLua, ReturnType: TypeInt, Params: []Param{{"1a", TypeInt}}}, "parameter name 1a isn't a valid LUA name"},
+		{Function{Language: LanguageLua, ReturnType: TypeInt, Params: []Param{{"a", TypeInt}, {"a", TypeReal}}}, "duplicate parameter a"},
+		{Function{Language: LanguageLua, ReturnType: TypeInt, Body: "return ("}, "line 1: unexpected symbol near '<eof>'"},
+	}
+	for _, t := range tests {
+		err := t.f.Compile()
+		c.Assert(err, NotNil)
+		c.Assert(errors.Cause(err).Error(), Equals, t.msg)
+	}
+}
+
+func (s *testUDFSuite) TestCall(c *C) {
+	defer testleak.AfterTest(c)()
+	f := &Function{
+		Language:   LanguageLua,
+		Params:     []Param{{"a", TypeInt}, {"b", TypeString}},
+		ReturnType: TypeString,
+		Body:       "if a == nil then return nil elseif a < 0 then return a < -1 end return b:rep(a)",
+	}
+	c.Assert(f.Compile(), IsNil)
+	tests := []struct {
+		args []interface{}
+		res  interface{}
+	}{
+		{[]interface{}{nil, "x"}, nil},
+		{[]interface{}{-2, "x"}, int64(1)},
+		{[]interface{}{-1, "x"}, int64(0)},
+		{[]interface{}{uint64(3), "ab"}, "ababab"},
+		{[]interface{}{types.NewDecFromInt(2), []byte("x")}, "xx"},
+	}
+	for _, t := range tests {
+		d, err := f.Call(lua.Limits{}, types.MakeDatums(t.args...))
+		c.Assert(err, IsNil)
+		c.Assert(d.GetValue(), DeepEquals, t.res)
+	}
+
+	_, err := f.Call(lua.Limits{MaxStringLen: 3}, types.MakeDatums(2, "ab"))
+	c.Assert(errors.Cause(err), Equals, lua.ErrStringLimit)
+}
+
+func (s *testUDFSuite) TestParams(c *C) {
+	defer testleak.AfterTest(c)()
+	params := []Param{{"a", TypeInt}, {"b", TypeString}}
+	str := ParamsString(params)
+	c.Assert(str, Equals, "a INT,b STRING")
+	res, err := parseParams(str)
+	c.Assert(err, IsNil)
+	c.Assert(res, DeepEquals, params)
+
+	res, err = parseParams(ParamsString(nil))
+	c.Assert(err, IsNil)
+	c.Assert(res, HasLen, 0)
+	_, err = parseParams("a")
+	c.Assert(err, NotNil)
+}
@@ -0,0 +1,123 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package audit sends the audit events of the server to the registered sinks.
+//
+// The events are the connections and disconnections of the clients, the executed statements
+// and the changes of the privileges. A sink is registered when the server starts, the events
+// are sent to all the sinks synchronously, so a sink should not block for long.
+package audit
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// EventType is the type of an audit event.
+type EventType string
+
+// Audit event types.
+const (
+	// EventConnect is sent when a client connects, the Error is set if the authentication fails.
+	EventConnect EventType = "connect"
+	// EventDisconnect is sent when a connection is closed.
+	EventDisconnect EventType = "disconnect"
+	// EventStatement is sent when a statement is executed.
+	EventStatement EventType = "statement"
+	// EventPrivilege is sent when a statement changes the users or the privileges successfully,
+	// it's sent after the EventStatement of the statement.
+	EventPrivilege EventType = "privilege"
+)
+
+// Event is an audit event.
+type Event struct {
+	Time     time.Time `json:"time"`
+	Type     EventType `json:"type"`
+	ConnID   uint64    `json:"conn_id"`
+	User     string    `json:"user"`
+	ClientIP string    `json:"client_ip"`
+	DB       string    `json:"db,omitempty"`
+	// SQL is the text of the statement, the literals are masked if the statement contains passwords
+	// or the session redacts the logs.
+	SQL          string `json:"sql,omitempty"`
+	Digest       string `json:"digest,omitempty"`
+	AffectedRows uint64 `json:"affected_rows,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+// Sink receives the audit events.
+type Sink interface {
+	// Audit handles the event, it's called concurrently by the connections.
+	Audit(event *Event)
+}
+
+var (
+	sinksLock sync.Mutex
+	// sinks is a []Sink, it's replaced on registration so Log reads it without lock.
+	sinks atomic.Value
+)
+
+// Register registers the sink to receive the audit events.
+func Register(sink Sink) {
+	sinksLock.Lock()
+	defer sinksLock.Unlock()
+	old, _ := sinks.Load().([]Sink)
+	newSinks := make([]Sink, len(old), len(old)+1)
+	copy(newSinks, old)
+	sinks.Store(append(newSinks, sink))
+}
+
+// Unregister removes the registered sink.
+func Unregister(sink Sink) {
+	sinksLock.Lock()
+	defer sinksLock.Unlock()
+	old, _ := sinks.Load().([]Sink)
+	newSinks := make([]Sink, 0, len(old))
+	for _, s := range old {
+		if s != sink {
+			newSinks = append(newSinks, s)
+		}
+	}
+	sinks.Store(newSinks)
+}
+
+// Enabled returns whether any sink is registered, the caller can skip building the event if not.
+func Enabled() bool {
+	s, _ := sinks.Load().([]Sink)
+	return len(s) > 0
+}
+
+// Log sends the event to all the registered sinks, the Time of the event is set if it's zero.
+func Log(event *Event) {
+	s, _ := sinks.Load().([]Sink)
+	if len(s) == 0 {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	for _, sink := range s {
+		sink.Audit(event)
+	}
+}
+
+// SplitUser splits the "user@host" of a session into the user name and the host.
+func SplitUser(user string) (name, host string) {
+	idx := strings.LastIndex(user, "@")
+	if idx < 0 {
+		return user, ""
+	}
+	return user[:idx], user[idx+1:]
+}
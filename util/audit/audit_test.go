@@ -0,0 +1,107 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/util/testleak"
+)
+
+func TestT(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testAuditSuite{})
+
+type testAuditSuite struct{}
+
+type mockSink struct {
+	events []*Event
+}
+
+func (s *mockSink) Audit(event *Event) {
+	s.events = append(s.events, event)
+}
+
+func (s *testAuditSuite) TestRegister(c *C) {
+	defer testleak.AfterTest(c)()
+	c.Assert(Enabled(), IsFalse)
+	Log(&Event{Type: EventConnect})
+
+	sink1, sink2 := &mockSink{}, &mockSink{}
+	Register(sink1)
+	Register(sink2)
+	c.Assert(Enabled(), IsTrue)
+	Log(&Event{Type: EventStatement, SQL: "select 1"})
+	c.Assert(sink1.events, HasLen, 1)
+	c.Assert(sink2.events, HasLen, 1)
+	c.Assert(sink1.events[0].Time.IsZero(), IsFalse)
+
+	Unregister(sink1)
+	Log(&Event{Type: EventDisconnect})
+	c.Assert(sink1.events, HasLen, 1)
+	c.Assert(sink2.events, HasLen, 2)
+	Unregister(sink2)
+	c.Assert(Enabled(), IsFalse)
+
+	name, host := SplitUser("root@127.0.0.1")
+	c.Assert(name, Equals, "root")
+	c.Assert(host, Equals, "127.0.0.1")
+	name, host = SplitUser("root")
+	c.Assert(name, Equals, "root")
+	c.Assert(host, Equals, "")
+}
+
+func (s *testAuditSuite) TestFileSink(c *C) {
+	defer testleak.AfterTest(c)()
+	dir, err := ioutil.TempDir("", "audit")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit.log")
+
+	events := []*Event{
+		{Type: EventConnect, ConnID: 1, User: "root", ClientIP: "127.0.0.1"},
+		{Type: EventStatement, ConnID: 1, User: "root", ClientIP: "127.0.0.1", SQL: "insert t values (1)", AffectedRows: 1},
+		{Type: EventDisconnect, ConnID: 1, User: "root", ClientIP: "127.0.0.1"},
+	}
+	sink, err := NewFileSink(path)
+	c.Assert(err, IsNil)
+	sink.Audit(events[0])
+	c.Assert(sink.Close(), IsNil)
+	// The events are appended to the existing file.
+	sink, err = NewFileSink(path)
+	c.Assert(err, IsNil)
+	sink.Audit(events[1])
+	sink.Audit(events[2])
+	c.Assert(sink.Close(), IsNil)
+
+	file, err := os.Open(path)
+	c.Assert(err, IsNil)
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	var i int
+	for ; scanner.Scan(); i++ {
+		event := &Event{}
+		c.Assert(json.Unmarshal(scanner.Bytes(), event), IsNil)
+		c.Assert(event, DeepEquals, events[i])
+	}
+	c.Assert(i, Equals, len(events))
+}
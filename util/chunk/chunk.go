@@ -0,0 +1,375 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunk
+
+import (
+	"encoding/binary"
+	"math"
+	"time"
+	"unsafe"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/util/hack"
+	"github.com/pingcap/tidb/util/types"
+	"github.com/pingcap/tidb/util/types/json"
+)
+
+// Chunk stores multiple rows of data in a columnar layout similar to Apache Arrow.
+// See https://arrow.apache.org/docs/memory_layout.html
+// Values are appended in compact format and can be directly accessed without decoding.
+// When the chunk is done processing, we can reuse the allocated memory by resetting it.
+type Chunk struct {
+	columns []*column
+}
+
+// NewChunk creates a new chunk with numCols columns.
+// The kind of a column is decided by the first non-null value appended to it.
+func NewChunk(numCols int) *Chunk {
+	c := &Chunk{columns: make([]*column, 0, numCols)}
+	for i := 0; i < numCols; i++ {
+		c.columns = append(c.columns, &column{})
+	}
+	return c
+}
+
+// NumCols returns the number of columns in the chunk.
+func (c *Chunk) NumCols() int {
+	return len(c.columns)
+}
+
+// NumRows returns the number of rows in the chunk.
+func (c *Chunk) NumRows() int {
+	if len(c.columns) == 0 {
+		return 0
+	}
+	return c.columns[0].length
+}
+
+// Reset resets the chunk, so the memory it allocated can be reused.
+// The kinds of the columns are kept.
+func (c *Chunk) Reset() {
+	for _, col := range c.columns {
+		col.reset()
+	}
+}
+
+// AppendDatum appends a datum to the column at colIdx.
+func (c *Chunk) AppendDatum(colIdx int, d *types.Datum) error {
+	return errors.Trace(c.columns[colIdx].appendDatum(d))
+}
+
+// AppendRow appends a row to the chunk. Nothing is appended if any of the datums
+// can not be stored in its column.
+func (c *Chunk) AppendRow(row []types.Datum) error {
+	if len(row) != len(c.columns) {
+		return errors.Errorf("row has %d values, but chunk has %d columns", len(row), len(c.columns))
+	}
+	for i := range row {
+		if err := c.columns[i].checkDatum(&row[i]); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	for i := range row {
+		if err := c.columns[i].appendDatum(&row[i]); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// GetRow gets the Row in the chunk with the row index.
+func (c *Chunk) GetRow(idx int) Row {
+	return Row{c: c, idx: idx}
+}
+
+// Row represents a row of data, can be used to access values.
+type Row struct {
+	c   *Chunk
+	idx int
+}
+
+// Idx returns the row index of Chunk.
+func (r Row) Idx() int {
+	return r.idx
+}
+
+// Len returns the number of values in the row.
+func (r Row) Len() int {
+	return r.c.NumCols()
+}
+
+// IsNull returns if the value at colIdx is NULL.
+func (r Row) IsNull(colIdx int) bool {
+	return r.c.columns[colIdx].isNull(r.idx)
+}
+
+// GetInt64 returns the int64 value at colIdx.
+func (r Row) GetInt64(colIdx int) int64 {
+	return int64(r.c.columns[colIdx].getUint64(r.idx))
+}
+
+// GetUint64 returns the uint64 value at colIdx.
+func (r Row) GetUint64(colIdx int) uint64 {
+	return r.c.columns[colIdx].getUint64(r.idx)
+}
+
+// GetFloat32 returns the float32 value at colIdx.
+func (r Row) GetFloat32(colIdx int) float32 {
+	col := r.c.columns[colIdx]
+	return math.Float32frombits(binary.LittleEndian.Uint32(col.data[r.idx*4:]))
+}
+
+// GetFloat64 returns the float64 value at colIdx.
+func (r Row) GetFloat64(colIdx int) float64 {
+	return math.Float64frombits(r.c.columns[colIdx].getUint64(r.idx))
+}
+
+// GetString returns the string value at colIdx.
+func (r Row) GetString(colIdx int) string {
+	return hack.String(r.c.columns[colIdx].getBytes(r.idx))
+}
+
+// GetBytes returns the bytes value at colIdx.
+func (r Row) GetBytes(colIdx int) []byte {
+	return r.c.columns[colIdx].getBytes(r.idx)
+}
+
+// GetMyDecimal returns the decimal value at colIdx.
+func (r Row) GetMyDecimal(colIdx int) *types.MyDecimal {
+	col := r.c.columns[colIdx]
+	dec := new(types.MyDecimal)
+	copy(decimalBytes(dec), col.data[r.idx*decimalElemLen:])
+	return dec
+}
+
+// GetDuration returns the duration value at colIdx.
+// The fsp is set to types.MaxFsp, the caller needs to round it according to the field type.
+func (r Row) GetDuration(colIdx int) types.Duration {
+	return types.Duration{Duration: time.Duration(r.GetInt64(colIdx)), Fsp: types.MaxFsp}
+}
+
+// GetJSON returns the JSON value at colIdx.
+func (r Row) GetJSON(colIdx int) (json.JSON, error) {
+	j, err := json.Deserialize(r.c.columns[colIdx].getBytes(r.idx))
+	return j, errors.Trace(err)
+}
+
+// GetDatum returns the value at colIdx as a Datum of the column kind.
+func (r Row) GetDatum(colIdx int) (types.Datum, error) {
+	var d types.Datum
+	if r.IsNull(colIdx) {
+		return d, nil
+	}
+	switch r.c.columns[colIdx].kind {
+	case types.KindInt64:
+		d.SetInt64(r.GetInt64(colIdx))
+	case types.KindUint64:
+		d.SetUint64(r.GetUint64(colIdx))
+	case types.KindFloat32:
+		d.SetFloat32(r.GetFloat32(colIdx))
+	case types.KindFloat64:
+		d.SetFloat64(r.GetFloat64(colIdx))
+	case types.KindString:
+		d.SetString(r.GetString(colIdx))
+	case types.KindBytes:
+		d.SetBytes(r.GetBytes(colIdx))
+	case types.KindMysqlDecimal:
+		d.SetMysqlDecimal(r.GetMyDecimal(colIdx))
+		// The precision and frac of the datum are stored after the decimal.
+		elem := r.c.columns[colIdx].data[r.idx*decimalElemLen+decimalSize:]
+		d.SetLength(int(elem[0]))
+		d.SetFrac(int(elem[1]))
+	case types.KindMysqlDuration:
+		d.SetMysqlDuration(r.GetDuration(colIdx))
+	case types.KindMysqlJSON:
+		j, err := r.GetJSON(colIdx)
+		if err != nil {
+			return d, errors.Trace(err)
+		}
+		d.SetMysqlJSON(j)
+	}
+	return d, nil
+}
+
+// decimalSize is the size of the in-memory representation of types.MyDecimal,
+// decimal values are stored in the chunk as they are in memory.
+const decimalSize = int(unsafe.Sizeof(types.MyDecimal{}))
+
+// decimalElemLen is the length of a decimal element, which also stores the precision and frac of the datum.
+const decimalElemLen = decimalSize + 2
+
+func decimalBytes(dec *types.MyDecimal) []byte {
+	return (*[decimalSize]byte)(unsafe.Pointer(dec))[:]
+}
+
+// varElemLen indicates that the elements of a column have variable length.
+const varElemLen = -1
+
+// elemLen returns the length of the elements of a column of the datum kind.
+// It returns 0 for KindNull, which means no value is stored.
+func elemLen(kind byte) (int, error) {
+	switch kind {
+	case types.KindNull:
+		return 0, nil
+	case types.KindFloat32:
+		return 4, nil
+	case types.KindInt64, types.KindUint64, types.KindFloat64, types.KindMysqlDuration:
+		return 8, nil
+	case types.KindMysqlDecimal:
+		return decimalElemLen, nil
+	case types.KindString, types.KindBytes, types.KindMysqlJSON:
+		return varElemLen, nil
+	}
+	return 0, errors.Errorf("unsupported datum kind %d for chunk", kind)
+}
+
+// column stores the values of a column, the layout is:
+// nullBitmap: a bit for each value, a 0 bit indicates the value is NULL.
+// offsets: for variable length elements, the value at i is in data[offsets[i]:offsets[i+1]].
+// data: for fixed length elements, the value at i is in data[i*elemLen:(i+1)*elemLen].
+type column struct {
+	kind       byte
+	elemLen    int
+	length     int
+	nullCount  int
+	nullBitmap []byte
+	offsets    []int32
+	data       []byte
+	elemBuf    [8]byte
+}
+
+func (c *column) reset() {
+	c.length = 0
+	c.nullCount = 0
+	c.nullBitmap = c.nullBitmap[:0]
+	if c.elemLen == varElemLen {
+		c.offsets = c.offsets[:1]
+	}
+	c.data = c.data[:0]
+}
+
+func (c *column) isNull(rowIdx int) bool {
+	return c.nullBitmap[rowIdx>>3]&(1<<(uint(rowIdx)&7)) == 0
+}
+
+func (c *column) getUint64(rowIdx int) uint64 {
+	return binary.LittleEndian.Uint64(c.data[rowIdx*8:])
+}
+
+func (c *column) getBytes(rowIdx int) []byte {
+	return c.data[c.offsets[rowIdx]:c.offsets[rowIdx+1]]
+}
+
+// setKind sets the kind of a column which only has NULL values.
+func (c *column) setKind(kind byte) error {
+	l, err := elemLen(kind)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	c.kind = kind
+	c.elemLen = l
+	if l == varElemLen {
+		c.offsets = make([]int32, c.length+1)
+	} else {
+		c.data = make([]byte, c.length*l)
+	}
+	return nil
+}
+
+// checkDatum checks if the datum can be appended to the column.
+func (c *column) checkDatum(d *types.Datum) error {
+	kind := d.Kind()
+	if kind == types.KindNull || kind == c.kind {
+		return nil
+	}
+	if c.kind != types.KindNull {
+		return errors.Errorf("can not append datum kind %d to chunk column of kind %d", kind, c.kind)
+	}
+	_, err := elemLen(kind)
+	return errors.Trace(err)
+}
+
+func (c *column) appendDatum(d *types.Datum) error {
+	if err := c.checkDatum(d); err != nil {
+		return errors.Trace(err)
+	}
+	if d.IsNull() {
+		c.appendNull()
+		return nil
+	}
+	if c.kind == types.KindNull {
+		if err := c.setKind(d.Kind()); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	switch c.kind {
+	case types.KindInt64:
+		c.appendUint64(uint64(d.GetInt64()))
+	case types.KindUint64:
+		c.appendUint64(d.GetUint64())
+	case types.KindFloat32:
+		binary.LittleEndian.PutUint32(c.elemBuf[:], math.Float32bits(d.GetFloat32()))
+		c.data = append(c.data, c.elemBuf[:4]...)
+	case types.KindFloat64:
+		c.appendUint64(math.Float64bits(d.GetFloat64()))
+	case types.KindMysqlDuration:
+		c.appendUint64(uint64(d.GetMysqlDuration().Duration))
+	case types.KindMysqlDecimal:
+		c.data = append(c.data, decimalBytes(d.GetMysqlDecimal())...)
+		c.data = append(c.data, byte(d.Length()), byte(d.Frac()))
+	case types.KindString, types.KindBytes:
+		c.appendBytes(d.GetBytes())
+	case types.KindMysqlJSON:
+		c.appendBytes(json.Serialize(d.GetMysqlJSON()))
+	}
+	c.appendNullBitmap(true)
+	c.length++
+	return nil
+}
+
+func (c *column) appendNull() {
+	c.appendNullBitmap(false)
+	c.nullCount++
+	if c.elemLen == varElemLen {
+		c.offsets = append(c.offsets, int32(len(c.data)))
+	} else {
+		for i := 0; i < c.elemLen; i++ {
+			c.data = append(c.data, 0)
+		}
+	}
+	c.length++
+}
+
+func (c *column) appendUint64(v uint64) {
+	binary.LittleEndian.PutUint64(c.elemBuf[:], v)
+	c.data = append(c.data, c.elemBuf[:]...)
+}
+
+func (c *column) appendBytes(b []byte) {
+	c.data = append(c.data, b...)
+	c.offsets = append(c.offsets, int32(len(c.data)))
+}
+
+func (c *column) appendNullBitmap(notNull bool) {
+	idx := c.length >> 3
+	if idx >= len(c.nullBitmap) {
+		c.nullBitmap = append(c.nullBitmap, 0)
+	}
+	mask := byte(1 << (uint(c.length) & 7))
+	if notNull {
+		c.nullBitmap[idx] |= mask
+	} else {
+		c.nullBitmap[idx] &^= mask
+	}
+}
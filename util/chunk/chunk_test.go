@@ -0,0 +1,174 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunk
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/util/testleak"
+	"github.com/pingcap/tidb/util/types"
+	"github.com/pingcap/tidb/util/types/json"
+)
+
+func TestT(t *testing.T) {
+	CustomVerboseFlag = true
+	TestingT(t)
+}
+
+var _ = Suite(&testChunkSuite{})
+
+type testChunkSuite struct{}
+
+func newTestRows(c *C) [][]types.Datum {
+	j, err := json.ParseFromString(`{"a": [1, "b"]}`)
+	c.Assert(err, IsNil)
+	rows := make([][]types.Datum, 0, 20)
+	for i := 0; i < 20; i++ {
+		row := []types.Datum{
+			types.NewIntDatum(int64(i - 10)),
+			types.NewUintDatum(uint64(i) << 60),
+			types.NewFloat64Datum(float64(i) / 4),
+			types.NewBytesDatum([]byte{byte(i), 'a', 'b'}),
+			types.NewDecimalDatum(types.NewDecFromFloatForTest(float64(i) + 0.125)),
+			types.NewDurationDatum(types.Duration{Duration: time.Duration(i) * time.Second, Fsp: types.MaxFsp}),
+			{},
+			{},
+		}
+		row[4].SetLength(10)
+		row[4].SetFrac(3 + i%2)
+		row[6].SetMysqlJSON(j)
+		row[7].SetString(string([]byte("string")[:i%7]))
+		if i%3 == 0 {
+			// Make some of the values NULL, including the first ones of the columns.
+			for k := 0; k < len(row); k += 2 {
+				row[k].SetNull()
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func checkRows(c *C, chk *Chunk, rows [][]types.Datum) {
+	c.Assert(chk.NumRows(), Equals, len(rows))
+	for i, expected := range rows {
+		row := chk.GetRow(i)
+		c.Assert(row.Idx(), Equals, i)
+		c.Assert(row.Len(), Equals, len(expected))
+		for j := range expected {
+			c.Assert(row.IsNull(j), Equals, expected[j].IsNull())
+			d, err := row.GetDatum(j)
+			c.Assert(err, IsNil)
+			c.Assert(d.Kind(), Equals, expected[j].Kind())
+			if !d.IsNull() {
+				c.Assert(d.Length(), Equals, expected[j].Length())
+				c.Assert(d.Frac(), Equals, expected[j].Frac())
+			}
+			cmp, err := d.CompareDatum(nil, expected[j])
+			c.Assert(err, IsNil)
+			c.Assert(cmp, Equals, 0, Commentf("row %d column %d", i, j))
+		}
+	}
+}
+
+func (s *testChunkSuite) TestAppendAndGet(c *C) {
+	defer testleak.AfterTest(c)()
+	rows := newTestRows(c)
+	chk := NewChunk(len(rows[0]))
+	c.Assert(chk.NumCols(), Equals, len(rows[0]))
+	c.Assert(chk.NumRows(), Equals, 0)
+	for _, row := range rows {
+		c.Assert(chk.AppendRow(row), IsNil)
+	}
+	checkRows(c, chk, rows)
+
+	row := chk.GetRow(1)
+	c.Assert(row.GetInt64(0), Equals, int64(-9))
+	c.Assert(row.GetUint64(1), Equals, uint64(1)<<60)
+	c.Assert(row.GetFloat64(2), Equals, 0.25)
+	c.Assert(row.GetBytes(3), BytesEquals, []byte{1, 'a', 'b'})
+	c.Assert(row.GetMyDecimal(4).String(), Equals, "1.125")
+	c.Assert(row.GetDuration(5).Duration, Equals, time.Second)
+	c.Assert(row.GetString(7), Equals, "s")
+
+	// Reset keeps the kinds of the columns.
+	chk.Reset()
+	c.Assert(chk.NumRows(), Equals, 0)
+	for _, row := range rows[3:] {
+		c.Assert(chk.AppendRow(row), IsNil)
+	}
+	checkRows(c, chk, rows[3:])
+
+	chk = NewChunk(1)
+	d := types.NewFloat32Datum(1.5)
+	c.Assert(chk.AppendDatum(0, &d), IsNil)
+	c.Assert(chk.GetRow(0).GetFloat32(0), Equals, float32(1.5))
+}
+
+func (s *testChunkSuite) TestAppendMismatchedKind(c *C) {
+	defer testleak.AfterTest(c)()
+	chk := NewChunk(2)
+	c.Assert(chk.AppendRow(types.MakeDatums(1, "a")), IsNil)
+	// The row is not appended if any value mismatches the kind of its column.
+	c.Assert(chk.AppendRow(types.MakeDatums(2, 1.5)), NotNil)
+	c.Assert(chk.NumRows(), Equals, 1)
+	c.Assert(chk.AppendRow(types.MakeDatums(1)), NotNil)
+	c.Assert(chk.NumRows(), Equals, 1)
+	// Values of unsupported kinds can not be appended.
+	d := types.NewDatum(types.Enum{Name: "a", Value: 1})
+	c.Assert(NewChunk(1).AppendDatum(0, &d), NotNil)
+}
+
+func (s *testChunkSuite) TestEncodeDecode(c *C) {
+	defer testleak.AfterTest(c)()
+	rows := newTestRows(c)
+	chk := NewChunk(len(rows[0]) + 1)
+	for _, row := range rows {
+		// The last column only has NULL values.
+		c.Assert(chk.AppendRow(append(row, types.Datum{})), IsNil)
+	}
+	for i := range rows {
+		rows[i] = append(rows[i], types.Datum{})
+	}
+	buf := Encode(chk)
+	c.Assert(buf, HasLen, encodedLen(chk))
+	buf = append(buf, 'x')
+
+	decoded, remained, err := Decode(buf)
+	c.Assert(err, IsNil)
+	c.Assert(remained, BytesEquals, []byte("x"))
+	checkRows(c, decoded, rows)
+
+	// Appending to a decoded chunk doesn't overwrite the remained bytes.
+	c.Assert(decoded.AppendRow(rows[0]), IsNil)
+	c.Assert(decoded.AppendRow(rows[1]), IsNil)
+	checkRows(c, decoded, append(rows, rows[0], rows[1]))
+	c.Assert(remained, BytesEquals, []byte("x"))
+
+	// An empty chunk.
+	decoded, remained, err = Decode(Encode(NewChunk(3)))
+	c.Assert(err, IsNil)
+	c.Assert(remained, HasLen, 0)
+	c.Assert(decoded.NumCols(), Equals, 3)
+	c.Assert(decoded.NumRows(), Equals, 0)
+
+	// Truncated data can not be decoded.
+	buf = Encode(chk)
+	for _, l := range []int{0, 3, 10, len(buf) / 2, len(buf) - 1} {
+		_, _, err = Decode(buf[:l])
+		c.Assert(err, NotNil)
+	}
+}
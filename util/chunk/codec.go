@@ -0,0 +1,147 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunk
+
+import (
+	"encoding/binary"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/util/types"
+)
+
+var errInsufficientBytes = errors.New("insufficient bytes to decode chunk")
+
+// Encode encodes a chunk to a byte slice, the layout is:
+// numCols(uint32) and then for each column:
+// kind(byte) | length(uint32) | nullCount(uint32) | nullBitmap | offsets | data
+// nullBitmap is omitted if nullCount is 0, offsets only exist for variable length columns.
+// All the numbers are in little endian.
+func Encode(c *Chunk) []byte {
+	buf := make([]byte, 4, encodedLen(c))
+	binary.LittleEndian.PutUint32(buf, uint32(len(c.columns)))
+	for _, col := range c.columns {
+		buf = encodeColumn(buf, col)
+	}
+	return buf
+}
+
+func encodedLen(c *Chunk) int {
+	l := 4
+	for _, col := range c.columns {
+		l += 9 + len(col.data)
+		if col.nullCount > 0 {
+			l += len(col.nullBitmap)
+		}
+		if col.elemLen == varElemLen {
+			l += len(col.offsets) * 4
+		}
+	}
+	return l
+}
+
+func encodeColumn(buf []byte, col *column) []byte {
+	var lenBuf [4]byte
+	buf = append(buf, col.kind)
+	binary.LittleEndian.PutUint32(lenBuf[:], uint32(col.length))
+	buf = append(buf, lenBuf[:]...)
+	binary.LittleEndian.PutUint32(lenBuf[:], uint32(col.nullCount))
+	buf = append(buf, lenBuf[:]...)
+	if col.nullCount > 0 {
+		buf = append(buf, col.nullBitmap...)
+	}
+	if col.elemLen == varElemLen {
+		for _, offset := range col.offsets {
+			binary.LittleEndian.PutUint32(lenBuf[:], uint32(offset))
+			buf = append(buf, lenBuf[:]...)
+		}
+	}
+	return append(buf, col.data...)
+}
+
+// Decode decodes a chunk from a byte slice and returns the remained bytes.
+// The data of the chunk refers to the byte slice without copy.
+func Decode(buf []byte) (*Chunk, []byte, error) {
+	if len(buf) < 4 {
+		return nil, buf, errInsufficientBytes
+	}
+	numCols := int(binary.LittleEndian.Uint32(buf))
+	buf = buf[4:]
+	c := &Chunk{columns: make([]*column, 0, numCols)}
+	for i := 0; i < numCols; i++ {
+		col := &column{}
+		var err error
+		buf, err = decodeColumn(buf, col)
+		if err != nil {
+			return nil, buf, errors.Trace(err)
+		}
+		if i > 0 && col.length != c.columns[0].length {
+			return nil, buf, errors.Errorf("chunk columns have different lengths %d and %d", c.columns[0].length, col.length)
+		}
+		c.columns = append(c.columns, col)
+	}
+	return c, buf, nil
+}
+
+func decodeColumn(buf []byte, col *column) ([]byte, error) {
+	if len(buf) < 9 {
+		return buf, errInsufficientBytes
+	}
+	col.kind = buf[0]
+	l, err := elemLen(col.kind)
+	if err != nil {
+		return buf, errors.Trace(err)
+	}
+	col.elemLen = l
+	col.length = int(binary.LittleEndian.Uint32(buf[1:]))
+	col.nullCount = int(binary.LittleEndian.Uint32(buf[5:]))
+	buf = buf[9:]
+	if col.nullCount > col.length || (col.kind == types.KindNull && col.nullCount != col.length) {
+		return buf, errors.Errorf("invalid null count %d for chunk column of length %d", col.nullCount, col.length)
+	}
+	bitmapLen := (col.length + 7) >> 3
+	if col.nullCount > 0 {
+		if len(buf) < bitmapLen {
+			return buf, errInsufficientBytes
+		}
+		col.nullBitmap = append([]byte(nil), buf[:bitmapLen]...)
+		buf = buf[bitmapLen:]
+	} else {
+		col.nullBitmap = make([]byte, bitmapLen)
+		for i := range col.nullBitmap {
+			col.nullBitmap[i] = 0xFF
+		}
+	}
+	dataLen := col.length * col.elemLen
+	if col.elemLen == varElemLen {
+		offsetsLen := (col.length + 1) * 4
+		if len(buf) < offsetsLen {
+			return buf, errInsufficientBytes
+		}
+		col.offsets = make([]int32, col.length+1)
+		for i := range col.offsets {
+			col.offsets[i] = int32(binary.LittleEndian.Uint32(buf[i*4:]))
+			if i > 0 && col.offsets[i] < col.offsets[i-1] {
+				return buf, errors.Errorf("invalid offsets for chunk column")
+			}
+		}
+		buf = buf[offsetsLen:]
+		dataLen = int(col.offsets[col.length])
+	}
+	if len(buf) < dataLen {
+		return buf, errInsufficientBytes
+	}
+	// Limit the capacity, so appending to the column never overwrites the remained bytes.
+	col.data = buf[:dataLen:dataLen]
+	return buf[dataLen:], nil
+}
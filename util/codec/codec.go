@@ -36,7 +36,11 @@ const (
 	varintFlag       byte = 8
 	uvarintFlag      byte = 9
 	jsonFlag         byte = 10
-	maxFlag          byte = 250
+	// descFlag is followed by the inverted bytes of a value encoded by EncodeKey, so the values are
+	// in descending order. TiKV can't decode it, so the keys with it are only read by TiDB and the
+	// mock TiKV, see kv.ReqSubTypeDescKey.
+	descFlag byte = 11
+	maxFlag  byte = 250
 )
 
 // encode will encode a datum and append it to a byte slice. If comparable is true, the encoded bytes can be sorted as it's original order.
@@ -158,6 +162,29 @@ func EncodeKey(b []byte, v ...types.Datum) ([]byte, error) {
 	return encode(b, v, true, false)
 }
 
+// EncodeKeyWithDesc is like EncodeKey, but the values whose desc are true are encoded in descending order,
+// the values after the end of desc are in ascending order.
+func EncodeKeyWithDesc(b []byte, desc []bool, v ...types.Datum) ([]byte, error) {
+	var err error
+	for i := range v {
+		if i >= len(desc) || !desc[i] {
+			b, err = encode(b, v[i:i+1], true, false)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			continue
+		}
+		b = append(b, descFlag)
+		start := len(b)
+		b, err = encode(b, v[i:i+1], true, false)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		safeReverseBytes(b[start:])
+	}
+	return b, nil
+}
+
 // EncodeValue appends the encoded values to byte slice b, returning the appended
 // slice. It does not guarantee the order for comparison.
 func EncodeValue(b []byte, v ...types.Datum) ([]byte, error) {
@@ -248,6 +275,8 @@ func DecodeOne(b []byte) (remain []byte, d types.Datum, err error) {
 		if err == nil {
 			d.SetMysqlJSON(j)
 		}
+	case descFlag:
+		b, d, err = decodeDesc(b)
 	case NilFlag:
 	default:
 		return b, d, errors.Errorf("invalid encoded key flag %v", flag)
@@ -258,6 +287,18 @@ func DecodeOne(b []byte) (remain []byte, d types.Datum, err error) {
 	return b, d, nil
 }
 
+// decodeDesc decodes a value encoded in descending order, b is the bytes after the descFlag.
+func decodeDesc(b []byte) ([]byte, types.Datum, error) {
+	buf := make([]byte, len(b))
+	copy(buf, b)
+	safeReverseBytes(buf)
+	remain, d, err := DecodeOne(buf)
+	if err != nil {
+		return b, d, errors.Trace(err)
+	}
+	return b[len(b)-len(remain):], d, nil
+}
+
 // CutOne cuts the first encoded value from b.
 // It will return the first encoded item and the remains as byte slice.
 func CutOne(b []byte) (data []byte, remain []byte, err error) {
@@ -307,6 +348,11 @@ func peek(b []byte) (length int, err error) {
 		l, err = peekUvarint(b)
 	case jsonFlag:
 		l, err = json.PeekBytesAsJSON(b)
+	case descFlag:
+		buf := make([]byte, len(b))
+		copy(buf, b)
+		safeReverseBytes(buf)
+		l, err = peek(buf)
 	default:
 		return 0, errors.Errorf("invalid encoded key flag %v", flag)
 	}
@@ -202,6 +202,70 @@ func (s *testCodecSuite) TestCodecKeyCompare(c *C) {
 	}
 }
 
+func (s *testCodecSuite) TestCodecKeyWithDesc(c *C) {
+	defer testleak.AfterTest(c)()
+	table := []struct {
+		Left   []types.Datum
+		Right  []types.Datum
+		Expect int
+	}{
+		{
+			types.MakeDatums(1),
+			types.MakeDatums(2),
+			1,
+		},
+		{
+			types.MakeDatums("abc"),
+			types.MakeDatums("abcd"),
+			1,
+		},
+		{
+			types.MakeDatums(nil),
+			types.MakeDatums(int64(math.MaxInt64)),
+			1,
+		},
+		{
+			types.MakeDatums(3.15, 1),
+			types.MakeDatums(3.15, 2),
+			-1,
+		},
+		{
+			types.MakeDatums(types.NewDecFromInt(1), "b"),
+			types.MakeDatums(types.NewDecFromFloatForTest(1.3), "a"),
+			1,
+		},
+	}
+	desc := []bool{true}
+	for _, t := range table {
+		b1, err := EncodeKeyWithDesc(nil, desc, t.Left...)
+		c.Assert(err, IsNil)
+		b2, err := EncodeKeyWithDesc(nil, desc, t.Right...)
+		c.Assert(err, IsNil)
+		c.Assert(bytes.Compare(b1, b2), Equals, t.Expect, Commentf("%v - %v - %v - %v - %v", t.Left, t.Right, b1, b2, t.Expect))
+
+		// The descending values are decoded and cut like the ascending values.
+		v, err := Decode(b1, len(t.Left))
+		c.Assert(err, IsNil)
+		expect, err := EncodeKey(nil, t.Left...)
+		c.Assert(err, IsNil)
+		got, err := EncodeKey(nil, v...)
+		c.Assert(err, IsNil)
+		c.Assert(got, DeepEquals, expect)
+		for range t.Left {
+			_, b1, err = CutOne(b1)
+			c.Assert(err, IsNil)
+		}
+		c.Assert(b1, HasLen, 0)
+	}
+
+	// The max value is the least key of a descending value.
+	maxKey, err := EncodeKeyWithDesc(nil, desc, types.MaxValueDatum())
+	c.Assert(err, IsNil)
+	key, err := EncodeKeyWithDesc(nil, desc, types.NewIntDatum(math.MaxInt64))
+	c.Assert(err, IsNil)
+	c.Assert(bytes.Compare(maxKey, key), Equals, -1)
+}
+
 func (s *testCodecSuite) TestNumberCodec(c *C) {
 	defer testleak.AfterTest(c)()
 	tblInt64 := []int64{
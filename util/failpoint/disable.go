@@ -0,0 +1,40 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// +build !failpoint
+
+package failpoint
+
+import "github.com/juju/errors"
+
+var errNotSupported = errors.New("failpoint is not supported, build with tag 'failpoint' to enable it")
+
+// Enable returns an error when build tag 'failpoint' is not set.
+func Enable(name, terms string) error {
+	return errors.Trace(errNotSupported)
+}
+
+// Disable returns an error when build tag 'failpoint' is not set.
+func Disable(name string) error {
+	return errors.Trace(errNotSupported)
+}
+
+// Eval is a dummy implementation when build tag 'failpoint' is not set, the failpoint
+// is never triggered.
+func Eval(name string) (string, bool) {
+	return "", false
+}
+
+// Enabled is a dummy implementation when build tag 'failpoint' is not set.
+func Enabled() []Status {
+	return nil
+}
@@ -0,0 +1,39 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// +build failpoint
+
+package failpoint
+
+var failpoints = newRegistry()
+
+// Enable enables the failpoint with the terms, it replaces the terms if the failpoint
+// is already enabled.
+func Enable(name, terms string) error {
+	return failpoints.enable(name, terms)
+}
+
+// Disable disables the failpoint.
+func Disable(name string) error {
+	return failpoints.disable(name)
+}
+
+// Eval evaluates the failpoint, it returns the argument of the return action and true
+// if the failpoint is triggered.
+func Eval(name string) (string, bool) {
+	return failpoints.eval(name)
+}
+
+// Enabled returns the enabled failpoints sorted by name.
+func Enabled() []Status {
+	return failpoints.list()
+}
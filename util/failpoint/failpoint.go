@@ -0,0 +1,186 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package failpoint injects faults at the named points of the code for testing.
+//
+// A failpoint is evaluated by calling Eval with its name at the place where the fault
+// should happen, it does nothing until it's enabled with a list of terms joined by "->":
+//
+//	[count*]action[(arg)]
+//
+// The terms are applied in order, a term with a count is applied count times before
+// moving to the next one, the last term without a count is applied forever. The actions:
+//
+//	off          the failpoint is not triggered.
+//	return(arg)  the failpoint is triggered, Eval returns the arg.
+//	sleep(ms)    sleep for ms milliseconds, the failpoint is not triggered.
+//	panic        panic.
+//
+// For example, "2*off->return(1)" makes the first 2 evaluations pass and the others
+// return "1". The failpoints can only be enabled when the binary is built with the tag
+// 'failpoint', otherwise Eval always returns false and costs almost nothing.
+package failpoint
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/juju/errors"
+)
+
+// Actions of the terms.
+const (
+	actionOff    = "off"
+	actionReturn = "return"
+	actionSleep  = "sleep"
+	actionPanic  = "panic"
+)
+
+type term struct {
+	// count is the number of times the term is applied, 0 means forever.
+	count  int
+	action string
+	arg    string
+}
+
+type failpoint struct {
+	mu    sync.Mutex
+	desc  string
+	terms []*term
+}
+
+// eval applies the current term of the failpoint.
+func (fp *failpoint) eval(name string) (string, bool) {
+	fp.mu.Lock()
+	if len(fp.terms) == 0 {
+		fp.mu.Unlock()
+		return "", false
+	}
+	t := fp.terms[0]
+	if t.count > 0 {
+		t.count--
+		if t.count == 0 {
+			fp.terms = fp.terms[1:]
+		}
+	}
+	fp.mu.Unlock()
+
+	switch t.action {
+	case actionReturn:
+		return t.arg, true
+	case actionSleep:
+		ms, _ := strconv.Atoi(t.arg)
+		time.Sleep(time.Duration(ms) * time.Millisecond)
+	case actionPanic:
+		panic(fmt.Sprintf("failpoint %s panics", name))
+	}
+	return "", false
+}
+
+// parseTerms parses the terms of a failpoint like "2*off->return(1)".
+func parseTerms(desc string) ([]*term, error) {
+	var terms []*term
+	for _, s := range strings.Split(desc, "->") {
+		s = strings.TrimSpace(s)
+		t := &term{}
+		if i := strings.Index(s, "*"); i >= 0 {
+			count, err := strconv.Atoi(strings.TrimSpace(s[:i]))
+			if err != nil || count <= 0 {
+				return nil, errors.Errorf("invalid count in failpoint term %q", s)
+			}
+			t.count = count
+			s = strings.TrimSpace(s[i+1:])
+		}
+		if i := strings.Index(s, "("); i >= 0 {
+			if !strings.HasSuffix(s, ")") {
+				return nil, errors.Errorf("unclosed argument in failpoint term %q", s)
+			}
+			t.arg = s[i+1 : len(s)-1]
+			s = strings.TrimSpace(s[:i])
+		}
+		t.action = s
+		switch t.action {
+		case actionOff, actionReturn, actionPanic:
+		case actionSleep:
+			if _, err := strconv.Atoi(t.arg); err != nil {
+				return nil, errors.Errorf("invalid sleep time in failpoint term %q", s)
+			}
+		default:
+			return nil, errors.Errorf("unknown failpoint action %q", t.action)
+		}
+		terms = append(terms, t)
+	}
+	return terms, nil
+}
+
+// registry holds the enabled failpoints.
+type registry struct {
+	mu         sync.RWMutex
+	failpoints map[string]*failpoint
+}
+
+func newRegistry() *registry {
+	return &registry{failpoints: make(map[string]*failpoint)}
+}
+
+func (r *registry) enable(name, desc string) error {
+	terms, err := parseTerms(desc)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	r.mu.Lock()
+	r.failpoints[name] = &failpoint{desc: desc, terms: terms}
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *registry) disable(name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.failpoints[name]; !ok {
+		return errors.Errorf("failpoint %s is not enabled", name)
+	}
+	delete(r.failpoints, name)
+	return nil
+}
+
+func (r *registry) eval(name string) (string, bool) {
+	r.mu.RLock()
+	fp, ok := r.failpoints[name]
+	r.mu.RUnlock()
+	if !ok {
+		return "", false
+	}
+	return fp.eval(name)
+}
+
+// Status is the status of an enabled failpoint.
+type Status struct {
+	Name  string
+	Terms string
+}
+
+func (r *registry) list() []Status {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := make([]Status, 0, len(r.failpoints))
+	for name, fp := range r.failpoints {
+		list = append(list, Status{Name: name, Terms: fp.desc})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
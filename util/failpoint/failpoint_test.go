@@ -0,0 +1,83 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package failpoint
+
+import (
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/util/testleak"
+)
+
+func TestT(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testFailpointSuite{})
+
+type testFailpointSuite struct{}
+
+func (s *testFailpointSuite) TestParseTerms(c *C) {
+	defer testleak.AfterTest(c)()
+	terms, err := parseTerms("2*off -> return(a) ->1*sleep(10)->panic")
+	c.Assert(err, IsNil)
+	c.Assert(terms, HasLen, 4)
+	c.Assert(*terms[0], Equals, term{count: 2, action: actionOff})
+	c.Assert(*terms[1], Equals, term{action: actionReturn, arg: "a"})
+	c.Assert(*terms[2], Equals, term{count: 1, action: actionSleep, arg: "10"})
+	c.Assert(*terms[3], Equals, term{action: actionPanic})
+
+	for _, desc := range []string{"", "abc", "0*off", "x*return", "return(1", "sleep(a)"} {
+		_, err = parseTerms(desc)
+		c.Assert(err, NotNil, Commentf("terms %q", desc))
+	}
+}
+
+func (s *testFailpointSuite) TestEval(c *C) {
+	defer testleak.AfterTest(c)()
+	r := newRegistry()
+	_, ok := r.eval("a")
+	c.Assert(ok, IsFalse)
+	c.Assert(r.disable("a"), NotNil)
+	c.Assert(r.enable("a", "return(abc"), NotNil)
+
+	c.Assert(r.enable("a", "2*off->1*return(1)->return(2)"), IsNil)
+	c.Assert(r.enable("b", "1*return"), IsNil)
+	c.Assert(r.list(), DeepEquals, []Status{
+		{Name: "a", Terms: "2*off->1*return(1)->return(2)"},
+		{Name: "b", Terms: "1*return"},
+	})
+	var results []string
+	for i := 0; i < 5; i++ {
+		val, ok := r.eval("a")
+		if !ok {
+			val = "-"
+		}
+		results = append(results, val)
+	}
+	c.Assert(results, DeepEquals, []string{"-", "-", "1", "2", "2"})
+
+	// The failpoint is not triggered after all the terms are applied.
+	_, ok = r.eval("b")
+	c.Assert(ok, IsTrue)
+	_, ok = r.eval("b")
+	c.Assert(ok, IsFalse)
+
+	c.Assert(r.disable("a"), IsNil)
+	_, ok = r.eval("a")
+	c.Assert(ok, IsFalse)
+
+	c.Assert(r.enable("c", "panic"), IsNil)
+	c.Assert(func() { r.eval("c") }, PanicMatches, "failpoint c panics")
+}
@@ -0,0 +1,315 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lua
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type tokenType int
+
+const (
+	tokEOF tokenType = iota
+	tokName
+	tokNumber
+	tokString
+	// tokOp is an operator or a punctuation, the text is in token.text.
+	tokOp
+	// tokKeyword is a reserved word, the text is in token.text.
+	tokKeyword
+)
+
+type token struct {
+	tp   tokenType
+	text string
+	// num is the value of a number, it's an int64 or a float64.
+	num  Value
+	line int
+}
+
+func (t token) String() string {
+	switch t.tp {
+	case tokEOF:
+		return "<eof>"
+	case tokString:
+		return strconv.Quote(t.text)
+	}
+	return t.text
+}
+
+var keywords = map[string]bool{
+	"and": true, "break": true, "do": true, "else": true, "elseif": true, "end": true,
+	"false": true, "for": true, "function": true, "goto": true, "if": true, "in": true,
+	"local": true, "nil": true, "not": true, "or": true, "repeat": true, "return": true,
+	"then": true, "true": true, "until": true, "while": true,
+}
+
+// ops are the operators and the punctuations, the longer ones are matched first.
+var ops = []string{
+	"...", "..", "==", "~=", "<=", ">=", "//", "::",
+	"+", "-", "*", "/", "%", "^", "#", "<", ">", "=", "(", ")", "{", "}", "[", "]", ";", ":", ",", ".",
+}
+
+type lexer struct {
+	src  string
+	pos  int
+	line int
+}
+
+func newLexer(src string) *lexer {
+	return &lexer{src: src, line: 1}
+}
+
+func (l *lexer) errorf(format string, args ...interface{}) error {
+	return &Error{Line: l.line, Msg: fmt.Sprintf(format, args...)}
+}
+
+// next scans the next token.
+func (l *lexer) next() (token, error) {
+	if err := l.skipSpaces(); err != nil {
+		return token{}, err
+	}
+	if l.pos >= len(l.src) {
+		return token{tp: tokEOF, line: l.line}, nil
+	}
+	c := l.src[l.pos]
+	switch {
+	case isLetter(c):
+		start := l.pos
+		for l.pos < len(l.src) && (isLetter(l.src[l.pos]) || isDigit(l.src[l.pos])) {
+			l.pos++
+		}
+		text := l.src[start:l.pos]
+		if keywords[text] {
+			return token{tp: tokKeyword, text: text, line: l.line}, nil
+		}
+		return token{tp: tokName, text: text, line: l.line}, nil
+	case isDigit(c) || (c == '.' && l.pos+1 < len(l.src) && isDigit(l.src[l.pos+1])):
+		return l.scanNumber()
+	case c == '"' || c == '\'':
+		return l.scanString(c)
+	case c == '[' && l.longBracketLevel() >= 0:
+		line := l.line
+		s, err := l.scanLongString()
+		return token{tp: tokString, text: s, line: line}, err
+	}
+	for _, op := range ops {
+		if strings.HasPrefix(l.src[l.pos:], op) {
+			l.pos += len(op)
+			return token{tp: tokOp, text: op, line: l.line}, nil
+		}
+	}
+	return token{}, l.errorf("unexpected symbol near '%c'", c)
+}
+
+func (l *lexer) skipSpaces() error {
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == '\n':
+			l.line++
+			l.pos++
+		case c == ' ' || c == '\t' || c == '\r' || c == '\v' || c == '\f':
+			l.pos++
+		case strings.HasPrefix(l.src[l.pos:], "--"):
+			l.pos += 2
+			if l.pos < len(l.src) && l.src[l.pos] == '[' && l.longBracketLevel() >= 0 {
+				if _, err := l.scanLongString(); err != nil {
+					return err
+				}
+				continue
+			}
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+				l.pos++
+			}
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+// longBracketLevel returns the level of the opening long bracket like "[==[" at the position, or -1.
+func (l *lexer) longBracketLevel() int {
+	i := l.pos + 1
+	for i < len(l.src) && l.src[i] == '=' {
+		i++
+	}
+	if i < len(l.src) && l.src[i] == '[' {
+		return i - l.pos - 1
+	}
+	return -1
+}
+
+func (l *lexer) scanLongString() (string, error) {
+	level := l.longBracketLevel()
+	l.pos += level + 2
+	// A newline right after the opening bracket is skipped.
+	if l.pos < len(l.src) && l.src[l.pos] == '\n' {
+		l.line++
+		l.pos++
+	}
+	closing := "]" + strings.Repeat("=", level) + "]"
+	end := strings.Index(l.src[l.pos:], closing)
+	if end < 0 {
+		return "", l.errorf("unfinished long string")
+	}
+	s := l.src[l.pos : l.pos+end]
+	l.line += strings.Count(s, "\n")
+	l.pos += end + len(closing)
+	return s, nil
+}
+
+func (l *lexer) scanNumber() (token, error) {
+	start := l.pos
+	hex := strings.HasPrefix(l.src[l.pos:], "0x") || strings.HasPrefix(l.src[l.pos:], "0X")
+	if hex {
+		l.pos += 2
+	}
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		if isDigit(c) || c == '.' || (hex && isHexDigit(c)) {
+			l.pos++
+			continue
+		}
+		if (!hex && (c == 'e' || c == 'E')) || (hex && (c == 'p' || c == 'P')) {
+			l.pos++
+			if l.pos < len(l.src) && (l.src[l.pos] == '+' || l.src[l.pos] == '-') {
+				l.pos++
+			}
+			continue
+		}
+		break
+	}
+	text := l.src[start:l.pos]
+	num, ok := parseNumber(text)
+	if !ok || (l.pos < len(l.src) && isLetter(l.src[l.pos])) {
+		return token{}, l.errorf("malformed number near '%s'", text)
+	}
+	return token{tp: tokNumber, text: text, num: num, line: l.line}, nil
+}
+
+var escapes = map[byte]byte{
+	'a': '\a', 'b': '\b', 'f': '\f', 'n': '\n', 'r': '\r', 't': '\t', 'v': '\v',
+	'\\': '\\', '"': '"', '\'': '\'', '\n': '\n',
+}
+
+func (l *lexer) scanString(quote byte) (token, error) {
+	line := l.line
+	l.pos++
+	var buf bytes.Buffer
+	for {
+		if l.pos >= len(l.src) || l.src[l.pos] == '\n' {
+			return token{}, l.errorf("unfinished string")
+		}
+		c := l.src[l.pos]
+		l.pos++
+		if c == quote {
+			break
+		}
+		if c != '\\' {
+			buf.WriteByte(c)
+			continue
+		}
+		if l.pos >= len(l.src) {
+			return token{}, l.errorf("unfinished string")
+		}
+		c = l.src[l.pos]
+		if e, ok := escapes[c]; ok {
+			if c == '\n' {
+				l.line++
+			}
+			buf.WriteByte(e)
+			l.pos++
+			continue
+		}
+		if !isDigit(c) {
+			return token{}, l.errorf("invalid escape sequence '\\%c'", c)
+		}
+		// \ddd is a byte in decimal.
+		var n int
+		for i := 0; i < 3 && l.pos < len(l.src) && isDigit(l.src[l.pos]); i++ {
+			n = n*10 + int(l.src[l.pos]-'0')
+			l.pos++
+		}
+		if n > 255 {
+			return token{}, l.errorf("decimal escape too large")
+		}
+		buf.WriteByte(byte(n))
+	}
+	return token{tp: tokString, text: buf.String(), line: line}, nil
+}
+
+func isLetter(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isHexDigit(c byte) bool {
+	return isDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+// parseNumber parses a numeral like Lua, an integer overflows to a float.
+func parseNumber(s string) (Value, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, false
+	}
+	neg := false
+	body := s
+	if body[0] == '-' || body[0] == '+' {
+		neg = body[0] == '-'
+		body = body[1:]
+	}
+	if strings.HasPrefix(body, "0x") || strings.HasPrefix(body, "0X") {
+		if !strings.ContainsAny(body[2:], ".pP") {
+			// Hexadecimal integers wrap around like Lua.
+			u, err := strconv.ParseUint(body[2:], 16, 64)
+			if err != nil {
+				return nil, false
+			}
+			if neg {
+				return -int64(u), true
+			}
+			return int64(u), true
+		}
+		f, err := strconv.ParseFloat(s[:len(s)-len(body)]+"0x"+body[2:]+pSuffix(body), 64)
+		return f, err == nil
+	}
+	if !strings.ContainsAny(body, ".eEnN") {
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return i, true
+		}
+	}
+	if strings.ContainsAny(body, "nNiI") {
+		// strconv accepts "nan" and "inf" but Lua doesn't.
+		return nil, false
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	return f, err == nil
+}
+
+// pSuffix adds the binary exponent required by strconv for a hexadecimal float.
+func pSuffix(body string) string {
+	if strings.ContainsAny(body, "pP") {
+		return ""
+	}
+	return "p0"
+}
@@ -0,0 +1,526 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lua
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// argError is a bad argument of a library function, the line and the function name are added by the caller.
+type argError struct {
+	n   int
+	msg string
+}
+
+func (e *argError) Error() string {
+	return fmt.Sprintf("bad argument #%d (%s)", e.n, e.msg)
+}
+
+// raisedError is raised by the error function of the script.
+type raisedError struct {
+	msg string
+}
+
+func (e *raisedError) Error() string {
+	return e.msg
+}
+
+// libs are the names of the libraries, their fields are in builtins as "lib.name".
+var libs = map[string]bool{"math": true, "string": true}
+
+var builtins = map[string]Value{
+	"assert":   fn("assert", luaAssert),
+	"error":    fn("error", luaError),
+	"tonumber": fn("tonumber", luaToNumber),
+	"tostring": fn("tostring", luaToString),
+	"type":     fn("type", luaType),
+
+	"math.abs":        fn("abs", mathAbs),
+	"math.ceil":       fn("ceil", mathRound(math.Ceil)),
+	"math.floor":      fn("floor", mathRound(math.Floor)),
+	"math.max":        fn("max", mathMinMax(false)),
+	"math.min":        fn("min", mathMinMax(true)),
+	"math.fmod":       fn("fmod", mathFmod),
+	"math.tointeger":  fn("tointeger", mathToInteger),
+	"math.sqrt":       fn("sqrt", mathFloat(math.Sqrt)),
+	"math.exp":        fn("exp", mathFloat(math.Exp)),
+	"math.sin":        fn("sin", mathFloat(math.Sin)),
+	"math.cos":        fn("cos", mathFloat(math.Cos)),
+	"math.tan":        fn("tan", mathFloat(math.Tan)),
+	"math.log":        fn("log", mathLog),
+	"math.pi":         math.Pi,
+	"math.huge":       math.Inf(1),
+	"math.maxinteger": int64(math.MaxInt64),
+	"math.mininteger": int64(math.MinInt64),
+
+	"string.byte":    fn("byte", strByte),
+	"string.char":    fn("char", strChar),
+	"string.format":  fn("format", strFormat),
+	"string.len":     fn("len", strLen),
+	"string.lower":   fn("lower", strMap(strings.ToLower)),
+	"string.rep":     fn("rep", strRep),
+	"string.reverse": fn("reverse", strReverse),
+	"string.sub":     fn("sub", strSub),
+	"string.upper":   fn("upper", strMap(strings.ToUpper)),
+}
+
+func fn(name string, f func(s *state, args []Value) (Value, error)) *goFunction {
+	return &goFunction{name: name, fn: f}
+}
+
+func arg(args []Value, i int) Value {
+	if i < len(args) {
+		return args[i]
+	}
+	return nil
+}
+
+func checkNumber(args []Value, i int) (Value, error) {
+	v := arg(args, i)
+	n, ok := toNumber(v)
+	if !ok {
+		return nil, &argError{n: i + 1, msg: "number expected, got " + typeName(v)}
+	}
+	return n, nil
+}
+
+func checkInt(args []Value, i int) (int64, error) {
+	n, err := checkNumber(args, i)
+	if err != nil {
+		return 0, err
+	}
+	if v, ok := floatToInt(n); ok {
+		return v, nil
+	}
+	return 0, &argError{n: i + 1, msg: "number has no integer representation"}
+}
+
+func optInt(args []Value, i int, def int64) (int64, error) {
+	if arg(args, i) == nil {
+		return def, nil
+	}
+	return checkInt(args, i)
+}
+
+func checkString(args []Value, i int) (string, error) {
+	v := arg(args, i)
+	str, ok := toStringCoerce(v)
+	if !ok {
+		return "", &argError{n: i + 1, msg: "string expected, got " + typeName(v)}
+	}
+	return str, nil
+}
+
+// floatToInt converts an integral number to an int64.
+func floatToInt(n Value) (int64, bool) {
+	switch x := n.(type) {
+	case int64:
+		return x, true
+	case float64:
+		if x == math.Floor(x) && x >= -(1<<63) && x < 1<<63 {
+			return int64(x), true
+		}
+	}
+	return 0, false
+}
+
+func luaAssert(s *state, args []Value) (Value, error) {
+	if truthy(arg(args, 0)) {
+		return arg(args, 0), nil
+	}
+	msg := "assertion failed!"
+	if m, ok := arg(args, 1).(string); ok {
+		msg = m
+	}
+	return nil, &raisedError{msg: msg}
+}
+
+func luaError(s *state, args []Value) (Value, error) {
+	return nil, &raisedError{msg: ToString(arg(args, 0))}
+}
+
+func luaToNumber(s *state, args []Value) (Value, error) {
+	if len(args) < 2 || args[1] == nil {
+		n, ok := toNumber(arg(args, 0))
+		if !ok {
+			return nil, nil
+		}
+		return n, nil
+	}
+	base, err := checkInt(args, 1)
+	if err != nil {
+		return nil, err
+	}
+	if base < 2 || base > 36 {
+		return nil, &argError{n: 2, msg: "base out of range"}
+	}
+	str, ok := arg(args, 0).(string)
+	if !ok {
+		return nil, &argError{n: 1, msg: "string expected, got " + typeName(arg(args, 0))}
+	}
+	i, err := strconv.ParseInt(strings.TrimSpace(str), int(base), 64)
+	if err != nil {
+		return nil, nil
+	}
+	return i, nil
+}
+
+func luaToString(s *state, args []Value) (Value, error) {
+	return ToString(arg(args, 0)), nil
+}
+
+func luaType(s *state, args []Value) (Value, error) {
+	if len(args) == 0 {
+		return nil, &argError{n: 1, msg: "value expected"}
+	}
+	return typeName(args[0]), nil
+}
+
+func mathAbs(s *state, args []Value) (Value, error) {
+	n, err := checkNumber(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	if i, ok := n.(int64); ok {
+		if i < 0 {
+			return -i, nil
+		}
+		return i, nil
+	}
+	return math.Abs(n.(float64)), nil
+}
+
+// mathRound returns math.floor or math.ceil, the result is an integer if it fits.
+func mathRound(round func(float64) float64) func(s *state, args []Value) (Value, error) {
+	return func(s *state, args []Value) (Value, error) {
+		n, err := checkNumber(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		if i, ok := n.(int64); ok {
+			return i, nil
+		}
+		f := round(n.(float64))
+		if i, ok := floatToInt(f); ok {
+			return i, nil
+		}
+		return f, nil
+	}
+}
+
+func mathMinMax(min bool) func(s *state, args []Value) (Value, error) {
+	return func(s *state, args []Value) (Value, error) {
+		result, err := checkNumber(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		for i := 1; i < len(args); i++ {
+			n, err := checkNumber(args, i)
+			if err != nil {
+				return nil, err
+			}
+			if (min && numLess(n, result)) || (!min && numLess(result, n)) {
+				result = n
+			}
+		}
+		return result, nil
+	}
+}
+
+func numLess(a, b Value) bool {
+	ai, aok := a.(int64)
+	bi, bok := b.(int64)
+	if aok && bok {
+		return ai < bi
+	}
+	return toFloat(a) < toFloat(b)
+}
+
+func mathFmod(s *state, args []Value) (Value, error) {
+	a, err := checkNumber(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	b, err := checkNumber(args, 1)
+	if err != nil {
+		return nil, err
+	}
+	ai, aok := a.(int64)
+	bi, bok := b.(int64)
+	if aok && bok {
+		if bi == 0 {
+			return nil, &argError{n: 2, msg: "zero"}
+		}
+		if bi == -1 {
+			return int64(0), nil
+		}
+		return ai % bi, nil
+	}
+	return math.Mod(toFloat(a), toFloat(b)), nil
+}
+
+func mathToInteger(s *state, args []Value) (Value, error) {
+	if i, ok := floatToInt(arg(args, 0)); ok {
+		return i, nil
+	}
+	return nil, nil
+}
+
+func mathFloat(f func(float64) float64) func(s *state, args []Value) (Value, error) {
+	return func(s *state, args []Value) (Value, error) {
+		n, err := checkNumber(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		return f(toFloat(n)), nil
+	}
+}
+
+func mathLog(s *state, args []Value) (Value, error) {
+	n, err := checkNumber(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	if arg(args, 1) == nil {
+		return math.Log(toFloat(n)), nil
+	}
+	base, err := checkNumber(args, 1)
+	if err != nil {
+		return nil, err
+	}
+	switch toFloat(base) {
+	case 2:
+		return math.Log2(toFloat(n)), nil
+	case 10:
+		return math.Log10(toFloat(n)), nil
+	}
+	return math.Log(toFloat(n)) / math.Log(toFloat(base)), nil
+}
+
+func strLen(s *state, args []Value) (Value, error) {
+	str, err := checkString(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	return int64(len(str)), nil
+}
+
+func strMap(f func(string) string) func(s *state, args []Value) (Value, error) {
+	return func(s *state, args []Value) (Value, error) {
+		str, err := checkString(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		return f(str), nil
+	}
+}
+
+// strRange converts the Lua indexes i and j of a string of length n to a Go slice range.
+func strRange(i, j int64, n int) (int, int) {
+	l := int64(n)
+	if i < 0 {
+		i = l + i + 1
+	}
+	if j < 0 {
+		j = l + j + 1
+	}
+	if i < 1 {
+		i = 1
+	}
+	if j > l {
+		j = l
+	}
+	if i > j {
+		return 0, 0
+	}
+	return int(i - 1), int(j)
+}
+
+func strSub(s *state, args []Value) (Value, error) {
+	str, err := checkString(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	i, err := optInt(args, 1, 1)
+	if err != nil {
+		return nil, err
+	}
+	j, err := optInt(args, 2, -1)
+	if err != nil {
+		return nil, err
+	}
+	start, end := strRange(i, j, len(str))
+	return str[start:end], nil
+}
+
+func strRep(s *state, args []Value) (Value, error) {
+	str, err := checkString(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	n, err := checkInt(args, 1)
+	if err != nil {
+		return nil, err
+	}
+	var sep string
+	if arg(args, 2) != nil {
+		if sep, err = checkString(args, 2); err != nil {
+			return nil, err
+		}
+	}
+	if n <= 0 {
+		return "", nil
+	}
+	// Check the length before creating the string.
+	total := float64(len(str)+len(sep))*float64(n) - float64(len(sep))
+	if s.limits.MaxStringLen > 0 && total > float64(s.limits.MaxStringLen) {
+		return nil, ErrStringLimit
+	}
+	if total > math.MaxInt32 {
+		return nil, &argError{n: 2, msg: "resulting string too large"}
+	}
+	var buf bytes.Buffer
+	for k := int64(0); k < n; k++ {
+		if k > 0 {
+			buf.WriteString(sep)
+		}
+		buf.WriteString(str)
+	}
+	return buf.String(), nil
+}
+
+func strReverse(s *state, args []Value) (Value, error) {
+	str, err := checkString(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	b := []byte(str)
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	return string(b), nil
+}
+
+// strByte returns the code of the i-th byte, the multiple results of Lua are not supported.
+func strByte(s *state, args []Value) (Value, error) {
+	str, err := checkString(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	i, err := optInt(args, 1, 1)
+	if err != nil {
+		return nil, err
+	}
+	start, end := strRange(i, i, len(str))
+	if start >= end {
+		return nil, nil
+	}
+	return int64(str[start]), nil
+}
+
+func strChar(s *state, args []Value) (Value, error) {
+	b := make([]byte, 0, len(args))
+	for i := range args {
+		c, err := checkInt(args, i)
+		if err != nil {
+			return nil, err
+		}
+		if c < 0 || c > 255 {
+			return nil, &argError{n: i + 1, msg: "value out of range"}
+		}
+		b = append(b, byte(c))
+	}
+	return string(b), nil
+}
+
+// strFormat supports the conversions %d, %i, %u, %c, %x, %X, %o, %e, %E, %f, %g, %G, %s and %%.
+func strFormat(s *state, args []Value) (Value, error) {
+	format, err := checkString(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	n := 1
+	for i := 0; i < len(format); i++ {
+		c := format[i]
+		if c != '%' {
+			buf.WriteByte(c)
+			continue
+		}
+		i++
+		if i < len(format) && format[i] == '%' {
+			buf.WriteByte('%')
+			continue
+		}
+		// Flags, width and precision.
+		start := i
+		for i < len(format) && strings.IndexByte("-+ #0123456789.", format[i]) >= 0 {
+			i++
+		}
+		if i >= len(format) {
+			return nil, &argError{n: 1, msg: "invalid conversion '%' to 'format'"}
+		}
+		spec := "%" + format[start:i]
+		if !validFormatSpec(format[start:i]) {
+			return nil, &argError{n: 1, msg: "invalid format (width or precision too long)"}
+		}
+		switch conv := format[i]; conv {
+		case 'd', 'i', 'u', 'c', 'x', 'X', 'o':
+			v, err := checkInt(args, n)
+			if err != nil {
+				return nil, err
+			}
+			switch conv {
+			case 'i', 'u':
+				conv = 'd'
+			}
+			fmt.Fprintf(&buf, spec+string(conv), v)
+		case 'e', 'E', 'f', 'F', 'g', 'G':
+			v, err := checkNumber(args, n)
+			if err != nil {
+				return nil, err
+			}
+			fmt.Fprintf(&buf, spec+string(conv), toFloat(v))
+		case 's':
+			fmt.Fprintf(&buf, spec+"s", ToString(arg(args, n)))
+		default:
+			return nil, &argError{n: 1, msg: fmt.Sprintf("invalid conversion '%s%c' to 'format'", spec, conv)}
+		}
+		n++
+		if s.limits.MaxStringLen > 0 && buf.Len() > s.limits.MaxStringLen {
+			return nil, ErrStringLimit
+		}
+	}
+	return buf.String(), nil
+}
+
+// validFormatSpec checks that the width and the precision have at most 2 digits like Lua, so a
+// conversion can't create a huge string.
+func validFormatSpec(spec string) bool {
+	spec = strings.TrimLeft(spec, "-+ #0")
+	parts := strings.Split(spec, ".")
+	if len(parts) > 2 {
+		return false
+	}
+	for _, part := range parts {
+		if len(part) > 2 || strings.Trim(part, "0123456789") != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,724 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package lua implements an interpreter of a subset of Lua 5.3 for the user-defined functions.
+//
+// The subset has the nil, boolean, number, string and function values, the local variables,
+// the control structures except goto and the generic for, and the string and math libraries.
+// Tables, varargs, multiple results and coroutines are not supported, so a script can only
+// compute a value from its arguments. The resources used by a call are bounded by Limits.
+//
+// The interpreter is kept in the tree instead of vendoring a full Lua implementation, because the
+// scripts run inside the server: they must not reach the os, io and package libraries or the
+// globals shared by the calls, and every call must be stopped by the step, string and call depth
+// limits, which the general purpose interpreters only offer as hooks on a global state that can't
+// be shared by the concurrent calls. The package has no dependencies on the rest of TiDB, so it can
+// be replaced by a vendored interpreter without changing the udf package.
+package lua
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/juju/errors"
+)
+
+// Value is a Lua value, it's nil, a bool, an int64, a float64, a string or a function.
+type Value interface{}
+
+// Error is a syntax error or a runtime error of a script.
+type Error struct {
+	Line int
+	Msg  string
+}
+
+// Error implements error interface.
+func (e *Error) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+}
+
+// Errors of the resource limits.
+var (
+	ErrStepLimit   = errors.New("the script exceeds the step limit")
+	ErrStringLimit = errors.New("the script creates a too long string")
+	ErrCallDepth   = errors.New("stack overflow")
+)
+
+// Limits limits the resources used by a call, a zero limit means unlimited.
+type Limits struct {
+	// MaxSteps is the max number of the statements and the expressions evaluated.
+	MaxSteps int64
+	// MaxStringLen is the max length of the strings created.
+	MaxStringLen int
+	// MaxCallDepth is the max depth of the nested function calls.
+	MaxCallDepth int
+}
+
+// Function is a compiled script, it's safe to be called concurrently.
+type Function struct {
+	params []string
+	body   *block
+}
+
+// Compile compiles the script as the body of a function with the params, the params are the
+// local variables of the script and the value it returns is the result.
+func Compile(params []string, src string) (*Function, error) {
+	body, err := parse(src)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &Function{params: params, body: body}, nil
+}
+
+// Call calls the function with the args, the missing args are nil.
+func (f *Function) Call(limits Limits, args ...Value) (Value, error) {
+	s := &state{limits: limits, globals: make(map[string]Value)}
+	fn := &closure{fn: &funcExpr{node: node{1}, params: f.params, body: f.body}}
+	v, err := s.call(fn, args, 0)
+	return v, errors.Trace(err)
+}
+
+type closure struct {
+	fn    *funcExpr
+	scope *scope
+}
+
+type goFunction struct {
+	name string
+	fn   func(s *state, args []Value) (Value, error)
+}
+
+// scope is a block of the local variables, it's captured by the closures defined in it.
+type scope struct {
+	parent *scope
+	names  []string
+	values []Value
+}
+
+func newScope(parent *scope) *scope {
+	return &scope{parent: parent}
+}
+
+func (sc *scope) declare(name string, v Value) {
+	sc.names = append(sc.names, name)
+	sc.values = append(sc.values, v)
+}
+
+// lookup finds the latest declared local variable with the name.
+func (sc *scope) lookup(name string) (*scope, int) {
+	for ; sc != nil; sc = sc.parent {
+		for i := len(sc.names) - 1; i >= 0; i-- {
+			if sc.names[i] == name {
+				return sc, i
+			}
+		}
+	}
+	return nil, -1
+}
+
+type state struct {
+	limits Limits
+	steps  int64
+	depth  int
+	// globals are the global variables assigned by the script, they overwrite the libraries.
+	globals map[string]Value
+}
+
+func (s *state) errorf(line int, format string, args ...interface{}) error {
+	return &Error{Line: line, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (s *state) step() error {
+	s.steps++
+	if s.limits.MaxSteps > 0 && s.steps > s.limits.MaxSteps {
+		return ErrStepLimit
+	}
+	return nil
+}
+
+func (s *state) checkString(str string) (Value, error) {
+	if s.limits.MaxStringLen > 0 && len(str) > s.limits.MaxStringLen {
+		return nil, ErrStringLimit
+	}
+	return str, nil
+}
+
+// control is how a statement transfers the control.
+type control int
+
+const (
+	ctlNext control = iota
+	ctlBreak
+	ctlReturn
+)
+
+func (s *state) call(fn Value, args []Value, line int) (Value, error) {
+	switch f := fn.(type) {
+	case *goFunction:
+		v, err := f.fn(s, args)
+		switch e := err.(type) {
+		case *argError:
+			return nil, s.errorf(line, "bad argument #%d to '%s' (%s)", e.n, f.name, e.msg)
+		case *raisedError:
+			return nil, s.errorf(line, "%s", e.msg)
+		}
+		return v, err
+	case *closure:
+		s.depth++
+		defer func() { s.depth-- }()
+		if s.limits.MaxCallDepth > 0 && s.depth > s.limits.MaxCallDepth {
+			return nil, ErrCallDepth
+		}
+		sc := newScope(f.scope)
+		for i, name := range f.fn.params {
+			var v Value
+			if i < len(args) {
+				v = args[i]
+			}
+			sc.declare(name, v)
+		}
+		ctl, v, err := s.execBlock(f.fn.body, sc)
+		if err != nil || ctl != ctlReturn {
+			return nil, err
+		}
+		return v, nil
+	}
+	return nil, s.errorf(line, "attempt to call a %s value", typeName(fn))
+}
+
+func (s *state) execBlock(b *block, sc *scope) (control, Value, error) {
+	for _, st := range b.stmts {
+		ctl, v, err := s.exec(st, sc)
+		if err != nil || ctl != ctlNext {
+			return ctl, v, err
+		}
+	}
+	return ctlNext, nil, nil
+}
+
+func (s *state) exec(st stmt, sc *scope) (control, Value, error) {
+	if err := s.step(); err != nil {
+		return ctlNext, nil, err
+	}
+	switch x := st.(type) {
+	case *localStmt:
+		values, err := s.evalList(x.exprs, len(x.names), sc)
+		if err != nil {
+			return ctlNext, nil, err
+		}
+		for i, name := range x.names {
+			sc.declare(name, values[i])
+		}
+	case *localFuncStmt:
+		// The function is declared before it's created, so it can call itself.
+		sc.declare(x.name, nil)
+		sc.values[len(sc.values)-1] = &closure{fn: x.fn, scope: sc}
+	case *assignStmt:
+		values, err := s.evalList(x.exprs, len(x.names), sc)
+		if err != nil {
+			return ctlNext, nil, err
+		}
+		for i, name := range x.names {
+			s.assign(name, values[i], sc)
+		}
+	case *callStmt:
+		_, err := s.eval(x.call, sc)
+		return ctlNext, nil, err
+	case *ifStmt:
+		for i, cond := range x.conds {
+			v, err := s.eval(cond, sc)
+			if err != nil {
+				return ctlNext, nil, err
+			}
+			if truthy(v) {
+				return s.execBlock(x.blocks[i], newScope(sc))
+			}
+		}
+		if x.elseBlock != nil {
+			return s.execBlock(x.elseBlock, newScope(sc))
+		}
+	case *whileStmt:
+		for {
+			v, err := s.eval(x.cond, sc)
+			if err != nil {
+				return ctlNext, nil, err
+			}
+			if !truthy(v) {
+				break
+			}
+			ctl, v, err := s.execBlock(x.body, newScope(sc))
+			if err != nil || ctl == ctlReturn {
+				return ctl, v, err
+			}
+			if ctl == ctlBreak {
+				break
+			}
+		}
+	case *repeatStmt:
+		for {
+			// The condition can see the local variables of the body.
+			body := newScope(sc)
+			ctl, v, err := s.execBlock(x.body, body)
+			if err != nil || ctl == ctlReturn {
+				return ctl, v, err
+			}
+			if ctl == ctlBreak {
+				break
+			}
+			if v, err = s.eval(x.cond, body); err != nil {
+				return ctlNext, nil, err
+			}
+			if truthy(v) {
+				break
+			}
+		}
+	case *forStmt:
+		return s.execFor(x, sc)
+	case *doStmt:
+		return s.execBlock(x.body, newScope(sc))
+	case *returnStmt:
+		if x.e == nil {
+			return ctlReturn, nil, nil
+		}
+		v, err := s.eval(x.e, sc)
+		return ctlReturn, v, err
+	case *breakStmt:
+		return ctlBreak, nil, nil
+	}
+	return ctlNext, nil, nil
+}
+
+func (s *state) execFor(x *forStmt, sc *scope) (control, Value, error) {
+	var bounds [3]Value
+	for i, e := range []expr{x.start, x.limit, x.step} {
+		if e == nil {
+			bounds[i] = int64(1)
+			continue
+		}
+		v, err := s.eval(e, sc)
+		if err != nil {
+			return ctlNext, nil, err
+		}
+		n, ok := toNumber(v)
+		if !ok {
+			return ctlNext, nil, s.errorf(x.line, "'for' %s must be a number", [3]string{"initial value", "limit", "step"}[i])
+		}
+		bounds[i] = n
+	}
+	start, startInt := bounds[0].(int64)
+	limit, limitInt := bounds[1].(int64)
+	step, stepInt := bounds[2].(int64)
+	if startInt && limitInt && stepInt {
+		if step == 0 {
+			return ctlNext, nil, s.errorf(x.line, "'for' step is zero")
+		}
+		for i := start; (step > 0 && i <= limit) || (step < 0 && i >= limit); i += step {
+			ctl, v, err := s.execForBody(x, i, sc)
+			if err != nil || ctl == ctlReturn {
+				return ctl, v, err
+			}
+			// Check the overflow before the next step.
+			if ctl == ctlBreak || (step > 0 && i > math.MaxInt64-step) || (step < 0 && i < math.MinInt64-step) {
+				break
+			}
+		}
+		return ctlNext, nil, nil
+	}
+	fstart, flimit, fstep := toFloat(bounds[0]), toFloat(bounds[1]), toFloat(bounds[2])
+	if fstep == 0 {
+		return ctlNext, nil, s.errorf(x.line, "'for' step is zero")
+	}
+	for i := fstart; (fstep > 0 && i <= flimit) || (fstep < 0 && i >= flimit); i += fstep {
+		ctl, v, err := s.execForBody(x, i, sc)
+		if err != nil || ctl == ctlReturn {
+			return ctl, v, err
+		}
+		if ctl == ctlBreak {
+			break
+		}
+	}
+	return ctlNext, nil, nil
+}
+
+func (s *state) execForBody(x *forStmt, i Value, sc *scope) (control, Value, error) {
+	// Every iteration has its own loop variable.
+	body := newScope(sc)
+	body.declare(x.name, i)
+	ctl, v, err := s.execBlock(x.body, body)
+	return ctl, v, err
+}
+
+func (s *state) assign(name string, v Value, sc *scope) {
+	if found, i := sc.lookup(name); found != nil {
+		found.values[i] = v
+		return
+	}
+	s.globals[name] = v
+}
+
+// evalList evaluates the exprs to n values, the missing values are nil.
+func (s *state) evalList(exprs []expr, n int, sc *scope) ([]Value, error) {
+	values := make([]Value, n)
+	for i, e := range exprs {
+		v, err := s.eval(e, sc)
+		if err != nil {
+			return nil, err
+		}
+		if i < n {
+			values[i] = v
+		}
+	}
+	return values, nil
+}
+
+func (s *state) eval(e expr, sc *scope) (Value, error) {
+	if err := s.step(); err != nil {
+		return nil, err
+	}
+	switch x := e.(type) {
+	case *constExpr:
+		return x.value, nil
+	case *nameExpr:
+		if found, i := sc.lookup(x.name); found != nil {
+			return found.values[i], nil
+		}
+		if v, ok := s.globals[x.name]; ok {
+			return v, nil
+		}
+		return builtins[x.name], nil
+	case *fieldExpr:
+		if found, _ := sc.lookup(x.lib); found != nil {
+			return nil, s.errorf(x.line, "attempt to index a local variable '%s'", x.lib)
+		}
+		if _, ok := s.globals[x.lib]; ok || !libs[x.lib] {
+			return nil, s.errorf(x.line, "attempt to index a non-library global '%s'", x.lib)
+		}
+		return builtins[x.lib+"."+x.name], nil
+	case *funcExpr:
+		return &closure{fn: x, scope: sc}, nil
+	case *callExpr:
+		return s.evalCall(x, sc)
+	case *unaryExpr:
+		v, err := s.eval(x.e, sc)
+		if err != nil {
+			return nil, err
+		}
+		return s.unary(x, v)
+	case *binaryExpr:
+		return s.evalBinary(x, sc)
+	}
+	return nil, s.errorf(e.exprLine(), "unknown expression")
+}
+
+func (s *state) evalCall(x *callExpr, sc *scope) (Value, error) {
+	fn, err := s.eval(x.fn, sc)
+	if err != nil {
+		return nil, err
+	}
+	args := make([]Value, 0, len(x.args)+1)
+	if x.method != "" {
+		// Only the strings have methods, they are the functions of the string library.
+		if _, ok := fn.(string); !ok {
+			return nil, s.errorf(x.line, "attempt to index a %s value", typeName(fn))
+		}
+		args = append(args, fn)
+		if fn = builtins["string."+x.method]; fn == nil {
+			return nil, s.errorf(x.line, "attempt to call a nil value (method '%s')", x.method)
+		}
+	}
+	for _, arg := range x.args {
+		v, err := s.eval(arg, sc)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, v)
+	}
+	return s.call(fn, args, x.line)
+}
+
+func (s *state) evalBinary(x *binaryExpr, sc *scope) (Value, error) {
+	l, err := s.eval(x.l, sc)
+	if err != nil {
+		return nil, err
+	}
+	// "and" and "or" are short-circuit.
+	switch x.op {
+	case "and":
+		if !truthy(l) {
+			return l, nil
+		}
+		return s.eval(x.r, sc)
+	case "or":
+		if truthy(l) {
+			return l, nil
+		}
+		return s.eval(x.r, sc)
+	}
+	r, err := s.eval(x.r, sc)
+	if err != nil {
+		return nil, err
+	}
+	switch x.op {
+	case "==":
+		return rawEqual(l, r), nil
+	case "~=":
+		return !rawEqual(l, r), nil
+	case "<", "<=", ">", ">=":
+		return s.compare(x, l, r)
+	case "..":
+		ls, lok := toStringCoerce(l)
+		rs, rok := toStringCoerce(r)
+		if !lok || !rok {
+			bad := l
+			if lok {
+				bad = r
+			}
+			return nil, s.errorf(x.line, "attempt to concatenate a %s value", typeName(bad))
+		}
+		if s.limits.MaxStringLen > 0 && len(ls)+len(rs) > s.limits.MaxStringLen {
+			return nil, ErrStringLimit
+		}
+		return ls + rs, nil
+	}
+	return s.arith(x, l, r)
+}
+
+func (s *state) unary(x *unaryExpr, v Value) (Value, error) {
+	switch x.op {
+	case "not":
+		return !truthy(v), nil
+	case "#":
+		str, ok := v.(string)
+		if !ok {
+			return nil, s.errorf(x.line, "attempt to get length of a %s value", typeName(v))
+		}
+		return int64(len(str)), nil
+	}
+	n, ok := toNumber(v)
+	if !ok {
+		return nil, s.errorf(x.line, "attempt to perform arithmetic on a %s value", typeName(v))
+	}
+	if i, ok := n.(int64); ok {
+		return -i, nil
+	}
+	return -n.(float64), nil
+}
+
+func (s *state) arith(x *binaryExpr, l, r Value) (Value, error) {
+	ln, lok := toNumber(l)
+	rn, rok := toNumber(r)
+	if !lok || !rok {
+		bad := l
+		if lok {
+			bad = r
+		}
+		return nil, s.errorf(x.line, "attempt to perform arithmetic on a %s value", typeName(bad))
+	}
+	li, lInt := ln.(int64)
+	ri, rInt := rn.(int64)
+	if lInt && rInt {
+		switch x.op {
+		case "+":
+			return li + ri, nil
+		case "-":
+			return li - ri, nil
+		case "*":
+			return li * ri, nil
+		case "//":
+			if ri == 0 {
+				return nil, s.errorf(x.line, "attempt to perform 'n//0'")
+			}
+			return floorDiv(li, ri), nil
+		case "%":
+			if ri == 0 {
+				return nil, s.errorf(x.line, "attempt to perform 'n%%0'")
+			}
+			return li - floorDiv(li, ri)*ri, nil
+		}
+	}
+	lf, rf := toFloat(ln), toFloat(rn)
+	switch x.op {
+	case "+":
+		return lf + rf, nil
+	case "-":
+		return lf - rf, nil
+	case "*":
+		return lf * rf, nil
+	case "/":
+		return lf / rf, nil
+	case "//":
+		return math.Floor(lf / rf), nil
+	case "%":
+		m := math.Mod(lf, rf)
+		if m != 0 && (m < 0) != (rf < 0) {
+			m += rf
+		}
+		return m, nil
+	case "^":
+		return math.Pow(lf, rf), nil
+	}
+	return nil, s.errorf(x.line, "unknown operator '%s'", x.op)
+}
+
+func floorDiv(a, b int64) int64 {
+	if b == -1 {
+		// Avoid the overflow of math.MinInt64 / -1.
+		return -a
+	}
+	q := a / b
+	if (a%b != 0) && ((a < 0) != (b < 0)) {
+		q--
+	}
+	return q
+}
+
+func (s *state) compare(x *binaryExpr, l, r Value) (Value, error) {
+	var less, equal bool
+	ln, lok := l.(int64)
+	rn, rok := r.(int64)
+	ls, lstr := l.(string)
+	rs, rstr := r.(string)
+	switch {
+	case lok && rok:
+		less, equal = ln < rn, ln == rn
+	case isNumber(l) && isNumber(r):
+		lf, rf := toFloat(l), toFloat(r)
+		less, equal = lf < rf, lf == rf
+	case lstr && rstr:
+		less, equal = ls < rs, ls == rs
+	default:
+		return nil, s.errorf(x.line, "attempt to compare %s with %s", typeName(l), typeName(r))
+	}
+	switch x.op {
+	case "<":
+		return less, nil
+	case "<=":
+		return less || equal, nil
+	case ">":
+		return !less && !equal, nil
+	}
+	return !less, nil
+}
+
+func truthy(v Value) bool {
+	if v == nil {
+		return false
+	}
+	if b, ok := v.(bool); ok {
+		return b
+	}
+	return true
+}
+
+func rawEqual(l, r Value) bool {
+	if isNumber(l) && isNumber(r) {
+		li, lok := l.(int64)
+		ri, rok := r.(int64)
+		if lok && rok {
+			return li == ri
+		}
+		return toFloat(l) == toFloat(r)
+	}
+	return l == r
+}
+
+func isNumber(v Value) bool {
+	switch v.(type) {
+	case int64, float64:
+		return true
+	}
+	return false
+}
+
+// toNumber converts the value to an int64 or a float64, a string is converted like Lua.
+func toNumber(v Value) (Value, bool) {
+	switch x := v.(type) {
+	case int64, float64:
+		return x, true
+	case string:
+		return parseNumber(x)
+	}
+	return nil, false
+}
+
+func toFloat(v Value) float64 {
+	if i, ok := v.(int64); ok {
+		return float64(i)
+	}
+	return v.(float64)
+}
+
+// toStringCoerce converts a string or a number to a string.
+func toStringCoerce(v Value) (string, bool) {
+	switch x := v.(type) {
+	case string:
+		return x, true
+	case int64, float64:
+		return ToString(x), true
+	}
+	return "", false
+}
+
+// ToString converts the value to a string like the tostring function of Lua.
+func ToString(v Value) string {
+	switch x := v.(type) {
+	case nil:
+		return "nil"
+	case bool:
+		return strconv.FormatBool(x)
+	case int64:
+		return strconv.FormatInt(x, 10)
+	case float64:
+		return formatFloat(x)
+	case string:
+		return x
+	case *closure, *goFunction:
+		return fmt.Sprintf("function: %p", x)
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "inf"
+	case math.IsInf(f, -1):
+		return "-inf"
+	case math.IsNaN(f):
+		return "nan"
+	}
+	s := strconv.FormatFloat(f, 'g', 14, 64)
+	if !strings.ContainsAny(s, ".e") {
+		// A float looks like a float even if it's integral, like Lua 5.3.
+		s += ".0"
+	}
+	return s
+}
+
+func typeName(v Value) string {
+	switch v.(type) {
+	case nil:
+		return "nil"
+	case bool:
+		return "boolean"
+	case int64, float64:
+		return "number"
+	case string:
+		return "string"
+	case *closure, *goFunction:
+		return "function"
+	}
+	return "userdata"
+}
@@ -0,0 +1,247 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lua
+
+import (
+	"math"
+	"testing"
+
+	"github.com/juju/errors"
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/util/testleak"
+)
+
+func TestT(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testLuaSuite{})
+
+type testLuaSuite struct{}
+
+func (s *testLuaSuite) run(c *C, src string, args ...Value) (Value, error) {
+	params := []string{"a", "b", "c"}[:len(args)]
+	f, err := Compile(params, src)
+	c.Assert(err, IsNil, Commentf("%s", src))
+	return f.Call(Limits{MaxSteps: 100000, MaxStringLen: 1024, MaxCallDepth: 50}, args...)
+}
+
+func (s *testLuaSuite) TestEval(c *C) {
+	defer testleak.AfterTest(c)()
+	tests := []struct {
+		src    string
+		args   []Value
+		result Value
+	}{
+		{"return 1 + 2 * 3", nil, int64(7)},
+		{"return (1 + 2) * 3", nil, int64(9)},
+		{"return 7 // 2", nil, int64(3)},
+		{"return -7 // 2", nil, int64(-4)},
+		{"return -7 % 3", nil, int64(2)},
+		{"return 7.5 % -2", nil, -0.5},
+		{"return 1 / 2", nil, 0.5},
+		{"return 2 ^ 10", nil, float64(1024)},
+		{"return -2 ^ 2", nil, float64(-4)},
+		{"return 0x10 + 1e2", nil, float64(116)},
+		{"return math.maxinteger + 1 == math.mininteger", nil, true},
+		{`return "10" + 1`, nil, int64(11)},
+		{`return "a" .. 1 .. "b" .. 1.5`, nil, "a1b1.5"},
+		{"return 2.0 .. ''", nil, "2.0"},
+		{"return #'hello'", nil, int64(5)},
+		{"return 1 < 2 and 'yes' or 'no'", nil, "yes"},
+		{"return nil or false", nil, false},
+		{"return not nil", nil, true},
+		{"return 1 == 1.0", nil, true},
+		{"return 'a' < 'b'", nil, true},
+		{"return a + b", []Value{int64(1), 2.5}, 3.5},
+		{"return a", []Value{nil}, nil},
+		{"return", nil, nil},
+		{"", nil, nil},
+		{`local s = 0
+		for i = 1, 10 do s = s + i end
+		return s`, nil, int64(55)},
+		{`local s = 0
+		for i = 10, 1, -3 do s = s * 10 + i end
+		return s`, nil, int64(10741)},
+		{`local s = 0
+		for i = 0, 1, 0.25 do s = s + i end
+		return s`, nil, 2.5},
+		{`local i, s = 0, 0
+		while true do
+			i = i + 1
+			if i > 5 then break elseif i % 2 == 0 then s = s + i else s = s - i end
+		end
+		return s`, nil, int64(-3)},
+		{`local i = 0
+		repeat local j = i; i = i + 1 until j >= 3
+		return i`, nil, int64(4)},
+		{`local function fib(n) if n < 2 then return n end return fib(n - 1) + fib(n - 2) end
+		return fib(a)`, []Value{int64(15)}, int64(610)},
+		{`function twice(f, x) return f(f(x)) end
+		return twice(function(x) return x * a end, 3)`, []Value{int64(2)}, int64(12)},
+		{`local function counter() local n = 0; return function() n = n + 1; return n end end
+		local f = counter(); f(); f()
+		return f()`, nil, int64(3)},
+		{`local x = 1
+		do local x = 2 end
+		return x`, nil, int64(1)},
+		{"a, b = b, a return a .. b", []Value{"x", "y"}, "yx"},
+		{"return tostring(nil) .. tostring(true) .. tostring(12)", nil, "niltrue12"},
+		{"return tonumber('0x1F') + tonumber('z', 36) + tonumber(' 1.5 ')", nil, 67.5},
+		{"return tonumber('abc')", nil, nil},
+		{"return type(a) .. type(1) .. type('') .. type(type)", []Value{nil}, "nilnumberstringfunction"},
+		{"return math.floor(3.7) + math.ceil(3.2) + math.abs(-2)", nil, int64(9)},
+		{"return math.max(1, 5.5, 3) + math.min(4, 2)", nil, 7.5},
+		{"return math.fmod(-7, 3)", nil, int64(-1)},
+		{"return math.tointeger(3.0)", nil, int64(3)},
+		{"return math.sqrt(16)", nil, float64(4)},
+		{"return math.log(8, 2)", nil, float64(3)},
+		{"return math.huge > math.maxinteger", nil, true},
+		{"return string.upper(a) .. a:lower() .. ('x'):rep(3, '-')", []Value{"Ab"}, "ABabx-x-x"},
+		{"return a:sub(2, -2) .. a:sub(-1) .. a:sub(10)", []Value{"hello"}, "ello"},
+		{"return a:len() + a:byte(1)", []Value{"A"}, int64(66)},
+		{"return string.char(72, 105) .. string.reverse('abc')", nil, "Hicba"},
+		{"return string.format('%d-%5.2f-%s-%x-%%', 42, 3.14159, 'x', 255)", nil, "42- 3.14-x-ff-%"},
+		{"-- comment\nreturn --[[ long\ncomment ]] [[long\nstring]]", nil, "long\nstring"},
+		{`return "tab\tnew\nline\65"`, nil, "tab\tnew\nlineA"},
+	}
+	for _, t := range tests {
+		v, err := s.run(c, t.src, t.args...)
+		c.Assert(err, IsNil, Commentf("%s", t.src))
+		c.Assert(v, DeepEquals, t.result, Commentf("%s", t.src))
+	}
+}
+
+func (s *testLuaSuite) TestSyntaxError(c *C) {
+	defer testleak.AfterTest(c)()
+	tests := []struct {
+		src string
+		msg string
+	}{
+		{"return 1 +", "line 1: unexpected symbol near '<eof>'"},
+		{"if a then", "line 1: 'end' expected near '<eof>'"},
+		{"return 1 return 2", "line 1: 'end' expected near 'return'"},
+		{"return 1, 2", "line 1: multiple return values are not supported"},
+		{"local t = {}", "line 1: tables are not supported"},
+		{"return a[1]", "line 1: tables are not supported"},
+		{"for k, v in x do end", "line 1: generic for is not supported"},
+		{"x\n= = 1", "line 2: unexpected symbol near '='"},
+		{"1 + 1", "line 1: unexpected symbol near '1'"},
+		{"x", "line 1: syntax error near '<eof>'"},
+		{"return 'abc", "line 1: unfinished string"},
+		{"return 3x", "line 1: malformed number near '3'"},
+		{"return @", "line 1: unexpected symbol near '@'"},
+	}
+	for _, t := range tests {
+		_, err := Compile(nil, t.src)
+		c.Assert(err, NotNil, Commentf("%s", t.src))
+		c.Assert(errors.Cause(err).Error(), Equals, t.msg, Commentf("%s", t.src))
+	}
+	deep := ""
+	for i := 0; i < maxSyntaxLevels; i++ {
+		deep += "("
+	}
+	_, err := Compile(nil, "return "+deep+"1")
+	c.Assert(errors.Cause(err).Error(), Equals, "line 1: chunk has too many syntax levels")
+}
+
+func (s *testLuaSuite) TestRuntimeError(c *C) {
+	defer testleak.AfterTest(c)()
+	tests := []struct {
+		src string
+		msg string
+	}{
+		{"return 1 + nil", "line 1: attempt to perform arithmetic on a nil value"},
+		{"return {} ", "line 1: tables are not supported"},
+		{"return 1 // 0", "line 1: attempt to perform 'n//0'"},
+		{"return 1 < 'x'", "line 1: attempt to compare number with string"},
+		{"return 'x' .. nil", "line 1: attempt to concatenate a nil value"},
+		{"return #1", "line 1: attempt to get length of a number value"},
+		{"\nreturn f()", "line 2: attempt to call a nil value"},
+		{"return (1):upper()", "line 1: attempt to index a number value"},
+		{"return math.nope(1)", "line 1: attempt to call a nil value"},
+		{"local math = 1 return math.pi", "line 1: attempt to index a local variable 'math'"},
+		{"return foo.bar", "line 1: attempt to index a non-library global 'foo'"},
+		{"error('boom')", "line 1: boom"},
+		{"assert(false, 'bad input')", "line 1: bad input"},
+		{"return math.floor('x')", "line 1: bad argument #1 to 'floor' (number expected, got string)"},
+		{"return string.rep('x', 1.5)", "line 1: bad argument #2 to 'rep' (number has no integer representation)"},
+		{"return string.format('%d', 1.5)", "line 1: bad argument #2 to 'format' (number has no integer representation)"},
+		{"return string.format('%100d', 1)", "line 1: bad argument #1 to 'format' (invalid format (width or precision too long))"},
+		{"for i = 1, 10, 0 do end", "line 1: 'for' step is zero"},
+	}
+	for _, t := range tests {
+		f, err := Compile(nil, t.src)
+		if err == nil {
+			_, err = f.Call(Limits{})
+		}
+		c.Assert(err, NotNil, Commentf("%s", t.src))
+		c.Assert(errors.Cause(err).Error(), Equals, t.msg, Commentf("%s", t.src))
+	}
+}
+
+func (s *testLuaSuite) TestLimits(c *C) {
+	defer testleak.AfterTest(c)()
+	f, err := Compile(nil, "while true do end")
+	c.Assert(err, IsNil)
+	_, err = f.Call(Limits{MaxSteps: 1000})
+	c.Assert(errors.Cause(err), Equals, ErrStepLimit)
+
+	f, err = Compile([]string{"n"}, "local s = '' for i = 1, n do s = s .. 'x' end return s")
+	c.Assert(err, IsNil)
+	v, err := f.Call(Limits{MaxStringLen: 10}, int64(10))
+	c.Assert(err, IsNil)
+	c.Assert(v, Equals, "xxxxxxxxxx")
+	_, err = f.Call(Limits{MaxStringLen: 10}, int64(11))
+	c.Assert(errors.Cause(err), Equals, ErrStringLimit)
+
+	f, err = Compile(nil, "return string.rep('x', math.maxinteger)")
+	c.Assert(err, IsNil)
+	_, err = f.Call(Limits{MaxStringLen: 1 << 20})
+	c.Assert(errors.Cause(err), Equals, ErrStringLimit)
+
+	f, err = Compile(nil, "local function f(n) return f(n + 1) end return f(1)")
+	c.Assert(err, IsNil)
+	_, err = f.Call(Limits{MaxCallDepth: 100})
+	c.Assert(errors.Cause(err), Equals, ErrCallDepth)
+
+	// The limits are counted per call.
+	f, err = Compile([]string{"n"}, "local s = 0 for i = 1, n do s = s + i end return s")
+	c.Assert(err, IsNil)
+	for i := 0; i < 3; i++ {
+		v, err = f.Call(Limits{MaxSteps: 100}, int64(10))
+		c.Assert(err, IsNil)
+		c.Assert(v, Equals, int64(55))
+	}
+}
+
+func (s *testLuaSuite) TestToString(c *C) {
+	defer testleak.AfterTest(c)()
+	tests := []struct {
+		v   Value
+		str string
+	}{
+		{nil, "nil"},
+		{false, "false"},
+		{int64(-3), "-3"},
+		{1.5, "1.5"},
+		{float64(10), "10.0"},
+		{1e100, "1e+100"},
+		{math.Inf(-1), "-inf"},
+		{"str", "str"},
+	}
+	for _, t := range tests {
+		c.Assert(ToString(t.v), Equals, t.str)
+	}
+}
@@ -0,0 +1,717 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lua
+
+import (
+	"fmt"
+)
+
+type expr interface {
+	exprLine() int
+}
+
+type stmt interface {
+	stmtLine() int
+}
+
+type node struct {
+	line int
+}
+
+func (n node) exprLine() int { return n.line }
+func (n node) stmtLine() int { return n.line }
+
+type block struct {
+	stmts []stmt
+}
+
+type (
+	constExpr struct {
+		node
+		value Value
+	}
+	nameExpr struct {
+		node
+		name string
+	}
+	// fieldExpr is lib.name, the fields can only be read from the libraries as there is no table.
+	fieldExpr struct {
+		node
+		lib  string
+		name string
+	}
+	binaryExpr struct {
+		node
+		op   string
+		l, r expr
+	}
+	unaryExpr struct {
+		node
+		op string
+		e  expr
+	}
+	callExpr struct {
+		node
+		fn   expr
+		args []expr
+		// method is set for a method call like s:upper().
+		method string
+	}
+	funcExpr struct {
+		node
+		params []string
+		body   *block
+	}
+)
+
+type (
+	localStmt struct {
+		node
+		names []string
+		exprs []expr
+	}
+	assignStmt struct {
+		node
+		names []string
+		exprs []expr
+	}
+	callStmt struct {
+		node
+		call *callExpr
+	}
+	ifStmt struct {
+		node
+		conds  []expr
+		blocks []*block
+		// elseBlock is nil if there is no else.
+		elseBlock *block
+	}
+	whileStmt struct {
+		node
+		cond expr
+		body *block
+	}
+	repeatStmt struct {
+		node
+		body *block
+		cond expr
+	}
+	forStmt struct {
+		node
+		name               string
+		start, limit, step expr
+		body               *block
+	}
+	doStmt struct {
+		node
+		body *block
+	}
+	returnStmt struct {
+		node
+		// e is nil if nothing is returned.
+		e expr
+	}
+	breakStmt struct {
+		node
+	}
+	localFuncStmt struct {
+		node
+		name string
+		fn   *funcExpr
+	}
+)
+
+// maxSyntaxLevels limits the nesting of the blocks and the expressions, so the recursion of the
+// parser and the interpreter is bounded.
+const maxSyntaxLevels = 200
+
+type parser struct {
+	lex    *lexer
+	tok    token
+	peeked *token
+	levels int
+}
+
+func parse(src string) (*block, error) {
+	p := &parser{lex: newLexer(src)}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	b, err := p.block()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.tp != tokEOF {
+		return nil, p.errorf("'<eof>' expected near '%s'", p.tok)
+	}
+	return b, nil
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return &Error{Line: p.tok.line, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) advance() error {
+	if p.peeked != nil {
+		p.tok, p.peeked = *p.peeked, nil
+		return nil
+	}
+	tok, err := p.lex.next()
+	p.tok = tok
+	return err
+}
+
+func (p *parser) peek() (token, error) {
+	if p.peeked == nil {
+		tok, err := p.lex.next()
+		if err != nil {
+			return tok, err
+		}
+		p.peeked = &tok
+	}
+	return *p.peeked, nil
+}
+
+// is checks whether the current token is the keyword or the operator.
+func (p *parser) is(text string) bool {
+	return (p.tok.tp == tokKeyword || p.tok.tp == tokOp) && p.tok.text == text
+}
+
+func (p *parser) expect(text string) error {
+	if !p.is(text) {
+		return p.errorf("'%s' expected near '%s'", text, p.tok)
+	}
+	return p.advance()
+}
+
+func (p *parser) name() (string, error) {
+	if p.tok.tp != tokName {
+		return "", p.errorf("<name> expected near '%s'", p.tok)
+	}
+	name := p.tok.text
+	return name, p.advance()
+}
+
+func (p *parser) enter() error {
+	p.levels++
+	if p.levels > maxSyntaxLevels {
+		return p.errorf("chunk has too many syntax levels")
+	}
+	return nil
+}
+
+func (p *parser) leave() {
+	p.levels--
+}
+
+func (p *parser) blockEnd() bool {
+	return p.tok.tp == tokEOF || p.is("end") || p.is("else") || p.is("elseif") || p.is("until")
+}
+
+func (p *parser) block() (*block, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+	b := &block{}
+	for !p.blockEnd() {
+		if p.is("return") {
+			s, err := p.returnStmt()
+			if err != nil {
+				return nil, err
+			}
+			b.stmts = append(b.stmts, s)
+			if !p.blockEnd() {
+				return nil, p.errorf("'end' expected near '%s'", p.tok)
+			}
+			break
+		}
+		s, err := p.statement()
+		if err != nil {
+			return nil, err
+		}
+		if s != nil {
+			b.stmts = append(b.stmts, s)
+		}
+	}
+	return b, nil
+}
+
+func (p *parser) statement() (stmt, error) {
+	line := p.tok.line
+	if p.tok.tp == tokKeyword {
+		switch p.tok.text {
+		case "if":
+			return p.ifStmt()
+		case "while":
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			cond, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			body, err := p.doEnd()
+			return &whileStmt{node: node{line}, cond: cond, body: body}, err
+		case "do":
+			body, err := p.doEnd()
+			return &doStmt{node: node{line}, body: body}, err
+		case "for":
+			return p.forStmt()
+		case "repeat":
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			body, err := p.block()
+			if err != nil {
+				return nil, err
+			}
+			if err = p.expect("until"); err != nil {
+				return nil, err
+			}
+			cond, err := p.expr()
+			return &repeatStmt{node: node{line}, body: body, cond: cond}, err
+		case "function":
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			if p.is(".") || p.is(":") {
+				return nil, p.errorf("tables are not supported")
+			}
+			fn, err := p.funcBody(line)
+			return &assignStmt{node: node{line}, names: []string{name}, exprs: []expr{fn}}, err
+		case "local":
+			return p.localStmt()
+		case "break":
+			return &breakStmt{node: node{line}}, p.advance()
+		case "goto":
+			return nil, p.errorf("goto is not supported")
+		}
+	}
+	if p.is(";") {
+		return nil, p.advance()
+	}
+	if p.is("::") {
+		return nil, p.errorf("labels are not supported")
+	}
+	return p.exprStmt()
+}
+
+func (p *parser) doEnd() (*block, error) {
+	if err := p.expect("do"); err != nil {
+		return nil, err
+	}
+	body, err := p.block()
+	if err != nil {
+		return nil, err
+	}
+	return body, p.expect("end")
+}
+
+func (p *parser) ifStmt() (stmt, error) {
+	s := &ifStmt{node: node{p.tok.line}}
+	for p.is("if") || p.is("elseif") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		cond, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		if err = p.expect("then"); err != nil {
+			return nil, err
+		}
+		b, err := p.block()
+		if err != nil {
+			return nil, err
+		}
+		s.conds = append(s.conds, cond)
+		s.blocks = append(s.blocks, b)
+	}
+	if p.is("else") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		b, err := p.block()
+		if err != nil {
+			return nil, err
+		}
+		s.elseBlock = b
+	}
+	return s, p.expect("end")
+}
+
+func (p *parser) forStmt() (stmt, error) {
+	s := &forStmt{node: node{p.tok.line}}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	var err error
+	if s.name, err = p.name(); err != nil {
+		return nil, err
+	}
+	if p.is(",") || p.is("in") {
+		return nil, p.errorf("generic for is not supported")
+	}
+	if err = p.expect("="); err != nil {
+		return nil, err
+	}
+	if s.start, err = p.expr(); err != nil {
+		return nil, err
+	}
+	if err = p.expect(","); err != nil {
+		return nil, err
+	}
+	if s.limit, err = p.expr(); err != nil {
+		return nil, err
+	}
+	if p.is(",") {
+		if err = p.advance(); err != nil {
+			return nil, err
+		}
+		if s.step, err = p.expr(); err != nil {
+			return nil, err
+		}
+	}
+	s.body, err = p.doEnd()
+	return s, err
+}
+
+func (p *parser) localStmt() (stmt, error) {
+	line := p.tok.line
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	if p.is("function") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		fn, err := p.funcBody(line)
+		return &localFuncStmt{node: node{line}, name: name, fn: fn}, err
+	}
+	s := &localStmt{node: node{line}}
+	for {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		s.names = append(s.names, name)
+		if !p.is(",") {
+			break
+		}
+		if err = p.advance(); err != nil {
+			return nil, err
+		}
+	}
+	if p.is("=") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		exprs, err := p.exprList()
+		if err != nil {
+			return nil, err
+		}
+		s.exprs = exprs
+	}
+	return s, nil
+}
+
+func (p *parser) returnStmt() (stmt, error) {
+	s := &returnStmt{node: node{p.tok.line}}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	if !p.blockEnd() && !p.is(";") {
+		e, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		if p.is(",") {
+			return nil, p.errorf("multiple return values are not supported")
+		}
+		s.e = e
+	}
+	if p.is(";") {
+		return s, p.advance()
+	}
+	return s, nil
+}
+
+func (p *parser) exprStmt() (stmt, error) {
+	line := p.tok.line
+	e, err := p.suffixedExpr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.is("=") && !p.is(",") {
+		call, ok := e.(*callExpr)
+		if !ok {
+			return nil, p.errorf("syntax error near '%s'", p.tok)
+		}
+		return &callStmt{node: node{line}, call: call}, nil
+	}
+	s := &assignStmt{node: node{line}}
+	for {
+		name, ok := e.(*nameExpr)
+		if !ok {
+			return nil, p.errorf("syntax error near '%s', only variables can be assigned", p.tok)
+		}
+		s.names = append(s.names, name.name)
+		if !p.is(",") {
+			break
+		}
+		if err = p.advance(); err != nil {
+			return nil, err
+		}
+		if e, err = p.suffixedExpr(); err != nil {
+			return nil, err
+		}
+	}
+	if err = p.expect("="); err != nil {
+		return nil, err
+	}
+	s.exprs, err = p.exprList()
+	return s, err
+}
+
+func (p *parser) exprList() ([]expr, error) {
+	var exprs []expr
+	for {
+		e, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, e)
+		if !p.is(",") {
+			return exprs, nil
+		}
+		if err = p.advance(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *parser) funcBody(line int) (*funcExpr, error) {
+	fn := &funcExpr{node: node{line}}
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	for !p.is(")") {
+		if p.is("...") {
+			return nil, p.errorf("varargs are not supported")
+		}
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		fn.params = append(fn.params, name)
+		if !p.is(",") {
+			break
+		}
+		if err = p.advance(); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	body, err := p.block()
+	if err != nil {
+		return nil, err
+	}
+	fn.body = body
+	return fn, p.expect("end")
+}
+
+// binaryPriority is the {left, right} priority of the binary operators like Lua.
+var binaryPriority = map[string][2]int{
+	"or": {1, 1}, "and": {2, 2},
+	"<": {3, 3}, ">": {3, 3}, "<=": {3, 3}, ">=": {3, 3}, "~=": {3, 3}, "==": {3, 3},
+	"..": {9, 8}, "+": {10, 10}, "-": {10, 10},
+	"*": {11, 11}, "/": {11, 11}, "//": {11, 11}, "%": {11, 11},
+	"^": {14, 13},
+}
+
+const unaryPriority = 12
+
+func (p *parser) expr() (expr, error) {
+	return p.subExpr(0)
+}
+
+// subExpr parses the expression whose binary operators have the priorities higher than limit.
+func (p *parser) subExpr(limit int) (expr, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+	var (
+		e   expr
+		err error
+	)
+	if p.is("not") || p.is("-") || p.is("#") {
+		u := &unaryExpr{node: node{p.tok.line}, op: p.tok.text}
+		if err = p.advance(); err != nil {
+			return nil, err
+		}
+		if u.e, err = p.subExpr(unaryPriority); err != nil {
+			return nil, err
+		}
+		e = u
+	} else if e, err = p.simpleExpr(); err != nil {
+		return nil, err
+	}
+	for p.tok.tp == tokOp || p.tok.tp == tokKeyword {
+		pri, ok := binaryPriority[p.tok.text]
+		if !ok || pri[0] <= limit {
+			break
+		}
+		b := &binaryExpr{node: node{p.tok.line}, op: p.tok.text, l: e}
+		if err = p.advance(); err != nil {
+			return nil, err
+		}
+		if b.r, err = p.subExpr(pri[1]); err != nil {
+			return nil, err
+		}
+		e = b
+	}
+	return e, nil
+}
+
+func (p *parser) simpleExpr() (expr, error) {
+	line := p.tok.line
+	switch {
+	case p.tok.tp == tokNumber:
+		e := &constExpr{node: node{line}, value: p.tok.num}
+		return e, p.advance()
+	case p.tok.tp == tokString:
+		e := &constExpr{node: node{line}, value: p.tok.text}
+		return e, p.advance()
+	case p.is("nil"):
+		return &constExpr{node: node{line}}, p.advance()
+	case p.is("true"):
+		return &constExpr{node: node{line}, value: true}, p.advance()
+	case p.is("false"):
+		return &constExpr{node: node{line}, value: false}, p.advance()
+	case p.is("function"):
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		return p.funcBody(line)
+	case p.is("..."):
+		return nil, p.errorf("varargs are not supported")
+	case p.is("{"):
+		return nil, p.errorf("tables are not supported")
+	}
+	return p.suffixedExpr()
+}
+
+func (p *parser) primaryExpr() (expr, error) {
+	line := p.tok.line
+	if p.is("(") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		e, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		return e, p.expect(")")
+	}
+	if p.tok.tp != tokName {
+		return nil, p.errorf("unexpected symbol near '%s'", p.tok)
+	}
+	name := p.tok.text
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	if p.is(".") {
+		// Only the fields of the libraries like math.pi can be read.
+		next, err := p.peek()
+		if err != nil {
+			return nil, err
+		}
+		if next.tp != tokName {
+			return nil, p.errorf("<name> expected near '%s'", next)
+		}
+		if err = p.advance(); err != nil {
+			return nil, err
+		}
+		if err = p.advance(); err != nil {
+			return nil, err
+		}
+		return &fieldExpr{node: node{line}, lib: name, name: next.text}, nil
+	}
+	return &nameExpr{node: node{line}, name: name}, nil
+}
+
+func (p *parser) suffixedExpr() (expr, error) {
+	e, err := p.primaryExpr()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		line := p.tok.line
+		switch {
+		case p.is("(") || p.tok.tp == tokString:
+			args, err := p.callArgs()
+			if err != nil {
+				return nil, err
+			}
+			e = &callExpr{node: node{line}, fn: e, args: args}
+		case p.is(":"):
+			if err = p.advance(); err != nil {
+				return nil, err
+			}
+			method, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			args, err := p.callArgs()
+			if err != nil {
+				return nil, err
+			}
+			e = &callExpr{node: node{line}, fn: e, args: args, method: method}
+		case p.is(".") || p.is("["):
+			return nil, p.errorf("tables are not supported")
+		default:
+			return e, nil
+		}
+	}
+}
+
+func (p *parser) callArgs() ([]expr, error) {
+	if p.tok.tp == tokString {
+		e := &constExpr{node: node{p.tok.line}, value: p.tok.text}
+		return []expr{e}, p.advance()
+	}
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	if p.is(")") {
+		return nil, p.advance()
+	}
+	args, err := p.exprList()
+	if err != nil {
+		return nil, err
+	}
+	return args, p.expect(")")
+}
@@ -27,6 +27,11 @@ type ProcessInfo struct {
 	Time    time.Time
 	State   uint16
 	Info    string
+	// Plan is the plan of the statement being executed or of the last executed one, it's a plan.Plan
+	// and is explained by EXPLAIN FOR CONNECTION.
+	Plan interface{}
+	// ConnectAttrs are the connection attributes sent by the client in the handshake.
+	ConnectAttrs map[string]string
 }
 
 // SessionManager is an interface for session manage. Show processlist and
@@ -35,3 +40,47 @@ type SessionManager interface {
 	ShowProcessList() []ProcessInfo
 	Kill(connectionID uint64, query bool)
 }
+
+// SlowQueryInfo is a query executed longer than the slow threshold, the recent slow queries are kept in memory.
+type SlowQueryInfo struct {
+	SQL      string
+	Digest   string
+	Start    time.Time
+	Duration time.Duration
+	ConnID   uint64
+	User     string
+	DB       string
+}
+
+// HotRegionStat is the QPS of the requests a server sends to a table or an index in a region, the index ID is 0
+// for the rows of the table.
+type HotRegionStat struct {
+	RegionID uint64
+	TableID  int64
+	IndexID  int64
+	ReadQPS  float64
+	WriteQPS float64
+}
+
+// HotRegionCollector is a storage which collects the QPS of the regions, the hot regions and tables are found by it.
+type HotRegionCollector interface {
+	HotRegions() []HotRegionStat
+}
+
+// ClusterManager gets the information of all the TiDB servers in the cluster, the information is keyed by
+// the address of the server. A server which fails to respond is skipped, its error is returned in errs.
+// The cluster tables of the information schema rely on this interface, it's implemented by the session
+// manager which can reach the other servers.
+type ClusterManager interface {
+	ClusterProcessList() (processes map[string][]ProcessInfo, errs []error)
+	ClusterSlowQueries() (queries map[string][]SlowQueryInfo, errs []error)
+	ClusterConfig() (config map[string]map[string]string, errs []error)
+	ClusterHotRegions() (stats map[string][]HotRegionStat, errs []error)
+}
+
+// GlobalKiller is a session manager whose connection IDs may be unique in the cluster. The standard KILL
+// statement is supported when they are, because the connection is killed on the right server wherever
+// the statement is sent.
+type GlobalKiller interface {
+	GlobalConnID() bool
+}
@@ -0,0 +1,380 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package protocol encodes the values of the result rows in the text and the binary formats of the MySQL
+// client/server protocol, and decodes the temporal parameters of the binary protocol.
+// The values are encoded by the types of their columns, so the clients always get what the column definitions
+// tell them, e.g. the microseconds of a DATETIME(6) and the scale of a DECIMAL(10, 2).
+package protocol
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+	gotime "time"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// Column is the type of a result column, the values are encoded by it.
+type Column struct {
+	// Type is one of the mysql.Type* types.
+	Type uint8
+	// Flag is the flag of the column, e.g. mysql.UnsignedFlag.
+	Flag uint16
+	// Decimal is the scale of a decimal or the fsp of a temporal column, mysql.NotFixedDec means it's unspecified.
+	Decimal uint8
+}
+
+// fsp returns the fractional seconds precision of a temporal column, or -1 if it's unspecified.
+func (col Column) fsp() int {
+	if int(col.Decimal) > types.MaxFsp {
+		return -1
+	}
+	return int(col.Decimal)
+}
+
+// scale returns the scale of a decimal column, or -1 if it's unspecified.
+func (col Column) scale() int {
+	if int(col.Decimal) > types.MaxFraction {
+		return -1
+	}
+	return int(col.Decimal)
+}
+
+// AppendTextValue appends the text of the value in the column, the value must not be NULL.
+func AppendTextValue(buf []byte, col Column, d types.Datum) ([]byte, error) {
+	switch d.Kind() {
+	case types.KindInt64:
+		return strconv.AppendInt(buf, d.GetInt64(), 10), nil
+	case types.KindUint64:
+		return strconv.AppendUint(buf, d.GetUint64(), 10), nil
+	case types.KindFloat32:
+		prec := -1
+		if frac := d.Frac(); frac > 0 {
+			prec = frac
+		}
+		return strconv.AppendFloat(buf, d.GetFloat64(), 'f', prec, 32), nil
+	case types.KindFloat64:
+		prec := -1
+		if frac := d.Frac(); frac > 0 {
+			prec = frac
+		}
+		return strconv.AppendFloat(buf, d.GetFloat64(), 'f', prec, 64), nil
+	case types.KindString, types.KindBytes:
+		return append(buf, d.GetBytes()...), nil
+	case types.KindMysqlTime:
+		t, err := roundTime(d.GetMysqlTime(), col)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return append(buf, t.String()...), nil
+	case types.KindMysqlDuration:
+		dur := d.GetMysqlDuration()
+		if fsp := col.fsp(); fsp >= 0 {
+			var err error
+			dur, err = dur.RoundFrac(fsp)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
+		return append(buf, dur.String()...), nil
+	case types.KindMysqlDecimal:
+		dec, err := roundDecimal(d.GetMysqlDecimal(), col)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return append(buf, dec.ToString()...), nil
+	case types.KindMysqlEnum:
+		return append(buf, d.GetMysqlEnum().String()...), nil
+	case types.KindMysqlSet:
+		return append(buf, d.GetMysqlSet().String()...), nil
+	case types.KindMysqlJSON:
+		return append(buf, d.GetMysqlJSON().String()...), nil
+	case types.KindMysqlBit:
+		return append(buf, d.GetMysqlBit().ToString()...), nil
+	case types.KindMysqlHex:
+		return append(buf, d.GetMysqlHex().ToString()...), nil
+	}
+	return nil, errors.Errorf("invalid type %v", d.Kind())
+}
+
+// AppendBinaryValue appends the binary protocol encoding of the value in the column, the value must not be NULL.
+// The value is converted to the type of the column if its kind doesn't match the type.
+func AppendBinaryValue(buf []byte, col Column, d types.Datum) ([]byte, error) {
+	var err error
+	switch col.Type {
+	case mysql.TypeTiny, mysql.TypeShort, mysql.TypeYear, mysql.TypeInt24, mysql.TypeLong, mysql.TypeLonglong:
+		var v uint64
+		switch d.Kind() {
+		case types.KindInt64:
+			v = uint64(d.GetInt64())
+		case types.KindUint64:
+			v = d.GetUint64()
+		default:
+			if d, err = convert(d, col); err != nil {
+				return nil, errors.Trace(err)
+			}
+			return AppendBinaryValue(buf, col, d)
+		}
+		switch col.Type {
+		case mysql.TypeTiny:
+			return append(buf, byte(v)), nil
+		case mysql.TypeShort, mysql.TypeYear:
+			return append(buf, byte(v), byte(v>>8)), nil
+		case mysql.TypeInt24, mysql.TypeLong:
+			return appendUint32(buf, uint32(v)), nil
+		default:
+			return appendUint64(buf, v), nil
+		}
+	case mysql.TypeFloat:
+		f, err := d.ToFloat64(new(variable.StatementContext))
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return appendUint32(buf, math.Float32bits(float32(f))), nil
+	case mysql.TypeDouble:
+		f, err := d.ToFloat64(new(variable.StatementContext))
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return appendUint64(buf, math.Float64bits(f)), nil
+	case mysql.TypeDate, mysql.TypeNewDate, mysql.TypeDatetime, mysql.TypeTimestamp:
+		if d.Kind() != types.KindMysqlTime {
+			if d, err = convert(d, col); err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
+		t := d.GetMysqlTime()
+		if col.Type == mysql.TypeDate || col.Type == mysql.TypeNewDate {
+			t.Type = col.Type
+		}
+		if t, err = roundTime(t, col); err != nil {
+			return nil, errors.Trace(err)
+		}
+		return AppendBinaryTime(buf, t), nil
+	case mysql.TypeDuration:
+		if d.Kind() != types.KindMysqlDuration {
+			if d, err = convert(d, col); err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
+		dur := d.GetMysqlDuration()
+		if fsp := col.fsp(); fsp >= 0 {
+			if dur, err = dur.RoundFrac(fsp); err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
+		return AppendBinaryDuration(buf, dur), nil
+	}
+	// The other types, including DECIMAL, are sent as length encoded strings.
+	text, err := AppendTextValue(nil, col, d)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	buf = appendLengthEncodedInt(buf, uint64(len(text)))
+	return append(buf, text...), nil
+}
+
+// AppendBinaryTime appends the binary protocol encoding of a DATE, DATETIME or TIMESTAMP value. The length is
+// 0 for the zero value, 4 if there's only the date, 7 if there are no microseconds, and 11 otherwise.
+func AppendBinaryTime(buf []byte, t types.Time) []byte {
+	if t.IsZero() {
+		return append(buf, 0)
+	}
+	hour, minute, second := t.Clock()
+	micro := t.Time.Microsecond()
+	length := byte(11)
+	switch {
+	case t.Type == mysql.TypeDate || t.Type == mysql.TypeNewDate || hour == 0 && minute == 0 && second == 0 && micro == 0:
+		length = 4
+	case micro == 0:
+		length = 7
+	}
+	year := t.Time.Year()
+	buf = append(buf, length, byte(year), byte(year>>8), byte(t.Time.Month()), byte(t.Time.Day()))
+	if length == 4 {
+		return buf
+	}
+	buf = append(buf, byte(hour), byte(minute), byte(second))
+	if length == 7 {
+		return buf
+	}
+	return appendUint32(buf, uint32(micro))
+}
+
+// AppendBinaryDuration appends the binary protocol encoding of a TIME value. The length is 0 for the zero
+// value, 8 if there are no microseconds, and 12 otherwise.
+func AppendBinaryDuration(buf []byte, d types.Duration) []byte {
+	dur := d.Duration
+	if dur == 0 {
+		return append(buf, 0)
+	}
+	var neg byte
+	if dur < 0 {
+		neg = 1
+		dur = -dur
+	}
+	days := dur / (24 * gotime.Hour)
+	dur -= days * 24 * gotime.Hour
+	hours := dur / gotime.Hour
+	dur -= hours * gotime.Hour
+	minutes := dur / gotime.Minute
+	dur -= minutes * gotime.Minute
+	seconds := dur / gotime.Second
+	dur -= seconds * gotime.Second
+	micro := dur / gotime.Microsecond
+	length := byte(12)
+	if micro == 0 {
+		length = 8
+	}
+	buf = append(buf, length, neg)
+	buf = appendUint32(buf, uint32(days))
+	buf = append(buf, byte(hours), byte(minutes), byte(seconds))
+	if micro == 0 {
+		return buf
+	}
+	return appendUint32(buf, uint32(micro))
+}
+
+// ParseBinaryTime decodes a DATE, DATETIME or TIMESTAMP parameter of the binary protocol, it returns the text of
+// the value and the number of the bytes read.
+func ParseBinaryTime(data []byte, tp uint8) (string, int, error) {
+	if len(data) == 0 || len(data) < int(data[0])+1 {
+		return "", 0, errors.Trace(mysql.ErrMalformPacket)
+	}
+	length := int(data[0])
+	var year, month, day, hour, minute, second, micro int
+	switch length {
+	case 0:
+	case 4, 7, 11:
+		year = int(binary.LittleEndian.Uint16(data[1:3]))
+		month, day = int(data[3]), int(data[4])
+		if length > 4 {
+			hour, minute, second = int(data[5]), int(data[6]), int(data[7])
+		}
+		if length > 7 {
+			micro = int(binary.LittleEndian.Uint32(data[8:12]))
+		}
+	default:
+		return "", 0, errors.Trace(mysql.ErrMalformPacket)
+	}
+	if tp == mysql.TypeDate || tp == mysql.TypeNewDate {
+		return fmt.Sprintf("%04d-%02d-%02d", year, month, day), length + 1, nil
+	}
+	return fmt.Sprintf("%04d-%02d-%02d %02d:%02d:%02d.%06d", year, month, day, hour, minute, second, micro), length + 1, nil
+}
+
+// ParseBinaryDuration decodes a TIME parameter of the binary protocol, it returns the text of the value and the
+// number of the bytes read.
+func ParseBinaryDuration(data []byte) (string, int, error) {
+	if len(data) == 0 || len(data) < int(data[0])+1 {
+		return "", 0, errors.Trace(mysql.ErrMalformPacket)
+	}
+	length := int(data[0])
+	var sign string
+	var hours, minutes, seconds, micro int
+	switch length {
+	case 0:
+	case 8, 12:
+		if data[1] == 1 {
+			sign = "-"
+		}
+		hours = int(binary.LittleEndian.Uint32(data[2:6]))*24 + int(data[6])
+		minutes, seconds = int(data[7]), int(data[8])
+		if length == 12 {
+			micro = int(binary.LittleEndian.Uint32(data[9:13]))
+		}
+	default:
+		return "", 0, errors.Trace(mysql.ErrMalformPacket)
+	}
+	return fmt.Sprintf("%s%02d:%02d:%02d.%06d", sign, hours, minutes, seconds, micro), length + 1, nil
+}
+
+// roundTime rounds the time to the fsp of the column, the zero value and the dates are not changed.
+func roundTime(t types.Time, col Column) (types.Time, error) {
+	fsp := col.fsp()
+	if fsp < 0 || t.Type == mysql.TypeDate || t.Type == mysql.TypeNewDate {
+		return t, nil
+	}
+	if t.IsZero() {
+		t.Fsp = fsp
+		return t, nil
+	}
+	if t.Type == mysql.TypeTimestamp && t.TimeZone == nil {
+		// The timestamps decoded from the storage have no time zone, they're rounded as the other times.
+		t.TimeZone = gotime.Local
+	}
+	nt, err := t.RoundFrac(fsp)
+	return nt, errors.Trace(err)
+}
+
+// roundDecimal rounds the decimal to the scale of the column, the trailing zeros are kept so the text has
+// exactly the digits of the scale.
+func roundDecimal(dec *types.MyDecimal, col Column) (*types.MyDecimal, error) {
+	scale := col.scale()
+	if scale < 0 || col.Type != mysql.TypeNewDecimal && col.Type != mysql.TypeDecimal {
+		return dec, nil
+	}
+	to := new(types.MyDecimal)
+	if err := dec.Round(to, scale, types.ModeHalfEven); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return to, nil
+}
+
+// convert converts the value to the type of the column.
+func convert(d types.Datum, col Column) (types.Datum, error) {
+	ft := types.NewFieldType(col.Type)
+	ft.Flag = uint(col.Flag)
+	switch col.Type {
+	case mysql.TypeDate, mysql.TypeNewDate, mysql.TypeDatetime, mysql.TypeTimestamp, mysql.TypeDuration:
+		ft.Decimal = col.fsp()
+		if ft.Decimal < 0 {
+			ft.Decimal = types.MaxFsp
+		}
+	}
+	sc := &variable.StatementContext{IgnoreTruncate: true}
+	converted, err := d.ConvertTo(sc, ft)
+	if err != nil {
+		return d, errors.Trace(err)
+	}
+	if converted.IsNull() {
+		return d, errors.Errorf("can't convert %v to type %d", d.GetValue(), col.Type)
+	}
+	return converted, nil
+}
+
+func appendUint32(buf []byte, n uint32) []byte {
+	return append(buf, byte(n), byte(n>>8), byte(n>>16), byte(n>>24))
+}
+
+func appendUint64(buf []byte, n uint64) []byte {
+	return append(buf, byte(n), byte(n>>8), byte(n>>16), byte(n>>24), byte(n>>32), byte(n>>40), byte(n>>48), byte(n>>56))
+}
+
+func appendLengthEncodedInt(buf []byte, n uint64) []byte {
+	switch {
+	case n <= 250:
+		return append(buf, byte(n))
+	case n <= 0xffff:
+		return append(buf, 0xfc, byte(n), byte(n>>8))
+	case n <= 0xffffff:
+		return append(buf, 0xfd, byte(n), byte(n>>8), byte(n>>16))
+	}
+	return appendUint64(append(buf, 0xfe), n)
+}
@@ -0,0 +1,187 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protocol
+
+import (
+	"math"
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/util/testleak"
+	"github.com/pingcap/tidb/util/types"
+)
+
+func TestT(t *testing.T) {
+	CustomVerboseFlag = true
+	TestingT(t)
+}
+
+var _ = Suite(&testValueSuite{})
+
+type testValueSuite struct{}
+
+func timeDatum(c *C, s string, tp byte) types.Datum {
+	t, err := types.ParseTime(s, tp, types.MaxFsp)
+	c.Assert(err, IsNil)
+	var d types.Datum
+	d.SetMysqlTime(t)
+	return d
+}
+
+func durationDatum(c *C, s string) types.Datum {
+	dur, err := types.ParseDuration(s, types.MaxFsp)
+	c.Assert(err, IsNil)
+	var d types.Datum
+	d.SetMysqlDuration(dur)
+	return d
+}
+
+func (s *testValueSuite) TestAppendTextValue(c *C) {
+	defer testleak.AfterTest(c)()
+	dt := timeDatum(c, "2017-01-05 23:59:59.575601", mysql.TypeDatetime)
+	tm := durationDatum(c, "-11:30:45.999999")
+	tests := []struct {
+		col    Column
+		d      types.Datum
+		expect string
+	}{
+		{Column{Type: mysql.TypeLonglong}, types.NewIntDatum(-10), "-10"},
+		{Column{Type: mysql.TypeLonglong, Flag: mysql.UnsignedFlag}, types.NewUintDatum(11), "11"},
+		{Column{Type: mysql.TypeVarString}, types.NewStringDatum("bar"), "bar"},
+		{Column{Type: mysql.TypeDatetime, Decimal: mysql.NotFixedDec}, dt, "2017-01-05 23:59:59.575601"},
+		{Column{Type: mysql.TypeDatetime, Decimal: 0}, dt, "2017-01-06 00:00:00"},
+		{Column{Type: mysql.TypeDatetime, Decimal: 2}, dt, "2017-01-05 23:59:59.58"},
+		{Column{Type: mysql.TypeDatetime, Decimal: 6}, timeDatum(c, "2017-01-05 10:00:00", mysql.TypeDatetime), "2017-01-05 10:00:00.000000"},
+		{Column{Type: mysql.TypeTimestamp, Decimal: 3}, timeDatum(c, "0000-00-00 00:00:00", mysql.TypeTimestamp), "0000-00-00 00:00:00.000"},
+		{Column{Type: mysql.TypeDate, Decimal: 0}, timeDatum(c, "2017-01-05", mysql.TypeDate), "2017-01-05"},
+		{Column{Type: mysql.TypeDuration, Decimal: 0}, tm, "-11:30:46"},
+		{Column{Type: mysql.TypeDuration, Decimal: 3}, durationDatum(c, "11:30:45"), "11:30:45.000"},
+		{Column{Type: mysql.TypeNewDecimal, Decimal: 4}, types.NewDecimalDatum(types.NewDecFromStringForTest("1.23")), "1.2300"},
+		{Column{Type: mysql.TypeNewDecimal, Decimal: 1}, types.NewDecimalDatum(types.NewDecFromStringForTest("-1.25")), "-1.3"},
+		{Column{Type: mysql.TypeNewDecimal, Decimal: mysql.NotFixedDec}, types.NewDecimalDatum(types.NewDecFromStringForTest("1.23")), "1.23"},
+	}
+	for _, t := range tests {
+		b, err := AppendTextValue(nil, t.col, t.d)
+		c.Assert(err, IsNil)
+		c.Assert(string(b), Equals, t.expect, Commentf("%v %v", t.col, t.d))
+	}
+
+	f32 := types.NewFloat32Datum(1.2)
+	f32.SetFrac(2)
+	b, err := AppendTextValue([]byte("x"), Column{Type: mysql.TypeFloat}, f32)
+	c.Assert(err, IsNil)
+	c.Assert(string(b), Equals, "x1.20")
+}
+
+func (s *testValueSuite) TestAppendBinaryValue(c *C) {
+	defer testleak.AfterTest(c)()
+	dt := timeDatum(c, "2017-01-05 23:59:59.575601", mysql.TypeDatetime)
+	tests := []struct {
+		col    Column
+		d      types.Datum
+		expect []byte
+	}{
+		{Column{Type: mysql.TypeTiny}, types.NewIntDatum(-1), []byte{0xff}},
+		{Column{Type: mysql.TypeShort}, types.NewIntDatum(0x102), []byte{2, 1}},
+		{Column{Type: mysql.TypeYear}, types.NewIntDatum(2017), []byte{0xe1, 0x07}},
+		{Column{Type: mysql.TypeInt24}, types.NewIntDatum(1), []byte{1, 0, 0, 0}},
+		{Column{Type: mysql.TypeLong}, types.NewUintDatum(math.MaxUint32), []byte{0xff, 0xff, 0xff, 0xff}},
+		{Column{Type: mysql.TypeLonglong}, types.NewIntDatum(-2), []byte{0xfe, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+		// The values of the other kinds are converted to the types of the columns.
+		{Column{Type: mysql.TypeLonglong}, types.NewStringDatum("3"), []byte{3, 0, 0, 0, 0, 0, 0, 0}},
+		{Column{Type: mysql.TypeLong}, types.NewDecimalDatum(types.NewDecFromStringForTest("4.4")), []byte{4, 0, 0, 0}},
+		{Column{Type: mysql.TypeFloat}, types.NewFloat64Datum(1.5), []byte{0, 0, 0xc0, 0x3f}},
+		{Column{Type: mysql.TypeDouble}, types.NewFloat32Datum(1.5), []byte{0, 0, 0, 0, 0, 0, 0xf8, 0x3f}},
+		{Column{Type: mysql.TypeDouble}, types.NewIntDatum(2), []byte{0, 0, 0, 0, 0, 0, 0, 0x40}},
+		{Column{Type: mysql.TypeDatetime, Decimal: 6}, dt, []byte{11, 0xe1, 0x07, 1, 5, 23, 59, 59, 0x71, 0xc8, 0x08, 0}},
+		{Column{Type: mysql.TypeDatetime, Decimal: 0}, dt, []byte{4, 0xe1, 0x07, 1, 6}},
+		{Column{Type: mysql.TypeDatetime, Decimal: 1}, dt, []byte{11, 0xe1, 0x07, 1, 5, 23, 59, 59, 0xc0, 0x27, 0x09, 0}},
+		{Column{Type: mysql.TypeTimestamp, Decimal: 0}, timeDatum(c, "2017-01-05 10:11:12", mysql.TypeTimestamp), []byte{7, 0xe1, 0x07, 1, 5, 10, 11, 12}},
+		{Column{Type: mysql.TypeTimestamp, Decimal: 6}, timeDatum(c, "0000-00-00 00:00:00", mysql.TypeTimestamp), []byte{0}},
+		{Column{Type: mysql.TypeDate, Decimal: 0}, timeDatum(c, "2017-01-05", mysql.TypeDate), []byte{4, 0xe1, 0x07, 1, 5}},
+		{Column{Type: mysql.TypeDate, Decimal: 0}, dt, []byte{4, 0xe1, 0x07, 1, 5}},
+		{Column{Type: mysql.TypeDatetime, Decimal: 0}, types.NewStringDatum("2017-01-05 10:11:12"), []byte{7, 0xe1, 0x07, 1, 5, 10, 11, 12}},
+		{Column{Type: mysql.TypeDuration, Decimal: 0}, durationDatum(c, "00:00:00"), []byte{0}},
+		{Column{Type: mysql.TypeDuration, Decimal: 0}, durationDatum(c, "-25:30:45.6"), []byte{8, 1, 1, 0, 0, 0, 1, 30, 46}},
+		{Column{Type: mysql.TypeDuration, Decimal: 6}, durationDatum(c, "11:30:45.000001"), []byte{12, 0, 0, 0, 0, 0, 11, 30, 45, 1, 0, 0, 0}},
+		{Column{Type: mysql.TypeDuration, Decimal: 0}, types.NewStringDatum("11:30:45"), []byte{8, 0, 0, 0, 0, 0, 11, 30, 45}},
+		{Column{Type: mysql.TypeNewDecimal, Decimal: 2}, types.NewDecimalDatum(types.NewDecFromStringForTest("1.5")), []byte{4, '1', '.', '5', '0'}},
+		{Column{Type: mysql.TypeVarString}, types.NewStringDatum("abc"), []byte{3, 'a', 'b', 'c'}},
+	}
+	for _, t := range tests {
+		b, err := AppendBinaryValue(nil, t.col, t.d)
+		c.Assert(err, IsNil)
+		c.Assert(b, DeepEquals, t.expect, Commentf("%v %v", t.col, t.d))
+	}
+
+	_, err := AppendBinaryValue(nil, Column{Type: mysql.TypeDatetime}, types.NewStringDatum("abc"))
+	c.Assert(err, NotNil)
+}
+
+func (s *testValueSuite) TestParseBinary(c *C) {
+	defer testleak.AfterTest(c)()
+	timeTests := []struct {
+		s      string
+		tp     byte
+		length int
+		expect string
+	}{
+		{"2017-01-05 23:59:59.575601", mysql.TypeDatetime, 12, "2017-01-05 23:59:59.575601"},
+		{"2017-01-05 23:59:59", mysql.TypeTimestamp, 8, "2017-01-05 23:59:59.000000"},
+		{"2017-01-05 00:00:00", mysql.TypeDatetime, 5, "2017-01-05 00:00:00.000000"},
+		{"2017-01-05", mysql.TypeDate, 5, "2017-01-05"},
+		{"0000-00-00 00:00:00", mysql.TypeDatetime, 1, "0000-00-00 00:00:00.000000"},
+	}
+	for _, t := range timeTests {
+		d := timeDatum(c, t.s, t.tp)
+		data := AppendBinaryTime(nil, d.GetMysqlTime())
+		c.Assert(data, HasLen, t.length)
+		str, n, err := ParseBinaryTime(append(data, 0xff), t.tp)
+		c.Assert(err, IsNil)
+		c.Assert(n, Equals, t.length)
+		c.Assert(str, Equals, t.expect)
+	}
+
+	durationTests := []struct {
+		s      string
+		length int
+		expect string
+	}{
+		{"-25:30:45.000001", 13, "-25:30:45.000001"},
+		{"838:59:59", 9, "838:59:59.000000"},
+		{"00:00:00", 1, "00:00:00.000000"},
+	}
+	for _, t := range durationTests {
+		d := durationDatum(c, t.s)
+		data := AppendBinaryDuration(nil, d.GetMysqlDuration())
+		c.Assert(data, HasLen, t.length)
+		str, n, err := ParseBinaryDuration(data)
+		c.Assert(err, IsNil)
+		c.Assert(n, Equals, t.length)
+		c.Assert(str, Equals, t.expect)
+		dur, err := types.ParseDuration(str, types.MaxFsp)
+		c.Assert(err, IsNil)
+		c.Assert(dur.Duration, Equals, d.GetMysqlDuration().Duration)
+	}
+
+	for _, data := range [][]byte{nil, {4, 1, 2}, {5, 1, 2, 3, 4, 5}} {
+		_, _, err := ParseBinaryTime(data, mysql.TypeDatetime)
+		c.Assert(err, NotNil)
+	}
+	for _, data := range [][]byte{nil, {8, 0, 0}, {3, 0, 0, 0}} {
+		_, _, err := ParseBinaryDuration(data)
+		c.Assert(err, NotNil)
+	}
+}
@@ -22,17 +22,30 @@ import (
 )
 
 func buildIndexRange(sc *variable.StatementContext, cols []*expression.Column, lengths []int, inAndEqCount int,
-	accessCondition []expression.Expression) ([]*types.IndexRange, error) {
+	accessCondition []expression.Expression) ([]*types.Range, error) {
 	rb := builder{sc: sc}
-	var ranges []*types.IndexRange
+	var ranges []*types.Range
+	// colOffset is the offset of the first column which has no point range.
+	colOffset := 0
 	for i := 0; i < inAndEqCount; i++ {
 		// Build ranges for equal or in access conditions.
+		if n := getDNFPointsColumnCount(accessCondition[i], cols, colOffset); n > 0 {
+			points := rb.buildDNFPoints(accessCondition[i], cols[colOffset:colOffset+n])
+			if i == 0 {
+				ranges = points
+			} else {
+				ranges = rb.appendPointRanges(ranges, points)
+			}
+			colOffset += n
+			continue
+		}
 		point := rb.build(accessCondition[i])
 		if i == 0 {
-			ranges = rb.buildIndexRanges(point, cols[i].RetType)
+			ranges = rb.buildIndexRanges(point, cols[colOffset].RetType)
 		} else {
-			ranges = rb.appendIndexRanges(ranges, point, cols[i].RetType)
+			ranges = rb.appendIndexRanges(ranges, point, cols[colOffset].RetType)
 		}
+		colOffset++
 	}
 	rangePoints := fullRange
 	// Build rangePoints for non-equal access conditions.
@@ -42,7 +55,7 @@ func buildIndexRange(sc *variable.StatementContext, cols []*expression.Column, l
 	if inAndEqCount == 0 {
 		ranges = rb.buildIndexRanges(rangePoints, cols[0].RetType)
 	} else if inAndEqCount < len(accessCondition) {
-		ranges = rb.appendIndexRanges(ranges, rangePoints, cols[inAndEqCount].RetType)
+		ranges = rb.appendIndexRanges(ranges, rangePoints, cols[colOffset].RetType)
 	}
 
 	// Take prefix index into consideration.
@@ -78,48 +91,48 @@ func hasPrefix(lengths []int) bool {
 	return false
 }
 
-func fixPrefixColRange(ranges []*types.IndexRange, lengths []int) {
+func fixPrefixColRange(ranges []*types.Range, lengths []int) {
 	for _, ran := range ranges {
 		for i := 0; i < len(ran.LowVal); i++ {
-			fixRangeDatum(&ran.LowVal[i], lengths[i])
+			if fixRangeDatum(&ran.LowVal[i], lengths[i]) && i == len(ran.LowVal)-1 {
+				ran.LowExclude = false
+			}
 		}
-		ran.LowExclude = false
 		for i := 0; i < len(ran.HighVal); i++ {
-			fixRangeDatum(&ran.HighVal[i], lengths[i])
+			if fixRangeDatum(&ran.HighVal[i], lengths[i]) && i == len(ran.HighVal)-1 {
+				ran.HighExclude = false
+			}
 		}
 	}
 }
 
-func fixRangeDatum(v *types.Datum, length int) {
+// fixRangeDatum cuts the datum to the prefix length. It returns true if the datum isn't shorter than the
+// prefix, then the key equal to it is also the key of the longer values, so the bound can't be excluded.
+func fixRangeDatum(v *types.Datum, length int) bool {
+	if length == types.UnspecifiedLength || (v.Kind() != types.KindString && v.Kind() != types.KindBytes) {
+		return false
+	}
 	// If this column is prefix and the prefix length is smaller than the range, cut it.
-	if length != types.UnspecifiedLength && length < len(v.GetBytes()) {
+	if length < len(v.GetBytes()) {
 		v.SetBytes(v.GetBytes()[:length])
 	}
+	return len(v.GetBytes()) >= length
 }
 
-// getEQColOffset judge if the expression is a eq function that one side is constant and another is column.
+// getEQColOffset judge if the expression is a eq or null-safe eq function that one side is constant and another is column.
 // If so, it will return the offset of this column in the slice.
 func getEQColOffset(expr expression.Expression, cols []*expression.Column) int {
 	f, ok := expr.(*expression.ScalarFunction)
-	if !ok || f.FuncName.L != ast.EQ {
+	if !ok || (f.FuncName.L != ast.EQ && f.FuncName.L != ast.NullEQ) {
 		return -1
 	}
-	if c, ok := f.GetArgs()[0].(*expression.Column); ok {
-		if _, ok := f.GetArgs()[1].(*expression.Constant); ok {
-			for i, col := range cols {
-				if col.Equal(c, nil) {
-					return i
-				}
-			}
-		}
+	c, ok := getColumnComparedWithConstants(f)
+	if !ok {
+		return -1
 	}
-	if c, ok := f.GetArgs()[1].(*expression.Column); ok {
-		if _, ok := f.GetArgs()[0].(*expression.Constant); ok {
-			for i, col := range cols {
-				if col.Equal(c, nil) {
-					return i
-				}
-			}
+	for i, col := range cols {
+		if col.Equal(c, nil) {
+			return i
 		}
 	}
 	return -1
@@ -145,7 +158,7 @@ func detachIndexScanConditions(conditions []expression.Expression, cols []*expre
 			accessEqualCount = i
 			break
 		}
-		if lengths[i] != types.UnspecifiedLength {
+		if !isCoveredByPrefix(cond, lengths[i]) {
 			filterConds = append(filterConds, cond)
 		}
 		if i == len(accessConds)-1 {
@@ -165,6 +178,22 @@ func detachIndexScanConditions(conditions []expression.Expression, cols []*expre
 		// First of all, we should extract all of in/eq expressions from rest conditions for every continuous index column.
 		// e.g. For index (a,b,c) and conditions a in (1,2) and b < 1 and c in (3,4), we should only extract column a in (1,2).
 		accessIdx := checker.findEqOrInFunc(conditions)
+		if accessIdx == -1 {
+			// The DNF of the equal conditions on the columns starting from this column also builds point ranges.
+			// e.g. For index (a,b,c) and conditions (a = 1 and b = 2 or a = 3 and b = 4) and c > 1.
+			var n int
+			accessIdx, n = findDNFPointsFunc(conditions, cols, curIndex)
+			if accessIdx != -1 {
+				accessInAndEqCount++
+				accessConds = append(accessConds, conditions[accessIdx])
+				if hasPrefix(lengths[curIndex : curIndex+n]) {
+					filterConds = append(filterConds, conditions[accessIdx])
+				}
+				conditions = append(conditions[:accessIdx], conditions[accessIdx+1:]...)
+				curIndex += n - 1
+				continue
+			}
+		}
 		// If we fail to find any in or eq expression, we should consider all of other conditions for the next column.
 		if accessIdx == -1 {
 			accessConds, filterConds = checker.extractAccessAndFilterConds(conditions, accessConds, filterConds)
@@ -172,7 +201,7 @@ func detachIndexScanConditions(conditions []expression.Expression, cols []*expre
 		}
 		accessInAndEqCount++
 		accessConds = append(accessConds, conditions[accessIdx])
-		if lengths[curIndex] != types.UnspecifiedLength {
+		if !isCoveredByPrefix(conditions[accessIdx], lengths[curIndex]) {
 			filterConds = append(filterConds, conditions[accessIdx])
 		}
 		conditions = append(conditions[:accessIdx], conditions[accessIdx+1:]...)
@@ -184,10 +213,118 @@ func detachIndexScanConditions(conditions []expression.Expression, cols []*expre
 	return accessConds, filterConds, accessEqualCount, accessInAndEqCount
 }
 
+// DetachIndexConditions detaches the conditions which build the ranges on the index columns from the filter
+// conditions like BuildRange with IndexRangeType, but the ranges aren't built.
+func DetachIndexConditions(conds []expression.Expression, cols []*expression.Column, lengths []int) (accessConds, filterConds []expression.Expression) {
+	conditions := make([]expression.Expression, 0, len(conds))
+	for _, cond := range conds {
+		conditions = append(conditions, cond.Clone())
+	}
+	accessConds, filterConds, _, _ = detachIndexScanConditions(conditions, cols, lengths)
+	return accessConds, filterConds
+}
+
+// findDNFPointsFunc finds the condition which builds the point ranges on the columns starting from the offset
+// by getDNFPointsColumnCount. It returns the index of the condition and the number of the columns.
+func findDNFPointsFunc(conditions []expression.Expression, cols []*expression.Column, offset int) (int, int) {
+	for i, cond := range conditions {
+		if n := getDNFPointsColumnCount(cond, cols, offset); n > 0 {
+			return i, n
+		}
+	}
+	return -1, 0
+}
+
+// getDNFPointsColumnCount checks whether the condition is a DNF, whose items are the conjunctions of the equal or
+// null-safe equal conditions between the same number of the columns starting from the offset and the constants,
+// every column appears once in an item, e.g. (a = 1 and b = 2) or (b = 4 and a = 3) for the columns (a, b). It
+// returns the number of the columns, or 0 if the condition isn't such a DNF.
+func getDNFPointsColumnCount(cond expression.Expression, cols []*expression.Column, offset int) int {
+	if f, ok := cond.(*expression.ScalarFunction); !ok || f.FuncName.L != ast.LogicOr {
+		return 0
+	}
+	count := 0
+	for _, item := range expression.SplitDNFItems(cond) {
+		eqConds := expression.SplitCNFItems(item)
+		if count == 0 {
+			count = len(eqConds)
+		}
+		if len(eqConds) != count || offset+count > len(cols) {
+			return 0
+		}
+		found := make([]bool, count)
+		for _, eqCond := range eqConds {
+			i := getEQColOffset(eqCond, cols[offset:offset+count])
+			if i == -1 || found[i] {
+				return 0
+			}
+			found[i] = true
+		}
+	}
+	return count
+}
+
+// buildDNFPoints builds the point ranges on the columns from the DNF checked by getDNFPointsColumnCount. The ranges
+// are sorted and the duplicated ones are removed.
+func (r *builder) buildDNFPoints(cond expression.Expression, cols []*expression.Column) []*types.Range {
+	var ranges []*types.Range
+	for _, item := range expression.SplitDNFItems(cond) {
+		ran := &types.Range{LowVal: make([]types.Datum, len(cols)), HighVal: make([]types.Datum, len(cols))}
+		isEmpty := false
+		for _, eqCond := range expression.SplitCNFItems(item) {
+			i := getEQColOffset(eqCond, cols)
+			points := r.build(eqCond)
+			if len(points) == 0 {
+				// The value is compared with null by "=".
+				isEmpty = true
+				break
+			}
+			startPoint := r.convertPoint(points[0], cols[i].RetType)
+			endPoint := r.convertPoint(points[1], cols[i].RetType)
+			less, err := rangePointLess(r.sc, startPoint, endPoint)
+			if err != nil {
+				r.err = errors.Trace(err)
+			}
+			if !less {
+				// The value can't be converted to the type of the column exactly.
+				isEmpty = true
+				break
+			}
+			ran.LowVal[i], ran.HighVal[i] = startPoint.value, endPoint.value
+		}
+		if !isEmpty {
+			ranges = append(ranges, ran)
+		}
+	}
+	ranges, err := types.UnionRanges(r.sc, ranges)
+	if err != nil {
+		r.err = errors.Trace(err)
+	}
+	return ranges
+}
+
+// appendPointRanges appends the point ranges of the following columns to the point ranges.
+func (r *builder) appendPointRanges(origin, points []*types.Range) []*types.Range {
+	newRanges := make([]*types.Range, 0, len(origin)*len(points))
+	for _, oRange := range origin {
+		if !oRange.IsPoint(r.sc) {
+			newRanges = append(newRanges, oRange)
+			continue
+		}
+		for _, point := range points {
+			newRanges = append(newRanges, &types.Range{
+				LowVal:  append(append([]types.Datum(nil), oRange.LowVal...), point.LowVal...),
+				HighVal: append(append([]types.Datum(nil), oRange.HighVal...), point.HighVal...),
+			})
+		}
+	}
+	return newRanges
+}
+
 // buildColumnRange builds the range for sampling histogram to calculate the row count.
-func buildColumnRange(conds []expression.Expression, sc *variable.StatementContext, tp *types.FieldType) ([]*types.ColumnRange, error) {
+func buildColumnRange(conds []expression.Expression, sc *variable.StatementContext, tp *types.FieldType) ([]*types.Range, error) {
 	if len(conds) == 0 {
-		return []*types.ColumnRange{{Low: types.Datum{}, High: types.MaxValueDatum()}}, nil
+		return []*types.Range{{LowVal: []types.Datum{{}}, HighVal: []types.Datum{types.MaxValueDatum()}}}, nil
 	}
 
 	rb := builder{sc: sc}
@@ -205,67 +342,23 @@ func buildColumnRange(conds []expression.Expression, sc *variable.StatementConte
 	return ranges, nil
 }
 
-// BuildRange is a method which can calculate IntColumnRange, ColumnRange, IndexRange.
-func BuildRange(sc *variable.StatementContext, conds []expression.Expression, rangeType int, cols []*expression.Column, lengths []int) (retRanges []types.Range,
-	accessConditions, otherConditions []expression.Expression, _ error) {
-	if rangeType == IntRangeType {
+// BuildRange builds the int handle ranges, the column ranges or the index ranges from the conditions by the range type.
+func BuildRange(sc *variable.StatementContext, conds []expression.Expression, rangeType int, cols []*expression.Column, lengths []int) (ranges []*types.Range,
+	accessConditions, otherConditions []expression.Expression, err error) {
+	switch rangeType {
+	case IntRangeType:
 		accessConditions, otherConditions = DetachColumnConditions(conds, cols[0].ColName)
-		ranges, err := BuildTableRange(accessConditions, sc)
-		if err != nil {
-			return nil, nil, nil, errors.Trace(err)
-		}
-		retRanges = make([]types.Range, 0, len(ranges))
-		for _, ran := range ranges {
-			retRanges = append(retRanges, ran)
-		}
-	} else if rangeType == ColumnRangeType {
+		ranges, err = BuildTableRange(accessConditions, sc)
+	case ColumnRangeType:
 		accessConditions, otherConditions = DetachColumnConditions(conds, cols[0].ColName)
-		ranges, err := buildColumnRange(accessConditions, sc, cols[0].RetType)
-		if err != nil {
-			return nil, nil, nil, errors.Trace(err)
-		}
-		retRanges = make([]types.Range, 0, len(ranges))
-		for _, ran := range ranges {
-			retRanges = append(retRanges, ran)
-		}
-	} else if rangeType == IndexRangeType {
+		ranges, err = buildColumnRange(accessConditions, sc, cols[0].RetType)
+	case IndexRangeType:
 		var eqAndInCount int
 		accessConditions, otherConditions, _, eqAndInCount = detachIndexScanConditions(conds, cols, lengths)
-		ranges, err := buildIndexRange(sc, cols, lengths, eqAndInCount, accessConditions)
-		if err != nil {
-			return nil, nil, nil, errors.Trace(err)
-		}
-		retRanges = make([]types.Range, 0, len(ranges))
-		for _, ran := range ranges {
-			retRanges = append(retRanges, ran)
-		}
-	}
-	return
-}
-
-// Ranges2IntRanges changes []types.Range to []types.IntColumnRange
-func Ranges2IntRanges(ranges []types.Range) []types.IntColumnRange {
-	retRanges := make([]types.IntColumnRange, 0, len(ranges))
-	for _, ran := range ranges {
-		retRanges = append(retRanges, ran.Convert2IntRange())
-	}
-	return retRanges
-}
-
-// Ranges2ColumnRanges changes []types.Range to []*types.ColumnRange
-func Ranges2ColumnRanges(ranges []types.Range) []*types.ColumnRange {
-	retRanges := make([]*types.ColumnRange, 0, len(ranges))
-	for _, ran := range ranges {
-		retRanges = append(retRanges, ran.Convert2ColumnRange())
+		ranges, err = buildIndexRange(sc, cols, lengths, eqAndInCount, accessConditions)
 	}
-	return retRanges
-}
-
-// Ranges2IndexRanges changes []types.Range to []*types.IndexRange
-func Ranges2IndexRanges(ranges []types.Range) []*types.IndexRange {
-	retRanges := make([]*types.IndexRange, 0, len(ranges))
-	for _, ran := range ranges {
-		retRanges = append(retRanges, ran.Convert2IndexRange())
+	if err != nil {
+		return nil, nil, nil, errors.Trace(err)
 	}
-	return retRanges
+	return ranges, accessConditions, otherConditions, nil
 }
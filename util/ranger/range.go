@@ -185,11 +185,15 @@ func (r *builder) buildFormBinOp(expr *expression.ScalarFunction) []point {
 		op = expr.FuncName.L
 	}
 	if value.IsNull() {
+		if op == ast.NullEQ {
+			// "a <=> null" is the same as "a is null".
+			return []point{{start: true}, {}}
+		}
 		return nil
 	}
 
 	switch op {
-	case ast.EQ:
+	case ast.EQ, ast.NullEQ:
 		startPoint := point{value: value, start: true}
 		endPoint := point{value: value}
 		return []point{startPoint, endPoint}
@@ -268,6 +272,10 @@ func (r *builder) newBuildFromIn(expr *expression.ScalarFunction) []point {
 			r.err = ErrUnsupportedType.Gen("expr:%v is not constant", e)
 			return fullRange
 		}
+		// The null values never match, e.g. those bound to the parameter markers.
+		if v.Value.IsNull() {
+			continue
+		}
 		startPoint := point{value: types.NewDatum(v.Value.GetValue()), start: true}
 		endPoint := point{value: types.NewDatum(v.Value.GetValue())}
 		rangePoints = append(rangePoints, startPoint, endPoint)
@@ -396,7 +404,7 @@ func (r *builder) buildFromNot(expr *expression.ScalarFunction) []point {
 
 func (r *builder) buildFromScalarFunc(expr *expression.ScalarFunction) []point {
 	switch op := expr.FuncName.L; op {
-	case ast.GE, ast.GT, ast.LT, ast.LE, ast.EQ, ast.NE:
+	case ast.GE, ast.GT, ast.LT, ast.LE, ast.EQ, ast.NE, ast.NullEQ:
 		return r.buildFormBinOp(expr)
 	case ast.LogicAnd:
 		return r.intersection(r.build(expr.GetArgs()[0]), r.build(expr.GetArgs()[1]))
@@ -467,8 +475,8 @@ func (r *builder) merge(a, b []point, union bool) []point {
 // buildIndexRanges build index ranges from range points.
 // Only the first column in the index is built, extra column ranges will be appended by
 // appendIndexRanges.
-func (r *builder) buildIndexRanges(rangePoints []point, tp *types.FieldType) []*types.IndexRange {
-	indexRanges := make([]*types.IndexRange, 0, len(rangePoints)/2)
+func (r *builder) buildIndexRanges(rangePoints []point, tp *types.FieldType) []*types.Range {
+	indexRanges := make([]*types.Range, 0, len(rangePoints)/2)
 	for i := 0; i < len(rangePoints); i += 2 {
 		startPoint := r.convertPoint(rangePoints[i], tp)
 		endPoint := r.convertPoint(rangePoints[i+1], tp)
@@ -479,7 +487,7 @@ func (r *builder) buildIndexRanges(rangePoints []point, tp *types.FieldType) []*
 		if !less {
 			continue
 		}
-		ir := &types.IndexRange{
+		ir := &types.Range{
 			LowVal:      []types.Datum{startPoint.value},
 			LowExclude:  startPoint.excl,
 			HighVal:     []types.Datum{endPoint.value},
@@ -539,8 +547,8 @@ func (r *builder) convertPoint(point point, tp *types.FieldType) point {
 // The additional column ranges can only be appended to point ranges.
 // for example we have an index (a, b), if the condition is (a > 1 and b = 2)
 // then we can not build a conjunctive ranges for this index.
-func (r *builder) appendIndexRanges(origin []*types.IndexRange, rangePoints []point, ft *types.FieldType) []*types.IndexRange {
-	var newIndexRanges []*types.IndexRange
+func (r *builder) appendIndexRanges(origin []*types.Range, rangePoints []point, ft *types.FieldType) []*types.Range {
+	var newIndexRanges []*types.Range
 	for i := 0; i < len(origin); i++ {
 		oRange := origin[i]
 		if !oRange.IsPoint(r.sc) {
@@ -552,8 +560,8 @@ func (r *builder) appendIndexRanges(origin []*types.IndexRange, rangePoints []po
 	return newIndexRanges
 }
 
-func (r *builder) appendIndexRange(origin *types.IndexRange, rangePoints []point, ft *types.FieldType) []*types.IndexRange {
-	newRanges := make([]*types.IndexRange, 0, len(rangePoints)/2)
+func (r *builder) appendIndexRange(origin *types.Range, rangePoints []point, ft *types.FieldType) []*types.Range {
+	newRanges := make([]*types.Range, 0, len(rangePoints)/2)
 	for i := 0; i < len(rangePoints); i += 2 {
 		startPoint := r.convertPoint(rangePoints[i], ft)
 		endPoint := r.convertPoint(rangePoints[i+1], ft)
@@ -573,7 +581,7 @@ func (r *builder) appendIndexRange(origin *types.IndexRange, rangePoints []point
 		copy(highVal, origin.HighVal)
 		highVal[len(origin.HighVal)] = endPoint.value
 
-		ir := &types.IndexRange{
+		ir := &types.Range{
 			LowVal:      lowVal,
 			LowExclude:  startPoint.excl,
 			HighVal:     highVal,
@@ -585,8 +593,8 @@ func (r *builder) appendIndexRange(origin *types.IndexRange, rangePoints []point
 }
 
 // buildTableRanges will construct the range slice with the given range points
-func (r *builder) buildTableRanges(rangePoints []point) []types.IntColumnRange {
-	tableRanges := make([]types.IntColumnRange, 0, len(rangePoints)/2)
+func (r *builder) buildTableRanges(rangePoints []point) []*types.Range {
+	tableRanges := make([]*types.Range, 0, len(rangePoints)/2)
 	for i := 0; i < len(rangePoints); i += 2 {
 		startPoint := rangePoints[i]
 		if startPoint.value.IsNull() || startPoint.value.Kind() == types.KindMinNotNull {
@@ -629,13 +637,13 @@ func (r *builder) buildTableRanges(rangePoints []point) []types.IntColumnRange {
 		if startInt > endInt {
 			continue
 		}
-		tableRanges = append(tableRanges, types.IntColumnRange{LowVal: startInt, HighVal: endInt})
+		tableRanges = append(tableRanges, types.NewIntRange(startInt, endInt))
 	}
 	return tableRanges
 }
 
-func (r *builder) buildColumnRanges(points []point, tp *types.FieldType) []*types.ColumnRange {
-	columnRanges := make([]*types.ColumnRange, 0, len(points)/2)
+func (r *builder) buildColumnRanges(points []point, tp *types.FieldType) []*types.Range {
+	columnRanges := make([]*types.Range, 0, len(points)/2)
 	for i := 0; i < len(points); i += 2 {
 		startPoint := r.convertPoint(points[i], tp)
 		endPoint := r.convertPoint(points[i+1], tp)
@@ -647,11 +655,11 @@ func (r *builder) buildColumnRanges(points []point, tp *types.FieldType) []*type
 		if !less {
 			continue
 		}
-		cr := &types.ColumnRange{
-			Low:      startPoint.value,
-			LowExcl:  startPoint.excl,
-			High:     endPoint.value,
-			HighExcl: endPoint.excl,
+		cr := &types.Range{
+			LowVal:      []types.Datum{startPoint.value},
+			LowExclude:  startPoint.excl,
+			HighVal:     []types.Datum{endPoint.value},
+			HighExclude: endPoint.excl,
 		}
 		columnRanges = append(columnRanges, cr)
 	}
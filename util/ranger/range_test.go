@@ -123,7 +123,7 @@ func (s *testRangerSuite) TestTableRange(c *C) {
 		},
 		{
 			exprStr:   "a in (1, 3, NULL, 2)",
-			resultStr: "[(-inf,-inf) [1,1] [2,2] [3,3]]",
+			resultStr: "[[1,1] [2,2] [3,3]]",
 		},
 		{
 			exprStr:   `a IN (8,8,81,45)`,
@@ -139,7 +139,7 @@ func (s *testRangerSuite) TestTableRange(c *C) {
 		},
 		{
 			exprStr:   "a not between null and 0",
-			resultStr: "[(-inf,+inf)]",
+			resultStr: "[[1,+inf)]",
 		},
 		{
 			exprStr:   "a between 2 and 1",
@@ -274,6 +274,71 @@ func (s *testRangerSuite) TestIndexRange(c *C) {
 			resultStr:  `[[a 1,a 1] [a 2,a 2] [a 3,a 3]]`,
 			inAndEqCnt: 2,
 		},
+		{
+			exprStr:    `(a, b) in (('a', 1), ('b', 2), ('a', 1))`,
+			resultStr:  `[[a 1,a 1] [b 2,b 2]]`,
+			inAndEqCnt: 2,
+		},
+		{
+			exprStr:    `(a = 'b' and b = 2) or (b = 1 and a = 'a')`,
+			resultStr:  `[[a 1,a 1] [b 2,b 2]]`,
+			inAndEqCnt: 2,
+		},
+		{
+			exprStr:    `(a = 'a' or a = 'b') and b = 1`,
+			resultStr:  `[[a 1,a 1] [b 1,b 1]]`,
+			inAndEqCnt: 2,
+		},
+		{
+			exprStr:    `(a = 'a' and b = 1) or a = 'b'`,
+			resultStr:  `[[<nil>,+inf]]`,
+			inAndEqCnt: 0,
+		},
+		{
+			exprStr:    `a <=> 'a' and b <=> 1`,
+			resultStr:  `[[a 1,a 1]]`,
+			inAndEqCnt: 2,
+		},
+		{
+			exprStr:    `a <=> null and b in (1, 2)`,
+			resultStr:  `[[<nil> 1,<nil> 1] [<nil> 2,<nil> 2]]`,
+			inAndEqCnt: 2,
+		},
+		{
+			exprStr:    `a = 'a' and b > 1.5`,
+			resultStr:  `[(a 1,a +inf]]`,
+			inAndEqCnt: 1,
+		},
+		{
+			exprStr:    `a = 'a' and b = '2.0'`,
+			resultStr:  `[[a 2,a 2]]`,
+			inAndEqCnt: 2,
+		},
+		{
+			exprStr:    `a in (1, 2)`,
+			resultStr:  `[[<nil>,+inf]]`,
+			inAndEqCnt: 0,
+		},
+		{
+			exprStr:    `a = 'a' and b in (1.5, 2)`,
+			resultStr:  `[[a 2,a 2]]`,
+			inAndEqCnt: 2,
+		},
+		{
+			exprStr:    `a in (null, 'a')`,
+			resultStr:  `[[a,a]]`,
+			inAndEqCnt: 1,
+		},
+		{
+			exprStr:    `a in (null)`,
+			resultStr:  `[]`,
+			inAndEqCnt: 1,
+		},
+		{
+			exprStr:    `a = 1 and b = 1`,
+			resultStr:  `[[<nil>,+inf]]`,
+			inAndEqCnt: 0,
+		},
 	}
 
 	for _, tt := range tests {
@@ -310,6 +375,97 @@ func (s *testRangerSuite) TestIndexRange(c *C) {
 	}
 }
 
+func (s *testRangerSuite) TestPrefixIndexRange(c *C) {
+	defer testleak.AfterTest(c)()
+	store, err := newStoreWithBootstrap()
+	defer store.Close()
+	c.Assert(err, IsNil)
+	testKit := testkit.NewTestKit(c, store)
+	testKit.MustExec("use test")
+	testKit.MustExec("drop table if exists t")
+	testKit.MustExec("create table t(a varchar(50), b int, index idx_ab(a(3), b))")
+
+	tests := []struct {
+		exprStr     string
+		resultStr   string
+		filterConds string
+	}{
+		{
+			exprStr:     "a LIKE 'ab%'",
+			resultStr:   "[[ab <nil>,ac <nil>)]",
+			filterConds: "[]",
+		},
+		{
+			exprStr:     "a LIKE 'abc%'",
+			resultStr:   "[[abc,abd]]",
+			filterConds: "[like(test.t.a, abc%, 92)]",
+		},
+		{
+			exprStr:     "a LIKE 'abcd%'",
+			resultStr:   "[[[97 98 99],[97 98 99]]]",
+			filterConds: "[like(test.t.a, abcd%, 92)]",
+		},
+		{
+			exprStr:     "a > 'ab'",
+			resultStr:   "[(ab +inf,+inf +inf]]",
+			filterConds: "[]",
+		},
+		{
+			exprStr:     "a < 'abcd'",
+			resultStr:   "[[-inf,[97 98 99]]]",
+			filterConds: "[lt(test.t.a, abcd)]",
+		},
+		{
+			exprStr:     "a >= 'abc' and a < 'abd'",
+			resultStr:   "[[abc,abd]]",
+			filterConds: "[ge(test.t.a, abc) lt(test.t.a, abd)]",
+		},
+		{
+			exprStr:     "a in ('a', 'ab') and b = 1",
+			resultStr:   "[[a 1,a 1] [ab 1,ab 1]]",
+			filterConds: "[]",
+		},
+		{
+			exprStr:     "a = 'abcd' and b > 1",
+			resultStr:   "[([97 98 99] 1,[97 98 99] +inf]]",
+			filterConds: "[eq(test.t.a, abcd)]",
+		},
+	}
+
+	for _, tt := range tests {
+		sql := "select * from t where " + tt.exprStr
+		ctx := testKit.Se.(context.Context)
+		stmts, err := tidb.Parse(ctx, sql)
+		c.Assert(err, IsNil, Commentf("error %v, for expr %s", err, tt.exprStr))
+		c.Assert(stmts, HasLen, 1)
+		is := sessionctx.GetDomain(ctx).InfoSchema()
+		err = plan.ResolveName(stmts[0], is, ctx)
+		c.Assert(err, IsNil, Commentf("error %v, for resolve name, expr %s", err, tt.exprStr))
+		p, err := plan.BuildLogicalPlan(ctx, stmts[0], is)
+		c.Assert(err, IsNil, Commentf("error %v, for build plan, expr %s", err, tt.exprStr))
+		var selection *plan.Selection
+		for _, child := range p.Children() {
+			plan, ok := child.(*plan.Selection)
+			if ok {
+				selection = plan
+				break
+			}
+		}
+		c.Assert(selection, NotNil, Commentf("expr:%v", tt.exprStr))
+		tbl := selection.Children()[0].(*plan.DataSource).TableInfo()
+		conds := make([]expression.Expression, 0, len(selection.Conditions))
+		for _, cond := range selection.Conditions {
+			conds = append(conds, expression.PushDownNot(cond, false, ctx))
+		}
+		cols, lengths := expression.IndexInfo2Cols(selection.Schema().Columns, tbl.Indices[0])
+		c.Assert(cols, NotNil)
+		result, _, filterConds, err := ranger.BuildRange(new(variable.StatementContext), conds, ranger.IndexRangeType, cols, lengths)
+		c.Assert(err, IsNil)
+		c.Assert(fmt.Sprintf("%v", result), Equals, tt.resultStr, Commentf("different for expr %s", tt.exprStr))
+		c.Assert(fmt.Sprintf("%s", filterConds), Equals, tt.filterConds, Commentf("different for expr %s", tt.exprStr))
+	}
+}
+
 func (s *testRangerSuite) TestColumnRange(c *C) {
 	defer testleak.AfterTest(c)()
 	store, err := newStoreWithBootstrap()
@@ -382,7 +538,7 @@ func (s *testRangerSuite) TestColumnRange(c *C) {
 		},
 		{
 			exprStr:   "a in (1, 3, NULL, 2)",
-			resultStr: "[[<nil>,<nil>] [1,1] [2,2] [3,3]]",
+			resultStr: "[[1,1] [2,2] [3,3]]",
 		},
 		{
 			exprStr:   `a IN (8,8,81,45)`,
@@ -396,11 +552,22 @@ func (s *testRangerSuite) TestColumnRange(c *C) {
 			exprStr:   "a not between 1 and 2",
 			resultStr: "[[-inf,1) (2,+inf]]",
 		},
-		//{
-		// `a > null` will be converted to `castAsString(a) > null` which can not be extracted as access condition.
-		//	exprStr:   "a not between null and 0",
-		//	resultStr[(0,+inf]]
-		//},
+		{
+			exprStr:   "a not between null and 0",
+			resultStr: "[(0,+inf]]",
+		},
+		{
+			exprStr:   "a > 1.5",
+			resultStr: "[(1,+inf]]",
+		},
+		{
+			exprStr:   "a <= '2.5'",
+			resultStr: "[[-inf,2]]",
+		},
+		{
+			exprStr:   "a = 1.5",
+			resultStr: "[[<nil>,+inf]]",
+		},
 		{
 			exprStr:   "a between 2 and 1",
 			resultStr: "[]",
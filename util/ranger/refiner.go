@@ -14,6 +14,7 @@
 package ranger
 
 import (
+	"bytes"
 	"math"
 
 	"github.com/juju/errors"
@@ -31,21 +32,21 @@ var fullRange = []point{
 	{value: types.MaxValueDatum()},
 }
 
-// FullIntRange is (-∞, +∞) for IntColumnRange.
-func FullIntRange() []types.IntColumnRange {
-	return []types.IntColumnRange{{LowVal: math.MinInt64, HighVal: math.MaxInt64}}
+// FullIntRange is (-∞, +∞) for an int handle.
+func FullIntRange() []*types.Range {
+	return []*types.Range{types.NewIntRange(math.MinInt64, math.MaxInt64)}
 }
 
-// FullIndexRange is (-∞, +∞) for IndexRange.
-func FullIndexRange() []*types.IndexRange {
-	return []*types.IndexRange{{LowVal: []types.Datum{{}}, HighVal: []types.Datum{types.MaxValueDatum()}}}
+// FullIndexRange is (-∞, +∞) for an index.
+func FullIndexRange() []*types.Range {
+	return []*types.Range{{LowVal: []types.Datum{{}}, HighVal: []types.Datum{types.MaxValueDatum()}}}
 }
 
 // BuildIndexRange will build range of index for PhysicalIndexScan
 func BuildIndexRange(sc *variable.StatementContext, tblInfo *model.TableInfo, index *model.IndexInfo,
-	accessInAndEqCount int, accessCondition []expression.Expression) ([]*types.IndexRange, error) {
+	accessInAndEqCount int, accessCondition []expression.Expression) ([]*types.Range, error) {
 	rb := builder{sc: sc}
-	var ranges []*types.IndexRange
+	var ranges []*types.Range
 	for i := 0; i < accessInAndEqCount; i++ {
 		// Build ranges for equal or in access conditions.
 		point := rb.build(accessCondition[i])
@@ -98,26 +99,64 @@ func BuildIndexRange(sc *variable.StatementContext, tblInfo *model.TableInfo, in
 	return ranges, errors.Trace(rb.err)
 }
 
-// refineRange changes the IndexRange taking prefix index length into consideration.
-func refineRange(v *types.IndexRange, idxInfo *model.IndexInfo) {
+// refineRange changes the index range taking prefix index length into consideration.
+func refineRange(v *types.Range, idxInfo *model.IndexInfo) {
 	for i := 0; i < len(v.LowVal); i++ {
-		refineRangeDatum(&v.LowVal[i], idxInfo.Columns[i])
-		v.LowExclude = false
+		if fixRangeDatum(&v.LowVal[i], idxInfo.Columns[i].Length) && i == len(v.LowVal)-1 {
+			v.LowExclude = false
+		}
 	}
 
 	for i := 0; i < len(v.HighVal); i++ {
-		refineRangeDatum(&v.HighVal[i], idxInfo.Columns[i])
-		v.HighExclude = false
+		if fixRangeDatum(&v.HighVal[i], idxInfo.Columns[i].Length) && i == len(v.HighVal)-1 {
+			v.HighExclude = false
+		}
 	}
 }
 
-func refineRangeDatum(v *types.Datum, ic *model.IndexColumn) {
-	if ic.Length != types.UnspecifiedLength {
-		// if index prefix length is used, change scan range.
-		if ic.Length < len(v.GetBytes()) {
-			v.SetBytes(v.GetBytes()[:ic.Length])
+// isCoveredByPrefix checks whether the access condition on an index column needn't be checked again on the
+// full column. It's true if the column isn't a prefix column, or all the constants of the condition are strings
+// shorter than the prefix, then a key equal to a constant only belongs to the same value, and a longer value
+// is compared with the constants as its key is.
+func isCoveredByPrefix(cond expression.Expression, length int) bool {
+	if length == types.UnspecifiedLength {
+		return true
+	}
+	f, ok := cond.(*expression.ScalarFunction)
+	if !ok {
+		return false
+	}
+	args := f.GetArgs()
+	switch f.FuncName.L {
+	case ast.LogicOr, ast.LogicAnd:
+		return isCoveredByPrefix(args[0], length) && isCoveredByPrefix(args[1], length)
+	case ast.IsNull:
+		return true
+	case ast.Like:
+		// The ranges are built on the string before the last '%' of the pattern.
+		if con, ok := args[1].(*expression.Constant); ok && bytes.HasSuffix(con.Value.GetBytes(), []byte("%")) {
+			return isShorterString(args[1], length+1)
+		}
+		return isShorterString(args[1], length)
+	case ast.EQ, ast.NullEQ, ast.GE, ast.GT, ast.LE, ast.LT, ast.In:
+		for _, arg := range args {
+			if _, ok := arg.(*expression.Column); !ok && !isShorterString(arg, length) {
+				return false
+			}
 		}
+		return true
+	}
+	return false
+}
+
+// isShorterString checks whether the expression is a string constant shorter than the length.
+func isShorterString(expr expression.Expression, length int) bool {
+	con, ok := expr.(*expression.Constant)
+	if !ok {
+		return false
 	}
+	kind := con.Value.Kind()
+	return (kind == types.KindString || kind == types.KindBytes) && len(con.Value.GetBytes()) < length
 }
 
 // getEQFunctionOffset judge if the expression is a eq function like A = 1 where a is an index.
@@ -127,24 +166,82 @@ func getEQFunctionOffset(expr expression.Expression, cols []*model.IndexColumn)
 	if !ok || f.FuncName.L != ast.EQ {
 		return -1
 	}
-	if c, ok := f.GetArgs()[0].(*expression.Column); ok {
-		if _, ok := f.GetArgs()[1].(*expression.Constant); ok {
-			for i, col := range cols {
-				if col.Name.L == c.ColName.L {
-					return i
-				}
+	c, ok := getColumnComparedWithConstants(f)
+	if !ok {
+		return -1
+	}
+	for i, col := range cols {
+		if col.Name.L == c.ColName.L {
+			return i
+		}
+	}
+	return -1
+}
+
+// getColumnComparedWithConstants gets the column of the comparison or the in function between a column and the
+// constants. It returns false if the function isn't such one, or the column can't build the ranges for it because
+// the constants aren't compared in the type of the column, see expression.IsComparedInColumnType.
+func getColumnComparedWithConstants(f *expression.ScalarFunction) (*expression.Column, bool) {
+	args := f.GetArgs()
+	col, ok := args[0].(*expression.Column)
+	cons := args[1:]
+	if !ok && f.FuncName.L != ast.In {
+		col, ok = args[1].(*expression.Column)
+		cons = args[:1]
+	}
+	if !ok {
+		return nil, false
+	}
+	for _, arg := range cons {
+		con, ok := arg.(*expression.Constant)
+		if !ok || !expression.IsComparedInColumnType(col, con) {
+			return nil, false
+		}
+	}
+	return col, true
+}
+
+// ConvertedColumn gets the column of the comparison or the in function between a column and the constants, which
+// can't build the ranges because the constants aren't compared in the type of the column, e.g. "varchar_col = 123" is
+// "cast(varchar_col) = 123". The access type which the index on the column misses is also returned, it's "ref" for
+// the equal conditions and "range" for the others like MySQL.
+func ConvertedColumn(cond expression.Expression) (*expression.Column, string) {
+	f, ok := cond.(*expression.ScalarFunction)
+	if !ok {
+		return nil, ""
+	}
+	args := f.GetArgs()
+	switch f.FuncName.L {
+	case ast.EQ, ast.NullEQ, ast.NE, ast.GE, ast.GT, ast.LE, ast.LT:
+		access := "range"
+		if f.FuncName.L == ast.EQ || f.FuncName.L == ast.NullEQ {
+			access = "ref"
+		}
+		for i := range args {
+			con, ok := args[1-i].(*expression.Constant)
+			if !ok {
+				continue
+			}
+			arg := args[i]
+			if cast, ok := arg.(*expression.ScalarFunction); ok && cast.FuncName.L == ast.Cast {
+				arg = cast.GetArgs()[0]
+			}
+			if col, ok := arg.(*expression.Column); ok && !expression.IsComparedInColumnType(col, con) {
+				return col, access
 			}
 		}
-	} else if _, ok := f.GetArgs()[0].(*expression.Constant); ok {
-		if c, ok := f.GetArgs()[1].(*expression.Column); ok {
-			for i, col := range cols {
-				if col.Name.L == c.ColName.L {
-					return i
-				}
+	case ast.In:
+		col, ok := args[0].(*expression.Column)
+		if !ok {
+			return nil, ""
+		}
+		for _, arg := range args[1:] {
+			if con, ok := arg.(*expression.Constant); ok && !expression.IsComparedInColumnType(col, con) {
+				return col, "range"
 			}
 		}
 	}
-	return -1
+	return nil, ""
 }
 
 func removeAccessConditions(conditions, accessConds []expression.Expression) []expression.Expression {
@@ -168,7 +265,7 @@ func checkIndexCondition(condition expression.Expression, indexColumns []*model.
 		}
 		isIndexColumn := false
 		for _, indCol := range indexColumns {
-			if col.ColName.L == indCol.Name.L && indCol.Length == types.UnspecifiedLength {
+			if col.ColName.L == indCol.Name.L && indCol.IsFullColumn(col.RetType) {
 				isIndexColumn = true
 				break
 			}
@@ -222,7 +319,7 @@ func DetachIndexScanConditions(conditions []expression.Expression, index *model.
 			accessEqualCount = i
 			break
 		}
-		if index.Columns[i].Length != types.UnspecifiedLength {
+		if !isCoveredByPrefix(cond, index.Columns[i].Length) {
 			filterConds = append(filterConds, cond)
 		}
 		if i == len(accessConds)-1 {
@@ -249,7 +346,7 @@ func DetachIndexScanConditions(conditions []expression.Expression, index *model.
 		}
 		accessInAndEqCount++
 		accessConds = append(accessConds, conditions[accessIdx])
-		if index.Columns[curIndex].Length != types.UnspecifiedLength {
+		if !isCoveredByPrefix(conditions[accessIdx], index.Columns[curIndex].Length) {
 			filterConds = append(filterConds, conditions[accessIdx])
 		}
 		conditions = append(conditions[:accessIdx], conditions[accessIdx+1:]...)
@@ -290,7 +387,7 @@ func DetachColumnConditions(conditions []expression.Expression, colName model.CI
 }
 
 // BuildTableRange will build range of pk for PhysicalTableScan
-func BuildTableRange(accessConditions []expression.Expression, sc *variable.StatementContext) ([]types.IntColumnRange, error) {
+func BuildTableRange(accessConditions []expression.Expression, sc *variable.StatementContext) ([]*types.Range, error) {
 	if len(accessConditions) == 0 {
 		return FullIntRange(), nil
 	}
@@ -340,7 +437,7 @@ func (c *conditionChecker) extractAccessAndFilterConds(conditions, accessConds,
 		}
 		accessConds = append(accessConds, cond)
 		// TODO: It will lead to repeated computation cost.
-		if c.length != types.UnspecifiedLength || c.shouldReserve {
+		if !isCoveredByPrefix(cond, c.length) || c.shouldReserve {
 			filterConds = append(filterConds, cond)
 			c.shouldReserve = false
 		}
@@ -373,16 +470,9 @@ func (c *conditionChecker) checkScalarFunction(scalar *expression.ScalarFunction
 	switch scalar.FuncName.L {
 	case ast.LogicOr, ast.LogicAnd:
 		return c.check(scalar.GetArgs()[0]) && c.check(scalar.GetArgs()[1])
-	case ast.EQ, ast.NE, ast.GE, ast.GT, ast.LE, ast.LT:
-		if _, ok := scalar.GetArgs()[0].(*expression.Constant); ok {
-			if c.checkColumn(scalar.GetArgs()[1]) {
-				return scalar.FuncName.L != ast.NE || c.length == types.UnspecifiedLength
-			}
-		}
-		if _, ok := scalar.GetArgs()[1].(*expression.Constant); ok {
-			if c.checkColumn(scalar.GetArgs()[0]) {
-				return scalar.FuncName.L != ast.NE || c.length == types.UnspecifiedLength
-			}
+	case ast.EQ, ast.NE, ast.GE, ast.GT, ast.LE, ast.LT, ast.NullEQ:
+		if col, ok := getColumnComparedWithConstants(scalar); ok && c.checkColumn(col) {
+			return c.checkCompareOp(scalar.FuncName.L, col)
 		}
 	case ast.IsNull, ast.IsTruth, ast.IsFalsity:
 		return c.checkColumn(scalar.GetArgs()[0])
@@ -398,23 +488,34 @@ func (c *conditionChecker) checkScalarFunction(scalar *expression.ScalarFunction
 		}
 		return c.check(scalar.GetArgs()[0])
 	case ast.In:
-		if !c.checkColumn(scalar.GetArgs()[0]) {
-			return false
-		}
-		for _, v := range scalar.GetArgs()[1:] {
-			if _, ok := v.(*expression.Constant); !ok {
-				return false
-			}
-		}
-		return true
+		col, ok := getColumnComparedWithConstants(scalar)
+		return ok && c.checkColumn(col)
 	case ast.Like:
 		return c.checkLikeFunc(scalar)
 	}
 	return false
 }
 
+func (c *conditionChecker) checkCompareOp(op string, col expression.Expression) bool {
+	switch op {
+	case ast.NE:
+		return c.length == types.UnspecifiedLength
+	case ast.GE, ast.GT, ast.LE, ast.LT:
+		return !isEnumOrSetColumn(col)
+	}
+	return true
+}
+
+// isEnumOrSetColumn checks if the column is enum or set type. Enum and set values are
+// encoded by their index in the definition, which doesn't keep the order of their strings,
+// so only point ranges can be built on them.
+func isEnumOrSetColumn(expr expression.Expression) bool {
+	tp := expr.GetType().Tp
+	return tp == mysql.TypeEnum || tp == mysql.TypeSet
+}
+
 func (c *conditionChecker) checkLikeFunc(scalar *expression.ScalarFunction) bool {
-	if !c.checkColumn(scalar.GetArgs()[0]) {
+	if !c.checkColumn(scalar.GetArgs()[0]) || isEnumOrSetColumn(scalar.GetArgs()[0]) {
 		return false
 	}
 	pattern, ok := scalar.GetArgs()[1].(*expression.Constant)
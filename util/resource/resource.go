@@ -0,0 +1,171 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package resource limits the resources used by the user accounts.
+//
+// An account may be limited by the number of the queries, the updates and the connections in an hour, like the
+// MAX_QUERIES_PER_HOUR option of CREATE USER in MySQL, the hourly usage is counted by each server. An account may
+// also belong to a resource group, which limits the concurrent statements of all the accounts in the group on a
+// server, so the statements of a tenant can't take all the workers and starve the others.
+package resource
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/terror"
+)
+
+// Limits is the resource limits of an account, 0 means no limit.
+type Limits struct {
+	MaxQueriesPerHour     int64
+	MaxUpdatesPerHour     int64
+	MaxConnectionsPerHour int64
+	MaxUserConnections    int64
+	// Group is the resource group of the account, the account isn't limited by a group if it's empty.
+	Group string
+}
+
+// Kind is the kind of the hourly resources of an account.
+type Kind int
+
+// The kinds of the hourly resources.
+const (
+	Queries Kind = iota
+	Updates
+	Connections
+)
+
+// names are the names of the resources in the error message, they are the same as MySQL.
+var names = [...]string{"max_questions", "max_updates", "max_connections_per_hour"}
+
+// usagePeriod is the period the usage of an account is counted in.
+const usagePeriod = time.Hour
+
+// usage is the resources used by an account since the start of the period.
+type usage struct {
+	start  time.Time
+	counts [len(names)]int64
+}
+
+// Tracker counts the hourly resources used by the accounts.
+type Tracker struct {
+	mu       sync.Mutex
+	accounts map[string]*usage
+}
+
+// NewTracker creates a Tracker.
+func NewTracker() *Tracker {
+	return &Tracker{accounts: make(map[string]*usage)}
+}
+
+// GlobalTracker is the Tracker of the server.
+var GlobalTracker = NewTracker()
+
+// Consume counts a use of the resource by the account. It returns ErrUserLimitReached if the account has used up
+// the limit in the current period, a limit of 0 means no limit and the use isn't counted.
+func (t *Tracker) Consume(account string, kind Kind, limit int64) error {
+	if limit <= 0 {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	u, ok := t.accounts[account]
+	now := time.Now()
+	if !ok || now.Sub(u.start) >= usagePeriod {
+		u = &usage{start: now}
+		t.accounts[account] = u
+	}
+	if u.counts[kind] >= limit {
+		return ErrUserLimitReached.GenByArgs(account, names[kind], limit)
+	}
+	u.counts[kind]++
+	return nil
+}
+
+// Reset clears the usage of all the accounts, it's done by FLUSH USER_RESOURCES.
+func (t *Tracker) Reset() {
+	t.mu.Lock()
+	t.accounts = make(map[string]*usage)
+	t.mu.Unlock()
+}
+
+// Groups limits the concurrent statements of the resource groups.
+type Groups struct {
+	tokens map[string]chan struct{}
+}
+
+// ParseGroups parses the resource groups in the format of "etl=4,report=8", the number is the max concurrent
+// statements of the group on a server.
+func ParseGroups(spec string) (*Groups, error) {
+	g := &Groups{tokens: make(map[string]chan struct{})}
+	for _, str := range strings.Split(spec, ",") {
+		str = strings.TrimSpace(str)
+		if str == "" {
+			continue
+		}
+		kv := strings.Split(str, "=")
+		if len(kv) != 2 {
+			return nil, errors.Errorf("invalid resource group %s", str)
+		}
+		name := strings.ToLower(strings.TrimSpace(kv[0]))
+		concurrency, err := strconv.Atoi(strings.TrimSpace(kv[1]))
+		if name == "" || err != nil || concurrency <= 0 {
+			return nil, errors.Errorf("invalid resource group %s", str)
+		}
+		g.tokens[name] = make(chan struct{}, concurrency)
+	}
+	return g, nil
+}
+
+// Exists checks whether the group is configured, the name is case insensitive.
+func (g *Groups) Exists(group string) bool {
+	if g == nil {
+		return false
+	}
+	_, ok := g.tokens[strings.ToLower(group)]
+	return ok
+}
+
+// Acquire waits until the group has a free slot for a statement, and returns the function to release the slot.
+// The accounts without a group or of an unknown group aren't limited.
+func (g *Groups) Acquire(group string) func() {
+	if g == nil || group == "" {
+		return func() {}
+	}
+	tokens, ok := g.tokens[strings.ToLower(group)]
+	if !ok {
+		return func() {}
+	}
+	tokens <- struct{}{}
+	return func() { <-tokens }
+}
+
+// Error codes.
+const (
+	codeUserLimitReached = mysql.ErrUserLimitReached
+)
+
+// ErrUserLimitReached is returned when an account has used up an hourly resource.
+var ErrUserLimitReached = terror.ClassResource.New(codeUserLimitReached, "User '%-.64s' has exceeded the '%s' resource (current value: %d)")
+
+func init() {
+	resourceMySQLErrCodes := map[terror.ErrCode]uint16{
+		codeUserLimitReached: mysql.ErrUserLimitReached,
+	}
+	terror.ErrClassToMySQLCodes[terror.ClassResource] = resourceMySQLErrCodes
+}
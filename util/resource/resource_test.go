@@ -0,0 +1,93 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/testleak"
+)
+
+func TestT(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testResourceSuite{})
+
+type testResourceSuite struct{}
+
+func (s *testResourceSuite) TestTracker(c *C) {
+	defer testleak.AfterTest(c)()
+	t := NewTracker()
+	for i := 0; i < 100; i++ {
+		c.Assert(t.Consume("u@%", Queries, 0), IsNil)
+	}
+	c.Assert(t.Consume("u@%", Queries, 2), IsNil)
+	c.Assert(t.Consume("u@%", Queries, 2), IsNil)
+	err := t.Consume("u@%", Queries, 2)
+	c.Assert(terror.ErrorEqual(err, ErrUserLimitReached), IsTrue)
+	c.Assert(err.Error(), Matches, ".*User 'u@%' has exceeded the 'max_questions' resource \\(current value: 2\\)")
+	// The kinds and the accounts are counted separately.
+	c.Assert(t.Consume("u@%", Updates, 1), IsNil)
+	c.Assert(t.Consume("v@%", Queries, 2), IsNil)
+
+	// The usage is cleared after the period.
+	t.accounts["u@%"].start = time.Now().Add(-usagePeriod)
+	c.Assert(t.Consume("u@%", Queries, 2), IsNil)
+	c.Assert(t.Consume("u@%", Queries, 2), IsNil)
+	c.Assert(t.Consume("u@%", Queries, 2), NotNil)
+	t.Reset()
+	c.Assert(t.Consume("u@%", Queries, 2), IsNil)
+}
+
+func (s *testResourceSuite) TestGroups(c *C) {
+	defer testleak.AfterTest(c)()
+	for _, spec := range []string{"etl", "etl=0", "etl=x", "=3", "etl=1=2"} {
+		_, err := ParseGroups(spec)
+		c.Assert(err, NotNil, Commentf("%s", spec))
+	}
+	g, err := ParseGroups(" ETL=1, report=2 ")
+	c.Assert(err, IsNil)
+	c.Assert(g.tokens, HasLen, 2)
+	c.Assert(g.Exists("etl"), IsTrue)
+	c.Assert(g.Exists("Report"), IsTrue)
+	c.Assert(g.Exists("unknown"), IsFalse)
+
+	// The accounts without a group or of an unknown group aren't limited.
+	var nilGroups *Groups
+	nilGroups.Acquire("etl")()
+	g.Acquire("")()
+	g.Acquire("unknown")()
+
+	release := g.Acquire("etl")
+	acquired := make(chan struct{})
+	go func() {
+		g.Acquire("Etl")()
+		close(acquired)
+	}()
+	select {
+	case <-acquired:
+		c.Fatal("the group has no free slot")
+	case <-time.After(50 * time.Millisecond):
+	}
+	release()
+	select {
+	case <-acquired:
+	case <-time.After(5 * time.Second):
+		c.Fatal("the slot isn't released")
+	}
+}
@@ -0,0 +1,193 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resultcache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+	"unsafe"
+
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// maxEntryRatio is the max ratio of the size of an entry to the capacity of the cache, the larger results are
+// not cached, so a single query can't evict all the other results.
+const maxEntryRatio = 8
+
+var datumSize = int64(unsafe.Sizeof(types.Datum{}))
+
+// Entry is the cached result of a query.
+type Entry struct {
+	Fields []*ast.ResultField
+	Rows   [][]types.Datum
+	// TableIDs are the IDs of the tables read by the query, the entry is removed when any of them is written.
+	TableIDs []int64
+
+	key    string
+	size   int64
+	expire time.Time
+}
+
+// EstimateSize returns the estimated memory size of the rows.
+func EstimateSize(row []types.Datum) int64 {
+	size := datumSize * int64(len(row))
+	for i := range row {
+		switch row[i].Kind() {
+		case types.KindString, types.KindBytes:
+			size += int64(len(row[i].GetBytes()))
+		}
+	}
+	return size
+}
+
+// MaxEntrySize returns the max size of an entry which can be put into the cache of the capacity.
+func MaxEntrySize(capacity int64) int64 {
+	return capacity / maxEntryRatio
+}
+
+// Cache is the LRU cache of the query results of a server. The results are removed when the tables they read are
+// written by the server, or when they're expired, the writes done on the other servers are only seen after the
+// results expire.
+type Cache struct {
+	mu    sync.Mutex
+	lru   *list.List
+	items map[string]*list.Element
+	size  int64
+	// tables maps the table IDs to the keys of the entries which read the tables.
+	tables map[int64]map[string]struct{}
+	// generation is increased by every invalidation, invalidated maps the table IDs to the generation in which
+	// they're invalidated. A result read before the invalidation of its table must not be put into the cache.
+	generation  uint64
+	invalidated map[int64]uint64
+}
+
+// NewCache creates an empty Cache.
+func NewCache() *Cache {
+	return &Cache{
+		lru:         list.New(),
+		items:       make(map[string]*list.Element),
+		tables:      make(map[int64]map[string]struct{}),
+		invalidated: make(map[int64]uint64),
+	}
+}
+
+// Generation returns the current generation of the cache, it must be got before the query starts to read, and be
+// passed to Put with the result of the query.
+func (c *Cache) Generation() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generation
+}
+
+// Get returns the entry of the key, the second return value is false if it's not cached or it's expired.
+func (c *Cache) Get(key string) (*Entry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	e := elem.Value.(*Entry)
+	if time.Now().After(e.expire) {
+		c.remove(elem)
+		return nil, false
+	}
+	c.lru.MoveToFront(elem)
+	return e, true
+}
+
+// Put puts the entry of the key into the cache, the least recently used entries are evicted if the cache is
+// larger than capacity bytes. The entry is not put if any of its tables is invalidated after generation.
+// It returns whether the entry is put.
+func (c *Cache) Put(key string, e *Entry, generation uint64, capacity int64, ttl time.Duration) bool {
+	e.key = key
+	e.size = int64(len(key))
+	for _, row := range e.Rows {
+		e.size += EstimateSize(row)
+	}
+	if e.size > MaxEntrySize(capacity) {
+		return false
+	}
+	e.expire = time.Now().Add(ttl)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, id := range e.TableIDs {
+		if c.invalidated[id] > generation {
+			return false
+		}
+	}
+	if elem, ok := c.items[key]; ok {
+		c.remove(elem)
+	}
+	c.items[key] = c.lru.PushFront(e)
+	c.size += e.size
+	for _, id := range e.TableIDs {
+		keys, ok := c.tables[id]
+		if !ok {
+			keys = make(map[string]struct{})
+			c.tables[id] = keys
+		}
+		keys[key] = struct{}{}
+	}
+	for c.size > capacity {
+		c.remove(c.lru.Back())
+	}
+	return true
+}
+
+// Invalidate removes the entries which read the tables, it's called after the tables are written.
+func (c *Cache) Invalidate(tableIDs []int64) {
+	if len(tableIDs) == 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	for _, id := range tableIDs {
+		c.invalidated[id] = c.generation
+		for key := range c.tables[id] {
+			c.remove(c.items[key])
+		}
+	}
+}
+
+// Len returns the number of the cached entries.
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// Size returns the estimated bytes of the cached entries.
+func (c *Cache) Size() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.size
+}
+
+func (c *Cache) remove(elem *list.Element) {
+	e := c.lru.Remove(elem).(*Entry)
+	delete(c.items, e.key)
+	c.size -= e.size
+	for _, id := range e.TableIDs {
+		keys := c.tables[id]
+		delete(keys, e.key)
+		if len(keys) == 0 {
+			delete(c.tables, id)
+		}
+	}
+}
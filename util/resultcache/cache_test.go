@@ -0,0 +1,101 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resultcache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/util/testleak"
+	"github.com/pingcap/tidb/util/types"
+)
+
+func TestT(t *testing.T) {
+	CustomVerboseFlag = true
+	TestingT(t)
+}
+
+var _ = Suite(&testResultCacheSuite{})
+
+type testResultCacheSuite struct{}
+
+func newEntry(tableIDs ...int64) *Entry {
+	return &Entry{
+		Rows:     [][]types.Datum{types.MakeDatums(1, "abc")},
+		TableIDs: tableIDs,
+	}
+}
+
+func (s *testResultCacheSuite) TestCache(c *C) {
+	defer testleak.AfterTest(c)()
+	c.Assert(EstimateSize(types.MakeDatums(1, "abc")), Equals, 2*datumSize+3)
+
+	cache := NewCache()
+	size := EstimateSize(types.MakeDatums(1, "abc")) + 2
+	capacity := size * maxEntryRatio
+	gen := cache.Generation()
+	c.Assert(cache.Put("k1", newEntry(1), gen, capacity, time.Minute), IsTrue)
+	c.Assert(cache.Put("k2", newEntry(1, 2), gen, capacity, time.Minute), IsTrue)
+	c.Assert(cache.Put("k3", newEntry(3), gen, capacity, time.Minute), IsTrue)
+	c.Assert(cache.Len(), Equals, 3)
+	c.Assert(cache.Size(), Equals, 3*size)
+	e, ok := cache.Get("k1")
+	c.Assert(ok, IsTrue)
+	c.Assert(e.Rows[0][1].GetString(), Equals, "abc")
+
+	// The result larger than 1/maxEntryRatio of the capacity is not cached.
+	c.Assert(cache.Put("k4", newEntry(4), gen, size*maxEntryRatio-1, time.Minute), IsFalse)
+	c.Assert(cache.Len(), Equals, 3)
+
+	// The tables are written, the results which read them are removed.
+	cache.Invalidate([]int64{2})
+	_, ok = cache.Get("k2")
+	c.Assert(ok, IsFalse)
+	_, ok = cache.Get("k1")
+	c.Assert(ok, IsTrue)
+	c.Assert(cache.Size(), Equals, 2*size)
+
+	// The result read before the invalidation of its tables is not cached.
+	c.Assert(cache.Put("k2", newEntry(1, 2), gen, capacity, time.Minute), IsFalse)
+	c.Assert(cache.Put("k2", newEntry(1, 2), cache.Generation(), capacity, time.Minute), IsTrue)
+	cache.Invalidate([]int64{1})
+	c.Assert(cache.Len(), Equals, 1)
+	cache.Invalidate(nil)
+	c.Assert(cache.Generation(), Equals, uint64(2))
+
+	// The least recently used results are evicted.
+	gen = cache.Generation()
+	for i := 4; i < 4+maxEntryRatio-1; i++ {
+		c.Assert(cache.Put(fmt.Sprintf("k%c", 'a'+i), newEntry(int64(i)), gen, capacity, time.Minute), IsTrue)
+	}
+	c.Assert(cache.Len(), Equals, maxEntryRatio)
+	_, ok = cache.Get("k3")
+	c.Assert(ok, IsTrue)
+	c.Assert(cache.Put("kx", newEntry(20), gen, capacity, time.Minute), IsTrue)
+	c.Assert(cache.Len(), Equals, maxEntryRatio)
+	_, ok = cache.Get("ke")
+	c.Assert(ok, IsFalse)
+	_, ok = cache.Get("k3")
+	c.Assert(ok, IsTrue)
+
+	// The expired results are removed.
+	c.Assert(cache.Put("ky", newEntry(21), gen, capacity, time.Millisecond), IsTrue)
+	time.Sleep(5 * time.Millisecond)
+	_, ok = cache.Get("ky")
+	c.Assert(ok, IsFalse)
+	c.Assert(cache.Len(), Equals, maxEntryRatio-1)
+	c.Assert(cache.Size(), Equals, (maxEntryRatio-1)*size)
+}
@@ -0,0 +1,190 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sketch
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+	"sort"
+
+	"github.com/juju/errors"
+)
+
+const (
+	// hllPrecision is the number of the hash bits used as the register index, the standard error of the estimation
+	// is about 1.04/sqrt(2^hllPrecision), i.e. 1.6%.
+	hllPrecision = 12
+	hllRegisters = 1 << hllPrecision
+	// hllSparseLimit is the max number of the registers kept in the sparse form, a sparse register takes 3 bytes
+	// when it's encoded, so the sparse form is converted to the dense form before it's larger.
+	hllSparseLimit = hllRegisters / 3
+)
+
+const (
+	hllFormatSparse byte = iota
+	hllFormatDense
+)
+
+// HyperLogLog estimates the number of the distinct values in a set. The sketches built from the parts of a set
+// can be merged into the sketch of the whole set, so the partial sketches can be built by the coprocessors.
+// The registers are kept in a map before many of them are set, so a small set takes little memory.
+type HyperLogLog struct {
+	sparse map[uint16]uint8
+	dense  []uint8
+}
+
+// NewHyperLogLog returns an empty HyperLogLog.
+func NewHyperLogLog() *HyperLogLog {
+	return &HyperLogLog{sparse: make(map[uint16]uint8)}
+}
+
+// Hash64 hashes the bytes, the FNV hash is finalized by the murmur3 mixer, so all the bits are well distributed.
+func Hash64(b []byte) uint64 {
+	h := fnv.New64a()
+	h.Write(b)
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
+
+// Insert inserts the encoded value into the sketch.
+func (h *HyperLogLog) Insert(value []byte) {
+	x := Hash64(value)
+	idx := uint16(x >> (64 - hllPrecision))
+	// The guard bit makes the rank at most 64 - hllPrecision + 1.
+	w := x<<hllPrecision | 1<<(hllPrecision-1)
+	rank := uint8(1)
+	for w&(1<<63) == 0 {
+		rank++
+		w <<= 1
+	}
+	h.set(idx, rank)
+}
+
+func (h *HyperLogLog) set(idx uint16, rank uint8) {
+	if h.dense != nil {
+		if rank > h.dense[idx] {
+			h.dense[idx] = rank
+		}
+		return
+	}
+	if rank > h.sparse[idx] {
+		h.sparse[idx] = rank
+	}
+	if len(h.sparse) > hllSparseLimit {
+		h.dense = make([]uint8, hllRegisters)
+		for i, r := range h.sparse {
+			h.dense[i] = r
+		}
+		h.sparse = nil
+	}
+}
+
+// Merge merges the other sketch into this one.
+func (h *HyperLogLog) Merge(other *HyperLogLog) {
+	if other.dense != nil {
+		for i, r := range other.dense {
+			if r > 0 {
+				h.set(uint16(i), r)
+			}
+		}
+		return
+	}
+	for i, r := range other.sparse {
+		h.set(i, r)
+	}
+}
+
+// Estimate returns the estimated number of the distinct values.
+func (h *HyperLogLog) Estimate() int64 {
+	m := float64(hllRegisters)
+	zeros := hllRegisters - len(h.sparse)
+	sum := float64(zeros)
+	if h.dense != nil {
+		zeros, sum = 0, 0
+		for _, r := range h.dense {
+			if r == 0 {
+				zeros++
+			}
+			sum += math.Ldexp(1, -int(r))
+		}
+	} else {
+		for _, r := range h.sparse {
+			sum += math.Ldexp(1, -int(r))
+		}
+	}
+	alpha := 0.7213 / (1 + 1.079/m)
+	estimate := alpha * m * m / sum
+	// The small cardinalities are estimated by the linear counting, which is more accurate.
+	if estimate <= 2.5*m && zeros > 0 {
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return int64(estimate + 0.5)
+}
+
+// Marshal encodes the sketch, the registers are encoded in the sparse form if there're only a few of them.
+func (h *HyperLogLog) Marshal() []byte {
+	if h.dense != nil {
+		b := make([]byte, 0, 1+hllRegisters)
+		b = append(b, hllFormatDense)
+		return append(b, h.dense...)
+	}
+	indices := make([]int, 0, len(h.sparse))
+	for i := range h.sparse {
+		indices = append(indices, int(i))
+	}
+	sort.Ints(indices)
+	b := make([]byte, 1, 1+3*len(indices))
+	b[0] = hllFormatSparse
+	for _, i := range indices {
+		b = append(b, byte(i>>8), byte(i), h.sparse[uint16(i)])
+	}
+	return b
+}
+
+// UnmarshalHyperLogLog decodes the sketch encoded by Marshal.
+func UnmarshalHyperLogLog(b []byte) (*HyperLogLog, error) {
+	if len(b) == 0 {
+		return nil, errors.New("empty HyperLogLog sketch")
+	}
+	h := NewHyperLogLog()
+	switch b[0] {
+	case hllFormatSparse:
+		b = b[1:]
+		if len(b)%3 != 0 {
+			return nil, errors.Errorf("invalid length %d of sparse HyperLogLog sketch", len(b))
+		}
+		for ; len(b) > 0; b = b[3:] {
+			idx := binary.BigEndian.Uint16(b)
+			if idx >= hllRegisters {
+				return nil, errors.Errorf("invalid register %d of HyperLogLog sketch", idx)
+			}
+			h.set(idx, b[2])
+		}
+	case hllFormatDense:
+		if len(b) != 1+hllRegisters {
+			return nil, errors.Errorf("invalid length %d of dense HyperLogLog sketch", len(b)-1)
+		}
+		h.sparse = nil
+		h.dense = append([]uint8(nil), b[1:]...)
+	default:
+		return nil, errors.Errorf("unknown format %d of HyperLogLog sketch", b[0])
+	}
+	return h, nil
+}
@@ -0,0 +1,125 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sketch
+
+import (
+	"math"
+	"math/rand"
+	"strconv"
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/util/testleak"
+)
+
+func TestT(t *testing.T) {
+	CustomVerboseFlag = true
+	TestingT(t)
+}
+
+var _ = Suite(&testSketchSuite{})
+
+type testSketchSuite struct{}
+
+func (s *testSketchSuite) TestHyperLogLog(c *C) {
+	defer testleak.AfterTest(c)()
+	for _, ndv := range []int{0, 1, 10, 1000, 100000} {
+		h := NewHyperLogLog()
+		for i := 0; i < ndv; i++ {
+			// Every value is inserted twice, the duplicates are not counted.
+			h.Insert([]byte(strconv.Itoa(i)))
+			h.Insert([]byte(strconv.Itoa(i)))
+		}
+		estimate := float64(h.Estimate())
+		c.Assert(math.Abs(estimate-float64(ndv)), LessEqual, float64(ndv)*0.05, Commentf("ndv %d, estimate %v", ndv, estimate))
+
+		// The sketch is the same after it's encoded and decoded.
+		h1, err := UnmarshalHyperLogLog(h.Marshal())
+		c.Assert(err, IsNil)
+		c.Assert(h1.Estimate(), Equals, h.Estimate())
+	}
+
+	// The merged sketch of the parts estimates the whole set.
+	whole := NewHyperLogLog()
+	parts := []*HyperLogLog{NewHyperLogLog(), NewHyperLogLog(), NewHyperLogLog()}
+	for i := 0; i < 30000; i++ {
+		v := []byte(strconv.Itoa(i % 20000))
+		whole.Insert(v)
+		parts[i%3].Insert(v)
+	}
+	merged := NewHyperLogLog()
+	for _, p := range parts {
+		p1, err := UnmarshalHyperLogLog(p.Marshal())
+		c.Assert(err, IsNil)
+		merged.Merge(p1)
+	}
+	c.Assert(merged.Estimate(), Equals, whole.Estimate())
+
+	_, err := UnmarshalHyperLogLog(nil)
+	c.Assert(err, NotNil)
+	_, err = UnmarshalHyperLogLog([]byte{hllFormatSparse, 0})
+	c.Assert(err, NotNil)
+	_, err = UnmarshalHyperLogLog([]byte{hllFormatDense, 0})
+	c.Assert(err, NotNil)
+}
+
+func (s *testSketchSuite) TestTDigest(c *C) {
+	defer testleak.AfterTest(c)()
+	t := NewTDigest()
+	_, ok := t.Quantile(0.5)
+	c.Assert(ok, IsFalse)
+
+	t.Add(3)
+	v, ok := t.Quantile(0.9)
+	c.Assert(ok, IsTrue)
+	c.Assert(v, Equals, float64(3))
+
+	// The quantiles of a few numbers are exact.
+	t = NewTDigest()
+	for i := 1; i <= 9; i++ {
+		t.Add(float64(i))
+	}
+	for _, ca := range []struct {
+		q      float64
+		result float64
+	}{{0, 1}, {0.5, 5}, {1, 9}} {
+		v, _ = t.Quantile(ca.q)
+		c.Assert(v, Equals, ca.result)
+	}
+
+	r := rand.New(rand.NewSource(1))
+	values := r.Perm(100000)
+	t = NewTDigest()
+	parts := []*TDigest{NewTDigest(), NewTDigest(), NewTDigest()}
+	for i, v := range values {
+		t.Add(float64(v))
+		parts[i%3].Add(float64(v))
+	}
+	merged := NewTDigest()
+	for _, p := range parts {
+		p1, err := UnmarshalTDigest(p.Marshal())
+		c.Assert(err, IsNil)
+		merged.Merge(p1)
+	}
+	c.Assert(merged.Count(), Equals, float64(len(values)))
+	for _, q := range []float64{0.01, 0.1, 0.5, 0.9, 0.99} {
+		for _, d := range []*TDigest{t, merged} {
+			v, _ = d.Quantile(q)
+			c.Assert(math.Abs(v-q*float64(len(values))), LessEqual, float64(len(values))*0.01, Commentf("q %v, v %v", q, v))
+		}
+	}
+
+	_, err := UnmarshalTDigest([]byte{1, 2, 3})
+	c.Assert(err, NotNil)
+}
@@ -0,0 +1,183 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sketch
+
+import (
+	"encoding/binary"
+	"math"
+	"sort"
+
+	"github.com/juju/errors"
+)
+
+// tdigestCompression bounds the number of the centroids, a larger one is more accurate but takes more memory.
+const tdigestCompression = 100
+
+type centroid struct {
+	mean   float64
+	weight float64
+}
+
+// TDigest estimates the quantiles of a set of numbers. The numbers are clustered into the centroids, the
+// centroids near the both ends are kept small, so the extreme quantiles are still accurate.
+// The digests built from the parts of a set can be merged into the digest of the whole set.
+// See https://github.com/tdunning/t-digest/blob/master/docs/t-digest-paper/histo.pdf.
+type TDigest struct {
+	// centroids are sorted by the means, the unmerged ones are merged into them when there're too many.
+	centroids []centroid
+	unmerged  []centroid
+	count     float64
+	min       float64
+	max       float64
+}
+
+// NewTDigest returns an empty TDigest.
+func NewTDigest() *TDigest {
+	return &TDigest{min: math.Inf(1), max: math.Inf(-1)}
+}
+
+// Count returns the number of the numbers added.
+func (t *TDigest) Count() float64 {
+	return t.count
+}
+
+// Add adds a number to the digest.
+func (t *TDigest) Add(x float64) {
+	t.add(centroid{mean: x, weight: 1})
+}
+
+func (t *TDigest) add(c centroid) {
+	t.unmerged = append(t.unmerged, c)
+	t.count += c.weight
+	t.min = math.Min(t.min, c.mean)
+	t.max = math.Max(t.max, c.mean)
+	if len(t.unmerged) >= 5*tdigestCompression {
+		t.compress()
+	}
+}
+
+// Merge merges the other digest into this one.
+func (t *TDigest) Merge(other *TDigest) {
+	for _, c := range other.centroids {
+		t.add(c)
+	}
+	for _, c := range other.unmerged {
+		t.add(c)
+	}
+	t.min = math.Min(t.min, other.min)
+	t.max = math.Max(t.max, other.max)
+}
+
+// compress merges the adjacent centroids as long as the weight of every centroid is within the limit of its
+// quantile q, which is 4 * count * q * (1 - q) / compression.
+func (t *TDigest) compress() {
+	if len(t.unmerged) == 0 {
+		return
+	}
+	all := make([]centroid, 0, len(t.centroids)+len(t.unmerged))
+	all = append(all, t.centroids...)
+	all = append(all, t.unmerged...)
+	sort.Slice(all, func(i, j int) bool { return all[i].mean < all[j].mean })
+	merged := make([]centroid, 0, len(all))
+	cur := all[0]
+	var soFar float64
+	for _, c := range all[1:] {
+		proposed := cur.weight + c.weight
+		q0, q2 := soFar/t.count, (soFar+proposed)/t.count
+		if proposed <= 4*t.count*math.Min(q0*(1-q0), q2*(1-q2))/tdigestCompression {
+			cur.mean += (c.mean - cur.mean) * c.weight / proposed
+			cur.weight = proposed
+			continue
+		}
+		soFar += cur.weight
+		merged = append(merged, cur)
+		cur = c
+	}
+	t.centroids = append(merged, cur)
+	t.unmerged = nil
+}
+
+// Quantile returns the estimated q quantile, 0 <= q <= 1. The value is interpolated between the means of the
+// adjacent centroids, or between a mean and the min or the max value at the ends.
+// The second return value is false if the digest is empty.
+func (t *TDigest) Quantile(q float64) (float64, bool) {
+	t.compress()
+	n := len(t.centroids)
+	if n == 0 {
+		return 0, false
+	}
+	if n == 1 {
+		return t.centroids[0].mean, true
+	}
+	index := q * t.count
+	first := t.centroids[0]
+	if index < first.weight/2 {
+		return t.min + (first.mean-t.min)*index/(first.weight/2), true
+	}
+	var cum float64
+	for i := 0; i < n-1; i++ {
+		left := cum + t.centroids[i].weight/2
+		right := cum + t.centroids[i].weight + t.centroids[i+1].weight/2
+		if index <= right {
+			return t.centroids[i].mean + (t.centroids[i+1].mean-t.centroids[i].mean)*(index-left)/(right-left), true
+		}
+		cum += t.centroids[i].weight
+	}
+	last := t.centroids[n-1]
+	left := t.count - last.weight/2
+	return last.mean + (t.max-last.mean)*math.Min(index-left, last.weight/2)/(last.weight/2), true
+}
+
+// Marshal encodes the digest, it's the min and the max value followed by the mean and the weight of the centroids.
+func (t *TDigest) Marshal() []byte {
+	t.compress()
+	b := make([]byte, 0, 16*(len(t.centroids)+1))
+	b = appendFloat64(b, t.min)
+	b = appendFloat64(b, t.max)
+	for _, c := range t.centroids {
+		b = appendFloat64(b, c.mean)
+		b = appendFloat64(b, c.weight)
+	}
+	return b
+}
+
+func appendFloat64(b []byte, f float64) []byte {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], math.Float64bits(f))
+	return append(b, buf[:]...)
+}
+
+// UnmarshalTDigest decodes the digest encoded by Marshal.
+func UnmarshalTDigest(b []byte) (*TDigest, error) {
+	if len(b) < 16 || len(b)%16 != 0 {
+		return nil, errors.Errorf("invalid length %d of t-digest", len(b))
+	}
+	t := &TDigest{
+		min:       math.Float64frombits(binary.BigEndian.Uint64(b)),
+		max:       math.Float64frombits(binary.BigEndian.Uint64(b[8:])),
+		centroids: make([]centroid, 0, len(b)/16-1),
+	}
+	for b = b[16:]; len(b) > 0; b = b[16:] {
+		c := centroid{
+			mean:   math.Float64frombits(binary.BigEndian.Uint64(b)),
+			weight: math.Float64frombits(binary.BigEndian.Uint64(b[8:])),
+		}
+		if c.weight <= 0 {
+			return nil, errors.Errorf("invalid weight %v of t-digest centroid", c.weight)
+		}
+		t.centroids = append(t.centroids, c)
+		t.count += c.weight
+	}
+	return t, nil
+}
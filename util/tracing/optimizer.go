@@ -0,0 +1,78 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"encoding/json"
+
+	"github.com/juju/errors"
+)
+
+// OptimizerTrace records how the optimizer chooses the plan of a statement, it's returned to the client
+// as a JSON document. Like the span, the methods of a nil OptimizerTrace do nothing.
+type OptimizerTrace struct {
+	// Rules are the logical optimization rules in the order they are applied.
+	Rules []*RuleTrace `json:"rules"`
+	// Candidates are the physical plans compared by their costs, the best one of the candidates
+	// of a logical plan with the same property is chosen.
+	Candidates []*CandidateTrace `json:"candidates"`
+	// FinalPlan and FinalCost are the chosen plan of the statement and its cost.
+	FinalPlan string  `json:"final_plan"`
+	FinalCost float64 `json:"final_cost"`
+}
+
+// RuleTrace is an application of a logical optimization rule.
+type RuleTrace struct {
+	Name    string `json:"name"`
+	Before  string `json:"before"`
+	After   string `json:"after"`
+	Changed bool   `json:"changed"`
+}
+
+// CandidateTrace is a physical plan of a logical plan under a required property.
+type CandidateTrace struct {
+	Logical  string  `json:"logical"`
+	Property string  `json:"property"`
+	Plan     string  `json:"plan"`
+	Cost     float64 `json:"cost"`
+	RowCount float64 `json:"row_count"`
+	Chosen   bool    `json:"chosen"`
+	// Reason tells why the candidate is rejected.
+	Reason string `json:"reason,omitempty"`
+}
+
+// AppendRule records the application of a logical optimization rule.
+func (t *OptimizerTrace) AppendRule(name, before, after string) {
+	if t == nil {
+		return
+	}
+	t.Rules = append(t.Rules, &RuleTrace{Name: name, Before: before, After: after, Changed: before != after})
+}
+
+// AppendCandidates records the candidates which are compared together.
+func (t *OptimizerTrace) AppendCandidates(candidates ...*CandidateTrace) {
+	if t == nil {
+		return
+	}
+	t.Candidates = append(t.Candidates, candidates...)
+}
+
+// JSON encodes the trace into an indented JSON document.
+func (t *OptimizerTrace) JSON() (string, error) {
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,89 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tracing records the spans of a statement execution.
+//
+// A span is a named and timed piece of work, the spans started from a span are its children,
+// so the spans of a statement form a tree. The span is passed to the storage layer in the
+// context of the requests. The methods of a nil span do nothing, so the code can be traced
+// without checking whether the tracing is on.
+package tracing
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	goctx "golang.org/x/net/context"
+)
+
+// Span is a named and timed piece of work.
+type Span struct {
+	Operation string
+	Start     time.Time
+	Duration  time.Duration
+
+	mu       sync.Mutex
+	children []*Span
+}
+
+// NewSpan creates a root span which starts now.
+func NewSpan(operation string) *Span {
+	return &Span{Operation: operation, Start: time.Now()}
+}
+
+// StartChild creates a child span which starts now, it's safe to be called concurrently.
+func (s *Span) StartChild(operation string) *Span {
+	if s == nil {
+		return nil
+	}
+	child := NewSpan(operation)
+	s.mu.Lock()
+	s.children = append(s.children, child)
+	s.mu.Unlock()
+	return child
+}
+
+// Finish sets the duration of the span to the time since it starts.
+func (s *Span) Finish() {
+	if s == nil {
+		return
+	}
+	s.Duration = time.Since(s.Start)
+}
+
+// Children returns the child spans in the order of their start time.
+func (s *Span) Children() []*Span {
+	s.mu.Lock()
+	children := make([]*Span, len(s.children))
+	copy(children, s.children)
+	s.mu.Unlock()
+	sort.SliceStable(children, func(i, j int) bool { return children[i].Start.Before(children[j].Start) })
+	return children
+}
+
+type spanKey struct{}
+
+// ContextWithSpan returns a context which carries the span.
+func ContextWithSpan(ctx goctx.Context, s *Span) goctx.Context {
+	return goctx.WithValue(ctx, spanKey{}, s)
+}
+
+// SpanFromContext returns the span in the context, it returns nil if there isn't one.
+func SpanFromContext(ctx goctx.Context) *Span {
+	if ctx == nil {
+		return nil
+	}
+	s, _ := ctx.Value(spanKey{}).(*Span)
+	return s
+}
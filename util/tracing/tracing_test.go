@@ -0,0 +1,101 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/util/testleak"
+	goctx "golang.org/x/net/context"
+)
+
+func TestT(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testTracingSuite{})
+
+type testTracingSuite struct{}
+
+func (s *testTracingSuite) TestSpan(c *C) {
+	defer testleak.AfterTest(c)()
+	root := NewSpan("root")
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			child := root.StartChild("child")
+			time.Sleep(time.Millisecond)
+			child.Finish()
+		}()
+	}
+	wg.Wait()
+	root.Finish()
+
+	children := root.Children()
+	c.Assert(children, HasLen, 10)
+	for i, child := range children {
+		c.Assert(child.Operation, Equals, "child")
+		c.Assert(child.Duration >= time.Millisecond, IsTrue)
+		c.Assert(child.Duration <= root.Duration, IsTrue)
+		if i > 0 {
+			c.Assert(child.Start.Before(children[i-1].Start), IsFalse)
+		}
+	}
+
+	// The methods of a nil span do nothing.
+	var span *Span
+	c.Assert(span.StartChild("child"), IsNil)
+	span.Finish()
+}
+
+func (s *testTracingSuite) TestContext(c *C) {
+	defer testleak.AfterTest(c)()
+	c.Assert(SpanFromContext(nil), IsNil)
+	ctx := goctx.Background()
+	c.Assert(SpanFromContext(ctx), IsNil)
+	root := NewSpan("root")
+	c.Assert(SpanFromContext(ContextWithSpan(ctx, root)), Equals, root)
+}
+
+func (s *testTracingSuite) TestOptimizerTrace(c *C) {
+	defer testleak.AfterTest(c)()
+	var nilTrace *OptimizerTrace
+	nilTrace.AppendRule("rule", "a", "b")
+	nilTrace.AppendCandidates(&CandidateTrace{Plan: "p"})
+
+	trace := &OptimizerTrace{}
+	trace.AppendRule("ppdSolver", "DataScan(t)->Sel([eq(a, 1)])", "DataScan(t)")
+	trace.AppendRule("columnPruner", "DataScan(t)", "DataScan(t)")
+	trace.AppendCandidates(&CandidateTrace{Plan: "Table(t)", Cost: 10, Reason: "higher cost"}, &CandidateTrace{Plan: "Index(t.a)", Cost: 1, Chosen: true})
+	trace.FinalPlan = "Index(t.a)"
+	trace.FinalCost = 1
+	c.Assert(trace.Rules[0].Changed, IsTrue)
+	c.Assert(trace.Rules[1].Changed, IsFalse)
+
+	doc, err := trace.JSON()
+	c.Assert(err, IsNil)
+	decoded := &OptimizerTrace{}
+	c.Assert(json.Unmarshal([]byte(doc), decoded), IsNil)
+	c.Assert(decoded, DeepEquals, trace)
+	var raw map[string][]map[string]interface{}
+	json.Unmarshal([]byte(doc), &raw)
+	_, ok := raw["candidates"][1]["reason"]
+	c.Assert(ok, IsFalse)
+}
@@ -67,6 +67,8 @@ const (
 // Width is the display width for bit representation. -1 means calculating
 // width dynamically, using following algorithm: (len("011101") + 7) & ^7,
 // e.g, if bit string is 0b01, the above will return 8 for its bit width.
+// A dynamic width may exceed MaxBitWidth when the string has leading zeros,
+// but the value itself must still fit in 64 bits.
 func ParseBit(s string, width int) (Bit, error) {
 	if len(s) == 0 {
 		return Bit{}, errors.Errorf("invalid empty string for parsing bit type")
@@ -82,7 +84,8 @@ func ParseBit(s string, width int) (Bit, error) {
 		return Bit{}, errors.Errorf("invalid bit type format %s", s)
 	}
 
-	if width == UnspecifiedBitWidth {
+	dynamicWidth := width == UnspecifiedBitWidth
+	if dynamicWidth {
 		width = (len(s) + 7) & ^7
 	}
 
@@ -90,7 +93,7 @@ func ParseBit(s string, width int) (Bit, error) {
 		width = MinBitWidth
 	}
 
-	if width < MinBitWidth || width > MaxBitWidth {
+	if width < MinBitWidth || (width > MaxBitWidth && !dynamicWidth) {
 		return Bit{}, errors.Errorf("invalid display width for bit type, must in [1, 64], but %d", width)
 	}
 
@@ -99,7 +102,7 @@ func ParseBit(s string, width int) (Bit, error) {
 		return Bit{}, errors.Trace(err)
 	}
 
-	if n > (uint64(1)<<uint64(width))-1 {
+	if width < MaxBitWidth && n > (uint64(1)<<uint64(width))-1 {
 		return Bit{}, errors.Errorf("bit %s is too long for width %d", s, width)
 	}
 
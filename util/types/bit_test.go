@@ -35,6 +35,7 @@ func (s *testBitSuite) TestBit(c *C) {
 		{"0b01", 8, 1, "0b00000001", "\x01"},
 		{"0b111111111", 16, 511, "0b0000000111111111", "\x01\xff"},
 		{"0b01", -1, 1, "0b00000001", "\x01"},
+		{"b'1010'", -1, 10, "0b00001010", "\n"},
 	}
 
 	for _, t := range tbl {
@@ -49,12 +50,21 @@ func (s *testBitSuite) TestBit(c *C) {
 		c.Assert(n, Equals, uint64(t.Number))
 	}
 
+	// Leading zeros may make the dynamic width exceed MaxBitWidth.
+	b, err := ParseBit("b'000000000000000000000000000000000000000000000000000000000000000001000001'", -1)
+	c.Assert(err, IsNil)
+	c.Assert(b.Value, Equals, uint64(65))
+	c.Assert(b.Width, Equals, 72)
+	c.Assert(b.ToString(), Equals, "\x00\x00\x00\x00\x00\x00\x00\x00A")
+
 	tblErr := []struct {
 		Input string
 		Width int
 	}{
 		{"0b11", 1},
 		{"0B11", 2},
+		{"0b01", 65},
+		{"b'11111111111111111111111111111111111111111111111111111111111111111'", -1},
 	}
 
 	for _, t := range tblErr {
@@ -200,3 +200,36 @@ func (s *testCompareSuite) TestCompareDatum(c *C) {
 		c.Assert(ret, Equals, -t.ret, comment)
 	}
 }
+
+func (s *testCompareSuite) TestCompareDatumForMaxMin(c *C) {
+	defer testleak.AfterTest(c)()
+	cmpTbl := []struct {
+		lhs    Datum
+		rhs    Datum
+		ret    int // the result of CompareDatum.
+		maxMin int // the result of CompareDatumForMaxMin.
+	}{
+		{NewDatum(Enum{Name: "a", Value: 2}), NewDatum(Enum{Name: "c", Value: 1}), 1, -1},
+		{NewDatum(Enum{Name: "b", Value: 3}), NewDatum(Enum{Name: "c", Value: 1}), 1, -1},
+		{NewDatum(Enum{Name: "a", Value: 1}), NewDatum(Enum{Name: "a", Value: 1}), 0, 0},
+		{NewDatum(Set{Name: "z", Value: 4}), NewDatum(Set{Name: "x,y", Value: 3}), 1, 1},
+		{NewDatum(Set{Name: "", Value: 0}), NewDatum(Set{Name: "x", Value: 1}), -1, -1},
+		{NewDatum(Set{Name: "y", Value: 2}), NewDatum(Enum{Name: "x", Value: 1}), 1, 1},
+		{NewDatum(Enum{Name: "a", Value: 2}), NewDatum(int64(1)), 1, 1},
+	}
+	sc := new(variable.StatementContext)
+	for i, t := range cmpTbl {
+		comment := Commentf("%d %v %v", i, t.lhs, t.rhs)
+		ret, err := t.lhs.CompareDatum(sc, t.rhs)
+		c.Assert(err, IsNil)
+		c.Assert(ret, Equals, t.ret, comment)
+
+		ret, err = t.lhs.CompareDatumForMaxMin(sc, t.rhs)
+		c.Assert(err, IsNil)
+		c.Assert(ret, Equals, t.maxMin, comment)
+
+		ret, err = t.rhs.CompareDatumForMaxMin(sc, t.lhs)
+		c.Assert(err, IsNil)
+		c.Assert(ret, Equals, -t.maxMin, comment)
+	}
+}
@@ -463,6 +463,20 @@ func (d *Datum) CompareDatum(sc *variable.StatementContext, ad Datum) (int, erro
 	}
 }
 
+// CompareDatumForMaxMin compares datum to another datum for MAX() and MIN().
+// Unlike ORDER BY, MAX() and MIN() compare ENUM and SET values by their string
+// value rather than by their relative position in the definition.
+func (d *Datum) CompareDatumForMaxMin(sc *variable.StatementContext, ad Datum) (int, error) {
+	if isEnumOrSetKind(d.k) && isEnumOrSetKind(ad.k) {
+		return CompareString(d.GetString(), ad.GetString()), nil
+	}
+	return d.CompareDatum(sc, ad)
+}
+
+func isEnumOrSetKind(k byte) bool {
+	return k == KindMysqlEnum || k == KindMysqlSet
+}
+
 func (d *Datum) compareInt64(sc *variable.StatementContext, i int64) (int, error) {
 	switch d.k {
 	case KindMaxValue:
@@ -925,7 +939,7 @@ func (d *Datum) convertToUint(sc *variable.StatementContext, target *FieldType)
 	case KindMysqlHex:
 		val, err = ConvertFloatToUint(sc, d.GetMysqlHex().ToNumber(), upperBound, tp)
 	case KindMysqlBit:
-		val, err = ConvertFloatToUint(sc, d.GetMysqlBit().ToNumber(), upperBound, tp)
+		val, err = ConvertUintToUint(d.GetMysqlBit().Value, upperBound, tp)
 	case KindMysqlEnum:
 		val, err = ConvertFloatToUint(sc, d.GetMysqlEnum().ToNumber(), upperBound, tp)
 	case KindMysqlSet:
@@ -1093,7 +1107,7 @@ func (d *Datum) convertToMysqlDecimal(sc *variable.StatementContext, target *Fie
 	case KindMysqlDuration:
 		dec = d.GetMysqlDuration().ToNumber()
 	case KindMysqlBit:
-		dec.FromFloat64(d.GetMysqlBit().ToNumber())
+		dec.FromUint(d.GetMysqlBit().Value)
 	case KindMysqlEnum:
 		dec.FromFloat64(d.GetMysqlEnum().ToNumber())
 	case KindMysqlHex:
@@ -1446,8 +1460,7 @@ func (d *Datum) toSignedInteger(sc *variable.StatementContext, tp byte) (int64,
 	case KindMysqlHex:
 		return d.GetMysqlHex().Value, nil
 	case KindMysqlBit:
-		fval := d.GetMysqlBit().ToNumber()
-		return ConvertFloatToInt(sc, fval, lowerBound, upperBound, tp)
+		return ConvertUintToInt(d.GetMysqlBit().Value, upperBound, tp)
 	case KindMysqlEnum:
 		fval := d.GetMysqlEnum().ToNumber()
 		return ConvertFloatToInt(sc, fval, lowerBound, upperBound, tp)
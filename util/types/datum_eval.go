@@ -58,7 +58,7 @@ func CoerceArithmetic(sc *variable.StatementContext, a Datum) (d Datum, err erro
 		d.SetFloat64(a.GetMysqlHex().ToNumber())
 		return d, nil
 	case KindMysqlBit:
-		d.SetFloat64(a.GetMysqlBit().ToNumber())
+		d.SetUint64(a.GetMysqlBit().Value)
 		return d, nil
 	case KindMysqlEnum:
 		d.SetFloat64(a.GetMysqlEnum().ToNumber())
@@ -220,6 +220,7 @@ func ComputeDiv(sc *variable.StatementContext, a, b Datum) (d Datum, err error)
 		}
 
 		if y == 0 {
+			handleDivByZero(sc)
 			return d, nil
 		}
 
@@ -245,6 +246,7 @@ func ComputeDiv(sc *variable.StatementContext, a, b Datum) (d Datum, err error)
 		if err != ErrDivByZero {
 			d.SetMysqlDecimal(to)
 		} else {
+			handleDivByZero(sc)
 			err = nil
 		}
 		return d, err
@@ -260,6 +262,7 @@ func ComputeMod(sc *variable.StatementContext, a, b Datum) (d Datum, err error)
 		case KindInt64:
 			y := b.GetInt64()
 			if y == 0 {
+				handleDivByZero(sc)
 				return d, nil
 			}
 			d.SetInt64(x % y)
@@ -267,6 +270,7 @@ func ComputeMod(sc *variable.StatementContext, a, b Datum) (d Datum, err error)
 		case KindUint64:
 			y := b.GetUint64()
 			if y == 0 {
+				handleDivByZero(sc)
 				return d, nil
 			} else if x < 0 {
 				d.SetInt64(-int64(uint64(-x) % y))
@@ -282,6 +286,7 @@ func ComputeMod(sc *variable.StatementContext, a, b Datum) (d Datum, err error)
 		case KindInt64:
 			y := b.GetInt64()
 			if y == 0 {
+				handleDivByZero(sc)
 				return d, nil
 			} else if y < 0 {
 				// first is uint64, return uint64.
@@ -293,6 +298,7 @@ func ComputeMod(sc *variable.StatementContext, a, b Datum) (d Datum, err error)
 		case KindUint64:
 			y := b.GetUint64()
 			if y == 0 {
+				handleDivByZero(sc)
 				return d, nil
 			}
 			d.SetUint64(x % y)
@@ -304,6 +310,7 @@ func ComputeMod(sc *variable.StatementContext, a, b Datum) (d Datum, err error)
 		case KindFloat64:
 			y := b.GetFloat64()
 			if y == 0 {
+				handleDivByZero(sc)
 				return d, nil
 			}
 			d.SetFloat64(math.Mod(x, y))
@@ -319,7 +326,8 @@ func ComputeMod(sc *variable.StatementContext, a, b Datum) (d Datum, err error)
 			if err != ErrDivByZero {
 				d.SetMysqlDecimal(to)
 			} else {
-				// div by zero returns nil without error.
+				// div by zero returns nil with a warning.
+				handleDivByZero(sc)
 				err = nil
 			}
 			return d, err
@@ -338,6 +346,7 @@ func ComputeIntDiv(sc *variable.StatementContext, a, b Datum) (d Datum, err erro
 		case KindInt64:
 			y := b.GetInt64()
 			if y == 0 {
+				handleDivByZero(sc)
 				return d, nil
 			}
 			r, err1 := DivInt64(x, y)
@@ -346,6 +355,7 @@ func ComputeIntDiv(sc *variable.StatementContext, a, b Datum) (d Datum, err erro
 		case KindUint64:
 			y := b.GetUint64()
 			if y == 0 {
+				handleDivByZero(sc)
 				return d, nil
 			}
 			r, err1 := DivIntWithUint(x, y)
@@ -358,6 +368,7 @@ func ComputeIntDiv(sc *variable.StatementContext, a, b Datum) (d Datum, err erro
 		case KindInt64:
 			y := b.GetInt64()
 			if y == 0 {
+				handleDivByZero(sc)
 				return d, nil
 			}
 			r, err1 := DivUintWithInt(x, y)
@@ -366,6 +377,7 @@ func ComputeIntDiv(sc *variable.StatementContext, a, b Datum) (d Datum, err erro
 		case KindUint64:
 			y := b.GetUint64()
 			if y == 0 {
+				handleDivByZero(sc)
 				return d, nil
 			}
 			d.SetUint64(x / y)
@@ -386,6 +398,7 @@ func ComputeIntDiv(sc *variable.StatementContext, a, b Datum) (d Datum, err erro
 	to := new(MyDecimal)
 	err = DecimalDiv(x, y, to, DivFracIncr)
 	if err == ErrDivByZero {
+		handleDivByZero(sc)
 		return d, nil
 	}
 	iVal, err1 := to.ToInt()
@@ -396,6 +409,13 @@ func ComputeIntDiv(sc *variable.StatementContext, a, b Datum) (d Datum, err erro
 	return d, nil
 }
 
+// handleDivByZero appends the warning of the division by zero, whose result is null.
+func handleDivByZero(sc *variable.StatementContext) {
+	if sc != nil {
+		sc.AppendWarning(ErrDivByZero)
+	}
+}
+
 // decimal2RoundUint converts a MyDecimal to an uint64 after rounding.
 func decimal2RoundUint(x *MyDecimal) (uint64, error) {
 	roundX := new(MyDecimal)
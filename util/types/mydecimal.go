@@ -677,7 +677,8 @@ func (d *MyDecimal) doMiniRightShift(shift, beg, end int) {
 	d.wordBuf[bufFrom] = d.wordBuf[bufFrom] / powers10[shift]
 }
 
-// Round rounds the decimal to "frac" digits.
+// Round rounds the decimal to "frac" digits. It works in the fixed-size word buffers of the decimals,
+// so it never allocates.
 //
 //    to			- result buffer. d == to is allowed
 //    frac			- to what position after fraction point to round. can be negative!
@@ -880,12 +881,13 @@ func (d *MyDecimal) Round(to *MyDecimal, frac int, roundMode RoundMode) (err err
 func (d *MyDecimal) FromInt(val int64) *MyDecimal {
 	var uVal uint64
 	if val < 0 {
-		d.negative = true
 		uVal = uint64(-val)
 	} else {
 		uVal = uint64(val)
 	}
-	return d.FromUint(uVal)
+	d.FromUint(uVal)
+	d.negative = val < 0
+	return d
 }
 
 // FromUint sets the decimal value from uint64.
@@ -896,7 +898,9 @@ func (d *MyDecimal) FromUint(val uint64) *MyDecimal {
 		wordIdx++
 		x /= wordBase
 	}
+	d.negative = false
 	d.digitsFrac = 0
+	d.resultFrac = 0
 	d.digitsInt = int8(wordIdx * digitsPerWord)
 	x = val
 	for wordIdx > 0 {
@@ -1360,9 +1364,40 @@ func (d *MyDecimal) Compare(to *MyDecimal) int {
 	return 1
 }
 
+// smallInt returns the int64 value of d if d is an integer of at most two words.
+// The sum or difference of two such values and the product of two one-word values
+// never overflow int64, so they can be computed without going through the words.
+func (d *MyDecimal) smallInt() (int64, bool) {
+	if d.digitsFrac != 0 || d.digitsInt > 2*digitsPerWord {
+		return 0, false
+	}
+	var x int64
+	for i := 0; i < digitsToWords(int(d.digitsInt)); i++ {
+		x = x*wordBase + int64(d.wordBuf[i])
+	}
+	if d.negative {
+		x = -x
+	}
+	return x, true
+}
+
 // DecimalAdd adds two decimals, sets the result to 'to'.
+// 'to' can be the same decimal as 'from1' or 'from2'.
 func DecimalAdd(from1, from2, to *MyDecimal) error {
-	to.resultFrac = myMaxInt8(from1.resultFrac, from2.resultFrac)
+	resultFrac := myMaxInt8(from1.resultFrac, from2.resultFrac)
+	if x, ok := from1.smallInt(); ok {
+		if y, ok := from2.smallInt(); ok {
+			to.FromInt(x + y)
+			to.resultFrac = resultFrac
+			return nil
+		}
+	}
+	if from1 == to || from2 == to {
+		// The operands must not be modified while the result is being written.
+		f1, f2 := *from1, *from2
+		return DecimalAdd(&f1, &f2, to)
+	}
+	to.resultFrac = resultFrac
 	if from1.negative == from2.negative {
 		return doAdd(from1, from2, to)
 	}
@@ -1371,8 +1406,21 @@ func DecimalAdd(from1, from2, to *MyDecimal) error {
 }
 
 // DecimalSub subs one decimal from another, sets the result to 'to'.
+// 'to' can be the same decimal as 'from1' or 'from2'.
 func DecimalSub(from1, from2, to *MyDecimal) error {
-	to.resultFrac = myMaxInt8(from1.resultFrac, from2.resultFrac)
+	resultFrac := myMaxInt8(from1.resultFrac, from2.resultFrac)
+	if x, ok := from1.smallInt(); ok {
+		if y, ok := from2.smallInt(); ok {
+			to.FromInt(x - y)
+			to.resultFrac = resultFrac
+			return nil
+		}
+	}
+	if from1 == to || from2 == to {
+		f1, f2 := *from1, *from2
+		return DecimalSub(&f1, &f2, to)
+	}
+	to.resultFrac = resultFrac
 	if from1.negative == from2.negative {
 		_, err := doSub(from1, from2, to)
 		return err
@@ -1698,8 +1746,27 @@ DecimalMul multiplies two decimals.
 
     XXX if this library is to be used with huge numbers of thousands of
     digits, fast multiplication must be implemented.
+
+    'to' can be the same decimal as 'from1' or 'from2'.
 */
 func DecimalMul(from1, from2, to *MyDecimal) error {
+	if from1.digitsInt <= digitsPerWord && from2.digitsInt <= digitsPerWord {
+		if x, ok := from1.smallInt(); ok {
+			if y, ok := from2.smallInt(); ok {
+				to.FromInt(x * y)
+				to.resultFrac = myMinInt8(from1.resultFrac+from2.resultFrac, MaxFraction)
+				return nil
+			}
+		}
+	}
+	if from1 == to || from2 == to {
+		f1, f2 := *from1, *from2
+		return doMul(&f1, &f2, to)
+	}
+	return doMul(from1, from2, to)
+}
+
+func doMul(from1, from2, to *MyDecimal) error {
 	var (
 		err         error
 		wordsInt1   = digitsToWords(int(from1.digitsInt))
@@ -1828,7 +1895,13 @@ func DecimalMul(from1, from2, to *MyDecimal) error {
 // from2    - divisor
 // to       - quotient
 // fracIncr - increment of fraction
+//
+// 'to' can be the same decimal as 'from1' or 'from2'.
 func DecimalDiv(from1, from2, to *MyDecimal, fracIncr int) error {
+	if from1 == to || from2 == to {
+		f1, f2 := *from1, *from2
+		return DecimalDiv(&f1, &f2, to, fracIncr)
+	}
 	to.resultFrac = myMinInt8(from1.resultFrac+int8(fracIncr), MaxFraction)
 	return doDivMod(from1, from2, to, nil, fracIncr)
 }
@@ -1856,8 +1929,14 @@ DecimalMod does modulus of two decimals.
      R = M - k*N, where k is integer
 
    thus, there's no requirement for M or N to be integers
+
+   'to' can be the same decimal as 'from1' or 'from2'.
 */
 func DecimalMod(from1, from2, to *MyDecimal) error {
+	if from1 == to || from2 == to {
+		f1, f2 := *from1, *from2
+		return DecimalMod(&f1, &f2, to)
+	}
 	to.resultFrac = myMaxInt8(from1.resultFrac, from2.resultFrac)
 	return doDivMod(from1, from2, nil, to, 0)
 }
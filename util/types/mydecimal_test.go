@@ -15,6 +15,7 @@ package types
 
 import (
 	"strings"
+	"testing"
 
 	. "github.com/pingcap/check"
 )
@@ -40,6 +41,13 @@ func (s *testMyDecimalSuite) TestFromInt(c *C) {
 		str := dec.ToString()
 		c.Check(string(str), Equals, tt.output)
 	}
+
+	// A reused decimal must not keep the sign and fraction of its old value.
+	dec := NewDecFromStringForTest("-1.5")
+	dec.FromInt(3)
+	c.Check(string(dec.ToString()), Equals, "3")
+	dec.FromUint(4)
+	c.Check(string(dec.ToString()), Equals, "4")
 }
 
 func (s *testMyDecimalSuite) TestFromUint(c *C) {
@@ -565,6 +573,59 @@ func (s *testMyDecimalSuite) TestMul(c *C) {
 	}
 }
 
+func (s *testMyDecimalSuite) TestArithmeticInPlace(c *C) {
+	tests := []struct {
+		a   string
+		b   string
+		add string
+		sub string
+		mul string
+		div string
+		mod string
+	}{
+		{"123", "45", "168", "78", "5535", "2.733333333", "33"},
+		{"-123", "45", "-78", "-168", "-5535", "-2.733333333", "-33"},
+		{"999999999999999999", "1", "1000000000000000000", "999999999999999998", "999999999999999999", "999999999999999999.000000000", "0"},
+		{"1234500009876.5", ".00012345000098765", "1234500009876.50012345000098765", "1234500009876.49987654999901235",
+			"152399027.438507859754525225", "10000000000000000.000000000000000000000000000", "0.00000000000000000"},
+		{"-12.5", "-6", "-18.5", "-6.5", "75.0", "2.083333333", "-0.5"},
+	}
+	div := func(from1, from2, to *MyDecimal) error {
+		return DecimalDiv(from1, from2, to, DivFracIncr)
+	}
+	for _, tt := range tests {
+		ops := []struct {
+			f      func(from1, from2, to *MyDecimal) error
+			result string
+		}{
+			{DecimalAdd, tt.add},
+			{DecimalSub, tt.sub},
+			{DecimalMul, tt.mul},
+			{div, tt.div},
+			{DecimalMod, tt.mod},
+		}
+		for _, op := range ops {
+			var expect MyDecimal
+			err := op.f(NewDecFromStringForTest(tt.a), NewDecFromStringForTest(tt.b), &expect)
+			c.Assert(err, IsNil)
+			c.Assert(string(expect.ToString()), Equals, op.result, Commentf("%s %s", tt.a, tt.b))
+
+			// The result can be written to the first operand.
+			a := NewDecFromStringForTest(tt.a)
+			err = op.f(a, NewDecFromStringForTest(tt.b), a)
+			c.Assert(err, IsNil)
+			c.Assert(a.Compare(&expect), Equals, 0)
+			c.Assert(string(a.ToString()), Equals, op.result)
+
+			// The result can be written to the second operand.
+			b := NewDecFromStringForTest(tt.b)
+			err = op.f(NewDecFromStringForTest(tt.a), b, b)
+			c.Assert(err, IsNil)
+			c.Assert(string(b.ToString()), Equals, op.result)
+		}
+	}
+}
+
 func (s *testMyDecimalSuite) TestDivMod(c *C) {
 	type tcase struct {
 		a      string
@@ -676,3 +737,55 @@ func (s *testMyDecimalSuite) TestMaxOrMin(c *C) {
 		c.Assert(dec.String(), Equals, tt.result)
 	}
 }
+
+func benchmarkDecimalOp(b *testing.B, x, y string, op func(from1, from2, to *MyDecimal) error) {
+	from1, from2 := NewDecFromStringForTest(x), NewDecFromStringForTest(y)
+	var to MyDecimal
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		op(from1, from2, &to)
+	}
+}
+
+func BenchmarkDecimalAdd(b *testing.B) {
+	benchmarkDecimalOp(b, "123456.789", "98765.4321", DecimalAdd)
+}
+
+func BenchmarkDecimalAddSmallInt(b *testing.B) {
+	benchmarkDecimalOp(b, "123456789", "987654321", DecimalAdd)
+}
+
+func BenchmarkDecimalAddInPlace(b *testing.B) {
+	sum := NewDecFromInt(0)
+	from := NewDecFromStringForTest("1.5")
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		DecimalAdd(sum, from, sum)
+	}
+}
+
+func BenchmarkDecimalMul(b *testing.B) {
+	benchmarkDecimalOp(b, "123456.789", "98765.4321", DecimalMul)
+}
+
+func BenchmarkDecimalMulSmallInt(b *testing.B) {
+	benchmarkDecimalOp(b, "12345", "98765", DecimalMul)
+}
+
+func BenchmarkDecimalDiv(b *testing.B) {
+	benchmarkDecimalOp(b, "123456.789", "98765.4321", func(from1, from2, to *MyDecimal) error {
+		return DecimalDiv(from1, from2, to, DivFracIncr)
+	})
+}
+
+func BenchmarkDecimalRound(b *testing.B) {
+	from := NewDecFromStringForTest("123456.789012345")
+	var to MyDecimal
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		from.Round(&to, 2, ModeHalfEven)
+	}
+}
@@ -16,120 +16,64 @@ package types
 import (
 	"fmt"
 	"math"
-	"strconv"
+	"sort"
 	"strings"
 
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/sessionctx/variable"
 )
 
-// Range is the interface of the three type of range.
-type Range interface {
-	fmt.Stringer
-	Convert2IntRange() IntColumnRange
-	Convert2ColumnRange() *ColumnRange
-	Convert2IndexRange() *IndexRange
-}
-
-// IntColumnRange represents a range for a integer column, both low and high are inclusive.
-type IntColumnRange struct {
-	LowVal  int64
-	HighVal int64
-}
-
-// IsPoint returns if the table range is a point.
-func (tr *IntColumnRange) IsPoint() bool {
-	return tr.HighVal == tr.LowVal
-}
-
-func (tr IntColumnRange) String() string {
-	var l, r string
-	if tr.LowVal == math.MinInt64 {
-		l = "(-inf"
-	} else {
-		l = "[" + strconv.FormatInt(tr.LowVal, 10)
-	}
-	if tr.HighVal == math.MaxInt64 {
-		r = "+inf)"
-	} else if tr.HighVal == math.MinInt64 {
-		// This branch is for nil
-		r = "-inf)"
-	} else {
-		r = strconv.FormatInt(tr.HighVal, 10) + "]"
-	}
-	return l + "," + r
-}
-
-// Convert2IntRange implements the Convert2IntRange interface.
-func (tr IntColumnRange) Convert2IntRange() IntColumnRange {
-	return tr
-}
-
-// Convert2ColumnRange implements the Convert2ColumnRange interface.
-func (tr IntColumnRange) Convert2ColumnRange() *ColumnRange {
-	panic("you shouldn't call this method.")
-}
+// Range represents a range for an index, a column or an int handle. The low value and the high value are
+// the datums of the columns of the range, a range for a column or an int handle has a datum on each side.
+type Range struct {
+	LowVal  []Datum
+	HighVal []Datum
 
-// Convert2IndexRange implements the Convert2IndexRange interface.
-func (tr IntColumnRange) Convert2IndexRange() *IndexRange {
-	panic("you shouldn't call this method.")
+	LowExclude  bool // Low value is exclusive.
+	HighExclude bool // High value is exclusive.
 }
 
-// ColumnRange represents a range for a column.
-type ColumnRange struct {
-	Low      Datum
-	High     Datum
-	LowExcl  bool
-	HighExcl bool
+// NewIntRange returns the range [low, high] for an int handle.
+func NewIntRange(low, high int64) *Range {
+	return &Range{LowVal: []Datum{NewIntDatum(low)}, HighVal: []Datum{NewIntDatum(high)}}
 }
 
-func (cr *ColumnRange) String() string {
-	var l, r string
-	if cr.LowExcl {
-		l = "("
-	} else {
-		l = "["
+// IntBounds returns the inclusive bounds of the range for an int handle, the infinite bounds are
+// the min and max int values.
+func (ran *Range) IntBounds() (low, high int64) {
+	low, high = math.MinInt64, math.MaxInt64
+	if len(ran.LowVal) > 0 && isIntDatum(ran.LowVal[0]) {
+		low = ran.LowVal[0].GetInt64()
+		if ran.LowExclude && low != math.MaxInt64 {
+			low++
+		}
 	}
-	if cr.HighExcl {
-		r = ")"
-	} else {
-		r = "]"
+	if len(ran.HighVal) > 0 {
+		if ran.HighVal[0].IsNull() {
+			// The range only contains the null value.
+			high = math.MinInt64
+		} else if isIntDatum(ran.HighVal[0]) {
+			high = ran.HighVal[0].GetInt64()
+			if ran.HighExclude && high != math.MinInt64 {
+				high--
+			}
+		}
 	}
-	return l + formatDatum(cr.Low) + "," + formatDatum(cr.High) + r
-}
-
-// Convert2IntRange implements the Convert2IntRange interface.
-func (cr *ColumnRange) Convert2IntRange() IntColumnRange {
-	panic("you shouldn't call this method.")
+	return low, high
 }
 
-// Convert2ColumnRange implements the Convert2ColumnRange interface.
-func (cr *ColumnRange) Convert2ColumnRange() *ColumnRange {
-	return cr
+func isIntDatum(d Datum) bool {
+	return d.Kind() == KindInt64 || d.Kind() == KindUint64
 }
 
-// Convert2IndexRange implements the Convert2IndexRange interface.
-func (cr *ColumnRange) Convert2IndexRange() *IndexRange {
-	panic("you shouldn't call this method.")
-}
-
-// IndexRange represents a range for an index.
-type IndexRange struct {
-	LowVal  []Datum
-	HighVal []Datum
-
-	LowExclude  bool // Low value is exclusive.
-	HighExclude bool // High value is exclusive.
-}
-
-// IsPoint returns if the index range is a point.
-func (ir *IndexRange) IsPoint(sc *variable.StatementContext) bool {
-	if len(ir.LowVal) != len(ir.HighVal) {
+// IsPoint returns if the range is a point.
+func (ran *Range) IsPoint(sc *variable.StatementContext) bool {
+	if len(ran.LowVal) != len(ran.HighVal) {
 		return false
 	}
-	for i := range ir.LowVal {
-		a := ir.LowVal[i]
-		b := ir.HighVal[i]
+	for i := range ran.LowVal {
+		a := ran.LowVal[i]
+		b := ran.HighVal[i]
 		if a.Kind() == KindMinNotNull || b.Kind() == KindMaxValue {
 			return false
 		}
@@ -141,68 +85,114 @@ func (ir *IndexRange) IsPoint(sc *variable.StatementContext) bool {
 			return false
 		}
 	}
-	return !ir.LowExclude && !ir.HighExclude
+	return !ran.LowExclude && !ran.HighExclude
 }
 
-// Convert2IndexRange implements the Convert2IndexRange interface.
-func (ir *IndexRange) String() string {
-	lowStrs := make([]string, 0, len(ir.LowVal))
-	for _, d := range ir.LowVal {
+func (ran *Range) String() string {
+	lowStrs := make([]string, 0, len(ran.LowVal))
+	for _, d := range ran.LowVal {
 		lowStrs = append(lowStrs, formatDatum(d))
 	}
-	highStrs := make([]string, 0, len(ir.LowVal))
-	for _, d := range ir.HighVal {
+	highStrs := make([]string, 0, len(ran.LowVal))
+	for _, d := range ran.HighVal {
 		highStrs = append(highStrs, formatDatum(d))
 	}
 	l, r := "[", "]"
-	if ir.LowExclude {
+	if ran.LowExclude {
 		l = "("
 	}
-	if ir.HighExclude {
+	if ran.HighExclude {
 		r = ")"
 	}
+	// The min and max int values are the infinite bounds of a range for an int handle.
+	if isIntBound(ran.LowVal, math.MinInt64) {
+		l, lowStrs[0] = "(", "-inf"
+	}
+	if isIntBound(ran.HighVal, math.MaxInt64) {
+		r, highStrs[0] = ")", "+inf"
+	} else if isIntBound(ran.HighVal, math.MinInt64) {
+		// The range only contains the null value.
+		r, highStrs[0] = ")", "-inf"
+	}
 	return l + strings.Join(lowStrs, " ") + "," + strings.Join(highStrs, " ") + r
 }
 
-// Convert2IntRange implements the Convert2IntRange interface.
-func (ir *IndexRange) Convert2IntRange() IntColumnRange {
-	panic("you shouldn't call this method.")
+func isIntBound(vals []Datum, bound int64) bool {
+	return len(vals) == 1 && vals[0].Kind() == KindInt64 && vals[0].GetInt64() == bound
 }
 
-// Convert2ColumnRange implements the Convert2ColumnRange interface.
-func (ir *IndexRange) Convert2ColumnRange() *ColumnRange {
-	panic("you shouldn't call this method.")
+// Align appends low value and high value up to the number of columns with max value or null value, desc
+// indicates whether the columns are descending. The max value is the least key of a descending column and
+// the null value is the greatest key.
+func (ran *Range) Align(numColumns int, desc []bool) {
+	for i := len(ran.LowVal); i < numColumns; i++ {
+		minVal, maxVal := columnBounds(desc, i)
+		if ran.LowExclude {
+			ran.LowVal = append(ran.LowVal, maxVal)
+		} else {
+			ran.LowVal = append(ran.LowVal, minVal)
+		}
+	}
+	for i := len(ran.HighVal); i < numColumns; i++ {
+		minVal, maxVal := columnBounds(desc, i)
+		if ran.HighExclude {
+			ran.HighVal = append(ran.HighVal, minVal)
+		} else {
+			ran.HighVal = append(ran.HighVal, maxVal)
+		}
+	}
 }
 
-// Convert2IndexRange implements the Convert2IndexRange interface.
-func (ir *IndexRange) Convert2IndexRange() *IndexRange {
-	return ir
+// columnBounds returns the values of the least key and the greatest key of the ith column.
+func columnBounds(desc []bool, i int) (minVal, maxVal Datum) {
+	if i < len(desc) && desc[i] {
+		return MaxValueDatum(), Datum{}
+	}
+	return Datum{}, MaxValueDatum()
 }
 
-// Align appends low value and high value up to the number of columns with max value, min not null value or null value.
-func (ir *IndexRange) Align(numColumns int) {
-	for i := len(ir.LowVal); i < numColumns; i++ {
-		if ir.LowExclude {
-			ir.LowVal = append(ir.LowVal, MaxValueDatum())
-		} else {
-			ir.LowVal = append(ir.LowVal, Datum{})
-		}
+// ReverseRange converts the range on the index values to a new range on the index keys, desc indicates
+// whether the columns are descending. The keys of a descending column are in the reverse order of the
+// values, so the low value and the high value are swapped if the first column which isn't a point is
+// descending. e.g. the range (1 2,1 +inf] on the index (a, b desc) is [1 +inf,1 2) on the keys.
+func (ran *Range) ReverseRange(sc *variable.StatementContext, desc []bool) (*Range, error) {
+	n := len(ran.LowVal)
+	if len(ran.HighVal) < n {
+		n = len(ran.HighVal)
 	}
-	for i := len(ir.HighVal); i < numColumns; i++ {
-		if ir.HighExclude {
-			ir.HighVal = append(ir.HighVal, Datum{})
-		} else {
-			ir.HighVal = append(ir.HighVal, MaxValueDatum())
+	i := 0
+	for ; i < n; i++ {
+		cmp, err := ran.LowVal[i].CompareDatum(sc, ran.HighVal[i])
+		if err != nil {
+			return nil, errors.Trace(err)
 		}
+		if cmp != 0 {
+			break
+		}
+	}
+	if i == n || i >= len(desc) || !desc[i] {
+		return ran.Clone(), nil
+	}
+	reversed := &Range{
+		LowVal:      append([]Datum(nil), ran.HighVal...),
+		HighVal:     append([]Datum(nil), ran.LowVal...),
+		LowExclude:  ran.HighExclude,
+		HighExclude: ran.LowExclude,
 	}
+	if reversed.HighVal[i].Kind() == KindMinNotNull {
+		// The null value is the greatest key of a descending column, the range ends before the null value.
+		reversed.HighVal[i] = Datum{}
+		reversed.HighExclude = true
+	}
+	return reversed, nil
 }
 
 // PrefixEqualLen tells you how long the prefix of the range is a point.
 // e.g. If this range is (1 2 3, 1 2 +inf), then the return value is 2.
-func (ir *IndexRange) PrefixEqualLen(sc *variable.StatementContext) (int, error) {
-	// Here, len(ir.LowVal) always equal to len(ir.HighVal)
-	for i := 0; i < len(ir.LowVal); i++ {
-		cmp, err := ir.LowVal[i].CompareDatum(sc, ir.HighVal[i])
+func (ran *Range) PrefixEqualLen(sc *variable.StatementContext) (int, error) {
+	// Here, len(ran.LowVal) always equal to len(ran.HighVal)
+	for i := 0; i < len(ran.LowVal); i++ {
+		cmp, err := ran.LowVal[i].CompareDatum(sc, ran.HighVal[i])
 		if err != nil {
 			return 0, errors.Trace(err)
 		}
@@ -210,7 +200,221 @@ func (ir *IndexRange) PrefixEqualLen(sc *variable.StatementContext) (int, error)
 			return i, nil
 		}
 	}
-	return len(ir.LowVal), nil
+	return len(ran.LowVal), nil
+}
+
+// Clone returns a copy of the range.
+func (ran *Range) Clone() *Range {
+	return &Range{
+		LowVal:      append([]Datum(nil), ran.LowVal...),
+		HighVal:     append([]Datum(nil), ran.HighVal...),
+		LowExclude:  ran.LowExclude,
+		HighExclude: ran.HighExclude,
+	}
+}
+
+// The functions below treat a range as a set of values ordered by the datums of the columns, the bounds of
+// the ranges which are compared should have the same number of columns, the ranges of an index can be aligned
+// by Align. The values are continuous, so ranges for an int handle like [1,2] and [3,4] aren't adjacent.
+
+// IsEmpty checks whether the range contains no value.
+func (ran *Range) IsEmpty(sc *variable.StatementContext) (bool, error) {
+	cmp, err := compareValues(sc, ran.LowVal, ran.HighVal)
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	return cmp > 0 || (cmp == 0 && (ran.LowExclude || ran.HighExclude)), nil
+}
+
+// Overlap checks whether the two ranges have a common value.
+func (ran *Range) Overlap(sc *variable.StatementContext, other *Range) (bool, error) {
+	for _, r := range []*Range{ran, other} {
+		empty, err := r.IsEmpty(sc)
+		if err != nil || empty {
+			return false, errors.Trace(err)
+		}
+	}
+	before, err := startsBeforeEnd(sc, ran, other)
+	if err != nil || !before {
+		return false, errors.Trace(err)
+	}
+	before, err = startsBeforeEnd(sc, other, ran)
+	return before, errors.Trace(err)
+}
+
+// Adjacent checks whether the two ranges don't overlap but there's no value between them, e.g. [1,2) and [2,3].
+func (ran *Range) Adjacent(sc *variable.StatementContext, other *Range) (bool, error) {
+	overlap, err := ran.Overlap(sc, other)
+	if err != nil || overlap {
+		return false, errors.Trace(err)
+	}
+	for _, r := range []*Range{ran, other} {
+		empty, err := r.IsEmpty(sc)
+		if err != nil || empty {
+			return false, errors.Trace(err)
+		}
+	}
+	touch, err := endsAtStart(sc, ran, other)
+	if err != nil || touch {
+		return touch, errors.Trace(err)
+	}
+	touch, err = endsAtStart(sc, other, ran)
+	return touch, errors.Trace(err)
+}
+
+// Intersect returns the common values of the two ranges, it returns nil if they don't overlap.
+func (ran *Range) Intersect(sc *variable.StatementContext, other *Range) (*Range, error) {
+	overlap, err := ran.Overlap(sc, other)
+	if err != nil || !overlap {
+		return nil, errors.Trace(err)
+	}
+	result := ran.Clone()
+	cmp, err := compareLow(sc, ran, other)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if cmp < 0 {
+		result.LowVal, result.LowExclude = append([]Datum(nil), other.LowVal...), other.LowExclude
+	}
+	cmp, err = compareHigh(sc, ran, other)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if cmp > 0 {
+		result.HighVal, result.HighExclude = append([]Datum(nil), other.HighVal...), other.HighExclude
+	}
+	return result, nil
+}
+
+// UnionRanges returns the values in any of the ranges as ranges sorted by the low values, the ranges which
+// overlap or are adjacent are merged and the empty ranges are removed. The ranges aren't modified.
+func UnionRanges(sc *variable.StatementContext, ranges []*Range) ([]*Range, error) {
+	sorted := make([]*Range, 0, len(ranges))
+	for _, ran := range ranges {
+		empty, err := ran.IsEmpty(sc)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if !empty {
+			sorted = append(sorted, ran)
+		}
+	}
+	var err error
+	sort.SliceStable(sorted, func(i, j int) bool {
+		cmp, err1 := compareLow(sc, sorted[i], sorted[j])
+		if err1 != nil {
+			err = err1
+		}
+		return cmp < 0
+	})
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	result := make([]*Range, 0, len(sorted))
+	for _, ran := range sorted {
+		if len(result) == 0 {
+			result = append(result, ran.Clone())
+			continue
+		}
+		last := result[len(result)-1]
+		merge, err := last.Overlap(sc, ran)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if !merge {
+			merge, err = last.Adjacent(sc, ran)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
+		if !merge {
+			result = append(result, ran.Clone())
+			continue
+		}
+		cmp, err := compareHigh(sc, last, ran)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if cmp < 0 {
+			last.HighVal, last.HighExclude = append([]Datum(nil), ran.HighVal...), ran.HighExclude
+		}
+	}
+	return result, nil
+}
+
+// IntersectRanges returns the values in both of the two sets of ranges, the result is sorted and merged
+// like UnionRanges.
+func IntersectRanges(sc *variable.StatementContext, ranges, others []*Range) ([]*Range, error) {
+	var result []*Range
+	for _, ran := range ranges {
+		for _, other := range others {
+			intersection, err := ran.Intersect(sc, other)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			if intersection != nil {
+				result = append(result, intersection)
+			}
+		}
+	}
+	return UnionRanges(sc, result)
+}
+
+// compareValues compares the bound values column by column.
+func compareValues(sc *variable.StatementContext, a, b []Datum) (int, error) {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		cmp, err := a[i].CompareDatum(sc, b[i])
+		if err != nil {
+			return 0, errors.Trace(err)
+		}
+		if cmp != 0 {
+			return cmp, nil
+		}
+	}
+	return CompareInt64(int64(len(a)), int64(len(b))), nil
+}
+
+// compareLow compares the low bounds of the two ranges, an exclusive bound is greater than an inclusive one.
+func compareLow(sc *variable.StatementContext, a, b *Range) (int, error) {
+	cmp, err := compareValues(sc, a.LowVal, b.LowVal)
+	if err != nil || cmp != 0 || a.LowExclude == b.LowExclude {
+		return cmp, errors.Trace(err)
+	}
+	if a.LowExclude {
+		return 1, nil
+	}
+	return -1, nil
+}
+
+// compareHigh compares the high bounds of the two ranges, an exclusive bound is less than an inclusive one.
+func compareHigh(sc *variable.StatementContext, a, b *Range) (int, error) {
+	cmp, err := compareValues(sc, a.HighVal, b.HighVal)
+	if err != nil || cmp != 0 || a.HighExclude == b.HighExclude {
+		return cmp, errors.Trace(err)
+	}
+	if a.HighExclude {
+		return -1, nil
+	}
+	return 1, nil
+}
+
+// startsBeforeEnd checks whether the low bound of a isn't after the high bound of b.
+func startsBeforeEnd(sc *variable.StatementContext, a, b *Range) (bool, error) {
+	cmp, err := compareValues(sc, a.LowVal, b.HighVal)
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	return cmp < 0 || (cmp == 0 && !a.LowExclude && !b.HighExclude), nil
+}
+
+// endsAtStart checks whether the high bound of a meets the low bound of b, the value of the bounds belongs
+// to exactly one of them.
+func endsAtStart(sc *variable.StatementContext, a, b *Range) (bool, error) {
+	cmp, err := compareValues(sc, a.HighVal, b.LowVal)
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	return cmp == 0 && a.HighExclude != b.LowExclude, nil
 }
 
 func formatDatum(d Datum) string {
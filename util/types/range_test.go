@@ -14,7 +14,9 @@
 package types
 
 import (
+	"fmt"
 	"math"
+	"math/rand"
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb/sessionctx/variable"
@@ -27,18 +29,18 @@ type testRangeSuite struct {
 
 func (s *testRangeSuite) TestRange(c *C) {
 	alignedTests := []struct {
-		ran IndexRange
+		ran Range
 		str string
 	}{
 		{
-			ran: IndexRange{
+			ran: Range{
 				LowVal:  []Datum{NewIntDatum(1)},
 				HighVal: []Datum{NewIntDatum(1)},
 			},
 			str: "[1 <nil>,1 +inf]",
 		},
 		{
-			ran: IndexRange{
+			ran: Range{
 				LowVal:      []Datum{NewIntDatum(1)},
 				HighVal:     []Datum{NewIntDatum(1)},
 				HighExclude: true,
@@ -46,7 +48,7 @@ func (s *testRangeSuite) TestRange(c *C) {
 			str: "[1 <nil>,1 <nil>)",
 		},
 		{
-			ran: IndexRange{
+			ran: Range{
 				LowVal:      []Datum{NewIntDatum(1)},
 				HighVal:     []Datum{NewIntDatum(2)},
 				LowExclude:  true,
@@ -55,7 +57,7 @@ func (s *testRangeSuite) TestRange(c *C) {
 			str: "(1 +inf,2 <nil>)",
 		},
 		{
-			ran: IndexRange{
+			ran: Range{
 				LowVal:      []Datum{NewFloat64Datum(1.1)},
 				HighVal:     []Datum{NewFloat64Datum(1.9)},
 				HighExclude: true,
@@ -63,7 +65,7 @@ func (s *testRangeSuite) TestRange(c *C) {
 			str: "[1.1 <nil>,1.9 <nil>)",
 		},
 		{
-			ran: IndexRange{
+			ran: Range{
 				LowVal:      []Datum{MinNotNullDatum()},
 				HighVal:     []Datum{NewIntDatum(1)},
 				HighExclude: true,
@@ -72,37 +74,37 @@ func (s *testRangeSuite) TestRange(c *C) {
 		},
 	}
 	for _, t := range alignedTests {
-		t.ran.Align(2)
+		t.ran.Align(2, nil)
 		c.Assert(t.ran.String(), Equals, t.str)
 	}
 
 	isPointTests := []struct {
-		ran     IndexRange
+		ran     Range
 		isPoint bool
 	}{
 		{
-			ran: IndexRange{
+			ran: Range{
 				LowVal:  []Datum{NewIntDatum(1)},
 				HighVal: []Datum{NewIntDatum(1)},
 			},
 			isPoint: true,
 		},
 		{
-			ran: IndexRange{
+			ran: Range{
 				LowVal:  []Datum{NewStringDatum("abc")},
 				HighVal: []Datum{NewStringDatum("abc")},
 			},
 			isPoint: true,
 		},
 		{
-			ran: IndexRange{
+			ran: Range{
 				LowVal:  []Datum{NewIntDatum(1)},
 				HighVal: []Datum{NewIntDatum(1), NewIntDatum(1)},
 			},
 			isPoint: false,
 		},
 		{
-			ran: IndexRange{
+			ran: Range{
 				LowVal:     []Datum{NewIntDatum(1)},
 				HighVal:    []Datum{NewIntDatum(1)},
 				LowExclude: true,
@@ -110,7 +112,7 @@ func (s *testRangeSuite) TestRange(c *C) {
 			isPoint: false,
 		},
 		{
-			ran: IndexRange{
+			ran: Range{
 				LowVal:      []Datum{NewIntDatum(1)},
 				HighVal:     []Datum{NewIntDatum(1)},
 				HighExclude: true,
@@ -118,7 +120,7 @@ func (s *testRangeSuite) TestRange(c *C) {
 			isPoint: false,
 		},
 		{
-			ran: IndexRange{
+			ran: Range{
 				LowVal:  []Datum{NewIntDatum(1)},
 				HighVal: []Datum{NewIntDatum(2)},
 			},
@@ -131,56 +133,136 @@ func (s *testRangeSuite) TestRange(c *C) {
 	}
 }
 
-func (s *testRangeSuite) TestIntColumnRangeString(c *C) {
+func (s *testRangeSuite) TestReverseRange(c *C) {
 	tests := []struct {
-		ran IntColumnRange
-		ans string
+		ran  Range
+		desc []bool
+		str  string
 	}{
 		{
-			ran: IntColumnRange{
-				LowVal:  math.MinInt64,
-				HighVal: 2,
+			ran: Range{
+				LowVal:     []Datum{NewIntDatum(1), NewIntDatum(2)},
+				HighVal:    []Datum{NewIntDatum(1), MaxValueDatum()},
+				LowExclude: true,
+			},
+			desc: []bool{false, true},
+			str:  "[1 +inf,1 2)",
+		},
+		{
+			ran: Range{
+				LowVal:     []Datum{NewIntDatum(1), NewIntDatum(2)},
+				HighVal:    []Datum{NewIntDatum(1), MaxValueDatum()},
+				LowExclude: true,
+			},
+			desc: []bool{true, false},
+			str:  "(1 2,1 +inf]",
+		},
+		{
+			ran: Range{
+				LowVal:  []Datum{NewIntDatum(1)},
+				HighVal: []Datum{NewIntDatum(1)},
 			},
-			ans: "(-inf,2]",
+			desc: []bool{true},
+			str:  "[1,1]",
 		},
 		{
-			ran: IntColumnRange{
-				LowVal:  3,
-				HighVal: math.MaxInt64,
+			ran: Range{
+				LowVal:      []Datum{MinNotNullDatum()},
+				HighVal:     []Datum{NewIntDatum(1)},
+				HighExclude: true,
 			},
-			ans: "[3,+inf)",
+			desc: []bool{true},
+			str:  "(1,<nil>)",
+		},
+	}
+	sc := new(variable.StatementContext)
+	for _, t := range tests {
+		ran, err := t.ran.ReverseRange(sc, t.desc)
+		c.Assert(err, IsNil)
+		c.Assert(ran.String(), Equals, t.str)
+	}
+
+	// The padded values of a descending column are the least key and the greatest key.
+	ran := &Range{LowVal: []Datum{NewIntDatum(1)}, HighVal: []Datum{NewIntDatum(1)}}
+	ran.Align(2, []bool{false, true})
+	c.Assert(ran.String(), Equals, "[1 +inf,1 <nil>]")
+}
+
+func (s *testRangeSuite) TestIntRange(c *C) {
+	tests := []struct {
+		ran       *Range
+		ans       string
+		low, high int64
+	}{
+		{
+			ran:  NewIntRange(math.MinInt64, 2),
+			ans:  "(-inf,2]",
+			low:  math.MinInt64,
+			high: 2,
+		},
+		{
+			ran:  NewIntRange(3, math.MaxInt64),
+			ans:  "[3,+inf)",
+			low:  3,
+			high: math.MaxInt64,
+		},
+		{
+			ran:  NewIntRange(math.MinInt64, math.MinInt64),
+			ans:  "(-inf,-inf)",
+			low:  math.MinInt64,
+			high: math.MinInt64,
+		},
+		{
+			ran: &Range{
+				LowVal:      []Datum{NewIntDatum(1)},
+				LowExclude:  true,
+				HighVal:     []Datum{NewIntDatum(5)},
+				HighExclude: true,
+			},
+			ans:  "(1,5)",
+			low:  2,
+			high: 4,
+		},
+		{
+			ran:  &Range{LowVal: []Datum{MinNotNullDatum()}, HighVal: []Datum{MaxValueDatum()}},
+			ans:  "[-inf,+inf]",
+			low:  math.MinInt64,
+			high: math.MaxInt64,
 		},
 	}
 	for _, t := range tests {
 		c.Assert(t.ran.String(), Equals, t.ans)
+		low, high := t.ran.IntBounds()
+		c.Assert(low, Equals, t.low)
+		c.Assert(high, Equals, t.high)
 	}
 }
 
 func (s *testRangeSuite) TestColumnRangeString(c *C) {
 	tests := []struct {
-		ran ColumnRange
+		ran Range
 		ans string
 	}{
 		{
-			ran: ColumnRange{
-				Low:      NewStringDatum("a"),
-				High:     MaxValueDatum(),
-				HighExcl: true,
+			ran: Range{
+				LowVal:      []Datum{NewStringDatum("a")},
+				HighVal:     []Datum{MaxValueDatum()},
+				HighExclude: true,
 			},
 			ans: "[a,+inf)",
 		},
 		{
-			ran: ColumnRange{
-				Low:     NewFloat64Datum(3.2),
-				LowExcl: true,
-				High:    NewFloat64Datum(6.4),
+			ran: Range{
+				LowVal:     []Datum{NewFloat64Datum(3.2)},
+				LowExclude: true,
+				HighVal:    []Datum{NewFloat64Datum(6.4)},
 			},
 			ans: "(3.2,6.4]",
 		},
 		{
-			ran: ColumnRange{
-				Low:  Datum{},
-				High: Datum{},
+			ran: Range{
+				LowVal:  []Datum{{}},
+				HighVal: []Datum{{}},
 			},
 			ans: "[<nil>,<nil>]",
 		},
@@ -189,3 +271,164 @@ func (s *testRangeSuite) TestColumnRangeString(c *C) {
 		c.Assert(t.ran.String(), Equals, t.ans)
 	}
 }
+
+func (s *testRangeSuite) TestRangeSet(c *C) {
+	sc := new(variable.StatementContext)
+	newRange := func(low, high int64, lowExclude, highExclude bool) *Range {
+		return &Range{
+			LowVal:      []Datum{NewIntDatum(low)},
+			HighVal:     []Datum{NewIntDatum(high)},
+			LowExclude:  lowExclude,
+			HighExclude: highExclude,
+		}
+	}
+	tests := []struct {
+		a, b      *Range
+		overlap   bool
+		adjacent  bool
+		intersect string
+		union     string
+	}{
+		{newRange(1, 3, false, false), newRange(2, 4, false, false), true, false, "[[2,3]]", "[[1,4]]"},
+		{newRange(1, 2, false, true), newRange(2, 3, false, false), false, true, "[]", "[[1,3]]"},
+		{newRange(1, 2, false, false), newRange(2, 3, true, false), false, true, "[]", "[[1,3]]"},
+		{newRange(1, 2, false, true), newRange(2, 3, true, false), false, false, "[]", "[[1,2) (2,3]]"},
+		{newRange(1, 2, false, false), newRange(2, 3, false, false), true, false, "[[2,2]]", "[[1,3]]"},
+		{newRange(1, 5, true, true), newRange(2, 3, false, true), true, false, "[[2,3)]", "[(1,5)]"},
+		{newRange(1, 2, false, false), newRange(3, 4, false, false), false, false, "[]", "[[1,2] [3,4]]"},
+		{newRange(2, 2, false, true), newRange(1, 3, false, false), false, false, "[]", "[[1,3]]"},
+		{
+			&Range{LowVal: []Datum{NewIntDatum(1), {}}, HighVal: []Datum{NewIntDatum(1), MaxValueDatum()}},
+			&Range{LowVal: []Datum{NewIntDatum(1), NewIntDatum(2)}, HighVal: []Datum{NewIntDatum(2), {}}, HighExclude: true},
+			true, false, "[[1 2,1 +inf]]", "[[1 <nil>,2 <nil>)]",
+		},
+	}
+	for _, t := range tests {
+		overlap, err := t.a.Overlap(sc, t.b)
+		c.Assert(err, IsNil)
+		c.Assert(overlap, Equals, t.overlap, Commentf("%v %v", t.a, t.b))
+		adjacent, err := t.a.Adjacent(sc, t.b)
+		c.Assert(err, IsNil)
+		c.Assert(adjacent, Equals, t.adjacent, Commentf("%v %v", t.a, t.b))
+		intersection, err := IntersectRanges(sc, []*Range{t.a}, []*Range{t.b})
+		c.Assert(err, IsNil)
+		c.Assert(fmt.Sprintf("%v", intersection), Equals, t.intersect)
+		union, err := UnionRanges(sc, []*Range{t.a, t.b})
+		c.Assert(err, IsNil)
+		c.Assert(fmt.Sprintf("%v", union), Equals, t.union)
+	}
+}
+
+// TestRangeSetModel checks the range set functions against a brute force model. The bounds are integers or
+// infinities, every value between two consecutive bounds is in the same ranges, so the sets of ranges are
+// compared by the probe values at the bounds and between them.
+func (s *testRangeSuite) TestRangeSetModel(c *C) {
+	sc := new(variable.StatementContext)
+	bounds := []Datum{{}, NewFloat64Datum(0), NewFloat64Datum(1), NewFloat64Datum(2), NewFloat64Datum(3), MaxValueDatum()}
+	probes := []Datum{{}, NewFloat64Datum(-0.5)}
+	for i := 0; i <= 3; i++ {
+		probes = append(probes, NewFloat64Datum(float64(i)), NewFloat64Datum(float64(i)+0.5))
+	}
+	probes = append(probes, MaxValueDatum())
+	contains := func(ran *Range, v Datum) bool {
+		low, err := v.CompareDatum(sc, ran.LowVal[0])
+		c.Assert(err, IsNil)
+		high, err := v.CompareDatum(sc, ran.HighVal[0])
+		c.Assert(err, IsNil)
+		return (low > 0 || (low == 0 && !ran.LowExclude)) && (high < 0 || (high == 0 && !ran.HighExclude))
+	}
+	// members returns the indices of the probes in the ranges.
+	members := func(ranges ...*Range) []bool {
+		in := make([]bool, len(probes))
+		for i, v := range probes {
+			for _, ran := range ranges {
+				in[i] = in[i] || contains(ran, v)
+			}
+		}
+		return in
+	}
+	// checkNormalized checks the ranges are sorted, not empty, and neither overlap nor are adjacent.
+	checkNormalized := func(ranges []*Range) {
+		var last []bool
+		for _, ran := range ranges {
+			in := members(ran)
+			first := -1
+			for i := range in {
+				if in[i] && first == -1 {
+					first = i
+				}
+			}
+			c.Assert(first, Not(Equals), -1, Commentf("%v", ranges))
+			if last != nil {
+				lastEnd := -1
+				for i := range last {
+					if last[i] {
+						lastEnd = i
+					}
+				}
+				c.Assert(first > lastEnd+1, IsTrue, Commentf("%v", ranges))
+			}
+			last = in
+		}
+	}
+	rnd := rand.New(rand.NewSource(1))
+	randomRange := func() *Range {
+		return &Range{
+			LowVal:      []Datum{bounds[rnd.Intn(len(bounds)-1)]},
+			HighVal:     []Datum{bounds[rnd.Intn(len(bounds)-1)+1]},
+			LowExclude:  rnd.Intn(2) == 0,
+			HighExclude: rnd.Intn(2) == 0,
+		}
+	}
+	randomRanges := func() []*Range {
+		ranges := make([]*Range, rnd.Intn(4))
+		for i := range ranges {
+			ranges[i] = randomRange()
+		}
+		return ranges
+	}
+	for n := 0; n < 1000; n++ {
+		a, b := randomRange(), randomRange()
+		inA, inB := members(a), members(b)
+		overlap, adjacent := false, false
+		firstA, lastA, firstB, lastB := -1, -1, -1, -1
+		for i := range probes {
+			overlap = overlap || (inA[i] && inB[i])
+			if inA[i] {
+				if firstA == -1 {
+					firstA = i
+				}
+				lastA = i
+			}
+			if inB[i] {
+				if firstB == -1 {
+					firstB = i
+				}
+				lastB = i
+			}
+		}
+		if !overlap && firstA != -1 && firstB != -1 {
+			adjacent = lastA+1 == firstB || lastB+1 == firstA
+		}
+		result, err := a.Overlap(sc, b)
+		c.Assert(err, IsNil)
+		c.Assert(result, Equals, overlap, Commentf("%v %v", a, b))
+		result, err = a.Adjacent(sc, b)
+		c.Assert(err, IsNil)
+		c.Assert(result, Equals, adjacent, Commentf("%v %v", a, b))
+
+		rangesA, rangesB := randomRanges(), randomRanges()
+		inA, inB = members(rangesA...), members(rangesB...)
+		union, err := UnionRanges(sc, append(append([]*Range(nil), rangesA...), rangesB...))
+		c.Assert(err, IsNil)
+		checkNormalized(union)
+		intersection, err := IntersectRanges(sc, rangesA, rangesB)
+		c.Assert(err, IsNil)
+		checkNormalized(intersection)
+		inUnion, inIntersection := members(union...), members(intersection...)
+		for i := range probes {
+			c.Assert(inUnion[i], Equals, inA[i] || inB[i], Commentf("%v %v %v", rangesA, rangesB, union))
+			c.Assert(inIntersection[i], Equals, inA[i] && inB[i], Commentf("%v %v %v", rangesA, rangesB, intersection))
+		}
+	}
+}